@@ -8,3 +8,10 @@ HNSW graph indexing (O(log N) complexity).
 
 __version__ = "8.5.3"
 __author__ = "Seba Battig"
+
+
+def load_ipython_extension(ipython):
+    """Enable the %cidx notebook magic with '%load_ext code_indexer'."""
+    from .client.ipython_magic import load_ipython_extension as load
+
+    load(ipython)
@@ -16,6 +16,7 @@ from typing import Optional, Union, Callable, Dict, Any, List, Literal, cast
 import click
 from rich.console import Console
 from rich.table import Table
+from rich.text import Text
 
 # Rich progress imports removed - using MultiThreadedProgressManager instead
 
@@ -26,17 +27,31 @@ from .utils.enhanced_messaging import (
     get_service_unavailable_message,
 )
 from .utils.exception_logger import ExceptionLogger
+from .utils import platform_support
+from .utils.lazy_import import LazyAttribute
 from .mode_detection.command_mode_detector import CommandModeDetector, find_project_root
 from .disabled_commands import require_mode
 from . import __version__
 from .cli_scip import scip_group
+from .cli_dev import dev_group
 
-# Module-level imports for test mocking (noqa: F401 = intentionally unused for test patching)
-from .api_clients.admin_client import AdminAPIClient  # noqa: F401
-from .api_clients.repos_client import ReposAPIClient  # noqa: F401
-from .backends.backend_factory import BackendFactory  # noqa: F401
-from .services.embedding_factory import EmbeddingProviderFactory  # noqa: F401
-from .remote.credential_manager import ProjectCredentialManager  # noqa: F401
+# Module-level names for test mocking, imported on first use so read-only
+# commands don't pay for httpx, cryptography and vector store imports at startup
+AdminAPIClient = LazyAttribute(
+    ".api_clients.admin_client", "AdminAPIClient", __package__
+)
+ReposAPIClient = LazyAttribute(
+    ".api_clients.repos_client", "ReposAPIClient", __package__
+)
+BackendFactory = LazyAttribute(
+    ".backends.backend_factory", "BackendFactory", __package__
+)
+EmbeddingProviderFactory = LazyAttribute(
+    ".services.embedding_factory", "EmbeddingProviderFactory", __package__
+)
+ProjectCredentialManager = LazyAttribute(
+    ".remote.credential_manager", "ProjectCredentialManager", __package__
+)
 
 # Daemon delegation imports (lazy loaded when daemon enabled)
 from . import cli_daemon_delegation  # noqa: F401
@@ -707,6 +722,55 @@ def _display_fts_results(
         console.print()
 
 
+def _record_query_latency(
+    config_dir: Path, timing_info: Dict[str, Any], render_ms: float
+) -> None:
+    """Record per-stage query timings for ``cidx stats --latency`` (best effort)."""
+    try:
+        from .services.query_latency_stats import (
+            QueryLatencyStore,
+            stage_timings_from_query,
+        )
+
+        QueryLatencyStore(config_dir).record(
+            stage_timings_from_query(timing_info, render_ms=render_ms)
+        )
+    except Exception as e:
+        logger.debug(f"Query latency recording failed: {e}")
+
+
+def _highlighted_content(
+    content: str, line_start: int, highlight: Dict[str, Any]
+) -> Text:
+    """Chunk content with line numbers, marking the region matching the query.
+
+    Lines of the matched region are numbered with a ▶ marker and the matched
+    words are shown in bold yellow.
+    """
+    text = Text()
+    offset = 0
+    for j, line in enumerate(content.split("\n")):
+        line_num = line_start + j
+        in_region = highlight["line_start"] <= line_num <= highlight["line_end"]
+        if j:
+            text.append("\n")
+        text.append(
+            f"{line_num:3}{'▶' if in_region else ':'} ",
+            style="bold yellow" if in_region else None,
+        )
+        line_position = len(text)
+        text.append(line)
+        for match_start, match_end in highlight["matches"]:
+            if offset <= match_start and match_end <= offset + len(line):
+                text.stylize(
+                    "bold yellow",
+                    line_position + match_start - offset,
+                    line_position + match_end - offset,
+                )
+        offset += len(line) + 1
+    return text
+
+
 def _display_semantic_results(
     results: List[Dict[str, Any]],
     console: Console,
@@ -742,6 +806,15 @@ def _display_semantic_results(
         # Display timing summary
         if timing_info:
             _display_query_timing(console, timing_info)
+        # Ownership facets (CODEOWNERS owners recorded while indexing)
+        from .services.code_owners import owner_facets
+
+        facets = owner_facets(results)
+        if any(owner is not None for owner, _ in facets):
+            summary = ", ".join(
+                f"{owner or 'unowned'} ({count})" for owner, count in facets
+            )
+            console.print(f"👥 Owners: {summary}", markup=False)
 
     # Auto-detect current branch if not provided
     if current_display_branch is None and not quiet:
@@ -761,6 +834,8 @@ def _display_semantic_results(
         except Exception:
             current_display_branch = "unknown"
 
+    from .services.score_explanation import format_explanation
+
     for i, result in enumerate(results, 1):
         payload = result["payload"]
         score = result["score"]
@@ -770,6 +845,9 @@ def _display_semantic_results(
         language = payload.get("language", "unknown")
         content = payload.get("content", "")
 
+        # Region matching the query words (if found)
+        highlight = result.get("highlight")
+
         # Staleness info (if available)
         staleness_info = result.get("staleness", {})
         staleness_indicator = staleness_info.get("staleness_indicator", "")
@@ -789,13 +867,25 @@ def _display_semantic_results(
 
         if quiet:
             # Quiet mode - minimal output: match number, score, staleness, path with line numbers
+            more_in_file = (
+                f" (+{result['more_in_file']} more in file)"
+                if result.get("more_in_file")
+                else ""
+            )
             if staleness_indicator:
                 console.print(
-                    f"{i}. {score:.3f} {staleness_indicator} {file_path_with_lines}"
+                    f"{i}. {score:.3f} {staleness_indicator} "
+                    f"{file_path_with_lines}{more_in_file}"
                 )
             else:
-                console.print(f"{i}. {score:.3f} {file_path_with_lines}")
-            if content:
+                console.print(f"{i}. {score:.3f} {file_path_with_lines}{more_in_file}")
+            if result.get("explanation"):
+                console.print(
+                    f"   {format_explanation(result['explanation'])}", markup=False
+                )
+            if content and highlight and line_start is not None:
+                console.print(_highlighted_content(content, line_start, highlight))
+            elif content:
                 # Show full content with line numbers in quiet mode (no truncation)
                 content_lines = content.split("\n")
 
@@ -824,10 +914,18 @@ def _display_semantic_results(
             if language != "unknown":
                 header += f" | 🏷️  Language: {language}"
             header += f" | 📊 Score: {score:.3f}"
+            if result.get("id"):
+                # Result ID for 'cidx feedback'
+                from .services.relevance_feedback import short_result_id
+
+                header += f" | 🆔 {short_result_id(result['id'])}"
 
             # Add staleness indicator to header if available
             if staleness_indicator:
                 header += f" | {staleness_indicator}"
+            if result.get("more_in_file"):
+                # Matches collapsed by --group-by / --max-per-file
+                header += f" | ➕ {result['more_in_file']} more in file"
 
             console.print(f"\n[bold cyan]{header}[/bold cyan]")
 
@@ -861,7 +959,105 @@ def _display_semantic_results(
                     metadata_info += f" | 📦 Commit: {git_commit}"
 
             metadata_info += f" | 🏗️  Project: {project_id}"
+            if payload.get("license"):
+                metadata_info += f" | ⚖️  License: {payload['license']}"
+            if payload.get("owners"):
+                metadata_info += f" | 👥 Owners: {', '.join(payload['owners'])}"
+            if payload.get("struct_fields"):
+                structs = dict.fromkeys(f["struct"] for f in payload["struct_fields"])
+                metadata_info += f" | 🏷️  Structs: {', '.join(structs)}"
+            if payload.get("implements"):
+                interfaces = [i for i in payload["implements"] if "." not in i]
+                metadata_info += f" | 🔗 Implements: {', '.join(interfaces)}"
+            if payload.get("build_constraint"):
+                metadata_info += f" | 🖥️  Build: {payload['build_constraint']}"
+            if payload.get("test_of"):
+                metadata_info += f" | 🧪 Tests: {', '.join(payload['test_of'])}"
+            if payload.get("proto_name"):
+                metadata_info += f" | 📜 Proto: {payload['proto_name']}"
+            if payload.get("grpc_stubs"):
+                metadata_info += f" | 🔌 Stubs: {', '.join(payload['grpc_stubs'])}"
+            if payload.get("openapi_operation"):
+                metadata_info += f" | 🌐 API: {payload['openapi_operation']}"
+                if payload.get("operation_id"):
+                    metadata_info += f" ({payload['operation_id']})"
+            if payload.get("docker_stage"):
+                metadata_info += (
+                    f" | 🐳 Stage: {payload['docker_stage']}"
+                    f" (FROM {payload.get('docker_base_image', '?')})"
+                )
+            if payload.get("compose_service"):
+                metadata_info += f" | 🐳 Service: {payload['compose_service']}"
+                if payload.get("compose_image"):
+                    metadata_info += f" ({payload['compose_image']})"
+            if payload.get("k8s_kind"):
+                resource = payload.get("k8s_name", "?")
+                if payload.get("k8s_namespace"):
+                    resource = f"{payload['k8s_namespace']}/{resource}"
+                metadata_info += f" | ☸️ K8s: {payload['k8s_kind']} {resource}"
+            if payload.get("component_name"):
+                block = payload.get("component_block", "?")
+                if payload.get("component_lang"):
+                    block = f"{block} {payload['component_lang']}"
+                metadata_info += (
+                    f" | 🧩 Component: {payload['component_name']} <{block}>"
+                )
+            if payload.get("shell_function"):
+                metadata_info += f" | 🐚 Function: {payload['shell_function']}"
+            if payload.get("build_block"):
+                build_block = payload["build_block"]
+                if payload.get("build_name"):
+                    build_block += f" {payload['build_name']}"
+                metadata_info += (
+                    f" | 🔨 Build: {payload.get('build_system', '')} {build_block}"
+                )
+            if payload.get("cobol_division"):
+                cobol_path = " / ".join(
+                    payload[key]
+                    for key in ("cobol_division", "cobol_section", "cobol_paragraph")
+                    if payload.get(key)
+                )
+                metadata_info += f" | 🗄️ COBOL: {cobol_path}"
+            if payload.get("hdl_unit"):
+                hdl_unit = payload["hdl_unit"]
+                if payload.get("hdl_ports"):
+                    hdl_unit += f" ({len(payload['hdl_ports'])} ports)"
+                metadata_info += f" | 🔌 HDL: {hdl_unit}"
+            if payload.get("symbol_kind") and payload.get("symbol_name"):
+                metadata_info += (
+                    f" | 🧱 Symbol: {payload['symbol_kind']} {payload['symbol_name']}"
+                )
+                if len(payload.get("symbols") or []) > 1:
+                    metadata_info += f" (+{len(payload['symbols']) - 1} more)"
+            if result.get("feedback_adjustment"):
+                adjustment = result["feedback_adjustment"]
+                metadata_info += f" | 👍 Feedback: {adjustment:+.3f}"
             console.print(metadata_info)
+            if result.get("explanation"):
+                console.print(format_explanation(result["explanation"]), markup=False)
+
+            # Test coverage (if imported with `cidx coverage import`)
+            coverage_info = result.get("coverage")
+            if coverage_info:
+                coverage_line = (
+                    f"🧪 Coverage: {coverage_info['covered_lines']}/"
+                    f"{coverage_info['coverable_lines']} lines"
+                )
+                if coverage_info["tests"]:
+                    tests = ", ".join(coverage_info["tests"])
+                    coverage_line += f" | Covered by: {tests}"
+                console.print(coverage_line, markup=False)
+
+            if highlight:
+                match_line = (
+                    f"🎯 Match: line {highlight['line_start']}"
+                    if highlight["line_start"] == highlight["line_end"]
+                    else f"🎯 Match: lines {highlight['line_start']}-"
+                    f"{highlight['line_end']}"
+                )
+                console.print(
+                    f"{match_line} ({', '.join(highlight['terms'])})", markup=False
+                )
 
             # Note: Fixed-size chunking no longer provides semantic metadata
 
@@ -893,7 +1089,11 @@ def _display_semantic_results(
                     content_with_line_numbers = content
 
                 # Syntax highlighting if possible (note: syntax highlighting with line numbers is complex)
-                if language and language != "unknown":
+                if highlight and line_start is not None:
+                    console.print(
+                        _highlighted_content(content, line_start, highlight)
+                    )
+                elif language and language != "unknown":
                     try:
                         # For now, use plain text with line numbers for better readability
                         # Rich's Syntax with line_numbers=True uses its own numbering system
@@ -1240,6 +1440,20 @@ def _execute_semantic_search(
             )
             timing_info["git_filter_ms"] = (time.time() - git_filter_start) * 1000
 
+        # Re-rank with recorded relevance feedback ('cidx feedback')
+        from .services.relevance_feedback import FeedbackStore
+
+        git_results = FeedbackStore.for_project(
+            Path(config.codebase_dir) / ".code-indexer"
+        ).rerank(git_results)
+
+        # Post-process with configured post_query hooks
+        from .services.lifecycle_hooks import LifecycleHooks
+
+        query_hooks = LifecycleHooks.from_config(config, points=("post_query",))
+        if query_hooks is not None:
+            git_results = query_hooks.process_results(query, git_results)
+
         # Limit to requested number after filtering
         results = git_results[:limit]
 
@@ -1418,6 +1632,187 @@ def _display_hybrid_results(
             console.print("[yellow]No semantic matches found[/yellow]\n")
 
 
+def _display_fused_results(
+    fused_results: List[Any],
+    quiet: bool = False,
+    console: Optional[Console] = None,
+) -> None:
+    """Display reciprocal-rank-fused hybrid results (cidx query --hybrid).
+
+    Args:
+        fused_results: FusedResult list from hybrid_search.fuse_results
+        quiet: If True, show minimal output
+        console: Rich console for output (creates new if None)
+    """
+    if console is None:
+        console = Console()
+
+    if not fused_results:
+        if not quiet:
+            console.print("[yellow]No matches found[/yellow]\n")
+        return
+
+    for i, result in enumerate(fused_results, 1):
+        if result.line_start == result.line_end:
+            location = f"{result.path}:{result.line_start}"
+        else:
+            location = f"{result.path}:{result.line_start}-{result.line_end}"
+
+        sources = []
+        if result.semantic_rank is not None:
+            sources.append(f"semantic #{result.semantic_rank}")
+        if result.fts_rank is not None:
+            sources.append(f"text #{result.fts_rank}")
+
+        if result.semantic is not None:
+            content = result.semantic.get("payload", {}).get("content", "")
+            first_line = result.line_start
+        else:
+            match = result.fts_matches[0]
+            content = match.get("snippet") or match.get("match_text", "")
+            first_line = match.get("snippet_start_line", result.line_start)
+
+        if quiet:
+            console.print(f"{i}. {result.score:.4f} {location}", markup=False)
+            if content:
+                console.print(content, markup=False)
+            continue
+
+        console.print(
+            f"\n[cyan]{i}.[/cyan] RRF score: {result.score:.4f} "
+            f"[dim]({', '.join(sources)})[/dim]"
+        )
+        console.print(f"File: [green]{location}[/green]")
+        if content:
+            console.print("-" * 40)
+            for j, line in enumerate(content.split("\n")):
+                console.print(f"{first_line + j:4}: {line}", markup=False)
+            console.print("-" * 40)
+
+
+def _query_workspace(
+    query: str,
+    aliases: List[str],
+    limit: int,
+    languages: tuple,
+    path_filter: tuple,
+    min_score: Optional[float],
+    quiet: bool,
+) -> None:
+    """Search workspace repositories and display the merged results.
+
+    Args:
+        query: Query text
+        aliases: Workspace aliases of the repositories (cidx workspace add)
+        limit: Number of merged results
+        languages: Only these languages
+        path_filter: Only paths matching these patterns
+        min_score: Minimum similarity of results
+        quiet: If True, show minimal output
+    """
+    from .services.language_mapper import LanguageMapper
+    from .services.language_validator import LanguageValidator
+    from .services.workspace import WorkspaceRegistry, federated_search
+
+    try:
+        repositories = WorkspaceRegistry().resolve(aliases)
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red", markup=False)
+        sys.exit(1)
+
+    conditions: List[Dict[str, Any]] = []
+    if languages:
+        language_validator = LanguageValidator()
+        language_mapper = LanguageMapper()
+        language_filters = []
+        for lang in languages:
+            validation_result = language_validator.validate_language(lang)
+            if not validation_result.is_valid:
+                console.print(
+                    f"❌ {validation_result.error_message}", style="red", markup=False
+                )
+                sys.exit(1)
+            language_filters.append(language_mapper.build_language_filter(lang))
+        conditions.append(
+            {"should": language_filters}
+            if len(language_filters) > 1
+            else language_filters[0]
+        )
+    if path_filter:
+        path_filters = [{"key": "path", "match": {"text": pf}} for pf in path_filter]
+        conditions.append(
+            {"should": path_filters} if len(path_filters) > 1 else path_filters[0]
+        )
+
+    if not quiet:
+        console.print(
+            f"🔍 Searching {len(repositories)} workspace repositories for: '{query}'",
+            markup=False,
+        )
+    results, errors = federated_search(
+        repositories,
+        query,
+        limit,
+        filter_conditions={"must": conditions} if conditions else None,
+        min_score=min_score,
+    )
+    for alias, error in errors.items():
+        console.print(f"⚠️  {alias}: {error}", style="yellow", markup=False)
+    if len(errors) == len(repositories):
+        console.print("❌ No workspace repository could be searched", style="red")
+        sys.exit(1)
+    _display_workspace_results(results, quiet=quiet, console=console)
+
+
+def _display_workspace_results(
+    results: List[Dict[str, Any]],
+    quiet: bool = False,
+    console: Optional[Console] = None,
+) -> None:
+    """Display merged workspace results (cidx query --repos, local).
+
+    Args:
+        results: Results of workspace.federated_search
+        quiet: If True, show minimal output
+        console: Rich console for output (creates new if None)
+    """
+    from .services.workspace import REPOSITORY_KEY
+
+    if console is None:
+        console = Console()
+
+    if not results:
+        if not quiet:
+            console.print("[yellow]No matches found[/yellow]\n")
+        return
+
+    for i, result in enumerate(results, 1):
+        payload = result.get("payload", {})
+        line_start = payload.get("line_start", 1)
+        line_end = payload.get("line_end", line_start)
+        location = f"{payload.get('path', 'unknown')}:{line_start}-{line_end}"
+        repository = result[REPOSITORY_KEY]
+        score = result.get("score", 0.0)
+        content = payload.get("content", "")
+
+        if quiet:
+            console.print(f"{i}. {score:.3f} [{repository}] {location}", markup=False)
+            if content:
+                console.print(content, markup=False)
+            continue
+
+        console.print(
+            f"\n[cyan]{i}.[/cyan] [bold magenta]{repository}[/bold magenta] "
+            f"Score: {score:.3f}"
+        )
+        console.print(f"File: {location}", style="green", markup=False)
+        if content:
+            console.print("-" * 40)
+            for j, line in enumerate(content.split("\n")):
+                console.print(f"{line_start + j:4}: {line}", markup=False)
+            console.print("-" * 40)
+
+
 def _check_authentication_state(ctx) -> bool:
     """Check if user is authenticated and session is valid.
 
@@ -1753,6 +2148,9 @@ def cli(
 # Register SCIP commands
 cli.add_command(scip_group)
 
+# Register developer commands
+cli.add_command(dev_group)
+
 
 @cli.command()
 @click.argument(
@@ -2263,7 +2661,7 @@ def init(
                 )
                 console.print("ℹ️  Daemon will auto-start on first query", style="dim")
             except ValueError as e:
-                console.print(f"❌ Invalid daemon TTL: {e}", style="red")
+                console.print(f"❌ Cannot enable daemon mode: {e}", style="red")
                 sys.exit(1)
 
     except Exception as e:
@@ -2629,6 +3027,13 @@ def show_global_config():
     help="Number of context lines for git diffs (0-50, default: 5). "
     "Higher values improve search quality but increase storage.",
 )
+@click.option(
+    "--throttle",
+    type=click.Choice(["normal", "low"]),
+    default=None,
+    help="Resource usage level. 'low' uses fewer threads, pauses between files "
+    "and lowers CPU/IO priority (default: indexing.throttle from config.json)",
+)
 @click.pass_context
 @require_mode("local")
 def index(
@@ -2647,6 +3052,7 @@ def index(
     max_commits: Optional[int],
     since_date: Optional[str],
     diff_context: Optional[int],
+    throttle: Optional[str],
 ):
     """Index the codebase for semantic search.
 
@@ -2708,6 +3114,8 @@ def index(
       • Vector calculations can be parallelized for faster indexing
       • VoyageAI default: 8 threads (API supports parallel requests)
       • Configure thread count in config.json: voyage_ai.parallel_requests
+      • Use --throttle low to index in the background without slowing
+        down the machine (fewer threads, lower CPU/IO priority)
 
     \b
     EXAMPLES:
@@ -2719,6 +3127,7 @@ def index(
       code-indexer index -b 100          # Larger batch size for speed
       code-indexer index -p 4           # Use 4 parallel threads for vector calculations
       code-indexer index -p 1           # Force single-threaded for debugging
+      code-indexer index --throttle low  # Low-priority background indexing
 
     \b
     STORAGE:
@@ -2759,7 +3168,17 @@ def index(
 
     # Check if daemon mode is enabled and delegate accordingly
     config = config_manager.load()
-    daemon_enabled = config.daemon and config.daemon.enabled
+    daemon_enabled = (
+        config.daemon and config.daemon.enabled and platform_support.DAEMON_SUPPORTED
+    )
+
+    if (fts or rebuild_fts_index) and config.encryption.enabled:
+        console.print(
+            "❌ The full-text search index stores chunk content unencrypted and "
+            "cannot be built while encryption.enabled is set in config.json",
+            style="red",
+        )
+        sys.exit(1)
 
     # Handle --rebuild-fts-index BEFORE general daemon delegation
     if rebuild_fts_index and daemon_enabled:
@@ -2799,6 +3218,7 @@ def index(
             max_commits=max_commits,
             since_date=since_date,
             diff_context=diff_context,
+            throttle=throttle,
         )
         sys.exit(exit_code)
     else:
@@ -3300,6 +3720,25 @@ def index(
     try:
         config = config_manager.load()
 
+        # Apply throttle level (in-memory only, config.json is not modified)
+        from .services.indexing_throttle import (
+            THROTTLE_NORMAL,
+            apply_throttle_to_config,
+            lower_process_priority,
+        )
+
+        throttle_profile = apply_throttle_to_config(
+            config, throttle or config.indexing.throttle
+        )
+        if throttle_profile.name != THROTTLE_NORMAL:
+            adjustments = lower_process_priority(throttle_profile)
+            console.print(
+                f"🐢 Throttle '{throttle_profile.name}': "
+                f"{config.voyage_ai.parallel_requests} threads"
+                + (f", {', '.join(adjustments)}" if adjustments else ""),
+                style="dim",
+            )
+
         # Initialize services - lazy imports for index path
         from .services.smart_indexer import SmartIndexer
 
@@ -3706,9 +4145,30 @@ def index(
     is_flag=True,
     help="Enable FTS index updates alongside semantic index (requires tantivy)",
 )
+@click.option(
+    "--throttle",
+    type=click.Choice(["normal", "low"]),
+    default=None,
+    help="Resource usage level for watch indexing "
+    "(default: indexing.watch_throttle from config.json, which defaults to 'low')",
+)
+@click.option(
+    "--foreground",
+    is_flag=True,
+    help="Watch in this process instead of delegating to the daemon "
+    "(used by cidx service)",
+)
 @click.pass_context
 @require_mode("local", "proxy")
-def watch(ctx, debounce: float, batch_size: int, initial_sync: bool, fts: bool):
+def watch(
+    ctx,
+    debounce: float,
+    batch_size: int,
+    initial_sync: bool,
+    fts: bool,
+    throttle: Optional[str],
+    foreground: bool,
+):
     """Git-aware watch for file changes with branch support."""
     # Story #472: Re-enabled daemon delegation with non-blocking RPC
     # Watch now runs in background thread in daemon, allowing CLI to return immediately
@@ -3726,6 +4186,8 @@ def watch(ctx, debounce: float, batch_size: int, initial_sync: bool, fts: bool):
             args.append("--initial-sync")
         if fts:
             args.append("--fts")
+        if throttle:
+            args.extend(["--throttle", throttle])
 
         exit_code = execute_proxy_command(project_root, "watch", args)
         sys.exit(exit_code)
@@ -3734,7 +4196,7 @@ def watch(ctx, debounce: float, batch_size: int, initial_sync: bool, fts: bool):
     project_root = ctx.obj.get("project_root", Path.cwd())
 
     # Try daemon delegation first (Story #472)
-    if mode == "local":
+    if mode == "local" and not foreground:
         from .cli_daemon_delegation import start_watch_via_daemon
 
         # Attempt to delegate to daemon (non-blocking mode)
@@ -3744,6 +4206,7 @@ def watch(ctx, debounce: float, batch_size: int, initial_sync: bool, fts: bool):
             batch_size=batch_size,
             initial_sync=initial_sync,
             fts=fts,
+            throttle=throttle,
         ):
             # Successfully delegated to daemon - exit immediately
             sys.exit(0)
@@ -3779,6 +4242,29 @@ def watch(ctx, debounce: float, batch_size: int, initial_sync: bool, fts: bool):
 
         config = config_manager.load()
 
+        from .utils.json_logging import configure_project_json_logging
+
+        if configure_project_json_logging(config, "watch"):
+            console.print("🪵 Writing JSON logs (logging.json_logs)", style="dim")
+
+        # Watch runs continuously - throttle defaults to 'low' via watch_throttle
+        from .services.indexing_throttle import (
+            THROTTLE_NORMAL,
+            apply_throttle_to_config,
+            lower_process_priority,
+        )
+
+        throttle_profile = apply_throttle_to_config(
+            config, throttle or config.indexing.watch_throttle
+        )
+        if throttle_profile.name != THROTTLE_NORMAL:
+            lower_process_priority(throttle_profile)
+            console.print(
+                f"🐢 Watch throttle '{throttle_profile.name}' "
+                f"({config.voyage_ai.parallel_requests} threads)",
+                style="dim",
+            )
+
         # Lazy imports for watch services
         from .services.smart_indexer import SmartIndexer
 
@@ -4231,7 +4717,7 @@ def display_temporal_results(results, temporal_service):
     "--exclude-path",
     "exclude_paths",
     multiple=True,
-    help="Exclude files matching path pattern(s). Supports glob patterns (*, **, ?, [seq]). Can be specified multiple times. Example: --exclude-path '*/tests/*' --exclude-path '*.min.js'",
+    help="Exclude files matching path pattern(s). Supports glob patterns (*, **, ?, [seq]). Comma-separated or specified multiple times. Example: --exclude-path 'vendor/**,**/testdata/**' --exclude-path '*.min.js'",
 )
 @click.option("--min-score", type=float, help="Minimum similarity score (0.0-1.0)")
 @click.option(
@@ -4256,6 +4742,11 @@ def display_temporal_results(results, temporal_service):
     is_flag=True,
     help="Use semantic search (default). Combine with --fts for hybrid search.",
 )
+@click.option(
+    "--hybrid",
+    is_flag=True,
+    help="Run semantic and full-text search and rank the union of their results by reciprocal rank fusion (requires the FTS index)",
+)
 @click.option(
     "--case-sensitive",
     is_flag=True,
@@ -4322,7 +4813,163 @@ def display_temporal_results(results, temporal_service):
 @click.option(
     "--repos",
     type=str,
-    help="Query multiple repositories (comma-separated aliases, e.g., 'repo1,repo2,repo3'). Remote mode only. Mutually exclusive with --repo.",
+    help="Query multiple repositories (comma-separated aliases, e.g., 'repo1,repo2,repo3'): server repositories in remote mode, otherwise workspace repositories ('cidx workspace add') searched locally and merged into one ranking. Mutually exclusive with --repo.",
+)
+@click.option(
+    "--license",
+    "licenses",
+    multiple=True,
+    help="Only files under this SPDX license, e.g. MIT (can be specified multiple times). Local semantic search only.",
+)
+@click.option(
+    "--license-not",
+    "exclude_licenses",
+    multiple=True,
+    help="Exclude files under this SPDX license, e.g. Apache-2.0 (can be specified multiple times). Local semantic search only.",
+)
+@click.option(
+    "--owner",
+    "owners",
+    multiple=True,
+    help="Only files owned by this CODEOWNERS owner, e.g. @platform-team (can be specified multiple times). Local semantic search only.",
+)
+@click.option(
+    "--struct-tag",
+    "struct_tags",
+    multiple=True,
+    help="Only Go struct fields with this tag key or option, e.g. gorm:uniqueIndex or db (can be specified multiple times). Local semantic search only.",
+)
+@click.option(
+    "--implements",
+    "implements",
+    multiple=True,
+    help="Only Go types implementing this interface, e.g. UserRepository or repository.UserRepository (can be specified multiple times). Local semantic search only.",
+)
+@click.option(
+    "--platform",
+    "go_platform",
+    help="Only code that builds for this Go platform, GOOS or GOOS/GOARCH (e.g. linux/amd64); 'any' ignores the configured default. Local semantic search only.",
+)
+@click.option(
+    "--build-tag",
+    "build_tags",
+    multiple=True,
+    help="Only Go files whose build constraint mentions this tag, e.g. integration (can be specified multiple times). Local semantic search only.",
+)
+@click.option(
+    "--exclude-build-tag",
+    "exclude_build_tags",
+    multiple=True,
+    help="Exclude Go files whose build constraint mentions this tag (can be specified multiple times). Local semantic search only.",
+)
+@click.option(
+    "--depends-on",
+    "depends_on",
+    multiple=True,
+    help="Only Go files importing this module, e.g. github.com/gin-gonic/gin (can be specified multiple times). Local semantic search only.",
+)
+@click.option(
+    "--symbol-kind",
+    "symbol_kinds",
+    multiple=True,
+    help="Only declarations of this kind, e.g. widget for Flutter widgets, build for their build methods, category for Objective-C categories, process for VHDL processes or generic for generic Go functions and types (can be specified multiple times). Local semantic search only.",
+)
+@click.option(
+    "--exclude-kind",
+    "exclude_kinds",
+    multiple=True,
+    help="Leave out chunks of these kinds, comma-separated or repeated: test (test files), mock (mock files and mocks/ directories), generated, or a declaration kind as --symbol-kind takes. Example: --exclude-kind test,mock. Local semantic search only.",
+)
+@click.option(
+    "--min-complexity",
+    "min_complexity",
+    type=click.IntRange(min=1),
+    help="Only code chunks with at least this cyclomatic complexity, e.g. 15 for branchy handlers. Local semantic search only.",
+)
+@click.option(
+    "--vector-mode",
+    type=click.Choice(["body", "signature", "both"], case_sensitive=False),
+    default="body",
+    help="Chunk vectors to match: body (default), signature for symbol lookups, or both, keeping each chunk's best match. Signature vectors need indexing.signature_vectors. Local semantic search only.",
+)
+@click.option(
+    "--filter",
+    "filter_expression",
+    help="Filter expression combining terms with AND, OR, NOT and parentheses, e.g. 'lang:go AND path:internal/** AND kind:function AND NOT generated'. Fields: lang, path, kind, license, owner, tag, build-tag, module, implements; metrics: complexity, loc, nesting, params (complexity>=10); flags: generated, test. Local semantic search only.",
+)
+@click.option(
+    "--grep",
+    "grep_pattern",
+    help="Only results whose code matches this regex, e.g. 'backoff|jitter' (Python syntax, case-sensitive unless it starts with (?i)). Local semantic search only.",
+)
+@click.option(
+    "--hyde",
+    is_flag=True,
+    help="Embed hypothetical code answering the query, drafted by query_expansion.llm_command (HyDE). Improves recall for vague questions. Local semantic search only.",
+)
+@click.option(
+    "--hyde-mode",
+    type=click.Choice(["fuse", "replace"]),
+    help="With --hyde: average the query and code embeddings (fuse) or embed only the code (replace). Default: query_expansion.mode. Implies --hyde.",
+)
+@click.option(
+    "--group-by",
+    type=click.Choice(["file"]),
+    help="Collapse results by file: each file's best hit, with a count of its other matches. Local semantic search only.",
+)
+@click.option(
+    "--max-per-file",
+    type=click.IntRange(min=1),
+    help="Show at most N results per file, with a count of the others (implies --group-by file). Local semantic search only.",
+)
+@click.option(
+    "--at",
+    "at_ref",
+    help="Search the file versions at this commit, tag or branch (e.g. v2.3.0) instead of the current branch, without re-indexing. Finds versions that were indexed, e.g. on an indexed branch. Local semantic search only.",
+)
+@click.option(
+    "--changed-since",
+    help="Only search files changed since the branch forked from this ref (e.g. origin/main), including uncommitted and untracked files. Local semantic search only.",
+)
+@click.option(
+    "--explain",
+    is_flag=True,
+    help="Break down each result's score: vector similarity and rank, relevance feedback and post_query hook adjustments, query words matched, and the filters applied. Local semantic search only.",
+)
+@click.option(
+    "--include-tests",
+    "test_scope",
+    flag_value="include",
+    default=True,
+    help="Search test files and the code they test (default)",
+)
+@click.option(
+    "--only-tests",
+    "test_scope",
+    flag_value="only",
+    help="Only test files (foo_test.go, test_foo.py, foo.test.ts, FooTest.java). Local semantic search only.",
+)
+@click.option(
+    "--exclude-tests",
+    "test_scope",
+    flag_value="exclude",
+    help="Leave test files out. Local semantic search only.",
+)
+@click.option(
+    "--include-generated",
+    is_flag=True,
+    help="Also search generated files (protobuf stubs, 'Code generated ... DO NOT EDIT' headers, minified JS), which local semantic search leaves out by default",
+)
+@click.option(
+    "--uncovered",
+    is_flag=True,
+    help="Only code no imported test covers (see 'cidx coverage import'). Local semantic search only.",
+)
+@click.option(
+    "--covered-by",
+    "covered_by",
+    multiple=True,
+    help="Only code covered by this test (see 'cidx coverage import'). Can be specified multiple times. Local semantic search only.",
 )
 # --show-unchanged removed: Story 2 - all temporal results are changes now
 @click.pass_context
@@ -4340,6 +4987,7 @@ def query(
     quiet: bool,
     fts: bool,
     semantic: bool,
+    hybrid: bool,
     case_sensitive: bool,
     case_insensitive: bool,
     fuzzy: bool,
@@ -4353,6 +5001,32 @@ def query(
     chunk_type: Optional[str],
     repo: Optional[str],
     repos: Optional[str],
+    licenses: tuple,
+    exclude_licenses: tuple,
+    owners: tuple,
+    struct_tags: tuple,
+    implements: tuple,
+    go_platform: Optional[str],
+    build_tags: tuple,
+    exclude_build_tags: tuple,
+    depends_on: tuple,
+    symbol_kinds: tuple,
+    exclude_kinds: tuple,
+    min_complexity: Optional[int],
+    vector_mode: str,
+    filter_expression: Optional[str],
+    grep_pattern: Optional[str],
+    hyde: bool,
+    hyde_mode: Optional[str],
+    group_by: Optional[str],
+    max_per_file: Optional[int],
+    at_ref: Optional[str],
+    changed_since: Optional[str],
+    explain: bool,
+    test_scope: str,
+    include_generated: bool,
+    uncovered: bool,
+    covered_by: tuple,
 ):
     """Search the indexed codebase using semantic similarity.
 
@@ -4408,6 +5082,22 @@ def query(
       code-indexer query "config" --exclude-language js --exclude-language ts
       code-indexer query "api" --exclude-path '*/tests/*' --exclude-path '*.min.js'
       code-indexer query "function" --quiet  # Just score, path, and content
+      code-indexer query "crypto" --license-not Apache-2.0 --license-not MIT
+      code-indexer query "feature flags" --owner @platform-team
+      code-indexer query "order persistence" --struct-tag gorm:uniqueIndex
+      code-indexer query "user storage" --implements UserRepository
+      code-indexer query "file locking" --platform windows/amd64
+      code-indexer query "middleware" --depends-on github.com/gin-gonic/gin
+      code-indexer query "login form" --symbol-kind widget
+      code-indexer query "authentication handler" --min-complexity 15
+      code-indexer query "UserRepository find by email" --vector-mode signature
+      code-indexer query "retry" --filter 'lang:go AND NOT generated'
+      code-indexer query "retry logic" --grep 'backoff|jitter'
+      code-indexer query "token refresh" --only-tests
+      code-indexer query "user message" --include-generated
+      code-indexer query "map over a slice" --symbol-kind generic
+      code-indexer query "error handling" --path-filter '*/payments/*' --uncovered
+      code-indexer query "refund" --covered-by TestRefund
 
     \b
     ADVANCED FILTER COMBINATIONS:
@@ -4449,49 +5139,173 @@ def query(
     # Initialize console for output (needed by multiple code paths)
     console = Console()
 
-    # Handle --repos flag for multi-repository queries (Story #676)
-    if repos:
-        # AC1: Validate --repos and --repo are mutually exclusive
-        if repo:
-            console.print(
-                "[red]Error: --repos and --repo are mutually exclusive.[/red]",
-                style="red",
-            )
-            console.print(
-                "  Use --repo for single repository queries",
-                style="dim",
-            )
-            console.print(
-                "  Use --repos for multi-repository queries (comma-separated)",
-                style="dim",
+    coverage_filter = uncovered or bool(covered_by)
+    license_filter = bool(licenses or exclude_licenses)
+    from .services.exclusion_filters import split_values
+    from .services.go_build_constraints import ANY_PLATFORM, parse_platform
+
+    exclude_paths = tuple(split_values(exclude_paths))
+
+    # Flags that need local semantic search of the current code
+    local_only_flags = [
+        flag
+        for flag, given in (
+            ("--owner", owners),
+            ("--struct-tag", struct_tags),
+            ("--implements", implements),
+            (
+                "--platform",
+                go_platform and go_platform.strip().lower() != ANY_PLATFORM,
+            ),
+            ("--build-tag", build_tags),
+            ("--exclude-build-tag", exclude_build_tags),
+            ("--depends-on", depends_on),
+            ("--symbol-kind", symbol_kinds),
+            ("--exclude-kind", exclude_kinds),
+            ("--min-complexity", min_complexity),
+            ("--vector-mode", vector_mode.lower() != "body"),
+            ("--filter", filter_expression is not None),
+            ("--grep", grep_pattern is not None),
+            ("--hyde", hyde),
+            ("--hyde-mode", hyde_mode),
+            ("--group-by", group_by),
+            ("--max-per-file", max_per_file),
+            ("--at", at_ref),
+            ("--explain", explain),
+            ("--only-tests", test_scope == "only"),
+            ("--exclude-tests", test_scope == "exclude"),
+            ("--license", licenses),
+            ("--license-not", exclude_licenses),
+            ("--uncovered", uncovered),
+            ("--covered-by", covered_by),
+            ("--changed-since", changed_since),
+        )
+        if given
+    ]
+    code_filter = bool(
+        struct_tags
+        or implements
+        or build_tags
+        or exclude_build_tags
+        or depends_on
+        or symbol_kinds
+        or exclude_kinds
+        or min_complexity
+    )
+    vector_mode = vector_mode.lower()
+    if vector_mode != "body":
+        code_filter = True
+    filter_condition = None
+    filter_tests_generated = False
+    if filter_expression is not None:
+        from .services.generated_code import GENERATED_KEY
+        from .services.query_filter import FilterSyntaxError, filter_keys, parse_filter
+
+        try:
+            filter_condition = parse_filter(filter_expression)
+        except FilterSyntaxError as e:
+            console.print(f"[red]❌ Error: --filter: {e}[/red]")
+            sys.exit(1)
+        filter_tests_generated = GENERATED_KEY in filter_keys(filter_condition)
+        code_filter = True
+    grep_regex = None
+    if grep_pattern is not None:
+        from .services.result_grep import compile_grep
+
+        try:
+            grep_regex = compile_grep(grep_pattern)
+        except ValueError as e:
+            console.print(f"❌ Error: --grep: {e}", style="red", markup=False)
+            sys.exit(1)
+        code_filter = True
+    if hyde or hyde_mode:
+        hyde = True
+        code_filter = True
+    if group_by or max_per_file:
+        # --group-by file alone keeps the best hit of each file
+        max_per_file = max_per_file or 1
+        code_filter = True
+    if at_ref or changed_since or explain:
+        code_filter = True
+    if test_scope in ("only", "exclude"):
+        code_filter = True
+    if go_platform and go_platform.strip().lower() != ANY_PLATFORM:
+        code_filter = True
+    if local_only_flags and (
+        fts
+        or hybrid
+        or time_range
+        or time_range_all
+        or (mode != "local" and not repo)
+    ):
+        for flag in local_only_flags:
+            console.print(
+                f"❌ Error: {flag} applies to local semantic search only",
+                style="red",
+                markup=False,
             )
+        sys.exit(1)
+    # The configured default platform applies where --platform would
+    if (
+        go_platform is None
+        and mode == "local"
+        and not (fts or time_range or time_range_all or repo)
+        and ctx.obj.get("config_manager")
+    ):
+        default_platform = (
+            ctx.obj["config_manager"]
+            .load()
+            .indexing.go_build_constraints.default_platform
+        )
+        if isinstance(default_platform, str):
+            go_platform = default_platform
+    # Generated files are left out where the payload filters apply, unless a
+    # --filter expression tests them itself
+    exclude_generated = (
+        not include_generated
+        and not filter_tests_generated
+        and mode == "local"
+        and not (fts or time_range or time_range_all or repo)
+    )
+    build_platform = None
+    if go_platform and go_platform.strip().lower() != ANY_PLATFORM:
+        try:
+            build_platform = parse_platform(go_platform)
+        except ValueError as e:
+            console.print(f"[red]❌ Error: --platform: {e}[/red]")
             sys.exit(1)
+        code_filter = True
+    # Coverage and --grep filters and grouping by file drop results after the
+    # search - fetch more candidates
+    candidate_factor = 10 if coverage_filter or grep_regex or max_per_file else 2
 
-        # AC1: Validate repos parameter is not empty
-        repos_str = repos.strip()
-        if not repos_str:
+    # Handle --repos flag for multi-repository queries (Story #676)
+    if repos:
+        # AC1: Validate --repos and --repo are mutually exclusive
+        if repo:
             console.print(
-                "[red]Error: --repos cannot be empty.[/red]",
+                "[red]Error: --repos and --repo are mutually exclusive.[/red]",
                 style="red",
             )
             console.print(
-                "  Provide comma-separated repository aliases: --repos repo1,repo2",
+                "  Use --repo for single repository queries",
+                style="dim",
+            )
+            console.print(
+                "  Use --repos for multi-repository queries (comma-separated)",
                 style="dim",
             )
             sys.exit(1)
 
-        # AC2: Validate we're in remote mode (multi-repo requires server)
-        if mode != "remote":
+        # AC1: Validate repos parameter is not empty
+        repos_str = repos.strip()
+        if not repos_str:
             console.print(
-                "[red]Error: Multi-repository queries require remote mode.[/red]",
+                "[red]Error: --repos cannot be empty.[/red]",
                 style="red",
             )
             console.print(
-                "  Multi-repository search is only available when connected to a CIDX server.",
-                style="dim",
-            )
-            console.print(
-                "  To configure remote mode: [cyan]cidx init --remote[/cyan]",
+                "  Provide comma-separated repository aliases: --repos repo1,repo2",
                 style="dim",
             )
             sys.exit(1)
@@ -4510,6 +5324,31 @@ def query(
             )
             sys.exit(1)
 
+        # Outside remote mode the repositories are workspace members,
+        # searched locally
+        if mode != "remote":
+            if (
+                fts
+                or hybrid
+                or time_range
+                or time_range_all
+                or code_filter
+                or coverage_filter
+                or license_filter
+                or owners
+                or exclude_languages
+                or exclude_paths
+            ):
+                console.print(
+                    "[red]❌ Error: workspace queries (--repos) take --language, "
+                    "--path-filter, --min-score and --limit only[/red]"
+                )
+                sys.exit(1)
+            _query_workspace(
+                query, repos_list, limit, languages, path_filter, min_score, quiet
+            )
+            sys.exit(0)
+
         # AC3: Execute multi-repository query via /api/query/multi
         try:
             from .cli_multi_repo import (
@@ -4632,7 +5471,16 @@ def query(
         # Set time_range to "all" internally
         time_range = "all"
 
-    if mode == "local" and not standalone_mode:
+    # Coverage data is read locally and the daemon does not take license,
+    # owner or Go code filters, so these queries run standalone
+    if (
+        mode == "local"
+        and not standalone_mode
+        and not coverage_filter
+        and not license_filter
+        and not owners
+        and not code_filter
+    ):
         try:
             config_manager = ctx.obj.get("config_manager")
             if config_manager:
@@ -4893,7 +5741,7 @@ def query(
             sys.exit(0)
 
     # Determine search mode based on flags (Story 4)
-    if fts and semantic:
+    if hybrid or (fts and semantic):
         search_mode = "hybrid"
     elif fts:
         search_mode = "fts"
@@ -4902,6 +5750,19 @@ def query(
 
     # Validate --regex flag compatibility
     if regex:
+        # Fused results rank chunks, not regex matches
+        if hybrid:
+            console.print("[red]❌ Cannot combine --regex with --hybrid[/red]")
+            console.print()
+            console.print("Use one of:")
+            console.print(
+                "  [cyan]cidx query 'pattern' --fts --regex[/cyan]      # Regex search only"
+            )
+            console.print(
+                "  [cyan]cidx query 'text' --hybrid[/cyan]              # Fused hybrid search"
+            )
+            sys.exit(1)
+
         # Regex requires FTS mode
         if not fts:
             console.print(
@@ -5097,6 +5958,16 @@ def query(
                     console.print(f"[yellow]⚠️  Semantic search failed: {e}[/yellow]")
                     semantic_results = []
 
+            if hybrid:
+                from .services.hybrid_search import fuse_results
+
+                _display_fused_results(
+                    fuse_results(fts_results, semantic_results, limit),
+                    quiet=quiet,
+                    console=console,
+                )
+                sys.exit(0)
+
             # Display hybrid results with clear separation (AC#2)
             _display_hybrid_results(
                 fts_results=fts_results,
@@ -5446,6 +6317,22 @@ def query(
         # Ensure payload indexes exist (read-only check for query operations)
         vector_store_client.ensure_payload_indexes(collection_name, context="query")
 
+        # --hyde: the search embeds hypothetical code answering the query
+        hyde_provider = None
+        if hyde:
+            from .services.query_expansion import HydeEmbeddingProvider, HydeExpander
+
+            try:
+                hyde_provider = HydeEmbeddingProvider(
+                    embedding_provider,
+                    HydeExpander.from_config(config),
+                    hyde_mode or config.query_expansion.mode,
+                )
+            except ValueError as e:
+                console.print(f"❌ Error: --hyde: {e}", style="red", markup=False)
+                sys.exit(1)
+            embedding_provider = hyde_provider
+
         # Initialize timing dictionary for telemetry
         timing_info = {}
 
@@ -5453,6 +6340,7 @@ def query(
         # inside vector_store_client.search() - do NOT pre-compute embedding here
 
         # Build filter conditions for non-git path only
+        filter_build_start = time.time()
         filter_conditions: Dict[str, Any] = {}
         if languages:
             # Validate language parameters
@@ -5550,6 +6438,158 @@ def query(
                 else:
                     filter_conditions["must_not"] = path_exclusion_filters["must_not"]
 
+        # Payload metadata filters, also applied by the git-aware search below
+        metadata_conditions: List[Dict[str, Any]] = []
+
+        # License filters (payload "license" from indexing.license_detection)
+        if license_filter:
+            from .services.license_detection import LICENSE_KEY, canonical_license_id
+
+            license_conditions: List[Dict[str, Any]] = [
+                {"key": LICENSE_KEY, "match": {"value": canonical_license_id(lic)}}
+                for lic in licenses
+            ]
+            if len(license_conditions) > 1:
+                # Multiple licenses: OR logic
+                license_conditions = [{"should": license_conditions}]
+            metadata_conditions.extend(license_conditions)
+            filter_conditions.setdefault("must_not", []).extend(
+                {"key": LICENSE_KEY, "match": {"value": canonical_license_id(lic)}}
+                for lic in exclude_licenses
+            )
+
+        # Owner filters (payload "owners" from CODEOWNERS, a list of owners)
+        if owners:
+            from .services.code_owners import OWNERS_KEY, normalize_owner
+
+            owner_conditions: List[Dict[str, Any]] = [
+                {"key": OWNERS_KEY, "match": {"value": normalize_owner(owner)}}
+                for owner in owners
+            ]
+            if len(owner_conditions) > 1:
+                # Multiple owners: OR logic
+                owner_conditions = [{"should": owner_conditions}]
+            metadata_conditions.extend(owner_conditions)
+
+        # Struct tag filters (payload "struct_tags" of Go chunks, a list of
+        # tag keys and "key:option" terms)
+        if struct_tags:
+            from .services.struct_tags import STRUCT_TAGS_KEY, normalize_struct_tag
+
+            tag_conditions: List[Dict[str, Any]] = [
+                {"key": STRUCT_TAGS_KEY, "match": {"value": normalize_struct_tag(tag)}}
+                for tag in struct_tags
+            ]
+            if len(tag_conditions) > 1:
+                # Multiple struct tags: OR logic
+                tag_conditions = [{"should": tag_conditions}]
+            metadata_conditions.extend(tag_conditions)
+
+        # Go build constraint filters (payload "build_os", "build_arch" and
+        # "build_tags" of Go files with build constraints)
+        if build_platform:
+            from .services.go_build_constraints import platform_conditions
+
+            metadata_conditions.extend(platform_conditions(*build_platform))
+        if build_tags or exclude_build_tags:
+            from .services.go_build_constraints import BUILD_TAGS_KEY
+
+            build_tag_conditions: List[Dict[str, Any]] = [
+                {"key": BUILD_TAGS_KEY, "match": {"value": tag.strip()}}
+                for tag in build_tags
+            ]
+            if len(build_tag_conditions) > 1:
+                # Multiple build tags: OR logic
+                build_tag_conditions = [{"should": build_tag_conditions}]
+            metadata_conditions.extend(build_tag_conditions)
+            filter_conditions.setdefault("must_not", []).extend(
+                {"key": BUILD_TAGS_KEY, "match": {"value": tag.strip()}}
+                for tag in exclude_build_tags
+            )
+
+        # Module filters (payload "go_modules" of Go files, the modules their
+        # imports resolve to)
+        if depends_on:
+            from .services.go_dependencies import GO_MODULES_KEY
+
+            module_conditions: List[Dict[str, Any]] = [
+                {"key": GO_MODULES_KEY, "match": {"value": module.strip()}}
+                for module in depends_on
+            ]
+            if len(module_conditions) > 1:
+                # Multiple modules: OR logic
+                module_conditions = [{"should": module_conditions}]
+            metadata_conditions.extend(module_conditions)
+
+        # Declaration kind filters (payload "symbol_kind" of Dart,
+        # Objective-C, Julia, R, HDL, Rust, Zig, Elixir, Erlang and Scala
+        # chunks, and of generic Go code)
+        if symbol_kinds:
+            from .indexing.boundary_chunking import SYMBOL_KIND_KEY
+
+            kind_conditions: List[Dict[str, Any]] = [
+                {"key": SYMBOL_KIND_KEY, "match": {"value": kind.strip().lower()}}
+                for kind in symbol_kinds
+            ]
+            if len(kind_conditions) > 1:
+                # Multiple kinds: OR logic
+                kind_conditions = [{"should": kind_conditions}]
+            metadata_conditions.extend(kind_conditions)
+
+        # Complexity filter (payload "complexity" of code chunks)
+        if min_complexity:
+            from .services.code_metrics import COMPLEXITY_KEY
+
+            metadata_conditions.append(
+                {"key": COMPLEXITY_KEY, "range": {"gte": min_complexity}}
+            )
+
+        # Test file filters (payload "is_test" of files named like tests)
+        if test_scope in ("only", "exclude"):
+            from .services.test_linkage import IS_TEST_KEY
+
+            test_condition = {"key": IS_TEST_KEY, "match": {"value": True}}
+            if test_scope == "only":
+                metadata_conditions.append(test_condition)
+            else:
+                filter_conditions.setdefault("must_not", []).append(test_condition)
+
+        # Kind exclusions (--exclude-kind test,mock)
+        if exclude_kinds:
+            from .services.exclusion_filters import kind_exclusion_conditions
+
+            filter_conditions.setdefault("must_not", []).extend(
+                kind_exclusion_conditions(exclude_kinds)
+            )
+
+        # Generated files (payload "generated" from indexing.generated_code)
+        if exclude_generated:
+            from .services.generated_code import GENERATED_KEY
+
+            filter_conditions.setdefault("must_not", []).append(
+                {"key": GENERATED_KEY, "match": {"value": True}}
+            )
+
+        # Interface filters (payload "implements" of Go type declarations)
+        if implements:
+            from .services.go_interfaces import IMPLEMENTS_KEY
+
+            implements_conditions: List[Dict[str, Any]] = [
+                {"key": IMPLEMENTS_KEY, "match": {"value": interface.strip()}}
+                for interface in implements
+            ]
+            if len(implements_conditions) > 1:
+                # Multiple interfaces: OR logic
+                implements_conditions = [{"should": implements_conditions}]
+            metadata_conditions.extend(implements_conditions)
+
+        # Filter expression (--filter), already a payload filter condition
+        if filter_condition:
+            metadata_conditions.append(filter_condition)
+
+        if metadata_conditions:
+            filter_conditions.setdefault("must", []).extend(metadata_conditions)
+
         # Detect and warn about filter conflicts (Story 3.1)
         from .services.filter_conflict_detector import FilterConflictDetector
 
@@ -5591,6 +6631,7 @@ def query(
             import json
 
             logger.debug(f"Query filters: {json.dumps(filter_conditions, indent=2)}")
+        timing_info["filter_build_ms"] = (time.time() - filter_build_start) * 1000
 
         # Check if project uses git-aware indexing
         from .services.git_topology_service import GitTopologyService
@@ -5600,6 +6641,48 @@ def query(
         git_topology_service = GitTopologyService(config.codebase_dir)
         is_git_aware = git_topology_service.is_git_available()
 
+        # --at: search the file versions of a commit or tag
+        snapshot = None
+        if at_ref:
+            from .services.ref_search import resolve_ref
+
+            if not is_git_aware:
+                console.print("❌ Error: --at needs a git repository", style="red")
+                sys.exit(1)
+            try:
+                snapshot = resolve_ref(Path(config.codebase_dir), at_ref)
+            except ValueError as e:
+                console.print(f"❌ Error: --at: {e}", style="red", markup=False)
+                sys.exit(1)
+            metadata_conditions.append(snapshot.payload_condition())
+
+        # --changed-since: search only the files a branch touches
+        changed_files = None
+        if changed_since:
+            from .services.diff_scope import resolve_changed_files
+
+            if not is_git_aware:
+                console.print(
+                    "❌ Error: --changed-since needs a git repository", style="red"
+                )
+                sys.exit(1)
+            try:
+                changed_files = resolve_changed_files(
+                    Path(config.codebase_dir), changed_since
+                )
+            except ValueError as e:
+                console.print(
+                    f"❌ Error: --changed-since: {e}", style="red", markup=False
+                )
+                sys.exit(1)
+            if not changed_files.paths:
+                console.print(
+                    f"⚠️  No files changed since {changed_since}",
+                    style="yellow",
+                    markup=False,
+                )
+            metadata_conditions.append(changed_files.payload_condition())
+
         # Initialize query service for git-aware filtering
         query_service = GenericQueryService(config.codebase_dir, config)
 
@@ -5623,6 +6706,17 @@ def query(
             if is_git_aware:
                 console.print(f"📂 Git repository: {config.codebase_dir.name}")
                 console.print(f"🌿 Current branch: {current_branch}")
+                if snapshot is not None:
+                    console.print(
+                        f"🕰️  At: {snapshot.ref} ({snapshot.commit[:12]})",
+                        markup=False,
+                    )
+                if changed_files is not None:
+                    console.print(
+                        f"🔀 Changed since: {changed_files.ref} "
+                        f"({len(changed_files.paths)} files)",
+                        markup=False,
+                    )
             else:
                 branch_context = query_service.get_current_branch_context()
                 console.print(f"📁 Non-git project: {branch_context['project_id']}")
@@ -5671,6 +6765,8 @@ def query(
                     pass
         except Exception:
             pass
+        if snapshot is not None:
+            current_display_branch = snapshot.ref
 
         # Get current embedding model for filtering
         current_model = embedding_provider.get_current_model()
@@ -5722,6 +6818,7 @@ def query(
                     filter_conditions_list.append(
                         {"key": "path", "match": {"text": path_filter[0]}}
                     )
+            filter_conditions_list.extend(metadata_conditions)
 
             # Build filter conditions preserving both must and must_not conditions
             query_filter_conditions = (
@@ -5745,9 +6842,10 @@ def query(
                     query=query,  # Pass query text for parallel embedding
                     embedding_provider=embedding_provider,  # Provider for parallel execution
                     filter_conditions=query_filter_conditions,
-                    limit=limit * 2,  # Get more to account for post-filtering
+                    limit=limit * candidate_factor,  # More to allow post-filtering
                     collection_name=collection_name,
                     return_timing=True,
+                    vector_mode=vector_mode,
                 )
             else:
                 # FilesystemVectorStore: pre-compute embedding (no parallel support yet)
@@ -5755,7 +6853,7 @@ def query(
                 raw_results_list = vector_store_client.search(
                     query_vector=query_embedding,
                     filter_conditions=query_filter_conditions,
-                    limit=limit * 2,
+                    limit=limit * candidate_factor,
                     collection_name=collection_name,
                 )
                 raw_results = raw_results_list  # Type compatibility
@@ -5777,8 +6875,23 @@ def query(
 
             # Apply git-aware post-filtering (checks file existence in current branch)
             git_filter_start = time.time()
-            # Type hint: raw_results is always List[Dict[str, Any]] here
-            git_results = query_service.filter_results_by_current_branch(raw_results)  # type: ignore[arg-type]
+            if snapshot is not None:
+                # --at: versions hidden on the current branch still count
+                from .services.ref_search import filter_results_at_ref
+
+                git_results = filter_results_at_ref(raw_results, snapshot)  # type: ignore[arg-type]
+                if not git_results:
+                    console.print(
+                        f"⚠️  No indexed file versions at {snapshot.ref} match. "
+                        "Only versions that were indexed (e.g. on an indexed "
+                        "branch) can be searched; --time-range-all searches "
+                        "the full git history.",
+                        style="yellow",
+                        markup=False,
+                    )
+            else:
+                # Type hint: raw_results is always List[Dict[str, Any]] here
+                git_results = query_service.filter_results_by_current_branch(raw_results)  # type: ignore[arg-type]
             timing_info["git_filter_ms"] = (time.time() - git_filter_start) * 1000
 
             # Apply minimum score filtering (language and path already handled by Filesystem filters)
@@ -5803,10 +6916,11 @@ def query(
                     query=query,
                     embedding_provider=embedding_provider,
                     filter_conditions=filter_conditions if filter_conditions else None,
-                    limit=limit * 2,
+                    limit=limit * candidate_factor,
                     score_threshold=min_score,
                     collection_name=collection_name,
                     return_timing=True,
+                    vector_mode=vector_mode,
                 )
                 timing_info.update(search_timing)
             else:
@@ -5816,7 +6930,7 @@ def query(
                 raw_results_list = vector_store_client.search_with_model_filter(
                     query_vector=query_embedding,
                     embedding_model=current_model,
-                    limit=limit * 2,
+                    limit=limit * candidate_factor,
                     score_threshold=min_score,
                     additional_filters=filter_conditions,
                     accuracy=accuracy,
@@ -5832,11 +6946,77 @@ def query(
             git_results = query_service.filter_results_by_current_branch(raw_results)  # type: ignore[arg-type]
             timing_info["git_filter_ms"] = (time.time() - git_filter_start) * 1000
 
+        # --explain: similarity and rank straight after the vector search
+        if explain:
+            from .services.score_explanation import record_vector_scores
+
+            record_vector_scores(git_results)
+
+        if hyde_provider is not None and hyde_provider.error:
+            console.print(
+                f"⚠️  --hyde: {hyde_provider.error}; searched the plain query",
+                style="yellow",
+                markup=False,
+            )
+        elif hyde_provider is not None and not quiet:
+            console.print("🧪 Searched with hypothetical code:", style="cyan")
+            console.print(hyde_provider.hypothetical, style="dim", markup=False)
+
+        # Keep results whose code matches --grep
+        if grep_regex is not None:
+            from .services.result_grep import grep_results
+
+            git_results = grep_results(git_results, grep_regex)
+
+        # Annotate results with imported test coverage and apply coverage filters
+        from .services.coverage_mapping import CoverageMap
+
+        coverage_map = CoverageMap(
+            Path(config.codebase_dir), Path(config.codebase_dir) / ".code-indexer"
+        )
+        if coverage_map.has_data:
+            coverage_map.annotate_results(git_results)
+            if coverage_filter:
+                git_results = coverage_map.filter_results(
+                    git_results, uncovered=uncovered, covered_by=covered_by
+                )
+        elif coverage_filter:
+            console.print(
+                "❌ No coverage imported - run 'cidx coverage import' first",
+                style="red",
+            )
+            sys.exit(1)
+
+        # Re-rank with recorded relevance feedback ('cidx feedback')
+        from .services.relevance_feedback import FeedbackStore
+
+        git_results = FeedbackStore.for_project(
+            Path(config.codebase_dir) / ".code-indexer"
+        ).rerank(git_results)
+
+        # Post-process with configured post_query hooks
+        from .services.lifecycle_hooks import HookError, LifecycleHooks
+
+        try:
+            query_hooks = LifecycleHooks.from_config(config, points=("post_query",))
+            if query_hooks is not None:
+                git_results = query_hooks.process_results(query, git_results)
+        except (HookError, ValueError) as e:
+            console.print(f"❌ {e}", style="red", markup=False)
+            sys.exit(1)
+
+        # Collapse the results of each file (--group-by, --max-per-file)
+        if max_per_file:
+            from .services.result_grouping import group_results
+
+            git_results = group_results(git_results, max_per_file, limit)
+
         # Limit to requested number after filtering
         results = git_results[:limit]
 
-        # Apply staleness detection to local query results
-        if results:
+        # Apply staleness detection to local query results (--at results are
+        # past versions by design)
+        if results and snapshot is None:
             try:
                 staleness_start = time.time()
                 # Convert local results to QueryResultItem format for staleness detection
@@ -5911,7 +7091,42 @@ def query(
                         f"⚠️  Staleness detection unavailable: {e}", style="dim yellow"
                     )
 
+        # Mark the region of each chunk matching the query
+        from .services.match_highlighting import annotate_highlights
+
+        annotate_highlights(results, query)
+
+        if explain:
+            from .services.score_explanation import describe_filter, explain_results
+
+            explain_results(results, query)
+            payload_filters = describe_filter(
+                query_filter_conditions if use_branch_aware_query else filter_conditions
+            )
+            post_filters = []
+            if snapshot is not None:
+                post_filters.append(f"files at {snapshot.ref}")
+            elif use_branch_aware_query:
+                post_filters.append("visible on the current branch")
+            if min_score:
+                post_filters.append(f"--min-score {min_score}")
+            if grep_pattern is not None:
+                post_filters.append(f"--grep {grep_pattern}")
+            if uncovered:
+                post_filters.append("--uncovered")
+            post_filters.extend(f"--covered-by {test}" for test in covered_by)
+            if max_per_file:
+                post_filters.append(f"--max-per-file {max_per_file}")
+            console.print(
+                f"🧮 Payload filters: {'; '.join(payload_filters) or 'none'}",
+                markup=False,
+            )
+            console.print(
+                f"🧮 Post-filters: {'; '.join(post_filters) or 'none'}", markup=False
+            )
+
         # Display results using shared display function (DRY principle)
+        render_start = time.time()
         _display_semantic_results(
             results=results,
             console=console,
@@ -5919,6 +7134,11 @@ def query(
             timing_info=timing_info,
             current_display_branch=current_display_branch,
         )
+        _record_query_latency(
+            config.codebase_dir / ".code-indexer",
+            timing_info,
+            render_ms=(time.time() - render_start) * 1000,
+        )
 
     except Exception as e:
         console.print(f"❌ Search failed: {e}", style="red", markup=False)
@@ -6473,6 +7693,16 @@ def _status_impl(ctx):
             else:
                 provider_details = "Service unreachable"
 
+            # Self-hosted endpoints: probe them so TLS, token and URL problems
+            # show up here rather than in the middle of indexing
+            from .config import DEFAULT_VOYAGE_API_ENDPOINT
+
+            endpoint = config.voyage_ai.api_endpoint
+            if provider_ok and endpoint != DEFAULT_VOYAGE_API_ENDPOINT:
+                endpoint_ok, endpoint_details = embedding_provider.check_endpoint()
+                provider_status = "✅ Ready" if endpoint_ok else "❌ Not Available"
+                provider_details += f"\nEndpoint: {endpoint}\n{endpoint_details}"
+
             table.add_row(
                 f"{provider_name} Provider", provider_status, provider_details
             )
@@ -6539,6 +7769,22 @@ def _status_impl(ctx):
                             # Build index files status
                             index_files_status = []
 
+                            # Format version (older formats migrate on next use)
+                            from .storage.index_format import check_collection_format
+
+                            format_status = check_collection_format(collection_path)
+                            if format_status is not None:
+                                version = f"v{format_status.version}"
+                                if format_status.is_newer:
+                                    format_display = f"❌ {version} (newer cidx required)"
+                                elif format_status.needs_migration:
+                                    format_display = f"⚠️ {version} (migrates on next use)"
+                                else:
+                                    format_display = f"✅ {version}"
+                                index_files_status.append(
+                                    f"Index Format: {format_display}"
+                                )
+
                             # Projection matrix (CRITICAL - queries fail without it)
                             if proj_matrix.exists():
                                 size_kb = proj_matrix.stat().st_size / 1024
@@ -7637,67 +8883,2534 @@ def list_collections_cmd(ctx):
         # Get vector store client
         vector_store = backend.get_vector_store_client()
 
-        # Get all collections
-        collections = vector_store.list_collections()
+        # Get all collections
+        collections = vector_store.list_collections()
+
+        if not collections:
+            console.print("ℹ️  No collections found", style="blue")
+            return
+
+        # Create table
+        table = Table(title="Collections")
+        table.add_column("Name", style="cyan")
+        table.add_column("Vectors", justify="right", style="green")
+        table.add_column("Dimensions", justify="right", style="yellow")
+        table.add_column("Size", justify="right", style="magenta")
+        table.add_column("Created", style="blue")
+
+        # Populate table
+        for coll_name in collections:
+            # Get vector count
+            vector_count = vector_store.count_points(coll_name)
+
+            # Get metadata
+            try:
+                metadata = vector_store.get_collection_info(coll_name)
+                vector_size = metadata.get("vector_size", "N/A")
+                created_at = metadata.get("created_at", "N/A")
+                if created_at != "N/A":
+                    # Format datetime
+                    from datetime import datetime
+
+                    dt = datetime.fromisoformat(created_at)
+                    created_at = dt.strftime("%Y-%m-%d %H:%M")
+            except Exception:
+                vector_size = "N/A"
+                created_at = "N/A"
+
+            # Get collection size
+            collection_size = 0
+            if hasattr(vector_store, "get_collection_size"):
+                collection_size = vector_store.get_collection_size(coll_name)
+
+            size_str = "N/A"
+            if collection_size > 0:
+                size_mb = collection_size / (1024 * 1024)
+                if size_mb >= 1.0:
+                    size_str = f"{size_mb:.2f} MB"
+                else:
+                    size_kb = collection_size / 1024
+                    size_str = f"{size_kb:.2f} KB"
+
+            table.add_row(
+                coll_name, str(vector_count), str(vector_size), size_str, created_at
+            )
+
+        console.print(table)
+
+    except Exception as e:
+        console.print(f"❌ Failed to list collections: {e}", style="red")
+        import traceback
+
+        traceback.print_exc()
+        sys.exit(1)
+
+
+@cli.command("compress-index")
+@click.option("--collection", help="Collection to migrate (default: all collections)")
+@click.option(
+    "--payloads",
+    is_flag=True,
+    help="Also compress large payload fields (e.g. commit messages)",
+)
+@click.option(
+    "--payload-min-bytes",
+    type=int,
+    default=None,
+    help="Minimum size of payload fields compressed with --payloads (default: 4096)",
+)
+@click.option(
+    "--level",
+    type=click.IntRange(1, 22),
+    default=None,
+    help="Zstd compression level (default: 3)",
+)
+@click.option(
+    "--decompress",
+    is_flag=True,
+    help="Revert collections to uncompressed storage",
+)
+@click.pass_context
+@require_mode("local")
+def compress_index(
+    ctx,
+    collection: Optional[str],
+    payloads: bool,
+    payload_min_bytes: Optional[int],
+    level: Optional[int],
+    decompress: bool,
+):
+    """Compress stored chunk text of existing collections with zstd.
+
+    \b
+    Collections created by this version already store compressed chunk
+    text. Run this once to migrate collections indexed by older versions;
+    searches keep working while it runs.
+
+    \b
+    EXAMPLES:
+      cidx compress-index                  # Compress chunk text, all collections
+      cidx compress-index --payloads       # Also compress large payload fields
+      cidx compress-index --decompress     # Revert to uncompressed storage
+    """
+    from .services.indexing_lock import IndexingLockError, create_indexing_lock
+    from .storage.chunk_compression import (
+        DEFAULT_COMPRESSION_LEVEL,
+        DEFAULT_PAYLOAD_MIN_BYTES,
+        CompressionSettings,
+    )
+
+    config_manager = ctx.obj["config_manager"]
+    config = config_manager.get_config()
+
+    settings = CompressionSettings(
+        enabled=not decompress,
+        level=level or DEFAULT_COMPRESSION_LEVEL,
+        payload_min_bytes=(
+            (payload_min_bytes or DEFAULT_PAYLOAD_MIN_BYTES) if payloads else None
+        ),
+    )
+
+    # Migration rewrites vector files - keep indexing out while it runs
+    indexing_lock = create_indexing_lock(config.codebase_dir / ".code-indexer")
+    try:
+        indexing_lock.acquire(str(config.codebase_dir))
+    except IndexingLockError as e:
+        console.print(f"❌ {e}", style="red")
+        sys.exit(1)
+
+    try:
+        backend = BackendFactory.create(config, config.codebase_dir)
+        vector_store = backend.get_vector_store_client()
+
+        collections = [collection] if collection else vector_store.list_collections()
+        if not collections:
+            console.print("ℹ️  No collections found", style="blue")
+            return
+
+        action = "Decompressing" if decompress else "Compressing"
+        for coll_name in collections:
+            console.print(f"🗜️  {action} {coll_name}...")
+            result = vector_store.migrate_compression(coll_name, settings)
+
+            before_mb = result.bytes_before / (1024 * 1024)
+            after_mb = result.bytes_after / (1024 * 1024)
+            console.print(
+                f"✅ {coll_name}: {result.files_rewritten}/{result.files_scanned} "
+                f"files rewritten, {before_mb:.2f} MB -> {after_mb:.2f} MB",
+                style="green",
+            )
+            if result.files_failed:
+                console.print(
+                    f"⚠️  {result.files_failed} unreadable vector files skipped",
+                    style="yellow",
+                )
+
+    except Exception as e:
+        console.print(f"❌ Compression migration failed: {e}", style="red")
+        sys.exit(1)
+    finally:
+        indexing_lock.release()
+
+
+@cli.command("encrypt-index")
+@click.option("--collection", help="Collection to migrate (default: all collections)")
+@click.option(
+    "--key-source",
+    type=click.Choice(["keyring", "passphrase"]),
+    default=None,
+    help="Key for collections not yet encrypted (default: encryption.key_source)",
+)
+@click.option(
+    "--decrypt",
+    is_flag=True,
+    help="Revert collections to plain text storage and remove their keys",
+)
+@click.pass_context
+@require_mode("local")
+def encrypt_index(
+    ctx,
+    collection: Optional[str],
+    key_source: Optional[str],
+    decrypt: bool,
+):
+    """Encrypt stored chunk text and payloads of existing collections.
+
+    \b
+    Uses AES-256-GCM with a random key kept in the OS keyring, or a key
+    derived from a passphrase (prompted, or read from CIDX_INDEX_PASSPHRASE).
+    Vectors and file paths stay unencrypted. With encryption.enabled in
+    config.json, collections created later are encrypted automatically.
+
+    \b
+    EXAMPLES:
+      cidx encrypt-index                          # Encrypt all collections
+      cidx encrypt-index --key-source passphrase  # Passphrase-derived key
+      cidx encrypt-index --decrypt                # Revert to plain text
+    """
+    from .services.indexing_lock import IndexingLockError, create_indexing_lock
+    from .storage.index_encryption import IndexKeyError
+
+    config_manager = ctx.obj["config_manager"]
+    config = config_manager.get_config()
+
+    target_key_source = None if decrypt else key_source or config.encryption.key_source
+
+    # Migration rewrites vector files - keep indexing out while it runs
+    indexing_lock = create_indexing_lock(config.codebase_dir / ".code-indexer")
+    try:
+        indexing_lock.acquire(str(config.codebase_dir))
+    except IndexingLockError as e:
+        console.print(f"❌ {e}", style="red")
+        sys.exit(1)
+
+    try:
+        backend = BackendFactory.create(config, config.codebase_dir)
+        vector_store = backend.get_vector_store_client()
+
+        collections = [collection] if collection else vector_store.list_collections()
+        if not collections:
+            console.print("ℹ️  No collections found", style="blue")
+            return
+
+        action = "Decrypting" if decrypt else "Encrypting"
+        for coll_name in collections:
+            console.print(f"🔒 {action} {coll_name}...")
+            result = vector_store.migrate_encryption(coll_name, target_key_source)
+            console.print(
+                f"✅ {coll_name}: {result.files_rewritten}/{result.files_scanned} "
+                "files rewritten",
+                style="green",
+            )
+            if result.files_failed:
+                console.print(
+                    f"⚠️  {result.files_failed} unreadable vector files skipped",
+                    style="yellow",
+                )
+
+        if not decrypt and not config.encryption.enabled:
+            console.print(
+                "ℹ️  Set encryption.enabled in config.json to encrypt collections "
+                "created later (e.g. after 'cidx clean-data')",
+                style="blue",
+            )
+
+    except IndexKeyError as e:
+        console.print(f"❌ {e}", style="red")
+        sys.exit(1)
+    except Exception as e:
+        console.print(f"❌ Encryption migration failed: {e}", style="red")
+        sys.exit(1)
+    finally:
+        indexing_lock.release()
+
+
+@cli.command("bench")
+@click.argument(
+    "path",
+    required=False,
+    type=click.Path(exists=True, file_okay=False, path_type=Path),
+)
+@click.option(
+    "--files",
+    "num_files",
+    type=click.IntRange(1, 100000),
+    default=None,
+    help="Files in the synthetic corpus (default: 200, ignored with PATH)",
+)
+@click.option(
+    "--seed",
+    type=int,
+    default=None,
+    help="Synthetic corpus seed (default: 42, ignored with PATH)",
+)
+@click.option(
+    "--repeat",
+    type=click.IntRange(1, 100),
+    default=None,
+    help="Runs of each standard query (default: 3)",
+)
+@click.option(
+    "--batch-size",
+    type=click.IntRange(1, 10000),
+    default=None,
+    help="Texts per embedding request (default: from config)",
+)
+@click.option(
+    "--parallel-requests",
+    type=click.IntRange(1, 256),
+    default=None,
+    help="Concurrent embedding requests (default: from config)",
+)
+@click.option("--json", "as_json", is_flag=True, help="Output results as JSON")
+@click.pass_context
+@require_mode("local")
+def bench(
+    ctx,
+    path: Optional[Path],
+    num_files: Optional[int],
+    seed: Optional[int],
+    repeat: Optional[int],
+    batch_size: Optional[int],
+    parallel_requests: Optional[int],
+    as_json: bool,
+):
+    """Benchmark indexing and query throughput.
+
+    \b
+    Indexes a synthetic corpus (or PATH) into a scratch index with the
+    project's configuration and embedding provider, then runs a standard
+    query set. The project's own index is not touched. Embedding calls
+    count against your provider quota.
+
+    \b
+    Reports chunks/sec, embeddings/sec, upsert throughput, index build
+    time and query p50/p95. Compare runs with the same corpus to measure
+    the effect of provider, backend or config changes.
+
+    \b
+    EXAMPLES:
+      cidx bench                           # 200-file synthetic corpus
+      cidx bench --files 1000 --repeat 5   # Larger corpus, more query runs
+      cidx bench ~/src/my-service --json   # Real code, machine-readable
+    """
+    import tempfile
+
+    from .services.benchmark import (
+        DEFAULT_BENCH_FILES,
+        DEFAULT_BENCH_SEED,
+        DEFAULT_QUERY_REPEATS,
+        find_corpus_files,
+        generate_synthetic_corpus,
+        run_benchmark,
+    )
+
+    config_manager = ctx.obj["config_manager"]
+    config = config_manager.get_config()
+
+    def progress(message: str) -> None:
+        if not as_json:
+            console.print(f"⏱️  {message}...")
+
+    try:
+        embedding_provider = EmbeddingProviderFactory.create(config, console)
+
+        with tempfile.TemporaryDirectory(prefix="cidx-bench-") as work:
+            work_dir = Path(work)
+            if path:
+                corpus_dir = path.resolve()
+                files = find_corpus_files(config, corpus_dir)
+            else:
+                corpus_dir = work_dir / "corpus"
+                files = generate_synthetic_corpus(
+                    corpus_dir,
+                    num_files=num_files or DEFAULT_BENCH_FILES,
+                    seed=DEFAULT_BENCH_SEED if seed is None else seed,
+                )
+
+            result = run_benchmark(
+                config,
+                embedding_provider,
+                corpus_dir=corpus_dir,
+                files=files,
+                work_dir=work_dir,
+                query_repeats=repeat or DEFAULT_QUERY_REPEATS,
+                batch_size=batch_size,
+                parallel_requests=parallel_requests,
+                progress_callback=progress,
+            )
+    except Exception as e:
+        console.print(f"❌ Benchmark failed: {e}", style="red")
+        sys.exit(1)
+
+    if not path:
+        result.corpus = "synthetic"
+
+    if as_json:
+        click.echo(json.dumps(result.to_dict(), indent=2))
+        return
+
+    table = Table(title=f"cidx bench ({result.provider} / {result.model})")
+    table.add_column("Stage", style="cyan")
+    table.add_column("Items", justify="right", style="green")
+    table.add_column("Seconds", justify="right", style="yellow")
+    table.add_column("Throughput", justify="right", style="magenta")
+    table.add_row(
+        "Chunking",
+        f"{result.chunks} chunks",
+        f"{result.chunk_seconds:.2f}",
+        f"{result.chunks_per_second:.1f} chunks/s",
+    )
+    table.add_row(
+        "Embedding",
+        f"{result.embeddings} vectors",
+        f"{result.embed_seconds:.2f}",
+        f"{result.embeddings_per_second:.1f} embeddings/s",
+    )
+    table.add_row(
+        "Upsert",
+        f"{result.points_upserted} points",
+        f"{result.upsert_seconds:.2f}",
+        f"{result.upserts_per_second:.1f} points/s",
+    )
+    table.add_row("Index build", "", f"{result.index_build_seconds:.2f}", "")
+    table.add_row(
+        "Query",
+        f"{len(result.query_latencies_ms)} queries",
+        "",
+        f"p50 {result.query_p50_ms:.0f} ms / p95 {result.query_p95_ms:.0f} ms",
+    )
+    console.print(table)
+    console.print(
+        f"Corpus: {result.corpus} ({result.files} files, "
+        f"{result.bytes_read / 1024:.0f} KB), total {result.elapsed_seconds:.1f}s"
+    )
+
+
+@cli.command("stats")
+@click.option(
+    "--latency",
+    is_flag=True,
+    help="Report per-stage query latency percentiles (p50/p95/p99)",
+)
+@click.option("--reset", is_flag=True, help="Delete recorded query timings")
+@click.option("--json", "as_json", is_flag=True, help="Output results as JSON")
+@click.pass_context
+@require_mode("local")
+def stats(ctx, latency: bool, reset: bool, as_json: bool):
+    """Show locally recorded query performance statistics.
+
+    \b
+    Every semantic query records how long its stages took (filter build,
+    vector search, payload fetch, rerank, render) in
+    .code-indexer/query_latency.jsonl. The most recent 1000 queries are
+    kept, so percentiles track current performance.
+
+    \b
+    EXAMPLES:
+      cidx stats --latency                 # p50/p95/p99 per query stage
+      cidx stats --latency --json          # Machine-readable
+      cidx stats --reset                   # Start a fresh measurement
+    """
+    from .services.query_latency_stats import (
+        QUERY_STAGES,
+        TOTAL_KEY,
+        QueryLatencyStore,
+    )
+
+    config_manager = ctx.obj["config_manager"]
+    config = config_manager.get_config()
+    store = QueryLatencyStore(config.codebase_dir / ".code-indexer")
+
+    if reset:
+        store.clear()
+        if not as_json:
+            console.print("✅ Query latency statistics cleared", style="green")
+        if not latency:
+            return
+
+    if not latency:
+        console.print(
+            "ℹ️  Specify a report, e.g. cidx stats --latency", style="blue"
+        )
+        return
+
+    summary = store.summarize()
+    if as_json:
+        click.echo(json.dumps(summary, indent=2))
+        return
+
+    if not summary["queries"]:
+        console.print(
+            "ℹ️  No query timings recorded yet - run some queries first",
+            style="blue",
+        )
+        return
+
+    table = Table(title=f"Query latency ({summary['queries']} queries)")
+    table.add_column("Stage", style="cyan")
+    table.add_column("Samples", justify="right", style="green")
+    table.add_column("p50", justify="right", style="yellow")
+    table.add_column("p95", justify="right", style="yellow")
+    table.add_column("p99", justify="right", style="magenta")
+    for stage in QUERY_STAGES + (TOTAL_KEY,):
+        stage_summary = summary["stages"][stage]
+        label = stage[: -len("_ms")].replace("_", " ")
+        if not stage_summary["count"]:
+            table.add_row(label, "0", "-", "-", "-")
+            continue
+        table.add_row(
+            label,
+            str(stage_summary["count"]),
+            f"{stage_summary['p50']:.1f} ms",
+            f"{stage_summary['p95']:.1f} ms",
+            f"{stage_summary['p99']:.1f} ms",
+        )
+    console.print(table)
+
+
+@cli.command("verify")
+@click.option(
+    "--consistency",
+    is_flag=True,
+    help="Cross-check tracked files, indexed chunks and collection indexes "
+    "(default when no check is given)",
+)
+@click.option(
+    "--integrity",
+    is_flag=True,
+    help="Check stored chunk text against its hash and tracked sources",
+)
+@click.option(
+    "--repair",
+    is_flag=True,
+    help="Repair consistency issues: rebuild drifted indexes, remove orphaned "
+    "files and re-index incomplete ones",
+)
+@click.option("--collection", help="Collection to verify (default: all collections)")
+@click.option(
+    "--max-issues",
+    type=click.IntRange(1, 10000),
+    default=20,
+    help="Issues listed per collection (default: 20)",
+)
+@click.option("--json", "as_json", is_flag=True, help="Output results as JSON")
+@click.option(
+    "--ci",
+    is_flag=True,
+    help="Check the index against the checked-out commit and print a JSON "
+    "report; exits 1 when the index is stale",
+)
+@click.option(
+    "--max-stale-files",
+    type=click.IntRange(min=0),
+    default=0,
+    help="With --ci: changed indexed files tolerated since the indexed "
+    "commit (default: 0)",
+)
+@click.pass_context
+@require_mode("local")
+def verify(
+    ctx,
+    consistency: bool,
+    integrity: bool,
+    repair: bool,
+    collection: Optional[str],
+    max_issues: int,
+    as_json: bool,
+    ci: bool,
+    max_stale_files: int,
+):
+    """Verify the local index.
+
+    \b
+    --consistency (the default) cross-checks the index end to end: every
+    tracked file has a complete set of chunks visible on the current
+    branch, no chunks stay visible for deleted or excluded files, at most
+    one version of a file is visible, and the vector files agree with the
+    ID, path and HNSW indexes and the file count shown by 'cidx status'.
+    With --repair, drifted indexes are rebuilt from the vector files,
+    orphaned files are removed from the current branch and missing or
+    incomplete files are re-indexed, then the check runs again.
+
+    \b
+    --integrity checks every stored chunk twice: its text must still match
+    the chunk_hash recorded at indexing time (tampering, silent disk
+    corruption), and it must still be part of a tracked source - the file
+    on disk, its indexed git blob or the file at its indexed commit.
+
+    \b
+    --ci gates pipelines on a restored or shared index: the last run
+    completed, the configured embedding model built it, its commit is in
+    the checked-out history, no indexed file changed since, and no tracked
+    file is missing. The JSON report's "action" is "index" when an
+    incremental 'cidx index' fixes the drift and "rebuild" when only
+    'cidx index --clear' does. No embedding API key is needed.
+
+    Exits with status 1 when any check fails.
+
+    \b
+    EXAMPLES:
+      cidx verify                          # Consistency check
+      cidx verify --repair                 # Check and repair
+      cidx verify --integrity              # Chunk integrity, all collections
+      cidx verify --integrity --json       # Machine-readable, for CI
+      cidx verify --ci                     # Staleness gate for pipelines
+    """
+    if ci:
+        if consistency or integrity or repair:
+            console.print(
+                "❌ --ci runs its own checks; it cannot be combined with "
+                "--consistency, --integrity or --repair",
+                style="red",
+            )
+            sys.exit(1)
+        _verify_ci(ctx.obj["config_manager"], max_stale_files)
+        return
+    if repair and integrity and not consistency:
+        console.print(
+            "❌ --repair applies to the consistency check, not --integrity",
+            style="red",
+        )
+        sys.exit(1)
+    if not integrity:
+        consistency = True
+
+    config_manager = ctx.obj["config_manager"]
+    output: Dict[str, Any] = {}
+    ok = True
+    if consistency:
+        consistency_ok, output["consistency"] = _verify_consistency(
+            config_manager, collection, repair, max_issues, as_json
+        )
+        ok = ok and consistency_ok
+    if integrity:
+        integrity_ok, output["integrity"] = _verify_integrity(
+            config_manager, collection, max_issues, as_json
+        )
+        ok = ok and integrity_ok
+
+    if as_json:
+        # A single check keeps its own document shape
+        data = next(iter(output.values())) if len(output) == 1 else output
+        click.echo(json.dumps(data, indent=2))
+    if not ok:
+        sys.exit(1)
+
+
+def _verify_ci(config_manager, max_stale_files: int) -> None:
+    """Run 'cidx verify --ci': print the JSON report, exit 1 when stale."""
+    from .indexing.file_finder import FileFinder
+    from .services.ci_verification import CiIndexVerifier
+
+    config = config_manager.get_config()
+    project_root = Path(config.codebase_dir)
+    try:
+        backend = BackendFactory.create(config, project_root)
+        verifier = CiIndexVerifier(
+            project_root,
+            config_manager.config_path.parent / "metadata.json",
+            config.voyage_ai.model,
+            backend.get_vector_store_client(),
+            [
+                str(path.relative_to(project_root)) if path.is_absolute() else str(path)
+                for path in FileFinder(config).find_files()
+            ],
+            max_stale_files=max_stale_files,
+        )
+        report = verifier.verify()
+    except Exception as e:
+        click.echo(json.dumps({"ok": False, "error": str(e)}, indent=2))
+        sys.exit(1)
+
+    click.echo(json.dumps(report.to_dict(), indent=2))
+    if not report.ok:
+        sys.exit(1)
+
+
+def _verify_consistency(
+    config_manager,
+    collection: Optional[str],
+    repair: bool,
+    max_issues: int,
+    as_json: bool,
+) -> tuple:
+    """Run the consistency check of 'cidx verify'; returns (ok, JSON data)."""
+    from .indexing.file_finder import FileFinder
+    from .services.index_consistency import IndexConsistencyChecker
+    from .services.indexing_lock import IndexingLockError, create_indexing_lock
+    from .utils.git_runner import get_current_branch
+
+    config = config_manager.get_config()
+    project_root = Path(config.codebase_dir)
+
+    # Repair re-indexes files and rewrites indexes - keep indexing out
+    indexing_lock = None
+    if repair:
+        indexing_lock = create_indexing_lock(project_root / ".code-indexer")
+        try:
+            indexing_lock.acquire(str(project_root))
+        except IndexingLockError as e:
+            console.print(f"❌ {e}", style="red")
+            sys.exit(1)
+
+    repair_result = None
+    try:
+        backend = BackendFactory.create(config, project_root)
+        vector_store = backend.get_vector_store_client()
+        embedding_provider = EmbeddingProviderFactory.create(config, console)
+        content_collection = vector_store.resolve_collection_name(
+            config, embedding_provider
+        )
+        tracked_files = [
+            str(path.relative_to(project_root)) if path.is_absolute() else str(path)
+            for path in FileFinder(config).find_files()
+        ]
+        branch = get_current_branch(project_root) or "master"
+        checker = IndexConsistencyChecker(
+            vector_store, tracked_files, branch, project_root
+        )
+
+        collections = [collection] if collection else vector_store.list_collections()
+
+        def check_all():
+            results = []
+            for coll_name in collections:
+                if not as_json:
+                    console.print(f"🔍 Checking consistency of {coll_name}...")
+                results.append(
+                    checker.check_collection(
+                        coll_name, check_project=coll_name == content_collection
+                    )
+                )
+            return results
+
+        results = check_all()
+        if repair and any(not r.ok for r in results):
+            from .services.smart_indexer import SmartIndexer
+
+            metadata_path = config_manager.config_path.parent / "metadata.json"
+            smart_indexer = SmartIndexer(
+                config, embedding_provider, vector_store, metadata_path
+            )
+            if not as_json:
+                console.print("🔧 Repairing...")
+            repair_result = checker.repair(
+                results,
+                reindex_files=lambda paths: smart_indexer.process_files_incrementally(
+                    paths, quiet=True
+                ),
+                remove_file=lambda path: smart_indexer.delete_file_branch_aware(
+                    path, content_collection
+                ),
+            )
+            results = check_all()
+    except Exception as e:
+        console.print(f"❌ Consistency check failed: {e}", style="red")
+        sys.exit(1)
+    finally:
+        if indexing_lock is not None:
+            indexing_lock.release()
+
+    ok = all(r.ok for r in results) and not (repair_result and repair_result.errors)
+    data = {
+        "branch": branch,
+        "tracked_files": len(tracked_files),
+        "collections": [r.to_dict() for r in results],
+        "repair": repair_result.to_dict() if repair_result else None,
+        "ok": ok,
+    }
+    if as_json:
+        return ok, data
+
+    if not results:
+        console.print("ℹ️  No collections found", style="blue")
+    if repair_result is not None:
+        console.print(
+            f"🔧 Rebuilt indexes of {len(repair_result.indexes_rebuilt)} "
+            f"collections, removed {repair_result.files_removed} orphaned files, "
+            f"re-indexed {repair_result.files_reindexed} files"
+        )
+        for error in repair_result.errors:
+            console.print(f"  • {error}", style="red", markup=False)
+    for result in results:
+        files = (
+            f", {result.files_indexed}/{len(tracked_files)} tracked files indexed "
+            f"on '{branch}'"
+            if result.project_checked
+            else ""
+        )
+        summary = f"{result.collection}: {result.points_on_disk} points{files}"
+        if result.ok:
+            console.print(f"✅ {summary}", style="green", markup=False)
+            continue
+        console.print(f"❌ {summary}", style="red", markup=False)
+        for issue in result.issues[:max_issues]:
+            console.print(
+                f"  • [{issue.kind}] {issue.path}: {issue.detail}", markup=False
+            )
+        if len(result.issues) > max_issues:
+            console.print(
+                f"  ... and {len(result.issues) - max_issues} more issues",
+                style="dim",
+            )
+    if not ok and not repair:
+        console.print(
+            "💡 Run 'cidx verify --repair' to fix consistency issues",
+            style="yellow",
+        )
+    return ok, data
+
+
+def _verify_integrity(
+    config_manager, collection: Optional[str], max_issues: int, as_json: bool
+) -> tuple:
+    """Run the integrity check of 'cidx verify'; returns (ok, JSON data)."""
+    from .services.chunk_integrity import ChunkIntegrityVerifier
+
+    config = config_manager.get_config()
+
+    try:
+        backend = BackendFactory.create(config, config.codebase_dir)
+        vector_store = backend.get_vector_store_client()
+        verifier = ChunkIntegrityVerifier(vector_store, Path(config.codebase_dir))
+
+        collections = [collection] if collection else vector_store.list_collections()
+        reports = []
+        for coll_name in collections:
+            if not as_json:
+                console.print(f"🔍 Verifying {coll_name}...")
+            reports.append(verifier.verify_collection(coll_name))
+    except Exception as e:
+        console.print(f"❌ Integrity verification failed: {e}", style="red")
+        sys.exit(1)
+
+    ok = all(r.ok for r in reports)
+    data = [r.to_dict() for r in reports]
+    if as_json:
+        return ok, data
+
+    if not reports:
+        console.print("ℹ️  No collections found", style="blue")
+    for report in reports:
+        skipped = (
+            f", {report.chunks_skipped} non-content points skipped"
+            if report.chunks_skipped
+            else ""
+        )
+        summary = (
+            f"{report.collection}: {report.chunks_verified}/"
+            f"{report.chunks_checked} chunks verified{skipped}"
+        )
+        if report.ok:
+            console.print(f"✅ {summary}", style="green")
+            continue
+        console.print(f"❌ {summary}", style="red", markup=False)
+        for issue in report.issues[:max_issues]:
+            console.print(
+                f"  • [{issue.kind}] {issue.path} ({issue.point_id}): "
+                f"{issue.detail}",
+                markup=False,
+            )
+        if len(report.issues) > max_issues:
+            console.print(
+                f"  ... and {len(report.issues) - max_issues} more issues",
+                style="dim",
+            )
+    if not ok:
+        console.print(
+            "💡 Run 'cidx index --clear' to rebuild the affected index",
+            style="yellow",
+        )
+    return ok, data
+
+
+@cli.command("purge")
+@click.option(
+    "--older-than",
+    help="Purge points older than this (e.g. 90d, 12w, 36h)",
+)
+@click.option(
+    "--branches",
+    type=click.Choice(["merged", "deleted"]),
+    default=None,
+    help="Purge content indexed on branches merged into HEAD or deleted",
+)
+@click.option("--collection", help="Collection to purge (default: all collections)")
+@click.option("--dry-run", is_flag=True, help="Report what would be purged")
+@click.option("--json", "as_json", is_flag=True, help="Output the report as JSON")
+@click.pass_context
+@require_mode("local")
+def purge(
+    ctx,
+    older_than: Optional[str],
+    branches: Optional[str],
+    collection: Optional[str],
+    dry_run: bool,
+    as_json: bool,
+):
+    """Delete indexed points past a retention window.
+
+    \b
+    A point is purged when it matches every given criterion:
+      --older-than  git history by commit date, content by indexing date
+      --branches    content indexed on branches merged into the current
+                    branch (merged) or no longer present (deleted)
+
+    \b
+    Content visible on the current branch is never purged, and purged git
+    history is not re-indexed by later 'cidx index --index-commits' runs.
+
+    \b
+    EXAMPLES:
+      cidx purge --older-than 90d --branches merged --dry-run
+      cidx purge --older-than 90d              # Old history and stale content
+      cidx purge --branches deleted --json     # Machine-readable report
+    """
+    from .services.index_purge import IndexPurger, PurgeCriteria, parse_duration
+    from .services.indexing_lock import IndexingLockError, create_indexing_lock
+
+    try:
+        criteria = PurgeCriteria(
+            older_than_seconds=parse_duration(older_than) if older_than else None,
+            branches=branches,
+        )
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red")
+        sys.exit(1)
+
+    config_manager = ctx.obj["config_manager"]
+    config = config_manager.get_config()
+
+    # Purging rewrites the HNSW and ID indexes - keep indexing out while it runs
+    indexing_lock = None
+    if not dry_run:
+        indexing_lock = create_indexing_lock(config.codebase_dir / ".code-indexer")
+        try:
+            indexing_lock.acquire(str(config.codebase_dir))
+        except IndexingLockError as e:
+            console.print(f"❌ {e}", style="red")
+            sys.exit(1)
+
+    try:
+        backend = BackendFactory.create(config, config.codebase_dir)
+        vector_store = backend.get_vector_store_client()
+        purger = IndexPurger(vector_store, Path(config.codebase_dir))
+        report = purger.purge(
+            criteria,
+            collections=[collection] if collection else None,
+            dry_run=dry_run,
+        )
+    except Exception as e:
+        console.print(f"❌ Purge failed: {e}", style="red")
+        sys.exit(1)
+    finally:
+        if indexing_lock is not None:
+            indexing_lock.release()
+
+    if as_json:
+        click.echo(json.dumps(report.to_dict(), indent=2))
+        return
+
+    if not report.collections:
+        console.print("ℹ️  No collections found", style="blue")
+        return
+
+    title = "Purge report (dry run)" if dry_run else "Purge report"
+    table = Table(title=title)
+    table.add_column("Collection", style="cyan")
+    table.add_column("Scanned", justify="right")
+    table.add_column("Purged", justify="right", style="yellow")
+    table.add_column("Files", justify="right")
+    table.add_column("Branches", style="dim")
+    for coll_report in report.collections:
+        table.add_row(
+            coll_report.collection,
+            str(coll_report.points_scanned),
+            str(coll_report.points_purged),
+            str(coll_report.files_affected),
+            ", ".join(
+                f"{branch or '-'} ({count})"
+                for branch, count in sorted(coll_report.by_branch.items())
+            ),
+        )
+    console.print(table)
+
+    if dry_run:
+        console.print(
+            f"ℹ️  {report.points_purged} points would be purged - "
+            "run without --dry-run to delete them",
+            style="blue",
+        )
+    else:
+        console.print(f"✅ Purged {report.points_purged} points", style="green")
+
+
+@cli.group("coverage")
+@click.pass_context
+def coverage_group(ctx):
+    """Map test coverage onto the local index.
+
+    Imported coverage reports annotate query results with the tests that
+    cover them, enable 'cidx query --uncovered' and '--covered-by', and
+    answer which tests exercise a file or line range.
+    """
+    pass
+
+
+def _load_coverage_map(ctx):
+    """Coverage map of the current project."""
+    from .services.coverage_mapping import CoverageMap
+
+    project_root = Path(ctx.obj["config_manager"].get_config().codebase_dir)
+    return CoverageMap(project_root, project_root / ".code-indexer")
+
+
+@coverage_group.command("import")
+@click.argument(
+    "reports", nargs=-1, required=True, type=click.Path(exists=True, dir_okay=False)
+)
+@click.option(
+    "--format",
+    "report_format",
+    type=click.Choice(["auto", "go", "cobertura", "lcov"]),
+    default="auto",
+    help="Report format (default: detected from content)",
+)
+@click.option(
+    "--test",
+    "test_name",
+    help="Test the reports belong to (lcov: overrides TN records)",
+)
+@click.option(
+    "--replace", is_flag=True, help="Discard previously imported coverage first"
+)
+@click.pass_context
+@require_mode("local")
+def coverage_import(
+    ctx, reports, report_format: str, test_name: Optional[str], replace: bool
+):
+    """Import coverage reports.
+
+    \b
+    Supported formats:
+      go         go test -coverprofile
+      cobertura  coverage.xml (coverage.py, Istanbul, gcovr, JaCoCo converters)
+      lcov       lcov tracefiles (TN: records name the tests)
+
+    \b
+    Reports are merged with earlier imports. For per-test mapping, import
+    one report per test with --test, or an lcov file with TN: records.
+
+    \b
+    EXAMPLES:
+      cidx coverage import coverage.xml --replace
+      go test -run '^TestCharge$' -coverprofile=c.out ./payments
+      cidx coverage import c.out --test TestCharge
+    """
+    coverage_map = _load_coverage_map(ctx)
+    if replace:
+        coverage_map.clear()
+
+    for report in reports:
+        try:
+            summary = coverage_map.import_report(
+                Path(report),
+                report_format=None if report_format == "auto" else report_format,
+                test_name=test_name,
+            )
+        except (OSError, ValueError) as e:
+            console.print(f"❌ {e}", style="red")
+            sys.exit(1)
+        console.print(
+            f"✅ {report}: {summary['format']}, {summary['files']} files"
+            + (f", {len(summary['tests'])} tests" if summary["tests"] else ""),
+            style="green",
+        )
+        if summary["skipped_files"]:
+            console.print(
+                f"⚠️ Skipped {summary['skipped_files']} files outside the project",
+                style="yellow",
+            )
+    coverage_map.save()
+
+
+@coverage_group.command("status")
+@click.option("--json", "as_json", is_flag=True, help="Output status as JSON")
+@click.pass_context
+@require_mode("local")
+def coverage_status(ctx, as_json: bool):
+    """Show imported coverage reports and totals."""
+    coverage_map = _load_coverage_map(ctx)
+    files = coverage_map.files
+    coverable = sum(len(c.coverable) for c in files.values())
+    covered = sum(len(c.covered) for c in files.values())
+    tests = sorted({name for c in files.values() for name in c.tests})
+    status = {
+        "reports": coverage_map.reports,
+        "files": len(files),
+        "coverable_lines": coverable,
+        "covered_lines": covered,
+        "tests": len(tests),
+    }
+
+    if as_json:
+        click.echo(json.dumps(status, indent=2))
+        return
+    if not coverage_map.has_data:
+        console.print(
+            "ℹ️  No coverage imported - run 'cidx coverage import'", style="blue"
+        )
+        return
+
+    percent = 100.0 * covered / coverable if coverable else 0.0
+    console.print(
+        f"🧪 {len(files)} files, {covered}/{coverable} lines covered "
+        f"({percent:.1f}%), {len(tests)} named tests"
+    )
+    for report in coverage_map.reports:
+        console.print(
+            f"  {report['report']} ({report['format']}, {report['files']} files, "
+            f"imported {report['imported_at']})",
+            style="dim",
+            markup=False,
+        )
+
+
+@coverage_group.command("tests-for")
+@click.argument("target")
+@click.option("--json", "as_json", is_flag=True, help="Output tests as JSON")
+@click.pass_context
+@require_mode("local")
+def coverage_tests_for(ctx, target: str, as_json: bool):
+    """List the tests covering a file or line range.
+
+    TARGET is a project-relative PATH, PATH:LINE or PATH:START-END.
+
+    \b
+    EXAMPLES:
+      cidx coverage tests-for payments/charge.go
+      cidx coverage tests-for payments/charge.go:40-75
+    """
+    import re
+
+    match = re.match(r"^(.*?)(?::(\d+)(?:-(\d+))?)?$", target)
+    path, start, end = match.groups() if match else (target, None, None)
+    line_start = int(start) if start else None
+    line_end = int(end) if end else line_start
+
+    coverage = _load_coverage_map(ctx).range_coverage(
+        Path(path).as_posix(), line_start, line_end
+    )
+    if coverage is None:
+        console.print(f"❌ No coverage imported for {path}", style="red")
+        sys.exit(1)
+
+    if as_json:
+        click.echo(
+            json.dumps(
+                {
+                    "path": path,
+                    "line_start": line_start,
+                    "line_end": line_end,
+                    "coverable_lines": coverage.coverable,
+                    "covered_lines": coverage.covered,
+                    "tests": coverage.tests,
+                },
+                indent=2,
+            )
+        )
+        return
+
+    console.print(
+        f"🧪 {target}: {coverage.covered}/{coverage.coverable} lines covered"
+    )
+    if coverage.tests:
+        for name in coverage.tests:
+            console.print(f"  {name}", markup=False)
+    elif coverage.covered:
+        console.print("  (covered by tests without recorded names)", style="dim")
+
+
+@coverage_group.command("clear")
+@click.pass_context
+@require_mode("local")
+def coverage_clear(ctx):
+    """Discard all imported coverage."""
+    coverage_map = _load_coverage_map(ctx)
+    coverage_map.clear()
+    coverage_map.save()
+    console.print("✅ Coverage data cleared", style="green")
+
+
+@cli.group("workspace")
+@click.pass_context
+def workspace_group(ctx):
+    """Register local repositories to search together.
+
+    'cidx query --repos backend,frontend' searches the workspace
+    repositories' own indexes in parallel, merges the results into one
+    ranking and labels each hit with its repository. The workspace is per
+    user and works from any directory.
+    """
+    pass
+
+
+@workspace_group.command("add")
+@click.argument(
+    "path", type=click.Path(exists=True, file_okay=False), default=".", required=False
+)
+@click.option(
+    "--alias", help="Name to query the repository by (default: directory name)"
+)
+def workspace_add(path: str, alias: Optional[str]):
+    """Add an indexed repository to the workspace.
+
+    \b
+    EXAMPLES:
+      cidx workspace add ../backend
+      cidx workspace add ~/src/web-frontend --alias frontend
+      cidx query "session expiry" --repos backend,frontend
+    """
+    from .services.workspace import WorkspaceRegistry
+
+    try:
+        added = WorkspaceRegistry().add(Path(path), alias)
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red", markup=False)
+        sys.exit(1)
+    console.print(f"✅ Added {added}", style="green", markup=False)
+
+
+@workspace_group.command("remove")
+@click.argument("alias")
+def workspace_remove(alias: str):
+    """Remove a repository from the workspace (its index is kept)."""
+    from .services.workspace import WorkspaceRegistry
+
+    if not WorkspaceRegistry().remove(alias):
+        console.print(f"❌ Not in the workspace: {alias}", style="red", markup=False)
+        sys.exit(1)
+    console.print(f"✅ Removed {alias}", style="green", markup=False)
+
+
+@workspace_group.command("list")
+@click.option("--json", "as_json", is_flag=True, help="Output as JSON")
+def workspace_list(as_json: bool):
+    """List the repositories of the workspace."""
+    from rich.table import Table
+    from .services.workspace import WorkspaceRegistry
+
+    repositories = WorkspaceRegistry().repositories
+    if as_json:
+        click.echo(
+            json.dumps(
+                {alias: str(root) for alias, root in repositories.items()}, indent=2
+            )
+        )
+        return
+    if not repositories:
+        console.print("No repositories in the workspace", style="yellow")
+        console.print("To add one: cidx workspace add <path>", markup=False)
+        return
+
+    table = Table(title=f"Workspace ({len(repositories)} repositories)")
+    table.add_column("Alias", style="cyan", no_wrap=True)
+    table.add_column("Path", style="green")
+    table.add_column("Indexed", justify="center")
+    for alias, root in repositories.items():
+        indexed = (root / ".code-indexer" / "config.json").is_file()
+        table.add_row(alias, str(root), "✅" if indexed else "❌ missing")
+    console.print(table)
+
+
+@cli.command("todos")
+@click.option(
+    "--older-than",
+    help="Only task comments at least this old (e.g. 90d, 12w, 1y)",
+)
+@click.option(
+    "--path",
+    "path_filter",
+    help="Only files under this path (pkg/) or matching this glob (*/api/*.go)",
+)
+@click.option(
+    "--marker",
+    "markers",
+    multiple=True,
+    help="Only this marker, e.g. FIXME (repeatable; default: all)",
+)
+@click.option("--author", help="Only comments whose author name or email contains this")
+@click.option(
+    "--limit",
+    type=click.IntRange(1, None),
+    default=None,
+    help="Show at most this many comments (oldest first)",
+)
+@click.option("--json", "as_json", is_flag=True, help="Output comments as JSON")
+@click.pass_context
+@require_mode("local")
+def todos(
+    ctx,
+    older_than: Optional[str],
+    path_filter: Optional[str],
+    markers: tuple,
+    author: Optional[str],
+    limit: Optional[int],
+    as_json: bool,
+):
+    """List TODO/FIXME/HACK comments recorded in the index.
+
+    \b
+    Task comments are extracted while indexing (indexing.task_markers),
+    with author and date from git blame. Uncommitted comments and projects
+    without git have no author and are dated by file modification time.
+    Results come from the index, so re-index (or run watch) to pick up
+    new comments.
+
+    \b
+    EXAMPLES:
+      cidx todos                                 # All, oldest first
+      cidx todos --older-than 1y --path pkg/     # Old tech debt in pkg/
+      cidx todos --marker FIXME --author alice
+      cidx todos --json                          # Machine-readable, for CI
+    """
+    from .services.index_purge import parse_duration
+    from .services.task_markers import find_task_markers
+
+    try:
+        older_than_seconds = parse_duration(older_than) if older_than else None
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red")
+        sys.exit(1)
+
+    config = ctx.obj["config_manager"].get_config()
+    now = time.time()
+    try:
+        backend = BackendFactory.create(config, config.codebase_dir)
+        task_markers = find_task_markers(
+            backend.get_vector_store_client(),
+            Path(config.codebase_dir),
+            older_than_seconds=older_than_seconds,
+            path_filter=path_filter,
+            markers=markers,
+            author=author,
+            now=now,
+        )
+    except Exception as e:
+        console.print(f"❌ Failed to read task comments: {e}", style="red")
+        sys.exit(1)
+
+    total = len(task_markers)
+    if limit is not None:
+        task_markers = task_markers[:limit]
+
+    if as_json:
+        click.echo(
+            json.dumps(
+                {
+                    "total": total,
+                    "todos": [
+                        {**task.to_dict(), "age_seconds": task.age_seconds(now)}
+                        for task in task_markers
+                    ],
+                },
+                indent=2,
+            )
+        )
+        return
+
+    if not task_markers:
+        console.print("ℹ️  No task comments found", style="blue")
+        return
+
+    table = Table(title=f"Task comments ({total})")
+    table.add_column("Age", justify="right", style="yellow")
+    table.add_column("Marker", style="magenta")
+    table.add_column("Location", style="cyan")
+    table.add_column("Author")
+    table.add_column("Comment")
+    for task in task_markers:
+        table.add_row(
+            _format_age(task.age_seconds(now)),
+            task.marker,
+            f"{task.path}:{task.line}",
+            task.author or "-",
+            task.text,
+        )
+    console.print(table)
+    if total > len(task_markers):
+        console.print(
+            f"ℹ️  Showing {len(task_markers)} of {total} - raise --limit for more",
+            style="blue",
+        )
+
+
+def _format_age(age_seconds: Optional[float]) -> str:
+    """Compact age such as 5h, 12d, 8mo or 2y."""
+    if age_seconds is None:
+        return "-"
+    days = age_seconds / 86400
+    if days < 1:
+        return f"{int(age_seconds // 3600)}h"
+    if days < 60:
+        return f"{int(days)}d"
+    if days < 365:
+        return f"{int(days // 30)}mo"
+    return f"{days / 365:.1f}y"
+
+
+@cli.command("deps")
+@click.option(
+    "--module",
+    help="List the packages importing this module, e.g. github.com/gin-gonic/gin",
+)
+@click.option("--json", "as_json", is_flag=True, help="Output as JSON")
+@click.pass_context
+@require_mode("local")
+def deps(ctx, module: Optional[str], as_json: bool):
+    """List Go modules and the packages importing them.
+
+    \b
+    Without --module, lists the modules of the project's go.mod files with
+    their requirements, and the go.work workspaces. With --module, lists
+    the indexed packages importing that module (indexing.go_dependencies);
+    re-index (or run watch) to pick up new imports.
+
+    \b
+    EXAMPLES:
+      cidx deps                                      # Modules and requirements
+      cidx deps --module github.com/gin-gonic/gin    # Who imports gin
+      cidx deps --module github.com/gin-gonic/gin --json
+    """
+    from .services.go_dependencies import GoDependencyGraph, find_importing_packages
+
+    config = ctx.obj["config_manager"].get_config()
+
+    if module is None:
+        graph = GoDependencyGraph(Path(config.codebase_dir))
+        modules = graph.modules()
+        if as_json:
+            click.echo(
+                json.dumps(
+                    {
+                        "modules": [m.to_dict() for m in modules],
+                        "workspaces": graph.workspaces(),
+                    },
+                    indent=2,
+                )
+            )
+            return
+        if not modules:
+            console.print("ℹ️  No go.mod files found", style="blue")
+            return
+        for directory, uses in graph.workspaces().items():
+            console.print(
+                f"🧩 Workspace {directory or '.'}/go.work: {', '.join(uses)}",
+                style="cyan",
+            )
+        for go_module in modules:
+            table = Table(
+                title=f"{go_module.path} ({go_module.dir or '.'}/go.mod)"
+            )
+            table.add_column("Module", style="cyan")
+            table.add_column("Version", style="yellow")
+            table.add_column("Indirect")
+            table.add_column("Replaced by")
+            for requirement in go_module.requires:
+                table.add_row(
+                    requirement.module,
+                    requirement.version,
+                    "yes" if requirement.indirect else "",
+                    requirement.replaced_by or "",
+                )
+            console.print(table)
+        return
+
+    try:
+        backend = BackendFactory.create(config, config.codebase_dir)
+        packages = find_importing_packages(
+            backend.get_vector_store_client(), Path(config.codebase_dir), module
+        )
+    except Exception as e:
+        console.print(f"❌ Failed to read Go imports: {e}", style="red")
+        sys.exit(1)
+
+    if as_json:
+        click.echo(
+            json.dumps(
+                {"module": module, "packages": [p.to_dict() for p in packages]},
+                indent=2,
+            )
+        )
+        return
+
+    if not packages:
+        console.print(f"ℹ️  No indexed package imports {module}", style="blue")
+        return
+
+    table = Table(title=f"Packages importing {module} ({len(packages)})")
+    table.add_column("Package", style="cyan")
+    table.add_column("Files", justify="right", style="yellow")
+    table.add_column("Imports")
+    for package in packages:
+        table.add_row(
+            package.package, str(len(package.files)), ", ".join(package.imports)
+        )
+    console.print(table)
+
+
+@cli.command("imports")
+@click.option(
+    "--of",
+    "of",
+    required=True,
+    help="Project file or directory to trace, e.g. pkg/service",
+)
+@click.option(
+    "--reverse",
+    is_flag=True,
+    help="List the files importing it, directly or indirectly",
+)
+@click.option(
+    "--depth",
+    type=click.IntRange(min=1),
+    help="Import hops to follow (default: all)",
+)
+@click.option("--json", "as_json", is_flag=True, help="Output as JSON")
+@click.pass_context
+@require_mode("local")
+def imports(ctx, of: str, reverse: bool, depth: Optional[int], as_json: bool):
+    """Trace the import graph of the indexed files.
+
+    \b
+    Lists the project files and package directories the files under --of
+    import, following their imports in turn. With --reverse, lists the
+    files importing them instead: what would be affected by a change.
+    Imports are recorded when files are indexed (indexing.import_graph);
+    re-index (or run watch) to pick up new imports.
+
+    \b
+    EXAMPLES:
+      cidx imports --of pkg/service                # What it depends on
+      cidx imports --of pkg/service --reverse      # What depends on it
+      cidx imports --of src/app/cart.py --reverse --depth 1
+    """
+    from .services.import_graph import load_imports, trace_imports
+
+    config = ctx.obj["config_manager"].get_config()
+
+    try:
+        backend = BackendFactory.create(config, config.codebase_dir)
+        files = load_imports(
+            backend.get_vector_store_client(), Path(config.codebase_dir)
+        )
+    except Exception as e:
+        console.print(f"❌ Failed to read imports: {e}", style="red")
+        sys.exit(1)
+
+    trace = trace_imports(files, of, reverse=reverse, max_depth=depth)
+
+    if as_json:
+        click.echo(json.dumps(trace.to_dict(), indent=2))
+        return
+
+    if not trace.files:
+        console.print(f"ℹ️  No indexed file under {trace.of}", style="blue")
+        return
+    if not trace.edges:
+        relation = "imports" if reverse else "is imported by"
+        console.print(f"ℹ️  No indexed file {relation} {trace.of}", style="blue")
+        return
+
+    title = "Files importing" if reverse else "Paths imported by"
+    table = Table(title=f"{title} {trace.of} ({len(trace.edges)})")
+    table.add_column("Path", style="cyan")
+    table.add_column("Depth", justify="right", style="yellow")
+    table.add_column("Via")
+    for edge in trace.edges:
+        table.add_row(edge.path, str(edge.depth), ", ".join(edge.via))
+    console.print(table)
+
+
+@cli.command("symbol")
+@click.argument("name")
+@click.option("--prefix", is_flag=True, help="Also match names starting with NAME")
+@click.option(
+    "--kind",
+    "kinds",
+    multiple=True,
+    help="Only declarations of this kind, e.g. class, function, method (repeatable)",
+)
+@click.option(
+    "--ignore-case", "-i", is_flag=True, help="Match names case-insensitively"
+)
+@click.option(
+    "--limit",
+    "-l",
+    type=click.IntRange(min=1),
+    default=20,
+    show_default=True,
+    help="Maximum definitions to show",
+)
+@click.option("--json", "as_json", is_flag=True, help="Output as JSON")
+@click.pass_context
+@require_mode("local")
+def symbol(
+    ctx,
+    name: str,
+    prefix: bool,
+    kinds: tuple,
+    ignore_case: bool,
+    limit: int,
+    as_json: bool,
+):
+    """Find where a function, method or type is defined.
+
+    \b
+    Looks NAME up in the declarations recorded for the indexed chunks,
+    without embedding a query: exact matches first, then (with --prefix)
+    longer names, with types ranked before functions and methods.
+    Declarations are recorded when files are indexed
+    (indexing.symbol_declarations); re-index (or run watch) to pick up new
+    definitions.
+
+    \b
+    EXAMPLES:
+      cidx symbol UserService                   # Go to the definition
+      cidx symbol parse --prefix --kind function
+      cidx symbol handlerequest -i --json
+    """
+    from .services.symbol_declarations import find_symbols
+
+    config = ctx.obj["config_manager"].get_config()
+
+    try:
+        backend = BackendFactory.create(config, config.codebase_dir)
+        definitions = find_symbols(
+            backend.get_vector_store_client(),
+            Path(config.codebase_dir),
+            name,
+            prefix=prefix,
+            ignore_case=ignore_case,
+            kinds=kinds,
+        )
+    except Exception as e:
+        console.print(f"❌ Failed to read symbols: {e}", style="red")
+        sys.exit(1)
+
+    shown = definitions[:limit]
+
+    if as_json:
+        click.echo(
+            json.dumps(
+                {
+                    "name": name,
+                    "total": len(definitions),
+                    "definitions": [d.to_dict() for d in shown],
+                },
+                indent=2,
+            )
+        )
+        return
+
+    if not definitions:
+        console.print(f"ℹ️  No indexed definition of {name}", style="blue")
+        return
+
+    table = Table(title=f"Definitions of {name} ({len(definitions)})")
+    table.add_column("Kind", style="yellow")
+    table.add_column("Name", style="cyan")
+    table.add_column("Location")
+    for definition in shown:
+        table.add_row(
+            definition.kind, definition.name, f"{definition.path}:{definition.line}"
+        )
+    console.print(table)
+    if len(definitions) > limit:
+        console.print(
+            f"ℹ️  Showing {limit} of {len(definitions)}; raise --limit for more",
+            style="blue",
+        )
+
+
+@cli.command("similar")
+@click.argument("location", required=False)
+@click.option(
+    "--symbol",
+    "symbol_name",
+    help="Start from the declaration of this symbol, e.g. Foo.Bar",
+)
+@click.option(
+    "--limit",
+    "-l",
+    type=click.IntRange(min=1),
+    default=10,
+    show_default=True,
+    help="Number of results to return",
+)
+@click.option(
+    "--language",
+    "languages",
+    multiple=True,
+    help="Only results in this language (repeatable)",
+)
+@click.option(
+    "--path-filter",
+    "path_filter",
+    multiple=True,
+    help="Only paths matching this pattern (repeatable)",
+)
+@click.option(
+    "--exclude-path",
+    "exclude_paths",
+    multiple=True,
+    help="Leave out paths matching these patterns (comma-separated, repeatable)",
+)
+@click.option(
+    "--include-generated",
+    is_flag=True,
+    help="Also return generated files (left out by default)",
+)
+@click.option("--min-score", type=float, help="Minimum similarity score (0.0-1.0)")
+@click.option("--quiet", "-q", is_flag=True, help="Minimal output")
+@click.pass_context
+@require_mode("local")
+def similar(
+    ctx,
+    location: Optional[str],
+    symbol_name: Optional[str],
+    limit: int,
+    languages: tuple,
+    path_filter: tuple,
+    exclude_paths: tuple,
+    include_generated: bool,
+    min_score: Optional[float],
+    quiet: bool,
+):
+    """Find code similar to a piece of code ("more like this").
+
+    \b
+    LOCATION is a file with an optional line range (path:start-end): those
+    lines are embedded and the index searched for the most similar chunks,
+    to find copy-paste variants and prior art. With --symbol, the indexed
+    chunk declaring the symbol is the starting point and its stored vector
+    is reused. The referenced lines themselves are not returned.
+
+    \b
+    EXAMPLES:
+      cidx similar path/to/file.go:42-90
+      cidx similar src/retry.py:15 --language python
+      cidx similar --symbol Client.Do --exclude-path vendor/**
+    """
+    from .services.embedding_factory import EmbeddingProviderFactory
+    from .services.exclusion_filters import split_values
+    from .services.generated_code import GENERATED_KEY
+    from .services.generic_query_service import GenericQueryService
+    from .services.language_mapper import LanguageMapper
+    from .services.similar_code import (
+        reference_from_location,
+        reference_from_symbol,
+        search_similar,
+    )
+
+    if bool(location) == bool(symbol_name):
+        console.print("❌ Give either a LOCATION or --symbol", style="red")
+        sys.exit(1)
+
+    config = ctx.obj["config_manager"].get_config()
+    project_root = Path(config.codebase_dir)
+
+    filter_conditions: Dict[str, Any] = {}
+    if languages:
+        language_mapper = LanguageMapper()
+        language_filters = [
+            language_mapper.build_language_filter(lang) for lang in languages
+        ]
+        filter_conditions.setdefault("must", []).append(
+            {"should": language_filters}
+            if len(language_filters) > 1
+            else language_filters[0]
+        )
+    if path_filter:
+        path_filters = [{"key": "path", "match": {"text": pf}} for pf in path_filter]
+        filter_conditions.setdefault("must", []).append(
+            {"should": path_filters} if len(path_filters) > 1 else path_filters[0]
+        )
+    for pattern in split_values(exclude_paths):
+        filter_conditions.setdefault("must_not", []).append(
+            {"key": "path", "match": {"text": pattern}}
+        )
+    if not include_generated:
+        filter_conditions.setdefault("must_not", []).append(
+            {"key": GENERATED_KEY, "match": {"value": True}}
+        )
+
+    try:
+        embedding_provider = EmbeddingProviderFactory.create(config, console)
+        vector_store = BackendFactory.create(
+            config, project_root
+        ).get_vector_store_client()
+        collection_name = vector_store.resolve_collection_name(
+            config, embedding_provider
+        )
+        if symbol_name:
+            reference, matches = reference_from_symbol(
+                vector_store, collection_name, project_root, symbol_name
+            )
+        else:
+            reference, matches = reference_from_location(project_root, location), 1
+        results = search_similar(
+            vector_store,
+            embedding_provider,
+            collection_name,
+            reference,
+            limit,
+            filter_conditions=filter_conditions or None,
+        )
+        results = GenericQueryService(
+            project_root, config
+        ).filter_results_by_current_branch(results)
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red", markup=False)
+        sys.exit(1)
+    except Exception as e:
+        console.print(f"❌ Failed to search similar code: {e}", style="red")
+        sys.exit(1)
+
+    if min_score is not None:
+        results = [r for r in results if r.get("score", 0.0) >= min_score]
+    results = results[:limit]
+
+    if not quiet:
+        console.print(f"🔁 Similar to: {reference.location}")
+        if matches > 1:
+            console.print(
+                f"ℹ️  {matches} declarations match {symbol_name}; "
+                "qualify the name (Type.Name) to pick another",
+                style="blue",
+            )
+    _display_semantic_results(results, console, quiet=quiet)
+
+
+@cli.command("clones")
+@click.option(
+    "--threshold",
+    type=click.FloatRange(0.0, 1.0),
+    default=0.93,
+    show_default=True,
+    help="Minimum similarity of chunks reported as clones",
+)
+@click.option(
+    "--min-lines",
+    type=click.IntRange(min=1),
+    default=5,
+    show_default=True,
+    help="Ignore chunks shorter than this",
+)
+@click.option(
+    "--repos",
+    help="Detect clones across these workspace repositories (comma-separated)",
+)
+@click.option(
+    "--include-generated",
+    is_flag=True,
+    help="Also compare generated files (left out by default)",
+)
+@click.option(
+    "--limit",
+    "-l",
+    type=click.IntRange(min=1),
+    default=20,
+    show_default=True,
+    help="Maximum clusters to show",
+)
+@click.option("--json", "as_json", is_flag=True, help="Output as JSON")
+@click.pass_context
+@require_mode("local")
+def clones(
+    ctx,
+    threshold: float,
+    min_lines: int,
+    repos: Optional[str],
+    include_generated: bool,
+    limit: int,
+    as_json: bool,
+):
+    """Find clusters of near-duplicate code.
+
+    \b
+    Compares the stored vectors of all indexed chunks on the current
+    branch (nothing is embedded) and groups chunks at least --threshold
+    similar into clusters, reporting the lines duplicated in each cluster
+    and in total. With --repos, chunks of workspace repositories
+    ('cidx workspace add') are compared across repositories; they must
+    use the same embedding model.
+
+    \b
+    EXAMPLES:
+      cidx clones                              # Default threshold 0.93
+      cidx clones --threshold 0.97 --min-lines 10
+      cidx clones --repos backend,frontend --json
+    """
+    from .services.clone_detection import (
+        detect_clones,
+        format_cluster,
+        load_repository_chunks,
+        load_workspace_chunks,
+    )
+    from .services.workspace import WorkspaceRegistry
+
+    try:
+        if repos:
+            aliases = [alias.strip() for alias in repos.split(",") if alias.strip()]
+            chunks, vectors = load_workspace_chunks(
+                WorkspaceRegistry().resolve(aliases),
+                min_lines=min_lines,
+                include_generated=include_generated,
+            )
+        else:
+            config = ctx.obj["config_manager"].get_config()
+            chunks, vectors, _ = load_repository_chunks(
+                Path(config.codebase_dir),
+                min_lines=min_lines,
+                include_generated=include_generated,
+            )
+        report = detect_clones(chunks, vectors, threshold)
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red", markup=False)
+        sys.exit(1)
+    except Exception as e:
+        console.print(f"❌ Failed to detect clones: {e}", style="red")
+        sys.exit(1)
+
+    if as_json:
+        click.echo(json.dumps(report.to_dict(), indent=2))
+        return
+
+    if not report.clusters:
+        console.print(
+            f"ℹ️  No clones at similarity {threshold} among "
+            f"{report.chunks_compared} chunks",
+            style="blue",
+        )
+        return
+
+    for number, cluster in enumerate(report.clusters[:limit], start=1):
+        console.print(f"\n🧬 {format_cluster(cluster, number)}", markup=False)
+        for chunk in cluster.chunks:
+            console.print(f"   {chunk.location}", style="cyan", markup=False)
+    console.print(
+        f"\n📊 {len(report.clusters)} clusters, {report.duplicated_lines} "
+        f"duplicated lines among {report.chunks_compared} chunks"
+    )
+    if len(report.clusters) > limit:
+        console.print(
+            f"ℹ️  Showing {limit} of {len(report.clusters)} clusters; "
+            "raise --limit for more",
+            style="blue",
+        )
+
+
+@cli.command("refs")
+@click.argument("symbol_name")
+@click.option(
+    "--limit",
+    "-l",
+    type=click.IntRange(min=1),
+    default=50,
+    show_default=True,
+    help="Maximum references to show",
+)
+@click.option(
+    "--exact",
+    is_flag=True,
+    help="Match the exact SCIP symbol name (no substring matching)",
+)
+@click.option("--json", "as_json", is_flag=True, help="Output as JSON")
+@click.pass_context
+@require_mode("local")
+def refs(ctx, symbol_name: str, limit: int, exact: bool, as_json: bool):
+    """Find the references to a function, method or type.
+
+    \b
+    Uses the precise occurrences of the SCIP indexes when they exist
+    ('cidx scip generate'). Otherwise falls back to the semantic index:
+    the chunks most similar to SYMBOL_NAME are searched for lines using it
+    as a whole identifier, leaving out its declarations. Each reference
+    shows the backend that found it (scip or semantic); semantic matches
+    are by name, so same-named symbols are included.
+
+    \b
+    EXAMPLES:
+      cidx refs UserService
+      cidx refs UserService#authenticate --exact
+      cidx refs parse_config --limit 20 --json
+    """
+    from .services.embedding_factory import EmbeddingProviderFactory
+    from .services.reference_search import (
+        SEMANTIC_BACKEND,
+        scip_references,
+        semantic_references,
+    )
+
+    config = ctx.obj["config_manager"].get_config()
+    project_root = Path(config.codebase_dir)
+
+    try:
+        hits = scip_references(project_root, symbol_name, limit, exact=exact)
+        if hits is None:
+            embedding_provider = EmbeddingProviderFactory.create(config, console)
+            vector_store = BackendFactory.create(
+                config, project_root
+            ).get_vector_store_client()
+            hits = semantic_references(
+                vector_store,
+                embedding_provider,
+                vector_store.resolve_collection_name(config, embedding_provider),
+                project_root,
+                config,
+                symbol_name,
+                limit,
+            )
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red", markup=False)
+        sys.exit(1)
+    except Exception as e:
+        console.print(f"❌ Failed to find references: {e}", style="red")
+        sys.exit(1)
+
+    if as_json:
+        click.echo(
+            json.dumps(
+                {
+                    "symbol": symbol_name,
+                    "total": len(hits),
+                    "references": [hit.to_dict() for hit in hits],
+                },
+                indent=2,
+            )
+        )
+        return
+
+    if not hits:
+        console.print(f"ℹ️  No references to {symbol_name} found", style="blue")
+        return
+
+    table = Table(title=f"References to {symbol_name} ({len(hits)})")
+    table.add_column("Location", style="cyan")
+    table.add_column("Backend", style="yellow")
+    table.add_column("Code")
+    for hit in hits:
+        table.add_row(f"{hit.path}:{hit.line}", hit.backend, hit.context)
+    console.print(table)
+    if any(hit.backend == SEMANTIC_BACKEND for hit in hits):
+        console.print(
+            "ℹ️  No SCIP index: references matched by name in the semantic "
+            "index (run 'cidx scip generate' for precise results)",
+            style="blue",
+        )
+
+
+@cli.command("hierarchy")
+@click.argument("symbol_name")
+@click.option(
+    "--direction",
+    type=click.Choice(["callers", "callees"]),
+    default="callers",
+    show_default=True,
+    help="Who calls the symbol, or what it calls",
+)
+@click.option(
+    "--depth",
+    type=click.IntRange(1, 10),
+    default=3,
+    show_default=True,
+    help="Levels of calls below the symbol",
+)
+@click.option(
+    "--exact",
+    is_flag=True,
+    help="Match the exact SCIP symbol name (no substring matching)",
+)
+@click.option("--json", "as_json", is_flag=True, help="Output the tree as JSON")
+@click.pass_context
+@require_mode("local")
+def hierarchy(
+    ctx, symbol_name: str, direction: str, depth: int, exact: bool, as_json: bool
+):
+    """Show the call hierarchy of a function or method as a tree.
+
+    \b
+    Reads the call graph of the SCIP indexes ('cidx scip generate'): with
+    --direction callers, each level holds the callers of the level above;
+    with callees, the functions it calls. Every node shows where its symbol
+    is defined and the lines of the calls. A symbol calling back into one
+    of its ancestors is marked (recursive) and not expanded again.
+
+    \b
+    EXAMPLES:
+      cidx hierarchy authenticate                        # Who calls it
+      cidx hierarchy UserService#save --direction callees --depth 2
+      cidx hierarchy handle_request --json
+    """
+    from rich.text import Text
+    from rich.tree import Tree
+
+    from .cli_scip import _extract_display_name
+    from .scip.query import SCIPQueryEngine
+
+    config = ctx.obj["config_manager"].get_config()
+    index_files = SCIPQueryEngine.index_files(Path(config.codebase_dir))
+    if not index_files:
+        console.print(
+            "❌ No SCIP index found (run 'cidx scip generate' first)", style="red"
+        )
+        sys.exit(1)
+
+    roots = []
+    for index_file in index_files:
+        try:
+            roots.extend(
+                SCIPQueryEngine(index_file).call_hierarchy(
+                    symbol_name, direction=direction, depth=depth, exact=exact
+                )
+            )
+        except Exception as e:
+            console.print(
+                f"⚠️  Failed to query {index_file}: {e}",
+                style="yellow",
+                markup=False,
+            )
+
+    if as_json:
+        click.echo(
+            json.dumps(
+                {
+                    "symbol": symbol_name,
+                    "direction": direction,
+                    "depth": depth,
+                    "roots": [root.to_dict() for root in roots],
+                },
+                indent=2,
+            )
+        )
+        return
+
+    if not roots:
+        console.print(f"ℹ️  No SCIP definition of {symbol_name}", style="blue")
+        return
+
+    def label(node) -> Text:
+        text = _extract_display_name(node.symbol)
+        if node.file_path:
+            text += f"  {node.file_path}:{node.line}"
+        if node.call_lines:
+            lines = ", ".join(f"L{line}" for line in node.call_lines)
+            text += f"  (calls at {lines})"
+        if node.recursive:
+            text += "  (recursive)"
+        if node.truncated:
+            text += "  (truncated)"
+        return Text(text)
+
+    def add_children(tree, node) -> None:
+        for child in node.children:
+            add_children(tree.add(label(child)), child)
+
+    title = "Callers of" if direction == "callers" else "Calls from"
+    for root in roots:
+        tree = Tree(Text(f"{title} ") + label(root))
+        add_children(tree, root)
+        if not root.children:
+            tree.add("(none)", style="dim")
+        console.print(tree)
+
+
+@cli.command("feedback")
+@click.argument("result_id", required=False)
+@click.option(
+    "--relevant/--irrelevant",
+    default=None,
+    help="Judge the result as useful or not useful",
+)
+@click.option("--query", "query_text", help="Query that returned the result")
+@click.option("--list", "list_feedback", is_flag=True, help="Show recorded boosts")
+@click.option("--clear", is_flag=True, help="Delete all recorded judgments")
+@click.pass_context
+@require_mode("local")
+def feedback(
+    ctx,
+    result_id: Optional[str],
+    relevant: Optional[bool],
+    query_text: Optional[str],
+    list_feedback: bool,
+    clear: bool,
+):
+    """Record whether a query result was useful, to improve later rankings.
+
+    \b
+    RESULT_ID is the ID shown next to each 'cidx query' result. Judgments
+    are stored in .code-indexer/feedback.json; each one nudges later
+    scores of the same file, the files of its directory and the symbols
+    defined in the result, by up to 0.1 in total per result.
+
+    \b
+    EXAMPLES:
+      cidx feedback 3f9a2c1e --relevant
+      cidx feedback 77b0d4aa --irrelevant --query "retry policy"
+      cidx feedback --list                 # Strongest boosts and penalties
+      cidx feedback --clear
+    """
+    import getpass
+
+    from .services.relevance_feedback import (
+        FeedbackStore,
+        chunk_text,
+        resolve_result_id,
+    )
+
+    config = ctx.obj["config_manager"].get_config()
+    project_root = Path(config.codebase_dir)
+    store = FeedbackStore.for_project(project_root / ".code-indexer")
+
+    if clear:
+        count = store.clear()
+        console.print(f"✅ Deleted {count} judgments", style="green")
+        return
+
+    if list_feedback:
+        adjustments = store.top_adjustments(limit=20)
+        if not adjustments:
+            console.print("ℹ️  No feedback recorded", style="blue")
+            return
+        table = Table(title=f"Relevance feedback ({len(store.judgments)} judgments)")
+        table.add_column("Votes", justify="right")
+        table.add_column("Kind", style="magenta")
+        table.add_column("Name", style="cyan")
+        for entry in adjustments:
+            table.add_row(f"{entry['votes']:+d}", entry["kind"], entry["name"])
+        console.print(table)
+        return
+
+    if not result_id or relevant is None:
+        console.print(
+            "❌ Usage: cidx feedback RESULT_ID --relevant|--irrelevant",
+            style="red",
+        )
+        sys.exit(1)
+
+    try:
+        backend = BackendFactory.create(config, config.codebase_dir)
+        point = resolve_result_id(backend.get_vector_store_client(), result_id)
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red")
+        sys.exit(1)
+    except Exception as e:
+        console.print(f"❌ Failed to look up result: {e}", style="red")
+        sys.exit(1)
+    if point is None:
+        console.print(
+            f"❌ No indexed result with ID '{result_id}' - "
+            "IDs change when files are re-indexed",
+            style="red",
+        )
+        sys.exit(1)
+
+    payload = point.get("payload", {})
+    judgment = store.record(
+        path=str(payload.get("path", "")),
+        relevant=relevant,
+        text=chunk_text(point, project_root),
+        query=query_text,
+        user=getpass.getuser(),
+        result_key=str(point["id"]),
+    )
+    verdict = "relevant" if relevant else "irrelevant"
+    console.print(f"✅ Recorded {judgment.path} as {verdict}", style="green")
+    if judgment.symbols:
+        console.print(f"   Symbols: {', '.join(judgment.symbols)}", style="dim")
+
+
+@cli.command("generate-queries")
+@click.option(
+    "--output",
+    "-o",
+    type=click.Path(dir_okay=False, path_type=Path),
+    help="JSONL file to write (default: stdout)",
+)
+@click.option(
+    "--llm",
+    "use_llm",
+    is_flag=True,
+    help="Ask the configured LLM command instead of using templates",
+)
+@click.option(
+    "--llm-command",
+    help="LLM command for this run, e.g. 'claude --print' (implies --llm)",
+)
+@click.option(
+    "--per-chunk",
+    type=click.IntRange(1, 20),
+    default=None,
+    help="Queries per chunk (default: query_generation.queries_per_chunk)",
+)
+@click.option(
+    "--sample",
+    type=click.IntRange(1, None),
+    default=None,
+    help="Only this many randomly chosen chunks (default: all)",
+)
+@click.option("--seed", type=int, default=None, help="Sample seed (default: 42)")
+@click.option(
+    "--path",
+    "path_filter",
+    help="Only files under this path (pkg/) or matching this glob (*/api/*.go)",
+)
+@click.pass_context
+@require_mode("local")
+def generate_queries(
+    ctx,
+    output: Optional[Path],
+    use_llm: bool,
+    llm_command: Optional[str],
+    per_chunk: Optional[int],
+    sample: Optional[int],
+    seed: Optional[int],
+    path_filter: Optional[str],
+):
+    """Generate candidate queries for an evaluation golden set.
+
+    \b
+    Writes one JSON line per query, labeled with the indexed chunks that
+    should answer it:
+      {"query": "...", "expected": [{"path", "line_start", "line_end"}],
+       "source": "template" | "llm"}
+
+    \b
+    Templates turn doc comments and defined symbol names into queries.
+    With --llm, each chunk is sent to query_generation.llm_command, which
+    reads a prompt on stdin and prints one query per line. Review the
+    output before using it as ground truth.
+
+    \b
+    EXAMPLES:
+      cidx generate-queries -o eval.jsonl
+      cidx generate-queries --sample 200 --path src/ -o eval.jsonl
+      cidx generate-queries --llm-command "claude --print" --sample 100
+    """
+    from .services.query_generation import (
+        DEFAULT_SAMPLE_SEED,
+        generate_queries as run_generation,
+        generator_from_config,
+        indexed_chunks,
+    )
+
+    config = ctx.obj["config_manager"].get_config()
+    project_root = Path(config.codebase_dir)
+    try:
+        generator = generator_from_config(
+            config, use_llm or bool(llm_command), llm_command
+        )
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red")
+        sys.exit(1)
+
+    try:
+        backend = BackendFactory.create(config, config.codebase_dir)
+        chunks = indexed_chunks(
+            backend.get_vector_store_client(), project_root, path_filter
+        )
+        queries = run_generation(
+            chunks,
+            project_root,
+            generator,
+            queries_per_chunk=per_chunk or config.query_generation.queries_per_chunk,
+            sample=sample,
+            seed=DEFAULT_SAMPLE_SEED if seed is None else seed,
+        )
+    except Exception as e:
+        console.print(f"❌ Query generation failed: {e}", style="red")
+        sys.exit(1)
+
+    lines = "".join(json.dumps(query.to_dict()) + "\n" for query in queries)
+    if output is None:
+        click.echo(lines, nl=False)
+        return
+    output.write_text(lines)
+    console.print(
+        f"✅ Wrote {len(queries)} {generator.source} queries for "
+        f"{min(sample or len(chunks), len(chunks))} chunks to {output}",
+        style="green",
+    )
+
+
+@cli.command("report-bug")
+@click.option(
+    "--output",
+    "-o",
+    type=click.Path(path_type=Path),
+    help="Bundle file or directory (default: .code-indexer/crash-reports/)",
+)
+@click.pass_context
+def report_bug(ctx, output: Optional[Path]):
+    """Write a diagnostic bundle to attach to a bug report.
+
+    \b
+    The bundle is a zip file with:
+      • Project configuration, with credentials redacted
+      • Tails of recent error, daemon and JSON logs
+      • Versions of cidx, Python, git and key dependencies
+      • Environment summary (platform, memory, names of set variables)
+      • Last operation state (indexing progress, watch metadata)
+
+    \b
+    A bundle is also written automatically when cidx fails with an
+    unexpected error. Review the bundle before sharing it.
+
+    \b
+    EXAMPLES:
+      cidx report-bug
+      cidx report-bug -o ~/cidx-report.zip
+    """
+    from .utils.crash_report import (
+        BUG_REPORT_URL,
+        EncryptedProjectError,
+        write_bundle,
+    )
+
+    project_root = ctx.obj.get("project_root")
+    try:
+        bundle_path = write_bundle(project_root, output=output)
+    except EncryptedProjectError as e:
+        console.print(f"❌ {e}", style="red")
+        sys.exit(1)
+    except OSError as e:
+        console.print(f"❌ Failed to write diagnostic bundle: {e}", style="red")
+        sys.exit(1)
+
+    console.print(f"📦 Diagnostic bundle written to {bundle_path}", markup=False)
+    console.print(
+        f"💡 Review it, then attach it to an issue at {BUG_REPORT_URL}", style="dim"
+    )
+
+
+@cli.group("telemetry")
+def telemetry_group():
+    """Opt-in anonymous usage telemetry.
+
+    \b
+    Off unless enabled. When enabled, each cidx run records its command
+    name, duration, error category (exception type or exit code), cidx and
+    Python versions, OS and day in ~/.code-indexer/telemetry/ - never
+    arguments, queries, code, paths or names. Events stay local unless an
+    upload endpoint is set; 'cidx telemetry show' prints all of it.
+    """
+    pass
 
-        if not collections:
-            console.print("ℹ️  No collections found", style="blue")
-            return
 
-        # Create table
-        table = Table(title="Collections")
-        table.add_column("Name", style="cyan")
-        table.add_column("Vectors", justify="right", style="green")
-        table.add_column("Dimensions", justify="right", style="yellow")
-        table.add_column("Size", justify="right", style="magenta")
-        table.add_column("Created", style="blue")
+@telemetry_group.command("enable")
+@click.option(
+    "--endpoint",
+    help="HTTPS URL recorded events are uploaded to once a day (default: keep local)",
+)
+def telemetry_enable(endpoint: Optional[str]):
+    """Start recording usage events."""
+    from .utils.telemetry import Telemetry
 
-        # Populate table
-        for coll_name in collections:
-            # Get vector count
-            vector_count = vector_store.count_points(coll_name)
+    if endpoint and not endpoint.startswith(("https://", "http://")):
+        console.print("❌ --endpoint must be an http(s) URL", style="red")
+        sys.exit(1)
+    telemetry = Telemetry()
+    telemetry.enable(endpoint=endpoint)
+    console.print("✅ Telemetry enabled", style="green")
+    if telemetry.endpoint:
+        console.print(
+            f"   Events are uploaded daily to {telemetry.endpoint}",
+            style="dim",
+            markup=False,
+        )
+    else:
+        console.print(
+            "   Events stay on this machine - review them with "
+            "'cidx telemetry show'",
+            style="dim",
+        )
 
-            # Get metadata
-            try:
-                metadata = vector_store.get_collection_info(coll_name)
-                vector_size = metadata.get("vector_size", "N/A")
-                created_at = metadata.get("created_at", "N/A")
-                if created_at != "N/A":
-                    # Format datetime
-                    from datetime import datetime
 
-                    dt = datetime.fromisoformat(created_at)
-                    created_at = dt.strftime("%Y-%m-%d %H:%M")
-            except Exception:
-                vector_size = "N/A"
-                created_at = "N/A"
+@telemetry_group.command("disable")
+def telemetry_disable():
+    """Stop recording and delete unsent events."""
+    from .utils.telemetry import Telemetry
 
-            # Get collection size
-            collection_size = 0
-            if hasattr(vector_store, "get_collection_size"):
-                collection_size = vector_store.get_collection_size(coll_name)
+    deleted = Telemetry().disable()
+    console.print(
+        f"✅ Telemetry disabled, {deleted} unsent events deleted", style="green"
+    )
 
-            size_str = "N/A"
-            if collection_size > 0:
-                size_mb = collection_size / (1024 * 1024)
-                if size_mb >= 1.0:
-                    size_str = f"{size_mb:.2f} MB"
-                else:
-                    size_kb = collection_size / 1024
-                    size_str = f"{size_kb:.2f} KB"
 
-            table.add_row(
-                coll_name, str(vector_count), str(vector_size), size_str, created_at
-            )
+@telemetry_group.command("show")
+@click.option(
+    "--json", "as_json", is_flag=True, help="Print exactly what an upload sends"
+)
+def telemetry_show(as_json: bool):
+    """Show telemetry settings and recorded events."""
+    from .utils.telemetry import Telemetry
 
-        console.print(table)
+    telemetry = Telemetry()
+    if as_json:
+        click.echo(json.dumps(telemetry.upload_payload(), indent=2))
+        return
 
-    except Exception as e:
-        console.print(f"❌ Failed to list collections: {e}", style="red")
-        import traceback
+    state = "enabled" if telemetry.enabled else "disabled"
+    console.print(f"📊 Telemetry {state}")
+    console.print(
+        f"   Upload endpoint: {telemetry.endpoint or 'none (events stay local)'}",
+        markup=False,
+    )
+    console.print(f"   Events file: {telemetry.events_file}", markup=False)
 
-        traceback.print_exc()
-        sys.exit(1)
+    summary = telemetry.summary()
+    if not summary:
+        console.print("ℹ️  No events recorded", style="blue")
+        return
+    table = Table(title=f"Recorded events ({len(telemetry.events())})")
+    table.add_column("Command", style="cyan")
+    table.add_column("Runs", justify="right")
+    table.add_column("Errors", justify="right", style="red")
+    table.add_column("p50", justify="right", style="yellow")
+    table.add_column("Max", justify="right", style="yellow")
+    table.add_column("Error categories")
+    for row in summary:
+        table.add_row(
+            row["command"],
+            str(row["runs"]),
+            str(row["errors"]),
+            f"{row['p50_ms']} ms",
+            f"{row['max_ms']} ms",
+            ", ".join(f"{k} ({v})" for k, v in row["error_categories"].items()),
+        )
+    console.print(table)
 
 
 @cli.command("uninstall")
@@ -8214,6 +11927,11 @@ def install_server(
 @click.option(
     "--timeout", type=int, default=300, help="Job timeout in seconds (default: 300)"
 )
+@click.option(
+    "--delta",
+    is_flag=True,
+    help="Upload changed chunks from the local index instead of re-indexing on the server",
+)
 @click.pass_context
 @require_mode("remote")
 def sync(
@@ -8224,6 +11942,7 @@ def sync(
     no_pull: bool,
     dry_run: bool,
     timeout: int,
+    delta: bool,
 ):
     """Synchronize repositories with the remote CIDX server.
 
@@ -8243,6 +11962,9 @@ def sync(
       • --no-pull         Skip git pull, only index existing files
       • --dry-run         Preview what would be synced without execution
       • --timeout 600     Set job timeout (default: 300 seconds)
+      • --delta           Upload only chunks changed since the server's indexed
+                          commit (needs a local index built with the same
+                          embedding model; falls back to a server-side sync)
 
     \b
     EXAMPLES:
@@ -8252,6 +11974,7 @@ def sync(
       cidx sync --full-reindex           # Force full re-indexing
       cidx sync --no-pull --dry-run      # Preview indexing without git pull
       cidx sync --all --timeout 600     # Sync all with extended timeout
+      cidx sync --delta                  # Upload local index changes
 
     \b
     The sync command submits jobs to the server and tracks their progress.
@@ -8273,6 +11996,13 @@ def sync(
             console.print("❌ Error: Timeout must be a positive number", style="red")
             sys.exit(1)
 
+        if delta and (all or full_reindex):
+            console.print(
+                "❌ Error: --delta cannot be combined with --all or --full-reindex",
+                style="red",
+            )
+            sys.exit(1)
+
         # Import here to avoid circular imports
         from .mode_detection.command_mode_detector import find_project_root
         from .remote.sync_execution import (
@@ -8347,6 +12077,8 @@ def sync(
         sync_options = []
         if full_reindex:
             sync_options.append("full re-index")
+        elif delta:
+            sync_options.append("index delta upload")
         else:
             sync_options.append("incremental")
 
@@ -8402,6 +12134,7 @@ def sync(
                     timeout=timeout,
                     enable_polling=not dry_run,
                     progress_callback=progress_callback,
+                    delta=delta,
                 )
             )
 
@@ -8445,7 +12178,7 @@ def sync(
                         style="dim",
                     )
 
-        if not dry_run:
+        if not dry_run and any(result.job_id for result in results):
             console.print()
             console.print("🔔 Sync jobs are processing on the server.", style="cyan")
             console.print(
@@ -9669,6 +13402,105 @@ def server_restart(ctx, server_dir: Optional[str]):
         sys.exit(1)
 
 
+@server_group.command("generate-deploy")
+@click.option(
+    "--docker-compose",
+    "target",
+    flag_value="docker-compose",
+    help="Generate a Docker Compose project",
+)
+@click.option("--helm", "target", flag_value="helm", help="Generate a Helm chart")
+@click.option(
+    "--output-dir",
+    "-o",
+    type=click.Path(file_okay=False),
+    default="cidx-deploy",
+    show_default=True,
+    help="Directory to write the deployment files to",
+)
+@click.option(
+    "--issuer-url",
+    help="Public URL of the deployed server (default: http://localhost:<port>)",
+)
+@click.option("--force", is_flag=True, help="Overwrite existing deployment files")
+@click.option(
+    "--server-dir",
+    type=click.Path(),
+    help="Server directory path (default: ~/.cidx-server)",
+)
+@click.pass_context
+def server_generate_deploy(
+    ctx,
+    target: Optional[str],
+    output_dir: str,
+    issuer_url: Optional[str],
+    force: bool,
+    server_dir: Optional[str],
+):
+    """Generate ready-to-run deployment files for the CIDX server.
+
+    Writes a Dockerfile plus either a Docker Compose project (--docker-compose)
+    or a Helm chart (--helm). Port and server settings are taken from the
+    server configuration; secrets are not copied and are supplied through
+    .env or Helm values instead.
+
+    \b
+    Examples:
+      cidx server generate-deploy --docker-compose
+      cidx server generate-deploy --helm --issuer-url https://cidx.example.com
+    """
+    if target is None:
+        console.print(
+            "❌ Error: Choose a target with --docker-compose or --helm", style="red"
+        )
+        sys.exit(1)
+
+    try:
+        from .server.deploy_generator import DeploymentGenerator
+        from .server.utils.config_manager import ServerConfigManager
+
+        config_manager = ServerConfigManager(server_dir)
+        config = config_manager.load_config()
+        if config is None:
+            console.print(
+                f"ℹ️ No configuration at {config_manager.config_file_path}, "
+                "using defaults",
+                style="dim",
+            )
+            config = config_manager.create_default_config()
+
+        generator = DeploymentGenerator(config, issuer_url=issuer_url)
+        output_path = Path(output_dir)
+        if target == "helm":
+            written = generator.generate_helm_chart(output_path, overwrite=force)
+        else:
+            written = generator.generate_docker_compose(output_path, overwrite=force)
+
+    except FileExistsError as e:
+        console.print(f"❌ Error: {e}", style="red")
+        console.print("💡 Use --force to overwrite them", style="dim")
+        sys.exit(1)
+    except Exception as e:
+        console.print(f"❌ Error: {str(e)}", style="red")
+        sys.exit(1)
+
+    console.print(f"✅ Deployment files written to {output_path}", style="green bold")
+    for path in written:
+        console.print(f"   {path}", style="dim")
+    console.print()
+    console.print("📋 Next steps:", style="cyan")
+    if target == "helm":
+        console.print(f"   docker build -t cidx-server:{__version__} {output_path}")
+        console.print(
+            f"   helm install cidx {output_path / 'cidx-server'} "
+            "--set secrets.voyageApiKey=<key>"
+        )
+    else:
+        console.print(f"   cp {output_path / '.env.example'} {output_path / '.env'}")
+        console.print("   Set VOYAGE_API_KEY in .env")
+        console.print(f"   docker compose --project-directory {output_path} up -d")
+
+
 @server_group.command("add-index")
 @click.argument("alias")
 @click.argument("index_type", type=click.Choice(["semantic_fts", "temporal", "scip"]))
@@ -10176,6 +14008,221 @@ def server_auto_update_status(ctx):
 
 
 # SSH Key Management commands
+@cli.group("service")
+@click.pass_context
+def service_group(ctx):
+    """Run watch or the server as a user service.
+
+    Registers a systemd user unit on Linux or a launchd agent on macOS, so
+    the process starts at login, restarts after failures, and survives
+    reboots without hand-written unit files.
+    """
+    pass
+
+
+def _service_spec(ctx, target: str, pass_env, throttle: Optional[str] = None):
+    """Build the service specification of a service target."""
+    from .services.service_installer import server_service_spec, watch_service_spec
+
+    if target == "server":
+        from .server.utils.config_manager import ServerConfigManager
+
+        config_manager = ServerConfigManager()
+        server_config = config_manager.load_config()
+        if server_config is None:
+            server_config = config_manager.create_default_config()
+        return server_service_spec(
+            server_config.host, server_config.port, pass_env=pass_env
+        )
+
+    project_root = ctx.obj["project_root"]
+    if not (project_root / ".code-indexer" / "config.json").exists():
+        raise click.ClickException(
+            "No cidx project found. Run 'cidx init' in the project first."
+        )
+    watch_args = ["--foreground"]
+    if throttle:
+        watch_args.extend(["--throttle", throttle])
+    return watch_service_spec(project_root, watch_args=watch_args, pass_env=pass_env)
+
+
+@service_group.command("install")
+@click.argument(
+    "target", type=click.Choice(["watch", "server"]), default="watch", required=False
+)
+@click.option(
+    "--pass-env",
+    multiple=True,
+    help="Environment variable to copy into the service (repeatable). "
+    "Default: the embedding API key variable",
+)
+@click.option(
+    "--throttle",
+    type=click.Choice(["normal", "low"]),
+    default=None,
+    help="Resource usage level of the watch service",
+)
+@click.pass_context
+def service_install(ctx, target: str, pass_env, throttle: Optional[str]):
+    """Install and start a user service for watch or the server.
+
+    TARGET is 'watch' (default) for the current project, or 'server'.
+    Each project gets its own watch service.
+
+    \b
+    Examples:
+      cidx service install                  # Watch the current project
+      cidx service install server
+      cidx service install --pass-env HTTPS_PROXY
+    """
+    from .services.service_installer import ServiceInstaller
+
+    if not pass_env:
+        api_key_env = "VOYAGE_API_KEY"
+        if target == "watch":
+            try:
+                api_key_env = ctx.obj["config_manager"].load().voyage_ai.api_key_env
+            except Exception:
+                pass  # Project checks below report a missing configuration
+        pass_env = (api_key_env,)
+        if target == "server":
+            pass_env += ("ANTHROPIC_API_KEY",)
+
+    try:
+        installer = ServiceInstaller()
+        spec = _service_spec(ctx, target, pass_env, throttle=throttle)
+        service_file = installer.install(spec)
+    except click.ClickException:
+        raise
+    except Exception as e:
+        console.print(f"❌ Error: {e}", style="red")
+        sys.exit(1)
+
+    console.print(f"✅ Service {spec.name} installed and started", style="green bold")
+    console.print(f"📄 Service file: {service_file}", style="dim")
+
+    copied = [name for name in pass_env if name in spec.environment]
+    missing = [name for name in pass_env if name not in spec.environment]
+    if copied:
+        console.print(
+            f"🔒 Copied {', '.join(copied)} into the service file "
+            "(readable only by you)",
+            style="dim",
+        )
+    for name in missing:
+        console.print(f"⚠️ {name} is not set and was not copied", style="yellow")
+
+    if installer.manager == "systemd":
+        console.print(f"📜 Logs: journalctl --user -u {spec.name} -f", style="dim")
+        if installer.linger_enabled() is False:
+            console.print(
+                "💡 Run 'loginctl enable-linger' to start the service at boot "
+                "without logging in",
+                style="dim",
+            )
+    else:
+        console.print(f"📜 Logs: {installer.log_file(spec.name)}", style="dim")
+
+
+@service_group.command("uninstall")
+@click.argument(
+    "target", type=click.Choice(["watch", "server"]), default="watch", required=False
+)
+@click.pass_context
+def service_uninstall(ctx, target: str):
+    """Stop and remove a user service installed with 'cidx service install'.
+
+    TARGET is 'watch' (default) for the current project, or 'server'.
+    """
+    from .services.service_installer import ServiceInstaller
+
+    try:
+        installer = ServiceInstaller()
+        spec = _service_spec(ctx, target, pass_env=())
+        removed = installer.uninstall(spec.name)
+    except click.ClickException:
+        raise
+    except Exception as e:
+        console.print(f"❌ Error: {e}", style="red")
+        sys.exit(1)
+
+    if removed:
+        console.print(f"✅ Service {spec.name} removed", style="green")
+    else:
+        console.print(f"ℹ️ Service {spec.name} is not installed", style="dim")
+
+
+@cli.group("hooks")
+@click.pass_context
+def hooks_group(ctx):
+    """Keep the index fresh with git hooks instead of watch mode.
+
+    \b
+    The post-commit, post-checkout and post-merge hooks start an
+    incremental 'cidx index --throttle low' in the background. Triggers
+    during an update, or within the minimum interval after one, are
+    coalesced into a single follow-up update.
+    """
+    pass
+
+
+def _git_index_hooks(ctx):
+    """Git hooks of the current project."""
+    from .services.git_index_hooks import GitIndexHooks
+
+    return GitIndexHooks(Path(ctx.obj["config_manager"].get_config().codebase_dir))
+
+
+@hooks_group.command("install")
+@click.option(
+    "--min-interval",
+    type=click.IntRange(min=0),
+    default=30,
+    show_default=True,
+    help="Minimum seconds between two hook-triggered updates",
+)
+@click.pass_context
+@require_mode("local")
+def hooks_install(ctx, min_interval: int):
+    """Install the auto-index hooks, keeping existing hook code."""
+    hooks = _git_index_hooks(ctx)
+    try:
+        written = hooks.install(min_interval=min_interval)
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red", markup=False)
+        sys.exit(1)
+    for hook_file in written:
+        console.print(f"✅ {hook_file}", style="green", markup=False)
+    console.print(
+        f"📜 Output of the last update: {hooks.log_path}", style="dim", markup=False
+    )
+
+
+@hooks_group.command("uninstall")
+@click.pass_context
+@require_mode("local")
+def hooks_uninstall(ctx):
+    """Remove the auto-index hooks."""
+    try:
+        changed = _git_index_hooks(ctx).uninstall()
+    except ValueError as e:
+        console.print(f"❌ {e}", style="red", markup=False)
+        sys.exit(1)
+    if not changed:
+        console.print("ℹ️ No auto-index hooks installed", style="dim")
+    for hook_file in changed:
+        console.print(f"✅ Removed from {hook_file}", style="green", markup=False)
+
+
+@hooks_group.command("run", hidden=True)
+@click.option("--min-interval", type=click.IntRange(min=0), default=30)
+@click.pass_context
+@require_mode("local")
+def hooks_run(ctx, min_interval: int):
+    """Internal: update the index for a git hook trigger."""
+    _git_index_hooks(ctx).run(min_interval=min_interval)
+
+
 @cli.group("ssh-key")
 @click.pass_context
 def ssh_key_group(ctx):
@@ -16468,20 +20515,53 @@ def main():
         sys.exit(1)
     except Exception as e:
         console.print(f"❌ Unexpected error: {str(e)}", style="red", markup=False)
+        _write_crash_report(e)
         sys.exit(1)
 
 
+def _write_crash_report(exception: Exception) -> None:
+    """Log an unhandled exception and write a diagnostic bundle for it."""
+    from .utils.crash_report import BUG_REPORT_URL, write_crash_bundle
+
+    exception_logger = ExceptionLogger.get_instance()
+    if exception_logger is not None:
+        try:
+            exception_logger.log_exception(exception, context={"argv": sys.argv})
+        except Exception:
+            pass  # Never mask the original failure
+
+    bundle_path = write_crash_bundle(find_project_root(Path.cwd()), exception)
+    if bundle_path is not None:
+        console.print(
+            f"📦 Diagnostic bundle written to {bundle_path}",
+            style="yellow",
+            markup=False,
+        )
+        console.print(
+            f"💡 Please attach it to a bug report at {BUG_REPORT_URL}", style="dim"
+        )
+
+
 @cli.command("start")
+@click.option(
+    "--preload",
+    is_flag=True,
+    help="Warm index caches and prime the embedding provider so the first query is fast",
+)
 @click.pass_context
 @require_mode("local")
-def start_command(ctx):
+def start_command(ctx, preload: bool):
     """Start CIDX daemon manually.
 
     Only available when daemon.enabled: true in config.
     Normally daemon auto-starts on first query, but this allows
     explicit control for debugging or pre-loading.
+
+    \b
+    Use --preload (or daemon.preload_on_start in config.json) to load
+    the index into memory before the first query of a session.
     """
-    exit_code = cli_daemon_lifecycle.start_daemon_command()
+    exit_code = cli_daemon_lifecycle.start_daemon_command(preload=preload)
     sys.exit(exit_code)
 
 
@@ -16514,6 +20594,48 @@ def watch_stop_command(ctx):
     sys.exit(exit_code)
 
 
+@cli.command("daemon")
+@click.option(
+    "--port",
+    type=int,
+    default=0,
+    show_default=True,
+    help="TCP port on 127.0.0.1 (0 picks a free port)",
+)
+@click.option(
+    "--max-projects",
+    type=click.IntRange(min=1),
+    default=8,
+    show_default=True,
+    help="Projects kept warm in memory at once",
+)
+def editor_daemon_command(port: int, max_projects: int):
+    """Serve a local API for editor extensions.
+
+    Runs in the foreground until interrupted and answers JSON POST requests
+    on 127.0.0.1 (/query, /symbol, /status) for any project on this
+    machine, keeping each project's services and HNSW index warm. The URL
+    and access token are written to ~/.code-indexer/editor-daemon.json for
+    editors to pick up.
+
+    \b
+    This is independent of the per-project daemon ('cidx start').
+    """
+    from .daemon.editor_server import STATE_FILE, EditorApi, EditorServer
+
+    try:
+        server = EditorServer(EditorApi(max_projects=max_projects), port=port)
+    except OSError as e:
+        console.print(f"❌ Cannot listen on port {port}: {e}", style="red")
+        sys.exit(1)
+    console.print(f"✅ Editor API listening on http://127.0.0.1:{server.port}")
+    console.print(f"Connection details: {STATE_FILE}", style="dim")
+    try:
+        server.serve()
+    except KeyboardInterrupt:
+        console.print("Editor API stopped", style="dim")
+
+
 if __name__ == "__main__":
     main()
 
@@ -898,6 +898,7 @@ def _index_via_daemon(
                 "all_branches": kwargs.get("all_branches", False),
                 "max_commits": kwargs.get("max_commits"),
                 "since_date": kwargs.get("since_date"),
+                "throttle": kwargs.get("throttle"),
                 # rebuild_* flags not supported in daemon mode yet (early-exit paths in local mode)
             }
 
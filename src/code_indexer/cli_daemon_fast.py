@@ -36,6 +36,18 @@ def get_socket_path(config_path: Path) -> Path:
     return cast(Path, config_manager.get_socket_path())
 
 
+def _default_platform(config_path: Path) -> Optional[str]:
+    """indexing.go_build_constraints.default_platform, read without pydantic."""
+    import json
+
+    try:
+        data = json.loads(config_path.read_text())
+        value = data["indexing"]["go_build_constraints"]["default_platform"]
+    except (OSError, ValueError, KeyError, TypeError):
+        return None
+    return value if isinstance(value, str) else None
+
+
 def parse_query_args(args: List[str]) -> Dict[str, Any]:
     """Parse query arguments without Click (faster).
 
@@ -65,6 +77,7 @@ def parse_query_args(args: List[str]) -> Dict[str, Any]:
         "--exclude-path",
         "--snippet-lines",
         "--repo",
+        "--include-tests",  # Default test scope, nothing to pass on
     }
 
     result: Dict[str, Any] = {
@@ -138,18 +151,19 @@ def parse_query_args(args: List[str]) -> Dict[str, Any]:
                         result["filters"]["exclude_language"].append(args[i + 1])
                 i += 1
             elif arg == "--exclude-path" and i + 1 < len(args):
-                # Accumulate multiple values into list
-                if "exclude_path" not in result["filters"]:
-                    result["filters"]["exclude_path"] = args[i + 1]
-                else:
+                # Accumulate multiple values (and comma-separated patterns)
+                # into list
+                for pattern in [p for p in args[i + 1].split(",") if p.strip()]:
+                    if "exclude_path" not in result["filters"]:
+                        result["filters"]["exclude_path"] = pattern.strip()
                     # Convert to list on second occurrence
-                    if isinstance(result["filters"]["exclude_path"], str):
+                    elif isinstance(result["filters"]["exclude_path"], str):
                         result["filters"]["exclude_path"] = [
                             result["filters"]["exclude_path"],
-                            args[i + 1],
+                            pattern.strip(),
                         ]
                     else:
-                        result["filters"]["exclude_path"].append(args[i + 1])
+                        result["filters"]["exclude_path"].append(pattern.strip())
                 i += 1
             elif arg == "--snippet-lines" and i + 1 < len(args):
                 result["filters"]["snippet_lines"] = int(args[i + 1])
@@ -241,6 +255,44 @@ def execute_via_daemon(argv: List[str], config_path: Path) -> int:
     # Skip daemon for --repo flag (global repos require full CLI for alias resolution)
     if command == "query" and "--repo" in args:
         raise ConnectionRefusedError("--repo requires full CLI (not daemon)")
+    # Workspace queries search other repositories' indexes
+    if command == "query" and "--repos" in args:
+        raise ConnectionRefusedError("--repos requires full CLI (not daemon)")
+
+    # Skip daemon for coverage, license and owner filters (applied by the full CLI)
+    local_filters = (
+        "--uncovered",
+        "--covered-by",
+        "--license",
+        "--license-not",
+        "--owner",
+        "--struct-tag",
+        "--implements",
+        "--platform",
+        "--build-tag",
+        "--exclude-build-tag",
+        "--depends-on",
+        "--symbol-kind",
+        "--min-complexity",
+        "--vector-mode",
+        "--filter",
+        "--grep",
+        "--hyde",
+        "--hyde-mode",
+        "--group-by",
+        "--max-per-file",
+        "--at",
+        "--changed-since",
+        "--explain",
+        "--exclude-kind",
+        "--hybrid",
+        "--only-tests",
+        "--exclude-tests",
+    )
+    if command == "query" and any(flag in args for flag in local_filters):
+        raise ConnectionRefusedError("query filter requires full CLI (not daemon)")
+    if command == "query" and _default_platform(config_path):
+        raise ConnectionRefusedError("default platform requires full CLI (not daemon)")
 
     # CRITICAL: Validate arguments BEFORE attempting daemon connection
     # This ensures typos and invalid flags are caught immediately
@@ -364,9 +416,24 @@ def execute_via_daemon(argv: List[str], config_path: Path) -> int:
                 # CRITICAL FIX: Parse response dict with results and timing
                 result = response.get("results", [])
                 timing_info = response.get("timing", None)
+                # Mark the region of each chunk matching the query
+                from .services.match_highlighting import annotate_highlights
+
+                annotate_highlights(result, query_text)
 
             # Display results with full formatting including timing and quiet flag
+            import time
+
+            render_start = time.time()
             _display_results(result, console, timing_info=timing_info, quiet=is_quiet)
+            if timing_info:
+                from .cli import _record_query_latency
+
+                _record_query_latency(
+                    config_path.parent,
+                    timing_info,
+                    render_ms=(time.time() - render_start) * 1000,
+                )
 
         elif command == "start":
             # Start daemon (should already be handled by cli_daemon_lifecycle)
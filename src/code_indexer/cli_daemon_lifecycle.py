@@ -15,8 +15,10 @@ from .cli_daemon_delegation import _start_daemon
 
 console = Console()
 
+PRELOAD_WAIT_SECONDS = 120.0
 
-def start_daemon_command() -> int:
+
+def start_daemon_command(preload: bool = False) -> int:
     """
     Start CIDX daemon manually.
 
@@ -24,6 +26,10 @@ def start_daemon_command() -> int:
     Normally daemon auto-starts on first query, but this allows
     explicit control for debugging or pre-loading.
 
+    Args:
+        preload: Warm index caches and prime the embedding provider before
+            returning, so the first query of the session is fast
+
     Returns:
         Exit code (0 = success, 1 = error)
     """
@@ -45,9 +51,11 @@ def start_daemon_command() -> int:
         # Try to get status to verify it's responsive
         try:
             conn.root.exposed_get_status()
-            conn.close()
             console.print("[yellow]Daemon already running[/yellow]")
             console.print(f"  Socket: {socket_path}")
+            if preload:
+                _preload_daemon_caches(conn, config_manager.config_path.parent.parent)
+            conn.close()
             return 0
         except Exception:
             # Connected but not responsive, close and restart
@@ -68,10 +76,12 @@ def start_daemon_command() -> int:
 
         conn = unix_connect(str(socket_path))
         _ = conn.root.exposed_get_status()
-        conn.close()
 
         console.print("[green]✓ Daemon started[/green]")
         console.print(f"  Socket: {socket_path}")
+        if preload:
+            _preload_daemon_caches(conn, config_manager.config_path.parent.parent)
+        conn.close()
         return 0
     except Exception as e:
         console.print("[red]Failed to start daemon[/red]")
@@ -79,6 +89,46 @@ def start_daemon_command() -> int:
         return 1
 
 
+def _preload_daemon_caches(
+    conn, project_root: Path, timeout_seconds: float = PRELOAD_WAIT_SECONDS
+) -> None:
+    """
+    Ask the daemon to warm its caches and wait for it to finish.
+
+    Preloading is best-effort: failures are reported but never fail `cidx start`.
+
+    Args:
+        conn: Open RPyC connection to the daemon
+        project_root: Project whose index should be warmed
+        timeout_seconds: Stop waiting (preload continues in the daemon) after this
+    """
+    try:
+        conn.root.exposed_preload(str(project_root))
+        console.print("Preloading index and embedding provider...")
+
+        deadline = time.time() + timeout_seconds
+        status = conn.root.exposed_get_status()
+        while status.get("preload_running") and time.time() < deadline:
+            time.sleep(0.5)
+            status = conn.root.exposed_get_status()
+    except Exception as e:
+        console.print(f"[yellow]⚠️  Preload failed: {e}[/yellow]")
+        return
+
+    if status.get("preload_running"):
+        console.print("[dim]Preload still running in the daemon[/dim]")
+        return
+
+    result = status.get("preload_result") or {}
+    megabytes = result.get("bytes_warmed", 0) / (1024 * 1024)
+    console.print(
+        f"[green]✓ Preloaded {result.get('files_warmed', 0)} files "
+        f"({megabytes:.1f} MB) in {result.get('elapsed_seconds', 0.0):.1f}s[/green]"
+    )
+    for error in result.get("errors", []):
+        console.print(f"[yellow]⚠️  {error}[/yellow]")
+
+
 def stop_daemon_command() -> int:
     """
     Stop CIDX daemon gracefully.
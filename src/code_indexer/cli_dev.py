@@ -0,0 +1,96 @@
+"""Developer CLI commands for code-indexer contributors."""
+
+import sys
+from pathlib import Path
+from typing import Optional, Tuple
+
+import click
+from rich.console import Console
+
+from .indexing.chunk_fixtures import (
+    DEFAULT_FIXTURE_CHUNK_SIZE,
+    DEFAULT_FIXTURES_DIR,
+    GOLDEN_SUFFIX,
+    generate_fixture,
+)
+
+console = Console()
+
+
+@click.group("dev")
+def dev_group():
+    """Developer tools for working on code-indexer itself.
+
+    Available commands:
+      gen-fixtures   - Write golden chunk files for chunker test fixtures
+    """
+    pass
+
+
+@dev_group.command("gen-fixtures")
+@click.argument("language")
+@click.argument(
+    "files",
+    nargs=-1,
+    required=True,
+    type=click.Path(exists=True, dir_okay=False, path_type=Path),
+)
+@click.option(
+    "--category",
+    help="Fixture subdirectory for copied files, e.g. classes (default: general)",
+)
+@click.option(
+    "--fixtures-dir",
+    type=click.Path(file_okay=False, path_type=Path),
+    default=DEFAULT_FIXTURES_DIR,
+    show_default=True,
+    help="Root of the fixture tree",
+)
+@click.option(
+    "--chunk-size",
+    type=click.IntRange(16, 100000),
+    default=DEFAULT_FIXTURE_CHUNK_SIZE,
+    show_default=True,
+    help="Chunk size in characters",
+)
+def gen_fixtures(
+    language: str,
+    files: Tuple[Path, ...],
+    category: Optional[str],
+    fixtures_dir: Path,
+    chunk_size: int,
+):
+    """Chunk source files and write their expected chunks as golden files.
+
+    Copies each file into FIXTURES_DIR/LANGUAGE/CATEGORY/ (files already in
+    the fixture tree are regenerated in place) and writes the chunks the
+    indexing chunker produces next to it as <file>.chunks.json. The fixture
+    test suite fails whenever chunking output diverges from a golden file.
+
+    Run from the root of a code-indexer checkout.
+
+    \b
+    EXAMPLES:
+      cidx dev gen-fixtures rust samples/lib.rs --category functions
+      cidx dev gen-fixtures python tests/ast_test_cases/python/*/*.py
+    """
+    failed = False
+    for source in files:
+        if source.name.endswith(GOLDEN_SUFFIX):
+            continue
+        try:
+            golden = generate_fixture(
+                source,
+                language,
+                fixtures_dir=fixtures_dir,
+                category=category,
+                chunk_size=chunk_size,
+            )
+        except (ValueError, OSError) as e:
+            console.print(f"❌ {source}: {e}", style="red", markup=False)
+            failed = True
+            continue
+        console.print(f"✅ {golden}", style="green", markup=False)
+
+    if failed:
+        sys.exit(1)
@@ -11,6 +11,7 @@ Performance targets:
 """
 
 import json
+import socket
 import sys
 from pathlib import Path
 from typing import Tuple, Optional
@@ -27,6 +28,10 @@ def quick_daemon_check() -> Tuple[bool, Optional[Path]]:
         - is_daemon_enabled: True if daemon.enabled: true in config
         - config_path: Path to config.json if found, None otherwise
     """
+    # No AF_UNIX sockets (Windows): the daemon cannot run, always standalone
+    if not hasattr(socket, "AF_UNIX"):
+        return False, None
+
     current = Path.cwd()
 
     # Walk up directory tree (like git does)
@@ -97,6 +102,42 @@ def is_delegatable_command(command: str, args: list) -> bool:
 
 
 def main() -> int:
+    """Entry point: runs the command and records opt-in usage telemetry.
+
+    Returns:
+        Exit code (0 for success, non-zero for error)
+    """
+    from .utils.telemetry import UsageRecorder
+
+    usage = UsageRecorder()
+    exit_code = 1
+    try:
+        exit_code = _run(usage)
+        return exit_code
+    except SystemExit as e:
+        exit_code = e.code if isinstance(e.code, int) else (0 if e.code is None else 1)
+        raise
+    except BaseException as e:
+        usage.exception = e
+        raise
+    finally:
+        if usage.enabled:
+            usage.finish(_command_name(sys.argv[1:]), exit_code)
+
+
+def _command_name(args: list) -> Optional[str]:
+    """Command an invocation ran, for telemetry; never its arguments."""
+    from .utils.telemetry import resolve_command_name
+
+    cli_module = sys.modules.get("code_indexer.cli")
+    if cli_module is not None:
+        return resolve_command_name(cli_module.cli, args)
+    # Fast path: only delegatable commands get here without the full CLI
+    command = args[0] if args else None
+    return command if command and is_delegatable_command(command, args) else None
+
+
+def _run(usage) -> int:
     """Optimized entry point with daemon fast path.
 
     Routes commands to fast path (daemon delegation) or slow path (full CLI)
@@ -123,7 +164,8 @@ def main() -> int:
         try:
             cli(obj={})
             return 0
-        except KeyboardInterrupt:
+        except KeyboardInterrupt as e:
+            usage.exception = e
             from rich.console import Console
 
             Console().print("\n❌ Interrupted by user", style="red")
@@ -165,12 +207,14 @@ def main() -> int:
     try:
         cli(obj={})
         return 0
-    except KeyboardInterrupt:
+    except KeyboardInterrupt as e:
+        usage.exception = e
         from rich.console import Console
 
         Console().print("\n❌ Interrupted by user", style="red")
         return 1
     except Exception as e:
+        usage.exception = e
         from rich.console import Console
 
         Console().print(f"❌ Unexpected error: {e}", style="red", markup=False)
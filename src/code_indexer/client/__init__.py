@@ -0,0 +1,35 @@
+"""
+Supported library API of code-indexer.
+
+Internal tools integrate with cidx through CidxClient instead of running the
+CLI and parsing its output:
+
+    from code_indexer.client import CidxClient
+
+    client = CidxClient("/path/to/repo")
+    client.index()
+    for result in client.query("retry failed payments", limit=5):
+        print(result.path, result.line_start, result.score)
+
+The names exported here are the stable API; everything else in the package
+may change between releases.
+"""
+
+from .cidx_client import CidxClient, CidxClientError
+from .models import (
+    IndexResult,
+    IndexStatus,
+    QueryResult,
+    Repository,
+    SymbolResult,
+)
+
+__all__ = [
+    "CidxClient",
+    "CidxClientError",
+    "IndexResult",
+    "IndexStatus",
+    "QueryResult",
+    "Repository",
+    "SymbolResult",
+]
@@ -0,0 +1,465 @@
+"""
+CidxClient: library API over local indexes and CIDX servers.
+
+The client picks its mode from the project's .code-indexer directory the
+same way the CLI does: a linked remote repository is queried through the
+server, a local configuration is indexed and queried in-process.
+"""
+
+import asyncio
+import logging
+import threading
+from pathlib import Path
+from dataclasses import dataclass
+from typing import Any, Callable, Dict, List, Optional, Sequence, Tuple
+
+from .models import IndexResult, IndexStatus, QueryResult, Repository, SymbolResult
+
+logger = logging.getLogger(__name__)
+
+# HNSW ef per query accuracy, as used by 'cidx query --accuracy'
+ACCURACY_EF = {"fast": 50, "balanced": 100, "high": 200}
+
+
+class CidxClientError(Exception):
+    """A client operation failed or is not available in the project's mode."""
+
+
+def _index_signature(collection_path: Path) -> Tuple[Any, ...]:
+    """Changes whenever the collection's HNSW or ID index file is rewritten."""
+    signature: List[Any] = []
+    for name in ("hnsw_index.bin", "id_index.bin"):
+        try:
+            stat = (collection_path / name).stat()
+            signature.append((stat.st_mtime_ns, stat.st_size))
+        except OSError:
+            signature.append(None)
+    return tuple(signature)
+
+
+@dataclass
+class _LocalServices:
+    """Services of a local project kept between calls."""
+
+    embedding_provider: Any
+    vector_store: Any
+    collection: str
+    collection_path: Path
+    git_aware: bool
+    signature: Tuple[Any, ...]
+
+
+def _run(coroutine: Any) -> Any:
+    """Run a coroutine to completion, also from inside a running event loop."""
+    try:
+        asyncio.get_running_loop()
+    except RuntimeError:
+        return asyncio.run(coroutine)
+
+    outcome: Dict[str, Any] = {}
+
+    def run() -> None:
+        try:
+            outcome["result"] = asyncio.run(coroutine)
+        except BaseException as e:
+            outcome["error"] = e
+
+    thread = threading.Thread(target=run)
+    thread.start()
+    thread.join()
+    if "error" in outcome:
+        raise outcome["error"]
+    return outcome.get("result")
+
+
+class CidxClient:
+    """
+    Index, query and inspect a cidx project from Python.
+
+    Example:
+        client = CidxClient("/path/to/repo")
+        for result in client.query("retry failed payments", limit=5):
+            print(result.path, result.line_start, result.score)
+    """
+
+    def __init__(
+        self, project_root: Optional[Path] = None, hnsw_cache: Optional[Any] = None
+    ):
+        """
+        Open the project containing a directory.
+
+        Args:
+            project_root: Project directory or any directory below it
+                (default: the current directory)
+            hnsw_cache: HNSWIndexCache keeping loaded HNSW indexes in memory
+                across queries (and clients)
+
+        Raises:
+            CidxClientError: If the project is not initialized with 'cidx init'
+        """
+        from ..mode_detection.command_mode_detector import (
+            CommandModeDetector,
+            find_project_root,
+        )
+
+        start = Path(project_root) if project_root else Path.cwd()
+        self.project_root = find_project_root(start)
+        self.mode = CommandModeDetector(self.project_root).detect_mode()
+        if self.mode == "uninitialized":
+            raise CidxClientError(
+                f"No cidx project found at {start}; run 'cidx init' first"
+            )
+        self.hnsw_cache = hnsw_cache
+        self._config: Any = None
+        self._services: Optional[_LocalServices] = None
+        self._services_lock = threading.Lock()
+
+    @property
+    def config(self) -> Any:
+        """Configuration of a local project."""
+        self._require_mode("local")
+        if self._config is None:
+            from ..config import ConfigManager
+
+            self._config = ConfigManager.create_with_backtrack(
+                self.project_root
+            ).get_config()
+        return self._config
+
+    def query(
+        self,
+        text: str,
+        limit: int = 10,
+        languages: Sequence[str] = (),
+        paths: Sequence[str] = (),
+        min_score: Optional[float] = None,
+        accuracy: str = "balanced",
+    ) -> List[QueryResult]:
+        """
+        Semantic search.
+
+        Local results are re-ranked with recorded relevance feedback and
+        passed through the post_query hooks, like 'cidx query'.
+
+        Args:
+            text: Natural language query
+            limit: Maximum number of results
+            languages: Only match these languages (any of them)
+            paths: Only match paths matching these patterns (any of them)
+            min_score: Minimum similarity score
+            accuracy: "fast", "balanced" or "high" (local mode)
+
+        Raises:
+            CidxClientError: If the search fails
+        """
+        if self.mode == "remote":
+            return self._remote_query(text, limit, languages, paths, min_score)
+        self._require_mode("local")
+        if accuracy not in ACCURACY_EF:
+            raise CidxClientError(
+                f"Unknown accuracy '{accuracy}'; use one of {', '.join(ACCURACY_EF)}"
+            )
+        return self._local_query(text, limit, languages, paths, min_score, accuracy)
+
+    def index(
+        self,
+        clear: bool = False,
+        reconcile: bool = False,
+        detect_deletions: bool = False,
+        fts: bool = False,
+        progress_callback: Optional[Callable] = None,
+    ) -> IndexResult:
+        """
+        Index the project (incrementally unless clear is set), like 'cidx index'.
+
+        Args:
+            clear: Re-index everything
+            reconcile: Reconcile files on disk with the index
+            detect_deletions: Remove files deleted from disk from the index
+            fts: Also build the full-text search index
+            progress_callback: SmartIndexer progress callback
+
+        Raises:
+            CidxClientError: In remote mode, or if a service is unavailable
+        """
+        from ..services.smart_indexer import SmartIndexer
+
+        config = self.config
+        services = self._local_services()
+        indexer = SmartIndexer(
+            config,
+            services.embedding_provider,
+            services.vector_store,
+            self.project_root / ".code-indexer" / "metadata.json",
+        )
+        try:
+            stats = indexer.smart_index(
+                force_full=clear,
+                reconcile_with_database=reconcile,
+                progress_callback=progress_callback,
+                quiet=True,
+                vector_thread_count=config.voyage_ai.parallel_requests,
+                detect_deletions=detect_deletions,
+                enable_fts=fts,
+            )
+        except Exception as e:
+            raise CidxClientError(f"Indexing failed: {e}") from e
+        return IndexResult.from_stats(stats)
+
+    def status(self) -> IndexStatus:
+        """
+        Index status: the collection and its size (local), or the linked
+        server repository (remote).
+
+        Raises:
+            CidxClientError: If the status cannot be read
+        """
+        if self.mode == "remote":
+            return self._remote_status()
+        self._require_mode("local")
+        from ..backends.backend_factory import BackendFactory
+        from ..services.embedding_factory import EmbeddingProviderFactory
+
+        config = self.config
+        vector_store = BackendFactory.create(
+            config, self.project_root
+        ).get_vector_store_client()
+        collection = vector_store.resolve_collection_name(
+            config, EmbeddingProviderFactory.create(config)
+        )
+        status = IndexStatus(mode=self.mode, project_root=str(self.project_root))
+        if vector_store.collection_exists(collection):
+            status.collection = collection
+            status.chunks = vector_store.count_points(collection)
+            status.indexed_files = vector_store.get_indexed_file_count_fast(
+                collection
+            )
+        return status
+
+    def symbol(
+        self, name: str, exact: bool = False, limit: int = 20
+    ) -> List[SymbolResult]:
+        """
+        Definitions of a symbol from the project's SCIP indexes, like
+        'cidx scip definition'.
+
+        Args:
+            name: Symbol name (substring match unless exact)
+            exact: Match the symbol name exactly
+            limit: Maximum number of definitions
+
+        Raises:
+            CidxClientError: Outside local mode, or if no SCIP index exists
+        """
+        self._require_mode("local")
+        scip_dir = self.project_root / ".code-indexer" / "scip"
+        databases = sorted(scip_dir.glob("**/*.scip.db")) if scip_dir.is_dir() else []
+        if not databases:
+            raise CidxClientError(
+                "No SCIP indexes found; run 'cidx scip generate' first"
+            )
+        from ..scip.query import SCIPQueryEngine
+
+        results: List[SymbolResult] = []
+        for database in databases:
+            try:
+                definitions = SCIPQueryEngine(database).find_definition(
+                    name, exact=exact
+                )
+            except Exception as e:
+                logger.warning(f"Failed to query {database}: {e}")
+                continue
+            results.extend(SymbolResult.from_scip(d) for d in definitions)
+            if len(results) >= limit:
+                break
+        return results[:limit]
+
+    def list_repositories(self) -> List[Repository]:
+        """
+        Repositories activated for the user on the linked CIDX server.
+
+        Raises:
+            CidxClientError: Outside remote mode, or if the server call fails
+        """
+        from ..business_logic.remote_operations import (
+            _get_decrypted_credentials,
+            _load_remote_configuration,
+            list_available_repositories,
+        )
+
+        self._require_mode("remote")
+        remote = self._remote_call(_load_remote_configuration, self.project_root)
+        credentials = self._remote_call(_get_decrypted_credentials, self.project_root)
+        repositories = self._remote_call(
+            lambda: _run(
+                list_available_repositories(remote["server_url"], credentials)
+            )
+        )
+        return [Repository.from_activated(r) for r in repositories]
+
+    def _require_mode(self, mode: str) -> None:
+        if self.mode != mode:
+            raise CidxClientError(
+                f"This operation needs a {mode} project; "
+                f"{self.project_root} is in {self.mode} mode"
+            )
+
+    def _local_services(self) -> _LocalServices:
+        """
+        Healthy services of a local project.
+
+        They are kept warm between calls until the collection's index files
+        change on disk (re-index, watch mode, another process).
+        """
+        with self._services_lock:
+            services = self._services
+            if services is not None:
+                if _index_signature(services.collection_path) == services.signature:
+                    return services
+                if self.hnsw_cache is not None:
+                    self.hnsw_cache.invalidate(str(services.collection_path))
+            self._services = self._create_local_services()
+            return self._services
+
+    def _create_local_services(self) -> _LocalServices:
+        from ..backends.backend_factory import BackendFactory
+        from ..services.embedding_factory import EmbeddingProviderFactory
+        from ..services.git_topology_service import GitTopologyService
+
+        config = self.config
+        embedding_provider = EmbeddingProviderFactory.create(config)
+        if not embedding_provider.health_check():
+            raise CidxClientError(
+                f"{embedding_provider.get_provider_name().title()} service "
+                "not available"
+            )
+        backend = BackendFactory.create(
+            config, self.project_root, hnsw_cache=self.hnsw_cache
+        )
+        if not backend.health_check():
+            raise CidxClientError("Vector store not available")
+        vector_store = backend.get_vector_store_client()
+        collection = vector_store.resolve_collection_name(config, embedding_provider)
+        collection_path = Path(vector_store.base_path) / collection
+        return _LocalServices(
+            embedding_provider=embedding_provider,
+            vector_store=vector_store,
+            collection=collection,
+            collection_path=collection_path,
+            git_aware=GitTopologyService(config.codebase_dir).is_git_available(),
+            signature=_index_signature(collection_path),
+        )
+
+    def _local_query(
+        self,
+        text: str,
+        limit: int,
+        languages: Sequence[str],
+        paths: Sequence[str],
+        min_score: Optional[float],
+        accuracy: str,
+    ) -> List[QueryResult]:
+        from ..services.generic_query_service import GenericQueryService
+        from ..services.language_mapper import LanguageMapper
+        from ..services.lifecycle_hooks import LifecycleHooks
+        from ..services.relevance_feedback import FeedbackStore, chunk_text
+
+        config = self.config
+        services = self._local_services()
+
+        must: List[Dict[str, Any]] = []
+        if services.git_aware:
+            must.append({"key": "git_available", "match": {"value": True}})
+        language_mapper = LanguageMapper()
+        for conditions in (
+            [language_mapper.build_language_filter(lang) for lang in languages],
+            [{"key": "path", "match": {"text": p}} for p in paths],
+        ):
+            # Several values of one filter match any of them
+            if len(conditions) == 1:
+                must.append(conditions[0])
+            elif conditions:
+                must.append({"should": conditions})
+
+        try:
+            results = services.vector_store.search(
+                query=text,
+                embedding_provider=services.embedding_provider,
+                collection_name=services.collection,
+                limit=limit * 2,
+                score_threshold=min_score,
+                filter_conditions={"must": must} if must else None,
+                ef=ACCURACY_EF[accuracy],
+            )
+            results = GenericQueryService(
+                config.codebase_dir, config
+            ).filter_results_by_current_branch(results)
+            results = FeedbackStore.for_project(
+                self.project_root / ".code-indexer"
+            ).rerank(results)
+            query_hooks = LifecycleHooks.from_config(config, points=("post_query",))
+            if query_hooks is not None:
+                results = query_hooks.process_results(text, results)
+        except Exception as e:
+            raise CidxClientError(f"Query failed: {e}") from e
+        return [
+            QueryResult.from_local(result, chunk_text(result, self.project_root))
+            for result in results[:limit]
+        ]
+
+    def _remote_query(
+        self,
+        text: str,
+        limit: int,
+        languages: Sequence[str],
+        paths: Sequence[str],
+        min_score: Optional[float],
+    ) -> List[QueryResult]:
+        if len(languages) > 1 or len(paths) > 1:
+            raise CidxClientError(
+                "Remote queries accept at most one language and one path filter"
+            )
+        from ..business_logic.remote_operations import execute_remote_query
+
+        items = self._remote_call(
+            lambda: _run(
+                execute_remote_query(
+                    text,
+                    limit,
+                    self.project_root,
+                    language_filter=languages[0] if languages else None,
+                    path_filter=paths[0] if paths else None,
+                    min_score=min_score,
+                )
+            )
+        )
+        return [QueryResult.from_remote(item) for item in items]
+
+    def _remote_status(self) -> IndexStatus:
+        from ..business_logic.remote_operations import (
+            _load_remote_configuration,
+            get_remote_repository_status,
+        )
+
+        remote = self._remote_call(_load_remote_configuration, self.project_root)
+        info = self._remote_call(
+            lambda: _run(get_remote_repository_status(self.project_root))
+        )
+        return IndexStatus(
+            mode=self.mode,
+            project_root=str(self.project_root),
+            server_url=remote["server_url"],
+            repository=info.id,
+            branch=info.branch or info.default_branch,
+            branches=list(info.branches),
+        )
+
+    @staticmethod
+    def _remote_call(function: Callable, *args: Any) -> Any:
+        """Call a remote operation, raising its failures as CidxClientError."""
+        from ..business_logic.remote_operations import RemoteOperationError
+
+        try:
+            return function(*args)
+        except RemoteOperationError as e:
+            raise CidxClientError(str(e)) from e
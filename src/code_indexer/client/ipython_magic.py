@@ -0,0 +1,176 @@
+"""
+IPython extension: query the index from notebooks.
+
+    %load_ext code_indexer
+    %cidx query "retry failed payments" -l 5 --language python
+    %cidx similar 12           # Code similar to input cell 12
+    %cidx similar              # ... to the previous cell
+
+    %%cidx similar             # ... to the body of this cell
+    def retry(payment): ...
+
+Results render as a ranked list with syntax-highlighted code in notebooks
+and as plain text in terminals. The project is the one containing the
+working directory unless --project is given.
+"""
+
+import argparse
+import html
+import re
+import shlex
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+from .cidx_client import CidxClient, CidxClientError
+from .models import QueryResult
+
+# Lines of each result's code shown inline
+MAX_RESULT_LINES = 30
+
+
+def _parser() -> argparse.ArgumentParser:
+    parser = argparse.ArgumentParser(prog="%cidx", add_help=False)
+    parser.add_argument("action", choices=["query", "similar"])
+    parser.add_argument("target", nargs="*")
+    parser.add_argument("-l", "--limit", type=int, default=10)
+    parser.add_argument("--language", action="append", default=[])
+    parser.add_argument("--path-filter", action="append", default=[])
+    parser.add_argument("--min-score", type=float)
+    parser.add_argument("--project")
+    return parser
+
+
+def cell_source(history: List[str], reference: Optional[str]) -> str:
+    """
+    Source of an input cell, without magic lines.
+
+    Args:
+        history: IPython's In list (index = execution count)
+        reference: Execution count of the cell, None for the previous one
+            (the cell before the one running the magic)
+    """
+    if reference is None:
+        index = len(history) - 2
+    else:
+        match = re.fullmatch(r"(?:In\[)?(\d+)\]?", reference)
+        if not match:
+            raise CidxClientError(f"'{reference}' is not an input cell number")
+        index = int(match.group(1))
+    if not 0 < index < len(history):
+        raise CidxClientError(f"There is no input cell {reference or index}")
+    lines = [
+        line
+        for line in history[index].splitlines()
+        if not line.lstrip().startswith(("%", "!"))
+    ]
+    return "\n".join(lines).strip()
+
+
+def _highlight(code: str, path: str) -> str:
+    """HTML of code, syntax highlighted when pygments is available."""
+    try:
+        from pygments import highlight
+        from pygments.formatters import HtmlFormatter
+        from pygments.lexers import TextLexer, get_lexer_for_filename
+        from pygments.util import ClassNotFound
+    except ImportError:
+        return f"<pre>{html.escape(code)}</pre>"
+    try:
+        lexer = get_lexer_for_filename(path)
+    except ClassNotFound:
+        lexer = TextLexer()
+    return str(highlight(code, lexer, HtmlFormatter(noclasses=True)))
+
+
+class QueryResults:
+    """Ranked results displayed by IPython (HTML in notebooks)."""
+
+    def __init__(self, query: str, results: List[QueryResult]):
+        self.query = query
+        self.results = results
+
+    def __len__(self) -> int:
+        return len(self.results)
+
+    def __getitem__(self, index: int) -> QueryResult:
+        return self.results[index]
+
+    def _excerpt(self, result: QueryResult) -> str:
+        lines = result.content.splitlines()
+        if len(lines) > MAX_RESULT_LINES:
+            lines = lines[:MAX_RESULT_LINES] + ["..."]
+        return "\n".join(lines)
+
+    def __repr__(self) -> str:
+        if not self.results:
+            return "No results"
+        return "\n".join(
+            f"{rank}. {r.path}:{r.line_start}-{r.line_end} (score {r.score:.3f})"
+            for rank, r in enumerate(self.results, 1)
+        )
+
+    def _repr_html_(self) -> str:
+        if not self.results:
+            return "<p>No results</p>"
+        parts = []
+        for rank, r in enumerate(self.results, 1):
+            location = html.escape(f"{r.path}:{r.line_start}-{r.line_end}")
+            parts.append(
+                f"<div><b>{rank}. {location}</b> "
+                f"<span style='color:gray'>score {r.score:.3f}</span>"
+                f"{_highlight(self._excerpt(r), r.path)}</div>"
+            )
+        return "".join(parts)
+
+
+class CidxMagic:
+    """State of the %cidx magic: one client per project."""
+
+    def __init__(self, shell: Any):
+        self.shell = shell
+        self.clients: Dict[Path, CidxClient] = {}
+
+    def client(self, project: Optional[str]) -> CidxClient:
+        root = Path(project).expanduser().resolve() if project else Path.cwd()
+        if root not in self.clients:
+            self.clients[root] = CidxClient(root)
+        return self.clients[root]
+
+    def __call__(self, line: str, cell: Optional[str] = None) -> Any:
+        try:
+            args = _parser().parse_args(shlex.split(line))
+        except (SystemExit, ValueError):
+            print(
+                'Usage: %cidx query "text" | %cidx similar [cell] '
+                "[-l N] [--language L] [--path-filter P] [--min-score S] "
+                "[--project DIR]"
+            )
+            return None
+        try:
+            if cell is not None:
+                text = cell.strip()
+            elif args.action == "query":
+                text = " ".join(args.target)
+            else:
+                history = self.shell.user_ns.get("In", [])
+                text = cell_source(history, args.target[0] if args.target else None)
+            if not text:
+                raise CidxClientError(f"Nothing to {args.action}")
+            results = self.client(args.project).query(
+                text,
+                limit=args.limit,
+                languages=args.language,
+                paths=args.path_filter,
+                min_score=args.min_score,
+            )
+        except CidxClientError as e:
+            print(f"cidx: {e}")
+            return None
+        return QueryResults(text, results)
+
+
+def load_ipython_extension(ipython: Any) -> None:
+    """Register %cidx / %%cidx (called by %load_ext)."""
+    ipython.register_magic_function(
+        CidxMagic(ipython), magic_kind="line_cell", magic_name="cidx"
+    )
@@ -0,0 +1,141 @@
+"""Typed results of the CidxClient library API."""
+
+from dataclasses import asdict, dataclass, field
+from typing import Any, Dict, List, Optional
+
+
+@dataclass
+class QueryResult:
+    """One semantic search match."""
+
+    path: str
+    score: float
+    line_start: int
+    line_end: int
+    content: str
+    language: Optional[str] = None
+    # Repository alias of remote results, None for local results
+    repository: Optional[str] = None
+    # Full stored payload (local results only)
+    payload: Dict[str, Any] = field(default_factory=dict)
+
+    @classmethod
+    def from_local(cls, result: Dict[str, Any], content: str) -> "QueryResult":
+        """Result from a vector store search result."""
+        payload = result.get("payload", {})
+        return cls(
+            path=payload.get("path", ""),
+            score=float(result.get("score", 0.0)),
+            line_start=int(payload.get("line_start") or 0),
+            line_end=int(payload.get("line_end") or 0),
+            content=content,
+            language=payload.get("language"),
+            payload=payload,
+        )
+
+    @classmethod
+    def from_remote(cls, item: Any) -> "QueryResult":
+        """Result from a server QueryResultItem."""
+        snippet = item.code_snippet or ""
+        return cls(
+            path=item.file_path,
+            score=float(item.similarity_score),
+            line_start=item.line_number,
+            line_end=item.line_number + max(snippet.count("\n"), 0),
+            content=snippet,
+            repository=item.repository_alias,
+        )
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class IndexResult:
+    """Outcome of an indexing run."""
+
+    files_processed: int
+    chunks_created: int
+    failed_files: int
+    duration_seconds: float
+    cancelled: bool = False
+
+    @classmethod
+    def from_stats(cls, stats: Any) -> "IndexResult":
+        """Result from the ProcessingStats of SmartIndexer.smart_index()."""
+        return cls(
+            files_processed=stats.files_processed,
+            chunks_created=stats.chunks_created,
+            failed_files=stats.failed_files,
+            duration_seconds=stats.duration,
+            cancelled=stats.cancelled,
+        )
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class IndexStatus:
+    """State of the index a client works with."""
+
+    mode: str
+    project_root: str
+    # Local mode
+    collection: Optional[str] = None
+    indexed_files: int = 0
+    chunks: int = 0
+    # Remote mode
+    server_url: Optional[str] = None
+    repository: Optional[str] = None
+    branch: Optional[str] = None
+    branches: List[str] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class SymbolResult:
+    """Location of a symbol definition from a SCIP index."""
+
+    symbol: str
+    path: str
+    line: int
+    column: int
+
+    @classmethod
+    def from_scip(cls, result: Any) -> "SymbolResult":
+        """Result from a SCIP query QueryResult."""
+        return cls(
+            symbol=result.symbol,
+            path=result.file_path,
+            line=result.line,
+            column=result.column,
+        )
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class Repository:
+    """A repository activated for the user on a CIDX server."""
+
+    alias: str
+    golden_alias: str
+    branch: str
+    status: str
+
+    @classmethod
+    def from_activated(cls, repository: Any) -> "Repository":
+        """Repository from a server ActivatedRepository."""
+        return cls(
+            alias=repository.user_alias,
+            golden_alias=repository.golden_alias,
+            branch=repository.branch,
+            status=repository.status,
+        )
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
@@ -6,7 +6,7 @@ import yaml  # type: ignore
 from pathlib import Path
 from typing import List, Optional, Any, Literal, Tuple, Dict
 
-from pydantic import BaseModel, Field, field_validator
+from pydantic import BaseModel, Field, field_validator, model_validator
 
 logger = logging.getLogger(__name__)
 
@@ -78,6 +78,9 @@ def _validate_no_legacy_config(data: Dict[str, Any]) -> None:
             )
 
 
+DEFAULT_VOYAGE_API_ENDPOINT = "https://api.voyageai.com/v1/embeddings"
+
+
 class VoyageAIConfig(BaseModel):
     """Configuration for VoyageAI embedding service.
 
@@ -87,14 +90,35 @@ class VoyageAIConfig(BaseModel):
 
     # API configuration - API key should be set via VOYAGE_API_KEY environment variable
     api_endpoint: str = Field(
-        default="https://api.voyageai.com/v1/embeddings",
-        description="VoyageAI API endpoint URL",
+        default=DEFAULT_VOYAGE_API_ENDPOINT,
+        description=(
+            "VoyageAI-compatible embeddings endpoint URL (the public API, or a "
+            "gateway run by your team)"
+        ),
     )
     model: str = Field(
         default="voyage-code-3",
         description="VoyageAI embedding model name (e.g., voyage-code-3, voyage-large-2, voyage-2)",
     )
     timeout: int = Field(default=30, description="Request timeout in seconds")
+    api_key_env: str = Field(
+        default="VOYAGE_API_KEY",
+        description=(
+            "Environment variable holding the bearer token sent to api_endpoint "
+            "(e.g. the token of a team embedding gateway)"
+        ),
+    )
+    ca_bundle: Optional[str] = Field(
+        default=None,
+        description=(
+            "PEM file of CA certificates trusted for api_endpoint, for gateways "
+            "behind a private CA (None = system CA store)"
+        ),
+    )
+    verify_tls: bool = Field(
+        default=True,
+        description="Verify the TLS certificate of api_endpoint",
+    )
 
     # Parallel processing configuration
     parallel_requests: int = Field(
@@ -104,6 +128,27 @@ class VoyageAIConfig(BaseModel):
         default=128,
         description="Maximum number of texts to send in a single batch request",
     )
+    auto_tune: bool = Field(
+        default=False,
+        description=(
+            "Automatically tune parallel requests and batch sizes during the first "
+            "minutes of indexing based on provider latency, CPU load and vector "
+            "store ingest rate"
+        ),
+    )
+    auto_tune_max_parallel_requests: int = Field(
+        default=32,
+        ge=1,
+        description="Upper bound for parallel requests when auto_tune is enabled",
+    )
+    batch_latency_target: Optional[float] = Field(
+        default=None,
+        gt=0,
+        description=(
+            "Target seconds per embedding request: batches are split or merged "
+            "during indexing to stay near it (None = fixed batch token budget)"
+        ),
+    )
     max_concurrent_batches_per_commit: int = Field(
         default=10,
         description="Maximum number of batches a single commit can have in-flight simultaneously (prevents monopolization)",
@@ -125,6 +170,316 @@ class VoyageAIConfig(BaseModel):
 VoyageConfig = VoyageAIConfig
 
 
+class LanguageChunkingConfig(BaseModel):
+    """Chunk size settings of one language."""
+
+    chunk_max_tokens: Optional[int] = Field(
+        default=None,
+        gt=0,
+        description=(
+            "Maximum tokens of a chunk, counted with the embedding model's "
+            "tokenizer (None = model-aware default size)"
+        ),
+    )
+    chunk_overlap: Optional[int] = Field(
+        default=None,
+        ge=0,
+        description="Overlap between windows in characters (None = 15% of the size)",
+    )
+    min_chunk_chars: int = Field(
+        default=0,
+        ge=0,
+        description="Chunks shorter than this are merged into the chunk before them",
+    )
+
+    @model_validator(mode="after")
+    def validate_overlap(self) -> "LanguageChunkingConfig":
+        """Validate that the overlap is smaller than the chunk size."""
+        from .indexing.fixed_size_chunker import CHARS_PER_TOKEN
+
+        if (
+            self.chunk_max_tokens is not None
+            and self.chunk_overlap is not None
+            and self.chunk_overlap >= self.chunk_max_tokens * CHARS_PER_TOKEN
+        ):
+            raise ValueError(
+                f"chunk_overlap {self.chunk_overlap} must be smaller than the "
+                f"{self.chunk_max_tokens * CHARS_PER_TOKEN} characters of "
+                f"chunk_max_tokens {self.chunk_max_tokens}"
+            )
+        return self
+
+
+class PiiScrubbingConfig(BaseModel):
+    """Configuration for masking PII in chunk text before embedding."""
+
+    enabled: bool = Field(
+        default=False,
+        description="Mask PII in chunk text before it is embedded and stored",
+    )
+    builtin_patterns: List[Literal["email", "phone", "ssn", "credit_card"]] = Field(
+        default_factory=lambda: ["email", "phone", "ssn", "credit_card"],
+        description="Built-in PII patterns to mask",
+    )
+    custom_patterns: Dict[str, str] = Field(
+        default_factory=dict,
+        description="Additional patterns to mask as {name: regular expression}",
+    )
+    string_literals_only: bool = Field(
+        default=False,
+        description=(
+            "In source files, only mask inside string literals (data files such "
+            "as JSON, YAML and CSV are always masked entirely)"
+        ),
+    )
+
+    @field_validator("custom_patterns")
+    @classmethod
+    def validate_custom_patterns(cls, v: Dict[str, str]) -> Dict[str, str]:
+        """Validate custom pattern names and regular expressions."""
+        import re
+
+        for name, pattern in v.items():
+            if not re.fullmatch(r"[A-Za-z][A-Za-z0-9_]*", name):
+                raise ValueError(
+                    f"Invalid PII pattern name '{name}': use letters, digits "
+                    "and underscores"
+                )
+            try:
+                re.compile(pattern)
+            except re.error as e:
+                raise ValueError(f"Invalid PII pattern '{name}': {e}")
+        return v
+
+
+class BoilerplateFilterConfig(BaseModel):
+    """Configuration for removing boilerplate from the text that is embedded."""
+
+    enabled: bool = Field(
+        default=False,
+        description=(
+            "Remove boilerplate from the text sent to the embedding provider "
+            "(stored chunk text is unchanged)"
+        ),
+    )
+    filters: List[Literal["license_headers", "imports", "accessors"]] = Field(
+        default_factory=lambda: ["license_headers", "imports", "accessors"],
+        description="Kinds of boilerplate to remove",
+    )
+    min_import_lines: int = Field(
+        default=10,
+        ge=1,
+        description="Import blocks with at least this many lines are removed",
+    )
+
+
+class TaskMarkersConfig(BaseModel):
+    """Configuration for extracting TODO/FIXME-style task comments."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Record task comments of each chunk for 'cidx todos'",
+    )
+    markers: List[str] = Field(
+        default_factory=lambda: ["TODO", "FIXME", "HACK", "XXX"],
+        description="Comment markers to extract (matched case-sensitively)",
+    )
+    blame: bool = Field(
+        default=True,
+        description="Record author and date of task comments via git blame",
+    )
+
+
+class LicenseDetectionConfig(BaseModel):
+    """Configuration for classifying the license of indexed files."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Record the SPDX license of each file for query filters",
+    )
+    use_license_files: bool = Field(
+        default=True,
+        description=(
+            "Files without a license header inherit the license of the "
+            "nearest LICENSE/COPYING file"
+        ),
+    )
+
+
+class CodeOwnersConfig(BaseModel):
+    """Configuration for CODEOWNERS-based ownership metadata."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Record the CODEOWNERS owners of each file for --owner filters",
+    )
+
+
+class StructTagsConfig(BaseModel):
+    """Configuration for recording Go struct tags as payload fields."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Record the struct tags of Go files for --struct-tag filters",
+    )
+
+
+class GoInterfacesConfig(BaseModel):
+    """Configuration for linking Go types to the interfaces they implement."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Record the interfaces each Go type implements for --implements",
+    )
+
+
+class GoBuildConstraintsConfig(BaseModel):
+    """Configuration for recording the build constraints of Go files."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Record //go:build constraints and GOOS/GOARCH file suffixes",
+    )
+    default_platform: Optional[str] = Field(
+        default=None,
+        description=(
+            "Platform queries are limited to when --platform is not given, "
+            "e.g. 'linux' or 'linux/amd64' (None = all platforms)"
+        ),
+    )
+
+    @field_validator("default_platform")
+    @classmethod
+    def validate_default_platform(cls, v: Optional[str]) -> Optional[str]:
+        """Validate the GOOS and GOARCH of the default platform."""
+        if v is None:
+            return v
+        from .services.go_build_constraints import parse_platform
+
+        parse_platform(v)
+        return v
+
+
+class GoDependenciesConfig(BaseModel):
+    """Configuration for resolving Go imports to modules."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Record the modules each Go file imports for 'cidx deps'",
+    )
+
+
+class ImportGraphConfig(BaseModel):
+    """Configuration for recording the import graph of the project's files."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Record the project files each file imports for 'cidx imports'",
+    )
+
+
+class TestLinkageConfig(BaseModel):
+    """Configuration for linking test files to the files they test."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Mark test files and link them to their subjects",
+    )
+
+
+class GeneratedCodeConfig(BaseModel):
+    """Configuration for detecting generated files."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Mark generated and minified files for --include-generated",
+    )
+
+
+class GrpcStubsConfig(BaseModel):
+    """Configuration for linking rpc definitions to generated gRPC stubs."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Record the generated stub files of each rpc and service",
+    )
+
+
+class DocCommentsConfig(BaseModel):
+    """Configuration for pairing doc comments with their declarations."""
+
+    enabled: bool = Field(
+        default=True,
+        description=(
+            "Record the doc comment of each chunk's first declaration and embed "
+            "it with the chunk when the chunk boundary split it off"
+        ),
+    )
+
+
+class SignatureVectorsConfig(BaseModel):
+    """Configuration for signature vectors of chunks."""
+
+    enabled: bool = Field(
+        default=False,
+        description=(
+            "Store a second vector per declaring chunk, embedding its signature "
+            "and doc comment, for 'cidx query --vector-mode signature|both'"
+        ),
+    )
+
+
+class CodeMetricsConfig(BaseModel):
+    """Configuration for size and complexity metrics of chunks."""
+
+    enabled: bool = Field(
+        default=True,
+        description=(
+            "Record lines of code, cyclomatic complexity, nesting depth and "
+            "parameter count of code chunks for --min-complexity filters"
+        ),
+    )
+
+
+class SymbolDeclarationsConfig(BaseModel):
+    """Configuration for recording the declarations of chunks."""
+
+    enabled: bool = Field(
+        default=True,
+        description=(
+            "Record the functions, methods and types declared by code chunks "
+            "for cidx symbol lookups"
+        ),
+    )
+
+
+class ChunkContextConfig(BaseModel):
+    """Configuration for embedding the enclosing context of chunks."""
+
+    enabled: bool = Field(
+        default=False,
+        description=(
+            "Prefix the embedded text of each chunk with its package, imports "
+            "and enclosing type (stored chunk text is unchanged)"
+        ),
+    )
+    max_imports: int = Field(
+        default=10,
+        ge=0,
+        description="Imports listed in the header before the rest are counted",
+    )
+
+
+class TypeParametersConfig(BaseModel):
+    """Configuration for recording Go type parameters as payload fields."""
+
+    enabled: bool = Field(
+        default=True,
+        description="Record the type parameters of generic Go declarations "
+        "for --symbol-kind generic",
+    )
+
+
 class IndexingConfig(BaseModel):
     """Configuration for indexing behavior."""
 
@@ -136,6 +491,294 @@ class IndexingConfig(BaseModel):
     index_comments: bool = Field(
         default=True, description="Include comments in indexing"
     )
+    throttle: Literal["normal", "low"] = Field(
+        default="normal",
+        description=(
+            "Resource usage level for 'cidx index' (low = fewer threads, "
+            "per-file pauses, lower CPU/IO priority)"
+        ),
+    )
+    watch_throttle: Literal["normal", "low"] = Field(
+        default="low",
+        description="Resource usage level for watch mode indexing",
+    )
+    hash_algorithm: Literal["sha256", "xxh3"] = Field(
+        default="sha256",
+        description=(
+            "Content hash used for change detection (xxh3 is much faster on "
+            "large repositories; git blob hashes are unaffected)"
+        ),
+    )
+    upsert_queue_size: int = Field(
+        default=0,
+        ge=0,
+        description=(
+            "Capacity of the bounded queue feeding a single vector storage "
+            "writer thread (0 = worker threads write in parallel, inline)"
+        ),
+    )
+    deduplicate_identical_files: bool = Field(
+        default=True,
+        description=(
+            "Chunk and embed files with identical content (e.g. vendored copies) "
+            "once and reuse the embeddings for every path"
+        ),
+    )
+    deduplicate_branch_content: bool = Field(
+        default=True,
+        description=(
+            "On branch switches, reuse the stored points of changed files whose "
+            "content is already indexed and only update their branch visibility"
+        ),
+    )
+    max_memory_mb: Optional[int] = Field(
+        default=None,
+        ge=256,
+        description=(
+            "Memory limit for indexing in MB: shrinks the upsert queue and "
+            "spills embedded points that do not fit to disk (None = unlimited)"
+        ),
+    )
+    document_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Markdown, reStructuredText and AsciiDoc files by heading "
+            "sections, embedding each chunk with its heading breadcrumb"
+        ),
+    )
+    proto_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Protocol Buffers files by message, enum and rpc, recording "
+            "package, full name and options"
+        ),
+    )
+    openapi_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk OpenAPI and Swagger specs by operation and schema, recording "
+            "operationId and tags"
+        ),
+    )
+    container_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Dockerfiles by build stage and Compose files by service, "
+            "recording base images, images, ports and volumes"
+        ),
+    )
+    kubernetes_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Kubernetes manifests and Helm templates by resource, "
+            "recording kind, name and namespace"
+        ),
+    )
+    component_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Vue and Svelte components by template, script and style "
+            "block, recording the component name and block language"
+        ),
+    )
+    shell_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk shell scripts by function and top-level case block, "
+            "recording function names"
+        ),
+    )
+    build_file_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Gradle, Maven and CMake build files by block, task and "
+            "target, recording dependency coordinates and plugins"
+        ),
+    )
+    dart_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Dart files by class, mixin, extension and function, with "
+            "Flutter widget build methods as chunks of their own"
+        ),
+    )
+    objc_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Objective-C files by interface, implementation, category, "
+            "method and C function"
+        ),
+    )
+    julia_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Julia files by function, macro, struct, abstract type and "
+            "module"
+        ),
+    )
+    r_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk R files by function and by S4, reference and R6 class "
+            "definitions"
+        ),
+    )
+    cobol_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk COBOL programs by division, section and paragraph, and "
+            "resolve the copybooks named by their COPY statements"
+        ),
+    )
+    hdl_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Verilog and SystemVerilog modules by always block, function "
+            "and task, and VHDL entities and architectures by process, "
+            "recording their port lists"
+        ),
+    )
+    rust_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Rust files by function, struct, enum, trait, impl block, "
+            "macro_rules! definition and inline module, recording derives"
+        ),
+    )
+    zig_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Zig files by function, container, error set, test and "
+            "comptime block, splitting containers at their functions"
+        ),
+    )
+    beam_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Elixir files by module, protocol, implementation and "
+            "function, and Erlang files by function, keeping clauses together"
+        ),
+    )
+    scala_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk Scala files by class, trait, object, enum, method, given "
+            "and extension, splitting containers at their definitions"
+        ),
+    )
+    embedded_chunking: bool = Field(
+        default=True,
+        description=(
+            "Chunk <script> and <style> blocks of HTML pages and templates, "
+            "ERB and Blade template code, and Markdown code blocks as code of "
+            "their own language"
+        ),
+    )
+    micro_chunk_chars: int = Field(
+        default=0,
+        ge=0,
+        description=(
+            "Adjacent methods and functions of the same class shorter than this "
+            "are merged into one chunk listing their names (0 = off)"
+        ),
+    )
+    language_chunking: Dict[str, LanguageChunkingConfig] = Field(
+        default_factory=dict,
+        description=(
+            "Chunk size, overlap and minimum chunk length per language token "
+            "(py, go, md, ...)"
+        ),
+    )
+    pii_scrubbing: PiiScrubbingConfig = Field(
+        default_factory=PiiScrubbingConfig,
+        description="Masking of emails, phone numbers and other PII in chunk text",
+    )
+    boilerplate_filter: BoilerplateFilterConfig = Field(
+        default_factory=BoilerplateFilterConfig,
+        description=(
+            "Removal of license headers, import blocks and accessors before "
+            "embedding"
+        ),
+    )
+    task_markers: TaskMarkersConfig = Field(
+        default_factory=TaskMarkersConfig,
+        description="Extraction of TODO/FIXME/HACK comments for 'cidx todos'",
+    )
+    license_detection: LicenseDetectionConfig = Field(
+        default_factory=LicenseDetectionConfig,
+        description="Per-file license classification for --license filters",
+    )
+    code_owners: CodeOwnersConfig = Field(
+        default_factory=CodeOwnersConfig,
+        description="Per-file CODEOWNERS owners for --owner filters",
+    )
+    struct_tags: StructTagsConfig = Field(
+        default_factory=StructTagsConfig,
+        description="Go struct tags (json, db, gorm, ...) for --struct-tag filters",
+    )
+    go_interfaces: GoInterfacesConfig = Field(
+        default_factory=GoInterfacesConfig,
+        description="Go interface implementations for --implements filters",
+    )
+    go_build_constraints: GoBuildConstraintsConfig = Field(
+        default_factory=GoBuildConstraintsConfig,
+        description="Go build constraints (OS, architecture, tags) of each file",
+    )
+    go_dependencies: GoDependenciesConfig = Field(
+        default_factory=GoDependenciesConfig,
+        description="Go imports resolved via go.mod/go.sum for 'cidx deps'",
+    )
+    import_graph: ImportGraphConfig = Field(
+        default_factory=ImportGraphConfig,
+        description="Imports resolved to project files for 'cidx imports'",
+    )
+    test_linkage: TestLinkageConfig = Field(
+        default_factory=TestLinkageConfig,
+        description="Test-to-subject file links for --only-tests/--exclude-tests",
+    )
+    generated_code: GeneratedCodeConfig = Field(
+        default_factory=GeneratedCodeConfig,
+        description="Generated files left out of queries unless --include-generated",
+    )
+    grpc_stubs: GrpcStubsConfig = Field(
+        default_factory=GrpcStubsConfig,
+        description="Links from .proto rpc definitions to generated gRPC stubs",
+    )
+    doc_comments: DocCommentsConfig = Field(
+        default_factory=DocCommentsConfig,
+        description="Doc comments and docstrings paired with their declarations",
+    )
+    chunk_context: ChunkContextConfig = Field(
+        default_factory=ChunkContextConfig,
+        description="Package, imports and enclosing type embedded with each chunk",
+    )
+    code_metrics: CodeMetricsConfig = Field(
+        default_factory=CodeMetricsConfig,
+        description="Size and complexity metrics of code chunks",
+    )
+    symbol_declarations: SymbolDeclarationsConfig = Field(
+        default_factory=SymbolDeclarationsConfig,
+        description="Functions, methods and types declared by code chunks",
+    )
+    signature_vectors: SignatureVectorsConfig = Field(
+        default_factory=SignatureVectorsConfig,
+        description="Signature and doc comment vectors stored next to chunk bodies",
+    )
+    type_parameters: TypeParametersConfig = Field(
+        default_factory=TypeParametersConfig,
+        description="Go type parameters and constraints for --symbol-kind generic",
+    )
+
+    @field_validator("language_chunking")
+    @classmethod
+    def normalize_language_chunking(
+        cls, v: Dict[str, LanguageChunkingConfig]
+    ) -> Dict[str, LanguageChunkingConfig]:
+        """Key language chunking settings by lowercase language token."""
+        return {
+            language.strip().lstrip(".").lower(): settings
+            for language, settings in v.items()
+        }
 
 
 class TimeoutsConfig(BaseModel):
@@ -256,6 +899,10 @@ class DaemonConfig(BaseModel):
     socket_base: Optional[str] = Field(
         default=None, description="Custom socket base directory (overrides socket_mode)"
     )
+    preload_on_start: bool = Field(
+        default=False,
+        description="Warm index caches and prime the embedding provider when the daemon starts",
+    )
 
     @field_validator("ttl_minutes")
     @classmethod
@@ -293,6 +940,141 @@ class TemporalConfig(BaseModel):
     )
 
 
+class EncryptionConfig(BaseModel):
+    """Configuration for encryption at rest of local index data."""
+
+    enabled: bool = Field(
+        default=False,
+        description="Encrypt chunk text and payloads of newly created collections",
+    )
+    key_source: Literal["keyring", "passphrase"] = Field(
+        default="keyring",
+        description=(
+            "Where the key of new collections comes from: a random key stored in "
+            "the OS keyring, or a key derived from a passphrase"
+        ),
+    )
+
+
+class LoggingConfig(BaseModel):
+    """Configuration for structured JSON logs of long-running processes."""
+
+    json_logs: bool = Field(
+        default=False,
+        description=(
+            "Write JSON log lines to rotating files while watch or the daemon runs"
+        ),
+    )
+    log_file: Optional[str] = Field(
+        default=None,
+        description=(
+            "Log file, relative to the project root "
+            "(default: .code-indexer/logs/<component>.jsonl)"
+        ),
+    )
+    level: Literal["DEBUG", "INFO", "WARNING", "ERROR"] = Field(
+        default="INFO", description="Minimum level of logged records"
+    )
+    max_bytes: int = Field(
+        default=10 * 1024 * 1024,
+        ge=1024,
+        description="Size in bytes at which the log file is rotated",
+    )
+    backup_count: int = Field(
+        default=5, ge=1, le=100, description="Rotated log files kept"
+    )
+
+
+class HookConfig(BaseModel):
+    """One lifecycle hook: an external command or a Python plugin."""
+
+    command: Optional[str] = Field(
+        default=None,
+        description=(
+            "Command run with the hook event as JSON on stdin; prints the "
+            "changed event fields as JSON (nothing = unchanged)"
+        ),
+    )
+    plugin: Optional[str] = Field(
+        default=None,
+        description=(
+            "Python callable 'package.module:function' or "
+            "'path/to/file.py:function' (relative to the project root)"
+        ),
+    )
+    timeout_seconds: int = Field(
+        default=30, ge=1, description="Timeout of one command run"
+    )
+    on_error: Literal["fail", "ignore"] = Field(
+        default="fail",
+        description=(
+            "fail: a failing hook fails the file or query; ignore: log it and "
+            "continue with unchanged data"
+        ),
+    )
+
+
+class HooksConfig(BaseModel):
+    """Lifecycle hooks, run in the listed order at each hook point."""
+
+    pre_index: List[HookConfig] = Field(
+        default_factory=list,
+        description="Per file before indexing; may veto the file",
+    )
+    post_chunk: List[HookConfig] = Field(
+        default_factory=list,
+        description="Per file after chunking; may change, drop or annotate chunks",
+    )
+    pre_embed: List[HookConfig] = Field(
+        default_factory=list,
+        description="Per file before embedding; may change the embedded texts",
+    )
+    post_query: List[HookConfig] = Field(
+        default_factory=list,
+        description="Per semantic query; may re-order, drop or annotate results",
+    )
+
+
+class QueryGenerationConfig(BaseModel):
+    """Configuration for synthetic query generation ('cidx generate-queries')."""
+
+    llm_command: Optional[str] = Field(
+        default=None,
+        description=(
+            "Command that reads a prompt on stdin and prints one query per line, "
+            "e.g. 'claude --print' (None = templates over symbols and doc comments)"
+        ),
+    )
+    queries_per_chunk: int = Field(
+        default=3, ge=1, le=20, description="Candidate queries generated per chunk"
+    )
+    timeout_seconds: int = Field(
+        default=120, ge=1, description="Timeout of one llm_command run"
+    )
+
+
+class QueryExpansionConfig(BaseModel):
+    """Configuration for HyDE query expansion ('cidx query --hyde')."""
+
+    llm_command: Optional[str] = Field(
+        default=None,
+        description=(
+            "Command that reads a prompt on stdin and prints hypothetical code "
+            "answering the query (None = query_generation.llm_command)"
+        ),
+    )
+    mode: Literal["fuse", "replace"] = Field(
+        default="fuse",
+        description=(
+            "Embed the hypothetical code averaged with the query (fuse) or "
+            "instead of it (replace)"
+        ),
+    )
+    timeout_seconds: int = Field(
+        default=60, ge=1, description="Timeout of one llm_command run"
+    )
+
+
 class GlobalRefreshConfig(BaseModel):
     """Configuration for global repository refresh intervals."""
 
@@ -408,6 +1190,7 @@ class Config(BaseModel):
             "psgi",
             "sh",
             "bash",
+            "zsh",
             "html",
             "css",
             "md",
@@ -421,6 +1204,23 @@ class Config(BaseModel):
             "kts",
             "scala",
             "dart",
+            "m",  # Objective-C
+            "mm",  # Objective-C++
+            "jl",  # Julia
+            "R",  # R
+            "r",  # R
+            "cbl",  # COBOL
+            "cob",  # COBOL
+            "cpy",  # COBOL copybooks
+            "CBL",  # COBOL (mainframe exports are often upper case)
+            "COB",  # COBOL
+            "CPY",  # COBOL copybooks
+            "v",  # Verilog
+            "vh",  # Verilog headers
+            "sv",  # SystemVerilog
+            "svh",  # SystemVerilog headers
+            "vhd",  # VHDL
+            "vhdl",  # VHDL
             "vue",
             "jsx",
             "pas",
@@ -445,8 +1245,23 @@ class Config(BaseModel):
             "rbw",  # Ruby
             "gemspec",  # Ruby gems
             "htm",  # HTML
+            "erb",  # ERB templates
+            "j2",  # Jinja templates
+            "jinja",  # Jinja templates
+            "jinja2",  # Jinja templates
             "scss",  # SCSS
             "sass",  # Sass
+            "zig",  # Zig
+            "ex",  # Elixir
+            "exs",  # Elixir scripts
+            "erl",  # Erlang
+            "hrl",  # Erlang headers
+            "proto",  # Protocol Buffers
+            "svelte",  # Svelte
+            # Files without an extension, recognized by name
+            "makefile",  # Makefile, GNUmakefile
+            "dockerfile",  # Dockerfile, Containerfile
+            "cmake",  # CMakeLists.txt, .cmake
         ],
         description="File extensions to index",
     )
@@ -469,6 +1284,10 @@ class Config(BaseModel):
             ".nuxt",
             "dist-*",
             ".code-indexer",
+            "zig-out",  # Zig build output
+            ".zig-cache",  # Zig build cache
+            "zig-cache",  # Zig build cache (before 0.13)
+            "_build",  # Mix and rebar3 build output
         ],
         description="Directories to exclude from indexing",
     )
@@ -517,6 +1336,36 @@ class Config(BaseModel):
         description="Temporal (git history) indexing configuration",
     )
 
+    # Encryption at rest configuration
+    encryption: EncryptionConfig = Field(
+        default_factory=EncryptionConfig,
+        description="Encryption at rest of local index data",
+    )
+
+    # Structured logging configuration
+    logging: LoggingConfig = Field(
+        default_factory=LoggingConfig,
+        description="Structured JSON logging of long-running processes",
+    )
+
+    # Synthetic query generation configuration
+    query_generation: QueryGenerationConfig = Field(
+        default_factory=QueryGenerationConfig,
+        description="Candidate queries for evaluation sets",
+    )
+
+    # HyDE query expansion configuration
+    query_expansion: QueryExpansionConfig = Field(
+        default_factory=QueryExpansionConfig,
+        description="Hypothetical code embedded for --hyde queries",
+    )
+
+    # Lifecycle hook configuration
+    hooks: HooksConfig = Field(
+        default_factory=HooksConfig,
+        description="Scripts and plugins run at indexing and query hook points",
+    )
+
     # Global refresh configuration
     global_refresh: GlobalRefreshConfig = Field(
         default_factory=GlobalRefreshConfig,
@@ -941,8 +1790,16 @@ code-indexer index --clear
             ttl_minutes: Cache TTL in minutes (default: 10)
 
         Raises:
-            ValueError: If ttl_minutes is invalid
+            ValueError: If ttl_minutes is invalid or the platform has no daemon
         """
+        from .utils.platform_support import (
+            DAEMON_SUPPORTED,
+            DAEMON_UNSUPPORTED_REASON,
+        )
+
+        if not DAEMON_SUPPORTED:
+            raise ValueError(DAEMON_UNSUPPORTED_REASON)
+
         # Validate TTL before creating config
         if ttl_minutes < 1:
             raise ValueError("TTL must be positive")
@@ -1044,8 +1901,11 @@ code-indexer index --clear
 
         Returns:
             Dictionary containing daemon configuration. If no daemon config exists,
-            returns defaults with enabled=False.
+            returns defaults with enabled=False. enabled is always False on
+            platforms without daemon support (Windows).
         """
+        from .utils.platform_support import DAEMON_SUPPORTED
+
         config = self.get_config()
 
         # If no daemon config, return defaults
@@ -1054,7 +1914,10 @@ code-indexer index --clear
 
         # Merge with defaults to ensure all fields present
         daemon_dict = config.daemon.model_dump()
-        return {**self.DAEMON_DEFAULTS, **daemon_dict}
+        merged = {**self.DAEMON_DEFAULTS, **daemon_dict}
+        if not DAEMON_SUPPORTED:
+            merged["enabled"] = False
+        return merged
 
     def get_socket_path(self) -> Path:
         """Get daemon socket path using system-wide directory.
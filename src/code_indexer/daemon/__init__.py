@@ -8,6 +8,7 @@ Key Components:
 - CacheEntry: In-memory cache for HNSW and Tantivy indexes
 - TTLEvictionThread: Background thread for cache eviction
 - DaemonServer: Server startup with socket binding as atomic lock
+- EditorServer: Loopback HTTP API for editor extensions ('cidx daemon')
 """
 
 __all__ = [
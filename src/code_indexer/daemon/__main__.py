@@ -66,9 +66,27 @@ def main():
         print(f"ERROR: Config path is not a file: {config_path}", file=sys.stderr)
         sys.exit(1)
 
+    # Structured JSON logs when enabled in config.json (logging.json_logs)
+    try:
+        from code_indexer.config import ConfigManager
+        from code_indexer.utils.json_logging import configure_project_json_logging
+
+        configure_project_json_logging(ConfigManager(config_path).load(), "daemon")
+    except Exception as e:
+        logger.warning(f"JSON logging not enabled: {e}")
+
     # Start daemon
     logger.info(f"Starting daemon for {config_path}")
-    start_daemon(config_path)
+    try:
+        start_daemon(config_path)
+    except Exception as e:
+        from code_indexer.utils.crash_report import write_crash_bundle
+
+        logger.exception(f"Daemon failed: {e}")
+        bundle_path = write_crash_bundle(config_path.parent.parent, e)
+        if bundle_path is not None:
+            logger.error(f"Diagnostic bundle written to {bundle_path}")
+        sys.exit(1)
 
 
 if __name__ == "__main__":
@@ -0,0 +1,301 @@
+"""Editor-integration daemon ('cidx daemon').
+
+A small JSON-over-HTTP API on 127.0.0.1 for editor extensions (VS Code,
+Neovim) that query on every keystroke and cannot pay CLI startup per call.
+One daemon serves every project: each request names a file or directory and
+is routed to the project containing it. Per project, the CidxClient with its
+embedding provider and vector store stays warm, and loaded HNSW indexes are
+shared in memory until the index changes on disk.
+
+Every request is a POST with a JSON object body and an
+"Authorization: Bearer <token>" header:
+
+- /query  {"project", "query", "limit"?, "languages"?, "paths"?, "min_score"?}
+- /symbol {"project", "name", "exact"?, "limit"?}
+- /status {"project"?}: project index status, or the daemon status
+
+Responses are JSON objects with the results and "took_ms", or {"error"}
+with a 4xx/5xx status. The port and token are written to STATE_FILE
+(readable only by the user) for editors to discover.
+"""
+
+import hmac
+import json
+import logging
+import os
+import secrets
+import threading
+import time
+from collections import OrderedDict
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional
+
+from ..client import CidxClient, CidxClientError
+
+logger = logging.getLogger(__name__)
+
+STATE_FILE = Path.home() / ".code-indexer" / "editor-daemon.json"
+
+# Projects kept warm; the least recently used one is dropped beyond this
+DEFAULT_MAX_PROJECTS = 8
+
+# HNSW indexes unused this long are dropped from memory
+HNSW_CACHE_TTL_MINUTES = 30
+
+MAX_REQUEST_BYTES = 1024 * 1024
+
+
+class EditorApiError(Exception):
+    """A request the API rejects, with the HTTP status to answer with."""
+
+    def __init__(self, message: str, status: int = 400):
+        super().__init__(message)
+        self.status = status
+
+
+def _strings(request: Dict[str, Any], key: str) -> List[str]:
+    values = request.get(key) or []
+    if not isinstance(values, list) or not all(isinstance(v, str) for v in values):
+        raise EditorApiError(f"'{key}' must be a list of strings")
+    return values
+
+
+class EditorApi:
+    """Routes editor requests to warm per-project clients."""
+
+    def __init__(
+        self,
+        hnsw_cache: Optional[Any] = None,
+        max_projects: int = DEFAULT_MAX_PROJECTS,
+        client_factory: Callable[..., CidxClient] = CidxClient,
+    ):
+        """
+        Initialize the API.
+
+        Args:
+            hnsw_cache: HNSWIndexCache shared by all projects (default: a new
+                cache with HNSW_CACHE_TTL_MINUTES)
+            max_projects: Projects kept warm at once
+            client_factory: Creates the client of a project root
+        """
+        if hnsw_cache is None:
+            from ..server.cache.hnsw_index_cache import (
+                HNSWIndexCache,
+                HNSWIndexCacheConfig,
+            )
+
+            hnsw_cache = HNSWIndexCache(
+                HNSWIndexCacheConfig(ttl_minutes=HNSW_CACHE_TTL_MINUTES)
+            )
+        self.hnsw_cache = hnsw_cache
+        self.max_projects = max_projects
+        self.client_factory = client_factory
+        self.clients: "OrderedDict[Path, CidxClient]" = OrderedDict()
+        self.lock = threading.Lock()
+        self.started = time.time()
+
+    def client(self, project: Any) -> CidxClient:
+        """Warm client of the project containing a file or directory."""
+        from ..mode_detection.command_mode_detector import find_project_root
+
+        if not isinstance(project, str) or not os.path.isabs(project):
+            raise EditorApiError("'project' must be an absolute path")
+        start = Path(project)
+        if start.is_file():
+            start = start.parent
+        root = find_project_root(start)
+        with self.lock:
+            client = self.clients.get(root)
+            if client is not None:
+                self.clients.move_to_end(root)
+                return client
+        # Created outside the lock: a slow project must not block the others
+        try:
+            client = self.client_factory(root, hnsw_cache=self.hnsw_cache)
+        except CidxClientError as e:
+            raise EditorApiError(str(e), status=404) from e
+        with self.lock:
+            client = self.clients.setdefault(root, client)
+            self.clients.move_to_end(root)
+            while len(self.clients) > self.max_projects:
+                self.clients.popitem(last=False)
+        return client
+
+    def handle(self, endpoint: str, request: Dict[str, Any]) -> Dict[str, Any]:
+        """
+        Answer one request.
+
+        Raises:
+            EditorApiError: For unknown endpoints, bad parameters and
+                failed operations
+        """
+        handlers = {
+            "/query": self._query,
+            "/symbol": self._symbol,
+            "/status": self._status,
+        }
+        handler = handlers.get(endpoint)
+        if handler is None:
+            raise EditorApiError(f"Unknown endpoint {endpoint}", status=404)
+        started = time.monotonic()
+        try:
+            response = handler(request)
+        except CidxClientError as e:
+            raise EditorApiError(str(e), status=500) from e
+        except (TypeError, ValueError) as e:
+            raise EditorApiError(f"Invalid request: {e}") from e
+        response["took_ms"] = round((time.monotonic() - started) * 1000, 1)
+        return response
+
+    def _query(self, request: Dict[str, Any]) -> Dict[str, Any]:
+        text = request.get("query")
+        if not isinstance(text, str) or not text.strip():
+            raise EditorApiError("'query' is required")
+        results = self.client(request.get("project")).query(
+            text,
+            limit=int(request.get("limit", 10)),
+            languages=_strings(request, "languages"),
+            paths=_strings(request, "paths"),
+            min_score=request.get("min_score"),
+        )
+        return {"results": [result.to_dict() for result in results]}
+
+    def _symbol(self, request: Dict[str, Any]) -> Dict[str, Any]:
+        name = request.get("name")
+        if not isinstance(name, str) or not name:
+            raise EditorApiError("'name' is required")
+        results = self.client(request.get("project")).symbol(
+            name,
+            exact=bool(request.get("exact", False)),
+            limit=int(request.get("limit", 20)),
+        )
+        return {"results": [result.to_dict() for result in results]}
+
+    def _status(self, request: Dict[str, Any]) -> Dict[str, Any]:
+        from code_indexer import __version__
+
+        if request.get("project") is not None:
+            return {"status": self.client(request["project"]).status().to_dict()}
+        with self.lock:
+            projects = [str(root) for root in self.clients]
+        return {
+            "version": __version__,
+            "pid": os.getpid(),
+            "uptime_seconds": int(time.time() - self.started),
+            "projects": projects,
+        }
+
+
+class _Handler(BaseHTTPRequestHandler):
+    """HTTP front end of EditorApi."""
+
+    server: "EditorServer"
+    protocol_version = "HTTP/1.1"  # Keep-alive: no connection setup per call
+
+    def do_POST(self) -> None:
+        try:
+            self._authorize()
+            request = self._read_json()
+            response = self.server.api.handle(self.path, request)
+            self._send(200, response)
+        except EditorApiError as e:
+            self._fail(e.status, str(e))
+        except Exception as e:
+            logger.exception(f"Editor API request {self.path} failed")
+            self._fail(500, str(e))
+
+    def do_GET(self) -> None:
+        self._fail(405, "Use POST with a JSON body")
+
+    def _fail(self, status: int, message: str) -> None:
+        # The request body may be unread: don't reuse the connection
+        self.close_connection = True
+        self._send(status, {"error": message})
+
+    def _authorize(self) -> None:
+        # Browsers send Origin; editors don't. Refuse pages on localhost.
+        if self.headers.get("Origin"):
+            raise EditorApiError("Browser requests are not allowed", status=403)
+        expected = f"Bearer {self.server.token}"
+        given = self.headers.get("Authorization", "")
+        if not hmac.compare_digest(given.encode(), expected.encode()):
+            raise EditorApiError("Missing or wrong token", status=401)
+
+    def _read_json(self) -> Dict[str, Any]:
+        length = int(self.headers.get("Content-Length") or 0)
+        if length > MAX_REQUEST_BYTES:
+            raise EditorApiError("Request too large", status=413)
+        body = self.rfile.read(length) if length else b"{}"
+        try:
+            request = json.loads(body)
+        except ValueError as e:
+            raise EditorApiError(f"Invalid JSON: {e}") from e
+        if not isinstance(request, dict):
+            raise EditorApiError("Request body must be a JSON object")
+        return request
+
+    def _send(self, status: int, payload: Dict[str, Any]) -> None:
+        body = json.dumps(payload, default=str).encode("utf-8")
+        self.send_response(status)
+        self.send_header("Content-Type", "application/json")
+        self.send_header("Content-Length", str(len(body)))
+        self.end_headers()
+        self.wfile.write(body)
+
+    def log_message(self, format: str, *args: Any) -> None:
+        logger.debug(f"{self.address_string()} {format % args}")
+
+
+class EditorServer(ThreadingHTTPServer):
+    """Loopback-only HTTP server of the editor API."""
+
+    daemon_threads = True
+
+    def __init__(self, api: EditorApi, port: int = 0, token: Optional[str] = None):
+        """
+        Bind 127.0.0.1.
+
+        Args:
+            api: API answering requests
+            port: TCP port (0 picks a free one)
+            token: Bearer token required on every request (default: random)
+        """
+        super().__init__(("127.0.0.1", port), _Handler)
+        self.api = api
+        self.token = token or secrets.token_urlsafe(32)
+
+    @property
+    def port(self) -> int:
+        return int(self.server_address[1])
+
+    def write_state(self, state_file: Path = STATE_FILE) -> None:
+        """Publish port and token for editors (file readable by the user only)."""
+        state_file.parent.mkdir(parents=True, exist_ok=True)
+        tmp_file = state_file.with_name(f".{state_file.name}.{os.getpid()}")
+        fd = os.open(str(tmp_file), os.O_WRONLY | os.O_CREAT | os.O_TRUNC, 0o600)
+        with os.fdopen(fd, "w") as f:
+            json.dump(
+                {
+                    "url": f"http://127.0.0.1:{self.port}",
+                    "port": self.port,
+                    "token": self.token,
+                    "pid": os.getpid(),
+                },
+                f,
+                indent=2,
+            )
+        os.replace(tmp_file, state_file)
+
+    def serve(self, state_file: Path = STATE_FILE) -> None:
+        """Serve until interrupted, publishing the state file meanwhile."""
+        self.write_state(state_file)
+        try:
+            self.serve_forever()
+        finally:
+            self.server_close()
+            try:
+                if json.loads(state_file.read_text()).get("pid") == os.getpid():
+                    state_file.unlink()
+            except (OSError, ValueError):
+                pass
@@ -53,6 +53,13 @@ def start_daemon(config_path: Path) -> None:
     # This ensures cache and watch state are shared, not per-connection
     shared_service = CIDXDaemonService()
 
+    # Warm caches while the socket comes up so the first query is fast
+    try:
+        if config_manager.get_daemon_config().get("preload_on_start"):
+            shared_service.exposed_preload(str(config_dir.parent))
+    except Exception as e:
+        logger.warning(f"Could not start cache preload: {e}")
+
     # Create and start RPyC server with shared service instance
     try:
         server = ThreadedServer(
@@ -1,6 +1,6 @@
 """CIDX Daemon Service - RPyC-based daemon for in-memory index caching.
 
-Provides 16 exposed methods for semantic search, FTS, temporal queries, watch mode, and daemon management.
+Provides 17 exposed methods for semantic search, FTS, temporal queries, watch mode, and daemon management.
 """
 
 from __future__ import annotations
@@ -24,12 +24,12 @@ logger = logging.getLogger(__name__)
 class CIDXDaemonService(Service):
     """RPyC daemon service for in-memory index caching.
 
-    Provides 16 exposed methods organized into categories:
+    Provides 17 exposed methods organized into categories:
     - Query Operations (4): query, query_fts, query_hybrid, query_temporal
     - Indexing (3): index_blocking, index, get_index_progress
     - Watch Mode (3): watch_start, watch_stop, watch_status
     - Storage Operations (3): clean, clean_data, status
-    - Daemon Management (4): get_status, clear_cache, shutdown, ping
+    - Daemon Management (5): get_status, preload, clear_cache, shutdown, ping
 
     Thread Safety:
         - cache_lock: Protects cache entry loading/replacement
@@ -73,6 +73,10 @@ class CIDXDaemonService(Service):
         self.indexing_error: Optional[str] = None
         self.indexing_stats: Optional[Dict[str, Any]] = None
 
+        # Warm-up preload state (cidx start --preload, daemon.preload_on_start)
+        self.preload_thread: Optional[threading.Thread] = None
+        self.preload_result: Optional[Dict[str, Any]] = None
+
         # Configuration (TODO: Load from config file)
         self.config = type("Config", (), {"auto_shutdown_on_idle": False})()
 
@@ -593,6 +597,18 @@ class CIDXDaemonService(Service):
             config_manager = ConfigManager.create_with_backtrack(Path(project_path))
             config = config_manager.get_config()
 
+            # Throttle limits threads and per-file pauses only - the daemon process
+            # priority is never lowered because it also serves queries
+            from code_indexer.services.indexing_throttle import (
+                apply_throttle_to_config,
+                get_configured_throttle_profile,
+            )
+
+            apply_throttle_to_config(
+                config,
+                kwargs.get("throttle") or get_configured_throttle_profile(config).name,
+            )
+
             # Create embedding provider and vector store
             embedding_provider = EmbeddingProviderFactory.create(config=config)
             backend = BackendFactory.create(config, Path(project_path))
@@ -1197,6 +1213,12 @@ class CIDXDaemonService(Service):
                 ),
             }
 
+        preload_status = {
+            "preload_running": self.preload_thread is not None
+            and self.preload_thread.is_alive(),
+            "preload_result": self.preload_result,
+        }
+
         # Get watch status from DaemonWatchManager
         watch_stats = self.watch_manager.get_stats()
         watch_status = {
@@ -1209,9 +1231,40 @@ class CIDXDaemonService(Service):
         return {
             **cache_status,
             **indexing_status,
+            **preload_status,
             **watch_status,
         }
 
+    def exposed_preload(
+        self, project_path: str, max_bytes: Optional[int] = None
+    ) -> Dict[str, Any]:
+        """Warm caches in the background so the first query is fast.
+
+        Loads semantic/FTS indexes into the daemon cache, reads index files
+        into the OS page cache and primes the embedding provider. Progress and
+        results are reported by exposed_get_status().
+
+        Args:
+            project_path: Path to project root
+            max_bytes: Page cache budget (default: DEFAULT_WARMUP_MAX_BYTES)
+
+        Returns:
+            Preload status ("started" or "running")
+        """
+        if self.preload_thread is not None and self.preload_thread.is_alive():
+            return {"status": "running"}
+
+        logger.info(f"exposed_preload: warming caches for {project_path}")
+        self.preload_result = None
+        self.preload_thread = threading.Thread(
+            target=self._run_preload,
+            args=(project_path, max_bytes),
+            name="DaemonPreload",
+            daemon=True,
+        )
+        self.preload_thread.start()
+        return {"status": "started"}
+
     def exposed_clear_cache(self) -> Dict[str, Any]:
         """Clear cache manually.
 
@@ -1320,6 +1373,25 @@ class CIDXDaemonService(Service):
                 # Load FTS indexes
                 self._load_fts_indexes(self.cache_entry)
 
+    def _run_preload(self, project_path: str, max_bytes: Optional[int]) -> None:
+        """Background warm-up for exposed_preload (never raises)."""
+        from code_indexer.services.index_warmup import (
+            DEFAULT_WARMUP_MAX_BYTES,
+            WarmupResult,
+            warm_project_index,
+        )
+
+        try:
+            self._ensure_cache_loaded(project_path)
+            result = warm_project_index(
+                Path(project_path),
+                max_bytes=max_bytes or DEFAULT_WARMUP_MAX_BYTES,
+            )
+        except Exception as e:
+            logger.error(f"Preload failed: {e}")
+            result = WarmupResult(project_root=project_path, errors=[str(e)])
+        self.preload_result = result.to_dict()
+
     def _load_semantic_indexes(self, entry: CacheEntry) -> None:
         """Load REAL HNSW index using HNSWIndexManager.
 
@@ -1587,6 +1659,20 @@ class CIDXDaemonService(Service):
                 results = results_raw
                 timing_info = {}
 
+            # Re-rank with recorded relevance feedback ('cidx feedback')
+            from code_indexer.services.relevance_feedback import FeedbackStore
+
+            results = FeedbackStore.for_project(
+                Path(project_path) / ".code-indexer"
+            ).rerank(results)
+
+            # Post-process with configured post_query hooks
+            from code_indexer.services.lifecycle_hooks import LifecycleHooks
+
+            query_hooks = LifecycleHooks.from_config(config, points=("post_query",))
+            if query_hooks is not None:
+                results = query_hooks.process_results(query, results)
+
             logger.info(f"Semantic search returned {len(results)} results")
             return results, timing_info
 
@@ -301,6 +301,19 @@ class DaemonWatchManager:
             else:
                 config_manager = ConfigManager.create_with_backtrack(Path(project_path))
 
+            # Watch defaults to low throttle (threads and per-file pauses only -
+            # daemon process priority stays normal so queries remain fast)
+            from code_indexer.services.indexing_throttle import (
+                apply_throttle_to_config,
+                get_configured_throttle_profile,
+            )
+
+            apply_throttle_to_config(
+                config,
+                kwargs.get("throttle")
+                or get_configured_throttle_profile(config, watch=True).name,
+            )
+
             # Create embedding provider and vector store
             embedding_provider = EmbeddingProviderFactory.create(config=config)
             backend = BackendFactory.create(config, Path(project_path))
@@ -94,6 +94,10 @@ COMMAND_COMPATIBILITY: Dict[str, Dict[str, bool]] = {
     },  # Server installation
     # Server management commands - local only since they manage local server instances
     "server": {"local": True, "remote": False, "proxy": False, "uninitialized": True},
+    # User service installation - watch or a local server as systemd/launchd service
+    "service": {"local": True, "remote": False, "proxy": False, "uninitialized": True},
+    # Git hooks triggering incremental indexing - local only, like index
+    "hooks": {"local": True, "remote": False, "proxy": False, "uninitialized": False},
     # Authentication commands - remote only since they manage remote server credentials
     "auth": {"local": False, "remote": True, "proxy": False, "uninitialized": False},
     # Repository synchronization - remote only since it syncs with remote server
@@ -144,6 +148,114 @@ COMMAND_COMPATIBILITY: Dict[str, Dict[str, bool]] = {
         "proxy": False,
         "uninitialized": False,
     },  # List collections with metadata
+    "compress-index": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Migrate local collections to compressed chunk storage
+    "encrypt-index": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Encrypt or decrypt stored chunk text of local collections
+    "bench": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Benchmark indexing and query throughput against a scratch index
+    "stats": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Locally recorded query latency percentiles
+    "verify": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Chunk integrity and provenance checks of the local index
+    "purge": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Retention purge of old points from the local index
+    "coverage": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Test coverage reports mapped onto the local index
+    "todos": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Task comments recorded in the local index
+    "deps": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Go module dependencies recorded in the local index
+    "imports": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # File import graph recorded in the local index
+    "symbol": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Symbol declarations recorded in the local index
+    "similar": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # "More like this" search of the local index
+    "clones": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Near-duplicate detection over local (or workspace) indexes
+    "refs": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # References from local SCIP indexes or the local semantic index
+    "hierarchy": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Call hierarchy from local SCIP indexes
+    "workspace": {
+        "local": True,
+        "remote": True,
+        "proxy": True,
+        "uninitialized": True,
+    },  # Local repositories searched together with 'cidx query --repos'
+    "feedback": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Relevance judgments re-ranking local queries
+    "generate-queries": {
+        "local": True,
+        "remote": False,
+        "proxy": False,
+        "uninitialized": False,
+    },  # Candidate evaluation queries generated from the local index
     # SCIP code intelligence commands - local only since they generate and query local SCIP indexes
     "scip": {
         "local": True,
@@ -151,6 +263,30 @@ COMMAND_COMPATIBILITY: Dict[str, Dict[str, bool]] = {
         "proxy": False,
         "uninitialized": False,
     },  # SCIP index generation and code navigation
+    "report-bug": {
+        "local": True,
+        "remote": True,
+        "proxy": True,
+        "uninitialized": True,
+    },  # Diagnostic bundle for bug reports
+    "telemetry": {
+        "local": True,
+        "remote": True,
+        "proxy": True,
+        "uninitialized": True,
+    },  # Opt-in usage telemetry settings (per user)
+    "daemon": {
+        "local": True,
+        "remote": True,
+        "proxy": True,
+        "uninitialized": True,
+    },  # Editor API serving every project on the machine
+    "dev": {
+        "local": True,
+        "remote": True,
+        "proxy": True,
+        "uninitialized": True,
+    },  # Contributor tools, independent of any index
     # Global repository commands - local only since they manage ~/.code-indexer/golden-repos
     "global": {
         "local": True,
@@ -198,6 +334,7 @@ COMMAND_ALTERNATIVES: Dict[str, str] = {
     "setup-global-registry": "Remote mode doesn't use local port registries. Server configuration handles resource management.",
     "install-server": "Remote mode connects to existing servers. Use 'cidx init' to configure remote server connection.",
     "server": "Remote mode doesn't manage local server instances. You're already connected to a remote server.",
+    "service": "Remote mode doesn't run local watch or server processes. Server indexes stay current automatically.",
     "list_jobs": "Local mode doesn't have background job management. Use remote mode to access server-side job monitoring capabilities.",
     "jobs": "Local mode doesn't have background job management. Use remote mode to access server-side job monitoring capabilities.",
     "admin": "Local mode doesn't have admin functions. Use remote mode to access server administration capabilities.",
@@ -335,6 +472,11 @@ def require_mode(*allowed_modes: str):
 
             current_mode = detect_current_mode()
 
+            # Workspace queries (--repos outside remote mode) resolve their
+            # repositories from the workspace registry, from any directory
+            if kwargs.get("repos") and current_mode == "uninitialized":
+                return command_func(*args, **kwargs)
+
             if current_mode not in allowed_modes:
                 # Extract command name from function
                 command_name = getattr(command_func, "__name__", "unknown")
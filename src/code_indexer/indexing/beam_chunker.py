@@ -0,0 +1,409 @@
+"""Declaration-aware chunking of Elixir and Erlang source files.
+
+Elixir files are split at their modules (`defmodule`), protocols
+(`defprotocol`) and implementations (`defimpl`), and each of these at its
+functions (`def`, `defp`), macros (`defmacro`, `defmacrop`), guards and
+delegates. Nested modules are split like top-level ones. `@doc`, `@spec`
+and `@impl` attributes and comments directly above a function belong to
+it; `use`, `alias`, `@moduledoc`, `defstruct` and other module code stay in
+a chunk of the module.
+
+Erlang files are split at their functions: the clauses of a function form
+one chunk, and the `-spec` and `-doc` attributes and comments directly
+above it belong to it. Module attributes, records and macros form a chunk
+of the module.
+
+Consecutive clauses of the same function are one chunk in both languages.
+Each chunk records:
+
+- "symbol_kind": module, protocol, impl, function, macro, guard, delegate
+  or file
+- "symbol_name": the qualified name with arity, "MyApp.Accounts.get_user/1"
+  in Elixir and "my_server:handle_call/3" in Erlang
+
+Strings, heredocs, sigils, character literals and comments are skipped
+when matching blocks. Declarations longer than the chunk size are split
+into overlapping windows like FixedSizeChunker does, and every window keeps
+these fields.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Set, Tuple
+
+from .boundary_chunking import (
+    DEGRADED_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    chunk_segments,
+    keep_segments,
+    leading_comments,
+)
+
+ELIXIR_LANGUAGES = {"ex", "exs"}
+ERLANG_LANGUAGES = {"erl", "hrl"}
+
+_EX_MODULE = re.compile(r"^\s*(defmodule|defprotocol)\s+([\w.]+)")
+_EX_IMPL = re.compile(r"^\s*defimpl\s+([\w.]+)(?:\s*,\s*for:\s*([\w.]+))?")
+_EX_FUNCTION = re.compile(
+    r"^\s*(def|defp|defmacro|defmacrop|defguard|defguardp|defdelegate)\s+"
+    r"([a-z_][\w]*[?!]?)"
+)
+_EX_KINDS = {
+    "def": "function",
+    "defp": "function",
+    "defmacro": "macro",
+    "defmacrop": "macro",
+    "defguard": "guard",
+    "defguardp": "guard",
+    "defdelegate": "delegate",
+    "defmodule": "module",
+    "defprotocol": "protocol",
+}
+# Attributes directly above a function that belong to it
+_EX_ATTRIBUTE = re.compile(r"^\s*@(doc|spec|impl|deprecated|since|dialyzer)\b")
+# Keywords opening and closing blocks; "do:" and ":end" are not keywords
+_EX_TOKEN = re.compile(r"(?<![\w:.@?!])(do|fn|end)(?![\w:?!])|[()\[\]{}]")
+_EX_SIGIL = re.compile(r"~[a-zA-Z]+(\"\"\"|'''|[/|\"'(\[{<])")
+_EX_CLOSERS = {"(": ")", "[": "]", "{": "}", "<": ">"}
+# Lines after which a one-line function continues
+_EX_CONTINUATIONS = (",", "\\", "|>", "=", "->", "when", "do:")
+
+_ERL_FUNCTION = re.compile(r"^([a-z][\w@]*|'(?:[^'\\]|\\.)*')\s*\(")
+_ERL_ATTRIBUTE = re.compile(r"^-(spec|doc)\b")
+_ERL_MODULE = re.compile(r"^-module\s*\(\s*([\w@]+|'[^']*')\s*\)")
+
+
+def is_beam(language: str) -> bool:
+    """Whether a language token is chunked by Elixir or Erlang declarations."""
+    return language.lower() in ELIXIR_LANGUAGES | ERLANG_LANGUAGES
+
+
+def _elixir_code(lines: List[str]) -> Tuple[List[str], Set[int]]:
+    """
+    Lines of an Elixir file with comments, strings and sigils blanked.
+
+    Returns:
+        The blanked lines, and the lines starting inside a multi-line
+        string or heredoc
+    """
+    code_lines = []
+    continued: Set[int] = set()
+    closer: Optional[str] = None  # Closing delimiter of an open string
+    for n, line in enumerate(lines):
+        if closer is not None:
+            continued.add(n)
+        code = []
+        i = 0
+        while i < len(line):
+            if closer is not None:
+                if line[i] == "\\":
+                    i += 2
+                elif line.startswith(closer, i):
+                    i += len(closer)
+                    closer = None
+                    code.append('"')
+                else:
+                    i += 1
+                continue
+            if line[i] == "#":
+                break
+            previous = line[i - 1] if i else " "
+            if (
+                line[i] == "?"
+                and i + 1 < len(line)
+                and not (previous.isalnum() or previous == "_")
+            ):
+                # Character literals: ?a ?# ?\n
+                i += 3 if line[i + 1] == "\\" else 2
+                code.append("0")
+                continue
+            sigil = _EX_SIGIL.match(line, i)
+            opener = sigil.group(1) if sigil else None
+            if opener is None:
+                opener = next(
+                    (o for o in ('"""', "'''", '"', "'") if line.startswith(o, i)),
+                    None,
+                )
+            if opener is not None:
+                closer = _EX_CLOSERS.get(opener, opener)
+                i = sigil.end() if sigil else i + len(opener)
+                code.append('"')
+                continue
+            code.append(line[i])
+            i += 1
+        code_lines.append("".join(code))
+    return code_lines, continued
+
+
+def _erlang_code(line: str) -> str:
+    """A line of Erlang with its comment, strings and characters blanked."""
+    code = []
+    i = 0
+    while i < len(line):
+        if line[i] == "%":
+            break
+        if line[i] == "$" and i + 1 < len(line):
+            i += 3 if line[i + 1] == "\\" else 2
+            code.append("0")
+            continue
+        if line[i] == '"':
+            i += 1
+            while i < len(line) and line[i] != '"':
+                i += 2 if line[i] == "\\" else 1
+            i += 1
+            code.append('""')
+            continue
+        code.append(line[i])
+        i += 1
+    return "".join(code)
+
+
+def _arity(code: str, open_at: int) -> int:
+    """Number of arguments in the parentheses opening at open_at."""
+    depth = 0
+    commas = 0
+    empty = True
+    for char in code[open_at:]:
+        if char in ")]}":
+            depth -= 1
+            if depth == 0:
+                break
+        elif char == "," and depth == 1:
+            commas += 1
+        if depth and not char.isspace():
+            empty = False
+        if char in "([{":
+            depth += 1
+    return 0 if empty else commas + 1
+
+
+def _is_comment(line: str) -> bool:
+    return line.lstrip().startswith(("#", "%"))
+
+
+def chunk_beam(
+    text: str, language: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split an Elixir or Erlang file into modules and functions.
+
+    Args:
+        text: File text
+        language: Language token of the file, e.g. "ex" or "erl"
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long declaration
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the SYMBOL_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    top_level = {SYMBOL_KIND_KEY: "file"}
+    if language.lower() in ERLANG_LANGUAGES:
+        code_lines = [_erlang_code(line) for line in lines]
+        segments = _erlang_segments(lines, code_lines, top_level)
+    else:
+        code_lines, continued = _elixir_code(lines)
+        segments = _elixir_segments(lines, code_lines, continued, top_level)
+
+    # Segments holding only blank lines, comments and "end" belong to the
+    # segment before them
+    def is_filler(first: int, end: int, payload: Dict[str, Any]) -> bool:
+        return all(s.strip() in ("", "end") for s in code_lines[first:end])
+
+    kept = keep_segments(segments, len(lines), is_filler, top_level)
+    return chunk_segments(text, kept, chunk_size, overlap_size)
+
+
+def _elixir_header(code_lines: List[str], i: int) -> str:
+    """Code of a declaration header from line i to its "do"."""
+    header = []
+    depth = 0
+    for code in code_lines[i : i + 10]:
+        header.append(code)
+        depth += sum(code.count(c) for c in "([{") - sum(code.count(c) for c in ")]}")
+        if depth <= 0:
+            break
+    return " ".join(header)
+
+
+def _elixir_declaration(
+    code_lines: List[str], i: int, module: Dict[str, Any]
+) -> Optional[Dict[str, Any]]:
+    """Payload of the Elixir declaration starting line i, or None."""
+    code = code_lines[i]
+    prefix = module.get(SYMBOL_NAME_KEY)
+    if prefix and module[SYMBOL_KIND_KEY] == "impl":
+        # "defimpl Size, for: Map" defines the module Size.Map
+        prefix = prefix.replace(" for ", ".")
+    declaration = _EX_MODULE.match(code)
+    if declaration:
+        name = declaration.group(2)
+        if prefix and module[SYMBOL_KIND_KEY] == "module":
+            name = f"{prefix}.{name}"
+        return {
+            SYMBOL_KIND_KEY: _EX_KINDS[declaration.group(1)],
+            SYMBOL_NAME_KEY: name,
+        }
+    implementation = _EX_IMPL.match(code)
+    if implementation:
+        protocol, target = implementation.groups()
+        name = f"{protocol} for {target or prefix}" if target or prefix else protocol
+        return {SYMBOL_KIND_KEY: "impl", SYMBOL_NAME_KEY: name}
+    function = _EX_FUNCTION.match(code)
+    if function:
+        header = _elixir_header(code_lines, i)
+        after = header[function.end() :].lstrip()
+        arity = _arity(after, 0) if after.startswith("(") else 0
+        name = f"{function.group(2)}/{arity}"
+        return {
+            SYMBOL_KIND_KEY: _EX_KINDS[function.group(1)],
+            SYMBOL_NAME_KEY: f"{prefix}.{name}" if prefix else name,
+        }
+    return None
+
+
+def _indentation(line: str) -> int:
+    return len(line) - len(line.lstrip())
+
+
+def _elixir_segments(
+    lines: List[str],
+    code_lines: List[str],
+    continued: Set[int],
+    top_level: Dict[str, Any],
+) -> List[Tuple[int, Dict[str, Any]]]:
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    # Open modules, with the block depth of their declarations
+    modules: List[Tuple[Dict[str, Any], int]] = [(top_level, 0)]
+    depth = brackets = 0
+    # The open declaration, whether its do block has started, and the
+    # previous function of the module (its clauses continue it)
+    declaration: Optional[Dict[str, Any]] = None
+    opened = False
+    previous: Optional[Dict[str, Any]] = None
+    attribute_start: Optional[int] = None
+    last_code_line = -1
+
+    for i, code in enumerate(code_lines):
+        stripped = code.strip()
+        module, declaration_depth = modules[-1]
+        # Heredocs and indented continuation lines of an attribute
+        in_attribute = attribute_start is not None and (
+            i in continued
+            or _indentation(lines[i]) > _indentation(lines[attribute_start])
+        )
+        if (
+            declaration is None
+            and depth == declaration_depth
+            and brackets == 0
+            and stripped
+            and not in_attribute
+        ):
+            payload = _elixir_declaration(code_lines, i, module)
+            if payload is None and _EX_ATTRIBUTE.match(code):
+                if attribute_start is None:
+                    attribute_start = i
+            elif payload is not None:
+                if (
+                    previous is not None
+                    and previous[SYMBOL_KIND_KEY] == payload[SYMBOL_KIND_KEY]
+                    and previous[SYMBOL_NAME_KEY] == payload[SYMBOL_NAME_KEY]
+                ):
+                    payload = previous  # Another clause of the same function
+                first = i if attribute_start is None else attribute_start
+                first = leading_comments(lines, first, last_code_line, _is_comment)
+                segments.append((first, payload))
+                declaration, opened = payload, False
+                attribute_start = None
+            else:
+                if not segments or segments[-1][1] is not module:
+                    first = i if attribute_start is None else attribute_start
+                    segments.append((first, module))
+                previous = attribute_start = None
+
+        for token in _EX_TOKEN.finditer(code):
+            word = token.group()
+            if word in ("(", "[", "{"):
+                brackets += 1
+            elif word in (")", "]", "}"):
+                brackets = max(brackets - 1, 0)
+            elif word in ("do", "fn"):
+                depth += 1
+                opened = opened or word == "do"
+            else:
+                depth = max(depth - 1, 0)
+                if len(modules) > 1 and depth < modules[-1][1]:
+                    modules.pop()
+                    # Code after a module continues its parent
+                    segments.append((i + 1, modules[-1][0]))
+                    previous = None
+
+        if declaration is not None:
+            kind = declaration[SYMBOL_KIND_KEY]
+            if kind in ("module", "protocol", "impl") and depth > declaration_depth:
+                # Declarations inside a module are split like top-level ones
+                modules.append((declaration, declaration_depth + 1))
+                declaration = previous = None
+            elif depth == declaration_depth and brackets == 0 and (
+                opened or not stripped.endswith(_EX_CONTINUATIONS)
+            ):
+                previous = declaration
+                declaration = None
+                # Code after a declaration continues its module
+                segments.append((i + 1, modules[-1][0]))
+        if stripped and attribute_start is None:
+            last_code_line = i
+    if declaration is not None:
+        # A declaration still open at the end of the file has a syntax
+        # error; the earlier clauses of its function keep their chunks
+        n = max(n for n, (_, payload) in enumerate(segments) if payload is declaration)
+        segments[n] = (segments[n][0], {**declaration, DEGRADED_KEY: True})
+    return segments
+
+
+def _erlang_segments(
+    lines: List[str], code_lines: List[str], top_level: Dict[str, Any]
+) -> List[Tuple[int, Dict[str, Any]]]:
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    module_name = next(
+        (m.group(1) for m in map(_ERL_MODULE.match, lines) if m is not None), None
+    )
+    if module_name is not None:
+        top_level[SYMBOL_KIND_KEY] = "module"
+        top_level[SYMBOL_NAME_KEY] = module_name
+    function: Optional[Dict[str, Any]] = None
+    attribute_start: Optional[int] = None
+    last_code_line = -1
+
+    # Forms start in column 0; clause bodies and continuation lines are
+    # indented
+    for i, code in enumerate(code_lines):
+        if not code.strip():
+            continue
+        clause = _ERL_FUNCTION.match(code)
+        if clause:
+            # The argument list may span lines
+            header = " ".join(code_lines[i : i + 10])
+            name = f"{clause.group(1)}/{_arity(header, clause.end() - 1)}"
+            if module_name is not None:
+                name = f"{module_name}:{name}"
+            if function is None or function[SYMBOL_NAME_KEY] != name:
+                function = {SYMBOL_KIND_KEY: "function", SYMBOL_NAME_KEY: name}
+                first = i if attribute_start is None else attribute_start
+                first = leading_comments(lines, first, last_code_line, _is_comment)
+                segments.append((first, function))
+            attribute_start = None
+        elif _ERL_ATTRIBUTE.match(code):
+            if attribute_start is None:
+                attribute_start = i
+        elif not code[0].isspace():
+            function = None
+            if not segments or segments[-1][1] is not top_level:
+                first = i if attribute_start is None else attribute_start
+                segments.append((first, top_level))
+            attribute_start = None
+        if attribute_start is None:
+            last_code_line = i
+    return segments
@@ -0,0 +1,168 @@
+"""Windowing shared by the structure-aware chunkers.
+
+A language chunker only finds where its units start: it scans the file and
+returns segments, (first line index, payload) pairs in file order. This
+module does the rest the same way for every language:
+
+- keep_segments() drops segments without code of their own, so blank lines,
+  comments and closing lines stay with the unit before them
+- chunk_segments() cuts the text at the segment starts, so the chunks of a
+  file cover it exactly
+- split_segment() turns a unit longer than the chunk size into overlapping
+  windows like FixedSizeChunker does; every window keeps the payload
+
+A chunker marks a unit that is still open at the end of the file with
+DEGRADED_KEY instead of guessing where it ends.
+"""
+
+import re
+from typing import Any, Callable, Dict, List, Optional, Tuple
+
+# Chunk and payload keys describing the declaration of a chunk. Every
+# declaration chunker records its kinds and names under them, so
+# `cidx query --symbol-kind` filters the chunks of all those languages
+SYMBOL_KIND_KEY = "symbol_kind"
+SYMBOL_NAME_KEY = "symbol_name"
+# Chunk and payload key holding the language of an embedded region
+EMBEDDED_LANGUAGE_KEY = "embedded_language"
+# Chunk and payload key marking chunks of broken code. A chunker sets it on
+# the payload of a unit that never ends (an unclosed brace or block), and
+# FixedSizeChunker line-windows that unit and chunks the code after it again
+DEGRADED_KEY = "is_degraded"
+
+# (first line index, payload) of a unit of a file
+Segment = Tuple[int, Dict[str, Any]]
+
+
+def line_offsets(text: str) -> List[int]:
+    """Offset of the first character of every line of text."""
+    return [0] + [m.end() for m in re.finditer("\n", text)]
+
+
+def leading_comments(
+    lines: List[str], first: int, floor: int, is_comment: Callable[[str], bool]
+) -> int:
+    """First line of the comments directly above line first, after floor."""
+    while first - 1 > floor and is_comment(lines[first - 1]):
+        first -= 1
+    return first
+
+
+def keep_segments(
+    segments: List[Segment],
+    line_count: int,
+    is_filler: Callable[[int, int, Dict[str, Any]], bool],
+    top_level: Dict[str, Any],
+) -> List[Segment]:
+    """
+    Segments that hold code of their own; the first starts at the top.
+
+    Args:
+        segments: Segments in file order
+        line_count: Number of lines of the file
+        is_filler: Whether lines [first, end) of a segment with the given
+            payload hold nothing but blank lines, comments and closing lines
+        top_level: Payload of a file without any segment
+
+    Returns:
+        Segments for chunk_segments(). Empty and filler segments, and a
+        segment continuing the payload of the one before it, are merged into
+        the segment before them.
+    """
+    kept: List[Segment] = []
+    for n, (first_line, payload) in enumerate(segments):
+        end_line = segments[n + 1][0] if n + 1 < len(segments) else line_count
+        if first_line >= end_line or (
+            kept and is_filler(first_line, end_line, payload)
+        ):
+            continue
+        if kept and kept[-1][1] is payload:
+            continue
+        kept.append((0 if not kept else first_line, payload))
+    if not kept:
+        kept.append((0, top_level))
+    return kept
+
+
+def chunk_segments(
+    text: str,
+    segments: List[Segment],
+    chunk_size: int,
+    overlap_size: int,
+    annotate: Optional[Callable[[Dict[str, Any], Dict[str, Any]], None]] = None,
+) -> List[Dict[str, Any]]:
+    """
+    Chunks of the segments of a file.
+
+    Args:
+        text: File text
+        segments: Segments in file order; each runs to the start of the next
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long segment
+        annotate: Called with each chunk and its segment's payload to add
+            fields that depend on the window's text
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the payload
+        fields; segments holding only whitespace produce no chunk
+    """
+    offsets = line_offsets(text)
+
+    def offset(line: int) -> int:
+        return offsets[line] if line < len(offsets) else len(text)
+
+    chunks: List[Dict[str, Any]] = []
+    for n, (first_line, payload) in enumerate(segments):
+        end = offset(segments[n + 1][0]) if n + 1 < len(segments) else len(text)
+        segment = text[offset(first_line) : end]
+        if not segment.strip():
+            continue
+        for chunk in split_segment(
+            segment, first_line + 1, payload, chunk_size, overlap_size
+        ):
+            if annotate is not None:
+                annotate(chunk, payload)
+            chunks.append(chunk)
+    return chunks
+
+
+def split_segment(
+    segment: str,
+    first_line: int,
+    payload: Dict[str, Any],
+    chunk_size: int,
+    overlap_size: int,
+) -> List[Dict[str, Any]]:
+    """
+    Chunks of one unit: the whole of it, or overlapping windows.
+
+    Args:
+        segment: Text of the unit
+        first_line: 1-based line number of its first line
+        payload: Fields every window gets (lists are copied per window)
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of adjacent windows
+
+    Returns:
+        Chunk dicts; trailing blank lines do not extend a window's line range
+    """
+    step = max(chunk_size - overlap_size, 1)
+    chunks = []
+    start = 0
+    while True:
+        window = segment[start : start + chunk_size]
+        line_start = first_line + segment[:start].count("\n")
+        chunks.append(
+            {
+                "text": window,
+                "line_start": line_start,
+                "line_end": line_start + window.rstrip("\n").count("\n"),
+                **{
+                    key: list(value) if isinstance(value, list) else value
+                    for key, value in payload.items()
+                },
+            }
+        )
+        if start + chunk_size >= len(segment):
+            return chunks
+        start += step
@@ -0,0 +1,517 @@
+"""Block-aware chunking of Gradle, Maven and CMake build files.
+
+build.gradle and build.gradle.kts files are split at their top-level blocks
+(plugins, dependencies, repositories, ...) and task definitions. pom.xml
+files are split at the children of <project>, with the <plugins> and
+<pluginManagement> sections of <build> on their own. CMakeLists.txt and
+.cmake files are split at target definitions, functions, macros and
+dependency declarations. Other top-level statements form "project" chunks,
+and comments directly above a block belong to it. Each chunk records:
+
+- "build_system": gradle, maven or cmake
+- "build_block": plugins, dependencies, task, target, function, macro,
+  project, or the name of another block, e.g. "repositories"
+- "build_name": the task, target, function or macro name
+- "build_coordinates": the dependencies declared in the chunk, as
+  "group:artifact:version" for Gradle and Maven and "package:version" for
+  CMake (the version is left out when not given)
+- "build_plugins": the plugins applied in the chunk, as Gradle plugin ids
+  ("org.springframework.boot:3.2.0" with a version) or Maven coordinates
+
+The files are scanned rather than evaluated, so versions read from
+properties or variables are recorded as written ("${spring.version}").
+Blocks longer than the chunk size are split into overlapping windows like
+FixedSizeChunker does; the coordinates and plugins of a window are those
+declared in it.
+"""
+
+import re
+from typing import Any, Callable, Dict, List, Optional, Tuple
+
+from .boundary_chunking import chunk_segments, leading_comments
+from .chunk_payload_keys import (
+    BUILD_BLOCK_KEY,
+    BUILD_COORDINATES_KEY,
+    BUILD_NAME_KEY,
+    BUILD_PLUGINS_KEY,
+    BUILD_SYSTEM_KEY,
+)
+
+GRADLE_LANGUAGES = {"gradle"}
+CMAKE_LANGUAGES = {"cmake"}
+MAVEN_FILE_NAMES = {"pom.xml"}
+
+# Children of <project> chunked on their own; the others form "project"
+MAVEN_SECTIONS = {
+    "dependencies",
+    "dependencyManagement",
+    "build",
+    "profiles",
+    "repositories",
+    "pluginRepositories",
+    "reporting",
+    "properties",
+    "modules",
+    "distributionManagement",
+}
+# Children of <build> chunked on their own
+MAVEN_BUILD_SECTIONS = {"plugins", "pluginManagement"}
+MAVEN_DEFAULT_PLUGIN_GROUP = "org.apache.maven.plugins"
+
+CMAKE_TARGET_COMMANDS = {"add_executable", "add_library", "add_custom_target"}
+CMAKE_DEPENDENCY_COMMANDS = {
+    "find_package",
+    "fetchcontent_declare",
+    "fetchcontent_makeavailable",
+    "pkg_check_modules",
+}
+_CMAKE_BLOCK_ENDS = {"function": "endfunction", "macro": "endmacro"}
+_CMAKE_CONTROL_ENDS = {
+    "if": "endif",
+    "foreach": "endforeach",
+    "while": "endwhile",
+    "block": "endblock",
+}
+
+# Gradle
+_GRADLE_TASK = re.compile(
+    r"""^\s*(?:task\b\s*\(?\s*["']?([\w-]+)"""
+    r"""|tasks\s*\.\s*(?:register|create|named|getByName|maybeCreate)\s*"""
+    r"""(?:<[^>]*>)?\s*\(\s*["']([\w:-]+)["']"""
+    r"""|(?:val|def)\s+(\w+)\s+by\s+tasks\s*\.\s*"""
+    r"""(?:registering|creating|getting|existing))"""
+)
+_GRADLE_BLOCK = re.compile(r"^\s*([\w.]+)")
+_GRADLE_DEPENDENCY = re.compile(
+    r"""^\s*(?!dependsOn|mustRunAfter|shouldRunAfter|finalizedBy)\w+\s*\(?\s*"""
+    r"""(?:(?:platform|enforcedPlatform)\s*\(\s*)?"""
+    r"""["']([\w.-]+:[\w.-]+(?::[^"'\s@]+)?)(?:@\w+)?["']""",
+    re.M,
+)
+_GRADLE_MAP_DEPENDENCY = re.compile(
+    r"""group\s*[:=]\s*["']([^"']+)["']\s*,\s*name\s*[:=]\s*["']([^"']+)["']"""
+    r"""(?:\s*,\s*version\s*[:=]\s*["']([^"']+)["'])?"""
+)
+_GRADLE_PLUGIN_ID = re.compile(
+    r"""\bid\s*\(?\s*["']([\w.-]+)["']\s*\)?"""
+    r"""(?:\s*version\s*\(?\s*["']([^"']+)["'])?"""
+)
+_GRADLE_APPLY_PLUGIN = re.compile(
+    r"""\bapply\s*\(?\s*plugin\s*[:=]\s*["']([\w.-]+)["']"""
+)
+# Core plugins in a plugins block: java, `java-library`
+_GRADLE_CORE_PLUGIN = re.compile(r"^[ \t]*(`[\w.-]+`|\w+)[ \t]*;?[ \t]*$", re.M)
+
+# Maven
+_XML_TOKEN = re.compile(
+    r"<!--.*?-->|<!\[CDATA\[.*?\]\]>|<[?!].*?>|<(/?)([\w.:-]+)[^>]*?(/?)>", re.S
+)
+_XML_COMMENT = re.compile(r"<!--.*?-->", re.S)
+_MAVEN_DEPENDENCY = re.compile(r"<dependency>(.*?)</dependency>", re.S)
+_MAVEN_PLUGIN = re.compile(r"<plugin>(.*?)</plugin>", re.S)
+_MAVEN_NESTED = re.compile(
+    r"<(exclusions|executions|configuration|dependencies)\b.*?</\1>", re.S
+)
+
+# CMake
+_CMAKE_IDENTIFIER = re.compile(r"[A-Za-z_]\w*")
+_CMAKE_BRACKET = re.compile(r"\[(=*)\[")
+_CMAKE_ARGUMENT = re.compile(r'"((?:\\.|[^"\\])*)"|([^\s()"]+)')
+_CMAKE_FIND_PACKAGE = re.compile(
+    r"\bfind_package\s*\(\s*([\w.+-]+)(?:\s+(\d[\w.]*))?", re.I
+)
+_CMAKE_FETCH_CONTENT = re.compile(
+    r"\bFetchContent_Declare\s*\(\s*([\w.+-]+)([^)]*)\)", re.I
+)
+_CMAKE_FETCH_VERSION = re.compile(r"\b(?:GIT_TAG|VERSION)\s+\"?([^\s\")]+)")
+
+
+def build_system(language: str, file_name: str) -> Optional[str]:
+    """Build system of a build file ("gradle", "maven", "cmake"), or None."""
+    name = file_name.lower()
+    if language.lower() in GRADLE_LANGUAGES or name.endswith(".gradle.kts"):
+        return "gradle"
+    if name in MAVEN_FILE_NAMES:
+        return "maven"
+    if language.lower() in CMAKE_LANGUAGES:
+        return "cmake"
+    return None
+
+
+def chunk_build_file(
+    text: str, system: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a build file into blocks, tasks and targets.
+
+    Args:
+        text: File text
+        system: Build system from build_system()
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long block
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the BUILD_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    extract: Callable[[str, Dict[str, Any]], Dict[str, Any]]
+    if system == "gradle":
+        segments = _gradle_segments(lines)
+        extract = _gradle_fields
+    elif system == "maven":
+        segments = _maven_segments(text, lines)
+        extract = _maven_fields
+    else:
+        segments = _cmake_segments(text, lines)
+        extract = _cmake_fields
+
+    # The first segment starts at the top of the file
+    kept: List[Tuple[int, Dict[str, Any]]] = []
+    for first_line, payload in segments:
+        if kept and first_line <= kept[-1][0]:
+            continue
+        kept.append((0 if not kept else first_line, payload))
+    if not kept:
+        kept.append((0, {BUILD_BLOCK_KEY: "project"}))
+
+    # The coordinates and plugins of a window are those declared in it
+    def annotate(chunk: Dict[str, Any], payload: Dict[str, Any]) -> None:
+        chunk.update(extract(chunk["text"], payload))
+
+    fields = [
+        (first_line, {BUILD_SYSTEM_KEY: system, **payload})
+        for first_line, payload in kept
+    ]
+    return chunk_segments(text, fields, chunk_size, overlap_size, annotate)
+
+
+def _gradle_code(lines: List[str]) -> List[str]:
+    """Lines of a Gradle script with comments and strings blanked."""
+    code_lines = []
+    state: Optional[str] = None
+    for line in lines:
+        code = []
+        i = 0
+        while i < len(line):
+            if state is not None:
+                closer = "*/" if state == "/*" else state
+                if len(state) == 1 and line[i] == "\\":
+                    i += 2
+                    continue
+                if line.startswith(closer, i):
+                    state = None
+                    code.append(" ")
+                    i += len(closer)
+                else:
+                    i += 1
+                continue
+            if line.startswith("//", i):
+                break
+            opener = next(
+                (o for o in ("/*", '"""', "'''", '"', "'") if line.startswith(o, i)),
+                None,
+            )
+            if opener is not None:
+                state = opener
+                i += len(opener)
+                continue
+            code.append(line[i])
+            i += 1
+        if state in ('"', "'"):
+            state = None  # Unterminated single-line string
+        code_lines.append("".join(code))
+    return code_lines
+
+
+def _is_gradle_comment(line: str) -> bool:
+    return line.lstrip().startswith(("//", "/*", "*"))
+
+
+def _gradle_segments(lines: List[str]) -> List[Tuple[int, Dict[str, Any]]]:
+    """(first line index, payload) of the top-level blocks of a Gradle script."""
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    depth = 0
+    last_code_line = -1
+    for i, code in enumerate(_gradle_code(lines)):
+        if depth == 0 and code.strip():
+            task = _GRADLE_TASK.match(lines[i])
+            payload: Optional[Dict[str, Any]] = None
+            if task:
+                payload = {
+                    BUILD_BLOCK_KEY: "task",
+                    BUILD_NAME_KEY: next(g for g in task.groups() if g),
+                }
+            elif "{" in code:
+                block = _GRADLE_BLOCK.match(code)
+                payload = {BUILD_BLOCK_KEY: block.group(1) if block else "project"}
+            elif not segments or segments[-1][1][BUILD_BLOCK_KEY] != "project":
+                payload = {BUILD_BLOCK_KEY: "project"}
+            if payload is not None:
+                first = leading_comments(lines, i, last_code_line, _is_gradle_comment)
+                segments.append((first, payload))
+        depth = max(depth + code.count("{") - code.count("}"), 0)
+        if code.strip():
+            last_code_line = i
+    return segments
+
+
+def _gradle_fields(text: str, payload: Dict[str, Any]) -> Dict[str, Any]:
+    """Dependency coordinates and plugins declared in Gradle script text."""
+    found: List[Tuple[int, str]] = [
+        (m.start(), m.group(1)) for m in _GRADLE_DEPENDENCY.finditer(text)
+    ]
+    for match in _GRADLE_MAP_DEPENDENCY.finditer(text):
+        found.append((match.start(), ":".join(g for g in match.groups() if g)))
+    plugins: List[Tuple[int, str]] = []
+    for match in _GRADLE_PLUGIN_ID.finditer(text):
+        plugins.append((match.start(), ":".join(g for g in match.groups() if g)))
+    for match in _GRADLE_APPLY_PLUGIN.finditer(text):
+        plugins.append((match.start(), match.group(1)))
+    if payload.get(BUILD_BLOCK_KEY) == "plugins":
+        for match in _GRADLE_CORE_PLUGIN.finditer(text):
+            if match.group(1) not in ("plugins", "}"):
+                plugins.append((match.start(), match.group(1).strip("`")))
+    return _coordinate_fields(found, plugins)
+
+
+def _coordinate_fields(
+    coordinates: List[Tuple[int, str]], plugins: List[Tuple[int, str]]
+) -> Dict[str, Any]:
+    """BUILD_COORDINATES_KEY and BUILD_PLUGINS_KEY in order of declaration."""
+    fields: Dict[str, Any] = {}
+    for key, found in (
+        (BUILD_COORDINATES_KEY, coordinates),
+        (BUILD_PLUGINS_KEY, plugins),
+    ):
+        values = list(dict.fromkeys(value for _, value in sorted(found)))
+        if values:
+            fields[key] = values
+    return fields
+
+
+def _maven_segments(
+    text: str, lines: List[str]
+) -> List[Tuple[int, Dict[str, Any]]]:
+    """(first line index, payload) of the sections of a pom.xml."""
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    stack: List[str] = []
+    last_line = -1
+    for match in _XML_TOKEN.finditer(text):
+        closing, tag, self_closing = match.groups()
+        if tag is None:
+            continue  # Comment, CDATA, declaration
+        if closing:
+            if tag in stack:
+                del stack[len(stack) - 1 - stack[::-1].index(tag) :]
+            continue
+        parent = stack[-1] if stack else None
+        line = text.count("\n", 0, match.start())
+        block: Optional[str] = None
+        if len(stack) == 1 and parent == "project":
+            block = tag if tag in MAVEN_SECTIONS else "project"
+        elif len(stack) == 2 and parent == "build" and tag in MAVEN_BUILD_SECTIONS:
+            block = tag
+        if not self_closing:
+            stack.append(tag)
+        if block is None or line <= last_line:
+            continue
+        if block == "project" and segments and segments[-1][1][
+            BUILD_BLOCK_KEY
+        ] == "project":
+            continue
+        first = leading_comments(lines, line, last_line, _is_xml_comment)
+        segments.append((first, {BUILD_BLOCK_KEY: block}))
+        last_line = line
+    return segments
+
+
+def _is_xml_comment(line: str) -> bool:
+    stripped = line.strip()
+    return stripped.startswith("<!--") and stripped.endswith("-->")
+
+
+def _maven_coordinate(body: str, default_group: Optional[str] = None) -> str:
+    """group:artifact:version of a <dependency> or <plugin> element body."""
+    body = _MAVEN_NESTED.sub("", body)
+    parts = []
+    for tag in ("groupId", "artifactId", "version"):
+        value = re.search(rf"<{tag}>\s*([^<]*?)\s*</{tag}>", body)
+        if value:
+            parts.append(value.group(1))
+        elif tag == "groupId" and default_group:
+            parts.append(default_group)
+    return ":".join(parts)
+
+
+def _maven_fields(text: str, payload: Dict[str, Any]) -> Dict[str, Any]:
+    """Dependency and plugin coordinates declared in pom.xml text."""
+    text = _XML_COMMENT.sub("", text)
+    coordinates = [
+        (m.start(), _maven_coordinate(m.group(1)))
+        for m in _MAVEN_DEPENDENCY.finditer(text)
+    ]
+    plugins = [
+        (m.start(), _maven_coordinate(m.group(1), MAVEN_DEFAULT_PLUGIN_GROUP))
+        for m in _MAVEN_PLUGIN.finditer(text)
+    ]
+    return _coordinate_fields(
+        [c for c in coordinates if ":" in c[1]], [p for p in plugins if ":" in p[1]]
+    )
+
+
+def cmake_commands(text: str) -> List[Tuple[str, List[str], int, int]]:
+    """
+    Commands of a CMake file.
+
+    Returns:
+        (lowercase command name, arguments, first line index, last line
+        index) per command; quoted arguments are unquoted
+    """
+    commands = []
+    position = 0
+    while position < len(text):
+        char = text[position]
+        if char.isspace():
+            position += 1
+        elif char == "#":
+            bracket = _CMAKE_BRACKET.match(text, position + 1)
+            if bracket:
+                closer = "]" + bracket.group(1) + "]"
+                end = text.find(closer, bracket.end())
+                position = len(text) if end < 0 else end + len(closer)
+            else:
+                end = text.find("\n", position)
+                position = len(text) if end < 0 else end
+        else:
+            name = _CMAKE_IDENTIFIER.match(text, position)
+            open_paren = name and re.compile(r"[ \t]*\(").match(text, name.end())
+            if not name or not open_paren:
+                end = text.find("\n", position)
+                position = len(text) if end < 0 else end
+                continue
+            end = _cmake_arguments_end(text, open_paren.end())
+            arguments = [
+                _cmake_argument(argument)
+                for argument in _CMAKE_ARGUMENT.finditer(
+                    _cmake_strip_comments(text[open_paren.end() : end - 1])
+                )
+            ]
+            commands.append(
+                (
+                    name.group(0).lower(),
+                    arguments,
+                    text.count("\n", 0, position),
+                    text.count("\n", 0, max(end - 1, position)),
+                )
+            )
+            position = end
+    return commands
+
+
+def _cmake_arguments_end(text: str, position: int) -> int:
+    """Offset after the parenthesis closing the arguments opened before."""
+    depth = 1
+    while position < len(text):
+        char = text[position]
+        if char == '"':
+            position += 1
+            while position < len(text) and text[position] != '"':
+                position += 2 if text[position] == "\\" else 1
+        elif char == "#":
+            end = text.find("\n", position)
+            position = len(text) if end < 0 else end
+            continue
+        elif char == "(":
+            depth += 1
+        elif char == ")":
+            depth -= 1
+            if depth == 0:
+                return position + 1
+        position += 1
+    return len(text)
+
+
+def _cmake_argument(match: "re.Match[str]") -> str:
+    """An argument, unquoted if quoted."""
+    return match.group(1) if match.group(1) is not None else match.group(2)
+
+
+def _cmake_strip_comments(arguments: str) -> str:
+    return re.sub(r'"(?:\\.|[^"\\])*"|#[^\n]*', _keep_strings, arguments)
+
+
+def _keep_strings(match: "re.Match[str]") -> str:
+    return match.group(0) if match.group(0).startswith('"') else ""
+
+
+def _cmake_segments(
+    text: str, lines: List[str]
+) -> List[Tuple[int, Dict[str, Any]]]:
+    """(first line index, payload) of the blocks of a CMake file."""
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    # Closing command of the open function, macro or control block
+    ends: List[str] = []
+    target: Optional[str] = None
+    last_line = -1
+    for name, arguments, first_line, last_command_line in cmake_commands(text):
+        if ends:
+            if name == ends[-1]:
+                ends.pop()
+            elif name in _CMAKE_BLOCK_ENDS:
+                ends.append(_CMAKE_BLOCK_ENDS[name])
+            elif name in _CMAKE_CONTROL_ENDS:
+                ends.append(_CMAKE_CONTROL_ENDS[name])
+            last_line = last_command_line
+            continue
+        current = segments[-1][1][BUILD_BLOCK_KEY] if segments else None
+        payload: Optional[Dict[str, Any]] = None
+        if name in _CMAKE_BLOCK_ENDS or name in CMAKE_TARGET_COMMANDS:
+            payload = {
+                BUILD_BLOCK_KEY: "target" if name in CMAKE_TARGET_COMMANDS else name
+            }
+            if arguments:
+                payload[BUILD_NAME_KEY] = arguments[0]
+            target = arguments[0] if name in CMAKE_TARGET_COMMANDS else None
+        elif name in CMAKE_DEPENDENCY_COMMANDS:
+            if current != "dependencies":
+                payload = {BUILD_BLOCK_KEY: "dependencies"}
+            target = None
+        elif current == "target" and target in arguments[:1] + arguments[1:2]:
+            pass  # target_link_libraries(app ...) and the like stay with it
+        elif current == "dependencies" and name == "include":
+            pass  # include(FetchContent) among the declarations
+        elif current != "project":
+            payload = {BUILD_BLOCK_KEY: "project"}
+            target = None
+        if name in _CMAKE_BLOCK_ENDS:
+            ends.append(_CMAKE_BLOCK_ENDS[name])
+        elif name in _CMAKE_CONTROL_ENDS:
+            ends.append(_CMAKE_CONTROL_ENDS[name])
+        if payload is not None:
+            first = leading_comments(lines, first_line, last_line, _is_cmake_comment)
+            segments.append((first, payload))
+        last_line = last_command_line
+    return segments
+
+
+def _is_cmake_comment(line: str) -> bool:
+    return line.lstrip().startswith("#")
+
+
+def _cmake_fields(text: str, payload: Dict[str, Any]) -> Dict[str, Any]:
+    """Packages declared in CMake text as package:version."""
+    found = [
+        (m.start(), ":".join(g for g in m.groups() if g))
+        for m in _CMAKE_FIND_PACKAGE.finditer(text)
+    ]
+    for match in _CMAKE_FETCH_CONTENT.finditer(text):
+        version = _CMAKE_FETCH_VERSION.search(match.group(2))
+        found.append(
+            (
+                match.start(),
+                match.group(1) + (":" + version.group(1) if version else ""),
+            )
+        )
+    return _coordinate_fields(found, [])
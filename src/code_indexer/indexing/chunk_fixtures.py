@@ -0,0 +1,122 @@
+"""Golden chunk fixtures for chunker regression tests.
+
+``cidx dev gen-fixtures`` copies source files into the fixture tree
+(tests/ast_test_cases/<language>/<category>/) and writes the chunks the
+indexing chunker produces for each next to it as ``<file>.chunks.json``.
+tests/unit/chunking/test_chunk_fixtures.py re-chunks every fixture and fails
+when the output no longer matches its golden file, so a contributor adding a
+language only has to supply representative sources. Fixtures are chunked
+with a small chunk size so even short sources cover chunk boundaries and
+overlap.
+
+Regenerate the golden files after an intentional chunking change and review
+the diff like any other test expectation.
+"""
+
+import json
+import shutil
+from pathlib import Path
+from typing import Any, Dict, Optional
+
+from ..config import IndexingConfig
+from .fixed_size_chunker import FixedSizeChunker
+
+GOLDEN_SUFFIX = ".chunks.json"
+DEFAULT_FIXTURES_DIR = Path("tests") / "ast_test_cases"
+DEFAULT_CATEGORY = "general"
+# Small enough that typical fixtures span several overlapping chunks
+DEFAULT_FIXTURE_CHUNK_SIZE = 256
+
+
+def golden_path(source: Path) -> Path:
+    """Path of the golden file belonging to a fixture source file."""
+    return source.with_name(source.name + GOLDEN_SUFFIX)
+
+
+def build_golden(
+    source: Path, language: str, chunk_size: int = DEFAULT_FIXTURE_CHUNK_SIZE
+) -> Dict[str, Any]:
+    """
+    Chunk a fixture source file into its golden representation.
+
+    Args:
+        source: Fixture source file
+        language: Language the fixture belongs to
+        chunk_size: Chunk size in characters
+
+    Returns:
+        Golden data: chunker settings and the expected chunks
+
+    Raises:
+        ValueError: If the file cannot be read
+    """
+    chunker = FixedSizeChunker(IndexingConfig(), chunk_size=chunk_size)
+    chunks = chunker.chunk_file(source)
+    return {
+        "language": language,
+        "source": source.name,
+        "chunker": type(chunker).__name__,
+        "chunk_size": chunker.chunk_size,
+        "overlap_size": chunker.overlap_size,
+        "chunks": [
+            {
+                "chunk_index": chunk["chunk_index"],
+                "total_chunks": chunk["total_chunks"],
+                "line_start": chunk["line_start"],
+                "line_end": chunk["line_end"],
+                "size": chunk["size"],
+                "text": chunk["text"],
+            }
+            for chunk in chunks
+        ],
+    }
+
+
+def generate_fixture(
+    source: Path,
+    language: str,
+    fixtures_dir: Path = DEFAULT_FIXTURES_DIR,
+    category: Optional[str] = None,
+    chunk_size: int = DEFAULT_FIXTURE_CHUNK_SIZE,
+) -> Path:
+    """
+    Add a source file to the fixture tree and write its golden file.
+
+    A file already inside fixtures_dir/<language> is regenerated in place;
+    any other file is copied to fixtures_dir/<language>/<category>/ first.
+
+    Args:
+        source: Source file to turn into a fixture
+        language: Language name or extension (e.g. "python", "py")
+        fixtures_dir: Root of the fixture tree
+        category: Subdirectory for copied files (default: "general")
+        chunk_size: Chunk size in characters
+
+    Returns:
+        Path of the written golden file
+
+    Raises:
+        ValueError: If the file's extension does not belong to the language
+            or the file cannot be chunked
+    """
+    from ..services.language_mapper import LanguageMapper
+
+    extension = source.suffix.lstrip(".").lower()
+    if extension not in LanguageMapper().get_extensions(language):
+        raise ValueError(f"{source.name} is not a {language} file")
+
+    language_dir = (fixtures_dir / language).resolve()
+    resolved = source.resolve()
+    if language_dir in resolved.parents:
+        fixture = resolved
+    else:
+        fixture = language_dir / (category or DEFAULT_CATEGORY) / source.name
+        if fixture.exists() and fixture.read_bytes() != source.read_bytes():
+            raise ValueError(f"A different fixture already exists at {fixture}")
+        fixture.parent.mkdir(parents=True, exist_ok=True)
+        shutil.copyfile(source, fixture)
+
+    golden = build_golden(fixture, language, chunk_size)
+    target = golden_path(fixture)
+    target.write_text(json.dumps(golden, indent=2, ensure_ascii=False) + "\n")
+    return target
@@ -0,0 +1,70 @@
+"""Chunk and payload keys recorded by the structure-aware chunkers.
+
+The chunker modules take their keys from here, so services that only copy
+or read these payload fields do not import the chunkers (see
+CHUNKER_ROUTES in fixed_size_chunker). The declaration and embedded
+language keys every chunker shares live in boundary_chunking.
+"""
+
+# Chunk and payload key holding the heading breadcrumb of a chunk
+HEADING_PATH_KEY = "heading_path"
+
+# Chunk and payload keys describing the protobuf definition of a chunk
+PROTO_KIND_KEY = "proto_kind"
+PROTO_NAME_KEY = "proto_name"
+PROTO_PACKAGE_KEY = "proto_package"
+PROTO_OPTIONS_KEY = "proto_options"
+
+# Chunk and payload keys describing the OpenAPI definition of a chunk
+OPENAPI_KIND_KEY = "openapi_kind"
+OPENAPI_OPERATION_KEY = "openapi_operation"
+OPERATION_ID_KEY = "operation_id"
+OPENAPI_TAGS_KEY = "openapi_tags"
+OPENAPI_SCHEMA_KEY = "openapi_schema"
+
+# Chunk and payload keys describing the build stage of a Dockerfile chunk
+DOCKER_STAGE_KEY = "docker_stage"
+DOCKER_BASE_IMAGE_KEY = "docker_base_image"
+# Chunk and payload keys describing the service of a Compose file chunk
+COMPOSE_SERVICE_KEY = "compose_service"
+COMPOSE_IMAGE_KEY = "compose_image"
+COMPOSE_PORTS_KEY = "compose_ports"
+COMPOSE_VOLUMES_KEY = "compose_volumes"
+
+# Chunk and payload keys describing the resource of a manifest chunk
+K8S_KIND_KEY = "k8s_kind"
+K8S_NAME_KEY = "k8s_name"
+K8S_NAMESPACE_KEY = "k8s_namespace"
+
+# Chunk and payload keys describing the component block of a chunk
+COMPONENT_NAME_KEY = "component_name"
+COMPONENT_BLOCK_KEY = "component_block"
+COMPONENT_LANG_KEY = "component_lang"
+
+# Chunk and payload keys describing the shell construct of a chunk
+SHELL_BLOCK_KEY = "shell_block"
+SHELL_FUNCTION_KEY = "shell_function"
+
+# Chunk and payload keys describing the build file block of a chunk
+BUILD_SYSTEM_KEY = "build_system"
+BUILD_BLOCK_KEY = "build_block"
+BUILD_NAME_KEY = "build_name"
+BUILD_COORDINATES_KEY = "build_coordinates"
+BUILD_PLUGINS_KEY = "build_plugins"
+
+# Chunk and payload key naming the Flutter widget class of a build method
+FLUTTER_WIDGET_KEY = "flutter_widget"
+
+# Chunk and payload keys describing the program structure of a chunk
+COBOL_PROGRAM_KEY = "cobol_program"
+COBOL_DIVISION_KEY = "cobol_division"
+COBOL_SECTION_KEY = "cobol_section"
+COBOL_PARAGRAPH_KEY = "cobol_paragraph"
+COBOL_COPYBOOKS_KEY = "cobol_copybooks"
+
+# Chunk and payload keys describing the hardware unit of a chunk
+HDL_UNIT_KEY = "hdl_unit"
+HDL_PORTS_KEY = "hdl_ports"
+
+# Chunk and payload key listing the derived traits of a struct or enum
+RUST_DERIVES_KEY = "rust_derives"
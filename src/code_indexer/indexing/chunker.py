@@ -5,6 +5,7 @@ from typing import List, Optional, Dict, Any, Tuple
 from pathlib import Path
 
 from ..config import IndexingConfig
+from .language_detection import detect_language
 
 
 class TextChunker:
@@ -937,10 +938,10 @@ class TextChunker:
         if not text or not text.strip():
             return []
 
-        # Determine file extension for smart splitting
+        # Determine language for smart splitting (Makefile, scripts, .h)
         file_extension = ""
         if file_path:
-            file_extension = file_path.suffix.lstrip(".")
+            file_extension = detect_language(file_path, text)
 
         # Split text into lines for line tracking
         text_lines = text.splitlines()
@@ -0,0 +1,210 @@
+"""Structure-aware chunking of COBOL programs and copybooks.
+
+A COBOL program is split at its DIVISION and SECTION headers and, in the
+PROCEDURE DIVISION, at each paragraph, so a search for a business rule
+finds the paragraph implementing it rather than a window of a 50,000-line
+program. Comment lines directly above a paragraph or section belong to it.
+Both fixed format (sequence numbers in columns 1-6, an indicator in column
+7, area A from column 8) and free format (`>>SOURCE FORMAT FREE`, `*>`
+comments) are read. Each chunk records:
+
+- "cobol_program": the PROGRAM-ID of the program the chunk belongs to
+- "cobol_division": IDENTIFICATION, ENVIRONMENT, DATA or PROCEDURE
+- "cobol_section": the section, e.g. WORKING-STORAGE or 2000-PROCESS
+- "cobol_paragraph": the PROCEDURE DIVISION paragraph, e.g. 2100-VALIDATE
+- "cobol_copybooks": members named by the COPY statements and
+  `EXEC SQL INCLUDE` of the chunk, e.g. ["CUSTREC", "SQLCA"]
+
+Copybooks (.cpy) have no divisions and are chunked like any other file,
+with only the copybooks they copy recorded. Which files the members name
+is resolved by services/cobol_copybooks.py when the chunks are indexed.
+Sections and paragraphs longer than the chunk size are split into
+overlapping windows like FixedSizeChunker does; every window keeps these
+fields and records the copybooks it copies itself.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import chunk_segments, leading_comments
+from .chunk_payload_keys import (
+    COBOL_COPYBOOKS_KEY,
+    COBOL_DIVISION_KEY,
+    COBOL_PARAGRAPH_KEY,
+    COBOL_PROGRAM_KEY,
+    COBOL_SECTION_KEY,
+)
+
+COBOL_LANGUAGES = {"cbl", "cob", "cobol", "cpy"}
+
+# Fixed format: columns 1-6 sequence area, 7 indicator, 8-72 program text
+_INDICATOR_COLUMN = 6
+_TEXT_END_COLUMN = 72
+
+_FREE_FORMAT = re.compile(
+    r">>\s*SOURCE\s+(?:FORMAT\s+)?(?:IS\s+)?FREE|\$\s*SET\s+SOURCEFORMAT\s*"
+    r"\(?\s*[\"']?FREE",
+    re.IGNORECASE,
+)
+_DIVISION = re.compile(
+    r"^\s*(IDENTIFICATION|ID|ENVIRONMENT|DATA|PROCEDURE)\s+DIVISION\b",
+    re.IGNORECASE,
+)
+_SECTION = re.compile(r"^\s*([A-Z0-9][\w-]*)\s+SECTION\b[^.]*\.", re.IGNORECASE)
+_PARAGRAPH = re.compile(r"^\s*([A-Z0-9][\w-]*)\s*\.\s*$", re.IGNORECASE)
+_PROGRAM_ID = re.compile(r"\bPROGRAM-ID\s*\.?\s*[\"']?([\w-]+)", re.IGNORECASE)
+_COPY = re.compile(
+    r"(?:^|[\s.])(?:COPY\s+|EXEC\s+SQL\s+INCLUDE\s+)[\"']?([\w.#@$-]+?)[\"']?"
+    r"(?=[\s.]|$)",
+    re.IGNORECASE,
+)
+# Single-word statements that look like paragraph names
+_STATEMENTS = {"EXIT", "GOBACK", "CONTINUE", "ELSE"}
+
+
+def is_cobol(language: str) -> bool:
+    """Whether a language token is chunked by COBOL program structure."""
+    return language.lower() in COBOL_LANGUAGES
+
+
+def is_fixed_format(text: str) -> bool:
+    """Whether a program is in fixed format rather than free format."""
+    if _FREE_FORMAT.search(text):
+        return False
+    # A division header before column 8 is only valid in free format
+    for line in text.split("\n"):
+        if _DIVISION.match(line):
+            return len(line) - len(line.lstrip()) >= 7
+    return True
+
+
+def copy_members(text: str, fixed_format: bool = True) -> List[str]:
+    """Members named by COPY statements and EXEC SQL INCLUDE, in order."""
+    members: List[str] = []
+    for code, _ in (_source_line(line, fixed_format) for line in text.split("\n")):
+        for member in _COPY.findall(code):
+            name = member.upper()
+            if name not in members:
+                members.append(name)
+    return members
+
+
+def _source_line(line: str, fixed_format: bool) -> Tuple[str, bool]:
+    """
+    Program text of a line, and whether it starts in area A.
+
+    Comment lines have no program text.
+    """
+    if fixed_format:
+        indicator = line[_INDICATOR_COLUMN : _INDICATOR_COLUMN + 1]
+        if indicator in ("*", "/"):
+            return "", False
+        code = line[_INDICATOR_COLUMN + 1 : _TEXT_END_COLUMN]
+        area_a = bool(code[:4].strip())
+    else:
+        code = line
+        area_a = True  # Free format has no areas
+    code = code.split("*>", 1)[0]
+    return code, area_a and bool(code.strip())
+
+
+def _is_comment(line: str, fixed_format: bool) -> bool:
+    if fixed_format:
+        return line[_INDICATOR_COLUMN : _INDICATOR_COLUMN + 1] in ("*", "/")
+    return line.lstrip().startswith("*>")
+
+
+def chunk_cobol(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a COBOL program into divisions, sections and paragraphs.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long section or paragraph
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the COBOL_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    fixed_format = is_fixed_format(text)
+    # (first line index, payload) of each segment
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    context: Dict[str, Any] = {}
+    last_code_line = -1
+    # Line of the last division, section or paragraph header
+    header_line = -1
+
+    def start(i: int, fields: Dict[str, Optional[str]]) -> None:
+        nonlocal header_line
+        # Comment lines directly above belong to the new segment
+        first = leading_comments(
+            lines, i, last_code_line, lambda line: _is_comment(line, fixed_format)
+        )
+        # A header directly followed by another, like a division by its
+        # first section, shares its chunk
+        if segments and last_code_line == header_line:
+            first = segments.pop()[0]
+        # Compiler directives before the first division belong to it
+        if len(segments) == 1 and not segments[0][1]:
+            first = segments.pop()[0]
+        header_line = i
+        context.update(fields)
+        segments.append(
+            (first, {key: value for key, value in context.items() if value})
+        )
+
+    for i, line in enumerate(lines):
+        code, area_a = _source_line(line, fixed_format)
+        stripped = code.strip()
+        if not stripped:
+            continue
+        program = _PROGRAM_ID.search(code)
+        division = _DIVISION.match(code)
+        section = _SECTION.match(code)
+        paragraph = _PARAGRAPH.match(code)
+        if program:
+            context[COBOL_PROGRAM_KEY] = program.group(1).upper()
+            if segments:
+                segments[-1][1][COBOL_PROGRAM_KEY] = context[COBOL_PROGRAM_KEY]
+        if division:
+            name = division.group(1).upper()
+            start(
+                i,
+                {
+                    COBOL_DIVISION_KEY: "IDENTIFICATION" if name == "ID" else name,
+                    COBOL_SECTION_KEY: None,
+                    COBOL_PARAGRAPH_KEY: None,
+                },
+            )
+        elif section and area_a:
+            name = section.group(1).upper()
+            start(i, {COBOL_SECTION_KEY: name, COBOL_PARAGRAPH_KEY: None})
+        elif (
+            paragraph
+            and area_a
+            and context.get(COBOL_DIVISION_KEY) == "PROCEDURE"
+            and paragraph.group(1).upper() not in _STATEMENTS
+            and not paragraph.group(1).upper().startswith("END-")
+        ):
+            start(i, {COBOL_PARAGRAPH_KEY: paragraph.group(1).upper()})
+        elif not segments:
+            segments.append((i, {}))
+        last_code_line = i
+
+    if not segments:
+        segments.append((0, {}))
+    # The first segment starts at the top of the file
+    segments[0] = (0, segments[0][1])
+
+    # The copybooks of a window are those it copies
+    def annotate(chunk: Dict[str, Any], payload: Dict[str, Any]) -> None:
+        copybooks = copy_members(chunk["text"], fixed_format)
+        if copybooks:
+            chunk[COBOL_COPYBOOKS_KEY] = copybooks
+
+    return chunk_segments(text, segments, chunk_size, overlap_size, annotate)
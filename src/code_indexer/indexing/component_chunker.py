@@ -0,0 +1,188 @@
+"""Block-aware chunking of Vue and Svelte single-file components.
+
+A .vue file is split at its top-level blocks (<template>, <script>,
+<style> and custom blocks such as <i18n>), and a .svelte file at its
+top-level <script> and <style> blocks, with the markup between them
+forming "markup" chunks. Comments and blank lines before a block belong to
+it. Each chunk records:
+
+- "component_name": the component, named after its file (UserCard.vue is
+  "UserCard"; index.vue and SvelteKit's +page.svelte take the name of their
+  directory)
+- "component_block": template, script, style, markup or the custom tag
+- "component_lang": the language of the block from its lang attribute,
+  e.g. "ts" or "scss", defaulting to html, js and css
+
+Blocks also record [component, block] under HEADING_PATH_KEY, so their
+embedding is prefixed with it. Blocks longer than the chunk size are split
+into overlapping windows like FixedSizeChunker does.
+"""
+
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Tuple
+
+from .boundary_chunking import chunk_segments, line_offsets
+from .chunk_payload_keys import (
+    COMPONENT_BLOCK_KEY,
+    COMPONENT_LANG_KEY,
+    COMPONENT_NAME_KEY,
+    HEADING_PATH_KEY,
+)
+
+VUE_LANGUAGES = {"vue"}
+SVELTE_LANGUAGES = {"svelte"}
+
+# Language of a block without a lang attribute
+DEFAULT_BLOCK_LANGS = {
+    "template": "html",
+    "markup": "html",
+    "script": "js",
+    "style": "css",
+}
+
+# Opening tag of a top-level block at the start of a line
+_BLOCK_OPEN = re.compile(r"^<([A-Za-z][\w-]*)((?:\s[^>]*)?)>", re.M)
+_LANG_ATTR = re.compile(r"""\blang\s*=\s*["']?([\w-]+)""")
+_TEMPLATE_TAG = re.compile(r"<(/?)template\b[^>]*?(/?)>")
+
+
+def is_component(language: str) -> bool:
+    """Whether a language token is chunked by component blocks."""
+    return language.lower() in VUE_LANGUAGES | SVELTE_LANGUAGES
+
+
+def component_name(file_path: Path) -> str:
+    """Name of the component in a file."""
+    stem = file_path.stem
+    if (stem.lower() == "index" or stem.startswith("+")) and file_path.parent.name:
+        return file_path.parent.name
+    return stem
+
+
+def _block_end(text: str, tag: str, position: int) -> int:
+    """Offset after the closing tag of the block opened before position."""
+    if tag.lower() == "template":
+        # Templates nest <template> elements
+        depth = 1
+        for match in _TEMPLATE_TAG.finditer(text, position):
+            if match.group(2):
+                continue  # Self-closing
+            depth += -1 if match.group(1) else 1
+            if depth == 0:
+                return match.end()
+        return len(text)
+    closing = re.compile(rf"</{re.escape(tag)}\s*>", re.I).search(text, position)
+    return closing.end() if closing else len(text)
+
+
+def find_blocks(text: str, language: str) -> List[Tuple[int, int, str, str]]:
+    """
+    Top-level blocks of a component.
+
+    Returns:
+        (start offset, end offset, tag, lang) per block; for Svelte only
+        <script> and <style> blocks
+    """
+    svelte = language.lower() in SVELTE_LANGUAGES
+    blocks = []
+    position = 0
+    while True:
+        match = _BLOCK_OPEN.search(text, position)
+        if match is None:
+            return blocks
+        tag = match.group(1).lower()
+        if svelte and tag not in ("script", "style"):
+            position = match.end()
+            continue
+        if match.group(2).rstrip().endswith("/"):
+            end = match.end()  # Self-closing custom block
+        else:
+            end = _block_end(text, tag, match.end())
+        lang = _LANG_ATTR.search(match.group(2))
+        blocks.append(
+            (
+                match.start(),
+                end,
+                tag,
+                lang.group(1).lower() if lang else DEFAULT_BLOCK_LANGS.get(tag, ""),
+            )
+        )
+        position = end
+
+
+def chunk_component(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a Vue or Svelte component into blocks.
+
+    Args:
+        text: File text
+        file_path: Path of the file, naming the component
+        language: "vue" or "svelte"
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long block
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the COMPONENT_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    name = component_name(file_path)
+    offsets = line_offsets(text)
+
+    def line_of(offset: int) -> int:
+        return text.count("\n", 0, offset)
+
+    def payload(block: str, lang: str) -> Dict[str, Any]:
+        fields: Dict[str, Any] = {
+            COMPONENT_NAME_KEY: name,
+            COMPONENT_BLOCK_KEY: block,
+        }
+        if lang:
+            fields[COMPONENT_LANG_KEY] = lang
+        fields[HEADING_PATH_KEY] = [name, block]
+        return fields
+
+    # (first line index, payload) of each segment; a block starts on the line
+    # after the previous segment, so leading comments belong to it
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    next_line = 0
+    for start, end, tag, lang in find_blocks(text, language):
+        start_line = line_of(start)
+        if start_line < next_line:
+            continue  # Shares a line with the previous block
+        gap = text[offsets[next_line] : offsets[start_line]]
+        if language.lower() in SVELTE_LANGUAGES and _has_markup(gap):
+            segments.append((next_line, payload("markup", "html")))
+            # Blank lines and comments before the block belong to it
+            first = start_line
+            while first > next_line and _is_blank_or_comment(
+                text[offsets[first - 1] : offsets[first]]
+            ):
+                first -= 1
+            segments.append((first, payload(tag, lang)))
+        else:
+            segments.append((next_line, payload(tag, lang)))
+        next_line = line_of(max(end - 1, start)) + 1
+        if next_line >= len(offsets):
+            break
+    if next_line < len(offsets):
+        rest = text[offsets[next_line] :]
+        if language.lower() in SVELTE_LANGUAGES and _has_markup(rest):
+            segments.append((next_line, payload("markup", "html")))
+    if not segments:
+        segments.append((0, payload("markup", "html")))
+
+    return chunk_segments(text, segments, chunk_size, overlap_size)
+
+
+def _is_blank_or_comment(line: str) -> bool:
+    stripped = line.strip()
+    return not stripped or (stripped.startswith("<!--") and stripped.endswith("-->"))
+
+
+def _has_markup(text: str) -> bool:
+    """Whether text holds more than blank lines and HTML comments."""
+    return bool(re.sub(r"<!--.*?-->", "", text, flags=re.S).strip())
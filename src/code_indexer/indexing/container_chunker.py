@@ -0,0 +1,271 @@
+"""Stage- and service-aware chunking of Dockerfiles and Compose files.
+
+A Dockerfile (or Containerfile) is split at its FROM instructions, so every
+build stage is one chunk. Instructions and comments before the first FROM
+(parser directives, global ARGs) belong to the first stage, and comments
+directly above a FROM belong to its stage. Each stage chunk records:
+
+- "docker_stage": the stage name ("FROM golang:1.22 AS build") or, for
+  unnamed stages, its index ("0", "1", ...)
+- "docker_base_image": the image or earlier stage it builds on
+
+A Compose file (docker-compose.yml, compose.yaml, compose.override.yml, ...)
+is split into one chunk per service under "services"; the remaining
+sections (networks, volumes, ...) form chunks of their own. Each service
+chunk records:
+
+- "compose_service": the service name
+- "compose_image": its image, if any
+- "compose_ports" and "compose_volumes": its port and volume mappings as
+  written in short syntax ("8080:80", "./data:/var/lib/postgresql/data")
+
+Service chunks also record their key path (["services", "web"]) under
+HEADING_PATH_KEY, so their embedding is prefixed with it. Stages and
+services longer than the chunk size are split into overlapping windows like
+FixedSizeChunker does. Compose files that do not parse fall back to
+fixed-size chunking.
+"""
+
+import bisect
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+import yaml
+
+from .boundary_chunking import chunk_segments, line_offsets
+from .chunk_payload_keys import (
+    COMPOSE_IMAGE_KEY,
+    COMPOSE_PORTS_KEY,
+    COMPOSE_SERVICE_KEY,
+    COMPOSE_VOLUMES_KEY,
+    DOCKER_BASE_IMAGE_KEY,
+    DOCKER_STAGE_KEY,
+    HEADING_PATH_KEY,
+)
+
+DOCKERFILE_LANGUAGES = {"dockerfile"}
+COMPOSE_LANGUAGES = {"yaml", "yml"}
+
+_COMPOSE_FILE_NAME = re.compile(r"^(?:docker-)?compose(?:\.[\w.-]+)?\.ya?ml$", re.I)
+_ESCAPE_DIRECTIVE = re.compile(r"^#\s*escape\s*=\s*(\S)\s*$", re.I)
+_HEREDOC = re.compile(r"<<-?\s*[\"']?(\w+)[\"']?")
+
+
+def is_dockerfile(language: str) -> bool:
+    """Whether a language token is chunked by build stages."""
+    return language.lower() in DOCKERFILE_LANGUAGES
+
+
+def is_compose_file(language: str, file_name: str) -> bool:
+    """Whether a file is a Compose file, by its language and name."""
+    return language.lower() in COMPOSE_LANGUAGES and bool(
+        _COMPOSE_FILE_NAME.match(file_name)
+    )
+
+
+def _is_blank_or_comment(line: str) -> bool:
+    stripped = line.strip()
+    return not stripped or stripped.startswith("#")
+
+
+def dockerfile_instructions(text: str) -> List[Tuple[int, int, str]]:
+    """
+    Instructions of a Dockerfile with continuation lines and heredocs joined.
+
+    Returns:
+        (first line index, last line index, instruction text) per instruction
+    """
+    lines = text.split("\n")
+    escape = "\\"
+    for line in lines:
+        if not line.startswith("#"):
+            break  # Parser directives only precede the first instruction
+        match = _ESCAPE_DIRECTIVE.match(line)
+        if match:
+            escape = match.group(1)
+    instructions = []
+    i = 0
+    while i < len(lines):
+        if _is_blank_or_comment(lines[i]):
+            i += 1
+            continue
+        first = i
+        parts = []
+        while True:
+            line = lines[i].rstrip()
+            if line.endswith(escape) and i + 1 < len(lines):
+                parts.append(line[: -len(escape)])
+                i += 1
+                # Comments and blank lines inside a continued instruction
+                while i + 1 < len(lines) and _is_blank_or_comment(lines[i]):
+                    i += 1
+                continue
+            parts.append(line)
+            break
+        instruction = " ".join(p.strip() for p in parts)
+        for delimiter in _HEREDOC.findall(instruction):
+            while i + 1 < len(lines) and lines[i + 1].strip() != delimiter:
+                i += 1
+            i += 1
+        instructions.append((first, min(i, len(lines) - 1), instruction))
+        i += 1
+    return instructions
+
+
+def parse_from(instruction: str) -> Tuple[str, Optional[str]]:
+    """Base image and stage name of a FROM instruction."""
+    words = [w for w in instruction.split()[1:] if not w.startswith("--")]
+    base_image = words[0] if words else ""
+    if len(words) >= 3 and words[1].lower() == "as":
+        return base_image, words[2]
+    return base_image, None
+
+
+def chunk_dockerfile(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a Dockerfile into build stages.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long stage
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the DOCKER_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    # (first line index, payload) of each stage
+    stages: List[Tuple[int, Dict[str, Any]]] = []
+    previous_last = -1
+    for first, last, instruction in dockerfile_instructions(text):
+        if instruction.split(None, 1)[0].upper() == "FROM":
+            base_image, name = parse_from(instruction)
+            payload = {
+                DOCKER_STAGE_KEY: name or str(len(stages)),
+                DOCKER_BASE_IMAGE_KEY: base_image,
+            }
+            if not stages:
+                stages.append((0, payload))
+            else:
+                # Comments directly above FROM belong to its stage
+                start = first
+                while start - 1 > previous_last and lines[start - 1].strip():
+                    start -= 1
+                stages.append((start, payload))
+        previous_last = last
+    if not stages:
+        stages.append((0, {}))
+    return chunk_segments(text, stages, chunk_size, overlap_size)
+
+
+def chunk_compose(
+    text: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    """
+    Split a Compose file into services.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long service
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the COMPOSE_*
+        fields (chunk_index, total_chunks and file fields are left to the
+        caller), or None if the text does not parse
+    """
+    try:
+        root = yaml.compose(text, Loader=yaml.SafeLoader)
+    except yaml.YAMLError:
+        return None
+    if not isinstance(root, yaml.MappingNode):
+        return None
+
+    offsets = line_offsets(text)
+    lines = text.split("\n")
+
+    def line_of(offset: int) -> int:
+        return bisect.bisect_right(offsets, offset) - 1
+
+    # (first line index, payload) of each segment; a segment starts after
+    # the last value line of the previous one, so comments above a service
+    # belong to it
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    previous_end = 0
+
+    def add(first: int, value: Any, payload: Dict[str, Any]) -> None:
+        nonlocal previous_end
+        start = previous_end if segments else 0
+        if segments and start <= segments[-1][0]:
+            return  # Shares a line with the previous segment
+        if payload or not segments or segments[-1][1]:
+            segments.append((start, payload))
+        end = value.end_mark.index
+        while end > 0 and text[end - 1].isspace():
+            end -= 1
+        last = line_of(end - 1)
+        while last > first and _is_blank_or_comment(lines[last]):
+            last -= 1
+        previous_end = last + 1
+
+    for key, value in root.value:
+        if (
+            isinstance(key, yaml.ScalarNode)
+            and key.value == "services"
+            and isinstance(value, yaml.MappingNode)
+        ):
+            for service_key, service in value.value:
+                name = str(service_key.value)
+                add(
+                    line_of(service_key.start_mark.index),
+                    service,
+                    {
+                        COMPOSE_SERVICE_KEY: name,
+                        **_service_fields(service),
+                        HEADING_PATH_KEY: ["services", name],
+                    },
+                )
+        else:
+            add(line_of(key.start_mark.index), value, {})
+    if not segments:
+        return None
+    return chunk_segments(text, segments, chunk_size, overlap_size)
+
+
+def _service_fields(service: Any) -> Dict[str, Any]:
+    """Image, ports and volumes of a Compose service node."""
+    fields: Dict[str, Any] = {}
+    if not isinstance(service, yaml.MappingNode):
+        return fields
+    values = {str(k.value): v for k, v in service.value}
+    image = values.get("image")
+    if isinstance(image, yaml.ScalarNode) and image.value:
+        fields[COMPOSE_IMAGE_KEY] = str(image.value)
+    for key, field_key, source, target in (
+        ("ports", COMPOSE_PORTS_KEY, "published", "target"),
+        ("volumes", COMPOSE_VOLUMES_KEY, "source", "target"),
+    ):
+        entries = values.get(key)
+        if not isinstance(entries, yaml.SequenceNode):
+            continue
+        mappings = []
+        for entry in entries.value:
+            if isinstance(entry, yaml.ScalarNode):
+                mappings.append(str(entry.value))
+            elif isinstance(entry, yaml.MappingNode):
+                # Long syntax: {published: 8080, target: 80}
+                long_form = {
+                    str(k.value): str(v.value)
+                    for k, v in entry.value
+                    if isinstance(v, yaml.ScalarNode)
+                }
+                parts = [long_form[f] for f in (source, target) if long_form.get(f)]
+                if parts:
+                    mappings.append(":".join(parts))
+        if mappings:
+            fields[field_key] = mappings
+    return fields
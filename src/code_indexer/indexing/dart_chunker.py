@@ -0,0 +1,259 @@
+"""Declaration-aware chunking of Dart and Flutter source files.
+
+A .dart file is split at its top-level declarations: classes, mixins,
+extensions, enums, typedefs and functions. Directives and top-level
+variables between them form "library" chunks, and doc comments and
+annotations directly above a declaration belong to it. In Flutter widgets
+(subclasses of StatelessWidget, StatefulWidget and their Riverpod and hooks
+variants) and in the State classes of stateful widgets, the
+"Widget build(BuildContext context)" method is a chunk of its own, so a
+screen's layout is found apart from its state handling. Each chunk
+records:
+
+- "symbol_kind": class, mixin, extension, enum, typedef, function, widget
+  (a widget class), build (a widget's build method) or library
+- "symbol_name": the declaration name ("CounterPage", "CounterPage.build",
+  "on String" for an unnamed extension)
+- "flutter_widget": the widget a chunk belongs to, for widget classes,
+  their State classes and build methods
+
+Strings, including multi-line and raw strings, and comments are skipped
+when matching braces. Declarations longer than the chunk size are split
+into overlapping windows like FixedSizeChunker does, and every window keeps
+these fields.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import (
+    DEGRADED_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    chunk_segments,
+    keep_segments,
+    leading_comments,
+)
+from .chunk_payload_keys import FLUTTER_WIDGET_KEY
+
+DART_LANGUAGES = {"dart"}
+
+# Base classes of Flutter widgets and of the State of stateful widgets
+FLUTTER_WIDGET_BASES = {
+    "StatelessWidget",
+    "StatefulWidget",
+    "ConsumerWidget",
+    "ConsumerStatefulWidget",
+    "HookWidget",
+    "HookConsumerWidget",
+    "StatefulHookWidget",
+}
+FLUTTER_STATE_BASES = {"State", "ConsumerState"}
+
+_DECLARATION = re.compile(
+    r"^\s*(?:(?:abstract|base|final|interface|sealed)\s+)*"
+    r"(mixin\s+class|class|mixin|extension\s+type|extension|enum|typedef)\b"
+    r"\s*(\w+)?"
+)
+_UNNAMED_EXTENSION = re.compile(
+    r"^\s*extension\s*(?:<[^>]*>\s*)?on\s+([\w.<>?, ]+?)\s*\{"
+)
+_EXTENDS = re.compile(r"\bextends\s+([\w.]+)\s*(?:<\s*(\w+)[^>]*>)?")
+_FUNCTION = re.compile(
+    r"^(?:external\s+)?(?:[\w<>?,.\[\] ]+?\s+)?(?:(?:get|set)\s+)?"
+    r"(?!(?:if|for|while|switch|return|assert)\b)([A-Za-z_$][\w$]*)\s*"
+    r"(?:<[^>()]*>)?\s*(?:\(|=>|\{|async\b)"
+)
+_BUILD_METHOD = re.compile(r"^\s*Widget\s+build\s*\(")
+_ANNOTATION = re.compile(r"^\s*@\w+(?:\.\w+)*(?:\(.*\))?\s*$")
+_KEYWORDS = {"import", "export", "part", "library", "const", "final", "var", "late"}
+
+
+def is_dart(language: str) -> bool:
+    """Whether a language token is chunked by Dart declarations."""
+    return language.lower() in DART_LANGUAGES
+
+
+def _dart_code(lines: List[str]) -> List[str]:
+    """Lines of a Dart file with comments and string contents blanked."""
+    code_lines = []
+    state: Optional[str] = None  # Open comment or string delimiter
+    raw = False
+    for line in lines:
+        code = []
+        i = 0
+        while i < len(line):
+            if state is not None:
+                closer = "*/" if state == "/*" else state
+                if not raw and state != "/*" and line[i] == "\\":
+                    i += 2
+                    continue
+                if line.startswith(closer, i):
+                    state = None
+                    code.append(" ")
+                    i += len(closer)
+                else:
+                    i += 1
+                continue
+            if line.startswith("//", i):
+                break
+            raw = line[i] == "r" and line[i + 1 : i + 2] in ("'", '"')
+            start = i + 1 if raw else i
+            opener = next(
+                (
+                    o
+                    for o in ("/*", "'''", '"""', "'", '"')
+                    if line.startswith(o, start) and (not raw or o != "/*")
+                ),
+                None,
+            )
+            if opener is not None:
+                state = opener
+                code.append(" ")
+                i = start + len(opener)
+                continue
+            code.append(line[i])
+            i += 1
+        if state in ("'", '"'):
+            state = None  # Unterminated single-line string
+        code_lines.append("".join(code))
+    return code_lines
+
+
+def _is_comment_or_annotation(line: str) -> bool:
+    stripped = line.lstrip()
+    return stripped.startswith(("//", "/*", "*")) or bool(_ANNOTATION.match(line))
+
+
+def _header(code_lines: List[str], i: int) -> str:
+    """Code of a declaration header from line i to its body or end."""
+    header = []
+    for code in code_lines[i : i + 10]:
+        header.append(code.strip())
+        if "{" in code or ";" in code or "=>" in code:
+            break
+    return " ".join(header)
+
+
+def _declaration(header: str) -> Optional[Dict[str, Any]]:
+    """Payload of the declaration starting a header, or None."""
+    declaration = _DECLARATION.match(header)
+    if declaration:
+        keyword = declaration.group(1).split()[-1]
+        if keyword == "type":
+            keyword = "extension"
+        name = declaration.group(2)
+        if keyword == "extension" and name in (None, "on"):
+            unnamed = _UNNAMED_EXTENSION.match(header)
+            name = f"on {unnamed.group(1)}" if unnamed else None
+        payload: Dict[str, Any] = {SYMBOL_KIND_KEY: keyword}
+        if name:
+            payload[SYMBOL_NAME_KEY] = name
+        extends = _EXTENDS.search(header) if keyword == "class" else None
+        if extends and name:
+            base = extends.group(1).split(".")[-1]
+            if base in FLUTTER_WIDGET_BASES:
+                payload[SYMBOL_KIND_KEY] = "widget"
+                payload[FLUTTER_WIDGET_KEY] = name
+            elif base in FLUTTER_STATE_BASES and extends.group(2):
+                payload[FLUTTER_WIDGET_KEY] = extends.group(2)
+        return payload
+    function = _FUNCTION.match(header)
+    if function and function.group(1) not in _KEYWORDS and "=" not in header.split(
+        "(", 1
+    )[0].replace("=>", ""):
+        return {SYMBOL_KIND_KEY: "function", SYMBOL_NAME_KEY: function.group(1)}
+    return None
+
+
+def chunk_dart(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a Dart file into declarations and widget build methods.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long declaration
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the SYMBOL_*
+        and FLUTTER_WIDGET_KEY fields; chunk_index, total_chunks and file
+        fields are left to the caller
+    """
+    lines = text.split("\n")
+    code_lines = _dart_code(lines)
+    library = {SYMBOL_KIND_KEY: "library"}
+    # (first line index, payload) of each segment
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    depth = 0
+    # Payload of the open declaration, and of the open build method
+    declaration: Optional[Dict[str, Any]] = None
+    build: Optional[Dict[str, Any]] = None
+    # Whether the body of the open declaration or build method has started
+    opened = build_opened = False
+    last_code_line = -1
+
+    def start(i: int, payload: Dict[str, Any]) -> None:
+        # Doc comments and annotations directly above belong to it
+        first = leading_comments(lines, i, last_code_line, _is_comment_or_annotation)
+        segments.append((first, payload))
+
+    for i, code in enumerate(code_lines):
+        stripped = code.strip()
+        if stripped and _ANNOTATION.match(code):
+            continue  # Annotations belong to the next declaration
+        if declaration is None and depth == 0 and stripped:
+            payload = _declaration(_header(code_lines, i))
+            if payload is not None:
+                declaration = payload
+                opened = False
+                start(i, payload)
+            elif not segments or segments[-1][1] is not library:
+                segments.append((i, library))
+        elif (
+            declaration is not None
+            and build is None
+            and depth == 1
+            and FLUTTER_WIDGET_KEY in declaration
+            and _BUILD_METHOD.match(code)
+        ):
+            build = {
+                SYMBOL_KIND_KEY: "build",
+                SYMBOL_NAME_KEY: f"{declaration.get(SYMBOL_NAME_KEY)}.build",
+                FLUTTER_WIDGET_KEY: declaration[FLUTTER_WIDGET_KEY],
+            }
+            build_opened = False
+            start(i, build)
+
+        opens = code.count("{")
+        depth = max(depth + opens - code.count("}"), 0)
+        if opens:
+            opened = True
+            build_opened = build is not None
+        if build is not None and depth <= 1 and (build_opened or ";" in code):
+            build = None
+            # The rest of the class follows its build method
+            segments.append((i + 1, declaration))
+        if declaration is not None and depth == 0 and (opened or ";" in code):
+            declaration = None
+            build = None
+            segments.append((i + 1, library))
+        if stripped:
+            last_code_line = i
+
+    if declaration is not None:
+        # A declaration still open at the end of the file has a syntax error
+        declaration[DEGRADED_KEY] = True
+
+    # Segments holding only blank lines, comments and closing braces belong
+    # to the segment before them
+    def is_filler(first: int, end: int, payload: Dict[str, Any]) -> bool:
+        return all(
+            not s.strip() or s.strip() in ("}", "};") for s in code_lines[first:end]
+        )
+
+    kept = keep_segments(segments, len(lines), is_filler, library)
+    return chunk_segments(text, kept, chunk_size, overlap_size)
+
@@ -0,0 +1,223 @@
+"""Heading-aware chunking of Markdown, reStructuredText and AsciiDoc files.
+
+Documentation is split at its headings instead of fixed character windows,
+so every chunk is one section (or a window of a long section) and search
+results for design docs and READMEs are well scoped. Each chunk records its
+heading breadcrumb (e.g. ["Deployment", "Kubernetes", "Secrets"]) under
+HEADING_PATH_KEY; indexing stores it in the payload and embeds it in front of
+the chunk text ("Deployment > Kubernetes > Secrets"), while the stored chunk
+text stays an exact excerpt of the file.
+
+Sections whose body is empty (a heading directly followed by a subheading)
+are merged into the next section. Sections longer than the chunk size are
+split into overlapping windows like FixedSizeChunker does, and every window
+keeps the section's breadcrumb. Headings inside fenced or literal blocks are
+ignored.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import split_segment
+from .chunk_payload_keys import HEADING_PATH_KEY
+
+BREADCRUMB_SEPARATOR = " > "
+
+MARKDOWN_LANGUAGES = {"md", "markdown", "mdx", "mkd"}
+RST_LANGUAGES = {"rst", "rest"}
+ASCIIDOC_LANGUAGES = {"adoc", "asciidoc"}
+DOCUMENT_LANGUAGES = MARKDOWN_LANGUAGES | RST_LANGUAGES | ASCIIDOC_LANGUAGES
+
+_MD_ATX = re.compile(r"^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$")
+_MD_SETEXT = re.compile(r"^ {0,3}(=+|-+)[ \t]*$")
+_MD_FENCE = re.compile(r"^ {0,3}(`{3,}|~{3,})")
+_RST_ADORNMENT = re.compile(r"^([=\-~^\"'`#*+:._])\1{2,}[ \t]*$")
+_ADOC_HEADING = re.compile(r"^(={1,6})[ \t]+(\S.*?)[ \t]*$")
+_ADOC_DELIMITER = re.compile(r"^(-{4,}|\.{4,}|={4,}|\+{4,}|/{4,}|_{4,})[ \t]*$")
+
+# (first line index, number of lines, level, title)
+Heading = Tuple[int, int, int, str]
+
+
+def is_document(language: str) -> bool:
+    """Whether a language token is chunked by headings."""
+    return language.lower() in DOCUMENT_LANGUAGES
+
+
+def breadcrumb(heading_path: List[str]) -> str:
+    """Display form of a heading path."""
+    return BREADCRUMB_SEPARATOR.join(heading_path)
+
+
+def find_headings(lines: List[str], language: str) -> List[Heading]:
+    """Headings of a document, in order."""
+    language = language.lower()
+    if language in RST_LANGUAGES:
+        return _rst_headings(lines)
+    if language in ASCIIDOC_LANGUAGES:
+        return _asciidoc_headings(lines)
+    return _markdown_headings(lines)
+
+
+def _markdown_headings(lines: List[str]) -> List[Heading]:
+    headings: List[Heading] = []
+    fence: Optional[str] = None
+    start = 0
+    # YAML front matter is not a setext heading
+    if lines and lines[0].rstrip() == "---":
+        for i in range(1, len(lines)):
+            if lines[i].rstrip() in ("---", "..."):
+                start = i + 1
+                break
+    for i in range(start, len(lines)):
+        line = lines[i]
+        fence_match = _MD_FENCE.match(line)
+        if fence is not None:
+            if fence_match and fence_match.group(1)[0] == fence[0]:
+                if len(fence_match.group(1)) >= len(fence):
+                    fence = None
+            continue
+        if fence_match:
+            fence = fence_match.group(1)
+            continue
+        atx = _MD_ATX.match(line)
+        if atx:
+            headings.append((i, 1, len(atx.group(1)), atx.group(2).strip()))
+            continue
+        setext = _MD_SETEXT.match(line)
+        if (
+            setext
+            and i > start
+            and lines[i - 1].strip()
+            and not lines[i - 1].startswith(("    ", "\t"))
+            and not _MD_ATX.match(lines[i - 1])
+            and not (headings and headings[-1][0] + headings[-1][1] > i - 1)
+            # A paragraph continues over several lines; only one-line
+            # paragraphs are taken as titles
+            and (i - 1 == start or not lines[i - 2].strip())
+        ):
+            level = 1 if setext.group(1)[0] == "=" else 2
+            headings.append((i - 1, 2, level, lines[i - 1].strip()))
+    return headings
+
+
+def _rst_headings(lines: List[str]) -> List[Heading]:
+    headings: List[Heading] = []
+    # Levels follow the order in which adornment styles first appear
+    styles: List[Tuple[str, bool]] = []
+    i = 0
+    while i < len(lines) - 1:
+        title = lines[i]
+        overline = False
+        start = i
+        if (
+            _RST_ADORNMENT.match(title)
+            and i + 2 < len(lines)
+            and lines[i + 1].strip()
+            and _RST_ADORNMENT.match(lines[i + 2])
+            and lines[i + 2].strip()[0] == title.strip()[0]
+        ):
+            overline = True
+            title = lines[i + 1]
+            underline = lines[i + 2]
+            end = i + 2
+        else:
+            underline = lines[i + 1]
+            end = i + 1
+        if (
+            title.strip()
+            and not title.startswith((" ", "\t"))
+            and not _RST_ADORNMENT.match(title)
+            and _RST_ADORNMENT.match(underline)
+            and len(underline.rstrip()) >= len(title.rstrip())
+        ):
+            style = (underline.strip()[0], overline)
+            if style not in styles:
+                styles.append(style)
+            headings.append(
+                (start, end - start + 1, styles.index(style) + 1, title.strip())
+            )
+            i = end + 1
+            continue
+        i += 1
+    return headings
+
+
+def _asciidoc_headings(lines: List[str]) -> List[Heading]:
+    headings: List[Heading] = []
+    delimiter: Optional[str] = None
+    for i, line in enumerate(lines):
+        block = _ADOC_DELIMITER.match(line)
+        if delimiter is not None:
+            if block and block.group(1) == delimiter:
+                delimiter = None
+            continue
+        if block:
+            delimiter = block.group(1)
+            continue
+        heading = _ADOC_HEADING.match(line)
+        if heading:
+            headings.append((i, 1, len(heading.group(1)), heading.group(2)))
+    return headings
+
+
+def chunk_document(
+    text: str,
+    language: str,
+    chunk_size: int,
+    overlap_size: int,
+) -> List[Dict[str, Any]]:
+    """
+    Split a document into heading sections.
+
+    Args:
+        text: Document text
+        language: Language token (see DOCUMENT_LANGUAGES)
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long section
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and
+        HEADING_PATH_KEY; chunk_index, total_chunks and file fields are left
+        to the caller
+    """
+    # Only "\n" ends a line, as in the line numbers of all other chunks
+    parts = text.split("\n")
+    lines = [part + "\n" for part in parts[:-1]] + ([parts[-1]] if parts[-1] else [])
+    offsets = [0]
+    for line in lines:
+        offsets.append(offsets[-1] + len(line))
+    headings = find_headings([line.rstrip("\r\n") for line in lines], language)
+
+    # Sections: (first line, first body line, heading path)
+    sections: List[Tuple[int, int, List[str]]] = []
+    if not headings or headings[0][0] > 0:
+        sections.append((0, 0, []))
+    stack: List[Tuple[int, str]] = []
+    for line_index, line_count, level, title in headings:
+        while stack and stack[-1][0] >= level:
+            stack.pop()
+        stack.append((level, title))
+        sections.append((line_index, line_index + line_count, [t for _, t in stack]))
+
+    chunks: List[Dict[str, Any]] = []
+    chunk_start = 0
+    for n, (_, body_start, heading_path) in enumerate(sections):
+        next_line = sections[n + 1][0] if n + 1 < len(sections) else len(lines)
+        has_body = "".join(lines[body_start:next_line]).strip()
+        if not has_body and n + 1 < len(sections):
+            continue  # Merged into the next section
+        section = text[offsets[chunk_start] : offsets[next_line]]
+        first_line = chunk_start + 1
+        chunk_start = next_line
+        if section.strip():
+            chunks.extend(
+                split_segment(
+                    section,
+                    first_line,
+                    {HEADING_PATH_KEY: heading_path},
+                    chunk_size,
+                    overlap_size,
+                )
+            )
+    return chunks
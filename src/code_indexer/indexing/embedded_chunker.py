@@ -0,0 +1,339 @@
+"""Routing of code embedded in HTML, templates and Markdown to its language.
+
+An HTML page holds JavaScript in <script> and CSS in <style> blocks, a
+README holds Python or shell in fenced code blocks, and an ERB or Blade
+template holds Ruby or PHP. Such files are split into regions of the host
+language and embedded regions, and each embedded region is chunked as a file
+of its own language would be, so the JavaScript of a page is indexed, filtered
+and searched as JavaScript. Regions are found:
+
+- HTML (.html, .htm, .xhtml) and templates: the bodies of <script> blocks
+  (JavaScript, TypeScript or JSON by their type or lang attribute; script
+  templates stay HTML) and <style> blocks (CSS, SCSS, Sass or Less)
+- ERB templates (.erb, .rhtml): multi-line <% %> tags as Ruby
+- Blade templates (.blade.php): @php ... @endphp and <?php ?> as PHP
+- Jinja templates (.j2, .jinja, .jinja2): only their script and style blocks,
+  as Jinja tags are not code of another language
+- Markdown: fenced code blocks whose info string names a known language
+  (```python, ~~~bash); other fences stay Markdown
+
+Chunks of embedded regions record the region's language token under
+EMBEDDED_LANGUAGE_KEY, which indexing stores as the chunk's language.
+Markdown chunks, embedded or not, keep the heading breadcrumb of the
+section they are in. Single-line tags and inline code stay in the host.
+"""
+
+import re
+from typing import Any, Callable, Dict, List, Optional, Tuple
+
+from .boundary_chunking import EMBEDDED_LANGUAGE_KEY
+from .document_chunker import HEADING_PATH_KEY, MARKDOWN_LANGUAGES, find_headings
+
+HTML_LANGUAGES = {"html", "htm", "xhtml"}
+ERB_LANGUAGES = {"erb", "rhtml"}
+JINJA_LANGUAGES = {"j2", "jinja", "jinja2"}
+BLADE_SUFFIX = ".blade.php"
+
+# (start offset, end offset, language token of an embedded region or None)
+Region = Tuple[int, int, Optional[str]]
+
+_SCRIPT = re.compile(r"<script\b([^>]*)>(.*?)</script\s*>", re.IGNORECASE | re.DOTALL)
+_STYLE = re.compile(r"<style\b([^>]*)>(.*?)</style\s*>", re.IGNORECASE | re.DOTALL)
+_ATTRIBUTE = re.compile(r"""\b(type|lang)\s*=\s*["']?([^"'\s>]+)""", re.IGNORECASE)
+_ERB_CODE = re.compile(r"<%(?![%#])[-=]?(.*?)-?%>", re.DOTALL)
+_BLADE_CODE = re.compile(r"@php\b(.*?)@endphp\b|<\?php\b(.*?)(?:\?>|$)", re.DOTALL)
+_MD_FENCE = re.compile(r"^ {0,3}(`{3,}|~{3,})[ \t]*([^`\s{]*)")
+
+# Script type or lang attribute -> language token; other types (templates)
+# stay in the host
+_SCRIPT_TYPES = {
+    "": "js",
+    "text/javascript": "js",
+    "application/javascript": "js",
+    "module": "js",
+    "js": "js",
+    "text/babel": "jsx",
+    "jsx": "jsx",
+    "text/typescript": "ts",
+    "ts": "ts",
+    "tsx": "tsx",
+    "application/json": "json",
+    "application/ld+json": "json",
+    "importmap": "json",
+    "speculationrules": "json",
+}
+_STYLE_LANGS = {
+    "": "css",
+    "text/css": "css",
+    "scss": "scss",
+    "sass": "sass",
+    "less": "less",
+}
+
+# Markdown info string -> language token
+FENCE_LANGUAGES = {
+    "python": "py",
+    "py": "py",
+    "python3": "py",
+    "javascript": "js",
+    "js": "js",
+    "jsx": "jsx",
+    "node": "js",
+    "typescript": "ts",
+    "ts": "ts",
+    "tsx": "tsx",
+    "bash": "sh",
+    "sh": "sh",
+    "shell": "sh",
+    "zsh": "sh",
+    "ruby": "rb",
+    "rb": "rb",
+    "go": "go",
+    "golang": "go",
+    "rust": "rs",
+    "rs": "rs",
+    "java": "java",
+    "kotlin": "kt",
+    "kt": "kt",
+    "scala": "scala",
+    "groovy": "groovy",
+    "swift": "swift",
+    "c": "c",
+    "cpp": "cpp",
+    "c++": "cpp",
+    "csharp": "cs",
+    "cs": "cs",
+    "c#": "cs",
+    "objective-c": "m",
+    "objc": "m",
+    "php": "php",
+    "perl": "pl",
+    "lua": "lua",
+    "dart": "dart",
+    "r": "r",
+    "julia": "jl",
+    "elixir": "ex",
+    "erlang": "erl",
+    "zig": "zig",
+    "sql": "sql",
+    "html": "html",
+    "xml": "xml",
+    "css": "css",
+    "scss": "scss",
+    "json": "json",
+    "yaml": "yaml",
+    "yml": "yaml",
+    "toml": "toml",
+    "dockerfile": "dockerfile",
+    "docker": "dockerfile",
+    "makefile": "makefile",
+    "make": "makefile",
+    "cmake": "cmake",
+    "proto": "proto",
+    "protobuf": "proto",
+    "cobol": "cbl",
+    "verilog": "v",
+    "systemverilog": "sv",
+    "vhdl": "vhd",
+}
+
+
+def is_embedded_host(language: str, file_name: str = "") -> bool:
+    """Whether a file may hold code of other languages to route."""
+    language = language.lower()
+    return (
+        language in HTML_LANGUAGES | ERB_LANGUAGES | JINJA_LANGUAGES
+        or language in MARKDOWN_LANGUAGES
+        or file_name.lower().endswith(BLADE_SUFFIX)
+    )
+
+
+def embedded_regions(text: str, language: str, file_name: str = "") -> List[Region]:
+    """
+    Regions of a file, in order and covering all of its text.
+
+    Args:
+        text: File text
+        language: Language token of the file
+        file_name: File name, which tells Blade templates from PHP files
+
+    Returns:
+        (start, end, language) of each region; the language is None for
+        regions of the host language
+    """
+    language = language.lower()
+    if language in MARKDOWN_LANGUAGES:
+        embedded = _fenced_blocks(text)
+    else:
+        embedded = _tag_blocks(text)
+        if language in ERB_LANGUAGES:
+            embedded += _code_tags(text, _ERB_CODE, "rb")
+        elif file_name.lower().endswith(BLADE_SUFFIX):
+            embedded += _code_tags(text, _BLADE_CODE, "php")
+
+    regions: List[Region] = []
+    position = 0
+    for start, end, region_language in sorted(embedded):
+        if start < position or not text[start:end].strip():
+            continue  # Inside an earlier region, or empty
+        if start > position:
+            regions.append((position, start, None))
+        regions.append((start, end, region_language))
+        position = end
+    if position < len(text) or not regions:
+        regions.append((position, len(text), None))
+    return regions
+
+
+def chunk_embedded(
+    text: str,
+    language: str,
+    file_name: str,
+    chunk_region: Callable[[str, Optional[str]], List[Dict[str, Any]]],
+    heading_paths: bool = True,
+) -> List[Dict[str, Any]]:
+    """
+    Chunk a file region by region.
+
+    Args:
+        text: File text
+        language: Language token of the file
+        file_name: File name
+        chunk_region: Chunks the text of a region given its embedded
+            language, or None for the host language; line numbers are
+            relative to the region
+        heading_paths: Whether Markdown chunks record their breadcrumb
+
+    Returns:
+        Chunk dicts of all regions with file line numbers; chunks of
+        embedded regions record EMBEDDED_LANGUAGE_KEY
+    """
+    regions = embedded_regions(text, language, file_name)
+    chunks: List[Dict[str, Any]] = []
+    for start, end, region_language in regions:
+        region = text[start:end]
+        if not region.strip():
+            continue
+        first_line = text.count("\n", 0, start)
+        for chunk in chunk_region(region, region_language):
+            chunk["line_start"] += first_line
+            chunk["line_end"] += first_line
+            if region_language is not None:
+                chunk[EMBEDDED_LANGUAGE_KEY] = region_language
+            chunks.append(chunk)
+
+    # A document without embedded regions keeps the breadcrumbs of its
+    # sections as they are
+    markdown = language.lower() in MARKDOWN_LANGUAGES
+    if heading_paths and markdown and len(regions) > 1:
+        _add_heading_paths(text, language, chunks)
+    return chunks
+
+
+def _region_body(text: str, start: int, end: int) -> Tuple[int, int]:
+    """
+    Body of a block between its tags, as whole lines when the tags are on
+    lines of their own.
+    """
+    body = text[start:end]
+    first_break = body.find("\n")
+    if first_break >= 0 and not body[:first_break].strip():
+        start += first_break + 1
+        body = text[start:end]
+    last_break = body.rfind("\n")
+    if last_break >= 0 and not body[last_break + 1 :].strip():
+        end = start + last_break + 1
+    return start, end
+
+
+def _tag_blocks(text: str) -> List[Region]:
+    blocks: List[Region] = []
+    for pattern, languages in ((_SCRIPT, _SCRIPT_TYPES), (_STYLE, _STYLE_LANGS)):
+        for block in pattern.finditer(text):
+            attributes = dict(
+                (name.lower(), value.lower())
+                for name, value in _ATTRIBUTE.findall(block.group(1))
+            )
+            kind = attributes.get("lang", attributes.get("type", ""))
+            if kind in languages:
+                start, end = _region_body(text, block.start(2), block.end(2))
+                blocks.append((start, end, languages[kind]))
+    return blocks
+
+
+def _code_tags(text: str, pattern: "re.Pattern[str]", language: str) -> List[Region]:
+    tags: List[Region] = []
+    for tag in pattern.finditer(text):
+        group = 1 if tag.group(1) is not None else 2
+        # Single-line tags are part of the markup around them
+        if "\n" in tag.group(group):
+            start, end = _region_body(text, tag.start(group), tag.end(group))
+            tags.append((start, end, language))
+    return tags
+
+
+def _fenced_blocks(text: str) -> List[Region]:
+    blocks: List[Region] = []
+    offset = 0
+    fence: Optional[str] = None
+    body_start = 0
+    fence_language: Optional[str] = None
+    for line in text.split("\n"):
+        match = _MD_FENCE.match(line)
+        if fence is None and match:
+            fence = match.group(1)
+            body_start = offset + len(line) + 1
+            fence_language = FENCE_LANGUAGES.get(match.group(2).lower())
+        elif (
+            fence is not None
+            and match
+            and match.group(1)[0] == fence[0]
+            and len(match.group(1)) >= len(fence)
+            and not match.group(2)
+        ):
+            if fence_language is not None:
+                blocks.append((body_start, offset, fence_language))
+            fence = None
+        offset += len(line) + 1
+    return blocks
+
+
+def _add_heading_paths(
+    text: str, language: str, chunks: List[Dict[str, Any]]
+) -> None:
+    """
+    Give every chunk the breadcrumb of the section it is in.
+
+    Regions are chunked apart from the headings above them, so the path is
+    taken from the whole document: the path in effect at the chunk's first
+    line that is not a heading or blank.
+    """
+    lines = text.split("\n")
+    headings = {
+        line: (line_count, level, title)
+        for line, line_count, level, title in find_headings(lines, language)
+    }
+    paths: List[List[str]] = []
+    heading_lines = set()
+    stack: List[Tuple[int, str]] = []
+    for i in range(len(lines)):
+        if i in headings:
+            line_count, level, title = headings[i]
+            while stack and stack[-1][0] >= level:
+                stack.pop()
+            stack.append((level, title))
+            heading_lines.update(range(i, i + line_count))
+        paths.append([title for _, title in stack])
+
+    for chunk in chunks:
+        first = chunk["line_start"] - 1
+        last = min(chunk["line_end"], len(lines))
+        body = next(
+            (
+                i
+                for i in range(first, last)
+                if i not in heading_lines and lines[i].strip()
+            ),
+            first,
+        )
+        chunk[HEADING_PATH_KEY] = list(paths[min(body, len(paths) - 1)])
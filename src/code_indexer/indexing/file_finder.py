@@ -7,6 +7,8 @@ import pathspec
 
 from ..config import Config
 from ..services.override_filter_service import OverrideFilterService
+from ..services.lifecycle_hooks import LifecycleHooks
+from .language_detection import detect_file_language
 
 
 class FileFinder:
@@ -16,6 +18,11 @@ class FileFinder:
         self.config = config
         self._create_gitignore_spec()
 
+        # pre_index hooks may veto files that pass every other filter
+        self.lifecycle_hooks = LifecycleHooks.from_config(
+            config, points=("pre_index",)
+        )
+
         # Initialize override filter service if override config is available
         self.override_filter_service = None
         self._force_include_spec = None
@@ -196,10 +203,14 @@ class FileFinder:
             # Apply override filtering if available
             if self.override_filter_service:
                 relative_path = file_path.relative_to(self.config.codebase_dir)
-                return self.override_filter_service.should_include_file(
+                base_result = self.override_filter_service.should_include_file(
                     relative_path, base_result
                 )
 
+            if base_result and self.lifecycle_hooks is not None:
+                relative_path = file_path.relative_to(self.config.codebase_dir)
+                return self.lifecycle_hooks.allows_file(relative_path.as_posix())
+
             return base_result
 
         except (OSError, ValueError):
@@ -208,10 +219,12 @@ class FileFinder:
     def _get_base_filtering_result(self, file_path: Path) -> bool:
         """Get base filtering result from config and gitignore rules."""
         try:
-            # Check if file extension is in allowed list
+            # Check if file extension is in allowed list. Files without one
+            # (Makefile, scripts) are checked by their detected language.
             extension = file_path.suffix.lstrip(".")
             if extension not in self.config.file_extensions:
-                return False
+                if detect_file_language(file_path) not in self.config.file_extensions:
+                    return False
 
             # Check against exclude patterns
             relative_path = file_path.relative_to(self.config.codebase_dir)
@@ -3,20 +3,338 @@
 This module implements model-aware fixed-size chunking algorithm:
 - Dynamic chunk size: optimized per embedding model (voyage-code-3: 4096, nomic-embed-text: 2048)
 - Fixed overlap: 15% of chunk size between adjacent chunks
-- Pure arithmetic: no parsing, no regex, no string analysis
 - Pattern: next_start = current_start + (chunk_size - overlap_size)
+
+Files of a language listed in CHUNKER_ROUTES are split at their sections,
+declarations or blocks by that language's structure-aware chunker instead,
+when its indexing flag is on; the fixed-size windows are the fallback. A
+chunker's module is imported when the first file of its language is chunked.
+
+Broken code does not push a whole file to the fixed-size windows. A unit
+that never ends (an unclosed brace or block, marked with DEGRADED_KEY by its
+chunker) is line-windowed from its first line to the next line starting at
+the left margin, and the code from there on is chunked by declaration again.
+A chunker that fails, or finds no chunk in a file with code, leaves the rest
+of the file to windows. These windows record "is_degraded": true.
+
+Languages listed under indexing.language_chunking get their own chunk size
+(from a token budget), overlap and minimum chunk length.
+
+Getters, setters and one-line wrappers make tiny chunks in the languages
+chunked by declaration (Dart, Objective-C, Julia, R, Rust, Zig, Elixir,
+Erlang, Scala). When indexing.micro_chunk_chars is set, adjacent methods
+and functions of the same class (or at top level) shorter than it are
+merged into one chunk whose "symbols" field lists their names.
 """
 
-from typing import List, Dict, Any, Optional, Union
+import copy
+import logging
+import re
+from dataclasses import dataclass
+from typing import Callable, Iterable, List, Dict, Any, Optional, Tuple, Union
 from pathlib import Path
 
-from ..config import IndexingConfig, Config
+from ..config import IndexingConfig, Config, LanguageChunkingConfig
+from .boundary_chunking import (
+    DEGRADED_KEY,
+    EMBEDDED_LANGUAGE_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    line_offsets,
+    split_segment,
+)
+from .language_detection import detect_language
+
+logger = logging.getLogger(__name__)
+
+# Characters per token the model-aware chunk sizes assume (4096 ≈ 1024 tokens)
+CHARS_PER_TOKEN = 4
+
+# Chunk and payload key listing the declarations merged into one chunk
+SYMBOLS_KEY = "symbols"
+
+# Declaration kinds merged when shorter than micro_chunk_chars
+MICRO_CHUNK_KINDS = {"method", "function"}
+
+# Class of an Objective-C method name: -[UserStore save:], +[Store(Sync) shared]
+_OBJC_METHOD_CLASS = re.compile(r"^[-+]\[(\S+) ")
+
+# A line at the left margin that can start a unit (not a closing line); the
+# chunking of broken code resumes at one
+_MARGIN_CODE = re.compile(r"(?!end\b)[^\s})\]]")
+
+# Chunk fields that are not part of a unit's payload
+_CHUNK_TEXT_FIELDS = ("text", "line_start", "line_end")
+
+
+def _estimate_tokens(text: str) -> int:
+    return len(text) // CHARS_PER_TOKEN
+
+
+def _model_token_counter(config: Union[IndexingConfig, Config]) -> Callable[[str], int]:
+    """
+    Token counter of the configured VoyageAI model, falling back to an
+    estimate when its tokenizer cannot be loaded.
+    """
+    if not isinstance(config, Config) or config.embedding_provider != "voyage-ai":
+        return _estimate_tokens
+    model = config.voyage_ai.model
+    tokenizer_failed = False
+
+    def count_tokens(text: str) -> int:
+        nonlocal tokenizer_failed
+        if not tokenizer_failed:
+            try:
+                from ..services.embedded_voyage_tokenizer import VoyageTokenizer
+
+                return int(VoyageTokenizer.count_tokens([text], model=model))
+            except Exception:
+                # Offline, or the tokenizers package is not installed
+                tokenizer_failed = True
+        return _estimate_tokens(text)
+
+    return count_tokens
+
+
+# Structure-aware chunker of a file: (text, file path, language, chunk size,
+# overlap size) -> chunks without chunk_index, total_chunks and file fields,
+# or None to leave the file to the next chunker of its language
+ChunkFunction = Callable[[str, Path, str, int, int], Optional[List[Dict[str, Any]]]]
+
+
+@dataclass(frozen=True)
+class ChunkerRoute:
+    """A structure-aware chunker and the indexing flag enabling it."""
+
+    flag: str
+    chunk: ChunkFunction
+    # Tiny adjacent methods and functions are merged (micro_chunk_chars)
+    merge_micro_chunks: bool = False
+
+
+def _chunk_document(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .document_chunker import chunk_document
+
+    return chunk_document(text, language, chunk_size, overlap_size)
+
+
+def _chunk_proto(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .proto_chunker import chunk_proto
+
+    return chunk_proto(text, chunk_size, overlap_size)
+
+
+def _chunk_openapi(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .openapi_chunker import chunk_openapi, is_openapi
+
+    if not is_openapi(language, text):
+        return None
+    # Specs that do not parse are chunked like any other file
+    return chunk_openapi(text, chunk_size, overlap_size) or None
+
+
+def _chunk_dockerfile(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .container_chunker import chunk_dockerfile
+
+    return chunk_dockerfile(text, chunk_size, overlap_size)
+
+
+def _chunk_compose(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .container_chunker import chunk_compose, is_compose_file
+
+    if not is_compose_file(language, file_path.name):
+        return None
+    return chunk_compose(text, chunk_size, overlap_size) or None
+
+
+def _chunk_kubernetes(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .kubernetes_chunker import chunk_kubernetes, is_kubernetes_manifest
+
+    if not is_kubernetes_manifest(language, text):
+        return None
+    return chunk_kubernetes(text, chunk_size, overlap_size)
+
+
+def _chunk_component(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .component_chunker import chunk_component
+
+    return chunk_component(text, file_path, language, chunk_size, overlap_size)
+
+
+def _chunk_shell(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .shell_chunker import chunk_shell
+
+    return chunk_shell(text, chunk_size, overlap_size)
+
+
+def _chunk_build_file(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .build_file_chunker import build_system, chunk_build_file
+
+    # Only pom.xml of the XML files, and only Gradle scripts of the .kts ones
+    system = build_system(language, file_path.name)
+    if system is None:
+        return None
+    return chunk_build_file(text, system, chunk_size, overlap_size)
+
+
+def _chunk_dart(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .dart_chunker import chunk_dart
+
+    return chunk_dart(text, chunk_size, overlap_size)
+
+
+def _chunk_objc(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .objc_chunker import chunk_objc
+
+    return chunk_objc(text, chunk_size, overlap_size)
+
+
+def _chunk_julia(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .julia_chunker import chunk_julia
+
+    return chunk_julia(text, chunk_size, overlap_size)
+
+
+def _chunk_r(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .r_chunker import chunk_r
+
+    return chunk_r(text, chunk_size, overlap_size)
+
+
+def _chunk_cobol(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .cobol_chunker import chunk_cobol
+
+    return chunk_cobol(text, chunk_size, overlap_size)
+
+
+def _chunk_hdl(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .hdl_chunker import chunk_hdl
+
+    return chunk_hdl(text, language, chunk_size, overlap_size)
+
+
+def _chunk_rust(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .rust_chunker import chunk_rust
+
+    return chunk_rust(text, chunk_size, overlap_size)
+
+
+def _chunk_zig(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .zig_chunker import chunk_zig
+
+    return chunk_zig(text, chunk_size, overlap_size)
+
+
+def _chunk_beam(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .beam_chunker import chunk_beam
+
+    return chunk_beam(text, language, chunk_size, overlap_size)
+
+
+def _chunk_scala(
+    text: str, file_path: Path, language: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    from .scala_chunker import chunk_scala
+
+    return chunk_scala(text, chunk_size, overlap_size)
+
+
+def _is_embedded_host(language: str, file_name: str) -> bool:
+    from .embedded_chunker import is_embedded_host
+
+    return is_embedded_host(language, file_name)
+
+
+def _route_table(
+    *routes: Tuple[Iterable[str], ChunkerRoute]
+) -> Dict[str, Tuple[ChunkerRoute, ...]]:
+    """Language token -> routes of the languages, in the given order."""
+    table: Dict[str, Tuple[ChunkerRoute, ...]] = {}
+    for languages, route in routes:
+        for language in languages:
+            table[language] = table.get(language, ()) + (route,)
+    return table
+
+
+# Language token -> structure-aware chunkers tried in order; the first enabled
+# one that takes the file chunks it
+CHUNKER_ROUTES = _route_table(
+    (
+        ("md", "markdown", "mdx", "mkd", "rst", "rest", "adoc", "asciidoc"),
+        ChunkerRoute("document_chunking", _chunk_document),
+    ),
+    (("proto",), ChunkerRoute("proto_chunking", _chunk_proto)),
+    (("yaml", "yml", "json"), ChunkerRoute("openapi_chunking", _chunk_openapi)),
+    (("dockerfile",), ChunkerRoute("container_chunking", _chunk_dockerfile)),
+    (("yaml", "yml"), ChunkerRoute("container_chunking", _chunk_compose)),
+    (("yaml", "yml"), ChunkerRoute("kubernetes_chunking", _chunk_kubernetes)),
+    (("vue", "svelte"), ChunkerRoute("component_chunking", _chunk_component)),
+    (("sh", "bash", "zsh", "ksh"), ChunkerRoute("shell_chunking", _chunk_shell)),
+    (
+        ("gradle", "kts", "xml", "cmake"),
+        ChunkerRoute("build_file_chunking", _chunk_build_file),
+    ),
+    (("dart",), ChunkerRoute("dart_chunking", _chunk_dart, True)),
+    (("m", "mm"), ChunkerRoute("objc_chunking", _chunk_objc, True)),
+    (("jl",), ChunkerRoute("julia_chunking", _chunk_julia, True)),
+    (("r",), ChunkerRoute("r_chunking", _chunk_r, True)),
+    (("cbl", "cob", "cobol", "cpy"), ChunkerRoute("cobol_chunking", _chunk_cobol)),
+    (
+        ("v", "vh", "sv", "svh", "vhd", "vhdl"),
+        ChunkerRoute("hdl_chunking", _chunk_hdl),
+    ),
+    (("rs",), ChunkerRoute("rust_chunking", _chunk_rust, True)),
+    (("zig",), ChunkerRoute("zig_chunking", _chunk_zig, True)),
+    (("ex", "exs", "erl", "hrl"), ChunkerRoute("beam_chunking", _chunk_beam, True)),
+    (("scala", "sc"), ChunkerRoute("scala_chunking", _chunk_scala, True)),
+)
+
+# Indexing flags of the structure-aware chunkers
+CHUNKER_FLAGS = sorted(
+    {route.flag for routes in CHUNKER_ROUTES.values() for route in routes}
+)
 
 
 class FixedSizeChunker:
     """Model-aware fixed-size chunker optimized for different embedding providers.
 
-    Algorithm:
+    Algorithm (files without a structure-aware chunker, see CHUNKER_ROUTES):
     1. Determine optimal chunk size based on embedding model
     2. 15% overlap between adjacent chunks (consistent across models)
     3. Simple math: next_start = current_start + step_size
@@ -43,11 +361,20 @@ class FixedSizeChunker:
     # Fixed overlap percentage (15% of chunk size)
     OVERLAP_PERCENTAGE = 0.15
 
-    def __init__(self, config: Union[IndexingConfig, Config]):
+    def __init__(
+        self,
+        config: Union[IndexingConfig, Config],
+        chunk_size: Optional[int] = None,
+        token_counter: Optional[Callable[[str], int]] = None,
+    ):
         """Initialize the model-aware fixed-size chunker.
 
         Args:
             config: Indexing configuration or full Config with embedding provider info
+            chunk_size: Override the model-aware chunk size (golden fixtures)
+            token_counter: Counts the tokens of a text for chunk_max_tokens
+                budgets (default: the embedding model's tokenizer, or an
+                estimate of CHARS_PER_TOKEN characters per token)
         """
         self.config = config
 
@@ -68,10 +395,94 @@ class FixedSizeChunker:
             # IndexingConfig only - use default chunk size
             self.chunk_size = self.MODEL_CHUNK_SIZES["default"]
 
+        if chunk_size is not None:
+            self.chunk_size = chunk_size
+
+        # Markdown, reStructuredText and AsciiDoc files are split at headings
+        indexing = config.indexing if isinstance(config, Config) else config
+        self.document_chunking = indexing.document_chunking
+        # Protocol Buffers files are split at messages, enums and rpcs
+        self.proto_chunking = indexing.proto_chunking
+        # OpenAPI and Swagger specs are split at operations and schemas
+        self.openapi_chunking = indexing.openapi_chunking
+        # Dockerfiles are split at build stages, Compose files at services
+        self.container_chunking = indexing.container_chunking
+        # Kubernetes manifests and Helm templates are split at resources
+        self.kubernetes_chunking = indexing.kubernetes_chunking
+        # Vue and Svelte components are split at template, script and style
+        self.component_chunking = indexing.component_chunking
+        # Shell scripts are split at functions and case blocks
+        self.shell_chunking = indexing.shell_chunking
+        # Gradle, Maven and CMake files are split at blocks, tasks and targets
+        self.build_file_chunking = indexing.build_file_chunking
+        # Dart files are split at declarations and Flutter build methods
+        self.dart_chunking = indexing.dart_chunking
+        # Objective-C files are split at classes, categories, methods and
+        # functions
+        self.objc_chunking = indexing.objc_chunking
+        # Julia and R files are split at functions, types and classes
+        self.julia_chunking = indexing.julia_chunking
+        self.r_chunking = indexing.r_chunking
+        # COBOL programs are split at divisions, sections and paragraphs
+        self.cobol_chunking = indexing.cobol_chunking
+        # Verilog and VHDL files are split at modules, entities, architectures
+        # and their always blocks and processes
+        self.hdl_chunking = indexing.hdl_chunking
+        # Rust files are split at items, impl blocks and inline modules
+        self.rust_chunking = indexing.rust_chunking
+        # Zig files are split at functions, containers, tests and comptime
+        # blocks
+        self.zig_chunking = indexing.zig_chunking
+        # Elixir and Erlang files are split at modules and functions
+        self.beam_chunking = indexing.beam_chunking
+        # Scala files are split at classes, objects, methods, givens and
+        # extensions
+        self.scala_chunking = indexing.scala_chunking
+        # Scripts and styles of HTML, template code and Markdown code blocks
+        # are chunked as their own language
+        self.embedded_chunking = indexing.embedded_chunking
+        # Tiny adjacent methods and functions are merged into one chunk
+        self.micro_chunk_chars = indexing.micro_chunk_chars
+
         # Calculate derived values
         self.overlap_size = int(self.chunk_size * self.OVERLAP_PERCENTAGE)
         self.step_size = self.chunk_size - self.overlap_size
 
+        # Token budget and minimum chunk length (set on language chunkers)
+        self.chunk_max_tokens: Optional[int] = None
+        self.min_chunk_chars = 0
+        self._count_tokens = token_counter or _model_token_counter(config)
+        # Language token -> chunker with that language's settings
+        self._language_chunkers: Dict[str, "FixedSizeChunker"] = {}
+        language_chunkers = {
+            language: self._language_chunker(settings)
+            for language, settings in indexing.language_chunking.items()
+        }
+        for language, chunker in language_chunkers.items():
+            # Code embedded in a file of the language uses the settings of its
+            # own language
+            chunker._language_chunkers = {
+                other: other_chunker
+                for other, other_chunker in language_chunkers.items()
+                if other != language
+            }
+        self._language_chunkers = language_chunkers
+
+    def _language_chunker(self, settings: LanguageChunkingConfig) -> "FixedSizeChunker":
+        """Copy of this chunker with the chunk settings of one language."""
+        chunker = copy.copy(self)
+        if settings.chunk_max_tokens is not None:
+            chunker.chunk_size = settings.chunk_max_tokens * CHARS_PER_TOKEN
+            chunker.chunk_max_tokens = settings.chunk_max_tokens
+        if settings.chunk_overlap is not None:
+            chunker.overlap_size = settings.chunk_overlap
+        else:
+            chunker.overlap_size = int(chunker.chunk_size * self.OVERLAP_PERCENTAGE)
+        chunker.overlap_size = min(chunker.overlap_size, chunker.chunk_size - 1)
+        chunker.step_size = chunker.chunk_size - chunker.overlap_size
+        chunker.min_chunk_chars = settings.min_chunk_chars
+        return chunker
+
     def _calculate_line_numbers(
         self, text: str, start_pos: int, end_pos: int
     ) -> tuple[int, int]:
@@ -112,10 +523,10 @@ class FixedSizeChunker:
         if not text or not text.strip():
             return []
 
-        # Determine file extension
+        # Language token recorded as file_extension (Makefile, scripts, .h)
         file_extension = ""
         if file_path:
-            file_extension = file_path.suffix.lstrip(".")
+            file_extension = detect_language(file_path, text)
 
         chunks = []
         current_start = 0
@@ -125,6 +536,9 @@ class FixedSizeChunker:
         while current_start < len(text):
             # Calculate chunk boundaries
             chunk_end = current_start + self.chunk_size
+            # A tail shorter than min_chunk_chars joins this chunk
+            if len(text) - chunk_end < self.min_chunk_chars:
+                chunk_end = len(text)
 
             # Extract chunk text
             if chunk_end >= len(text):
@@ -202,8 +616,297 @@ class FixedSizeChunker:
         if text is None:
             raise ValueError(f"Could not decode file {file_path}")
 
+        if (
+            any(getattr(self, flag) for flag in CHUNKER_FLAGS)
+            or self.embedded_chunking
+            or self._language_chunkers
+        ):
+            language = detect_language(file_path, text)
+            if self.embedded_chunking and _is_embedded_host(language, file_path.name):
+                chunker = self._language_chunkers.get(language.lower(), self)
+                return chunker._chunk_embedded(text, file_path, language)
+            return self._chunk_language(text, file_path, language)
+        return self.chunk_text(text, file_path)
+
+    def _chunk_language(
+        self, text: str, file_path: Path, language: str
+    ) -> List[Dict[str, Any]]:
+        """Chunk text with the structure-aware chunker of its language."""
+        language_chunker = self._language_chunkers.get(language.lower())
+        if language_chunker is not None:
+            chunks = language_chunker._chunk_language(text, file_path, language)
+            chunks = language_chunker._apply_chunk_settings(chunks)
+            return self._number_chunks(chunks, file_path, language)
+        if not text.strip():
+            return []
+        for route in CHUNKER_ROUTES.get(language.lower(), ()):
+            if not getattr(self, route.flag):
+                continue
+            chunks = self._route_chunks(route, text, file_path, language)
+            if chunks is None:
+                continue
+            if route.merge_micro_chunks:
+                chunks = self._merge_micro_chunks(chunks)
+            return self._number_chunks(chunks, file_path, language)
         return self.chunk_text(text, file_path)
 
+    def _route_chunks(
+        self, route: ChunkerRoute, text: str, file_path: Path, language: str
+    ) -> Optional[List[Dict[str, Any]]]:
+        """
+        Chunks of a file by one structure-aware chunker, recovering the
+        units around broken code.
+
+        Returns:
+            Chunks without chunk_index, total_chunks and file fields, or None
+            when the chunker leaves the file to the next one
+        """
+        recovered: List[Dict[str, Any]] = []
+        # Lines of the file before the text still to chunk
+        shift = 0
+        while True:
+            try:
+                chunks = route.chunk(
+                    text, file_path, language, self.chunk_size, self.overlap_size
+                )
+            except Exception as e:
+                logger.warning(f"{route.flag} failed on {file_path}: {e}")
+                chunks = []
+            if chunks is None and shift == 0:
+                return None
+            if not chunks:
+                # Nothing recognized: the rest of the file is windowed
+                return recovered + self._degraded_windows(text, shift, {})
+            broken = next(
+                (n for n, chunk in enumerate(chunks) if chunk.get(DEGRADED_KEY)),
+                None,
+            )
+            recovered.extend(
+                self._shift_lines(chunks if broken is None else chunks[:broken], shift)
+            )
+            if broken is None:
+                return recovered
+            # The broken unit runs to the next line at the left margin
+            lines = text.split("\n")
+            first = chunks[broken]["line_start"] - 1
+            resume = next(
+                (
+                    i
+                    for i in range(first + 1, len(lines))
+                    if _MARGIN_CODE.match(lines[i])
+                ),
+                len(lines),
+            )
+            offsets = line_offsets(text) + [len(text)]
+            payload = {
+                key: value
+                for key, value in chunks[broken].items()
+                if key not in _CHUNK_TEXT_FIELDS
+            }
+            recovered.extend(
+                self._degraded_windows(
+                    text[offsets[first] : offsets[resume]], shift + first, payload
+                )
+            )
+            if resume == len(lines):
+                return recovered
+            text = text[offsets[resume] :]
+            shift += resume
+
+    def _degraded_windows(
+        self, text: str, shift: int, payload: Dict[str, Any]
+    ) -> List[Dict[str, Any]]:
+        """Windows of broken code starting after line shift, with payload."""
+        if not text.strip():
+            return []
+        return split_segment(
+            text,
+            shift + 1,
+            {**payload, DEGRADED_KEY: True},
+            self.chunk_size,
+            self.overlap_size,
+        )
+
+    @staticmethod
+    def _shift_lines(
+        chunks: List[Dict[str, Any]], shift: int
+    ) -> List[Dict[str, Any]]:
+        """Chunks of a text starting after line shift, numbered in the file."""
+        for chunk in chunks:
+            chunk["line_start"] += shift
+            chunk["line_end"] += shift
+        return chunks
+
+    def _chunk_embedded(
+        self, text: str, file_path: Path, language: str
+    ) -> List[Dict[str, Any]]:
+        """Chunk a page, template or document with embedded code by region."""
+        from .document_chunker import chunk_document, is_document
+        from .embedded_chunker import chunk_embedded
+
+        if not text.strip():
+            return []
+
+        def chunk_region(region: str, embedded: Optional[str]) -> List[Dict[str, Any]]:
+            if embedded is not None:
+                return self._chunk_language(region, file_path, embedded)
+            if self.document_chunking and is_document(language):
+                chunks = chunk_document(
+                    region, language, self.chunk_size, self.overlap_size
+                )
+            else:
+                chunks = self.chunk_text(region, file_path)
+            return self._apply_chunk_settings(chunks)
+
+        chunks = chunk_embedded(
+            text, language, file_path.name, chunk_region, self.document_chunking
+        )
+        return self._number_chunks(chunks, file_path, language)
+
+    def _apply_chunk_settings(
+        self, chunks: List[Dict[str, Any]]
+    ) -> List[Dict[str, Any]]:
+        """Fit chunks to the token budget and merge chunks that are too short."""
+        if self.chunk_max_tokens is not None:
+            chunks = [window for chunk in chunks for window in self._fit_chunk(chunk)]
+        if self.min_chunk_chars:
+            chunks = self._merge_short_chunks(chunks)
+        return chunks
+
+    def _fits(self, text: str) -> bool:
+        return (
+            self.chunk_max_tokens is None
+            or self._count_tokens(text) <= self.chunk_max_tokens
+        )
+
+    def _fit_chunk(self, chunk: Dict[str, Any]) -> List[Dict[str, Any]]:
+        """
+        A chunk, or overlapping windows of it when it has more tokens than
+        chunk_max_tokens (dense code has fewer characters per token than the
+        character-sized chunks assume).
+        """
+        assert self.chunk_max_tokens is not None
+        text = chunk["text"]
+        tokens = self._count_tokens(text)
+        if tokens <= self.chunk_max_tokens or len(text) < 2:
+            return [chunk]
+        size = min(max(len(text) * self.chunk_max_tokens // tokens, 1), len(text) - 1)
+        step = size - min(self.overlap_size, size // 2)
+        windows: List[Dict[str, Any]] = []
+        start = 0
+        while True:
+            window = text[start : start + size]
+            line_start = chunk["line_start"] + text[:start].count("\n")
+            windows.extend(
+                self._fit_chunk(
+                    {
+                        **chunk,
+                        "text": window,
+                        "line_start": line_start,
+                        "line_end": line_start + window.rstrip("\n").count("\n"),
+                    }
+                )
+            )
+            if start + size >= len(text):
+                return windows
+            start += step
+
+    def _merge_short_chunks(
+        self, chunks: List[Dict[str, Any]]
+    ) -> List[Dict[str, Any]]:
+        """
+        Merge chunks shorter than min_chunk_chars into the chunk before them.
+
+        Only a chunk starting on a line after the end of the one before it is
+        merged, so overlapping windows never repeat text, and only while the
+        merged chunk stays within the token budget.
+        """
+        merged: List[Dict[str, Any]] = []
+        for chunk in chunks:
+            previous = merged[-1] if merged else None
+            if (
+                previous is not None
+                and len(chunk["text"]) < self.min_chunk_chars
+                and chunk["line_start"] > previous["line_end"]
+                and chunk.get(EMBEDDED_LANGUAGE_KEY)
+                == previous.get(EMBEDDED_LANGUAGE_KEY)
+                and self._fits(previous["text"] + chunk["text"])
+            ):
+                merged[-1] = {
+                    **previous,
+                    "text": previous["text"] + chunk["text"],
+                    "line_end": chunk["line_end"],
+                }
+            else:
+                merged.append(chunk)
+        return merged
+
+    def _merge_micro_chunks(
+        self, chunks: List[Dict[str, Any]]
+    ) -> List[Dict[str, Any]]:
+        """
+        Merge runs of adjacent methods and functions shorter than
+        micro_chunk_chars that belong to the same class into one chunk.
+
+        The merged chunk keeps the kind and name of its first declaration and
+        lists the names of all of them under SYMBOLS_KEY. A run ends before
+        a chunk that would make it longer than the chunk size.
+        """
+        if not self.micro_chunk_chars:
+            return chunks
+        merged: List[Dict[str, Any]] = []
+        previous_parent: Optional[str] = None
+        for chunk in chunks:
+            parent = self._micro_chunk_parent(chunk)
+            previous = merged[-1] if merged else None
+            if (
+                previous is not None
+                and parent is not None
+                and parent == previous_parent
+                and chunk["line_start"] > previous["line_end"]
+                and len(previous["text"]) + len(chunk["text"]) <= self.chunk_size
+                and self._fits(previous["text"] + chunk["text"])
+            ):
+                symbols = previous.get(SYMBOLS_KEY) or [previous[SYMBOL_NAME_KEY]]
+                merged[-1] = {
+                    **previous,
+                    "text": previous["text"] + chunk["text"],
+                    "line_end": chunk["line_end"],
+                    SYMBOLS_KEY: symbols + [chunk[SYMBOL_NAME_KEY]],
+                }
+            else:
+                merged.append(chunk)
+            previous_parent = parent
+        return merged
+
+    def _micro_chunk_parent(self, chunk: Dict[str, Any]) -> Optional[str]:
+        """Class of a method or function chunk short enough to merge, or None."""
+        if (
+            chunk.get(SYMBOL_KIND_KEY) not in MICRO_CHUNK_KINDS
+            or not chunk.get(SYMBOL_NAME_KEY)
+            or chunk.get(DEGRADED_KEY)
+            or len(chunk["text"]) >= self.micro_chunk_chars
+        ):
+            return None
+        match = _OBJC_METHOD_CLASS.match(chunk[SYMBOL_NAME_KEY])
+        return match.group(1) if match else ""
+
+    def _number_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path, language: str
+    ) -> List[Dict[str, Any]]:
+        """Add the index, count and file fields to the chunks of a file."""
+        for chunk_index, chunk in enumerate(chunks):
+            chunk.update(
+                {
+                    "chunk_index": chunk_index,
+                    "total_chunks": len(chunks),
+                    "size": len(chunk["text"]),
+                    "file_path": str(file_path),
+                    "file_extension": chunk.get(EMBEDDED_LANGUAGE_KEY, language),
+                }
+            )
+        return chunks
+
     def estimate_chunks(self, text: str) -> int:
         """Estimate number of chunks for given text using fixed-size algorithm.
 
@@ -0,0 +1,414 @@
+"""Structure-aware chunking of Verilog, SystemVerilog and VHDL sources.
+
+Verilog and SystemVerilog files are split at their modules (and
+interfaces, packages and programs), and each module at its always, initial
+and final blocks, functions and tasks. VHDL files are split at their design
+units (entities, architectures, packages, package bodies and
+configurations), and each architecture at its processes; the library and
+use clauses above a unit belong to it. Declarations, assignments and
+instances between blocks stay in a chunk of their module or architecture,
+and comments directly above a block belong to it. Each chunk records:
+
+- "symbol_kind": module, interface, package, program, always, initial,
+  final, function or task in Verilog; entity, architecture, package,
+  package_body, configuration or process in VHDL; file for anything else
+- "symbol_name": the unit or block name; labels name always blocks
+  ("begin : sync_reset") and processes ("sync: process (clk)")
+- "hdl_unit": the module or entity a chunk belongs to; an architecture
+  belongs to the entity it implements
+- "hdl_ports": the ports of a module or entity, as declared, e.g.
+  "input [7:0] data" or "data : in std_logic_vector(7 downto 0)"
+
+Strings and comments are skipped when matching blocks. Units and blocks
+longer than the chunk size are split into overlapping windows like
+FixedSizeChunker does, and every window keeps these fields.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import (
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    chunk_segments,
+    keep_segments,
+)
+from .chunk_payload_keys import HDL_PORTS_KEY, HDL_UNIT_KEY
+
+VERILOG_LANGUAGES = {"v", "vh", "sv", "svh"}
+VHDL_LANGUAGES = {"vhd", "vhdl"}
+
+_V_UNIT = re.compile(
+    r"^\s*(?:extern\s+)?(module|macromodule|interface|package|program)\s+"
+    r"(?:(?:automatic|static)\s+)?(\w+)"
+)
+_V_UNIT_END = re.compile(r"^\s*end(?:module|interface|package|program)\b")
+_V_BLOCK = re.compile(
+    r"^\s*(always(?:_ff|_comb|_latch)?|initial|final"
+    r"|(?:(?:virtual|static|protected|local)\s+)*(?:function|task))\b"
+)
+_V_SUBROUTINE_NAME = re.compile(
+    r"\b(?:function|task)\s+(?:(?:automatic|static)\s+)?"
+    r"(?:[\w\[\]:$\s]*?\s)?(\w+)\s*[(;]"
+)
+_V_LABEL = re.compile(r"\bbegin\s*:\s*(\w+)")
+_V_TOKEN = re.compile(
+    r"\b(?:begin|fork|case[xz]?|randcase|end|join(?:_any|_none)?|endcase"
+    r"|endfunction|endtask)\b|;"
+)
+_V_PORT_DIRECTION = re.compile(r"^\s*(input|output|inout|ref)\b")
+# Compiler directives and package imports above a module belong to it
+_V_CONTEXT = re.compile(r"^\s*(?:`\w+|import\s+\w+\s*::)")
+
+_VHDL_UNIT = re.compile(
+    r"^\s*(?:(entity)\s+(\w+)\s+is\b"
+    r"|(architecture)\s+(\w+)\s+of\s+(\w+)\s+is\b"
+    r"|(package\s+body)\s+(\w+)\s+is\b"
+    r"|(package)\s+(\w+)\s+is\b(?!\s+new\b)"
+    r"|(configuration)\s+(\w+)\s+of\s+(\w+)\s+is\b)",
+    re.IGNORECASE,
+)
+_VHDL_CONTEXT = re.compile(r"^\s*(?:library|use|context)\s+\w", re.IGNORECASE)
+_VHDL_PROCESS = re.compile(
+    r"^\s*(?:(\w+)\s*:\s*)?(?:postponed\s+)?process\b", re.IGNORECASE
+)
+_VHDL_PROCESS_END = re.compile(r"\bend\s+(?:postponed\s+)?process\b", re.IGNORECASE)
+_VHDL_PORT = re.compile(r"\bport\s*\(", re.IGNORECASE)
+# "end", "endmodule", "end architecture rtl;" and the like
+_END_LINE = re.compile(r"^\s*end\w*\b[^;]*;?\s*$", re.IGNORECASE)
+
+
+def is_hdl(language: str) -> bool:
+    """Whether a language token is chunked by hardware description units."""
+    return language.lower() in VERILOG_LANGUAGES | VHDL_LANGUAGES
+
+
+def _blank_code(lines: List[str], line_comment: str, block_comments: bool) -> List[str]:
+    """Lines with comments and string contents blanked."""
+    code_lines = []
+    in_comment = False
+    for line in lines:
+        code = []
+        i = 0
+        while i < len(line):
+            if in_comment:
+                end = line.find("*/", i)
+                if end < 0:
+                    break
+                in_comment = False
+                i = end + 2
+                continue
+            if line.startswith(line_comment, i):
+                break
+            if block_comments and line.startswith("/*", i):
+                in_comment = True
+                i += 2
+                continue
+            if line[i] == '"':
+                end = i + 1
+                while end < len(line) and line[end] != '"':
+                    end += 2 if line[end] == "\\" else 1
+                code.append(" ")
+                i = end + 1
+                continue
+            code.append(line[i])
+            i += 1
+        code_lines.append("".join(code))
+    return code_lines
+
+
+def _closing(text: str, open_at: int) -> int:
+    """Index of the parenthesis closing the one at open_at, or len(text)."""
+    depth = 0
+    for i in range(open_at, len(text)):
+        if text[i] == "(":
+            depth += 1
+        elif text[i] == ")":
+            depth -= 1
+            if not depth:
+                return i
+    return len(text)
+
+
+def _statement(text: str) -> Optional[str]:
+    """Text up to the first ";" outside parentheses, or None without one."""
+    depth = 0
+    for i, char in enumerate(text):
+        if char == "(":
+            depth += 1
+        elif char == ")":
+            depth -= 1
+        elif char == ";" and depth <= 0:
+            return text[:i]
+    return None
+
+
+def _split_top_level(text: str, separator: str) -> List[str]:
+    """Parts of text split at separators outside parentheses and brackets."""
+    parts = []
+    depth = 0
+    current: List[str] = []
+    for char in text:
+        if char in "([{":
+            depth += 1
+        elif char in ")]}":
+            depth -= 1
+        if char == separator and depth == 0:
+            parts.append("".join(current))
+            current = []
+        else:
+            current.append(char)
+    parts.append("".join(current))
+    return [" ".join(part.split()) for part in parts if part.strip()]
+
+
+def _direction_ports(declarations: str) -> List[str]:
+    """
+    Ports of comma-separated Verilog port declarations.
+
+    A name without a direction shares the declaration before it, so
+    "input [7:0] a, b" gives "input [7:0] a" and "input [7:0] b".
+    """
+    ports = []
+    declaration = ""
+    for part in _split_top_level(declarations, ","):
+        if _V_PORT_DIRECTION.match(part):
+            declaration = re.sub(r"\s*\w+(?:\s*\[[^\]]*\])*$", "", part)
+            ports.append(part)
+        elif declaration and re.fullmatch(r"\w+(?:\s*\[[^\]]*\])*", part):
+            ports.append(f"{declaration} {part}")
+    return ports
+
+
+def verilog_ports(header: str) -> List[str]:
+    """
+    Ports of a Verilog module header declaring them (ANSI style).
+
+    Parameters in "#(...)" are skipped. A non-ANSI header only names its
+    ports; their input and output declarations follow in the module body.
+    """
+    start = header.find("(")
+    parameters = re.search(r"#\s*\(", header)
+    if parameters:
+        start = header.find("(", _closing(header, parameters.end() - 1) + 1)
+    if start < 0:
+        return []
+    return _direction_ports(header[start + 1 : _closing(header, start)])
+
+
+def vhdl_ports(text: str) -> List[str]:
+    """Ports of a VHDL entity; "a, b : in bit" gives "a : in bit" and "b : in bit"."""
+    port = _VHDL_PORT.search(text)
+    if port is None:
+        return []
+    ports = []
+    clause = text[port.end() : _closing(text, port.end() - 1)]
+    for declaration in _split_top_level(clause, ";"):
+        names, _, port_type = declaration.partition(":")
+        for name in names.split(","):
+            if name.strip() and port_type.strip():
+                ports.append(f"{name.strip()} : {port_type.strip()}")
+    return ports
+
+
+def chunk_hdl(
+    text: str, language: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a Verilog, SystemVerilog or VHDL file into units and blocks.
+
+    Args:
+        text: File text
+        language: Language token of the file, e.g. "sv" or "vhd"
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long unit or block
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end", the symbol
+        fields and the HDL_* fields; chunk_index, total_chunks and file
+        fields are left to the caller
+    """
+    lines = text.split("\n")
+    vhdl = language.lower() in VHDL_LANGUAGES
+    code_lines = _blank_code(lines, "--" if vhdl else "//", True)
+    top_level = {SYMBOL_KIND_KEY: "file"}
+    segments = (_vhdl_segments if vhdl else _verilog_segments)(
+        lines, code_lines, top_level
+    )
+
+    # Segments holding only blank lines, comments and end lines belong to
+    # the segment before them
+    def is_filler(first: int, end: int, payload: Dict[str, Any]) -> bool:
+        return all(
+            not s.strip() or _END_LINE.match(s) for s in code_lines[first:end]
+        )
+
+    kept = keep_segments(segments, len(lines), is_filler, top_level)
+    return chunk_segments(text, kept, chunk_size, overlap_size)
+
+
+class _Segments:
+    """Segments of a file, as (first line index, payload)."""
+
+    def __init__(self, lines: List[str]):
+        self.lines = lines
+        self.segments: List[Tuple[int, Dict[str, Any]]] = []
+        self.last_code_line = -1
+
+    def start_unit(self, i: int, payload: Dict[str, Any]) -> None:
+        # Comments and context clauses since the previous unit belong to it
+        first = self.last_code_line + 1
+        while first < i and not self.lines[first].strip():
+            first += 1
+        self.segments.append((first, payload))
+
+    def start_block(self, i: int, payload: Dict[str, Any]) -> None:
+        # Comments directly above belong to it
+        first = i
+        while first - 1 > self.last_code_line and self.lines[first - 1].strip():
+            first -= 1
+        self.segments.append((first, payload))
+
+    def continue_with(self, i: int, payload: Dict[str, Any]) -> None:
+        if not self.segments or self.segments[-1][1] is not payload:
+            self.segments.append((i, payload))
+
+
+def _verilog_segments(
+    lines: List[str], code_lines: List[str], top_level: Dict[str, Any]
+) -> List[Tuple[int, Dict[str, Any]]]:
+    found = _Segments(lines)
+    module: Optional[Dict[str, Any]] = None
+    # Module header up to its ";", while it is read
+    header: Optional[List[str]] = None
+    block: Optional[Dict[str, Any]] = None
+    depth = 0
+
+    for i, code in enumerate(code_lines):
+        stripped = code.strip()
+        if block is None and stripped and header is None:
+            unit = _V_UNIT.match(code) if module is None else None
+            opener = _V_BLOCK.match(code)
+            if unit:
+                kind = unit.group(1).replace("macromodule", "module")
+                module = {
+                    SYMBOL_KIND_KEY: kind,
+                    SYMBOL_NAME_KEY: unit.group(2),
+                    HDL_UNIT_KEY: unit.group(2),
+                }
+                found.start_unit(i, module)
+                header = []
+            elif module is None:
+                if not _V_CONTEXT.match(code):
+                    found.continue_with(i, top_level)
+            elif _V_UNIT_END.match(code):
+                module = None
+                found.segments.append((i + 1, top_level))
+            elif opener:
+                kind = opener.group(1).split()[-1]
+                # always_ff, always_comb and always_latch are always blocks
+                block = {SYMBOL_KIND_KEY: kind.split("_")[0]}
+                if kind in ("function", "task"):
+                    name = _V_SUBROUTINE_NAME.search(code)
+                    if name:
+                        block[SYMBOL_NAME_KEY] = name.group(1)
+                block[HDL_UNIT_KEY] = module[HDL_UNIT_KEY]
+                found.start_block(i, block)
+                depth = 0
+            elif _V_PORT_DIRECTION.match(code):
+                # A non-ANSI port declaration
+                ports = _direction_ports(code.split(";")[0])
+                module.setdefault(HDL_PORTS_KEY, []).extend(ports)
+
+        if header is not None and module is not None:
+            header.append(code)
+            declaration = _statement(" ".join(header))
+            if declaration is not None:
+                ports = verilog_ports(declaration)
+                if ports:
+                    module[HDL_PORTS_KEY] = ports
+                header = None
+
+        if block is not None:
+            procedural = block[SYMBOL_KIND_KEY] not in ("function", "task")
+            for token in _V_TOKEN.finditer(code):
+                word = token.group()
+                if word in ("endfunction", "endtask"):
+                    depth = 0
+                elif word == "begin" and depth == 0 and procedural:
+                    label = _V_LABEL.match(code, token.start())
+                    if label and SYMBOL_NAME_KEY not in block:
+                        block[SYMBOL_NAME_KEY] = label.group(1)
+                    depth = 1
+                    continue
+                elif word in ("begin", "fork", "randcase") or word.startswith("case"):
+                    depth += 1
+                    continue
+                elif word == "end" or word.startswith("join") or word == "endcase":
+                    depth -= 1
+                    if depth > 0 or not procedural:
+                        continue
+                elif depth or not procedural:
+                    continue
+                # The block ends here; code after it continues its module
+                block = None
+                found.segments.append((i + 1, module or top_level))
+                break
+
+        if stripped and not (module is None and _V_CONTEXT.match(code)):
+            found.last_code_line = i
+    return found.segments
+
+
+def _vhdl_segments(
+    lines: List[str], code_lines: List[str], top_level: Dict[str, Any]
+) -> List[Tuple[int, Dict[str, Any]]]:
+    found = _Segments(lines)
+    unit: Optional[Dict[str, Any]] = None
+    unit_line = 0
+    process: Optional[Dict[str, Any]] = None
+
+    def finish_unit(end_line: int) -> None:
+        if unit is not None and unit[SYMBOL_KIND_KEY] == "entity":
+            ports = vhdl_ports("\n".join(code_lines[unit_line:end_line]))
+            if ports:
+                unit[HDL_PORTS_KEY] = ports
+
+    for i, code in enumerate(code_lines):
+        if not code.strip():
+            continue
+        design_unit = _VHDL_UNIT.match(code) if process is None else None
+        process_start = _VHDL_PROCESS.match(code) if process is None else None
+        if design_unit:
+            finish_unit(i)
+            unit, unit_line = _vhdl_unit(design_unit), i
+            found.start_unit(i, unit)
+        elif unit is None:
+            if not _VHDL_CONTEXT.match(code):
+                found.continue_with(i, top_level)
+        elif process_start and unit[SYMBOL_KIND_KEY] == "architecture":
+            process = {SYMBOL_KIND_KEY: "process"}
+            if process_start.group(1):
+                process[SYMBOL_NAME_KEY] = process_start.group(1)
+            process[HDL_UNIT_KEY] = unit[HDL_UNIT_KEY]
+            found.start_block(i, process)
+        if process is not None and _VHDL_PROCESS_END.search(code):
+            process = None
+            # Code after a process continues its architecture
+            found.segments.append((i + 1, unit or top_level))
+        if not _VHDL_CONTEXT.match(code):
+            found.last_code_line = i
+    finish_unit(len(code_lines))
+    return found.segments
+
+
+def _vhdl_unit(match: "re.Match[str]") -> Dict[str, Any]:
+    """Payload of a VHDL design unit from its _VHDL_UNIT match."""
+    groups = [group for group in match.groups() if group is not None]
+    kind = "_".join(groups[0].lower().split())
+    # Architectures and configurations belong to their entity
+    return {
+        SYMBOL_KIND_KEY: kind,
+        SYMBOL_NAME_KEY: groups[1],
+        HDL_UNIT_KEY: groups[2] if len(groups) > 2 else groups[1],
+    }
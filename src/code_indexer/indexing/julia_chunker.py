@@ -0,0 +1,235 @@
+"""Declaration-aware chunking of Julia source files.
+
+A .jl file is split at its functions (both the `function f(x) ... end` and
+the `f(x) = ...` forms), macros, structs, abstract and primitive types, and
+at modules. Declarations inside a module are split like top-level ones, so
+a package whose whole source sits in one `module ... end` is still chunked
+by function. Imports, exports and other code between declarations form
+"file" chunks, and comments and docstrings directly above a declaration
+belong to it. Each chunk records:
+
+- "symbol_kind": function, macro, struct (also mutable structs), abstract,
+  primitive, module or file
+- "symbol_name": the declaration name ("solve!", "Base.show", "Point")
+
+Strings, including triple-quoted docstrings, character literals and
+comments are skipped when matching blocks, and `end` inside brackets
+(`x[end]`) does not close one. Declarations longer than the chunk size are
+split into overlapping windows like FixedSizeChunker does, and every window
+keeps these fields.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import (
+    DEGRADED_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    chunk_segments,
+    keep_segments,
+)
+
+JULIA_LANGUAGES = {"jl"}
+
+# Macro calls such as @inline and Base.@kwdef before a declaration
+_PREFIX = r"^\s*(?:(?:\w+\.)*@\w+(?:\.\w+)*\s+)*"
+_DECLARATION = re.compile(
+    _PREFIX + r"(?:(function|macro)\s+([\w.!]+|\([^)]*\))"
+    r"|(?:mutable\s+)?(struct)\s+(\w+)"
+    r"|(abstract|primitive)\s+type\s+(\w+)"
+    r"|(module|baremodule)\s+(\w+))"
+)
+_SHORT_FUNCTION = re.compile(
+    _PREFIX + r"([A-Za-z_][\w.!]*)\s*(?:\{[^}]*\})?\s*\(.*\)\s*"
+    r"(?:::\s*[^=]+?)?\s*(?:where\s+.+?)?=(?![=>])"
+)
+# Keywords opening a block that an "end" closes
+_BLOCK_OPENER = re.compile(
+    r"(?<![\w.:])(?:function|macro|module|baremodule|struct|if|for|while|let"
+    r"|begin|quote|do|try|(?:abstract|primitive)\s+type)\b"
+)
+_BLOCK_END = re.compile(r"(?<![\w.:])end\b")
+_TOKEN = re.compile(r"[()\[\]{}]|" + _BLOCK_OPENER.pattern + "|" + _BLOCK_END.pattern)
+
+
+def is_julia(language: str) -> bool:
+    """Whether a language token is chunked by Julia declarations."""
+    return language.lower() in JULIA_LANGUAGES
+
+
+def _julia_code(lines: List[str]) -> Tuple[List[str], Dict[int, int]]:
+    """
+    Lines of a Julia file with comments, strings and characters blanked.
+
+    Returns:
+        The blanked lines, and the first line of each multi-line string
+        keyed by its last line
+    """
+    code_lines = []
+    string_starts: Dict[int, int] = {}
+    state: Optional[str] = None  # Open string delimiter, or "#=" comments
+    comment_depth = 0
+    opened_at = 0
+    for n, line in enumerate(lines):
+        code = []
+        i = 0
+        while i < len(line):
+            if state == "#=":
+                if line.startswith("#=", i):
+                    comment_depth += 1
+                    i += 2
+                elif line.startswith("=#", i):
+                    comment_depth -= 1
+                    i += 2
+                    if not comment_depth:
+                        state = None
+                else:
+                    i += 1
+                continue
+            if state is not None:
+                if line[i] == "\\":
+                    i += 2
+                elif line.startswith(state, i):
+                    if opened_at != n:
+                        string_starts[n] = opened_at
+                    i += len(state)
+                    state = None
+                    code.append(" ")
+                else:
+                    i += 1
+                continue
+            if line.startswith("#=", i):
+                state, comment_depth = "#=", 1
+                i += 2
+                continue
+            if line[i] == "#":
+                break
+            # A quote after a value is the adjoint operator, not a character
+            char = re.match(r"'(?:\\.[^']*|[^'\\])'", line[i:])
+            if char and not (code and (code[-1].isalnum() or code[-1] in "_)]}'")):
+                code.append(" ")
+                i += char.end()
+                continue
+            if line[i] == '"':
+                state = '"""' if line.startswith('"""', i) else '"'
+                opened_at = n
+                i += len(state)
+                continue
+            code.append(line[i])
+            i += 1
+        code_lines.append("".join(code))
+    return code_lines, string_starts
+
+
+def _declaration(code: str) -> Optional[Dict[str, Any]]:
+    """Payload of the declaration starting a line, or None."""
+    declaration = _DECLARATION.match(code)
+    if declaration:
+        groups = declaration.groups()
+        n = next(n for n in range(0, len(groups), 2) if groups[n])
+        kind = "module" if groups[n] == "baremodule" else groups[n]
+        return {SYMBOL_KIND_KEY: kind, SYMBOL_NAME_KEY: groups[n + 1]}
+    function = _SHORT_FUNCTION.match(code)
+    if function:
+        return {SYMBOL_KIND_KEY: "function", SYMBOL_NAME_KEY: function.group(1)}
+    return None
+
+
+def chunk_julia(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a Julia file into functions, types and modules.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long declaration
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the SYMBOL_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    code_lines, string_starts = _julia_code(lines)
+    top_level = {SYMBOL_KIND_KEY: "file"}
+    # (first line index, payload) of each segment
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    # Open blocks, with the payload of the modules among them
+    blocks: List[Optional[Dict[str, Any]]] = []
+    modules: List[Dict[str, Any]] = [top_level]
+    brackets = 0
+    # The open declaration and the block depth it started at
+    declaration: Optional[Dict[str, Any]] = None
+    declaration_depth = 0
+    last_code_line = -1
+
+    def start(i: int, payload: Dict[str, Any]) -> None:
+        # Comments and docstrings directly above belong to it
+        first = i
+        while first - 1 > last_code_line and lines[first - 1].strip():
+            first -= 1
+            if string_starts.get(first, -1) > last_code_line:
+                first = string_starts[first]
+        segments.append((first, payload))
+
+    for i, code in enumerate(code_lines):
+        stripped = code.strip()
+        if (
+            declaration is None
+            and brackets == 0
+            and all(block is not None for block in blocks)
+            and stripped
+        ):
+            payload = _declaration(code)
+            if payload is not None:
+                start(i, payload)
+                if payload[SYMBOL_KIND_KEY] != "module":
+                    declaration = payload
+                    declaration_depth = len(blocks)
+            elif not segments or segments[-1][1] is not modules[-1]:
+                segments.append((i, modules[-1]))
+
+        for token in _TOKEN.finditer(code):
+            word = token.group()
+            if word in ("(", "[", "{"):
+                brackets += 1
+            elif word in (")", "]", "}"):
+                brackets = max(brackets - 1, 0)
+            elif brackets:
+                continue  # Comprehensions and x[end] do not open or close
+            elif word != "end":
+                module = word in ("module", "baremodule") and declaration is None
+                blocks.append(segments[-1][1] if module else None)
+                if module:
+                    modules.append(segments[-1][1])
+            elif blocks and blocks.pop() is not None:
+                modules.pop()
+                # Code after a module continues its parent
+                segments.append((i + 1, modules[-1]))
+
+        if (
+            declaration is not None
+            and len(blocks) <= declaration_depth
+            and brackets == 0
+            and not stripped.endswith(("=", "->", "&&", "||", ","))
+        ):
+            declaration = None
+            # Code after a declaration continues its module
+            segments.append((i + 1, modules[-1]))
+        if stripped:
+            last_code_line = i
+
+    if declaration is not None:
+        # A declaration still open at the end of the file has a syntax error
+        declaration[DEGRADED_KEY] = True
+
+    # Segments holding only blank lines, comments and "end" belong to the
+    # segment before them
+    def is_filler(first: int, end: int, payload: Dict[str, Any]) -> bool:
+        return all(s.strip() in ("", "end") for s in code_lines[first:end])
+
+    kept = keep_segments(segments, len(lines), is_filler, top_level)
+    return chunk_segments(text, kept, chunk_size, overlap_size)
@@ -0,0 +1,131 @@
+"""Resource-aware chunking of Kubernetes manifests and Helm templates.
+
+A YAML file with top-level "apiVersion" and "kind" keys is split at its
+"---" document separators, so every resource is one chunk. The file is
+scanned line by line rather than parsed, so Helm templates, whose
+{{ ... }} expressions are not valid YAML, are chunked the same way and keep
+their template expressions in the chunk text. Documents holding only
+comments or template directives belong to the next resource. Each chunk
+records:
+
+- "k8s_kind": the resource kind, e.g. "Deployment"
+- "k8s_name": metadata.name as written, e.g. "redis" or
+  "{{ include "app.fullname" . }}"
+- "k8s_namespace": metadata.namespace, if set
+
+Resources also record [kind, name] under HEADING_PATH_KEY, so their
+embedding is prefixed with it. Resources longer than the chunk size are
+split into overlapping windows like FixedSizeChunker does.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import chunk_segments
+from .chunk_payload_keys import (
+    HEADING_PATH_KEY,
+    K8S_KIND_KEY,
+    K8S_NAMESPACE_KEY,
+    K8S_NAME_KEY,
+)
+
+KUBERNETES_LANGUAGES = {"yaml", "yml"}
+
+_API_VERSION = re.compile(r"^apiVersion:[ \t]*\S", re.M)
+_KIND = re.compile(r"^kind:[ \t]*(.*?)[ \t]*$")
+_METADATA = re.compile(r"^metadata:[ \t]*(?:#.*)?$")
+_METADATA_FIELD = re.compile(r"^([ \t]+)(name|namespace):[ \t]*(.*?)[ \t]*$")
+_DOCUMENT_SEPARATOR = re.compile(r"^---(?:[ \t].*)?$")
+_TRAILING_COMMENT = re.compile(r"[ \t]+#(?![^{]*\}\}).*$")
+
+
+def is_kubernetes_manifest(language: str, text: str) -> bool:
+    """Whether a file looks like a Kubernetes manifest or Helm template."""
+    return (
+        language.lower() in KUBERNETES_LANGUAGES
+        and bool(_API_VERSION.search(text))
+        and any(_KIND.match(line) for line in text.split("\n"))
+    )
+
+
+def _value(raw: str) -> str:
+    """Scalar value of a line, without trailing comment or quotes."""
+    value = _TRAILING_COMMENT.sub("", raw).strip()
+    if len(value) >= 2 and value[0] == value[-1] and value[0] in "\"'":
+        value = value[1:-1]
+    return value
+
+
+def resource_fields(lines: List[str]) -> Dict[str, Any]:
+    """Kind, name and namespace of the resource in a document's lines."""
+    fields: Dict[str, Any] = {}
+    in_metadata = False
+    metadata_indent: Optional[str] = None
+    for line in lines:
+        if line and not line[0].isspace() and not line.startswith("#"):
+            in_metadata = bool(_METADATA.match(line))
+            metadata_indent = None
+            kind = _KIND.match(line)
+            if kind and _value(kind.group(1)):
+                fields[K8S_KIND_KEY] = _value(kind.group(1))
+            continue
+        if not in_metadata:
+            continue
+        match = _METADATA_FIELD.match(line)
+        if match is None:
+            continue
+        indent, key, raw = match.groups()
+        # Only direct children of metadata (not labels or annotations)
+        if metadata_indent is None:
+            metadata_indent = indent
+        if indent == metadata_indent and _value(raw):
+            fields[K8S_NAME_KEY if key == "name" else K8S_NAMESPACE_KEY] = (
+                _value(raw)
+            )
+    if K8S_KIND_KEY in fields and K8S_NAME_KEY in fields:
+        fields[HEADING_PATH_KEY] = [fields[K8S_KIND_KEY], fields[K8S_NAME_KEY]]
+    return fields
+
+
+def chunk_kubernetes(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a Kubernetes manifest or Helm template into resources.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long resource
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the K8S_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    # (first line index, payload) of each document; separators start the
+    # document they introduce
+    documents: List[Tuple[int, Dict[str, Any]]] = []
+    start = 0
+    for i in range(1, len(lines) + 1):
+        if i == len(lines) or _DOCUMENT_SEPARATOR.match(lines[i]):
+            documents.append((start, resource_fields(lines[start:i])))
+            start = i
+
+    # Documents without a resource belong to the next one, or at the end of
+    # the file to the last one
+    resources: List[Tuple[int, Dict[str, Any]]] = []
+    pending_start: Optional[int] = None
+    for first_line, fields in documents:
+        if K8S_KIND_KEY not in fields:
+            if pending_start is None:
+                pending_start = first_line
+            continue
+        if pending_start is not None:
+            first_line, pending_start = pending_start, None
+        resources.append((first_line, fields))
+    if not resources:
+        resources.append((0, {}))
+
+    return chunk_segments(text, resources, chunk_size, overlap_size)
@@ -0,0 +1,282 @@
+"""Language detection for files with missing or ambiguous extensions.
+
+Indexed chunks record their language as an extension-style token ("py",
+"sh", "makefile"), so language filters and the language mappings treat a
+detected file like a file with that extension. Detection order:
+
+1. Well-known file names (Makefile, Dockerfile, Jenkinsfile, ...)
+2. The file extension, unless it is missing or ambiguous
+3. Missing extension: the shebang, then editor modelines
+4. Ambiguous ".h" extension: modelines, then Objective-C and C++ content
+   heuristics
+"""
+
+import re
+from pathlib import Path
+from typing import Dict, List, Optional
+
+# Bytes read from disk when a file's language depends on its content
+DETECTION_HEAD_BYTES = 4096
+
+# Vim reads modelines from the first and last lines of a file
+MODELINE_SCAN_LINES = 5
+
+HEADER_EXTENSION = "h"
+# C++ headers are labeled "cpp" so that `--language cpp` finds them
+CPP_HEADER_LANGUAGE = "cpp"
+# Objective-C headers are labeled like the .m and .mm files that import them
+OBJC_HEADER_LANGUAGE = "m"
+OBJCPP_HEADER_LANGUAGE = "mm"
+
+FILENAME_LANGUAGES: Dict[str, str] = {
+    "makefile": "makefile",
+    "gnumakefile": "makefile",
+    "dockerfile": "dockerfile",
+    "containerfile": "dockerfile",
+    "cmakelists.txt": "cmake",
+    "jenkinsfile": "groovy",
+    "rakefile": "rb",
+    "gemfile": "rb",
+    "vagrantfile": "rb",
+    "podfile": "rb",
+    "brewfile": "rb",
+    "snakefile": "py",
+    "sconstruct": "py",
+    "sconscript": "py",
+    ".bashrc": "sh",
+    ".bash_profile": "sh",
+    ".profile": "sh",
+    ".zshrc": "sh",
+}
+
+# Interpreter names (without version suffix) used in shebangs
+INTERPRETER_LANGUAGES: Dict[str, str] = {
+    "python": "py",
+    "pypy": "py",
+    "sh": "sh",
+    "bash": "sh",
+    "zsh": "sh",
+    "ksh": "sh",
+    "dash": "sh",
+    "ash": "sh",
+    "node": "js",
+    "nodejs": "js",
+    "ts-node": "ts",
+    "tsx": "ts",
+    "ruby": "rb",
+    "perl": "pl",
+    "php": "php",
+    "lua": "lua",
+    "groovy": "groovy",
+    "pwsh": "ps1",
+    "make": "makefile",
+    "swift": "swift",
+    "scala": "scala",
+    "kotlin": "kts",
+    "elixir": "exs",
+    "escript": "erl",
+    "julia": "jl",
+    "rscript": "r",
+}
+
+# Emacs major modes and Vim filetypes
+MODELINE_LANGUAGES: Dict[str, str] = {
+    "python": "py",
+    "sh": "sh",
+    "bash": "sh",
+    "zsh": "sh",
+    "shell-script": "sh",
+    "ruby": "rb",
+    "perl": "pl",
+    "cperl": "pl",
+    "javascript": "js",
+    "js": "js",
+    "js2": "js",
+    "typescript": "ts",
+    "c": "c",
+    "c++": "cpp",
+    "cpp": "cpp",
+    "objc": "m",
+    "objcpp": "mm",
+    "go": "go",
+    "rust": "rs",
+    "zig": "zig",
+    "elixir": "ex",
+    "erlang": "erl",
+    "java": "java",
+    "php": "php",
+    "lua": "lua",
+    "groovy": "groovy",
+    "julia": "jl",
+    "r": "r",
+    "ess-r": "r",
+    "make": "makefile",
+    "makefile": "makefile",
+    "dockerfile": "dockerfile",
+    "cmake": "cmake",
+    "yaml": "yaml",
+    "json": "json",
+    "toml": "toml",
+    "sql": "sql",
+    "xml": "xml",
+    "html": "html",
+    "markdown": "md",
+}
+
+_EMACS_MODELINE = re.compile(r"-\*-(.+?)-\*-")
+_EMACS_MODE = re.compile(r"(?:^|;)\s*mode\s*:\s*([\w+-]+)", re.IGNORECASE)
+_VIM_MODELINE = re.compile(
+    r"(?:^|\s)(?:vi|vim|ex):.*?\b(?:ft|filetype|syn|syntax)=([\w+-]+)"
+)
+
+# C++-only constructs; extern "C" and __cplusplus guards appear in C headers
+_CPP_HEADER_MARKERS = re.compile(
+    r"^\s*(?:template\s*<"
+    r"|namespace\s+[\w:]*\s*\{"
+    r"|class\s+\w+[^;()]*$"
+    r"|(?:public|protected|private)\s*:"
+    r"|using\s+namespace\s)"
+    r"|\bstd::"
+    r"|^\s*#\s*include\s*<\w+>",
+    re.MULTILINE,
+)
+
+# Objective-C directives, which no C or C++ header contains
+_OBJC_HEADER_MARKERS = re.compile(
+    r"^\s*(?:@(?:interface|protocol|class|import)\b|#\s*import\b)",
+    re.MULTILINE,
+)
+
+
+def detect_language(file_path: Path, content: Optional[str] = None) -> str:
+    """
+    Detect the language token of a file.
+
+    Args:
+        file_path: Path of the file (only the name is used)
+        content: File content, or its head; without it only the name is used
+
+    Returns:
+        Extension-style language token, or "" when unknown
+    """
+    language = _language_from_name(file_path)
+    if language is not None or not content:
+        return language if language is not None else file_path.suffix.lstrip(".")
+
+    if file_path.suffix.lstrip(".").lower() == HEADER_EXTENSION:
+        return _header_language(content)
+    return _script_language(content) or ""
+
+
+def detect_file_language(file_path: Path) -> str:
+    """
+    Detect the language token of a file on disk.
+
+    The file is only read when its name does not determine the language.
+
+    Args:
+        file_path: File to inspect
+
+    Returns:
+        Extension-style language token, or "" when unknown
+    """
+    language = _language_from_name(file_path)
+    if language is not None:
+        return language
+    try:
+        with open(file_path, "rb") as f:
+            head = f.read(DETECTION_HEAD_BYTES)
+    except OSError:
+        return file_path.suffix.lstrip(".")
+    return detect_language(file_path, head.decode("utf-8", errors="replace"))
+
+
+def _language_from_name(file_path: Path) -> Optional[str]:
+    """Language implied by the file name, or None if content is needed."""
+    name = file_path.name.lower()
+    if name in FILENAME_LANGUAGES:
+        return FILENAME_LANGUAGES[name]
+    if name.startswith(("dockerfile.", "containerfile.")):
+        return "dockerfile"
+
+    extension = file_path.suffix.lstrip(".")
+    if not extension or extension.lower() == HEADER_EXTENSION:
+        return None
+    return extension
+
+
+def _script_language(content: str) -> Optional[str]:
+    lines = content.splitlines()
+    if not lines:
+        return None
+    return _shebang_language(lines[0]) or _modeline_language(lines)
+
+
+def _header_language(content: str) -> str:
+    modeline = _modeline_language(content.splitlines())
+    if modeline in (CPP_HEADER_LANGUAGE, OBJC_HEADER_LANGUAGE, OBJCPP_HEADER_LANGUAGE):
+        return modeline
+    if modeline is not None:
+        return HEADER_EXTENSION
+    head = content[:DETECTION_HEAD_BYTES]
+    cpp = _CPP_HEADER_MARKERS.search(head) is not None
+    if _OBJC_HEADER_MARKERS.search(head):
+        return OBJCPP_HEADER_LANGUAGE if cpp else OBJC_HEADER_LANGUAGE
+    return CPP_HEADER_LANGUAGE if cpp else HEADER_EXTENSION
+
+
+def _shebang_language(first_line: str) -> Optional[str]:
+    """Language of the interpreter named by a shebang line."""
+    if not first_line.startswith("#!"):
+        return None
+    words = first_line[2:].split()
+    if not words:
+        return None
+
+    interpreter = words[0].rsplit("/", 1)[-1]
+    if interpreter == "env":
+        # Skip env options (-S) and variable assignments
+        args = [w for w in words[1:] if not w.startswith("-") and "=" not in w]
+        if not args:
+            return None
+        interpreter = args[0].rsplit("/", 1)[-1]
+
+    # python3.11 -> python
+    name = re.sub(r"[\d.]+$", "", interpreter.lower())
+    return INTERPRETER_LANGUAGES.get(name)
+
+
+def _modeline_language(lines: List[str]) -> Optional[str]:
+    """Language declared by an Emacs or Vim modeline."""
+    # Emacs only reads the first line, or the second after a shebang
+    for line in lines[:2]:
+        match = _EMACS_MODELINE.search(line)
+        if match:
+            settings = match.group(1).strip()
+            mode_match = _EMACS_MODE.search(settings)
+            if mode_match:
+                mode = mode_match.group(1)
+            elif ":" not in settings:
+                mode = settings
+            else:
+                continue
+            language = _modeline_mode_language(mode)
+            if language:
+                return language
+
+    head = lines[:MODELINE_SCAN_LINES]
+    tail = lines[MODELINE_SCAN_LINES:][-MODELINE_SCAN_LINES:]
+    for line in head + tail:
+        match = _VIM_MODELINE.search(line)
+        if match:
+            language = _modeline_mode_language(match.group(1))
+            if language:
+                return language
+    return None
+
+
+def _modeline_mode_language(mode: str) -> Optional[str]:
+    mode = mode.lower()
+    if mode.endswith("-mode"):
+        mode = mode[: -len("-mode")]
+    return MODELINE_LANGUAGES.get(mode)
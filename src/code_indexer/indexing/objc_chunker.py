@@ -0,0 +1,225 @@
+"""Declaration-aware chunking of Objective-C and Objective-C++ files.
+
+.m and .mm files (and .h headers labeled "m" by language detection) are
+split at their @interface, @protocol and @implementation blocks and at
+top-level C functions. An @implementation is further split at its method
+definitions, so every method is a chunk of its own. Imports, typedefs and
+other top-level code between them form "file" chunks, and comments
+directly above a declaration belong to it. Each chunk records:
+
+- "symbol_kind": interface, category (an @interface or @implementation of a
+  category), extension (a class extension "@interface Foo ()"), protocol,
+  implementation, method, function or file
+- "symbol_name": the class ("UserStore"), "Class (Category)", protocol or
+  function name; methods are named like the runtime does,
+  "-[UserStore saveUser:error:]" or "+[UserStore(Sync) sharedStore]"
+
+Strings, character literals and comments are skipped when matching
+braces. Declarations longer than the chunk size are split into overlapping
+windows like FixedSizeChunker does, and every window keeps these fields.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import (
+    DEGRADED_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    chunk_segments,
+    keep_segments,
+    leading_comments,
+)
+
+OBJC_LANGUAGES = {"m", "mm"}
+
+_CONTAINER = re.compile(
+    r"^\s*@(interface|implementation|protocol)\s+(\w+)"
+    r"(?:\s*\(\s*(\w*)\s*\))?"
+)
+_END = re.compile(r"^\s*@end\b")
+_METHOD = re.compile(r"^\s*([-+])\s*\(")
+_FUNCTION = re.compile(
+    r"^\s*(?!(?:if|for|while|switch|return|else|do|typedef|struct|enum|union"
+    r"|class|namespace|extern|template|using)\b)"
+    r"[\w*&:<>,\s]*?\b([A-Za-z_]\w*)\s*\([^;{]*\)\s*(?:const\s*)?\{"
+)
+_TYPE_IN_PARENS = re.compile(r"\((?:[^()]|\([^()]*\))*\)")
+_SELECTOR_PART = re.compile(r"(\w*)\s*:")
+_PRAGMA_MARK = re.compile(r"^\s*#\s*pragma\s+mark\b")
+
+
+def is_objc(language: str) -> bool:
+    """Whether a language token is chunked by Objective-C declarations."""
+    return language.lower() in OBJC_LANGUAGES
+
+
+def _objc_code(lines: List[str]) -> List[str]:
+    """Lines of a file with comments, strings and character literals blanked."""
+    code_lines = []
+    in_comment = False
+    for line in lines:
+        code = []
+        i = 0
+        while i < len(line):
+            if in_comment:
+                end = line.find("*/", i)
+                if end < 0:
+                    break
+                in_comment = False
+                i = end + 2
+                continue
+            if line.startswith("//", i):
+                break
+            if line.startswith("/*", i):
+                in_comment = True
+                i += 2
+                continue
+            if line[i] in "\"'":
+                # @"..." literals are C strings after the @
+                quote = line[i]
+                i += 1
+                while i < len(line) and line[i] != quote:
+                    i += 2 if line[i] == "\\" else 1
+                code.append(" ")
+                i += 1
+                continue
+            code.append(line[i])
+            i += 1
+        code_lines.append("".join(code))
+    return code_lines
+
+
+def _is_comment(line: str) -> bool:
+    stripped = line.lstrip()
+    return stripped.startswith(("//", "/*", "*")) or bool(_PRAGMA_MARK.match(line))
+
+
+def method_selector(header: str) -> str:
+    """Selector of a method declaration, e.g. "saveUser:error:"."""
+    signature = _TYPE_IN_PARENS.sub(" ", header.strip()[1:].split("{", 1)[0])
+    parts = _SELECTOR_PART.findall(signature.split(";", 1)[0])
+    if parts:
+        return "".join(f"{part}:" for part in parts)
+    words = signature.split()
+    return words[0] if words else ""
+
+
+def _header(code_lines: List[str], i: int) -> str:
+    """Code of a declaration header from line i to its body or end."""
+    header = []
+    for code in code_lines[i : i + 10]:
+        header.append(code.strip())
+        if "{" in code or ";" in code:
+            break
+    return " ".join(header)
+
+
+def _container_payload(match: "re.Match[str]") -> Dict[str, Any]:
+    keyword, name, category = match.groups()
+    if category is None:
+        kind = keyword
+    elif category:
+        kind, name = "category", f"{name} ({category})"
+    else:
+        kind = "extension"
+    return {SYMBOL_KIND_KEY: kind, SYMBOL_NAME_KEY: name}
+
+
+def chunk_objc(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split an Objective-C file into declarations, methods and functions.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long declaration
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the SYMBOL_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    code_lines = _objc_code(lines)
+    top_level = {SYMBOL_KIND_KEY: "file"}
+    # (first line index, payload) of each segment
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    depth = 0
+    # The open @interface/@protocol/@implementation, and the class of methods
+    container: Optional[Dict[str, Any]] = None
+    implementation = ""
+    # The open method or function, and whether its body has started
+    body: Optional[Dict[str, Any]] = None
+    opened = False
+    last_code_line = -1
+
+    def start(i: int, payload: Dict[str, Any]) -> None:
+        # Comments and "#pragma mark" lines directly above belong to it
+        first = leading_comments(lines, i, last_code_line, _is_comment)
+        segments.append((first, payload))
+
+    for i, code in enumerate(code_lines):
+        stripped = code.strip()
+        if body is None and depth == 0 and stripped:
+            header = _header(code_lines, i)
+            container_match = _CONTAINER.match(code)
+            function = _FUNCTION.match(header)
+            if container is not None and _END.match(code):
+                container = None
+                implementation = ""
+                segments.append((i + 1, top_level))
+            elif container is None and container_match and not stripped.endswith(
+                ";"
+            ):
+                # "@protocol Foo;" is a forward declaration
+                container = _container_payload(container_match)
+                if container_match.group(1) == "implementation":
+                    implementation = container[SYMBOL_NAME_KEY].replace(" ", "")
+                start(i, container)
+            elif implementation and _METHOD.match(code):
+                body = {
+                    SYMBOL_KIND_KEY: "method",
+                    SYMBOL_NAME_KEY: (
+                        f"{stripped[0]}[{implementation} {method_selector(header)}]"
+                    ),
+                }
+                opened = False
+                start(i, body)
+            elif (container is None or implementation) and function:
+                body = {SYMBOL_KIND_KEY: "function", SYMBOL_NAME_KEY: function.group(1)}
+                opened = False
+                start(i, body)
+            elif container is None and (
+                not segments or segments[-1][1] is not top_level
+            ):
+                segments.append((i, top_level))
+
+        opens = code.count("{")
+        depth = max(depth + opens - code.count("}"), 0)
+        if opens:
+            opened = True
+        if body is not None and depth == 0 and opened:
+            body = None
+            # Code after a method continues its @implementation
+            segments.append((i + 1, container if container else top_level))
+        if stripped and not _PRAGMA_MARK.match(code):
+            last_code_line = i
+
+    if body is not None:
+        # A method or function still open at the end of the file has a
+        # syntax error
+        body[DEGRADED_KEY] = True
+
+    # Segments holding only blank lines, comments, closing braces, @end and
+    # "#pragma mark" lines belong to the segment before them
+    def is_filler(first: int, end: int, payload: Dict[str, Any]) -> bool:
+        return all(
+            not s.strip() or s.strip() in ("}", "@end") or _PRAGMA_MARK.match(s)
+            for s in code_lines[first:end]
+        )
+
+    kept = keep_segments(segments, len(lines), is_filler, top_level)
+    return chunk_segments(text, kept, chunk_size, overlap_size)
@@ -0,0 +1,198 @@
+"""Operation-aware chunking of OpenAPI and Swagger specifications.
+
+A YAML or JSON file with a top-level "openapi" or "swagger" key is split at
+its operations and schemas instead of fixed character windows: every
+path+method under "paths" is one chunk, and every schema under
+"components.schemas" (Swagger 2: "definitions") is one chunk. The info,
+servers, security and other sections between them form "spec" chunks.
+Comments and path keys before an operation belong to its chunk. Each chunk
+records:
+
+- "openapi_kind": spec, operation or schema
+- "openapi_operation": method and path of an operation, e.g.
+  "GET /users/{id}"
+- "operation_id" and "openapi_tags": the operationId and tags of an
+  operation
+- "openapi_schema": the name of a schema
+
+Operations and schemas also record their key path (["paths", "/users/{id}",
+"get"]) under HEADING_PATH_KEY, so their embedding is prefixed with it like
+the sections of documentation files. Definitions longer than the chunk size
+are split into overlapping windows like FixedSizeChunker does. Files that do
+not parse fall back to fixed-size chunking.
+"""
+
+import bisect
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+import yaml
+
+from .boundary_chunking import chunk_segments
+from .chunk_payload_keys import (
+    HEADING_PATH_KEY,
+    OPENAPI_KIND_KEY,
+    OPENAPI_OPERATION_KEY,
+    OPENAPI_SCHEMA_KEY,
+    OPENAPI_TAGS_KEY,
+    OPERATION_ID_KEY,
+)
+
+OPENAPI_LANGUAGES = {"yaml", "yml", "json"}
+
+HTTP_METHODS = {"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+# Spec version key near the start of the file; confirmed after parsing
+_VERSION_KEY = re.compile(r"""(?:^|[{,\s])["']?(?:openapi|swagger)["']?\s*:""")
+_DETECTION_WINDOW = 4096
+
+
+def is_openapi(language: str, text: str) -> bool:
+    """Whether a file looks like an OpenAPI or Swagger specification."""
+    return language.lower() in OPENAPI_LANGUAGES and bool(
+        _VERSION_KEY.search(text[:_DETECTION_WINDOW])
+    )
+
+
+def _scalar(node: Any) -> Optional[str]:
+    if isinstance(node, yaml.ScalarNode):
+        return str(node.value)
+    return None
+
+
+def chunk_openapi(
+    text: str, chunk_size: int, overlap_size: int
+) -> Optional[List[Dict[str, Any]]]:
+    """
+    Split an OpenAPI or Swagger specification into operations and schemas.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long definition
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the OPENAPI_*
+        fields (chunk_index, total_chunks and file fields are left to the
+        caller), or None if the text is not a specification
+    """
+    try:
+        root = yaml.compose(text, Loader=yaml.SafeLoader)
+    except yaml.YAMLError:
+        return None
+    if not isinstance(root, yaml.MappingNode):
+        return None
+    top_keys = {_scalar(key) for key, _ in root.value}
+    if "openapi" not in top_keys and "swagger" not in top_keys:
+        return None
+
+    # Only "\n" ends a line, as in the line numbers of all other chunks
+    line_offsets = [0] + [m.end() for m in re.finditer("\n", text)]
+    if line_offsets[-1] == len(text) and len(line_offsets) > 1:
+        line_offsets.pop()
+    lines = text.split("\n")
+
+    def line_of(offset: int) -> int:
+        return bisect.bisect_right(line_offsets, offset) - 1
+
+    def last_line(key: Any, value: Any) -> int:
+        """Last line of a key and its value, without trailing comments."""
+        first = line_of(key.start_mark.index)
+        # Block collections end at the next key, after the whitespace before it
+        end = value.end_mark.index
+        while end > key.start_mark.index + 1 and text[end - 1].isspace():
+            end -= 1
+        line = line_of(end - 1)
+        while line > first and (
+            not lines[line].strip() or lines[line].lstrip().startswith("#")
+        ):
+            line -= 1
+        return line
+
+    # (last line, payload) of each segment; segments run from the line after
+    # the previous one, so leading comments and keys belong to the next one
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+
+    def add_spec(last: int) -> None:
+        if segments and last <= segments[-1][0]:
+            return  # Shares a line with the previous segment
+        if segments and segments[-1][1][OPENAPI_KIND_KEY] == "spec":
+            segments[-1] = (last, segments[-1][1])
+        else:
+            segments.append((last, {OPENAPI_KIND_KEY: "spec"}))
+
+    def add_definition(last: int, payload: Dict[str, Any]) -> None:
+        if segments and last <= segments[-1][0]:
+            return
+        segments.append((last, payload))
+
+    def add_schemas(key: Any, value: Any, key_path: List[str]) -> None:
+        if not isinstance(value, yaml.MappingNode):
+            add_spec(last_line(key, value))
+            return
+        for schema_key, schema in value.value:
+            name = _scalar(schema_key) or ""
+            add_definition(
+                last_line(schema_key, schema),
+                {
+                    OPENAPI_KIND_KEY: "schema",
+                    OPENAPI_SCHEMA_KEY: name,
+                    HEADING_PATH_KEY: key_path + [name],
+                },
+            )
+
+    def add_operation(path: str, method_key: Any, operation: Any) -> None:
+        method = (_scalar(method_key) or "").lower()
+        fields = {_scalar(k): v for k, v in operation.value}
+        payload: Dict[str, Any] = {
+            OPENAPI_KIND_KEY: "operation",
+            OPENAPI_OPERATION_KEY: f"{method.upper()} {path}",
+            HEADING_PATH_KEY: ["paths", path, method],
+        }
+        operation_id = _scalar(fields.get("operationId"))
+        if operation_id:
+            payload[OPERATION_ID_KEY] = operation_id
+        tags = fields.get("tags")
+        if isinstance(tags, yaml.SequenceNode):
+            tag_names = [t for t in map(_scalar, tags.value) if t]
+            if tag_names:
+                payload[OPENAPI_TAGS_KEY] = tag_names
+        add_definition(last_line(method_key, operation), payload)
+
+    for key, value in root.value:
+        name = _scalar(key)
+        if name == "paths" and isinstance(value, yaml.MappingNode):
+            for path_key, item in value.value:
+                if not isinstance(item, yaml.MappingNode):
+                    add_spec(last_line(path_key, item))
+                    continue
+                path = _scalar(path_key) or ""
+                for method_key, operation in item.value:
+                    if (_scalar(method_key) or "").lower() in HTTP_METHODS and (
+                        isinstance(operation, yaml.MappingNode)
+                    ):
+                        add_operation(path, method_key, operation)
+                    else:
+                        add_spec(last_line(method_key, operation))  # parameters
+        elif name == "components" and isinstance(value, yaml.MappingNode):
+            for section_key, section in value.value:
+                if _scalar(section_key) == "schemas":
+                    add_schemas(section_key, section, ["components", "schemas"])
+                else:
+                    add_spec(last_line(section_key, section))
+        elif name == "definitions":
+            add_schemas(key, value, ["definitions"])
+        else:
+            add_spec(last_line(key, value))
+
+    if not segments:
+        return None
+    # Trailing comments and blank lines belong to the last segment
+    segments[-1] = (len(line_offsets) - 1, segments[-1][1])
+
+    starts: List[Tuple[int, Dict[str, Any]]] = []
+    first_line = 0
+    for last, payload in segments:
+        starts.append((first_line, payload))
+        first_line = last + 1
+    return chunk_segments(text, starts, chunk_size, overlap_size)
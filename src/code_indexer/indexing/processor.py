@@ -75,7 +75,7 @@ class DocumentProcessor:
             for chunk in chunks:
                 # Prepare metadata for the chunk
                 chunk_metadata = {
-                    "path": str(file_path.relative_to(self.config.codebase_dir)),
+                    "path": file_path.relative_to(self.config.codebase_dir).as_posix(),
                     "language": chunk["file_extension"],
                     "file_size": file_path.stat().st_size,
                     "chunk_index": chunk["chunk_index"],
@@ -0,0 +1,249 @@
+"""Definition-aware chunking of Protocol Buffers (.proto) files.
+
+A .proto file is split at its top-level definitions instead of fixed
+character windows: every message, enum and extend block is one chunk, and
+every rpc of a service is one chunk (the service header travels with its
+first rpc). The syntax, package, import and option statements between
+definitions form "file" chunks. Comments before a definition belong to its
+chunk. Each chunk records:
+
+- "proto_kind": file, message, enum, extend, service or rpc
+- "proto_name": the full name, e.g. "acme.user.v1.UserService.GetUser"
+- "proto_package": the package of the file
+- "proto_options": the file options and the definition's own options as
+  "name=value" terms, e.g. ["go_package=github.com/acme/user/v1"]
+
+Definitions longer than the chunk size are split into overlapping windows
+like FixedSizeChunker does, and every window keeps the definition's fields.
+"""
+
+import bisect
+import re
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import chunk_segments
+from .chunk_payload_keys import (
+    PROTO_KIND_KEY,
+    PROTO_NAME_KEY,
+    PROTO_OPTIONS_KEY,
+    PROTO_PACKAGE_KEY,
+)
+
+PROTO_LANGUAGES = {"proto"}
+
+# Top-level statements chunked on their own
+DEFINITION_KEYWORDS = {"message", "enum", "service", "extend"}
+# Statements whose braces hold further statements (not option values)
+_BLOCK_KEYWORDS = DEFINITION_KEYWORDS | {"rpc", "oneof"}
+
+_TOKEN = re.compile(
+    r"""\s+
+    |//[^\n]*
+    |/\*.*?\*/
+    |(?P<string>"(?:\\.|[^"\\\n])*"|'(?:\\.|[^'\\\n])*')
+    |(?P<word>[A-Za-z_.][\w.]*|[-+]?\d[\w.+-]*)
+    |(?P<punct>.)""",
+    re.VERBOSE | re.DOTALL,
+)
+
+
+@dataclass
+class Statement:
+    """A statement of a .proto file, with the statements of its block."""
+
+    words: List[str]
+    start: int
+    end: int
+    value: str = ""
+    children: List["Statement"] = field(default_factory=list)
+
+    @property
+    def keyword(self) -> str:
+        return self.words[0] if self.words else ""
+
+    @property
+    def name(self) -> str:
+        return self.words[1] if len(self.words) > 1 else ""
+
+
+def is_proto(language: str) -> bool:
+    """Whether a language token is chunked by protobuf definitions."""
+    return language.lower() in PROTO_LANGUAGES
+
+
+def parse_statements(text: str) -> List[Statement]:
+    """Top-level statements of a .proto file; comments and strings skipped."""
+    tokens: List[Tuple[str, int, int]] = []
+    for match in _TOKEN.finditer(text):
+        kind = match.lastgroup
+        if kind is not None:
+            tokens.append((match.group(kind), match.start(), match.end()))
+    statements, _ = _parse_block(text, tokens, 0)
+    return statements
+
+
+def _parse_block(
+    text: str, tokens: List[Tuple[str, int, int]], i: int
+) -> Tuple[List[Statement], int]:
+    """Statements from token i up to the closing brace of the block."""
+    statements = []
+    while i < len(tokens):
+        token, start, _ = tokens[i]
+        if token == "}":
+            return statements, i + 1
+        if token == ";":
+            i += 1
+            continue
+        statement = Statement(words=[], start=start, end=start)
+        value_start = None
+        while i < len(tokens):
+            token, token_start, token_end = tokens[i]
+            i += 1
+            statement.end = token_end
+            if token == ";":
+                break
+            if token == "=" and value_start is None:
+                value_start = token_end
+            elif token == "{":
+                if statement.keyword in _BLOCK_KEYWORDS and value_start is None:
+                    statement.children, i = _parse_block(text, tokens, i)
+                    statement.end = tokens[i - 1][2]
+                    break
+                i = _skip_braces(tokens, i)  # Aggregate option value
+                statement.end = tokens[i - 1][2]
+            elif token == "}":
+                i -= 1  # Unterminated statement: the block ends
+                break
+            elif value_start is None:
+                statement.words.append(token)
+        if value_start is not None:
+            statement.value = " ".join(text[value_start : statement.end].split())
+            statement.value = statement.value.rstrip(";").strip()
+        statements.append(statement)
+    return statements, i
+
+
+def _skip_braces(tokens: List[Tuple[str, int, int]], i: int) -> int:
+    depth = 1
+    while i < len(tokens) and depth:
+        if tokens[i][0] == "{":
+            depth += 1
+        elif tokens[i][0] == "}":
+            depth -= 1
+        i += 1
+    return i
+
+
+def option_terms(statements: List[Statement]) -> List[str]:
+    """Terms "name=value" of the option statements among statements."""
+    terms = []
+    for statement in statements:
+        if statement.keyword != "option":
+            continue
+        name = "".join(statement.words[1:])
+        value = statement.value
+        if len(value) >= 2 and value[0] == value[-1] and value[0] in "\"'":
+            value = value[1:-1]
+        terms.append(f"{name}={value}")
+    return terms
+
+
+def chunk_proto(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a .proto file into definitions.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long definition
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the PROTO_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    # Only "\n" ends a line, as in the line numbers of all other chunks
+    line_offsets = [0] + [m.end() for m in re.finditer("\n", text)]
+    if line_offsets[-1] == len(text) and len(line_offsets) > 1:
+        line_offsets.pop()
+
+    def line_of(offset: int) -> int:
+        return bisect.bisect_right(line_offsets, offset) - 1
+
+    statements = parse_statements(text)
+    package = next((s.name for s in statements if s.keyword == "package"), "")
+    file_options = option_terms(statements)
+
+    def full_name(*names: str) -> str:
+        return ".".join(n for n in (package, *names) if n)
+
+    # (last line, payload) of each segment; segments run from the line after
+    # the previous one, so leading comments belong to the next definition
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    for statement in statements:
+        last_line = line_of(statement.end - 1)
+        if segments and last_line <= segments[-1][0]:
+            continue  # Shares a line with the previous segment
+        keyword = statement.keyword
+        if keyword not in DEFINITION_KEYWORDS:
+            if segments and segments[-1][1][PROTO_KIND_KEY] == "file":
+                segments[-1] = (last_line, segments[-1][1])
+            else:
+                segments.append((last_line, {PROTO_KIND_KEY: "file"}))
+            continue
+        rpcs = [c for c in statement.children if c.keyword == "rpc"]
+        if keyword == "service" and rpcs:
+            for n, rpc in enumerate(rpcs):
+                rpc_last = last_line if n == len(rpcs) - 1 else line_of(rpc.end - 1)
+                if segments and rpc_last <= segments[-1][0]:
+                    continue
+                segments.append(
+                    (
+                        rpc_last,
+                        {
+                            PROTO_KIND_KEY: "rpc",
+                            PROTO_NAME_KEY: full_name(statement.name, rpc.name),
+                            PROTO_OPTIONS_KEY: option_terms(rpc.children),
+                        },
+                    )
+                )
+            continue
+        name = statement.name if keyword == "extend" else full_name(statement.name)
+        segments.append(
+            (
+                last_line,
+                {
+                    PROTO_KIND_KEY: keyword,
+                    PROTO_NAME_KEY: name,
+                    PROTO_OPTIONS_KEY: option_terms(statement.children),
+                },
+            )
+        )
+
+    if not segments:
+        segments.append((len(line_offsets) - 1, {PROTO_KIND_KEY: "file"}))
+    # Trailing comments and blank lines belong to the last segment
+    segments[-1] = (len(line_offsets) - 1, segments[-1][1])
+
+    starts: List[Tuple[int, Dict[str, Any]]] = []
+    first_line = 0
+    for last_line, payload in segments:
+        if package:
+            payload[PROTO_PACKAGE_KEY] = package
+        options = file_options + payload.get(PROTO_OPTIONS_KEY, [])
+        if options:
+            payload[PROTO_OPTIONS_KEY] = options
+        else:
+            payload.pop(PROTO_OPTIONS_KEY, None)
+        starts.append((first_line, payload))
+        first_line = last_line + 1
+    return chunk_segments(text, starts, chunk_size, overlap_size)
+
+
+def grpc_method_path(proto_name: str) -> Optional[str]:
+    """gRPC path ("pkg.Service/Method") of an rpc's full name."""
+    service, _, method = proto_name.rpartition(".")
+    return f"{service}/{method}" if service else None
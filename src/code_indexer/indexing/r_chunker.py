@@ -0,0 +1,204 @@
+"""Declaration-aware chunking of R source files.
+
+An .R file is split at its top-level statements defining functions
+(`name <- function(...)`), S4 classes, generics and methods (`setClass`,
+`setGeneric`, `setMethod`), reference classes (`setRefClass`) and R6
+classes (`R6Class`). Library calls and other code between them form "file"
+chunks, and comments, including roxygen `#'` blocks, directly above a
+definition belong to it. Each chunk records:
+
+- "symbol_kind": function, s4_class, s4_generic, s4_method, ref_class,
+  r6_class or file
+- "symbol_name": the defined name; an S4 method is named by its generic and
+  signature ("area,Circle")
+
+A statement runs until its brackets are closed and its last line does not
+end with an operator; strings, raw strings, backquoted names and comments
+are skipped when matching brackets. Definitions longer than the chunk size
+are split into overlapping windows like FixedSizeChunker does, and every
+window keeps these fields.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import (
+    DEGRADED_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    chunk_segments,
+    keep_segments,
+    leading_comments,
+)
+
+R_LANGUAGES = {"r"}
+
+_NAME = r"(`[^`]+`|[A-Za-z.][\w.]*)"
+_ASSIGNMENT = re.compile(r"^\s*" + _NAME + r"\s*(?:<<?-|=)\s*(.*)$")
+_FUNCTION = re.compile(r"^(?:function\b|\\\s*\()")
+_CALL = re.compile(
+    r"^(?:(?:methods|R6)::)?"
+    r"(setClass|setGeneric|setMethod|setRefClass|R6Class)\s*\(\s*"
+    r"(?:\w+\s*=\s*)?[\"']([^\"']+)[\"']"
+    r"(?:\s*,\s*(?:signature\s*=\s*)?(?:signature\s*\(\s*(?:\w+\s*=\s*)?)?"
+    r"[\"']([^\"']+)[\"'])?"
+)
+_CALL_KINDS = {
+    "setClass": "s4_class",
+    "setGeneric": "s4_generic",
+    "setMethod": "s4_method",
+    "setRefClass": "ref_class",
+    "R6Class": "r6_class",
+}
+# A line ending with an operator continues on the next line
+_CONTINUED = re.compile(r"(?:<-|[-+*/^=,&|~$@!<>]|%[^%]*%|\|>)\s*$")
+# A function whose body starts on the next line
+_FUNCTION_HEADER = re.compile(r"(?:\bfunction|\\)\s*\((?:[^()]|\([^()]*\))*\)\s*$")
+_RAW_STRING = re.compile(r"[rR](['\"])(-*)([(\[{])")
+_CLOSERS = {"(": ")", "[": "]", "{": "}"}
+
+
+def is_r(language: str) -> bool:
+    """Whether a language token is chunked by R definitions."""
+    return language.lower() in R_LANGUAGES
+
+
+def _r_code(lines: List[str]) -> List[str]:
+    """Lines of an R file with comments, strings and backquoted names blanked."""
+    code_lines = []
+    closer: Optional[str] = None  # Closing delimiter of the open string
+    for line in lines:
+        code = []
+        i = 0
+        while i < len(line):
+            if closer is not None:
+                if len(closer) == 1 and line[i] == "\\":
+                    i += 2
+                elif line.startswith(closer, i):
+                    i += len(closer)
+                    closer = None
+                    code.append(" ")
+                else:
+                    i += 1
+                continue
+            if line[i] == "#":
+                break
+            raw = _RAW_STRING.match(line, i)
+            if raw and not (code and (code[-1].isalnum() or code[-1] in "._")):
+                quote, dashes, bracket = raw.groups()
+                closer = _CLOSERS[bracket] + dashes + quote
+                i = raw.end()
+                continue
+            if line[i] in "\"'`":
+                closer = line[i]
+                i += 1
+                continue
+            code.append(line[i])
+            i += 1
+        code_lines.append("".join(code))
+    return code_lines
+
+
+def _definition(header: str) -> Optional[Dict[str, Any]]:
+    """Payload of the definition a statement starts with, or None."""
+    name: Optional[str] = None
+    value = header.strip()
+    assignment = _ASSIGNMENT.match(header)
+    if assignment:
+        name, value = assignment.group(1).strip("`"), assignment.group(2)
+    if name and _FUNCTION.match(value):
+        return {SYMBOL_KIND_KEY: "function", SYMBOL_NAME_KEY: name}
+    call = _CALL.match(value)
+    if call:
+        function, defined, signature = call.groups()
+        kind = _CALL_KINDS[function]
+        if kind == "s4_method" and signature:
+            defined = f"{defined},{signature}"
+        elif kind == "r6_class" and name:
+            defined = name
+        return {SYMBOL_KIND_KEY: kind, SYMBOL_NAME_KEY: defined}
+    return None
+
+
+def _statement_header(lines: List[str], code_lines: List[str], i: int) -> str:
+    """First line of a statement, joined with the next if it continues."""
+    code = code_lines[i]
+    if _CONTINUED.search(code) or code.count("(") > code.count(")"):
+        return " ".join(line.strip() for line in lines[i : i + 2])
+    return lines[i]
+
+
+def _is_comment(line: str) -> bool:
+    return line.lstrip().startswith("#")
+
+
+def chunk_r(text: str, chunk_size: int, overlap_size: int) -> List[Dict[str, Any]]:
+    """
+    Split an R file into function and class definitions.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long definition
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the SYMBOL_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    code_lines = _r_code(lines)
+    top_level = {SYMBOL_KIND_KEY: "file"}
+    # (first line index, payload) of each segment
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    depth = 0
+    # Code of the open top-level statement, and its definition if any
+    statement: Optional[str] = None
+    definition: Optional[Dict[str, Any]] = None
+    last_code_line = -1
+
+    def start(i: int, payload: Dict[str, Any]) -> None:
+        # Comments and roxygen blocks directly above belong to it
+        first = leading_comments(lines, i, last_code_line, _is_comment)
+        segments.append((first, payload))
+
+    for i, code in enumerate(code_lines):
+        stripped = code.strip()
+        if statement is None and stripped:
+            statement = ""
+            definition = _definition(_statement_header(lines, code_lines, i))
+            if definition is not None:
+                start(i, definition)
+            elif not segments or segments[-1][1] is not top_level:
+                segments.append((i, top_level))
+
+        for char in code:
+            if char in "([{":
+                depth += 1
+            elif char in ")]}":
+                depth = max(depth - 1, 0)
+        if statement is not None and stripped:
+            statement = f"{statement} {stripped}"
+            if (
+                depth == 0
+                and not _CONTINUED.search(code)
+                and not _FUNCTION_HEADER.search(statement)
+            ):
+                statement = None
+                if definition is not None:
+                    definition = None
+                    segments.append((i + 1, top_level))
+        if stripped:
+            last_code_line = i
+
+    if definition is not None:
+        # A definition still open at the end of the file has a syntax error
+        definition[DEGRADED_KEY] = True
+
+    # Segments holding only blank lines and comments belong to the segment
+    # before them
+    def is_filler(first: int, end: int, payload: Dict[str, Any]) -> bool:
+        return all(not s.strip() for s in code_lines[first:end])
+
+    kept = keep_segments(segments, len(lines), is_filler, top_level)
+    return chunk_segments(text, kept, chunk_size, overlap_size)
@@ -0,0 +1,291 @@
+"""Item-aware chunking of Rust source files.
+
+A .rs file is split at its items: functions, structs, enums, unions,
+traits, impl blocks, type aliases and `macro_rules!` definitions. Items
+inside an inline `mod name { ... }` are split like top-level ones, and the
+module line forms a `module` chunk. Uses, constants, statics and other code
+between items form "file" chunks, and doc comments and attributes directly
+above an item belong to it. Each chunk records:
+
+- "symbol_kind": function, struct, enum, union, trait, impl, type, macro,
+  module or file
+- "symbol_name": the item name ("parse_args", "Point", "vec_of"); an impl
+  block is named by its trait and type ("fmt::Display for Point<T>") or by
+  its type alone
+- "rust_derives": the traits of the item's `#[derive(...)]` attributes,
+  e.g. ["Debug", "Clone", "serde::Serialize"]
+
+Strings, including raw and byte strings, character literals and comments
+are skipped when matching braces; lifetimes are not mistaken for
+characters. Items longer than the chunk size are split into overlapping
+windows like FixedSizeChunker does, and every window keeps these fields.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import (
+    DEGRADED_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    chunk_segments,
+    keep_segments,
+    leading_comments,
+)
+from .chunk_payload_keys import RUST_DERIVES_KEY
+
+RUST_LANGUAGES = {"rs"}
+
+_ITEM = re.compile(
+    r"^\s*(?:pub\s*(?:\([^)]*\)\s*)?)?"
+    r'(?:(?:default|const|async|unsafe|auto|extern\s*(?:""\s*)?)\s+)*'
+    r"(fn|struct|enum|union|trait|impl|mod|type|macro_rules!)"
+    r"\s*([A-Za-z_]\w*)?"
+)
+_KINDS = {
+    "fn": "function",
+    "mod": "module",
+    "macro_rules!": "macro",
+}
+_DERIVE = re.compile(r"\bderive\s*\(([^)]*)\)")
+# Attributes at the start of a line: #[derive(Debug)] #[serde(default)]
+_ATTRIBUTES = re.compile(r"^\s*(?:#\[[^\]]*\]\s*)+")
+# A character literal; 'a in <'a> and &'a str is a lifetime
+_CHAR = re.compile(r"'(?:\\(?:u\{[0-9A-Fa-f]+\}|x[0-9A-Fa-f]{2}|.)|[^'\\\n])'")
+_RAW_STRING = re.compile(r'b?r(#*)"')
+
+
+def is_rust(language: str) -> bool:
+    """Whether a language token is chunked by Rust items."""
+    return language.lower() in RUST_LANGUAGES
+
+
+def _rust_code(lines: List[str]) -> List[str]:
+    """Lines of a Rust file with comments, strings and characters blanked."""
+    code_lines = []
+    closer: Optional[str] = None  # Closing delimiter of an open string
+    raw = False
+    comment_depth = 0
+    for line in lines:
+        code = []
+        i = 0
+        while i < len(line):
+            if comment_depth:
+                if line.startswith("/*", i):
+                    comment_depth += 1
+                    i += 2
+                elif line.startswith("*/", i):
+                    comment_depth -= 1
+                    i += 2
+                else:
+                    i += 1
+                continue
+            if closer is not None:
+                if not raw and line[i] == "\\":
+                    i += 2
+                elif line.startswith(closer, i):
+                    i += len(closer)
+                    closer = None
+                    code.append('"')
+                else:
+                    i += 1
+                continue
+            if line.startswith("//", i):
+                break
+            if line.startswith("/*", i):
+                comment_depth = 1
+                i += 2
+                continue
+            previous = line[i - 1] if i else " "
+            raw_string = _RAW_STRING.match(line, i)
+            if raw_string and not (previous.isalnum() or previous == "_"):
+                closer = '"' + raw_string.group(1)
+                raw = True
+                code.append('"')
+                i = raw_string.end()
+                continue
+            if line[i] == '"':
+                closer, raw = '"', False
+                code.append('"')
+                i += 1
+                continue
+            char = _CHAR.match(line, i)
+            if char:
+                code.append("' '")
+                i = char.end()
+                continue
+            code.append(line[i])
+            i += 1
+        code_lines.append("".join(code))
+    return code_lines
+
+
+def _is_comment(line: str) -> bool:
+    return line.lstrip().startswith(("//", "/*", "*"))
+
+
+def _header(code_lines: List[str], i: int) -> str:
+    """Code of an item header from line i to its body or end."""
+    header = []
+    for code in code_lines[i : i + 10]:
+        header.append(code.strip())
+        if "{" in code or ";" in code:
+            break
+    return " ".join(header)
+
+
+def _impl_name(header: str) -> str:
+    """Trait and type of an impl block header, without its generics."""
+    rest = header.split("impl", 1)[1].lstrip()
+    if rest.startswith("<"):
+        depth = 0
+        for n, char in enumerate(rest):
+            depth += {"<": 1, ">": -1}.get(char, 0)
+            if depth == 0:
+                rest = rest[n + 1 :]
+                break
+    rest = re.split(r"\bwhere\b|\{", rest, maxsplit=1)[0]
+    return " ".join(rest.split())
+
+
+def _item(header: str) -> Optional[Dict[str, Any]]:
+    """Payload of the item starting a header, or None."""
+    item = _ITEM.match(header)
+    if item is None:
+        return None
+    keyword, name = item.groups()
+    if keyword == "mod" and "{" not in header:
+        return None  # "mod name;" declares a file module
+    if keyword == "impl":
+        name = _impl_name(header)
+    elif name is None:
+        return None
+    return {SYMBOL_KIND_KEY: _KINDS.get(keyword, keyword), SYMBOL_NAME_KEY: name}
+
+
+def _derives(attributes: str) -> List[str]:
+    """Traits listed by the derive attributes of an item."""
+    return [
+        "".join(trait.split())
+        for derive in _DERIVE.finditer(attributes)
+        for trait in derive.group(1).split(",")
+        if trait.strip()
+    ]
+
+
+def chunk_rust(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a Rust file into items.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long item
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the SYMBOL_*
+        and RUST_DERIVES_KEY fields; chunk_index, total_chunks and file
+        fields are left to the caller
+    """
+    lines = text.split("\n")
+    code_lines = _rust_code(lines)
+    top_level = {SYMBOL_KIND_KEY: "file"}
+    # (first line index, payload) of each segment
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    # Open inline modules, with the brace depth of their items
+    modules: List[Tuple[Dict[str, Any], int]] = [(top_level, 0)]
+    depth = brackets = 0
+    # The open item, and whether its body has started or a ";" ended it
+    item: Optional[Dict[str, Any]] = None
+    opened = ended = False
+    # First line and code of the attributes above the next item
+    attribute_start: Optional[int] = None
+    attributes: List[str] = []
+    last_code_line = -1
+
+    for i, code in enumerate(code_lines):
+        stripped = code.strip()
+        module, item_depth = modules[-1]
+        in_attribute = attribute_start is not None and brackets > 0
+        if item is None and depth == item_depth and stripped and not in_attribute:
+            header = _header(code_lines, i)
+            prefix = _ATTRIBUTES.match(header)
+            if stripped.startswith("#[") and (
+                prefix is None or not header[prefix.end() :].strip()
+            ):
+                if attribute_start is None:
+                    attribute_start = i
+                attributes.append(stripped)
+            else:
+                if prefix is not None:
+                    attributes.append(prefix.group())
+                    header = header[prefix.end() :]
+                payload = _item(header)
+                if payload is not None:
+                    derives = _derives(" ".join(attributes))
+                    if derives:
+                        payload[RUST_DERIVES_KEY] = derives
+                    first = leading_comments(
+                        lines,
+                        i if attribute_start is None else attribute_start,
+                        last_code_line,
+                        _is_comment,
+                    )
+                    segments.append((first, payload))
+                    item, opened, ended = payload, False, False
+                elif not segments or segments[-1][1] is not module:
+                    # Attributes above other code stay with it
+                    first = i if attribute_start is None else attribute_start
+                    segments.append((first, module))
+                attribute_start, attributes = None, []
+        elif in_attribute:
+            attributes.append(stripped)
+
+        for char in code:
+            if char == "{":
+                depth += 1
+                opened = True
+            elif char == "}":
+                depth = max(depth - 1, 0)
+                if len(modules) > 1 and depth < modules[-1][1]:
+                    modules.pop()
+                    # Code after a module continues its parent
+                    segments.append((i + 1, modules[-1][0]))
+            elif char in "([":
+                brackets += 1
+            elif char in ")]":
+                brackets = max(brackets - 1, 0)
+            elif char == ";" and depth == item_depth and brackets == 0:
+                ended = True
+
+        if (
+            item is not None
+            and item[SYMBOL_KIND_KEY] == "module"
+            and depth > item_depth
+        ):
+            # Items inside an inline module are split like top-level ones
+            modules.append((item, item_depth + 1))
+            item = None
+        elif item is not None and depth == item_depth and (opened or ended):
+            item = None
+            # Code after an item continues its module
+            segments.append((i + 1, modules[-1][0]))
+        if stripped and attribute_start is None:
+            last_code_line = i
+
+    if item is not None:
+        # An item still open at the end of the file has a syntax error
+        item[DEGRADED_KEY] = True
+
+    # Segments holding only blank lines, comments and closing brackets
+    # belong to the segment before them
+    def is_filler(first: int, end: int, payload: Dict[str, Any]) -> bool:
+        return all(
+            not s.strip() or s.strip() in ("}", "};", ");", "]", "];")
+            for s in code_lines[first:end]
+        )
+
+    kept = keep_segments(segments, len(lines), is_filler, top_level)
+    return chunk_segments(text, kept, chunk_size, overlap_size)
@@ -0,0 +1,323 @@
+"""Definition-aware chunking of Scala source files.
+
+A .scala file is split at its definitions: classes, traits, objects, enums,
+methods (`def`), type aliases (including Scala 3 opaque types), given
+instances and extension methods. The definitions inside a class, trait,
+object, enum or extension are split like top-level ones, and the container
+line with its fields forms a chunk of the container. Bodies in braces and
+Scala 3 indented bodies (`object Shapes:`, an `extension (c: Circle)` with
+its methods below it, `end Shapes`) are both followed. Imports, vals and
+other code between definitions form chunks of their container, and
+Scaladoc comments and annotations directly above a definition belong to it.
+Each chunk records:
+
+- "symbol_kind": class, trait, object, enum, function, type, given,
+  extension or file
+- "symbol_name": the definition name, qualified by its enclosing classes
+  and objects ("Shapes.sum"); an anonymous given is named by its type
+  ("Ordering[Circle]") and an extension by the type it extends ("Circle");
+  extension methods are qualified by the container of the extension
+
+Strings, including multi-line `\"\"\"` strings, character literals and
+nested comments are skipped when matching braces and indentation.
+Definitions longer than the chunk size are split into overlapping windows
+like FixedSizeChunker does, and every window keeps these fields.
+"""
+
+import re
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional, Set, Tuple
+
+from .boundary_chunking import (
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    chunk_segments,
+    keep_segments,
+    leading_comments,
+)
+
+SCALA_LANGUAGES = {"scala", "sc"}
+
+# Definitions whose members are split like top-level ones
+CONTAINER_KINDS = {"class", "trait", "object", "enum", "extension"}
+
+_MODIFIERS = (
+    r"(?:(?:private|protected)\s*(?:\[[^\]]*\])?|final|sealed|abstract|implicit"
+    r"|lazy|override|case|inline|open|transparent|infix|opaque)"
+)
+_DEFINITION = re.compile(
+    rf"^\s*(?:{_MODIFIERS}\s+)*(?:package\s+(?=object\b))?"
+    r"(class|trait|object|enum|def|type|given|extension)\b\s*"
+    r"(`[^`]+`|[A-Za-z_$][\w$]*(?:_[!#%&*+\-/:<=>?@\\^|~]+)?"
+    r"|[!#%&*+\-/:<=>?@\\^|~]+)?"
+)
+_KINDS = {"def": "function"}
+# Annotations at the start of a line: @tailrec @deprecated("", "")
+_ANNOTATIONS = re.compile(
+    r"^\s*(?:@[\w.]+(?:\[[^\]]*\])?(?:\([^)]*\))?\s*)+"
+)
+# A named given: given intOrdering: Ordering[Int]
+_NAMED_GIVEN = re.compile(
+    r"^\s*([A-Za-z_$][\w$]*)\s*(?:\[[^\]]*\]\s*)?(?:\([^)]*\)\s*)*:(?!\s*$)"
+)
+_CHAR = re.compile(r"'(?:\\(?:u[0-9A-Fa-f]{4}|.)|[^'\\\n])'")
+# Lines closing a body: }, ), end Shapes
+_CLOSING = re.compile(r"^(?:[})\]]+;?|end(?:\s+\S+)?)$")
+
+
+@dataclass
+class _Scope:
+    """An open container and where its members are."""
+
+    payload: Dict[str, Any]
+    # Qualifier of the names of its members ("Shapes.")
+    prefix: str
+    # Brace depth of its members
+    depth: int
+    # Indentation of its header, for a body without braces
+    header_indent: Optional[int] = None
+    # Indentation of its members, once the first one is seen
+    indent: Optional[int] = None
+
+
+def is_scala(language: str) -> bool:
+    """Whether a language token is chunked by Scala definitions."""
+    return language.lower() in SCALA_LANGUAGES
+
+
+def _scala_code(lines: List[str]) -> Tuple[List[str], Set[int]]:
+    """
+    Lines of a Scala file with comments, strings and characters blanked,
+    and the indexes of the lines that start inside a string or comment.
+    """
+    code_lines = []
+    continued: Set[int] = set()
+    closer: Optional[str] = None  # Closing delimiter of an open string
+    comment_depth = 0
+    for n, line in enumerate(lines):
+        if closer is not None or comment_depth:
+            continued.add(n)
+        code = []
+        i = 0
+        while i < len(line):
+            if comment_depth:
+                if line.startswith("/*", i):
+                    comment_depth += 1
+                    i += 2
+                elif line.startswith("*/", i):
+                    comment_depth -= 1
+                    i += 2
+                else:
+                    i += 1
+                continue
+            if closer is not None:
+                if closer == '"' and line[i] == "\\":
+                    i += 2
+                elif line.startswith(closer, i):
+                    i += len(closer)
+                    closer = None
+                    code.append('"')
+                else:
+                    i += 1
+                continue
+            if line.startswith("//", i):
+                break
+            if line.startswith("/*", i):
+                comment_depth = 1
+                i += 2
+                continue
+            char = _CHAR.match(line, i)
+            if char:
+                code.append("' '")
+                i = char.end()
+                continue
+            if line[i] == '"':
+                closer = '"""' if line.startswith('"""', i) else '"'
+                code.append('"')
+                i += len(closer)
+                continue
+            code.append(line[i])
+            i += 1
+        if closer == '"':
+            closer = None  # Only triple-quoted strings span lines
+        code_lines.append("".join(code))
+    return code_lines, continued
+
+
+def _is_comment(line: str) -> bool:
+    return line.lstrip().startswith(("//", "/*", "*"))
+
+
+def _clauses(text: str) -> Tuple[List[str], str]:
+    """Leading [...] and (...) clauses of a header, and the text after them."""
+    clauses = []
+    rest = text.lstrip()
+    while rest[:1] in ("[", "("):
+        depth = 0
+        for n, char in enumerate(rest):
+            depth += {"[": 1, "(": 1, "]": -1, ")": -1}.get(char, 0)
+            if depth == 0:
+                break
+        clauses.append(rest[: n + 1])
+        rest = rest[n + 1 :].lstrip()
+    return clauses, rest
+
+
+def _given_type(header: str) -> Optional[str]:
+    """Type of an anonymous given: Ordering[Circle] in given Ordering[Circle]."""
+    # given [T](using Ordering[T]): Ordering[List[T]] with
+    _, rest = _clauses(header)
+    rest = rest.lstrip(" :=>")
+    rest = re.split(r"\bwith\b|=|\{|:\s*$", rest, maxsplit=1)[0]
+    return " ".join(rest.split()) or None
+
+
+def _extension_name(header: str) -> Optional[str]:
+    """Type extended by an extension: Circle in extension (c: Circle)."""
+    clauses, _ = _clauses(header)
+    for clause in clauses:
+        if clause.startswith("(") and ":" in clause:
+            return " ".join(clause[1:-1].split(":", 1)[1].split())
+    return None
+
+
+def _definition(code: str, prefix: str) -> Optional[Dict[str, Any]]:
+    """Payload of the definition starting a line, or None."""
+    definition = _DEFINITION.match(code)
+    if definition is None:
+        return None
+    keyword, name = definition.groups()
+    header = code[definition.end(1) :]
+    if keyword == "given":
+        named = _NAMED_GIVEN.match(header)
+        name = prefix + named.group(1) if named else _given_type(header)
+    elif keyword == "extension":
+        name = _extension_name(header)
+    elif name is None:
+        return None
+    else:
+        name = prefix + name.strip("`")
+    payload: Dict[str, Any] = {SYMBOL_KIND_KEY: _KINDS.get(keyword, keyword)}
+    if name:
+        payload[SYMBOL_NAME_KEY] = name
+    return payload
+
+
+def chunk_scala(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a Scala file into definitions.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long definition
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the SYMBOL_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    code_lines, continued = _scala_code(lines)
+    top_level = {SYMBOL_KIND_KEY: "file"}
+    # (first line index, payload) of each segment
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    scopes = [_Scope(top_level, "", 0)]
+    depth = brackets = 0
+    # A container whose header has been seen but not its body, with the
+    # brace depth and indentation of the header
+    pending: Optional[Tuple[Dict[str, Any], int, int]] = None
+    # First line of the annotations above the next definition
+    annotation_start: Optional[int] = None
+    last_code_line = -1
+
+    for i, code in enumerate(code_lines):
+        stripped = code.strip()
+        indent = len(code) - len(code.lstrip())
+        if stripped and brackets == 0 and i not in continued:
+            # A body without braces ends at the first line indented no
+            # deeper than its header
+            while (
+                scopes[-1].header_indent is not None
+                and depth == scopes[-1].depth
+                and indent <= scopes[-1].header_indent
+            ):
+                scopes.pop()
+            scope = scopes[-1]
+            if (
+                depth == scope.depth
+                and (scope.indent is None or indent <= scope.indent)
+                and not (pending is not None and stripped.startswith("{"))
+            ):
+                scope.indent = indent if scope.indent is None else scope.indent
+                pending = None
+                prefix = _ANNOTATIONS.match(code)
+                header = code[prefix.end() :] if prefix else code
+                if stripped.startswith("@") and (
+                    not header.strip() or code.count("(") > code.count(")")
+                ):
+                    if annotation_start is None:
+                        annotation_start = i
+                else:
+                    payload = _definition(header, scope.prefix)
+                    if payload is not None:
+                        first = leading_comments(
+                            lines,
+                            i if annotation_start is None else annotation_start,
+                            last_code_line,
+                            _is_comment,
+                        )
+                        segments.append((first, payload))
+                        if payload[SYMBOL_KIND_KEY] in CONTAINER_KINDS:
+                            pending = (payload, depth, indent)
+                    elif not segments or segments[-1][1] is not scope.payload:
+                        # Annotations above other code stay with it
+                        first = i if annotation_start is None else annotation_start
+                        segments.append((first, scope.payload))
+                    annotation_start = None
+
+        for char in code:
+            if char == "{":
+                depth += 1
+            elif char == "}":
+                depth = max(depth - 1, 0)
+                while len(scopes) > 1 and depth < scopes[-1].depth:
+                    scopes.pop()
+                    # Code after a container continues its parent
+                    segments.append((i + 1, scopes[-1].payload))
+            elif char in "([":
+                brackets += 1
+            elif char in ")]":
+                brackets = max(brackets - 1, 0)
+
+        if pending is not None and brackets == 0:
+            payload, header_depth, header_indent = pending
+            if payload[SYMBOL_KIND_KEY] == "extension":
+                prefix = scopes[-1].prefix
+            else:
+                prefix = payload[SYMBOL_NAME_KEY] + "."
+            ending = code.rstrip()
+            if depth > header_depth:
+                scopes.append(_Scope(payload, prefix, header_depth + 1))
+                pending = None
+            elif ending.endswith(":") or (
+                payload[SYMBOL_KIND_KEY] == "extension" and ending.endswith(")")
+            ):
+                # Members of a body without braces are indented below it
+                scopes.append(_Scope(payload, prefix, header_depth, header_indent))
+                pending = None
+        if stripped and annotation_start is None:
+            last_code_line = i
+
+    # Segments holding only blank lines, comments, closing braces and end
+    # markers belong to the segment before them
+    def is_filler(first: int, end: int, payload: Dict[str, Any]) -> bool:
+        return all(
+            not s.strip() or _CLOSING.match(s.strip())
+            for s in code_lines[first:end]
+        )
+
+    kept = keep_segments(segments, len(lines), is_filler, top_level)
+    return chunk_segments(text, kept, chunk_size, overlap_size)
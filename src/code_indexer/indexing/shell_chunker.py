@@ -0,0 +1,178 @@
+"""Function-aware chunking of shell scripts.
+
+Bash, zsh, ksh and POSIX sh scripts (by extension or, for extensionless
+scripts, by shebang; see language_detection) are split at their top-level
+function definitions ("deploy() {" and "function deploy {") and top-level
+case blocks (the command dispatch of most ops scripts). The commands
+between them form "script" chunks. Heredoc bodies, quoted strings and
+comments are skipped when looking for braces and "esac", so a function
+ends at its own closing brace. Comments directly above a function or case
+block belong to its chunk. Each chunk records:
+
+- "shell_block": script, function or case
+- "shell_function": the function name, for function chunks
+
+Functions longer than the chunk size are split into overlapping windows
+like FixedSizeChunker does, and every window keeps these fields.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import chunk_segments, keep_segments, leading_comments
+from .chunk_payload_keys import SHELL_BLOCK_KEY, SHELL_FUNCTION_KEY
+
+SHELL_LANGUAGES = {"sh", "bash", "zsh", "ksh"}
+
+_FUNCTION = re.compile(
+    r"^\s*(?:function\s+([\w.:-]+)\s*(?:\(\s*\))?|([\w.:-]+)\s*\(\s*\))\s*(\{)?"
+)
+_CASE = re.compile(r"^\s*case\s.*\bin\b")
+_HEREDOC = re.compile(r"<<(-?)\s*(?:'([^']+)'|\"([^\"]+)\"|\\?([\w.-]+))")
+_OPEN_BRACE = re.compile(r"(?:^|[\s;&|(])\{(?=\s|$)")
+_CLOSE_BRACE = re.compile(r"(?:^|[\s;&|])\}(?=[\s;&|)<>]|$)")
+_CASE_WORD = re.compile(r"(?:^|[\s;&|(])(case|esac)(?=[\s;&|)]|$)")
+
+
+def is_shell(language: str) -> bool:
+    """Whether a language token is chunked by shell functions."""
+    return language.lower() in SHELL_LANGUAGES
+
+
+class _Scanner:
+    """Tracks multi-line quotes and heredocs across the lines of a script."""
+
+    def __init__(self) -> None:
+        self.quote: Optional[str] = None
+        # (delimiter, leading tabs stripped) of the heredocs to read
+        self.heredocs: List[Tuple[str, bool]] = []
+
+    def code(self, line: str) -> str:
+        """Code of a line with strings, comments and heredoc bodies blanked."""
+        if self.heredocs:
+            delimiter, strip_tabs = self.heredocs[0]
+            body = line.lstrip("\t") if strip_tabs else line
+            if body.rstrip("\r") == delimiter:
+                self.heredocs.pop(0)
+            return ""
+        code = []
+        i = 0
+        while i < len(line):
+            char = line[i]
+            if self.quote is not None:
+                if char == "\\" and self.quote != "'":
+                    i += 2
+                    continue
+                if char == self.quote:
+                    self.quote = None
+                i += 1
+                continue
+            if char == "\\":
+                i += 2
+                continue
+            if line.startswith("<<<", i):
+                i += 3  # Here-string
+                continue
+            heredoc = _HEREDOC.match(line, i) if line.startswith("<<", i) else None
+            if heredoc:
+                delimiter = next(g for g in heredoc.groups()[1:] if g)
+                self.heredocs.append((delimiter, heredoc.group(1) == "-"))
+                code.append(" ")
+                i = heredoc.end()
+                continue
+            if char in "'\"`":
+                self.quote = char
+                code.append(" ")
+            elif char == "#" and (i == 0 or line[i - 1] in " \t;&|("):
+                break
+            else:
+                code.append(char)
+            i += 1
+        return "".join(code)
+
+
+def _is_comment(line: str) -> bool:
+    return line.lstrip().startswith("#")
+
+
+def chunk_shell(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a shell script into functions, case blocks and script commands.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long function
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the SHELL_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    scanner = _Scanner()
+    # (first line index, payload) of each segment
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    # Payload and nesting depth of the open function or case block
+    block: Optional[Dict[str, Any]] = None
+    depth = 0
+    waiting_for_brace = False
+    last_code_line = -1
+
+    def start_block(i: int, payload: Dict[str, Any]) -> None:
+        # Comments directly above the definition belong to it
+        first = leading_comments(lines, i, last_code_line, _is_comment)
+        segments.append((first, payload))
+
+    for i, line in enumerate(lines):
+        in_heredoc_or_quote = bool(scanner.heredocs) or scanner.quote is not None
+        code = scanner.code(line)
+        if block is None and not in_heredoc_or_quote:
+            function = _FUNCTION.match(code)
+            if function:
+                block = {
+                    SHELL_BLOCK_KEY: "function",
+                    SHELL_FUNCTION_KEY: function.group(1) or function.group(2),
+                }
+                start_block(i, block)
+                depth = 0
+                waiting_for_brace = function.group(3) is None
+                # Braces of the body only, from its opening brace on
+                code = "" if waiting_for_brace else code[function.start(3) :]
+            elif _CASE.match(code):
+                block = {SHELL_BLOCK_KEY: "case"}
+                start_block(i, block)
+                depth = 0
+            elif code.strip() and (
+                not segments or segments[-1][1][SHELL_BLOCK_KEY] != "script"
+            ):
+                segments.append((i, {SHELL_BLOCK_KEY: "script"}))
+        if block is not None:
+            if block[SHELL_BLOCK_KEY] == "case":
+                for word in _CASE_WORD.findall(code):
+                    depth += 1 if word == "case" else -1
+            else:
+                opens = len(_OPEN_BRACE.findall(code))
+                if waiting_for_brace and opens:
+                    waiting_for_brace = False
+                depth += opens - len(_CLOSE_BRACE.findall(code))
+            if depth <= 0 and not waiting_for_brace and not scanner.heredocs:
+                block = None
+                # Commands after the block start a new script segment
+                segments.append((i + 1, {SHELL_BLOCK_KEY: "script"}))
+        if code.strip():
+            last_code_line = i
+
+    # Script segments holding only blank lines and comments belong to the
+    # segment before them
+    def is_filler(first: int, end: int, payload: Dict[str, Any]) -> bool:
+        return payload[SHELL_BLOCK_KEY] == "script" and all(
+            not s.strip() or _is_comment(s) for s in lines[first:end]
+        )
+
+    kept = keep_segments(
+        segments, len(lines), is_filler, {SHELL_BLOCK_KEY: "script"}
+    )
+    return chunk_segments(text, kept, chunk_size, overlap_size)
@@ -0,0 +1,214 @@
+"""Declaration-aware chunking of Zig source files.
+
+A .zig file is split at its functions, container declarations
+(`const Point = struct { ... };` and the enum, union and opaque forms),
+error sets, tests and top-level `comptime` blocks. The functions declared
+inside a container are split like top-level ones, and the container line
+with its fields forms a chunk of the container. Imports and other
+declarations between them form "file" chunks, and doc comments directly
+above a declaration belong to it. Each chunk records:
+
+- "symbol_kind": function, struct, enum, union, opaque, error, test,
+  comptime or file
+- "symbol_name": the declaration name, qualified by its containers
+  ("Point.init"); a test is named by its description ("parses a header")
+
+Strings, multi-line `\\\\` string literals, character literals and
+comments are skipped when matching braces. Declarations longer than the
+chunk size are split into overlapping windows like FixedSizeChunker does,
+and every window keeps these fields.
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from .boundary_chunking import (
+    DEGRADED_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    chunk_segments,
+    keep_segments,
+    leading_comments,
+)
+
+ZIG_LANGUAGES = {"zig"}
+
+# Containers whose functions are split like top-level ones
+CONTAINER_KINDS = {"struct", "enum", "union", "opaque"}
+
+_FUNCTION = re.compile(
+    r'^\s*(?:pub\s+)?(?:(?:export|extern(?:\s*"")?|inline|noinline)\s+)*'
+    r"fn\s+([A-Za-z_]\w*)"
+)
+_CONTAINER = re.compile(
+    r"^\s*(?:pub\s+)?(?:const|var)\s+([A-Za-z_]\w*)\s*(?::[^=]*)?=\s*"
+    r"(?:(?:extern|packed)\s+)?(struct|enum|union|opaque|error)\b"
+)
+_TEST = re.compile(r'^\s*test\b\s*(?:"((?:[^"\\]|\\.)*)"|([A-Za-z_]\w*))?')
+_COMPTIME = re.compile(r"^\s*comptime\s*\{")
+_CHAR = re.compile(r"'(?:\\(?:u\{[0-9A-Fa-f]+\}|x[0-9A-Fa-f]{2}|.)|[^'\\])'")
+
+
+def is_zig(language: str) -> bool:
+    """Whether a language token is chunked by Zig declarations."""
+    return language.lower() in ZIG_LANGUAGES
+
+
+def _zig_code(lines: List[str]) -> List[str]:
+    """Lines of a Zig file with comments, strings and characters blanked."""
+    code_lines = []
+    for line in lines:
+        if line.lstrip().startswith("\\\\"):
+            code_lines.append("")  # A line of a multi-line string literal
+            continue
+        code = []
+        i = 0
+        while i < len(line):
+            if line.startswith("//", i):
+                break
+            if line[i] == '"':
+                i += 1
+                while i < len(line) and line[i] != '"':
+                    i += 2 if line[i] == "\\" else 1
+                code.append('""')
+                i += 1
+                continue
+            char = _CHAR.match(line, i)
+            if char:
+                code.append("' '")
+                i = char.end()
+                continue
+            code.append(line[i])
+            i += 1
+        code_lines.append("".join(code))
+    return code_lines
+
+
+def _is_comment(line: str) -> bool:
+    return line.lstrip().startswith("//")
+
+
+def _declaration(line: str, code: str) -> Optional[Dict[str, Any]]:
+    """Payload of the declaration starting a line, or None."""
+    function = _FUNCTION.match(code)
+    if function:
+        return {SYMBOL_KIND_KEY: "function", SYMBOL_NAME_KEY: function.group(1)}
+    container = _CONTAINER.match(code)
+    if container:
+        return {
+            SYMBOL_KIND_KEY: container.group(2),
+            SYMBOL_NAME_KEY: container.group(1),
+        }
+    if _TEST.match(code):
+        # The description is a string, blanked in the code
+        test = _TEST.match(line)
+        name = (test.group(1) or test.group(2)) if test else None
+        payload: Dict[str, Any] = {SYMBOL_KIND_KEY: "test"}
+        if name:
+            payload[SYMBOL_NAME_KEY] = name
+        return payload
+    if _COMPTIME.match(code):
+        return {SYMBOL_KIND_KEY: "comptime"}
+    return None
+
+
+def chunk_zig(
+    text: str, chunk_size: int, overlap_size: int
+) -> List[Dict[str, Any]]:
+    """
+    Split a Zig file into functions, containers, tests and comptime blocks.
+
+    Args:
+        text: File text
+        chunk_size: Maximum chunk size in characters
+        overlap_size: Overlap of the windows of a long declaration
+
+    Returns:
+        Chunk dicts with "text", "line_start", "line_end" and the SYMBOL_*
+        fields; chunk_index, total_chunks and file fields are left to the
+        caller
+    """
+    lines = text.split("\n")
+    code_lines = _zig_code(lines)
+    top_level = {SYMBOL_KIND_KEY: "file"}
+    # (first line index, payload) of each segment
+    segments: List[Tuple[int, Dict[str, Any]]] = []
+    # Open containers, with the brace depth of their declarations
+    containers: List[Tuple[Dict[str, Any], int]] = [(top_level, 0)]
+    depth = brackets = 0
+    # The open declaration, and whether its body has started or a ";" ended it
+    declaration: Optional[Dict[str, Any]] = None
+    opened = ended = False
+    last_code_line = -1
+
+    for i, code in enumerate(code_lines):
+        stripped = code.strip()
+        container, declaration_depth = containers[-1]
+        if (
+            declaration is None
+            and depth == declaration_depth
+            and brackets == 0
+            and stripped
+        ):
+            payload = _declaration(lines[i], code)
+            if payload is not None:
+                if container is not top_level and SYMBOL_NAME_KEY in payload:
+                    payload[SYMBOL_NAME_KEY] = (
+                        f"{container[SYMBOL_NAME_KEY]}.{payload[SYMBOL_NAME_KEY]}"
+                    )
+                first = leading_comments(lines, i, last_code_line, _is_comment)
+                segments.append((first, payload))
+                declaration, opened, ended = payload, False, False
+            elif not segments or segments[-1][1] is not container:
+                segments.append((i, container))
+
+        for char in code:
+            if char == "{":
+                depth += 1
+                opened = True
+            elif char == "}":
+                depth = max(depth - 1, 0)
+                if len(containers) > 1 and depth < containers[-1][1]:
+                    containers.pop()
+                    # Code after a container continues its parent
+                    segments.append((i + 1, containers[-1][0]))
+            elif char in "([":
+                brackets += 1
+            elif char in ")]":
+                brackets = max(brackets - 1, 0)
+            elif char == ";" and depth == declaration_depth and brackets == 0:
+                ended = True
+
+        if (
+            declaration is not None
+            and declaration[SYMBOL_KIND_KEY] in CONTAINER_KINDS
+            and depth > declaration_depth
+        ):
+            # Functions inside a container are split like top-level ones
+            containers.append((declaration, declaration_depth + 1))
+            declaration = None
+        elif (
+            declaration is not None
+            and depth == declaration_depth
+            and (opened or ended)
+        ):
+            declaration = None
+            # Code after a declaration continues its container
+            segments.append((i + 1, containers[-1][0]))
+        if stripped:
+            last_code_line = i
+
+    if declaration is not None:
+        # A declaration still open at the end of the file has a syntax error
+        declaration[DEGRADED_KEY] = True
+
+    # Segments holding only blank lines, comments and closing braces belong
+    # to the segment before them
+    def is_filler(first: int, end: int, payload: Dict[str, Any]) -> bool:
+        return all(
+            not s.strip() or s.strip() in ("}", "};", "},")
+            for s in code_lines[first:end]
+        )
+
+    kept = keep_segments(segments, len(lines), is_filler, top_level)
+    return chunk_segments(text, kept, chunk_size, overlap_size)
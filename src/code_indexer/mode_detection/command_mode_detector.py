@@ -6,11 +6,38 @@ based on configuration file presence and validation.
 
 import json
 import logging
+import threading
 from pathlib import Path
-from typing import Literal, Optional
+from typing import Dict, Literal, Optional, Tuple
 
 logger = logging.getLogger(__name__)
 
+Mode = Literal["local", "remote", "proxy", "uninitialized"]
+
+# File state (mtime_ns, size) or None when missing
+_FileSignature = Optional[Tuple[int, int]]
+_DetectionSignature = Tuple[_FileSignature, _FileSignature, _FileSignature]
+
+# Detection results per config directory. A single CLI invocation runs mode
+# detection several times (group callback, require_mode, daemon delegation);
+# entries are only reused while the config files are unchanged on disk.
+_detection_cache: Dict[Path, Tuple[_DetectionSignature, Mode, bool]] = {}
+_detection_cache_lock = threading.Lock()
+
+
+def _file_signature(path: Path) -> _FileSignature:
+    try:
+        stat = path.stat()
+    except OSError:
+        return None
+    return (stat.st_mtime_ns, stat.st_size)
+
+
+def clear_mode_detection_cache() -> None:
+    """Forget cached mode detection results (e.g. after init or link)."""
+    with _detection_cache_lock:
+        _detection_cache.clear()
+
 
 class ModeDetectionError(Exception):
     """Exception raised when mode detection fails."""
@@ -61,7 +88,7 @@ class CommandModeDetector:
         self.config_dir = project_root / ".code-indexer" if project_root else None
         self._is_proxy_mode = False
 
-    def detect_mode(self) -> Literal["local", "remote", "proxy", "uninitialized"]:
+    def detect_mode(self) -> Mode:
         """Detect current operational mode based on configuration files.
 
         Detection priority:
@@ -70,6 +97,9 @@ class CommandModeDetector:
         3. Local config (config.json) if valid
         4. Uninitialized if no valid configuration found
 
+        Results are cached per config directory until any of the config files
+        change on disk.
+
         Returns:
             Mode string: "local", "remote", "proxy", or "uninitialized"
         """
@@ -77,6 +107,26 @@ class CommandModeDetector:
         if not self.config_dir or not self.config_dir.exists():
             return "uninitialized"
 
+        signature = (
+            _file_signature(self.config_dir),
+            _file_signature(self.config_dir / ".remote-config"),
+            _file_signature(self.config_dir / "config.json"),
+        )
+        with _detection_cache_lock:
+            cached = _detection_cache.get(self.config_dir)
+        if cached is not None and cached[0] == signature:
+            _, mode, self._is_proxy_mode = cached
+            return mode
+
+        mode = self._detect_mode_uncached()
+        with _detection_cache_lock:
+            _detection_cache[self.config_dir] = (signature, mode, self._is_proxy_mode)
+        return mode
+
+    def _detect_mode_uncached(self) -> Mode:
+        """Detect mode by reading and validating configuration files."""
+        assert self.config_dir is not None
+
         # Check for remote configuration first (higher priority)
         remote_config_path = self.config_dir / ".remote-config"
         if remote_config_path.exists():
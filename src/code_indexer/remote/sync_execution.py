@@ -6,7 +6,7 @@ through the CIDX server with job tracking and progress reporting.
 
 import logging
 from pathlib import Path
-from typing import List, Optional, Callable, Any, cast
+from typing import Dict, List, Optional, Callable, Any, cast
 from dataclasses import dataclass
 
 from ..api_clients.base_client import (
@@ -123,6 +123,50 @@ class SyncClient(CIDXRemoteAPIClient):
                 raise
             raise RemoteSyncExecutionError(f"Unexpected error during sync: {e}")
 
+    async def get_delta_base(self, repo_alias: str) -> Dict[str, Any]:
+        """Get the server index state an index delta must be built against.
+
+        Args:
+            repo_alias: Activated repository alias
+
+        Returns:
+            Dict with collection_name, vector_size, indexed_commit and branch
+
+        Raises:
+            APIClientError: If the repository cannot receive deltas
+        """
+        response = await self._authenticated_request(
+            "GET", f"/api/repositories/{repo_alias}/sync/delta-base"
+        )
+        return cast(Dict[str, Any], response.json())
+
+    async def upload_index_delta(
+        self, repo_alias: str, delta_bytes: bytes, timeout: int = 300
+    ) -> Dict[str, Any]:
+        """Upload a serialized index delta for the server to apply.
+
+        Args:
+            repo_alias: Activated repository alias
+            delta_bytes: Output of IndexDelta.to_bytes()
+            timeout: Request timeout in seconds
+
+        Returns:
+            Dict with indexed_commit and point counts
+
+        Raises:
+            APIClientError: If the server rejects the delta (e.g. stale base)
+        """
+        from ..sync.index_delta import DELTA_MEDIA_TYPE
+
+        response = await self._authenticated_request(
+            "POST",
+            f"/api/repositories/{repo_alias}/sync/delta",
+            content=delta_bytes,
+            headers={"Content-Type": DELTA_MEDIA_TYPE},
+            timeout=timeout,
+        )
+        return cast(Dict[str, Any], response.json())
+
     async def sync_all_repositories(
         self,
         force_sync: bool = False,
@@ -196,6 +240,7 @@ async def execute_repository_sync(
     timeout: int = 300,
     enable_polling: bool = True,
     progress_callback: Optional[Callable] = None,
+    delta: bool = False,
 ) -> List[SyncJobResult]:
     """Execute repository synchronization with the remote server.
 
@@ -209,6 +254,9 @@ async def execute_repository_sync(
         timeout: Job timeout in seconds
         enable_polling: Enable job polling for progress updates
         progress_callback: Callback for progress updates (current, total, path, info)
+        delta: Upload an index delta built from the local index instead of
+            having the server re-index; falls back to a sync job if the delta
+            cannot be built or is rejected
 
     Returns:
         List of sync job results
@@ -313,6 +361,13 @@ async def execute_repository_sync(
                             "Use 'cidx link' to link this repository or specify a repository alias."
                         )
 
+                if delta:
+                    delta_result = await _try_delta_sync(
+                        sync_client, repo_name, project_root, timeout, progress_callback
+                    )
+                    if delta_result is not None:
+                        return [delta_result]
+
                 result = await sync_client.sync_repository(
                     repo_alias=repo_name,
                     force_sync=False,
@@ -347,6 +402,81 @@ async def execute_repository_sync(
         raise RemoteSyncExecutionError(f"Sync execution failed: {e}")
 
 
+async def _try_delta_sync(
+    sync_client: SyncClient,
+    repo_name: str,
+    project_root: Path,
+    timeout: int,
+    progress_callback: Optional[Callable] = None,
+) -> Optional[SyncJobResult]:
+    """Sync by uploading an index delta; None means fall back to a sync job."""
+    from ..sync.index_delta import (
+        DELTA_MAX_UPLOAD_BYTES,
+        IndexDeltaError,
+        build_index_delta,
+    )
+
+    def report(message: str) -> None:
+        logger.info(message)
+        if progress_callback:
+            progress_callback(0, 0, Path(""), info=message)
+
+    try:
+        base = await sync_client.get_delta_base(repo_name)
+        if not base.get("indexed_commit"):
+            report("Server index has no indexed commit, using server-side sync")
+            return None
+
+        index_delta = build_index_delta(
+            project_root, base["indexed_commit"], base["collection_name"]
+        )
+        if index_delta.vector_size != base["vector_size"]:
+            raise IndexDeltaError(
+                f"Vector size mismatch: local {index_delta.vector_size}, "
+                f"server {base['vector_size']}"
+            )
+        if index_delta.target_commit == index_delta.base_commit:
+            return SyncJobResult(
+                job_id="",
+                status="completed",
+                message=f"Index already at {index_delta.target_commit[:12]}",
+                repository=repo_name,
+            )
+
+        delta_bytes = index_delta.to_bytes()
+        if len(delta_bytes) > DELTA_MAX_UPLOAD_BYTES:
+            raise IndexDeltaError(
+                f"Index delta of {len(delta_bytes)} bytes exceeds the server "
+                f"limit of {DELTA_MAX_UPLOAD_BYTES} bytes"
+            )
+        report(
+            f"Uploading index delta: {len(index_delta.upserts)} chunks, "
+            f"{len(index_delta.deleted_paths)} removed files "
+            f"({len(delta_bytes) / 1024:.1f} KB)"
+        )
+        applied = await sync_client.upload_index_delta(
+            repo_name, delta_bytes, timeout=timeout
+        )
+    except AuthenticationError:
+        raise
+    except Exception as e:
+        # IndexDeltaError, APIClientError (e.g. 409 stale base) or network errors
+        report(f"Index delta sync unavailable ({e}), using server-side sync")
+        return None
+
+    return SyncJobResult(
+        job_id="",
+        status="completed",
+        message=(
+            f"Index delta applied at {applied['indexed_commit'][:12]}: "
+            f"{applied['points_upserted']} chunks updated, "
+            f"{applied['points_deleted']} removed "
+            f"({applied['elapsed_seconds']:.1f}s on server)"
+        ),
+        repository=repo_name,
+    )
+
+
 async def _poll_sync_jobs(
     sync_client: "SyncClient",
     results: List[SyncJobResult],
@@ -5,7 +5,6 @@ re-authentication fallback, and comprehensive security features.
 """
 
 import json
-import fcntl
 import secrets
 import threading
 import time
@@ -16,6 +15,7 @@ from typing import Optional
 
 import jwt as jose_jwt
 
+from ..utils.file_lock import lock_file, unlock_file
 from .credential_manager import ProjectCredentialManager
 from .exceptions import RemoteConfigurationError
 
@@ -307,7 +307,7 @@ class PersistentTokenManager:
 
         while True:
             try:
-                fcntl.flock(file_handle.fileno(), fcntl.LOCK_EX | fcntl.LOCK_NB)
+                lock_file(file_handle, blocking=False)
                 return  # Lock acquired successfully
             except BlockingIOError:
                 # Lock not available, check timeout
@@ -328,7 +328,7 @@ class PersistentTokenManager:
             file_handle: Open file handle
         """
         try:
-            fcntl.flock(file_handle.fileno(), fcntl.LOCK_UN)
+            unlock_file(file_handle)
         except Exception:
             # Ignore unlock errors - lock will be released when file closes
             pass
@@ -1266,6 +1266,121 @@ def get_dependents(
     return results
 
 
+# Symbols bound per IN (...) list, below SQLite's parameter limit
+_MAX_IN_PARAMETERS = 500
+
+
+def get_call_edges(
+    conn: sqlite3.Connection,
+    symbol_ids: List[int],
+    direction: str = "callers",
+) -> List[Dict[str, Any]]:
+    """
+    Direct callers or callees of symbols, from the call_graph table.
+
+    Import and write edges are not calls and are skipped.
+
+    Args:
+        conn: SQLite database connection
+        symbol_ids: IDs of the symbols whose calls to follow
+        direction: "callers" (symbols calling them) or "callees" (symbols
+            they call)
+
+    Returns:
+        List of dictionaries with keys:
+            - from_id: ID of the given symbol
+            - to_id: ID of the caller (callers) or callee (callees)
+            - relationship: Relationship type (calls, reference)
+            - call_line: Line of the call in the caller (0-indexed), if known
+    """
+    if direction not in ("callers", "callees"):
+        raise ValueError(f"Direction must be callers or callees, got {direction}")
+    from_column, to_column = (
+        ("callee_symbol_id", "caller_symbol_id")
+        if direction == "callers"
+        else ("caller_symbol_id", "callee_symbol_id")
+    )
+
+    cursor = conn.cursor()
+    edges = []
+    for start in range(0, len(symbol_ids), _MAX_IN_PARAMETERS):
+        batch = symbol_ids[start : start + _MAX_IN_PARAMETERS]
+        placeholders = ",".join("?" * len(batch))
+        cursor.execute(
+            f"""
+            SELECT cg.{from_column}, cg.{to_column}, cg.relationship, co.start_line
+            FROM call_graph cg
+            LEFT JOIN occurrences co ON co.id = cg.occurrence_id
+            WHERE cg.{from_column} IN ({placeholders})
+                AND (cg.relationship IS NULL
+                     OR cg.relationship NOT IN ('import', 'write'))
+            ORDER BY cg.{from_column}, co.start_line
+            """,
+            batch,
+        )
+        edges.extend(
+            {
+                "from_id": row[0],
+                "to_id": row[1],
+                "relationship": row[2],
+                "call_line": row[3],
+            }
+            for row in cursor.fetchall()
+        )
+    return edges
+
+
+def get_symbol_locations(
+    conn: sqlite3.Connection, symbol_ids: List[int]
+) -> Dict[int, Dict[str, Any]]:
+    """
+    Names and definition locations of symbols.
+
+    Args:
+        conn: SQLite database connection
+        symbol_ids: Symbol IDs
+
+    Returns:
+        Dictionary of symbol ID to a dictionary with keys:
+            - symbol_name: Full SCIP symbol identifier
+            - kind: Symbol kind (Class, Method, etc.)
+            - file_path: Relative path of the definition (None if not
+              defined in the index)
+            - line: Line of the definition (0-indexed), or None
+            - column: Column of the definition (0-indexed), or None
+    """
+    cursor = conn.cursor()
+    locations: Dict[int, Dict[str, Any]] = {}
+    for start in range(0, len(symbol_ids), _MAX_IN_PARAMETERS):
+        batch = symbol_ids[start : start + _MAX_IN_PARAMETERS]
+        placeholders = ",".join("?" * len(batch))
+        cursor.execute(
+            f"""
+            SELECT s.id, s.name, s.kind, d.relative_path, o.start_line, o.start_char
+            FROM symbols s
+            LEFT JOIN occurrences o
+                ON o.symbol_id = s.id AND (o.role & ?) = ?
+            LEFT JOIN documents d ON d.id = o.document_id
+            WHERE s.id IN ({placeholders})
+            ORDER BY s.id, d.relative_path, o.start_line
+            """,
+            [ROLE_DEFINITION, ROLE_DEFINITION, *batch],
+        )
+        for row in cursor.fetchall():
+            # First definition of each symbol
+            locations.setdefault(
+                row[0],
+                {
+                    "symbol_name": row[1],
+                    "kind": row[2],
+                    "file_path": row[3],
+                    "line": row[4],
+                    "column": row[5],
+                },
+            )
+    return locations
+
+
 def analyze_impact(
     conn: sqlite3.Connection,
     symbol_id: int,
@@ -1,9 +1,10 @@
 """SCIP query backend abstraction layer."""
 
+import re
 from abc import ABC, abstractmethod
-from dataclasses import dataclass
+from dataclasses import asdict, dataclass, field
 from pathlib import Path
-from typing import Dict, List, Optional
+from typing import Any, Dict, List, Optional, Set, Tuple
 
 try:
     from pysqlite3 import dbapi2 as sqlite3
@@ -31,6 +32,32 @@ class CallChain:
     has_cycle: bool  # True if path contains cycle
 
 
+@dataclass
+class CallHierarchyNode:
+    """A symbol of a call hierarchy, with its callers or callees as children."""
+
+    symbol: str
+    file_path: Optional[str]  # Definition file, None if not defined in the index
+    line: Optional[int]  # Definition line (1-indexed)
+    relationship: Optional[str] = None  # Edge to the parent: calls, reference
+    call_lines: List[int] = field(
+        default_factory=list
+    )  # Lines (1-indexed) of the calls, in the calling symbol's file
+    recursive: bool = False  # Already an ancestor, so not expanded again
+    truncated: bool = False  # Children left out at the node limit
+    children: List["CallHierarchyNode"] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+# Nodes of a call hierarchy before it is truncated
+MAX_HIERARCHY_NODES = 500
+
+# SCIP descriptors of methods/functions (name().) and types (Name#)
+_CALLABLE_SYMBOL = re.compile(r"(\)\.|#)$")
+
+
 class SCIPBackend(ABC):
     """Abstract base class for SCIP query backends."""
 
@@ -131,6 +158,30 @@ class SCIPBackend(ABC):
         """
         pass
 
+    @abstractmethod
+    def call_hierarchy(
+        self,
+        symbol: str,
+        direction: str = "callers",
+        depth: int = 3,
+        exact: bool = False,
+        max_nodes: int = MAX_HIERARCHY_NODES,
+    ) -> List[CallHierarchyNode]:
+        """
+        Build the tree of callers or callees of a symbol.
+
+        Args:
+            symbol: Symbol name
+            direction: "callers" or "callees"
+            depth: Levels of the tree below the symbol (1-10, default 3)
+            exact: If True, match exact symbol name; if False, match substring
+            max_nodes: Nodes of the tree before it is truncated
+
+        Returns:
+            One tree per definition of the symbol
+        """
+        pass
+
 
 class DatabaseBackend(SCIPBackend):
     """SQLite database backend for SCIP queries."""
@@ -616,3 +667,97 @@ class DatabaseBackend(SCIPBackend):
                 unique_chains.append(chain)
 
         return sorted(unique_chains, key=lambda c: c.length)[:limit]
+
+    def call_hierarchy(
+        self,
+        symbol: str,
+        direction: str = "callers",
+        depth: int = 3,
+        exact: bool = False,
+        max_nodes: int = MAX_HIERARCHY_NODES,
+    ) -> List[CallHierarchyNode]:
+        """Build call hierarchy trees level by level from the call_graph table."""
+        from ..database.queries import get_call_edges, get_symbol_locations
+
+        if depth < 1 or depth > 10:
+            raise ValueError(f"Depth must be between 1 and 10, got {depth}")
+        if direction not in ("callers", "callees"):
+            raise ValueError(f"Direction must be callers or callees, got {direction}")
+
+        cursor = self.conn.cursor()
+        root_ids: List[int] = []
+        for defn in self.find_definition(symbol, exact=exact):
+            cursor.execute("SELECT id FROM symbols WHERE name = ?", (defn.symbol,))
+            row = cursor.fetchone()
+            if row is not None and row[0] not in root_ids:
+                root_ids.append(row[0])
+        locations = get_symbol_locations(self.conn, root_ids)
+
+        roots = [_hierarchy_node(locations[i]) for i in root_ids if i in locations]
+        # Nodes to expand: node, its symbol ID and the IDs of its ancestors
+        frontier: List[Tuple[CallHierarchyNode, int, Set[int]]] = [
+            (node, i, {i}) for node, i in zip(roots, root_ids)
+        ]
+        node_count = len(roots)
+
+        for _ in range(depth):
+            if not frontier:
+                break
+            edges = get_call_edges(
+                self.conn, list({i for _, i, _ in frontier}), direction
+            )
+            edges_from: Dict[int, List[Dict[str, Any]]] = {}
+            for edge in edges:
+                edges_from.setdefault(edge["from_id"], []).append(edge)
+            new_ids = {edge["to_id"] for edge in edges} - set(locations)
+            locations.update(get_symbol_locations(self.conn, list(new_ids)))
+
+            next_frontier = []
+            for node, symbol_id, ancestors in frontier:
+                children: Dict[int, CallHierarchyNode] = {}
+                for edge in edges_from.get(symbol_id, []):
+                    target_id = edge["to_id"]
+                    info = locations.get(target_id)
+                    if info is None or not _is_callable(info):
+                        continue
+                    child = children.get(target_id)
+                    if child is None:
+                        if node_count >= max_nodes:
+                            node.truncated = True
+                            continue
+                        child = _hierarchy_node(info, edge["relationship"])
+                        child.recursive = target_id in ancestors
+                        children[target_id] = child
+                        node.children.append(child)
+                        node_count += 1
+                        if not child.recursive:
+                            next_frontier.append(
+                                (child, target_id, ancestors | {target_id})
+                            )
+                    call_line = edge["call_line"]
+                    if call_line is not None and call_line + 1 not in child.call_lines:
+                        child.call_lines.append(call_line + 1)
+            frontier = next_frontier
+
+        return roots
+
+
+def _is_callable(info: Dict[str, Any]) -> bool:
+    """Whether a symbol can call or be called (not a local, field or module)."""
+    name = info["symbol_name"]
+    if info.get("kind") in ("Local", "Parameter") or name.startswith("local "):
+        return False
+    return _CALLABLE_SYMBOL.search(name) is not None
+
+
+def _hierarchy_node(
+    info: Dict[str, Any], relationship: Optional[str] = None
+) -> CallHierarchyNode:
+    """Call hierarchy node of a symbol (database lines are 0-indexed)."""
+    line = info.get("line")
+    return CallHierarchyNode(
+        symbol=info["symbol_name"],
+        file_path=info.get("file_path"),
+        line=line + 1 if line is not None else None,
+        relationship=relationship,
+    )
@@ -7,7 +7,7 @@ from typing import List, Optional, Tuple, TYPE_CHECKING
 from .loader import SCIPLoader
 
 if TYPE_CHECKING:
-    from .backends import CallChain, DatabaseBackend
+    from .backends import CallChain, CallHierarchyNode, DatabaseBackend
 
 
 @dataclass
@@ -231,6 +231,26 @@ class SCIPQueryEngine:
         )
         self.db_conn = self.backend.conn
 
+    @staticmethod
+    def index_files(project_root: Path) -> List[Path]:
+        """
+        SCIP databases of a project, sorted by path.
+
+        Generated by 'cidx scip generate' under .code-indexer/scip, one per
+        discovered (sub)project. A .scip file without its .scip.db cannot be
+        queried and is not listed.
+
+        Args:
+            project_root: Root of the indexed project
+
+        Returns:
+            Paths of the .scip.db files (empty when SCIP was never generated)
+        """
+        scip_dir = Path(project_root) / ".code-indexer" / "scip"
+        if not scip_dir.is_dir():
+            return []
+        return sorted(scip_dir.glob("**/*.scip.db"))
+
     def find_definition(self, symbol: str, exact: bool = False) -> List[QueryResult]:
         """
         Find definition locations for a symbol.
@@ -356,3 +376,35 @@ class SCIPQueryEngine:
         return self.backend.trace_call_chain(
             from_symbol, to_symbol, max_depth=max_depth, limit=limit
         )
+
+    def call_hierarchy(
+        self,
+        symbol: str,
+        direction: str = "callers",
+        depth: int = 3,
+        exact: bool = False,
+    ) -> List["CallHierarchyNode"]:
+        """
+        Build the call hierarchy of a symbol: who calls it, or what it calls.
+
+        Each level of the tree holds the direct callers (or callees) of the
+        symbols of the level above. A symbol that is already one of its own
+        ancestors is marked recursive instead of being expanded again.
+
+        Args:
+            symbol: Symbol name (e.g., "authenticate", "UserService#save")
+            direction: "callers" (incoming calls) or "callees" (outgoing calls)
+            depth: Levels below the symbol (1-10, default 3)
+            exact: If True, match exact symbol name; if False, match substring
+
+        Returns:
+            One CallHierarchyNode tree per definition of the symbol
+
+        Example:
+            >>> engine = SCIPQueryEngine(scip_file)
+            >>> for root in engine.call_hierarchy("db_query", depth=2):
+            ...     print(root.symbol, [c.symbol for c in root.children])
+        """
+        return self.backend.call_hierarchy(
+            symbol, direction=direction, depth=depth, exact=exact
+        )
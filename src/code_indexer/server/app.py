@@ -17,6 +17,7 @@ from fastapi import (
     Query,
     Body,
 )
+from fastapi.concurrency import run_in_threadpool
 from fastapi.exceptions import RequestValidationError
 from fastapi.middleware.cors import CORSMiddleware
 from pydantic import BaseModel, Field, field_validator, model_validator
@@ -118,6 +119,7 @@ from .services.search_service import search_service
 from .services.health_service import health_service
 from .services.sqlite_log_handler import SQLiteLogHandler
 from .services.workspace_cleanup_service import WorkspaceCleanupService
+from .services.retention_service import RetentionService
 from .managers.composite_file_listing import _list_composite_files
 
 
@@ -752,6 +754,12 @@ class SemanticQueryRequest(BaseModel):
         description="Exclude files matching path pattern (e.g., '*/tests/*', '*.min.js')",
     )
 
+    # Filter expression of semantic search
+    filter: Optional[str] = Field(
+        None,
+        description="Filter expression, e.g. 'lang:go AND path:internal/** AND NOT generated'. Semantic search of the current code only.",
+    )
+
     # Accuracy profile (Story #503 Phase 1)
     accuracy: Literal["fast", "balanced", "high"] = Field(
         default="balanced",
@@ -985,6 +993,29 @@ class SemanticQueryResponse(BaseModel):
     )
 
 
+class QueryFeedbackRequest(BaseModel):
+    """Request model for relevance feedback on a query result."""
+
+    repository_alias: str = Field(
+        ..., min_length=1, max_length=255, description="Repository of the result"
+    )
+    file_path: str = Field(
+        ..., min_length=1, max_length=4096, description="File path of the result"
+    )
+    relevant: bool = Field(..., description="Whether the result was useful")
+    line_number: Optional[int] = Field(
+        None, ge=1, description="Line number of the result"
+    )
+    code_snippet: Optional[str] = Field(
+        None,
+        max_length=100000,
+        description="Code of the result; symbols defined in it share the vote",
+    )
+    query_text: Optional[str] = Field(
+        None, max_length=1000, description="Query that returned the result"
+    )
+
+
 class FTSResultItem(BaseModel):
     """Individual FTS (full-text search) result item (Story 5)."""
 
@@ -1224,6 +1255,28 @@ class RepositorySyncJobResponse(BaseModel):
     options: SyncJobOptions = Field(description="Sync job options")
 
 
+class IndexDeltaBaseResponse(BaseModel):
+    """Response model for the index state an index delta must be built against."""
+
+    repository_id: str = Field(description="Repository identifier")
+    collection_name: str = Field(description="Semantic collection to update")
+    vector_size: int = Field(description="Vector dimensions of the collection")
+    indexed_commit: Optional[str] = Field(description="Commit the index was built from")
+    branch: Optional[str] = Field(description="Branch the index was built from")
+
+
+class IndexDeltaApplyResponse(BaseModel):
+    """Response model for an applied index delta."""
+
+    repository_id: str = Field(description="Repository identifier")
+    collection_name: str = Field(description="Updated collection")
+    indexed_commit: str = Field(description="Commit the index now reflects")
+    points_upserted: int = Field(description="Chunks added or replaced")
+    points_deleted: int = Field(description="Chunks removed")
+    files_changed: int = Field(description="Files touched by the delta")
+    elapsed_seconds: float = Field(description="Server-side apply time")
+
+
 class GeneralRepositorySyncRequest(BaseModel):
     """Request model for general repository synchronization via repository alias."""
 
@@ -2013,6 +2066,35 @@ def create_app() -> FastAPI:
                 extra={"correlation_id": get_correlation_id()},
             )
 
+        # Startup: Structured JSON logs for log pipelines (json_logging_config)
+        json_logging = getattr(app.state, "json_logging_config", None)
+        if json_logging is not None and json_logging.enabled:
+            try:
+                from code_indexer.utils.json_logging import install_json_log_handler
+
+                json_log_file = Path(
+                    json_logging.log_file
+                    or Path(server_data_dir) / "logs" / "server.jsonl"
+                )
+                install_json_log_handler(
+                    json_log_file,
+                    level=getattr(logging, json_logging.level),
+                    max_bytes=json_logging.max_bytes,
+                    backup_count=json_logging.backup_count,
+                    static_fields={"component": "server"},
+                    context_provider=lambda: {"correlation_id": get_correlation_id()},
+                )
+                logger.info(
+                    f"JSON log handler initialized: {json_log_file}",
+                    extra={"correlation_id": get_correlation_id()},
+                )
+            except Exception as e:
+                logger.error(
+                    f"Failed to initialize JSON log handler: {e}",
+                    exc_info=True,
+                    extra={"correlation_id": get_correlation_id()},
+                )
+
         # Startup: Initialize SQLite database schema and run migrations (Story #702)
         logger.info(
             "Server startup: Initializing SQLite database schema",
@@ -2177,6 +2259,8 @@ def create_app() -> FastAPI:
             group_manager = GroupAccessManager(groups_db_path)
             set_group_manager(group_manager)
             app.state.group_manager = group_manager
+            if hasattr(app.state, "retention_service"):
+                app.state.retention_service.group_manager = group_manager
 
             logger.info(
                 f"GroupAccessManager initialized: {groups_db_path}",
@@ -2282,6 +2366,29 @@ def create_app() -> FastAPI:
                 extra={"correlation_id": get_correlation_id()},
             )
 
+        # Startup: Preload global repo indexes into caches (opt-in)
+        try:
+            from code_indexer.server.services.config_service import get_config_service
+
+            cache_config = get_config_service().get_config().cache_config
+            if cache_config is not None and cache_config.index_cache_preload_on_startup:
+                from code_indexer.server.cache.index_preloader import (
+                    start_index_preload,
+                )
+
+                logger.info(
+                    "Server startup: Preloading global repo indexes in background",
+                    extra={"correlation_id": get_correlation_id()},
+                )
+                start_index_preload(Path(golden_repos_dir), _server_hnsw_cache)
+        except Exception as e:
+            # Log error but don't block server startup
+            logger.error(
+                f"Failed to start index preload: {e}",
+                exc_info=True,
+                extra={"correlation_id": get_correlation_id()},
+            )
+
         # Startup: Initialize PayloadCache for semantic search result truncation (Story #679)
         payload_cache = None
         logger.info(
@@ -2527,8 +2634,24 @@ def create_app() -> FastAPI:
                 extra={"correlation_id": get_correlation_id()},
             )
 
+        # Startup: Start data retention (no-op unless retention_config.enabled)
+        retention_service = getattr(app.state, "retention_service", None)
+        if retention_service is not None:
+            try:
+                retention_service.start()
+            except Exception as e:
+                logger.error(
+                    f"Failed to start data retention: {e}",
+                    exc_info=True,
+                    extra={"correlation_id": get_correlation_id()},
+                )
+
         yield  # Server is now running
 
+        # Shutdown: Stop data retention
+        if retention_service is not None:
+            retention_service.stop()
+
         # Shutdown: Stop global repos background services BEFORE other cleanup
         logger.info(
             "Server shutdown: Stopping global repos background services",
@@ -2737,13 +2860,23 @@ def create_app() -> FastAPI:
         workspace_root="/tmp",  # Standard temp directory for SCIP workspaces
     )
 
+    # Initialize RetentionService for data retention policies; the audit log's
+    # GroupAccessManager is injected at startup and the service started there
+    retention_service = RetentionService(
+        config=server_config,
+        job_manager=background_job_manager,
+        golden_repos_dir=Path(golden_repo_manager.golden_repos_dir),
+    )
+
     # Store managers in app.state for access by routes
+    app.state.json_logging_config = server_config.json_logging_config
     app.state.golden_repo_manager = golden_repo_manager
     app.state.background_job_manager = background_job_manager
     app.state.activated_repo_manager = activated_repo_manager
     app.state.repository_listing_manager = repository_listing_manager
     app.state.semantic_query_manager = semantic_query_manager
     app.state.workspace_cleanup_service = workspace_cleanup_service
+    app.state.retention_service = retention_service
 
     # Initialize MCP credential manager
     from code_indexer.server.auth.mcp_credential_manager import MCPCredentialManager
@@ -3912,6 +4045,43 @@ def create_app() -> FastAPI:
                 detail=f"Workspace cleanup failed: {str(e)}",
             )
 
+    @app.post("/api/admin/retention/purge")
+    async def retention_purge(
+        dry_run: bool = True,
+        current_user: dependencies.User = Depends(dependencies.get_current_admin_user),
+    ):
+        """
+        Apply the data retention policies now.
+
+        Deletes golden repo index points, job records and audit log entries
+        past their retention_config windows. Defaults to a dry run that only
+        reports what would be deleted; pass dry_run=false to delete.
+
+        Args:
+            dry_run: Only report what would be deleted (default: true)
+            current_user: Current authenticated admin user
+
+        Returns:
+            Retention report with per-repo and per-record-type counts
+
+        Raises:
+            HTTPException: If the retention run fails
+        """
+        try:
+            report = app.state.retention_service.run(dry_run=dry_run)
+            return report.to_dict()
+
+        except Exception as e:
+            logger.error(
+                f"Data retention purge failed: {e}",
+                exc_info=True,
+                extra={"correlation_id": get_correlation_id()},
+            )
+            raise HTTPException(
+                status_code=status.HTTP_500_INTERNAL_SERVER_ERROR,
+                detail=f"Retention purge failed: {str(e)}",
+            )
+
     @app.get("/api/admin/scip-cleanup-status")
     async def get_scip_cleanup_status(
         current_user: dependencies.User = Depends(dependencies.get_current_admin_user),
@@ -5763,6 +5933,15 @@ def create_app() -> FastAPI:
         start_time = time.time()
 
         try:
+            # Filter expressions apply to synchronous semantic search only
+            if request.filter is not None and (
+                request.async_query or request.search_mode != "semantic"
+            ):
+                raise HTTPException(
+                    status_code=status.HTTP_400_BAD_REQUEST,
+                    detail="filter only supported for synchronous semantic search",
+                )
+
             # Handle background job submission (semantic mode only)
             if request.async_query:
                 if request.search_mode != "semantic":
@@ -6094,6 +6273,7 @@ def create_app() -> FastAPI:
                 diff_type=request.diff_type,
                 author=request.author,
                 chunk_type=request.chunk_type,
+                filter_expression=request.filter,
             )
 
             # Apply access filtering based on user's group membership (Story #707)
@@ -6169,6 +6349,46 @@ def create_app() -> FastAPI:
                 detail=f"Internal search error: {str(e)}",
             )
 
+    @app.post("/api/query/feedback")
+    async def query_feedback(
+        request: QueryFeedbackRequest,
+        current_user: dependencies.User = Depends(dependencies.get_current_user),
+    ):
+        """
+        Record whether a semantic query result was useful.
+
+        Judgments re-rank later semantic queries of the repository: results
+        in the same file, the same directory or defining the same symbols
+        move up (relevant) or down (irrelevant).
+
+        Args:
+            request: Judged result and verdict
+            current_user: Current authenticated user
+
+        Returns:
+            Recorded judgment and the file's resulting score adjustment
+
+        Raises:
+            HTTPException: If the repository is not found for the user
+        """
+        try:
+            return semantic_query_manager.record_feedback(
+                username=current_user.username,
+                repository_alias=request.repository_alias,
+                file_path=request.file_path,
+                relevant=request.relevant,
+                code_snippet=request.code_snippet,
+                line_number=request.line_number,
+                query_text=request.query_text,
+            )
+        except SemanticQueryError as e:
+            status_code = (
+                status.HTTP_404_NOT_FOUND
+                if "not found" in str(e).lower()
+                else status.HTTP_400_BAD_REQUEST
+            )
+            raise HTTPException(status_code=status_code, detail=str(e))
+
     @app.get("/api/repositories/{repo_id}")
     async def get_repository_details_v2(
         repo_id: str,
@@ -6818,6 +7038,199 @@ def create_app() -> FastAPI:
                 detail=f"Failed to submit sync job: {str(e)}",
             )
 
+    def _resolve_activated_repo_path(username: str, repo_id: str) -> Path:
+        """Path of a user's activated repository by user alias or golden alias."""
+        if activated_repo_manager:
+            activated_repos = activated_repo_manager.list_activated_repositories(
+                username
+            )
+            for repo in activated_repos:
+                if repo_id in (repo["user_alias"], repo["golden_repo_alias"]):
+                    return Path(
+                        activated_repo_manager.get_activated_repo_path(
+                            username=username, user_alias=repo["user_alias"]
+                        )
+                    )
+        raise HTTPException(
+            status_code=status.HTTP_404_NOT_FOUND,
+            detail=f"Activated repository '{repo_id}' not found",
+        )
+
+    @app.get(
+        "/api/repositories/{repo_id}/sync/delta-base",
+        response_model=IndexDeltaBaseResponse,
+    )
+    def get_index_delta_base(
+        repo_id: str,
+        current_user: dependencies.User = Depends(dependencies.get_current_user),
+    ):
+        """
+        Get the index state a client-built index delta must be based on.
+
+        Clients diff their own index against indexed_commit and upload the
+        result to POST /api/repositories/{repo_id}/sync/delta.
+
+        Raises:
+            HTTPException: 404 if the activated repository is not found,
+                409 if its index cannot receive deltas
+        """
+        from code_indexer.sync.index_delta import (
+            IndexDeltaError,
+            get_index_state,
+            get_semantic_collection_name,
+        )
+        from code_indexer.storage.filesystem_vector_store import (
+            FilesystemVectorStore,
+        )
+
+        cleaned_repo_id = repo_id.strip()
+        repo_path = _resolve_activated_repo_path(
+            current_user.username, cleaned_repo_id
+        )
+        CompositeRepoValidator.check_operation(repo_path, "sync")
+
+        try:
+            collection_name = get_semantic_collection_name(repo_path)
+        except IndexDeltaError as e:
+            raise HTTPException(status_code=status.HTTP_409_CONFLICT, detail=str(e))
+
+        state = get_index_state(repo_path)
+        vector_store = FilesystemVectorStore(
+            base_path=repo_path / ".code-indexer" / "index", project_root=repo_path
+        )
+        return IndexDeltaBaseResponse(
+            repository_id=cleaned_repo_id,
+            collection_name=collection_name,
+            vector_size=vector_store.get_collection_info(collection_name)[
+                "vector_size"
+            ],
+            indexed_commit=state["indexed_commit"],
+            branch=state["branch"],
+        )
+
+    @app.post(
+        "/api/repositories/{repo_id}/sync/delta",
+        response_model=IndexDeltaApplyResponse,
+    )
+    async def apply_repository_index_delta(
+        repo_id: str,
+        request: Request,
+        current_user: dependencies.User = Depends(dependencies.get_current_user),
+    ):
+        """
+        Apply a client-built index delta to an activated repository.
+
+        The request body is an index delta (see code_indexer.sync.index_delta)
+        built against the commit reported by the delta-base endpoint. Applying
+        it replaces server-side re-indexing: no embeddings are computed.
+
+        Raises:
+            HTTPException: 400 for a malformed delta, 404 if the activated
+                repository is not found, 409 if the delta does not match the
+                repository's index (stale base commit, different model) or the
+                repository is being indexed, 413 if the delta is larger than
+                DELTA_MAX_UPLOAD_BYTES
+        """
+        from code_indexer.sync.index_delta import (
+            DELTA_MAX_UPLOAD_BYTES,
+            IndexDelta,
+            IndexDeltaError,
+            apply_index_delta,
+        )
+        from code_indexer.services.indexing_lock import (
+            IndexingLockError,
+            create_indexing_lock,
+        )
+
+        cleaned_repo_id = repo_id.strip()
+
+        def resolve_repo_path() -> Path:
+            repo_path = _resolve_activated_repo_path(
+                current_user.username, cleaned_repo_id
+            )
+            CompositeRepoValidator.check_operation(repo_path, "sync")
+            return repo_path
+
+        repo_path = await run_in_threadpool(resolve_repo_path)
+
+        # Refuse oversized deltas before buffering them
+        too_large = HTTPException(
+            status_code=status.HTTP_413_REQUEST_ENTITY_TOO_LARGE,
+            detail=(
+                f"Index delta exceeds {DELTA_MAX_UPLOAD_BYTES} bytes - "
+                "sync the repository on the server instead"
+            ),
+        )
+        content_length = request.headers.get("content-length", "")
+        if content_length.isdigit() and int(content_length) > DELTA_MAX_UPLOAD_BYTES:
+            raise too_large
+        body = bytearray()
+        async for part in request.stream():
+            body.extend(part)
+            if len(body) > DELTA_MAX_UPLOAD_BYTES:
+                raise too_large
+
+        def apply_delta():
+            # Decoding and applying touch the filesystem and vector store, so
+            # they run in the threadpool rather than on the event loop
+            try:
+                delta = IndexDelta.from_bytes(bytes(body))
+            except IndexDeltaError as e:
+                raise HTTPException(
+                    status_code=status.HTTP_400_BAD_REQUEST, detail=str(e)
+                )
+
+            indexing_lock = create_indexing_lock(repo_path / ".code-indexer")
+            try:
+                indexing_lock.acquire(str(repo_path))
+            except IndexingLockError as e:
+                raise HTTPException(
+                    status_code=status.HTTP_409_CONFLICT, detail=str(e)
+                )
+
+            try:
+                return apply_index_delta(repo_path, delta)
+            except IndexDeltaError as e:
+                raise HTTPException(
+                    status_code=status.HTTP_409_CONFLICT, detail=str(e)
+                )
+            except Exception as e:
+                logging.error(
+                    f"Failed to apply index delta to {cleaned_repo_id}: {e}"
+                )
+                raise HTTPException(
+                    status_code=status.HTTP_500_INTERNAL_SERVER_ERROR,
+                    detail=f"Failed to apply index delta: {str(e)}",
+                )
+            finally:
+                indexing_lock.release()
+                if _server_hnsw_cache is not None:
+                    _server_hnsw_cache.invalidate(
+                        str(
+                            repo_path
+                            / ".code-indexer"
+                            / "index"
+                            / delta.collection_name
+                        )
+                    )
+
+        result = await run_in_threadpool(apply_delta)
+
+        logging.info(
+            f"Applied index delta to '{cleaned_repo_id}' for user "
+            f"'{current_user.username}': {result.points_upserted} chunks upserted, "
+            f"{result.points_deleted} deleted"
+        )
+        return IndexDeltaApplyResponse(
+            repository_id=cleaned_repo_id,
+            collection_name=result.collection_name,
+            indexed_commit=result.target_commit,
+            points_upserted=result.points_upserted,
+            points_deleted=result.points_deleted,
+            files_changed=result.files_changed,
+            elapsed_seconds=result.elapsed_seconds,
+        )
+
     # Repository Statistics Endpoint
     @app.get(
         "/api/repositories/{repo_id}/stats", response_model=RepositoryStatsResponse
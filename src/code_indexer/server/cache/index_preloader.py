@@ -0,0 +1,87 @@
+"""
+Startup preloading of global repo indexes into the server caches.
+
+Enabled by ``cache_config.index_cache_preload_on_startup`` (Web UI: Config >
+Cache). Runs in a background thread after startup so the server starts serving
+immediately; queries that arrive before a repo is warmed simply load it
+themselves as before.
+
+Warm-up is bounded by the HNSW cache TTL - indexes preloaded here are evicted
+like any other cache entry if no query touches them.
+"""
+
+import json
+import logging
+import threading
+from pathlib import Path
+from typing import Any, List
+
+from code_indexer.server.middleware.correlation import get_correlation_id
+
+logger = logging.getLogger(__name__)
+
+
+def get_global_repo_index_paths(golden_repos_dir: Path) -> List[Path]:
+    """Index roots that global repo aliases currently point to."""
+    aliases_dir = golden_repos_dir / "aliases"
+    if not aliases_dir.is_dir():
+        return []
+
+    index_paths: List[Path] = []
+    for alias_file in sorted(aliases_dir.glob("*.json")):
+        try:
+            with open(alias_file, "r") as f:
+                target_path = json.load(f).get("target_path")
+        except (json.JSONDecodeError, OSError) as e:
+            logger.warning(
+                f"Skipping unreadable alias {alias_file.name}: {e}",
+                extra={"correlation_id": get_correlation_id()},
+            )
+            continue
+        if target_path and Path(target_path).is_dir():
+            index_paths.append(Path(target_path))
+    return index_paths
+
+
+def preload_global_repo_indexes(golden_repos_dir: Path, hnsw_cache: Any) -> int:
+    """
+    Warm every global repo index into the HNSW cache and OS page cache.
+
+    Args:
+        golden_repos_dir: Server golden repos directory (contains aliases/)
+        hnsw_cache: Server HNSWIndexCache
+
+    Returns:
+        Number of repositories warmed
+    """
+    from code_indexer.services.index_warmup import warm_project_index
+
+    warmed = 0
+    for index_path in get_global_repo_index_paths(golden_repos_dir):
+        result = warm_project_index(index_path, hnsw_cache=hnsw_cache)
+        if result.collections:
+            warmed += 1
+    logger.info(
+        f"Index preload complete: {warmed} global repos warmed",
+        extra={"correlation_id": get_correlation_id()},
+    )
+    return warmed
+
+
+def start_index_preload(golden_repos_dir: Path, hnsw_cache: Any) -> threading.Thread:
+    """Run preload_global_repo_indexes() in a daemon thread."""
+
+    def run() -> None:
+        try:
+            preload_global_repo_indexes(golden_repos_dir, hnsw_cache)
+        except Exception as e:
+            # Preloading is an optimization - never take the server down
+            logger.error(
+                f"Index preload failed: {e}",
+                exc_info=True,
+                extra={"correlation_id": get_correlation_id()},
+            )
+
+    thread = threading.Thread(target=run, name="IndexPreload", daemon=True)
+    thread.start()
+    return thread
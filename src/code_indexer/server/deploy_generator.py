@@ -0,0 +1,423 @@
+"""
+Deployment artifact generation for CIDX Server.
+
+Renders a container image definition plus either a Docker Compose project
+or a Helm chart, parameterized from the server configuration. Vector
+indexes are stored on the filesystem inside the server data directory, so
+one persistent volume holds all server state and no separate vector store
+service is needed.
+"""
+
+import json
+import logging
+from dataclasses import asdict
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+import yaml  # type: ignore
+
+from .. import __version__
+from .utils.config_manager import ServerConfig
+
+logger = logging.getLogger(__name__)
+
+# Server home inside the container: the data volume is mounted at
+# <home>/.cidx-server, the default server directory
+CONTAINER_HOME = "/home/cidx"
+CONTAINER_SERVER_DIR = f"{CONTAINER_HOME}/.cidx-server"
+SEED_CONFIG_PATH = "/etc/cidx-server/config.json"
+
+# Unauthenticated endpoint used by health checks
+HEALTH_CHECK_PATH = "/docs"
+
+REPOSITORY_URL = "https://github.com/jsbattig/code-indexer.git"
+
+DOCKERFILE_TEMPLATE = """\
+# CIDX Server image
+# Generated by cidx server generate-deploy
+
+FROM python:3.11-slim
+
+# git is required to clone and refresh golden repositories
+RUN apt-get update \\
+    && apt-get install -y --no-install-recommends git \\
+    && rm -rf /var/lib/apt/lists/*
+
+RUN pip install --no-cache-dir "git+{repository_url}@v{version}"
+
+RUN useradd --create-home --home-dir {home} cidx \\
+    && mkdir -p {server_dir} \\
+    && chown cidx:cidx {server_dir}
+USER cidx
+ENV HOME={home} PYTHONUNBUFFERED=1
+WORKDIR {home}
+
+EXPOSE {port}
+"""
+
+ENV_TEMPLATE = """\
+# Environment of the CIDX Server container
+# Copy to .env and fill in before running docker compose up
+
+# VoyageAI API key for embedding generation (required)
+VOYAGE_API_KEY=
+
+# Public URL of the server, used as OAuth issuer (required behind a proxy)
+CIDX_ISSUER_URL={issuer_url}
+
+# Anthropic API key for AI features (optional)
+ANTHROPIC_API_KEY=
+"""
+
+HELM_DEPLOYMENT_TEMPLATE = """\
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+  labels:
+    app.kubernetes.io/name: cidx-server
+    app.kubernetes.io/instance: {{ .Release.Name }}
+spec:
+  # SQLite databases and file locks on the data volume allow one replica only
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: cidx-server
+      app.kubernetes.io/instance: {{ .Release.Name }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: cidx-server
+        app.kubernetes.io/instance: {{ .Release.Name }}
+      annotations:
+        checksum/config: {{ include (print $.Template.BasePath "/configmap.yaml") . | sha256sum }}
+    spec:
+      securityContext:
+        fsGroup: 1000
+      containers:
+        - name: cidx-server
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+          imagePullPolicy: {{ .Values.image.pullPolicy }}
+          command: ["sh", "-c"]
+          args:
+            - "%(start_command)s"
+          ports:
+            - name: http
+              containerPort: {{ .Values.service.port }}
+          env:
+            - name: CIDX_ISSUER_URL
+              value: {{ .Values.issuerUrl | quote }}
+          envFrom:
+            - secretRef:
+                name: {{ .Values.existingSecret | default .Release.Name }}
+          volumeMounts:
+            - name: data
+              mountPath: %(server_dir)s
+            - name: seed-config
+              mountPath: %(seed_dir)s
+              readOnly: true
+          startupProbe:
+            httpGet:
+              path: %(health_path)s
+              port: http
+            periodSeconds: 10
+            failureThreshold: 30
+          livenessProbe:
+            httpGet:
+              path: %(health_path)s
+              port: http
+            periodSeconds: 30
+            timeoutSeconds: 10
+          readinessProbe:
+            httpGet:
+              path: %(health_path)s
+              port: http
+            periodSeconds: 10
+          resources:
+            {{- toYaml .Values.resources | nindent 12 }}
+      volumes:
+        - name: data
+          persistentVolumeClaim:
+            claimName: {{ .Release.Name }}-data
+        - name: seed-config
+          configMap:
+            name: {{ .Release.Name }}-config
+"""
+
+HELM_SERVICE_TEMPLATE = """\
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Release.Name }}
+  labels:
+    app.kubernetes.io/name: cidx-server
+    app.kubernetes.io/instance: {{ .Release.Name }}
+spec:
+  type: {{ .Values.service.type }}
+  ports:
+    - name: http
+      port: {{ .Values.service.port }}
+      targetPort: http
+  selector:
+    app.kubernetes.io/name: cidx-server
+    app.kubernetes.io/instance: {{ .Release.Name }}
+"""
+
+HELM_PVC_TEMPLATE = """\
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: {{ .Release.Name }}-data
+  labels:
+    app.kubernetes.io/name: cidx-server
+    app.kubernetes.io/instance: {{ .Release.Name }}
+spec:
+  accessModes:
+    - ReadWriteOnce
+  {{- if .Values.persistence.storageClass }}
+  storageClassName: {{ .Values.persistence.storageClass }}
+  {{- end }}
+  resources:
+    requests:
+      storage: {{ .Values.persistence.size }}
+"""
+
+HELM_CONFIGMAP_TEMPLATE = """\
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-config
+  labels:
+    app.kubernetes.io/name: cidx-server
+    app.kubernetes.io/instance: {{ .Release.Name }}
+data:
+  config.json: |
+    {{- .Values.serverConfig | toPrettyJson | nindent 4 }}
+"""
+
+HELM_SECRET_TEMPLATE = """\
+{{- if not .Values.existingSecret }}
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Release.Name }}
+  labels:
+    app.kubernetes.io/name: cidx-server
+    app.kubernetes.io/instance: {{ .Release.Name }}
+type: Opaque
+stringData:
+  VOYAGE_API_KEY: {{ required "secrets.voyageApiKey is required" .Values.secrets.voyageApiKey | quote }}
+  ANTHROPIC_API_KEY: {{ .Values.secrets.anthropicApiKey | quote }}
+{{- end }}
+"""
+
+
+class DeploymentGenerator:
+    """
+    Generates deployment artifacts for the CIDX server.
+
+    The generated server configuration is seeded into the data volume on
+    first start only, so settings changed later through the web UI persist
+    across restarts and upgrades.
+    """
+
+    def __init__(self, config: ServerConfig, issuer_url: Optional[str] = None):
+        """
+        Initialize deployment generator.
+
+        Args:
+            config: Server configuration the deployment is derived from
+            issuer_url: Public URL of the deployed server
+        """
+        self.config = config
+        self.port = config.port
+        self.issuer_url = issuer_url or f"http://localhost:{config.port}"
+
+    def container_config(self) -> Dict[str, Any]:
+        """
+        Server configuration for use inside the container.
+
+        Host paths are replaced by their container equivalents and secrets
+        are removed: secrets are passed as environment variables instead.
+
+        Returns:
+            Configuration dictionary in config.json format
+        """
+        config = asdict(self.config)
+        config["server_dir"] = CONTAINER_SERVER_DIR
+        config["host"] = "0.0.0.0"
+        config["anthropic_api_key"] = None
+        if config.get("oidc_provider_config"):
+            config["oidc_provider_config"]["client_secret"] = ""
+        if config.get("json_logging_config"):
+            # A host log path would not exist in the container
+            config["json_logging_config"]["log_file"] = None
+        return config
+
+    def start_command(self, port: Optional[str] = None) -> str:
+        """
+        Shell command that seeds the configuration and starts the server.
+
+        Args:
+            port: Port expression (default: the configured port)
+        """
+        return (
+            f"test -f {CONTAINER_SERVER_DIR}/config.json"
+            f" || cp {SEED_CONFIG_PATH} {CONTAINER_SERVER_DIR}/config.json;"
+            f" exec python -m code_indexer.server.main"
+            f" --host 0.0.0.0 --port {port or self.port}"
+        )
+
+    def dockerfile(self) -> str:
+        """Render the Dockerfile of the server image."""
+        return DOCKERFILE_TEMPLATE.format(
+            repository_url=REPOSITORY_URL,
+            version=__version__,
+            home=CONTAINER_HOME,
+            server_dir=CONTAINER_SERVER_DIR,
+            port=self.port,
+        )
+
+    def docker_compose(self) -> Dict[str, Any]:
+        """Build the docker-compose.yml document."""
+        health_url = f"http://127.0.0.1:{self.port}{HEALTH_CHECK_PATH}"
+        return {
+            "services": {
+                "cidx-server": {
+                    "build": ".",
+                    "image": f"cidx-server:{__version__}",
+                    "restart": "unless-stopped",
+                    "command": ["sh", "-c", self.start_command()],
+                    "ports": [f"{self.port}:{self.port}"],
+                    "env_file": [".env"],
+                    "volumes": [
+                        f"cidx-data:{CONTAINER_SERVER_DIR}",
+                        f"./config.json:{SEED_CONFIG_PATH}:ro",
+                    ],
+                    "healthcheck": {
+                        "test": [
+                            "CMD",
+                            "python",
+                            "-c",
+                            "import urllib.request; "
+                            f"urllib.request.urlopen('{health_url}', timeout=5)",
+                        ],
+                        "interval": "30s",
+                        "timeout": "10s",
+                        "retries": 3,
+                        "start_period": "60s",
+                    },
+                }
+            },
+            "volumes": {"cidx-data": {}},
+        }
+
+    def helm_values(self) -> Dict[str, Any]:
+        """Build the values.yaml document of the Helm chart."""
+        return {
+            "image": {
+                "repository": "cidx-server",
+                "tag": __version__,
+                "pullPolicy": "IfNotPresent",
+            },
+            "service": {"type": "ClusterIP", "port": self.port},
+            "issuerUrl": self.issuer_url,
+            "existingSecret": "",
+            "secrets": {"voyageApiKey": "", "anthropicApiKey": ""},
+            "persistence": {"size": "20Gi", "storageClass": ""},
+            "resources": {
+                "requests": {"cpu": "500m", "memory": "1Gi"},
+                "limits": {"memory": "4Gi"},
+            },
+            "serverConfig": self.container_config(),
+        }
+
+    def generate_docker_compose(
+        self, output_dir: Path, overwrite: bool = False
+    ) -> List[Path]:
+        """
+        Write a Docker Compose project.
+
+        Args:
+            output_dir: Directory to write the project to
+            overwrite: Replace existing files
+
+        Returns:
+            Paths of the written files
+
+        Raises:
+            FileExistsError: If a file exists and overwrite is False
+        """
+        files = {
+            "Dockerfile": self.dockerfile(),
+            "docker-compose.yml": _dump_yaml(self.docker_compose()),
+            "config.json": json.dumps(self.container_config(), indent=2) + "\n",
+            ".env.example": ENV_TEMPLATE.format(issuer_url=self.issuer_url),
+        }
+        return _write_files(output_dir, files, overwrite)
+
+    def generate_helm_chart(
+        self, output_dir: Path, overwrite: bool = False
+    ) -> List[Path]:
+        """
+        Write a Helm chart and the Dockerfile of its image.
+
+        Args:
+            output_dir: Directory to write the chart to
+            overwrite: Replace existing files
+
+        Returns:
+            Paths of the written files
+
+        Raises:
+            FileExistsError: If a file exists and overwrite is False
+        """
+        chart = {
+            "apiVersion": "v2",
+            "name": "cidx-server",
+            "description": "CIDX multi-user semantic code search server",
+            "type": "application",
+            "version": __version__,
+            "appVersion": __version__,
+        }
+        deployment = HELM_DEPLOYMENT_TEMPLATE % {
+            "server_dir": CONTAINER_SERVER_DIR,
+            "seed_dir": str(Path(SEED_CONFIG_PATH).parent),
+            "health_path": HEALTH_CHECK_PATH,
+            "start_command": self.start_command("{{ .Values.service.port }}"),
+        }
+        files = {
+            "Dockerfile": self.dockerfile(),
+            "cidx-server/Chart.yaml": _dump_yaml(chart),
+            "cidx-server/values.yaml": _dump_yaml(self.helm_values()),
+            "cidx-server/templates/deployment.yaml": deployment,
+            "cidx-server/templates/service.yaml": HELM_SERVICE_TEMPLATE,
+            "cidx-server/templates/pvc.yaml": HELM_PVC_TEMPLATE,
+            "cidx-server/templates/configmap.yaml": HELM_CONFIGMAP_TEMPLATE,
+            "cidx-server/templates/secret.yaml": HELM_SECRET_TEMPLATE,
+        }
+        return _write_files(output_dir, files, overwrite)
+
+
+def _dump_yaml(document: Dict[str, Any]) -> str:
+    return "# Generated by cidx server generate-deploy\n" + yaml.safe_dump(
+        document, sort_keys=False, default_flow_style=False
+    )
+
+
+def _write_files(
+    output_dir: Path, files: Dict[str, str], overwrite: bool
+) -> List[Path]:
+    targets = {output_dir / name: content for name, content in files.items()}
+    existing = [path for path in targets if path.exists()]
+    if existing and not overwrite:
+        names = ", ".join(str(path) for path in existing)
+        raise FileExistsError(f"Deployment files already exist: {names}")
+
+    for path, content in targets.items():
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_text(content)
+        logger.info(f"Wrote deployment file {path}")
+    return list(targets)
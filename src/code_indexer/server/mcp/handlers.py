@@ -920,6 +920,15 @@ async def search_code(params: Dict[str, Any], user: User) -> Dict[str, Any]:
                 }
             ]
 
+            # _perform_search skips repositories it fails on, so a malformed
+            # filter is reported here
+            if params.get("filter") is not None:
+                app_module.semantic_query_manager._validate_filter_expression(
+                    params["filter"],
+                    params.get("search_mode", "semantic"),
+                    _is_temporal_query(params),
+                )
+
             # Call _perform_search directly with all query parameters
             # Track query execution with QueryTracker for concurrency safety
             import time
@@ -964,6 +973,7 @@ async def search_code(params: Dict[str, Any], user: User) -> Dict[str, Any]:
                     diff_type=params.get("diff_type"),
                     author=params.get("author"),
                     chunk_type=params.get("chunk_type"),
+                    filter_expression=params.get("filter"),
                 )
                 execution_time_ms = int((time.time() - start_time) * 1000)
                 timeout_occurred = False
@@ -1049,6 +1059,7 @@ async def search_code(params: Dict[str, Any], user: User) -> Dict[str, Any]:
             diff_type=params.get("diff_type"),
             author=params.get("author"),
             chunk_type=params.get("chunk_type"),
+            filter_expression=params.get("filter"),
         )
 
         # Apply payload truncation based on search mode
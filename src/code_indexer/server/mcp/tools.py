@@ -365,6 +365,10 @@ WHEN NOT TO USE: (1) Need ALL matches with pattern -> use regex_search, (2) Expl
                     "items": {"type": "string"},
                     "description": 'Filter by file extensions (e.g., [".py", ".js"]). Alternative to language filter when you need exact extension matching.',
                 },
+                "filter": {
+                    "type": "string",
+                    "description": "Filter expression combining terms with AND, OR, NOT and parentheses, e.g. 'lang:go AND path:internal/** AND kind:function AND NOT generated'. Fields (field:value): lang, path, kind, license, owner, tag, build-tag, module, implements. Metrics (complexity>=10): complexity, loc, nesting, params. Flags: generated, test. Only applicable to semantic search of the current code (not fts, hybrid or temporal queries).",
+                },
                 "accuracy": {
                     "type": "string",
                     "enum": ["fast", "balanced", "high"],
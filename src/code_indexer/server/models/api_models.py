@@ -200,6 +200,12 @@ class SemanticSearchRequest(BaseModel):
     include_source: bool = Field(
         default=True, description="Whether to include source code in results"
     )
+    filter: Optional[str] = Field(
+        default=None,
+        description=(
+            "Filter expression, e.g. 'lang:go AND path:internal/** AND NOT generated'"
+        ),
+    )
 
 
 class SearchResultItem(BaseModel):
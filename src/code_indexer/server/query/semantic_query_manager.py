@@ -22,6 +22,9 @@ from ..repositories.background_jobs import BackgroundJobManager
 from ...search.query import SearchResult
 from ...proxy.config_manager import ProxyConfigManager
 from ...proxy.cli_integration import _execute_query
+from ...services.relevance_feedback import FeedbackStore
+from ...services.match_highlighting import matched_region
+from ...services.query_filter import FilterSyntaxError, parse_filter
 
 
 class SemanticQueryError(Exception):
@@ -65,6 +68,8 @@ class QueryResult:
     temporal_context: Optional[Dict[str, Any]] = (
         None  # Aggregate: first_seen, last_seen, commit_count
     )
+    # Region of the snippet matching the query words (semantic results)
+    highlight: Optional[Dict[str, Any]] = None
 
     @classmethod
     def from_search_result(
@@ -98,6 +103,8 @@ class QueryResult:
             result["metadata"] = self.metadata
         if self.temporal_context is not None:
             result["temporal_context"] = self.temporal_context
+        if self.highlight is not None:
+            result["highlight"] = self.highlight
         return result
 
 
@@ -379,6 +386,8 @@ class SemanticQueryManager:
         diff_type: Optional[Union[str, List[str]]] = None,
         author: Optional[str] = None,
         chunk_type: Optional[str] = None,
+        # Filter expression of current-code semantic search
+        filter_expression: Optional[str] = None,
     ) -> Dict[str, Any]:
         """
         Perform semantic query on user's activated repositories.
@@ -407,6 +416,8 @@ class SemanticQueryManager:
             edit_distance: Fuzzy match tolerance level 0-3 (FTS-only)
             snippet_lines: Context lines around FTS matches 0-50 (FTS-only)
             regex: Interpret query as regex pattern (FTS-only, incompatible with fuzzy)
+            filter_expression: Filter expression, e.g. 'lang:go AND NOT generated'
+                (semantic search of the current code only)
 
         Returns:
             Dictionary with results, total_results, and query_metadata
@@ -416,6 +427,12 @@ class SemanticQueryManager:
         """
         # Validate query parameters
         self._validate_query_parameters(query_text, limit, min_score)
+        if filter_expression is not None:
+            self._validate_filter_expression(
+                filter_expression,
+                search_mode,
+                any([time_range, time_range_all, at_commit, show_evolution]),
+            )
 
         # Get user's activated repositories
         user_repos = self.activated_repo_manager.list_activated_repositories(username)
@@ -504,6 +521,7 @@ class SemanticQueryManager:
                 diff_type=diff_type,
                 author=author,
                 chunk_type=chunk_type,
+                filter_expression=filter_expression,
             )
             execution_time_ms = int((time.time() - start_time) * 1000)
             timeout_occurred = False
@@ -570,6 +588,117 @@ class SemanticQueryManager:
 
         return response
 
+    def record_feedback(
+        self,
+        username: str,
+        repository_alias: str,
+        file_path: str,
+        relevant: bool,
+        code_snippet: Optional[str] = None,
+        line_number: Optional[int] = None,
+        query_text: Optional[str] = None,
+    ) -> Dict[str, Any]:
+        """
+        Record a relevance judgment of a query result.
+
+        Judgments are shared by everyone querying the repository: global
+        repositories pool the feedback of all users, activated repositories
+        that of their owner. Later semantic queries of the repository are
+        re-ranked with them.
+
+        Args:
+            username: User giving the feedback
+            repository_alias: Repository the result came from
+            file_path: Result file path
+            relevant: Whether the result was useful
+            code_snippet: Result code; symbols defined in it share the vote
+            line_number: Result line; a new judgment of the same file and line
+                by the same user replaces the earlier one
+            query_text: Query that returned the result
+
+        Returns:
+            Recorded judgment and the file's resulting score adjustment
+
+        Raises:
+            SemanticQueryError: If the repository is not found for the user
+        """
+        user_aliases = {
+            repo["user_alias"]
+            for repo in self.activated_repo_manager.list_activated_repositories(
+                username
+            )
+        }
+        if repository_alias in user_aliases:
+            is_global = False
+        elif self._resolve_global_alias(repository_alias):
+            is_global = True
+        else:
+            raise SemanticQueryError(
+                f"Repository '{repository_alias}' not found for user '{username}'"
+            )
+
+        store = self._feedback_store(username, repository_alias, is_global)
+        if store is None:
+            raise SemanticQueryError(
+                f"Feedback is not supported for repository '{repository_alias}'"
+            )
+        judgment = store.record(
+            path=file_path,
+            relevant=relevant,
+            text=code_snippet or "",
+            query=query_text,
+            user=username,
+            result_key=(
+                f"{file_path}:{line_number}" if line_number is not None else None
+            ),
+        )
+        return {
+            "repository_alias": repository_alias,
+            "file_path": judgment.path,
+            "relevant": judgment.relevant,
+            "symbols": judgment.symbols,
+            "adjustment": store.adjustment(judgment.path, code_snippet or ""),
+        }
+
+    def _resolve_global_alias(self, repository_alias: str) -> Optional[str]:
+        """Target path of a global repository alias, or None."""
+        from code_indexer.global_repos.alias_manager import AliasManager
+
+        data_dir = Path(self.activated_repo_manager.activated_repos_dir).parent
+        aliases_dir = data_dir / "golden-repos" / "aliases"
+        if not aliases_dir.exists():
+            return None
+        return AliasManager(str(aliases_dir)).read_alias(repository_alias)
+
+    def _feedback_store(
+        self, username: str, repository_alias: str, is_global: bool
+    ) -> Optional[FeedbackStore]:
+        """
+        Feedback of a repository, kept outside its (replaceable) index.
+
+        Returns None for aliases that are not safe as file names.
+        """
+        if not re.fullmatch(r"[\w.\-]+", repository_alias) or set(
+            repository_alias
+        ) == {"."}:
+            return None
+        owner = "global" if is_global else username
+        return FeedbackStore(
+            Path(self.data_dir) / "feedback" / owner / f"{repository_alias}.json"
+        )
+
+    @staticmethod
+    def _apply_feedback(
+        store: Optional[FeedbackStore], results: List[QueryResult]
+    ) -> None:
+        """Add feedback adjustments to the similarity scores of results."""
+        if store is None or not store.has_feedback:
+            return
+        for result in results:
+            result.similarity_score += store.adjustment(
+                result.file_path, result.code_snippet
+            )
+
     def submit_query_job(
         self,
         username: str,
@@ -651,6 +780,25 @@ class SemanticQueryManager:
         if min_score is not None and (min_score < 0.0 or min_score > 1.0):
             raise SemanticQueryError("Min score must be between 0.0 and 1.0")
 
+    def _validate_filter_expression(
+        self, filter_expression: str, search_mode: str, temporal: bool
+    ) -> None:
+        """
+        Validate a filter expression and the search it applies to.
+
+        Raises:
+            SemanticQueryError: If the expression is malformed or the search is
+                not a semantic search of the current code
+        """
+        if search_mode != "semantic" or temporal:
+            raise SemanticQueryError(
+                "filter applies to semantic search of the current code only"
+            )
+        try:
+            parse_filter(filter_expression)
+        except FilterSyntaxError as e:
+            raise SemanticQueryError(f"Invalid filter: {e}")
+
     def _perform_search(
         self,
         username: str,
@@ -683,6 +831,8 @@ class SemanticQueryManager:
         diff_type: Optional[Union[str, List[str]]] = None,
         author: Optional[str] = None,
         chunk_type: Optional[str] = None,
+        # Filter expression of current-code semantic search
+        filter_expression: Optional[str] = None,
     ) -> List[QueryResult]:
         """
         Perform the actual search across user repositories.
@@ -716,6 +866,7 @@ class SemanticQueryManager:
             edit_distance: Fuzzy match tolerance 0-3
             snippet_lines: Context lines around FTS matches 0-50
             regex: Interpret query as regex pattern
+            filter_expression: Filter expression of semantic search
 
         Returns:
             List of QueryResult objects sorted by similarity score
@@ -788,7 +939,18 @@ class SemanticQueryManager:
                     diff_type=diff_type,
                     author=author,
                     chunk_type=chunk_type,
+                    filter_expression=filter_expression,
                 )
+                # Re-rank current-code semantic results with relevance feedback
+                if search_mode == "semantic" and not any(
+                    [time_range, time_range_all, at_commit, show_evolution]
+                ):
+                    self._apply_feedback(
+                        self._feedback_store(
+                            username, repo_alias, bool(repo_info.get("is_global"))
+                        ),
+                        results,
+                    )
                 all_results.extend(results)
 
             except (TimeoutError, Exception) as e:
@@ -843,6 +1005,8 @@ class SemanticQueryManager:
         diff_type: Optional[Union[str, List[str]]] = None,
         author: Optional[str] = None,
         chunk_type: Optional[str] = None,
+        # Filter expression of current-code semantic search
+        filter_expression: Optional[str] = None,
     ) -> List[QueryResult]:
         """
         Search a single repository using the appropriate search service.
@@ -887,6 +1051,7 @@ class SemanticQueryManager:
             edit_distance: Fuzzy match tolerance 0-3
             snippet_lines: Context lines around FTS matches 0-50
             regex: Interpret query as regex pattern
+            filter_expression: Filter expression of semantic search
 
         Returns:
             List of QueryResult objects from this repository
@@ -920,6 +1085,7 @@ class SemanticQueryManager:
                     diff_type=diff_type,
                     author=author,
                     chunk_type=chunk_type,
+                    filter_expression=filter_expression,
                 )
 
             # TEMPORAL QUERY HANDLING (Story #446)
@@ -1007,6 +1173,7 @@ class SemanticQueryManager:
             search_request = SemanticSearchRequest(
                 query=query_text, limit=limit, include_source=True
             )
+            search_request.filter = filter_expression
 
             # Perform search on the repository using direct path
             search_response = search_service.search_repository_path(
@@ -1036,6 +1203,9 @@ class SemanticQueryManager:
                     similarity_score=search_item.score,
                     repository_alias=repository_alias,
                     source_repo=None,  # Single repository, no source_repo
+                    highlight=matched_region(
+                        search_item.content, query_text, search_item.line_start
+                    ),
                 )
                 semantic_results.append(query_result)
 
@@ -1073,6 +1243,8 @@ class SemanticQueryManager:
         diff_type: Optional[Union[str, List[str]]] = None,
         author: Optional[str] = None,
         chunk_type: Optional[str] = None,
+        # Filter expression of current-code semantic search
+        filter_expression: Optional[str] = None,
     ) -> List[str]:
         """
         Convert server parameters to CLI args format.
@@ -1160,6 +1332,9 @@ class SemanticQueryManager:
         if chunk_type is not None:
             args.extend(["--chunk-type", chunk_type])
 
+        if filter_expression is not None:
+            args.extend(["--filter", filter_expression])
+
         return args
 
     def _execute_cli_query(
@@ -1183,6 +1358,8 @@ class SemanticQueryManager:
         diff_type: Optional[Union[str, List[str]]] = None,
         author: Optional[str] = None,
         chunk_type: Optional[str] = None,
+        # Filter expression of current-code semantic search
+        filter_expression: Optional[str] = None,
     ) -> List[QueryResult]:
         """
         Execute CLI query and parse results.
@@ -1241,6 +1418,7 @@ class SemanticQueryManager:
             diff_type=diff_type,
             author=author,
             chunk_type=chunk_type,
+            filter_expression=filter_expression,
         )
 
         # Capture stdout
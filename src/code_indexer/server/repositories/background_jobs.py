@@ -381,7 +381,9 @@ class BackgroundJobManager:
             job.progress = 10
             self._persist_jobs()
 
-        logging.info(f"Starting background job {job_id}")
+        logging.info(
+            f"Starting background job {job_id}", extra=self._job_log_fields(job_id)
+        )
 
         try:
             # Create progress callback function
@@ -431,11 +433,17 @@ class BackgroundJobManager:
                     job.completed_at = datetime.now(timezone.utc)
                 self._persist_jobs()
 
-            logging.info(f"Background job {job_id} completed successfully")
+            logging.info(
+                f"Background job {job_id} completed successfully",
+                extra=self._job_log_fields(job_id),
+            )
 
         except InterruptedError as e:
             # Job was cancelled
-            logging.info(f"Background job {job_id} was cancelled: {e}")
+            logging.info(
+                f"Background job {job_id} was cancelled: {e}",
+                extra=self._job_log_fields(job_id),
+            )
             with self._lock:
                 job = self.jobs[job_id]
                 job.status = JobStatus.CANCELLED
@@ -446,7 +454,10 @@ class BackgroundJobManager:
         except Exception as e:
             # Job failed
             error_msg = str(e)
-            logging.error(f"Background job {job_id} failed: {error_msg}")
+            logging.error(
+                f"Background job {job_id} failed: {error_msg}",
+                extra=self._job_log_fields(job_id),
+            )
 
             with self._lock:
                 job = self.jobs[job_id]
@@ -461,6 +472,34 @@ class BackgroundJobManager:
             with self._lock:
                 self._running_jobs.pop(job_id, None)
 
+    def _job_log_fields(self, job_id: str) -> Dict[str, Any]:
+        """
+        Structured log fields of a job for JSON log pipelines.
+
+        Args:
+            job_id: Job identifier
+
+        Returns:
+            Fields passed as extra= to job lifecycle log records
+        """
+        with self._lock:
+            job = self.jobs.get(job_id)
+            if job is None:
+                return {"job_id": job_id}
+            fields: Dict[str, Any] = {
+                "job_id": job_id,
+                "operation": job.operation_type,
+                "project": job.repo_alias,
+                "username": job.username,
+                "status": job.status.value,
+            }
+            if job.started_at is not None:
+                finished = job.completed_at or datetime.now(timezone.utc)
+                fields["duration_ms"] = round(
+                    (finished - job.started_at).total_seconds() * 1000
+                )
+            return fields
+
     def _execute_with_cancellation_check(
         self, job_id: str, func: Callable, args: tuple, kwargs: dict
     ) -> Any:
@@ -512,15 +551,16 @@ class BackgroundJobManager:
         # Should not reach here normally
         return {"status": "completed"}
 
-    def cleanup_old_jobs(self, max_age_hours: int = 24) -> int:
+    def cleanup_old_jobs(self, max_age_hours: int = 24, dry_run: bool = False) -> int:
         """
         Clean up old completed/failed jobs.
 
         Args:
             max_age_hours: Maximum age of jobs to keep in hours
+            dry_run: Only count the jobs that would be cleaned up
 
         Returns:
-            Number of jobs cleaned up
+            Number of jobs cleaned up (or, in a dry run, to be cleaned up)
         """
         cutoff_time = datetime.now(timezone.utc) - timedelta(hours=max_age_hours)
         cleaned_count = 0
@@ -537,6 +577,19 @@ class BackgroundJobManager:
                 ):
                     job_ids_to_remove.append(job_id)
 
+            if self._sqlite_backend:
+                # Only the newest jobs are loaded into memory; older rows
+                # exist in the database alone
+                if dry_run:
+                    return self._sqlite_backend.count_old_jobs(max_age_hours)
+                for job_id in job_ids_to_remove:
+                    del self.jobs[job_id]
+                cleaned_count = self._sqlite_backend.cleanup_old_jobs(max_age_hours)
+                return cleaned_count
+
+            if dry_run:
+                return len(job_ids_to_remove)
+
             for job_id in job_ids_to_remove:
                 del self.jobs[job_id]
                 cleaned_count += 1
@@ -102,6 +102,7 @@ class ConfigService:
                 "index_cache_ttl_minutes": config.cache_config.index_cache_ttl_minutes,
                 "index_cache_cleanup_interval": config.cache_config.index_cache_cleanup_interval,
                 "index_cache_max_size_mb": config.cache_config.index_cache_max_size_mb,
+                "index_cache_preload_on_startup": config.cache_config.index_cache_preload_on_startup,
                 "fts_cache_ttl_minutes": config.cache_config.fts_cache_ttl_minutes,
                 "fts_cache_cleanup_interval": config.cache_config.fts_cache_cleanup_interval,
                 "fts_cache_max_size_mb": config.cache_config.fts_cache_max_size_mb,
@@ -286,6 +287,8 @@ class ConfigService:
             cache.index_cache_cleanup_interval = int(value)
         elif key == "index_cache_max_size_mb":
             cache.index_cache_max_size_mb = int(value) if value else None
+        elif key == "index_cache_preload_on_startup":
+            cache.index_cache_preload_on_startup = value in ["true", True, "True", "1"]
         elif key == "fts_cache_ttl_minutes":
             cache.fts_cache_ttl_minutes = float(value)
         elif key == "fts_cache_cleanup_interval":
@@ -12,7 +12,7 @@ Story #705: Default Group Bootstrap and User Assignment Infrastructure
 import logging
 import sqlite3
 from dataclasses import dataclass
-from datetime import datetime, timezone
+from datetime import datetime, timedelta, timezone
 from pathlib import Path
 from typing import Any, List, Optional
 
@@ -1101,6 +1101,41 @@ class GroupAccessManager:
         finally:
             conn.close()
 
+    def cleanup_old_audit_logs(self, max_age_days: int, dry_run: bool = False) -> int:
+        """
+        Delete audit log entries older than the retention window.
+
+        Args:
+            max_age_days: Maximum age of entries to keep in days
+            dry_run: Only count the entries that would be deleted
+
+        Returns:
+            Number of entries deleted (or, in a dry run, to be deleted)
+        """
+        cutoff = datetime.now(timezone.utc) - timedelta(days=max_age_days)
+        conn = self._get_connection()
+        try:
+            cursor = conn.cursor()
+            if dry_run:
+                cursor.execute(
+                    "SELECT COUNT(*) as count FROM audit_logs WHERE timestamp < ?",
+                    (cutoff.isoformat(),),
+                )
+                count: int = cursor.fetchone()["count"]
+                return count
+
+            cursor.execute(
+                "DELETE FROM audit_logs WHERE timestamp < ?", (cutoff.isoformat(),)
+            )
+            conn.commit()
+            deleted: int = cursor.rowcount
+        finally:
+            conn.close()
+
+        if deleted > 0:
+            logger.info(f"Deleted {deleted} audit log entries past retention")
+        return deleted
+
 
 def seed_users_to_groups(
     user_manager: Any, group_manager: "GroupAccessManager"
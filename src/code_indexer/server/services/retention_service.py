@@ -0,0 +1,303 @@
+"""
+Data retention service for the CIDX Server.
+
+Applies the retention windows of ``retention_config`` on a schedule:
+
+- golden repo indexes: git history past index_history_days, superseded
+  content past index_content_days and content of merged branches, using the
+  same rules as ``cidx purge``
+- background job records past job_records_days
+- admin audit log entries past audit_log_days
+
+Every run can be a dry run that only reports what would be deleted.
+"""
+
+from code_indexer.server.middleware.correlation import get_correlation_id
+
+import logging
+import threading
+from dataclasses import asdict, dataclass, field
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+from code_indexer.server.utils.config_manager import ServerConfig
+
+logger = logging.getLogger(__name__)
+
+SECONDS_PER_DAY = 86400
+
+
+@dataclass
+class RepoRetentionResult:
+    """Index points purged from one golden repo (or snapshot)."""
+
+    index_path: str
+    points_purged: int = 0
+    collections: Dict[str, int] = field(default_factory=dict)
+    skipped_reason: Optional[str] = None
+
+
+@dataclass
+class RetentionReport:
+    """Result of one retention run."""
+
+    dry_run: bool
+    started_at: str = ""
+    repos: List[RepoRetentionResult] = field(default_factory=list)
+    job_records_purged: int = 0
+    audit_entries_purged: int = 0
+    errors: List[str] = field(default_factory=list)
+
+    @property
+    def index_points_purged(self) -> int:
+        return sum(r.points_purged for r in self.repos)
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = asdict(self)
+        data["index_points_purged"] = self.index_points_purged
+        return data
+
+
+class RetentionService:
+    """
+    Deletes collection points, job records and audit entries past retention.
+
+    Runs periodically in a background thread while retention is enabled,
+    and on demand (optionally as a dry run) via the admin API.
+    """
+
+    def __init__(
+        self,
+        config: ServerConfig,
+        job_manager: Any,
+        golden_repos_dir: Path,
+        group_manager: Optional[Any] = None,
+    ):
+        """
+        Initialize the retention service.
+
+        Args:
+            config: Server configuration with retention_config
+            job_manager: BackgroundJobManager holding the job records
+            golden_repos_dir: Directory containing golden repos and snapshots
+            group_manager: GroupAccessManager holding the audit log; set later
+                when it is created after this service
+        """
+        self.config = config
+        self.job_manager = job_manager
+        self.golden_repos_dir = Path(golden_repos_dir)
+        self.group_manager = group_manager
+        self.last_report: Optional[RetentionReport] = None
+        self._run_lock = threading.Lock()
+        self._timer_thread: Optional[threading.Thread] = None
+        self._stop_event = threading.Event()
+
+    def start(self) -> None:
+        """Start the periodic retention thread if retention is enabled."""
+        retention = self.config.retention_config
+        if retention is None or not retention.enabled:
+            logger.info(
+                "Data retention disabled - no periodic purge",
+                extra={"correlation_id": get_correlation_id()},
+            )
+            return
+        if self._timer_thread is not None and self._timer_thread.is_alive():
+            return
+
+        self._stop_event.clear()
+        self._timer_thread = threading.Thread(
+            target=self._timer_loop, name="RetentionService", daemon=True
+        )
+        self._timer_thread.start()
+        logger.info(
+            f"Data retention started (every {retention.interval_hours}h)",
+            extra={"correlation_id": get_correlation_id()},
+        )
+
+    def stop(self) -> None:
+        """Stop the periodic retention thread."""
+        self._stop_event.set()
+        if self._timer_thread is not None:
+            self._timer_thread.join(timeout=5.0)
+            self._timer_thread = None
+
+    def _timer_loop(self) -> None:
+        """Run retention, then wait for the next interval or a stop."""
+        while not self._stop_event.is_set():
+            try:
+                self.run()
+            except Exception as e:
+                logger.error(
+                    f"Error in data retention run: {e}",
+                    exc_info=True,
+                    extra={"correlation_id": get_correlation_id()},
+                )
+            interval_hours = max(1, self.config.retention_config.interval_hours)
+            self._stop_event.wait(timeout=interval_hours * 3600)
+
+    def run(self, dry_run: bool = False) -> RetentionReport:
+        """
+        Apply every configured retention window once.
+
+        Args:
+            dry_run: Only report what would be deleted
+
+        Returns:
+            RetentionReport with per-repo and per-record-type counts
+        """
+        retention = self.config.retention_config
+        report = RetentionReport(
+            dry_run=dry_run, started_at=datetime.now(timezone.utc).isoformat()
+        )
+        if retention is None:
+            return report
+
+        with self._run_lock:
+            criteria = self._index_criteria()
+            if criteria:
+                for index_dir in self._index_dirs():
+                    report.repos.append(
+                        self._purge_index(index_dir, criteria, dry_run, report)
+                    )
+
+            if retention.job_records_days is not None:
+                try:
+                    report.job_records_purged = self.job_manager.cleanup_old_jobs(
+                        max_age_hours=retention.job_records_days * 24,
+                        dry_run=dry_run,
+                    )
+                except Exception as e:
+                    report.errors.append(f"job records: {e}")
+
+            if retention.audit_log_days is not None:
+                if self.group_manager is None:
+                    report.errors.append("audit log: group manager not available")
+                else:
+                    try:
+                        report.audit_entries_purged = (
+                            self.group_manager.cleanup_old_audit_logs(
+                                retention.audit_log_days, dry_run=dry_run
+                            )
+                        )
+                    except Exception as e:
+                        report.errors.append(f"audit log: {e}")
+
+        if not dry_run:
+            self.last_report = report
+        logger.info(
+            f"Data retention {'dry run' if dry_run else 'run'}: "
+            f"{report.index_points_purged} index points, "
+            f"{report.job_records_purged} job records, "
+            f"{report.audit_entries_purged} audit entries",
+            extra={"correlation_id": get_correlation_id()},
+        )
+        return report
+
+    def _index_criteria(self) -> List[Tuple[str, Any]]:
+        """
+        (scope, PurgeCriteria) pairs for the configured index windows.
+
+        Scope "history" applies to temporal collections, "content" to the
+        others. Windows are applied in turn, so in a dry run a content point
+        matching both content windows is counted twice.
+        """
+        from code_indexer.services.index_purge import PurgeCriteria
+
+        retention = self.config.retention_config
+        criteria: List[Tuple[str, Any]] = []
+        if retention.index_history_days is not None:
+            history_seconds = retention.index_history_days * SECONDS_PER_DAY
+            criteria.append(
+                ("history", PurgeCriteria(older_than_seconds=history_seconds))
+            )
+        if retention.index_content_days is not None:
+            content_seconds = retention.index_content_days * SECONDS_PER_DAY
+            criteria.append(
+                ("content", PurgeCriteria(older_than_seconds=content_seconds))
+            )
+        if retention.purge_merged_branches:
+            criteria.append(("content", PurgeCriteria(branches="merged")))
+        return criteria
+
+    def _index_dirs(self) -> List[Path]:
+        """Index directories of golden repos and their versioned snapshots."""
+        if not self.golden_repos_dir.exists():
+            return []
+        repo_dirs = [p for p in self.golden_repos_dir.iterdir() if p.is_dir()]
+        versioned = self.golden_repos_dir / ".versioned"
+        if versioned.is_dir():
+            for alias_dir in versioned.iterdir():
+                if alias_dir.is_dir():
+                    repo_dirs.extend(p for p in alias_dir.iterdir() if p.is_dir())
+        return sorted(
+            repo_dir / ".code-indexer" / "index"
+            for repo_dir in repo_dirs
+            if (repo_dir / ".code-indexer" / "index").is_dir()
+        )
+
+    def _purge_index(
+        self,
+        index_dir: Path,
+        criteria: List[Tuple[str, Any]],
+        dry_run: bool,
+        report: RetentionReport,
+    ) -> RepoRetentionResult:
+        """Purge one repo's collections, skipping it while it is being indexed."""
+        from code_indexer.services.index_purge import IndexPurger
+        from code_indexer.services.indexing_lock import (
+            IndexingLockError,
+            create_indexing_lock,
+        )
+        from code_indexer.storage.filesystem_vector_store import (
+            FilesystemVectorStore,
+        )
+        from code_indexer.storage.temporal_metadata_store import (
+            TemporalMetadataStore,
+        )
+
+        repo_root = index_dir.parent.parent
+        result = RepoRetentionResult(index_path=str(index_dir))
+
+        indexing_lock = None
+        if not dry_run:
+            indexing_lock = create_indexing_lock(index_dir.parent)
+            try:
+                indexing_lock.acquire(str(repo_root))
+            except IndexingLockError:
+                result.skipped_reason = "indexing in progress"
+                return result
+
+        try:
+            store = FilesystemVectorStore(base_path=index_dir, project_root=repo_root)
+            purger = IndexPurger(store, repo_root)
+            collections = store.list_collections()
+            for scope, purge_criteria in criteria:
+                selected = [
+                    c
+                    for c in collections
+                    if TemporalMetadataStore.is_temporal_collection(c)
+                    == (scope == "history")
+                ]
+                if not selected:
+                    continue
+                purge_report = purger.purge(
+                    purge_criteria, collections=selected, dry_run=dry_run
+                )
+                for coll_report in purge_report.collections:
+                    result.collections[coll_report.collection] = (
+                        result.collections.get(coll_report.collection, 0)
+                        + coll_report.points_purged
+                    )
+                result.points_purged += purge_report.points_purged
+        except Exception as e:
+            report.errors.append(f"{index_dir}: {e}")
+            logger.error(
+                f"Data retention failed for {index_dir}: {e}",
+                exc_info=True,
+                extra={"correlation_id": get_correlation_id()},
+            )
+        finally:
+            if indexing_lock is not None:
+                indexing_lock.release()
+        return result
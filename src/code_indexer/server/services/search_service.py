@@ -9,7 +9,7 @@ from code_indexer.server.middleware.correlation import get_correlation_id
 
 import os
 from pathlib import Path
-from typing import List, Optional
+from typing import Any, Dict, List, Optional
 import logging
 
 from ..models.api_models import (
@@ -20,6 +20,7 @@ from ..models.api_models import (
 from ...config import ConfigManager
 from ...backends.backend_factory import BackendFactory
 from ...services.embedding_factory import EmbeddingProviderFactory
+from ...services.query_filter import parse_filter
 
 logger = logging.getLogger(__name__)
 
@@ -38,15 +39,34 @@ LANGUAGE_EXTENSIONS = {
     ".cs": "csharp",
     ".go": "go",
     ".rs": "rust",
+    ".zig": "zig",
+    ".ex": "elixir",
+    ".exs": "elixir",
+    ".erl": "erlang",
     ".php": "php",
     ".rb": "ruby",
     ".swift": "swift",
     ".kt": "kotlin",
     ".scala": "scala",
+    ".dart": "dart",
+    ".m": "objective-c",
+    ".mm": "objective-cpp",
+    ".jl": "julia",
+    ".r": "r",
+    ".cbl": "cobol",
+    ".cob": "cobol",
+    ".cpy": "cobol",
+    ".v": "verilog",
+    ".vh": "verilog",
+    ".sv": "systemverilog",
+    ".svh": "systemverilog",
+    ".vhd": "vhdl",
+    ".vhdl": "vhdl",
     ".sql": "sql",
     ".html": "html",
     ".css": "css",
     ".vue": "vue",
+    ".svelte": "svelte",
     ".jsx": "jsx",
     ".tsx": "tsx",
 }
@@ -114,11 +134,19 @@ class SemanticSearchService:
         # 3. Search vector store with correct collection name
         # 4. Rank results by semantic similarity
 
+        # Filter expression syntax errors surface as ValueError
+        filter_conditions = (
+            {"must": [parse_filter(search_request.filter)]}
+            if search_request.filter
+            else None
+        )
+
         search_results = self._perform_semantic_search(
             repo_path,
             search_request.query,
             search_request.limit,
             search_request.include_source,
+            filter_conditions,
         )
 
         return SemanticSearchResponse(
@@ -128,7 +156,12 @@ class SemanticSearchService:
         )
 
     def _perform_semantic_search(
-        self, repo_path: str, query: str, limit: int, include_source: bool
+        self,
+        repo_path: str,
+        query: str,
+        limit: int,
+        include_source: bool,
+        filter_conditions: Optional[Dict[str, Any]] = None,
     ) -> List[SearchResultItem]:
         """
         Perform real semantic search using repository-specific configuration.
@@ -141,6 +174,7 @@ class SemanticSearchService:
             query: Search query
             limit: Maximum number of results
             include_source: Whether to include source code in results
+            filter_conditions: Payload filter of a filter expression, if any
 
         Returns:
             List of search results ranked by semantic similarity
@@ -200,6 +234,7 @@ class SemanticSearchService:
                     embedding_provider=embedding_service,
                     collection_name=collection_name,
                     limit=limit,
+                    filter_conditions=filter_conditions,
                     return_timing=True,
                 )
             else:
@@ -209,6 +244,7 @@ class SemanticSearchService:
                     query_vector=query_embedding,
                     limit=limit,
                     collection_name=collection_name,
+                    filter_conditions=filter_conditions,
                 )
 
             logger.info(
@@ -41,11 +41,29 @@ LANGUAGE_EXTENSIONS = {
     ".cs": "csharp",
     ".go": "go",
     ".rs": "rust",
+    ".zig": "zig",
+    ".ex": "elixir",
+    ".exs": "elixir",
+    ".erl": "erlang",
     ".php": "php",
     ".rb": "ruby",
     ".swift": "swift",
     ".kt": "kotlin",
     ".scala": "scala",
+    ".dart": "dart",
+    ".m": "objective-c",
+    ".mm": "objective-cpp",
+    ".jl": "julia",
+    ".r": "r",
+    ".cbl": "cobol",
+    ".cob": "cobol",
+    ".cpy": "cobol",
+    ".v": "verilog",
+    ".vh": "verilog",
+    ".sv": "systemverilog",
+    ".svh": "systemverilog",
+    ".vhd": "vhdl",
+    ".vhdl": "vhdl",
     ".sql": "sql",
     ".html": "html",
     ".css": "css",
@@ -53,6 +71,7 @@ LANGUAGE_EXTENSIONS = {
     ".sass": "sass",
     ".less": "less",
     ".vue": "vue",
+    ".svelte": "svelte",
     ".jsx": "jsx",
     ".tsx": "tsx",
     ".md": "markdown",
@@ -305,15 +324,34 @@ class RepositoryStatsService:
                 ".cs",
                 ".go",
                 ".rs",
+                ".zig",
+                ".ex",
+                ".exs",
+                ".erl",
                 ".php",
                 ".rb",
                 ".swift",
                 ".kt",
                 ".scala",
+                ".dart",
+                ".m",
+                ".mm",
+                ".jl",
+                ".r",
+                ".cbl",
+                ".cob",
+                ".cpy",
+                ".v",
+                ".vh",
+                ".sv",
+                ".svh",
+                ".vhd",
+                ".vhdl",
                 ".sql",
                 ".html",
                 ".css",
                 ".vue",
+                ".svelte",
                 ".jsx",
                 ".tsx",
             }
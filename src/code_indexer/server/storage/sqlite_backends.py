@@ -1518,6 +1518,20 @@ class BackgroundJobsSqliteBackend:
             logger.info(f"Cleaned up {count} old background jobs")
         return count
 
+    def count_old_jobs(self, max_age_hours: int = 24) -> int:
+        """Count completed/failed/cancelled jobs that cleanup_old_jobs() removes."""
+        cutoff_time = datetime.now(timezone.utc) - timedelta(hours=max_age_hours)
+        conn = self._conn_manager.get_connection()
+        cursor = conn.execute(
+            """SELECT COUNT(*) FROM background_jobs
+               WHERE status IN ('completed', 'failed', 'cancelled')
+               AND completed_at IS NOT NULL
+               AND completed_at < ?""",
+            (cutoff_time.isoformat(),),
+        )
+        count: int = cursor.fetchone()[0]
+        return count
+
     def count_jobs_by_status(self) -> Dict[str, int]:
         """Get count of jobs grouped by status."""
         conn = self._conn_manager.get_connection()
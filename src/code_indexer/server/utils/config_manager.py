@@ -35,6 +35,8 @@ class CacheConfig:
     index_cache_ttl_minutes: float = 10.0
     index_cache_cleanup_interval: int = 60
     index_cache_max_size_mb: Optional[int] = None
+    # Warm global repo indexes into the HNSW cache at server startup
+    index_cache_preload_on_startup: bool = False
 
     # FTS index cache settings
     fts_cache_ttl_minutes: float = 10.0
@@ -177,6 +179,44 @@ class TelemetryConfig:
     deployment_environment: str = "development"
 
 
+@dataclass
+class RetentionConfig:
+    """
+    Data retention policies for the CIDX Server.
+
+    Each window is in days; None keeps that data forever. Disabled by
+    default - nothing is deleted until an administrator opts in.
+    """
+
+    enabled: bool = False
+    interval_hours: int = 24  # How often the retention sweep runs
+
+    # Golden repo indexes (see ``cidx purge``)
+    index_history_days: Optional[int] = None  # Git history by commit date
+    index_content_days: Optional[int] = None  # Superseded content by index date
+    purge_merged_branches: bool = False  # Content of branches merged into HEAD
+
+    # Server records
+    job_records_days: Optional[int] = None  # Completed/failed/cancelled jobs
+    audit_log_days: Optional[int] = None  # Admin audit log entries
+
+
+@dataclass
+class JsonLoggingConfig:
+    """
+    Structured JSON logs of the CIDX Server.
+
+    When enabled, every log record is also written as one JSON line to a
+    size-rotated file for log pipelines, with the request's correlation ID.
+    """
+
+    enabled: bool = False
+    log_file: Optional[str] = None  # Default: <server_dir>/logs/server.jsonl
+    level: str = "INFO"
+    max_bytes: int = 10 * 1024 * 1024  # Rotate at this size
+    backup_count: int = 5  # Rotated files kept
+
+
 @dataclass
 class ServerConfig:
     """
@@ -200,6 +240,8 @@ class ServerConfig:
     auto_watch_config: Optional[AutoWatchConfig] = None
     oidc_provider_config: Optional[OIDCProviderConfig] = None
     telemetry_config: Optional[TelemetryConfig] = None
+    retention_config: Optional[RetentionConfig] = None
+    json_logging_config: Optional[JsonLoggingConfig] = None
 
     # Claude CLI integration settings
     anthropic_api_key: Optional[str] = None
@@ -232,6 +274,10 @@ class ServerConfig:
             self.oidc_provider_config = OIDCProviderConfig()
         if self.telemetry_config is None:
             self.telemetry_config = TelemetryConfig()
+        if self.retention_config is None:
+            self.retention_config = RetentionConfig()
+        if self.json_logging_config is None:
+            self.json_logging_config = JsonLoggingConfig()
 
 
 class ServerConfigManager:
@@ -360,6 +406,22 @@ class ServerConfigManager:
                     **config_dict["telemetry_config"]
                 )
 
+            # Convert nested retention_config dict to RetentionConfig
+            if "retention_config" in config_dict and isinstance(
+                config_dict["retention_config"], dict
+            ):
+                config_dict["retention_config"] = RetentionConfig(
+                    **config_dict["retention_config"]
+                )
+
+            # Convert nested json_logging_config dict to JsonLoggingConfig
+            if "json_logging_config" in config_dict and isinstance(
+                config_dict["json_logging_config"], dict
+            ):
+                config_dict["json_logging_config"] = JsonLoggingConfig(
+                    **config_dict["json_logging_config"]
+                )
+
             return ServerConfig(**config_dict)
         except json.JSONDecodeError as e:
             raise ValueError(f"Failed to parse configuration file: {e}")
@@ -543,6 +605,40 @@ class ServerConfigManager:
                     f"machine_metrics_interval_seconds must be >= 1, got {config.telemetry_config.machine_metrics_interval_seconds}"
                 )
 
+        # Validate retention configuration
+        if config.retention_config:
+            if config.retention_config.interval_hours < 1:
+                raise ValueError(
+                    f"retention interval_hours must be >= 1, got {config.retention_config.interval_hours}"
+                )
+            for window in (
+                "index_history_days",
+                "index_content_days",
+                "job_records_days",
+                "audit_log_days",
+            ):
+                days = getattr(config.retention_config, window)
+                if days is not None and days < 1:
+                    raise ValueError(
+                        f"retention {window} must be >= 1 or null, got {days}"
+                    )
+
+        # Validate JSON logging configuration
+        if config.json_logging_config:
+            json_logging = config.json_logging_config
+            if json_logging.level not in ("DEBUG", "INFO", "WARNING", "ERROR"):
+                raise ValueError(
+                    f"json_logging_config level must be DEBUG, INFO, WARNING or ERROR, got {json_logging.level}"
+                )
+            if json_logging.max_bytes < 1024:
+                raise ValueError(
+                    f"json_logging_config max_bytes must be >= 1024, got {json_logging.max_bytes}"
+                )
+            if json_logging.backup_count < 1:
+                raise ValueError(
+                    f"json_logging_config backup_count must be >= 1, got {json_logging.backup_count}"
+                )
+
     def create_server_directories(self) -> None:
         """
         Create necessary server directories.
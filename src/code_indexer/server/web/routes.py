@@ -3906,8 +3906,26 @@ def _detect_language_from_path(file_path: str) -> str:
         ".cs": "csharp",
         ".go": "go",
         ".rs": "rust",
+        ".zig": "zig",
+        ".ex": "elixir",
+        ".exs": "elixir",
+        ".erl": "erlang",
         ".rb": "ruby",
         ".php": "php",
+        ".dart": "dart",
+        ".m": "objective-c",
+        ".mm": "objective-cpp",
+        ".jl": "julia",
+        ".r": "r",
+        ".cbl": "cobol",
+        ".cob": "cobol",
+        ".cpy": "cobol",
+        ".v": "verilog",
+        ".vh": "verilog",
+        ".sv": "systemverilog",
+        ".svh": "systemverilog",
+        ".vhd": "vhdl",
+        ".vhdl": "vhdl",
         ".html": "html",
         ".css": "css",
         ".sql": "sql",
@@ -3918,6 +3936,7 @@ def _detect_language_from_path(file_path: str) -> str:
         ".xml": "xml",
         ".sh": "bash",
         ".bash": "bash",
+        ".zsh": "bash",
     }
     from pathlib import Path
 
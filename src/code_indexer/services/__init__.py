@@ -1,8 +1,18 @@
-"""Service clients for external APIs."""
+"""Service clients for external APIs.
 
-from .embedding_factory import EmbeddingProviderFactory
-from .rag_context_extractor import RAGContextExtractor
-from .claude_integration import ClaudeIntegrationService, check_claude_sdk_availability
+Exports are loaded lazily (PEP 562) so importing any ``code_indexer.services``
+submodule does not pull in every provider client at CLI startup.
+"""
+
+import importlib
+from typing import Any
+
+_LAZY_EXPORTS = {
+    "EmbeddingProviderFactory": ".embedding_factory",
+    "RAGContextExtractor": ".rag_context_extractor",
+    "ClaudeIntegrationService": ".claude_integration",
+    "check_claude_sdk_availability": ".claude_integration",
+}
 
 __all__ = [
     "EmbeddingProviderFactory",
@@ -10,3 +20,12 @@ __all__ = [
     "ClaudeIntegrationService",
     "check_claude_sdk_availability",
 ]
+
+
+def __getattr__(name: str) -> Any:
+    module_name = _LAZY_EXPORTS.get(name)
+    if module_name is None:
+        raise AttributeError(f"module {__name__!r} has no attribute {name!r}")
+    value = getattr(importlib.import_module(module_name, __name__), name)
+    globals()[name] = value
+    return value
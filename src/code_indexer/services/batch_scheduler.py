@@ -0,0 +1,163 @@
+"""
+Latency-aware sizing of embedding batches.
+
+Embedding batches are cut when their token count reaches a fraction of the
+model's token limit (VectorCalculationManager.batch_token_fraction). The best
+fraction depends on the model and the provider's current load: oversized
+batches can take so long that they stall workers and approach the vector
+processing timeout, while tiny batches waste per-request overhead.
+
+The scheduler measures every completed batch and keeps an exponentially
+weighted estimate of seconds per token. Only batches that used at least half
+of the current token budget count: smaller batches (small files) are dominated
+by fixed request overhead and say nothing about oversized batches. From the
+estimate it derives the batch size that should complete in the configured
+target latency:
+
+- If batches run slower than the target, the fraction shrinks, so files are
+  split into more and smaller batches.
+- If batches run faster than the target, the fraction grows back, so chunks
+  are merged into fewer and larger batches.
+
+Changes are rate limited per adjustment and ignored inside a tolerance band so
+batch sizes do not oscillate.
+"""
+
+import logging
+import threading
+from typing import Any, Dict, Optional
+
+logger = logging.getLogger(__name__)
+
+# Constants
+CHARS_PER_TOKEN = 4  # Same rough estimate FileChunkingManager uses for batching
+LATENCY_SMOOTHING = 0.3  # EWMA weight of the newest batch
+MIN_SAMPLES_BEFORE_ADJUSTING = 3
+FULL_BATCH_RATIO = 0.5  # Share of the token budget a batch must use to count
+LATENCY_TOLERANCE = 0.2  # No change while within +/-20% of the target
+MAX_SHRINK_FACTOR = 0.5  # Fraction at most halves per adjustment
+MAX_GROW_FACTOR = 1.5
+MIN_BATCH_TOKEN_FRACTION = 0.05
+MAX_BATCH_TOKEN_FRACTION = 0.9  # Same 90% safety margin as VoyageAI client
+
+
+class LatencyAwareBatchScheduler:
+    """Adjusts the batch token fraction to keep batch latency near a target."""
+
+    def __init__(
+        self,
+        target_latency_seconds: float,
+        model_token_limit: int,
+        initial_batch_token_fraction: float = MAX_BATCH_TOKEN_FRACTION,
+    ):
+        """
+        Initialize the scheduler.
+
+        Args:
+            target_latency_seconds: Desired provider latency per batch
+            model_token_limit: Embedding model's per-request token limit
+            initial_batch_token_fraction: Starting fraction of model token limit
+
+        Raises:
+            ValueError: If target latency or token limit is not positive
+        """
+        if target_latency_seconds <= 0:
+            raise ValueError(
+                f"target_latency_seconds must be positive, got {target_latency_seconds}"
+            )
+        if model_token_limit <= 0:
+            raise ValueError(
+                f"model_token_limit must be positive, got {model_token_limit}"
+            )
+
+        self.target_latency_seconds = target_latency_seconds
+        self.model_token_limit = model_token_limit
+        self.batch_token_fraction = min(
+            MAX_BATCH_TOKEN_FRACTION,
+            max(MIN_BATCH_TOKEN_FRACTION, initial_batch_token_fraction),
+        )
+
+        self._lock = threading.Lock()
+        self._seconds_per_token: Optional[float] = None
+        self._average_latency: Optional[float] = None
+        self._samples = 0
+        self._splits = 0
+        self._merges = 0
+
+    def record_batch(self, num_chars: int, latency_seconds: float) -> float:
+        """
+        Record a completed batch and return the (possibly new) fraction.
+
+        Args:
+            num_chars: Total characters of the batch's chunk texts
+            latency_seconds: Provider latency of the batch
+
+        Returns:
+            Batch token fraction to use for the next batches
+        """
+        tokens = num_chars / CHARS_PER_TOKEN
+        if tokens <= 0 or latency_seconds <= 0:
+            return self.batch_token_fraction
+
+        with self._lock:
+            budget_tokens = self.batch_token_fraction * self.model_token_limit
+            if tokens < budget_tokens * FULL_BATCH_RATIO:
+                return self.batch_token_fraction
+
+            self._samples += 1
+            self._seconds_per_token = _smooth(
+                self._seconds_per_token, latency_seconds / tokens
+            )
+            self._average_latency = _smooth(self._average_latency, latency_seconds)
+
+            if self._samples >= MIN_SAMPLES_BEFORE_ADJUSTING:
+                self._adjust()
+            return self.batch_token_fraction
+
+    def _adjust(self) -> None:
+        """Move the fraction towards the size predicted to hit the target."""
+        if self._seconds_per_token is None:
+            return
+        predicted_latency = (
+            self._seconds_per_token * self.batch_token_fraction * self.model_token_limit
+        )
+        ratio = predicted_latency / self.target_latency_seconds
+        if abs(ratio - 1.0) <= LATENCY_TOLERANCE:
+            return
+
+        factor = min(MAX_GROW_FACTOR, max(MAX_SHRINK_FACTOR, 1.0 / ratio))
+        new_fraction = min(
+            MAX_BATCH_TOKEN_FRACTION,
+            max(MIN_BATCH_TOKEN_FRACTION, self.batch_token_fraction * factor),
+        )
+        if new_fraction < self.batch_token_fraction:
+            self._splits += 1
+        elif new_fraction > self.batch_token_fraction:
+            self._merges += 1
+        else:
+            return
+
+        logger.debug(
+            f"Batch scheduler: predicted {predicted_latency:.1f}s per batch "
+            f"(target {self.target_latency_seconds:.1f}s), batch token budget "
+            f"{self.batch_token_fraction:.0%} -> {new_fraction:.0%}"
+        )
+        self.batch_token_fraction = new_fraction
+
+    def get_stats(self) -> Dict[str, Any]:
+        """Return a snapshot of scheduler statistics."""
+        with self._lock:
+            return {
+                "target_latency_seconds": self.target_latency_seconds,
+                "average_latency_seconds": self._average_latency,
+                "batch_token_fraction": self.batch_token_fraction,
+                "batches_measured": self._samples,
+                "splits": self._splits,
+                "merges": self._merges,
+            }
+
+
+def _smooth(previous: Optional[float], value: float) -> float:
+    if previous is None:
+        return value
+    return LATENCY_SMOOTHING * value + (1 - LATENCY_SMOOTHING) * previous
@@ -0,0 +1,376 @@
+"""
+Indexing and query benchmark behind ``cidx bench``.
+
+Runs the stages of an indexing run one after another against a scratch index
+(never the project's own), so each stage gets its own throughput number:
+
+- chunking: files -> chunks with the configured chunker (chunks/sec)
+- embedding: chunk batches through the embedding provider (embeddings/sec)
+- upsert: points into a FilesystemVectorStore (points/sec)
+- index build: HNSW/ID index finalization in end_indexing()
+- query: a fixed query set through FilesystemVectorStore.search() (p50/p95)
+
+Comparing results before and after a provider, backend or config change only
+means something when the corpus is the same, so by default a synthetic corpus
+is generated deterministically from a seed.
+"""
+
+import logging
+import math
+import random
+import time
+import uuid
+from concurrent.futures import ThreadPoolExecutor
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional, Sequence
+
+logger = logging.getLogger(__name__)
+
+# Constants
+DEFAULT_BENCH_FILES = 200
+DEFAULT_BENCH_SEED = 42
+DEFAULT_QUERY_REPEATS = 3
+BENCH_COLLECTION_NAME = "cidx-bench"
+BENCH_UPSERT_BATCH_SIZE = 100
+
+# Standard query set - keep stable so results stay comparable across versions
+BENCH_QUERIES = (
+    "authentication and session token validation",
+    "retry failed http request with exponential backoff",
+    "parse configuration file and apply defaults",
+    "database connection pool",
+    "cache eviction policy",
+    "serialize object to json",
+    "handle file upload and store to disk",
+    "compute checksum of file contents",
+    "schedule background job",
+    "validate user input and report errors",
+    "rate limiting",
+    "walk directory tree and filter ignored files",
+)
+
+# Vocabulary for the synthetic corpus
+_DOMAINS = (
+    "auth",
+    "cache",
+    "config",
+    "database",
+    "http",
+    "jobs",
+    "storage",
+    "users",
+    "billing",
+    "search",
+)
+_VERBS = (
+    "load",
+    "save",
+    "validate",
+    "parse",
+    "fetch",
+    "update",
+    "delete",
+    "compute",
+    "retry",
+    "schedule",
+)
+_NOUNS = (
+    "token",
+    "session",
+    "record",
+    "request",
+    "checksum",
+    "entry",
+    "payload",
+    "connection",
+    "policy",
+    "settings",
+)
+
+
+@dataclass
+class BenchmarkResult:
+    """Timings and throughput of one benchmark run."""
+
+    corpus: str
+    provider: str
+    model: str
+    files: int = 0
+    bytes_read: int = 0
+    chunks: int = 0
+    chunk_seconds: float = 0.0
+    embeddings: int = 0
+    embed_seconds: float = 0.0
+    points_upserted: int = 0
+    upsert_seconds: float = 0.0
+    index_build_seconds: float = 0.0
+    query_latencies_ms: List[float] = field(default_factory=list)
+    elapsed_seconds: float = 0.0
+
+    @property
+    def chunks_per_second(self) -> float:
+        return _rate(self.chunks, self.chunk_seconds)
+
+    @property
+    def embeddings_per_second(self) -> float:
+        return _rate(self.embeddings, self.embed_seconds)
+
+    @property
+    def upserts_per_second(self) -> float:
+        return _rate(self.points_upserted, self.upsert_seconds)
+
+    @property
+    def query_p50_ms(self) -> float:
+        return percentile(self.query_latencies_ms, 50)
+
+    @property
+    def query_p95_ms(self) -> float:
+        return percentile(self.query_latencies_ms, 95)
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = asdict(self)
+        data.update(
+            chunks_per_second=self.chunks_per_second,
+            embeddings_per_second=self.embeddings_per_second,
+            upserts_per_second=self.upserts_per_second,
+            query_p50_ms=self.query_p50_ms,
+            query_p95_ms=self.query_p95_ms,
+        )
+        return data
+
+
+def _rate(count: int, seconds: float) -> float:
+    return count / seconds if seconds > 0 else 0.0
+
+
+def percentile(values: Sequence[float], pct: float) -> float:
+    """Nearest-rank percentile (0.0 for no values)."""
+    if not values:
+        return 0.0
+    ordered = sorted(values)
+    rank = max(1, math.ceil(len(ordered) * pct / 100))
+    return float(ordered[min(rank, len(ordered)) - 1])
+
+
+def generate_synthetic_corpus(
+    target_dir: Path,
+    num_files: int = DEFAULT_BENCH_FILES,
+    seed: int = DEFAULT_BENCH_SEED,
+) -> List[Path]:
+    """
+    Write a deterministic corpus of Python source files.
+
+    The same (num_files, seed) always produces byte-identical files, so runs
+    on different machines or versions index the same content.
+
+    Returns:
+        Paths of the generated files, sorted
+    """
+    rng = random.Random(seed)
+    files: List[Path] = []
+
+    for i in range(num_files):
+        domain = _DOMAINS[i % len(_DOMAINS)]
+        module_dir = target_dir / domain
+        module_dir.mkdir(parents=True, exist_ok=True)
+        path = module_dir / f"{domain}_module_{i:04d}.py"
+        path.write_text(_synthetic_module(rng, domain, i))
+        files.append(path)
+
+    return sorted(files)
+
+
+def _synthetic_module(rng: random.Random, domain: str, index: int) -> str:
+    class_name = f"{domain.capitalize()}Service{index}"
+    lines = [
+        f'"""{domain.capitalize()} service module {index}."""',
+        "",
+        "import logging",
+        "",
+        "logger = logging.getLogger(__name__)",
+        "",
+        "",
+        f"class {class_name}:",
+        f'    """Manages {domain} {rng.choice(_NOUNS)}s."""',
+        "",
+        "    def __init__(self, store, max_retries=3):",
+        "        self.store = store",
+        "        self.max_retries = max_retries",
+    ]
+
+    for _ in range(rng.randint(4, 12)):
+        verb = rng.choice(_VERBS)
+        noun = rng.choice(_NOUNS)
+        other = rng.choice(_NOUNS)
+        lines += [
+            "",
+            f"    def {verb}_{noun}(self, {noun}_id, {other}=None):",
+            f'        """{verb.capitalize()} the {noun} for a {domain} {other}."""',
+            "        for attempt in range(self.max_retries):",
+            "            try:",
+            f"                {noun} = self.store.get('{domain}', {noun}_id)",
+            f"                if {noun} is None:",
+            f"                    raise KeyError({noun}_id)",
+            f"                return self._apply_{verb}({noun}, {other})",
+            "            except ConnectionError as e:",
+            f"                logger.warning('{verb} {noun} attempt %d: %s', attempt, e)",
+            f"        raise RuntimeError('{verb} {noun} failed after retries')",
+        ]
+
+    return "\n".join(lines) + "\n"
+
+
+def find_corpus_files(config: Any, corpus_dir: Path) -> List[Path]:
+    """Files under corpus_dir that 'cidx index' would index with this config."""
+    from ..indexing.file_finder import FileFinder
+
+    corpus_config = config.model_copy(update={"codebase_dir": corpus_dir})
+    return sorted(FileFinder(corpus_config).find_files())
+
+
+def run_benchmark(
+    config: Any,
+    embedding_provider: Any,
+    corpus_dir: Path,
+    files: List[Path],
+    work_dir: Path,
+    queries: Sequence[str] = BENCH_QUERIES,
+    query_repeats: int = DEFAULT_QUERY_REPEATS,
+    batch_size: Optional[int] = None,
+    parallel_requests: Optional[int] = None,
+    progress_callback: Optional[Callable[[str], None]] = None,
+) -> BenchmarkResult:
+    """
+    Index files into a scratch collection and run the query set against it.
+
+    Args:
+        config: Project configuration (chunker settings, provider batching)
+        embedding_provider: Provider used for chunk and query embeddings
+        corpus_dir: Root the file paths are stored relative to
+        files: Files to index
+        work_dir: Scratch directory for the benchmark index (caller cleans up)
+        queries: Query set; each query runs query_repeats times
+        query_repeats: Runs per query
+        batch_size: Texts per embedding request (default: provider config)
+        parallel_requests: Concurrent embedding requests (default: provider
+            config)
+        progress_callback: Called with a short message as each stage starts
+
+    Returns:
+        BenchmarkResult with per-stage timings
+    """
+    from ..indexing.fixed_size_chunker import FixedSizeChunker
+    from ..storage.filesystem_vector_store import FilesystemVectorStore
+
+    def report(message: str) -> None:
+        if progress_callback:
+            progress_callback(message)
+
+    start = time.time()
+    result = BenchmarkResult(
+        corpus=str(corpus_dir),
+        provider=embedding_provider.get_provider_name(),
+        model=embedding_provider.get_current_model(),
+    )
+    batch_size = batch_size or config.voyage_ai.batch_size
+    parallel_requests = parallel_requests or config.voyage_ai.parallel_requests
+
+    # Stage 1: chunking
+    report(f"Chunking {len(files)} files")
+    chunker = FixedSizeChunker(config)
+    chunks: List[Dict[str, Any]] = []
+    stage_start = time.perf_counter()
+    for file_path in files:
+        try:
+            file_chunks = chunker.chunk_file(file_path)
+        except ValueError as e:
+            logger.warning(f"Benchmark skipped {file_path}: {e}")
+            continue
+        relative_path = str(file_path.relative_to(corpus_dir))
+        for chunk in file_chunks:
+            chunk["file_path"] = relative_path
+        chunks.extend(file_chunks)
+        result.files += 1
+        result.bytes_read += file_path.stat().st_size
+    result.chunk_seconds = time.perf_counter() - stage_start
+    result.chunks = len(chunks)
+
+    if not chunks:
+        raise ValueError(f"No indexable content found in {corpus_dir}")
+
+    # Stage 2: embedding
+    report(f"Embedding {len(chunks)} chunks")
+    batches = [
+        [chunk["text"] for chunk in chunks[i : i + batch_size]]
+        for i in range(0, len(chunks), batch_size)
+    ]
+    stage_start = time.perf_counter()
+    with ThreadPoolExecutor(max_workers=max(1, parallel_requests)) as executor:
+        vectors = [
+            vector
+            for batch_vectors in executor.map(
+                embedding_provider.get_embeddings_batch, batches
+            )
+            for vector in batch_vectors
+        ]
+    result.embed_seconds = time.perf_counter() - stage_start
+    result.embeddings = len(vectors)
+
+    # Stage 3: upsert into a scratch store (no git, chunk text stored inline)
+    report(f"Upserting {len(vectors)} points")
+    vector_store = FilesystemVectorStore(
+        base_path=work_dir / "index", project_root=work_dir
+    )
+    vector_store.create_collection(BENCH_COLLECTION_NAME, vector_size=len(vectors[0]))
+    points = [
+        {
+            "id": str(
+                uuid.uuid5(
+                    uuid.NAMESPACE_URL, f"{chunk['file_path']}:{chunk['chunk_index']}"
+                )
+            ),
+            "vector": vector,
+            "payload": {
+                "path": chunk["file_path"],
+                "chunk_index": chunk["chunk_index"],
+                "line_start": chunk["line_start"],
+                "line_end": chunk["line_end"],
+                "language": chunk["file_extension"] or "txt",
+                "type": "content",
+            },
+            "chunk_text": chunk["text"],
+        }
+        for chunk, vector in zip(chunks, vectors)
+    ]
+    stage_start = time.perf_counter()
+    vector_store.begin_indexing(BENCH_COLLECTION_NAME)
+    for i in range(0, len(points), BENCH_UPSERT_BATCH_SIZE):
+        vector_store.upsert_points(
+            BENCH_COLLECTION_NAME, points[i : i + BENCH_UPSERT_BATCH_SIZE]
+        )
+    result.upsert_seconds = time.perf_counter() - stage_start
+    result.points_upserted = len(points)
+
+    report("Building indexes")
+    stage_start = time.perf_counter()
+    vector_store.end_indexing(BENCH_COLLECTION_NAME)
+    result.index_build_seconds = time.perf_counter() - stage_start
+
+    # Stage 4: queries (embedding + search, as 'cidx query' does)
+    report(f"Running {len(queries) * query_repeats} queries")
+    for _ in range(query_repeats):
+        for query in queries:
+            stage_start = time.perf_counter()
+            vector_store.search(
+                query=query,
+                embedding_provider=embedding_provider,
+                collection_name=BENCH_COLLECTION_NAME,
+                limit=10,
+            )
+            result.query_latencies_ms.append(
+                (time.perf_counter() - stage_start) * 1000
+            )
+
+    result.elapsed_seconds = time.time() - start
+    return result
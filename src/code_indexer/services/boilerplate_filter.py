@@ -0,0 +1,405 @@
+"""
+Removal of low-information boilerplate from the text that is embedded.
+
+License headers, long import blocks and trivial getters/setters make chunks
+of unrelated files look alike to the embedding model, which inflates their
+similarity scores. When indexing.boilerplate_filter is enabled, these parts
+are removed from the text sent to the embedding provider only: the stored
+chunk text, shown in query results and indexed for FTS, stays complete.
+
+Comment, import and accessor syntax depends on the chunk's language. Chunks
+of languages without known syntax, and chunks that would be empty after
+filtering, are embedded unchanged.
+"""
+
+import logging
+import re
+import threading
+from dataclasses import dataclass
+from typing import Any, Callable, Dict, List, Optional, Pattern, Tuple
+
+logger = logging.getLogger(__name__)
+
+# Chunk key holding the text to embed when it differs from "text"
+EMBEDDING_TEXT_KEY = "embedding_text"
+
+FILTER_NAMES = ("license_headers", "imports", "accessors")
+
+# Distinct phrases of license headers; two are required so ordinary
+# comments that mention a license are kept
+_LICENSE_MARKERS = [
+    re.compile(pattern, re.IGNORECASE)
+    for pattern in (
+        r"copyright|\(c\)",
+        r"licen[cs]e",
+        r"spdx-license-identifier",
+        r"all rights reserved",
+        r"permission is hereby granted",
+        r"warrant(?:y|ies)",
+        r"redistribution",
+    )
+]
+
+_MEMBER = r"(?:this\.|this->|\$this->|self\.)?\$?\w+"
+
+# Trivial getX()/isX()/setX(v) bodies in brace languages, one or three lines.
+# Up to six modifier/type tokens may precede the name.
+_BRACE_ACCESSOR = re.compile(
+    r"^[ \t]*(?:@\w+[ \t]*\n[ \t]*)*"
+    r"(?:[\w<>\[\]?,.:*&$]+[ \t]+){0,6}"
+    r"(?:get|is|set)[A-Z_]\w*[ \t]*\([^()\n]*\)[ \t]*"
+    r"(?:const[ \t]*)?(?::[ \t]*\??[\w<>\[\]]+[ \t]*)?"
+    rf"\{{\s*(?:return[ \t]+{_MEMBER}|{_MEMBER}[ \t]*=[ \t]*\$?\w+)[ \t]*;\s*\}}"
+    r"[ \t]*(?:\n|$)",
+    re.MULTILINE,
+)
+
+_JS_ACCESSOR = re.compile(
+    r"^[ \t]*(?:static[ \t]+)?(?:get|set)[ \t]+\w+[ \t]*\([^()\n]*\)[ \t]*"
+    r"\{\s*(?:return[ \t]+this\.\w+|this\.\w+[ \t]*=[ \t]*\w+)[ \t]*;?\s*\}"
+    r"[ \t]*(?:\n|$)",
+    re.MULTILINE,
+)
+
+_PYTHON_ACCESSOR = re.compile(
+    r"^[ \t]*@(?:property|\w+\.setter)[ \t]*\n"
+    r"[ \t]*def[ \t]+\w+\(self[^)]*\)[^:\n]*:[ \t]*\n"
+    r"[ \t]*(?:return[ \t]+self\.\w+|self\.\w+[ \t]*=[ \t]*\w+)[ \t]*(?:\n|$)",
+    re.MULTILINE,
+)
+
+
+@dataclass(frozen=True)
+class _Syntax:
+    """Boilerplate syntax of a language family."""
+
+    line_comments: Tuple[str, ...]
+    block_comment: Optional[Tuple[str, str]]
+    import_line: Optional[Pattern[str]]
+    accessor: Optional[Pattern[str]]
+
+
+_C_COMMENT = ("/*", "*/")
+
+_SYNTAX: Dict[str, _Syntax] = {
+    "python": _Syntax(
+        ("#",),
+        None,
+        re.compile(r"\s*(?:import\s+\w|from\s+[\w.]+\s+import\b)"),
+        _PYTHON_ACCESSOR,
+    ),
+    "javascript": _Syntax(
+        ("//",),
+        _C_COMMENT,
+        re.compile(
+            r"\s*(?:import[\s{*'\"]|export\s+(?:\*|\{[^}]*\})\s+from\s"
+            r"|(?:const|let|var)\s+[\w{}\s,]+=\s*require\()"
+        ),
+        _JS_ACCESSOR,
+    ),
+    "jvm": _Syntax(
+        ("//",),
+        _C_COMMENT,
+        re.compile(r"\s*import\s+(?:static\s+)?[\w.*]+\s*;?\s*$"),
+        _BRACE_ACCESSOR,
+    ),
+    "csharp": _Syntax(
+        ("//",),
+        _C_COMMENT,
+        re.compile(r"\s*(?:global\s+)?using\s+(?:static\s+)?[\w.=\s]+;\s*$"),
+        _BRACE_ACCESSOR,
+    ),
+    "c": _Syntax(
+        ("//",),
+        _C_COMMENT,
+        re.compile(r"\s*(?:#\s*(?:include|import)\b|@import\s)"),
+        _BRACE_ACCESSOR,
+    ),
+    "go": _Syntax(("//",), _C_COMMENT, re.compile(r"\s*import\b"), None),
+    "rust": _Syntax(
+        ("//",),
+        _C_COMMENT,
+        re.compile(r"\s*(?:(?:pub(?:\([^)]*\))?\s+)?use\s|extern\s+crate\s)"),
+        None,
+    ),
+    "php": _Syntax(
+        ("//", "#"),
+        _C_COMMENT,
+        re.compile(r"\s*(?:use\s+[\w\\]+|(?:require|include)(?:_once)?\b)"),
+        _BRACE_ACCESSOR,
+    ),
+    "ruby": _Syntax(("#",), None, re.compile(r"\s*require(?:_relative)?\s"), None),
+    "swift": _Syntax(("//",), _C_COMMENT, re.compile(r"\s*import\s+\w"), None),
+    "dart": _Syntax(
+        ("//",),
+        _C_COMMENT,
+        re.compile(r"\s*(?:import|export|part)\s+['\"]"),
+        _BRACE_ACCESSOR,
+    ),
+    "julia": _Syntax(
+        ("#",), ("#=", "=#"), re.compile(r"\s*(?:using|import)\s+\w"), None
+    ),
+    "r": _Syntax(("#",), None, re.compile(r"\s*(?:library|require)\s*\("), None),
+    "shell": _Syntax(("#",), None, None, None),
+    "sql": _Syntax(("--",), _C_COMMENT, None, None),
+    "verilog": _Syntax(
+        ("//",), _C_COMMENT, re.compile(r"\s*(?:`include\b|import\s+\w+::)"), None
+    ),
+    "vhdl": _Syntax(
+        ("--",), _C_COMMENT, re.compile(r"\s*(?:library|use)\s+\w", re.I), None
+    ),
+    "markup": _Syntax((), ("<!--", "-->"), None, None),
+}
+
+# Language token (chunk "file_extension") -> syntax family
+_LANGUAGE_FAMILIES: Dict[str, str] = {
+    "py": "python",
+    "pyi": "python",
+    "pyw": "python",
+    "js": "javascript",
+    "jsx": "javascript",
+    "mjs": "javascript",
+    "cjs": "javascript",
+    "ts": "javascript",
+    "tsx": "javascript",
+    "java": "jvm",
+    "kt": "jvm",
+    "kts": "jvm",
+    "scala": "jvm",
+    "groovy": "jvm",
+    "gradle": "jvm",
+    "cs": "csharp",
+    "c": "c",
+    "h": "c",
+    "cpp": "c",
+    "cc": "c",
+    "cxx": "c",
+    "hpp": "c",
+    "hxx": "c",
+    "m": "c",
+    "mm": "c",
+    "go": "go",
+    "rs": "rust",
+    "php": "php",
+    "rb": "ruby",
+    "rake": "ruby",
+    "swift": "swift",
+    "dart": "dart",
+    "jl": "julia",
+    "r": "r",
+    "sh": "shell",
+    "bash": "shell",
+    "zsh": "shell",
+    "sql": "sql",
+    "v": "verilog",
+    "vh": "verilog",
+    "sv": "verilog",
+    "svh": "verilog",
+    "vhd": "vhdl",
+    "vhdl": "vhdl",
+    "html": "markup",
+    "htm": "markup",
+    "xml": "markup",
+    "xsd": "markup",
+}
+
+
+class BoilerplateFilter:
+    """Removes boilerplate from the text embedded for chunks."""
+
+    def __init__(self, filters: Optional[List[str]] = None, min_import_lines: int = 10):
+        """
+        Initialize the filter.
+
+        Args:
+            filters: Names from FILTER_NAMES to apply (default: all)
+            min_import_lines: Import blocks with at least this many non-blank
+                lines are removed
+
+        Raises:
+            ValueError: If a filter name is unknown
+        """
+        self.filters = list(FILTER_NAMES) if filters is None else list(filters)
+        for name in self.filters:
+            if name not in FILTER_NAMES:
+                raise ValueError(f"Unknown boilerplate filter: {name}")
+        self.min_import_lines = min_import_lines
+
+        self._lock = threading.Lock()
+        self._removed: Dict[str, int] = {name: 0 for name in self.filters}
+        self._chunks_filtered = 0
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["BoilerplateFilter"]:
+        """Filter from indexing.boilerplate_filter, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        filter_config = getattr(indexing_config, "boilerplate_filter", None)
+        if getattr(filter_config, "enabled", False) is not True:
+            return None
+        return cls(
+            filters=list(filter_config.filters),
+            min_import_lines=filter_config.min_import_lines,
+        )
+
+    def filter_text(self, text: str, language: str, starts_file: bool = False) -> str:
+        """
+        Remove boilerplate from one piece of text.
+
+        Args:
+            text: Chunk text
+            language: Language token of the chunk ("py", "java", ...)
+            starts_file: Whether the text starts at the top of the file,
+                where license headers are
+
+        Returns:
+            Text to embed; the original text when nothing is removed or
+            nothing would remain
+        """
+        family = _LANGUAGE_FAMILIES.get(language.lower())
+        if family is None:
+            return text
+        syntax = _SYNTAX[family]
+
+        steps: List[Tuple[str, Callable[[str, _Syntax], str]]] = []
+        if "license_headers" in self.filters and starts_file:
+            steps.append(("license_headers", self._strip_license_header))
+        if "imports" in self.filters and syntax.import_line is not None:
+            steps.append(("imports", self._strip_import_blocks))
+        if "accessors" in self.filters and syntax.accessor is not None:
+            steps.append(("accessors", self._strip_accessors))
+
+        filtered = text
+        removed: List[str] = []
+        for name, step in steps:
+            result = step(filtered, syntax)
+            if result != filtered:
+                removed.append(name)
+                filtered = result
+
+        if not removed or not filtered.strip():
+            return text
+        with self._lock:
+            for name in removed:
+                self._removed[name] += 1
+        return filtered
+
+    def filter_chunks(self, chunks: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+        """Return the chunks with EMBEDDING_TEXT_KEY set where text was removed."""
+        filtered_chunks = []
+        for chunk in chunks:
+            text = self.filter_text(
+                chunk["text"],
+                chunk.get("file_extension") or "",
+                starts_file=chunk.get("line_start") == 1,
+            )
+            if text != chunk["text"]:
+                chunk = {**chunk, EMBEDDING_TEXT_KEY: text}
+                with self._lock:
+                    self._chunks_filtered += 1
+            filtered_chunks.append(chunk)
+        return filtered_chunks
+
+    def get_stats(self) -> Dict[str, Any]:
+        """Return filtered chunk counts and removals per filter."""
+        with self._lock:
+            return {
+                "chunks_filtered": self._chunks_filtered,
+                "removed": dict(self._removed),
+            }
+
+    def _strip_license_header(self, text: str, syntax: _Syntax) -> str:
+        """Remove the first comment block if it is a license header."""
+        lines = text.split("\n")
+        start = 1 if lines[0].startswith("#!") else 0
+        while start < len(lines) and not lines[start].strip():
+            start += 1
+        if start == len(lines):
+            return text
+
+        end = self._comment_block_end(lines, start, syntax)
+        if end == start:
+            return text
+        header = "\n".join(lines[start:end])
+        if sum(1 for marker in _LICENSE_MARKERS if marker.search(header)) < 2:
+            return text
+
+        while end < len(lines) and not lines[end].strip():
+            end += 1
+        return "\n".join(lines[:start] + lines[end:])
+
+    @staticmethod
+    def _comment_block_end(lines: List[str], start: int, syntax: _Syntax) -> int:
+        """Index after the comment block starting at lines[start]."""
+        first = lines[start].lstrip()
+        if syntax.block_comment and first.startswith(syntax.block_comment[0]):
+            opener, closer = syntax.block_comment
+            if closer in first[len(opener) :]:
+                return start + 1
+            for i in range(start + 1, len(lines)):
+                if closer in lines[i]:
+                    return i + 1
+            # Unterminated in this chunk: the header continues past it
+            return start
+
+        end = start
+        while (
+            end < len(lines)
+            and syntax.line_comments
+            and lines[end].lstrip().startswith(syntax.line_comments)
+        ):
+            end += 1
+        return end
+
+    @staticmethod
+    def _strip_accessors(text: str, syntax: _Syntax) -> str:
+        """Remove trivial getters and setters."""
+        assert syntax.accessor is not None
+        return syntax.accessor.sub("", text)
+
+    def _strip_import_blocks(self, text: str, syntax: _Syntax) -> str:
+        """Remove runs of import statements of at least min_import_lines lines."""
+        assert syntax.import_line is not None
+        kept: List[str] = []
+        block: List[str] = []
+        # Lines of the block up to its last import line
+        block_imports_end = 0
+        depth = 0
+
+        def flush() -> None:
+            nonlocal block, block_imports_end
+            imports = block[:block_imports_end]
+            trailing = block[block_imports_end:]
+            if sum(1 for line in imports if line.strip()) < self.min_import_lines:
+                kept.extend(imports)
+            else:
+                # Drop the blank lines separating the block from what follows
+                while trailing and not trailing[0].strip():
+                    trailing.pop(0)
+            kept.extend(trailing)
+            block = []
+            block_imports_end = 0
+
+        for line in text.split("\n"):
+            if depth > 0 or syntax.import_line.match(line):
+                # Multi-line imports: from x import (, import {, import (
+                depth = max(0, depth + _bracket_delta(line))
+                block.append(line)
+                block_imports_end = len(block)
+                continue
+            stripped = line.strip()
+            if block and (
+                not stripped
+                or (syntax.line_comments and stripped.startswith(syntax.line_comments))
+            ):
+                block.append(line)
+                continue
+            if block:
+                flush()
+            kept.append(line)
+        if block:
+            flush()
+        return "\n".join(kept)
+
+
+def _bracket_delta(line: str) -> int:
+    return line.count("(") + line.count("{") - line.count(")") - line.count("}")
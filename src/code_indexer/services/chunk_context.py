@@ -0,0 +1,351 @@
+"""
+Enclosing-context headers for the text that is embedded.
+
+A method chunked on its own loses the class, receiver and package it belongs
+to, so a question such as "how does the cart service apply discounts" does
+not match the method that does. When indexing.chunk_context is enabled, the
+text sent to the embedding provider starts with a header synthesized from the
+rest of the file:
+
+    Package: com.shop.cart
+    Imports: java.util.List, com.shop.model.Item (+3 more)
+    Enclosing type: public class CartService extends BaseService
+
+- Package: the package or namespace statement (Go, Java, Kotlin, Scala,
+  Groovy, C#, PHP) or the dotted module path of a Python file
+- Imports: the modules the file imports, up to max_imports of them
+- Enclosing type: the declaration line of the classes, structs, interfaces
+  or impl blocks the chunk starts in; for Go, the type declaration of the
+  receiver of the function the chunk starts in
+
+Parts the chunk already contains (the chunk that holds the imports) are left
+out. The stored chunk text, shown in query results and indexed for FTS, is
+unchanged.
+"""
+
+import logging
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+from ..indexing.boundary_chunking import EMBEDDED_LANGUAGE_KEY
+from .boilerplate_filter import EMBEDDING_TEXT_KEY
+
+logger = logging.getLogger(__name__)
+
+BRACE_LANGUAGES = {
+    "java",
+    "kt",
+    "kts",
+    "scala",
+    "groovy",
+    "cs",
+    "php",
+    "js",
+    "jsx",
+    "mjs",
+    "cjs",
+    "ts",
+    "tsx",
+    "rs",
+    "swift",
+    "dart",
+    "cpp",
+    "cc",
+    "cxx",
+    "hpp",
+    "h",
+}
+
+_PACKAGES = {
+    "go": re.compile(r"^package\s+(\w+)"),
+    "java": re.compile(r"^package\s+([\w.]+)"),
+    "kt": re.compile(r"^package\s+([\w.]+)"),
+    "kts": re.compile(r"^package\s+([\w.]+)"),
+    "scala": re.compile(r"^package\s+([\w.]+)"),
+    "groovy": re.compile(r"^package\s+([\w.]+)"),
+    "cs": re.compile(r"^\s*namespace\s+([\w.]+)"),
+    "php": re.compile(r"^namespace\s+([\w\\]+)\s*;"),
+}
+
+_JVM_IMPORT = re.compile(r"^import\s+(?:static\s+)?([\w.*]+)")
+_JS_IMPORT = re.compile(
+    r"""^\s*(?:import\b[^'"]*?\bfrom\s*|import\s*"""
+    r"""|(?:const|let|var)\s+[^=]+=\s*require\(\s*)['"]([^'"]+)['"]"""
+)
+_IMPORTS = {
+    "py": re.compile(r"^(?:from\s+([\w.]+)\s+import|import\s+([\w.]+))"),
+    "java": _JVM_IMPORT,
+    "kt": _JVM_IMPORT,
+    "kts": _JVM_IMPORT,
+    "scala": _JVM_IMPORT,
+    "groovy": _JVM_IMPORT,
+    "cs": re.compile(r"^\s*using\s+(?:static\s+)?([\w.]+)\s*;"),
+    "php": re.compile(r"^use\s+([\w\\]+)"),
+    "rs": re.compile(r"^\s*(?:pub\s+)?use\s+([\w:]+)"),
+    "rb": re.compile(r"^\s*require(?:_relative)?\s*\(?\s*['\"]([^'\"]+)['\"]"),
+    "js": _JS_IMPORT,
+    "jsx": _JS_IMPORT,
+    "mjs": _JS_IMPORT,
+    "cjs": _JS_IMPORT,
+    "ts": _JS_IMPORT,
+    "tsx": _JS_IMPORT,
+}
+_GO_IMPORT = re.compile(r'^import\s+(?:[\w.]+\s+)?"([^"]+)"')
+_GO_IMPORT_BLOCK = re.compile(r"^import\s*\(")
+_GO_IMPORT_SPEC = re.compile(r'^\s*(?:[\w.]+\s+)?"([^"]+)"')
+
+_PY_CLASS = re.compile(r"^(\s*)class\s+\w+")
+_BRACE_TYPE = re.compile(
+    r"^\s*(?:[@\w<>\[\](),.\"'=:]+\s+)*?"
+    r"(?:class|interface|struct|enum|record|object|trait|impl|extension|protocol)"
+    r"\b[^;]*$"
+)
+# func (c *Cart) Total(), func (l List[T]) Len(), func New()
+_GO_FUNC = re.compile(r"^func\s*(?:\(\s*(?:\w+\s+)?\*?(\w+)(?:\[[^\]]*\])?\s*\))?")
+_STRINGS = re.compile(r""""(?:\\.|[^"\\])*"|'(?:\\.|[^'\\])*'|//.*|#.*""")
+
+Header = Tuple[Optional[str], List[str], Optional[int], Optional[int]]
+
+
+def package_of(
+    lines: List[str], language: str
+) -> Tuple[Optional[str], Optional[int]]:
+    """Package or namespace of a file and the index of its line."""
+    pattern = _PACKAGES.get(language)
+    if pattern is None:
+        return None, None
+    for i, line in enumerate(lines):
+        match = pattern.match(line)
+        if match:
+            return match.group(1), i
+    return None, None
+
+
+def imports_of(
+    lines: List[str], language: str
+) -> Tuple[List[str], Optional[int]]:
+    """Modules a file imports, in order, and the index of the first import line."""
+    imports: List[str] = []
+    first: Optional[int] = None
+    if language == "go":
+        in_block = False
+        for i, line in enumerate(lines):
+            if in_block:
+                if line.strip().startswith(")"):
+                    in_block = False
+                    continue
+                match = _GO_IMPORT_SPEC.match(line)
+            elif _GO_IMPORT_BLOCK.match(line):
+                in_block = True
+                first = i if first is None else first
+                continue
+            else:
+                match = _GO_IMPORT.match(line)
+            if match:
+                first = i if first is None else first
+                imports.append(match.group(1))
+        return _unique(imports), first
+    pattern = _IMPORTS.get(language)
+    if pattern is None:
+        return [], None
+    for i, line in enumerate(lines):
+        match = pattern.match(line)
+        if match:
+            first = i if first is None else first
+            imports.append(next(group for group in match.groups() if group))
+    return _unique(imports), first
+
+
+def enclosing_types(lines: List[str], line: int, language: str) -> List[str]:
+    """
+    Declaration lines of the types that line (0-based) is inside of,
+    outermost first.
+    """
+    if language == "py":
+        return _python_classes(lines, line)
+    if language == "go":
+        return _go_receiver(lines, line)
+    if language in BRACE_LANGUAGES:
+        return _brace_types(lines, line)
+    return []
+
+
+def _unique(names: List[str]) -> List[str]:
+    return list(dict.fromkeys(names))
+
+
+def _within(line: Optional[int], first: int, last: int) -> bool:
+    return line is not None and first <= line <= last
+
+
+def _signature(line: str) -> str:
+    return line.strip().rstrip("{").rstrip(":").strip()
+
+
+def _first_code_line(lines: List[str], line: int, end: int) -> int:
+    for i in range(line, min(end, len(lines))):
+        if lines[i].strip():
+            return i
+    return line
+
+
+def _python_classes(lines: List[str], line: int) -> List[str]:
+    if line >= len(lines):
+        return []
+    indent = len(lines[line]) - len(lines[line].lstrip())
+    classes: List[str] = []
+    for i in range(line - 1, -1, -1):
+        text = lines[i]
+        if not text.strip() or text.lstrip().startswith("#"):
+            continue
+        current = len(text) - len(text.lstrip())
+        if current >= indent:
+            continue
+        indent = current
+        if _PY_CLASS.match(text):
+            classes.append(_signature(text))
+        if indent == 0:
+            break
+    return classes[::-1]
+
+
+def _brace_depth(line: str) -> int:
+    code = _STRINGS.sub("", line)
+    return code.count("{") - code.count("}")
+
+
+def _brace_types(lines: List[str], line: int) -> List[str]:
+    types: List[str] = []
+    # Braces opened above the line and still open at it, innermost first
+    depth = 0
+    for i in range(line - 1, -1, -1):
+        depth += _brace_depth(lines[i])
+        if depth <= 0:
+            continue
+        # lines[i] opens a block around the line
+        declaration = i
+        if "{" in lines[i] and not lines[i].split("{")[0].strip() and i > 0:
+            declaration = i - 1  # Brace on a line of its own
+        if _BRACE_TYPE.match(lines[declaration]):
+            types.append(_signature(lines[declaration]))
+        depth = 0
+    return types[::-1]
+
+
+def _go_receiver(lines: List[str], line: int) -> List[str]:
+    receiver = None
+    for i in range(min(line, len(lines) - 1), -1, -1):
+        match = _GO_FUNC.match(lines[i])
+        if match:
+            receiver = match.group(1)
+            break
+        if lines[i].startswith("}") and i < line:
+            break  # Between functions
+    if receiver is None:
+        return []
+    name = re.escape(receiver)
+    # type Cart struct {, or Cart struct { inside a type ( ... ) group
+    declaration = re.compile(rf"^type\s+{name}\b|^\s+{name}\s+(?:struct|interface)\b")
+    for text in lines:
+        if declaration.match(text):
+            signature = _signature(text)
+            if not signature.startswith("type"):
+                signature = f"type {signature}"
+            return [signature]
+    return [f"receiver {receiver}"]
+
+
+class ChunkContextEnricher:
+    """Prefixes the embedded text of chunks with their enclosing context."""
+
+    def __init__(self, codebase_dir: Path, max_imports: int = 10):
+        """
+        Initialize the enricher.
+
+        Args:
+            codebase_dir: Project root, for the module paths of Python files
+            max_imports: Imports listed in a header before the rest are counted
+        """
+        self.codebase_dir = Path(codebase_dir).resolve()
+        self.max_imports = max_imports
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["ChunkContextEnricher"]:
+        """Enricher from indexing.chunk_context, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        context_config = getattr(indexing_config, "chunk_context", None)
+        if getattr(context_config, "enabled", False) is not True:
+            return None
+        return cls(Path(config.codebase_dir), context_config.max_imports)
+
+    def header(self, lines: List[str], language: str, file_path: Path) -> Header:
+        """Package, imports and the line indexes they start at."""
+        package, package_line = package_of(lines, language)
+        if package is None and language == "py":
+            package = self._module_path(file_path)
+        imports, imports_line = imports_of(lines, language)
+        return package, imports, package_line, imports_line
+
+    def context_text(
+        self, lines: List[str], chunk: Dict[str, Any], header: Header
+    ) -> str:
+        """Header text of one chunk; empty when there is no context to add."""
+        package, imports, package_line, imports_line = header
+        first = chunk.get("line_start", 1) - 1
+        last = chunk.get("line_end", first + 1) - 1
+        language = (chunk.get("file_extension") or "").lower()
+        parts = []
+        if package and not _within(package_line, first, last):
+            parts.append(f"Package: {package}")
+        if imports and not _within(imports_line, first, last):
+            listed = ", ".join(imports[: self.max_imports])
+            more = len(imports) - self.max_imports
+            if more > 0:
+                listed += f" (+{more} more)"
+            parts.append(f"Imports: {listed}")
+        start = _first_code_line(lines, first, last + 1)
+        for signature in enclosing_types(lines, start, language):
+            parts.append(f"Enclosing type: {signature}")
+        return "\n".join(parts)
+
+    def enrich_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks with their context in front of EMBEDDING_TEXT_KEY."""
+        if not chunks:
+            return chunks
+        try:
+            text = Path(file_path).read_bytes().decode("utf-8", errors="replace")
+        except OSError as e:
+            logger.debug(f"No chunk context for {file_path}: {e}")
+            return chunks
+        lines = text.split("\n")
+        headers: Dict[str, Header] = {}
+        enriched = []
+        for chunk in chunks:
+            language = (chunk.get("file_extension") or "").lower()
+            # Code embedded in a page or document has no file context
+            if chunk.get(EMBEDDED_LANGUAGE_KEY) or not language:
+                enriched.append(chunk)
+                continue
+            if language not in headers:
+                headers[language] = self.header(lines, language, Path(file_path))
+            context = self.context_text(lines, chunk, headers[language])
+            if context:
+                embedded = chunk.get(EMBEDDING_TEXT_KEY, chunk["text"])
+                chunk = {**chunk, EMBEDDING_TEXT_KEY: f"{context}\n\n{embedded}"}
+            enriched.append(chunk)
+        return enriched
+
+    def _module_path(self, file_path: Path) -> Optional[str]:
+        try:
+            relative = Path(file_path).resolve().relative_to(self.codebase_dir)
+        except ValueError:
+            return None
+        parts = list(relative.with_suffix("").parts)
+        if parts and parts[-1] == "__init__":
+            parts.pop()
+        if parts and parts[0] == "src":
+            parts.pop(0)
+        return ".".join(parts) or None
@@ -0,0 +1,48 @@
+"""
+Deterministic point IDs for content chunks.
+
+A chunk's point ID is derived from the repository (project_id), the chunk's
+path, its position in the file and the hash of its text - never from random
+values, the time of indexing or configuration such as the file hash
+algorithm. Re-indexing identical content therefore yields identical IDs, so
+indexes built from the same sources can be diffed point by point and
+exported reproducibly.
+
+A chunk is a fixed-size window or, for the languages in CHUNKER_ROUTES, a
+section or declaration, and its position (chunk_index) identifies it within
+its file. With declaration chunks an edit inside one declaration changes
+that chunk's ID only; adding or removing a declaration moves the chunks
+after it to new positions and so gives them new IDs. In fixed-size chunks
+any edit that changes the text length shifts every later window, so their
+IDs change as well. The path keeps identical files (vendored copies) from
+sharing points.
+
+Git history points (temporal collections) have their own deterministic IDs
+built from the commit hash, see TemporalIndexer.
+"""
+
+import uuid
+from typing import Tuple
+
+# Fixed namespace for chunk IDs; changing it changes every ID
+CHUNK_ID_NAMESPACE = uuid.UUID("5f0b6a3c-7d4e-5b8a-9c21-3e6f4d8a2b17")
+
+
+def compute_chunk_point_id(
+    project_id: str, path: str, chunk_index: int, chunk_hash: str
+) -> Tuple[str, str]:
+    """
+    Compute the point ID of a content chunk.
+
+    Args:
+        project_id: Repository identifier (FileIdentifier project_id)
+        path: Path of the chunk's file as stored in the payload
+        chunk_index: Position of the chunk within its file
+        chunk_hash: Hash of the chunk's stored text (compute_chunk_hash)
+
+    Returns:
+        Tuple of (point_id, unique_key): a UUID string and the human-readable
+        key it is derived from
+    """
+    unique_key = f"{project_id}:{path}:{chunk_index}:{chunk_hash}"
+    return str(uuid.uuid5(CHUNK_ID_NAMESPACE, unique_key)), unique_key
@@ -0,0 +1,230 @@
+"""
+Chunk provenance and integrity verification behind ``cidx verify --integrity``.
+
+Every content chunk records its provenance in the payload: the source path,
+the commit it was indexed at (git_commit_hash, git projects), the version of
+cidx that indexed it (indexer_version) and a hash of its text (chunk_hash).
+
+Verification checks each stored chunk twice:
+
+- integrity: the stored text still hashes to chunk_hash, so it was not
+  corrupted or edited after indexing
+- provenance: the stored text is still part of a tracked source - the file on
+  disk, its indexed git blob, or the file at its indexed commit
+
+Chunks stored as git blob pointers have no text of their own; git already
+content-addresses blobs, so only the blob's presence is checked. Chunks whose
+text was PII-scrubbed no longer appear verbatim in their source and are only
+checked for integrity. Chunks indexed before provenance was recorded have no
+chunk_hash and are only checked for provenance.
+"""
+
+import hashlib
+import logging
+import subprocess
+from dataclasses import asdict, dataclass, field
+from functools import lru_cache
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+logger = logging.getLogger(__name__)
+
+# Constants
+CHUNK_HASH_PREFIX = "sha256:"
+SOURCE_CACHE_SIZE = 256  # Decoded source files/blobs kept while verifying
+GIT_TIMEOUT_SECONDS = 30
+_SOURCE_ENCODINGS = ("utf-8", "utf-8-sig", "latin-1", "cp1252")  # As the chunker
+
+# Issue kinds
+ISSUE_UNREADABLE = "unreadable"  # Vector file cannot be read or decoded
+ISSUE_CORRUPTED = "corrupted"  # Stored text does not match chunk_hash
+ISSUE_UNTRACKED = "untracked"  # Stored text is not part of any tracked source
+ISSUE_MISSING_BLOB = "missing_blob"  # Git blob pointer without the blob
+
+
+def compute_chunk_hash(text: str) -> str:
+    """Hash of a chunk's text as stored in the chunk_hash payload field."""
+    return CHUNK_HASH_PREFIX + hashlib.sha256(text.encode("utf-8")).hexdigest()
+
+
+@dataclass
+class IntegrityIssue:
+    """One chunk that failed verification."""
+
+    point_id: str
+    path: str
+    kind: str
+    detail: str = ""
+
+
+@dataclass
+class IntegrityReport:
+    """Verification result of one collection."""
+
+    collection: str
+    chunks_checked: int = 0
+    chunks_verified: int = 0
+    chunks_skipped: int = 0  # Non-content points (e.g. commit history)
+    issues: List[IntegrityIssue] = field(default_factory=list)
+
+    @property
+    def ok(self) -> bool:
+        return not self.issues
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = asdict(self)
+        data["ok"] = self.ok
+        return data
+
+
+class ChunkIntegrityVerifier:
+    """Verifies stored chunks against their hashes and tracked sources."""
+
+    def __init__(self, vector_store: Any, project_root: Path):
+        """
+        Initialize the verifier.
+
+        Args:
+            vector_store: FilesystemVectorStore holding the collections
+            project_root: Project root that chunk paths are relative to
+        """
+        self.vector_store = vector_store
+        self.project_root = project_root
+        self._read_git_object = lru_cache(maxsize=SOURCE_CACHE_SIZE)(
+            self._read_git_object_uncached
+        )
+        self._read_working_file = lru_cache(maxsize=SOURCE_CACHE_SIZE)(
+            self._read_working_file_uncached
+        )
+
+    def verify_collection(self, collection_name: str) -> IntegrityReport:
+        """
+        Verify every chunk of a collection.
+
+        Args:
+            collection_name: Name of the collection
+
+        Returns:
+            IntegrityReport listing every chunk that failed verification
+        """
+        report = IntegrityReport(collection=collection_name)
+        for vector_file, data, error in self.vector_store.iter_vector_records(
+            collection_name
+        ):
+            if data is None:
+                report.chunks_checked += 1
+                report.issues.append(
+                    IntegrityIssue(
+                        point_id=vector_file.stem.replace("vector_", "", 1),
+                        path=str(vector_file),
+                        kind=ISSUE_UNREADABLE,
+                        detail=error or "",
+                    )
+                )
+                continue
+
+            payload = data.get("payload", {})
+            if payload.get("type", "content") != "content":
+                report.chunks_skipped += 1
+                continue
+
+            report.chunks_checked += 1
+            issue = self.verify_chunk(data)
+            if issue is None:
+                report.chunks_verified += 1
+            else:
+                report.issues.append(issue)
+
+        return report
+
+    def verify_chunk(self, data: Dict[str, Any]) -> Optional[IntegrityIssue]:
+        """
+        Verify one stored chunk.
+
+        Args:
+            data: Decoded vector file data (id, payload, chunk_text, ...)
+
+        Returns:
+            The problem found, or None if the chunk verified
+        """
+        payload = data.get("payload", {})
+        point_id = str(data.get("id", ""))
+        path = str(payload.get("path", ""))
+        blob_hash = data.get("git_blob_hash") or payload.get("git_blob_hash")
+
+        text = data.get("chunk_text", payload.get("content"))
+        if text is None:
+            # Blob pointer: the text lives in git's content-addressed store
+            if blob_hash and self._read_git_object(blob_hash) is not None:
+                return None
+            return IntegrityIssue(
+                point_id, path, ISSUE_MISSING_BLOB, f"git blob {blob_hash} not found"
+            )
+
+        stored_hash = payload.get("chunk_hash")
+        if stored_hash and compute_chunk_hash(text) != stored_hash:
+            return IntegrityIssue(
+                point_id, path, ISSUE_CORRUPTED, "stored text does not match chunk_hash"
+            )
+
+        if payload.get("pii_scrubbed"):
+            return None
+
+        for source in self._tracked_sources(payload, blob_hash):
+            if text in source:
+                return None
+        return IntegrityIssue(
+            point_id,
+            path,
+            ISSUE_UNTRACKED,
+            "stored text not found in working file, indexed blob or indexed commit",
+        )
+
+    def _tracked_sources(self, payload: Dict[str, Any], blob_hash: Optional[str]):
+        """Yield the texts a chunk may legitimately come from, cheapest first."""
+        path = payload.get("path")
+        if path:
+            working = self._read_working_file(str(path))
+            if working is not None:
+                yield working
+        if blob_hash:
+            blob = self._read_git_object(blob_hash)
+            if blob is not None:
+                yield blob
+        commit_hash = payload.get("git_commit_hash")
+        if path and commit_hash:
+            committed = self._read_git_object(f"{commit_hash}:{path}")
+            if committed is not None:
+                yield committed
+
+    def _read_working_file_uncached(self, path: str) -> Optional[str]:
+        try:
+            return _decode((self.project_root / path).read_bytes())
+        except OSError:
+            return None
+
+    def _read_git_object_uncached(self, object_name: str) -> Optional[str]:
+        try:
+            result = subprocess.run(
+                ["git", "cat-file", "-p", object_name],
+                cwd=self.project_root,
+                capture_output=True,
+                timeout=GIT_TIMEOUT_SECONDS,
+            )
+        except (OSError, subprocess.TimeoutExpired) as e:
+            logger.debug(f"Could not read git object {object_name}: {e}")
+            return None
+        if result.returncode != 0:
+            return None
+        return _decode(result.stdout)
+
+
+def _decode(raw: bytes) -> Optional[str]:
+    """Decode like the chunker reads files (text mode, universal newlines)."""
+    for encoding in _SOURCE_ENCODINGS:
+        try:
+            text = raw.decode(encoding)
+        except UnicodeDecodeError:
+            continue
+        return text.replace("\r\n", "\n").replace("\r", "\n")
+    return None
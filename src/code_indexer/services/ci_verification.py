@@ -0,0 +1,270 @@
+"""
+Index staleness gate for CI pipelines ('cidx verify --ci').
+
+Checks an index that a pipeline restored or shares (the team index) against
+the commit being built, without calling the embedding API:
+
+- the last indexing run completed
+- the index was built with the configured embedding model
+- the indexed commit is part of the checked-out history
+- no tracked file changed between the indexed commit and HEAD
+- every tracked file has chunks in the index
+
+The report names the action that brings the index back in line: an
+incremental 'cidx index', or a full 'cidx index --clear' when the index
+cannot be updated incrementally (other model, unknown history).
+"""
+
+import json
+import subprocess
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional
+
+from ..utils.git_runner import get_current_branch, get_current_commit, run_git_command
+from .index_consistency import ISSUE_MISSING_FILE, IndexConsistencyChecker
+
+# Actions recommended by a report, from least to most expensive
+ACTION_NONE = "none"
+ACTION_INDEX = "index"  # cidx index
+ACTION_REBUILD = "rebuild"  # cidx index --clear
+
+# Paths listed per check in the report
+MAX_LISTED_PATHS = 100
+
+
+@dataclass
+class CiCheck:
+    """Outcome of one check."""
+
+    name: str
+    ok: bool
+    detail: str = ""
+    # Action that fixes a failed check
+    action: str = ACTION_NONE
+    paths: List[str] = field(default_factory=list)
+    path_count: int = 0
+
+
+@dataclass
+class CiReport:
+    """Result of 'cidx verify --ci'."""
+
+    commit: Optional[str]
+    branch: Optional[str]
+    indexed_commit: Optional[str]
+    indexed_branch: Optional[str]
+    commits_behind: Optional[int]
+    model: str
+    indexed_model: Optional[str]
+    checks: List[CiCheck] = field(default_factory=list)
+
+    @property
+    def ok(self) -> bool:
+        return all(check.ok for check in self.checks)
+
+    @property
+    def action(self) -> str:
+        actions = {check.action for check in self.checks if not check.ok}
+        for action in (ACTION_REBUILD, ACTION_INDEX):
+            if action in actions:
+                return action
+        return ACTION_NONE
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = asdict(self)
+        data["ok"] = self.ok
+        data["action"] = self.action
+        return data
+
+
+def collection_name(model: str) -> str:
+    """Collection name the filesystem vector store uses for a model."""
+    return model.replace("/", "_").replace(":", "_")
+
+
+class CiIndexVerifier:
+    """Checks a local index against the checked-out commit."""
+
+    def __init__(
+        self,
+        project_root: Path,
+        metadata_path: Path,
+        model: str,
+        vector_store: Any,
+        tracked_files: Iterable[str],
+        max_stale_files: int = 0,
+    ):
+        """
+        Initialize the verifier.
+
+        Args:
+            project_root: Root of the checkout
+            metadata_path: Indexing metadata (.code-indexer/metadata.json)
+            model: Configured embedding model
+            vector_store: FilesystemVectorStore holding the index
+            tracked_files: Paths cidx would index, relative to project_root
+            max_stale_files: Changed tracked files tolerated before the index
+                counts as stale
+        """
+        self.project_root = project_root
+        self.metadata_path = metadata_path
+        self.model = model
+        self.vector_store = vector_store
+        self.tracked_files = set(tracked_files)
+        self.max_stale_files = max_stale_files
+
+    def verify(self) -> CiReport:
+        """Run all checks."""
+        metadata = self._metadata()
+        indexed_commit = metadata.get("current_commit")
+        indexed_branch = metadata.get("current_branch")
+        commit = get_current_commit(self.project_root)
+        report = CiReport(
+            commit=commit,
+            branch=get_current_branch(self.project_root),
+            indexed_commit=indexed_commit,
+            indexed_branch=indexed_branch,
+            commits_behind=None,
+            model=self.model,
+            indexed_model=metadata.get("embedding_model"),
+        )
+
+        model = self._check_model(report.indexed_model)
+        history = self._check_history(indexed_commit, commit, report)
+        report.checks.extend([self._check_status(metadata), model, history])
+        if history.ok and indexed_commit and commit:
+            report.checks.append(self._check_changed_files(indexed_commit, commit))
+        if model.ok:
+            # Reads every chunk of the collection; pointless for another model
+            report.checks.append(self._check_missing_files(indexed_branch))
+        return report
+
+    def _metadata(self) -> Dict[str, Any]:
+        try:
+            with open(self.metadata_path) as f:
+                metadata: Dict[str, Any] = json.load(f)
+            return metadata
+        except (OSError, json.JSONDecodeError):
+            return {}
+
+    def _check_status(self, metadata: Dict[str, Any]) -> CiCheck:
+        status = metadata.get("status", "not_started")
+        if status == "completed":
+            return CiCheck("index_complete", True, "last indexing run completed")
+        if status == "not_started":
+            return CiCheck(
+                "index_complete", False, "project was never indexed", ACTION_REBUILD
+            )
+        return CiCheck(
+            "index_complete",
+            False,
+            f"last indexing run is '{status}'",
+            ACTION_INDEX,
+        )
+
+    def _check_model(self, indexed_model: Optional[str]) -> CiCheck:
+        if indexed_model and indexed_model != self.model:
+            return CiCheck(
+                "embedding_model",
+                False,
+                f"index built with '{indexed_model}', configured model is "
+                f"'{self.model}'",
+                ACTION_REBUILD,
+            )
+        if collection_name(self.model) not in self.vector_store.list_collections():
+            return CiCheck(
+                "embedding_model",
+                False,
+                f"no collection for '{self.model}'",
+                ACTION_REBUILD,
+            )
+        return CiCheck("embedding_model", True, f"index built with '{self.model}'")
+
+    def _check_history(
+        self, indexed_commit: Optional[str], commit: Optional[str], report: CiReport
+    ) -> CiCheck:
+        if not commit:
+            return CiCheck("history", False, "not a git checkout", ACTION_REBUILD)
+        if not indexed_commit:
+            return CiCheck(
+                "history", False, "no indexed commit recorded", ACTION_REBUILD
+            )
+        known = self._git("cat-file", "-e", f"{indexed_commit}^{{commit}}")
+        if known is None:
+            return CiCheck(
+                "history",
+                False,
+                f"indexed commit {indexed_commit[:12]} is not in this checkout "
+                "(shallow clone or rewritten history)",
+                ACTION_REBUILD,
+            )
+        behind = self._git("rev-list", "--count", f"{indexed_commit}..{commit}")
+        report.commits_behind = int(behind) if behind else 0
+        if self._git("merge-base", "--is-ancestor", indexed_commit, commit) is None:
+            return CiCheck(
+                "history",
+                True,
+                f"indexed commit {indexed_commit[:12]} is on another branch, "
+                f"{report.commits_behind} commits not indexed",
+            )
+        return CiCheck(
+            "history", True, f"{report.commits_behind} commits since indexing"
+        )
+
+    def _check_changed_files(self, indexed_commit: str, commit: str) -> CiCheck:
+        output = self._git(
+            "diff", "--name-only", "--relative", "-z", indexed_commit, commit
+        )
+        # Deleted files are no longer tracked, but their chunks are stale too
+        changed = [
+            path
+            for path in (output or "").split("\0")
+            if path in self.tracked_files
+            or (path and not (self.project_root / path).exists())
+        ]
+        return self._path_check(
+            "changed_files",
+            changed,
+            ok=len(changed) <= self.max_stale_files,
+            detail=f"{len(changed)} indexed files changed since {indexed_commit[:12]}",
+        )
+
+    def _check_missing_files(self, indexed_branch: Optional[str]) -> CiCheck:
+        checker = IndexConsistencyChecker(
+            self.vector_store,
+            self.tracked_files,
+            indexed_branch or "master",
+            self.project_root,
+        )
+        result = checker.check_collection(
+            collection_name(self.model), check_project=True
+        )
+        missing = result.paths(ISSUE_MISSING_FILE)
+        return self._path_check(
+            "missing_files",
+            missing,
+            ok=not missing,
+            detail=f"{len(missing)} of {len(self.tracked_files)} tracked files "
+            "not indexed",
+        )
+
+    def _path_check(
+        self, name: str, paths: List[str], ok: bool, detail: str
+    ) -> CiCheck:
+        return CiCheck(
+            name,
+            ok,
+            detail,
+            ACTION_NONE if ok else ACTION_INDEX,
+            paths=paths[:MAX_LISTED_PATHS],
+            path_count=len(paths),
+        )
+
+    def _git(self, *args: str) -> Optional[str]:
+        """Output of a git command, None if it fails."""
+        try:
+            result = run_git_command(["git", *args], cwd=self.project_root)
+        except (subprocess.CalledProcessError, FileNotFoundError):
+            return None
+        return str(result.stdout).strip()
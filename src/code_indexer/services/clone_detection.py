@@ -0,0 +1,306 @@
+"""
+Near-duplicate code detection of cidx clones.
+
+Every indexed code chunk visible on the current branch is compared with
+every other by the cosine similarity of its stored vector, so nothing is
+embedded. Pairs at or above the threshold are clones; clones sharing a chunk
+are grouped into one cluster (a function copied three times is one cluster
+of three). Overlapping chunks of the same file are not clones of each other.
+
+A cluster's duplicated lines are the lines of all its chunks but the longest
+one, the copy considered the original. 'cidx clones --repos a,b' detects
+clones across workspace repositories, which must use the same embedding
+model: similarities of different models are not comparable.
+"""
+
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+from ..storage.vector_kinds import VECTOR_KIND_KEY
+
+DEFAULT_THRESHOLD = 0.93
+DEFAULT_MIN_LINES = 5
+
+# Rows of the similarity matrix computed at once
+_BLOCK_SIZE = 1024
+
+
+@dataclass
+class CloneChunk:
+    """An indexed chunk compared for clones."""
+
+    path: str
+    line_start: int
+    line_end: int
+    language: str = ""
+    # Workspace alias of the chunk's repository ("" for the current one)
+    repository: str = ""
+
+    @property
+    def lines(self) -> int:
+        return self.line_end - self.line_start + 1
+
+    @property
+    def location(self) -> str:
+        prefix = f"[{self.repository}] " if self.repository else ""
+        return f"{prefix}{self.path}:{self.line_start}-{self.line_end}"
+
+    def overlaps(self, other: "CloneChunk") -> bool:
+        return (
+            self.repository == other.repository
+            and self.path == other.path
+            and self.line_start <= other.line_end
+            and other.line_start <= self.line_end
+        )
+
+
+@dataclass
+class CloneCluster:
+    """Chunks that are near-duplicates of each other."""
+
+    chunks: List[CloneChunk]
+    # Lowest and highest similarity of the pairs joining the cluster
+    min_similarity: float
+    max_similarity: float
+
+    @property
+    def duplicated_lines(self) -> int:
+        lines = sorted((chunk.lines for chunk in self.chunks), reverse=True)
+        return sum(lines[1:])
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "chunks": [asdict(chunk) for chunk in self.chunks],
+            "min_similarity": self.min_similarity,
+            "max_similarity": self.max_similarity,
+            "duplicated_lines": self.duplicated_lines,
+        }
+
+
+@dataclass
+class CloneReport:
+    """Clusters of near-duplicate chunks, most duplicated lines first."""
+
+    threshold: float
+    chunks_compared: int
+    clusters: List[CloneCluster] = field(default_factory=list)
+
+    @property
+    def duplicated_lines(self) -> int:
+        return sum(cluster.duplicated_lines for cluster in self.clusters)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "threshold": self.threshold,
+            "chunks_compared": self.chunks_compared,
+            "duplicated_lines": self.duplicated_lines,
+            "clusters": [cluster.to_dict() for cluster in self.clusters],
+        }
+
+
+def load_chunks(
+    vector_store: Any,
+    collection_name: str,
+    project_root: Path,
+    min_lines: int = DEFAULT_MIN_LINES,
+    include_generated: bool = False,
+    repository: str = "",
+) -> Tuple[List[CloneChunk], List[List[float]]]:
+    """
+    Code chunks of a collection visible on the current branch, with their
+    stored vectors.
+
+    Signature, summary and other extra vectors, chunks shorter than
+    min_lines and (unless include_generated) generated files are skipped.
+    """
+    from ..utils.git_runner import get_current_branch
+    from .generated_code import GENERATED_KEY
+
+    current_branch = get_current_branch(project_root)
+    chunks: List[CloneChunk] = []
+    vectors: List[List[float]] = []
+    for _, data, _ in vector_store.iter_vector_records(collection_name):
+        if not data or not data.get("vector"):
+            continue
+        payload = data.get("payload", {})
+        if payload.get(VECTOR_KIND_KEY):
+            continue
+        if current_branch and current_branch in payload.get("hidden_branches", []):
+            continue
+        if payload.get(GENERATED_KEY) and not include_generated:
+            continue
+        start = payload.get("line_start") or 0
+        end = payload.get("line_end") or start
+        if not payload.get("path") or end - start + 1 < min_lines:
+            continue
+        chunks.append(
+            CloneChunk(
+                str(payload["path"]).replace("\\", "/"),
+                start,
+                end,
+                str(payload.get("language", "")),
+                repository,
+            )
+        )
+        vectors.append(data["vector"])
+    return chunks, vectors
+
+
+def find_similar_pairs(
+    vectors: Sequence[Sequence[float]], threshold: float
+) -> List[Tuple[int, int, float]]:
+    """
+    Pairs (i, j, similarity) of vectors with cosine similarity of at least
+    threshold, i < j.
+
+    The similarity matrix is computed a block of rows at a time, so memory
+    stays proportional to the number of vectors.
+    """
+    import numpy as np
+
+    if len(vectors) < 2:
+        return []
+    matrix = np.asarray(vectors, dtype=np.float32)
+    norms = np.linalg.norm(matrix, axis=1, keepdims=True)
+    matrix = matrix / np.where(norms == 0, 1.0, norms)
+
+    pairs: List[Tuple[int, int, float]] = []
+    for start in range(0, len(matrix), _BLOCK_SIZE):
+        block = matrix[start : start + _BLOCK_SIZE] @ matrix.T
+        rows, columns = np.nonzero(block >= threshold)
+        for row, column in zip(rows.tolist(), columns.tolist()):
+            i = start + row
+            if column > i:
+                pairs.append((i, column, float(block[row, column])))
+    return pairs
+
+
+def cluster_clones(
+    chunks: List[CloneChunk], pairs: List[Tuple[int, int, float]]
+) -> List[CloneCluster]:
+    """
+    Group clone pairs into clusters, most duplicated lines first.
+
+    Pairs of overlapping chunks of the same file are ignored.
+    """
+    parent = list(range(len(chunks)))
+
+    def find(i: int) -> int:
+        while parent[i] != i:
+            parent[i] = parent[parent[i]]
+            i = parent[i]
+        return i
+
+    similarities: Dict[int, List[float]] = {}
+    for i, j, similarity in pairs:
+        if chunks[i].overlaps(chunks[j]):
+            continue
+        root_i, root_j = find(i), find(j)
+        if root_i != root_j:
+            parent[root_j] = root_i
+            similarities.setdefault(root_i, []).extend(
+                similarities.pop(root_j, [])
+            )
+        similarities.setdefault(root_i, []).append(similarity)
+
+    members: Dict[int, List[CloneChunk]] = {}
+    for i, chunk in enumerate(chunks):
+        members.setdefault(find(i), []).append(chunk)
+
+    clusters = [
+        CloneCluster(
+            sorted(group, key=lambda c: (c.repository, c.path, c.line_start)),
+            min(similarities[root]),
+            max(similarities[root]),
+        )
+        for root, group in members.items()
+        if len(group) > 1
+    ]
+    clusters.sort(key=lambda c: (-c.duplicated_lines, -c.max_similarity))
+    return clusters
+
+
+def detect_clones(
+    chunks: List[CloneChunk],
+    vectors: Sequence[Sequence[float]],
+    threshold: float = DEFAULT_THRESHOLD,
+) -> CloneReport:
+    """Clusters of near-duplicate chunks among the given ones."""
+    pairs = find_similar_pairs(vectors, threshold)
+    return CloneReport(threshold, len(chunks), cluster_clones(chunks, pairs))
+
+
+def load_repository_chunks(
+    project_root: Path,
+    repository: str = "",
+    min_lines: int = DEFAULT_MIN_LINES,
+    include_generated: bool = False,
+) -> Tuple[List[CloneChunk], List[List[float]], str]:
+    """
+    Chunks and vectors of an indexed repository (see load_chunks), and the
+    "provider/model" that embedded them.
+    """
+    from ..backends.backend_factory import BackendFactory
+    from ..config import ConfigManager
+    from .embedding_factory import EmbeddingProviderFactory
+
+    config = ConfigManager.create_with_backtrack(Path(project_root)).load()
+    codebase_dir = Path(config.codebase_dir)
+    embedding_provider = EmbeddingProviderFactory.create(config=config)
+    vector_store = BackendFactory.create(config, codebase_dir).get_vector_store_client()
+    collection_name = vector_store.resolve_collection_name(config, embedding_provider)
+    chunks, vectors = load_chunks(
+        vector_store,
+        collection_name,
+        codebase_dir,
+        min_lines=min_lines,
+        include_generated=include_generated,
+        repository=repository,
+    )
+    model = (
+        f"{embedding_provider.get_provider_name()}/"
+        f"{embedding_provider.get_current_model()}"
+    )
+    return chunks, vectors, model
+
+
+def load_workspace_chunks(
+    repositories: Dict[str, Path],
+    min_lines: int = DEFAULT_MIN_LINES,
+    include_generated: bool = False,
+) -> Tuple[List[CloneChunk], List[List[float]]]:
+    """
+    Chunks and vectors of workspace repositories (alias to project root).
+
+    Raises:
+        ValueError: If the repositories use different embedding models
+    """
+    chunks: List[CloneChunk] = []
+    vectors: List[List[float]] = []
+    models: Dict[str, str] = {}
+    for alias, root in repositories.items():
+        repo_chunks, repo_vectors, models[alias] = load_repository_chunks(
+            root, alias, min_lines=min_lines, include_generated=include_generated
+        )
+        chunks.extend(repo_chunks)
+        vectors.extend(repo_vectors)
+    if len(set(models.values())) > 1:
+        used = ", ".join(f"{alias}: {model}" for alias, model in models.items())
+        raise ValueError(
+            f"Clones can only be compared within one embedding model ({used})"
+        )
+    return chunks, vectors
+
+
+def format_cluster(cluster: CloneCluster, number: Optional[int] = None) -> str:
+    """Header line of a cluster."""
+    label = f"Cluster {number}: " if number is not None else ""
+    if cluster.min_similarity == cluster.max_similarity:
+        similarity = f"{cluster.max_similarity:.3f}"
+    else:
+        similarity = f"{cluster.min_similarity:.3f}-{cluster.max_similarity:.3f}"
+    return (
+        f"{label}{len(cluster.chunks)} copies, similarity {similarity}, "
+        f"{cluster.duplicated_lines} duplicated lines"
+    )
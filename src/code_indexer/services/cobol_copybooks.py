@@ -0,0 +1,135 @@
+"""
+Resolves the copybooks named by COBOL COPY statements to project files.
+
+`COPY CUSTREC.` names a library member, which the compiler looks up in the
+copy libraries of the build by the member name with a copybook extension.
+When the first COBOL chunk of an indexing run is annotated, the copybook
+files of the project (.cpy and .copy, then .cbl and .cob programs) are
+listed once by member name.
+
+Chunks naming members under "cobol_copybooks" record the files of those
+members under "cobol_copybook_paths", and the members no project file
+provides under "cobol_unresolved_copybooks"; these are usually system
+copybooks such as SQLCA or copy libraries kept outside the repository.
+Links are computed when a program is indexed; run 'cidx index --clear'
+after adding copybooks.
+"""
+
+import logging
+import os
+import threading
+from collections import defaultdict
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional
+
+from ..indexing.chunk_payload_keys import COBOL_COPYBOOKS_KEY
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload keys holding resolved and unresolved copybook members
+COPYBOOK_PATHS_KEY = "cobol_copybook_paths"
+UNRESOLVED_COPYBOOKS_KEY = "cobol_unresolved_copybooks"
+
+# File extensions of copy members, most specific first
+COPYBOOK_SUFFIXES = (".cpy", ".copy", ".cbl", ".cob")
+
+
+def copybook_member(file_name: str) -> Optional[str]:
+    """Member name of a copybook file ("CUSTREC" for custrec.cpy), or None."""
+    stem, suffix = os.path.splitext(file_name)
+    if suffix.lower() not in COPYBOOK_SUFFIXES or not stem:
+        return None
+    return stem.upper()
+
+
+class CopybookIndex:
+    """Finds the files of the copybooks COBOL programs copy."""
+
+    def __init__(self, codebase_dir: Path, file_finder: Optional[Any] = None):
+        """
+        Initialize the index; copybook files are listed on first use.
+
+        Args:
+            codebase_dir: Project root
+            file_finder: FileFinder of the indexing run; without it, every
+                copybook file outside hidden directories is listed
+        """
+        self.codebase_dir = Path(codebase_dir).resolve()
+        self.file_finder = file_finder
+        self._lock = threading.Lock()
+        # Member name -> copybook files, most specific extension first
+        self._copybooks: Optional[Dict[str, List[str]]] = None
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["CopybookIndex"]:
+        """Index when indexing.cobol_chunking is on, otherwise None."""
+        indexing_config = getattr(config, "indexing", None)
+        if getattr(indexing_config, "cobol_chunking", False) is not True:
+            return None
+        from ..indexing.file_finder import FileFinder
+
+        return cls(Path(config.codebase_dir), FileFinder(config))
+
+    def files_of(self, member: str) -> List[str]:
+        """Files of a copy member, by its name in a COPY statement."""
+        return list(self.copybooks().get(member.upper(), []))
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks with the files of their copybooks added."""
+        if not any(chunk.get(COBOL_COPYBOOKS_KEY) for chunk in chunks):
+            return chunks
+        annotated = []
+        for chunk in chunks:
+            paths: List[str] = []
+            unresolved: List[str] = []
+            for member in chunk.get(COBOL_COPYBOOKS_KEY) or []:
+                files = self.files_of(member)
+                if files:
+                    paths.extend(path for path in files if path not in paths)
+                else:
+                    unresolved.append(member)
+            if paths:
+                chunk = {**chunk, COPYBOOK_PATHS_KEY: paths}
+            if unresolved:
+                chunk = {**chunk, UNRESOLVED_COPYBOOKS_KEY: unresolved}
+            annotated.append(chunk)
+        return annotated
+
+    def copybooks(self) -> Dict[str, List[str]]:
+        """Copybook files per member name, listing them on first use."""
+        with self._lock:
+            if self._copybooks is None:
+                self._copybooks = self._scan()
+            return self._copybooks
+
+    def _copybook_files(self) -> Iterable[Path]:
+        if self.file_finder is not None:
+            for path in self.file_finder.find_files():
+                if copybook_member(path.name) is not None:
+                    yield path
+            return
+        for root, dirs, files in os.walk(self.codebase_dir):
+            dirs[:] = [d for d in dirs if not d.startswith(".")]
+            for name in files:
+                if copybook_member(name) is not None:
+                    yield Path(root) / name
+
+    def _scan(self) -> Dict[str, List[str]]:
+        found: Dict[str, Dict[int, List[str]]] = defaultdict(lambda: defaultdict(list))
+        for path in self._copybook_files():
+            try:
+                relative = path.resolve().relative_to(self.codebase_dir).as_posix()
+            except (OSError, ValueError):
+                continue
+            member = copybook_member(path.name)
+            rank = COPYBOOK_SUFFIXES.index(path.suffix.lower())
+            if member is not None:
+                found[member][rank].append(relative)
+        logger.debug(f"COBOL copybooks: {len(found)} members")
+        # Only the most specific extension of a member: CUSTREC.cpy rather
+        # than a CUSTREC.cbl program of the same name
+        return {
+            member: sorted(ranked[min(ranked)]) for member, ranked in found.items()
+        }
@@ -0,0 +1,227 @@
+"""
+Size and complexity metrics of code chunks.
+
+Every code chunk records numeric payload fields, so queries can be narrowed
+to large or branchy code ("complex authentication handlers" with
+--min-complexity 15):
+
+- loc: lines of code, without blank and comment-only lines
+- complexity: cyclomatic complexity, 1 plus the number of decision points
+  (if/elif, loops, case/when arms, catch/except/rescue, && and ||, and/or in
+  Python and Ruby, C-style ternaries)
+- nesting_depth: deepest block nesting in the chunk, counted from the
+  chunk's outermost level (braces, or indentation in Python)
+- parameter_count: parameters of the chunk's first function or method, without
+  self/cls receivers (only set when the chunk declares one)
+
+Metrics are computed from the stored chunk text with string literals and
+comments removed; they are approximations, not a parse of the language.
+"""
+
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+# Chunk and payload keys
+LOC_KEY = "loc"
+COMPLEXITY_KEY = "complexity"
+NESTING_DEPTH_KEY = "nesting_depth"
+PARAMETER_COUNT_KEY = "parameter_count"
+
+HASH_COMMENT_LANGUAGES = {"py", "pyw", "rb", "sh", "bash", "r", "pl", "pm", "jl"}
+INDENT_LANGUAGES = {"py", "pyw"}
+BRACE_LANGUAGES = {
+    "c",
+    "h",
+    "cc",
+    "cpp",
+    "cxx",
+    "hpp",
+    "cs",
+    "java",
+    "kt",
+    "kts",
+    "scala",
+    "groovy",
+    "go",
+    "rs",
+    "js",
+    "jsx",
+    "mjs",
+    "cjs",
+    "ts",
+    "tsx",
+    "php",
+    "swift",
+    "dart",
+    "m",
+    "mm",
+}
+METRIC_LANGUAGES = BRACE_LANGUAGES | HASH_COMMENT_LANGUAGES
+
+_TRIPLE_QUOTED = re.compile(r'"""[\s\S]*?"""|\'\'\'[\s\S]*?\'\'\'')
+_STRING = re.compile(r'"(?:\\.|[^"\\\n])*"|\'(?:\\.|[^\'\\\n])*\'|`[^`]*`')
+_BLOCK_COMMENT = re.compile(r"/\*[\s\S]*?\*/")
+_LINE_COMMENT = re.compile(r"//[^\n]*")
+_HASH_COMMENT = re.compile(r"#[^\n]*")
+
+_DECISIONS = re.compile(
+    r"\b(?:if|elif|elsif|unless|for|foreach|while|until|case|when|catch|except"
+    r"|rescue)\b|&&|\|\||\s\?\s"
+)
+_WORD_OPERATORS = re.compile(r"\b(?:and|or)\b")
+
+_FUNCTION = re.compile(
+    r"\b(?:def|fun|func|fn|function|sub)\b\s*(?:\([^)]*\)\s*)?[\w.$!?]*\s*"
+    r"(?:<[^>(]*>\s*)?(?:\[[^\]]*\]\s*)?\("
+)
+# (a, b) => in JavaScript and TypeScript
+_ARROW = re.compile(r"\([^()]*\)\s*(?::[^=;{]*)?=>")
+# C-style declarations: a return type or modifiers, then name( ... ) {
+_C_FUNCTION = re.compile(r"^\s*(?:[\w<>\[\],.?*&:@]+\s+)+[*&]?(\w+)\s*\(", re.M)
+_NOT_FUNCTION = {
+    "if",
+    "for",
+    "while",
+    "switch",
+    "return",
+    "catch",
+    "new",
+    "sizeof",
+    "else",
+    "throw",
+    "await",
+}
+_RECEIVERS = {"self", "cls", "this", "&self", "&mut self", "mut self"}
+
+
+def strip_code(text: str, language: str) -> str:
+    """Text with string literals and comments blanked, lines kept."""
+
+    def blank(match: "re.Match[str]") -> str:
+        return re.sub(r"[^\n]", " ", match.group(0))
+
+    if language in INDENT_LANGUAGES:
+        text = _TRIPLE_QUOTED.sub(blank, text)
+    text = _STRING.sub(blank, text)
+    if language in HASH_COMMENT_LANGUAGES:
+        return _HASH_COMMENT.sub(blank, text)
+    text = _BLOCK_COMMENT.sub(blank, text)
+    return _LINE_COMMENT.sub(blank, text)
+
+
+def _brace_depth(code: str) -> int:
+    depth = lowest = deepest = 0
+    for char in code:
+        if char == "{":
+            depth += 1
+            deepest = max(deepest, depth - lowest)
+        elif char == "}":
+            depth -= 1
+            lowest = min(lowest, depth)
+    return deepest
+
+
+def _indent_depth(lines: List[str]) -> int:
+    stack: List[int] = []
+    deepest = 0
+    for line in lines:
+        if not line.strip():
+            continue
+        indent = len(line) - len(line.lstrip())
+        while stack and indent < stack[-1]:
+            stack.pop()
+        if not stack or indent > stack[-1]:
+            stack.append(indent)
+        deepest = max(deepest, len(stack) - 1)
+    return deepest
+
+
+def _parameters(code: str, start: int) -> int:
+    """Parameters of the parenthesized list opening at code[start]."""
+    depth = 0
+    parameters: List[str] = []
+    current = ""
+    for char in code[start:]:
+        if char in "([{<":
+            depth += 1
+            if depth == 1:
+                continue
+        elif char in ")]}>":
+            depth -= 1
+            if depth == 0:
+                break
+        elif char == "," and depth == 1:
+            parameters.append(current.strip())
+            current = ""
+            continue
+        current += char
+    parameters.append(current.strip())
+    return sum(
+        1 for p in parameters if p and p != "void" and p.split(":")[0] not in _RECEIVERS
+    )
+
+
+def parameter_count(code: str) -> Optional[int]:
+    """Parameters of the first function declared in the code, if any."""
+    candidates = [m.end() - 1 for m in [_FUNCTION.search(code)] if m]
+    candidates += [m.start() for m in [_ARROW.search(code)] if m]
+    for match in _C_FUNCTION.finditer(code):
+        if match.group(1) in _NOT_FUNCTION:
+            continue
+        # A declaration continues with a body, not a statement end
+        rest = code[match.end() :].split(")", 1)[-1].lstrip()
+        if rest.startswith(";"):
+            continue
+        candidates.append(match.end() - 1)
+        break
+    if not candidates:
+        return None
+    return _parameters(code, min(candidates))
+
+
+def chunk_metrics(text: str, language: str) -> Dict[str, int]:
+    """Metrics of the text of a chunk in the language (file extension)."""
+    language = language.lower()
+    code = strip_code(text, language)
+    lines = [line for line in code.split("\n") if line.strip()]
+    decisions = len(_DECISIONS.findall(code))
+    if language in {"py", "pyw", "rb"}:
+        decisions += len(_WORD_OPERATORS.findall(code))
+    metrics = {
+        LOC_KEY: len(lines),
+        COMPLEXITY_KEY: 1 + decisions,
+        NESTING_DEPTH_KEY: (
+            _indent_depth(lines) if language in INDENT_LANGUAGES else _brace_depth(code)
+        ),
+    }
+    parameters = parameter_count(code)
+    if parameters is not None:
+        metrics[PARAMETER_COUNT_KEY] = parameters
+    return metrics
+
+
+class CodeMetrics:
+    """Records size and complexity metrics in the payload of code chunks."""
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["CodeMetrics"]:
+        """Metrics from indexing.code_metrics, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        metrics_config = getattr(indexing_config, "code_metrics", None)
+        if getattr(metrics_config, "enabled", False) is not True:
+            return None
+        return cls()
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks with their metrics added."""
+        annotated = []
+        for chunk in chunks:
+            language = (chunk.get("file_extension") or "").lower()
+            if language not in METRIC_LANGUAGES:
+                annotated.append(chunk)
+                continue
+            annotated.append({**chunk, **chunk_metrics(chunk["text"], language)})
+        return annotated
@@ -0,0 +1,206 @@
+"""
+CODEOWNERS-based ownership metadata.
+
+The project's CODEOWNERS file is parsed once per indexing run, and every
+chunk of a file carries the owners of its path in the "owners" payload field
+(for example ["@acme/platform-team", "@alice"]). ``cidx query --owner``
+filters on it, and query results are summarized per owner.
+
+Rules follow GitHub semantics: the last matching rule wins, a rule without
+owners removes ownership, patterns without a slash match at any depth, and
+"dir/*" matches the files directly inside dir only. GitLab section headers
+("[Section]") are skipped; their rules apply like any other.
+"""
+
+import logging
+import re
+from collections import Counter
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Pattern, Sequence, Tuple
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload key holding the owners of a chunk's file
+OWNERS_KEY = "owners"
+
+# Searched in this order; the first existing file is used
+CODEOWNERS_LOCATIONS = (
+    ".github/CODEOWNERS",
+    "CODEOWNERS",
+    "docs/CODEOWNERS",
+    ".gitlab/CODEOWNERS",
+)
+
+_SECTION_HEADER = re.compile(r"^\^?\[[^\]]+\]")
+
+
+def find_codeowners_file(codebase_dir: Path) -> Optional[Path]:
+    """Return the CODEOWNERS file of a project, or None if it has none."""
+    for location in CODEOWNERS_LOCATIONS:
+        candidate = Path(codebase_dir) / location
+        if candidate.is_file():
+            return candidate
+    return None
+
+
+def normalize_owner(owner: str) -> str:
+    """Owner as written in CODEOWNERS: "platform-team" becomes "@platform-team"."""
+    owner = owner.strip()
+    if owner and "@" not in owner:
+        owner = "@" + owner
+    return owner
+
+
+def pattern_to_regex(pattern: str) -> Pattern[str]:
+    """
+    Compile a CODEOWNERS pattern to a regex over project-relative paths.
+
+    Args:
+        pattern: Pattern as written in CODEOWNERS ("*.go", "/docs/", "src/**/api")
+
+    Returns:
+        Regex matching the paths the pattern applies to
+    """
+    body = pattern.rstrip("/")
+    # A slash at the start or in the middle anchors the pattern to the root
+    anchored = "/" in body
+    body = body.lstrip("/")
+
+    parts = []
+    i = 0
+    while i < len(body):
+        if body.startswith("**/", i):
+            parts.append("(?:.*/)?")
+            i += 3
+        elif body.startswith("**", i):
+            parts.append(".*")
+            i += 2
+        elif body[i] == "*":
+            parts.append("[^/]*")
+            i += 1
+        elif body[i] == "?":
+            parts.append("[^/]")
+            i += 1
+        else:
+            parts.append(re.escape(body[i]))
+            i += 1
+
+    prefix = "^" if anchored else "^(?:.*/)?"
+    if pattern.endswith("/"):
+        suffix = "/.*$"  # Directory contents only
+    elif body.endswith("/*"):
+        suffix = "$"  # Direct children only
+    else:
+        suffix = "(?:/.*)?$"  # The file, or everything below the directory
+    return re.compile(prefix + "".join(parts) + suffix)
+
+
+def parse_codeowners(text: str) -> List[Tuple[Pattern[str], List[str]]]:
+    """
+    Parse CODEOWNERS content.
+
+    Returns:
+        (path regex, owners) rules in file order
+    """
+    rules = []
+    for raw in text.splitlines():
+        line = raw.split(" #", 1)[0].strip()
+        if not line or line.startswith("#") or _SECTION_HEADER.match(line):
+            continue
+        fields = line.split()
+        pattern, owners = fields[0].replace("\\#", "#"), fields[1:]
+        try:
+            rules.append((pattern_to_regex(pattern), owners))
+        except re.error as e:
+            logger.warning(f"Skipping CODEOWNERS pattern {pattern!r}: {e}")
+    return rules
+
+
+class CodeOwners:
+    """Resolves the owners of indexed files from CODEOWNERS rules."""
+
+    def __init__(
+        self,
+        codebase_dir: Path,
+        rules: Sequence[Tuple[Pattern[str], List[str]]],
+    ):
+        """
+        Initialize the resolver.
+
+        Args:
+            codebase_dir: Project root; CODEOWNERS paths are relative to it
+            rules: Parsed rules, see parse_codeowners()
+        """
+        self.codebase_dir = Path(codebase_dir).resolve()
+        self.rules = list(rules)
+
+    @classmethod
+    def load(cls, codebase_dir: Path) -> Optional["CodeOwners"]:
+        """Resolver for a project's CODEOWNERS file, or None if it has none."""
+        codeowners_file = find_codeowners_file(codebase_dir)
+        if codeowners_file is None:
+            return None
+        try:
+            text = codeowners_file.read_text(encoding="utf-8", errors="replace")
+        except OSError as e:
+            logger.warning(f"Could not read {codeowners_file}: {e}")
+            return None
+        return cls(codebase_dir, parse_codeowners(text))
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["CodeOwners"]:
+        """
+        Resolver from indexing.code_owners, or None when disabled or the
+        project has no CODEOWNERS file.
+        """
+        indexing_config = getattr(config, "indexing", None)
+        owners_config = getattr(indexing_config, "code_owners", None)
+        if getattr(owners_config, "enabled", False) is not True:
+            return None
+        return cls.load(Path(config.codebase_dir))
+
+    def owners_for(self, relative_path: str) -> List[str]:
+        """Owners of a project-relative path; the last matching rule wins."""
+        path = relative_path.replace("\\", "/").lstrip("/")
+        for regex, owners in reversed(self.rules):
+            if regex.match(path):
+                return list(owners)
+        return []
+
+    def classify_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks with the file's owners added under OWNERS_KEY."""
+        if not chunks:
+            return chunks
+        try:
+            relative = Path(file_path).resolve().relative_to(self.codebase_dir)
+        except ValueError:
+            return chunks  # Outside the project
+        owners = self.owners_for(relative.as_posix())
+        if not owners:
+            return chunks
+        return [{**chunk, OWNERS_KEY: owners} for chunk in chunks]
+
+
+def owner_facets(results: List[Dict[str, Any]]) -> List[Tuple[Optional[str], int]]:
+    """
+    Count query results per owner.
+
+    Returns:
+        (owner, result count) pairs, most results first; owner None counts
+        results without owners and comes last
+    """
+    counts: Counter = Counter()
+    unowned = 0
+    for result in results:
+        owners = result.get("payload", {}).get(OWNERS_KEY) or []
+        if not owners:
+            unowned += 1
+        counts.update(set(owners))
+    facets: List[Tuple[Optional[str], int]] = sorted(
+        counts.items(), key=lambda item: (-item[1], item[0])
+    )
+    if unowned:
+        facets.append((None, unowned))
+    return facets
@@ -0,0 +1,315 @@
+"""
+Automatic concurrency tuning for indexing runs.
+
+Replaces per-machine manual tuning of ``voyage_ai.parallel_requests`` with a
+feedback loop that runs during the first minutes of an indexing run:
+
+- Provider latency and throughput come from VectorCalculationManager stats
+- CPU load comes from the OS load average (normalized by CPU count)
+- Vector store ingest pressure comes from the UpsertStage queue depth
+
+Thread limits follow additive-increase / multiplicative-decrease (AIMD):
+increase while throughput keeps improving, halve on server throttling, and back
+off when the CPU or the vector store is saturated. Embedding batch token budgets
+shrink when per-batch latency approaches the vector processing timeout and grow
+back when batches are fast (unless a latency-aware batch scheduler owns the
+batch size).
+
+After the tuning window closes the best configuration observed is kept for the
+remainder of the run.
+"""
+
+import logging
+import os
+import threading
+import time
+from dataclasses import dataclass
+from typing import Callable, List, Optional
+
+from .batch_scheduler import LatencyAwareBatchScheduler
+
+logger = logging.getLogger(__name__)
+
+# Constants
+DEFAULT_TUNING_WINDOW_SECONDS = 180.0  # Tune during the first 3 minutes
+DEFAULT_SAMPLE_INTERVAL_SECONDS = 10.0
+THROUGHPUT_IMPROVEMENT_THRESHOLD = 0.05  # 5% gain required to keep increasing
+CPU_SATURATION_RATIO = 0.9  # load average / cpu count
+INGEST_BACKLOG_RATIO = 0.8  # upsert queue depth / capacity
+TARGET_BATCH_LATENCY_SECONDS = 15.0
+MIN_BATCH_TOKEN_FRACTION = 0.25
+MAX_BATCH_TOKEN_FRACTION = 0.9  # Same 90% safety margin as VoyageAI client
+
+
+@dataclass
+class TuningSample:
+    """Snapshot of pipeline health used for one tuning decision."""
+
+    embeddings_per_second: float
+    average_batch_latency: float
+    server_throttle_count: int
+    cpu_load_ratio: Optional[float] = None
+    ingest_backlog_ratio: Optional[float] = None
+
+
+@dataclass
+class TuningDecision:
+    """Result of evaluating one sample."""
+
+    thread_limit: int
+    batch_token_fraction: float
+    reason: str
+
+
+def get_cpu_load_ratio() -> Optional[float]:
+    """Return 1-minute load average divided by CPU count, or None if unavailable."""
+    try:
+        load_1m = os.getloadavg()[0]
+    except (AttributeError, OSError):
+        # os.getloadavg() is not available on Windows
+        return None
+    return load_1m / max(os.cpu_count() or 1, 1)
+
+
+class ConcurrencyTuner:
+    """AIMD controller deciding thread limits and batch token budgets."""
+
+    def __init__(
+        self,
+        initial_threads: int,
+        max_threads: int,
+        min_threads: int = 1,
+        initial_batch_token_fraction: float = MAX_BATCH_TOKEN_FRACTION,
+        increase_step: int = 2,
+    ):
+        """
+        Initialize the tuner.
+
+        Args:
+            initial_threads: Starting thread limit (usually parallel_requests)
+            max_threads: Upper bound the tuner may grow to
+            min_threads: Lower bound the tuner may shrink to
+            initial_batch_token_fraction: Starting fraction of model token limit
+            increase_step: Threads added per additive increase
+
+        Raises:
+            ValueError: If bounds are inconsistent
+        """
+        if min_threads <= 0:
+            raise ValueError(f"min_threads must be positive, got {min_threads}")
+        if max_threads < min_threads:
+            raise ValueError(
+                f"max_threads ({max_threads}) must be >= min_threads ({min_threads})"
+            )
+
+        self.min_threads = min_threads
+        self.max_threads = max_threads
+        self.increase_step = increase_step
+        self.thread_limit = max(min_threads, min(initial_threads, max_threads))
+        self.batch_token_fraction = initial_batch_token_fraction
+
+        self._last_throttle_count = 0
+        self._last_throughput: Optional[float] = None
+        self._best_throughput = 0.0
+        self._best_thread_limit = self.thread_limit
+        self._pending_increase = False
+        self._holding = False  # Set once probing stops paying off
+        self.history: List[TuningDecision] = []
+
+    def evaluate(self, sample: TuningSample) -> TuningDecision:
+        """
+        Evaluate a sample and return the new thread limit and batch fraction.
+
+        Args:
+            sample: Current pipeline health snapshot
+
+        Returns:
+            TuningDecision (also applied to this tuner's state)
+        """
+        new_throttles = sample.server_throttle_count - self._last_throttle_count
+        self._last_throttle_count = sample.server_throttle_count
+        throughput = sample.embeddings_per_second
+
+        # Only credit extra threads with a meaningful gain - marginal improvements
+        # don't justify the added provider load
+        if throughput > self._best_throughput * (1 + THROUGHPUT_IMPROVEMENT_THRESHOLD):
+            self._best_throughput = throughput
+            self._best_thread_limit = self.thread_limit
+
+        if new_throttles > 0:
+            # Multiplicative decrease - provider is rate limiting us
+            self.thread_limit = max(self.min_threads, self.thread_limit // 2)
+            self._pending_increase = False
+            reason = f"server throttling ({new_throttles} events)"
+        elif (
+            sample.cpu_load_ratio is not None
+            and sample.cpu_load_ratio > CPU_SATURATION_RATIO
+        ):
+            self.thread_limit = max(self.min_threads, self.thread_limit - 1)
+            self._pending_increase = False
+            reason = f"CPU saturated (load ratio {sample.cpu_load_ratio:.2f})"
+        elif (
+            sample.ingest_backlog_ratio is not None
+            and sample.ingest_backlog_ratio > INGEST_BACKLOG_RATIO
+        ):
+            # Vector store is the bottleneck - more embedding threads won't help
+            self.thread_limit = max(self.min_threads, self.thread_limit - 1)
+            self._pending_increase = False
+            reason = (
+                f"vector store backlog ({sample.ingest_backlog_ratio:.0%} of queue)"
+            )
+        elif self._pending_increase and not self._improved(throughput):
+            # Last increase did not pay off - return to best known limit and hold
+            self.thread_limit = self._best_thread_limit
+            self._pending_increase = False
+            self._holding = True
+            reason = "no throughput gain from last increase"
+        elif self._holding:
+            reason = "holding best known concurrency"
+        elif self.thread_limit < self.max_threads:
+            self.thread_limit = min(
+                self.max_threads, self.thread_limit + self.increase_step
+            )
+            self._pending_increase = True
+            reason = "probing higher concurrency"
+        else:
+            self._pending_increase = False
+            reason = "at maximum concurrency"
+
+        self._last_throughput = throughput
+        self._tune_batch_size(sample.average_batch_latency)
+
+        decision = TuningDecision(
+            thread_limit=self.thread_limit,
+            batch_token_fraction=self.batch_token_fraction,
+            reason=reason,
+        )
+        self.history.append(decision)
+        return decision
+
+    def finalize(self) -> TuningDecision:
+        """Settle on the best thread limit observed when tuning ends."""
+        if self._best_throughput > 0:
+            self.thread_limit = self._best_thread_limit
+        decision = TuningDecision(
+            thread_limit=self.thread_limit,
+            batch_token_fraction=self.batch_token_fraction,
+            reason="tuning window closed",
+        )
+        self.history.append(decision)
+        return decision
+
+    def _improved(self, throughput: float) -> bool:
+        if self._last_throughput is None or self._last_throughput <= 0:
+            return throughput > 0
+        gain = (throughput - self._last_throughput) / self._last_throughput
+        return gain >= THROUGHPUT_IMPROVEMENT_THRESHOLD
+
+    def _tune_batch_size(self, average_batch_latency: float) -> None:
+        if average_batch_latency <= 0:
+            return
+        if average_batch_latency > TARGET_BATCH_LATENCY_SECONDS:
+            self.batch_token_fraction = max(
+                MIN_BATCH_TOKEN_FRACTION, self.batch_token_fraction * 0.75
+            )
+        elif average_batch_latency < TARGET_BATCH_LATENCY_SECONDS / 3:
+            self.batch_token_fraction = min(
+                MAX_BATCH_TOKEN_FRACTION, self.batch_token_fraction * 1.25
+            )
+
+
+class AutoTuneController:
+    """Background sampler applying ConcurrencyTuner decisions to a running pipeline."""
+
+    def __init__(
+        self,
+        tuner: ConcurrencyTuner,
+        vector_manager,  # VectorCalculationManager
+        ingest_backlog_provider: Optional[Callable[[], Optional[float]]] = None,
+        tuning_window_seconds: float = DEFAULT_TUNING_WINDOW_SECONDS,
+        sample_interval_seconds: float = DEFAULT_SAMPLE_INTERVAL_SECONDS,
+        cpu_load_provider: Callable[[], Optional[float]] = get_cpu_load_ratio,
+    ):
+        """
+        Initialize the controller.
+
+        Args:
+            tuner: Decision engine
+            vector_manager: VectorCalculationManager to sample and adjust
+            ingest_backlog_provider: Returns upsert queue fill ratio (0.0-1.0)
+            tuning_window_seconds: How long to keep tuning after start
+            sample_interval_seconds: Seconds between samples
+            cpu_load_provider: Returns normalized CPU load or None
+        """
+        self.tuner = tuner
+        self.vector_manager = vector_manager
+        self.ingest_backlog_provider = ingest_backlog_provider
+        self.tuning_window_seconds = tuning_window_seconds
+        self.sample_interval_seconds = sample_interval_seconds
+        self.cpu_load_provider = cpu_load_provider
+
+        self._stop_event = threading.Event()
+        self._thread: Optional[threading.Thread] = None
+
+    def start(self) -> "AutoTuneController":
+        """Start the sampling thread."""
+        self._apply(
+            TuningDecision(
+                thread_limit=self.tuner.thread_limit,
+                batch_token_fraction=self.tuner.batch_token_fraction,
+                reason="initial",
+            )
+        )
+        self._thread = threading.Thread(
+            target=self._run, name="AutoTune", daemon=True
+        )
+        self._thread.start()
+        return self
+
+    def __enter__(self) -> "AutoTuneController":
+        return self.start()
+
+    def __exit__(self, exc_type, exc_val, exc_tb):
+        self.stop()
+
+    def stop(self) -> None:
+        """Stop sampling."""
+        self._stop_event.set()
+        if self._thread is not None:
+            self._thread.join(timeout=self.sample_interval_seconds + 1.0)
+
+    def sample(self) -> TuningSample:
+        """Collect a TuningSample from the live pipeline."""
+        stats = self.vector_manager.get_stats()
+        ingest_ratio = (
+            self.ingest_backlog_provider() if self.ingest_backlog_provider else None
+        )
+        return TuningSample(
+            embeddings_per_second=stats.embeddings_per_second,
+            average_batch_latency=stats.average_processing_time,
+            server_throttle_count=stats.server_throttle_count,
+            cpu_load_ratio=self.cpu_load_provider(),
+            ingest_backlog_ratio=ingest_ratio,
+        )
+
+    def _run(self) -> None:
+        deadline = time.time() + self.tuning_window_seconds
+        while not self._stop_event.wait(self.sample_interval_seconds):
+            try:
+                if time.time() >= deadline:
+                    self._apply(self.tuner.finalize())
+                    return
+                self._apply(self.tuner.evaluate(self.sample()))
+            except Exception as e:
+                # Tuning is best-effort - never break indexing
+                logger.warning(f"Auto-tune sample failed: {e}")
+
+    def _apply(self, decision: TuningDecision) -> None:
+        self.vector_manager.set_concurrency_limit(decision.thread_limit)
+        batch_scheduler = getattr(self.vector_manager, "batch_scheduler", None)
+        if not isinstance(batch_scheduler, LatencyAwareBatchScheduler):
+            self.vector_manager.batch_token_fraction = decision.batch_token_fraction
+        logger.info(
+            f"Auto-tune: {decision.thread_limit} threads, "
+            f"{decision.batch_token_fraction:.0%} batch token budget ({decision.reason})"
+        )
@@ -0,0 +1,49 @@
+"""
+Deduplication of identical files within an indexing run.
+
+Vendored and third-party code (vendor/, node_modules/, copied libraries) often
+contains the same file dozens of times. Files with the same content hash are
+chunked and embedded once: the canonical file (first path in sort order) is
+processed normally and its chunks and embeddings are written again for every
+duplicate path. Each path keeps its own points, so path filters, deletions and
+branch visibility keep working per path; only the embedding work is shared.
+"""
+
+from pathlib import Path
+from typing import Dict, List, Tuple
+
+# Metadata key listing the duplicate paths (as strings, so metadata stays
+# JSON-serializable) a canonical file is written for
+DUPLICATE_PATHS_KEY = "duplicate_paths"
+
+
+def group_identical_files(
+    hash_results: Dict[Path, tuple],
+) -> Tuple[Dict[Path, List[Path]], int]:
+    """
+    Group files sharing a content hash.
+
+    Args:
+        hash_results: {file_path: (file_metadata, file_size)} from the hash phase
+
+    Returns:
+        ({canonical_path: [duplicate_paths]} for groups with duplicates,
+        number of duplicate files that need no embedding)
+    """
+    by_hash: Dict[str, List[Path]] = {}
+    for file_path, (file_metadata, _) in hash_results.items():
+        file_hash = file_metadata.get("file_hash", "")
+        if not file_hash or ":error-" in file_hash:
+            # Unreadable files get unique placeholder hashes - never group them
+            continue
+        by_hash.setdefault(file_hash, []).append(file_path)
+
+    groups: Dict[Path, List[Path]] = {}
+    duplicate_count = 0
+    for paths in by_hash.values():
+        if len(paths) < 2:
+            continue
+        canonical, *duplicates = sorted(paths, key=str)
+        groups[canonical] = duplicates
+        duplicate_count += len(duplicates)
+    return groups, duplicate_count
@@ -0,0 +1,429 @@
+"""
+Test-to-code coverage mapping.
+
+Coverage reports (Go coverprofile, Cobertura coverage.xml, lcov) are
+imported into .code-indexer/coverage.json, keyed by the same relative paths
+as indexed chunks. Query results are then annotated with the tests that
+cover their lines, filtered to uncovered code or to code covered by given
+tests, and test-impact lookups answer which tests cover a file or line
+range.
+
+Per-test mapping comes from lcov "TN:" records, or from importing one report
+per test with an explicit test name (e.g. `go test -run '^TestX$'
+-coverprofile=x.out`). Coverage without a test name still marks lines as
+covered.
+
+Coverage describes the code at the commit the tests ran on; re-import after
+changing code so line numbers stay aligned with the index.
+"""
+
+import json
+import logging
+import os
+import re
+import xml.etree.ElementTree as ET
+from dataclasses import dataclass, field
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Sequence, Set
+
+logger = logging.getLogger(__name__)
+
+COVERAGE_FILENAME = "coverage.json"
+COVERAGE_FORMAT_VERSION = 1
+
+REPORT_FORMATS = ("go", "cobertura", "lcov")
+
+# Go coverprofile block: file.go:startLine.startCol,endLine.endCol numStmt count
+_GO_BLOCK = re.compile(r"^(.+):(\d+)\.\d+,(\d+)\.\d+ \d+ (\d+)$")
+
+
+@dataclass
+class FileCoverage:
+    """Coverage of one source file."""
+
+    coverable: Set[int] = field(default_factory=set)
+    covered: Set[int] = field(default_factory=set)
+    tests: Dict[str, Set[int]] = field(default_factory=dict)
+
+    def add_line(self, line: int, hits: int, test_name: Optional[str]) -> None:
+        self.coverable.add(line)
+        if hits > 0:
+            self.covered.add(line)
+            if test_name:
+                self.tests.setdefault(test_name, set()).add(line)
+
+    def merge(self, other: "FileCoverage") -> None:
+        self.coverable |= other.coverable
+        self.covered |= other.covered
+        for test_name, lines in other.tests.items():
+            self.tests.setdefault(test_name, set()).update(lines)
+
+
+@dataclass
+class RangeCoverage:
+    """Coverage of a line range, such as a chunk."""
+
+    coverable: int
+    covered: int
+    tests: List[str]
+
+    @property
+    def is_uncovered(self) -> bool:
+        """Whether the range has executable lines and none of them ran."""
+        return self.coverable > 0 and self.covered == 0
+
+
+def detect_report_format(report_path: Path) -> str:
+    """
+    Detect the format of a coverage report from its content.
+
+    Raises:
+        ValueError: If the format is not recognized
+    """
+    with open(report_path, encoding="utf-8", errors="replace") as f:
+        head = f.read(4096)
+    stripped = head.lstrip()
+    if stripped.startswith("mode:"):
+        return "go"
+    if stripped.startswith("<") and "<coverage" in head:
+        return "cobertura"
+    if re.search(r"^(?:TN|SF):", head, re.MULTILINE):
+        return "lcov"
+    raise ValueError(
+        f"Unrecognized coverage report format: {report_path} "
+        f"(supported: {', '.join(REPORT_FORMATS)})"
+    )
+
+
+def parse_go_coverprofile(
+    report_path: Path, test_name: Optional[str] = None
+) -> Dict[str, FileCoverage]:
+    """Parse a Go coverprofile (go test -coverprofile)."""
+    files: Dict[str, FileCoverage] = {}
+    with open(report_path, encoding="utf-8") as f:
+        for line in f:
+            match = _GO_BLOCK.match(line.strip())
+            if not match:
+                continue
+            path, start, end, count = match.groups()
+            coverage = files.setdefault(path, FileCoverage())
+            for number in range(int(start), int(end) + 1):
+                coverage.add_line(number, int(count), test_name)
+    return files
+
+
+def parse_cobertura(
+    report_path: Path, test_name: Optional[str] = None
+) -> Dict[str, FileCoverage]:
+    """
+    Parse a Cobertura XML report (coverage.py, Istanbul, gcovr, ...).
+
+    Filenames are returned as written; relative ones are resolved against
+    cobertura_source_roots() when imported.
+    """
+    files: Dict[str, FileCoverage] = {}
+    root = ET.parse(report_path).getroot()
+    for class_element in root.iter("class"):
+        filename = class_element.get("filename")
+        if not filename:
+            continue
+        coverage = files.setdefault(filename, FileCoverage())
+        for line_element in class_element.iter("line"):
+            try:
+                number = int(line_element.get("number", ""))
+                hits = int(line_element.get("hits", "0"))
+            except ValueError:
+                continue
+            coverage.add_line(number, hits, test_name)
+    return files
+
+
+def cobertura_source_roots(report_path: Path) -> List[str]:
+    """<source> directories of a Cobertura report."""
+    root = ET.parse(report_path).getroot()
+    return [s.text.strip() for s in root.iter("source") if s.text and s.text.strip()]
+
+
+def parse_lcov(
+    report_path: Path, test_name: Optional[str] = None
+) -> Dict[str, FileCoverage]:
+    """
+    Parse an lcov tracefile.
+
+    Test names come from "TN:" records unless test_name overrides them.
+    """
+    files: Dict[str, FileCoverage] = {}
+    record_test: Optional[str] = None
+    coverage: Optional[FileCoverage] = None
+    with open(report_path, encoding="utf-8") as f:
+        for raw_line in f:
+            line = raw_line.strip()
+            if line.startswith("TN:"):
+                record_test = line[3:].strip() or None
+            elif line.startswith("SF:"):
+                coverage = files.setdefault(line[3:].strip(), FileCoverage())
+            elif line.startswith("DA:") and coverage is not None:
+                parts = line[3:].split(",")
+                try:
+                    number, hits = int(parts[0]), int(float(parts[1]))
+                except (IndexError, ValueError):
+                    continue
+                coverage.add_line(number, hits, test_name or record_test)
+            elif line == "end_of_record":
+                coverage = None
+    return files
+
+
+_PARSERS = {
+    "go": parse_go_coverprofile,
+    "cobertura": parse_cobertura,
+    "lcov": parse_lcov,
+}
+
+
+class CoverageMap:
+    """Imported coverage of a project, keyed by indexed relative paths."""
+
+    def __init__(self, project_root: Path, index_dir: Path):
+        """
+        Initialize the coverage map, loading previously imported coverage.
+
+        Args:
+            project_root: Root of the indexed project
+            index_dir: Project's .code-indexer directory
+        """
+        self.project_root = project_root
+        self.path = index_dir / COVERAGE_FILENAME
+        self.files: Dict[str, FileCoverage] = {}
+        self.reports: List[Dict[str, Any]] = []
+        self._load()
+
+    @property
+    def has_data(self) -> bool:
+        return bool(self.files)
+
+    def import_report(
+        self,
+        report_path: Path,
+        report_format: Optional[str] = None,
+        test_name: Optional[str] = None,
+    ) -> Dict[str, Any]:
+        """
+        Merge a coverage report into the map (call save() to persist).
+
+        Args:
+            report_path: Coverage report
+            report_format: One of REPORT_FORMATS (default: detected)
+            test_name: Test the report belongs to (lcov: overrides TN records)
+
+        Returns:
+            Summary of the imported report
+
+        Raises:
+            ValueError: If the format is unknown or the report is malformed
+        """
+        report_format = report_format or detect_report_format(report_path)
+        if report_format not in _PARSERS:
+            raise ValueError(f"Unknown coverage report format: {report_format}")
+        try:
+            parsed = _PARSERS[report_format](report_path, test_name)
+        except ET.ParseError as e:
+            raise ValueError(f"Malformed coverage report {report_path}: {e}")
+
+        source_roots = (
+            cobertura_source_roots(report_path) if report_format == "cobertura" else []
+        )
+        imported = 0
+        skipped = 0
+        tests: Set[str] = set()
+        for report_file, coverage in parsed.items():
+            relative_path = self._relative_path(report_file, source_roots)
+            if relative_path is None:
+                skipped += 1
+                continue
+            self.files.setdefault(relative_path, FileCoverage()).merge(coverage)
+            tests.update(coverage.tests)
+            imported += 1
+
+        summary = {
+            "report": str(report_path),
+            "format": report_format,
+            "files": imported,
+            "skipped_files": skipped,
+            "tests": sorted(tests),
+            "imported_at": datetime.now(timezone.utc).isoformat(),
+        }
+        self.reports.append(summary)
+        return summary
+
+    def clear(self) -> None:
+        """Forget all imported coverage (call save() to persist)."""
+        self.files = {}
+        self.reports = []
+
+    def save(self) -> None:
+        """Persist the map atomically."""
+        data = {
+            "version": COVERAGE_FORMAT_VERSION,
+            "reports": self.reports,
+            "files": {
+                path: {
+                    "coverable": sorted(coverage.coverable),
+                    "covered": sorted(coverage.covered),
+                    "tests": {
+                        name: sorted(lines)
+                        for name, lines in sorted(coverage.tests.items())
+                    },
+                }
+                for path, coverage in sorted(self.files.items())
+            },
+        }
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        tmp_path = self.path.with_suffix(".json.tmp")
+        with open(tmp_path, "w") as f:
+            json.dump(data, f)
+        os.replace(tmp_path, self.path)
+
+    def range_coverage(
+        self,
+        path: str,
+        line_start: Optional[int] = None,
+        line_end: Optional[int] = None,
+    ) -> Optional[RangeCoverage]:
+        """
+        Coverage of a line range of a file.
+
+        Args:
+            path: Indexed relative path
+            line_start: First line (default: start of file)
+            line_end: Last line (default: end of file)
+
+        Returns:
+            RangeCoverage, or None when no coverage was imported for the file
+        """
+        coverage = self.files.get(path)
+        if coverage is None:
+            return None
+
+        def in_range(lines: Iterable[int]) -> Set[int]:
+            return {
+                n
+                for n in lines
+                if (line_start is None or n >= line_start)
+                and (line_end is None or n <= line_end)
+            }
+
+        return RangeCoverage(
+            coverable=len(in_range(coverage.coverable)),
+            covered=len(in_range(coverage.covered)),
+            tests=sorted(
+                name for name, lines in coverage.tests.items() if in_range(lines)
+            ),
+        )
+
+    def annotate_results(self, results: List[Dict[str, Any]]) -> None:
+        """Add a "coverage" entry to query results whose file has coverage."""
+        for result in results:
+            payload = result.get("payload", {})
+            coverage = self.range_coverage(
+                payload.get("path", ""),
+                payload.get("line_start"),
+                payload.get("line_end"),
+            )
+            if coverage is not None:
+                result["coverage"] = {
+                    "coverable_lines": coverage.coverable,
+                    "covered_lines": coverage.covered,
+                    "tests": coverage.tests,
+                    "uncovered": coverage.is_uncovered,
+                }
+
+    @staticmethod
+    def filter_results(
+        results: List[Dict[str, Any]],
+        uncovered: bool = False,
+        covered_by: Sequence[str] = (),
+    ) -> List[Dict[str, Any]]:
+        """
+        Keep annotated results matching coverage filters.
+
+        Args:
+            results: Results annotated by annotate_results()
+            uncovered: Keep only results with executable lines that never ran
+            covered_by: Keep only results covered by any of these tests
+
+        Returns:
+            Matching results; results without coverage data never match
+        """
+        filtered = []
+        for result in results:
+            coverage = result.get("coverage")
+            if coverage is None:
+                continue
+            if uncovered and not coverage["uncovered"]:
+                continue
+            if covered_by and not set(covered_by) & set(coverage["tests"]):
+                continue
+            filtered.append(result)
+        return filtered
+
+    def _load(self) -> None:
+        if not self.path.exists():
+            return
+        try:
+            with open(self.path) as f:
+                data = json.load(f)
+        except (OSError, json.JSONDecodeError) as e:
+            logger.warning(f"Ignoring unreadable coverage data {self.path}: {e}")
+            return
+        self.reports = data.get("reports", [])
+        for path, entry in data.get("files", {}).items():
+            self.files[path] = FileCoverage(
+                coverable=set(entry.get("coverable", [])),
+                covered=set(entry.get("covered", [])),
+                tests={
+                    name: set(lines) for name, lines in entry.get("tests", {}).items()
+                },
+            )
+
+    def _relative_path(
+        self, report_file: str, source_roots: List[str]
+    ) -> Optional[str]:
+        """
+        Indexed relative path of a file named in a report.
+
+        Returns:
+            Relative POSIX path, or None for files outside the project
+        """
+        root = self.project_root.resolve()
+        raw = Path(report_file)
+        if raw.is_absolute():
+            candidates = [raw]
+        else:
+            candidates = [Path(source) / raw for source in source_roots]
+            candidates.append(root / raw)
+
+        for candidate in candidates:
+            try:
+                relative = candidate.resolve().relative_to(root)
+            except ValueError:
+                continue
+            if (root / relative).exists():
+                return relative.as_posix()
+
+        # Go coverprofiles name files by import path
+        module = self._go_module()
+        if module and report_file.startswith(module + "/"):
+            return report_file[len(module) + 1 :]
+
+        if raw.is_absolute():
+            return None
+        return raw.as_posix()
+
+    def _go_module(self) -> Optional[str]:
+        go_mod = self.project_root / "go.mod"
+        if not go_mod.exists():
+            return None
+        match = re.search(r"^module\s+(\S+)", go_mod.read_text(), re.MULTILINE)
+        return match.group(1) if match else None
@@ -0,0 +1,86 @@
+"""
+Diff-scoped semantic search of cidx query --changed-since.
+
+'cidx query "..." --changed-since origin/main' searches only the files a
+branch touches, so reviewers can explore a change semantically. The changed
+files are those differing between the merge base of the ref and HEAD and the
+working tree (committed, staged and unstaged changes), plus untracked files
+that are not ignored, relative to the project root. Deleted files have
+nothing left to find. The search is restricted to points of those paths, so
+all candidates come from the change.
+"""
+
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List
+
+from ..utils.git_runner import run_git_command
+
+logger = logging.getLogger(__name__)
+
+
+@dataclass
+class ChangedFiles:
+    """Files changed since the merge base of a ref."""
+
+    ref: str
+    base: str
+    paths: List[str] = field(default_factory=list)
+
+    def payload_condition(self) -> Dict[str, Any]:
+        """Payload filter condition matching points of the changed files."""
+        return {"key": "path", "match": {"any": set(self.paths)}}
+
+
+def resolve_changed_files(project_root: Path, ref: str) -> ChangedFiles:
+    """
+    Files changed on the current branch and in the working tree since it
+    forked from a ref.
+
+    Raises:
+        ValueError: If the ref does not name a commit or shares no history
+            with HEAD
+    """
+    result = run_git_command(
+        ["git", "rev-parse", "--verify", "--quiet", f"{ref}^{{commit}}"],
+        cwd=project_root,
+        check=False,
+    )
+    if result.returncode != 0:
+        raise ValueError(f"'{ref}' is not a commit, tag or branch of this repository")
+    base = run_git_command(
+        ["git", "merge-base", result.stdout.strip(), "HEAD"],
+        cwd=project_root,
+        check=False,
+    )
+    if base.returncode != 0:
+        raise ValueError(f"'{ref}' has no history in common with HEAD")
+    base_commit = base.stdout.strip()
+
+    diff = run_git_command(
+        [
+            "git",
+            "diff",
+            "--name-only",
+            "-z",
+            "--relative",
+            "--diff-filter=d",
+            base_commit,
+        ],
+        cwd=project_root,
+        check=False,
+    )
+    if diff.returncode != 0:
+        raise ValueError(f"Cannot diff against {ref}: {diff.stderr.strip()}")
+    untracked = run_git_command(
+        ["git", "ls-files", "--others", "--exclude-standard", "-z"],
+        cwd=project_root,
+        check=False,
+    )
+    paths = [p for p in diff.stdout.split("\0") if p]
+    if untracked.returncode == 0:
+        paths.extend(p for p in untracked.stdout.split("\0") if p)
+    paths = sorted(set(paths))
+    logger.debug(f"{len(paths)} files changed since {ref} ({base_commit})")
+    return ChangedFiles(ref=ref, base=base_commit, paths=paths)
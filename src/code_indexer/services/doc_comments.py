@@ -0,0 +1,258 @@
+"""
+Pairing of doc comments with the declarations they document.
+
+Fixed-size chunk boundaries can fall between a doc comment and the function
+or type below it, leaving the comment in the neighbouring chunk and the
+declaration without the words that describe it. The doc of a declaration is:
+
+- Go: the // comment lines directly above a top-level func, type, var or
+  const
+- Java, Kotlin, Scala, Groovy, C#, C/C++, JavaScript/TypeScript, PHP, Swift
+  and Dart: the /** ... */ block or /// lines directly above it, annotations
+  and decorators in between allowed
+- Rust: the /// lines directly above an item, attributes in between allowed
+- Python: the docstring of a def or class
+- Ruby: the # comment lines directly above a def, class or module
+
+Every chunk holding a declaration records the doc of its first declaration
+under "doc", with comment markers stripped. When the doc lies (partly)
+outside the chunk, it is also put in front of the chunk's embedded text, so
+the declaration is found by the words of its doc. The stored chunk text is
+unchanged.
+"""
+
+import inspect
+import re
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+from .boilerplate_filter import EMBEDDING_TEXT_KEY
+
+# Chunk and payload key
+DOC_KEY = "doc"
+
+# Docs are cut to this many characters
+MAX_DOC_CHARS = 1000
+
+BLOCK_DOC_LANGUAGES = {
+    "java",
+    "kt",
+    "kts",
+    "scala",
+    "groovy",
+    "cs",
+    "c",
+    "h",
+    "cc",
+    "cpp",
+    "cxx",
+    "hpp",
+    "js",
+    "jsx",
+    "mjs",
+    "cjs",
+    "ts",
+    "tsx",
+    "php",
+    "swift",
+    "dart",
+}
+
+_GO_DECLARATION = re.compile(r"^(?:func|type|var|const)\b")
+_RUBY_DECLARATION = re.compile(r"^\s*(?:def|class|module)\s")
+_PY_DECLARATION = re.compile(r"^\s*(?:async\s+)?(?:def|class)\s")
+_PY_DOCSTRING = re.compile(r"^\s*[rRuUbB]?(\"\"\"|''')")
+# @Override, @Test(timeout = 5), [Obsolete], #[derive(Debug)], @objc
+_ANNOTATION = re.compile(r"^\s*(?:@[\w.]+(?:\(.*\))?|\[[\w.]+.*\]|#!?\[.*\])\s*$")
+# Lines a /** */ block above does not document: license headers
+_NOT_DECLARATION = re.compile(r"^\s*(?:package|import|using|namespace|#include)\b")
+# //go:generate, //nolint:errcheck and other tool directives are not docs
+_DIRECTIVE = re.compile(r"^\s*//[a-z]+:\S")
+
+# Lines a Python signature may span
+MAX_SIGNATURE_LINES = 20
+
+
+@dataclass
+class DocComment:
+    """A doc comment and the declaration it documents (0-based lines)."""
+
+    first_line: int
+    last_line: int
+    declaration_line: int
+    text: str
+
+
+def _skip_annotations(lines: List[str], i: int) -> int:
+    """Index of the first line at or after i that is not an annotation."""
+    while i < len(lines) and _ANNOTATION.match(lines[i]):
+        i += 1
+    return i
+
+
+def _clean(lines: List[str], markers: str) -> str:
+    """Doc text of comment lines, without comment markers."""
+    cleaned = []
+    for line in lines:
+        if _DIRECTIVE.match(line):
+            continue
+        line = line.strip()
+        if line.endswith("*/"):
+            line = line[:-2]
+        for marker in markers.split():
+            if line.startswith(marker):
+                line = line[len(marker) :]
+                break
+        cleaned.append(line.strip())
+    text = "\n".join(cleaned).strip()
+    return text[:MAX_DOC_CHARS]
+
+
+def _line_docs(
+    lines: List[str], prefix: str, is_declaration: Any, annotations: bool
+) -> List[DocComment]:
+    docs = []
+    i = 0
+    while i < len(lines):
+        if not lines[i].lstrip().startswith(prefix):
+            i += 1
+            continue
+        first = i
+        while i < len(lines) and lines[i].lstrip().startswith(prefix):
+            i += 1
+        declaration = _skip_annotations(lines, i) if annotations else i
+        if declaration < len(lines) and is_declaration(lines[declaration]):
+            text = _clean(lines[first:i], prefix)
+            if text:
+                docs.append(DocComment(first, i - 1, declaration, text))
+    return docs
+
+
+def _block_docs(lines: List[str]) -> List[DocComment]:
+    docs = []
+    i = 0
+    while i < len(lines):
+        stripped = lines[i].strip()
+        if stripped.startswith("/**") and not stripped.startswith("/**/"):
+            first = i
+            while i < len(lines) and "*/" not in lines[i]:
+                i += 1
+            last = min(i, len(lines) - 1)
+            i = last + 1
+            markers = "/** *"
+        elif stripped.startswith("///"):
+            first = i
+            while i < len(lines) and lines[i].lstrip().startswith("///"):
+                i += 1
+            last = i - 1
+            markers = "///"
+        else:
+            i += 1
+            continue
+        declaration = _skip_annotations(lines, last + 1)
+        if declaration >= len(lines) or not lines[declaration].strip():
+            continue
+        if not _NOT_DECLARATION.match(lines[declaration]):
+            text = _clean(lines[first : last + 1], markers)
+            if text:
+                docs.append(DocComment(first, last, declaration, text))
+    return docs
+
+
+def _python_docs(lines: List[str]) -> List[DocComment]:
+    docs = []
+    for i, line in enumerate(lines):
+        if not _PY_DECLARATION.match(line):
+            continue
+        # The body starts after the line ending the signature
+        end = i
+        while end < min(len(lines), i + MAX_SIGNATURE_LINES):
+            if lines[end].split("#")[0].rstrip().endswith(":"):
+                break
+            end += 1
+        body = end + 1
+        while body < len(lines) and not lines[body].strip():
+            body += 1
+        match = _PY_DOCSTRING.match(lines[body]) if body < len(lines) else None
+        if not match:
+            continue
+        quote = match.group(1)
+        rest = lines[body][match.end() :]
+        last = body
+        if quote not in rest:
+            last = body + 1
+            while last < len(lines) and quote not in lines[last]:
+                last += 1
+            last = min(last, len(lines) - 1)
+        docstring = "\n".join([rest] + lines[body + 1 : last + 1])
+        text = inspect.cleandoc(docstring.split(quote)[0])
+        if text:
+            docs.append(DocComment(body, last, i, text[:MAX_DOC_CHARS]))
+    return docs
+
+
+def doc_comments(text: str, language: str) -> List[DocComment]:
+    """Doc comments of a file, ordered by declaration line."""
+    lines = text.split("\n")
+    language = language.lower()
+    if language == "py":
+        docs = _python_docs(lines)
+    elif language == "go":
+        docs = _line_docs(lines, "//", _GO_DECLARATION.match, annotations=False)
+    elif language == "rb":
+        docs = _line_docs(lines, "#", _RUBY_DECLARATION.match, annotations=False)
+    elif language == "rs":
+        docs = _line_docs(lines, "///", lambda line: bool(line.strip()), True)
+    elif language in BLOCK_DOC_LANGUAGES:
+        docs = _block_docs(lines)
+    else:
+        return []
+    return sorted(docs, key=lambda doc: doc.declaration_line)
+
+
+class DocCommentPairer:
+    """Records the doc comments of declarations with their chunks."""
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["DocCommentPairer"]:
+        """Pairer from indexing.doc_comments, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        doc_config = getattr(indexing_config, "doc_comments", None)
+        if getattr(doc_config, "enabled", False) is not True:
+            return None
+        return cls()
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """
+        Return the chunks with the doc of their first declaration under
+        DOC_KEY, and in front of EMBEDDING_TEXT_KEY when split off.
+        """
+        if not chunks:
+            return chunks
+        language = (chunks[0].get("file_extension") or "").lower()
+        if language not in BLOCK_DOC_LANGUAGES | {"py", "go", "rb", "rs"}:
+            return chunks
+        try:
+            text = Path(file_path).read_bytes().decode("utf-8", errors="replace")
+        except OSError:
+            return chunks
+        docs = doc_comments(text, language)
+        if not docs:
+            return chunks
+        annotated = []
+        for chunk in chunks:
+            first = chunk.get("line_start", 1) - 1
+            last = chunk.get("line_end", first + 1) - 1
+            doc = next((d for d in docs if first <= d.declaration_line <= last), None)
+            if doc is None or chunk.get("file_extension", language) != language:
+                annotated.append(chunk)
+                continue
+            chunk = {**chunk, DOC_KEY: doc.text}
+            if doc.first_line < first or doc.last_line > last:
+                embedded = chunk.get(EMBEDDING_TEXT_KEY, chunk["text"])
+                chunk[EMBEDDING_TEXT_KEY] = f"{doc.text}\n\n{embedded}"
+            annotated.append(chunk)
+        return annotated
@@ -133,3 +133,10 @@ class EmbeddingProvider(ABC):
             True if batch processing is supported and efficient
         """
         pass
+
+    def warm_up(self) -> None:
+        """Load local resources (tokenizers, model specs) ahead of the first call.
+
+        Must not call the remote API. Default implementation does nothing.
+        """
+        return None
@@ -0,0 +1,64 @@
+"""
+Exclusion filters of cidx query: --exclude-path and --exclude-kind.
+
+Both take comma-separated lists ('--exclude-path vendor/**,**/testdata/**',
+'--exclude-kind test,mock') and become must_not payload conditions, so the
+vector store drops excluded chunks while it collects candidates: noisy
+directories and file kinds never take a place in the ranking.
+
+Kinds:
+
+- test: chunks of test files (payload "is_test", see test_linkage)
+- mock: files named or placed like mocks (mock_foo.go, foo_mock.go,
+  foo.mock.ts, FooMock.java, mocks/, __mocks__/)
+- generated: generated files (payload "generated"); left out by default
+  unless --include-generated
+- any other kind: declarations of that kind (payload "symbol_kind", the
+  kinds --symbol-kind selects)
+"""
+
+from typing import Any, Dict, Iterable, List
+
+from ..indexing.boundary_chunking import SYMBOL_KIND_KEY
+from .generated_code import GENERATED_KEY
+from .test_linkage import IS_TEST_KEY
+
+TEST_KIND = "test"
+MOCK_KIND = "mock"
+GENERATED_KIND = "generated"
+
+# Paths of mock files, gitignore-style (see path_pattern_matcher)
+MOCK_PATH_PATTERNS = (
+    "**/mocks/**",
+    "**/__mocks__/**",
+    "**/mock_*",
+    "**/*_mock.*",
+    "**/*_mocks.*",
+    "**/*.mock.*",
+    "**/*Mock.*",
+)
+
+
+def split_values(values: Iterable[str]) -> List[str]:
+    """Values of a repeatable option, each possibly comma-separated."""
+    return [
+        part.strip() for value in values for part in value.split(",") if part.strip()
+    ]
+
+
+def kind_exclusion_conditions(kinds: Iterable[str]) -> List[Dict[str, Any]]:
+    """must_not payload conditions excluding chunks of these kinds."""
+    conditions: List[Dict[str, Any]] = []
+    for kind in dict.fromkeys(kind.lower() for kind in split_values(kinds)):
+        if kind == TEST_KIND:
+            conditions.append({"key": IS_TEST_KEY, "match": {"value": True}})
+        elif kind == GENERATED_KIND:
+            conditions.append({"key": GENERATED_KEY, "match": {"value": True}})
+        elif kind == MOCK_KIND:
+            conditions.extend(
+                {"key": "path", "match": {"text": pattern}}
+                for pattern in MOCK_PATH_PATTERNS
+            )
+        else:
+            conditions.append({"key": SYMBOL_KIND_KEY, "match": {"value": kind}})
+    return conditions
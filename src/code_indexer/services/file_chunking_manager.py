@@ -4,6 +4,12 @@ FileChunkingManager for parallel file processing with vector integration and vec
 Handles complete file lifecycle: chunk → vector → wait → write to vector storage
 with file atomicity and immediate progress feedback.
 
+When an upsert queue size is configured, the write step is handed off to a
+dedicated UpsertStage through a bounded queue so chunking/embedding of the next
+file overlaps with storage writes of the previous one (backpressure-aware).
+With a memory budget, points that do not fit in memory wait in a spill file
+instead of the queue.
+
 This implementation addresses the specific user problems:
 1. "not efficient for very small files" - solved by parallel processing
 2. "no feedback when chunking files" - solved by immediate progress callbacks
@@ -12,20 +18,120 @@ Architecture:
 - ThreadPoolExecutor with (thread_count + 2) workers per specifications
 - File atomicity: all chunks from one file written together
 - Worker threads handle complete lifecycle: chunk → vector → wait → write
+  (or chunk → vector → wait → enqueue when the pipelined upsert stage is enabled)
 - Immediate queuing feedback before async processing
 """
 
-import hashlib
 import logging
 import time
-from concurrent.futures import ThreadPoolExecutor, Future
+from concurrent.futures import ThreadPoolExecutor, Future, InvalidStateError
 from pathlib import Path
 from typing import Dict, Any, Optional, Callable, List
 from dataclasses import dataclass
 
 from .vector_calculation_manager import VectorCalculationManager
-from ..indexing.fixed_size_chunker import FixedSizeChunker
+from ..indexing.fixed_size_chunker import SYMBOLS_KEY, FixedSizeChunker
+from ..indexing.language_detection import detect_language
 from .clean_slot_tracker import CleanSlotTracker, FileData, FileStatus
+from .upsert_stage import UpsertStage
+from .memory_budget import MemoryBudget, estimate_points_bytes
+from .content_dedup import DUPLICATE_PATHS_KEY
+from .pii_scrubber import PII_SCRUBBED_KEY, PiiScrubber
+from ..indexing.boundary_chunking import (
+    DEGRADED_KEY,
+    EMBEDDED_LANGUAGE_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+)
+from ..indexing.chunk_payload_keys import (
+    BUILD_BLOCK_KEY,
+    BUILD_COORDINATES_KEY,
+    BUILD_NAME_KEY,
+    BUILD_PLUGINS_KEY,
+    BUILD_SYSTEM_KEY,
+    COBOL_COPYBOOKS_KEY,
+    COBOL_DIVISION_KEY,
+    COBOL_PARAGRAPH_KEY,
+    COBOL_PROGRAM_KEY,
+    COBOL_SECTION_KEY,
+    COMPONENT_BLOCK_KEY,
+    COMPONENT_LANG_KEY,
+    COMPONENT_NAME_KEY,
+    COMPOSE_IMAGE_KEY,
+    COMPOSE_PORTS_KEY,
+    COMPOSE_SERVICE_KEY,
+    COMPOSE_VOLUMES_KEY,
+    DOCKER_BASE_IMAGE_KEY,
+    DOCKER_STAGE_KEY,
+    FLUTTER_WIDGET_KEY,
+    HDL_PORTS_KEY,
+    HDL_UNIT_KEY,
+    HEADING_PATH_KEY,
+    K8S_KIND_KEY,
+    K8S_NAMESPACE_KEY,
+    K8S_NAME_KEY,
+    OPENAPI_KIND_KEY,
+    OPENAPI_OPERATION_KEY,
+    OPENAPI_SCHEMA_KEY,
+    OPENAPI_TAGS_KEY,
+    OPERATION_ID_KEY,
+    PROTO_KIND_KEY,
+    PROTO_NAME_KEY,
+    PROTO_OPTIONS_KEY,
+    PROTO_PACKAGE_KEY,
+    RUST_DERIVES_KEY,
+    SHELL_BLOCK_KEY,
+    SHELL_FUNCTION_KEY,
+)
+from .boilerplate_filter import EMBEDDING_TEXT_KEY, BoilerplateFilter
+from .task_markers import TASK_MARKERS_KEY, TaskMarkerExtractor
+from .license_detection import LICENSE_KEY, LICENSE_SOURCE_KEY, LicenseDetector
+from .code_owners import OWNERS_KEY, CodeOwners
+from .struct_tags import STRUCT_FIELDS_KEY, STRUCT_TAGS_KEY, StructTagExtractor
+from .go_interfaces import IMPLEMENTS_KEY, GoInterfaceIndex
+from .go_build_constraints import (
+    BUILD_ARCH_KEY,
+    BUILD_CONSTRAINT_KEY,
+    BUILD_OS_KEY,
+    BUILD_TAGS_KEY,
+    GoBuildConstraints,
+)
+from .go_dependencies import (
+    GO_IMPORTS_KEY,
+    GO_MODULES_KEY,
+    GO_PACKAGE_KEY,
+    GoDependencyGraph,
+)
+from .import_graph import IMPORTED_PATHS_KEY, IMPORTS_KEY, ImportGraph
+from .test_linkage import IS_TEST_KEY, TEST_OF_KEY, SubjectLinker
+from .generated_code import GENERATED_KEY, GeneratedCodeDetector
+from .grpc_stubs import GRPC_STUBS_KEY, GrpcStubIndex
+from .cobol_copybooks import (
+    COPYBOOK_PATHS_KEY,
+    UNRESOLVED_COPYBOOKS_KEY,
+    CopybookIndex,
+)
+from .doc_comments import DOC_KEY, DocCommentPairer
+from .chunk_context import ChunkContextEnricher
+from .code_metrics import (
+    COMPLEXITY_KEY,
+    LOC_KEY,
+    NESTING_DEPTH_KEY,
+    PARAMETER_COUNT_KEY,
+    CodeMetrics,
+)
+from .symbol_declarations import DECLARATIONS_KEY, SymbolDeclarations
+from .signature_vectors import SignatureVectors
+from ..storage.vector_kinds import BODY_POINT_KEY, SIGNATURE_VECTOR, VECTOR_KIND_KEY
+from .type_parameters import (
+    TYPE_CONSTRAINTS_KEY,
+    TYPE_PARAMETERS_KEY,
+    TypeParameterExtractor,
+)
+from .lifecycle_hooks import CUSTOM_METADATA_KEY, LifecycleHooks
+from .chunk_integrity import compute_chunk_hash
+from .chunk_ids import compute_chunk_point_id
+from .. import __version__
 import threading
 
 # Token counting for large file handling - using embedded tokenizer
@@ -38,6 +144,91 @@ logger = logging.getLogger(__name__)
 VECTOR_PROCESSING_TIMEOUT = 300.0  # 5 minutes timeout for vector processing
 THREAD_POOL_SHUTDOWN_TIMEOUT = 30.0  # 30 seconds for graceful shutdown
 
+# Payload key holding the hash of the text embedded for a chunk; watch mode
+# reuses the stored vectors of chunks whose embedded text is unchanged
+EMBEDDING_HASH_KEY = "embedding_hash"
+
+# Chunk keys carried from chunking into the point payload
+CHUNK_PAYLOAD_KEYS = (
+    PII_SCRUBBED_KEY,
+    TASK_MARKERS_KEY,
+    LICENSE_KEY,
+    LICENSE_SOURCE_KEY,
+    OWNERS_KEY,
+    STRUCT_TAGS_KEY,
+    STRUCT_FIELDS_KEY,
+    IMPLEMENTS_KEY,
+    BUILD_CONSTRAINT_KEY,
+    BUILD_OS_KEY,
+    BUILD_ARCH_KEY,
+    BUILD_TAGS_KEY,
+    GO_PACKAGE_KEY,
+    GO_IMPORTS_KEY,
+    GO_MODULES_KEY,
+    IMPORTS_KEY,
+    IMPORTED_PATHS_KEY,
+    IS_TEST_KEY,
+    TEST_OF_KEY,
+    GENERATED_KEY,
+    DOC_KEY,
+    LOC_KEY,
+    COMPLEXITY_KEY,
+    NESTING_DEPTH_KEY,
+    PARAMETER_COUNT_KEY,
+    DECLARATIONS_KEY,
+    VECTOR_KIND_KEY,
+    TYPE_PARAMETERS_KEY,
+    TYPE_CONSTRAINTS_KEY,
+    CUSTOM_METADATA_KEY,
+    HEADING_PATH_KEY,
+    PROTO_KIND_KEY,
+    PROTO_NAME_KEY,
+    PROTO_PACKAGE_KEY,
+    PROTO_OPTIONS_KEY,
+    GRPC_STUBS_KEY,
+    OPENAPI_KIND_KEY,
+    OPENAPI_OPERATION_KEY,
+    OPERATION_ID_KEY,
+    OPENAPI_TAGS_KEY,
+    OPENAPI_SCHEMA_KEY,
+    DOCKER_STAGE_KEY,
+    DOCKER_BASE_IMAGE_KEY,
+    COMPOSE_SERVICE_KEY,
+    COMPOSE_IMAGE_KEY,
+    COMPOSE_PORTS_KEY,
+    COMPOSE_VOLUMES_KEY,
+    K8S_KIND_KEY,
+    K8S_NAME_KEY,
+    K8S_NAMESPACE_KEY,
+    COMPONENT_NAME_KEY,
+    COMPONENT_BLOCK_KEY,
+    COMPONENT_LANG_KEY,
+    SHELL_BLOCK_KEY,
+    SHELL_FUNCTION_KEY,
+    BUILD_SYSTEM_KEY,
+    BUILD_BLOCK_KEY,
+    BUILD_NAME_KEY,
+    BUILD_COORDINATES_KEY,
+    BUILD_PLUGINS_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    SYMBOLS_KEY,
+    FLUTTER_WIDGET_KEY,
+    COBOL_PROGRAM_KEY,
+    COBOL_DIVISION_KEY,
+    COBOL_SECTION_KEY,
+    COBOL_PARAGRAPH_KEY,
+    COBOL_COPYBOOKS_KEY,
+    COPYBOOK_PATHS_KEY,
+    UNRESOLVED_COPYBOOKS_KEY,
+    HDL_UNIT_KEY,
+    HDL_PORTS_KEY,
+    EMBEDDED_LANGUAGE_KEY,
+    DEGRADED_KEY,
+    EMBEDDING_HASH_KEY,
+    RUST_DERIVES_KEY,
+)
+
 
 @dataclass
 class FileProcessingResult:
@@ -62,6 +253,31 @@ class FileChunkingManager:
         slot_tracker: CleanSlotTracker,
         codebase_dir: Path,  # CRITICAL FOR COW CLONING: Needed for path normalization
         fts_manager=None,  # Optional FTS index manager
+        upsert_queue_size: int = 0,  # 0 = write inline in worker threads
+        file_delay_seconds: float = 0.0,  # Throttle: pause per file in workers
+        memory_budget: Optional[MemoryBudget] = None,  # Spill queued points
+        pii_scrubber: Optional[PiiScrubber] = None,  # Mask PII before embedding
+        boilerplate_filter: Optional[BoilerplateFilter] = None,  # Embed less noise
+        task_marker_extractor: Optional[TaskMarkerExtractor] = None,  # cidx todos
+        license_detector: Optional[LicenseDetector] = None,  # --license filters
+        code_owners: Optional[CodeOwners] = None,  # --owner filters
+        struct_tag_extractor: Optional[StructTagExtractor] = None,  # --struct-tag
+        go_interface_index: Optional[GoInterfaceIndex] = None,  # --implements
+        go_build_constraints: Optional[GoBuildConstraints] = None,  # --platform
+        go_dependency_graph: Optional[GoDependencyGraph] = None,  # cidx deps
+        import_graph: Optional[ImportGraph] = None,  # cidx imports
+        subject_linker: Optional[SubjectLinker] = None,  # --only-tests
+        generated_code_detector: Optional[GeneratedCodeDetector] = None,  # codegen
+        grpc_stub_index: Optional[GrpcStubIndex] = None,  # .proto rpc links
+        copybook_index: Optional[CopybookIndex] = None,  # COBOL COPY links
+        code_metrics: Optional[CodeMetrics] = None,  # --min-complexity
+        symbol_declarations: Optional[SymbolDeclarations] = None,  # cidx symbol
+        doc_comment_pairer: Optional[DocCommentPairer] = None,  # doc payload
+        chunk_context: Optional[ChunkContextEnricher] = None,  # Enclosing type
+        signature_vectors: Optional[SignatureVectors] = None,  # --vector-mode
+        type_parameter_extractor: Optional[TypeParameterExtractor] = None,  # generics
+        lifecycle_hooks: Optional[LifecycleHooks] = None,  # post_chunk, pre_embed
+        reuse_embeddings: bool = False,  # Watch mode: embed changed chunks only
     ):
         """
         Initialize FileChunkingManager with complete functionality.
@@ -73,6 +289,60 @@ class FileChunkingManager:
             thread_count: Number of worker threads (thread_count + 2 per specs)
             slot_tracker: CleanSlotTracker for progress tracking and slot management
             codebase_dir: Repository root directory for path normalization
+            fts_manager: Optional FTS index manager
+            upsert_queue_size: Capacity of the bounded queue feeding the dedicated
+                upsert writer. 0 disables pipelining (workers write inline).
+            file_delay_seconds: Pause after each file in worker threads so
+                low-priority (throttled) indexing yields the CPU.
+            memory_budget: Limits embedded points waiting in the upsert queue;
+                files that do not fit are spilled to disk until written.
+            pii_scrubber: Masks PII in chunk text before it is embedded and
+                stored.
+            boilerplate_filter: Removes license headers, import blocks and
+                accessors from the embedded text; stored text is unchanged.
+            task_marker_extractor: Records TODO/FIXME-style comments of each
+                chunk, with git blame attribution, in its payload.
+            license_detector: Records the SPDX license of each file in the
+                payload of its chunks.
+            code_owners: Records the CODEOWNERS owners of each file in the
+                payload of its chunks.
+            struct_tag_extractor: Records the struct tags of the Go struct
+                fields in each chunk in its payload.
+            go_interface_index: Records the interfaces implemented by the Go
+                types declared in each chunk in its payload.
+            go_build_constraints: Records the build constraint (OS,
+                architecture, tags) of each Go file in the payload of its
+                chunks.
+            go_dependency_graph: Records the package, imports and imported
+                modules of each Go file in the payload of its chunks.
+            import_graph: Records the imports of each file and the project
+                files they resolve to in the payload of its chunks.
+            subject_linker: Marks the chunks of test files and records the
+                files they test.
+            generated_code_detector: Marks the chunks of generated and
+                minified files.
+            grpc_stub_index: Records the generated gRPC stubs of the rpc and
+                service chunks of .proto files.
+            copybook_index: Records the copybook files named by the COPY
+                statements of COBOL chunks.
+            code_metrics: Records the lines of code, cyclomatic complexity,
+                nesting depth and parameter count of code chunks.
+            symbol_declarations: Records the functions, methods and types
+                declared by code chunks.
+            doc_comment_pairer: Records the doc comment of each chunk's first
+                declaration and embeds it with the chunk when split off.
+            chunk_context: Prefixes the embedded text of each chunk with its
+                package, imports and enclosing type.
+            signature_vectors: Adds a signature point, embedding the signature
+                and doc comment, for each chunk that declares a symbol.
+            type_parameter_extractor: Records the type parameters of the
+                generic Go declarations in each chunk in its payload, and
+                names them in the embedded text of chunks inside their bodies.
+            lifecycle_hooks: Runs the configured post_chunk hooks on the
+                chunks of each file and pre_embed hooks on the embedded texts.
+            reuse_embeddings: Reuse the stored vectors of a file's chunks
+                whose embedded text is unchanged instead of embedding them
+                again, so saving a large file only embeds the edited chunks.
 
         Raises:
             ValueError: If thread_count is invalid or dependencies are None
@@ -89,6 +359,10 @@ class FileChunkingManager:
             raise ValueError("slot_tracker cannot be None")
         if not codebase_dir:
             raise ValueError("codebase_dir cannot be None")
+        if upsert_queue_size < 0:
+            raise ValueError(
+                f"upsert_queue_size must be non-negative, got {upsert_queue_size}"
+            )
 
         self.vector_manager = vector_manager
         self.chunker = chunker
@@ -97,6 +371,34 @@ class FileChunkingManager:
         self.slot_tracker = slot_tracker
         self.codebase_dir = codebase_dir
         self.fts_manager = fts_manager
+        self.upsert_queue_size = upsert_queue_size
+        self.file_delay_seconds = max(0.0, file_delay_seconds)
+        self.memory_budget = memory_budget
+        self.pii_scrubber = pii_scrubber
+        self.boilerplate_filter = boilerplate_filter
+        self.task_marker_extractor = task_marker_extractor
+        self.license_detector = license_detector
+        self.code_owners = code_owners
+        self.struct_tag_extractor = struct_tag_extractor
+        self.go_interface_index = go_interface_index
+        self.go_build_constraints = go_build_constraints
+        self.go_dependency_graph = go_dependency_graph
+        self.import_graph = import_graph
+        self.subject_linker = subject_linker
+        self.generated_code_detector = generated_code_detector
+        self.grpc_stub_index = grpc_stub_index
+        self.copybook_index = copybook_index
+        self.code_metrics = code_metrics
+        self.symbol_declarations = symbol_declarations
+        self.doc_comment_pairer = doc_comment_pairer
+        self.chunk_context = chunk_context
+        self.signature_vectors = signature_vectors
+        self.type_parameter_extractor = type_parameter_extractor
+        self.lifecycle_hooks = lifecycle_hooks
+        self.reuse_embeddings = reuse_embeddings
+
+        # Pipelined upsert stage (created on __enter__ when enabled)
+        self._upsert_stage: Optional[UpsertStage] = None
 
         # CRITICAL FIX: Single cancellation event shared with VectorCalculationManager
         self._cancellation_requested = False
@@ -124,21 +426,21 @@ class FileChunkingManager:
             file_path: File path (absolute or relative)
 
         Returns:
-            Relative path string for database storage
+            Relative path string with forward slashes on every platform
 
         Raises:
             ValueError: If file_path is not under codebase_dir
         """
         if file_path.is_absolute():
             try:
-                return str(file_path.relative_to(self.codebase_dir))
+                return file_path.relative_to(self.codebase_dir).as_posix()
             except ValueError as e:
                 logger.error(
                     f"Cannot normalize path {file_path} - not under codebase_dir "
                     f"{self.codebase_dir}: {e}"
                 )
                 raise
-        return str(file_path)
+        return file_path.as_posix()
 
     def __enter__(self):
         """Context manager entry - start thread pool."""
@@ -148,6 +450,11 @@ class FileChunkingManager:
         logger.info(
             f"Started FileChunkingManager thread pool with {self.thread_count + 2} workers"
         )
+        if self.upsert_queue_size > 0:
+            self._upsert_stage = UpsertStage(self.upsert_queue_size).start()
+            logger.info(
+                f"Started pipelined upsert stage with queue size {self.upsert_queue_size}"
+            )
         return self
 
     def __exit__(self, exc_type, exc_val, exc_tb):
@@ -195,8 +502,38 @@ class FileChunkingManager:
                 logger.error(f"Error during FileChunkingManager shutdown: {e}")
 
             finally:
+                # Drain the upsert stage after workers stop producing write jobs
+                if self._upsert_stage is not None:
+                    self._upsert_stage.close()
+                    logger.info(
+                        f"Upsert stage stats: {self._upsert_stage.get_stats()}"
+                    )
+                    self._upsert_stage = None
+                if self.memory_budget is not None:
+                    logger.info(
+                        f"Memory budget stats: {self.memory_budget.get_stats()}"
+                    )
+                    self.memory_budget.cleanup()
+                if self.pii_scrubber is not None:
+                    logger.info(f"PII scrubber stats: {self.pii_scrubber.get_stats()}")
+                if self.boilerplate_filter is not None:
+                    logger.info(
+                        "Boilerplate filter stats: "
+                        f"{self.boilerplate_filter.get_stats()}"
+                    )
+                if self.task_marker_extractor is not None:
+                    logger.info(
+                        "Task marker stats: "
+                        f"{self.task_marker_extractor.get_stats()}"
+                    )
                 self._shutdown_complete.set()
 
+    def get_upsert_backlog_ratio(self) -> Optional[float]:
+        """Upsert queue fill ratio, or None when writes happen inline."""
+        if self._upsert_stage is None:
+            return None
+        return self._upsert_stage.get_backlog_ratio()
+
     def request_cancellation(self) -> None:
         """Request cancellation of all file processing."""
         self._cancellation_requested = True
@@ -262,6 +599,21 @@ class FileChunkingManager:
 
         # Submit to worker thread (immediate return)
         # Always use clean implementation
+        if self._upsert_stage is not None:
+            # PIPELINED: Result future completes when the upsert stage has written
+            # the file, not when the worker thread hands it off
+            result_future: Future[FileProcessingResult] = Future()
+            worker_future = self.executor.submit(
+                self._process_file_pipelined,
+                file_path,
+                metadata,
+                progress_callback,
+                result_future,
+            )
+            self._pending_futures.append(worker_future)
+            self._pending_futures.append(result_future)
+            return result_future
+
         process_method = self._process_file_clean_lifecycle
 
         future = self.executor.submit(
@@ -277,6 +629,112 @@ class FileChunkingManager:
 
         return future
 
+    def _process_file_pipelined(
+        self,
+        file_path: Path,
+        metadata: Dict[str, Any],
+        progress_callback: Optional[Callable],
+        result_future: "Future[FileProcessingResult]",
+    ) -> None:
+        """
+        Worker entry point when the upsert stage is enabled.
+
+        Runs chunk → vector on the worker thread. Files that reach the write
+        phase are handed to the upsert stage, which resolves result_future;
+        every other outcome (empty file, failure, cancellation) resolves it here.
+        """
+        try:
+            result = self._process_file_clean_lifecycle(
+                file_path,
+                metadata,
+                progress_callback,
+                self.slot_tracker,
+                result_future=result_future,
+            )
+        except Exception as e:
+            result = FileProcessingResult(
+                success=False,
+                file_path=file_path,
+                chunks_processed=0,
+                processing_time=0.0,
+                error=f"File processing failed: {e}",
+            )
+
+        if result is not None:
+            self._resolve_result_future(result_future, result)
+
+    @staticmethod
+    def _resolve_result_future(
+        result_future: "Future[FileProcessingResult]",
+        result: FileProcessingResult,
+    ) -> None:
+        """Set result unless the future was cancelled during shutdown."""
+        try:
+            if not result_future.done():
+                result_future.set_result(result)
+        except InvalidStateError:
+            # Cancelled concurrently by __exit__ - nothing is waiting for it
+            pass
+
+    def _embed_heading_paths(
+        self, chunks: List[Dict[str, Any]]
+    ) -> List[Dict[str, Any]]:
+        """Prefix the embedded text of documentation chunks with their breadcrumb."""
+        if not any(chunk.get(HEADING_PATH_KEY) for chunk in chunks):
+            return chunks
+        # Heading paths come from the document chunker, imported by now
+        from ..indexing.document_chunker import breadcrumb
+
+        return [
+            (
+                {
+                    **chunk,
+                    EMBEDDING_TEXT_KEY: f"{breadcrumb(chunk[HEADING_PATH_KEY])}\n\n"
+                    f"{chunk.get(EMBEDDING_TEXT_KEY, chunk['text'])}",
+                }
+                if chunk.get(HEADING_PATH_KEY)
+                else chunk
+            )
+            for chunk in chunks
+        ]
+
+    def _apply_pre_embed_hooks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """Set EMBEDDING_TEXT_KEY where pre_embed hooks change a chunk's text."""
+        assert self.lifecycle_hooks is not None
+        if not self.lifecycle_hooks.has("pre_embed"):
+            return chunks
+        texts = [chunk.get(EMBEDDING_TEXT_KEY, chunk["text"]) for chunk in chunks]
+        changed = self.lifecycle_hooks.embedding_texts(
+            texts, self._normalize_path_for_storage(file_path)
+        )
+        return [
+            {**chunk, EMBEDDING_TEXT_KEY: new} if new != old else chunk
+            for chunk, old, new in zip(chunks, texts, changed)
+        ]
+
+    def _stored_embeddings(
+        self, file_path: Path, metadata: Dict[str, Any]
+    ) -> Dict[str, List[float]]:
+        """Stored vectors of a file's indexed chunks by EMBEDDING_HASH_KEY."""
+        get_vectors = getattr(self.vector_store_client, "get_vectors_for_paths", None)
+        collection_name = metadata.get("collection_name")
+        if get_vectors is None or not collection_name:
+            return {}
+        try:
+            points = get_vectors(
+                collection_name, [self._normalize_path_for_storage(file_path)]
+            )
+        except Exception as e:
+            logger.debug(f"No stored vectors to reuse for {file_path}: {e}")
+            return {}
+        return {
+            point["payload"][EMBEDDING_HASH_KEY]: point["vector"]
+            for point in points
+            if point.get("payload", {}).get(EMBEDDING_HASH_KEY) and point.get("vector")
+        }
+
     def _create_vector_point(
         self,
         chunk: Dict[str, Any],
@@ -353,6 +811,16 @@ class FileChunkingManager:
             indexed_timestamp=indexed_timestamp,
         )
 
+        # Provenance for 'cidx verify --integrity'
+        payload["chunk_hash"] = compute_chunk_hash(chunk["text"])
+        payload["indexer_version"] = __version__
+
+        # Per-chunk annotations (PII flag, task comments, license, owners,
+        # hook metadata)
+        for key in CHUNK_PAYLOAD_KEYS:
+            if chunk.get(key):
+                payload[key] = chunk[key]
+
         # Add filesystem metadata for non-git projects
         if not metadata.get("git_available", False) and metadata_info:
             if "file_mtime" in metadata_info:
@@ -362,30 +830,238 @@ class FileChunkingManager:
 
         # UNIVERSAL TIMESTAMP COLLECTION: Timestamp fields are now handled by GitAwareMetadataSchema
 
-        # Create point ID using hash of file and chunk (ensuring uniqueness)
-        point_id_data = (
-            f"{metadata['project_id']}_{metadata['file_hash']}_{chunk['chunk_index']}"
+        # Deterministic point ID: identical content always gets the same ID
+        point_id, unique_key = compute_chunk_point_id(
+            metadata["project_id"],
+            payload["path"],
+            chunk["chunk_index"],
+            payload["chunk_hash"],
         )
-        point_id = hashlib.md5(point_id_data.encode()).hexdigest()
+        if chunk.get(VECTOR_KIND_KEY) == SIGNATURE_VECTOR:
+            # The signature point of a chunk is keyed off its body point
+            payload[BODY_POINT_KEY] = point_id
+            point_id, unique_key = compute_chunk_point_id(
+                metadata["project_id"],
+                payload["path"],
+                chunk["chunk_index"],
+                f"{payload['chunk_hash']}:{SIGNATURE_VECTOR}",
+            )
 
         payload["point_id"] = point_id
-        payload["unique_key"] = point_id_data
+        payload["unique_key"] = unique_key
 
         # Create vector point
         vector_point = {"id": point_id, "vector": embedding, "payload": payload}
 
         return vector_point
 
+    def _write_file_points(
+        self,
+        file_path: Path,
+        metadata: Dict[str, Any],
+        file_points: List[Dict[str, Any]],
+    ) -> int:
+        """
+        Write the points of one file, then of each identical duplicate file.
+
+        Duplicates (metadata["duplicate_paths"]) reuse the chunks and
+        embeddings of file_path, so their content is embedded only once.
+
+        Returns:
+            Number of points written across all paths
+
+        Raises:
+            RuntimeError: If the vector store rejects a write
+        """
+        written = 0
+        duplicates = [Path(p) for p in metadata.get(DUPLICATE_PATHS_KEY, [])]
+        for path in [file_path, *duplicates]:
+            overrides: Dict[str, Any] = {}
+            if path is not file_path and "file_mtime" in metadata:
+                # Non-git staleness checks compare each path's own mtime
+                overrides["file_mtime"] = path.stat().st_mtime
+            self._write_points_for_path(path, metadata, file_points, overrides)
+            written += len(file_points)
+        return written
+
+    def _write_points_for_path(
+        self,
+        file_path: Path,
+        metadata: Dict[str, Any],
+        file_points: List[Dict[str, Any]],
+        metadata_overrides: Optional[Dict[str, Any]] = None,
+    ) -> None:
+        """
+        Atomically write all points of one file to vector storage, then FTS.
+
+        Raises:
+            RuntimeError: If the vector store rejects the write
+        """
+        # The first chunk holds the file head (shebang, modelines)
+        head = file_points[0]["text"] if file_points else None
+        language = detect_language(file_path, head) or "txt"
+
+        # Signature points follow the body point of their chunk
+        total_chunks = sum(1 for p in file_points if VECTOR_KIND_KEY not in p)
+        chunk_index = -1
+        points_data = []
+        for point in file_points:
+            if VECTOR_KIND_KEY not in point:
+                chunk_index += 1
+            # Create proper Filesystem point using existing method
+            chunk_data = {
+                "text": point["text"],
+                "chunk_index": chunk_index,
+                "total_chunks": total_chunks,
+                "line_start": point["metadata"].get("line_start"),
+                "line_end": point["metadata"].get("line_end"),
+                # Code embedded in a page or document is stored as its own
+                # language
+                "file_extension": point.get(EMBEDDED_LANGUAGE_KEY) or language,
+                **{key: point[key] for key in CHUNK_PAYLOAD_KEYS if key in point},
+            }
+
+            # Use the existing _create_vector_point method to ensure proper formatting
+            vector_point = self._create_vector_point(
+                chunk_data,
+                point["vector"],
+                {**point["metadata"], **(metadata_overrides or {})},
+                file_path,
+            )
+            points_data.append(vector_point)
+
+        # Atomic write to vector storage
+        success = self.vector_store_client.upsert_points(
+            points=points_data,
+            collection_name=metadata.get("collection_name"),
+        )
+        if not success:
+            raise RuntimeError(
+                f"Failed to write {len(points_data)} points to vector storage"
+            )
+
+        logger.debug(f"Successfully wrote {len(points_data)} points for {file_path}")
+
+        # Add FTS documents if FTS manager is available
+        if self.fts_manager:
+            for i, point in enumerate(file_points):
+                if VECTOR_KIND_KEY in point:
+                    continue
+                try:
+                    # Extract identifiers from chunk text (simple whitespace split)
+                    chunk_text = point.get("text", "")
+                    identifiers = chunk_text.split()
+
+                    # Create FTS document
+                    fts_doc = {
+                        "path": file_path.relative_to(self.codebase_dir).as_posix(),
+                        "content": chunk_text,
+                        "content_raw": chunk_text,
+                        "identifiers": identifiers,
+                        "line_start": point["metadata"].get("line_start", 0),
+                        "line_end": point["metadata"].get("line_end", 0),
+                        "language": point.get(EMBEDDED_LANGUAGE_KEY) or language,
+                    }
+
+                    # Add to FTS index
+                    self.fts_manager.add_document(fts_doc)
+                except Exception as e:
+                    # Log FTS errors but don't fail semantic indexing
+                    logger.warning(
+                        f"FTS indexing failed for chunk {i} of {file_path}: {e}"
+                    )
+                    # Continue with next chunk
+
+    def _make_upsert_job(
+        self,
+        file_path: Path,
+        metadata: Dict[str, Any],
+        file_points: List[Dict[str, Any]],
+        progress_callback: Optional[Callable],
+        start_time: float,
+        result_future: "Future[FileProcessingResult]",
+        slot_tracker: CleanSlotTracker,
+        slot_id: int,
+    ) -> Callable[[], None]:
+        """
+        Build the write job executed on the upsert stage's writer thread.
+
+        The job owns the file's slot: it marks the slot complete once the
+        write succeeded and releases it afterwards, so a queued file is never
+        reported complete before it is stored.
+
+        With a memory budget the points either hold a reservation (released
+        after the write) or are spilled to disk now and read back by the job.
+        """
+        spill_path: Optional[Path] = None
+        reserved_bytes = 0
+        if self.memory_budget is not None:
+            points_bytes = estimate_points_bytes(file_points)
+            if self.memory_budget.try_reserve(points_bytes):
+                reserved_bytes = points_bytes
+            else:
+                spill_path = self.memory_budget.spill(file_points)
+                file_points = []
+                logger.debug(f"Spilled {points_bytes} bytes of points for {file_path}")
+
+        def upsert_job() -> None:
+            points = file_points
+            try:
+                if spill_path is not None and self.memory_budget is not None:
+                    points = self.memory_budget.load_spilled(spill_path)
+                points_written = self._write_file_points(file_path, metadata, points)
+                slot_tracker.update_slot(slot_id, FileStatus.COMPLETE)
+                result = FileProcessingResult(
+                    success=True,
+                    file_path=file_path,
+                    chunks_processed=points_written,
+                    processing_time=time.time() - start_time,
+                    error=None,
+                )
+            except Exception as e:
+                logger.error(f"Vector storage write failed for {file_path}: {e}")
+                result = FileProcessingResult(
+                    success=False,
+                    file_path=file_path,
+                    chunks_processed=0,
+                    processing_time=time.time() - start_time,
+                    error=f"Vector storage write failed: {e}",
+                )
+            finally:
+                if reserved_bytes and self.memory_budget is not None:
+                    self.memory_budget.release(reserved_bytes)
+
+            # PROGRESS REPORTING ADJUSTMENT: File completion callback (after write)
+            if progress_callback:
+                try:
+                    progress_callback(
+                        -1,  # Signal: display update only, no progress bar change
+                        -1,  # Signal: display update only
+                        file_path,
+                        concurrent_files=self.slot_tracker.get_concurrent_files_data(),
+                    )
+                except Exception as e:
+                    logger.warning(f"Progress callback failed for {file_path}: {e}")
+
+            slot_tracker.release_slot(slot_id)
+            self._resolve_result_future(result_future, result)
+
+        return upsert_job
+
     def _process_file_clean_lifecycle(
         self,
         file_path: Path,
         metadata: Dict[str, Any],
         progress_callback: Optional[Callable],
         slot_tracker: CleanSlotTracker,
-    ) -> FileProcessingResult:
+        result_future: Optional["Future[FileProcessingResult]"] = None,
+    ) -> Optional[FileProcessingResult]:
         """
         CLEAN IMPLEMENTATION: Process file with proper resource management.
 
+        Returns None only when the write phase was handed off to the pipelined
+        upsert stage, which then resolves result_future itself.
+
         DESIGN PRINCIPLES:
         1. Single acquire at start
         2. All work in try block
@@ -408,6 +1084,8 @@ class FileChunkingManager:
 
         # Single acquire using CleanSlotTracker
         slot_id = slot_tracker.acquire_slot(file_data)
+        # Set once the pipelined write job owns (and will release) the slot
+        slot_handed_off = False
 
         # PROGRESS REPORTING ADJUSTMENT: Remove initial callback
         # HighThroughputProcessor handles file-level progress counting
@@ -420,6 +1098,10 @@ class FileChunkingManager:
             # Phase 1: Chunk the file
             logger.debug(f"Starting chunking for {file_path}")
             chunks = self.chunker.chunk_file(file_path)
+            if chunks and self.lifecycle_hooks is not None:
+                chunks = self.lifecycle_hooks.process_chunks(
+                    chunks, self._normalize_path_for_storage(file_path)
+                )
 
             if not chunks:
                 # Empty files are valid but don't need indexing
@@ -449,6 +1131,54 @@ class FileChunkingManager:
 
             logger.debug(f"Generated {len(chunks)} chunks for {file_path}")
 
+            if self.pii_scrubber is not None:
+                chunks = self.pii_scrubber.scrub_chunks(chunks, file_path)
+            if self.task_marker_extractor is not None:
+                chunks = self.task_marker_extractor.annotate_chunks(chunks, file_path)
+            if self.license_detector is not None:
+                chunks = self.license_detector.classify_chunks(chunks, file_path)
+            if self.code_owners is not None:
+                chunks = self.code_owners.classify_chunks(chunks, file_path)
+            if self.struct_tag_extractor is not None:
+                chunks = self.struct_tag_extractor.annotate_chunks(chunks, file_path)
+            if self.go_interface_index is not None:
+                chunks = self.go_interface_index.annotate_chunks(chunks, file_path)
+            if self.go_build_constraints is not None:
+                chunks = self.go_build_constraints.classify_chunks(chunks, file_path)
+            if self.go_dependency_graph is not None:
+                chunks = self.go_dependency_graph.annotate_chunks(chunks, file_path)
+            if self.import_graph is not None:
+                chunks = self.import_graph.annotate_chunks(chunks, file_path)
+            if self.subject_linker is not None:
+                chunks = self.subject_linker.annotate_chunks(chunks, file_path)
+            if self.generated_code_detector is not None:
+                chunks = self.generated_code_detector.annotate_chunks(
+                    chunks, file_path
+                )
+            if self.grpc_stub_index is not None:
+                chunks = self.grpc_stub_index.annotate_chunks(chunks, file_path)
+            if self.copybook_index is not None:
+                chunks = self.copybook_index.annotate_chunks(chunks, file_path)
+            if self.code_metrics is not None:
+                chunks = self.code_metrics.annotate_chunks(chunks, file_path)
+            if self.symbol_declarations is not None:
+                chunks = self.symbol_declarations.annotate_chunks(chunks, file_path)
+            if self.boilerplate_filter is not None:
+                chunks = self.boilerplate_filter.filter_chunks(chunks)
+            if self.doc_comment_pairer is not None:
+                chunks = self.doc_comment_pairer.annotate_chunks(chunks, file_path)
+            if self.chunk_context is not None:
+                chunks = self.chunk_context.enrich_chunks(chunks, file_path)
+            if self.type_parameter_extractor is not None:
+                chunks = self.type_parameter_extractor.annotate_chunks(
+                    chunks, file_path
+                )
+            chunks = self._embed_heading_paths(chunks)
+            if self.lifecycle_hooks is not None:
+                chunks = self._apply_pre_embed_hooks(chunks, file_path)
+            if self.signature_vectors is not None:
+                chunks = self.signature_vectors.add_signature_chunks(chunks)
+
             # Update status after chunking
             slot_tracker.update_slot(slot_id, FileStatus.VECTORIZING)
 
@@ -461,16 +1191,42 @@ class FileChunkingManager:
             model_limit = (
                 self.vector_manager.embedding_provider._get_model_token_limit()  # type: ignore[attr-defined]
             )
-            TOKEN_LIMIT = int(
-                model_limit * 0.9
-            )  # Apply same 90% safety margin as VoyageAI client
+            # Apply same 90% safety margin as VoyageAI client (auto-tune may lower it)
+            batch_token_fraction = getattr(
+                self.vector_manager, "batch_token_fraction", 0.9
+            )
+            if not isinstance(batch_token_fraction, float):
+                batch_token_fraction = 0.9
+            TOKEN_LIMIT = int(model_limit * batch_token_fraction)
 
             current_batch: List[str] = []
             current_tokens = 0
             batch_futures = []
 
-            for chunk in chunks:
-                chunk_text = chunk["text"]
+            # Stored text stays complete; boilerplate is only left out of
+            # the embedding
+            chunks = [
+                {
+                    **chunk,
+                    EMBEDDING_HASH_KEY: compute_chunk_hash(
+                        chunk.get(EMBEDDING_TEXT_KEY, chunk["text"])
+                    ),
+                }
+                for chunk in chunks
+            ]
+            # Chunks whose embedded text is unchanged keep their stored vector
+            stored_embeddings = (
+                self._stored_embeddings(file_path, metadata)
+                if self.reuse_embeddings
+                else {}
+            )
+            reused_embeddings: Dict[int, List[float]] = {}
+
+            for i, chunk in enumerate(chunks):
+                if chunk[EMBEDDING_HASH_KEY] in stored_embeddings:
+                    reused_embeddings[i] = stored_embeddings[chunk[EMBEDDING_HASH_KEY]]
+                    continue
+                chunk_text = chunk.get(EMBEDDING_TEXT_KEY, chunk["text"])
                 chunk_tokens = self._count_tokens(chunk_text)
 
                 # If this chunk would exceed limit, submit current batch
@@ -527,7 +1283,7 @@ class FileChunkingManager:
                         )
                     raise
 
-            if not batch_futures:
+            if not batch_futures and not reused_embeddings:
                 logger.warning(f"No batches created for {file_path}")
                 return FileProcessingResult(
                     success=False,
@@ -537,7 +1293,10 @@ class FileChunkingManager:
                     error="No batches created",
                 )
 
-            logger.debug(f"Submitted {len(batch_futures)} batches for {file_path}")
+            logger.debug(
+                f"Submitted {len(batch_futures)} batches for {file_path}, "
+                f"reusing {len(reused_embeddings)} stored vectors"
+            )
 
             slot_tracker.update_slot(slot_id, FileStatus.FINALIZING)
 
@@ -581,9 +1340,10 @@ class FileChunkingManager:
                         )
 
                 # CRITICAL: Validate total embedding count matches chunks
-                if len(all_embeddings) != len(chunks):
+                embedded_count = len(chunks) - len(reused_embeddings)
+                if len(all_embeddings) != embedded_count:
                     logger.error(
-                        f"Total embedding count mismatch: {len(all_embeddings)} embeddings for {len(chunks)} chunks in {file_path}"
+                        f"Total embedding count mismatch: {len(all_embeddings)} embeddings for {embedded_count} chunks in {file_path}"
                     )
                     return FileProcessingResult(
                         success=False,
@@ -593,8 +1353,15 @@ class FileChunkingManager:
                         error="Total embedding count mismatch",
                     )
 
+                # Reused vectors take the places of the chunks not embedded
+                new_embeddings = iter(all_embeddings)
+                embeddings = [
+                    reused_embeddings.get(i) or next(new_embeddings)
+                    for i in range(len(chunks))
+                ]
+
                 # Create points with preserved order: chunks[i] → embeddings[i] → points[i]
-                for i, (chunk, embedding) in enumerate(zip(chunks, all_embeddings)):
+                for i, (chunk, embedding) in enumerate(zip(chunks, embeddings)):
                     if embedding:  # Validate individual embedding
                         file_points.append(
                             {
@@ -605,6 +1372,11 @@ class FileChunkingManager:
                                     "line_start": chunk["line_start"],
                                     "line_end": chunk["line_end"],
                                 },
+                                **{
+                                    key: chunk[key]
+                                    for key in CHUNK_PAYLOAD_KEYS
+                                    if key in chunk
+                                },
                             }
                         )
                     else:
@@ -623,72 +1395,36 @@ class FileChunkingManager:
                 )
 
             # Phase 4: Atomic write to vector storage if we have valid vectors
+            upsert_stage = self._upsert_stage
+            if file_points and result_future is not None and upsert_stage is not None:
+                # PIPELINED: Hand off to upsert stage (blocks while queue is full)
+                # and free this worker for the next file's chunk → vector phases.
+                # The slot stays FINALIZING until the write job completes it.
+                upsert_job = self._make_upsert_job(
+                    file_path,
+                    metadata,
+                    file_points,
+                    progress_callback,
+                    start_time,
+                    result_future,
+                    slot_tracker,
+                    slot_id,
+                )
+                slot_handed_off = True
+                try:
+                    upsert_stage.submit(upsert_job)
+                except RuntimeError:
+                    # The stage closed while this file was embedded: write it
+                    # here so its budget, slot and result future are settled
+                    upsert_job()
+                return None
+
+            points_written = 0
             if file_points:
                 try:
-                    points_data = []
-                    for i, point in enumerate(file_points):
-                        # Create proper Filesystem point using existing method
-                        chunk_data = {
-                            "text": point["text"],
-                            "chunk_index": i,
-                            "total_chunks": len(file_points),
-                            "line_start": point["metadata"].get("line_start"),
-                            "line_end": point["metadata"].get("line_end"),
-                            "file_extension": file_path.suffix.lstrip(".") or "txt",
-                        }
-
-                        # Use the existing _create_vector_point method to ensure proper formatting
-                        vector_point = self._create_vector_point(
-                            chunk_data, point["vector"], point["metadata"], file_path
-                        )
-                        points_data.append(vector_point)
-
-                    # Atomic write to vector storage
-                    success = self.vector_store_client.upsert_points(
-                        points=points_data,
-                        collection_name=metadata.get("collection_name"),
+                    points_written = self._write_file_points(
+                        file_path, metadata, file_points
                     )
-                    if not success:
-                        raise RuntimeError(
-                            f"Failed to write {len(points_data)} points to vector storage"
-                        )
-
-                    logger.debug(
-                        f"Successfully wrote {len(points_data)} points for {file_path}"
-                    )
-
-                    # Add FTS documents if FTS manager is available
-                    if self.fts_manager:
-                        for i, point in enumerate(file_points):
-                            try:
-                                # Extract identifiers from chunk text (simple whitespace split)
-                                chunk_text = point.get("text", "")
-                                identifiers = chunk_text.split()
-
-                                # Create FTS document
-                                fts_doc = {
-                                    "path": str(
-                                        file_path.relative_to(self.codebase_dir)
-                                    ),
-                                    "content": chunk_text,
-                                    "content_raw": chunk_text,
-                                    "identifiers": identifiers,
-                                    "line_start": point["metadata"].get(
-                                        "line_start", 0
-                                    ),
-                                    "line_end": point["metadata"].get("line_end", 0),
-                                    "language": file_path.suffix.lstrip(".") or "txt",
-                                }
-
-                                # Add to FTS index
-                                self.fts_manager.add_document(fts_doc)
-                            except Exception as e:
-                                # Log FTS errors but don't fail semantic indexing
-                                logger.warning(
-                                    f"FTS indexing failed for chunk {i} of {file_path}: {e}"
-                                )
-                                # Continue with next chunk
-
                 except Exception as e:
                     logger.error(f"Vector storage write failed for {file_path}: {e}")
                     return FileProcessingResult(
@@ -721,7 +1457,7 @@ class FileChunkingManager:
             return FileProcessingResult(
                 success=True,
                 file_path=file_path,
-                chunks_processed=len(file_points),
+                chunks_processed=points_written,
                 processing_time=processing_time,
                 error=None,
             )
@@ -756,5 +1492,9 @@ class FileChunkingManager:
 
         finally:
             # SINGLE release - guaranteed (CLAUDE.md Foundation #8 compliance)
-            if slot_id is not None:
+            if slot_id is not None and not slot_handed_off:
                 slot_tracker.release_slot(slot_id)
+
+            # THROTTLE: Yield CPU between files for low-priority indexing
+            if self.file_delay_seconds > 0 and not self._cancellation_requested:
+                time.sleep(self.file_delay_seconds)
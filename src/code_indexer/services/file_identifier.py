@@ -7,13 +7,13 @@ metadata when available, while gracefully falling back to filesystem-based
 identification when git is not available.
 """
 
-import hashlib
 import subprocess
 from datetime import datetime, timezone
 from pathlib import Path
 from typing import Dict, Any, Optional
 
 from ..config import Config
+from ..utils.file_hashing import hash_file, resolve_hash_algorithm
 from ..utils.git_runner import run_git_command, is_git_repository
 
 
@@ -38,6 +38,9 @@ class FileIdentifier:
         self.config = config
         self.git_available = self._detect_git()
         self._project_id: Optional[str] = None
+        self.hash_algorithm = resolve_hash_algorithm(
+            getattr(getattr(config, "indexing", None), "hash_algorithm", None)
+        )
 
     def _detect_git(self) -> bool:
         """
@@ -86,23 +89,20 @@ class FileIdentifier:
 
     def _get_file_content_hash(self, file_path: Path) -> str:
         """
-        Generate SHA256 hash of file content.
+        Generate a content hash for change detection.
 
         Args:
             file_path: Path to the file
 
         Returns:
-            SHA256 hash of file content with 'sha256:' prefix
+            Hash of file content with algorithm prefix (e.g. 'sha256:', 'xxh3:'),
+            per indexing.hash_algorithm
         """
-        hasher = hashlib.sha256()
         try:
-            with open(file_path, "rb") as f:
-                for chunk in iter(lambda: f.read(8192), b""):
-                    hasher.update(chunk)
-            return f"sha256:{hasher.hexdigest()}"
+            return hash_file(file_path, self.hash_algorithm)
         except (IOError, OSError):
             # Fallback hash for unreadable files
-            return f"sha256:error-{abs(hash(str(file_path)))}"
+            return f"{self.hash_algorithm}:error-{abs(hash(str(file_path)))}"
 
     def _should_index_file(self, file_path: str) -> bool:
         """
@@ -57,7 +57,7 @@ class FTSWatchHandler(FileSystemEventHandler):
             identifiers = self._extract_identifiers(content, file_path)
 
             # Detect language
-            language = self._detect_language(file_path)
+            language = self._detect_language(file_path, content)
 
             # Create FTS document
             doc = {
@@ -142,9 +142,10 @@ class FTSWatchHandler(FileSystemEventHandler):
     def _should_include_deleted_file(self, file_path: Path) -> bool:
         """Check if a deleted file would have been included in indexing."""
         try:
-            # For deleted files, just check the file extension
-            extension = file_path.suffix.lstrip(".")
-            return extension in self.config.file_extensions
+            from ..indexing.language_detection import detect_language
+
+            # Deleted files cannot be read, so only their name is checked
+            return detect_language(file_path) in self.config.file_extensions
         except Exception as e:
             logger.warning(f"Failed to check if deleted file should be included: {e}")
             return False
@@ -165,9 +166,11 @@ class FTSWatchHandler(FileSystemEventHandler):
         # Limit to reasonable number to avoid bloat
         return identifiers[:1000]
 
-    def _detect_language(self, file_path: Path) -> str:
-        """Detect programming language from file extension."""
-        extension = file_path.suffix.lstrip(".").lower()
+    def _detect_language(self, file_path: Path, content: str = "") -> str:
+        """Detect programming language from file name and content."""
+        from ..indexing.language_detection import detect_language
+
+        extension = detect_language(file_path, content).lower()
 
         # Map extensions to language names
         language_map = {
@@ -186,8 +189,23 @@ class FTSWatchHandler(FileSystemEventHandler):
             "swift": "swift",
             "kt": "kotlin",
             "scala": "scala",
+            "dart": "dart",
+            "m": "objective-c",
+            "mm": "objective-cpp",
+            "jl": "julia",
+            "r": "r",
+            "cbl": "cobol",
+            "cob": "cobol",
+            "cpy": "cobol",
+            "v": "verilog",
+            "vh": "verilog",
+            "sv": "systemverilog",
+            "svh": "systemverilog",
+            "vhd": "vhdl",
+            "vhdl": "vhdl",
             "sh": "shell",
             "bash": "shell",
+            "zsh": "shell",
             "sql": "sql",
             "md": "markdown",
             "txt": "text",
@@ -196,7 +214,25 @@ class FTSWatchHandler(FileSystemEventHandler):
             "json": "json",
             "xml": "xml",
             "html": "html",
+            "erb": "erb",
+            "j2": "jinja",
+            "jinja": "jinja",
+            "jinja2": "jinja",
             "css": "css",
+            "makefile": "makefile",
+            "dockerfile": "dockerfile",
+            "cmake": "cmake",
+            "pl": "perl",
+            "lua": "lua",
+            "groovy": "groovy",
+            "zig": "zig",
+            "ex": "elixir",
+            "exs": "elixir",
+            "erl": "erlang",
+            "hrl": "erlang",
+            "proto": "protobuf",
+            "vue": "vue",
+            "svelte": "svelte",
         }
 
         return language_map.get(extension, "unknown")
@@ -0,0 +1,132 @@
+"""
+Detection of generated files.
+
+Protobuf stubs, ORM models, minified bundles and other generated files tend
+to crowd the hand-written code they were generated from out of search
+results. A file is considered generated when:
+
+- its header (the first lines) carries a generator marker: Go's
+  "Code generated ... DO NOT EDIT.", "@generated", "Generated by the
+  protocol buffer compiler", a protoc-gen-* plugin name, C#'s
+  <auto-generated> or "This file was automatically generated"
+- its name follows a generator convention: .pb.go, _pb2.py, _pb2_grpc.py,
+  .pb.h/.pb.cc, _pb.js, .g.dart, .freezed.dart, .designer.cs, a
+  _generated or .generated suffix before the extension, zz_generated.*
+- it is minified JavaScript or CSS: a .min.js/.min.css name, or lines
+  averaging more than MINIFIED_LINE_LENGTH characters
+
+Every chunk of a generated file records "generated": true. Local semantic
+search leaves such chunks out unless ``cidx query --include-generated`` is
+given.
+"""
+
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+# Chunk and payload key
+GENERATED_KEY = "generated"
+
+# Lines of the file header searched for generator markers
+HEADER_LINES = 40
+
+# Average line length above which JavaScript or CSS counts as minified
+MINIFIED_LINE_LENGTH = 200
+
+# Files shorter than this are never considered minified
+MINIFIED_MIN_LENGTH = 1000
+
+MINIFIABLE_EXTENSIONS = {".js", ".mjs", ".cjs", ".css"}
+
+_MARKERS = re.compile(
+    r"Code generated .*DO NOT EDIT"
+    r"|@generated\b"
+    r"|Generated by the protocol buffer compiler"
+    r"|\bprotoc-gen-[\w-]+"
+    r"|<auto-generated"
+    r"|This (?:file|code) (?:is|was) (?:auto-?generated|automatically generated)",
+    re.IGNORECASE,
+)
+
+_NAME_SUFFIXES = (
+    ".pb.go",
+    ".pb.gw.go",
+    "_pb2.py",
+    "_pb2.pyi",
+    "_pb2_grpc.py",
+    ".pb.h",
+    ".pb.cc",
+    "_pb.js",
+    "_pb.d.ts",
+    ".g.dart",
+    ".freezed.dart",
+    ".designer.cs",
+    ".min.js",
+    ".min.mjs",
+    ".min.css",
+)
+
+# foo_generated.go, schema.generated.ts, zz_generated.deepcopy.go
+_GENERATED_NAME = re.compile(r"[._]generated\.[^.]+$|^zz_generated\.", re.IGNORECASE)
+
+
+def has_generated_name(file_name: str) -> bool:
+    """Whether a file name follows the convention of a code generator."""
+    name = file_name.lower()
+    return name.endswith(_NAME_SUFFIXES) or bool(_GENERATED_NAME.search(name))
+
+
+def has_generated_marker(head: str) -> bool:
+    """Whether the header of a file carries a generator marker."""
+    header = "\n".join(head.split("\n", HEADER_LINES)[:HEADER_LINES])
+    return bool(_MARKERS.search(header))
+
+
+def is_minified(text: str) -> bool:
+    """Whether text reads as minified code: few, very long lines."""
+    if len(text) < MINIFIED_MIN_LENGTH:
+        return False
+    lines = [line for line in text.split("\n") if line.strip()]
+    return bool(lines) and len(text) / len(lines) > MINIFIED_LINE_LENGTH
+
+
+def is_generated(file_path: Path, text: str) -> bool:
+    """
+    Whether a file is generated.
+
+    Args:
+        file_path: File path; only its name is looked at
+        text: File text, or at least its beginning
+
+    Returns:
+        True for files with a generated name, a generator marker in their
+        header, or minified JavaScript or CSS
+    """
+    path = Path(file_path)
+    if has_generated_name(path.name) or has_generated_marker(text):
+        return True
+    return path.suffix.lower() in MINIFIABLE_EXTENSIONS and is_minified(text)
+
+
+class GeneratedCodeDetector:
+    """Marks the chunks of generated files."""
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["GeneratedCodeDetector"]:
+        """Detector from indexing.generated_code, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        detection_config = getattr(indexing_config, "generated_code", None)
+        if getattr(detection_config, "enabled", False) is not True:
+            return None
+        return cls()
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks of generated files with GENERATED_KEY set."""
+        if not chunks:
+            return chunks
+        text = "".join(chunk["text"] for chunk in chunks)
+        if not is_generated(file_path, text):
+            return chunks
+        return [{**chunk, GENERATED_KEY: True} for chunk in chunks]
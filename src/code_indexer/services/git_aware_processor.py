@@ -9,6 +9,8 @@ from typing import List, Dict, Any
 from code_indexer.config import Config
 from code_indexer.services.embedding_provider import EmbeddingProvider
 from code_indexer.indexing.processor import DocumentProcessor
+from code_indexer.services.chunk_ids import compute_chunk_point_id
+from code_indexer.services.chunk_integrity import compute_chunk_hash
 from code_indexer.services.file_identifier import FileIdentifier
 from code_indexer.services.git_detection import GitDetectionService
 from code_indexer.services.metadata_schema import (
@@ -45,21 +47,21 @@ class GitAwareDocumentProcessor(DocumentProcessor):
             file_path: File path (absolute or relative)
 
         Returns:
-            Relative path string for database storage
+            Relative path string with forward slashes on every platform
 
         Raises:
             ValueError: If file_path is not under codebase_dir
         """
         if file_path.is_absolute():
             try:
-                return str(file_path.relative_to(self.config.codebase_dir))
+                return file_path.relative_to(self.config.codebase_dir).as_posix()
             except ValueError as e:
                 logger.error(
                     f"Cannot normalize path {file_path} - not under codebase_dir "
                     f"{self.config.codebase_dir}: {e}"
                 )
                 raise
-        return str(file_path)
+        return file_path.as_posix()
 
     def process_file(self, file_path: Path) -> List[Dict[str, Any]]:
         """Process a single file with git-aware metadata."""
@@ -174,16 +176,17 @@ class GitAwareDocumentProcessor(DocumentProcessor):
                             f"Warning: Metadata validation failed for {file_path}: {validation_result['errors']}"
                         )
 
-                    # Create unique point ID using our git-aware scheme
-                    point_id = self._create_point_id(
-                        file_metadata, chunk["chunk_index"]
+                    # Deterministic point ID: identical content gets the same ID
+                    point_id, unique_key = compute_chunk_point_id(
+                        file_metadata["project_id"],
+                        payload["path"],
+                        chunk["chunk_index"],
+                        compute_chunk_hash(chunk["text"]),
                     )
 
                     # Add the unique identifier to payload for tracking/deduplication
                     payload["point_id"] = point_id
-                    payload["unique_key"] = self._create_unique_key(
-                        file_metadata, chunk["chunk_index"]
-                    )
+                    payload["unique_key"] = unique_key
 
                     # Create Filesystem point with the calculated embedding
                     point = self.vector_store_client.create_point(
@@ -207,44 +210,6 @@ class GitAwareDocumentProcessor(DocumentProcessor):
         except Exception as e:
             raise ValueError(f"Failed to process file {file_path}: {e}")
 
-    def _create_point_id(self, file_metadata: Dict[str, Any], chunk_index: int) -> str:
-        """Create deterministic UUID-based point ID.
-
-        Uses a deterministic UUID based on the file signature and chunk index
-        to ensure the same file+chunk always gets the same ID.
-        """
-        import uuid
-
-        project_id = file_metadata["project_id"]
-
-        # Use git blob hash if available, otherwise file hash
-        if file_metadata["git_available"] and file_metadata.get("git_hash"):
-            signature = file_metadata["git_hash"]
-        else:
-            signature = file_metadata["file_hash"]
-
-        # Create deterministic seed for UUID
-        seed_string = f"{project_id}:{signature}:{chunk_index}"
-
-        # Generate deterministic UUID using namespace UUID and seed
-        point_uuid = uuid.uuid5(uuid.NAMESPACE_DNS, seed_string)
-
-        return str(point_uuid)
-
-    def _create_unique_key(
-        self, file_metadata: Dict[str, Any], chunk_index: int
-    ) -> str:
-        """Create human-readable unique key for tracking purposes."""
-        project_id = file_metadata["project_id"]
-
-        # Use git blob hash if available, otherwise file hash
-        if file_metadata["git_available"] and file_metadata.get("git_hash"):
-            signature = file_metadata["git_hash"][:12]  # Short hash
-        else:
-            signature = file_metadata["file_hash"][:12]  # Short hash
-
-        return f"{project_id}:{signature}:{chunk_index}"
-
     def _validate_embedding(self, embedding: List[float]) -> bool:
         """Validate embedding dimensions match collection configuration."""
         if not embedding:
@@ -203,10 +203,10 @@ class GitAwareWatchHandler(FileSystemEventHandler):
     def _should_include_deleted_file(self, file_path: Path) -> bool:
         """Check if a deleted file would have been included in indexing."""
         try:
-            # For deleted files, just check the file extension
-            # This is a simplified check since we can't access file contents or size
-            extension = file_path.suffix.lstrip(".")
-            return extension in self.config.file_extensions
+            from ..indexing.language_detection import detect_language
+
+            # Deleted files cannot be read, so only their name is checked
+            return detect_language(file_path) in self.config.file_extensions
         except Exception as e:
             logger.warning(f"Failed to check if deleted file should be included: {e}")
             return False
@@ -270,15 +270,16 @@ class GitAwareWatchHandler(FileSystemEventHandler):
                 relative_paths = []
                 for file_path in changes_to_process:
                     try:
-                        relative_path = str(
-                            file_path.relative_to(self.config.codebase_dir)
-                        )
+                        relative_path = file_path.relative_to(
+                            self.config.codebase_dir
+                        ).as_posix()
                         relative_paths.append(relative_path)
                     except ValueError:
                         # File outside codebase directory
                         continue
 
                 if relative_paths:
+                    batch_start = time.time()
                     # Use SmartIndexer for git-aware processing (same as index command)
                     stats = self.smart_indexer.process_files_incrementally(
                         relative_paths,
@@ -295,7 +296,15 @@ class GitAwareWatchHandler(FileSystemEventHandler):
                         f"📝 Processed {stats.files_processed} files: {', '.join(relative_paths)}"
                     )
                     logger.info(
-                        f"Processed {stats.files_processed} files in git-aware mode"
+                        f"Processed {stats.files_processed} files in git-aware mode",
+                        extra={
+                            "operation": "watch_batch",
+                            "files_changed": len(relative_paths),
+                            "files_processed": stats.files_processed,
+                            "files_failed": stats.failed_files,
+                            "chunks_created": stats.chunks_created,
+                            "duration_ms": round((time.time() - batch_start) * 1000),
+                        },
                     )
 
             # Update metadata after successful processing
@@ -320,6 +329,7 @@ class GitAwareWatchHandler(FileSystemEventHandler):
         new_branch = change_event["new_branch"]
 
         logger.info(f"Git branch change detected: {old_branch} → {new_branch}")
+        branch_change_start = time.time()
 
         try:
             # Stop current processing
@@ -376,7 +386,17 @@ class GitAwareWatchHandler(FileSystemEventHandler):
                             )
 
                 logger.info(
-                    f"Branch transition complete: {content_points_created} content points created, {content_points_reused} content points reused"
+                    f"Branch transition complete: {content_points_created} content points created, {content_points_reused} content points reused",
+                    extra={
+                        "operation": "branch_change",
+                        "old_branch": old_branch,
+                        "new_branch": new_branch,
+                        "chunks_created": content_points_created,
+                        "files_reused": content_points_reused,
+                        "duration_ms": round(
+                            (time.time() - branch_change_start) * 1000
+                        ),
+                    },
                 )
 
             # Clear pending changes as they might be from the old branch context
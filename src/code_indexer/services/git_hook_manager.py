@@ -74,14 +74,18 @@ if [ "$3" = "1" ]; then
     python3 -c "
 import sys
 import json
-import fcntl
 from pathlib import Path
+try:
+    import fcntl
+except ImportError:  # Windows: no flock, update without a lock
+    fcntl = None
 
 metadata_file = Path('{self.metadata_file}')
 if metadata_file.exists():
     try:
         with open(metadata_file, 'r+') as f:
-            fcntl.flock(f.fileno(), fcntl.LOCK_EX)
+            if fcntl is not None:
+                fcntl.flock(f.fileno(), fcntl.LOCK_EX)
             f.seek(0)
             try:
                 data = json.load(f)
@@ -0,0 +1,234 @@
+"""
+Git hooks keeping the index fresh without watch mode.
+
+'cidx hooks install' adds a section to the post-commit, post-checkout and
+post-merge hooks of the repository that starts 'cidx hooks run' in the
+background, so git returns immediately. Runs are throttled: a trigger while
+an update is running (or less than the minimum interval after the last one)
+is queued, and all queued triggers are served by a single incremental
+'cidx index' once the interval has passed. Rebases and pulls that fire many
+hooks in a row therefore cost one update, not one per commit.
+"""
+
+import logging
+import os
+import shlex
+import subprocess
+import time
+from pathlib import Path
+from typing import Callable, List, Optional
+
+from ..utils.file_lock import lock_file, unlock_file
+from .service_installer import cidx_command
+
+logger = logging.getLogger(__name__)
+
+HOOK_NAMES = ("post-commit", "post-checkout", "post-merge")
+
+# Minimum time between the end of one update and the start of the next
+DEFAULT_MIN_INTERVAL_SECONDS = 30
+
+_SECTION_BEGIN = "# >>> cidx auto-index"
+_SECTION_END = "# <<< cidx auto-index"
+
+
+class GitIndexHooks:
+    """Installs and runs the auto-index git hooks of a project."""
+
+    def __init__(self, project_root: Path):
+        """
+        Initialize for a project.
+
+        Args:
+            project_root: Root of the cidx project (may be below the git root)
+        """
+        self.project_root = Path(project_root)
+        state_dir = self.project_root / ".code-indexer"
+        self.lock_path = state_dir / "git-hooks.lock"
+        self.pending_path = state_dir / "git-hooks.pending"
+        self.last_run_path = state_dir / "git-hooks.last-run"
+        self.log_path = state_dir / "git-hooks.log"
+
+    def hooks_dir(self) -> Path:
+        """Hooks directory of the repository (honors core.hooksPath)."""
+        result = subprocess.run(
+            ["git", "rev-parse", "--git-path", "hooks"],
+            cwd=self.project_root,
+            capture_output=True,
+            text=True,
+        )
+        if result.returncode != 0:
+            raise ValueError(f"Not a git repository: {self.project_root}")
+        return (self.project_root / result.stdout.strip()).resolve()
+
+    def _markers(self) -> List[str]:
+        # Keyed by project: several cidx projects can share one repository
+        return [
+            f"{_SECTION_BEGIN} {self.project_root} >>>",
+            f"{_SECTION_END} {self.project_root} <<<",
+        ]
+
+    def _section(self, min_interval: int) -> str:
+        begin, end = self._markers()
+        command = " ".join(
+            shlex.quote(part)
+            for part in cidx_command()
+            + ["hooks", "run", "--min-interval", str(min_interval)]
+        )
+        return (
+            f"{begin}\n"
+            "# Installed by 'cidx hooks install': update the index in the background\n"
+            f"(cd {shlex.quote(str(self.project_root))} && {command})"
+            " >/dev/null 2>&1 </dev/null &\n"
+            f"{end}\n"
+        )
+
+    def _without_section(self, content: str) -> str:
+        begin, end = self._markers()
+        lines = content.splitlines(keepends=True)
+        kept, skipping = [], False
+        for line in lines:
+            if line.rstrip("\n") == begin:
+                skipping = True
+            elif skipping and line.rstrip("\n") == end:
+                skipping = False
+            elif not skipping:
+                kept.append(line)
+        return "".join(kept)
+
+    def install(self, min_interval: int = DEFAULT_MIN_INTERVAL_SECONDS) -> List[Path]:
+        """
+        Add the auto-index section to every hook, keeping existing hook code.
+
+        Reinstalling replaces the section, e.g. to change the interval.
+
+        Returns:
+            Hook files written
+
+        Raises:
+            ValueError: If the project is not in a git repository, or an
+                existing hook is not a shell script
+        """
+        hooks_dir = self.hooks_dir()
+        hooks_dir.mkdir(parents=True, exist_ok=True)
+        written = []
+        for name in HOOK_NAMES:
+            hook_file = hooks_dir / name
+            content = hook_file.read_text() if hook_file.exists() else ""
+            if not content.strip():
+                content = "#!/bin/sh\n"
+            shebang = content.splitlines()[0] if content.strip() else ""
+            if shebang.startswith("#!") and not shebang.rstrip().endswith("sh"):
+                raise ValueError(
+                    f"{hook_file} is not a shell script; add this line to it "
+                    f"manually:\n{self._section(min_interval).splitlines()[2]}"
+                )
+            content = self._without_section(content).rstrip("\n")
+            hook_file.write_text(f"{content}\n\n{self._section(min_interval)}")
+            hook_file.chmod(hook_file.stat().st_mode | 0o111)
+            written.append(hook_file)
+        return written
+
+    def uninstall(self) -> List[Path]:
+        """
+        Remove the auto-index section, deleting hooks left without code.
+
+        Returns:
+            Hook files changed or deleted
+        """
+        hooks_dir = self.hooks_dir()
+        changed = []
+        for name in HOOK_NAMES:
+            hook_file = hooks_dir / name
+            if not hook_file.exists():
+                continue
+            content = hook_file.read_text()
+            remaining = self._without_section(content)
+            if remaining == content:
+                continue
+            if remaining.strip() in ("", "#!/bin/sh", "#!/bin/bash"):
+                hook_file.unlink()
+            else:
+                hook_file.write_text(remaining.rstrip("\n") + "\n")
+            changed.append(hook_file)
+        return changed
+
+    def installed_hooks(self) -> List[str]:
+        """Names of the hooks containing the auto-index section."""
+        hooks_dir = self.hooks_dir()
+        begin = self._markers()[0]
+        return [
+            name
+            for name in HOOK_NAMES
+            if (hooks_dir / name).exists()
+            and begin in (hooks_dir / name).read_text().splitlines()
+        ]
+
+    def run(
+        self,
+        min_interval: int = DEFAULT_MIN_INTERVAL_SECONDS,
+        update: Optional[Callable[[], int]] = None,
+    ) -> bool:
+        """
+        Serve a hook trigger: update the index, or queue the trigger.
+
+        Args:
+            min_interval: Minimum seconds between the end of one update and
+                the start of the next
+            update: Runs one index update (default: 'cidx index --throttle low')
+
+        Returns:
+            True if this process ran the updates, False if the trigger was
+            queued for the process already running them
+        """
+        update = update or self._index
+        self.pending_path.parent.mkdir(parents=True, exist_ok=True)
+        self.pending_path.touch()
+        ran = False
+        # A trigger queued between the last check and unlocking is picked up
+        # by retrying once the lock is released
+        while self.pending_path.exists():
+            with open(self.lock_path, "a+") as f:
+                try:
+                    lock_file(f, blocking=False)
+                except BlockingIOError:
+                    return ran
+                try:
+                    self._serve_pending(min_interval, update)
+                    ran = True
+                finally:
+                    unlock_file(f)
+        return ran
+
+    def _serve_pending(self, min_interval: int, update: Callable[[], int]) -> None:
+        while self.pending_path.exists():
+            wait = self._last_run() + min_interval - time.time()
+            if wait > 0:
+                time.sleep(wait)
+            self.pending_path.unlink(missing_ok=True)
+            exit_code = update()
+            if exit_code != 0:
+                logger.warning(
+                    f"Index update from git hook failed with exit code {exit_code}"
+                )
+            self.last_run_path.write_text(str(time.time()))
+
+    def _last_run(self) -> float:
+        try:
+            return float(self.last_run_path.read_text())
+        except (OSError, ValueError):
+            return 0.0
+
+    def _index(self) -> int:
+        # Hooks export GIT_DIR, GIT_INDEX_FILE etc. for the hook's repository
+        # state; they would redirect the git commands of the indexer
+        env = {k: v for k, v in os.environ.items() if not k.startswith("GIT_")}
+        with open(self.log_path, "w") as log:
+            return subprocess.run(
+                cidx_command() + ["index", "--throttle", "low"],
+                cwd=self.project_root,
+                env=env,
+                stdout=log,
+                stderr=subprocess.STDOUT,
+                stdin=subprocess.DEVNULL,
+            ).returncode
@@ -0,0 +1,355 @@
+"""
+Build constraints of Go files.
+
+Go selects the files of a build by `//go:build` lines (or legacy `// +build`
+lines) and by _GOOS / _GOARCH file name suffixes, so the same repository
+holds code that never builds together. While a Go file is indexed, its
+constraint is recorded in the payload of every chunk of the file:
+
+- "build_constraint": the combined expression, e.g. "linux && (amd64 || arm64)"
+- "build_os": the GOOS values the file builds for, when it is OS-specific
+- "build_arch": the GOARCH values the file builds for, when it is
+  architecture-specific
+- "build_tags": the other tags the expression mentions, e.g. ["integration"]
+
+``cidx query --platform linux/amd64`` keeps unconstrained code and code that
+builds for that OS and architecture; ``--build-tag`` and
+``--exclude-build-tag`` select on the tags. OS and architecture are checked
+separately, so a file for linux/amd64 or darwin/arm64 also matches
+linux/arm64.
+"""
+
+import itertools
+import logging
+import re
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional, Set, Tuple, Union
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload keys holding the build constraint of a chunk's file
+BUILD_CONSTRAINT_KEY = "build_constraint"
+BUILD_OS_KEY = "build_os"
+BUILD_ARCH_KEY = "build_arch"
+BUILD_TAGS_KEY = "build_tags"
+
+# --platform value that turns off the configured default platform
+ANY_PLATFORM = "any"
+
+# GOOS and GOARCH values of go/build's syslist; file name suffixes use all
+KNOWN_OS = (
+    "aix android darwin dragonfly freebsd hurd illumos ios js linux nacl "
+    "netbsd openbsd plan9 solaris wasip1 windows zos"
+).split()
+KNOWN_ARCH = (
+    "386 amd64 amd64p32 arm armbe arm64 arm64be loong64 mips mipsle mips64 "
+    "mips64le mips64p32 mips64p32le ppc ppc64 ppc64le riscv riscv64 s390 "
+    "s390x sparc sparc64 wasm"
+).split()
+UNIX_OS = set(
+    "aix android darwin dragonfly freebsd hurd illumos ios linux netbsd "
+    "openbsd solaris".split()
+)
+# GOOS values that also satisfy another OS name
+_OS_ALIASES = {"android": "linux", "illumos": "solaris", "ios": "darwin"}
+
+# Tag variables enumerated when resolving OS and architecture
+MAX_FREE_TAGS = 10
+
+_TOKEN = re.compile(r"\s*(\(|\)|!|&&|\|\||[A-Za-z0-9_.]+)")
+
+# ("tag", name) | ("not", expr) | ("and", a, b) | ("or", a, b)
+Expr = Tuple[Any, ...]
+
+
+def parse_expression(text: str) -> Expr:
+    """
+    Parse a //go:build expression.
+
+    Raises:
+        ValueError: If the expression is malformed
+    """
+    tokens = []
+    position = 0
+    text = text.strip()
+    while position < len(text):
+        match = _TOKEN.match(text, position)
+        if match is None:
+            raise ValueError(f"Invalid build constraint: {text!r}")
+        tokens.append(match.group(1))
+        position = match.end()
+
+    def parse_or(i: int) -> Tuple[Expr, int]:
+        left, i = parse_and(i)
+        while i < len(tokens) and tokens[i] == "||":
+            right, i = parse_and(i + 1)
+            left = ("or", left, right)
+        return left, i
+
+    def parse_and(i: int) -> Tuple[Expr, int]:
+        left, i = parse_not(i)
+        while i < len(tokens) and tokens[i] == "&&":
+            right, i = parse_not(i + 1)
+            left = ("and", left, right)
+        return left, i
+
+    def parse_not(i: int) -> Tuple[Expr, int]:
+        if i >= len(tokens):
+            raise ValueError(f"Invalid build constraint: {text!r}")
+        if tokens[i] == "!":
+            inner, i = parse_not(i + 1)
+            return ("not", inner), i
+        if tokens[i] == "(":
+            inner, i = parse_or(i + 1)
+            if i >= len(tokens) or tokens[i] != ")":
+                raise ValueError(f"Invalid build constraint: {text!r}")
+            return inner, i + 1
+        if tokens[i] in (")", "&&", "||"):
+            raise ValueError(f"Invalid build constraint: {text!r}")
+        return ("tag", tokens[i]), i + 1
+
+    expr, end = parse_or(0)
+    if end != len(tokens):
+        raise ValueError(f"Invalid build constraint: {text!r}")
+    return expr
+
+
+def plus_build_expression(lines: List[str]) -> str:
+    """
+    //go:build form of legacy "// +build" lines.
+
+    Lines are ANDed; within a line, space-separated options are ORed and
+    comma-separated terms ANDed.
+    """
+    clauses = []
+    for line in lines:
+        options = [
+            " && ".join(option.split(","))
+            for option in line.split()
+            if option.strip(",")
+        ]
+        if len(options) > 1:
+            clauses.append("(" + " || ".join(options) + ")")
+        elif options:
+            clauses.append(options[0])
+    return " && ".join(clauses)
+
+
+def file_name_expression(file_name: str) -> str:
+    """Constraint implied by a _GOOS, _GOARCH or _GOOS_GOARCH suffix."""
+    name = file_name.split(".", 1)[0]
+    if "_" not in name:
+        return ""
+    parts = name[name.index("_") :].split("_")
+    if parts[-1] == "test":
+        parts = parts[:-1]
+    if len(parts) >= 2 and parts[-2] in KNOWN_OS and parts[-1] in KNOWN_ARCH:
+        return f"{parts[-2]} && {parts[-1]}"
+    if parts and (parts[-1] in KNOWN_OS or parts[-1] in KNOWN_ARCH):
+        return parts[-1]
+    return ""
+
+
+def header_expression(text: str) -> str:
+    """Build expression of the comment lines before a file's package clause."""
+    go_build = None
+    plus_build: List[str] = []
+    for line in text.split("\n"):
+        stripped = line.strip()
+        if stripped.startswith("//go:build"):
+            go_build = stripped[len("//go:build") :].strip()
+        elif stripped.startswith("// +build"):
+            plus_build.append(stripped[len("// +build") :])
+        elif stripped and not stripped.startswith(("//", "/*", "*")):
+            break  # package clause
+    if go_build is not None:
+        return go_build
+    return plus_build_expression(plus_build)
+
+
+def tag_names(expr: Expr) -> Set[str]:
+    if expr[0] == "tag":
+        return {expr[1]}
+    return set().union(*(tag_names(e) for e in expr[1:]))
+
+
+def evaluate(expr: Expr, is_set: Callable[[str], bool]) -> bool:
+    kind = expr[0]
+    if kind == "tag":
+        return is_set(expr[1])
+    if kind == "not":
+        return not evaluate(expr[1], is_set)
+    if kind == "and":
+        return evaluate(expr[1], is_set) and evaluate(expr[2], is_set)
+    return evaluate(expr[1], is_set) or evaluate(expr[2], is_set)
+
+
+def _os_matches(name: str, goos: str) -> bool:
+    return (
+        name == goos
+        or _OS_ALIASES.get(goos) == name
+        or (name == "unix" and goos in UNIX_OS)
+    )
+
+
+def platforms(expr: Expr) -> Tuple[List[str], List[str], List[str]]:
+    """
+    GOOS and GOARCH values an expression can build for, and its free tags.
+
+    A platform counts when some setting of the free tags satisfies the
+    expression.
+    """
+    names = tag_names(expr)
+    tags = sorted(names - set(KNOWN_OS) - set(KNOWN_ARCH) - {"unix"})
+    enumerated = tags[:MAX_FREE_TAGS]
+    # One architecture the expression does not name stands for all others
+    other_arch = next(a for a in KNOWN_ARCH if a not in names)
+    archs = [a for a in KNOWN_ARCH if a in names] + [other_arch]
+
+    buildable: Set[Tuple[str, str]] = set()
+    for goos, goarch in itertools.product(KNOWN_OS, archs):
+        for values in itertools.product((False, True), repeat=len(enumerated)):
+            enabled = {t for t, on in zip(enumerated, values) if on}
+
+            def is_set(name: str) -> bool:
+                if name in KNOWN_ARCH:
+                    return name == goarch
+                if name in KNOWN_OS or name == "unix":
+                    return _os_matches(name, goos)
+                # Tags beyond MAX_FREE_TAGS count as set
+                return name in enabled or name not in enumerated
+
+            if evaluate(expr, is_set):
+                buildable.add((goos, goarch))
+                break
+
+    os_values = {goos for goos, _ in buildable}
+    arch_values = {goarch for _, goarch in buildable}
+    if other_arch in arch_values:
+        arch_values |= set(KNOWN_ARCH) - names
+    return sorted(os_values), sorted(arch_values), tags
+
+
+class FileConstraint:
+    """Build constraint of one Go file."""
+
+    def __init__(self, expression: str):
+        """
+        Args:
+            expression: //go:build expression ("" when unconstrained)
+
+        Raises:
+            ValueError: If the expression is malformed
+        """
+        self.expression = expression
+        self.os: List[str] = []
+        self.arch: List[str] = []
+        self.tags: List[str] = []
+        if expression:
+            os_values, arch_values, self.tags = platforms(
+                parse_expression(expression)
+            )
+            # All values is no restriction
+            if len(os_values) < len(KNOWN_OS):
+                self.os = os_values
+            if len(arch_values) < len(KNOWN_ARCH):
+                self.arch = arch_values
+
+    @classmethod
+    def of_file(cls, file_name: str, text: str) -> "FileConstraint":
+        """Constraint of a file from its header lines and name."""
+        clauses = [
+            c for c in (header_expression(text), file_name_expression(file_name)) if c
+        ]
+        if len(clauses) == 2 and "||" in clauses[0]:
+            clauses[0] = f"({clauses[0]})"
+        return cls(" && ".join(clauses))
+
+    def payload(self) -> Dict[str, Union[str, List[str]]]:
+        if not self.expression:
+            return {}
+        payload: Dict[str, Union[str, List[str]]] = {
+            BUILD_CONSTRAINT_KEY: self.expression
+        }
+        if self.os:
+            payload[BUILD_OS_KEY] = self.os
+        if self.arch:
+            payload[BUILD_ARCH_KEY] = self.arch
+        if self.tags:
+            payload[BUILD_TAGS_KEY] = self.tags
+        return payload
+
+
+def parse_platform(platform: str) -> Tuple[str, Optional[str]]:
+    """
+    GOOS and optional GOARCH of a --platform value ("linux" or "linux/amd64").
+
+    Raises:
+        ValueError: If the OS or architecture is unknown
+    """
+    goos, _, goarch = platform.strip().lower().partition("/")
+    if goos not in KNOWN_OS:
+        raise ValueError(f"Unknown GOOS '{goos}'")
+    if goarch and goarch not in KNOWN_ARCH:
+        raise ValueError(f"Unknown GOARCH '{goarch}'")
+    return goos, goarch or None
+
+
+def platform_conditions(goos: str, goarch: Optional[str]) -> List[Dict[str, Any]]:
+    """
+    Payload filter conditions keeping the code that builds for a platform.
+
+    Code without an OS (or architecture) restriction always matches.
+    """
+    conditions = []
+    for key, value, known in (
+        (BUILD_OS_KEY, goos, KNOWN_OS),
+        (BUILD_ARCH_KEY, goarch, KNOWN_ARCH),
+    ):
+        if value is None:
+            continue
+        conditions.append(
+            {
+                "should": [
+                    {"key": key, "match": {"value": value}},
+                    # No restriction: the field holds none of the known values
+                    {"must_not": [{"key": key, "match": {"any": list(known)}}]},
+                ]
+            }
+        )
+    return conditions
+
+
+class GoBuildConstraints:
+    """Records the build constraints of Go files in their chunks."""
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["GoBuildConstraints"]:
+        """Annotator from indexing.go_build_constraints, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        build_config = getattr(indexing_config, "go_build_constraints", None)
+        if getattr(build_config, "enabled", False) is not True:
+            return None
+        return cls()
+
+    def classify_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks with the file's build constraint fields added."""
+        path = Path(file_path)
+        if not chunks or path.suffix != ".go":
+            return chunks
+        try:
+            with open(path, encoding="utf-8", errors="replace") as f:
+                header = f.read(16384)
+            constraint = FileConstraint.of_file(path.name, header)
+        except OSError as e:
+            logger.warning(f"Could not read {file_path} for build constraints: {e}")
+            return chunks
+        except ValueError as e:
+            logger.warning(f"Skipping build constraint of {file_path}: {e}")
+            return chunks
+        payload = constraint.payload()
+        if not payload:
+            return chunks
+        return [{**chunk, **payload} for chunk in chunks]
@@ -0,0 +1,349 @@
+"""
+Go module dependency graph behind ``cidx deps``.
+
+The go.mod, go.sum and go.work files of a project are read once per indexing
+run, when the first Go file is annotated. Each Go file belongs to the module
+of the nearest go.mod above it; its imports are resolved to the modules that
+provide them (the longest module path prefix among the project's modules,
+the requirements of its go.mod and the modules listed in its go.sum).
+Standard library imports are left out.
+
+Every chunk of a Go file records in its payload:
+
+- "go_package": the import path of the file's package
+- "go_imports": its non-standard-library imports
+- "go_modules": the modules providing them, e.g. ["github.com/gin-gonic/gin"]
+
+``cidx deps --module github.com/gin-gonic/gin`` lists the packages importing
+a module from the index, and ``cidx query --depends-on`` limits a search to
+files importing it. Imports are resolved when a file is indexed; run
+'cidx index --clear' after adding requirements to refresh unchanged files.
+"""
+
+import logging
+import os
+import re
+import threading
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Set, Tuple
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload keys holding the package and imports of a Go chunk's file
+GO_PACKAGE_KEY = "go_package"
+GO_IMPORTS_KEY = "go_imports"
+GO_MODULES_KEY = "go_modules"
+
+# Directories the go command ignores when looking for modules
+_SKIPPED_DIRS = {"vendor", "testdata", "node_modules"}
+
+_IMPORT_SPEC = re.compile(r'^\s*(?:[A-Za-z_.]\w*\s+)?"([^"]+)"')
+_IMPORT_LINE = re.compile(r'^import\s+(?:[A-Za-z_.]\w*\s+)?"([^"]+)"', re.M)
+_IMPORT_BLOCK = re.compile(r"^import\s*\((.*?)^\s*\)", re.M | re.S)
+
+
+@dataclass
+class Requirement:
+    """A module required by a go.mod file."""
+
+    module: str
+    version: str
+    indirect: bool = False
+    replaced_by: Optional[str] = None
+
+
+@dataclass
+class GoModule:
+    """A go.mod file of the project."""
+
+    path: str
+    # Directory of the go.mod, relative to the project root ("" for the root)
+    dir: str
+    go_version: Optional[str] = None
+    requires: List[Requirement] = field(default_factory=list)
+    # Modules listed in the go.sum next to the go.mod
+    sums: List[str] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def _directives(text: str) -> Iterable[Tuple[str, str, str]]:
+    """(verb, arguments, comment) of go.mod/go.work lines, blocks flattened."""
+    block: Optional[str] = None
+    for raw in text.split("\n"):
+        line, _, comment = raw.partition("//")
+        line = line.strip()
+        if block is not None:
+            if line == ")":
+                block = None
+            elif line:
+                yield block, line, comment.strip()
+            continue
+        verb, _, arguments = line.partition(" ")
+        arguments = arguments.strip()
+        if arguments == "(":
+            block = verb
+        elif verb:
+            yield verb, arguments, comment.strip()
+
+
+def _unquote(token: str) -> str:
+    return token.strip().strip('"`')
+
+
+def parse_go_mod(text: str, directory: str = "") -> Optional[GoModule]:
+    """Parse a go.mod file; None if it declares no module."""
+    module: Optional[GoModule] = None
+    requires: Dict[str, Requirement] = {}
+    replaces: Dict[str, str] = {}
+    go_version = None
+    for verb, arguments, comment in _directives(text):
+        if verb == "module":
+            module = GoModule(path=_unquote(arguments), dir=directory)
+        elif verb == "go":
+            go_version = arguments
+        elif verb == "require":
+            parts = arguments.split()
+            if len(parts) >= 2:
+                requires[_unquote(parts[0])] = Requirement(
+                    module=_unquote(parts[0]),
+                    version=parts[1],
+                    indirect=comment == "indirect" or comment.startswith("indirect;"),
+                )
+        elif verb == "replace" and "=>" in arguments:
+            old, new = arguments.split("=>", 1)
+            replaces[_unquote(old.split()[0])] = " ".join(new.split())
+    if module is None:
+        return None
+    for path, target in replaces.items():
+        if path in requires:
+            requires[path].replaced_by = target
+    module.go_version = go_version
+    module.requires = sorted(requires.values(), key=lambda r: r.module)
+    return module
+
+
+def parse_go_work(text: str) -> List[str]:
+    """Module directories of a go.work file, as written in its use directives."""
+    return [
+        _unquote(arguments)
+        for verb, arguments, _ in _directives(text)
+        if verb == "use"
+    ]
+
+
+def parse_go_sum(text: str) -> List[str]:
+    """Modules listed in a go.sum file."""
+    modules = {line.split()[0] for line in text.split("\n") if line.strip()}
+    return sorted(modules)
+
+
+def go_imports(text: str) -> List[str]:
+    """Import paths of a Go source file, in order."""
+    imports = _IMPORT_LINE.findall(text)
+    for block in _IMPORT_BLOCK.findall(text):
+        for line in block.split("\n"):
+            spec = _IMPORT_SPEC.match(line.split("//", 1)[0])
+            if spec:
+                imports.append(spec.group(1))
+    return list(dict.fromkeys(imports))
+
+
+def is_standard_library(import_path: str) -> bool:
+    """Standard library packages have no dot in their first path element."""
+    return "." not in import_path.split("/", 1)[0]
+
+
+def _under(import_path: str, module: str) -> bool:
+    return import_path == module or import_path.startswith(module + "/")
+
+
+class GoDependencyGraph:
+    """Resolves the imports of a project's Go files to modules."""
+
+    def __init__(self, codebase_dir: Path):
+        """
+        Initialize the graph; module files are read on first use.
+
+        Args:
+            codebase_dir: Project root
+        """
+        self.codebase_dir = Path(codebase_dir).resolve()
+        self._lock = threading.Lock()
+        self._modules: Optional[List[GoModule]] = None
+        self._workspaces: Dict[str, List[str]] = {}
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["GoDependencyGraph"]:
+        """Graph from indexing.go_dependencies, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        dependencies_config = getattr(indexing_config, "go_dependencies", None)
+        if getattr(dependencies_config, "enabled", False) is not True:
+            return None
+        return cls(Path(config.codebase_dir))
+
+    def modules(self) -> List[GoModule]:
+        """Modules of the project, ordered by directory."""
+        with self._lock:
+            if self._modules is None:
+                self._modules = self._scan()
+            return self._modules
+
+    def workspaces(self) -> Dict[str, List[str]]:
+        """Module directories per go.work file (relative directory)."""
+        self.modules()
+        return self._workspaces
+
+    def module_of(self, relative_path: str) -> Optional[GoModule]:
+        """Module of the nearest go.mod above a project-relative path."""
+        directory = os.path.dirname(relative_path)
+        best = None
+        for module in self.modules():
+            if module.dir == "" or _under(directory, module.dir):
+                if best is None or len(module.dir) > len(best.dir):
+                    best = module
+        return best
+
+    def resolve(self, import_path: str, module: Optional[GoModule]) -> Optional[str]:
+        """Module providing an import of a file in the given module."""
+        if is_standard_library(import_path):
+            return None
+        candidates: Set[str] = {m.path for m in self.modules()}
+        if module is not None:
+            candidates.update(r.module for r in module.requires)
+            candidates.update(module.sums)
+        matching = [c for c in candidates if _under(import_path, c)]
+        # Unknown modules (no go.mod/go.sum entry) are named by the import
+        return max(matching, key=len) if matching else import_path
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """
+        Return the chunks with the file's package, imports and imported
+        modules under GO_PACKAGE_KEY, GO_IMPORTS_KEY and GO_MODULES_KEY.
+        """
+        if not chunks or Path(file_path).suffix != ".go":
+            return chunks
+        try:
+            relative = Path(file_path).resolve().relative_to(self.codebase_dir)
+            text = Path(file_path).read_text(encoding="utf-8", errors="replace")
+        except (OSError, ValueError):
+            return chunks
+        module = self.module_of(relative.as_posix())
+        imports = [i for i in go_imports(text) if not is_standard_library(i)]
+
+        payload: Dict[str, Any] = {}
+        if module is not None:
+            package_dir = os.path.relpath(
+                os.path.dirname(relative.as_posix()) or ".", module.dir or "."
+            ).replace(os.sep, "/")
+            payload[GO_PACKAGE_KEY] = (
+                module.path if package_dir == "." else f"{module.path}/{package_dir}"
+            )
+        if imports:
+            payload[GO_IMPORTS_KEY] = imports
+            modules = (self.resolve(i, module) for i in imports)
+            payload[GO_MODULES_KEY] = list(dict.fromkeys(m for m in modules if m))
+        if not payload:
+            return chunks
+        return [{**chunk, **payload} for chunk in chunks]
+
+    def _scan(self) -> List[GoModule]:
+        modules: List[GoModule] = []
+        for root, dirs, files in os.walk(self.codebase_dir):
+            dirs[:] = sorted(
+                d for d in dirs if not d.startswith(".") and d not in _SKIPPED_DIRS
+            )
+            relative = Path(root).relative_to(self.codebase_dir).as_posix()
+            relative = "" if relative == "." else relative
+            if "go.work" in files:
+                text = _read(Path(root) / "go.work")
+                self._workspaces[relative] = parse_go_work(text)
+            if "go.mod" not in files:
+                continue
+            module = parse_go_mod(_read(Path(root) / "go.mod"), relative)
+            if module is None:
+                continue
+            if "go.sum" in files:
+                module.sums = parse_go_sum(_read(Path(root) / "go.sum"))
+            modules.append(module)
+        return modules
+
+
+def _read(path: Path) -> str:
+    try:
+        return path.read_text(encoding="utf-8", errors="replace")
+    except OSError as e:
+        logger.warning(f"Could not read {path}: {e}")
+        return ""
+
+
+@dataclass
+class ImportingPackage:
+    """A package of the project importing a module."""
+
+    package: str
+    files: List[str] = field(default_factory=list)
+    # Imported packages of the module
+    imports: List[str] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def find_importing_packages(
+    vector_store: Any,
+    project_root: Path,
+    module: str,
+    collections: Optional[Iterable[str]] = None,
+) -> List[ImportingPackage]:
+    """
+    List the packages of the indexed code that import a module.
+
+    Args:
+        vector_store: FilesystemVectorStore holding the collections
+        project_root: Git working tree the collections were indexed from
+        module: Module path, or the import path of one of its packages
+        collections: Content collections to read (default: all but git history)
+
+    Returns:
+        Importing packages ordered by import path
+    """
+    from ..storage.temporal_metadata_store import TemporalMetadataStore
+    from ..utils.git_runner import get_current_branch
+
+    if collections is None:
+        collections = [
+            name
+            for name in vector_store.list_collections()
+            if not TemporalMetadataStore.is_temporal_collection(name)
+        ]
+    current_branch = get_current_branch(project_root)
+
+    packages: Dict[str, ImportingPackage] = {}
+    for collection_name in collections:
+        for _, data, _ in vector_store.iter_vector_records(collection_name):
+            payload = (data or {}).get("payload", {})
+            imports = [
+                i for i in payload.get(GO_IMPORTS_KEY) or [] if _under(i, module)
+            ]
+            if not imports:
+                continue
+            if current_branch and current_branch in payload.get("hidden_branches", []):
+                continue
+            path = str(payload.get("path", ""))
+            name = payload.get(GO_PACKAGE_KEY) or os.path.dirname(path) or "."
+            package = packages.setdefault(name, ImportingPackage(name))
+            if path not in package.files:
+                package.files.append(path)
+            for i in imports:
+                if i not in package.imports:
+                    package.imports.append(i)
+
+    for package in packages.values():
+        package.files.sort()
+        package.imports.sort()
+    return sorted(packages.values(), key=lambda p: p.package)
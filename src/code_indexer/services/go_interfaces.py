@@ -0,0 +1,359 @@
+"""
+Interface-to-implementation linkage for Go.
+
+Go types satisfy interfaces implicitly, so "what implements UserRepository"
+cannot be answered from a single file. When the first Go file of an indexing
+run is annotated, every Go file of the project is scanned once for interface
+declarations and for the methods declared on each type. A type implements an
+interface when its method set contains every method of the interface with
+the same parameter and result types.
+
+The chunk holding a type's declaration records the interfaces it implements
+in the "implements" payload field, both bare and qualified by the package
+name (["UserRepository", "repository.UserRepository"]), and
+``cidx query --implements`` filters on it.
+
+The matching is syntactic: package qualifiers are dropped when comparing
+types, pointer and value receivers count alike, methods promoted from
+embedded fields are not followed, and interfaces that embed interfaces from
+outside the project or carry type constraints are skipped. Links are
+computed when a file is indexed, so a type whose file did not change keeps
+its links until it is reindexed ('cidx index --clear' refreshes all).
+"""
+
+import logging
+import os
+import re
+import threading
+from collections import defaultdict
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Set, Tuple
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload key holding the interfaces a chunk's type implements
+IMPLEMENTS_KEY = "implements"
+
+GO_EXTENSION = ".go"
+
+_PACKAGE = re.compile(r"^package\s+([A-Za-z_]\w*)", re.M)
+_TYPE_DECL = re.compile(
+    r"^(?:type[ \t]+|\t)([A-Za-z_]\w*)(?:\[[^\]\n]*\])?[ \t]+(?!=)(interface\b)?"
+)
+_METHOD_DECL = re.compile(
+    r"^func\s*\(\s*(?:[A-Za-z_]\w*\s+)?\*?\s*([A-Za-z_]\w*)(?:\[[^\]]*\])?\s*\)"
+    r"\s*([A-Za-z_]\w*)\s*(?:\[[^\]]*\])?\(",
+    re.M,
+)
+_INTERFACE_METHOD = re.compile(r"^([A-Za-z_]\w*)\s*\(")
+_EMBEDDED_NAME = re.compile(r"^(?:[A-Za-z_]\w*\.)?([A-Za-z_]\w*)$")
+_QUALIFIER = re.compile(r"\b[A-Za-z_]\w*\.(?=[A-Za-z_])")
+_NAMED_PARAM = re.compile(r"^([A-Za-z_]\w*)\s+(\S.*)$")
+_TYPE_KEYWORDS = {"func", "chan", "map", "struct", "interface"}
+
+# Method name -> normalized signature
+MethodSet = Dict[str, str]
+# Declaration line of a type and the interfaces it implements
+TypeLinks = Tuple[int, List[str]]
+
+
+def _closing_paren(text: str, start: int) -> int:
+    """Index of the parenthesis closing the one at text[start]."""
+    depth = 0
+    for i in range(start, len(text)):
+        if text[i] == "(":
+            depth += 1
+        elif text[i] == ")":
+            depth -= 1
+            if depth == 0:
+                return i
+    return len(text)
+
+
+def _split_top_level(text: str) -> List[str]:
+    """Split on commas outside brackets, braces and parentheses."""
+    parts, depth, current = [], 0, []
+    for char in text:
+        if char in "([{":
+            depth += 1
+        elif char in ")]}":
+            depth -= 1
+        if char == "," and depth == 0:
+            parts.append("".join(current))
+            current = []
+        else:
+            current.append(char)
+    parts.append("".join(current))
+    return [part.strip() for part in parts if part.strip()]
+
+
+def _normalize_type(type_text: str) -> str:
+    return re.sub(r"\s+", "", _QUALIFIER.sub("", type_text))
+
+
+def parameter_types(params: str) -> List[str]:
+    """Types of a Go parameter or result list, without names or qualifiers."""
+    parts = _split_top_level(params)
+    named = [_NAMED_PARAM.match(part) for part in parts]
+    if not any(match and match.group(1) not in _TYPE_KEYWORDS for match in named):
+        return [_normalize_type(part) for part in parts]
+    # Named parameters: "a, b int" gives both names the following type
+    types: List[str] = []
+    pending = 0
+    for part, match in zip(parts, named):
+        if match and match.group(1) not in _TYPE_KEYWORDS:
+            types.extend([_normalize_type(match.group(2))] * (pending + 1))
+            pending = 0
+        else:
+            pending += 1
+    return types
+
+
+def signature(text: str, params_start: int) -> str:
+    """Normalized signature of the function whose parameters open there."""
+    params_end = _closing_paren(text, params_start)
+    rest = text[params_end + 1 :].split("\n", 1)[0].split("//", 1)[0]
+    # gofmt separates the body brace by a space, unlike "interface{}"
+    rest = rest.split(" {", 1)[0].strip()
+    if rest.startswith("("):
+        results = parameter_types(rest[1 : _closing_paren(rest, 0)])
+    else:
+        results = [_normalize_type(rest)] if rest else []
+    params = parameter_types(text[params_start + 1 : params_end])
+    return f"({','.join(params)})({','.join(results)})"
+
+
+def _block(lines: List[str], start: int) -> Tuple[List[str], int]:
+    """Lines inside the braces opened on lines[start], and the last index."""
+    depth = 0
+    for i in range(start, len(lines)):
+        code = lines[i].split("//", 1)[0]
+        depth += code.count("{") - code.count("}")
+        if depth <= 0:
+            return lines[start + 1 : i], i
+    return lines[start + 1 :], len(lines) - 1
+
+
+class GoFileDeclarations:
+    """Interfaces, type declarations and methods of one Go file."""
+
+    def __init__(self, text: str):
+        package = _PACKAGE.search(text)
+        self.package = package.group(1) if package else ""
+        # Interface name -> (methods, embedded interface names); None for
+        # interfaces with type constraints
+        self.interfaces: Dict[str, Optional[Tuple[MethodSet, List[str]]]] = {}
+        # Type name -> line of its declaration
+        self.types: Dict[str, int] = {}
+        self.methods: Dict[str, MethodSet] = defaultdict(dict)
+
+        lines = text.split("\n")
+        in_group = False
+        i = 0
+        while i < len(lines):
+            line = lines[i]
+            if line.startswith("type ("):
+                in_group = True
+            elif in_group and line.startswith(")"):
+                in_group = False
+            declaration = _TYPE_DECL.match(line)
+            if declaration and (in_group or line.startswith("type")):
+                name = declaration.group(1)
+                if declaration.group(2) and "{" in line:
+                    start = i
+                    body, i = _block(lines, i)
+                    if i == start:  # "type Closer interface { Close() error }"
+                        body = [line[line.index("{") + 1 : line.rindex("}")]]
+                    self.interfaces[name] = self._interface(body)
+                elif not declaration.group(2):
+                    self.types[name] = i + 1
+            i += 1
+
+        for match in _METHOD_DECL.finditer(text):
+            self.methods[match.group(1)][match.group(2)] = signature(
+                text, match.end() - 1
+            )
+
+    @staticmethod
+    def _interface(body: List[str]) -> Optional[Tuple[MethodSet, List[str]]]:
+        methods: MethodSet = {}
+        embedded: List[str] = []
+        text = "\n".join(line.split("//", 1)[0] for line in body)
+        for element in (e.strip() for e in re.split(r"[\n;]", text)):
+            if not element:
+                continue
+            method = _INTERFACE_METHOD.match(element)
+            if method:
+                methods[method.group(1)] = signature(element, method.end() - 1)
+                continue
+            name = _EMBEDDED_NAME.match(element)
+            if name is None:
+                return None  # Type constraint, e.g. "~int | ~string"
+            embedded.append(name.group(1))
+        return methods, embedded
+
+
+class GoInterfaceIndex:
+    """Links the Go types of a project to the interfaces they implement."""
+
+    def __init__(self, codebase_dir: Path, file_finder: Optional[Any] = None):
+        """
+        Initialize the index; the project is scanned on first use.
+
+        Args:
+            codebase_dir: Project root
+            file_finder: FileFinder of the indexing run; without it, every
+                .go file outside hidden directories is scanned
+        """
+        self.codebase_dir = Path(codebase_dir).resolve()
+        self.file_finder = file_finder
+        self._lock = threading.Lock()
+        # Relative file path -> type name -> (declaration line, interfaces)
+        self._links: Optional[Dict[str, Dict[str, TypeLinks]]] = None
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["GoInterfaceIndex"]:
+        """Index from indexing.go_interfaces, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        interfaces_config = getattr(indexing_config, "go_interfaces", None)
+        if getattr(interfaces_config, "enabled", False) is not True:
+            return None
+        from ..indexing.file_finder import FileFinder
+
+        return cls(Path(config.codebase_dir), FileFinder(config))
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """
+        Return the chunks with the interfaces implemented by the types
+        declared in them under IMPLEMENTS_KEY.
+        """
+        if not chunks or Path(file_path).suffix != GO_EXTENSION:
+            return chunks
+        try:
+            relative = Path(file_path).resolve().relative_to(self.codebase_dir)
+        except ValueError:
+            return chunks  # Outside the project
+        types = self.links().get(relative.as_posix())
+        if not types:
+            return chunks
+
+        annotated = []
+        for chunk in chunks:
+            line_start = chunk.get("line_start") or 1
+            line_end = chunk.get("line_end") or line_start
+            implemented = [
+                interface
+                for line, interfaces in types.values()
+                if line_start <= line <= line_end
+                for interface in interfaces
+            ]
+            if implemented:
+                chunk = {**chunk, IMPLEMENTS_KEY: list(dict.fromkeys(implemented))}
+            annotated.append(chunk)
+        return annotated
+
+    def links(self) -> Dict[str, Dict[str, TypeLinks]]:
+        """Implemented interfaces per file and type, scanning on first use."""
+        with self._lock:
+            if self._links is None:
+                self._links = self._scan()
+            return self._links
+
+    def _go_files(self) -> Iterable[Path]:
+        if self.file_finder is not None:
+            for path in self.file_finder.find_files():
+                if path.suffix == GO_EXTENSION:
+                    yield path
+            return
+        for root, dirs, files in os.walk(self.codebase_dir):
+            dirs[:] = [d for d in dirs if not d.startswith(".")]
+            for name in files:
+                if name.endswith(GO_EXTENSION):
+                    yield Path(root) / name
+
+    def _scan(self) -> Dict[str, Dict[str, TypeLinks]]:
+        files: Dict[str, GoFileDeclarations] = {}
+        for path in self._go_files():
+            try:
+                text = path.read_text(encoding="utf-8", errors="replace")
+                relative = path.resolve().relative_to(self.codebase_dir)
+            except (OSError, ValueError):
+                continue
+            files[relative.as_posix()] = GoFileDeclarations(text)
+
+        interfaces = _resolve_interfaces(files)
+        # Methods of a type may be declared in any file of its package
+        package_methods: Dict[Tuple[str, str], MethodSet] = defaultdict(dict)
+        for path, declarations in files.items():
+            for type_name, methods in declarations.methods.items():
+                package_methods[(os.path.dirname(path), type_name)].update(methods)
+
+        links: Dict[str, Dict[str, TypeLinks]] = {}
+        for path, declarations in files.items():
+            for type_name, line in declarations.types.items():
+                methods = package_methods.get((os.path.dirname(path), type_name))
+                if not methods:
+                    continue
+                implemented = [
+                    term
+                    for (package, name), required in sorted(interfaces.items())
+                    if all(methods.get(m) == sig for m, sig in required.items())
+                    for term in (name, f"{package}.{name}")
+                ]
+                if implemented:
+                    links.setdefault(path, {})[type_name] = (
+                        line,
+                        list(dict.fromkeys(implemented)),
+                    )
+        logger.debug(f"Go interface links: {sum(map(len, links.values()))} types")
+        return links
+
+
+def _resolve_interfaces(
+    files: Dict[str, GoFileDeclarations],
+) -> Dict[Tuple[str, str], MethodSet]:
+    """
+    Full method sets of the project's interfaces, keyed by (package, name).
+
+    Embedded interfaces are looked up by name across the project; interfaces
+    whose method set cannot be resolved, or is empty, are left out.
+    """
+    declared: Dict[Tuple[str, str], Tuple[MethodSet, List[str]]] = {}
+    by_name: Dict[str, List[Tuple[str, str]]] = defaultdict(list)
+    for declarations in files.values():
+        for name, interface in declarations.interfaces.items():
+            key = (declarations.package, name)
+            if interface is not None and key not in declared:
+                declared[key] = interface
+                by_name[name].append(key)
+
+    resolved: Dict[Tuple[str, str], Optional[MethodSet]] = {}
+
+    def resolve(
+        key: Tuple[str, str], seen: Set[Tuple[str, str]]
+    ) -> Optional[MethodSet]:
+        if key in resolved:
+            return resolved[key]
+        if key in seen:
+            return None
+        methods, embedded = declared[key]
+        full = dict(methods)
+        for name in embedded:
+            candidates = by_name.get(name, [])
+            # Prefer the embedding interface's own package
+            candidates = sorted(candidates, key=lambda k: k[0] != key[0])
+            inner = resolve(candidates[0], seen | {key}) if candidates else None
+            if inner is None:
+                resolved[key] = None
+                return None
+            full.update(inner)
+        resolved[key] = full
+        return full
+
+    return {
+        key: methods
+        for key in declared
+        if (methods := resolve(key, set()))
+    }
@@ -0,0 +1,154 @@
+"""
+Links protobuf rpc definitions to the gRPC stubs generated from them.
+
+Generated client and server code names every method by its gRPC path
+("/acme.user.v1.UserService/GetUser") or, in Java, C# and Ruby, names the
+service once (SERVICE_NAME = "acme.user.v1.UserService"). When the first
+.proto chunk of an indexing run is annotated, the generated files of the
+project (recognized by protoc plugin file names such as user_grpc.pb.go,
+user_pb2_grpc.py or UserServiceGrpc.java) are scanned once for these names.
+
+rpc and service chunks of .proto files record the stub files under
+"grpc_stubs". Links are computed when a .proto file is indexed; run
+'cidx index --clear' after regenerating stubs for new services.
+"""
+
+import logging
+import os
+import re
+import threading
+from collections import defaultdict
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Set
+
+from ..indexing.chunk_payload_keys import PROTO_KIND_KEY, PROTO_NAME_KEY
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload key holding the generated stubs of an rpc or service
+GRPC_STUBS_KEY = "grpc_stubs"
+
+# File name endings of the gRPC plugins of protoc, buf and connect
+STUB_SUFFIXES = (
+    "_grpc.pb.go",
+    ".connect.go",
+    "_pb2_grpc.py",
+    "Grpc.java",
+    "GrpcKt.kt",
+    "Grpc.cs",
+    "_grpc_pb.js",
+    "_grpc_pb.d.ts",
+    "_grpc_web_pb.js",
+    "_connect.ts",
+    "_connect.js",
+    ".grpc.pb.cc",
+    ".grpc.pb.h",
+    "_services_pb.rb",
+)
+
+_METHOD_PATH = re.compile(r"""["']/([A-Za-z_][\w.]*)/([A-Za-z_]\w*)["']""")
+_SERVICE_NAME = re.compile(
+    r"""\b(?:SERVICE_NAME|__ServiceName|service_name|typeName)\s*[:=]\s*"""
+    r"""["']([A-Za-z_][\w.]*)["']"""
+)
+
+
+def is_stub_file(file_name: str) -> bool:
+    return file_name.endswith(STUB_SUFFIXES)
+
+
+class GrpcStubIndex:
+    """Finds the generated gRPC stubs of a project's services."""
+
+    def __init__(self, codebase_dir: Path, file_finder: Optional[Any] = None):
+        """
+        Initialize the index; stub files are scanned on first use.
+
+        Args:
+            codebase_dir: Project root
+            file_finder: FileFinder of the indexing run; without it, every
+                stub file outside hidden directories is scanned
+        """
+        self.codebase_dir = Path(codebase_dir).resolve()
+        self.file_finder = file_finder
+        self._lock = threading.Lock()
+        # gRPC path ("pkg.Service/Method") or service name -> stub files
+        self._stubs: Optional[Dict[str, List[str]]] = None
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["GrpcStubIndex"]:
+        """Index from indexing.grpc_stubs, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        stubs_config = getattr(indexing_config, "grpc_stubs", None)
+        if getattr(stubs_config, "enabled", False) is not True:
+            return None
+        from ..indexing.file_finder import FileFinder
+
+        return cls(Path(config.codebase_dir), FileFinder(config))
+
+    def stubs_of(self, proto_kind: str, proto_name: str) -> List[str]:
+        """Stub files of an rpc or service, by its full protobuf name."""
+        stubs = self.stubs()
+        if proto_kind == "service":
+            return list(stubs.get(proto_name, []))
+        if proto_kind != "rpc":
+            return []
+        from ..indexing.proto_chunker import grpc_method_path
+
+        method_path = grpc_method_path(proto_name)
+        if method_path is None:
+            return []
+        # Without stubs naming the method (Java, C#, Ruby), link the service's
+        service = method_path.split("/", 1)[0]
+        return list(stubs.get(method_path) or stubs.get(service, []))
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks with the stubs of their rpc or service added."""
+        if not chunks or Path(file_path).suffix != ".proto":
+            return chunks
+        annotated = []
+        for chunk in chunks:
+            stubs = self.stubs_of(
+                chunk.get(PROTO_KIND_KEY, ""), chunk.get(PROTO_NAME_KEY, "")
+            )
+            if stubs:
+                chunk = {**chunk, GRPC_STUBS_KEY: stubs}
+            annotated.append(chunk)
+        return annotated
+
+    def stubs(self) -> Dict[str, List[str]]:
+        """Stub files per gRPC path and service name, scanning on first use."""
+        with self._lock:
+            if self._stubs is None:
+                self._stubs = self._scan()
+            return self._stubs
+
+    def _stub_files(self) -> Iterable[Path]:
+        if self.file_finder is not None:
+            for path in self.file_finder.find_files():
+                if is_stub_file(path.name):
+                    yield path
+            return
+        for root, dirs, files in os.walk(self.codebase_dir):
+            dirs[:] = [d for d in dirs if not d.startswith(".")]
+            for name in files:
+                if is_stub_file(name):
+                    yield Path(root) / name
+
+    def _scan(self) -> Dict[str, List[str]]:
+        stubs: Dict[str, Set[str]] = defaultdict(set)
+        for path in self._stub_files():
+            try:
+                text = path.read_text(encoding="utf-8", errors="replace")
+                relative = path.resolve().relative_to(self.codebase_dir).as_posix()
+            except (OSError, ValueError):
+                continue
+            for service, method in _METHOD_PATH.findall(text):
+                stubs[f"{service}/{method}"].add(relative)
+                stubs[service].add(relative)
+            for service in _SERVICE_NAME.findall(text):
+                stubs[service].add(relative)
+        logger.debug(f"gRPC stubs: {len(stubs)} services and methods")
+        return {name: sorted(paths) for name, paths in stubs.items()}
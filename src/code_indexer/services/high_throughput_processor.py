@@ -20,16 +20,45 @@ import logging
 import os
 import time
 import concurrent.futures
+import contextlib
 from concurrent.futures import as_completed, ThreadPoolExecutor
 from dataclasses import dataclass
 from pathlib import Path
-from typing import List, Dict, Any, Optional, Callable
+from typing import List, Dict, Any, Optional, Callable, Tuple
 from queue import Queue, Empty
 import threading
 
 from ..indexing.processor import ProcessingStats
 from ..services.git_aware_processor import GitAwareDocumentProcessor
 from .vector_calculation_manager import VectorCalculationManager
+from .concurrency_tuner import AutoTuneController, ConcurrencyTuner
+from .indexing_throttle import get_configured_throttle_profile
+from .memory_budget import SPILL_DIR_NAME, MemoryBudget
+from .batch_scheduler import LatencyAwareBatchScheduler
+from .content_dedup import DUPLICATE_PATHS_KEY, group_identical_files
+from .pii_scrubber import PiiScrubber
+from .boilerplate_filter import BoilerplateFilter
+from .task_markers import TaskMarkerExtractor
+from .license_detection import LicenseDetector
+from .code_owners import CodeOwners
+from .struct_tags import StructTagExtractor
+from .go_interfaces import GoInterfaceIndex
+from .go_build_constraints import GoBuildConstraints
+from .go_dependencies import GoDependencyGraph
+from .import_graph import ImportGraph
+from .test_linkage import SubjectLinker
+from .generated_code import GeneratedCodeDetector
+from .grpc_stubs import GrpcStubIndex
+from .cobol_copybooks import CopybookIndex
+from .code_metrics import CodeMetrics
+from .symbol_declarations import SymbolDeclarations
+from .doc_comments import DocCommentPairer
+from .chunk_context import ChunkContextEnricher
+from .signature_vectors import SignatureVectors
+from .type_parameters import TypeParameterExtractor
+from .lifecycle_hooks import LifecycleHooks
+from .chunk_ids import compute_chunk_point_id
+from .chunk_integrity import compute_chunk_hash
 from .clean_slot_tracker import CleanSlotTracker, FileStatus, FileData
 from .file_chunking_manager import FileChunkingManager, FileProcessingResult
 
@@ -124,21 +153,21 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
             file_path: File path (absolute or relative)
 
         Returns:
-            Relative path string for database storage
+            Relative path string with forward slashes on every platform
 
         Raises:
             ValueError: If file_path is not under codebase_dir
         """
         if file_path.is_absolute():
             try:
-                return str(file_path.relative_to(self.config.codebase_dir))
+                return file_path.relative_to(self.config.codebase_dir).as_posix()
             except ValueError as e:
                 logger.error(
                     f"Cannot normalize path {file_path} - not under codebase_dir "
                     f"{self.config.codebase_dir}: {e}"
                 )
                 raise
-        return str(file_path)
+        return file_path.as_posix()
 
     def _initialize_file_rate_tracking(self):
         """Initialize file processing rate tracking."""
@@ -260,6 +289,76 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
                 else:
                     return 0.0
 
+    def _get_upsert_queue_size(self) -> int:
+        """Upsert stage queue capacity from config (0 disables pipelined writes)."""
+        indexing_config = getattr(self.config, "indexing", None)
+        queue_size = getattr(indexing_config, "upsert_queue_size", 0)
+        # Tolerate partially-populated configs (e.g. mocks) by disabling the stage
+        return queue_size if isinstance(queue_size, int) and queue_size > 0 else 0
+
+    def _create_memory_budget(self) -> Optional[MemoryBudget]:
+        """Memory budget from indexing.max_memory_mb, or None when unlimited."""
+        indexing_config = getattr(self.config, "indexing", None)
+        max_memory_mb = getattr(indexing_config, "max_memory_mb", None)
+        if not isinstance(max_memory_mb, int) or max_memory_mb <= 0:
+            return None
+        budget = MemoryBudget(
+            max_memory_mb,
+            Path(self.config.codebase_dir) / ".code-indexer" / SPILL_DIR_NAME,
+        )
+        budget.cleanup()  # Leftovers from an interrupted run
+        return budget
+
+    def _create_batch_scheduler(self) -> Optional[LatencyAwareBatchScheduler]:
+        """Latency-aware batch sizing when voyage_ai.batch_latency_target is set."""
+        voyage_config = getattr(self.config, "voyage_ai", None)
+        target = getattr(voyage_config, "batch_latency_target", None)
+        if not isinstance(target, (int, float)) or target <= 0:
+            return None
+        model_limit = (
+            self.embedding_provider._get_model_token_limit()  # type: ignore[attr-defined]
+        )
+        return LatencyAwareBatchScheduler(float(target), model_limit)
+
+    def _deduplicate_identical_files_enabled(self) -> bool:
+        """Whether identical files share one chunk/embedding pass."""
+        indexing_config = getattr(self.config, "indexing", None)
+        return getattr(indexing_config, "deduplicate_identical_files", False) is True
+
+    def _deduplicate_branch_content_enabled(self) -> bool:
+        """Whether branch changes reuse points stored for identical content."""
+        indexing_config = getattr(self.config, "indexing", None)
+        return getattr(indexing_config, "deduplicate_branch_content", False) is True
+
+    def _get_auto_tune_max_threads(self, vector_thread_count: int) -> Optional[int]:
+        """Pool size upper bound when auto-tuning is enabled, else None."""
+        voyage_config = getattr(self.config, "voyage_ai", None)
+        if getattr(voyage_config, "auto_tune", False) is not True:
+            return None
+        max_threads = getattr(voyage_config, "auto_tune_max_parallel_requests", 0)
+        if not isinstance(max_threads, int) or max_threads <= vector_thread_count:
+            return None
+        return max_threads
+
+    def _create_auto_tune_controller(
+        self,
+        vector_manager: VectorCalculationManager,
+        file_manager: FileChunkingManager,
+        vector_thread_count: int,
+        max_threads: Optional[int],
+    ):
+        """Context manager running the auto-tune sampler (no-op when disabled)."""
+        if max_threads is None:
+            return contextlib.nullcontext()
+        tuner = ConcurrencyTuner(
+            initial_threads=vector_thread_count, max_threads=max_threads
+        )
+        return AutoTuneController(
+            tuner,
+            vector_manager,
+            ingest_backlog_provider=file_manager.get_upsert_backlog_ratio,
+        )
+
     def process_files_high_throughput(
         self,
         files: List[Path],
@@ -268,11 +367,35 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
         progress_callback: Optional[Callable] = None,
         slot_tracker: Optional[CleanSlotTracker] = None,
         fts_manager: Optional[Any] = None,
+        reuse_embeddings: bool = False,
     ) -> ProcessingStats:
-        """Process files with maximum throughput using pre-queued chunks."""
+        """Process files with maximum throughput using pre-queued chunks.
+
+        With reuse_embeddings, chunks whose embedded text is unchanged keep
+        their stored vectors and only edited chunks are embedded (watch mode).
+        """
 
-        # Create local slot tracker for this processing phase
-        local_slot_tracker = CleanSlotTracker(max_slots=vector_thread_count + 2)
+        # AUTO-TUNE: Size pools for the upper bound, tuner caps actual concurrency
+        auto_tune_max_threads = self._get_auto_tune_max_threads(vector_thread_count)
+        pool_thread_count = auto_tune_max_threads or vector_thread_count
+
+        # BOUNDED MEMORY: Shrink the upsert queue and spill points over budget
+        memory_budget = self._create_memory_budget()
+        upsert_queue_size = self._get_upsert_queue_size()
+        if memory_budget is not None:
+            upsert_queue_size = memory_budget.queue_size_for(upsert_queue_size)
+            logger.info(
+                f"Indexing within {memory_budget.max_memory_mb} MB "
+                f"(upsert queue size {upsert_queue_size})"
+            )
+
+        # Create local slot tracker for this processing phase. Files waiting
+        # for or being written by the upsert stage keep their slot until the
+        # write completes.
+        max_slots = pool_thread_count + 2
+        if upsert_queue_size > 0:
+            max_slots += upsert_queue_size + 1
+        local_slot_tracker = CleanSlotTracker(max_slots=max_slots)
         if progress_callback:
             progress_callback(
                 0,
@@ -290,17 +413,55 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
 
         # PARALLEL FILE PROCESSING: Replace sequential chunking with parallel submission
         with VectorCalculationManager(
-            self.embedding_provider, vector_thread_count
+            self.embedding_provider,
+            vector_thread_count,
+            max_thread_count=auto_tune_max_threads,
+            batch_scheduler=self._create_batch_scheduler(),
         ) as vector_manager:
             with FileChunkingManager(
                 vector_manager=vector_manager,
                 chunker=self.fixed_size_chunker,
                 vector_store_client=self.vector_store_client,
-                thread_count=vector_thread_count,
+                thread_count=pool_thread_count,
                 slot_tracker=local_slot_tracker,
                 codebase_dir=self.config.codebase_dir,
                 fts_manager=fts_manager,
-            ) as file_manager:
+                upsert_queue_size=upsert_queue_size,
+                file_delay_seconds=get_configured_throttle_profile(
+                    self.config
+                ).file_delay_seconds,
+                memory_budget=memory_budget,
+                pii_scrubber=PiiScrubber.from_config(self.config),
+                boilerplate_filter=BoilerplateFilter.from_config(self.config),
+                task_marker_extractor=TaskMarkerExtractor.from_config(self.config),
+                license_detector=LicenseDetector.from_config(self.config),
+                code_owners=CodeOwners.from_config(self.config),
+                struct_tag_extractor=StructTagExtractor.from_config(self.config),
+                go_interface_index=GoInterfaceIndex.from_config(self.config),
+                go_build_constraints=GoBuildConstraints.from_config(self.config),
+                go_dependency_graph=GoDependencyGraph.from_config(self.config),
+                import_graph=ImportGraph.from_config(self.config),
+                subject_linker=SubjectLinker.from_config(self.config),
+                generated_code_detector=GeneratedCodeDetector.from_config(
+                    self.config
+                ),
+                grpc_stub_index=GrpcStubIndex.from_config(self.config),
+                copybook_index=CopybookIndex.from_config(self.config),
+                code_metrics=CodeMetrics.from_config(self.config),
+                symbol_declarations=SymbolDeclarations.from_config(self.config),
+                doc_comment_pairer=DocCommentPairer.from_config(self.config),
+                chunk_context=ChunkContextEnricher.from_config(self.config),
+                signature_vectors=SignatureVectors.from_config(self.config),
+                type_parameter_extractor=TypeParameterExtractor.from_config(
+                    self.config
+                ),
+                lifecycle_hooks=LifecycleHooks.from_config(
+                    self.config, points=("post_chunk", "pre_embed")
+                ),
+                reuse_embeddings=reuse_embeddings,
+            ) as file_manager, self._create_auto_tune_controller(
+                vector_manager, file_manager, vector_thread_count, auto_tune_max_threads
+            ):
                 # PARALLEL HASH CALCULATION - eliminate serial bottleneck
                 file_futures = []
 
@@ -579,13 +740,36 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
                     self.config, self.embedding_provider
                 )
 
+                # CONTENT DEDUP: Embed identical files (vendored copies) only once
+                duplicate_groups: Dict[Path, List[Path]] = {}
+                duplicate_files: set = set()
+                if self._deduplicate_identical_files_enabled():
+                    duplicate_groups, duplicate_count = group_identical_files(
+                        hash_results
+                    )
+                    for duplicates in duplicate_groups.values():
+                        duplicate_files.update(duplicates)
+                    if duplicate_count:
+                        logger.info(
+                            f"Deduplicated {duplicate_count} identical files into "
+                            f"{len(duplicate_groups)} embedded copies"
+                        )
+
                 for file_path in files:
                     if self.cancelled:
                         break
+                    if file_path in duplicate_files:
+                        continue  # Written together with its canonical file
 
                     try:
                         # Get pre-calculated metadata and size (no I/O)
                         file_metadata, file_size = hash_results[file_path]
+                        duplicates = duplicate_groups.get(file_path)
+                        if duplicates:
+                            file_metadata[DUPLICATE_PATHS_KEY] = [
+                                str(d) for d in duplicates
+                            ]
+                            file_size += sum(hash_results[d][1] for d in duplicates)
 
                         # CRITICAL FIX: Add collection_name to metadata for FilesystemVectorStore
                         # This prevents "collection_name is required when multiple collections exist" error
@@ -637,10 +821,15 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
                         # SIMPLE FIX: Use reasonable timeout for all file results
                         file_result = file_future.result(timeout=file_result_timeout)
 
+                        # Duplicates of this file were written (or failed) with it
+                        group_size = 1 + len(
+                            duplicate_groups.get(file_result.file_path, [])
+                        )
+
                         if file_result.success:
-                            stats.files_processed += 1
+                            stats.files_processed += group_size
                             stats.chunks_created += file_result.chunks_processed
-                            completed_files += 1
+                            completed_files += group_size
 
                             # Track source bytes for KB/s calculation when file completes
                             try:
@@ -703,7 +892,7 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
                                     stats.cancelled = True
                                     break
                         else:
-                            stats.failed_files += 1
+                            stats.failed_files += group_size
                             logger.error(f"File processing failed: {file_result.error}")
 
                     except concurrent.futures.TimeoutError:
@@ -822,14 +1011,15 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
             if "file_size" in metadata_info:
                 payload["filesystem_size"] = metadata_info["file_size"]
 
-        # Create point ID
-        point_id = self._create_point_id(
-            chunk_task.file_metadata, chunk_task.chunk_data["chunk_index"]
+        # Deterministic point ID: identical content gets the same ID
+        point_id, unique_key = compute_chunk_point_id(
+            chunk_task.file_metadata["project_id"],
+            payload["path"],
+            chunk_task.chunk_data["chunk_index"],
+            compute_chunk_hash(chunk_task.chunk_data["text"]),
         )
         payload["point_id"] = point_id
-        payload["unique_key"] = self._create_unique_key(
-            chunk_task.file_metadata, chunk_task.chunk_data["chunk_index"]
-        )
+        payload["unique_key"] = unique_key
 
         # Create vector point
         point = self.vector_store_client.create_point(
@@ -872,7 +1062,8 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
             collection_name: Filesystem collection name
             progress_callback: Optional callback for progress reporting
             vector_thread_count: Number of threads for parallel processing
-            watch_mode: If True, skip HNSW rebuild (for watch mode performance)
+            watch_mode: If True, skip HNSW rebuild and reuse the stored vectors
+                of unchanged chunks (for watch mode performance)
 
         Returns:
             BranchIndexingResult with processing statistics
@@ -893,9 +1084,18 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
         self.vector_store_client.begin_indexing(collection_name)
 
         try:
+            # Files whose content is already stored only need to become visible
+            files_to_index = changed_files
+            if changed_files and self._deduplicate_branch_content_enabled():
+                files_to_index, result.content_points_reused = (
+                    self._reuse_stored_content_thread_safe(
+                        changed_files, new_branch, collection_name
+                    )
+                )
+
             # Convert relative paths to absolute paths for processing
             absolute_changed_files = []
-            for rel_path in changed_files:
+            for rel_path in files_to_index:
                 abs_path = self.config.codebase_dir / rel_path
                 if abs_path.exists():
                     absolute_changed_files.append(abs_path)
@@ -921,6 +1121,7 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
                     progress_callback=progress_callback,
                     slot_tracker=slot_tracker,
                     fts_manager=fts_manager,
+                    reuse_embeddings=watch_mode,
                 )
 
                 result.files_processed = stats.files_processed
@@ -1217,6 +1418,84 @@ class HighThroughputProcessor(GitAwareDocumentProcessor):
 
             return True
 
+    def _reuse_stored_content_thread_safe(
+        self, changed_files: List[str], branch: str, collection_name: str
+    ) -> Tuple[List[str], int]:
+        """
+        Make the stored points of changed files with unchanged content visible
+        in the branch instead of chunking and embedding them again.
+
+        A changed file is reused when the index holds points for its path and
+        every one of them was built from the file's current content hash. The
+        points keep their vectors and gain visibility in the branch, so content
+        shared by several branches is stored once.
+
+        Args:
+            changed_files: Relative paths reported as changed by the branch switch
+            branch: Branch being switched to
+            collection_name: Filesystem collection name
+
+        Returns:
+            (relative paths that still need indexing, number of reused points)
+        """
+        try:
+            content_points, _ = self.vector_store_client.scroll_points(
+                filter_conditions={
+                    "must": [{"key": "type", "match": {"value": "content"}}]
+                },
+                limit=10000,
+                collection_name=collection_name,
+            )
+        except Exception as e:
+            logger.warning(f"Failed to read stored content for branch dedup: {e}")
+            return changed_files, 0
+        if not isinstance(content_points, list):
+            return changed_files, 0
+
+        changed_set = set(changed_files)
+        points_by_path: Dict[str, List[Dict[str, Any]]] = {}
+        for point in content_points:
+            path = point.get("payload", {}).get("path")
+            if path in changed_set:
+                points_by_path.setdefault(path, []).append(point)
+
+        files_to_index = []
+        points_to_update = []
+        reused = 0
+        for rel_path in changed_files:
+            stored = points_by_path.get(rel_path)
+            abs_path = self.config.codebase_dir / rel_path
+            if not stored or not abs_path.exists():
+                files_to_index.append(rel_path)
+                continue
+            file_hash = self.file_identifier._get_file_content_hash(abs_path)
+            if ":error-" in file_hash or any(
+                p.get("payload", {}).get("file_hash") != file_hash for p in stored
+            ):
+                files_to_index.append(rel_path)
+                continue
+            reused += len(stored)
+            for point in stored:
+                current_hidden = point.get("payload", {}).get("hidden_branches", [])
+                if branch in current_hidden:
+                    new_hidden = [b for b in current_hidden if b != branch]
+                    points_to_update.append(
+                        {"id": point["id"], "payload": {"hidden_branches": new_hidden}}
+                    )
+
+        if points_to_update:
+            with self._visibility_lock:
+                self.vector_store_client._batch_update_points(
+                    points_to_update, collection_name
+                )
+        if reused:
+            reused_files = len(changed_files) - len(files_to_index)
+            logger.info(
+                f"Branch dedup: reused {reused} stored points of "
+                f"{reused_files} files with unchanged content"
+            )
+        return files_to_index, reused
+
     def _batch_hide_files_in_branch(
         self,
         file_paths: List[str],
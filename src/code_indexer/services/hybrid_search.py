@@ -0,0 +1,91 @@
+"""
+Reciprocal rank fusion of full-text and semantic search results.
+
+Semantic search finds paraphrases but misses exact identifiers; the BM25
+full-text index (Tantivy, built with 'cidx index --fts') finds identifiers
+but not paraphrases. 'cidx query --hybrid' runs both and ranks the union by
+reciprocal rank fusion: a result at rank r in a list scores 1 / (k + r), and
+the scores of the lists a result appears in add up. Only ranks are used, so
+the incomparable BM25 and cosine scores never need normalizing.
+
+Results are fused per chunk: a full-text match inside a semantic result's
+line range counts for that chunk; other matches stand on their own line.
+Only the best-ranked match of a chunk counts.
+"""
+
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional, Tuple
+
+# Rank offset of reciprocal rank fusion (the usual value from the literature)
+RRF_K = 60
+
+
+@dataclass
+class FusedResult:
+    """One chunk or line in the fused ranking."""
+
+    path: str
+    line_start: int
+    line_end: int
+    score: float = 0.0
+    semantic: Optional[Dict[str, Any]] = None
+    semantic_rank: Optional[int] = None
+    fts_matches: List[Dict[str, Any]] = field(default_factory=list)
+    fts_rank: Optional[int] = None
+
+
+def fuse_results(
+    fts_results: List[Dict[str, Any]],
+    semantic_results: List[Dict[str, Any]],
+    limit: int,
+    k: int = RRF_K,
+) -> List[FusedResult]:
+    """
+    Rank the union of full-text and semantic results by reciprocal rank fusion.
+
+    Args:
+        fts_results: TantivyIndexManager.search() results, best first
+        semantic_results: Vector store search results, best first
+        limit: Number of fused results to return (0 for all)
+        k: Rank offset; larger values flatten the difference between ranks
+
+    Returns:
+        Fused results, best first
+    """
+    fused: Dict[Tuple[str, int, int], FusedResult] = {}
+    chunks_by_path: Dict[str, List[FusedResult]] = {}
+
+    for rank, result in enumerate(semantic_results, start=1):
+        payload = result.get("payload", {})
+        path = str(payload.get("path", ""))
+        line_start = int(payload.get("line_start") or 0)
+        line_end = int(payload.get("line_end") or line_start)
+        key = (path, line_start, line_end)
+        if key in fused:
+            continue
+        entry = FusedResult(
+            path, line_start, line_end, 1.0 / (k + rank), result, rank
+        )
+        fused[key] = entry
+        chunks_by_path.setdefault(path, []).append(entry)
+
+    for rank, match in enumerate(fts_results, start=1):
+        path = str(match.get("path", ""))
+        line = int(match.get("line") or 0)
+        entry = next(
+            (
+                chunk
+                for chunk in chunks_by_path.get(path, [])
+                if chunk.line_start <= line <= chunk.line_end
+            ),
+            None,
+        )
+        if entry is None:
+            entry = fused.setdefault((path, line, line), FusedResult(path, line, line))
+        entry.fts_matches.append(match)
+        if entry.fts_rank is None:
+            entry.fts_rank = rank
+            entry.score += 1.0 / (k + rank)
+
+    ranked = sorted(fused.values(), key=lambda entry: entry.score, reverse=True)
+    return ranked[:limit] if limit else ranked
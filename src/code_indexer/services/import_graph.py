@@ -0,0 +1,412 @@
+"""
+File import graph behind ``cidx imports``.
+
+When a file is indexed, its imports are read and, where they name code of
+the project, resolved to project paths:
+
+- Python: ``import a.b`` and ``from a.b import c`` to a/b.py, a/b/__init__.py
+  or a/b/c.py, anywhere under the project (src/ layouts included); relative
+  imports from the file's package
+- JavaScript/TypeScript: relative specifiers (``./cart``, ``../lib``) with
+  the usual extensions and index files
+- Go: import paths under one of the project's go.mod modules, to the package
+  directory
+- Java, Kotlin, Scala and Groovy: imported classes to their source files,
+  wildcard imports to the package directory
+- C and C++: ``#include "..."`` relative to the file or anywhere under the
+  project
+
+Every chunk of a file records in its payload:
+
+- "imports": the import specifiers of the file, as written
+- "imported_paths": the project files and package directories they resolve to
+
+``cidx imports --of pkg/service`` follows these edges from the index to the
+files a path depends on, and ``--reverse`` to the files that depend on it,
+directly or through other files. Imports are resolved when a file is indexed;
+run 'cidx index --clear' after moving files to refresh unchanged importers.
+"""
+
+import logging
+import os
+import posixpath
+import re
+import threading
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Set, Tuple
+
+from .chunk_context import imports_of
+from .go_dependencies import GoDependencyGraph, go_imports, is_standard_library
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload keys holding the imports of a chunk's file
+IMPORTS_KEY = "imports"
+IMPORTED_PATHS_KEY = "imported_paths"
+
+PYTHON_EXTENSIONS = (".py", ".pyi")
+JS_EXTENSIONS = (".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs")
+JVM_EXTENSIONS = (".java", ".kt", ".kts", ".scala", ".groovy")
+C_EXTENSIONS = (".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh", ".hxx")
+
+# Directories never holding project sources
+_SKIPPED_DIRS = {"node_modules", "vendor", "__pycache__", "venv", "build", "dist"}
+
+_PY_FROM = re.compile(
+    r"^[ \t]*from\s+(\.*[\w.]*)\s+import\s+(?:\(([^)]*)\)|([\w \t,]+))", re.M
+)
+_PY_IMPORT = re.compile(r"^[ \t]*import\s+([\w.]+(?:\s*,\s*[\w.]+)*)", re.M)
+_C_INCLUDE = re.compile(r'^\s*#\s*include\s+"([^"]+)"', re.M)
+
+
+def python_imports(text: str) -> List[Tuple[str, List[str]]]:
+    """(module, imported names) of a Python file; names are empty for import."""
+    imports: List[Tuple[str, List[str]]] = []
+    for module, grouped, names in _PY_FROM.findall(text):
+        names = re.sub(r"#.*", "", grouped or names)
+        imported = [n.split()[0] for n in names.split(",") if n.strip()]
+        imports.append((module, imported))
+    for modules in _PY_IMPORT.findall(text):
+        for module in modules.split(","):
+            imports.append((module.split()[0], []))
+    return imports
+
+
+class ImportGraph:
+    """Resolves the imports of a project's files to project paths."""
+
+    def __init__(self, codebase_dir: Path):
+        """
+        Initialize the graph; project files are listed on first use.
+
+        Args:
+            codebase_dir: Project root
+        """
+        self.codebase_dir = Path(codebase_dir).resolve()
+        self._lock = threading.Lock()
+        self._files: Optional[Set[str]] = None
+        self._dirs: Set[str] = set()
+        # File name without extension -> project paths without extension
+        self._stems: Dict[str, List[str]] = {}
+        self._go = GoDependencyGraph(self.codebase_dir)
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["ImportGraph"]:
+        """Graph from indexing.import_graph, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        graph_config = getattr(indexing_config, "import_graph", None)
+        if getattr(graph_config, "enabled", False) is not True:
+            return None
+        return cls(Path(config.codebase_dir))
+
+    def files(self) -> Set[str]:
+        """Project-relative paths of the project's files."""
+        with self._lock:
+            if self._files is None:
+                self._files = self._scan()
+            return self._files
+
+    def file_imports(
+        self, relative_path: str, text: str
+    ) -> Tuple[List[str], List[str]]:
+        """
+        Imports of a file and the project paths they resolve to.
+
+        Args:
+            relative_path: Project-relative path of the file
+            text: File text
+
+        Returns:
+            (import specifiers as written, resolved files and directories)
+        """
+        extension = posixpath.splitext(relative_path)[1].lower()
+        directory = posixpath.dirname(relative_path)
+        imports: List[str] = []
+        resolved: List[Optional[str]] = []
+        if extension in PYTHON_EXTENSIONS:
+            for module, names in python_imports(text):
+                imports.append(module)
+                resolved.extend(self._python(module, names, directory))
+        elif extension in JS_EXTENSIONS:
+            specifiers, _ = imports_of(text.split("\n"), extension[1:])
+            for specifier in specifiers:
+                imports.append(specifier)
+                if specifier.startswith("."):
+                    resolved.append(self._relative(directory, specifier))
+        elif extension == ".go":
+            for import_path in go_imports(text):
+                if not is_standard_library(import_path):
+                    imports.append(import_path)
+                    resolved.append(self._go_package(import_path))
+        elif extension in JVM_EXTENSIONS:
+            specifiers, _ = imports_of(text.split("\n"), extension[1:])
+            for specifier in specifiers:
+                imports.append(specifier)
+                resolved.append(self._jvm(specifier))
+        elif extension in C_EXTENSIONS:
+            for header in _C_INCLUDE.findall(text):
+                imports.append(header)
+                beside = posixpath.normpath(posixpath.join(directory, header))
+                resolved.append(self._existing(beside) or self._by_suffix(header))
+        paths = (p for p in resolved if p and p != relative_path)
+        return list(dict.fromkeys(imports)), list(dict.fromkeys(paths))
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """
+        Return the chunks with the file's imports under IMPORTS_KEY and their
+        project paths under IMPORTED_PATHS_KEY.
+        """
+        if not chunks:
+            return chunks
+        try:
+            relative = Path(file_path).resolve().relative_to(self.codebase_dir)
+            text = Path(file_path).read_text(encoding="utf-8", errors="replace")
+        except (OSError, ValueError):
+            return chunks
+        imports, paths = self.file_imports(relative.as_posix(), text)
+        if not imports:
+            return chunks
+        payload: Dict[str, Any] = {IMPORTS_KEY: imports}
+        if paths:
+            payload[IMPORTED_PATHS_KEY] = paths
+        return [{**chunk, **payload} for chunk in chunks]
+
+    def _python(
+        self, module: str, names: List[str], directory: str
+    ) -> List[Optional[str]]:
+        dots = len(module) - len(module.lstrip("."))
+        parts = [p for p in module[dots:].split(".") if p]
+        if dots:
+            base = directory
+            for _ in range(dots - 1):
+                base = posixpath.dirname(base)
+            stem = posixpath.join(base, *parts) if parts else base
+            find = self._existing
+        else:
+            stem = "/".join(parts)
+            find = self._by_suffix
+        resolved = []
+        # from package import submodule
+        for name in names:
+            resolved.append(self._python_module(f"{stem}/{name}", find))
+        if not any(resolved):
+            resolved.append(self._python_module(stem, find))
+        return resolved
+
+    def _python_module(self, stem: str, find: Any) -> Optional[str]:
+        for candidate in (f"{stem}.py", f"{stem}.pyi", f"{stem}/__init__.py"):
+            found = find(candidate.lstrip("/"))
+            if found:
+                return found
+        return None
+
+    def _relative(self, directory: str, specifier: str) -> Optional[str]:
+        path = posixpath.normpath(posixpath.join(directory, specifier))
+        candidates = [path]
+        candidates.extend(path + extension for extension in JS_EXTENSIONS)
+        candidates.extend(f"{path}/index{extension}" for extension in JS_EXTENSIONS)
+        for candidate in candidates:
+            found = self._existing(candidate)
+            if found:
+                return found
+        return None
+
+    def _go_package(self, import_path: str) -> Optional[str]:
+        for module in self._go.modules():
+            if import_path == module.path or import_path.startswith(module.path + "/"):
+                package = import_path[len(module.path) :].lstrip("/")
+                return posixpath.join(module.dir, package).rstrip("/") or "."
+        return None
+
+    def _jvm(self, specifier: str) -> Optional[str]:
+        if specifier.endswith(".*"):
+            return self._by_suffix(specifier[:-2].replace(".", "/"), directory=True)
+        parts = specifier.split(".")
+        # com.shop.Cart, or com.shop.Cart.Inner and static members
+        for end in range(len(parts), 1, -1):
+            stem = "/".join(parts[:end])
+            for extension in JVM_EXTENSIONS:
+                found = self._by_suffix(stem + extension)
+                if found:
+                    return found
+        return None
+
+    def _existing(self, path: str) -> Optional[str]:
+        return path if path in self.files() else None
+
+    def _by_suffix(self, path: str, directory: bool = False) -> Optional[str]:
+        """Shortest project path ending with path, at a path boundary."""
+        files = self.files()
+        if directory:
+            matches = [
+                d for d in self._dirs if d == path or d.endswith("/" + path)
+            ]
+        else:
+            stem, extension = posixpath.splitext(path)
+            matches = [
+                candidate + extension
+                for candidate in self._stems.get(posixpath.basename(stem), [])
+                if (candidate == stem or candidate.endswith("/" + stem))
+                and candidate + extension in files
+            ]
+        return min(matches, key=lambda p: (len(p), p)) if matches else None
+
+    def _scan(self) -> Set[str]:
+        files: Set[str] = set()
+        for root, dirs, names in os.walk(self.codebase_dir):
+            dirs[:] = sorted(
+                d for d in dirs if not d.startswith(".") and d not in _SKIPPED_DIRS
+            )
+            relative = Path(root).relative_to(self.codebase_dir).as_posix()
+            if relative != ".":
+                self._dirs.add(relative)
+            for name in names:
+                path = name if relative == "." else f"{relative}/{name}"
+                files.add(path)
+                stem = posixpath.splitext(path)[0]
+                self._stems.setdefault(posixpath.basename(stem), []).append(stem)
+        return files
+
+
+@dataclass
+class ImportEdge:
+    """A project path reached from the traced path through imports."""
+
+    path: str
+    # Import hops from the traced path (1 for direct imports)
+    depth: int
+    # Paths this one was reached through at the previous depth
+    via: List[str] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class ImportTrace:
+    """Paths a project path depends on, or that depend on it."""
+
+    of: str
+    reverse: bool
+    # Indexed files under the traced path
+    files: List[str] = field(default_factory=list)
+    edges: List[ImportEdge] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def load_imports(
+    vector_store: Any,
+    project_root: Path,
+    collections: Optional[Iterable[str]] = None,
+) -> Dict[str, Dict[str, List[str]]]:
+    """
+    Read the imports recorded in the index.
+
+    Args:
+        vector_store: FilesystemVectorStore holding the collections
+        project_root: Git working tree the collections were indexed from
+        collections: Content collections to read (default: all but git history)
+
+    Returns:
+        Per indexed file path, its IMPORTS_KEY and IMPORTED_PATHS_KEY lists
+    """
+    from ..storage.temporal_metadata_store import TemporalMetadataStore
+    from ..utils.git_runner import get_current_branch
+
+    if collections is None:
+        collections = [
+            name
+            for name in vector_store.list_collections()
+            if not TemporalMetadataStore.is_temporal_collection(name)
+        ]
+    current_branch = get_current_branch(project_root)
+
+    files: Dict[str, Dict[str, List[str]]] = {}
+    for collection_name in collections:
+        for _, data, _ in vector_store.iter_vector_records(collection_name):
+            payload = (data or {}).get("payload", {})
+            path = str(payload.get("path", ""))
+            if not path or path in files:
+                continue
+            if current_branch and current_branch in payload.get("hidden_branches", []):
+                continue
+            files[path] = {
+                IMPORTS_KEY: list(payload.get(IMPORTS_KEY) or []),
+                IMPORTED_PATHS_KEY: list(payload.get(IMPORTED_PATHS_KEY) or []),
+            }
+    return files
+
+
+def _under(path: str, prefix: str) -> bool:
+    return prefix in ("", ".") or path == prefix or path.startswith(prefix + "/")
+
+
+def trace_imports(
+    files: Dict[str, Dict[str, List[str]]],
+    of: str,
+    reverse: bool = False,
+    max_depth: Optional[int] = None,
+) -> ImportTrace:
+    """
+    Follow import edges from the indexed files under a path.
+
+    Args:
+        files: Imports per indexed file, as returned by load_imports
+        of: Project-relative file or directory
+        reverse: Follow edges to importers ("what depends on this")
+            instead of imported paths ("what this depends on")
+        max_depth: Import hops to follow (default: all)
+
+    Returns:
+        The traced files and the paths reached, by depth and then path
+    """
+    of = posixpath.normpath(of.strip().strip("/")) if of.strip("/ ") else "."
+    traced = sorted(path for path in files if _under(path, of))
+    trace = ImportTrace(of=of, reverse=reverse, files=traced)
+
+    # Package directories (Go packages, Java wildcard imports) stand for
+    # the files directly in them
+    by_dir: Dict[str, List[str]] = {}
+    for path in files:
+        by_dir.setdefault(posixpath.dirname(path) or ".", []).append(path)
+    importers: Dict[str, Set[str]] = {}
+    for path, imports in files.items():
+        for target in imports[IMPORTED_PATHS_KEY]:
+            importers.setdefault(target, set()).add(path)
+
+    def step(path: str) -> Set[str]:
+        if reverse:
+            directory = posixpath.dirname(path) or "."
+            return importers.get(path, set()) | importers.get(directory, set())
+        if path in files:
+            return set(files[path][IMPORTED_PATHS_KEY])
+        # Imports between the files of a package are not dependencies of it
+        return {
+            target
+            for source in by_dir.get(path, [])
+            for target in files[source][IMPORTED_PATHS_KEY]
+            if target != path and (posixpath.dirname(target) or ".") != path
+        }
+
+    reached: Dict[str, ImportEdge] = {}
+    frontier = traced
+    depth = 0
+    while frontier and (max_depth is None or depth < max_depth):
+        depth += 1
+        following: Dict[str, List[str]] = {}
+        for source in frontier:
+            for target in step(source):
+                if _under(target, of) or target in reached:
+                    continue
+                following.setdefault(target, []).append(source)
+        for target, sources in following.items():
+            reached[target] = ImportEdge(target, depth, sorted(sources))
+        frontier = sorted(following)
+
+    trace.edges = sorted(reached.values(), key=lambda e: (e.depth, e.path))
+    return trace
@@ -0,0 +1,389 @@
+"""
+End-to-end index consistency checks behind ``cidx verify``.
+
+Cross-checks the layers of a local index that are expected to agree:
+
+- tracked files vs indexed chunks: every file cidx would index has a complete
+  set of chunks visible on the current branch, and no chunks stay visible for
+  files that were deleted or are no longer indexed
+- branch visibility: at most one version of a file is visible on the current
+  branch, and hidden_branches is well-formed
+- collection point counts: the vector files on disk agree with the derived
+  indexes (id_index.bin, path_index.bin, the HNSW id mapping) and with the
+  unique_file_count shown by ``cidx status``
+
+Repair rebuilds drifted derived indexes from the vector files, removes
+orphaned files from the current branch and re-indexes files whose chunks are
+missing or inconsistent - the same operations incremental indexing uses.
+"""
+
+import json
+import logging
+from collections import defaultdict
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Callable, Dict, Iterable, List, Optional, Set
+
+from ..storage.vector_kinds import VECTOR_KIND_KEY
+
+logger = logging.getLogger(__name__)
+
+# Issue kinds
+ISSUE_UNREADABLE = "unreadable"  # Vector file cannot be read or decoded
+ISSUE_MISSING_FILE = "missing_file"  # Tracked file without visible chunks
+ISSUE_ORPHANED_POINTS = "orphaned_points"  # Visible chunks of an untracked file
+ISSUE_INCOMPLETE_FILE = "incomplete_file"  # Chunks missing or duplicated
+ISSUE_DUPLICATE_VERSIONS = "duplicate_versions"  # Several versions visible
+ISSUE_INVALID_VISIBILITY = "invalid_visibility"  # Malformed hidden_branches
+ISSUE_INDEX_DRIFT = "index_drift"  # Derived index disagrees with vector files
+
+# Issues fixed by re-indexing the file
+REINDEX_KINDS = (
+    ISSUE_MISSING_FILE,
+    ISSUE_INCOMPLETE_FILE,
+    ISSUE_DUPLICATE_VERSIONS,
+    ISSUE_INVALID_VISIBILITY,
+)
+
+
+@dataclass
+class ConsistencyIssue:
+    """One inconsistency between index layers."""
+
+    kind: str
+    path: str
+    detail: str = ""
+    point_count: int = 0
+
+
+@dataclass
+class CollectionConsistency:
+    """Consistency result of one collection."""
+
+    collection: str
+    points_on_disk: int = 0
+    files_indexed: int = 0  # Files with chunks visible on the current branch
+    project_checked: bool = False  # Tracked files were cross-checked
+    issues: List[ConsistencyIssue] = field(default_factory=list)
+
+    @property
+    def ok(self) -> bool:
+        return not self.issues
+
+    def paths(self, *kinds: str) -> List[str]:
+        """Paths of the issues of the given kinds."""
+        return sorted({i.path for i in self.issues if i.kind in kinds})
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = asdict(self)
+        data["ok"] = self.ok
+        return data
+
+
+@dataclass
+class ConsistencyRepair:
+    """What a repair run changed."""
+
+    indexes_rebuilt: List[str] = field(default_factory=list)
+    files_removed: int = 0
+    files_reindexed: int = 0
+    errors: List[str] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class IndexConsistencyChecker:
+    """Cross-checks tracked files, indexed chunks and derived indexes."""
+
+    def __init__(
+        self,
+        vector_store: Any,
+        tracked_files: Iterable[str],
+        current_branch: str,
+        project_root: Path,
+    ):
+        """
+        Initialize the checker.
+
+        Args:
+            vector_store: FilesystemVectorStore holding the collections
+            tracked_files: Paths cidx would index, relative to project_root
+            current_branch: Branch whose visibility is checked
+            project_root: Project root that chunk paths are relative to
+        """
+        self.vector_store = vector_store
+        self.tracked_files = set(tracked_files)
+        self.current_branch = current_branch
+        self.project_root = project_root
+
+    def check_collection(
+        self, collection_name: str, check_project: bool = False
+    ) -> CollectionConsistency:
+        """
+        Check one collection.
+
+        Args:
+            collection_name: Name of the collection
+            check_project: Also cross-check against the tracked files; only
+                meaningful for the collection the project is indexed into
+
+        Returns:
+            CollectionConsistency listing every inconsistency found
+        """
+        result = CollectionConsistency(
+            collection=collection_name, project_checked=check_project
+        )
+        point_files: Dict[str, Path] = {}
+        point_paths: Dict[str, Optional[str]] = {}
+        content_by_path: Dict[str, List[Dict[str, Any]]] = defaultdict(list)
+
+        for vector_file, data, error in self.vector_store.iter_vector_records(
+            collection_name
+        ):
+            result.points_on_disk += 1
+            if data is None:
+                result.issues.append(
+                    ConsistencyIssue(ISSUE_UNREADABLE, str(vector_file), error or "", 1)
+                )
+                continue
+            point_id = str(data.get("id", ""))
+            payload = data.get("payload", {})
+            point_files[point_id] = vector_file
+            point_paths[point_id] = payload.get("path")
+            if (
+                payload.get("type", "content") == "content"
+                and payload.get("path")
+                and VECTOR_KIND_KEY not in payload
+            ):
+                # Signature points repeat the chunks of their body points
+                content_by_path[payload["path"]].append(payload)
+
+        result.issues.extend(
+            self._check_derived_indexes(collection_name, point_files, point_paths)
+        )
+        if check_project:
+            result.files_indexed = self._check_files(content_by_path, result.issues)
+        return result
+
+    def repair(
+        self,
+        results: List[CollectionConsistency],
+        reindex_files: Callable[[List[str]], Any],
+        remove_file: Callable[[str], Any],
+    ) -> ConsistencyRepair:
+        """
+        Repair the issues found by check_collection().
+
+        Derived indexes are rebuilt first so the file-level repairs below work
+        on accurate indexes.
+
+        Args:
+            results: Results of check_collection()
+            reindex_files: Re-indexes the given paths (incremental indexing)
+            remove_file: Removes a path from the current branch (branch-aware
+                deletion)
+
+        Returns:
+            ConsistencyRepair describing the changes
+        """
+        repair = ConsistencyRepair()
+        for result in results:
+            if not any(i.kind == ISSUE_INDEX_DRIFT for i in result.issues):
+                continue
+            try:
+                self.vector_store.rebuild_indexes(result.collection)
+                repair.indexes_rebuilt.append(result.collection)
+            except Exception as e:
+                repair.errors.append(f"{result.collection}: {e}")
+
+        for result in results:
+            for path in result.paths(ISSUE_ORPHANED_POINTS):
+                try:
+                    remove_file(path)
+                    repair.files_removed += 1
+                except Exception as e:
+                    repair.errors.append(f"{path}: {e}")
+
+            paths = result.paths(*REINDEX_KINDS)
+            if paths:
+                try:
+                    reindex_files(paths)
+                    repair.files_reindexed += len(paths)
+                except Exception as e:
+                    repair.errors.append(f"{result.collection}: {e}")
+        return repair
+
+    def _check_files(
+        self,
+        content_by_path: Dict[str, List[Dict[str, Any]]],
+        issues: List[ConsistencyIssue],
+    ) -> int:
+        """Check visible chunks against tracked files; returns files indexed."""
+        visible_paths: Set[str] = set()
+        for path, payloads in sorted(content_by_path.items()):
+            visible = []
+            for payload in payloads:
+                hidden = payload.get("hidden_branches", [])
+                if not isinstance(hidden, list) or not all(
+                    isinstance(b, str) for b in hidden
+                ):
+                    issues.append(
+                        ConsistencyIssue(
+                            ISSUE_INVALID_VISIBILITY,
+                            path,
+                            f"hidden_branches is {hidden!r}",
+                            1,
+                        )
+                    )
+                    continue
+                if self.current_branch not in hidden:
+                    visible.append(payload)
+            if not visible:
+                continue
+
+            visible_paths.add(path)
+            if path not in self.tracked_files:
+                issues.append(
+                    ConsistencyIssue(
+                        ISSUE_ORPHANED_POINTS,
+                        path,
+                        "file is deleted or no longer indexed",
+                        len(visible),
+                    )
+                )
+                continue
+            issue = _check_chunks(path, visible)
+            if issue is not None:
+                issues.append(issue)
+
+        for path in sorted(self.tracked_files - visible_paths):
+            if self._is_blank(path):
+                continue  # The chunker yields no chunks for blank files
+            issues.append(
+                ConsistencyIssue(
+                    ISSUE_MISSING_FILE,
+                    path,
+                    f"no chunks visible on branch '{self.current_branch}'",
+                )
+            )
+        return len(visible_paths)
+
+    def _check_derived_indexes(
+        self,
+        collection_name: str,
+        point_files: Dict[str, Path],
+        point_paths: Dict[str, Optional[str]],
+    ) -> List[ConsistencyIssue]:
+        """Compare id_index.bin, path_index.bin and HNSW with the vector files."""
+        from ..storage.filesystem_vector_store import PathIndex
+        from ..storage.id_index_manager import IDIndexManager
+
+        collection_path = self.vector_store.base_path / collection_name
+        issues: List[ConsistencyIssue] = []
+
+        def drift(index_name: str, missing: int, stale: int) -> None:
+            if missing or stale:
+                issues.append(
+                    ConsistencyIssue(
+                        ISSUE_INDEX_DRIFT,
+                        index_name,
+                        f"{missing} points missing, {stale} stale entries",
+                        missing + stale,
+                    )
+                )
+
+        on_disk = set(point_files)
+        # Without id_index.bin the store falls back to scanning vector files
+        if (collection_path / IDIndexManager.INDEX_FILENAME).exists():
+            id_index = IDIndexManager().load_index(collection_path)
+            drift(
+                IDIndexManager.INDEX_FILENAME,
+                len(on_disk - set(id_index)),
+                len(set(id_index) - on_disk),
+            )
+
+        path_index_file = collection_path / "path_index.bin"
+        if path_index_file.exists():
+            path_index = PathIndex.load(path_index_file)
+            indexed = {
+                (path, point_id)
+                for path in path_index.file_paths()
+                for point_id in path_index.get_point_ids(path)
+            }
+            expected = {
+                (path, point_id) for point_id, path in point_paths.items() if path
+            }
+            drift(
+                path_index_file.name,
+                len(expected - indexed),
+                len(indexed - expected),
+            )
+
+        metadata = _read_metadata(collection_path)
+        hnsw = metadata.get("hnsw_index")
+        if hnsw and not hnsw.get("is_stale", True):
+            # Incremental updates only mark deleted labels, so extra entries in
+            # the mapping are expected; points missing from it are not searchable
+            mapped = set(hnsw.get("id_mapping", {}).values())
+            drift("HNSW index", len(on_disk - mapped), 0)
+
+        if "unique_file_count" in metadata:
+            actual = len({path for path in point_paths.values() if path})
+            recorded = metadata["unique_file_count"]
+            if recorded != actual:
+                issues.append(
+                    ConsistencyIssue(
+                        ISSUE_INDEX_DRIFT,
+                        "unique_file_count",
+                        f"metadata records {recorded} files, vectors cover {actual}",
+                        abs(actual - recorded),
+                    )
+                )
+        return issues
+
+    def _is_blank(self, path: str) -> bool:
+        try:
+            raw = (self.project_root / path).read_bytes()
+        except OSError:
+            return False
+        return not raw.strip()
+
+
+def _check_chunks(
+    path: str, visible: List[Dict[str, Any]]
+) -> Optional[ConsistencyIssue]:
+    """Check that the visible chunks of a file form one complete version."""
+    versions = {p.get("file_hash") for p in visible if p.get("file_hash")}
+    if len(versions) > 1:
+        return ConsistencyIssue(
+            ISSUE_DUPLICATE_VERSIONS,
+            path,
+            f"{len(versions)} versions visible",
+            len(visible),
+        )
+
+    total_chunks = visible[0].get("total_chunks")
+    if not isinstance(total_chunks, int):
+        return None  # Indexed before chunk positions were recorded
+    indices = [p.get("chunk_index") for p in visible]
+    missing = set(range(total_chunks)) - set(indices)
+    duplicated = len(indices) - len(set(indices))
+    if not missing and not duplicated and len(indices) == total_chunks:
+        return None
+    return ConsistencyIssue(
+        ISSUE_INCOMPLETE_FILE,
+        path,
+        f"{len(indices)} of {total_chunks} chunks visible "
+        f"({len(missing)} missing, {duplicated} duplicated)",
+        len(visible),
+    )
+
+
+def _read_metadata(collection_path: Path) -> Dict[str, Any]:
+    try:
+        with open(collection_path / "collection_meta.json") as f:
+            metadata: Dict[str, Any] = json.load(f)
+        return metadata
+    except (OSError, json.JSONDecodeError):
+        return {}
@@ -0,0 +1,276 @@
+"""
+Retention purge of indexed points behind ``cidx purge``.
+
+Points are purged when they match every given criterion:
+
+- older_than: git history points (temporal collection) by commit date,
+  content points by the time they were indexed
+- branches: content points indexed on a branch that has since been merged
+  into the current branch ("merged") or no longer exists ("deleted")
+
+Content that is visible on the current branch is never purged - it mirrors
+files in the working tree, and the next ``cidx index`` would re-add it
+anyway. Only content hidden on the current branch (older versions of changed
+files, files of other branches) is eligible. Git history points have no
+branch scope, so the branches criterion leaves them alone.
+
+Purged history is not re-indexed: incremental temporal indexing only picks up
+commits after the last indexed one.
+"""
+
+import logging
+import re
+import subprocess
+import time
+from dataclasses import asdict, dataclass, field
+from datetime import datetime
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Set, Tuple
+
+from ..storage.temporal_metadata_store import TemporalMetadataStore
+from ..utils.git_runner import get_current_branch, run_git_command
+
+logger = logging.getLogger(__name__)
+
+# Constants
+DURATION_UNITS = {"h": 3600, "d": 86400, "w": 7 * 86400, "y": 365 * 86400}
+BRANCH_FILTERS = ("merged", "deleted")
+DELETE_BATCH_SIZE = 1000
+GIT_TIMEOUT_SECONDS = 30
+
+_DURATION_PATTERN = re.compile(r"^\s*(\d+)\s*([hdwy])\s*$", re.IGNORECASE)
+
+
+def parse_duration(text: str) -> float:
+    """
+    Parse a retention window such as "90d", "12w", "36h" or "1y".
+
+    Returns:
+        Window length in seconds
+
+    Raises:
+        ValueError: If the text is not a positive number of hours/days/weeks/years
+    """
+    match = _DURATION_PATTERN.match(text)
+    if not match or int(match.group(1)) <= 0:
+        raise ValueError(
+            f"Invalid duration '{text}': expected e.g. 90d, 12w, 36h or 1y"
+        )
+    return int(match.group(1)) * DURATION_UNITS[match.group(2).lower()]
+
+
+@dataclass
+class PurgeCriteria:
+    """What to purge; a point must match every criterion that is set."""
+
+    older_than_seconds: Optional[float] = None
+    branches: Optional[str] = None  # One of BRANCH_FILTERS
+    now: float = field(default_factory=time.time)
+
+    def __post_init__(self):
+        if self.older_than_seconds is None and self.branches is None:
+            raise ValueError("Purge needs an age and/or a branches criterion")
+        if self.branches is not None and self.branches not in BRANCH_FILTERS:
+            raise ValueError(
+                f"Unknown branches filter '{self.branches}' "
+                f"(expected one of {', '.join(BRANCH_FILTERS)})"
+            )
+
+    @property
+    def cutoff(self) -> Optional[float]:
+        """Unix time before which points are too old, if age matters."""
+        if self.older_than_seconds is None:
+            return None
+        return self.now - self.older_than_seconds
+
+
+@dataclass
+class CollectionPurgeReport:
+    """Points purged (or, in a dry run, to be purged) from one collection."""
+
+    collection: str
+    points_scanned: int = 0
+    points_purged: int = 0
+    files_affected: int = 0
+    by_branch: Dict[str, int] = field(default_factory=dict)
+
+
+@dataclass
+class PurgeReport:
+    """Result of a purge across collections."""
+
+    dry_run: bool
+    collections: List[CollectionPurgeReport] = field(default_factory=list)
+
+    @property
+    def points_purged(self) -> int:
+        return sum(c.points_purged for c in self.collections)
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = asdict(self)
+        data["points_purged"] = self.points_purged
+        return data
+
+
+class IndexPurger:
+    """Deletes points past retention from a project's collections."""
+
+    def __init__(self, vector_store: Any, project_root: Path):
+        """
+        Initialize the purger.
+
+        Args:
+            vector_store: FilesystemVectorStore holding the collections
+            project_root: Git working tree the collections were indexed from
+        """
+        self.vector_store = vector_store
+        self.project_root = project_root
+
+    def purge(
+        self,
+        criteria: PurgeCriteria,
+        collections: Optional[List[str]] = None,
+        dry_run: bool = False,
+    ) -> PurgeReport:
+        """
+        Purge matching points.
+
+        Args:
+            criteria: What to purge
+            collections: Collections to purge (default: all)
+            dry_run: Only report what would be purged
+
+        Returns:
+            PurgeReport with per-collection counts
+        """
+        if collections is None:
+            collections = self.vector_store.list_collections()
+
+        branch_state = self._branch_state()
+        report = PurgeReport(dry_run=dry_run)
+        for collection_name in collections:
+            collection_report, point_ids = self._select_points(
+                collection_name, criteria, branch_state
+            )
+            if point_ids and not dry_run:
+                self._delete_points(collection_name, point_ids)
+            report.collections.append(collection_report)
+        return report
+
+    def _select_points(
+        self,
+        collection_name: str,
+        criteria: PurgeCriteria,
+        branch_state: Dict[str, Any],
+    ) -> Tuple[CollectionPurgeReport, List[str]]:
+        report = CollectionPurgeReport(collection=collection_name)
+        temporal = TemporalMetadataStore.is_temporal_collection(collection_name)
+        point_ids: List[str] = []
+        files: Set[str] = set()
+
+        for _, data, _ in self.vector_store.iter_vector_records(collection_name):
+            if data is None:
+                continue
+            report.points_scanned += 1
+            payload = data.get("payload", {})
+            if temporal:
+                eligible = self._history_point_matches(payload, criteria)
+            else:
+                eligible = self._content_point_matches(payload, criteria, branch_state)
+            if not eligible:
+                continue
+
+            point_ids.append(str(data["id"]))
+            files.add(str(payload.get("path", "")))
+            branch = str(payload.get("git_branch") or "")
+            report.by_branch[branch] = report.by_branch.get(branch, 0) + 1
+
+        report.points_purged = len(point_ids)
+        report.files_affected = len(files)
+        return report, point_ids
+
+    @staticmethod
+    def _history_point_matches(
+        payload: Dict[str, Any], criteria: PurgeCriteria
+    ) -> bool:
+        if criteria.branches is not None or criteria.cutoff is None:
+            return False
+        commit_timestamp = payload.get("commit_timestamp")
+        if not isinstance(commit_timestamp, (int, float)):
+            return False
+        return commit_timestamp < criteria.cutoff
+
+    @staticmethod
+    def _content_point_matches(
+        payload: Dict[str, Any],
+        criteria: PurgeCriteria,
+        branch_state: Dict[str, Any],
+    ) -> bool:
+        current_branch = branch_state["current"]
+        if current_branch is None:
+            return False  # Not a git project: everything indexed is current
+        if current_branch not in payload.get("hidden_branches", []):
+            return False
+
+        if criteria.cutoff is not None:
+            indexed_at = _indexed_time(payload)
+            if indexed_at is None or indexed_at >= criteria.cutoff:
+                return False
+
+        if criteria.branches is not None:
+            branch = payload.get("git_branch")
+            if not branch or branch == current_branch:
+                return False
+            if criteria.branches == "merged":
+                return bool(branch in branch_state["merged"])
+            return bool(branch not in branch_state["existing"])
+
+        return True
+
+    def _branch_state(self) -> Dict[str, Any]:
+        """Current branch plus merged and existing local branch names."""
+        return {
+            "current": get_current_branch(self.project_root),
+            "merged": self._git_branches("--merged", "HEAD"),
+            "existing": self._git_branches(),
+        }
+
+    def _git_branches(self, *args: str) -> Set[str]:
+        try:
+            result = run_git_command(
+                ["git", "branch", "--format=%(refname:short)", *args],
+                cwd=self.project_root,
+                check=True,
+                timeout=GIT_TIMEOUT_SECONDS,
+            )
+        except (OSError, subprocess.SubprocessError) as e:
+            logger.debug(f"Could not list git branches: {e}")
+            return set()
+        return {line.strip() for line in result.stdout.splitlines() if line.strip()}
+
+    def _delete_points(self, collection_name: str, point_ids: List[str]) -> None:
+        """Delete points, updating the HNSW and ID indexes incrementally."""
+        self.vector_store.begin_indexing(collection_name)
+        try:
+            for start in range(0, len(point_ids), DELETE_BATCH_SIZE):
+                self.vector_store.delete_points(
+                    collection_name, point_ids[start : start + DELETE_BATCH_SIZE]
+                )
+        finally:
+            self.vector_store.end_indexing(collection_name)
+        logger.info(f"Purged {len(point_ids)} points from {collection_name}")
+
+
+def _indexed_time(payload: Dict[str, Any]) -> Optional[float]:
+    """Unix time a content point was indexed, if recorded."""
+    indexed_timestamp = payload.get("indexed_timestamp")
+    if isinstance(indexed_timestamp, (int, float)):
+        return float(indexed_timestamp)
+    indexed_at = payload.get("indexed_at")
+    if isinstance(indexed_at, str):
+        try:
+            # Older writers append "Z" to an already offset-qualified timestamp
+            return datetime.fromisoformat(indexed_at.rstrip("Z")).timestamp()
+        except ValueError:
+            return None
+    return None
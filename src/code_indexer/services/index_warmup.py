@@ -0,0 +1,209 @@
+"""
+Warm-cache preloading for first-query latency.
+
+The first query of a session is typically an order of magnitude slower than the
+ones after it: index files come from disk instead of the OS page cache, the
+embedding provider's tokenizer has to be loaded, and the query modules
+(numpy, hnswlib, httpx) are imported on demand.
+
+``warm_project_index()`` pays these costs up front so ``cidx start --preload``
+(and the server at startup) can hide them from the first query:
+
+- Reads HNSW/ID/path indexes and collection metadata, then as many vector files
+  as the byte budget allows, so later reads hit the page cache
+- Optionally loads HNSW indexes into the server's HNSW index cache
+- Imports the query path modules and primes the embedding provider (no API call)
+"""
+
+import logging
+import time
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Optional
+
+logger = logging.getLogger(__name__)
+
+# Constants
+DEFAULT_WARMUP_MAX_BYTES = 512 * 1024 * 1024  # Page cache budget per project
+WARMUP_READ_CHUNK_BYTES = 1024 * 1024
+
+# Read before vector files - every query touches these
+INDEX_FILE_NAMES = (
+    "collection_meta.json",
+    "hnsw_index.bin",
+    "id_index.bin",
+    "path_index.bin",
+    "projection_matrix.npy",
+)
+
+
+@dataclass
+class WarmupResult:
+    """Summary of a warm-up run."""
+
+    project_root: str
+    collections: List[str] = field(default_factory=list)
+    files_warmed: int = 0
+    bytes_warmed: int = 0
+    budget_exhausted: bool = False
+    hnsw_indexes_cached: int = 0
+    embedding_primed: bool = False
+    errors: List[str] = field(default_factory=list)
+    elapsed_seconds: float = 0.0
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def get_collection_paths(project_root: Path) -> List[Path]:
+    """Collection directories under the project's index, sorted by name."""
+    index_dir = project_root / ".code-indexer" / "index"
+    if not index_dir.is_dir():
+        return []
+    return sorted(
+        path
+        for path in index_dir.iterdir()
+        if path.is_dir() and (path / "collection_meta.json").exists()
+    )
+
+
+def iter_warmup_files(collection_paths: List[Path]) -> Iterator[Path]:
+    """Index files of every collection first, then vector files."""
+    for collection_path in collection_paths:
+        for name in INDEX_FILE_NAMES:
+            path = collection_path / name
+            if path.is_file():
+                yield path
+
+    for collection_path in collection_paths:
+        yield from sorted(collection_path.rglob("vector_*.json"))
+
+
+def warm_file(path: Path, buffer: bytearray) -> int:
+    """Read a file through the page cache, returning bytes read."""
+    total = 0
+    view = memoryview(buffer)
+    with open(path, "rb", buffering=0) as f:
+        while True:
+            read = f.readinto(view)
+            if not read:
+                break
+            total += read
+    return total
+
+
+def warm_page_cache(
+    files: Iterator[Path], max_bytes: int, result: WarmupResult
+) -> None:
+    """Read files until the byte budget is used up, updating result in place."""
+    buffer = bytearray(WARMUP_READ_CHUNK_BYTES)
+    for path in files:
+        if result.bytes_warmed >= max_bytes:
+            result.budget_exhausted = True
+            return
+        try:
+            result.bytes_warmed += warm_file(path, buffer)
+            result.files_warmed += 1
+        except OSError as e:
+            # File replaced by a concurrent index run - skip it
+            logger.debug(f"Warm-up skipped {path}: {e}")
+
+
+def load_hnsw_into_cache(collection_path: Path, hnsw_cache: Any) -> None:
+    """
+    Load a collection's HNSW index into the server HNSW index cache.
+
+    Uses the same cache key and loader as FilesystemVectorStore.search() so
+    the first query is a cache hit.
+    """
+    import json
+
+    from ..storage.hnsw_index_manager import HNSWIndexManager
+
+    with open(collection_path / "collection_meta.json", "r") as f:
+        vector_dim = json.load(f).get("vector_size", 1536)
+    hnsw_manager = HNSWIndexManager(vector_dim=vector_dim, space="cosine")
+
+    def hnsw_loader():
+        index = hnsw_manager.load_index(collection_path, max_elements=100000)
+        id_mapping = hnsw_manager._load_id_mapping(collection_path)
+        return index, id_mapping
+
+    hnsw_cache.get_or_load(str(collection_path.resolve()), hnsw_loader)
+
+
+def prime_embedding_provider(config: Any) -> None:
+    """Create the configured embedding provider and load its local resources."""
+    from .embedding_factory import EmbeddingProviderFactory
+
+    provider = EmbeddingProviderFactory.create(config=config)
+    provider.warm_up()
+
+
+def warm_project_index(
+    project_root: Path,
+    config: Optional[Any] = None,
+    max_bytes: int = DEFAULT_WARMUP_MAX_BYTES,
+    hnsw_cache: Optional[Any] = None,
+    prime_embedding: bool = True,
+) -> WarmupResult:
+    """
+    Warm caches for a project's index so the first query is fast.
+
+    Best-effort: failures are recorded in WarmupResult.errors, never raised.
+
+    Args:
+        project_root: Project (or golden repo index) root containing .code-indexer
+        config: Loaded Config; discovered from project_root when None
+        max_bytes: Page cache budget; index files are always read first
+        hnsw_cache: Server HNSWIndexCache to populate (None outside the server)
+        prime_embedding: Prime the embedding provider used for query vectors
+
+    Returns:
+        WarmupResult describing what was warmed
+    """
+    start = time.time()
+    result = WarmupResult(project_root=str(project_root))
+
+    collection_paths = get_collection_paths(project_root)
+    result.collections = [path.name for path in collection_paths]
+
+    warm_page_cache(iter_warmup_files(collection_paths), max_bytes, result)
+
+    if hnsw_cache is not None:
+        for collection_path in collection_paths:
+            if not (collection_path / "hnsw_index.bin").exists():
+                continue
+            try:
+                load_hnsw_into_cache(collection_path, hnsw_cache)
+                result.hnsw_indexes_cached += 1
+            except Exception as e:
+                result.errors.append(
+                    f"HNSW preload failed for {collection_path}: {e}"
+                )
+
+    if prime_embedding:
+        try:
+            if config is None:
+                from ..config import ConfigManager
+
+                config = ConfigManager.create_with_backtrack(project_root).get_config()
+            # Import the query path so the first query doesn't pay for it
+            from ..storage import filesystem_vector_store  # noqa: F401
+
+            prime_embedding_provider(config)
+            result.embedding_primed = True
+        except Exception as e:
+            result.errors.append(f"Embedding provider priming failed: {e}")
+
+    result.elapsed_seconds = time.time() - start
+    logger.info(
+        f"Warm-up for {project_root}: {result.files_warmed} files "
+        f"({result.bytes_warmed / (1024 * 1024):.1f} MB), "
+        f"{result.hnsw_indexes_cached} HNSW indexes cached, "
+        f"embedding primed: {result.embedding_primed} "
+        f"({result.elapsed_seconds:.2f}s)"
+    )
+    for error in result.errors:
+        logger.warning(f"Warm-up: {error}")
+    return result
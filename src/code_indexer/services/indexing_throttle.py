@@ -0,0 +1,156 @@
+"""
+Indexing throttle profiles for background / low-priority indexing.
+
+``cidx index --throttle low`` (and watch mode by default) caps resource usage so
+indexing can run continuously without making the machine unusable:
+
+- Fewer embedding worker threads (caps voyage_ai.parallel_requests)
+- A short pause after each file so worker threads yield the CPU
+- Lower CPU scheduling priority (nice) for the indexing process
+- Idle I/O scheduling class (ionice) where the platform supports it
+
+Config-level limits (threads, pauses) are applied to the in-memory Config only;
+config.json is never modified. Process priority is only lowered for
+standalone processes - the daemon serves queries and must stay responsive.
+"""
+
+import logging
+import os
+import sys
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional
+
+logger = logging.getLogger(__name__)
+
+THROTTLE_NORMAL = "normal"
+THROTTLE_LOW = "low"
+THROTTLE_LEVELS = (THROTTLE_NORMAL, THROTTLE_LOW)
+
+
+@dataclass(frozen=True)
+class ThrottleProfile:
+    """Resource limits applied to an indexing run."""
+
+    name: str
+    max_parallel_requests: Optional[int]  # None = use configured value
+    file_delay_seconds: float  # Pause per file in each worker thread
+    nice_increment: int  # Added to process niceness (0 = unchanged)
+    idle_io_priority: bool  # Use idle I/O scheduling class
+
+
+THROTTLE_PROFILES: Dict[str, ThrottleProfile] = {
+    THROTTLE_NORMAL: ThrottleProfile(
+        name=THROTTLE_NORMAL,
+        max_parallel_requests=None,
+        file_delay_seconds=0.0,
+        nice_increment=0,
+        idle_io_priority=False,
+    ),
+    THROTTLE_LOW: ThrottleProfile(
+        name=THROTTLE_LOW,
+        max_parallel_requests=2,
+        file_delay_seconds=0.05,
+        nice_increment=10,
+        idle_io_priority=True,
+    ),
+}
+
+
+def get_throttle_profile(level: str) -> ThrottleProfile:
+    """
+    Look up a throttle profile by name.
+
+    Raises:
+        ValueError: If level is not a known throttle level
+    """
+    try:
+        return THROTTLE_PROFILES[level]
+    except KeyError:
+        raise ValueError(
+            f"Unknown throttle level '{level}'. "
+            f"Valid levels: {', '.join(THROTTLE_LEVELS)}"
+        )
+
+
+def get_configured_throttle_profile(
+    config: Any, watch: bool = False
+) -> ThrottleProfile:
+    """
+    Profile configured for indexing (or watch mode), falling back to normal.
+
+    Args:
+        config: Config object (partially-populated configs are tolerated)
+        watch: Use indexing.watch_throttle instead of indexing.throttle
+    """
+    indexing_config = getattr(config, "indexing", None)
+    if watch:
+        level = getattr(indexing_config, "watch_throttle", THROTTLE_LOW)
+    else:
+        level = getattr(indexing_config, "throttle", THROTTLE_NORMAL)
+    if not isinstance(level, str) or level not in THROTTLE_PROFILES:
+        return THROTTLE_PROFILES[THROTTLE_NORMAL]
+    return THROTTLE_PROFILES[level]
+
+
+def apply_throttle_to_config(config: Any, level: str) -> ThrottleProfile:
+    """
+    Apply a throttle level to an in-memory Config (never saved to disk).
+
+    Args:
+        config: Loaded Config object
+        level: Throttle level name
+
+    Returns:
+        The applied ThrottleProfile
+
+    Raises:
+        ValueError: If level is not a known throttle level
+    """
+    profile = get_throttle_profile(level)
+    config.indexing.throttle = profile.name
+
+    if profile.max_parallel_requests is not None:
+        config.voyage_ai.parallel_requests = min(
+            config.voyage_ai.parallel_requests, profile.max_parallel_requests
+        )
+        # Auto-tune would probe concurrency back up - the cap must win
+        config.voyage_ai.auto_tune = False
+
+    return profile
+
+
+def lower_process_priority(profile: ThrottleProfile) -> List[str]:
+    """
+    Lower CPU and I/O priority of the current process per profile.
+
+    Best-effort: unsupported platforms and permission errors are logged and
+    skipped, never raised.
+
+    Returns:
+        Human-readable list of adjustments that were applied
+    """
+    applied: List[str] = []
+
+    if profile.nice_increment > 0 and hasattr(os, "nice"):
+        try:
+            niceness = os.nice(profile.nice_increment)
+            applied.append(f"CPU niceness {niceness}")
+        except OSError as e:
+            logger.debug(f"Could not lower CPU priority: {e}")
+
+    if profile.idle_io_priority:
+        try:
+            import psutil
+
+            process = psutil.Process()
+            if sys.platform.startswith("linux"):
+                process.ionice(psutil.IOPRIO_CLASS_IDLE)
+                applied.append("idle I/O priority")
+            elif sys.platform == "win32":
+                process.ionice(psutil.IOPRIO_VERYLOW)
+                applied.append("very low I/O priority")
+        except Exception as e:
+            # ImportError, psutil.AccessDenied, unsupported ionice class
+            logger.debug(f"Could not lower I/O priority: {e}")
+
+    return applied
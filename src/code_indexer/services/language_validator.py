@@ -85,8 +85,9 @@ class LanguageValidator:
             # Programming language alternatives
             "c#": ["csharp"],
             "c++": ["cpp"],
-            "objective-c": ["c"],
-            "objc": ["c"],
+            "objective-c++": ["objective-cpp"],
+            "obj-c": ["objective-c"],
+            "system-verilog": ["systemverilog"],
             "golang": ["go"],
             # File extensions with dots (common user mistake)
             ".py": ["python"],
@@ -102,9 +103,25 @@ class LanguageValidator:
             ".kt": ["kotlin"],
             ".scala": ["scala"],
             ".dart": ["dart"],
+            ".m": ["objective-c"],
+            ".mm": ["objective-cpp"],
+            ".jl": ["julia"],
+            ".r": ["r"],
+            ".cbl": ["cobol"],
+            ".cob": ["cobol"],
+            ".cpy": ["cobol"],
+            ".v": ["verilog"],
+            ".sv": ["systemverilog"],
+            ".vhd": ["vhdl"],
+            ".zig": ["zig"],
+            ".ex": ["elixir"],
+            ".exs": ["elixir"],
+            ".erl": ["erlang"],
+            ".proto": ["protobuf"],
             ".html": ["html"],
             ".css": ["css"],
             ".vue": ["vue"],
+            ".svelte": ["svelte"],
             ".md": ["markdown"],
             ".json": ["json"],
             ".yaml": ["yaml"],
@@ -113,6 +130,7 @@ class LanguageValidator:
             ".sql": ["sql"],
             ".sh": ["shell"],
             ".bash": ["bash"],
+            ".zsh": ["zsh"],
             # Common misspellings and typos (will be supplemented by fuzzy matching)
             "pythom": ["python"],
             "pytohn": ["python"],
@@ -330,9 +348,23 @@ class LanguageValidator:
                 "kotlin",
                 "scala",
                 "dart",
+                "objective-c",
+                "julia",
+                "r",
+                "cobol",
+                "verilog",
+                "systemverilog",
+                "vhdl",
+                "zig",
+                "elixir",
+                "erlang",
             ]
         ]
-        web = [lang for lang in supported_langs if lang in ["html", "css", "vue"]]
+        web = [
+            lang
+            for lang in supported_langs
+            if lang in ["html", "css", "vue", "svelte"]
+        ]
         markup = [
             lang
             for lang in supported_langs
@@ -0,0 +1,309 @@
+"""
+Per-file license classification.
+
+Each indexed file is classified once, and every chunk of the file carries the
+result in its payload:
+
+- "license": an SPDX identifier or expression ("Apache-2.0",
+  "MIT OR Apache-2.0")
+- "license_source": "header" when the file declares its license itself
+  (SPDX-License-Identifier line or a recognized license header), or
+  "license_file" when it was inherited from the nearest LICENSE/COPYING file
+  in its directory or a parent directory inside the project
+
+Files without either have no license keys. Query filters such as
+``cidx query --license-not Apache-2.0`` then reuse the index for compliance
+checks.
+"""
+
+import logging
+import re
+import threading
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Pattern, Tuple
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload keys
+LICENSE_KEY = "license"
+LICENSE_SOURCE_KEY = "license_source"
+
+LICENSE_FILE_NAMES = (
+    "LICENSE",
+    "LICENSE.txt",
+    "LICENSE.md",
+    "LICENCE",
+    "LICENCE.txt",
+    "LICENCE.md",
+    "COPYING",
+    "COPYING.txt",
+    "COPYING.md",
+)
+
+# Characters of a file examined for its license header or license text
+HEADER_SCAN_CHARS = 8192
+
+_LINE_COMMENT_OPENERS = ("#", "//", "--", ";", "%", "*")
+_BLOCK_COMMENTS = {
+    "/*": "*/",
+    '"""': '"""',
+    "'''": "'''",
+    "<!--": "-->",
+    "{-": "-}",
+}
+
+_SPDX_LINE = re.compile(
+    r"SPDX-License-Identifier:\s*(?P<expression>[A-Za-z0-9.+\-() ]+?)"
+    r"\s*(?:\*/|-->|\*\)|$)",
+    re.MULTILINE,
+)
+
+# License texts and headers, most specific first. Each entry is
+# (SPDX identifier, patterns that must all match the whitespace-normalized,
+# lower-cased text).
+_TEXT_SIGNATURES: List[Tuple[str, Tuple[Pattern[str], ...]]] = [
+    (identifier, tuple(re.compile(p) for p in patterns))
+    for identifier, patterns in (
+        (
+            "AGPL-3.0-or-later",
+            (r"gnu affero general public license", r"version 3", r"any later"),
+        ),
+        ("AGPL-3.0-only", (r"gnu affero general public license", r"version 3")),
+        (
+            "LGPL-3.0-or-later",
+            (r"gnu lesser general public license", r"version 3", r"any later"),
+        ),
+        ("LGPL-3.0-only", (r"gnu lesser general public license", r"version 3")),
+        (
+            "LGPL-2.1-or-later",
+            (r"gnu lesser general public license", r"version 2\.1", r"any later"),
+        ),
+        ("LGPL-2.1-only", (r"gnu lesser general public license", r"version 2\.1")),
+        (
+            "GPL-3.0-or-later",
+            (r"gnu general public license", r"version 3", r"any later"),
+        ),
+        ("GPL-3.0-only", (r"gnu general public license", r"version 3")),
+        (
+            "GPL-2.0-or-later",
+            (r"gnu general public license", r"version 2", r"any later"),
+        ),
+        ("GPL-2.0-only", (r"gnu general public license", r"version 2")),
+        ("Apache-2.0", (r"apache license,? version 2\.0",)),
+        ("MPL-2.0", (r"mozilla public license,? v(?:ersion|\.) ?2\.0",)),
+        ("EPL-2.0", (r"eclipse public license - v(?:ersion)? ?2\.0",)),
+        (
+            "BSD-3-Clause",
+            (
+                r"redistribution and use in source and binary forms",
+                r"neither the name of",
+            ),
+        ),
+        ("BSD-2-Clause", (r"redistribution and use in source and binary forms",)),
+        (
+            "MIT",
+            (
+                r"permission is hereby granted, free of charge",
+                r"without restriction",
+            ),
+        ),
+        (
+            "ISC",
+            (r"permission to use, copy, modify, and(?:/or)? distribute this software",),
+        ),
+        ("Unlicense", (r"this is free and unencumbered software",)),
+        ("MIT", (r"licensed under the mit license",)),
+        ("BSL-1.0", (r"boost software license",)),
+    )
+]
+
+# Canonical spelling of SPDX identifiers, keyed by lower case
+_CANONICAL_IDS = {
+    identifier.lower(): identifier for identifier, _ in _TEXT_SIGNATURES
+}
+_CANONICAL_IDS.update(
+    {
+        "gpl-2.0": "GPL-2.0-only",
+        "gpl-2.0+": "GPL-2.0-or-later",
+        "gpl-3.0": "GPL-3.0-only",
+        "gpl-3.0+": "GPL-3.0-or-later",
+        "lgpl-2.1": "LGPL-2.1-only",
+        "lgpl-2.1+": "LGPL-2.1-or-later",
+        "lgpl-3.0": "LGPL-3.0-only",
+        "lgpl-3.0+": "LGPL-3.0-or-later",
+        "agpl-3.0": "AGPL-3.0-only",
+        "0bsd": "0BSD",
+        "cc0-1.0": "CC0-1.0",
+        "proprietary": "LicenseRef-Proprietary",
+    }
+)
+
+
+def canonical_license_id(identifier: str) -> str:
+    """
+    Canonical spelling of an SPDX identifier or expression.
+
+    Known identifiers are matched case-insensitively and deprecated GPL
+    family forms ("GPL-2.0+", "GPL-3.0") map to their current identifiers;
+    operators (AND, OR, WITH) are upper-cased. Unknown identifiers are kept
+    as written.
+    """
+    tokens = re.split(r"(\s+|\(|\))", identifier.strip())
+    canonical = []
+    for token in tokens:
+        if token.upper() in ("AND", "OR", "WITH"):
+            canonical.append(token.upper())
+        else:
+            canonical.append(_CANONICAL_IDS.get(token.lower(), token))
+    return re.sub(r"\s+", " ", "".join(canonical))
+
+
+def leading_comments(text: str) -> str:
+    """
+    Leading comment block of source text, where license headers are.
+
+    Blank lines, a shebang, line comments, block comments and docstrings are
+    included; the first line of code ends the block.
+    """
+    lines = []
+    closer: Optional[str] = None
+    for line in text.split("\n"):
+        stripped = line.strip()
+        if closer is not None:
+            lines.append(line)
+            if closer in stripped:
+                closer = None
+            continue
+        if not stripped or stripped.startswith(_LINE_COMMENT_OPENERS):
+            lines.append(line)
+            continue
+        opener = next((o for o in _BLOCK_COMMENTS if stripped.startswith(o)), None)
+        if opener is None:
+            break
+        lines.append(line)
+        if _BLOCK_COMMENTS[opener] not in stripped[len(opener) :]:
+            closer = _BLOCK_COMMENTS[opener]
+    return "\n".join(lines)
+
+
+def detect_license_text(text: str, header_only: bool = True) -> Optional[str]:
+    """
+    License declared by a file header or license text.
+
+    Args:
+        text: Beginning of a file
+        header_only: Only look at the leading comment block (source files);
+            False for LICENSE files, which are entirely license text
+
+    Returns:
+        SPDX identifier or expression, or None if no license is recognized
+    """
+    head = text[:HEADER_SCAN_CHARS]
+    if header_only:
+        head = leading_comments(head)
+    spdx = _SPDX_LINE.search(head)
+    if spdx:
+        return canonical_license_id(spdx.group("expression"))
+
+    # Comment markers and line breaks split license sentences
+    normalized = re.sub(r"(?:^|\n)\s*(?:#|//|/?\*+/?|--|;+|%|!)?", " ", head)
+    normalized = re.sub(r"\s+", " ", normalized).lower()
+    for identifier, patterns in _TEXT_SIGNATURES:
+        if all(pattern.search(normalized) for pattern in patterns):
+            return identifier
+    return None
+
+
+class LicenseDetector:
+    """Classifies the license of indexed files."""
+
+    def __init__(self, codebase_dir: Path, use_license_files: bool = True):
+        """
+        Initialize the detector.
+
+        Args:
+            codebase_dir: Project root; LICENSE files above it are ignored
+            use_license_files: Inherit the license of the nearest LICENSE or
+                COPYING file for files without a license header
+        """
+        self.codebase_dir = Path(codebase_dir).resolve()
+        self.use_license_files = use_license_files
+        self._directory_licenses: Dict[Path, Optional[str]] = {}
+        self._lock = threading.Lock()
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["LicenseDetector"]:
+        """Detector from indexing.license_detection, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        detection_config = getattr(indexing_config, "license_detection", None)
+        if getattr(detection_config, "enabled", False) is not True:
+            return None
+        return cls(
+            codebase_dir=Path(config.codebase_dir),
+            use_license_files=detection_config.use_license_files,
+        )
+
+    def classify(self, file_path: Path, head: str) -> Dict[str, str]:
+        """
+        License payload fields of a file.
+
+        Args:
+            file_path: Indexed file
+            head: Beginning of the file's text
+
+        Returns:
+            {LICENSE_KEY, LICENSE_SOURCE_KEY}, or {} if no license applies
+        """
+        license_id = detect_license_text(head)
+        if license_id:
+            return {LICENSE_KEY: license_id, LICENSE_SOURCE_KEY: "header"}
+        if self.use_license_files:
+            license_id = self._inherited_license(Path(file_path).resolve().parent)
+            if license_id:
+                return {LICENSE_KEY: license_id, LICENSE_SOURCE_KEY: "license_file"}
+        return {}
+
+    def classify_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks with the file's license fields added."""
+        if not chunks:
+            return chunks
+        fields = self.classify(file_path, chunks[0]["text"])
+        if not fields:
+            return chunks
+        return [{**chunk, **fields} for chunk in chunks]
+
+    def _inherited_license(self, directory: Path) -> Optional[str]:
+        """License of the nearest LICENSE file in directory or its parents."""
+        project = self.codebase_dir
+        if directory != project and project not in directory.parents:
+            return None  # Outside the project
+        with self._lock:
+            if directory in self._directory_licenses:
+                return self._directory_licenses[directory]
+
+        license_id = self._license_file_id(directory)
+        if license_id is None and directory != self.codebase_dir:
+            license_id = self._inherited_license(directory.parent)
+
+        with self._lock:
+            self._directory_licenses[directory] = license_id
+        return license_id
+
+    def _license_file_id(self, directory: Path) -> Optional[str]:
+        for name in LICENSE_FILE_NAMES:
+            license_file = directory / name
+            if not license_file.is_file():
+                continue
+            try:
+                with open(license_file, encoding="utf-8", errors="replace") as f:
+                    license_id = detect_license_text(
+                        f.read(HEADER_SCAN_CHARS), header_only=False
+                    )
+            except OSError as e:
+                logger.debug(f"Could not read {license_file}: {e}")
+                continue
+            if license_id:
+                return license_id
+        return None
@@ -0,0 +1,287 @@
+"""
+Lifecycle hooks: external scripts and Python plugins at indexing and query
+hook points.
+
+Hooks are listed per hook point under "hooks" in config.json and run in the
+listed order. Every hook receives an event dict and returns the fields it
+changes (or nothing to leave the event unchanged):
+
+- pre_index {"path"}: return {"index": false} to veto the file. Vetoed
+  files are treated like excluded files.
+- post_chunk {"path", "chunks"}: return {"chunks": [...]} to change chunk
+  text (custom redaction), drop chunks, or attach a CUSTOM_METADATA_KEY dict
+  that is stored in each chunk's payload.
+- pre_embed {"path", "texts"}: return {"texts": [...]} (same length) to
+  change what is embedded; the stored chunk text stays as it is.
+- post_query {"query", "results"}: return {"results": [...]} to re-order,
+  drop or annotate semantic query results.
+
+A plugin is a Python callable ("package.module:function" or
+"path/to/file.py:function") called with the event dict. A command gets the
+event as JSON on stdin and prints the changed fields as a JSON object.
+Plugins are called from indexing worker threads concurrently.
+"""
+
+import importlib
+import importlib.util
+import json
+import logging
+import shlex
+import subprocess
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional, Sequence
+
+logger = logging.getLogger(__name__)
+
+HOOK_POINTS = ("pre_index", "post_chunk", "pre_embed", "post_query")
+
+# Chunk and payload key of metadata attached by post_chunk hooks
+CUSTOM_METADATA_KEY = "custom_metadata"
+
+# Hook stderr kept in error messages
+MAX_ERROR_OUTPUT = 500
+
+
+class HookError(RuntimeError):
+    """A hook failed and is configured with on_error "fail"."""
+
+
+def load_plugin(spec: str, project_root: Path) -> Callable[[Dict[str, Any]], Any]:
+    """
+    Import a plugin callable.
+
+    Args:
+        spec: "package.module:function" or "path/to/file.py:function"
+        project_root: Base of relative plugin file paths
+
+    Raises:
+        ValueError: If the spec is malformed or the callable cannot be loaded
+    """
+    target, separator, function_name = spec.rpartition(":")
+    if not separator or not target or not function_name:
+        raise ValueError(
+            f"Hook plugin '{spec}' must be 'module:function' or 'file.py:function'"
+        )
+    try:
+        if target.endswith(".py"):
+            path = Path(target)
+            if not path.is_absolute():
+                path = Path(project_root) / path
+            module_spec = importlib.util.spec_from_file_location(
+                f"cidx_hook_{path.stem}", path
+            )
+            if module_spec is None or module_spec.loader is None:
+                raise ImportError(f"cannot load {path}")
+            module = importlib.util.module_from_spec(module_spec)
+            module_spec.loader.exec_module(module)
+        else:
+            module = importlib.import_module(target)
+    except Exception as e:
+        raise ValueError(f"Cannot load hook plugin '{spec}': {e}") from e
+    function = getattr(module, function_name, None)
+    if not callable(function):
+        raise ValueError(f"Hook plugin '{spec}': {function_name} is not callable")
+    return function
+
+
+def command_hook(
+    command: str, project_root: Path, timeout_seconds: int
+) -> Callable[[Dict[str, Any]], Any]:
+    """
+    Callable running an external hook command.
+
+    The command runs in the project root with the event as JSON on stdin;
+    its stdout is parsed as the JSON object of changed fields.
+    """
+    argv = shlex.split(command)
+    if not argv:
+        raise ValueError("Hook command is empty")
+
+    def run(event: Dict[str, Any]) -> Optional[Dict[str, Any]]:
+        result = subprocess.run(
+            argv,
+            input=json.dumps(event, default=str),
+            capture_output=True,
+            text=True,
+            timeout=timeout_seconds,
+            cwd=str(project_root),
+        )
+        if result.returncode != 0:
+            raise RuntimeError(
+                f"exited with {result.returncode}: "
+                f"{result.stderr.strip()[:MAX_ERROR_OUTPUT]}"
+            )
+        output = result.stdout.strip()
+        return json.loads(output) if output else None
+
+    return run
+
+
+class Hook:
+    """One configured hook."""
+
+    def __init__(
+        self,
+        point: str,
+        name: str,
+        function: Callable[[Dict[str, Any]], Any],
+        on_error: str = "fail",
+    ):
+        self.point = point
+        self.name = name
+        self.function = function
+        self.on_error = on_error
+
+    @classmethod
+    def from_config(cls, point: str, hook_config: Any, project_root: Path) -> "Hook":
+        """
+        Hook from a HookConfig entry.
+
+        Raises:
+            ValueError: If the entry sets neither or both of command and
+                plugin, or the plugin cannot be loaded
+        """
+        command = getattr(hook_config, "command", None)
+        plugin = getattr(hook_config, "plugin", None)
+        if bool(command) == bool(plugin):
+            raise ValueError(
+                f"Each {point} hook needs exactly one of 'command' and 'plugin'"
+            )
+        if plugin:
+            function = load_plugin(plugin, project_root)
+            name = plugin
+        else:
+            function = command_hook(
+                command, project_root, getattr(hook_config, "timeout_seconds", 30)
+            )
+            name = command
+        return cls(point, name, function, getattr(hook_config, "on_error", "fail"))
+
+
+class LifecycleHooks:
+    """Runs the configured hooks of each hook point."""
+
+    def __init__(self, hooks: Dict[str, List[Hook]]):
+        """
+        Initialize the runner.
+
+        Args:
+            hooks: Hooks per hook point, in run order
+        """
+        self.hooks = hooks
+
+    @classmethod
+    def from_config(
+        cls, config: Any, points: Sequence[str] = HOOK_POINTS
+    ) -> Optional["LifecycleHooks"]:
+        """
+        Runner for the hooks of config.hooks, or None when none of the
+        given points has hooks.
+
+        Args:
+            config: Project configuration
+            points: Hook points to load (plugins of other points are not
+                imported)
+
+        Raises:
+            ValueError: If a hook is misconfigured
+        """
+        hooks_config = getattr(config, "hooks", None)
+        hooks: Dict[str, List[Hook]] = {}
+        for point in points:
+            entries = getattr(hooks_config, point, None)
+            if not isinstance(entries, list) or not entries:
+                continue
+            project_root = Path(config.codebase_dir)
+            hooks[point] = [
+                Hook.from_config(point, entry, project_root) for entry in entries
+            ]
+        return cls(hooks) if hooks else None
+
+    def has(self, point: str) -> bool:
+        return bool(self.hooks.get(point))
+
+    def run(self, point: str, event: Dict[str, Any]) -> Dict[str, Any]:
+        """
+        Run the hooks of a point over an event.
+
+        Returns:
+            The event with the changes of every hook applied
+
+        Raises:
+            HookError: If a hook with on_error "fail" raises, times out or
+                returns something other than a dict
+        """
+        event = {"hook": point, **event}
+        for hook in self.hooks.get(point, []):
+            try:
+                changes = hook.function(dict(event))
+                if changes is not None and not isinstance(changes, dict):
+                    raise TypeError(
+                        f"returned {type(changes).__name__}, expected a dict"
+                    )
+            except Exception as e:
+                if hook.on_error == "ignore":
+                    logger.warning(f"Ignoring failed {point} hook {hook.name}: {e}")
+                    continue
+                raise HookError(f"{point} hook {hook.name} failed: {e}") from e
+            if changes:
+                event.update(changes)
+        return event
+
+    def allows_file(self, path: str) -> bool:
+        """pre_index: False if a hook vetoes indexing the file."""
+        if not self.has("pre_index"):
+            return True
+        return self.run("pre_index", {"path": path}).get("index", True) is not False
+
+    def process_chunks(
+        self, chunks: List[Dict[str, Any]], path: str
+    ) -> List[Dict[str, Any]]:
+        """post_chunk: chunks as changed by the hooks."""
+        if not self.has("post_chunk"):
+            return chunks
+        chunks = self.run("post_chunk", {"path": path, "chunks": chunks})["chunks"]
+        if not isinstance(chunks, list) or not all(
+            isinstance(chunk, dict)
+            and isinstance(chunk.get("text"), str)
+            and "line_start" in chunk
+            and "line_end" in chunk
+            for chunk in chunks
+        ):
+            raise HookError(
+                "post_chunk hooks must return chunks with text, line_start "
+                "and line_end"
+            )
+        return chunks
+
+    def embedding_texts(self, texts: List[str], path: str) -> List[str]:
+        """pre_embed: texts to embed as changed by the hooks."""
+        if not self.has("pre_embed"):
+            return texts
+        changed = self.run("pre_embed", {"path": path, "texts": texts})["texts"]
+        if (
+            not isinstance(changed, list)
+            or len(changed) != len(texts)
+            or not all(isinstance(text, str) for text in changed)
+        ):
+            raise HookError(
+                f"pre_embed hooks must return {len(texts)} texts for {path}"
+            )
+        return changed
+
+    def process_results(
+        self, query: str, results: List[Dict[str, Any]]
+    ) -> List[Dict[str, Any]]:
+        """post_query: semantic query results as changed by the hooks."""
+        if not self.has("post_query"):
+            return results
+        changed = self.run("post_query", {"query": query, "results": results})[
+            "results"
+        ]
+        if not isinstance(changed, list) or not all(
+            isinstance(result, dict) and isinstance(result.get("payload"), dict)
+            for result in changed
+        ):
+            raise HookError("post_query hooks must return results with a payload")
+        return changed
@@ -0,0 +1,169 @@
+"""
+Highlighting of the region of a chunk that matches a query.
+
+A semantic result is a whole chunk, often dozens of lines, while the part
+answering the query is a few of them. The matched region is found by
+lexical alignment: the query and the chunk are split into words
+(identifiers split at camelCase and snake_case boundaries, "retryPayment"
+giving "retry" and "payment"), and the window of up to MAX_REGION_LINES
+lines covering the most distinct query words wins; ties go to the shorter,
+then the earlier window. Words match when equal or when they share a
+prefix of at least four letters that is at most one letter shorter than
+the shorter word ("retry" matches "retries", "charge" matches "charging").
+
+The region is a heuristic: a chunk matching the query by meaning only,
+without sharing any of its words, has no region.
+"""
+
+import os
+import re
+from typing import Any, Dict, List, Optional, Set, Tuple
+
+# Result key: matched region of the chunk text
+HIGHLIGHT_KEY = "highlight"
+
+# Lines a matched region may span
+MAX_REGION_LINES = 6
+
+# Shortest common prefix of words that match without being equal
+MIN_PREFIX_LENGTH = 4
+
+_STOP_WORDS = {
+    "and",
+    "are",
+    "code",
+    "does",
+    "for",
+    "from",
+    "how",
+    "the",
+    "that",
+    "this",
+    "what",
+    "where",
+    "which",
+    "with",
+    "work",
+}
+_WORD = re.compile(r"[A-Za-z0-9_]+")
+_WORD_PARTS = re.compile(r"[A-Z]+(?=[A-Z][a-z])|[A-Z]?[a-z]+\d*|[A-Z]+\d*|\d+")
+
+
+def query_terms(query: str) -> List[str]:
+    """Distinct lowercase words of a query, without stop words."""
+    terms: Dict[str, None] = {}
+    for word in _WORD.finditer(query):
+        for part in _word_parts(word.group()):
+            if len(part) >= 3 and part not in _STOP_WORDS:
+                terms.setdefault(part, None)
+    return list(terms)
+
+
+def _word_parts(word: str) -> List[str]:
+    return [
+        part.lower()
+        for piece in word.split("_")
+        for part in _WORD_PARTS.findall(piece)
+    ]
+
+
+def _matches(part: str, term: str) -> bool:
+    if part == term:
+        return True
+    common = len(os.path.commonprefix([part, term]))
+    return common >= max(MIN_PREFIX_LENGTH, min(len(part), len(term)) - 1)
+
+
+def _line_matches(
+    line: str, terms: List[str]
+) -> Tuple[Set[str], List[Tuple[int, int]]]:
+    """Terms a line matches, and the spans of the matching words."""
+    found: Set[str] = set()
+    spans: List[Tuple[int, int]] = []
+    for word in _WORD.finditer(line):
+        matched = {
+            term
+            for part in _word_parts(word.group())
+            for term in terms
+            if _matches(part, term)
+        }
+        if matched:
+            found |= matched
+            spans.append(word.span())
+    return found, spans
+
+
+def matched_region(
+    text: str,
+    query: str,
+    first_line: int = 1,
+    max_lines: int = MAX_REGION_LINES,
+) -> Optional[Dict[str, Any]]:
+    """
+    Region of the chunk text best matching the query's words.
+
+    Args:
+        text: Chunk text
+        query: Query text
+        first_line: File line of the chunk's first line
+        max_lines: Lines the region may span
+
+    Returns:
+        {"line_start", "line_end"} (file lines), {"char_start", "char_end"}
+        (offsets into text), "matches" (character ranges of the matched
+        words, as offsets into text) and "terms" (query words matched), or
+        None when no query word occurs in the chunk
+    """
+    terms = query_terms(query)
+    if not terms or not text:
+        return None
+    lines = text.split("\n")
+    offsets = []
+    offset = 0
+    for line in lines:
+        offsets.append(offset)
+        offset += len(line) + 1
+    per_line = [_line_matches(line, terms) for line in lines]
+
+    best: Optional[Tuple[int, int, int, int]] = None  # (covered, -size, start, end)
+    for start, (found, _) in enumerate(per_line):
+        if not found:
+            continue  # Regions start and end on matching lines
+        covered: Set[str] = set()
+        for end in range(start, min(start + max_lines, len(lines))):
+            if not per_line[end][0]:
+                continue
+            covered |= per_line[end][0]
+            if best is None or (len(covered), start - end) > best[:2]:
+                best = (len(covered), start - end, start, end)
+    if best is None:
+        return None
+
+    _, _, start, end = best
+    matched_terms: Set[str] = set()
+    matches = []
+    for index in range(start, end + 1):
+        found, spans = per_line[index]
+        matched_terms |= found
+        matches.extend(
+            [offsets[index] + span_start, offsets[index] + span_end]
+            for span_start, span_end in spans
+        )
+    return {
+        "line_start": first_line + start,
+        "line_end": first_line + end,
+        "char_start": offsets[start],
+        "char_end": offsets[end] + len(lines[end]),
+        "matches": matches,
+        "terms": [term for term in terms if term in matched_terms],
+    }
+
+
+def annotate_highlights(results: List[Dict[str, Any]], query: str) -> None:
+    """Record the matched region of each search result under HIGHLIGHT_KEY."""
+    for result in results:
+        payload = result.get("payload", {})
+        text = payload.get("content") or result.get("chunk_text") or ""
+        region = matched_region(text, query, payload.get("line_start") or 1)
+        if region is not None:
+            result[HIGHLIGHT_KEY] = region
@@ -0,0 +1,162 @@
+"""
+Memory budget for indexing very large repositories (indexing.max_memory_mb).
+
+The largest variable cost of an indexing run is embedded-but-unwritten points:
+a 1 MB source file yields hundreds of chunks, each carrying a 1024-dimension
+vector held as a Python list (roughly 32 bytes per dimension). With the
+pipelined upsert stage, a queue full of large files can grow well past what a
+16 GB machine can hold on a monorepo.
+
+When a budget is configured:
+
+- The upsert queue is shrunk so that typical files fit in the points share of
+  the budget.
+- Points are only kept in memory while the bytes held stay within that share.
+  Any file that would exceed it is spilled to a JSON file under the spill
+  directory and read back by the writer thread just before its upsert.
+
+Spill files live under .code-indexer/ (not the system temp dir, which may be
+RAM-backed tmpfs) and are removed once written or when the run ends.
+"""
+
+import json
+import logging
+import shutil
+import threading
+from pathlib import Path
+from typing import Any, Dict, List
+
+logger = logging.getLogger(__name__)
+
+BYTES_PER_MB = 1024 * 1024
+
+# Share of the budget for points waiting to be written; the rest is left for
+# the interpreter, chunk text, the ID index and the HNSW rebuild at the end
+POINTS_BUDGET_FRACTION = 0.25
+
+# Python list of floats: 8-byte list slot + 24-byte float object per dimension
+BYTES_PER_VECTOR_DIMENSION = 32
+POINT_OVERHEAD_BYTES = 1024  # dicts and metadata values per point
+
+# Size assumed per queued file when shrinking the upsert queue
+TYPICAL_FILE_POINTS_BYTES = 4 * BYTES_PER_MB
+
+SPILL_DIR_NAME = "index_spill"
+
+
+def estimate_points_bytes(file_points: List[Dict[str, Any]]) -> int:
+    """Approximate resident size of one file's embedded points."""
+    total = 0
+    for point in file_points:
+        total += POINT_OVERHEAD_BYTES
+        total += len(point.get("text", ""))
+        total += len(point.get("vector", ())) * BYTES_PER_VECTOR_DIMENSION
+    return total
+
+
+class MemoryBudget:
+    """Tracks points held in memory and spills the overflow to disk."""
+
+    def __init__(self, max_memory_mb: int, spill_dir: Path):
+        """
+        Initialize the budget.
+
+        Args:
+            max_memory_mb: Hard memory limit for the indexing run
+            spill_dir: Directory for spilled point batches (created on demand)
+
+        Raises:
+            ValueError: If max_memory_mb is not positive
+        """
+        if max_memory_mb <= 0:
+            raise ValueError(f"max_memory_mb must be positive, got {max_memory_mb}")
+
+        self.max_memory_mb = max_memory_mb
+        self.points_budget_bytes = int(
+            max_memory_mb * BYTES_PER_MB * POINTS_BUDGET_FRACTION
+        )
+        self.spill_dir = spill_dir
+        self._lock = threading.Lock()
+        self._held_bytes = 0
+        self._spill_counter = 0
+
+        # Statistics (read via get_stats())
+        self._max_held_bytes = 0
+        self._batches_spilled = 0
+        self._bytes_spilled = 0
+
+    def queue_size_for(self, configured_queue_size: int) -> int:
+        """Upsert queue capacity shrunk to fit the points budget (0 stays 0)."""
+        if configured_queue_size <= 0:
+            return configured_queue_size
+        fits = max(1, self.points_budget_bytes // TYPICAL_FILE_POINTS_BYTES)
+        return min(configured_queue_size, fits)
+
+    def try_reserve(self, num_bytes: int) -> bool:
+        """Reserve memory for points to be queued; False means spill instead."""
+        with self._lock:
+            if self._held_bytes + num_bytes > self.points_budget_bytes:
+                return False
+            self._held_bytes += num_bytes
+            self._max_held_bytes = max(self._max_held_bytes, self._held_bytes)
+            return True
+
+    def release(self, num_bytes: int) -> None:
+        """Return a reservation once its points have been written."""
+        with self._lock:
+            self._held_bytes = max(0, self._held_bytes - num_bytes)
+
+    def spill(self, file_points: List[Dict[str, Any]]) -> Path:
+        """
+        Write points to a spill file.
+
+        Returns:
+            Path to pass to load_spilled()
+
+        Raises:
+            OSError: If the spill file cannot be written
+        """
+        with self._lock:
+            self._spill_counter += 1
+            spill_path = self.spill_dir / f"points_{self._spill_counter:08d}.json"
+
+        self.spill_dir.mkdir(parents=True, exist_ok=True)
+        data = json.dumps(file_points)
+        spill_path.write_text(data, encoding="utf-8")
+
+        with self._lock:
+            self._batches_spilled += 1
+            self._bytes_spilled += len(data)
+        return spill_path
+
+    def load_spilled(self, spill_path: Path) -> List[Dict[str, Any]]:
+        """
+        Read spilled points back and delete the spill file.
+
+        Raises:
+            OSError: If the spill file cannot be read
+        """
+        try:
+            points: List[Dict[str, Any]] = json.loads(
+                spill_path.read_text(encoding="utf-8")
+            )
+        finally:
+            spill_path.unlink(missing_ok=True)
+        return points
+
+    def cleanup(self) -> None:
+        """Remove the spill directory (leftovers from an interrupted run)."""
+        if self.spill_dir.exists():
+            shutil.rmtree(self.spill_dir, ignore_errors=True)
+
+    def get_stats(self) -> Dict[str, Any]:
+        """Return a snapshot of budget statistics."""
+        with self._lock:
+            return {
+                "max_memory_mb": self.max_memory_mb,
+                "points_budget_bytes": self.points_budget_bytes,
+                "held_bytes": self._held_bytes,
+                "max_held_bytes": self._max_held_bytes,
+                "batches_spilled": self._batches_spilled,
+                "bytes_spilled": self._bytes_spilled,
+            }
@@ -89,6 +89,14 @@ class GitAwareMetadataSchema:
         "overlap_end",  # Integer: overlap end position (if any)
     }
 
+    # Provenance fields (optional, for 'cidx verify --integrity')
+    PROVENANCE_FIELDS = {
+        "chunk_hash",  # Hash of the chunk text as embedded and stored
+        "embedding_hash",  # Hash of the text embedded, for reusing vectors
+        "indexer_version",  # cidx version that indexed the chunk
+        "pii_scrubbed",  # Chunk text was PII-masked and differs from its source
+    }
+
     # All possible fields
     ALL_FIELDS = (
         REQUIRED_FIELDS
@@ -98,6 +106,7 @@ class GitAwareMetadataSchema:
         | UNIVERSAL_TIMESTAMP_FIELDS
         | LINE_NUMBER_FIELDS
         | CHUNKING_FIELDS
+        | PROVENANCE_FIELDS
     )
 
     @classmethod
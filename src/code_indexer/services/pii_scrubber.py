@@ -0,0 +1,301 @@
+"""
+Masking of personally identifiable information in chunk text.
+
+Repositories sometimes embed sample customer data in test fixtures, seed
+scripts or docs. When indexing.pii_scrubbing is enabled, every chunk is
+scrubbed after chunking and before embedding, so neither the embedding
+provider nor the stored chunk text sees the original values. Matches are
+replaced with a placeholder naming the pattern (e.g. ``<EMAIL>``), which keeps
+the surrounding code searchable.
+
+With string_literals_only, source files are scrubbed inside string literals
+only, so identifiers and code that merely look like PII stay intact. Literal
+syntax depends on the file's language, or on the language of code embedded
+in a page or document; data and documentation files (JSON, YAML, CSV, SQL,
+Markdown, ...) have no code to protect and are scrubbed entirely. A chunk
+boundary can cut a literal in half; when a chunk's literal delimiters do not
+balance, the whole chunk is scrubbed rather than risk leaving the cut literal
+unmasked.
+"""
+
+import logging
+import re
+import threading
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional, Pattern, Tuple
+
+from ..indexing.boundary_chunking import EMBEDDED_LANGUAGE_KEY
+from ..indexing.chunk_payload_keys import HEADING_PATH_KEY
+
+logger = logging.getLogger(__name__)
+
+
+def _luhn_valid(match_text: str) -> bool:
+    """Luhn checksum, so arbitrary 16-digit numbers are not masked as cards."""
+    digits = [int(c) for c in match_text if c.isdigit()]
+    total = 0
+    for i, digit in enumerate(reversed(digits)):
+        if i % 2 == 1:
+            digit *= 2
+            if digit > 9:
+                digit -= 9
+        total += digit
+    return total % 10 == 0
+
+
+# name -> (pattern, optional validator of the matched text)
+BUILTIN_PATTERNS: Dict[str, Tuple[str, Optional[Callable[[str], bool]]]] = {
+    "email": (
+        r"[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}",
+        None,
+    ),
+    # Separators are required so plain numeric constants are left alone
+    "phone": (
+        r"(?<!\w)(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)[ .-]?|\d{3}[ .-])"
+        r"\d{3}[ .-]\d{4}(?!\w)",
+        None,
+    ),
+    "ssn": (r"(?<![\w-])\d{3}-\d{2}-\d{4}(?![\w-])", None),
+    "credit_card": (r"(?<![\w-])(?:\d{4}[ -]?){3}\d{4}(?![\w-])", _luhn_valid),
+}
+
+# Chunk and payload flag marking text that differs from its source file
+PII_SCRUBBED_KEY = "pii_scrubbed"
+
+# String literal syntax per language family
+_DOUBLE = r'"(?:\\.|[^"\\\n])*"'
+_SINGLE = r"'(?:\\.|[^'\\\n])*'"
+_BACKTICK = r"`(?:\\.|[^`\\])*`"
+_TRIPLE_DOUBLE = r'"""[\s\S]*?"""'
+_TRIPLE_SINGLE = r"'''[\s\S]*?'''"
+
+_LITERAL_SYNTAX: Dict[str, Tuple[List[str], List[str]]] = {
+    # family: (literal patterns, most specific first; multi-line delimiters)
+    "python": ([_TRIPLE_DOUBLE, _TRIPLE_SINGLE, _DOUBLE, _SINGLE], ['"""', "'''"]),
+    "javascript": ([_BACKTICK, _DOUBLE, _SINGLE], ["`"]),
+    "go": ([_BACKTICK, _DOUBLE, _SINGLE], ["`"]),
+    "jvm": ([_TRIPLE_DOUBLE, _DOUBLE, _SINGLE], ['"""']),
+    "c": ([_DOUBLE, _SINGLE], []),
+    "script": ([_DOUBLE, _SINGLE], []),
+}
+
+_EXTENSION_FAMILIES: Dict[str, str] = {
+    "py": "python",
+    "pyi": "python",
+    "js": "javascript",
+    "jsx": "javascript",
+    "mjs": "javascript",
+    "cjs": "javascript",
+    "ts": "javascript",
+    "tsx": "javascript",
+    "vue": "javascript",
+    "svelte": "javascript",
+    "go": "go",
+    "java": "jvm",
+    "kt": "jvm",
+    "kts": "jvm",
+    "scala": "jvm",
+    "groovy": "jvm",
+    "swift": "jvm",
+    "jl": "jvm",
+    "c": "c",
+    "h": "c",
+    "cpp": "c",
+    "cc": "c",
+    "cxx": "c",
+    "hpp": "c",
+    "m": "c",
+    "mm": "c",
+    "cs": "c",
+    "rs": "c",
+    "dart": "c",
+    "zig": "c",
+    "rb": "script",
+    "php": "script",
+    "pl": "script",
+    "lua": "script",
+    "r": "script",
+    "cbl": "script",
+    "cob": "script",
+    "cpy": "script",
+    "sh": "script",
+    "bash": "script",
+    "zsh": "script",
+}
+
+
+class PiiScrubber:
+    """Masks PII patterns in chunk text."""
+
+    def __init__(
+        self,
+        builtin_patterns: Optional[List[str]] = None,
+        custom_patterns: Optional[Dict[str, str]] = None,
+        string_literals_only: bool = False,
+    ):
+        """
+        Initialize the scrubber.
+
+        Args:
+            builtin_patterns: Names from BUILTIN_PATTERNS to mask (default: all)
+            custom_patterns: Additional {name: regular expression} to mask
+            string_literals_only: Only mask inside string literals of source files
+
+        Raises:
+            ValueError: If a built-in name is unknown or a custom pattern is invalid
+        """
+        names = (
+            list(BUILTIN_PATTERNS) if builtin_patterns is None else builtin_patterns
+        )
+        self._patterns: List[
+            Tuple[str, Pattern[str], Optional[Callable[[str], bool]]]
+        ] = []
+        for name in names:
+            if name not in BUILTIN_PATTERNS:
+                raise ValueError(f"Unknown built-in PII pattern: {name}")
+            pattern, validator = BUILTIN_PATTERNS[name]
+            self._patterns.append((name, re.compile(pattern), validator))
+        for name, pattern in (custom_patterns or {}).items():
+            try:
+                self._patterns.append((name, re.compile(pattern), None))
+            except re.error as e:
+                raise ValueError(f"Invalid PII pattern '{name}': {e}")
+
+        self.string_literals_only = string_literals_only
+        self._literal_regexes = {
+            family: re.compile("|".join(literals))
+            for family, (literals, _) in _LITERAL_SYNTAX.items()
+        }
+
+        self._lock = threading.Lock()
+        self._matches: Dict[str, int] = {name: 0 for name, _, _ in self._patterns}
+        self._chunks_scrubbed = 0
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["PiiScrubber"]:
+        """Scrubber from indexing.pii_scrubbing, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        scrub_config = getattr(indexing_config, "pii_scrubbing", None)
+        if getattr(scrub_config, "enabled", False) is not True:
+            return None
+        return cls(
+            builtin_patterns=list(scrub_config.builtin_patterns),
+            custom_patterns=dict(scrub_config.custom_patterns),
+            string_literals_only=scrub_config.string_literals_only,
+        )
+
+    def scrub(
+        self,
+        text: str,
+        file_path: Optional[Path] = None,
+        language: Optional[str] = None,
+    ) -> str:
+        """
+        Mask PII in one piece of text.
+
+        Args:
+            text: Chunk text
+            file_path: Source file, used to pick the string literal syntax
+            language: Language token of code embedded in the file, which
+                picks the literal syntax instead of the file's extension
+
+        Returns:
+            Text with every match replaced by ``<PATTERN_NAME>``
+        """
+        family = self._literal_family(file_path, language)
+        if family is None:
+            return self._mask(text)
+
+        literal_regex = self._literal_regexes[family]
+        code_text = literal_regex.sub("", text)
+        if not self._literals_balanced(code_text, _LITERAL_SYNTAX[family][1]):
+            return self._mask(text)
+        return literal_regex.sub(lambda m: self._mask(m.group(0)), text)
+
+    def scrub_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Optional[Path] = None
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks with their "text" and heading path scrubbed."""
+        scrubbed = []
+        for chunk in chunks:
+            text = self.scrub(
+                chunk["text"], file_path, chunk.get(EMBEDDED_LANGUAGE_KEY)
+            )
+            if text != chunk["text"]:
+                chunk = {**chunk, "text": text, PII_SCRUBBED_KEY: True}
+                with self._lock:
+                    self._chunks_scrubbed += 1
+            if chunk.get(HEADING_PATH_KEY):
+                # Headings are stored and embedded apart from the text
+                chunk = {
+                    **chunk,
+                    HEADING_PATH_KEY: [
+                        self.scrub(heading, file_path)
+                        for heading in chunk[HEADING_PATH_KEY]
+                    ],
+                }
+            scrubbed.append(chunk)
+        return scrubbed
+
+    def get_stats(self) -> Dict[str, Any]:
+        """Return masked match counts per pattern."""
+        with self._lock:
+            return {
+                "chunks_scrubbed": self._chunks_scrubbed,
+                "matches": dict(self._matches),
+            }
+
+    def _literal_family(
+        self, file_path: Optional[Path], language: Optional[str] = None
+    ) -> Optional[str]:
+        """Literal syntax family for the file, or None to scrub everything."""
+        if not self.string_literals_only:
+            return None
+        if language is not None:
+            return _EXTENSION_FAMILIES.get(language.lower())
+        if file_path is None:
+            return None
+        return _EXTENSION_FAMILIES.get(file_path.suffix.lstrip(".").lower())
+
+    def _mask(self, text: str) -> str:
+        for name, regex, validator in self._patterns:
+            text = self._mask_pattern(text, name, regex, validator)
+        return text
+
+    def _mask_pattern(
+        self,
+        text: str,
+        name: str,
+        regex: Pattern[str],
+        validator: Optional[Callable[[str], bool]],
+    ) -> str:
+        count = 0
+
+        def replace(match: "re.Match[str]") -> str:
+            nonlocal count
+            if validator is not None and not validator(match.group(0)):
+                return match.group(0)
+            count += 1
+            return f"<{name.upper()}>"
+
+        text = regex.sub(replace, text)
+        if count:
+            with self._lock:
+                self._matches[name] += count
+        return text
+
+    @staticmethod
+    def _literals_balanced(code_text: str, multiline_delimiters: List[str]) -> bool:
+        """
+        Whether no literal is cut by the chunk's start or end.
+
+        Args:
+            code_text: Chunk text with all complete string literals removed
+            multiline_delimiters: Delimiters of literals that may span lines
+        """
+        if any(delimiter in code_text for delimiter in multiline_delimiters):
+            return False
+        # Fixed-size chunks can start or end mid-line, cutting a literal
+        lines = code_text.split("\n")
+        boundary_lines = (lines[0], lines[-1])
+        return not any(q in line for line in boundary_lines for q in "\"'")
@@ -1,21 +1,55 @@
 """
 Progressive metadata manager for resumable indexing operations.
+
+Per-file progress updates made inside batched_writes() are not written to
+metadata.json one by one. They are buffered and periodically appended to a
+journal (metadata.json.journal, one JSON record per line, fsynced per flush);
+metadata.json itself is rewritten atomically only when the batch ends or the
+journal grows large. Loading replays journal records newer than the
+"journal_seq" stored in metadata.json, so a crash loses at most the updates
+since the last flush - those files are simply re-processed on resume.
 """
 
 import json
+import logging
+import os
 import time
-import fcntl
+from contextlib import contextmanager
 from pathlib import Path
-from typing import Dict, Any, Optional, List
+from typing import Dict, Any, Iterator, Optional, List
 from datetime import datetime, timezone
 
+from ..utils.file_lock import lock_file
+
+logger = logging.getLogger(__name__)
+
+METADATA_JOURNAL_SUFFIX = ".journal"
+DEFAULT_FLUSH_INTERVAL_SECONDS = 2.0
+DEFAULT_FLUSH_EVERY_UPDATES = 500
+JOURNAL_COMPACT_RECORDS = 20000
+
 
 class ProgressiveMetadata:
     """Manages progressive metadata for resumable indexing."""
 
     def __init__(self, metadata_path: Path):
         self.metadata_path = metadata_path
+        self.journal_path = metadata_path.with_name(
+            metadata_path.name + METADATA_JOURNAL_SUFFIX
+        )
+
+        # Batched write state (see batched_writes())
+        self._batch_depth = 0
+        self._flush_interval_seconds = DEFAULT_FLUSH_INTERVAL_SECONDS
+        self._flush_every_updates = DEFAULT_FLUSH_EVERY_UPDATES
+        self._pending_records: List[Dict[str, Any]] = []
+        self._journal_records = 0
+        self._needs_full_write = False
+        self._last_flush = 0.0
+
         self.metadata = self._load_metadata()
+        self._journal_seq = int(self.metadata.get("journal_seq", 0))
+        self._replay_journal()
 
     def _load_metadata(self) -> Dict[str, Any]:
         """Load existing metadata or create empty structure."""
@@ -60,12 +94,138 @@ class ProgressiveMetadata:
         return default_metadata
 
     def _save_metadata(self):
-        """Save metadata to disk."""
+        """Save metadata to disk (deferred to the next flush inside a batch)."""
+        if self._batch_depth > 0:
+            self._needs_full_write = True
+            self._maybe_flush()
+        else:
+            self._write_metadata()
+
+    def _write_metadata(self) -> None:
+        """Atomically write the full metadata and drop the now-applied journal."""
         # Ensure parent directory exists
         self.metadata_path.parent.mkdir(parents=True, exist_ok=True)
 
-        with open(self.metadata_path, "w") as f:
+        self.metadata["journal_seq"] = self._journal_seq
+        temp_path = self.metadata_path.with_name(self.metadata_path.name + ".tmp")
+        with open(temp_path, "w") as f:
             json.dump(self.metadata, f, indent=2)
+            f.flush()
+            os.fsync(f.fileno())
+        temp_path.replace(self.metadata_path)
+
+        # Journal records are covered by journal_seq now; a leftover journal
+        # (crash before unlink) is skipped on replay
+        self.journal_path.unlink(missing_ok=True)
+        self._pending_records = []
+        self._journal_records = 0
+        self._needs_full_write = False
+        self._last_flush = time.time()
+
+    @contextmanager
+    def batched_writes(
+        self,
+        flush_interval_seconds: float = DEFAULT_FLUSH_INTERVAL_SECONDS,
+        flush_every_updates: int = DEFAULT_FLUSH_EVERY_UPDATES,
+    ) -> Iterator["ProgressiveMetadata"]:
+        """Batch metadata writes for a run of per-file updates.
+
+        Updates are journaled every flush_every_updates updates or
+        flush_interval_seconds, whichever comes first, and metadata.json is
+        rewritten once when the outermost batch exits (also on exceptions).
+        """
+        if self._batch_depth == 0:
+            self._flush_interval_seconds = flush_interval_seconds
+            self._flush_every_updates = flush_every_updates
+            self._last_flush = time.time()
+        self._batch_depth += 1
+        try:
+            yield self
+        finally:
+            self._batch_depth -= 1
+            if self._batch_depth == 0 and (
+                self._pending_records or self._journal_records or self._needs_full_write
+            ):
+                self._write_metadata()
+
+    def flush(self) -> None:
+        """Persist buffered updates now (journal append or full write)."""
+        if self._needs_full_write or (
+            self._journal_records + len(self._pending_records)
+            > JOURNAL_COMPACT_RECORDS
+        ):
+            self._write_metadata()
+            return
+
+        if self._pending_records:
+            self.journal_path.parent.mkdir(parents=True, exist_ok=True)
+            with open(self.journal_path, "a") as f:
+                f.write(
+                    "".join(json.dumps(r) + "\n" for r in self._pending_records)
+                )
+                f.flush()
+                os.fsync(f.fileno())
+            self._journal_records += len(self._pending_records)
+            self._pending_records = []
+        self._last_flush = time.time()
+
+    def _maybe_flush(self) -> None:
+        if (
+            len(self._pending_records) >= self._flush_every_updates
+            or time.time() - self._last_flush >= self._flush_interval_seconds
+        ):
+            self.flush()
+
+    def _record(self, record: Dict[str, Any]) -> None:
+        """Apply a per-file update and persist it (journaled inside a batch)."""
+        self._apply_record(record)
+        if self._batch_depth > 0:
+            self._journal_seq += 1
+            record["seq"] = self._journal_seq
+            self._pending_records.append(record)
+            self._maybe_flush()
+        else:
+            self._write_metadata()
+
+    def _apply_record(self, record: Dict[str, Any]) -> None:
+        op = record["op"]
+        if op == "completed":
+            self._apply_file_completed(record["file"], record["chunks"], record["ts"])
+        elif op == "failed":
+            self._apply_file_failed(record["file"])
+        elif op == "progress":
+            self._apply_progress(
+                record["files"], record["chunks"], record["failed"], record["ts"]
+            )
+        else:
+            raise ValueError(f"Unknown metadata journal operation: {op}")
+
+    def _replay_journal(self) -> None:
+        """Apply journal records written after the last full metadata write."""
+        if not self.journal_path.exists():
+            return
+
+        replayed = 0
+        try:
+            with open(self.journal_path, "r") as f:
+                for line in f:
+                    try:
+                        record = json.loads(line)
+                    except json.JSONDecodeError:
+                        # Torn final line from a crash mid-append
+                        break
+                    if record.get("seq", 0) <= self._journal_seq:
+                        continue
+                    self._apply_record(record)
+                    self._journal_seq = record["seq"]
+                    replayed += 1
+        except (OSError, KeyError, ValueError) as e:
+            logger.warning(
+                f"Stopped replaying metadata journal {self.journal_path}: {e}"
+            )
+
+        if replayed:
+            logger.info(f"Replayed {replayed} metadata journal records")
 
     def start_indexing(
         self, provider_name: str, model_name: str, git_status: Dict[str, Any]
@@ -92,16 +252,25 @@ class ProgressiveMetadata:
         self, files_processed: int = 0, chunks_added: int = 0, failed_files: int = 0
     ):
         """Update progress counters and timestamp after each file."""
-        current_timestamp = time.time()
+        # Saved after every update (journaled inside batched_writes())
+        self._record(
+            {
+                "op": "progress",
+                "files": files_processed,
+                "chunks": chunks_added,
+                "failed": failed_files,
+                "ts": time.time(),
+            }
+        )
 
-        self.metadata["last_index_timestamp"] = current_timestamp
+    def _apply_progress(
+        self, files_processed: int, chunks_added: int, failed_files: int, ts: float
+    ) -> None:
+        self.metadata["last_index_timestamp"] = ts
         self.metadata["files_processed"] += files_processed
         self.metadata["chunks_indexed"] += chunks_added
         self.metadata["failed_files"] += failed_files
 
-        # Save after every update for resumability
-        self._save_metadata()
-
     def complete_indexing(self):
         """Mark indexing as completed."""
         self.metadata["status"] = "completed"
@@ -247,9 +416,21 @@ class ProgressiveMetadata:
 
     def mark_file_completed(self, file_path: str, chunks_count: int = 0) -> None:
         """Mark a file as successfully processed."""
+        self._record(
+            {
+                "op": "completed",
+                "file": str(file_path),
+                "chunks": chunks_count,
+                "ts": time.time(),
+            }
+        )
+
+    def _apply_file_completed(
+        self, file_path: str, chunks_count: int, ts: float
+    ) -> None:
         completed_files = self.metadata.get("completed_files", [])
-        if str(file_path) not in completed_files:
-            completed_files.append(str(file_path))
+        if file_path not in completed_files:
+            completed_files.append(file_path)
             self.metadata["completed_files"] = completed_files
 
         # Advance the current file index
@@ -262,14 +443,14 @@ class ProgressiveMetadata:
         self.metadata["chunks_indexed"] = (
             self.metadata.get("chunks_indexed", 0) + chunks_count
         )
-        self.metadata["last_index_timestamp"] = time.time()
-
-        self._save_metadata()
+        self.metadata["last_index_timestamp"] = ts
 
     def mark_file_failed(self, file_path: str, error: str = "") -> None:
         """Mark a file as failed during processing."""
+        self._record({"op": "failed", "file": str(file_path)})
+
+    def _apply_file_failed(self, file_str: str) -> None:
         failed_files = self.metadata.get("failed_file_paths", [])
-        file_str = str(file_path)
 
         if file_str not in failed_files:
             failed_files.append(file_str)
@@ -283,8 +464,6 @@ class ProgressiveMetadata:
         # Update failed files count
         self.metadata["failed_files"] = len(failed_files)
 
-        self._save_metadata()
-
     def can_resume_interrupted_operation(self) -> bool:
         """Check if there's an interrupted indexing operation that can be resumed."""
         return (
@@ -308,7 +487,7 @@ class ProgressiveMetadata:
 
             with open(self.metadata_path, "r+") as f:
                 # Acquire exclusive lock
-                fcntl.flock(f.fileno(), fcntl.LOCK_EX)
+                lock_file(f)
 
                 # Read current metadata
                 f.seek(0)
@@ -345,7 +524,7 @@ class ProgressiveMetadata:
 
                 with open(self.metadata_path, "r") as f:
                     # Try to acquire shared lock (non-blocking)
-                    fcntl.flock(f.fileno(), fcntl.LOCK_SH | fcntl.LOCK_NB)
+                    lock_file(f, exclusive=False, blocking=False)
                     data = json.load(f)
                     branch = data.get("current_branch", fallback)
                     return str(branch) if branch is not None else fallback
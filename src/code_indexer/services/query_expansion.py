@@ -0,0 +1,144 @@
+"""
+HyDE query expansion of semantic queries.
+
+A vague question ("where do we throttle outgoing requests") is phrased
+nothing like the code answering it. With 'cidx query --hyde', the question is
+first sent to an LLM command, which drafts a short hypothetical code snippet
+answering it; the snippet is embedded in place of the query, or (the
+default "fuse" mode) averaged with the query's embedding so the literal terms
+of the question keep their weight. The search itself is unchanged.
+
+The LLM command is query_expansion.llm_command, or query_generation.llm_command
+when that is not set. A failing command falls back to the plain query.
+"""
+
+import logging
+import math
+import shlex
+from typing import Any, List, Optional
+
+from .query_generation import run_llm_command
+
+logger = logging.getLogger(__name__)
+
+HYDE_MODES = ("fuse", "replace")
+
+# Hypothetical snippets beyond this many characters are cut off
+MAX_HYPOTHETICAL_CHARS = 4000
+
+HYDE_PROMPT = """\
+A developer searches a code base for: {query}
+
+Write a short code snippet (at most 30 lines) that could be the code they \
+are looking for, with the function, type and variable names such code \
+would plausibly use and a one-line doc comment. Output only the code, \
+without explanations or Markdown fences.
+"""
+
+
+class HydeExpander:
+    """Drafts hypothetical code answering a query with an LLM command."""
+
+    def __init__(self, command: str, timeout_seconds: int = 60):
+        """
+        Initialize the expander.
+
+        Args:
+            command: Command that reads the prompt on stdin and prints the
+                snippet (e.g. "claude --print")
+            timeout_seconds: Timeout of one command run
+        """
+        self.command = shlex.split(command)
+        if not self.command:
+            raise ValueError("query_expansion.llm_command is empty")
+        self.timeout_seconds = timeout_seconds
+
+    @classmethod
+    def from_config(
+        cls, config: Any, command: Optional[str] = None
+    ) -> "HydeExpander":
+        """
+        Expander for 'cidx query --hyde'.
+
+        Args:
+            config: Project configuration
+            command: LLM command overriding the configured commands
+
+        Raises:
+            ValueError: If no LLM command is configured
+        """
+        settings = config.query_expansion
+        command = (
+            command or settings.llm_command or config.query_generation.llm_command
+        )
+        if not command:
+            raise ValueError(
+                "No LLM command configured - set query_expansion.llm_command "
+                "or query_generation.llm_command"
+            )
+        return cls(command, timeout_seconds=settings.timeout_seconds)
+
+    def expand(self, query: str) -> str:
+        """
+        Hypothetical code answering the query.
+
+        Raises:
+            RuntimeError: If the command fails or prints nothing
+        """
+        output = run_llm_command(
+            self.command, HYDE_PROMPT.format(query=query), self.timeout_seconds
+        )
+        lines = output.strip().splitlines()
+        if lines and lines[0].startswith("```"):
+            lines = lines[1:]
+        if lines and lines[-1].startswith("```"):
+            lines = lines[:-1]
+        snippet = "\n".join(lines).strip()
+        if not snippet:
+            raise RuntimeError(f"LLM command {self.command[0]!r} printed nothing")
+        return snippet[:MAX_HYPOTHETICAL_CHARS]
+
+
+def fuse_embeddings(vectors: List[List[float]]) -> List[float]:
+    """Mean of the unit-length vectors, scaled to unit length."""
+    total = [0.0] * len(vectors[0])
+    for vector in vectors:
+        norm = math.sqrt(sum(value * value for value in vector)) or 1.0
+        for i, value in enumerate(vector):
+            total[i] += value / norm
+    norm = math.sqrt(sum(value * value for value in total)) or 1.0
+    return [value / norm for value in total]
+
+
+class HydeEmbeddingProvider:
+    """
+    Embedding provider embedding the hypothetical code of queries.
+
+    Wraps the provider a search embeds its query with; everything but
+    get_embedding is passed through.
+    """
+
+    def __init__(self, provider: Any, expander: HydeExpander, mode: str = "fuse"):
+        if mode not in HYDE_MODES:
+            raise ValueError(f"HyDE mode must be one of {', '.join(HYDE_MODES)}")
+        self.provider = provider
+        self.expander = expander
+        self.mode = mode
+        # Snippet of the last query, or the error that prevented it
+        self.hypothetical: Optional[str] = None
+        self.error: Optional[str] = None
+
+    def __getattr__(self, name: str) -> Any:
+        return getattr(self.provider, name)
+
+    def get_embedding(self, text: str, model: Optional[str] = None) -> List[float]:
+        try:
+            self.hypothetical = self.expander.expand(text)
+        except RuntimeError as e:
+            logger.warning("HyDE expansion failed, using the plain query: %s", e)
+            self.error = str(e)
+            return list(self.provider.get_embedding(text, model))
+        if self.mode == "replace":
+            return list(self.provider.get_embedding(self.hypothetical, model))
+        vectors = self.provider.get_embeddings_batch([text, self.hypothetical], model)
+        return fuse_embeddings(vectors)
@@ -0,0 +1,220 @@
+"""
+Filter expressions of queries.
+
+'cidx query --filter', the REST query endpoint and the MCP search_code tool
+accept one expression in place of a combination of filter flags:
+
+    lang:go AND path:internal/** AND kind:function AND NOT generated
+    (owner:@org/payments OR owner:@org/billing) AND complexity>=10
+
+An expression combines terms with AND, OR, NOT and parentheses (NOT binds
+tightest, then AND, then OR; adjacent terms without an operator are ANDed).
+Keywords are case-insensitive. A term is one of:
+
+- field:value, matching a payload field (values with spaces in double quotes)
+- metric op number, comparing a chunk metric (op is one of > >= < <= = :)
+- flag, a boolean payload field
+
+The expression is parsed into the nested must/should/must_not payload filter
+of the vector stores.
+"""
+
+import re
+from typing import Any, Callable, Dict, List, Optional, Set
+
+from ..indexing.boundary_chunking import SYMBOL_KIND_KEY
+from .code_metrics import (
+    COMPLEXITY_KEY,
+    LOC_KEY,
+    NESTING_DEPTH_KEY,
+    PARAMETER_COUNT_KEY,
+)
+from .code_owners import OWNERS_KEY, normalize_owner
+from .generated_code import GENERATED_KEY
+from .go_build_constraints import BUILD_TAGS_KEY
+from .go_dependencies import GO_MODULES_KEY
+from .go_interfaces import IMPLEMENTS_KEY
+from .language_mapper import LanguageMapper
+from .license_detection import LICENSE_KEY, canonical_license_id
+from .struct_tags import STRUCT_TAGS_KEY, normalize_struct_tag
+from .test_linkage import IS_TEST_KEY
+
+
+class FilterSyntaxError(ValueError):
+    """Raised for a filter expression that cannot be parsed."""
+
+
+def _value_condition(key: str, normalize: Callable[[str], Any]) -> Callable:
+    def condition(value: str) -> Dict[str, Any]:
+        return {"key": key, "match": {"value": normalize(value)}}
+
+    return condition
+
+
+def _language_condition(value: str) -> Dict[str, Any]:
+    return LanguageMapper().build_language_filter(value.lower())
+
+
+def _path_condition(value: str) -> Dict[str, Any]:
+    return {"key": "path", "match": {"text": value.replace("\\", "/")}}
+
+
+# field:value terms
+FIELDS: Dict[str, Callable[[str], Dict[str, Any]]] = {
+    "lang": _language_condition,
+    "language": _language_condition,
+    "path": _path_condition,
+    "kind": _value_condition(SYMBOL_KIND_KEY, str.lower),
+    "license": _value_condition(LICENSE_KEY, canonical_license_id),
+    "owner": _value_condition(OWNERS_KEY, normalize_owner),
+    "tag": _value_condition(STRUCT_TAGS_KEY, normalize_struct_tag),
+    "build-tag": _value_condition(BUILD_TAGS_KEY, str.strip),
+    "module": _value_condition(GO_MODULES_KEY, str.strip),
+    "implements": _value_condition(IMPLEMENTS_KEY, str.strip),
+}
+# metric>=number terms
+METRICS: Dict[str, str] = {
+    "complexity": COMPLEXITY_KEY,
+    "loc": LOC_KEY,
+    "nesting": NESTING_DEPTH_KEY,
+    "params": PARAMETER_COUNT_KEY,
+}
+# Bare flag terms
+FLAGS: Dict[str, str] = {
+    "generated": GENERATED_KEY,
+    "test": IS_TEST_KEY,
+}
+
+_RANGES = {">": "gt", ">=": "gte", "<": "lt", "<=": "lte"}
+_TOKEN = re.compile(r'\s*(\(|\)|(?:[^\s()"]+|"[^"]*")+)')
+_TERM = re.compile(r"^([A-Za-z][\w-]*)(>=|<=|>|<|=|:)(.+)$")
+_KEYWORDS = {"AND", "OR", "NOT"}
+
+
+def _tokenize(expression: str) -> List[str]:
+    tokens = []
+    position = 0
+    expression = expression.rstrip()
+    while position < len(expression):
+        match = _TOKEN.match(expression, position)
+        if not match:
+            raise FilterSyntaxError(
+                f"unterminated quote at position {position + 1} in filter"
+            )
+        tokens.append(match.group(1))
+        position = match.end()
+    return tokens
+
+
+def _term(token: str) -> Dict[str, Any]:
+    if token.lower() in FLAGS:
+        return {"key": FLAGS[token.lower()], "match": {"value": True}}
+    match = _TERM.match(token)
+    if not match:
+        raise FilterSyntaxError(f"'{token}' is not a filter term")
+    field, op, value = match.group(1).lower(), match.group(2), match.group(3)
+    value = value[1:-1] if len(value) > 1 and value[0] == value[-1] == '"' else value
+    if field in METRICS:
+        try:
+            number = int(value)
+        except ValueError:
+            raise FilterSyntaxError(f"{field} needs a whole number, got '{value}'")
+        if op in _RANGES:
+            return {"key": METRICS[field], "range": {_RANGES[op]: number}}
+        return {"key": METRICS[field], "range": {"gte": number, "lte": number}}
+    if field not in FIELDS:
+        raise FilterSyntaxError(
+            f"unknown filter field '{field}' (fields: "
+            f"{', '.join(sorted(FIELDS))}; metrics: {', '.join(sorted(METRICS))}; "
+            f"flags: {', '.join(sorted(FLAGS))})"
+        )
+    if op != ":":
+        raise FilterSyntaxError(f"{field} takes field:value, got '{token}'")
+    if not value:
+        raise FilterSyntaxError(f"{field} needs a value")
+    return FIELDS[field](value)
+
+
+class _Parser:
+    """Recursive descent over the tokens of an expression."""
+
+    def __init__(self, tokens: List[str]):
+        self.tokens = tokens
+        self.position = 0
+
+    def peek(self) -> Optional[str]:
+        if self.position < len(self.tokens):
+            return self.tokens[self.position]
+        return None
+
+    def keyword(self) -> Optional[str]:
+        token = self.peek()
+        return token.upper() if token and token.upper() in _KEYWORDS else None
+
+    def take(self) -> str:
+        token = self.peek()
+        if token is None:
+            raise FilterSyntaxError("filter ends where a term was expected")
+        self.position += 1
+        return token
+
+    def parse_or(self) -> Dict[str, Any]:
+        operands = [self.parse_and()]
+        while self.keyword() == "OR":
+            self.take()
+            operands.append(self.parse_and())
+        return operands[0] if len(operands) == 1 else {"should": operands}
+
+    def parse_and(self) -> Dict[str, Any]:
+        operands = [self.parse_not()]
+        while self.peek() not in (None, ")") and self.keyword() != "OR":
+            if self.keyword() == "AND":
+                self.take()
+            operands.append(self.parse_not())
+        return operands[0] if len(operands) == 1 else {"must": operands}
+
+    def parse_not(self) -> Dict[str, Any]:
+        if self.keyword() == "NOT":
+            self.take()
+            return {"must_not": [self.parse_not()]}
+        token = self.take()
+        if token == "(":
+            condition = self.parse_or()
+            if self.peek() != ")":
+                raise FilterSyntaxError("missing ')' in filter")
+            self.take()
+            return condition
+        if token == ")" or token.upper() in _KEYWORDS:
+            raise FilterSyntaxError(f"unexpected '{token}' in filter")
+        return _term(token)
+
+
+def parse_filter(expression: str) -> Dict[str, Any]:
+    """
+    Payload filter condition of a filter expression.
+
+    Raises:
+        FilterSyntaxError: If the expression is empty or malformed
+    """
+    tokens = _tokenize(expression)
+    if not tokens:
+        raise FilterSyntaxError("filter is empty")
+    parser = _Parser(tokens)
+    condition = parser.parse_or()
+    if parser.peek() is not None:
+        raise FilterSyntaxError(f"unexpected '{parser.peek()}' in filter")
+    return condition
+
+
+def filter_keys(condition: Dict[str, Any]) -> Set[str]:
+    """Payload keys a filter condition tests."""
+    keys: Set[str] = set()
+    pending: List[Any] = [condition]
+    while pending:
+        current = pending.pop()
+        if "key" in current:
+            keys.add(current["key"])
+        for clause in ("must", "should", "must_not"):
+            pending.extend(current.get(clause, []))
+    return keys
+
@@ -0,0 +1,338 @@
+"""
+Synthetic query generation behind ``cidx generate-queries``.
+
+Evaluating retrieval quality needs a golden set of queries labeled with the
+chunks that answer them, and writing hundreds of those by hand is slow. This
+module bootstraps one from the index: for every indexed chunk (or a sample of
+them) it generates candidate natural-language queries, each labeled with the
+chunk it came from.
+
+Two generators are available:
+
+- TemplateQueryGenerator turns the chunk's doc comments and the names of the
+  symbols it defines into queries ("how does retry payment work"). It needs
+  no network access and is deterministic.
+- LlmQueryGenerator sends the chunk to the command configured as
+  query_generation.llm_command (for example ``claude --print``) and reads
+  one query per line from its output.
+
+Generated sets are candidates: review and prune them before treating them as
+ground truth. Queries produced for several chunks are merged into one entry
+that expects all of them.
+"""
+
+import fnmatch
+import logging
+import random
+import re
+import shlex
+import subprocess
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+from .relevance_feedback import chunk_text, defined_symbols
+from ..utils.git_runner import get_current_branch
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_SAMPLE_SEED = 42
+
+# Chunk text beyond this many characters is not sent to the LLM
+MAX_PROMPT_CHARS = 6000
+
+# Doc comments and queries with fewer words are too vague to be useful
+MIN_QUERY_WORDS = 3
+
+SYMBOL_TEMPLATES = (
+    "how does {words} work",
+    "where is {words} implemented",
+    "code that handles {words}",
+)
+
+LLM_PROMPT = """\
+Write {count} different questions or search phrases a developer might type \
+into a code search tool when the code below, from {path}, is the answer \
+they are looking for. Describe what the code does in plain words instead of \
+repeating its identifiers. Output one query per line, without numbering or \
+quotes.
+
+```
+{text}
+```
+"""
+
+_DOCSTRING = re.compile(r"(?P<quote>\"\"\"|''')(?P<body>.*?)(?P=quote)", re.DOTALL)
+_COMMENT_LINE = re.compile(r"^\s*(?:\*/|///?|#|--|/\*\*?|\*)\s?(?P<body>.*)$")
+_NOISE = re.compile(
+    r"SPDX-License|copyright|license|TODO|FIXME|HACK|XXX|-\*-|^!|noqa|"
+    r"type:\s*ignore|eslint|pylint|@param|@return",
+    re.IGNORECASE,
+)
+_IDENTIFIER_PARTS = re.compile(r"[A-Z]+(?=[A-Z][a-z])|[A-Z]?[a-z]+\d*|[A-Z]+\d*|\d+")
+_LIST_MARKER = re.compile(r"^\s*(?:[-*•]|\d+[.)])\s*")
+
+
+@dataclass
+class GeneratedQuery:
+    """A candidate evaluation query and the chunks expected to answer it."""
+
+    query: str
+    source: str
+    expected: List[Dict[str, Any]] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"query": self.query, "expected": self.expected, "source": self.source}
+
+
+def split_identifier(name: str) -> str:
+    """Words of an identifier: "retryPaymentHTTP_v2" becomes "retry payment http v2"."""
+    return " ".join(
+        part.lower()
+        for piece in re.split(r"[_\W]+", name)
+        for part in _IDENTIFIER_PARTS.findall(piece)
+    )
+
+
+def doc_comments(text: str) -> List[str]:
+    """
+    First sentences of the doc comments and comment blocks of chunk text.
+
+    Comments that are license headers, task markers or tool directives are
+    skipped, as are sentences shorter than MIN_QUERY_WORDS words.
+    """
+    blocks = [match.group("body") for match in _DOCSTRING.finditer(text or "")]
+    current: List[str] = []
+    for line in (text or "").splitlines():
+        match = _COMMENT_LINE.match(line)
+        if match:
+            current.append(match.group("body").replace("*/", "").strip())
+        elif current:
+            blocks.append(" ".join(current))
+            current = []
+    if current:
+        blocks.append(" ".join(current))
+
+    sentences: Dict[str, None] = {}
+    for block in blocks:
+        flat = " ".join(block.split())
+        sentence = re.split(r"(?<=[.!?])\s", flat, maxsplit=1)[0].rstrip(".!:; ")
+        if len(sentence.split()) < MIN_QUERY_WORDS or _NOISE.search(sentence):
+            continue
+        sentences.setdefault(sentence, None)
+    return list(sentences)
+
+
+class TemplateQueryGenerator:
+    """Queries from doc comments and defined symbol names."""
+
+    source = "template"
+
+    def generate(self, text: str, path: str, count: int) -> List[str]:
+        """
+        Candidate queries for a chunk.
+
+        Args:
+            text: Chunk text
+            path: Project-relative path of the chunk's file
+            count: Maximum number of queries
+
+        Returns:
+            Doc comment sentences first, then symbol templates
+        """
+        queries = doc_comments(text)
+        for index, symbol in enumerate(defined_symbols(text)):
+            if len(symbol) < 4 or symbol.startswith("__"):
+                continue  # Too short or a dunder method to describe anything
+            template = SYMBOL_TEMPLATES[index % len(SYMBOL_TEMPLATES)]
+            queries.append(template.format(words=split_identifier(symbol)))
+        return _unique(queries)[:count]
+
+
+class LlmQueryGenerator:
+    """Queries written by an LLM command line tool."""
+
+    source = "llm"
+
+    def __init__(self, command: str, timeout_seconds: int = 120):
+        """
+        Initialize the generator.
+
+        Args:
+            command: Command that reads the prompt on stdin and prints the
+                queries, one per line (e.g. "claude --print")
+            timeout_seconds: Timeout of one command run
+        """
+        self.command = shlex.split(command)
+        if not self.command:
+            raise ValueError("query_generation.llm_command is empty")
+        self.timeout_seconds = timeout_seconds
+
+    def generate(self, text: str, path: str, count: int) -> List[str]:
+        """
+        Candidate queries for a chunk.
+
+        Raises:
+            RuntimeError: If the command cannot be run, times out or fails
+        """
+        prompt = LLM_PROMPT.format(
+            count=count, path=path, text=text[:MAX_PROMPT_CHARS]
+        )
+        output = run_llm_command(self.command, prompt, self.timeout_seconds)
+        queries = []
+        for line in output.splitlines():
+            query = _LIST_MARKER.sub("", line).strip().strip("\"'`").strip()
+            if len(query.split()) >= MIN_QUERY_WORDS:
+                queries.append(query)
+        return _unique(queries)[:count]
+
+
+def run_llm_command(command: List[str], prompt: str, timeout_seconds: int) -> str:
+    """
+    Output of an LLM command given the prompt on stdin.
+
+    Raises:
+        RuntimeError: If the command cannot be run, times out or fails
+    """
+    try:
+        result = subprocess.run(
+            command,
+            input=prompt,
+            capture_output=True,
+            text=True,
+            timeout=timeout_seconds,
+        )
+    except (OSError, subprocess.TimeoutExpired) as e:
+        raise RuntimeError(f"LLM command {command[0]!r} failed: {e}") from e
+    if result.returncode != 0:
+        raise RuntimeError(
+            f"LLM command {command[0]!r} exited with {result.returncode}: "
+            f"{result.stderr.strip()[:500]}"
+        )
+    return str(result.stdout)
+
+
+def generator_from_config(config: Any, use_llm: bool, command: Optional[str] = None):
+    """
+    Generator for ``cidx generate-queries``.
+
+    Args:
+        config: Project configuration
+        use_llm: Use the LLM generator instead of templates
+        command: LLM command overriding query_generation.llm_command
+
+    Raises:
+        ValueError: If the LLM generator is requested but no command is set
+    """
+    settings = config.query_generation
+    if not use_llm:
+        return TemplateQueryGenerator()
+    command = command or settings.llm_command
+    if not command:
+        raise ValueError(
+            "No LLM command configured - set query_generation.llm_command "
+            "or pass --llm-command"
+        )
+    return LlmQueryGenerator(command, timeout_seconds=settings.timeout_seconds)
+
+
+def indexed_chunks(
+    vector_store: Any,
+    project_root: Path,
+    path_filter: Optional[str] = None,
+) -> List[Dict[str, Any]]:
+    """
+    Chunks visible on the current branch, sorted by path and line.
+
+    Args:
+        vector_store: FilesystemVectorStore holding the collections
+        project_root: Project the collections were indexed from
+        path_filter: Path prefix ("pkg/") or glob pattern ("*/api/*.go")
+
+    Returns:
+        Point dicts ({"id", "payload", ...}), one per (path, line range)
+    """
+    from ..storage.temporal_metadata_store import TemporalMetadataStore
+
+    current_branch = get_current_branch(project_root)
+    chunks: Dict[Tuple[str, int, int], Dict[str, Any]] = {}
+    for collection_name in vector_store.list_collections():
+        if TemporalMetadataStore.is_temporal_collection(collection_name):
+            continue
+        for _, data, _ in vector_store.iter_vector_records(collection_name):
+            payload = (data or {}).get("payload", {})
+            path = str(payload.get("path") or "")
+            if not path:
+                continue
+            if current_branch and current_branch in payload.get("hidden_branches", []):
+                continue
+            if path_filter and not _path_matches(path, path_filter):
+                continue
+            key = (
+                path,
+                int(payload.get("line_start") or 0),
+                int(payload.get("line_end") or 0),
+            )
+            chunks.setdefault(key, data)
+    return [chunks[key] for key in sorted(chunks)]
+
+
+def generate_queries(
+    chunks: Iterable[Dict[str, Any]],
+    project_root: Path,
+    generator: Any,
+    queries_per_chunk: int = 3,
+    sample: Optional[int] = None,
+    seed: int = DEFAULT_SAMPLE_SEED,
+) -> List[GeneratedQuery]:
+    """
+    Generate candidate evaluation queries for indexed chunks.
+
+    Args:
+        chunks: Point dicts, see indexed_chunks()
+        project_root: Project root, for chunks without stored text
+        generator: TemplateQueryGenerator or LlmQueryGenerator
+        queries_per_chunk: Maximum queries per chunk
+        sample: Only this many randomly chosen chunks (default: all)
+        seed: Seed of the sample, so runs are repeatable
+
+    Returns:
+        Queries in order of first generation; a query generated for several
+        chunks expects all of them
+    """
+    chunks = list(chunks)
+    if sample is not None and sample < len(chunks):
+        chunks = random.Random(seed).sample(chunks, sample)
+
+    queries: Dict[str, GeneratedQuery] = {}
+    for point in chunks:
+        text = chunk_text(point, project_root)
+        if not text.strip():
+            continue
+        payload = point.get("payload", {})
+        location = {
+            "path": payload["path"],
+            "line_start": payload.get("line_start"),
+            "line_end": payload.get("line_end"),
+        }
+        for query in generator.generate(text, payload["path"], queries_per_chunk):
+            entry = queries.setdefault(
+                query.lower(), GeneratedQuery(query=query, source=generator.source)
+            )
+            if location not in entry.expected:
+                entry.expected.append(location)
+    return list(queries.values())
+
+
+def _unique(queries: List[str]) -> List[str]:
+    seen: Dict[str, str] = {}
+    for query in queries:
+        seen.setdefault(query.lower(), query)
+    return list(seen.values())
+
+
+def _path_matches(path: str, path_filter: str) -> bool:
+    if any(char in path_filter for char in "*?["):
+        return fnmatch.fnmatch(path, path_filter)
+    prefix = path_filter.strip("/")
+    return path == prefix or path.startswith(prefix + "/")
@@ -0,0 +1,181 @@
+"""
+Local store of per-stage query latencies behind ``cidx stats --latency``.
+
+Every semantic query appends one JSON line with its stage timings to
+``.code-indexer/query_latency.jsonl``:
+
+- filter_build: building language/path filter conditions
+- vector_search: query embedding, index load and HNSW search
+- payload_fetch: loading candidate payloads and chunk content
+- rerank: result reranking (only recorded when a query reranks)
+- render: printing results to the terminal
+
+The file keeps the most recent MAX_RECORDS queries, so percentiles reflect
+current performance and the file never grows without bound. Recording is best
+effort: a query never fails because its timings could not be written.
+"""
+
+import json
+import logging
+import time
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+from .benchmark import percentile
+
+logger = logging.getLogger(__name__)
+
+# Constants
+QUERY_LATENCY_FILENAME = "query_latency.jsonl"
+MAX_RECORDS = 1000
+QUERY_STAGES = (
+    "filter_build_ms",
+    "vector_search_ms",
+    "payload_fetch_ms",
+    "rerank_ms",
+    "render_ms",
+)
+TOTAL_KEY = "total_ms"
+REPORTED_PERCENTILES = (50, 95, 99)
+
+
+def stage_timings_from_query(
+    timing_info: Dict[str, Any], render_ms: Optional[float] = None
+) -> Dict[str, float]:
+    """
+    Map the query command's timing telemetry onto the recorded stages.
+
+    Args:
+        timing_info: Timing dict collected by the query path (milliseconds)
+        render_ms: Time spent displaying results, if measured
+
+    Returns:
+        {stage: milliseconds} for the stages the query went through
+    """
+    stages: Dict[str, float] = {}
+
+    if "filter_build_ms" in timing_info:
+        stages["filter_build_ms"] = float(timing_info["filter_build_ms"])
+
+    if "parallel_load_ms" in timing_info:
+        # Embedding and index load run concurrently - count wall clock only
+        stages["vector_search_ms"] = float(
+            timing_info["parallel_load_ms"] + timing_info.get("hnsw_search_ms", 0)
+        )
+    elif "vector_search_ms" in timing_info:
+        stages["vector_search_ms"] = float(timing_info["vector_search_ms"])
+
+    payload_keys = ("candidate_load_ms", "staleness_detection_ms")
+    if any(key in timing_info for key in payload_keys):
+        stages["payload_fetch_ms"] = float(
+            sum(timing_info.get(key, 0) for key in payload_keys)
+        )
+
+    if "rerank_ms" in timing_info:
+        stages["rerank_ms"] = float(timing_info["rerank_ms"])
+
+    if render_ms is not None:
+        stages["render_ms"] = float(render_ms)
+
+    return stages
+
+
+class QueryLatencyStore:
+    """Append-only JSONL store of recent query stage timings."""
+
+    def __init__(self, config_dir: Path, max_records: int = MAX_RECORDS):
+        """
+        Initialize the store.
+
+        Args:
+            config_dir: Project's .code-indexer directory
+            max_records: Number of most recent queries to keep
+        """
+        self.path = config_dir / QUERY_LATENCY_FILENAME
+        self.max_records = max_records
+
+    def record(self, stage_timings: Dict[str, float]) -> bool:
+        """
+        Append one query's stage timings.
+
+        Args:
+            stage_timings: {stage: milliseconds}, see QUERY_STAGES
+
+        Returns:
+            True if the record was written, False on any error
+        """
+        if not stage_timings:
+            return False
+
+        entry: Dict[str, Any] = {"timestamp": time.time()}
+        entry.update({k: round(v, 3) for k, v in stage_timings.items()})
+        entry[TOTAL_KEY] = round(sum(stage_timings.values()), 3)
+
+        try:
+            if not self.path.parent.exists():
+                return False
+            with open(self.path, "a") as f:
+                f.write(json.dumps(entry) + "\n")
+            self._trim()
+            return True
+        except OSError as e:
+            logger.debug(f"Could not record query latency: {e}")
+            return False
+
+    def load(self) -> List[Dict[str, Any]]:
+        """Return recorded queries, oldest first, skipping corrupt lines."""
+        if not self.path.exists():
+            return []
+
+        records = []
+        try:
+            with open(self.path) as f:
+                for line in f:
+                    try:
+                        record = json.loads(line)
+                    except json.JSONDecodeError:
+                        continue
+                    if isinstance(record, dict):
+                        records.append(record)
+        except OSError as e:
+            logger.debug(f"Could not read query latency stats: {e}")
+        return records[-self.max_records :]
+
+    def summarize(self) -> Dict[str, Any]:
+        """
+        Compute latency percentiles per stage.
+
+        Returns:
+            {"queries": n, "stages": {stage: {"count", "p50", "p95", "p99"}}}
+            for every stage plus the per-query total. Stages without samples
+            have a count of 0.
+        """
+        records = self.load()
+        stages: Dict[str, Dict[str, float]] = {}
+        for stage in QUERY_STAGES + (TOTAL_KEY,):
+            values = [
+                float(r[stage])
+                for r in records
+                if isinstance(r.get(stage), (int, float))
+            ]
+            summary: Dict[str, float] = {"count": len(values)}
+            for pct in REPORTED_PERCENTILES:
+                summary[f"p{pct}"] = round(percentile(values, pct), 3)
+            stages[stage] = summary
+        return {"queries": len(records), "stages": stages}
+
+    def clear(self) -> None:
+        """Delete all recorded timings."""
+        self.path.unlink(missing_ok=True)
+
+    def _trim(self) -> None:
+        """Rewrite the file with the newest records once it doubles its cap."""
+        with open(self.path) as f:
+            lines = f.readlines()
+        if len(lines) <= self.max_records * 2:
+            return
+
+        tmp_path = self.path.with_suffix(".tmp")
+        with open(tmp_path, "w") as f:
+            f.writelines(lines[-self.max_records :])
+        tmp_path.replace(self.path)
@@ -0,0 +1,90 @@
+"""
+Semantic search against the files of a commit or tag.
+
+'cidx query "..." --at v2.3.0' answers from the file versions at a ref
+instead of the current branch, without re-indexing. Content points record
+the git blob hash of the file version they were chunked from
+(git_blob_hash), and the index keeps the versions of every indexed branch
+(versions not on the current branch are only hidden). The ref's tree maps
+each path to a blob: the search is restricted to points of those blobs, and
+results are kept when their path and blob both match the tree.
+
+Only file versions that were indexed at some point can be found: the tips
+of indexed branches, and earlier commits whose files have not changed
+since. Files changed after the ref was indexed are missing from results;
+'cidx query --time-range-all' searches the full git history instead.
+"""
+
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List
+
+from ..utils.git_runner import run_git_command
+
+logger = logging.getLogger(__name__)
+
+# Payload key: blob hash of the indexed file version
+BLOB_HASH_KEY = "git_blob_hash"
+
+
+@dataclass
+class RefSnapshot:
+    """The files of a commit: path to blob hash."""
+
+    ref: str
+    commit: str
+    files: Dict[str, str] = field(default_factory=dict)
+
+    def payload_condition(self) -> Dict[str, Any]:
+        """Payload filter condition matching points of the ref's blobs."""
+        return {"key": BLOB_HASH_KEY, "match": {"any": set(self.files.values())}}
+
+    def contains(self, payload: Dict[str, Any]) -> bool:
+        """Whether a point holds the version of its file at the ref."""
+        path = str(payload.get("path", "")).replace("\\", "/")
+        blob = payload.get(BLOB_HASH_KEY)
+        return blob is not None and self.files.get(path) == blob
+
+
+def resolve_ref(project_root: Path, ref: str) -> RefSnapshot:
+    """
+    Files of a commit, tag or branch.
+
+    Raises:
+        ValueError: If the project is not a git repository or the ref does
+            not name a commit
+    """
+    result = run_git_command(
+        ["git", "rev-parse", "--verify", "--quiet", f"{ref}^{{commit}}"],
+        cwd=project_root,
+        check=False,
+    )
+    if result.returncode != 0:
+        raise ValueError(f"'{ref}' is not a commit, tag or branch of this repository")
+    commit = result.stdout.strip()
+
+    tree = run_git_command(
+        ["git", "ls-tree", "-r", "-z", commit], cwd=project_root, check=False
+    )
+    if tree.returncode != 0:
+        raise ValueError(f"Cannot list the files of {ref}: {tree.stderr.strip()}")
+    files = {}
+    for entry in tree.stdout.split("\0"):
+        if "\t" not in entry:
+            continue
+        info, path = entry.split("\t", 1)
+        parts = info.split()
+        if len(parts) == 3 and parts[1] == "blob":
+            files[path] = parts[2]
+    logger.debug(f"Resolved {ref} to {commit} with {len(files)} files")
+    return RefSnapshot(ref=ref, commit=commit, files=files)
+
+
+def filter_results_at_ref(
+    results: List[Dict[str, Any]], snapshot: RefSnapshot
+) -> List[Dict[str, Any]]:
+    """Results (in order) holding the version of their file at the ref."""
+    return [
+        result for result in results if snapshot.contains(result.get("payload", {}))
+    ]
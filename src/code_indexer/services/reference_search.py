@@ -0,0 +1,211 @@
+"""
+Find-references of cidx refs: SCIP when available, semantic otherwise.
+
+When 'cidx scip generate' has produced SCIP databases, references are the
+precise occurrences recorded by the language's SCIP indexer. Without them,
+the semantic index answers: the chunks most similar to the symbol name are
+the candidates, and only their lines using the name as a whole identifier
+are references. The symbol's own declarations (see symbol_declarations)
+are left out. Each reference records which backend found it.
+
+The semantic fallback is lexical within the candidates it retrieves: it
+finds uses of the name, not of the symbol, so a same-named symbol of
+another type or module is also reported, and uses outside the candidate
+chunks are missed.
+"""
+
+import logging
+import re
+from dataclasses import asdict, dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Set, Tuple
+
+from ..storage.vector_kinds import VECTOR_KIND_KEY
+
+logger = logging.getLogger(__name__)
+
+SCIP_BACKEND = "scip"
+SEMANTIC_BACKEND = "semantic"
+
+# Candidate chunks retrieved per reference wanted by the semantic fallback
+_CANDIDATES_PER_REFERENCE = 3
+_MIN_CANDIDATES = 30
+
+_SYMBOL_SEPARATOR = re.compile(r"\.|::|#")
+
+
+@dataclass
+class ReferenceHit:
+    """A use of a symbol (1-based line and column)."""
+
+    path: str
+    line: int
+    column: int
+    context: str
+    backend: str
+    # Similarity of the candidate chunk (semantic backend only)
+    score: Optional[float] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def scip_references(
+    project_root: Path, symbol: str, limit: int, exact: bool = False
+) -> Optional[List[ReferenceHit]]:
+    """
+    References recorded in the project's SCIP databases.
+
+    Returns:
+        References sorted by path and line, or None when there is no SCIP
+        database that could be queried
+    """
+    from ..scip.query import SCIPQueryEngine
+
+    index_files = SCIPQueryEngine.index_files(project_root)
+    hits: List[ReferenceHit] = []
+    sources: Dict[str, List[str]] = {}
+    queried = False
+    for index_file in index_files:
+        try:
+            results = SCIPQueryEngine(index_file).find_references(
+                symbol, limit=limit, exact=exact
+            )
+        except Exception as e:
+            logger.warning(f"Failed to query {index_file}: {e}")
+            continue
+        queried = True
+        for result in results:
+            context = _source_line(sources, project_root, result.file_path, result.line)
+            # SCIP positions are 0-based
+            hits.append(
+                ReferenceHit(
+                    result.file_path,
+                    result.line + 1,
+                    result.column + 1,
+                    context,
+                    SCIP_BACKEND,
+                )
+            )
+    if not queried:
+        return None
+    hits = sorted(_unique(hits), key=lambda hit: (hit.path, hit.line, hit.column))
+    return hits[:limit] if limit > 0 else hits
+
+
+def references_in_results(
+    results: List[Dict[str, Any]],
+    name: str,
+    declarations: Iterable[Tuple[str, int]] = (),
+) -> List[ReferenceHit]:
+    """
+    Lines of search results using name as a whole identifier, in result
+    order.
+
+    Args:
+        results: Search results (best first) with chunk content and lines
+        name: Identifier to find
+        declarations: (path, line) of the symbol's declarations, skipped
+    """
+    identifier = re.compile(rf"(?<![\w$]){re.escape(name)}(?![\w$])")
+    skipped: Set[Tuple[str, int]] = set(declarations)
+    hits: List[ReferenceHit] = []
+    for result in results:
+        payload = result.get("payload", {})
+        if payload.get(VECTOR_KIND_KEY):
+            continue
+        path = str(payload.get("path", "")).replace("\\", "/")
+        first_line = payload.get("line_start") or 1
+        content = payload.get("content") or ""
+        for offset, text in enumerate(content.split("\n")):
+            match = identifier.search(text)
+            if match is None or (path, first_line + offset) in skipped:
+                continue
+            hits.append(
+                ReferenceHit(
+                    path,
+                    first_line + offset,
+                    match.start() + 1,
+                    text.strip(),
+                    SEMANTIC_BACKEND,
+                    result.get("score"),
+                )
+            )
+    return _unique(hits)
+
+
+def semantic_references(
+    vector_store: Any,
+    embedding_provider: Any,
+    collection_name: str,
+    project_root: Path,
+    config: Any,
+    symbol: str,
+    limit: int,
+) -> List[ReferenceHit]:
+    """
+    References found in the semantic index (see references_in_results),
+    among chunks visible on the current branch.
+
+    Raises:
+        ValueError: If the symbol has no name
+    """
+    from ..storage.filesystem_vector_store import FilesystemVectorStore
+    from .generic_query_service import GenericQueryService
+    from .symbol_declarations import find_symbols
+
+    parts = [part for part in _SYMBOL_SEPARATOR.split(symbol.strip()) if part]
+    if not parts:
+        raise ValueError("Empty symbol name")
+    name = parts[-1]
+    candidates = max(limit * _CANDIDATES_PER_REFERENCE, _MIN_CANDIDATES)
+    if isinstance(vector_store, FilesystemVectorStore):
+        results, _ = vector_store.search(
+            query=symbol,
+            embedding_provider=embedding_provider,
+            collection_name=collection_name,
+            limit=candidates,
+            return_timing=True,
+        )
+        definitions = find_symbols(
+            vector_store, project_root, name, collections=[collection_name]
+        )
+    else:
+        results = vector_store.search(
+            query_vector=embedding_provider.get_embedding(symbol),
+            collection_name=collection_name,
+            limit=candidates,
+        )
+        definitions = []
+    results = GenericQueryService(
+        project_root, config
+    ).filter_results_by_current_branch(results)
+    hits = references_in_results(
+        results, name, [(d.path, d.line) for d in definitions]
+    )
+    return hits[:limit] if limit > 0 else hits
+
+
+def _unique(hits: List[ReferenceHit]) -> List[ReferenceHit]:
+    """Hits without repeated locations, first occurrence kept."""
+    seen: Set[Tuple[str, int]] = set()
+    unique = []
+    for hit in hits:
+        if (hit.path, hit.line) not in seen:
+            seen.add((hit.path, hit.line))
+            unique.append(hit)
+    return unique
+
+
+def _source_line(
+    sources: Dict[str, List[str]], project_root: Path, path: str, line: int
+) -> str:
+    """A line (0-based) of a project file, stripped; empty if unreadable."""
+    if path not in sources:
+        try:
+            text = (Path(project_root) / path).read_text(errors="replace")
+        except OSError:
+            text = ""
+        sources[path] = text.split("\n")
+    lines = sources[path]
+    return lines[line].strip() if 0 <= line < len(lines) else ""
@@ -0,0 +1,340 @@
+"""
+Relevance feedback and feedback-based re-ranking.
+
+``cidx feedback <result-id> --relevant|--irrelevant`` (and the server's
+``POST /api/query/feedback``) record a judgment of a query result. Each
+judgment votes for or against:
+
+- the result's file path,
+- the other files of its directory (at half strength),
+- the symbols (functions, classes, types) defined in the result's chunk,
+  wherever they show up again.
+
+Later semantic queries add the net votes of each result, scaled by
+BOOST_PER_JUDGMENT and capped at MAX_ADJUSTMENT, to its similarity score and
+re-sort the results. Results nobody judged keep their score, so a team's
+judgments nudge rankings without overriding similarity.
+"""
+
+import json
+import logging
+import os
+import re
+import tempfile
+import threading
+import time
+from collections import Counter
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+logger = logging.getLogger(__name__)
+
+FEEDBACK_FILE_NAME = "feedback.json"
+
+# Score change per net judgment, and the largest total change of one result
+BOOST_PER_JUDGMENT = 0.02
+MAX_ADJUSTMENT = 0.1
+DIRECTORY_WEIGHT = 0.5
+SYMBOL_WEIGHT = 0.5
+
+# Oldest judgments are dropped beyond this many
+MAX_JUDGMENTS = 5000
+
+# Result IDs shown to users are this prefix of the point ID
+RESULT_ID_LENGTH = 8
+
+_DEFINITION = re.compile(
+    r"\b(?:def|class|func|function|fn|interface|struct|enum|trait|type|module)"
+    r"\s+(?:\([^)]*\)\s*)?(?P<name>[A-Za-z_]\w*)"
+)
+
+
+def short_result_id(point_id: Any) -> str:
+    """Result ID shown to users for a point ID."""
+    return str(point_id)[:RESULT_ID_LENGTH]
+
+
+def defined_symbols(text: str) -> List[str]:
+    """Names of the functions, classes and types defined in chunk text."""
+    seen: Dict[str, None] = {}
+    for match in _DEFINITION.finditer(text or ""):
+        seen.setdefault(match.group("name"), None)
+    return list(seen)
+
+
+@dataclass
+class Judgment:
+    """A recorded relevance judgment of one query result."""
+
+    path: str
+    relevant: bool
+    symbols: List[str] = field(default_factory=list)
+    query: Optional[str] = None
+    user: Optional[str] = None
+    # Identity of the judged result: point ID, or "path:line" from the API
+    result_key: Optional[str] = None
+    recorded_at: float = 0.0
+
+
+class FeedbackStore:
+    """Persists relevance judgments and derives score adjustments from them."""
+
+    def __init__(self, feedback_file: Path):
+        """
+        Initialize the store.
+
+        Args:
+            feedback_file: JSON file holding the judgments (created on first
+                record)
+        """
+        self.feedback_file = Path(feedback_file)
+        self._lock = threading.Lock()
+        self._judgments: Optional[List[Judgment]] = None
+        self._weights: Optional[Dict[str, Counter]] = None
+
+    @classmethod
+    def for_project(cls, index_dir: Path) -> "FeedbackStore":
+        """Store of a project's .code-indexer directory."""
+        return cls(Path(index_dir) / FEEDBACK_FILE_NAME)
+
+    @property
+    def judgments(self) -> List[Judgment]:
+        with self._lock:
+            return list(self._load())
+
+    @property
+    def has_feedback(self) -> bool:
+        with self._lock:
+            return bool(self._load())
+
+    def record(
+        self,
+        path: str,
+        relevant: bool,
+        text: str = "",
+        query: Optional[str] = None,
+        user: Optional[str] = None,
+        result_key: Optional[str] = None,
+    ) -> Judgment:
+        """
+        Record a judgment of a result.
+
+        A new judgment of the same result by the same user replaces the
+        earlier one, so a judgment can be reversed.
+
+        Args:
+            path: Project-relative file path of the result
+            relevant: True for --relevant, False for --irrelevant
+            text: Chunk text of the result; its defined symbols get the vote
+            query: Query that returned the result
+            user: Who judged
+            result_key: Identity of the result (point ID, or "path:line")
+
+        Returns:
+            The recorded judgment
+        """
+        judgment = Judgment(
+            path=path.replace("\\", "/").lstrip("/"),
+            relevant=relevant,
+            symbols=defined_symbols(text),
+            query=query,
+            user=user,
+            result_key=result_key,
+            recorded_at=time.time(),
+        )
+        with self._lock:
+            judgments = [
+                j
+                for j in self._load()
+                if not (
+                    result_key is not None
+                    and j.result_key == result_key
+                    and j.user == user
+                )
+            ]
+            judgments.append(judgment)
+            self._judgments = judgments[-MAX_JUDGMENTS:]
+            self._weights = None
+            self._save()
+        return judgment
+
+    def clear(self) -> int:
+        """Delete all judgments; returns how many there were."""
+        with self._lock:
+            count = len(self._load())
+            self._judgments = []
+            self._weights = None
+            try:
+                self.feedback_file.unlink()
+            except FileNotFoundError:
+                pass
+        return count
+
+    def adjustment(self, path: str, text: str = "") -> float:
+        """
+        Score adjustment of a result.
+
+        Args:
+            path: Project-relative file path of the result
+            text: Chunk text of the result
+
+        Returns:
+            Value in [-MAX_ADJUSTMENT, MAX_ADJUSTMENT] to add to its score
+        """
+        weights = self._get_weights()
+        if not any(weights.values()):
+            return 0.0
+        path = path.replace("\\", "/").lstrip("/")
+        votes = weights["path"][path]
+        votes += DIRECTORY_WEIGHT * weights["directory"][_directory(path)]
+        if weights["symbol"]:
+            votes += SYMBOL_WEIGHT * sum(
+                weights["symbol"][name] for name in defined_symbols(text)
+            )
+        return max(-MAX_ADJUSTMENT, min(MAX_ADJUSTMENT, votes * BOOST_PER_JUDGMENT))
+
+    def rerank(self, results: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+        """
+        Apply feedback adjustments to search results.
+
+        Results are {"score", "payload"} dicts as returned by vector store
+        searches. Adjusted results get their new score and a
+        "feedback_adjustment" entry; the list is re-sorted by score.
+        """
+        if not results or not self.has_feedback:
+            return results
+        for result in results:
+            payload = result.get("payload", {})
+            delta = self.adjustment(
+                str(payload.get("path") or payload.get("file_path") or ""),
+                str(payload.get("content") or ""),
+            )
+            if delta:
+                result["score"] = result.get("score", 0.0) + delta
+                result["feedback_adjustment"] = delta
+        return sorted(results, key=lambda r: r.get("score", 0.0), reverse=True)
+
+    def top_adjustments(self, limit: int = 10) -> List[Dict[str, Any]]:
+        """Paths and symbols with the most net votes, strongest first."""
+        weights = self._get_weights()
+        entries = [
+            {"kind": kind, "name": name, "votes": votes}
+            for kind in ("path", "symbol")
+            for name, votes in weights[kind].items()
+            if votes
+        ]
+        entries.sort(key=lambda e: (-abs(e["votes"]), e["kind"], e["name"]))
+        return entries[:limit]
+
+    def _get_weights(self) -> Dict[str, Counter]:
+        with self._lock:
+            if self._weights is None:
+                weights: Dict[str, Counter] = {
+                    "path": Counter(),
+                    "directory": Counter(),
+                    "symbol": Counter(),
+                }
+                for judgment in self._load():
+                    vote = 1 if judgment.relevant else -1
+                    weights["path"][judgment.path] += vote
+                    weights["directory"][_directory(judgment.path)] += vote
+                    for name in judgment.symbols:
+                        weights["symbol"][name] += vote
+                self._weights = weights
+            return self._weights
+
+    def _load(self) -> List[Judgment]:
+        """Judgments from disk (cached); caller holds the lock."""
+        if self._judgments is None:
+            self._judgments = []
+            if self.feedback_file.exists():
+                try:
+                    data = json.loads(self.feedback_file.read_text())
+                    self._judgments = [
+                        Judgment(**entry) for entry in data.get("judgments", [])
+                    ]
+                except (OSError, ValueError, TypeError) as e:
+                    logger.warning(f"Ignoring unreadable {self.feedback_file}: {e}")
+        return self._judgments
+
+    def _save(self) -> None:
+        """Atomically write the judgments; caller holds the lock."""
+        self.feedback_file.parent.mkdir(parents=True, exist_ok=True)
+        data = {"judgments": [asdict(j) for j in self._judgments or []]}
+        fd, tmp_name = tempfile.mkstemp(
+            dir=self.feedback_file.parent, prefix=".feedback-", suffix=".tmp"
+        )
+        try:
+            with os.fdopen(fd, "w") as f:
+                json.dump(data, f, indent=2)
+            os.replace(tmp_name, self.feedback_file)
+        except BaseException:
+            Path(tmp_name).unlink(missing_ok=True)
+            raise
+
+
+def _directory(path: str) -> str:
+    return path.rsplit("/", 1)[0] if "/" in path else ""
+
+
+def resolve_result_id(
+    vector_store: Any, result_id: str
+) -> Optional[Dict[str, Any]]:
+    """
+    Find the indexed chunk a result ID refers to.
+
+    Args:
+        vector_store: FilesystemVectorStore of the project
+        result_id: Result ID shown by ``cidx query`` (a point ID prefix) or a
+            full point ID
+
+    Returns:
+        Point dict ({"id", "payload", ...}), or None if no point matches
+
+    Raises:
+        ValueError: If the ID matches more than one point
+    """
+    from ..storage.temporal_metadata_store import TemporalMetadataStore
+
+    result_id = result_id.strip().lower()
+    matches = []
+    for collection_name in vector_store.list_collections():
+        if TemporalMetadataStore.is_temporal_collection(collection_name):
+            continue
+        for point_id in vector_store.load_id_index(collection_name):
+            if str(point_id).lower().startswith(result_id):
+                matches.append((collection_name, point_id))
+    if len(matches) > 1:
+        raise ValueError(
+            f"Result ID '{result_id}' is ambiguous ({len(matches)} matches); "
+            "use more characters of the ID"
+        )
+    if not matches:
+        return None
+    collection_name, point_id = matches[0]
+    return vector_store.get_point(point_id, collection_name)
+
+
+def chunk_text(point: Dict[str, Any], project_root: Path) -> str:
+    """
+    Text of an indexed chunk.
+
+    Uses the stored chunk text when the point has it, and otherwise reads
+    the chunk's lines from the working tree (git-aware projects store no
+    chunk text).
+    """
+    payload = point.get("payload", {})
+    text = payload.get("content") or point.get("chunk_text")
+    if isinstance(text, str) and text:
+        return text
+    path = payload.get("path")
+    if not path:
+        return ""
+    try:
+        lines = (Path(project_root) / path).read_text(errors="replace").split("\n")
+    except OSError:
+        return ""
+    start = max(int(payload.get("line_start") or 1), 1)
+    end = int(payload.get("line_end") or len(lines))
+    return "\n".join(lines[start - 1 : end])
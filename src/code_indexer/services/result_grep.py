@@ -0,0 +1,42 @@
+"""
+Regex post-filter of semantic search results.
+
+'cidx query "retry logic" --grep "backoff|jitter"' keeps the semantic hits
+whose chunk text literally matches the regex, constraining fuzzy results to
+code that mentions a token. The regex uses Python syntax and is searched
+anywhere in the chunk; it is case-sensitive unless it starts with (?i).
+
+Chunk text is not part of the payload the vector store filters on, so the
+regex applies after the search, to a larger set of candidates.
+"""
+
+import re
+from typing import Any, Dict, List, Pattern
+
+
+def compile_grep(pattern: str) -> Pattern[str]:
+    """
+    Compiled --grep regex.
+
+    Raises:
+        ValueError: If the pattern is empty or not a valid regex
+    """
+    if not pattern:
+        raise ValueError("regex is empty")
+    try:
+        return re.compile(pattern, re.MULTILINE)
+    except re.error as e:
+        raise ValueError(f"invalid regex '{pattern}': {e}")
+
+
+def grep_results(
+    results: List[Dict[str, Any]], regex: Pattern[str]
+) -> List[Dict[str, Any]]:
+    """Results (in order) whose chunk text matches the regex."""
+    return [
+        result
+        for result in results
+        if regex.search(
+            result.get("payload", {}).get("content") or result.get("chunk_text") or ""
+        )
+    ]
@@ -0,0 +1,65 @@
+"""
+Grouping of semantic search results by file.
+
+When one file matches a query in many chunks, its chunks crowd the other
+files out of the top results. 'cidx query --group-by file' keeps the best
+hit of each file and 'cidx query --max-per-file N' its N best hits; the
+first hit kept for a file records how many more of its chunks matched, in
+the "more_in_file" result field, so the collapsed matches stay visible. The
+count includes the hits of a shown file that the result limit cuts off.
+"""
+
+from typing import Any, Dict, List, Optional
+
+# Result key: further matches of the file that were collapsed
+MORE_IN_FILE_KEY = "more_in_file"
+
+
+def _path(result: Dict[str, Any]) -> str:
+    """File path of a search result."""
+    return str(result.get("payload", {}).get("path", ""))
+
+
+def group_results(
+    results: List[Dict[str, Any]],
+    max_per_file: int = 1,
+    limit: Optional[int] = None,
+) -> List[Dict[str, Any]]:
+    """
+    Results (in order) with at most max_per_file per file.
+
+    Args:
+        results: Search results with "payload" dicts, best first
+        max_per_file: Results kept per file
+        limit: Results returned (all kept results when None)
+
+    Returns:
+        Copies of the kept results; the best hit of a file with collapsed
+        matches has MORE_IN_FILE_KEY set to their count
+    """
+    if max_per_file < 1:
+        raise ValueError("max_per_file must be at least 1")
+    kept: List[Dict[str, Any]] = []
+    best_hits: Dict[str, Dict[str, Any]] = {}
+    counts: Dict[str, int] = {}
+    for result in results:
+        path = _path(result)
+        counts[path] = counts.get(path, 0) + 1
+        if counts[path] > max_per_file:
+            best = best_hits[path]
+            best[MORE_IN_FILE_KEY] = best.get(MORE_IN_FILE_KEY, 0) + 1
+            continue
+        result = dict(result)
+        result.pop(MORE_IN_FILE_KEY, None)
+        best_hits.setdefault(path, result)
+        kept.append(result)
+    if limit is None or len(kept) <= limit:
+        return kept
+    # Hits past the limit of files that are shown count as collapsed
+    shown = {_path(result) for result in kept[:limit]}
+    for result in kept[limit:]:
+        path = _path(result)
+        if path in shown:
+            best = best_hits[path]
+            best[MORE_IN_FILE_KEY] = best.get(MORE_IN_FILE_KEY, 0) + 1
+    return kept[:limit]
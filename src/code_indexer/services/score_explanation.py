@@ -0,0 +1,131 @@
+"""
+Score explanations of cidx query --explain.
+
+A local semantic result's score is its vector similarity, adjusted by
+relevance feedback ('cidx feedback') and by post_query hooks, which may
+change scores freely. --explain records the similarity and the rank each
+result had straight after the vector search, and once the results are
+final breaks their score down into those parts.
+
+It also reports the query words the chunk shares (see match_highlighting).
+They are lexical evidence for reading a ranking only: local semantic
+ranking uses no lexical score and no recency boost. The filters applied,
+payload filters during the search and post-filters after it, are described
+once per query.
+"""
+
+from typing import Any, Dict, List, Optional
+
+from .match_highlighting import HIGHLIGHT_KEY, matched_region, query_terms
+
+# Result key: score breakdown of the result
+EXPLANATION_KEY = "explanation"
+
+# Differences below this are rounding, not adjustments
+_EPSILON = 1e-9
+
+
+def record_vector_scores(results: List[Dict[str, Any]]) -> None:
+    """Record the similarity and rank of vector search results (best first)."""
+    for rank, result in enumerate(results, start=1):
+        result[EXPLANATION_KEY] = {
+            "vector_similarity": result.get("score", 0.0),
+            "vector_rank": rank,
+            "matched_vector": result.get("matched_vector", "body"),
+        }
+
+
+def explain_results(results: List[Dict[str, Any]], query: str) -> None:
+    """
+    Break down the final score of each result under EXPLANATION_KEY.
+
+    Results without recorded vector scores (e.g. replaced by a post_query
+    hook) get their final score and lexical evidence only.
+    """
+    terms = query_terms(query)
+    for rank, result in enumerate(results, start=1):
+        explanation = dict(result.get(EXPLANATION_KEY) or {})
+        score = result.get("score", 0.0)
+        feedback = result.get("feedback_adjustment", 0.0)
+        explanation["final_score"] = score
+        explanation["rank"] = rank
+        explanation["feedback_adjustment"] = feedback
+        if "vector_similarity" in explanation:
+            hooks = score - explanation["vector_similarity"] - feedback
+            explanation["hook_adjustment"] = hooks if abs(hooks) > _EPSILON else 0.0
+
+        region = result.get(HIGHLIGHT_KEY)
+        if region is None:
+            payload = result.get("payload", {})
+            region = matched_region(
+                payload.get("content") or "", query, payload.get("line_start") or 1
+            )
+        explanation["query_terms"] = terms
+        explanation["matched_terms"] = region["terms"] if region else []
+        result[EXPLANATION_KEY] = explanation
+
+
+def format_explanation(explanation: Dict[str, Any]) -> str:
+    """One-line breakdown of a result's score."""
+    parts = [f"🧮 Score {explanation['final_score']:.3f}"]
+    if "vector_similarity" in explanation:
+        parts.append(
+            f"= vector {explanation['vector_similarity']:.3f} "
+            f"({explanation['matched_vector']}, vector rank "
+            f"#{explanation['vector_rank']})"
+        )
+        parts.append(f"+ feedback {explanation['feedback_adjustment']:+.3f}")
+        parts.append(f"+ hooks {explanation['hook_adjustment']:+.3f}")
+    else:
+        parts.append("(set by a post_query hook)")
+    line = " ".join(parts)
+    terms = explanation["query_terms"]
+    if terms:
+        matched = explanation["matched_terms"]
+        line += f" | words {len(matched)}/{len(terms)}"
+        if matched:
+            line += f" ({', '.join(matched)})"
+    return line
+
+
+def describe_filter(filter_conditions: Optional[Dict[str, Any]]) -> List[str]:
+    """Readable terms of a payload filter ({"must", "should", "must_not"})."""
+    if not filter_conditions:
+        return []
+    terms = [_describe(c) for c in filter_conditions.get("must", [])]
+    if filter_conditions.get("should"):
+        terms.append(_join(filter_conditions["should"], " OR "))
+    terms.extend(f"NOT {_describe(c)}" for c in filter_conditions.get("must_not", []))
+    return terms
+
+
+def _join(conditions: List[Dict[str, Any]], operator: str) -> str:
+    described = [_describe(c) for c in conditions]
+    return described[0] if len(described) == 1 else f"({operator.join(described)})"
+
+
+def _describe(condition: Dict[str, Any]) -> str:
+    if "key" not in condition:
+        nested = []
+        if condition.get("must"):
+            nested.append(_join(condition["must"], " AND "))
+        if condition.get("should"):
+            nested.append(_join(condition["should"], " OR "))
+        nested.extend(f"NOT {_describe(c)}" for c in condition.get("must_not", []))
+        return " AND ".join(nested)
+    key = condition["key"]
+    match = condition.get("match", {})
+    if "value" in match:
+        return f"{key}={match['value']}"
+    if "text" in match:
+        return f"{key}~{match['text']}"
+    if "any" in match:
+        values = match["any"]
+        if len(values) > 3:
+            return f"{key} in {len(values)} values"
+        return f"{key} in ({', '.join(sorted(map(str, values)))})"
+    if "contains" in match:
+        return f"{key} contains {match['contains']}"
+    bounds = condition.get("range", {})
+    symbols = {"gte": ">=", "gt": ">", "lte": "<=", "lt": "<"}
+    return " ".join(f"{key}{symbols[op]}{bound}" for op, bound in bounds.items())
@@ -0,0 +1,337 @@
+"""
+User-level service installation for watch and server processes.
+
+Registers `cidx watch` for a project, or the CIDX server, as a systemd user
+unit on Linux or a launchd agent on macOS, with a restart policy and log
+routing, so indexing resumes after reboots and crashes without hand-written
+unit files.
+"""
+
+import hashlib
+import logging
+import os
+import plistlib
+import re
+import shutil
+import subprocess
+import sys
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Dict, List, Optional, Sequence
+
+logger = logging.getLogger(__name__)
+
+SERVICE_PREFIX = "cidx"
+LAUNCHD_LABEL_PREFIX = "io.github.jsbattig"
+RESTART_DELAY_SECONDS = 10
+
+
+@dataclass
+class ServiceSpec:
+    """Process registered as a user service."""
+
+    name: str
+    description: str
+    command: List[str]
+    working_dir: Path
+    environment: Dict[str, str] = field(default_factory=dict)
+
+
+def cidx_command() -> List[str]:
+    """Command line that runs the cidx CLI of this installation."""
+    executable = shutil.which("cidx")
+    if executable:
+        return [executable]
+    return [sys.executable, "-m", "code_indexer.cli"]
+
+
+def service_environment(pass_env: Sequence[str] = ()) -> Dict[str, str]:
+    """
+    Environment of a service process.
+
+    Service managers start processes with a minimal environment, so PATH
+    (needed to find git) is always kept. Other variables are copied only
+    when requested, because their values are stored in the service file.
+
+    Args:
+        pass_env: Names of variables to copy from the current environment
+
+    Returns:
+        Variables for the service definition
+    """
+    environment = {"PATH": os.environ.get("PATH", "/usr/local/bin:/usr/bin:/bin")}
+    for name in pass_env:
+        if name in os.environ:
+            environment[name] = os.environ[name]
+    return environment
+
+
+def watch_service_spec(
+    project_root: Path, watch_args: Sequence[str] = (), pass_env: Sequence[str] = ()
+) -> ServiceSpec:
+    """
+    Service running `cidx watch` for a project.
+
+    Args:
+        project_root: Root of the indexed project
+        watch_args: Extra arguments for cidx watch
+        pass_env: Environment variables to copy into the service
+
+    Returns:
+        Service specification named after the project
+    """
+    project_root = project_root.resolve()
+    slug = re.sub(r"[^A-Za-z0-9]+", "-", project_root.name).strip("-").lower()
+    digest = hashlib.sha1(str(project_root).encode()).hexdigest()[:8]
+    return ServiceSpec(
+        name=f"{SERVICE_PREFIX}-watch-{slug or 'project'}-{digest}",
+        description=f"CIDX watch for {project_root}",
+        command=cidx_command() + ["watch"] + list(watch_args),
+        working_dir=project_root,
+        environment=service_environment(pass_env),
+    )
+
+
+def server_service_spec(
+    host: str, port: int, pass_env: Sequence[str] = ()
+) -> ServiceSpec:
+    """
+    Service running the CIDX server.
+
+    Args:
+        host: Host to bind the server to
+        port: Server port
+        pass_env: Environment variables to copy into the service
+
+    Returns:
+        Service specification
+    """
+    return ServiceSpec(
+        name=f"{SERVICE_PREFIX}-server",
+        description="CIDX Multi-User Server",
+        command=[
+            sys.executable,
+            "-m",
+            "code_indexer.server.main",
+            "--host",
+            host,
+            "--port",
+            str(port),
+        ],
+        working_dir=Path.home(),
+        environment=service_environment(pass_env),
+    )
+
+
+def _systemd_quote(arg: str) -> str:
+    # % starts a systemd specifier in unit settings
+    arg = arg.replace("%", "%%")
+    if arg and not re.search(r'[\s"\\]', arg):
+        return arg
+    return '"' + arg.replace("\\", "\\\\").replace('"', '\\"') + '"'
+
+
+class ServiceInstaller:
+    """
+    Installs services with the user's service manager.
+
+    Uses systemd user units on Linux and launchd agents on macOS. Services
+    start on login (and on boot with systemd lingering enabled) and restart
+    after failures.
+    """
+
+    def __init__(self, platform: Optional[str] = None, home: Optional[Path] = None):
+        """
+        Initialize service installer.
+
+        Args:
+            platform: sys.platform value (default: the current platform)
+            home: User home directory (default: the current user's)
+
+        Raises:
+            RuntimeError: If the platform has no supported service manager
+        """
+        self.platform = platform or sys.platform
+        self.home = home or Path.home()
+        if self.platform.startswith("linux"):
+            self.manager = "systemd"
+        elif self.platform == "darwin":
+            self.manager = "launchd"
+        else:
+            raise RuntimeError(
+                "Service installation supports systemd (Linux) and launchd (macOS) "
+                f"only, not {self.platform}"
+            )
+
+    def service_file(self, name: str) -> Path:
+        """Path of the service definition of a service."""
+        if self.manager == "systemd":
+            return self.home / ".config" / "systemd" / "user" / f"{name}.service"
+        return self.home / "Library" / "LaunchAgents" / f"{self.label(name)}.plist"
+
+    def label(self, name: str) -> str:
+        """launchd label of a service."""
+        return f"{LAUNCHD_LABEL_PREFIX}.{name}"
+
+    def log_file(self, name: str) -> Optional[Path]:
+        """Log file of a launchd service (systemd logs go to the journal)."""
+        if self.manager == "systemd":
+            return None
+        return self.home / "Library" / "Logs" / "cidx" / f"{name}.log"
+
+    def render(self, spec: ServiceSpec) -> str:
+        """
+        Render the service definition.
+
+        Args:
+            spec: Service to render
+
+        Returns:
+            systemd unit or launchd plist content
+        """
+        if self.manager == "systemd":
+            return self._render_systemd(spec)
+        return self._render_launchd(spec)
+
+    def _render_systemd(self, spec: ServiceSpec) -> str:
+        environment = "".join(
+            f"Environment={_systemd_quote(f'{key}={value}')}\n"
+            for key, value in spec.environment.items()
+        )
+        exec_start = " ".join(_systemd_quote(arg) for arg in spec.command)
+        return f"""[Unit]
+Description={spec.description}
+After=network.target
+
+[Service]
+Type=simple
+WorkingDirectory={str(spec.working_dir).replace("%", "%%")}
+{environment}ExecStart={exec_start}
+Restart=on-failure
+RestartSec={RESTART_DELAY_SECONDS}
+StandardOutput=journal
+StandardError=journal
+SyslogIdentifier={spec.name}
+
+[Install]
+WantedBy=default.target
+"""
+
+    def _render_launchd(self, spec: ServiceSpec) -> str:
+        log_file = str(self.log_file(spec.name))
+        plist = {
+            "Label": self.label(spec.name),
+            "ProgramArguments": spec.command,
+            "WorkingDirectory": str(spec.working_dir),
+            "EnvironmentVariables": spec.environment,
+            "RunAtLoad": True,
+            # Restart after crashes, not after a clean exit
+            "KeepAlive": {"SuccessfulExit": False},
+            "ThrottleInterval": RESTART_DELAY_SECONDS,
+            "StandardOutPath": log_file,
+            "StandardErrorPath": log_file,
+        }
+        return plistlib.dumps(plist).decode("utf-8")
+
+    def install(self, spec: ServiceSpec) -> Path:
+        """
+        Write the service definition, then enable and start the service.
+
+        The definition may contain passed-through secrets, so it is only
+        readable by the user.
+
+        Args:
+            spec: Service to install
+
+        Returns:
+            Path of the service definition
+
+        Raises:
+            RuntimeError: If the service manager rejects the service
+        """
+        service_file = self.service_file(spec.name)
+        service_file.parent.mkdir(parents=True, exist_ok=True)
+        service_file.write_text(self.render(spec))
+        service_file.chmod(0o600)
+
+        if self.manager == "systemd":
+            self._run(["systemctl", "--user", "daemon-reload"])
+            self._run(
+                ["systemctl", "--user", "enable", "--now", f"{spec.name}.service"]
+            )
+        else:
+            log_file = self.log_file(spec.name)
+            if log_file is not None:
+                log_file.parent.mkdir(parents=True, exist_ok=True)
+            # Reinstalling replaces a loaded agent
+            self._run(["launchctl", "unload", str(service_file)], check=False)
+            self._run(["launchctl", "load", "-w", str(service_file)])
+
+        logger.info(f"Installed {self.manager} service {spec.name} at {service_file}")
+        return service_file
+
+    def uninstall(self, name: str) -> bool:
+        """
+        Stop and disable a service and remove its definition.
+
+        Args:
+            name: Service name
+
+        Returns:
+            True if the service was installed, False otherwise
+        """
+        service_file = self.service_file(name)
+        if not service_file.exists():
+            return False
+
+        if self.manager == "systemd":
+            self._run(
+                ["systemctl", "--user", "disable", "--now", f"{name}.service"],
+                check=False,
+            )
+            service_file.unlink()
+            self._run(["systemctl", "--user", "daemon-reload"], check=False)
+        else:
+            self._run(["launchctl", "unload", "-w", str(service_file)], check=False)
+            service_file.unlink()
+
+        logger.info(f"Uninstalled {self.manager} service {name}")
+        return True
+
+    def linger_enabled(self) -> Optional[bool]:
+        """
+        Whether systemd user services start at boot without a login.
+
+        Returns:
+            True or False for systemd, None when unknown or not applicable
+        """
+        if self.manager != "systemd":
+            return None
+        user = os.environ.get("USER") or os.environ.get("LOGNAME")
+        if not user:
+            return None
+        try:
+            result = subprocess.run(
+                ["loginctl", "show-user", user, "--property=Linger"],
+                capture_output=True,
+                text=True,
+                timeout=10,
+            )
+        except (OSError, subprocess.SubprocessError):
+            return None
+        if result.returncode != 0:
+            return None
+        return result.stdout.strip() == "Linger=yes"
+
+    def _run(self, command: List[str], check: bool = True) -> None:
+        try:
+            result = subprocess.run(command, capture_output=True, text=True, timeout=30)
+        except FileNotFoundError:
+            raise RuntimeError(
+                f"{command[0]} not found: {self.manager} is not available"
+            )
+        except subprocess.TimeoutExpired:
+            raise RuntimeError(f"Timed out running {' '.join(command)}")
+        if check and result.returncode != 0:
+            detail = result.stderr.strip() or result.stdout.strip()
+            raise RuntimeError(f"{' '.join(command)} failed: {detail}")
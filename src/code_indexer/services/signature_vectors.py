@@ -0,0 +1,91 @@
+"""
+Signature vectors of chunks.
+
+A chunk's body embedding mixes what its code does with what it is called.
+Queries that look a symbol up ("UserRepository find by email") match better
+against a short summary of the declaration: its signature line(s) and doc
+comment. For every chunk declaring a function, method or type, a signature
+chunk is added after it. It has the chunk's text and payload but embeds the
+summary, and is stored as a signature point of the chunk (see
+storage.vector_kinds); 'cidx query --vector-mode' picks which vectors a search
+matches against.
+
+The signature is the first declaration in the chunk, with continuation
+lines of a signature spanning several lines and without the opening brace.
+"""
+
+import re
+from typing import Any, Dict, List, Optional
+
+from ..storage.vector_kinds import SIGNATURE_VECTOR, VECTOR_KIND_KEY
+from .boilerplate_filter import EMBEDDING_TEXT_KEY
+from .code_metrics import METRIC_LANGUAGES, strip_code
+from .doc_comments import DOC_KEY
+from .symbol_declarations import is_declaration
+
+# Lines a signature may span
+MAX_SIGNATURE_LINES = 8
+
+
+def signature_of(text: str, language: str) -> Optional[str]:
+    """Signature of the first declaration in the text, if any."""
+    lines = text.split("\n")
+    code_lines = strip_code(text, language.lower()).split("\n")
+    for i, code in enumerate(code_lines):
+        if not is_declaration(code):
+            continue
+        signature = []
+        depth = 0
+        for line, stripped in zip(
+            lines[i : i + MAX_SIGNATURE_LINES], code_lines[i : i + MAX_SIGNATURE_LINES]
+        ):
+            signature.append(line.strip())
+            depth += stripped.count("(") - stripped.count(")")
+            if depth <= 0:
+                break
+        joined = re.sub(r"\(\s+", "(", " ".join(signature))
+        return joined.rstrip("{").strip()
+    return None
+
+
+def signature_summary(chunk: Dict[str, Any]) -> Optional[str]:
+    """Text embedded for the signature vector of a chunk, if it has one."""
+    language = (chunk.get("file_extension") or "").lower()
+    if language not in METRIC_LANGUAGES:
+        return None
+    signature = signature_of(chunk["text"], language)
+    if signature is None:
+        return None
+    doc = chunk.get(DOC_KEY)
+    return f"{signature}\n\n{doc}" if doc else signature
+
+
+class SignatureVectors:
+    """Adds a signature chunk after every chunk that declares a symbol."""
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["SignatureVectors"]:
+        """Signature vectors from indexing.signature_vectors, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        signature_config = getattr(indexing_config, "signature_vectors", None)
+        if getattr(signature_config, "enabled", False) is not True:
+            return None
+        return cls()
+
+    def add_signature_chunks(
+        self, chunks: List[Dict[str, Any]]
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks, each followed by its signature chunk if it has one."""
+        with_signatures = []
+        for chunk in chunks:
+            with_signatures.append(chunk)
+            summary = signature_summary(chunk)
+            if summary:
+                with_signatures.append(
+                    {
+                        **chunk,
+                        EMBEDDING_TEXT_KEY: summary,
+                        VECTOR_KIND_KEY: SIGNATURE_VECTOR,
+                    }
+                )
+        return with_signatures
@@ -0,0 +1,247 @@
+"""
+"More like this" search: code similar to a given piece of code.
+
+'cidx similar path/to/file.go:42-90' embeds those lines and searches the
+index for the most similar chunks, finding copy-paste variants and prior
+art. 'cidx similar --symbol Foo.Bar' starts from the indexed chunk declaring
+Bar (a method of Foo, for qualified names) and reuses its stored vector, so
+nothing is embedded. The referenced code itself is left out of the results.
+
+Locations are project paths with an optional line or line range
+(file.go, file.go:42, file.go:42-90); a whole file is embedded as one text.
+"""
+
+import re
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+from ..storage.vector_kinds import VECTOR_KIND_KEY
+from .symbol_declarations import find_symbols
+
+_LOCATION = re.compile(r"^(?P<path>.+?)(?::(?P<start>\d+)(?:-(?P<end>\d+))?)?$")
+_SYMBOL_SEPARATOR = re.compile(r"\.|::|#")
+_TYPE_DECLARATION = re.compile(
+    r"\b(?:class|struct|type|interface|trait|impl|object|enum|record|module|"
+    r"protocol|extension)\s+(?P<name>\w+)"
+)
+
+
+@dataclass
+class CodeReference:
+    """The code similar code is searched for."""
+
+    path: str
+    line_start: int
+    line_end: int
+    text: str
+    # Stored vector of the indexed chunk, when the reference is one
+    vector: Optional[List[float]] = None
+
+    @property
+    def location(self) -> str:
+        return f"{self.path}:{self.line_start}-{self.line_end}"
+
+    def overlaps(self, payload: Dict[str, Any]) -> bool:
+        """Whether a result's chunk covers lines of the reference."""
+        if str(payload.get("path", "")).replace("\\", "/") != self.path:
+            return False
+        start = payload.get("line_start") or 0
+        end = payload.get("line_end") or start
+        return start <= self.line_end and end >= self.line_start
+
+
+class StoredVectorProvider:
+    """
+    Embedding provider answering with a stored vector.
+
+    Wraps the provider a search embeds its query with; everything but
+    get_embedding is passed through.
+    """
+
+    def __init__(self, provider: Any, vector: List[float]):
+        self.provider = provider
+        self.vector = list(vector)
+
+    def __getattr__(self, name: str) -> Any:
+        return getattr(self.provider, name)
+
+    def get_embedding(self, text: str, model: Optional[str] = None) -> List[float]:
+        return list(self.vector)
+
+
+def parse_location(location: str) -> Tuple[str, Optional[int], Optional[int]]:
+    """
+    Path and line range of "file.go:42-90", "file.go:42" or "file.go".
+
+    Raises:
+        ValueError: If the line range is invalid
+    """
+    match = _LOCATION.match(location.strip())
+    if match is None:
+        raise ValueError(f"Invalid location '{location}' (use path:start-end)")
+    start = int(match.group("start")) if match.group("start") else None
+    end = int(match.group("end")) if match.group("end") else start
+    if start is not None and (start < 1 or end < start):  # type: ignore[operator]
+        raise ValueError(f"Invalid line range in '{location}'")
+    return match.group("path"), start, end
+
+
+def reference_from_location(project_root: Path, location: str) -> CodeReference:
+    """
+    The lines of a file named by a location.
+
+    Raises:
+        ValueError: If the location is invalid, outside the project or
+            beyond the end of the file
+    """
+    path, start, end = parse_location(location)
+    root = Path(project_root).resolve()
+    file_path = (Path.cwd() / path).resolve()
+    if not file_path.is_file():
+        file_path = (root / path).resolve()
+    if not file_path.is_file():
+        raise ValueError(f"No such file: {path}")
+    try:
+        relative = file_path.relative_to(root).as_posix()
+    except ValueError:
+        raise ValueError(f"{path} is outside the project {root}")
+
+    lines = file_path.read_text(errors="replace").split("\n")
+    if lines and lines[-1] == "":
+        lines.pop()
+    start = start or 1
+    end = min(end or len(lines), len(lines))
+    if start > end:
+        raise ValueError(f"{relative} has {len(lines)} lines")
+    text = "\n".join(lines[start - 1 : end])
+    if not text.strip():
+        raise ValueError(f"{relative}:{start}-{end} is blank")
+    return CodeReference(relative, start, end, text)
+
+
+def reference_from_symbol(
+    vector_store: Any, collection_name: str, project_root: Path, symbol: str
+) -> Tuple[CodeReference, int]:
+    """
+    The indexed chunk declaring a symbol.
+
+    "Foo.Bar" (or "Foo::Bar", "Foo#Bar") names Bar declared in type Foo:
+    a Go method with receiver Foo, or a member indented in the body of the
+    nearest enclosing class (struct, impl, ...) Foo.
+
+    Returns:
+        The chunk, and the number of declarations matching the symbol (the
+        best ranked one is used)
+
+    Raises:
+        ValueError: If the symbol has no indexed declaration
+    """
+    parts = [part for part in _SYMBOL_SEPARATOR.split(symbol.strip()) if part]
+    if not parts:
+        raise ValueError("Empty symbol name")
+    name, qualifier = parts[-1], parts[-2] if len(parts) > 1 else None
+
+    definitions = find_symbols(
+        vector_store, project_root, name, collections=[collection_name]
+    )
+    if qualifier:
+        definitions = [
+            definition
+            for definition in definitions
+            if _declared_in(
+                Path(project_root) / definition.path, definition.line, qualifier
+            )
+        ]
+    if not definitions:
+        raise ValueError(f"No indexed declaration of {symbol}")
+
+    definition = definitions[0]
+    chunks = [
+        point
+        for point in vector_store.get_points_for_paths(
+            collection_name, [definition.path]
+        )
+        if not point["payload"].get(VECTOR_KIND_KEY)
+        and (point["payload"].get("line_start") or 0)
+        <= definition.line
+        <= (point["payload"].get("line_end") or 0)
+    ]
+    if not chunks:
+        raise ValueError(
+            f"{definition.path}:{definition.line} is not indexed (re-index the file)"
+        )
+    chunk = min(
+        chunks,
+        key=lambda point: point["payload"]["line_end"] - point["payload"]["line_start"],
+    )
+    payload = chunk["payload"]
+    reference = CodeReference(
+        definition.path,
+        payload["line_start"],
+        payload["line_end"],
+        chunk.get("chunk_text") or "",
+        vector=chunk["vector"],
+    )
+    return reference, len(definitions)
+
+
+def _declared_in(file_path: Path, line: int, qualifier: str) -> bool:
+    """Whether the declaration at a line is a member of type qualifier."""
+    try:
+        lines = file_path.read_text(errors="replace").split("\n")
+    except OSError:
+        return False
+    if line > len(lines):
+        return False
+    declaration = lines[line - 1]
+    receiver = rf"^\s*func\s*\(\s*\w*\s*\*?{re.escape(qualifier)}\b"
+    if re.search(receiver, declaration):
+        return True  # Go method with receiver (f *Foo)
+    indent = len(declaration) - len(declaration.lstrip())
+    for text in reversed(lines[: line - 1]):
+        if not text.strip() or len(text) - len(text.lstrip()) >= indent:
+            continue
+        match = _TYPE_DECLARATION.search(text)
+        if match:
+            return match.group("name") == qualifier
+    return False
+
+
+def search_similar(
+    vector_store: Any,
+    embedding_provider: Any,
+    collection_name: str,
+    reference: CodeReference,
+    limit: int,
+    filter_conditions: Optional[Dict[str, Any]] = None,
+) -> List[Dict[str, Any]]:
+    """
+    Chunks most similar to the reference (best first), without the
+    reference's own lines.
+
+    Fetches extra candidates so that limit results remain after the caller's
+    branch filtering; the caller trims to limit.
+    """
+    from ..storage.filesystem_vector_store import FilesystemVectorStore
+
+    if reference.vector is not None:
+        embedding_provider = StoredVectorProvider(embedding_provider, reference.vector)
+    candidates = limit * 2 + 5
+    if isinstance(vector_store, FilesystemVectorStore):
+        results, _ = vector_store.search(
+            query=reference.text,
+            embedding_provider=embedding_provider,
+            collection_name=collection_name,
+            limit=candidates,
+            filter_conditions=filter_conditions,
+            return_timing=True,
+        )
+    else:
+        results = vector_store.search(
+            query_vector=embedding_provider.get_embedding(reference.text),
+            collection_name=collection_name,
+            limit=candidates,
+            filter_conditions=filter_conditions,
+        )
+    return [r for r in results if not reference.overlaps(r.get("payload", {}))]
@@ -28,6 +28,7 @@ from .git_topology_service import GitTopologyService
 from .indexing_lock import IndexingLockError, create_indexing_lock
 from .high_throughput_processor import HighThroughputProcessor
 from .git_hook_manager import GitHookManager
+from ..storage.membership_snapshot import IndexMembershipSnapshot, payload_timestamp
 from ..utils.enhanced_messaging import OperationType, create_enhanced_callback
 
 # CRITICAL: Lazy import for FTS - only load when --fts flag used
@@ -120,6 +121,9 @@ class SmartIndexer(HighThroughputProcessor):
         # Initialize git hook manager for branch change detection
         self.git_hook_manager = GitHookManager(config.codebase_dir, metadata_path)
 
+        # Per-run snapshot of indexed files, set by _get_indexed_files_snapshot()
+        self._membership_snapshot: Optional[IndexMembershipSnapshot] = None
+
     def _get_git_deltas_since_commit(
         self, last_commit: str, current_commit: str
     ) -> GitDelta:
@@ -1225,6 +1229,14 @@ class SmartIndexer(HighThroughputProcessor):
         files_to_index = []
         modified_files = 0
         missing_files = 0
+        indexed_relative_paths = {
+            (
+                str(Path(p).relative_to(self.config.codebase_dir))
+                if Path(p).is_absolute()
+                else str(p)
+            )
+            for p in indexed_files_with_timestamps.keys()
+        }
 
         for file_path in all_files_to_index:
             try:
@@ -1238,14 +1250,7 @@ class SmartIndexer(HighThroughputProcessor):
 
                 # RECONCILE FIX: Check if file exists in database AT ALL, not just visible in current branch
                 # Reconcile is about disk-to-database consistency, not branch visibility
-                file_in_db = relative_path in [
-                    (
-                        str(Path(p).relative_to(self.config.codebase_dir))
-                        if Path(p).is_absolute()
-                        else p
-                    )
-                    for p in indexed_files_with_timestamps.keys()
-                ]
+                file_in_db = relative_path in indexed_relative_paths
 
                 if not file_in_db:
                     # File exists on disk but NOT in database at all
@@ -1298,29 +1303,16 @@ class SmartIndexer(HighThroughputProcessor):
                 # Check if this file exists on disk in current branch (should be visible)
                 if relative_file_path in disk_files_set:
                     # File exists on disk, check if it's hidden for current branch
-                    content_points, _ = self.vector_store_client.scroll_points(
-                        filter_conditions={
-                            "must": [
-                                {"key": "type", "match": {"value": "content"}},
-                                {"key": "path", "match": {"value": relative_file_path}},
-                            ]
-                        },
-                        limit=1,  # Just need to check one point
-                        collection_name=collection_name,
+                    hidden_branches = self._get_hidden_branches_for_file(
+                        relative_file_path, collection_name
                     )
 
-                    if content_points:
-                        hidden_branches = (
-                            content_points[0]
-                            .get("payload", {})
-                            .get("hidden_branches", [])
+                    if current_branch in hidden_branches:
+                        # File exists on disk but is hidden for current branch - unhide it
+                        self._ensure_file_visible_in_branch_thread_safe(
+                            relative_file_path, current_branch, collection_name
                         )
-                        if current_branch in hidden_branches:
-                            # File exists on disk but is hidden for current branch - unhide it
-                            self._ensure_file_visible_in_branch_thread_safe(
-                                relative_file_path, current_branch, collection_name
-                            )
-                            files_unhidden += 1
+                        files_unhidden += 1
 
             if files_unhidden > 0 and progress_callback:
                 progress_callback(
@@ -1383,6 +1375,9 @@ class SmartIndexer(HighThroughputProcessor):
                     info=f"🗑️  Cleaned up {len(deleted_files)} deleted files from database",
                 )
 
+        # Membership lookups are done; the index is about to change
+        self._membership_snapshot = None
+
         if not files_to_index:
             if progress_callback:
                 progress_callback(
@@ -1835,14 +1830,18 @@ class SmartIndexer(HighThroughputProcessor):
             else 0
         )
 
-        for i, file_path in enumerate(files):
-            if i < successful_files:
-                # File was processed successfully
-                update_metadata(file_path, chunks_count=chunks_per_file, failed=False)
-                stats.total_size += file_path.stat().st_size
-            else:
-                # File was not processed successfully
-                update_metadata(file_path, chunks_count=0, failed=True)
+        # One journaled batch instead of a metadata.json rewrite per file
+        with self.progressive_metadata.batched_writes():
+            for i, file_path in enumerate(files):
+                if i < successful_files:
+                    # File was processed successfully
+                    update_metadata(
+                        file_path, chunks_count=chunks_per_file, failed=False
+                    )
+                    stats.total_size += file_path.stat().st_size
+                else:
+                    # File was not processed successfully
+                    update_metadata(file_path, chunks_count=0, failed=True)
 
         # Convert high-throughput stats to processing stats format
         stats.files_processed = high_throughput_stats.files_processed
@@ -1991,6 +1990,7 @@ class SmartIndexer(HighThroughputProcessor):
                         # Continue processing other files even if one deletion fails
 
             if not absolute_paths:
+                self._save_membership_snapshot()
                 stats.end_time = time.time()
                 return stats
 
@@ -2073,6 +2073,8 @@ class SmartIndexer(HighThroughputProcessor):
             logger.error(f"Incremental processing failed: {e}")
             stats.failed_files = len(file_paths)
 
+        # Deletions and branch hiding above run after end_indexing()
+        self._save_membership_snapshot()
         stats.end_time = time.time()
         return stats
 
@@ -2090,6 +2092,8 @@ class SmartIndexer(HighThroughputProcessor):
 
         This method loads only the minimal required data (file paths + timestamps)
         without vectors or full content, preventing memory issues and infinite loops.
+        The underlying membership snapshot is kept for the rest of the run so
+        per-file checks (content ID, branch visibility) need no database queries.
 
         Returns:
             Dict mapping file paths to their timestamps
@@ -2097,45 +2101,18 @@ class SmartIndexer(HighThroughputProcessor):
         indexed_files_with_timestamps: Dict[Path, float] = {}
 
         try:
-            if progress_callback:
-                progress_callback(
-                    0, 0, Path(""), info="📸 Taking snapshot of indexed files..."
-                )
-
-            # Get all content points in a single atomic operation
-            all_points = self._scroll_all_content_points(collection_name)
-
-            if progress_callback:
-                progress_callback(
-                    0,
-                    0,
-                    Path(""),
-                    info=f"📊 Processing {len(all_points)} points from database snapshot",
-                )
-
-            # Process all points in memory (no database access = no consistency issues)
-            for point in all_points:
-                payload = point.get("payload", {})
-
-                if "path" not in payload:
-                    continue
-
-                # Extract file path
-                path_from_db = payload["path"]
-                if Path(path_from_db).is_absolute():
-                    file_path = Path(path_from_db)
-                else:
-                    file_path = self.config.codebase_dir / path_from_db
-
-                # Extract best available timestamp
-                timestamp = self._extract_best_timestamp(payload)
+            snapshot = self._load_membership_snapshot(
+                collection_name, progress_callback
+            )
+            self._membership_snapshot = snapshot
 
-                # Keep the most recent timestamp per file (multiple chunks per file)
-                if (
-                    file_path not in indexed_files_with_timestamps
-                    or timestamp > indexed_files_with_timestamps[file_path]
-                ):
-                    indexed_files_with_timestamps[file_path] = timestamp
+            for path in snapshot:
+                entry = snapshot.get(path)
+                if entry is not None:
+                    # Absolute paths outside the codebase stay absolute
+                    indexed_files_with_timestamps[self.config.codebase_dir / path] = (
+                        entry.timestamp
+                    )
 
             if progress_callback:
                 progress_callback(
@@ -2147,6 +2124,7 @@ class SmartIndexer(HighThroughputProcessor):
 
         except Exception as e:
             logger.error(f"Failed to get indexed files snapshot: {e}")
+            self._membership_snapshot = None
             if progress_callback:
                 progress_callback(
                     0,
@@ -2159,6 +2137,89 @@ class SmartIndexer(HighThroughputProcessor):
 
         return indexed_files_with_timestamps
 
+    def _load_membership_snapshot(
+        self, collection_name: str, progress_callback: Optional[Callable] = None
+    ) -> IndexMembershipSnapshot:
+        """Load the persisted membership snapshot, rebuilding it if stale."""
+        snapshot_path = self.vector_store_client.get_membership_snapshot_path(
+            collection_name
+        )
+        snapshot = IndexMembershipSnapshot.load(snapshot_path)
+        if snapshot is not None:
+            logger.info(
+                f"Reusing membership snapshot for '{collection_name}' "
+                f"({len(snapshot)} files)"
+            )
+            return snapshot
+
+        if progress_callback:
+            progress_callback(
+                0, 0, Path(""), info="📸 Taking snapshot of indexed files..."
+            )
+
+        # Get all content points in a single atomic operation
+        all_points = self._scroll_all_content_points(collection_name)
+
+        if progress_callback:
+            progress_callback(
+                0,
+                0,
+                Path(""),
+                info=f"📊 Processing {len(all_points)} points from database snapshot",
+            )
+
+        # Process all points in memory (no database access = no consistency issues)
+        snapshot = IndexMembershipSnapshot.from_points(
+            all_points,
+            timestamp_fn=self._extract_best_timestamp,
+            normalize_path=self._normalize_indexed_path,
+        )
+        try:
+            snapshot.save(snapshot_path)
+        except OSError as e:
+            logger.warning(f"Failed to save membership snapshot: {e}")
+        return snapshot
+
+    def _save_membership_snapshot(self) -> None:
+        """Persist the membership snapshot updated by writes since end_indexing()."""
+        collection_name = self.vector_store_client.resolve_collection_name(
+            self.config, self.embedding_provider
+        )
+        self.vector_store_client.save_membership_snapshot(collection_name)
+
+    def _normalize_indexed_path(self, path: str) -> str:
+        """Stored payload path relative to the codebase where possible."""
+        if Path(path).is_absolute():
+            try:
+                return str(Path(path).relative_to(self.config.codebase_dir))
+            except ValueError:
+                return path
+        return path
+
+    def _get_hidden_branches_for_file(
+        self, file_path: str, collection_name: str
+    ) -> List[str]:
+        """Branches a file's indexed content is hidden in (first chunk)."""
+        if self._membership_snapshot is not None:
+            entry = self._membership_snapshot.get(file_path)
+            if entry is None:
+                return []
+            return list(entry.payload.get("hidden_branches", []))
+
+        content_points, _ = self.vector_store_client.scroll_points(
+            filter_conditions={
+                "must": [
+                    {"key": "type", "match": {"value": "content"}},
+                    {"key": "path", "match": {"value": file_path}},
+                ]
+            },
+            limit=1,  # Just need to check one point
+            collection_name=collection_name,
+        )
+        if not content_points:
+            return []
+        return list(content_points[0].get("payload", {}).get("hidden_branches", []))
+
     def _scroll_all_content_points(self, collection_name: str) -> List[Dict[str, Any]]:
         """Scroll through all content points and return them as a list.
 
@@ -2200,25 +2261,7 @@ class SmartIndexer(HighThroughputProcessor):
 
     def _extract_best_timestamp(self, payload: Dict[str, Any]) -> float:
         """Extract the best available timestamp from payload."""
-        # Priority 1: file_mtime from new architecture (most accurate)
-        if "file_mtime" in payload:
-            return float(payload["file_mtime"])
-        # Priority 2: filesystem_mtime from legacy architecture
-        elif "filesystem_mtime" in payload:
-            return float(payload["filesystem_mtime"])
-        # Priority 3: created_at (indexing time, less accurate for file changes)
-        elif "created_at" in payload:
-            return float(payload["created_at"])
-        # Priority 4: indexed_at as last resort
-        elif "indexed_at" in payload:
-            try:
-                dt = datetime.datetime.strptime(
-                    payload["indexed_at"], "%Y-%m-%dT%H:%M:%SZ"
-                )
-                return dt.timestamp()
-            except (ValueError, TypeError):
-                return 0.0
-        return 0.0
+        return payload_timestamp(payload)
 
     def _get_currently_visible_content_id(
         self, file_path: str, branch: str, collection_name: str
@@ -2318,6 +2361,14 @@ class SmartIndexer(HighThroughputProcessor):
         Used by reconcile to check if file exists in database regardless of branch.
         This is different from _get_currently_visible_content_id which filters by branch.
         """
+        if self._membership_snapshot is not None:
+            entry = self._membership_snapshot.get(file_path)
+            if entry is None:
+                return None
+            if "working_dir" in entry.point_id:
+                return f"{file_path}:working_dir:{entry.payload.get('filesystem_mtime', 'unknown')}:{entry.payload.get('file_size', 0)}"
+            return f"{file_path}:{entry.payload.get('git_commit_hash', 'unknown')}"
+
         try:
             # Query for ANY content for this file (no branch filtering)
             # Try absolute path first (what's actually stored in /tmp directories)
@@ -0,0 +1,179 @@
+"""
+Go struct tag metadata.
+
+Struct tags carry how Go types are encoded and persisted: JSON names,
+database columns, ORM constraints and validation rules. While a Go file is
+indexed, the tags of the struct fields in each chunk are recorded in its
+payload:
+
+- "struct_tags": "key:option" terms for filters, e.g. ["json:username",
+  "gorm:uniqueIndex", "gorm:not null", "validate:required"], plus every tag
+  key on its own ("gorm"). ``cidx query --struct-tag`` filters on them.
+- "struct_fields": one {"struct", "field", "line", "tags"} entry per tagged
+  field, with the raw tag values by key.
+
+Tags follow the reflect.StructTag convention (key:"value" pairs separated by
+spaces). Values are split into options on ","; gorm values on ";", so
+`gorm:"column:order_id;uniqueIndex"` yields "gorm:column:order_id" and
+"gorm:uniqueIndex". The struct of a field is resolved over the whole file, so
+fields keep their struct name when a chunk starts inside the struct.
+"""
+
+import logging
+import re
+from dataclasses import asdict, dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload keys holding the struct tags of a chunk
+STRUCT_TAGS_KEY = "struct_tags"
+STRUCT_FIELDS_KEY = "struct_fields"
+
+GO_EXTENSIONS = {".go"}
+
+# Tag keys whose options are separated by ";" instead of ","
+_SEMICOLON_KEYS = {"gorm"}
+
+# "type Name struct {" or, inside "type ( ... )", "Name struct {"
+_STRUCT_DECL = re.compile(
+    r"^\s*(?:type\s+)?([A-Za-z_]\w*)(?:\[[^\]]*\])?\s+struct\s*\{"
+)
+_TAGGED_FIELD = re.compile(r"^\s*\*?([A-Za-z_][\w.]*)[^`]*`([^`]*)`")
+# A field of anonymous struct type; its tag follows the closing brace
+_NESTED_FIELD = re.compile(r"^\s*([A-Za-z_]\w*)\s+[\[\]*\w.]*struct\s*\{")
+_CLOSING_TAG = re.compile(r"^\s*\}[^`]*`([^`]*)`")
+_TAG_PAIR = re.compile(r'([^\s:"]+):"((?:[^"\\]|\\.)*)"')
+
+
+@dataclass
+class StructField:
+    """A tagged field of a Go struct."""
+
+    struct: str
+    field: str
+    line: int
+    tags: Dict[str, str]
+
+    def terms(self) -> List[str]:
+        """Filter terms of the field's tags: keys and "key:option" pairs."""
+        terms = []
+        for key, value in self.tags.items():
+            terms.append(key)
+            terms.extend(f"{key}:{option}" for option in tag_options(key, value))
+        return terms
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def parse_tag(tag: str) -> Dict[str, str]:
+    """Key/value pairs of a struct tag (the text between the backticks)."""
+    return {key: value.replace('\\"', '"') for key, value in _TAG_PAIR.findall(tag)}
+
+
+def tag_options(key: str, value: str) -> List[str]:
+    """Options of one tag value, e.g. ["order_id", "omitempty"] for json."""
+    separator = ";" if key in _SEMICOLON_KEYS else ","
+    return [option.strip() for option in value.split(separator) if option.strip()]
+
+
+def normalize_struct_tag(term: str) -> str:
+    """
+    Canonical form of a --struct-tag filter term.
+
+    Accepts the Go notation as well: `gorm:"uniqueIndex"` becomes
+    "gorm:uniqueIndex".
+    """
+    return term.strip().strip("`").replace('"', "")
+
+
+def find_struct_fields(text: str) -> List[StructField]:
+    """
+    Tagged struct fields of a Go source file.
+
+    Fields of anonymous structs nested in a field belong to the enclosing
+    named struct.
+    """
+    fields: List[StructField] = []
+    struct: Optional[str] = None
+    nested: List[str] = []
+    depth = 0
+    for number, line in enumerate(text.split("\n"), start=1):
+        tag = ""
+        if struct is None:
+            declaration = _STRUCT_DECL.match(line)
+            if declaration is None:
+                continue
+            struct = declaration.group(1)
+            code = line[declaration.end() - 1 :]
+        else:
+            name = None
+            nested_field = _NESTED_FIELD.match(line)
+            if nested_field:
+                nested.append(nested_field.group(1))
+            elif line.lstrip().startswith("}") and nested:
+                name = nested.pop()
+                closing = _CLOSING_TAG.match(line)
+                tag = closing.group(1) if closing else ""
+            else:
+                tagged = _TAGGED_FIELD.match(line)
+                if tagged:
+                    name, tag = tagged.group(1), tagged.group(2)
+            if name and tag:
+                fields.append(StructField(struct, name, number, parse_tag(tag)))
+            code = line.replace(f"`{tag}`", "", 1) if tag else line
+        code = code.split("//", 1)[0]
+        depth += code.count("{") - code.count("}")
+        if depth <= 0:
+            struct, depth, nested = None, 0, []
+    return [f for f in fields if f.tags]
+
+
+class StructTagExtractor:
+    """Records the struct tags of Go chunks in their payload."""
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["StructTagExtractor"]:
+        """Extractor from indexing.struct_tags, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        tags_config = getattr(indexing_config, "struct_tags", None)
+        if getattr(tags_config, "enabled", False) is not True:
+            return None
+        return cls()
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """
+        Return the chunks with their struct tags under STRUCT_TAGS_KEY and
+        STRUCT_FIELDS_KEY.
+
+        Fields are assigned to chunks by line range; the file is parsed once.
+        """
+        if not chunks or Path(file_path).suffix.lower() not in GO_EXTENSIONS:
+            return chunks
+        try:
+            text = Path(file_path).read_text(encoding="utf-8", errors="replace")
+        except OSError as e:
+            logger.warning(f"Could not read {file_path} for struct tags: {e}")
+            return chunks
+        fields = find_struct_fields(text)
+        if not fields:
+            return chunks
+
+        annotated = []
+        for chunk in chunks:
+            line_start = chunk.get("line_start") or 1
+            line_end = chunk.get("line_end") or line_start
+            in_chunk = [f for f in fields if line_start <= f.line <= line_end]
+            if in_chunk:
+                terms = list(dict.fromkeys(t for f in in_chunk for t in f.terms()))
+                chunk = {
+                    **chunk,
+                    STRUCT_TAGS_KEY: terms,
+                    STRUCT_FIELDS_KEY: [f.to_dict() for f in in_chunk],
+                }
+            annotated.append(chunk)
+        return annotated
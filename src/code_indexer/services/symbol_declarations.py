@@ -0,0 +1,272 @@
+"""
+Declarations of code chunks, and symbol lookup.
+
+Every code chunk records the functions, methods and types it declares, in
+the "declarations" payload field: a list of {"name", "kind", "line"} entries,
+line being the file line of the declaration. Kinds are the declaring keyword
+(class, struct, interface, trait, enum, ...), "type" for other type
+declarations, and "function" or "method" (indented, or with a Go receiver).
+
+'cidx symbol NAME' looks names up in the recorded declarations without
+embedding anything: exact (or prefix) matches, types first, then functions,
+then methods. Declarations recorded by the language chunkers (symbol_name and
+symbol_kind of Dart, Objective-C, Julia, R, HDL, Rust, Zig, Elixir, Erlang
+and Scala chunks) are found too.
+
+Declarations are found line by line with string literals and comments
+removed; like the code metrics they approximate a parse of the language.
+"""
+
+import re
+from dataclasses import asdict, dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional
+
+from ..indexing.boundary_chunking import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from ..indexing.fixed_size_chunker import SYMBOLS_KEY
+from ..storage.vector_kinds import VECTOR_KIND_KEY
+from .code_metrics import METRIC_LANGUAGES, strip_code
+
+# Chunk and payload key
+DECLARATIONS_KEY = "declarations"
+
+DECLARATION_MODIFIERS = (
+    r"(?:(?:export|default|public|private|protected|internal|static|abstract|"
+    r"final|async|override|virtual|sealed|open|inline|partial|readonly|unsafe|"
+    r"extern|pub(?:\([\w:]+\))?)\s+)*"
+)
+KEYWORD_DECLARATION = re.compile(
+    r"^(?P<indent>\s*)" + DECLARATION_MODIFIERS + r"(?P<keyword>def|class|func|fun|"
+    r"fn|function|interface|struct|enum|trait|impl|module|record|object|protocol|"
+    r"extension|type|union)\b\s*(?P<receiver>\([^)]*\)\s*)?(?:self\.)?"
+    r"(?P<name>[A-Za-z_$][\w$]*[!?]?)?"
+)
+# C-style methods: return type or modifiers, then name(
+C_DECLARATION = re.compile(
+    r"^(?P<indent>\s*)(?:[\w<>\[\],.?*&:@]+\s+)+[*&]?(?P<name>\w+)\s*\("
+)
+_NOT_DECLARATION = {
+    "if",
+    "for",
+    "while",
+    "switch",
+    "return",
+    "catch",
+    "new",
+    "else",
+    "throw",
+    "await",
+}
+_FUNCTION_KEYWORDS = {"def", "func", "fun", "fn", "function"}
+# Keywords extending an existing type rather than declaring a name
+_EXTENDING_KEYWORDS = {"impl", "extension"}
+_GO_TYPE = re.compile(r"\s+(struct|interface)\b")
+
+# Lookup ranking: types, then functions, then methods, then other kinds
+_TYPE_KINDS = {
+    "class",
+    "struct",
+    "interface",
+    "trait",
+    "enum",
+    "type",
+    "record",
+    "object",
+    "protocol",
+    "module",
+    "union",
+}
+
+
+def is_declaration(line: str) -> bool:
+    """Whether a line (with strings and comments removed) declares something."""
+    if KEYWORD_DECLARATION.match(line):
+        return True
+    match = C_DECLARATION.match(line)
+    return (
+        match is not None
+        and match.group("name") not in _NOT_DECLARATION
+        and not line.rstrip().endswith(";")
+    )
+
+
+def _declaration(line: str) -> Optional[Dict[str, str]]:
+    match = KEYWORD_DECLARATION.match(line)
+    if match:
+        keyword, name = match.group("keyword"), match.group("name")
+        if not name or keyword in _EXTENDING_KEYWORDS:
+            return None
+        if keyword in _FUNCTION_KEYWORDS:
+            nested = match.group("receiver") or match.group("indent")
+            return {"name": name, "kind": "method" if nested else "function"}
+        if keyword == "type":
+            go_type = _GO_TYPE.match(line, match.end())
+            return {"name": name, "kind": go_type.group(1) if go_type else "type"}
+        return {"name": name, "kind": keyword}
+    if not is_declaration(line):
+        return None
+    match = C_DECLARATION.match(line)
+    if match is None:
+        return None
+    kind = "method" if match.group("indent") else "function"
+    return {"name": match.group("name"), "kind": kind}
+
+
+def declarations_of(
+    text: str, language: str, first_line: int = 1
+) -> List[Dict[str, Any]]:
+    """Declarations in the text of a chunk starting at first_line."""
+    declarations = []
+    for offset, line in enumerate(strip_code(text, language.lower()).split("\n")):
+        declaration = _declaration(line)
+        if declaration is not None:
+            declarations.append({**declaration, "line": first_line + offset})
+    return declarations
+
+
+class SymbolDeclarations:
+    """Records the declarations of code chunks in their payload."""
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["SymbolDeclarations"]:
+        """Declarations from indexing.symbol_declarations, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        declarations_config = getattr(indexing_config, "symbol_declarations", None)
+        if getattr(declarations_config, "enabled", False) is not True:
+            return None
+        return cls()
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """Return the chunks with their declarations added."""
+        annotated = []
+        for chunk in chunks:
+            language = (chunk.get("file_extension") or "").lower()
+            declarations = (
+                declarations_of(chunk["text"], language, chunk.get("line_start", 1))
+                if language in METRIC_LANGUAGES
+                else []
+            )
+            if declarations:
+                chunk = {**chunk, DECLARATIONS_KEY: declarations}
+            annotated.append(chunk)
+        return annotated
+
+
+@dataclass
+class SymbolDefinition:
+    """A declaration found by a symbol lookup."""
+
+    name: str
+    kind: str
+    path: str
+    line: int
+    language: str = ""
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def _kind_rank(kind: str) -> int:
+    if kind in _TYPE_KINDS:
+        return 0
+    if kind == "function":
+        return 1
+    if kind == "method":
+        return 2
+    return 3
+
+
+def _payload_declarations(payload: Dict[str, Any]) -> List[Dict[str, Any]]:
+    declarations = list(payload.get(DECLARATIONS_KEY) or [])
+    line = payload.get("line_start", 1)
+    kind = payload.get(SYMBOL_KIND_KEY) or "symbol"
+    for name in payload.get(SYMBOLS_KEY) or (
+        [payload[SYMBOL_NAME_KEY]] if payload.get(SYMBOL_NAME_KEY) else []
+    ):
+        declarations.append({"name": name, "kind": kind, "line": line})
+    return declarations
+
+
+def find_symbols(
+    vector_store: Any,
+    project_root: Path,
+    name: str,
+    prefix: bool = False,
+    ignore_case: bool = False,
+    kinds: Optional[Iterable[str]] = None,
+    collections: Optional[Iterable[str]] = None,
+) -> List[SymbolDefinition]:
+    """
+    Look up declarations by name in the indexed code.
+
+    Args:
+        vector_store: FilesystemVectorStore holding the collections
+        project_root: Git working tree the collections were indexed from
+        name: Symbol name
+        prefix: Also match names starting with name
+        ignore_case: Match names case-insensitively
+        kinds: Only declarations of these kinds (default: all)
+        collections: Content collections to read (default: all but git history)
+
+    Returns:
+        Definitions, exact matches first, then by kind (types, functions,
+        methods), path and line
+    """
+    from ..storage.temporal_metadata_store import TemporalMetadataStore
+    from ..utils.git_runner import get_current_branch
+
+    if collections is None:
+        collections = [
+            collection
+            for collection in vector_store.list_collections()
+            if not TemporalMetadataStore.is_temporal_collection(collection)
+        ]
+    current_branch = get_current_branch(project_root)
+    wanted = name.lower() if ignore_case else name
+    kind_filter = {kind.lower() for kind in kinds} if kinds else None
+
+    found: Dict[tuple, SymbolDefinition] = {}
+    for collection_name in collections:
+        for _, data, _ in vector_store.iter_vector_records(collection_name):
+            payload = (data or {}).get("payload", {})
+            if payload.get(VECTOR_KIND_KEY):
+                continue
+            declarations = _payload_declarations(payload)
+            if not declarations:
+                continue
+            if current_branch and current_branch in payload.get("hidden_branches", []):
+                continue
+            path = str(payload.get("path", ""))
+            for declaration in declarations:
+                declared = str(declaration["name"])
+                candidate = declared.lower() if ignore_case else declared
+                if not (
+                    candidate == wanted or (prefix and candidate.startswith(wanted))
+                ):
+                    continue
+                if kind_filter and declaration["kind"] not in kind_filter:
+                    continue
+                key = (path, declaration["line"], declared)
+                found.setdefault(
+                    key,
+                    SymbolDefinition(
+                        declared,
+                        declaration["kind"],
+                        path,
+                        int(declaration["line"]),
+                        str(payload.get("language", "")),
+                    ),
+                )
+
+    def rank(definition: SymbolDefinition) -> tuple:
+        exact = (definition.name.lower() if ignore_case else definition.name) == wanted
+        return (
+            not exact,
+            _kind_rank(definition.kind),
+            definition.path,
+            definition.line,
+        )
+
+    return sorted(found.values(), key=rank)
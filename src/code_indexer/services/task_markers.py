@@ -0,0 +1,331 @@
+"""
+Extraction of TODO/FIXME/HACK task comments behind ``cidx todos``.
+
+While a file is indexed, comments starting with a task marker are recorded in
+the payload of the chunk that contains them, together with the author and
+date of the line from git blame. Uncommitted lines, and files outside git,
+get no author and are dated by the file's modification time.
+
+``cidx todos`` then lists the markers of the content visible on the current
+branch straight from the index, filtered by age, path, marker and author.
+Chunks overlap, so a marker may be stored in two chunks; listings report
+each (path, line) once.
+"""
+
+import fnmatch
+import logging
+import re
+import subprocess
+import threading
+import time
+from dataclasses import asdict, dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Sequence
+
+from ..utils.git_runner import get_current_branch, run_git_command
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload key holding the task markers of a chunk
+TASK_MARKERS_KEY = "task_markers"
+
+DEFAULT_MARKERS = ("TODO", "FIXME", "HACK", "XXX")
+GIT_TIMEOUT_SECONDS = 30
+
+# Tokens that open a comment in the languages cidx indexes
+_COMMENT_OPENERS = ("//", "#", "/*", "*", "--", ";", "<!--", "%", '"""', "{-")
+_COMMENT_CLOSERS = re.compile(r"\s*(?:\*/|-->|-}|\"\"\")\s*$")
+_UNCOMMITTED_COMMIT = "0" * 40
+
+
+@dataclass
+class TaskMarker:
+    """A task comment found in an indexed file."""
+
+    path: str
+    line: int
+    marker: str
+    text: str
+    author: Optional[str] = None
+    author_email: Optional[str] = None
+    authored_at: Optional[float] = None
+    commit: Optional[str] = None
+
+    def age_seconds(self, now: float) -> Optional[float]:
+        if self.authored_at is None:
+            return None
+        return max(0.0, now - self.authored_at)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+class TaskMarkerExtractor:
+    """Finds task comments in chunks and dates them with git blame."""
+
+    def __init__(
+        self,
+        codebase_dir: Path,
+        markers: Sequence[str] = DEFAULT_MARKERS,
+        blame: bool = True,
+    ):
+        """
+        Initialize the extractor.
+
+        Args:
+            codebase_dir: Project root; git blame runs here
+            markers: Comment markers to extract (matched case-sensitively)
+            blame: Record author and date of each marker via git blame
+
+        Raises:
+            ValueError: If no markers are given
+        """
+        if not markers:
+            raise ValueError("At least one task marker is required")
+        self.codebase_dir = codebase_dir
+        self.markers = list(markers)
+        self.blame = blame
+        alternatives = "|".join(re.escape(m) for m in self.markers)
+        # MARKER, MARKER:, MARKER(owner): text
+        self._pattern = re.compile(
+            rf"(?<![\w-])(?P<marker>{alternatives})(?![\w-])"
+            r"(?:\((?P<owner>[^)\n]*)\))?[:!]?[ \t]*(?P<text>.*)$"
+        )
+        self._lock = threading.Lock()
+        self._files_with_markers = 0
+        self._markers_found = 0
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["TaskMarkerExtractor"]:
+        """Extractor from indexing.task_markers, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        markers_config = getattr(indexing_config, "task_markers", None)
+        if getattr(markers_config, "enabled", False) is not True:
+            return None
+        return cls(
+            codebase_dir=Path(config.codebase_dir),
+            markers=list(markers_config.markers),
+            blame=markers_config.blame,
+        )
+
+    def extract(self, text: str, line_start: int = 1) -> List[Dict[str, Any]]:
+        """
+        Find task comments in text.
+
+        Args:
+            text: Chunk text
+            line_start: File line number of the first line of text
+
+        Returns:
+            One {"marker", "line", "text"} entry per task comment
+        """
+        found = []
+        for offset, line in enumerate(text.split("\n")):
+            entry = self._match_line(line)
+            if entry is not None:
+                entry["line"] = line_start + offset
+                found.append(entry)
+        return found
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """
+        Return the chunks with their task comments under TASK_MARKERS_KEY.
+
+        Git blame runs once per file that has task comments.
+        """
+        per_chunk = [
+            self.extract(chunk["text"], chunk.get("line_start") or 1)
+            for chunk in chunks
+        ]
+        lines = sorted({entry["line"] for entries in per_chunk for entry in entries})
+        if not lines:
+            return chunks
+
+        attribution = self._attribute(file_path, lines)
+        annotated = []
+        for chunk, entries in zip(chunks, per_chunk):
+            if entries:
+                for entry in entries:
+                    entry.update(attribution.get(entry["line"], {}))
+                chunk = {**chunk, TASK_MARKERS_KEY: entries}
+            annotated.append(chunk)
+
+        with self._lock:
+            self._files_with_markers += 1
+            self._markers_found += len(lines)
+        return annotated
+
+    def get_stats(self) -> Dict[str, Any]:
+        """Return counts of files with task comments and comments found."""
+        with self._lock:
+            return {
+                "files_with_markers": self._files_with_markers,
+                "markers_found": self._markers_found,
+            }
+
+    def _match_line(self, line: str) -> Optional[Dict[str, Any]]:
+        match = self._pattern.search(line)
+        if match is None:
+            return None
+        # Only comments count: a comment opener must precede the marker
+        prefix = line[: match.start()].strip()
+        if not prefix or not any(
+            prefix.endswith(opener) or prefix.startswith(opener)
+            for opener in _COMMENT_OPENERS
+        ):
+            return None
+        text = _COMMENT_CLOSERS.sub("", match.group("text")).strip()
+        entry: Dict[str, Any] = {"marker": match.group("marker"), "text": text}
+        if match.group("owner"):
+            entry["owner"] = match.group("owner").strip()
+        return entry
+
+    def _attribute(self, file_path: Path, lines: List[int]) -> Dict[int, Dict]:
+        """Author and date of lines; uncommitted lines get the file's mtime."""
+        try:
+            fallback = {"authored_at": file_path.stat().st_mtime}
+        except OSError:
+            fallback = {"authored_at": time.time()}
+        attribution = {line: dict(fallback) for line in lines}
+        if not self.blame:
+            return attribution
+
+        cmd = ["git", "blame", "--line-porcelain"]
+        for line in lines:
+            cmd.extend(["-L", f"{line},{line}"])
+        cmd.extend(["--", str(file_path)])
+        try:
+            result = run_git_command(
+                cmd, cwd=self.codebase_dir, check=True, timeout=GIT_TIMEOUT_SECONDS
+            )
+        except (OSError, subprocess.SubprocessError) as e:
+            logger.debug(f"git blame unavailable for {file_path}: {e}")
+            return attribution
+
+        for line, blame in _parse_line_porcelain(result.stdout).items():
+            if blame["commit"] != _UNCOMMITTED_COMMIT and line in attribution:
+                attribution[line] = blame
+        return attribution
+
+
+def _parse_line_porcelain(output: str) -> Dict[int, Dict[str, Any]]:
+    """Parse ``git blame --line-porcelain`` into {final line: attribution}."""
+    blamed: Dict[int, Dict[str, Any]] = {}
+    current: Optional[Dict[str, Any]] = None
+    line_number = 0
+    for raw in output.split("\n"):
+        if raw.startswith("\t"):
+            if current is not None:
+                blamed[line_number] = current
+            current = None
+            continue
+        fields = raw.split(" ")
+        if current is None and len(fields) >= 3 and len(fields[0]) == 40:
+            current = {"commit": fields[0]}
+            line_number = int(fields[2])
+        elif current is not None and raw.startswith("author "):
+            current["author"] = raw[len("author ") :]
+        elif current is not None and raw.startswith("author-mail "):
+            current["author_email"] = raw[len("author-mail ") :].strip("<>")
+        elif current is not None and raw.startswith("author-time "):
+            current["authored_at"] = float(raw[len("author-time ") :])
+    return blamed
+
+
+def find_task_markers(
+    vector_store: Any,
+    project_root: Path,
+    collections: Optional[Iterable[str]] = None,
+    older_than_seconds: Optional[float] = None,
+    path_filter: Optional[str] = None,
+    markers: Sequence[str] = (),
+    author: Optional[str] = None,
+    now: Optional[float] = None,
+) -> List[TaskMarker]:
+    """
+    List task comments of the content visible on the current branch.
+
+    Args:
+        vector_store: FilesystemVectorStore holding the collections
+        project_root: Git working tree the collections were indexed from
+        collections: Content collections to read (default: all but git history)
+        older_than_seconds: Only comments at least this old
+        path_filter: Path prefix ("pkg/") or glob pattern ("*/api/*.go")
+        markers: Only these markers (default: all)
+        author: Only comments whose author name or email contains this text
+        now: Reference time for ages (default: current time)
+
+    Returns:
+        Task comments, oldest first; undated ones last
+    """
+    from ..storage.temporal_metadata_store import TemporalMetadataStore
+
+    now = time.time() if now is None else now
+    if collections is None:
+        collections = [
+            name
+            for name in vector_store.list_collections()
+            if not TemporalMetadataStore.is_temporal_collection(name)
+        ]
+    current_branch = get_current_branch(project_root)
+
+    found: Dict[tuple, TaskMarker] = {}
+    for collection_name in collections:
+        for _, data, _ in vector_store.iter_vector_records(collection_name):
+            payload = (data or {}).get("payload", {})
+            entries = payload.get(TASK_MARKERS_KEY)
+            if not entries:
+                continue
+            if current_branch and current_branch in payload.get("hidden_branches", []):
+                continue
+            path = str(payload.get("path", ""))
+            if path_filter and not _path_matches(path, path_filter):
+                continue
+            for entry in entries:
+                task = TaskMarker(
+                    path=path,
+                    line=int(entry.get("line", 0)),
+                    marker=str(entry.get("marker", "")),
+                    text=str(entry.get("text", "")),
+                    author=entry.get("author"),
+                    author_email=entry.get("author_email"),
+                    authored_at=entry.get("authored_at"),
+                    commit=entry.get("commit"),
+                )
+                if _task_matches(task, older_than_seconds, markers, author, now):
+                    found[(task.path, task.line)] = task
+
+    return sorted(
+        found.values(),
+        key=lambda t: (t.authored_at is None, t.authored_at or 0, t.path, t.line),
+    )
+
+
+def _path_matches(path: str, path_filter: str) -> bool:
+    if any(char in path_filter for char in "*?["):
+        return fnmatch.fnmatch(path, path_filter)
+    prefix = path_filter.strip("/")
+    return path == prefix or path.startswith(prefix + "/")
+
+
+def _task_matches(
+    task: TaskMarker,
+    older_than_seconds: Optional[float],
+    markers: Sequence[str],
+    author: Optional[str],
+    now: float,
+) -> bool:
+    if markers and task.marker not in markers:
+        return False
+    if author:
+        needle = author.lower()
+        names = f"{task.author or ''} {task.author_email or ''}".lower()
+        if needle not in names:
+            return False
+    if older_than_seconds is not None:
+        age = task.age_seconds(now)
+        if age is None or age < older_than_seconds:
+            return False
+    return True
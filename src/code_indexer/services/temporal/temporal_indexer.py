@@ -21,6 +21,7 @@ from ...config import ConfigManager
 from ...indexing.fixed_size_chunker import FixedSizeChunker
 from ...services.vector_calculation_manager import VectorCalculationManager
 from ...services.file_identifier import FileIdentifier
+from ...services.pii_scrubber import PiiScrubber
 from ...storage.filesystem_vector_store import FilesystemVectorStore
 
 from .models import CommitInfo
@@ -119,6 +120,7 @@ class TemporalIndexer:
             diff_context_lines=diff_context_lines,
         )
         self.chunker = FixedSizeChunker(self.config)
+        self.pii_scrubber = PiiScrubber.from_config(self.config)
 
         # Initialize blob registry for tracking indexed content
         self.indexed_blobs: set[str] = set()
@@ -185,6 +187,13 @@ class TemporalIndexer:
             self.vector_store.create_collection(
                 self.TEMPORAL_COLLECTION_NAME, vector_size
             )
+            from ...storage.index_encryption import key_source_from_config
+
+            key_source = key_source_from_config(self.config)
+            if key_source is not None:
+                self.vector_store.migrate_encryption(
+                    self.TEMPORAL_COLLECTION_NAME, key_source
+                )
 
     def _count_tokens(self, text: str, vector_manager) -> int:
         """Count tokens using provider-specific token counting.
@@ -668,6 +677,10 @@ class TemporalIndexer:
                             chunks = self.chunker.chunk_text(
                                 diff_info.diff_content, Path(diff_info.file_path)
                             )
+                            if chunks and self.pii_scrubber is not None:
+                                chunks = self.pii_scrubber.scrub_chunks(
+                                    chunks, Path(diff_info.file_path)
+                                )
 
                             if chunks:
                                 # BUG #7 FIX: Check point existence BEFORE collecting chunks
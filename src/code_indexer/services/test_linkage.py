@@ -0,0 +1,209 @@
+"""
+Links test files to the files they test.
+
+Test files are recognized by the naming conventions of their language:
+
+- Go: foo_test.go
+- Python: test_foo.py, foo_test.py
+- JavaScript/TypeScript: foo.test.ts, foo.spec.js (also under __tests__/)
+- Java/Kotlin: FooTest.java, FooTests.kt, TestFoo.java, FooIT.java
+
+The subject of a test file is the project file with the stripped name
+(foo.go, foo.py, Foo.java) in the same directory or, failing that, the one
+whose directories best match the test's once test directories such as
+tests/ or src/test/ are disregarded (tests/unit/api/test_user.py tests
+src/app/api/user.py; src/test/java/com/x/FooTest.java tests
+src/main/java/com/x/Foo.java). Names that remain ambiguous are not linked.
+
+Every chunk of a test file records "is_test": true and, when subjects are
+found, "test_of": their project-relative paths. ``cidx query --only-tests``
+and ``--exclude-tests`` filter on the former.
+"""
+
+import logging
+import os
+import re
+import threading
+from collections import defaultdict
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload keys marking test files and the files they test
+IS_TEST_KEY = "is_test"
+TEST_OF_KEY = "test_of"
+
+# Directories holding tests, disregarded when comparing test and subject paths
+TEST_DIRS = {"test", "tests", "__tests__", "spec", "specs", "testing"}
+
+# Directories never holding subjects
+_SKIPPED_DIRS = {"node_modules", "vendor", "__pycache__"}
+
+_JS_EXTENSIONS = (".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx")
+_JVM_EXTENSIONS = (".java", ".kt")
+
+_JS_TEST = re.compile(r"^(.+)\.(?:test|spec)\.(?:[mc]?js|jsx|tsx?)$")
+_JVM_TEST = re.compile(r"^(?:(?P<prefixed>Test[A-Z]\w*)|(?P<name>\w+?)(?:Tests?|IT))$")
+
+
+def subject_names(file_name: str) -> Optional[Tuple[str, ...]]:
+    """
+    Candidate file names of the subject of a test file.
+
+    Returns:
+        Subject file names, or None if the name is not a test file name
+    """
+    stem, extension = os.path.splitext(file_name)
+    if extension == ".go":
+        if stem.endswith("_test") and len(stem) > len("_test"):
+            return (stem[: -len("_test")] + ".go",)
+        return None
+    if extension == ".py":
+        if stem.startswith("test_") and len(stem) > len("test_"):
+            return (stem[len("test_") :] + ".py",)
+        if stem.endswith("_test") and len(stem) > len("_test"):
+            return (stem[: -len("_test")] + ".py",)
+        return None
+    if extension in _JS_EXTENSIONS:
+        match = _JS_TEST.match(file_name)
+        if match is None:
+            return None
+        return tuple(match.group(1) + e for e in _JS_EXTENSIONS)
+    if extension in _JVM_EXTENSIONS:
+        match = _JVM_TEST.match(stem)
+        if match is None:
+            return None
+        name = match.group("name") or match.group("prefixed")[len("Test") :]
+        return tuple(name + e for e in _JVM_EXTENSIONS)
+    return None
+
+
+def is_test_file(file_name: str) -> bool:
+    return subject_names(file_name) is not None
+
+
+def _source_dirs(relative_dir: str) -> List[str]:
+    """Directories of a path with test directories left out."""
+    return [
+        d for d in relative_dir.split("/") if d and d.lower() not in TEST_DIRS
+    ]
+
+
+def _shared_suffix(a: List[str], b: List[str]) -> int:
+    count = 0
+    while count < min(len(a), len(b)) and a[-1 - count] == b[-1 - count]:
+        count += 1
+    return count
+
+
+class SubjectLinker:
+    """Finds the subjects of a project's test files."""
+
+    def __init__(self, codebase_dir: Path, file_finder: Optional[Any] = None):
+        """
+        Initialize the linker; project file names are collected on first use.
+
+        Args:
+            codebase_dir: Project root
+            file_finder: FileFinder of the indexing run; without it, every
+                file outside hidden directories is considered
+        """
+        self.codebase_dir = Path(codebase_dir).resolve()
+        self.file_finder = file_finder
+        self._lock = threading.Lock()
+        # File name -> relative paths of the project files with that name
+        self._paths_by_name: Optional[Dict[str, List[str]]] = None
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["SubjectLinker"]:
+        """Linker from indexing.test_linkage, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        linkage_config = getattr(indexing_config, "test_linkage", None)
+        if getattr(linkage_config, "enabled", False) is not True:
+            return None
+        from ..indexing.file_finder import FileFinder
+
+        return cls(Path(config.codebase_dir), FileFinder(config))
+
+    def subjects_of(self, relative_path: str) -> List[str]:
+        """Relative paths of the files a test file tests (empty if unknown)."""
+        names = subject_names(os.path.basename(relative_path))
+        if not names:
+            return []
+        test_dir = os.path.dirname(relative_path)
+        paths_by_name = self.paths_by_name()
+        candidates = [p for n in names for p in paths_by_name.get(n, [])]
+
+        same_dir = [p for p in candidates if os.path.dirname(p) == test_dir]
+        if same_dir:
+            return sorted(same_dir)
+        # __tests__/foo.test.js tests ../foo.js
+        parent_dir = os.path.dirname(test_dir)
+        if os.path.basename(test_dir).lower() in TEST_DIRS:
+            parent = [p for p in candidates if os.path.dirname(p) == parent_dir]
+            if parent:
+                return sorted(parent)
+
+        test_dirs = _source_dirs(test_dir)
+        scored: Dict[int, List[str]] = defaultdict(list)
+        for path in candidates:
+            score = _shared_suffix(test_dirs, _source_dirs(os.path.dirname(path)))
+            scored[score].append(path)
+        if not scored:
+            return []
+        best = max(scored)
+        # A name shared by unrelated directories is ambiguous
+        if best == 0 and len(scored[best]) > 1:
+            return []
+        return sorted(scored[best])
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """
+        Return the chunks of test files with IS_TEST_KEY set and their
+        subjects under TEST_OF_KEY.
+        """
+        if not chunks or not is_test_file(Path(file_path).name):
+            return chunks
+        try:
+            relative = Path(file_path).resolve().relative_to(self.codebase_dir)
+        except ValueError:
+            return chunks  # Outside the project
+        payload: Dict[str, Any] = {IS_TEST_KEY: True}
+        subjects = self.subjects_of(relative.as_posix())
+        if subjects:
+            payload[TEST_OF_KEY] = subjects
+        return [{**chunk, **payload} for chunk in chunks]
+
+    def paths_by_name(self) -> Dict[str, List[str]]:
+        """Project files by name, collected on first use."""
+        with self._lock:
+            if self._paths_by_name is None:
+                self._paths_by_name = self._scan()
+            return self._paths_by_name
+
+    def _files(self) -> Iterable[Path]:
+        if self.file_finder is not None:
+            yield from self.file_finder.find_files()
+            return
+        for root, dirs, files in os.walk(self.codebase_dir):
+            dirs[:] = [
+                d for d in dirs if not d.startswith(".") and d not in _SKIPPED_DIRS
+            ]
+            for name in files:
+                yield Path(root) / name
+
+    def _scan(self) -> Dict[str, List[str]]:
+        paths_by_name: Dict[str, List[str]] = defaultdict(list)
+        for path in self._files():
+            if is_test_file(path.name):
+                continue
+            try:
+                relative = path.resolve().relative_to(self.codebase_dir)
+            except ValueError:
+                continue
+            paths_by_name[path.name].append(relative.as_posix())
+        logger.debug(f"Test linkage: {len(paths_by_name)} subject file names")
+        return dict(paths_by_name)
@@ -0,0 +1,263 @@
+"""
+Go type parameter metadata.
+
+Generic Go functions and types declare type parameters and the constraints
+they must satisfy: `func Map[T any, U comparable](...)`,
+`type Repository[T Entity, K comparable] struct`. While a Go file is
+indexed, the generic declarations each chunk contains or starts inside are
+recorded in its payload:
+
+- "type_parameters": one {"declaration", "kind", "line", "parameters"} entry
+  per generic declaration, "parameters" holding {"name", "constraint"} pairs
+- "type_constraints": the constraints of those parameters, e.g. ["any",
+  "comparable", "constraints.Ordered", "~int | ~float64"]
+- "symbol_kind": "generic", so ``cidx query --symbol-kind generic`` finds
+  them
+
+A chunk that starts inside a generic declaration gets the declaration's
+type parameter list in front of its embedded text, so what T stands for is
+part of what is embedded. Declarations are found line by line; a type
+parameter list may span lines.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+from ..indexing.boundary_chunking import SYMBOL_KIND_KEY
+from .boilerplate_filter import EMBEDDING_TEXT_KEY
+
+logger = logging.getLogger(__name__)
+
+# Chunk and payload keys holding the generic declarations of a chunk
+TYPE_PARAMETERS_KEY = "type_parameters"
+TYPE_CONSTRAINTS_KEY = "type_constraints"
+
+# symbol_kind of chunks with generic declarations
+GENERIC_KIND = "generic"
+
+GO_EXTENSIONS = {".go"}
+
+# Methods cannot declare type parameters; their receivers only name them
+_FUNC_DECL = re.compile(r"^func\s+([A-Za-z_]\w*)\s*\[")
+# "type Name[" or, inside "type ( ... )", "Name["
+_TYPE_DECL = re.compile(r"^type\s+([A-Za-z_]\w*)\s*\[")
+_GROUPED_TYPE_DECL = re.compile(r"^\s+([A-Za-z_]\w*)\s*\[")
+_TYPE_GROUP = re.compile(r"^type\s*\(")
+_STRING = re.compile(r'"(?:[^"\\]|\\.)*"|`[^`]*`|\'(?:[^\'\\]|\\.)*\'')
+_IDENTIFIER = re.compile(r"^[A-Za-z_]\w*$")
+_OPENERS = {"[": "]", "(": ")", "{": "}"}
+
+
+@dataclass
+class TypeParameter:
+    """A type parameter and its constraint."""
+
+    name: str
+    constraint: str
+
+
+@dataclass
+class GenericDeclaration:
+    """A generic Go function or type."""
+
+    declaration: str
+    kind: str  # "func" or "type"
+    line: int
+    end_line: int
+    parameters: List[TypeParameter] = field(default_factory=list)
+
+    def signature(self) -> str:
+        """The name and type parameter list, e.g. "Map[T any, U comparable]"."""
+        listed = ", ".join(f"{p.name} {p.constraint}" for p in self.parameters)
+        return f"{self.declaration}[{listed}]"
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "declaration": self.declaration,
+            "kind": self.kind,
+            "line": self.line,
+            "parameters": [
+                {"name": p.name, "constraint": p.constraint} for p in self.parameters
+            ],
+        }
+
+
+def _code(line: str) -> str:
+    """A line without its string literals and comment."""
+    return _STRING.sub('""', line).split("//", 1)[0]
+
+
+def _split_top_level(text: str) -> List[str]:
+    """Items of a comma-separated list, ignoring commas inside brackets."""
+    items, depth, start = [], 0, 0
+    for i, char in enumerate(text):
+        if char in "[({":
+            depth += 1
+        elif char in "])}":
+            depth -= 1
+        elif char == "," and depth == 0:
+            items.append(text[start:i])
+            start = i + 1
+    items.append(text[start:])
+    return [item.strip() for item in items if item.strip()]
+
+
+def parse_type_parameters(text: str) -> Optional[List[TypeParameter]]:
+    """
+    Type parameters of the text between the brackets of a declaration.
+
+    Names listed together share the constraint that follows them
+    ("K, V comparable"). Returns None when the text is not a type parameter
+    list, e.g. the length of an array type ("type Buffer [64]byte").
+    """
+    parameters: List[TypeParameter] = []
+    pending: List[str] = []
+    for item in _split_top_level(text):
+        name, _, constraint = item.partition(" ")
+        if not _IDENTIFIER.match(name):
+            return None
+        constraint = " ".join(constraint.split())
+        if not constraint:
+            pending.append(name)
+            continue
+        parameters.extend(TypeParameter(n, constraint) for n in (*pending, name))
+        pending = []
+    if pending or not parameters:
+        return None
+    return parameters
+
+
+def _bracket_text(lines: List[str], line: int, column: int) -> Tuple[str, int]:
+    """Text between the bracket at lines[line][column] and its match."""
+    depth = 0
+    parts = []
+    for number in range(line, len(lines)):
+        code = _code(lines[number])
+        start = column if number == line else 0
+        for i in range(start, len(code)):
+            char = code[i]
+            if char in _OPENERS:
+                depth += 1
+            elif char in _OPENERS.values():
+                depth -= 1
+                if depth == 0:
+                    parts.append(code[start + 1 if number == line else 0 : i])
+                    return " ".join(parts), number
+        parts.append(code[start + 1 if number == line else 0 :])
+    return " ".join(parts), len(lines) - 1
+
+
+def _end_line(lines: List[str], line: int) -> int:
+    """Last line of the declaration starting at line (0-based)."""
+    depth = 0
+    for number in range(line, len(lines)):
+        code = _code(lines[number])
+        depth += sum(code.count(c) for c in _OPENERS)
+        depth -= sum(code.count(c) for c in _OPENERS.values())
+        if depth <= 0:
+            return number
+    return len(lines) - 1
+
+
+def find_generic_declarations(text: str) -> List[GenericDeclaration]:
+    """Generic functions and types of a Go source file."""
+    lines = text.split("\n")
+    declarations: List[GenericDeclaration] = []
+    in_type_group = False
+    for number, line in enumerate(lines):
+        if in_type_group:
+            if line.startswith(")"):
+                in_type_group = False
+                continue
+            match = _GROUPED_TYPE_DECL.match(line)
+            kind = "type"
+        elif _TYPE_GROUP.match(line):
+            in_type_group = True
+            continue
+        else:
+            match = _FUNC_DECL.match(line)
+            kind = "func"
+            if match is None:
+                match = _TYPE_DECL.match(line)
+                kind = "type"
+        if match is None:
+            continue
+        params_text, _ = _bracket_text(lines, number, match.end() - 1)
+        parameters = parse_type_parameters(params_text)
+        if parameters is None:
+            continue
+        declarations.append(
+            GenericDeclaration(
+                declaration=match.group(1),
+                kind=kind,
+                line=number + 1,
+                end_line=_end_line(lines, number) + 1,
+                parameters=parameters,
+            )
+        )
+    return declarations
+
+
+class TypeParameterExtractor:
+    """Records the generic declarations of Go chunks in their payload."""
+
+    @classmethod
+    def from_config(cls, config: Any) -> Optional["TypeParameterExtractor"]:
+        """Extractor from indexing.type_parameters, or None when disabled."""
+        indexing_config = getattr(config, "indexing", None)
+        parameters_config = getattr(indexing_config, "type_parameters", None)
+        if getattr(parameters_config, "enabled", False) is not True:
+            return None
+        return cls()
+
+    def annotate_chunks(
+        self, chunks: List[Dict[str, Any]], file_path: Path
+    ) -> List[Dict[str, Any]]:
+        """
+        Return the chunks with their generic declarations under
+        TYPE_PARAMETERS_KEY and TYPE_CONSTRAINTS_KEY.
+
+        Declarations are assigned to the chunks their lines overlap; the file
+        is parsed once.
+        """
+        if not chunks or Path(file_path).suffix.lower() not in GO_EXTENSIONS:
+            return chunks
+        try:
+            text = Path(file_path).read_text(encoding="utf-8", errors="replace")
+        except OSError as e:
+            logger.warning(f"Could not read {file_path} for type parameters: {e}")
+            return chunks
+        declarations = find_generic_declarations(text)
+        if not declarations:
+            return chunks
+
+        annotated = []
+        for chunk in chunks:
+            line_start = chunk.get("line_start") or 1
+            line_end = chunk.get("line_end") or line_start
+            in_chunk = [
+                d
+                for d in declarations
+                if d.line <= line_end and d.end_line >= line_start
+            ]
+            if in_chunk:
+                constraints = [p.constraint for d in in_chunk for p in d.parameters]
+                chunk = {
+                    **chunk,
+                    TYPE_PARAMETERS_KEY: [d.to_dict() for d in in_chunk],
+                    TYPE_CONSTRAINTS_KEY: list(dict.fromkeys(constraints)),
+                }
+                chunk.setdefault(SYMBOL_KIND_KEY, GENERIC_KIND)
+                # The chunk starts inside the declarations' bodies
+                outer = [d for d in in_chunk if d.line < line_start]
+                if outer:
+                    context = "\n".join(
+                        f"Type parameters: {d.signature()}" for d in outer
+                    )
+                    embedded = chunk.get(EMBEDDING_TEXT_KEY, chunk["text"])
+                    chunk[EMBEDDING_TEXT_KEY] = f"{context}\n\n{embedded}"
+            annotated.append(chunk)
+        return annotated
@@ -0,0 +1,160 @@
+"""
+UpsertStage - dedicated vector storage writer fed by a bounded queue.
+
+Decouples the final pipeline stage (vector storage upsert + FTS document
+writes) from the chunk/embed worker threads:
+
+    chunk -> embed (FileChunkingManager workers) -> [bounded queue] -> upsert
+
+Worker threads hand off a completed file's points and immediately move on to
+chunking and embedding the next file while the writer thread persists the
+previous one. When storage falls behind, the bounded queue fills up and
+``submit()`` blocks, which propagates backpressure to the embedding stage
+instead of accumulating unbounded numbers of embedded-but-unwritten points in
+memory.
+"""
+
+import logging
+import queue
+import threading
+import time
+from typing import Callable, Optional
+
+logger = logging.getLogger(__name__)
+
+# Constants
+WRITER_SHUTDOWN_TIMEOUT = 60.0  # Seconds to wait for queued writes to drain
+SUBMIT_POLL_INTERVAL = 0.5  # Seconds between closed-stage checks while blocked
+
+
+class UpsertStage:
+    """Single writer thread consuming write jobs from a bounded queue."""
+
+    def __init__(self, queue_size: int, name: str = "UpsertWriter"):
+        """
+        Initialize the upsert stage.
+
+        Args:
+            queue_size: Maximum number of pending write jobs before producers block
+            name: Writer thread name (useful in thread dumps)
+
+        Raises:
+            ValueError: If queue_size is not positive
+        """
+        if queue_size <= 0:
+            raise ValueError(f"queue_size must be positive, got {queue_size}")
+
+        self.queue_size = queue_size
+        self.name = name
+        self._queue: "queue.Queue[Optional[Callable[[], None]]]" = queue.Queue(
+            maxsize=queue_size
+        )
+        self._thread: Optional[threading.Thread] = None
+        self._closed = threading.Event()
+        # Held while a job is queued and while close() queues the sentinel, so
+        # no job lands behind the sentinel
+        self._submit_lock = threading.Lock()
+        self._stats_lock = threading.Lock()
+
+        # Pipeline statistics (read via get_stats())
+        self._jobs_submitted = 0
+        self._jobs_completed = 0
+        self._jobs_failed = 0
+        self._max_queue_depth = 0
+        self._producer_wait_time = 0.0
+
+    def start(self) -> "UpsertStage":
+        """Start the writer thread. Idempotent."""
+        if self._thread is None:
+            self._thread = threading.Thread(
+                target=self._run, name=self.name, daemon=True
+            )
+            self._thread.start()
+            logger.debug(f"Started {self.name} with queue size {self.queue_size}")
+        return self
+
+    def submit(self, job: Callable[[], None]) -> None:
+        """
+        Queue a write job, blocking while the queue is full (backpressure).
+
+        Args:
+            job: Callable performing the write. Exceptions are logged, never raised
+                 on the writer thread, so jobs must report their own outcome.
+
+        Raises:
+            RuntimeError: If the stage is not started or has been closed; the
+                job was not queued and will not run
+        """
+        if self._thread is None:
+            raise RuntimeError("UpsertStage not started - call start() first")
+
+        wait_start = time.time()
+        while True:
+            with self._submit_lock:
+                if self._closed.is_set():
+                    raise RuntimeError("UpsertStage is closed")
+                try:
+                    self._queue.put(job, timeout=SUBMIT_POLL_INTERVAL)
+                    break
+                except queue.Full:
+                    pass
+
+        with self._stats_lock:
+            self._jobs_submitted += 1
+            self._producer_wait_time += time.time() - wait_start
+            self._max_queue_depth = max(self._max_queue_depth, self._queue.qsize())
+
+    def close(self, timeout: float = WRITER_SHUTDOWN_TIMEOUT) -> None:
+        """
+        Drain queued jobs and stop the writer thread.
+
+        Args:
+            timeout: Maximum seconds to wait for the queue to drain
+        """
+        with self._submit_lock:
+            if self._thread is None or self._closed.is_set():
+                return
+            self._closed.set()
+            # Sentinel is queued after all accepted jobs so pending writes
+            # complete; later submits see the stage closed
+            self._queue.put(None)
+        self._thread.join(timeout=timeout)
+        if self._thread.is_alive():
+            logger.warning(
+                f"{self.name} did not drain within {timeout}s - "
+                f"{self._queue.qsize()} write jobs abandoned"
+            )
+
+    def get_backlog_ratio(self) -> float:
+        """Fraction of queue capacity currently occupied (0.0 - 1.0)."""
+        return min(1.0, self._queue.qsize() / self.queue_size)
+
+    def get_stats(self) -> dict:
+        """Return a snapshot of pipeline statistics."""
+        with self._stats_lock:
+            return {
+                "queue_size": self.queue_size,
+                "queue_depth": self._queue.qsize(),
+                "max_queue_depth": self._max_queue_depth,
+                "jobs_submitted": self._jobs_submitted,
+                "jobs_completed": self._jobs_completed,
+                "jobs_failed": self._jobs_failed,
+                "producer_wait_time": self._producer_wait_time,
+            }
+
+    def _run(self) -> None:
+        """Writer loop - executes jobs in submission order until sentinel."""
+        while True:
+            job = self._queue.get()
+            try:
+                if job is None:
+                    break
+                job()
+                with self._stats_lock:
+                    self._jobs_completed += 1
+            except Exception as e:
+                logger.error(f"{self.name} write job failed: {e}")
+                with self._stats_lock:
+                    self._jobs_failed += 1
+            finally:
+                self._queue.task_done()
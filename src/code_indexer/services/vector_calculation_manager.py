@@ -18,6 +18,7 @@ from typing import Dict, Any, Optional, List, Tuple
 import copy
 
 from .embedding_provider import EmbeddingProvider
+from .batch_scheduler import LatencyAwareBatchScheduler
 from ..utils.log_path_helper import get_debug_log_path
 
 logger = logging.getLogger(__name__)
@@ -178,6 +179,45 @@ class VectorCalculationStats:
     total_embeddings_processed: int = 0  # CRITICAL FIX: Track actual embedding counts
 
 
+class ConcurrencyGate:
+    """Counting gate whose limit can be changed while threads are waiting.
+
+    threading.Semaphore cannot shrink, so auto-tuning uses this gate to cap
+    concurrent embedding API calls below the (fixed) thread pool size.
+    """
+
+    def __init__(self, limit: int):
+        if limit <= 0:
+            raise ValueError(f"limit must be positive, got {limit}")
+        self._limit = limit
+        self._active = 0
+        self._condition = threading.Condition()
+
+    @property
+    def limit(self) -> int:
+        return self._limit
+
+    def set_limit(self, limit: int) -> None:
+        """Change the limit; waiters are woken when capacity increases."""
+        if limit <= 0:
+            raise ValueError(f"limit must be positive, got {limit}")
+        with self._condition:
+            self._limit = limit
+            self._condition.notify_all()
+
+    def __enter__(self):
+        with self._condition:
+            while self._active >= self._limit:
+                self._condition.wait()
+            self._active += 1
+        return self
+
+    def __exit__(self, exc_type, exc_val, exc_tb):
+        with self._condition:
+            self._active -= 1
+            self._condition.notify()
+
+
 @dataclass
 class RollingWindowEntry:
     """Entry in rolling window for smoothed statistics."""
@@ -195,6 +235,8 @@ class VectorCalculationManager:
         thread_count: int,
         max_queue_size: int = 1000,
         config_dir: Optional[Path] = None,
+        max_thread_count: Optional[int] = None,
+        batch_scheduler: Optional[LatencyAwareBatchScheduler] = None,
     ):
         """
         Initialize vector calculation manager.
@@ -204,9 +246,15 @@ class VectorCalculationManager:
             thread_count: Number of worker threads
             max_queue_size: Maximum size of task queue
             config_dir: Path to .code-indexer directory for debug logs
+            max_thread_count: Pool size upper bound for auto-tuning. When set, the
+                pool is sized to this value and concurrent API calls are capped by
+                an adjustable limit starting at thread_count.
+            batch_scheduler: Adjusts batch_token_fraction from measured batch
+                latency. Without one the fraction stays fixed (or auto-tuned).
         """
         self.embedding_provider = embedding_provider
         self.thread_count = thread_count
+        self.max_thread_count = max(thread_count, max_thread_count or thread_count)
         self.max_queue_size = max_queue_size
         self.config_dir = config_dir
 
@@ -214,6 +262,20 @@ class VectorCalculationManager:
         self.executor: Optional[ThreadPoolExecutor] = None
         self.is_running = False
 
+        # Adjustable concurrency limit (only when pool is oversized for auto-tuning)
+        self._concurrency_gate: Optional[ConcurrencyGate] = (
+            ConcurrencyGate(thread_count)
+            if self.max_thread_count > thread_count
+            else None
+        )
+
+        # Fraction of model token limit used per embedding batch (auto-tune or
+        # the latency-aware batch scheduler adjusts)
+        self.batch_scheduler = batch_scheduler
+        self.batch_token_fraction = (
+            batch_scheduler.batch_token_fraction if batch_scheduler else 0.9
+        )
+
         # Cancellation support
         self.cancellation_event = threading.Event()
 
@@ -243,15 +305,37 @@ class VectorCalculationManager:
             return
 
         self.executor = ThreadPoolExecutor(
-            max_workers=self.thread_count, thread_name_prefix="VectorCalc"
+            max_workers=self.max_thread_count, thread_name_prefix="VectorCalc"
         )
         self.is_running = True
         self.start_time = time.time()
 
         logger.info(
-            f"Started vector calculation thread pool with {self.thread_count} workers"
+            f"Started vector calculation thread pool with {self.max_thread_count} workers"
         )
 
+    def get_concurrency_limit(self) -> int:
+        """Current maximum number of concurrent embedding API calls."""
+        if self._concurrency_gate is not None:
+            return self._concurrency_gate.limit
+        return self.thread_count
+
+    def set_concurrency_limit(self, limit: int) -> None:
+        """
+        Adjust concurrent embedding API calls at runtime (auto-tuning).
+
+        Args:
+            limit: New limit, clamped to 1..max_thread_count
+
+        Raises:
+            RuntimeError: If the manager was not created with max_thread_count
+        """
+        if self._concurrency_gate is None:
+            raise RuntimeError(
+                "Concurrency limit is fixed - create manager with max_thread_count"
+            )
+        self._concurrency_gate.set_limit(max(1, min(limit, self.max_thread_count)))
+
     def request_cancellation(self):
         """Request cancellation of all pending and new vector calculations."""
         self.cancellation_event.set()
@@ -310,7 +394,7 @@ class VectorCalculationManager:
             self.stats.total_tasks_submitted += 1
             self.stats.active_threads = min(
                 self.stats.total_tasks_submitted - self.stats.total_tasks_completed,
-                self.thread_count,
+                self.get_concurrency_limit(),
             )
 
         return future
@@ -447,7 +531,7 @@ class VectorCalculationManager:
             self.stats.total_tasks_submitted += 1
             self.stats.active_threads = min(
                 self.stats.total_tasks_submitted - self.stats.total_tasks_completed,
-                self.thread_count,
+                self.get_concurrency_limit(),
             )
 
         return future
@@ -510,12 +594,27 @@ class VectorCalculationManager:
                     )
                     f.flush()
 
-            embeddings_list = self.embedding_provider.get_embeddings_batch(
-                chunk_texts_list
-            )
+            if self._concurrency_gate is not None:
+                with self._concurrency_gate:
+                    api_start_time = time.time()
+                    embeddings_list = self.embedding_provider.get_embeddings_batch(
+                        chunk_texts_list
+                    )
+            else:
+                api_start_time = time.time()
+                embeddings_list = self.embedding_provider.get_embeddings_batch(
+                    chunk_texts_list
+                )
 
             processing_time = time.time() - start_time
 
+            # LATENCY-AWARE BATCHING: Provider latency only (excludes gate wait)
+            if self.batch_scheduler is not None:
+                self.batch_token_fraction = self.batch_scheduler.record_batch(
+                    sum(len(text) for text in chunk_texts_list),
+                    time.time() - api_start_time,
+                )
+
             # DEBUG: Log batch processing complete (only if config_dir available)
             if self.config_dir:
                 debug_log_path = get_debug_log_path(
@@ -615,7 +714,7 @@ class VectorCalculationManager:
             # Update active threads count
             self.stats.active_threads = min(
                 self.stats.total_tasks_submitted - self.stats.total_tasks_completed,
-                self.thread_count,
+                self.get_concurrency_limit(),
             )
             self.stats.queue_size = (
                 self.stats.total_tasks_submitted - self.stats.total_tasks_completed
@@ -721,6 +820,10 @@ class VectorCalculationManager:
 
         finally:
             self.executor = None
+            if self.batch_scheduler is not None:
+                logger.info(
+                    f"Batch scheduler stats: {self.batch_scheduler.get_stats()}"
+                )
 
     def __enter__(self):
         """Context manager entry."""
@@ -1,8 +1,10 @@
 """VoyageAI API client for embeddings generation."""
 
 import os
+import ssl
 import time
-from typing import List, Dict, Any, Optional
+from typing import List, Dict, Any, Optional, Tuple, Union
+from urllib.parse import urlparse
 import httpx
 from rich.console import Console
 import yaml  # type: ignore[import-untyped]
@@ -26,12 +28,13 @@ class VoyageAIClient(EmbeddingProvider):
         self.config = config
         self.console = console or Console()
 
-        # Get API key from environment
-        self.api_key = os.getenv("VOYAGE_API_KEY")
+        # Get API key from environment (VOYAGE_API_KEY unless api_key_env is set)
+        api_key_env = config.api_key_env
+        self.api_key = os.getenv(api_key_env)
         if not self.api_key:
             raise ValueError(
-                "VOYAGE_API_KEY environment variable is required for VoyageAI. "
-                "Set it with: export VOYAGE_API_KEY=your_api_key_here"
+                f"{api_key_env} environment variable is required for VoyageAI. "
+                f"Set it with: export {api_key_env}=your_api_key_here"
             )
 
         # Load model specifications from YAML
@@ -70,6 +73,10 @@ class VoyageAIClient(EmbeddingProvider):
 
         return VoyageTokenizer.count_tokens([text], model=self.config.model)
 
+    def warm_up(self) -> None:
+        """Load the tokenizer used for batch token counting (no API call)."""
+        self._count_tokens_accurately("warm up")
+
     def _get_model_token_limit(self) -> int:
         """Get token limit for current model."""
         try:
@@ -112,6 +119,72 @@ class VoyageAIClient(EmbeddingProvider):
         except Exception:
             return False
 
+    def _tls_verify(self) -> Union[bool, str]:
+        """TLS verification setting of api_endpoint requests."""
+        if not self.config.verify_tls:
+            return False
+        return self.config.ca_bundle or True
+
+    def _describe_connection_error(self, error: Exception) -> str:
+        """Explain why api_endpoint could not be reached."""
+        host = urlparse(self.config.api_endpoint).netloc or self.config.api_endpoint
+        cause: Optional[BaseException] = error
+        while cause is not None:
+            if isinstance(cause, ssl.SSLError) or "CERTIFICATE_VERIFY_FAILED" in str(
+                cause
+            ):
+                return (
+                    f"TLS verification failed for {host}: {error}. If the endpoint "
+                    "uses a private CA, set voyage_ai.ca_bundle to its PEM file"
+                )
+            cause = cause.__cause__ or cause.__context__
+        if isinstance(error, httpx.TimeoutException):
+            return (
+                f"Timed out after {self.config.timeout}s waiting for {host} "
+                "(voyage_ai.timeout)"
+            )
+        if isinstance(error, httpx.ConnectError):
+            return f"Cannot connect to {host}: {error}. Check voyage_ai.api_endpoint"
+        return f"Failed to connect to {host}: {error}"
+
+    def check_endpoint(self) -> Tuple[bool, str]:
+        """Probe api_endpoint with a single one-word embedding request.
+
+        Unlike health_check(test_api=True), the request is not retried and the
+        failure is explained (TLS, connectivity, credentials, wrong URL).
+
+        Returns:
+            Tuple of (reachable, human-readable detail)
+        """
+        start = time.time()
+        try:
+            with httpx.Client(
+                headers={
+                    "Authorization": f"Bearer {self.api_key}",
+                    "Content-Type": "application/json",
+                },
+                timeout=self.config.timeout,
+                verify=self._tls_verify(),
+            ) as client:
+                response = client.post(
+                    self.config.api_endpoint,
+                    json={"input": ["test"], "model": self.config.model},
+                )
+        except httpx.HTTPError as e:
+            return False, self._describe_connection_error(e)
+
+        status = response.status_code
+        if status in (401, 403):
+            return False, (
+                f"Endpoint rejected the token in {self.config.api_key_env} "
+                f"(HTTP {status})"
+            )
+        if status == 404:
+            return False, "Endpoint not found (HTTP 404): check voyage_ai.api_endpoint"
+        if status >= 400 and status != 429:  # Rate limited still means reachable
+            return False, f"Endpoint returned HTTP {status}: {response.text[:200]}"
+        return True, f"Reachable (HTTP {status}, {(time.time() - start) * 1000:.0f}ms)"
+
     def _make_sync_request(
         self, texts: List[str], model: Optional[str] = None
     ) -> Dict[str, Any]:
@@ -131,6 +204,7 @@ class VoyageAIClient(EmbeddingProvider):
                         "Content-Type": "application/json",
                     },
                     timeout=self.config.timeout,
+                    verify=self._tls_verify(),
                 ) as client:
                     response = client.post(self.config.api_endpoint, json=payload)
                 response.raise_for_status()
@@ -185,7 +259,7 @@ class VoyageAIClient(EmbeddingProvider):
         if isinstance(last_exception, httpx.HTTPStatusError):
             if last_exception.response.status_code == 401:
                 raise ValueError(
-                    "Invalid VoyageAI API key. Check VOYAGE_API_KEY environment variable."
+                    f"Invalid VoyageAI API key. Check {self.config.api_key_env} environment variable."
                 )
             elif last_exception.response.status_code == 429:
                 raise RuntimeError(
@@ -202,6 +276,10 @@ class VoyageAIClient(EmbeddingProvider):
                     f"Response: {response_text}"
                 )
         else:
+            if isinstance(last_exception, httpx.HTTPError):
+                raise ConnectionError(
+                    f"VoyageAI: {self._describe_connection_error(last_exception)}"
+                )
             raise ConnectionError(f"Failed to connect to VoyageAI: {last_exception}")
 
     def get_embedding(self, text: str, model: Optional[str] = None) -> List[float]:
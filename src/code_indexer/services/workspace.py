@@ -0,0 +1,281 @@
+"""
+Workspaces: local repositories searched together.
+
+'cidx workspace add ../backend' registers an indexed repository under an
+alias (its directory name by default). 'cidx query --repos backend,frontend'
+outside remote mode then searches each repository's own index, with its own
+configuration and embedding model, in parallel; merges the results into one
+ranking; and labels each hit with its repository.
+
+Cosine scores of repositories embedded with the same model are comparable,
+so their results are merged by score. Scores of different models are not:
+then the repositories' rankings are merged by reciprocal rank fusion
+(hybrid_search.RRF_K), which uses ranks only.
+
+The registry is a JSON file in the user's ~/.code-indexer directory.
+"""
+
+import json
+import logging
+import os
+import re
+import tempfile
+import threading
+from concurrent.futures import ThreadPoolExecutor
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+from .hybrid_search import RRF_K
+
+logger = logging.getLogger(__name__)
+
+WORKSPACE_FILE = Path.home() / ".code-indexer" / "workspace.json"
+
+# Result keys: alias of the repository a result came from, and the score
+# results were merged by (the similarity, or the fused rank score)
+REPOSITORY_KEY = "repository"
+MERGED_SCORE_KEY = "merged_score"
+
+# Repositories searched at once
+MAX_PARALLEL_SEARCHES = 4
+
+_ALIAS = re.compile(r"^[A-Za-z0-9][A-Za-z0-9._-]*$")
+
+
+class WorkspaceRegistry:
+    """Persists the aliases and paths of the repositories of a workspace."""
+
+    def __init__(self, workspace_file: Path = WORKSPACE_FILE):
+        """
+        Initialize the registry.
+
+        Args:
+            workspace_file: JSON file holding the repositories (created on
+                first add)
+        """
+        self.workspace_file = Path(workspace_file)
+        self._lock = threading.Lock()
+
+    @property
+    def repositories(self) -> Dict[str, Path]:
+        """Registered repositories: alias to project root."""
+        with self._lock:
+            return {alias: Path(path) for alias, path in self._load().items()}
+
+    def add(self, path: Path, alias: Optional[str] = None) -> str:
+        """
+        Register an indexed repository.
+
+        Args:
+            path: Project root of the repository
+            alias: Name to query it by (default: the directory name)
+
+        Returns:
+            The alias
+
+        Raises:
+            ValueError: If the repository is not indexed, or the alias is
+                invalid or taken by another repository
+        """
+        root = Path(path).expanduser().resolve()
+        if not (root / ".code-indexer" / "config.json").is_file():
+            raise ValueError(f"{root} is not an indexed repository (run 'cidx init')")
+        alias = alias or root.name
+        if not _ALIAS.match(alias):
+            raise ValueError(
+                f"Invalid alias '{alias}': use letters, digits, '.', '_' and '-'"
+            )
+        with self._lock:
+            repositories = self._load()
+            if repositories.get(alias, str(root)) != str(root):
+                raise ValueError(
+                    f"Alias '{alias}' is taken by {repositories[alias]} "
+                    "(choose another with --alias)"
+                )
+            repositories[alias] = str(root)
+            self._save(repositories)
+        return alias
+
+    def remove(self, alias: str) -> bool:
+        """Unregister a repository; False if the alias is unknown."""
+        with self._lock:
+            repositories = self._load()
+            if repositories.pop(alias, None) is None:
+                return False
+            self._save(repositories)
+        return True
+
+    def resolve(self, aliases: List[str]) -> Dict[str, Path]:
+        """
+        Project roots of repositories, in the order given.
+
+        Raises:
+            ValueError: If an alias is not registered
+        """
+        repositories = self.repositories
+        unknown = [alias for alias in aliases if alias not in repositories]
+        if unknown:
+            raise ValueError(
+                f"Not in the workspace: {', '.join(unknown)} "
+                "(register with 'cidx workspace add <path>')"
+            )
+        return {alias: repositories[alias] for alias in aliases}
+
+    def _load(self) -> Dict[str, str]:
+        """Repositories from disk; caller holds the lock."""
+        if not self.workspace_file.exists():
+            return {}
+        try:
+            data = json.loads(self.workspace_file.read_text())
+            return dict(data.get("repositories", {}))
+        except (OSError, ValueError, TypeError, AttributeError) as e:
+            logger.warning(f"Ignoring unreadable {self.workspace_file}: {e}")
+            return {}
+
+    def _save(self, repositories: Dict[str, str]) -> None:
+        """Atomically write the repositories; caller holds the lock."""
+        self.workspace_file.parent.mkdir(parents=True, exist_ok=True)
+        fd, tmp_name = tempfile.mkstemp(
+            dir=self.workspace_file.parent, prefix=".workspace-", suffix=".tmp"
+        )
+        try:
+            with os.fdopen(fd, "w") as f:
+                json.dump(
+                    {"repositories": dict(sorted(repositories.items()))}, f, indent=2
+                )
+            os.replace(tmp_name, self.workspace_file)
+        except BaseException:
+            Path(tmp_name).unlink(missing_ok=True)
+            raise
+
+
+def search_repository(
+    project_root: Path,
+    query: str,
+    limit: int,
+    filter_conditions: Optional[Dict[str, Any]] = None,
+) -> Tuple[List[Dict[str, Any]], str]:
+    """
+    Semantic search of one repository's index, visible on its current branch.
+
+    Returns:
+        Results (best first) and the "provider/model" that embedded them
+    """
+    from ..backends.backend_factory import BackendFactory
+    from ..config import ConfigManager
+    from ..storage.filesystem_vector_store import FilesystemVectorStore
+    from .embedding_factory import EmbeddingProviderFactory
+    from .generic_query_service import GenericQueryService
+
+    config = ConfigManager.create_with_backtrack(Path(project_root)).load()
+    codebase_dir = Path(config.codebase_dir)
+    embedding_provider = EmbeddingProviderFactory.create(config=config)
+    vector_store = BackendFactory.create(config, codebase_dir).get_vector_store_client()
+    collection_name = vector_store.resolve_collection_name(config, embedding_provider)
+
+    # Fetch more to allow for current-branch filtering
+    if isinstance(vector_store, FilesystemVectorStore):
+        results, _ = vector_store.search(
+            query=query,
+            embedding_provider=embedding_provider,
+            filter_conditions=filter_conditions,
+            limit=limit * 2,
+            collection_name=collection_name,
+            return_timing=True,
+        )
+    else:
+        results = vector_store.search(
+            query_vector=embedding_provider.get_embedding(query),
+            filter_conditions=filter_conditions,
+            limit=limit * 2,
+            collection_name=collection_name,
+        )
+    query_service = GenericQueryService(codebase_dir, config)
+    results = query_service.filter_results_by_current_branch(results)
+    model = (
+        f"{embedding_provider.get_provider_name()}/"
+        f"{embedding_provider.get_current_model()}"
+    )
+    return results[:limit], model
+
+
+def merge_results(
+    repo_results: Dict[str, List[Dict[str, Any]]],
+    models: Dict[str, str],
+    limit: int,
+    k: int = RRF_K,
+) -> List[Dict[str, Any]]:
+    """
+    Merge the rankings of several repositories into one.
+
+    Args:
+        repo_results: Results of each repository, best first
+        models: Embedding model of each repository
+        limit: Number of merged results to return
+        k: Rank offset of reciprocal rank fusion
+
+    Returns:
+        Copies of the results labelled with REPOSITORY_KEY and scored under
+        MERGED_SCORE_KEY, best first
+    """
+    fuse = len(set(models.values())) > 1
+    merged = []
+    for alias, results in repo_results.items():
+        for rank, result in enumerate(results, start=1):
+            entry = dict(result)
+            entry[REPOSITORY_KEY] = alias
+            entry[MERGED_SCORE_KEY] = (
+                1.0 / (k + rank) if fuse else result.get("score", 0.0)
+            )
+            merged.append(entry)
+    merged.sort(key=lambda entry: entry[MERGED_SCORE_KEY], reverse=True)
+    return merged[:limit]
+
+
+def federated_search(
+    repositories: Dict[str, Path],
+    query: str,
+    limit: int,
+    filter_conditions: Optional[Dict[str, Any]] = None,
+    min_score: Optional[float] = None,
+) -> Tuple[List[Dict[str, Any]], Dict[str, str]]:
+    """
+    Search several repositories in parallel and merge their results.
+
+    A repository whose search fails is reported and left out; the others
+    still answer.
+
+    Args:
+        repositories: Alias to project root
+        query: Query text
+        limit: Number of merged results
+        filter_conditions: Payload filter applied in every repository
+        min_score: Minimum similarity of results
+
+    Returns:
+        Merged results (see merge_results) and the error of each failed
+        repository
+    """
+    repo_results: Dict[str, List[Dict[str, Any]]] = {}
+    models: Dict[str, str] = {}
+    errors: Dict[str, str] = {}
+    with ThreadPoolExecutor(
+        max_workers=min(MAX_PARALLEL_SEARCHES, max(len(repositories), 1))
+    ) as executor:
+        futures = {
+            alias: executor.submit(
+                search_repository, root, query, limit, filter_conditions
+            )
+            for alias, root in repositories.items()
+        }
+        for alias, future in futures.items():
+            try:
+                results, models[alias] = future.result()
+            except Exception as e:
+                logger.warning(f"Search of {alias} failed: {e}")
+                errors[alias] = str(e)
+                continue
+            if min_score is not None:
+                results = [r for r in results if r.get("score", 0.0) >= min_score]
+            repo_results[alias] = results
+    return merge_results(repo_results, models, limit), errors
@@ -5,7 +5,7 @@ with file locking for cross-process coordination and atomic swap to prevent
 blocking query operations.
 
 Key Features:
-- File locking using fcntl for cross-process coordination
+- File locking (flock, or msvcrt on Windows) for cross-process coordination
 - Atomic file swap using os.rename (kernel-level atomic operation)
 - Lock held for entire rebuild duration (not just swap)
 - Queries don't need locks (OS-level atomic rename guarantees)
@@ -22,13 +22,14 @@ queries to continue reading the old index without blocking.
 """
 
 import contextlib
-import fcntl
 import logging
 import os
 import time
 from pathlib import Path
 from typing import Callable, Generator
 
+from ..utils.file_lock import lock_file, unlock_file
+
 logger = logging.getLogger(__name__)
 
 
@@ -64,7 +65,7 @@ class BackgroundIndexRebuilder:
     def acquire_lock(self) -> Generator[None, None, None]:
         """Acquire exclusive lock for rebuild operations.
 
-        Uses fcntl.flock() for cross-process coordination. Blocks if another
+        Uses a file lock for cross-process coordination. Blocks if another
         process/thread holds the lock.
 
         Yields:
@@ -76,12 +77,12 @@ class BackgroundIndexRebuilder:
         with open(self.lock_file, "r") as lock_f:
             try:
                 # Acquire exclusive lock (blocks if another process holds it)
-                fcntl.flock(lock_f.fileno(), fcntl.LOCK_EX)
+                lock_file(lock_f)
                 logger.debug(f"Acquired rebuild lock: {self.lock_file}")
                 yield
             finally:
                 # Release lock
-                fcntl.flock(lock_f.fileno(), fcntl.LOCK_UN)
+                unlock_file(lock_f)
                 logger.debug(f"Released rebuild lock: {self.lock_file}")
 
     def atomic_swap(self, temp_file: Path, target_file: Path) -> None:
@@ -0,0 +1,220 @@
+"""Zstd compression of stored chunk text and large payload fields.
+
+Vector files keep their JSON layout; compressed values are moved to separate
+keys so readers can tell the formats apart without consulting metadata:
+
+    "chunk_text": "..."          ->  "chunk_text_zstd": "<base64 zstd>"
+    "payload": {"field": "..."}  ->  "payload_zstd": {"field": "<base64 zstd>"}
+
+Whether a collection writes compressed vectors is recorded in
+collection_meta.json under "compression". Collections created before
+compression existed have no such key and keep writing plain text until
+migrated with migrate_collection() (`cidx compress-index`). Reads handle both
+formats, so a collection stays searchable mid-migration.
+"""
+
+import base64
+import json
+import logging
+import threading
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Callable, Dict, Optional
+
+import zstandard
+
+logger = logging.getLogger(__name__)
+
+COMPRESSION_ALGORITHM = "zstd"
+DEFAULT_COMPRESSION_LEVEL = 3
+DEFAULT_PAYLOAD_MIN_BYTES = 4096  # Used by `cidx compress-index --payloads`
+
+COMPRESSION_METADATA_KEY = "compression"
+COMPRESSED_CHUNK_TEXT_KEY = "chunk_text_zstd"
+COMPRESSED_PAYLOAD_KEY = "payload_zstd"
+
+# Read without decompression by path bookkeeping (file counts, path index)
+UNCOMPRESSED_PAYLOAD_FIELDS = frozenset({"path"})
+
+# ZstdCompressor/ZstdDecompressor instances are not safe to share across threads
+_thread_local = threading.local()
+
+
+@dataclass
+class CompressionSettings:
+    """Per-collection compression settings stored in collection_meta.json."""
+
+    enabled: bool = True
+    level: int = DEFAULT_COMPRESSION_LEVEL
+    payload_min_bytes: Optional[int] = None  # None: only chunk text is compressed
+
+    @classmethod
+    def from_metadata(cls, metadata: Dict[str, Any]) -> "CompressionSettings":
+        """Settings for a collection; legacy collections are uncompressed."""
+        compression = metadata.get(COMPRESSION_METADATA_KEY)
+        if not compression or compression.get("algorithm") != COMPRESSION_ALGORITHM:
+            return cls(enabled=False)
+        return cls(
+            enabled=True,
+            level=compression.get("level", DEFAULT_COMPRESSION_LEVEL),
+            payload_min_bytes=compression.get("payload_min_bytes"),
+        )
+
+    def to_metadata(self) -> Dict[str, Any]:
+        return {
+            "algorithm": COMPRESSION_ALGORITHM,
+            "level": self.level,
+            "payload_min_bytes": self.payload_min_bytes,
+        }
+
+
+@dataclass
+class CompressionMigrationResult:
+    """Summary of a collection compression migration."""
+
+    files_scanned: int = 0
+    files_rewritten: int = 0
+    files_failed: int = 0
+    bytes_before: int = 0
+    bytes_after: int = 0
+
+
+def _get_compressor(level: int) -> Any:
+    compressors = getattr(_thread_local, "compressors", None)
+    if compressors is None:
+        compressors = _thread_local.compressors = {}
+    if level not in compressors:
+        compressors[level] = zstandard.ZstdCompressor(level=level)
+    return compressors[level]
+
+
+def _get_decompressor() -> Any:
+    decompressor = getattr(_thread_local, "decompressor", None)
+    if decompressor is None:
+        decompressor = _thread_local.decompressor = zstandard.ZstdDecompressor()
+    return decompressor
+
+
+def compress_text(text: str, level: int = DEFAULT_COMPRESSION_LEVEL) -> str:
+    """Compress text to a base64 string suitable for JSON."""
+    compressed = _get_compressor(level).compress(text.encode("utf-8"))
+    return base64.b64encode(compressed).decode("ascii")
+
+
+def decompress_text(encoded: str) -> str:
+    """Inverse of compress_text()."""
+    compressed = base64.b64decode(encoded)
+    return str(_get_decompressor().decompress(compressed).decode("utf-8"))
+
+
+def compress_vector_data(
+    data: Dict[str, Any], settings: CompressionSettings
+) -> Dict[str, Any]:
+    """Compress chunk text (and large payload fields) of vector data in place."""
+    if not settings.enabled:
+        return data
+
+    chunk_text = data.pop("chunk_text", None)
+    if chunk_text is not None:
+        data[COMPRESSED_CHUNK_TEXT_KEY] = compress_text(chunk_text, settings.level)
+
+    if settings.payload_min_bytes is not None:
+        payload = data.get("payload", {})
+        compressed_fields = {
+            name: compress_text(value, settings.level)
+            for name, value in payload.items()
+            if isinstance(value, str)
+            and name not in UNCOMPRESSED_PAYLOAD_FIELDS
+            and len(value) >= settings.payload_min_bytes
+        }
+        for name in compressed_fields:
+            del payload[name]
+        if compressed_fields:
+            data[COMPRESSED_PAYLOAD_KEY] = compressed_fields
+
+    return data
+
+
+def decompress_vector_data(data: Dict[str, Any]) -> Dict[str, Any]:
+    """Restore compressed fields of vector data in place; plain data is untouched."""
+    encoded_text = data.pop(COMPRESSED_CHUNK_TEXT_KEY, None)
+    if encoded_text is not None:
+        data["chunk_text"] = decompress_text(encoded_text)
+
+    compressed_fields = data.pop(COMPRESSED_PAYLOAD_KEY, None)
+    if compressed_fields:
+        payload = data.setdefault("payload", {})
+        for name, encoded in compressed_fields.items():
+            payload[name] = decompress_text(encoded)
+
+    return data
+
+
+def migrate_collection(
+    collection_path: Path,
+    settings: CompressionSettings,
+    progress_callback: Optional[Callable[[int, int], None]] = None,
+) -> CompressionMigrationResult:
+    """
+    Rewrite every vector file of a collection in the requested format.
+
+    Compresses (settings.enabled) or decompresses (not settings.enabled) existing
+    vector files, then records the settings in collection_meta.json so new
+    writes use the same format. Safe to re-run after an interruption: files
+    already in the target format are rewritten only if their content changes.
+
+    Args:
+        collection_path: Collection directory containing collection_meta.json
+        settings: Target compression settings
+        progress_callback: Optional callback(current, total)
+
+    Returns:
+        CompressionMigrationResult with file and byte counts
+    """
+    meta_file = collection_path / "collection_meta.json"
+    if not meta_file.exists():
+        raise FileNotFoundError(f"Collection metadata not found at {meta_file}")
+
+    result = CompressionMigrationResult()
+    vector_files = sorted(collection_path.rglob("vector_*.json"))
+    total = len(vector_files)
+
+    for idx, vector_file in enumerate(vector_files, 1):
+        result.files_scanned += 1
+        try:
+            original = vector_file.read_bytes()
+            data = decompress_vector_data(json.loads(original))
+            compress_vector_data(data, settings)
+            rewritten = json.dumps(data, indent=2).encode("utf-8")
+
+            result.bytes_before += len(original)
+            result.bytes_after += len(rewritten)
+
+            if rewritten != original:
+                tmp_file = vector_file.with_suffix(".tmp")
+                tmp_file.write_bytes(rewritten)
+                tmp_file.replace(vector_file)
+                result.files_rewritten += 1
+        except (OSError, ValueError, zstandard.ZstdError) as e:
+            # json.JSONDecodeError and UnicodeDecodeError are ValueErrors
+            result.files_failed += 1
+            logger.warning(f"Compression migration skipped {vector_file}: {e}")
+
+        if progress_callback:
+            progress_callback(idx, total)
+
+    with open(meta_file, "r") as f:
+        metadata = json.load(f)
+    if settings.enabled:
+        metadata[COMPRESSION_METADATA_KEY] = settings.to_metadata()
+    else:
+        metadata.pop(COMPRESSION_METADATA_KEY, None)
+    with open(meta_file, "w") as f:
+        json.dump(metadata, f, indent=2)
+
+    logger.info(
+        f"Compression migration of {collection_path.name}: "
+        f"{result.files_rewritten}/{result.files_scanned} files rewritten, "
+        f"{result.bytes_before} -> {result.bytes_after} bytes"
+    )
+    return result
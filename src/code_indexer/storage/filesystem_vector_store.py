@@ -10,7 +10,7 @@ import os
 import random
 import subprocess
 from pathlib import Path
-from typing import List, Dict, Any, Optional, Tuple, Union, Set
+from typing import List, Dict, Any, Iterator, Optional, Tuple, Union, Set
 from datetime import datetime
 import threading
 import numpy as np
@@ -20,6 +20,42 @@ import msgpack
 from .vector_quantizer import VectorQuantizer
 from .projection_matrix_manager import ProjectionMatrixManager
 from .temporal_metadata_store import TemporalMetadataStore
+from .membership_snapshot import (
+    MEMBERSHIP_SNAPSHOT_FILENAME,
+    IndexMembershipSnapshot,
+    payload_timestamp,
+)
+from .chunk_compression import (
+    COMPRESSION_METADATA_KEY,
+    CompressionMigrationResult,
+    CompressionSettings,
+    compress_vector_data,
+    decompress_vector_data,
+    migrate_collection,
+)
+from .index_encryption import (
+    EncryptionMigrationResult,
+    EncryptionSettings,
+    IndexCipher,
+    create_encryption_settings,
+    delete_key,
+    is_encrypted,
+    key_source_from_config,
+    load_cipher,
+)
+from .index_encryption import migrate_collection as migrate_collection_encryption
+from .index_format import (
+    FORMAT_VERSION_KEY,
+    INDEX_FORMAT_VERSION,
+    ensure_collection_format,
+)
+from .payload_dictionary import (
+    PAYLOAD_DICTIONARY_METADATA_KEY,
+    PayloadDictionary,
+    dictionary_fields_from_metadata,
+    dictionary_metadata,
+)
+from .vector_kinds import BODY_MODE, matches_vector_mode, merge_by_chunk
 
 
 class PathIndex:
@@ -81,6 +117,14 @@ class PathIndex:
         """
         return self._path_index.get(file_path, set()).copy()
 
+    def file_paths(self) -> List[str]:
+        """Get all file paths that have at least one point_id.
+
+        Returns:
+            Sorted list of file paths
+        """
+        return sorted(self._path_index)
+
     def save(self, path: Path) -> None:
         """Save path index to disk using msgpack.
 
@@ -137,6 +181,7 @@ class FilesystemVectorStore:
     Features:
     - Path-as-vector quantization for efficient storage
     - Git-aware chunk storage (blob hash for clean, text for dirty)
+    - Zstd-compressed chunk text (per-collection, see chunk_compression)
     - Thread-safe atomic writes
     - ID indexing for fast lookups
     """
@@ -186,6 +231,13 @@ class FilesystemVectorStore:
         self._vector_size_cache: Dict[str, int] = {}
         self._collection_metadata_cache: Dict[str, Dict[str, Any]] = {}
         self._metadata_lock = threading.Lock()  # Protect cache from concurrent access
+        self._compression_settings_cache: Dict[str, CompressionSettings] = {}
+        # None: collection stores plain payloads
+        self._payload_dictionaries: Dict[str, Optional[PayloadDictionary]] = {}
+        # None: collection stores plain text
+        self._ciphers: Dict[str, Optional[IndexCipher]] = {}
+        # Collections whose format version was verified by this instance
+        self._format_checked: Set[str] = set()
 
         # HNSW-001 & HNSW-002: Incremental update change tracking
         # Structure: {collection_name: {'added': set(), 'updated': set(), 'deleted': set()}}
@@ -204,6 +256,11 @@ class FilesystemVectorStore:
         self._path_indexes: Dict[str, PathIndex] = {}
         self._path_index_lock = threading.Lock()
 
+        # Membership snapshots updated in place by this run's writes, saved
+        # once by end_indexing() (see membership_snapshot.py)
+        self._membership_snapshots: Dict[str, IndexMembershipSnapshot] = {}
+        self._membership_snapshot_lock = threading.Lock()
+
         # Story #669: Temporal metadata store for v2 format (lazy-initialized)
         self._temporal_metadata_store: Optional[TemporalMetadataStore] = None
         self._temporal_metadata_lock = threading.Lock()
@@ -245,13 +302,20 @@ class FilesystemVectorStore:
         # Range will be used for locality-preserving fixed-range scalar quantization
         metadata = {
             "name": collection_name,
+            FORMAT_VERSION_KEY: INDEX_FORMAT_VERSION,
             "vector_size": vector_size,
             "created_at": datetime.utcnow().isoformat(),
             "quantization_range": {
                 "min": float(min_val),  # Dynamically computed from matrix dimensions
                 "max": float(max_val),
             },
+            # New collections store compressed chunk text
+            COMPRESSION_METADATA_KEY: CompressionSettings().to_metadata(),
         }
+        # ...and dictionary-encoded payload strings (temporal collections keep
+        # payloads in their own metadata store)
+        if not TemporalMetadataStore.is_temporal_collection(collection_name):
+            metadata[PAYLOAD_DICTIONARY_METADATA_KEY] = dictionary_metadata()
 
         metadata_path = collection_path / "collection_meta.json"
         with open(metadata_path, "w") as f:
@@ -310,6 +374,8 @@ class FilesystemVectorStore:
             f"Beginning indexing session for collection '{collection_name}'"
         )
 
+        self._ensure_collection_format(collection_name)
+
         # Clear file path cache for this collection
         with self._id_index_lock:
             if collection_name in self._file_path_cache:
@@ -461,6 +527,8 @@ class FilesystemVectorStore:
             f"({unique_file_count} unique files)"
         )
 
+        self.save_membership_snapshot(collection_name)
+
         result = {
             "status": "ok",
             "vectors_indexed": vector_count,
@@ -475,6 +543,28 @@ class FilesystemVectorStore:
 
         return result
 
+    def _ensure_collection_format(self, collection_name: str) -> None:
+        """Verify the collection's format version once, migrating it if needed.
+
+        Args:
+            collection_name: Name of the collection
+
+        Raises:
+            IndexFormatError: If the collection was written by a newer cidx or
+                must be re-indexed
+        """
+        if collection_name in self._format_checked:
+            return
+        if not self.collection_exists(collection_name):
+            return
+
+        with self._metadata_lock:
+            if ensure_collection_format(self.base_path / collection_name):
+                # Cached metadata predates the migration
+                self._vector_size_cache.pop(collection_name, None)
+                self._collection_metadata_cache.pop(collection_name, None)
+            self._format_checked.add(collection_name)
+
     def _get_vector_size(self, collection_name: str) -> int:
         """Get vector size for collection (cached to avoid repeated file I/O).
 
@@ -571,6 +661,254 @@ class FilesystemVectorStore:
         except (json.JSONDecodeError, KeyError):
             return (-2.0, 2.0)
 
+    def _get_compression_settings(self, collection_name: str) -> CompressionSettings:
+        """Get compression settings for collection (cached).
+
+        Collections without a "compression" entry in collection_meta.json predate
+        compression and keep writing plain chunk text until migrated.
+
+        Args:
+            collection_name: Name of the collection
+
+        Returns:
+            CompressionSettings for new writes to the collection
+        """
+        with self._metadata_lock:
+            if collection_name in self._compression_settings_cache:
+                return self._compression_settings_cache[collection_name]
+
+            metadata = self._collection_metadata_cache.get(collection_name)
+            if metadata is None:
+                collection_path = self.base_path / collection_name
+                try:
+                    with open(collection_path / "collection_meta.json") as f:
+                        metadata = json.load(f)
+                except (OSError, json.JSONDecodeError):
+                    metadata = {}
+
+            settings = CompressionSettings.from_metadata(metadata)
+            self._compression_settings_cache[collection_name] = settings
+            return settings
+
+    def _get_payload_dictionary(
+        self, collection_name: str
+    ) -> Optional[PayloadDictionary]:
+        """Get the payload dictionary of a collection (cached).
+
+        Collections without a "payload_dictionary" entry in collection_meta.json
+        predate dictionary encoding and keep writing plain payloads.
+
+        Args:
+            collection_name: Name of the collection
+
+        Returns:
+            PayloadDictionary, or None if the collection stores plain payloads
+        """
+        with self._metadata_lock:
+            if collection_name in self._payload_dictionaries:
+                return self._payload_dictionaries[collection_name]
+
+            collection_path = self.base_path / collection_name
+            metadata = self._collection_metadata_cache.get(collection_name)
+            if metadata is None:
+                try:
+                    with open(collection_path / "collection_meta.json") as f:
+                        metadata = json.load(f)
+                except (OSError, json.JSONDecodeError):
+                    # Don't cache: the collection may not be created yet
+                    return None
+
+            fields = dictionary_fields_from_metadata(metadata)
+            dictionary = PayloadDictionary(collection_path, fields) if fields else None
+            self._payload_dictionaries[collection_name] = dictionary
+            return dictionary
+
+    def _get_cipher(self, collection_name: str) -> Optional[IndexCipher]:
+        """Get the cipher of an encrypted collection (cached).
+
+        Collections without an "encryption" entry in collection_meta.json store
+        plain text.
+
+        Args:
+            collection_name: Name of the collection
+
+        Returns:
+            IndexCipher, or None if the collection is not encrypted
+
+        Raises:
+            IndexKeyError: If the collection's key is unavailable or wrong
+        """
+        with self._metadata_lock:
+            if collection_name in self._ciphers:
+                return self._ciphers[collection_name]
+
+            metadata = self._collection_metadata_cache.get(collection_name)
+            if metadata is None:
+                collection_path = self.base_path / collection_name
+                try:
+                    with open(collection_path / "collection_meta.json") as f:
+                        metadata = json.load(f)
+                except (OSError, json.JSONDecodeError):
+                    # Don't cache: the collection may not be created yet
+                    return None
+
+            settings = EncryptionSettings.from_metadata(metadata)
+            cipher = load_cipher(settings) if settings is not None else None
+            self._ciphers[collection_name] = cipher
+            return cipher
+
+    def _get_stored_path(
+        self, collection_name: str, data: Dict[str, Any]
+    ) -> Optional[str]:
+        """payload["path"] of a raw vector file, without decoding the rest."""
+        dictionary = self._get_payload_dictionary(collection_name)
+        if dictionary is None:
+            return data.get("payload", {}).get("path")
+        return dictionary.get_path(data)
+
+    def _read_vector_file(
+        self, vector_file: Path, collection_name: str
+    ) -> Dict[str, Any]:
+        """Load a vector JSON file, decompressing and decoding payload fields.
+
+        Args:
+            vector_file: Path to vector JSON file
+            collection_name: Collection the file belongs to (payload dictionary)
+
+        Returns:
+            Vector data in plain (uncompressed, unencoded) form
+
+        Raises:
+            json.JSONDecodeError: If the file is not valid JSON
+            ValueError: If encrypted, compressed or dictionary-encoded fields are
+                corrupted
+            IndexKeyError: If the collection's encryption key is unavailable
+        """
+        with open(vector_file) as f:
+            data: Dict[str, Any] = json.load(f)
+        if is_encrypted(data):
+            cipher = self._get_cipher(collection_name)
+            if cipher is None:
+                raise ValueError(
+                    f"Encrypted data in unencrypted collection: {vector_file}"
+                )
+            try:
+                cipher.decrypt_vector_data(data)
+            except ValueError as e:
+                raise ValueError(f"Corrupted encrypted data in {vector_file}: {e}")
+        try:
+            decompress_vector_data(data)
+        except Exception as e:
+            raise ValueError(f"Corrupted compressed data in {vector_file}: {e}")
+
+        dictionary = self._get_payload_dictionary(collection_name)
+        if dictionary is not None:
+            dictionary.decode(data)
+        return data
+
+    def migrate_compression(
+        self,
+        collection_name: str,
+        settings: CompressionSettings,
+        progress_callback: Optional[Any] = None,
+    ) -> CompressionMigrationResult:
+        """Rewrite a collection's vector files with the given compression settings.
+
+        Args:
+            collection_name: Name of the collection
+            settings: Target settings (enabled=False decompresses the collection)
+            progress_callback: Optional callback(current, total)
+
+        Returns:
+            CompressionMigrationResult with file and byte counts
+
+        Raises:
+            ValueError: If collection does not exist or is encrypted
+        """
+        if not self.collection_exists(collection_name):
+            raise ValueError(f"Collection '{collection_name}' does not exist")
+        if self._get_cipher(collection_name) is not None:
+            # Chunk text of encrypted collections is only reachable with the key
+            raise ValueError(
+                f"Collection '{collection_name}' is encrypted; decrypt it with "
+                "'cidx encrypt-index --decrypt' before changing compression"
+            )
+
+        result = migrate_collection(
+            self.base_path / collection_name, settings, progress_callback
+        )
+
+        # Metadata changed on disk - drop cached copies
+        with self._metadata_lock:
+            self._compression_settings_cache.pop(collection_name, None)
+            self._collection_metadata_cache.pop(collection_name, None)
+            self._vector_size_cache.pop(collection_name, None)
+
+        return result
+
+    def migrate_encryption(
+        self,
+        collection_name: str,
+        key_source: Optional[str],
+        progress_callback: Optional[Any] = None,
+    ) -> EncryptionMigrationResult:
+        """Rewrite a collection's vector files encrypted or in plain text.
+
+        An already encrypted collection keeps its key; re-running finishes an
+        interrupted migration.
+
+        Args:
+            collection_name: Name of the collection
+            key_source: "keyring" or "passphrase" to encrypt, None to decrypt
+            progress_callback: Optional callback(current, total)
+
+        Returns:
+            EncryptionMigrationResult with file counts
+
+        Raises:
+            ValueError: If collection does not exist
+            IndexKeyError: If the current or new key is unavailable
+        """
+        if not self.collection_exists(collection_name):
+            raise ValueError(f"Collection '{collection_name}' does not exist")
+
+        collection_path = self.base_path / collection_name
+        with open(collection_path / "collection_meta.json") as f:
+            current_settings = EncryptionSettings.from_metadata(json.load(f))
+        current_cipher = self._get_cipher(collection_name)
+
+        target_settings: Optional[EncryptionSettings] = None
+        target_cipher: Optional[IndexCipher] = None
+        if key_source is not None:
+            if current_settings is not None:
+                target_settings, target_cipher = current_settings, current_cipher
+            else:
+                target_settings = create_encryption_settings(key_source)
+                target_cipher = load_cipher(target_settings)
+
+        # Metadata changes on disk - drop cached copies before files change
+        with self._metadata_lock:
+            self._ciphers.pop(collection_name, None)
+            self._collection_metadata_cache.pop(collection_name, None)
+
+        result = migrate_collection_encryption(
+            collection_path,
+            current_cipher,
+            target_settings,
+            target_cipher,
+            progress_callback,
+        )
+
+        with self._metadata_lock:
+            self._ciphers.pop(collection_name, None)
+            self._collection_metadata_cache.pop(collection_name, None)
+
+        if key_source is None and current_settings is not None:
+            if result.files_failed == 0:
+                delete_key(current_settings)
+
+        return result
+
     def _get_temporal_metadata_store(self) -> TemporalMetadataStore:
         """Get or initialize temporal metadata store (lazy initialization).
 
@@ -644,6 +982,13 @@ class FilesystemVectorStore:
         # Load quantization range for locality-preserving quantization
         min_val, max_val = self._load_quantization_range(collection_name)
 
+        compression_settings = self._get_compression_settings(collection_name)
+        payload_dictionary = self._get_payload_dictionary(collection_name)
+        cipher = self._get_cipher(collection_name)
+        if payload_dictionary is not None and points:
+            # New values must be on disk before vector files reference them
+            payload_dictionary.intern(p.get("payload", {}) for p in points)
+
         # Detect git repo root once for batch operation
         repo_root = self._get_repo_root()
 
@@ -665,6 +1010,14 @@ class FilesystemVectorStore:
             blob_hashes = self._get_blob_hashes_batch(file_paths, repo_root)
             uncommitted_files = self._check_uncommitted_batch(file_paths, repo_root)
 
+            # Points built from another checkout (index delta sync) carry the blob
+            # they were chunked from - if ours differs, store their content inline
+            for point in points:
+                expected_blob = point.get("git_blob_hash")
+                path = point.get("payload", {}).get("path", "")
+                if expected_blob and blob_hashes.get(path) not in (None, expected_blob):
+                    del blob_hashes[path]
+
         # Ensure ID index exists for this collection (also loads file path cache)
         with self._id_index_lock:
             if collection_name not in self._id_index:
@@ -833,6 +1186,11 @@ class FilesystemVectorStore:
                 blob_hashes=blob_hashes,
                 uncommitted_files=uncommitted_files,
             )
+            if payload_dictionary is not None:
+                payload_dictionary.encode(vector_data)
+            compress_vector_data(vector_data, compression_settings)
+            if cipher is not None:
+                cipher.encrypt_vector_data(vector_data)
 
             # Atomic write to filesystem
             self._atomic_write_json(vector_file, vector_data)
@@ -876,6 +1234,8 @@ class FilesystemVectorStore:
                     progress_callback=progress_callback,
                 )
 
+        self._update_membership_snapshot(collection_name, points)
+
         # Return success - index rebuilding now happens in end_indexing() (O(n) not O(n²))
         # This fixes the performance disaster where we rebuilt indexes after EVERY file.
         # Now indexes are rebuilt ONCE at the end of the indexing session.
@@ -934,6 +1294,15 @@ class FilesystemVectorStore:
             HNSW-001 & HNSW-002: Tracks deletions for incremental HNSW updates.
         """
         deleted = 0
+        deleted_paths: Set[str] = set()
+
+        # Temporal points also have a row in the temporal metadata store
+        metadata_store = (
+            self._get_temporal_metadata_store()
+            if point_ids
+            and TemporalMetadataStore.is_temporal_collection(collection_name)
+            else None
+        )
 
         with self._id_index_lock:
             if collection_name not in self._id_index:
@@ -951,7 +1320,9 @@ class FilesystemVectorStore:
                         try:
                             with open(vector_file) as f:
                                 vector_data = json.load(f)
-                                file_path = vector_data.get("payload", {}).get("path")
+                                file_path = self._get_stored_path(
+                                    collection_name, vector_data
+                                )
                         except (json.JSONDecodeError, KeyError, OSError):
                             # If we can't read the file, continue with deletion
                             pass
@@ -959,6 +1330,10 @@ class FilesystemVectorStore:
                         # Delete file
                         vector_file.unlink()
                         deleted += 1
+                        if metadata_store is not None:
+                            metadata_store.delete_metadata(
+                                vector_file.stem[len("vector_") :]
+                            )
 
                     # Remove from index
                     del index[point_id]
@@ -971,6 +1346,7 @@ class FilesystemVectorStore:
 
                     # Story #540: Remove from path index
                     if file_path:
+                        deleted_paths.add(file_path)
                         with self._path_index_lock:
                             if collection_name in self._path_indexes:
                                 self._path_indexes[collection_name].remove_point(
@@ -981,6 +1357,9 @@ class FilesystemVectorStore:
             if deleted > 0 and collection_name in self._file_path_cache:
                 del self._file_path_cache[collection_name]
 
+        if deleted_paths:
+            self._remove_from_membership_snapshot(collection_name, deleted_paths)
+
         return {"status": "ok", "deleted": deleted}
 
     def _prepare_vector_data(
@@ -1213,7 +1592,7 @@ class FilesystemVectorStore:
                     data = json.load(f)
 
                 # Extract file path from payload only
-                file_path = data.get("payload", {}).get("path", "")
+                file_path = self._get_stored_path(collection_name, data) or ""
                 if file_path:
                     file_paths.add(file_path)
 
@@ -1263,6 +1642,48 @@ class FilesystemVectorStore:
 
         path_index.save(path_index_file)
 
+    def rebuild_indexes(
+        self, collection_name: str, progress_callback: Optional[Any] = None
+    ) -> Dict[str, Any]:
+        """Rebuild the ID, path and HNSW indexes from the vector files on disk.
+
+        Repairs derived indexes that drifted from the vector files (e.g. after
+        an interrupted indexing run). Unreadable vector files are left out.
+
+        Args:
+            collection_name: Name of the collection
+            progress_callback: Optional callback for HNSW rebuild progress
+
+        Returns:
+            Status dictionary from end_indexing()
+
+        Raises:
+            ValueError: If collection doesn't exist
+        """
+        if not self.collection_exists(collection_name):
+            raise ValueError(f"Collection '{collection_name}' does not exist")
+
+        id_index: Dict[str, Path] = {}
+        path_index = PathIndex()
+        for vector_file, data, _ in self.iter_vector_records(collection_name):
+            if data is None:
+                continue
+            point_id = str(data["id"])
+            id_index[point_id] = vector_file
+            file_path = data.get("payload", {}).get("path")
+            if file_path:
+                path_index.add_point(file_path, point_id)
+
+        with self._id_index_lock:
+            self._id_index[collection_name] = id_index
+            self._file_path_cache.pop(collection_name, None)
+        with self._path_index_lock:
+            self._path_indexes[collection_name] = path_index
+
+        # No session changes: end_indexing() rebuilds HNSW from all vectors
+        self._indexing_session_changes.pop(collection_name, None)
+        return self.end_indexing(collection_name, progress_callback=progress_callback)
+
     # Story #726: _ensure_gitignore() method removed.
     # CIDX must NEVER modify files outside .code-indexer/ directory.
     # The .gitignore modification was causing git pull failures in golden repositories.
@@ -1504,8 +1925,7 @@ class FilesystemVectorStore:
                 return None
 
             try:
-                with open(vector_file) as f:
-                    data = json.load(f)
+                data = self._read_vector_file(vector_file, collection_name)
 
                 # Payload should always exist in new format, but provide empty fallback
                 payload = data.get("payload", {})
@@ -1518,7 +1938,7 @@ class FilesystemVectorStore:
                 if "chunk_text" in data:
                     result["chunk_text"] = data["chunk_text"]
                 return result
-            except (json.JSONDecodeError, KeyError):
+            except (json.JSONDecodeError, KeyError, ValueError):
                 return None
 
     def _parse_filter(self, filter_conditions: Optional[Dict[str, Any]]) -> Any:
@@ -1634,6 +2054,9 @@ class FilesystemVectorStore:
                     # Support "any" (set membership - NEW: temporal filter support)
                     if "any" in match_spec:
                         allowed_values = match_spec["any"]
+                        if isinstance(current, list):
+                            # List fields (e.g. owners) match if any element does
+                            return any(value in allowed_values for value in current)
                         return current in allowed_values
 
                     # Support "contains" (substring match - NEW: temporal filter support)
@@ -1647,6 +2070,8 @@ class FilesystemVectorStore:
                     if "value" in match_spec:
                         # Exact match
                         expected_value = match_spec["value"]
+                        if isinstance(current, list):
+                            return expected_value in current
                         return bool(current == expected_value)
                     elif "text" in match_spec:
                         # Pattern match (glob-style wildcards)
@@ -1765,8 +2190,7 @@ class FilesystemVectorStore:
         points: List[Dict[str, Any]] = []
         for vector_file in page_files:
             try:
-                with open(str(vector_file), "r") as file_handle:
-                    data: Dict[str, Any] = json.load(file_handle)
+                data = self._read_vector_file(vector_file, collection_name)
 
                 point: Dict[str, Any] = {"id": data["id"]}
 
@@ -1786,7 +2210,7 @@ class FilesystemVectorStore:
 
                 points.append(point)
 
-            except (json.JSONDecodeError, KeyError):
+            except (json.JSONDecodeError, KeyError, ValueError):
                 continue
 
         # Calculate next offset
@@ -1808,6 +2232,7 @@ class FilesystemVectorStore:
         lazy_load: bool = False,
         prefetch_limit: Optional[int] = None,
         ef: int = 50,
+        vector_mode: str = BODY_MODE,
     ) -> Union[List[Dict[str, Any]], Tuple[List[Dict[str, Any]], Dict[str, Any]]]:
         """Search for similar vectors using parallel execution of index loading and embedding generation.
 
@@ -1829,6 +2254,8 @@ class FilesystemVectorStore:
             return_timing: If True, return tuple of (results, timing_dict)
             lazy_load: If True, load payloads on-demand with early exit (optimization for restrictive filters)
             prefetch_limit: How many candidate IDs to fetch from HNSW (default: limit * 2 or limit * 15 for lazy_load)
+            vector_mode: Chunk vectors to match, "body" (default), "signature"
+                or "both" (see vector_kinds)
 
         Returns:
             List of results with id, score, payload (including content), and staleness
@@ -1848,6 +2275,8 @@ class FilesystemVectorStore:
         if not self.collection_exists(collection_name):
             return ([], timing) if return_timing else []
 
+        self._ensure_collection_format(collection_name)
+
         # Load metadata to get vector size
         meta_file = collection_path / "collection_meta.json"
         with open(meta_file) as f:
@@ -2017,8 +2446,9 @@ class FilesystemVectorStore:
                 continue
 
             try:
-                with open(vector_file) as f:
-                    data = json.load(f)
+                data = self._read_vector_file(vector_file, collection_name)
+                if not matches_vector_mode(data.get("payload", {}), vector_mode):
+                    continue
 
                 # Apply filter conditions
                 if filter_conditions:
@@ -2061,6 +2491,8 @@ class FilesystemVectorStore:
 
         # Sort by score and limit
         results.sort(key=lambda x: x["score"], reverse=True)
+        if vector_mode != BODY_MODE:
+            results = merge_by_chunk(results)
         limited_results = results[:limit]
 
         # Enhance with content and staleness
@@ -2316,6 +2748,9 @@ class FilesystemVectorStore:
 
         if not self.collection_exists(collection_name):
             self.create_collection(collection_name, vector_size)
+            key_source = key_source_from_config(config)
+            if key_source is not None:
+                self.migrate_encryption(collection_name, key_source)
 
         return collection_name
 
@@ -2353,11 +2788,20 @@ class FilesystemVectorStore:
                 if matrix_file.exists():
                     matrix_data = matrix_file.read_bytes()
                 if metadata_file.exists():
-                    metadata_data = metadata_file.read_bytes()
+                    metadata_data = self._metadata_for_cleared_collection(
+                        metadata_file
+                    )
 
             # Remove entire collection directory
             shutil.rmtree(collection_path)
 
+            # Payload dictionary was removed with the directory
+            with self._metadata_lock:
+                self._payload_dictionaries.pop(collection_name, None)
+                self._format_checked.discard(collection_name)
+            with self._membership_snapshot_lock:
+                self._membership_snapshots.pop(collection_name, None)
+
             # Clear ID index for this collection
             with self._id_index_lock:
                 if collection_name in self._id_index:
@@ -2376,6 +2820,29 @@ class FilesystemVectorStore:
         except Exception:
             return False
 
+    def _metadata_for_cleared_collection(self, metadata_file: Path) -> Optional[bytes]:
+        """Metadata to keep when a collection is cleared for re-indexing.
+
+        Re-indexed vectors are written in the current format, so the kept
+        metadata is stamped with it. Metadata that is unreadable or lacks the
+        vector size is dropped, so the collection is recreated from scratch.
+
+        Args:
+            metadata_file: collection_meta.json of the collection
+
+        Returns:
+            Metadata file content, or None to recreate the metadata
+        """
+        try:
+            with open(metadata_file) as f:
+                metadata = json.load(f)
+        except (OSError, json.JSONDecodeError):
+            return None
+        if "vector_size" not in metadata:
+            return None
+        metadata[FORMAT_VERSION_KEY] = INDEX_FORMAT_VERSION
+        return json.dumps(metadata, indent=2).encode("utf-8")
+
     def delete_collection(self, collection_name: str) -> bool:
         """Delete entire collection including structure and metadata.
 
@@ -2396,6 +2863,12 @@ class FilesystemVectorStore:
 
             shutil.rmtree(collection_path)
 
+            with self._metadata_lock:
+                self._payload_dictionaries.pop(collection_name, None)
+                self._format_checked.discard(collection_name)
+            with self._membership_snapshot_lock:
+                self._membership_snapshots.pop(collection_name, None)
+
             # Clear ID index and file path cache for this collection
             with self._id_index_lock:
                 if collection_name in self._id_index:
@@ -2562,6 +3035,248 @@ class FilesystemVectorStore:
         """
         pass
 
+    def get_membership_snapshot_path(self, collection_name: str) -> Path:
+        """Location of the collection's persisted membership snapshot.
+
+        See membership_snapshot.py; writes update the snapshot in memory and
+        end_indexing() saves it.
+        """
+        return self.base_path / collection_name / MEMBERSHIP_SNAPSHOT_FILENAME
+
+    def _pending_membership_snapshot(
+        self, collection_name: str
+    ) -> Optional[IndexMembershipSnapshot]:
+        """The snapshot this run's writes update (caller holds the lock).
+
+        The first write loads the persisted snapshot and removes the file, so
+        an interrupted run leaves nothing stale behind. Collections without a
+        snapshot are left alone; the next reconcile builds one.
+        """
+        snapshot = self._membership_snapshots.get(collection_name)
+        if snapshot is not None:
+            return snapshot
+
+        snapshot_path = self.get_membership_snapshot_path(collection_name)
+        snapshot = IndexMembershipSnapshot.load(snapshot_path)
+        try:
+            snapshot_path.unlink(missing_ok=True)
+        except OSError as e:
+            self.logger.warning(
+                f"Failed to remove membership snapshot for {collection_name}: {e}"
+            )
+            return None
+        if snapshot is not None:
+            self._membership_snapshots[collection_name] = snapshot
+        return snapshot
+
+    def _membership_key(self, path: str) -> str:
+        """Snapshot key of a stored path (relative to the project root)."""
+        if Path(path).is_absolute():
+            try:
+                return str(Path(path).relative_to(self.project_root))
+            except ValueError:
+                return path
+        return path
+
+    def _update_membership_snapshot(
+        self, collection_name: str, points: List[Dict[str, Any]]
+    ) -> None:
+        """Replace the snapshot entries of the files an upsert wrote."""
+        content_points = [
+            point
+            for point in points
+            if point.get("payload", {}).get("type") == "content"
+        ]
+        if not content_points:
+            return
+        with self._membership_snapshot_lock:
+            snapshot = self._pending_membership_snapshot(collection_name)
+            if snapshot is not None:
+                snapshot.update_from_points(
+                    content_points,
+                    timestamp_fn=payload_timestamp,
+                    normalize_path=self._membership_key,
+                )
+
+    def _remove_from_membership_snapshot(
+        self, collection_name: str, file_paths: Set[str]
+    ) -> None:
+        """Drop files a delete left without chunks from the snapshot.
+
+        Without a loaded path index we cannot tell whether chunks remain, so
+        the entry is dropped: reconcile then re-indexes the file, whereas a
+        kept entry could hide a file that is no longer indexed.
+        """
+        with self._path_index_lock:
+            path_index = self._path_indexes.get(collection_name)
+            remaining = {
+                path
+                for path in file_paths
+                if path_index is not None and path_index.get_point_ids(path)
+            }
+        with self._membership_snapshot_lock:
+            snapshot = self._pending_membership_snapshot(collection_name)
+            if snapshot is None:
+                return
+            for path in file_paths - remaining:
+                snapshot.remove(self._membership_key(path))
+
+    def save_membership_snapshot(self, collection_name: str) -> None:
+        """Persist the snapshot this run's writes updated, if any."""
+        with self._membership_snapshot_lock:
+            snapshot = self._membership_snapshots.pop(collection_name, None)
+        if snapshot is None:
+            return
+        try:
+            snapshot.save(self.get_membership_snapshot_path(collection_name))
+        except OSError as e:
+            self.logger.warning(
+                f"Failed to save membership snapshot for {collection_name}: {e}"
+            )
+
+    def get_points_for_paths(
+        self, collection_name: str, file_paths: List[str]
+    ) -> List[Dict[str, Any]]:
+        """Get all points indexed for the given files, with chunk content resolved.
+
+        Points are returned in upsert_points() format with chunk_text always set,
+        so they can be replayed into another store (index delta sync). Points
+        stored as git blob pointers also carry their git_blob_hash.
+
+        Args:
+            collection_name: Name of the collection
+            file_paths: File paths relative to the project root
+
+        Returns:
+            List of point dictionaries with id, vector, payload and chunk_text
+        """
+        points = []
+        for data in self._read_points_for_paths(collection_name, file_paths):
+            content, _ = self._get_chunk_content_with_staleness(data)
+            point: Dict[str, Any] = {
+                "id": data["id"],
+                "vector": data["vector"],
+                "payload": data.get("payload", {}),
+                "chunk_text": content,
+            }
+            if "git_blob_hash" in data:
+                point["git_blob_hash"] = data["git_blob_hash"]
+            points.append(point)
+
+        return points
+
+    def get_vectors_for_paths(
+        self, collection_name: str, file_paths: List[str]
+    ) -> List[Dict[str, Any]]:
+        """Get the vectors and payloads of all points indexed for the given files.
+
+        Unlike get_points_for_paths(), chunk content is not resolved, so no
+        file or git blob is read: watch mode looks up the stored vectors of a
+        saved file by their embedding_hash payload field before re-embedding.
+
+        Args:
+            collection_name: Name of the collection
+            file_paths: File paths relative to the project root
+
+        Returns:
+            List of point dictionaries with id, vector and payload
+        """
+        return [
+            {
+                "id": data["id"],
+                "vector": data["vector"],
+                "payload": data.get("payload", {}),
+            }
+            for data in self._read_points_for_paths(collection_name, file_paths)
+        ]
+
+    def _read_points_for_paths(
+        self, collection_name: str, file_paths: List[str]
+    ) -> Iterator[Dict[str, Any]]:
+        """Stored data of the points of the given files, in path order."""
+        with self._path_index_lock:
+            if collection_name not in self._path_indexes:
+                self._path_indexes[collection_name] = self._load_path_index(
+                    collection_name
+                )
+            path_index = self._path_indexes[collection_name]
+            point_ids = [
+                point_id
+                for file_path in file_paths
+                for point_id in sorted(path_index.get_point_ids(file_path))
+            ]
+
+        with self._id_index_lock:
+            if collection_name not in self._id_index:
+                self._id_index[collection_name] = self._load_id_index(collection_name)
+            id_index = dict(self._id_index[collection_name])
+
+        for point_id in point_ids:
+            vector_file = id_index.get(point_id)
+            if vector_file is None or not vector_file.exists():
+                continue
+            try:
+                data = self._read_vector_file(vector_file, collection_name)
+            except (json.JSONDecodeError, ValueError, OSError):
+                continue
+            yield data
+
+    def delete_points_for_paths(
+        self, collection_name: str, file_paths: List[str]
+    ) -> int:
+        """Delete all points indexed for the given files.
+
+        Args:
+            collection_name: Name of the collection
+            file_paths: File paths relative to the project root
+
+        Returns:
+            Number of points deleted
+        """
+        with self._path_index_lock:
+            if collection_name not in self._path_indexes:
+                self._path_indexes[collection_name] = self._load_path_index(
+                    collection_name
+                )
+            path_index = self._path_indexes[collection_name]
+            point_ids = [
+                point_id
+                for file_path in file_paths
+                for point_id in path_index.get_point_ids(file_path)
+            ]
+
+        if not point_ids:
+            return 0
+        result = self.delete_points(collection_name, point_ids)
+        return int(result["deleted"])
+
+    def iter_vector_records(
+        self, collection_name: str
+    ) -> Iterator[Tuple[Path, Optional[Dict[str, Any]], Optional[str]]]:
+        """Iterate over every vector file of a collection, including broken ones.
+
+        Unlike scroll_points(), unreadable files are not skipped silently, so
+        integrity checks can report them.
+
+        Args:
+            collection_name: Name of the collection
+
+        Yields:
+            (vector_file, decoded data, None) for readable files and
+            (vector_file, None, error message) for unreadable ones
+        """
+        collection_path = self.base_path / collection_name
+        if not self.collection_exists(collection_name):
+            return
+
+        for vector_file in sorted(collection_path.rglob("vector_*.json")):
+            try:
+                data = self._read_vector_file(vector_file, collection_name)
+            except (json.JSONDecodeError, ValueError, OSError) as e:
+                yield vector_file, None, str(e)
+                continue
+            yield vector_file, data, None
+
     def get_all_indexed_files(self, collection_name: str) -> List[str]:
         """Get all unique file paths from indexed vectors.
 
@@ -2660,7 +3375,7 @@ class FilesystemVectorStore:
         Note:
             Thread-safe: Uses file locking to prevent race conditions with daemon indexing
         """
-        import fcntl
+        from ..utils.file_lock import lock_file, unlock_file
         import json
 
         # Calculate unique file count from vectors
@@ -2680,7 +3395,7 @@ class FilesystemVectorStore:
                     vector_data = json.load(f)
 
                 # Extract source file path from payload
-                file_path = vector_data.get("payload", {}).get("path")
+                file_path = self._get_stored_path(collection_name, vector_data)
                 if file_path:
                     unique_files.add(file_path)
 
@@ -2699,7 +3414,7 @@ class FilesystemVectorStore:
 
         with open(lock_file, "r") as lock_f:
             # Acquire exclusive lock (blocks if daemon is writing)
-            fcntl.flock(lock_f.fileno(), fcntl.LOCK_EX)
+            lock_file(lock_f)
 
             try:
                 # Read current metadata
@@ -2719,7 +3434,7 @@ class FilesystemVectorStore:
 
             finally:
                 # Release lock
-                fcntl.flock(lock_f.fileno(), fcntl.LOCK_UN)
+                unlock_file(lock_f)
 
         return unique_file_count
 
@@ -2752,7 +3467,7 @@ class FilesystemVectorStore:
                     data = json.load(f)
 
                 # Extract file path from payload only
-                file_path = data.get("payload", {}).get("path", "")
+                file_path = self._get_stored_path(collection_name, data) or ""
 
                 if not file_path:
                     continue
@@ -2811,12 +3526,11 @@ class FilesystemVectorStore:
                     data = json.load(f)
 
                 # Get file_path from payload for consistency
-                payload = data.get("payload", {})
                 sampled_vectors.append(
                     {
                         "id": data["id"],
                         "vector": data["vector"],
-                        "file_path": payload.get("path", ""),
+                        "file_path": self._get_stored_path(collection_name, data) or "",
                         "metadata": data.get("metadata", {}),
                     }
                 )
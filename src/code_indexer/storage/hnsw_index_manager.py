@@ -374,7 +374,7 @@ class HNSWIndexManager:
         Note:
             This method is called by watch mode to defer HNSW rebuild until query time.
         """
-        import fcntl
+        from ..utils.file_lock import lock_file, unlock_file
 
         meta_file = collection_path / "collection_meta.json"
         lock_file = collection_path / ".metadata.lock"
@@ -382,7 +382,7 @@ class HNSWIndexManager:
 
         with open(lock_file, "r") as lock_f:
             # Acquire exclusive lock (blocks if query is rebuilding)
-            fcntl.flock(lock_f.fileno(), fcntl.LOCK_EX)
+            lock_file(lock_f)
             try:
                 # Load existing metadata
                 if not meta_file.exists():
@@ -405,7 +405,7 @@ class HNSWIndexManager:
                     json.dump(metadata, f, indent=2)
             finally:
                 # Release lock
-                fcntl.flock(lock_f.fileno(), fcntl.LOCK_UN)
+                unlock_file(lock_f)
 
     def is_stale(self, collection_path: Path) -> bool:
         """Check if HNSW index needs rebuilding.
@@ -480,7 +480,7 @@ class HNSWIndexManager:
             ids: List of vector IDs
             index_file_size: Size of index file in bytes
         """
-        import fcntl
+        from ..utils.file_lock import lock_file, unlock_file
         import uuid
 
         meta_file = collection_path / "collection_meta.json"
@@ -491,7 +491,7 @@ class HNSWIndexManager:
 
         with open(lock_file, "r") as lock_f:
             # Acquire exclusive lock
-            fcntl.flock(lock_f.fileno(), fcntl.LOCK_EX)
+            lock_file(lock_f)
             try:
                 # Load existing metadata or create new
                 if meta_file.exists():
@@ -527,7 +527,7 @@ class HNSWIndexManager:
                     json.dump(metadata, f, indent=2)
             finally:
                 # Release lock
-                fcntl.flock(lock_f.fileno(), fcntl.LOCK_UN)
+                unlock_file(lock_f)
 
     def _load_id_mapping(self, collection_path: Path) -> Dict[int, str]:
         """Load ID mapping from metadata.
@@ -703,7 +703,7 @@ class HNSWIndexManager:
             Updates both index file and metadata with new mappings.
             Preserves existing HNSW parameters (M, ef_construction).
         """
-        import fcntl
+        from ..utils.file_lock import lock_file, unlock_file
         import logging
 
         logger = logging.getLogger(__name__)
@@ -727,7 +727,7 @@ class HNSWIndexManager:
 
         with open(lock_file, "r") as lock_f:
             # Acquire exclusive lock
-            fcntl.flock(lock_f.fileno(), fcntl.LOCK_EX)
+            lock_file(lock_f)
             try:
                 # Load existing metadata
                 if meta_file.exists():
@@ -773,4 +773,4 @@ class HNSWIndexManager:
                     json.dump(metadata, f, indent=2)
             finally:
                 # Release lock
-                fcntl.flock(lock_f.fileno(), fcntl.LOCK_UN)
+                unlock_file(lock_f)
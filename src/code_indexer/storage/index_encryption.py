@@ -0,0 +1,449 @@
+"""AES-256-GCM encryption at rest of stored chunk text and payloads.
+
+Vector files keep their JSON layout; encrypted values are moved to a separate
+key so readers can tell the formats apart without consulting metadata:
+
+    "chunk_text": "...", "payload": {"path": "a.py", "language": "py", ...}
+        ->  "encrypted": "<base64 nonce + ciphertext>", "payload": {"path": ...}
+
+Chunk text (plain or compressed) and every payload field except "path" are
+encrypted. Vectors, point IDs, git blob hashes and paths stay readable: HNSW
+builds, file counts and path bookkeeping run without the key. The point ID is
+bound to the ciphertext as associated data, so encrypted values cannot be
+moved between vector files.
+
+Whether a collection is encrypted is recorded in collection_meta.json under
+"encryption", together with where its key comes from:
+
+- keyring: a random 256-bit key stored in the OS keyring (service
+  "cidx-index", one entry per key ID). Requires the optional keyring package.
+- passphrase: a key derived with scrypt from a passphrase and a per-collection
+  salt. The passphrase is read from CIDX_INDEX_PASSPHRASE or prompted for.
+
+A key check value detects a wrong key or passphrase before any file is read.
+Collections without an "encryption" entry store plain text; reads handle both
+formats, so a collection stays searchable while `cidx encrypt-index` migrates.
+"""
+
+import base64
+import getpass
+import json
+import logging
+import os
+import sys
+import threading
+import uuid
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Callable, Dict, Optional
+
+from cryptography.exceptions import InvalidTag
+from cryptography.hazmat.primitives.ciphers.aead import AESGCM
+from cryptography.hazmat.primitives.kdf.scrypt import Scrypt
+
+logger = logging.getLogger(__name__)
+
+ENCRYPTION_ALGORITHM = "aes-256-gcm"
+ENCRYPTION_METADATA_KEY = "encryption"
+ENCRYPTED_DATA_KEY = "encrypted"
+KEY_SOURCES = ("keyring", "passphrase")
+KEYRING_SERVICE = "cidx-index"
+PASSPHRASE_ENV_VAR = "CIDX_INDEX_PASSPHRASE"
+
+# Vector data keys moved into the encrypted blob (compressed forms included)
+ENCRYPTED_DATA_FIELDS = ("chunk_text", "chunk_text_zstd", "payload_zstd")
+# Read without the key by path bookkeeping (file counts, path index)
+UNENCRYPTED_PAYLOAD_FIELDS = frozenset({"path"})
+
+KEY_BYTES = 32
+NONCE_BYTES = 12
+SALT_BYTES = 16
+SCRYPT_N = 2**15
+SCRYPT_R = 8
+SCRYPT_P = 1
+_KEY_CHECK_PLAINTEXT = b"cidx-index-key-check"
+_KEY_CHECK_AAD = b"key-check"
+
+# Keys resolved in this process, by key ID (avoids repeated prompts and KDF runs)
+_key_cache: Dict[str, bytes] = {}
+_passphrase: Optional[str] = None
+_key_lock = threading.Lock()
+
+
+class IndexKeyError(RuntimeError):
+    """Raised when the key of an encrypted collection is unavailable or wrong.
+
+    Deliberately not a ValueError: readers skip corrupted vector files, but a
+    missing key must fail the operation instead of returning empty results.
+    """
+
+    pass
+
+
+@dataclass
+class EncryptionSettings:
+    """Per-collection encryption settings stored in collection_meta.json."""
+
+    key_source: str
+    key_id: str
+    key_check: str
+    salt: Optional[str] = None  # base64, passphrase keys only
+
+    @classmethod
+    def from_metadata(cls, metadata: Dict[str, Any]) -> Optional["EncryptionSettings"]:
+        """Settings for a collection, or None if it stores plain text."""
+        encryption = metadata.get(ENCRYPTION_METADATA_KEY)
+        if not encryption:
+            return None
+        if encryption.get("algorithm") != ENCRYPTION_ALGORITHM:
+            raise IndexKeyError(
+                f"Unsupported index encryption: {encryption.get('algorithm')}"
+            )
+        return cls(
+            key_source=encryption["key_source"],
+            key_id=encryption["key_id"],
+            key_check=encryption["key_check"],
+            salt=encryption.get("salt"),
+        )
+
+    def to_metadata(self) -> Dict[str, Any]:
+        metadata = {
+            "algorithm": ENCRYPTION_ALGORITHM,
+            "key_source": self.key_source,
+            "key_id": self.key_id,
+            "key_check": self.key_check,
+        }
+        if self.salt is not None:
+            metadata["salt"] = self.salt
+        return metadata
+
+
+@dataclass
+class EncryptionMigrationResult:
+    """Summary of a collection encryption migration."""
+
+    files_scanned: int = 0
+    files_rewritten: int = 0
+    files_failed: int = 0
+
+
+class IndexCipher:
+    """Encrypts and decrypts the sensitive fields of vector data."""
+
+    def __init__(self, key: bytes):
+        if len(key) != KEY_BYTES:
+            raise ValueError(f"Index encryption key must be {KEY_BYTES} bytes")
+        self._aead = AESGCM(key)
+
+    def encrypt(self, plaintext: bytes, associated_data: bytes) -> str:
+        """Encrypt bytes to a base64 string (random nonce + ciphertext)."""
+        nonce = os.urandom(NONCE_BYTES)
+        ciphertext = self._aead.encrypt(nonce, plaintext, associated_data)
+        return base64.b64encode(nonce + ciphertext).decode("ascii")
+
+    def decrypt(self, encoded: str, associated_data: bytes) -> bytes:
+        """Inverse of encrypt().
+
+        Raises:
+            ValueError: If the value was tampered with or uses another key
+        """
+        raw = base64.b64decode(encoded)
+        try:
+            return bytes(
+                self._aead.decrypt(
+                    raw[:NONCE_BYTES], raw[NONCE_BYTES:], associated_data
+                )
+            )
+        except InvalidTag:
+            raise ValueError("authentication failed (wrong key or tampered data)")
+
+    def encrypt_vector_data(self, data: Dict[str, Any]) -> Dict[str, Any]:
+        """Encrypt chunk text and payload fields of vector data in place."""
+        secret: Dict[str, Any] = {
+            name: data.pop(name) for name in ENCRYPTED_DATA_FIELDS if name in data
+        }
+        payload = data.get("payload")
+        if payload:
+            secret_payload = {
+                name: payload.pop(name)
+                for name in list(payload)
+                if name not in UNENCRYPTED_PAYLOAD_FIELDS
+            }
+            if secret_payload:
+                secret["payload"] = secret_payload
+        if secret:
+            data[ENCRYPTED_DATA_KEY] = self.encrypt(
+                json.dumps(secret).encode("utf-8"), _associated_data(data)
+            )
+        return data
+
+    def decrypt_vector_data(self, data: Dict[str, Any]) -> Dict[str, Any]:
+        """Restore encrypted fields of vector data in place; plain data is untouched."""
+        encoded = data.pop(ENCRYPTED_DATA_KEY, None)
+        if encoded is None:
+            return data
+        secret = json.loads(self.decrypt(encoded, _associated_data(data)))
+        secret_payload = secret.pop("payload", None)
+        if secret_payload:
+            data.setdefault("payload", {}).update(secret_payload)
+        data.update(secret)
+        return data
+
+
+def _associated_data(data: Dict[str, Any]) -> bytes:
+    return str(data.get("id", "")).encode("utf-8")
+
+
+def is_encrypted(data: Dict[str, Any]) -> bool:
+    """Whether raw vector data holds encrypted fields."""
+    return ENCRYPTED_DATA_KEY in data
+
+
+def key_source_from_config(config: Any) -> Optional[str]:
+    """Key source for new collections per config.encryption, None if disabled."""
+    encryption_config = getattr(config, "encryption", None)
+    if getattr(encryption_config, "enabled", False) is not True:
+        return None
+    key_source = getattr(encryption_config, "key_source", "keyring")
+    return key_source if isinstance(key_source, str) else "keyring"
+
+
+def create_encryption_settings(key_source: str) -> EncryptionSettings:
+    """
+    Create a new collection key and the settings that locate it.
+
+    Keyring keys are random and stored in the OS keyring; passphrase keys are
+    derived from the (prompted) passphrase with a fresh salt.
+
+    Args:
+        key_source: "keyring" or "passphrase"
+
+    Returns:
+        EncryptionSettings to record in collection_meta.json
+
+    Raises:
+        IndexKeyError: If the keyring or passphrase is unavailable
+    """
+    if key_source not in KEY_SOURCES:
+        raise ValueError(f"Unknown encryption key source: {key_source}")
+
+    key_id = str(uuid.uuid4())
+    salt: Optional[str] = None
+    if key_source == "keyring":
+        key = os.urandom(KEY_BYTES)
+        _keyring_module().set_password(
+            KEYRING_SERVICE, key_id, base64.b64encode(key).decode("ascii")
+        )
+    else:
+        salt = base64.b64encode(os.urandom(SALT_BYTES)).decode("ascii")
+        key = _derive_key(_get_passphrase(), salt)
+
+    key_check = IndexCipher(key).encrypt(_KEY_CHECK_PLAINTEXT, _KEY_CHECK_AAD)
+    with _key_lock:
+        _key_cache[key_id] = key
+    return EncryptionSettings(
+        key_source=key_source, key_id=key_id, key_check=key_check, salt=salt
+    )
+
+
+def load_cipher(settings: EncryptionSettings) -> IndexCipher:
+    """
+    Resolve the key of an encrypted collection (cached per process).
+
+    Raises:
+        IndexKeyError: If the key is unavailable or fails the key check
+    """
+    with _key_lock:
+        key = _key_cache.get(settings.key_id)
+    if key is None:
+        key = _resolve_key(settings)
+        cipher = IndexCipher(key)
+        try:
+            plaintext = cipher.decrypt(settings.key_check, _KEY_CHECK_AAD)
+        except ValueError:
+            plaintext = b""
+        if plaintext != _KEY_CHECK_PLAINTEXT:
+            if settings.key_source == "passphrase":
+                _forget_passphrase()
+            raise IndexKeyError(
+                f"Wrong {settings.key_source} key for encrypted index "
+                f"(key ID {settings.key_id})"
+            )
+        with _key_lock:
+            _key_cache[settings.key_id] = key
+    return IndexCipher(key)
+
+
+def delete_key(settings: EncryptionSettings) -> None:
+    """Remove a keyring key once no collection uses it (best effort)."""
+    with _key_lock:
+        _key_cache.pop(settings.key_id, None)
+    if settings.key_source != "keyring":
+        return
+    try:
+        _keyring_module().delete_password(KEYRING_SERVICE, settings.key_id)
+    except Exception as e:
+        logger.warning(f"Could not delete index key {settings.key_id}: {e}")
+
+
+def _resolve_key(settings: EncryptionSettings) -> bytes:
+    if settings.key_source == "keyring":
+        stored = _keyring_module().get_password(KEYRING_SERVICE, settings.key_id)
+        if stored is None:
+            raise IndexKeyError(
+                f"Index key {settings.key_id} not found in the OS keyring "
+                f"(service '{KEYRING_SERVICE}')"
+            )
+        return base64.b64decode(stored)
+    if settings.key_source == "passphrase":
+        if not settings.salt:
+            raise IndexKeyError("Encrypted index metadata is missing its salt")
+        return _derive_key(_get_passphrase(), settings.salt)
+    raise IndexKeyError(f"Unknown encryption key source: {settings.key_source}")
+
+
+def _derive_key(passphrase: str, salt: str) -> bytes:
+    kdf = Scrypt(
+        salt=base64.b64decode(salt),
+        length=KEY_BYTES,
+        n=SCRYPT_N,
+        r=SCRYPT_R,
+        p=SCRYPT_P,
+    )
+    return bytes(kdf.derive(passphrase.encode("utf-8")))
+
+
+def _get_passphrase() -> str:
+    """Passphrase from CIDX_INDEX_PASSPHRASE, else prompted once per process."""
+    global _passphrase
+    with _key_lock:
+        if _passphrase is not None:
+            return _passphrase
+
+        passphrase = os.environ.get(PASSPHRASE_ENV_VAR)
+        if not passphrase:
+            if not sys.stdin.isatty():
+                raise IndexKeyError(
+                    f"Index is encrypted with a passphrase; set {PASSPHRASE_ENV_VAR} "
+                    "for non-interactive use"
+                )
+            passphrase = getpass.getpass("Index passphrase: ")
+        if not passphrase:
+            raise IndexKeyError("Index passphrase must not be empty")
+        _passphrase = passphrase
+        return passphrase
+
+
+def _forget_passphrase() -> None:
+    global _passphrase
+    with _key_lock:
+        _passphrase = None
+
+
+def _keyring_module() -> Any:
+    try:
+        import keyring
+    except ImportError:
+        raise IndexKeyError(
+            "Keyring-backed index encryption requires the keyring package "
+            "(pip install 'code-indexer[keyring]')"
+        )
+    return keyring
+
+
+def migrate_collection(
+    collection_path: Path,
+    current_cipher: Optional[IndexCipher],
+    target_settings: Optional[EncryptionSettings],
+    target_cipher: Optional[IndexCipher],
+    progress_callback: Optional[Callable[[int, int], None]] = None,
+) -> EncryptionMigrationResult:
+    """
+    Rewrite every vector file of a collection encrypted or in plain text.
+
+    When encrypting, the settings are recorded in collection_meta.json before
+    any file is rewritten; when decrypting, they are removed only afterwards.
+    Either way every encrypted file stays readable if the migration is
+    interrupted, and re-running it finishes the job. Decrypting keeps the
+    settings if any file could not be rewritten.
+
+    Args:
+        collection_path: Collection directory containing collection_meta.json
+        current_cipher: Cipher of the collection's current key, if encrypted
+        target_settings: Settings to encrypt with, or None to decrypt
+        target_cipher: Cipher of target_settings' key, or None to decrypt
+        progress_callback: Optional callback(current, total)
+
+    Returns:
+        EncryptionMigrationResult with file counts
+    """
+    meta_file = collection_path / "collection_meta.json"
+    if not meta_file.exists():
+        raise FileNotFoundError(f"Collection metadata not found at {meta_file}")
+
+    if target_settings is not None:
+        _write_encryption_metadata(meta_file, target_settings)
+
+    result = EncryptionMigrationResult()
+    vector_files = sorted(collection_path.rglob("vector_*.json"))
+    total = len(vector_files)
+
+    for idx, vector_file in enumerate(vector_files, 1):
+        result.files_scanned += 1
+        try:
+            if _migrate_file(vector_file, current_cipher, target_cipher):
+                result.files_rewritten += 1
+        except (OSError, ValueError) as e:
+            # json.JSONDecodeError and UnicodeDecodeError are ValueErrors
+            result.files_failed += 1
+            logger.warning(f"Encryption migration skipped {vector_file}: {e}")
+
+        if progress_callback:
+            progress_callback(idx, total)
+
+    # Keep the key while any file may still be encrypted, so a re-run can finish
+    if target_settings is None and result.files_failed == 0:
+        _write_encryption_metadata(meta_file, None)
+
+    logger.info(
+        f"Encryption migration of {collection_path.name}: "
+        f"{result.files_rewritten}/{result.files_scanned} files rewritten"
+    )
+    return result
+
+
+def _migrate_file(
+    vector_file: Path,
+    current_cipher: Optional[IndexCipher],
+    target_cipher: Optional[IndexCipher],
+) -> bool:
+    """Rewrite one vector file in the target format; True if it changed."""
+    data = json.loads(vector_file.read_bytes())
+    if is_encrypted(data):
+        if current_cipher is None:
+            raise ValueError("encrypted, but the collection has no key")
+        if target_cipher is current_cipher:
+            return False  # Already encrypted with the target key
+        current_cipher.decrypt_vector_data(data)
+    elif target_cipher is None:
+        return False  # Already plain text
+
+    if target_cipher is not None:
+        target_cipher.encrypt_vector_data(data)
+    tmp_file = vector_file.with_suffix(".tmp")
+    tmp_file.write_text(json.dumps(data, indent=2))
+    tmp_file.replace(vector_file)
+    return True
+
+
+def _write_encryption_metadata(
+    meta_file: Path, settings: Optional[EncryptionSettings]
+) -> None:
+    with open(meta_file, "r") as f:
+        metadata = json.load(f)
+    if settings is not None:
+        metadata[ENCRYPTION_METADATA_KEY] = settings.to_metadata()
+    else:
+        metadata.pop(ENCRYPTION_METADATA_KEY, None)
+    with open(meta_file, "w") as f:
+        json.dump(metadata, f, indent=2)
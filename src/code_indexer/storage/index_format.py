@@ -0,0 +1,226 @@
+"""Index format versioning for filesystem vector collections.
+
+Every collection records the on-disk format it was written with as
+"format_version" in collection_meta.json. Before a collection is searched or
+indexed, its version is compared with INDEX_FORMAT_VERSION:
+
+- Same version: used as is.
+- Older version: registered migrations upgrade it in place, one version at a
+  time. A migration that cannot convert the data raises IndexFormatError
+  with the reason and the command that rebuilds the index.
+- Newer version: written by a newer cidx; this version refuses to touch it
+  and asks for an upgrade instead of failing later with unrelated errors.
+
+Collections created before versioning carry no "format_version" and are
+treated as UNVERSIONED_FORMAT.
+
+To change the format: bump INDEX_FORMAT_VERSION and register a migration
+from the previous version with @register_migration.
+"""
+
+import json
+import logging
+import os
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Callable, Dict, Optional
+
+from .temporal_metadata_store import TemporalMetadataStore
+
+logger = logging.getLogger(__name__)
+
+INDEX_FORMAT_VERSION = 2
+FORMAT_VERSION_KEY = "format_version"
+UNVERSIONED_FORMAT = 1
+
+COLLECTION_META_FILENAME = "collection_meta.json"
+
+REINDEX_COMMAND = "cidx index --clear"
+TEMPORAL_REINDEX_COMMAND = "cidx index --index-commits --clear"
+
+# Migration: (collection_path, metadata) -> upgraded metadata
+Migration = Callable[[Path, Dict[str, Any]], Dict[str, Any]]
+
+_MIGRATIONS: Dict[int, Migration] = {}
+
+
+class IndexFormatError(RuntimeError):
+    """Raised when a collection's on-disk format cannot be used by this cidx."""
+
+    def __init__(self, collection: str, found: int, reason: str, remedy: str):
+        self.collection = collection
+        self.found = found
+        self.reason = reason
+        self.remedy = remedy
+        super().__init__(
+            f"Index '{collection}' has format version {found}, this cidx uses "
+            f"version {INDEX_FORMAT_VERSION}: {reason}. {remedy}"
+        )
+
+
+@dataclass
+class FormatStatus:
+    """Format version of a collection relative to this cidx."""
+
+    collection: str
+    version: int
+    current: int = INDEX_FORMAT_VERSION
+
+    @property
+    def needs_migration(self) -> bool:
+        return self.version < self.current
+
+    @property
+    def is_newer(self) -> bool:
+        return self.version > self.current
+
+
+def register_migration(from_version: int) -> Callable[[Migration], Migration]:
+    """
+    Register the migration that upgrades collections from a format version.
+
+    Args:
+        from_version: Version the migration upgrades from (to from_version + 1)
+
+    Returns:
+        Decorator registering the migration function
+    """
+
+    def decorator(migration: Migration) -> Migration:
+        if from_version in _MIGRATIONS:
+            raise ValueError(f"Migration from format {from_version} already registered")
+        _MIGRATIONS[from_version] = migration
+        return migration
+
+    return decorator
+
+
+def stamp_metadata(metadata: Dict[str, Any]) -> Dict[str, Any]:
+    """Record the current format version in new collection metadata."""
+    metadata[FORMAT_VERSION_KEY] = INDEX_FORMAT_VERSION
+    return metadata
+
+
+def read_format_version(metadata: Dict[str, Any]) -> int:
+    """Format version recorded in collection metadata."""
+    return int(metadata.get(FORMAT_VERSION_KEY, UNVERSIONED_FORMAT))
+
+
+def check_collection_format(collection_path: Path) -> Optional[FormatStatus]:
+    """
+    Read the format status of a collection without changing it.
+
+    Args:
+        collection_path: Collection directory
+
+    Returns:
+        FormatStatus, or None when the collection has no metadata
+    """
+    meta_file = collection_path / COLLECTION_META_FILENAME
+    if not meta_file.exists():
+        return None
+    with open(meta_file) as f:
+        metadata = json.load(f)
+    return FormatStatus(collection_path.name, read_format_version(metadata))
+
+
+def ensure_collection_format(collection_path: Path) -> bool:
+    """
+    Make a collection usable by this cidx, migrating it when needed.
+
+    Args:
+        collection_path: Collection directory
+
+    Returns:
+        True if the collection was migrated, False if it was already current
+
+    Raises:
+        IndexFormatError: If the collection is newer than this cidx or cannot
+            be migrated and must be re-indexed
+    """
+    meta_file = collection_path / COLLECTION_META_FILENAME
+    with open(meta_file) as f:
+        metadata = json.load(f)
+
+    collection = collection_path.name
+    found = read_format_version(metadata)
+    if found == INDEX_FORMAT_VERSION:
+        return False
+    if found > INDEX_FORMAT_VERSION:
+        raise IndexFormatError(
+            collection,
+            found,
+            "it was written by a newer version of cidx",
+            "Upgrade cidx to use this index, or re-index with this version: "
+            f"{_reindex_command(collection)}",
+        )
+
+    version = found
+    while version < INDEX_FORMAT_VERSION:
+        migration = _MIGRATIONS.get(version)
+        if migration is None:
+            raise IndexFormatError(
+                collection,
+                found,
+                f"no migration from format version {version} is available",
+                f"Re-index required. Run: {_reindex_command(collection)}",
+            )
+        metadata = migration(collection_path, metadata)
+        version += 1
+        metadata[FORMAT_VERSION_KEY] = version
+        logger.info(
+            f"Migrated index '{collection}' to format version {version}",
+            extra={"operation": "index_format_migration", "collection": collection},
+        )
+
+    _write_metadata(meta_file, metadata)
+    return True
+
+
+def _reindex_command(collection: str) -> str:
+    if TemporalMetadataStore.is_temporal_collection(collection):
+        return TEMPORAL_REINDEX_COMMAND
+    return REINDEX_COMMAND
+
+
+def _write_metadata(meta_file: Path, metadata: Dict[str, Any]) -> None:
+    """Replace collection metadata atomically."""
+    tmp_file = meta_file.with_suffix(".json.tmp")
+    with open(tmp_file, "w") as f:
+        json.dump(metadata, f, indent=2)
+    os.replace(tmp_file, meta_file)
+
+
+@register_migration(UNVERSIONED_FORMAT)
+def _migrate_unversioned(
+    collection_path: Path, metadata: Dict[str, Any]
+) -> Dict[str, Any]:
+    """
+    Adopt collections written before format versioning.
+
+    Compression, payload dictionaries and encryption are detected from their
+    own metadata entries, so usable collections only need the version stamp.
+    Collections missing data that queries depend on must be rebuilt.
+    """
+    collection = collection_path.name
+    if "vector_size" not in metadata:
+        raise IndexFormatError(
+            collection,
+            UNVERSIONED_FORMAT,
+            "collection metadata does not record the vector size",
+            f"Re-index required. Run: {_reindex_command(collection)}",
+        )
+
+    is_temporal = TemporalMetadataStore.is_temporal_collection(collection)
+    if (
+        is_temporal
+        and TemporalMetadataStore.detect_format(collection_path) == "v1"
+        and any(collection_path.rglob("vector_*.json"))
+    ):
+        raise IndexFormatError(
+            collection,
+            UNVERSIONED_FORMAT,
+            "temporal vectors use the legacy filename format (v1)",
+            f"Re-index required. Run: {TEMPORAL_REINDEX_COMMAND}",
+        )
+    return metadata
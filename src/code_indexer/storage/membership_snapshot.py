@@ -0,0 +1,190 @@
+"""Persisted snapshot of which files a collection has indexed.
+
+Reconcile needs to know, for every file on disk, whether it is indexed and
+what content it was indexed from. Answering that with a filtered scroll per
+file means thousands of full collection scans at the start of every
+incremental run. Instead, one scroll builds an in-memory map of
+path -> FileMembership, which answers those checks in O(1).
+
+The map holds an entry for every indexed file rather than a Bloom filter or
+hash set of paths: reconcile reads each file's content ID, latest timestamp
+and hidden branches, not only whether it is indexed, and a false positive
+would report an unindexed file as indexed, so it would never be indexed.
+Entries are per file, not per chunk, and keep only SNAPSHOT_PAYLOAD_FIELDS,
+about 0.7 KB each in memory (70 MB for 100,000 files). Reconcile holds a
+path -> timestamp map of all indexed files anyway, and the scroll the
+snapshot replaces loaded the full payload of every chunk.
+
+The snapshot is saved next to the collection (membership_snapshot.bin) and
+kept current by FilesystemVectorStore: the first upsert/delete of a run loads
+it and removes the file, later writes update it in memory, and end_indexing()
+saves it once. A run that is interrupted leaves no snapshot behind, so a stale
+one is never read; the next run rebuilds it with a single scroll.
+"""
+
+import datetime
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Callable, Dict, Iterable, Iterator, Optional
+
+import msgpack
+
+logger = logging.getLogger(__name__)
+
+MEMBERSHIP_SNAPSHOT_FILENAME = "membership_snapshot.bin"
+MEMBERSHIP_SNAPSHOT_VERSION = 1
+
+# Payload fields reconcile reads from a file's chunks (content ID, visibility)
+SNAPSHOT_PAYLOAD_FIELDS = (
+    "git_commit_hash",
+    "commit_hash",
+    "filesystem_mtime",
+    "file_size",
+    "hidden_branches",
+)
+
+
+def payload_timestamp(payload: Dict[str, Any]) -> float:
+    """Best available file timestamp of a chunk payload (0.0 if none)."""
+    # Priority 1: file_mtime from new architecture (most accurate)
+    if "file_mtime" in payload:
+        return float(payload["file_mtime"])
+    # Priority 2: filesystem_mtime from legacy architecture
+    elif "filesystem_mtime" in payload:
+        return float(payload["filesystem_mtime"])
+    # Priority 3: created_at (indexing time, less accurate for file changes)
+    elif "created_at" in payload:
+        return float(payload["created_at"])
+    # Priority 4: indexed_at as last resort
+    elif "indexed_at" in payload:
+        try:
+            dt = datetime.datetime.strptime(payload["indexed_at"], "%Y-%m-%dT%H:%M:%SZ")
+            return dt.timestamp()
+        except (ValueError, TypeError):
+            return 0.0
+    return 0.0
+
+
+@dataclass
+class FileMembership:
+    """What the index holds for one file."""
+
+    point_id: str  # First chunk in scroll order
+    timestamp: float  # Latest timestamp across the file's chunks
+    payload: Dict[str, Any] = field(default_factory=dict)
+
+
+class IndexMembershipSnapshot:
+    """Path -> FileMembership map for O(1) "is this file indexed" checks."""
+
+    def __init__(self, entries: Optional[Dict[str, FileMembership]] = None) -> None:
+        self._entries: Dict[str, FileMembership] = entries or {}
+
+    @classmethod
+    def from_points(
+        cls,
+        points: Iterable[Dict[str, Any]],
+        timestamp_fn: Callable[[Dict[str, Any]], float],
+        normalize_path: Callable[[str], str] = str,
+    ) -> "IndexMembershipSnapshot":
+        """
+        Build a snapshot from content points (payloads, no vectors needed).
+
+        Args:
+            points: Points as returned by scroll_points()
+            timestamp_fn: Extracts a file timestamp from a chunk payload
+            normalize_path: Maps stored payload paths to snapshot keys (e.g.
+                absolute -> relative to the codebase)
+        """
+        entries: Dict[str, FileMembership] = {}
+        for point in points:
+            payload = point.get("payload", {})
+            if "path" not in payload:
+                continue
+
+            path = normalize_path(payload["path"])
+            timestamp = timestamp_fn(payload)
+            entry = entries.get(path)
+            if entry is None:
+                entries[path] = FileMembership(
+                    point_id=str(point.get("id", "")),
+                    timestamp=timestamp,
+                    payload={
+                        name: payload[name]
+                        for name in SNAPSHOT_PAYLOAD_FIELDS
+                        if name in payload
+                    },
+                )
+            elif timestamp > entry.timestamp:
+                entry.timestamp = timestamp
+
+        return cls(entries)
+
+    def update_from_points(
+        self,
+        points: Iterable[Dict[str, Any]],
+        timestamp_fn: Callable[[Dict[str, Any]], float],
+        normalize_path: Callable[[str], str] = str,
+    ) -> None:
+        """Replace the entries of the files the points belong to.
+
+        upsert_points() writes all chunks of a file together, so the points of
+        one call describe each of their files completely.
+        """
+        update = IndexMembershipSnapshot.from_points(
+            points, timestamp_fn, normalize_path
+        )
+        self._entries.update(update._entries)
+
+    def remove(self, path: str) -> None:
+        """Forget a file that no longer has indexed chunks."""
+        self._entries.pop(path, None)
+
+    def __contains__(self, path: object) -> bool:
+        return path in self._entries
+
+    def __len__(self) -> int:
+        return len(self._entries)
+
+    def __iter__(self) -> Iterator[str]:
+        return iter(self._entries)
+
+    def get(self, path: str) -> Optional[FileMembership]:
+        return self._entries.get(path)
+
+    def save(self, path: Path) -> None:
+        """Save the snapshot atomically with msgpack."""
+        data = {
+            "version": MEMBERSHIP_SNAPSHOT_VERSION,
+            "entries": {
+                file_path: [entry.point_id, entry.timestamp, entry.payload]
+                for file_path, entry in self._entries.items()
+            },
+        }
+        tmp_path = path.with_suffix(".tmp")
+        with open(tmp_path, "wb") as f:
+            msgpack.dump(data, f)
+        tmp_path.replace(path)
+
+    @classmethod
+    def load(cls, path: Path) -> Optional["IndexMembershipSnapshot"]:
+        """Load a saved snapshot; None if missing, unreadable or outdated."""
+        if not path.exists():
+            return None
+        try:
+            with open(path, "rb") as f:
+                data = msgpack.load(f)
+            if data.get("version") != MEMBERSHIP_SNAPSHOT_VERSION:
+                return None
+            return cls(
+                {
+                    file_path: FileMembership(point_id, timestamp, payload)
+                    for file_path, (point_id, timestamp, payload) in data[
+                        "entries"
+                    ].items()
+                }
+            )
+        except Exception as e:
+            logger.warning(f"Ignoring unreadable membership snapshot {path}: {e}")
+            return None
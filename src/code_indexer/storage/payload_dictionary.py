@@ -0,0 +1,205 @@
+"""Dictionary encoding of repeated payload strings.
+
+Paths, languages, branch names and project IDs are repeated verbatim in the
+payload of every chunk. A collection with a payload dictionary stores each
+distinct value once in a lookup table and keeps only integer IDs in vector
+files. Encoded fields move to a separate key, so readers can tell the formats
+apart without consulting metadata:
+
+    "payload": {"path": "src/app.py", "hidden_branches": ["a", "b"]}
+        ->  "payload_ids": {"path": 12, "hidden_branches": [3, 4]}
+
+The lookup table (payload_dictionary.jsonl, next to collection_meta.json) is
+append-only: one [field, id, value] line per value, written and fsynced
+before any vector file that references it. IDs are never reused, so a reader
+that sees an unknown ID (another process appended it) only has to re-read the
+file. A torn last line can only belong to a value no vector file references.
+
+Whether a collection encodes payloads is recorded in collection_meta.json
+under "payload_dictionary". Collections created before it existed have no such
+key and keep writing plain payloads; reads handle both formats.
+"""
+
+import json
+import logging
+import os
+import threading
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+logger = logging.getLogger(__name__)
+
+PAYLOAD_DICTIONARY_FILENAME = "payload_dictionary.jsonl"
+PAYLOAD_DICTIONARY_METADATA_KEY = "payload_dictionary"
+ENCODED_PAYLOAD_KEY = "payload_ids"
+
+# String fields repeated across many chunks (hidden_branches is a list of names)
+DEFAULT_DICTIONARY_FIELDS = (
+    "path",
+    "language",
+    "git_branch",
+    "project_id",
+    "hidden_branches",
+)
+
+
+class PayloadDictionaryError(ValueError):
+    """Raised when an encoded payload references an ID not in the dictionary."""
+
+    pass
+
+
+def dictionary_fields_from_metadata(metadata: Dict[str, Any]) -> Tuple[str, ...]:
+    """Fields a collection encodes; empty for collections without a dictionary."""
+    settings = metadata.get(PAYLOAD_DICTIONARY_METADATA_KEY)
+    if not settings:
+        return ()
+    return tuple(settings.get("fields", ()))
+
+
+def dictionary_metadata(
+    fields: Iterable[str] = DEFAULT_DICTIONARY_FIELDS,
+) -> Dict[str, Any]:
+    """collection_meta.json entry enabling dictionary encoding for fields."""
+    return {"fields": list(fields)}
+
+
+class PayloadDictionary:
+    """Append-only value <-> ID lookup table for one collection."""
+
+    def __init__(self, collection_path: Path, fields: Iterable[str]):
+        self.path = collection_path / PAYLOAD_DICTIONARY_FILENAME
+        self.fields = tuple(fields)
+        self._lock = threading.Lock()
+        self._ids: Dict[str, Dict[str, int]] = {}
+        self._values: Dict[str, Dict[int, str]] = {}
+        self._next_id: Dict[str, int] = {}
+        self._load()
+
+    def _load(self) -> None:
+        """(Re)read the lookup table from disk; caller holds the lock or owns self."""
+        self._ids = {name: {} for name in self.fields}
+        self._values = {name: {} for name in self.fields}
+        self._next_id = {name: 0 for name in self.fields}
+        if not self.path.exists():
+            return
+
+        with open(self.path, "r", encoding="utf-8") as f:
+            for line in f:
+                try:
+                    name, value_id, value = json.loads(line)
+                except ValueError:
+                    # Torn last line from a crash mid-append - nothing references it
+                    break
+                if name not in self._ids:
+                    continue
+                self._ids[name][value] = value_id
+                self._values[name][value_id] = value
+                self._next_id[name] = max(self._next_id[name], value_id + 1)
+
+    def __len__(self) -> int:
+        return sum(len(ids) for ids in self._ids.values())
+
+    def intern(self, payloads: Iterable[Dict[str, Any]]) -> int:
+        """
+        Assign IDs to values of dictionary fields not seen before and persist them.
+
+        Must be called (and return) before vector files using those IDs are
+        written. Returns the number of new values.
+        """
+        with self._lock:
+            if len(self) and not self.path.exists():
+                # Collection was cleared under us - cached IDs are gone from disk
+                self._load()
+
+            new_entries: List[Tuple[str, int, str]] = []
+            for payload in payloads:
+                for name in self.fields:
+                    for value in _field_values(payload.get(name)):
+                        if value not in self._ids[name]:
+                            value_id = self._next_id[name]
+                            self._next_id[name] += 1
+                            self._ids[name][value] = value_id
+                            self._values[name][value_id] = value
+                            new_entries.append((name, value_id, value))
+
+            if new_entries:
+                with open(self.path, "a", encoding="utf-8") as f:
+                    for entry in new_entries:
+                        f.write(json.dumps(entry) + "\n")
+                    f.flush()
+                    os.fsync(f.fileno())
+            return len(new_entries)
+
+    def encode(self, data: Dict[str, Any]) -> Dict[str, Any]:
+        """Replace dictionary fields of vector data with IDs, in place.
+
+        Values must have been interned; fields with non-string values are left
+        in the payload.
+        """
+        # Copy: the payload dict may still be referenced by the caller's point
+        payload = dict(data.get("payload", {}))
+        encoded: Dict[str, Any] = {}
+        for name in self.fields:
+            value = payload.get(name)
+            if isinstance(value, str):
+                encoded[name] = self._ids[name][value]
+            elif isinstance(value, list) and all(isinstance(v, str) for v in value):
+                encoded[name] = [self._ids[name][v] for v in value]
+            else:
+                continue
+            del payload[name]
+
+        if encoded:
+            data["payload"] = payload
+            data[ENCODED_PAYLOAD_KEY] = encoded
+        return data
+
+    def decode(self, data: Dict[str, Any]) -> Dict[str, Any]:
+        """Restore encoded fields of vector data in place; plain data is untouched."""
+        encoded = data.pop(ENCODED_PAYLOAD_KEY, None)
+        if not encoded:
+            return data
+
+        payload = data.setdefault("payload", {})
+        for name, value_ids in encoded.items():
+            if isinstance(value_ids, list):
+                payload[name] = [self.lookup(name, v) for v in value_ids]
+            else:
+                payload[name] = self.lookup(name, value_ids)
+        return data
+
+    def lookup(self, name: str, value_id: int) -> str:
+        """Value for an ID, re-reading the table once if another writer added it."""
+        values = self._values.get(name, {})
+        if value_id not in values:
+            with self._lock:
+                self._load()
+            values = self._values.get(name, {})
+            if value_id not in values:
+                raise PayloadDictionaryError(
+                    f"Payload dictionary {self.path} has no {name} value {value_id}"
+                )
+        return values[value_id]
+
+    def get_path(self, data: Dict[str, Any]) -> Optional[str]:
+        """payload["path"] of raw (undecoded) vector data, in either format."""
+        path = data.get("payload", {}).get("path")
+        if path is not None:
+            return str(path)
+        path_id = data.get(ENCODED_PAYLOAD_KEY, {}).get("path")
+        if path_id is None:
+            return None
+        try:
+            return self.lookup("path", path_id)
+        except PayloadDictionaryError as e:
+            logger.warning(str(e))
+            return None
+
+
+def _field_values(value: Any) -> List[str]:
+    if isinstance(value, str):
+        return [value]
+    if isinstance(value, list) and all(isinstance(v, str) for v in value):
+        return value
+    return []
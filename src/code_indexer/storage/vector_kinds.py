@@ -0,0 +1,60 @@
+"""Kinds of vectors stored for a chunk.
+
+Every chunk has a body vector, the embedding of its text. With
+indexing.signature_vectors enabled, chunks declaring a function or type also
+get a signature vector: a second point embedding only the declaration's
+signature and doc comment. Signature points copy the payload of their body
+point (path, lines, annotations, branch visibility) and add:
+
+- vector_kind: "signature"
+- body_point_id: ID of the chunk's body point
+
+Searches select the vectors they match against:
+
+- body (default): body points only, as before signature vectors existed
+- signature: signature points only, for symbol-lookup queries
+- both: either kind, each chunk listed once with its best score
+"""
+
+from typing import Any, Dict, List
+
+VECTOR_KIND_KEY = "vector_kind"
+BODY_POINT_KEY = "body_point_id"
+SIGNATURE_VECTOR = "signature"
+
+BODY_MODE = "body"
+SIGNATURE_MODE = "signature"
+BOTH_MODE = "both"
+VECTOR_MODES = (BODY_MODE, SIGNATURE_MODE, BOTH_MODE)
+
+
+def matches_vector_mode(payload: Dict[str, Any], mode: str) -> bool:
+    """Whether a point with this payload is searched in the mode."""
+    is_signature = payload.get(VECTOR_KIND_KEY) == SIGNATURE_VECTOR
+    if mode == SIGNATURE_MODE:
+        return is_signature
+    if mode == BOTH_MODE:
+        return True
+    return not is_signature
+
+
+def merge_by_chunk(results: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """
+    Results (sorted by score) with signature hits reported as their chunk.
+
+    A signature hit takes the ID of its body point and records
+    matched_vector "signature"; a chunk hit by both of its vectors is listed
+    once, with the better score.
+    """
+    merged: List[Dict[str, Any]] = []
+    seen = set()
+    for result in results:
+        payload = result.get("payload", {})
+        if payload.get(VECTOR_KIND_KEY) == SIGNATURE_VECTOR:
+            result["id"] = payload.get(BODY_POINT_KEY, result["id"])
+            result["matched_vector"] = SIGNATURE_VECTOR
+        if result["id"] in seen:
+            continue
+        seen.add(result["id"])
+        merged.append(result)
+    return merged
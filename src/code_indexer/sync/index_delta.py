@@ -0,0 +1,394 @@
+"""Index delta format for client -> server sync.
+
+Instead of having the server re-index an activated repository after a branch
+moves, the client ships the part of its own semantic index that changed
+between the commit the server has indexed (base) and the commit the client has
+indexed (target):
+
+- upserts: every chunk of files changed between base and target, with vectors
+  and chunk text, so the server needs no embedding calls
+- deleted_paths: files removed (or no longer indexable) since base
+- metadata: progressive metadata updates (indexed commit and branch)
+
+The delta is msgpack, zstd-compressed, with vectors packed as float32. Both
+sides must use the same embedding model; the collection name and vector size
+are checked before anything is applied.
+"""
+
+import logging
+import struct
+import subprocess
+import time
+from dataclasses import asdict, dataclass, field
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Optional, Tuple
+
+import msgpack
+import zstandard
+
+logger = logging.getLogger(__name__)
+
+DELTA_FORMAT_VERSION = 1
+DELTA_MEDIA_TYPE = "application/vnd.cidx.index-delta"
+DELTA_UPSERT_BATCH_SIZE = 500
+# Largest compressed delta the server accepts; bigger changes sync server-side
+DELTA_MAX_UPLOAD_BYTES = 512 * 1024 * 1024
+
+
+class IndexDeltaError(Exception):
+    """Raised when an index delta cannot be built or applied."""
+
+    pass
+
+
+@dataclass
+class IndexDelta:
+    """Changes to a semantic index between two indexed commits."""
+
+    collection_name: str
+    vector_size: int
+    base_commit: str
+    target_commit: str
+    branch: Optional[str] = None
+    upserts: List[Dict[str, Any]] = field(default_factory=list)
+    deleted_paths: List[str] = field(default_factory=list)
+    metadata: Dict[str, Any] = field(default_factory=dict)
+    format_version: int = DELTA_FORMAT_VERSION
+
+    def to_bytes(self) -> bytes:
+        """Serialize to compressed msgpack."""
+        data = asdict(self)
+        data["upserts"] = [
+            {**point, "vector": _pack_vector(point["vector"])}
+            for point in self.upserts
+        ]
+        packed = msgpack.packb(data, use_bin_type=True)
+        return bytes(zstandard.ZstdCompressor().compress(packed))
+
+    @classmethod
+    def from_bytes(cls, payload: bytes) -> "IndexDelta":
+        """Deserialize from to_bytes() output."""
+        try:
+            packed = zstandard.ZstdDecompressor().decompress(payload)
+            data = msgpack.unpackb(packed, raw=False)
+        except Exception as e:
+            raise IndexDeltaError(f"Invalid index delta: {e}")
+
+        if not isinstance(data, dict):
+            raise IndexDeltaError("Invalid index delta: not a mapping")
+        if data.get("format_version") != DELTA_FORMAT_VERSION:
+            raise IndexDeltaError(
+                f"Unsupported index delta format version "
+                f"{data.get('format_version')} (expected {DELTA_FORMAT_VERSION})"
+            )
+
+        try:
+            data["upserts"] = [
+                {**point, "vector": _unpack_vector(point["vector"])}
+                for point in data.get("upserts", [])
+            ]
+            return cls(**data)
+        except (KeyError, TypeError, struct.error) as e:
+            raise IndexDeltaError(f"Invalid index delta: {e}")
+
+
+@dataclass
+class DeltaApplyResult:
+    """Summary of an applied index delta."""
+
+    collection_name: str
+    target_commit: str
+    points_upserted: int = 0
+    points_deleted: int = 0
+    files_changed: int = 0
+    elapsed_seconds: float = 0.0
+
+
+def _upsert_batches(
+    points: List[Dict[str, Any]], batch_size: int
+) -> Iterator[List[Dict[str, Any]]]:
+    """
+    Points in batches of whole files, in file order.
+
+    upsert_points() removes the chunks of every path in a call that the call
+    does not contain, so all chunks of a file must go in the same call. A
+    file with more chunks than batch_size is a batch of its own.
+    """
+    by_path: Dict[Any, List[Dict[str, Any]]] = {}
+    for point in points:
+        by_path.setdefault(point["payload"].get("path"), []).append(point)
+    batch: List[Dict[str, Any]] = []
+    for file_points in by_path.values():
+        if batch and len(batch) + len(file_points) > batch_size:
+            yield batch
+            batch = []
+        batch.extend(file_points)
+    if batch:
+        yield batch
+
+
+def _pack_vector(vector: List[float]) -> bytes:
+    return struct.pack(f"<{len(vector)}f", *vector)
+
+
+def _unpack_vector(packed: bytes) -> List[float]:
+    return list(struct.unpack(f"<{len(packed) // 4}f", packed))
+
+
+def _index_dir(project_root: Path) -> Path:
+    return project_root / ".code-indexer" / "index"
+
+
+def _metadata_path(project_root: Path) -> Path:
+    return project_root / ".code-indexer" / "metadata.json"
+
+
+def _encryption_enabled(project_root: Path) -> bool:
+    """Whether the project's config enables encryption at rest."""
+    from ..config import ConfigManager
+
+    config_path = project_root / ".code-indexer" / "config.json"
+    return ConfigManager(config_path).load().encryption.enabled
+
+
+def get_changed_paths(
+    repo_root: Path, base_commit: str, target_commit: str
+) -> Tuple[List[str], List[str]]:
+    """
+    Files changed between two commits.
+
+    Renames are reported as a deletion plus an addition.
+
+    Returns:
+        Tuple of (added or modified paths, deleted paths)
+
+    Raises:
+        IndexDeltaError: If git cannot diff the commits (e.g. unknown base)
+    """
+    result = subprocess.run(
+        ["git", "diff", "--name-status", "--no-renames", base_commit, target_commit],
+        cwd=repo_root,
+        capture_output=True,
+        text=True,
+        timeout=60,
+    )
+    if result.returncode != 0:
+        raise IndexDeltaError(
+            f"Cannot diff {base_commit[:12]}..{target_commit[:12]}: "
+            f"{result.stderr.strip()}"
+        )
+
+    changed: List[str] = []
+    deleted: List[str] = []
+    for line in result.stdout.splitlines():
+        status, _, path = line.partition("\t")
+        if not path:
+            continue
+        if status.startswith("D"):
+            deleted.append(path)
+        else:
+            changed.append(path)
+    return changed, deleted
+
+
+def get_semantic_collection_name(project_root: Path) -> str:
+    """
+    Name of the project's semantic (non-temporal) collection.
+
+    Raises:
+        IndexDeltaError: If there is no index or the collection is ambiguous
+    """
+    from ..storage.temporal_metadata_store import TemporalMetadataStore
+
+    index_dir = _index_dir(project_root)
+    collections = (
+        sorted(
+            path.name
+            for path in index_dir.iterdir()
+            if (path / "collection_meta.json").exists()
+            and not TemporalMetadataStore.is_temporal_collection(path.name)
+        )
+        if index_dir.exists()
+        else []
+    )
+    if len(collections) != 1:
+        raise IndexDeltaError(
+            f"Expected one semantic collection in {index_dir}, found "
+            f"{len(collections)}: {', '.join(collections) or 'none'}"
+        )
+    return collections[0]
+
+
+def get_index_state(project_root: Path) -> Dict[str, Any]:
+    """Indexed commit and branch recorded by the last indexing run."""
+    from ..services.progressive_metadata import ProgressiveMetadata
+
+    metadata = ProgressiveMetadata(_metadata_path(project_root)).metadata
+    return {
+        "indexed_commit": metadata.get("current_commit"),
+        "branch": metadata.get("current_branch"),
+        "status": metadata.get("status"),
+        "embedding_model": metadata.get("embedding_model"),
+    }
+
+
+def build_index_delta(
+    project_root: Path, base_commit: str, collection_name: str
+) -> IndexDelta:
+    """
+    Build the delta that takes an index at base_commit to this project's index.
+
+    Args:
+        project_root: Locally indexed project root
+        base_commit: Commit the receiving index was built from
+        collection_name: Collection to take chunks from (must exist on both sides)
+
+    Returns:
+        IndexDelta ready for upload
+
+    Raises:
+        IndexDeltaError: If the local index is missing, incomplete, encrypted,
+            or cannot be diffed against base_commit
+    """
+    from ..storage.filesystem_vector_store import FilesystemVectorStore
+    from ..storage.index_encryption import ENCRYPTION_METADATA_KEY
+
+    state = get_index_state(project_root)
+    target_commit = state["indexed_commit"]
+    if not target_commit:
+        raise IndexDeltaError(
+            "Local index has no indexed commit - run 'cidx index' in a git checkout"
+        )
+    if state["status"] != "completed":
+        raise IndexDeltaError(
+            f"Local index is not complete (status: {state['status']}) - "
+            "run 'cidx index' first"
+        )
+
+    index_dir = _index_dir(project_root)
+    if not index_dir.exists():
+        raise IndexDeltaError(f"No local index found at {index_dir}")
+
+    vector_store = FilesystemVectorStore(base_path=index_dir, project_root=project_root)
+    if not vector_store.collection_exists(collection_name):
+        raise IndexDeltaError(
+            f"Local index has no collection '{collection_name}' - "
+            "client and server must use the same embedding model"
+        )
+    collection_info = vector_store.get_collection_info(collection_name)
+    if (
+        _encryption_enabled(project_root)
+        or ENCRYPTION_METADATA_KEY in collection_info
+    ):
+        raise IndexDeltaError(
+            "Index deltas carry chunk content unencrypted and are not built "
+            "while encryption at rest is enabled"
+        )
+    vector_size = collection_info["vector_size"]
+
+    changed, deleted = get_changed_paths(project_root, base_commit, target_commit)
+    upserts = vector_store.get_points_for_paths(collection_name, changed)
+
+    # Changed files without chunks (now empty, excluded or too large) lose theirs
+    paths_with_chunks = {point["payload"].get("path") for point in upserts}
+    deleted_paths = deleted + [p for p in changed if p not in paths_with_chunks]
+
+    logger.info(
+        f"Index delta {base_commit[:12]}..{target_commit[:12]}: "
+        f"{len(upserts)} chunks from {len(paths_with_chunks)} files, "
+        f"{len(deleted_paths)} files removed"
+    )
+
+    return IndexDelta(
+        collection_name=collection_name,
+        vector_size=vector_size,
+        base_commit=base_commit,
+        target_commit=target_commit,
+        branch=state["branch"],
+        upserts=upserts,
+        deleted_paths=deleted_paths,
+        metadata={
+            "current_commit": target_commit,
+            "current_branch": state["branch"],
+        },
+    )
+
+
+def apply_index_delta(project_root: Path, delta: IndexDelta) -> DeltaApplyResult:
+    """
+    Apply an index delta to a project's index.
+
+    Args:
+        project_root: Project root whose index is updated (activated repo on
+            the server)
+        delta: Delta built against this index's indexed commit
+
+    Returns:
+        DeltaApplyResult with point counts
+
+    Raises:
+        IndexDeltaError: If the delta does not fit this index (different base
+            commit, missing collection or vector size mismatch)
+    """
+    from ..services.progressive_metadata import ProgressiveMetadata
+    from ..storage.filesystem_vector_store import FilesystemVectorStore
+
+    start = time.time()
+
+    progressive_metadata = ProgressiveMetadata(_metadata_path(project_root))
+    indexed_commit = progressive_metadata.metadata.get("current_commit")
+    if indexed_commit != delta.base_commit:
+        raise IndexDeltaError(
+            f"Index delta base {delta.base_commit[:12]} does not match indexed "
+            f"commit {(indexed_commit or 'none')[:12]}"
+        )
+
+    vector_store = FilesystemVectorStore(
+        base_path=_index_dir(project_root), project_root=project_root
+    )
+    if not vector_store.collection_exists(delta.collection_name):
+        raise IndexDeltaError(f"Collection '{delta.collection_name}' does not exist")
+    vector_size = vector_store.get_collection_info(delta.collection_name)[
+        "vector_size"
+    ]
+    if vector_size != delta.vector_size:
+        raise IndexDeltaError(
+            f"Vector size mismatch: index has {vector_size}, "
+            f"delta has {delta.vector_size}"
+        )
+
+    result = DeltaApplyResult(
+        collection_name=delta.collection_name, target_commit=delta.target_commit
+    )
+
+    vector_store.begin_indexing(delta.collection_name)
+    # Chunks of changed files replace their old chunks via upsert_points()
+    result.points_deleted = vector_store.delete_points_for_paths(
+        delta.collection_name, delta.deleted_paths
+    )
+    for batch in _upsert_batches(delta.upserts, DELTA_UPSERT_BATCH_SIZE):
+        vector_store.upsert_points(delta.collection_name, batch)
+        result.points_upserted += len(batch)
+    vector_store.end_indexing(delta.collection_name)
+
+    progressive_metadata.metadata.update(delta.metadata)
+    progressive_metadata.metadata["indexed_at"] = datetime.now(
+        timezone.utc
+    ).isoformat()
+    if delta.branch:
+        progressive_metadata.metadata.setdefault("branch_commit_watermarks", {})[
+            delta.branch
+        ] = delta.target_commit
+    progressive_metadata._save_metadata()
+
+    result.files_changed = len(
+        {point["payload"].get("path") for point in delta.upserts}
+        | set(delta.deleted_paths)
+    )
+    result.elapsed_seconds = time.time() - start
+    logger.info(
+        f"Applied index delta to {project_root}: {result.points_upserted} chunks "
+        f"upserted, {result.points_deleted} deleted ({result.elapsed_seconds:.2f}s)"
+    )
+    return result
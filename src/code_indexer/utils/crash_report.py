@@ -0,0 +1,412 @@
+"""Diagnostic bundles for bug reports.
+
+A bundle is a zip file with everything needed to triage a failure in one
+round: the sanitized project configuration, tails of recent logs, versions,
+an environment summary and the state of the last operation (indexing
+progress, watch metadata). It is written automatically when the CLI or the
+daemon dies with an unhandled exception, and on demand by ``cidx report-bug``.
+
+Secrets never enter a bundle: configuration keys that look like credentials
+are redacted, credential files are never read, and environment variables are
+listed by name only. Projects with encryption at rest enabled get no bundle at
+all, since logs and exception messages may quote indexed content.
+"""
+
+import io
+import json
+import os
+import platform
+import subprocess
+import sys
+import traceback
+import zipfile
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Sequence
+
+BUG_REPORT_URL = "https://github.com/jsbattig/code-indexer/issues"
+
+REDACTED = "<redacted>"
+
+# Key fragments of configuration values that must never leave the machine
+SECRET_KEY_FRAGMENTS = (
+    "api_key",
+    "apikey",
+    "token",
+    "secret",
+    "password",
+    "passphrase",
+    "credential",
+    "private_key",
+    "encrypted",
+)
+
+# Last operation state files in .code-indexer/
+STATE_FILES = ("metadata.json", "indexing_progress.json", "watch_metadata.json")
+
+# Environment variables listed (by name only) in the environment summary
+ENV_PREFIXES = ("CIDX_", "VOYAGE_", "CODE_INDEXER_")
+ENV_NAMES = ("HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "SHELL", "TERM", "LANG")
+
+# Packages whose versions commonly matter for triage
+PACKAGES = (
+    "click",
+    "rich",
+    "pydantic",
+    "httpx",
+    "hnswlib",
+    "numpy",
+    "watchdog",
+    "rpyc",
+    "tokenizers",
+    "fastapi",
+    "uvicorn",
+)
+
+MAX_LOG_BYTES = 256 * 1024  # Tail kept of each log file
+MAX_STATE_BYTES = 512 * 1024  # Larger state files are truncated
+MAX_LOG_FILES = 5  # Newest error logs included
+KEEP_REPORTS = 10  # Bundles kept in the reports directory
+
+REPORTS_DIR_NAME = "crash-reports"
+
+
+class EncryptedProjectError(RuntimeError):
+    """Raised instead of writing a bundle for a project with encryption enabled."""
+
+
+def sanitize(value: Any) -> Any:
+    """
+    Redact credential-like entries of a configuration structure.
+
+    Args:
+        value: Parsed JSON value (dicts and lists are walked recursively)
+
+    Returns:
+        Copy of the value with secret values replaced by a marker
+    """
+    if isinstance(value, dict):
+        sanitized = {}
+        for key, item in value.items():
+            lowered = str(key).lower()
+            # Numbers and flags are settings (e.g. tokens_per_minute), not secrets
+            is_secret_value = isinstance(item, (str, dict, list)) and bool(item)
+            if is_secret_value and any(
+                fragment in lowered for fragment in SECRET_KEY_FRAGMENTS
+            ):
+                sanitized[key] = REDACTED
+            else:
+                sanitized[key] = sanitize(item)
+        return sanitized
+    if isinstance(value, list):
+        return [sanitize(item) for item in value]
+    return value
+
+
+def sanitize_command(command: Sequence[str]) -> List[str]:
+    """
+    Redact values of credential options on a command line.
+
+    Args:
+        command: Command line arguments
+
+    Returns:
+        Arguments with the values of e.g. --password replaced by a marker
+    """
+    sanitized: List[str] = []
+    redact_next = False
+    for arg in command:
+        if redact_next:
+            sanitized.append(REDACTED)
+            redact_next = False
+            continue
+        option, has_value, _ = arg.partition("=")
+        is_secret = option.startswith("-") and any(
+            fragment.replace("_", "-") in option.lower()
+            for fragment in SECRET_KEY_FRAGMENTS
+        )
+        if is_secret and has_value:
+            sanitized.append(f"{option}={REDACTED}")
+        else:
+            sanitized.append(arg)
+            redact_next = is_secret
+    return sanitized
+
+
+def reports_dir(project_root: Optional[Path]) -> Path:
+    """
+    Directory bundles are written to.
+
+    Args:
+        project_root: Project root, or None outside of a project
+
+    Returns:
+        <project>/.code-indexer/crash-reports, or ~/.code-indexer/crash-reports
+        when there is no initialized project
+    """
+    if project_root is not None and (project_root / ".code-indexer").is_dir():
+        return project_root / ".code-indexer" / REPORTS_DIR_NAME
+    return Path.home() / ".code-indexer" / REPORTS_DIR_NAME
+
+
+def collect_versions() -> Dict[str, Any]:
+    """Versions of cidx, Python, git and key dependencies."""
+    from importlib import metadata
+
+    from code_indexer import __version__
+
+    packages: Dict[str, Optional[str]] = {}
+    for name in PACKAGES:
+        try:
+            packages[name] = metadata.version(name)
+        except metadata.PackageNotFoundError:
+            packages[name] = None
+
+    try:
+        git_version: Optional[str] = subprocess.run(
+            ["git", "--version"], capture_output=True, text=True, timeout=5
+        ).stdout.strip()
+    except (OSError, subprocess.SubprocessError):
+        git_version = None
+
+    return {
+        "cidx": __version__,
+        "python": sys.version.split()[0],
+        "python_implementation": platform.python_implementation(),
+        "git": git_version,
+        "packages": packages,
+    }
+
+
+def collect_environment() -> Dict[str, Any]:
+    """Summary of the machine and process, without environment values."""
+    env_vars = sorted(
+        name
+        for name in os.environ
+        if name.startswith(ENV_PREFIXES) or name.upper() in ENV_NAMES
+    )
+    environment: Dict[str, Any] = {
+        "platform": platform.platform(),
+        "machine": platform.machine(),
+        "cpu_count": os.cpu_count(),
+        "python_executable": sys.executable,
+        "filesystem_encoding": sys.getfilesystemencoding(),
+        "cwd": os.getcwd(),
+        "env_vars_set": env_vars,
+    }
+    try:
+        import psutil
+
+        memory = psutil.virtual_memory()
+        environment["memory_total_mb"] = memory.total // (1024 * 1024)
+        environment["memory_available_mb"] = memory.available // (1024 * 1024)
+    except Exception:
+        pass  # psutil is optional for the report
+    return environment
+
+
+def _read_tail(path: Path, max_bytes: int) -> bytes:
+    with open(path, "rb") as f:
+        f.seek(0, os.SEEK_END)
+        size = f.tell()
+        f.seek(max(0, size - max_bytes))
+        data = f.read()
+    if size > max_bytes:
+        data = b"[... truncated ...]\n" + data.split(b"\n", 1)[-1]
+    return data
+
+
+def _log_files(config_dirs: Sequence[Path]) -> List[Path]:
+    """Recent log files of the project: error logs, daemon log, JSON logs."""
+    error_logs: List[Path] = []
+    other_logs: List[Path] = []
+    for config_dir in config_dirs:
+        if not config_dir.is_dir():
+            continue
+        # Every CLI run creates an error log; only non-empty ones matter
+        error_logs.extend(
+            p for p in config_dir.glob("error_*.log") if p.stat().st_size > 0
+        )
+        if (config_dir / "daemon.log").is_file():
+            other_logs.append(config_dir / "daemon.log")
+        logs_dir = config_dir / "logs"
+        if logs_dir.is_dir():
+            other_logs.extend(p for p in logs_dir.glob("*.jsonl") if p.is_file())
+    error_logs.sort(key=lambda p: p.stat().st_mtime, reverse=True)
+    return error_logs[:MAX_LOG_FILES] + other_logs
+
+
+def _read_json(path: Path) -> Any:
+    if path.stat().st_size > MAX_STATE_BYTES:
+        return {"truncated": True, "size_bytes": path.stat().st_size}
+    return json.loads(path.read_text(encoding="utf-8"))
+
+
+def _encryption_enabled(project_root: Path) -> bool:
+    """Whether the project's config.json enables encryption at rest."""
+    try:
+        config = _read_json(project_root / ".code-indexer" / "config.json")
+    except (OSError, ValueError):
+        return False
+    encryption = config.get("encryption") if isinstance(config, dict) else None
+    return isinstance(encryption, dict) and encryption.get("enabled") is True
+
+
+def build_report(
+    exception: Optional[BaseException] = None,
+    command: Optional[Sequence[str]] = None,
+    trigger: str = "report-bug",
+) -> Dict[str, Any]:
+    """
+    Assemble report.json, the summary part of a bundle.
+
+    Args:
+        exception: Unhandled exception being reported, if any
+        command: Command line of the failed process
+        trigger: "crash" for unhandled failures, "report-bug" on demand
+
+    Returns:
+        Report dictionary
+    """
+    report: Dict[str, Any] = {
+        "generated_at": datetime.now(timezone.utc).isoformat(),
+        "trigger": trigger,
+        "command": sanitize_command(command if command is not None else sys.argv),
+        "versions": collect_versions(),
+        "environment": collect_environment(),
+        "exception": None,
+    }
+    if exception is not None:
+        report["exception"] = {
+            "type": type(exception).__name__,
+            "message": str(exception),
+            "traceback": "".join(
+                traceback.format_exception(
+                    type(exception), exception, exception.__traceback__
+                )
+            ),
+        }
+    return report
+
+
+def write_bundle(
+    project_root: Optional[Path],
+    exception: Optional[BaseException] = None,
+    command: Optional[Sequence[str]] = None,
+    trigger: str = "report-bug",
+    output: Optional[Path] = None,
+) -> Path:
+    """
+    Write a diagnostic bundle.
+
+    Args:
+        project_root: Project root, or None outside of a project
+        exception: Unhandled exception being reported, if any
+        command: Command line of the failed process (default: sys.argv)
+        trigger: "crash" for unhandled failures, "report-bug" on demand
+        output: Bundle path or directory (default: the reports directory)
+
+    Returns:
+        Path of the written zip file
+
+    Raises:
+        EncryptedProjectError: If the project has encryption at rest enabled
+    """
+    if project_root is not None and _encryption_enabled(project_root):
+        raise EncryptedProjectError(
+            "Diagnostic bundles are not written while encryption.enabled is set "
+            "in config.json: logs and error messages may contain indexed content "
+            "in plain text"
+        )
+
+    timestamp = datetime.now().strftime("%Y%m%d_%H%M%S")
+    name = f"cidx-report-{timestamp}-{os.getpid()}.zip"
+    if output is None:
+        bundle_path = reports_dir(project_root) / name
+    elif output.is_dir():
+        bundle_path = output / name
+    else:
+        bundle_path = output
+    bundle_path.parent.mkdir(parents=True, exist_ok=True)
+
+    config_dirs: List[Path] = []
+    if project_root is not None:
+        config_dirs.append(project_root / ".code-indexer")
+    # ExceptionLogger writes CLI error logs below the working directory
+    cwd_config_dir = Path.cwd() / ".code-indexer"
+    if cwd_config_dir not in config_dirs:
+        config_dirs.append(cwd_config_dir)
+
+    report = build_report(exception, command, trigger)
+    report["project_root"] = str(project_root) if project_root else None
+    contents: List[str] = []
+    errors: Dict[str, str] = {}
+
+    buffer = io.BytesIO()
+    with zipfile.ZipFile(buffer, "w", zipfile.ZIP_DEFLATED) as bundle:
+        if project_root is not None:
+            config_dir = project_root / ".code-indexer"
+            for source, arcname in [
+                (config_dir / "config.json", "config.json"),
+                (config_dir / ".remote-config", "remote-config.json"),
+            ] + [(config_dir / f, f"state/{f}") for f in STATE_FILES]:
+                if not source.is_file():
+                    continue
+                try:
+                    data = sanitize(_read_json(source))
+                    bundle.writestr(arcname, json.dumps(data, indent=2, default=str))
+                    contents.append(arcname)
+                except (OSError, ValueError) as e:
+                    errors[arcname] = str(e)
+
+        for log_file in _log_files(config_dirs):
+            arcname = f"logs/{log_file.parent.name}/{log_file.name}"
+            if log_file.parent.name == ".code-indexer":
+                arcname = f"logs/{log_file.name}"
+            try:
+                bundle.writestr(arcname, _read_tail(log_file, MAX_LOG_BYTES))
+                contents.append(arcname)
+            except OSError as e:
+                errors[arcname] = str(e)
+
+        report["contents"] = contents
+        report["collection_errors"] = errors
+        bundle.writestr("report.json", json.dumps(report, indent=2, default=str))
+
+    bundle_path.write_bytes(buffer.getvalue())
+    if output is None:
+        _prune_reports(bundle_path.parent)
+    return bundle_path
+
+
+def _prune_reports(directory: Path) -> None:
+    """Keep only the newest KEEP_REPORTS bundles."""
+    bundles = sorted(
+        directory.glob("cidx-report-*.zip"),
+        key=lambda p: p.stat().st_mtime,
+        reverse=True,
+    )
+    for stale in bundles[KEEP_REPORTS:]:
+        try:
+            stale.unlink()
+        except OSError:
+            pass
+
+
+def write_crash_bundle(
+    project_root: Optional[Path], exception: BaseException
+) -> Optional[Path]:
+    """
+    Write a bundle for an unhandled exception, never raising.
+
+    Args:
+        project_root: Project root, or None outside of a project
+        exception: The unhandled exception
+
+    Returns:
+        Path of the bundle, or None when it could not be written
+    """
+    try:
+        return write_bundle(project_root, exception=exception, trigger="crash")
+    except Exception:
+        return None
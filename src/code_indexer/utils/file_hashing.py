@@ -0,0 +1,85 @@
+"""
+Content hashing for change detection.
+
+Change detection only needs to tell whether a file's content differs from what
+was indexed, so it does not need a cryptographic hash. On large repositories
+hashing every file with SHA-256 is measurably CPU bound; xxh3 (128-bit) is
+several times faster with a negligible collision rate for this purpose.
+
+Hashes carry an algorithm prefix ("sha256:..." / "xxh3:...") so values from
+different algorithms never compare equal. Switching algorithms therefore makes
+every file look changed once, after which the new hashes are stable.
+
+Integrity-sensitive hashing (git blob hashes, bundle manifests, project IDs)
+does not go through this module and stays on its fixed algorithm.
+"""
+
+import hashlib
+import logging
+from pathlib import Path
+from typing import Any
+
+logger = logging.getLogger(__name__)
+
+HASH_ALGORITHMS = ("sha256", "xxh3")
+DEFAULT_HASH_ALGORITHM = "sha256"
+HASH_READ_CHUNK_BYTES = 1024 * 1024
+
+_xxhash_warning_logged = False
+
+
+def resolve_hash_algorithm(algorithm: Any) -> str:
+    """
+    Algorithm that will actually be used for a configured value.
+
+    Unknown values fall back to sha256; xxh3 falls back to sha256 (with one
+    warning) when the xxhash package is not installed.
+    """
+    global _xxhash_warning_logged
+
+    if algorithm not in HASH_ALGORITHMS:
+        return DEFAULT_HASH_ALGORITHM
+
+    if algorithm == "xxh3":
+        try:
+            import xxhash  # noqa: F401
+        except ImportError:
+            if not _xxhash_warning_logged:
+                logger.warning(
+                    "indexing.hash_algorithm is 'xxh3' but the xxhash package is "
+                    "not installed - falling back to sha256"
+                )
+                _xxhash_warning_logged = True
+            return DEFAULT_HASH_ALGORITHM
+
+    return str(algorithm)
+
+
+def _new_hasher(algorithm: str) -> Any:
+    if algorithm == "xxh3":
+        import xxhash
+
+        return xxhash.xxh3_128()
+    return hashlib.sha256()
+
+
+def hash_file(file_path: Path, algorithm: str = DEFAULT_HASH_ALGORITHM) -> str:
+    """
+    Hash a file's content for change detection.
+
+    Args:
+        file_path: File to hash
+        algorithm: Algorithm from resolve_hash_algorithm()
+
+    Returns:
+        "<algorithm>:<hex digest>"
+
+    Raises:
+        OSError: If the file cannot be read
+    """
+    hasher = _new_hasher(algorithm)
+    with open(file_path, "rb") as f:
+        for chunk in iter(lambda: f.read(HASH_READ_CHUNK_BYTES), b""):
+            hasher.update(chunk)
+    return f"{algorithm}:{hasher.hexdigest()}"
+
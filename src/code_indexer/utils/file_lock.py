@@ -0,0 +1,95 @@
+"""Cross-platform advisory file locks.
+
+Wraps fcntl.flock on POSIX and msvcrt.locking on Windows behind one
+interface, so CLI and indexing code can coordinate processes on every
+platform. Windows has no shared locks: shared requests take an exclusive
+lock there, which is safe but serializes concurrent readers.
+
+Usage mirrors flock::
+
+    with open(lock_path, "a+") as f:
+        lock_file(f)
+        try:
+            ...
+        finally:
+            unlock_file(f)
+"""
+
+import sys
+import time
+from typing import IO, Any
+
+# Polling interval of blocking lock requests on Windows
+_WINDOWS_RETRY_SECONDS = 0.05
+
+if sys.platform == "win32":
+    import msvcrt
+
+    def lock_file(f: IO[Any], exclusive: bool = True, blocking: bool = True) -> None:
+        """
+        Acquire an advisory lock on an open file.
+
+        Args:
+            f: Open file object
+            exclusive: Exclusive lock (shared locks are exclusive on Windows)
+            blocking: Wait for the lock instead of failing
+
+        Raises:
+            BlockingIOError: If blocking is False and the lock is held elsewhere
+        """
+        # msvcrt locks a byte range from the current position: lock byte 0
+        while True:
+            position = f.tell()
+            try:
+                f.seek(0)
+                msvcrt.locking(f.fileno(), msvcrt.LK_NBLCK, 1)
+                return
+            except OSError:
+                if not blocking:
+                    raise BlockingIOError("File is locked by another process")
+                time.sleep(_WINDOWS_RETRY_SECONDS)
+            finally:
+                f.seek(position)
+
+    def unlock_file(f: IO[Any]) -> None:
+        """
+        Release a lock acquired with lock_file().
+
+        Args:
+            f: Open file object holding the lock
+        """
+        position = f.tell()
+        try:
+            f.seek(0)
+            msvcrt.locking(f.fileno(), msvcrt.LK_UNLCK, 1)
+        finally:
+            f.seek(position)
+
+else:
+    import fcntl
+
+    def lock_file(f: IO[Any], exclusive: bool = True, blocking: bool = True) -> None:
+        """
+        Acquire an advisory lock on an open file.
+
+        Args:
+            f: Open file object
+            exclusive: Exclusive lock (False = shared lock)
+            blocking: Wait for the lock instead of failing
+
+        Raises:
+            BlockingIOError: If blocking is False and the lock is held elsewhere
+        """
+        operation = fcntl.LOCK_EX if exclusive else fcntl.LOCK_SH
+        if not blocking:
+            operation |= fcntl.LOCK_NB
+        fcntl.flock(f.fileno(), operation)
+
+    def unlock_file(f: IO[Any]) -> None:
+        """
+        Release a lock acquired with lock_file().
+
+        Args:
+            f: Open file object holding the lock
+        """
+        fcntl.flock(f.fileno(), fcntl.LOCK_UN)
@@ -0,0 +1,175 @@
+"""Structured JSON logging to rotating files.
+
+Long-running processes (watch, the daemon, the CIDX server) can write one JSON
+object per log record to a size-rotated file, so standard log pipelines can
+ingest them instead of scraping console output. Each line carries timestamp,
+level, logger and message, the process's static fields (component, project)
+and any structured ``extra`` fields of the record, e.g.::
+
+    logger.info(
+        "Processed 3 files",
+        extra={"operation": "watch_batch", "files_processed": 3, "duration_ms": 812},
+    )
+
+Structured fields used across cidx: operation, project, files_processed,
+files_failed, chunks_created, duration_ms and correlation_id.
+"""
+
+import json
+import logging
+import logging.handlers
+import socket
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Callable, Dict, Optional
+
+# Attributes every LogRecord has; anything else was passed via extra=
+_STANDARD_RECORD_ATTRS = set(
+    vars(logging.LogRecord("", logging.INFO, "", 0, "", None, None))
+) | {"message", "asctime", "taskName"}
+
+DEFAULT_MAX_BYTES = 10 * 1024 * 1024
+DEFAULT_BACKUP_COUNT = 5
+
+
+class JSONLogFormatter(logging.Formatter):
+    """Formats log records as single-line JSON objects."""
+
+    def __init__(
+        self,
+        static_fields: Optional[Dict[str, Any]] = None,
+        context_provider: Optional[Callable[[], Dict[str, Any]]] = None,
+    ):
+        """
+        Initialize the formatter.
+
+        Args:
+            static_fields: Fields added to every record (e.g. component, project)
+            context_provider: Returns fields of the current context (e.g. the
+                request's correlation ID); explicit extra fields win
+        """
+        super().__init__()
+        self.static_fields = dict(static_fields or {})
+        self.context_provider = context_provider
+
+    def format(self, record: logging.LogRecord) -> str:
+        entry: Dict[str, Any] = {
+            "timestamp": datetime.fromtimestamp(record.created, timezone.utc)
+            .isoformat()
+            .replace("+00:00", "Z"),
+            "level": record.levelname,
+            "logger": record.name,
+            "message": record.getMessage(),
+            "pid": record.process,
+            "thread": record.threadName,
+        }
+        entry.update(self.static_fields)
+        if self.context_provider is not None:
+            try:
+                entry.update(
+                    {k: v for k, v in self.context_provider().items() if v is not None}
+                )
+            except Exception:
+                pass  # Context is best effort; never lose the record
+        for key, value in vars(record).items():
+            if key in _STANDARD_RECORD_ATTRS or key.startswith("_"):
+                continue
+            if value is None and key in entry:
+                continue  # e.g. correlation_id=None must not hide the context's
+            entry[key] = value
+        if record.exc_info:
+            entry["exception"] = self.formatException(record.exc_info)
+        return json.dumps(entry, default=str, ensure_ascii=False)
+
+
+def install_json_log_handler(
+    log_file: Path,
+    level: int = logging.INFO,
+    max_bytes: int = DEFAULT_MAX_BYTES,
+    backup_count: int = DEFAULT_BACKUP_COUNT,
+    static_fields: Optional[Dict[str, Any]] = None,
+    context_provider: Optional[Callable[[], Dict[str, Any]]] = None,
+    logger: Optional[logging.Logger] = None,
+) -> logging.Handler:
+    """
+    Attach a rotating JSON file handler to a logger (the root logger by default).
+
+    Installing twice for the same file replaces the earlier handler, so the
+    handler is safe to install from code paths that may run more than once.
+
+    Args:
+        log_file: File to write; rotated to log_file.1 ... log_file.N
+        level: Minimum level of records written
+        max_bytes: Size at which the file is rotated
+        backup_count: Rotated files kept
+        static_fields: Fields added to every record
+        context_provider: Returns fields of the current context
+        logger: Logger to attach to (default: root logger)
+
+    Returns:
+        The installed handler
+    """
+    target = logger or logging.getLogger()
+    log_file = Path(log_file)
+    log_file.parent.mkdir(parents=True, exist_ok=True)
+
+    for existing in list(target.handlers):
+        if getattr(existing, "_cidx_json_log_file", None) == str(log_file):
+            target.removeHandler(existing)
+            existing.close()
+
+    handler = logging.handlers.RotatingFileHandler(
+        log_file, maxBytes=max_bytes, backupCount=backup_count, encoding="utf-8"
+    )
+    handler._cidx_json_log_file = str(log_file)  # type: ignore[attr-defined]
+    handler.setLevel(level)
+    handler.setFormatter(JSONLogFormatter(static_fields, context_provider))
+    # Records below the logger's level never reach the handler: lower it, but
+    # keep the other handlers (console output) at the level they had
+    previous_level = target.getEffectiveLevel()
+    if previous_level > level:
+        for existing in target.handlers:
+            if existing.level == logging.NOTSET:
+                existing.setLevel(previous_level)
+        target.setLevel(level)
+    target.addHandler(handler)
+    return handler
+
+
+def configure_project_json_logging(
+    config: Any, component: str
+) -> Optional[logging.Handler]:
+    """
+    Install the JSON log handler of a project if its config enables it.
+
+    Args:
+        config: Project Config with a logging section
+        component: Process writing the log (e.g. "watch", "daemon")
+
+    Returns:
+        The installed handler, or None when JSON logging is disabled
+    """
+    logging_config = getattr(config, "logging", None)
+    if logging_config is None or not logging_config.json_logs:
+        return None
+
+    codebase_dir = Path(config.codebase_dir)
+    # One file per component: rotation is not safe across processes
+    log_file = (
+        Path(logging_config.log_file)
+        if logging_config.log_file
+        else codebase_dir / ".code-indexer" / "logs" / f"{component}.jsonl"
+    )
+    if not log_file.is_absolute():
+        log_file = codebase_dir / log_file
+    return install_json_log_handler(
+        log_file,
+        level=getattr(logging, logging_config.level),
+        max_bytes=logging_config.max_bytes,
+        backup_count=logging_config.backup_count,
+        static_fields={
+            "component": component,
+            "project": str(codebase_dir.resolve()),
+            "host": socket.gethostname(),
+        },
+    )
@@ -0,0 +1,82 @@
+"""Lazy attribute imports for faster CLI startup.
+
+Importing provider and backend modules pulls in httpx, cryptography and the
+vector store stack, which costs hundreds of milliseconds on every ``cidx``
+invocation even for commands that never touch them. ``LazyAttribute`` stands in
+for a module attribute and imports it on first use.
+
+The proxy forwards attribute access, assignment, deletion and calls to the real
+object, so ``unittest.mock.patch("code_indexer.cli.BackendFactory.create")``
+and ``patch("code_indexer.cli.AdminAPIClient")`` keep working unchanged.
+"""
+
+import importlib
+import threading
+from typing import Any, Optional
+
+
+class LazyAttribute:
+    """Proxy for ``getattr(import_module(module_name), attribute_name)``."""
+
+    __slots__ = ("_module_name", "_attribute_name", "_package", "_target", "_lock")
+
+    def __init__(
+        self, module_name: str, attribute_name: str, package: Optional[str] = None
+    ):
+        """
+        Initialize the proxy without importing anything.
+
+        Args:
+            module_name: Absolute module name, or relative name with package
+            attribute_name: Attribute to load from the module
+            package: Anchor package for relative module names
+        """
+        object.__setattr__(self, "_module_name", module_name)
+        object.__setattr__(self, "_attribute_name", attribute_name)
+        object.__setattr__(self, "_package", package)
+        object.__setattr__(self, "_target", None)
+        object.__setattr__(self, "_lock", threading.Lock())
+
+    def resolve(self) -> Any:
+        """Import the module (once) and return the real attribute."""
+        target = object.__getattribute__(self, "_target")
+        if target is not None:
+            return target
+
+        with object.__getattribute__(self, "_lock"):
+            target = object.__getattribute__(self, "_target")
+            if target is None:
+                module = importlib.import_module(
+                    object.__getattribute__(self, "_module_name"),
+                    object.__getattribute__(self, "_package"),
+                )
+                target = getattr(
+                    module, object.__getattribute__(self, "_attribute_name")
+                )
+                object.__setattr__(self, "_target", target)
+        return target
+
+    @property
+    def is_resolved(self) -> bool:
+        """True once the underlying module has been imported."""
+        return object.__getattribute__(self, "_target") is not None
+
+    def __getattr__(self, name: str) -> Any:
+        # Only called for names not found on the proxy itself, including
+        # __dict__ (mock.patch reads it to save and restore originals)
+        return getattr(self.resolve(), name)
+
+    def __setattr__(self, name: str, value: Any) -> None:
+        setattr(self.resolve(), name, value)
+
+    def __delattr__(self, name: str) -> None:
+        delattr(self.resolve(), name)
+
+    def __call__(self, *args: Any, **kwargs: Any) -> Any:
+        return self.resolve()(*args, **kwargs)
+
+    def __repr__(self) -> str:
+        module_name = object.__getattribute__(self, "_module_name")
+        attribute_name = object.__getattribute__(self, "_attribute_name")
+        state = "resolved" if self.is_resolved else "not loaded"
+        return f"<LazyAttribute {module_name}.{attribute_name} ({state})>"
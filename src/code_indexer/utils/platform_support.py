@@ -0,0 +1,19 @@
+"""Platform capabilities that decide which cidx features are available.
+
+cidx runs natively on Linux, macOS and Windows. The daemon is the exception:
+its RPC transport is a Unix domain socket, which CPython does not provide on
+Windows, so daemon mode is unavailable there and commands run standalone.
+"""
+
+import socket
+import sys
+
+IS_WINDOWS = sys.platform == "win32"
+
+# Daemon RPC uses AF_UNIX sockets
+DAEMON_SUPPORTED = hasattr(socket, "AF_UNIX")
+
+DAEMON_UNSUPPORTED_REASON = (
+    "Daemon mode needs Unix domain sockets, which Python does not support on "
+    "this platform; commands run in standalone mode"
+)
@@ -0,0 +1,324 @@
+"""Opt-in anonymous usage telemetry.
+
+Nothing is recorded until a user runs ``cidx telemetry enable``. From then
+on, every cidx invocation appends one event to
+~/.code-indexer/telemetry/events.jsonl with:
+
+- the command name ("query", "coverage import"), never its arguments,
+- the duration in milliseconds,
+- an error category (exception class name or exit code) for failed runs,
+- the cidx version, Python version, operating system and the day.
+
+Queries, code, paths, repository names, hostnames and usernames are never
+recorded. ``cidx telemetry show`` prints exactly what is stored. Events stay
+on the machine unless an upload endpoint is configured with
+``cidx telemetry enable --endpoint URL``; they are then uploaded at most once
+a day and deleted after a successful upload. ``cidx telemetry disable``
+stops recording and deletes unsent events.
+
+This module only uses the standard library, so the fast daemon path can
+record usage without importing the full CLI.
+"""
+
+import json
+import os
+import platform
+import sys
+import tempfile
+import time
+import uuid
+from collections import Counter, defaultdict
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Sequence
+
+SETTINGS_FILE_NAME = "settings.json"
+EVENTS_FILE_NAME = "events.jsonl"
+
+# Uploads happen at most this often, with this timeout
+UPLOAD_INTERVAL_SECONDS = 24 * 60 * 60
+UPLOAD_TIMEOUT_SECONDS = 3
+
+# Oldest events are dropped once the events file grows beyond this size
+MAX_EVENTS_BYTES = 1024 * 1024
+
+
+def telemetry_dir() -> Path:
+    """Directory holding telemetry settings and events (per user)."""
+    return Path.home() / ".code-indexer" / "telemetry"
+
+
+def resolve_command_name(group: Any, args: Sequence[str]) -> Optional[str]:
+    """
+    Name of the command a command line invokes, without its arguments.
+
+    Args:
+        group: Root click group of the CLI
+        args: Command line arguments after the program name
+
+    Returns:
+        Space-separated command path ("coverage import"), or None when no
+        known command is invoked
+    """
+    names: List[str] = []
+    command = group
+    for arg in args:
+        if arg.startswith("-"):
+            continue
+        commands = getattr(command, "commands", None)
+        subcommand = commands.get(arg) if isinstance(commands, dict) else None
+        if subcommand is None:
+            if names:
+                break  # First argument of the command
+            continue  # Value of a global option
+        names.append(arg)
+        command = subcommand
+    return " ".join(names) or None
+
+
+def error_category(
+    exit_code: int, exception: Optional[BaseException]
+) -> Optional[str]:
+    """Category of a failed run: the exception class name, or "exit_<code>"."""
+    if exception is not None:
+        return type(exception).__name__
+    if exit_code:
+        return f"exit_{exit_code}"
+    return None
+
+
+class Telemetry:
+    """Telemetry settings and the locally recorded events."""
+
+    def __init__(self, directory: Optional[Path] = None):
+        """
+        Initialize telemetry state.
+
+        Args:
+            directory: Settings and events directory (default: telemetry_dir())
+        """
+        self.directory = Path(directory) if directory else telemetry_dir()
+        self.settings_file = self.directory / SETTINGS_FILE_NAME
+        self.events_file = self.directory / EVENTS_FILE_NAME
+
+    @property
+    def settings(self) -> Dict[str, Any]:
+        try:
+            settings = json.loads(self.settings_file.read_text())
+        except (OSError, ValueError):
+            return {}
+        return settings if isinstance(settings, dict) else {}
+
+    @property
+    def enabled(self) -> bool:
+        return self.settings.get("enabled") is True
+
+    @property
+    def endpoint(self) -> Optional[str]:
+        return self.settings.get("endpoint") or None
+
+    def enable(self, endpoint: Optional[str] = None) -> None:
+        """
+        Start recording.
+
+        Args:
+            endpoint: HTTPS URL events are uploaded to (default: keep the
+                configured one; none means events stay local)
+        """
+        settings = self.settings
+        settings["enabled"] = True
+        # Random, not derived from the machine or user
+        settings.setdefault("install_id", uuid.uuid4().hex)
+        if endpoint is not None:
+            settings["endpoint"] = endpoint or None
+        self._save_settings(settings)
+
+    def disable(self) -> int:
+        """Stop recording and delete unsent events; returns how many."""
+        settings = self.settings
+        settings["enabled"] = False
+        self._save_settings(settings)
+        return self.clear()
+
+    def clear(self) -> int:
+        """Delete recorded events; returns how many there were."""
+        count = len(self.events())
+        try:
+            self.events_file.unlink()
+        except FileNotFoundError:
+            pass
+        return count
+
+    def record(
+        self,
+        command: str,
+        duration_seconds: float,
+        error: Optional[str] = None,
+        now: Optional[float] = None,
+    ) -> None:
+        """Append one usage event (only when enabled)."""
+        from code_indexer import __version__
+
+        if not self.enabled:
+            return
+        now = time.time() if now is None else now
+        event = {
+            "command": command,
+            "duration_ms": int(duration_seconds * 1000),
+            "error": error,
+            "version": __version__,
+            "python": ".".join(str(part) for part in sys.version_info[:2]),
+            "os": platform.system(),
+            "day": datetime.fromtimestamp(now, timezone.utc).strftime("%Y-%m-%d"),
+        }
+        self.directory.mkdir(parents=True, exist_ok=True)
+        with open(self.events_file, "a", encoding="utf-8") as f:
+            f.write(json.dumps(event) + "\n")
+        if self.events_file.stat().st_size > MAX_EVENTS_BYTES:
+            self._trim()
+
+    def events(self) -> List[Dict[str, Any]]:
+        """Recorded events, oldest first."""
+        try:
+            lines = self.events_file.read_text(encoding="utf-8").splitlines()
+        except OSError:
+            return []
+        events = []
+        for line in lines:
+            try:
+                events.append(json.loads(line))
+            except ValueError:
+                continue  # Torn write of a concurrent process
+        return events
+
+    def summary(self) -> List[Dict[str, Any]]:
+        """
+        Recorded events per command.
+
+        Returns:
+            {"command", "runs", "errors", "p50_ms", "max_ms", "error_categories"}
+            dicts, most runs first
+        """
+        durations: Dict[str, List[int]] = defaultdict(list)
+        errors: Dict[str, Counter] = defaultdict(Counter)
+        for event in self.events():
+            command = str(event.get("command"))
+            durations[command].append(int(event.get("duration_ms") or 0))
+            if event.get("error"):
+                errors[command][str(event["error"])] += 1
+        rows = []
+        for command, values in durations.items():
+            values.sort()
+            rows.append(
+                {
+                    "command": command,
+                    "runs": len(values),
+                    "errors": sum(errors[command].values()),
+                    "p50_ms": values[len(values) // 2],
+                    "max_ms": values[-1],
+                    "error_categories": dict(errors[command].most_common()),
+                }
+            )
+        rows.sort(key=lambda row: (-row["runs"], row["command"]))
+        return rows
+
+    def upload_payload(self) -> Dict[str, Any]:
+        """Exactly what an upload sends."""
+        return {
+            "install_id": self.settings.get("install_id"),
+            "events": self.events(),
+        }
+
+    def maybe_upload(self, now: Optional[float] = None) -> bool:
+        """
+        Upload recorded events if an endpoint is configured and the last
+        attempt is at least UPLOAD_INTERVAL_SECONDS old.
+
+        Failures are silent; events are kept and retried a day later.
+
+        Returns:
+            True if events were uploaded (and deleted locally)
+        """
+        import urllib.request
+
+        settings = self.settings
+        endpoint = settings.get("endpoint")
+        now = time.time() if now is None else now
+        if not settings.get("enabled") or not endpoint:
+            return False
+        last_attempt = float(settings.get("last_upload_attempt") or 0)
+        if now - last_attempt < UPLOAD_INTERVAL_SECONDS:
+            return False
+        payload = self.upload_payload()
+        if not payload["events"]:
+            return False
+
+        settings["last_upload_attempt"] = now
+        self._save_settings(settings)
+        request = urllib.request.Request(
+            endpoint,
+            data=json.dumps(payload).encode("utf-8"),
+            headers={"Content-Type": "application/json"},
+            method="POST",
+        )
+        try:
+            with urllib.request.urlopen(request, timeout=UPLOAD_TIMEOUT_SECONDS) as r:
+                if not 200 <= r.status < 300:
+                    return False
+        except Exception:
+            return False
+        self.clear()
+        return True
+
+    def _trim(self) -> None:
+        """Drop the oldest half of the events."""
+        events = self.events()
+        self._atomic_write(
+            self.events_file,
+            "".join(json.dumps(e) + "\n" for e in events[len(events) // 2 :]),
+        )
+
+    def _save_settings(self, settings: Dict[str, Any]) -> None:
+        self._atomic_write(self.settings_file, json.dumps(settings, indent=2))
+
+    def _atomic_write(self, path: Path, content: str) -> None:
+        self.directory.mkdir(parents=True, exist_ok=True)
+        fd, tmp_name = tempfile.mkstemp(dir=self.directory, suffix=".tmp")
+        try:
+            with os.fdopen(fd, "w", encoding="utf-8") as f:
+                f.write(content)
+            os.replace(tmp_name, path)
+        except BaseException:
+            Path(tmp_name).unlink(missing_ok=True)
+            raise
+
+
+class UsageRecorder:
+    """Times one cidx invocation and records it when telemetry is enabled."""
+
+    def __init__(self, telemetry: Optional[Telemetry] = None):
+        self.telemetry = telemetry or Telemetry()
+        self.enabled = self.telemetry.enabled
+        self.started = time.monotonic()
+        self.exception: Optional[BaseException] = None
+
+    def finish(self, command: Optional[str], exit_code: int) -> None:
+        """
+        Record the invocation. Never raises: telemetry must not break cidx.
+
+        Args:
+            command: Command name, see resolve_command_name(); None skips
+                recording (e.g. plain "cidx" or "--help")
+            exit_code: Exit code of the invocation
+        """
+        if not self.enabled or not command:
+            return
+        try:
+            self.telemetry.record(
+                command,
+                time.monotonic() - self.started,
+                error=error_category(exit_code, self.exception),
+            )
+            self.telemetry.maybe_upload()
+        except Exception:
+            pass
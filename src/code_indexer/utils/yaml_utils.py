@@ -25,10 +25,26 @@ DEFAULT_LANGUAGE_MAPPINGS = {
     "kotlin": ["kt", "kts"],
     "scala": ["scala"],
     "dart": ["dart"],
+    "objective-c": ["m"],
+    "objective-cpp": ["mm"],
+    "objc": ["m", "mm"],  # Alias for both Objective-C dialects
+    "julia": ["jl"],
+    "r": ["r", "R"],
+    "cobol": ["cbl", "cob", "cpy", "CBL", "COB", "CPY"],
+    "verilog": ["v", "vh"],
+    "systemverilog": ["sv", "svh"],
+    "vhdl": ["vhd", "vhdl"],
+    "zig": ["zig"],
+    "elixir": ["ex", "exs"],
+    "erlang": ["erl", "hrl"],
+    "protobuf": ["proto"],
     # Web technologies
     "html": ["html", "htm"],
     "css": ["css"],
     "vue": ["vue"],
+    "svelte": ["svelte"],
+    "erb": ["erb", "rhtml"],
+    "jinja": ["j2", "jinja", "jinja2"],
     # Markup and documentation
     "markdown": ["md", "markdown"],
     "xml": ["xml"],
@@ -41,8 +57,9 @@ DEFAULT_LANGUAGE_MAPPINGS = {
     "ini": ["ini"],
     "sql": ["sql"],
     # Shell and scripting
-    "shell": ["sh", "bash"],
+    "shell": ["sh", "bash", "zsh"],
     "bash": ["sh", "bash"],
+    "zsh": ["zsh"],
     "powershell": ["ps1", "psm1", "psd1"],
     "batch": ["bat", "cmd"],
     # Build and config files
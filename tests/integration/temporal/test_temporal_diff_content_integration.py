@@ -15,6 +15,7 @@ from src.code_indexer.services.temporal.temporal_indexer import TemporalIndexer
 from src.code_indexer.services.temporal.temporal_search_service import (
     TemporalSearchService,
 )
+from src.code_indexer.storage.chunk_compression import decompress_vector_data
 from src.code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
 from src.code_indexer.config import ConfigManager
 
@@ -115,7 +116,7 @@ class TestTemporalDiffContentIntegration:
             found_diff_content = False
             for vector_file in vector_files:
                 with open(vector_file) as f:
-                    data = json.load(f)
+                    data = decompress_vector_data(json.load(f))
                     if "chunk_text" in data:
                         # Found diff content!
                         chunk_text = data["chunk_text"]
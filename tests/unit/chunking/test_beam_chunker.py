@@ -0,0 +1,200 @@
+"""
+Unit tests for declaration-aware chunking of Elixir and Erlang files.
+
+Tests finding Elixir modules, protocols, implementations and functions past
+heredocs, sigils and character literals, Erlang functions and their specs,
+keeping the clauses of a function together, and how FixedSizeChunker
+applies it to .ex and .erl files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.beam_chunker import chunk_beam, is_beam
+from code_indexer.indexing.boundary_chunking import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+
+ELIXIR = '''defmodule MyApp.Accounts do
+  @moduledoc """
+  User accounts. Use `get_user/1` do
+  """
+  alias MyApp.Repo
+
+  defstruct [:id, :name]
+
+  @doc """
+  Fetches a user; the "end" of it.
+  """
+  @spec get_user(integer()) :: map() | nil
+  def get_user(id) when is_integer(id) do
+    Repo.get(User, id)
+  end
+
+  def valid?(%{name: name}), do: name != ""
+
+  # Pattern-matched clauses
+  def handle(:ping, state), do: {:pong, state}
+  def handle({:put, key, value}, state) do
+    Enum.reduce([key], state, fn k, acc ->
+      Map.put(acc, k, value)
+    end)
+  end
+
+  defp format(name) do
+    ~s(#{name} end) <> "do" <> <<?e, ?n, ?d>>
+  end
+
+  defmacro unless_nil(value, do: block) do
+    quote do
+      if unquote(value) != nil, do: unquote(block)
+    end
+  end
+
+  defmodule Session do
+    def new, do: %{}
+  end
+end
+
+defprotocol MyApp.Size do
+  def size(data)
+end
+
+defimpl MyApp.Size, for: Map do
+  def size(map), do: map_size(map)
+end
+'''
+
+ERLANG = """%% Counter server
+-module(counter).
+-behaviour(gen_server).
+-export([start_link/0, init/1, handle_call/3]).
+
+-record(state, {count = 0 :: integer()}).
+
+start_link() ->
+    gen_server:start_link({local, ?MODULE}, ?MODULE, [], []).
+
+%% Starts at zero
+-spec init([]) -> {ok, #state{}}.
+init([]) ->
+    {ok, #state{}}.
+
+handle_call(increment, _From,
+            #state{count = Count} = State) ->
+    {reply, ok, State#state{count = Count + 1}};
+handle_call(get, _From, State) ->
+    Msg = "get, a, b",
+    {reply, State#state.count, State}.
+
+-define(TIMEOUT, 5000).
+'quoted name'(X) -> X.
+"""
+
+
+def symbols_of(chunks):
+    return [(c[SYMBOL_KIND_KEY], c.get(SYMBOL_NAME_KEY)) for c in chunks]
+
+
+class TestChunkElixir:
+    """Tests for splitting Elixir files into modules and functions."""
+
+    def test_detection(self):
+        assert is_beam("ex")
+        assert is_beam("exs")
+        assert is_beam("erl")
+        assert not is_beam("e")
+
+    def test_declarations(self):
+        chunks = chunk_beam(ELIXIR, "ex", 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("module", "MyApp.Accounts"),
+            ("function", "MyApp.Accounts.get_user/1"),
+            ("function", "MyApp.Accounts.valid?/1"),
+            ("function", "MyApp.Accounts.handle/2"),
+            ("function", "MyApp.Accounts.format/1"),
+            ("macro", "MyApp.Accounts.unless_nil/2"),
+            ("module", "MyApp.Accounts.Session"),
+            ("function", "MyApp.Accounts.Session.new/0"),
+            ("protocol", "MyApp.Size"),
+            ("function", "MyApp.Size.size/1"),
+            ("impl", "MyApp.Size for Map"),
+            ("function", "MyApp.Size.Map.size/1"),
+        ]
+        assert "".join(c["text"] for c in chunks) == ELIXIR
+
+    def test_attributes_and_comments_belong_to_the_function(self):
+        chunks = chunk_beam(ELIXIR, "ex", 2000, 300)
+
+        assert "@moduledoc" in chunks[0]["text"]
+        assert chunks[1]["text"].startswith('  @doc """\n')
+        assert (chunks[1]["line_start"], chunks[1]["line_end"]) == (9, 15)
+        assert chunks[3]["text"].startswith("  # Pattern-matched clauses\n")
+
+    def test_clauses_of_a_function_are_one_chunk(self):
+        chunks = chunk_beam(ELIXIR, "ex", 2000, 300)
+
+        handle = chunks[3]
+        assert (handle["line_start"], handle["line_end"]) == (19, 25)
+        assert "def handle(:ping, state)" in handle["text"]
+        assert "def handle({:put, key, value}, state) do" in handle["text"]
+
+    def test_strings_sigils_and_characters_do_not_end_blocks(self):
+        chunks = chunk_beam(ELIXIR, "ex", 2000, 300)
+
+        assert (chunks[4]["line_start"], chunks[4]["line_end"]) == (27, 29)
+        assert (chunks[5]["line_start"], chunks[5]["line_end"]) == (31, 35)
+
+
+class TestChunkErlang:
+    """Tests for splitting Erlang files into functions."""
+
+    def test_functions(self):
+        chunks = chunk_beam(ERLANG, "erl", 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("module", "counter"),
+            ("function", "counter:start_link/0"),
+            ("function", "counter:init/1"),
+            ("function", "counter:handle_call/3"),
+            ("module", "counter"),
+            ("function", "counter:'quoted name'/1"),
+        ]
+        assert "".join(c["text"] for c in chunks) == ERLANG
+
+    def test_specs_comments_and_clauses_belong_to_the_function(self):
+        chunks = chunk_beam(ERLANG, "erl", 2000, 300)
+
+        assert chunks[2]["text"].startswith("%% Starts at zero\n-spec init([])")
+        handle_call = chunks[3]
+        assert (handle_call["line_start"], handle_call["line_end"]) == (16, 21)
+        assert "handle_call(get, _From, State) ->" in handle_call["text"]
+
+    def test_file_without_module_attribute(self):
+        chunks = chunk_beam("-include(\"a.hrl\").\n\nf(X) -> X.\n", "hrl", 2000, 300)
+
+        assert symbols_of(chunks) == [("file", None), ("function", "f/1")]
+
+    def test_long_function_is_windowed(self):
+        body = "".join(f"    X{i} = X + {i},\n" for i in range(40))
+        text = f"accumulate(X) ->\n{body}    X.\n"
+
+        chunks = chunk_beam(text, "erl", 200, 50)
+
+        assert len(chunks) > 1
+        assert all(c[SYMBOL_NAME_KEY] == "accumulate/1" for c in chunks)
+        assert chunks[-1]["line_end"] == text.count("\n")
+
+
+class TestFixedSizeChunkerBeam:
+    """Tests for Elixir and Erlang files in FixedSizeChunker."""
+
+    def test_elixir_and_erlang_files_are_chunked_by_function(self, tmp_path):
+        (tmp_path / "accounts.ex").write_text(ELIXIR)
+        (tmp_path / "counter.erl").write_text(ERLANG)
+        chunker = FixedSizeChunker(IndexingConfig())
+
+        elixir = chunker.chunk_file(tmp_path / "accounts.ex")
+        erlang = chunker.chunk_file(tmp_path / "counter.erl")
+
+        assert elixir[1][SYMBOL_NAME_KEY] == "MyApp.Accounts.get_user/1"
+        assert erlang[3][SYMBOL_NAME_KEY] == "counter:handle_call/3"
+        assert erlang[0]["file_extension"] == "erl"
@@ -0,0 +1,147 @@
+"""
+Unit tests for the windowing shared by the structure-aware chunkers.
+
+Tests dropping and merging segments, cutting a file at its segment starts,
+splitting long segments into overlapping windows, and the line helpers.
+"""
+
+from code_indexer.indexing.boundary_chunking import (
+    chunk_segments,
+    keep_segments,
+    leading_comments,
+    line_offsets,
+    split_segment,
+)
+
+TEXT = "# header\n\ndef a():\n    pass\n\n# about b\ndef b():\n    pass\n"
+
+
+class TestLineHelpers:
+    """Tests for line_offsets() and leading_comments()."""
+
+    def test_line_offsets(self):
+        assert line_offsets("ab\ncd\n") == [0, 3, 6]
+        assert line_offsets("") == [0]
+
+    def test_leading_comments_stop_at_code_and_floor(self):
+        lines = TEXT.split("\n")
+
+        def is_comment(line: str) -> bool:
+            return line.startswith("#")
+
+        assert leading_comments(lines, 6, 3, is_comment) == 5
+        assert leading_comments(lines, 2, 0, is_comment) == 2
+        assert leading_comments(lines, 1, -1, is_comment) == 0
+
+
+class TestKeepSegments:
+    """Tests for keep_segments()."""
+
+    def test_first_segment_starts_at_the_top(self):
+        a, b = {"name": "a"}, {"name": "b"}
+
+        kept = keep_segments([(2, a), (6, b)], 9, lambda *_: False, {})
+
+        assert kept == [(0, a), (6, b)]
+
+    def test_filler_segments_join_the_segment_before_them(self):
+        a, closing, b = {"name": "a"}, {"name": "closing"}, {"name": "b"}
+
+        kept = keep_segments(
+            [(0, a), (4, closing), (6, b)],
+            9,
+            lambda first, end, payload: payload is closing,
+            {},
+        )
+
+        assert kept == [(0, a), (6, b)]
+
+    def test_segment_continuing_a_payload_is_merged(self):
+        top = {"kind": "top_level"}
+
+        kept = keep_segments([(0, top), (3, top)], 5, lambda *_: False, {})
+
+        assert kept == [(0, top)]
+
+    def test_empty_segments_are_dropped(self):
+        a, b, c = {"name": "a"}, {"name": "b"}, {"name": "c"}
+
+        kept = keep_segments([(0, a), (3, b), (3, c)], 5, lambda *_: False, {})
+
+        assert kept == [(0, a), (3, c)]
+
+    def test_file_without_segments_is_top_level(self):
+        top = {"kind": "top_level"}
+
+        assert keep_segments([], 3, lambda *_: False, top) == [(0, top)]
+
+
+class TestChunkSegments:
+    """Tests for chunk_segments()."""
+
+    def test_chunks_cover_the_file(self):
+        segments = [(0, {"name": "a"}), (5, {"name": "b"})]
+
+        chunks = chunk_segments(TEXT, segments, 2000, 300)
+
+        assert "".join(c["text"] for c in chunks) == TEXT
+        assert [(c["line_start"], c["line_end"], c["name"]) for c in chunks] == [
+            (1, 4, "a"),
+            (6, 8, "b"),
+        ]
+
+    def test_whitespace_segments_make_no_chunk(self):
+        text = "a\n\n\nb\n"
+
+        chunks = chunk_segments(text, [(0, {}), (1, {}), (3, {})], 2000, 300)
+
+        assert [c["text"] for c in chunks] == ["a\n", "b\n"]
+
+    def test_segment_past_the_last_line_makes_no_chunk(self):
+        chunks = chunk_segments("a\n", [(0, {}), (5, {})], 2000, 300)
+
+        assert [c["text"] for c in chunks] == ["a\n"]
+
+    def test_annotate_sees_every_window(self):
+        seen = []
+
+        def annotate(chunk, payload):
+            seen.append(payload["name"])
+            chunk["length"] = len(chunk["text"])
+
+        chunks = chunk_segments(TEXT, [(0, {"name": "a"})], 30, 10, annotate)
+
+        assert len(chunks) > 1
+        assert seen == ["a"] * len(chunks)
+        assert all(c["length"] == len(c["text"]) for c in chunks)
+
+
+class TestSplitSegment:
+    """Tests for split_segment()."""
+
+    def test_short_segment_is_one_chunk(self):
+        chunks = split_segment("a\nb\n\n", 3, {"name": "a"}, 2000, 300)
+
+        assert chunks == [
+            {"text": "a\nb\n\n", "line_start": 3, "line_end": 4, "name": "a"}
+        ]
+
+    def test_long_segment_is_windowed(self):
+        segment = "".join(f"line {i:02d}\n" for i in range(20))
+
+        chunks = split_segment(segment, 1, {}, 50, 10)
+
+        assert [c["text"] for c in chunks] == [
+            segment[start : start + 50] for start in range(0, len(segment) - 10, 40)
+        ]
+        assert chunks[1]["line_start"] == segment[:40].count("\n") + 1
+        assert chunks[-1]["line_end"] == 20
+
+    def test_windows_get_their_own_lists(self):
+        path = ["Guide", "Install"]
+
+        chunks = split_segment("x" * 100, 1, {"path": path}, 40, 10)
+
+        chunks[0]["path"].append("changed")
+        assert all(c["path"] == ["Guide", "Install"] for c in chunks[1:])
+        assert path == ["Guide", "Install"]
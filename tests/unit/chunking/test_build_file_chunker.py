@@ -0,0 +1,247 @@
+"""
+Unit tests for block-aware chunking of Gradle, Maven and CMake build files.
+
+Tests build system detection, splitting Gradle scripts, pom.xml files and
+CMake files into blocks with their names, dependency coordinates and
+plugins, and how FixedSizeChunker applies it to files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.build_file_chunker import (
+    BUILD_BLOCK_KEY,
+    BUILD_COORDINATES_KEY,
+    BUILD_NAME_KEY,
+    BUILD_PLUGINS_KEY,
+    BUILD_SYSTEM_KEY,
+    build_system,
+    chunk_build_file,
+    cmake_commands,
+)
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+
+GRADLE = """// Build of the API service
+plugins {
+    java
+    `java-library`
+    id("org.springframework.boot") version "3.2.0"
+    id("io.spring.dependency-management") version "1.1.4"
+}
+
+group = "com.acme"
+version = "1.0.0"
+
+repositories { mavenCentral() }
+
+dependencies {
+    implementation("org.springframework.boot:spring-boot-starter-web")
+    implementation(platform("org.junit:junit-bom:5.10.0"))
+    runtimeOnly(group = "org.postgresql", name = "postgresql", version = "42.7.1")
+    // not "a:b:c" { brace
+    testImplementation("org.junit.jupiter:junit-jupiter:5.10.0")
+}
+
+/* Integration tests */
+tasks.register<Test>("integrationTest") {
+    description = "Runs } integration tests"
+    dependsOn("app:build")
+}
+
+tasks.named("test") { useJUnitPlatform() }
+"""
+
+POM = """<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <modelVersion>4.0.0</modelVersion>
+  <groupId>com.acme</groupId>
+  <artifactId>api</artifactId>
+  <version>1.0.0</version>
+
+  <properties>
+    <spring.version>6.1.2</spring.version>
+  </properties>
+
+  <!-- Runtime dependencies -->
+  <dependencies>
+    <dependency>
+      <groupId>org.springframework</groupId>
+      <artifactId>spring-core</artifactId>
+      <version>${spring.version}</version>
+      <exclusions>
+        <exclusion>
+          <groupId>commons-logging</groupId>
+          <artifactId>commons-logging</artifactId>
+        </exclusion>
+      </exclusions>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+
+  <build>
+    <finalName>api</finalName>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <version>3.11.0</version>
+        <configuration>
+          <release>21</release>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+</project>
+"""
+
+CMAKE = """cmake_minimum_required(VERSION 3.20)
+project(acme CXX)
+
+find_package(Boost 1.70 REQUIRED COMPONENTS system)
+include(FetchContent)
+FetchContent_Declare(fmt
+  GIT_REPOSITORY https://github.com/fmtlib/fmt.git  # upstream
+  GIT_TAG 10.2.1)
+FetchContent_MakeAvailable(fmt)
+
+# The server binary
+add_executable(server
+  src/main.cpp  # entry point )
+  src/server.cpp)
+target_link_libraries(server PRIVATE fmt::fmt Boost::system)
+
+#[[ Helpers
+add_library(fake src/fake.cpp)
+]]
+function(add_acme_test name)
+  add_executable(${name} tests/${name}.cpp)
+  add_test(NAME ${name} COMMAND ${name})
+endfunction()
+
+if(BUILD_TESTING)
+  add_acme_test(server_test)
+endif()
+"""
+
+
+def blocks_of(chunks):
+    return [(c[BUILD_BLOCK_KEY], c.get(BUILD_NAME_KEY)) for c in chunks]
+
+
+class TestChunkBuildFile:
+    """Tests for splitting build files into blocks."""
+
+    def test_build_system(self):
+        assert build_system("gradle", "build.gradle") == "gradle"
+        assert build_system("kts", "build.gradle.kts") == "gradle"
+        assert build_system("kts", "Main.kts") is None
+        assert build_system("xml", "pom.xml") == "maven"
+        assert build_system("xml", "web.xml") is None
+        assert build_system("cmake", "CMakeLists.txt") == "cmake"
+
+    def test_gradle(self):
+        chunks = chunk_build_file(GRADLE, "gradle", 1000, 150)
+
+        assert blocks_of(chunks) == [
+            ("plugins", None),
+            ("project", None),
+            ("repositories", None),
+            ("dependencies", None),
+            ("task", "integrationTest"),
+            ("task", "test"),
+        ]
+        assert chunks[0][BUILD_PLUGINS_KEY] == [
+            "java",
+            "java-library",
+            "org.springframework.boot:3.2.0",
+            "io.spring.dependency-management:1.1.4",
+        ]
+        assert chunks[3][BUILD_COORDINATES_KEY] == [
+            "org.springframework.boot:spring-boot-starter-web",
+            "org.junit:junit-bom:5.10.0",
+            "org.postgresql:postgresql:42.7.1",
+            "org.junit.jupiter:junit-jupiter:5.10.0",
+        ]
+        # Braces in strings and comments do not end blocks
+        assert chunks[4]["text"].startswith("/* Integration tests */\n")
+        assert (chunks[4]["line_start"], chunks[4]["line_end"]) == (22, 26)
+        assert BUILD_COORDINATES_KEY not in chunks[4]
+        assert "".join(c["text"] for c in chunks) == GRADLE
+
+    def test_maven(self):
+        chunks = chunk_build_file(POM, "maven", 1000, 150)
+
+        assert blocks_of(chunks) == [
+            ("project", None),
+            ("properties", None),
+            ("dependencies", None),
+            ("build", None),
+            ("plugins", None),
+        ]
+        assert chunks[2]["text"].startswith("  <!-- Runtime dependencies -->\n")
+        # Exclusions are not dependencies; versions are kept as written
+        assert chunks[2][BUILD_COORDINATES_KEY] == [
+            "org.springframework:spring-core:${spring.version}",
+            "junit:junit",
+        ]
+        assert chunks[4][BUILD_PLUGINS_KEY] == [
+            "org.apache.maven.plugins:maven-compiler-plugin:3.11.0"
+        ]
+        assert chunks[4]["text"].endswith("</project>\n")
+        assert "".join(c["text"] for c in chunks) == POM
+
+    def test_cmake_commands(self):
+        commands = cmake_commands(CMAKE)
+
+        names = [name for name, _, _, _ in commands]
+        # Bracket comments are skipped
+        assert "add_library" not in names
+        name, arguments, first_line, last_line = commands[6]
+        assert name == "add_executable"
+        assert arguments == ["server", "src/main.cpp", "src/server.cpp"]
+        assert (first_line, last_line) == (11, 13)
+
+    def test_cmake(self):
+        chunks = chunk_build_file(CMAKE, "cmake", 1000, 150)
+
+        assert blocks_of(chunks) == [
+            ("project", None),
+            ("dependencies", None),
+            ("target", "server"),
+            ("function", "add_acme_test"),
+            ("project", None),
+        ]
+        assert chunks[1][BUILD_COORDINATES_KEY] == ["Boost:1.70", "fmt:10.2.1"]
+        assert "target_link_libraries" in chunks[2]["text"]
+        assert chunks[2]["text"].startswith("# The server binary\n")
+        assert "".join(c["text"] for c in chunks) == CMAKE
+
+    def test_windows_record_their_own_coordinates(self):
+        lines = "".join(
+            f'    implementation("com.acme:lib{i}:1.0")\n' for i in range(20)
+        )
+        text = f"dependencies {{\n{lines}}}\n"
+
+        chunks = chunk_build_file(text, "gradle", 300, 50)
+
+        assert len(chunks) > 1
+        assert chunks[0][BUILD_COORDINATES_KEY][0] == "com.acme:lib0:1.0"
+        assert "com.acme:lib0:1.0" not in chunks[-1][BUILD_COORDINATES_KEY]
+
+
+class TestFixedSizeChunkerBuildFiles:
+    """Tests for build files in FixedSizeChunker."""
+
+    def test_build_files_are_chunked_by_block(self, tmp_path):
+        (tmp_path / "CMakeLists.txt").write_text(CMAKE)
+        (tmp_path / "pom.xml").write_text(POM)
+
+        chunker = FixedSizeChunker(IndexingConfig())
+        cmake_chunks = chunker.chunk_file(tmp_path / "CMakeLists.txt")
+        pom_chunks = chunker.chunk_file(tmp_path / "pom.xml")
+
+        assert [c["chunk_index"] for c in cmake_chunks] == [0, 1, 2, 3, 4]
+        assert cmake_chunks[2]["file_extension"] == "cmake"
+        assert pom_chunks[2][BUILD_SYSTEM_KEY] == "maven"
+        assert pom_chunks[2]["file_extension"] == "xml"
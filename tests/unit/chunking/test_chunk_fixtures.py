@@ -0,0 +1,98 @@
+"""
+Golden tests for chunking of the fixtures in tests/ast_test_cases.
+
+Every fixture source has a <file>.chunks.json golden file written by
+``cidx dev gen-fixtures``; these tests re-chunk each fixture and compare, and
+cover the generator itself.
+"""
+
+import json
+from pathlib import Path
+
+import pytest
+
+from code_indexer.indexing.chunk_fixtures import (
+    GOLDEN_SUFFIX,
+    build_golden,
+    generate_fixture,
+    golden_path,
+)
+
+FIXTURES_DIR = Path(__file__).parents[2] / "ast_test_cases"
+GOLDEN_FILES = sorted(FIXTURES_DIR.rglob(f"*{GOLDEN_SUFFIX}"))
+
+
+def test_every_fixture_has_a_golden_file():
+    sources = [
+        p
+        for p in FIXTURES_DIR.rglob("*")
+        if p.is_file() and not p.name.endswith(GOLDEN_SUFFIX)
+    ]
+
+    assert sources
+    assert [p for p in sources if not golden_path(p).exists()] == []
+
+
+@pytest.mark.parametrize(
+    "golden_file", GOLDEN_FILES, ids=lambda p: str(p.relative_to(FIXTURES_DIR))
+)
+def test_chunks_match_golden_file(golden_file):
+    expected = json.loads(golden_file.read_text())
+    source = golden_file.with_name(expected["source"])
+
+    actual = build_golden(source, expected["language"], expected["chunk_size"])
+
+    assert actual == expected, (
+        f"Chunking of {source.name} changed - if intended, regenerate with "
+        f"'cidx dev gen-fixtures {expected['language']} {source}'"
+    )
+
+
+class TestGenerateFixture:
+    """Tests for generate_fixture()."""
+
+    def test_copies_source_and_writes_golden(self, tmp_path):
+        source = tmp_path / "lib.rs"
+        source.write_text("fn main() {\n" + '    println!("hi");\n' * 20 + "}\n")
+
+        golden_file = generate_fixture(
+            source, "rust", fixtures_dir=tmp_path / "cases", category="functions"
+        )
+
+        fixture = tmp_path / "cases" / "rust" / "functions" / "lib.rs"
+        assert fixture.read_text() == source.read_text()
+        assert golden_file == golden_path(fixture.resolve())
+        golden = json.loads(golden_file.read_text())
+        assert golden["language"] == "rust"
+        assert len(golden["chunks"]) > 1
+        assert golden["chunks"][-1]["text"].endswith("}\n")
+
+    def test_regenerates_fixture_in_place(self, tmp_path):
+        fixture = tmp_path / "cases" / "python" / "classes" / "a.py"
+        fixture.parent.mkdir(parents=True)
+        fixture.write_text("class A:\n    pass\n")
+
+        golden_file = generate_fixture(
+            fixture, "python", fixtures_dir=tmp_path / "cases"
+        )
+
+        assert golden_file.parent == fixture.parent.resolve()
+        assert not (tmp_path / "cases" / "python" / "general").exists()
+
+    def test_rejects_file_of_another_language(self, tmp_path):
+        source = tmp_path / "main.go"
+        source.write_text("package main\n")
+
+        with pytest.raises(ValueError, match="not a python file"):
+            generate_fixture(source, "python", fixtures_dir=tmp_path / "cases")
+
+    def test_does_not_overwrite_different_fixture(self, tmp_path):
+        existing = tmp_path / "cases" / "python" / "general" / "a.py"
+        existing.parent.mkdir(parents=True)
+        existing.write_text("x = 1\n")
+        source = tmp_path / "a.py"
+        source.write_text("x = 2\n")
+
+        with pytest.raises(ValueError, match="already exists"):
+            generate_fixture(source, "python", fixtures_dir=tmp_path / "cases")
+        assert existing.read_text() == "x = 1\n"
@@ -0,0 +1,384 @@
+"""
+Unit tests for routing files to the structure-aware chunkers.
+
+Tests that CHUNKER_ROUTES covers the languages of every chunker and every
+indexing flag, that each chunker applies to its files only while its flag
+is on, that broken code is windowed without losing the declarations around
+it, and that a chunker's module is only imported once it is needed.
+"""
+
+import json
+import subprocess
+import sys
+
+import pytest
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.beam_chunker import ELIXIR_LANGUAGES, ERLANG_LANGUAGES
+from code_indexer.indexing.boundary_chunking import DEGRADED_KEY, SYMBOL_NAME_KEY
+from code_indexer.indexing.build_file_chunker import (
+    BUILD_BLOCK_KEY,
+    CMAKE_LANGUAGES,
+    GRADLE_LANGUAGES,
+)
+from code_indexer.indexing.cobol_chunker import COBOL_DIVISION_KEY, COBOL_LANGUAGES
+from code_indexer.indexing.component_chunker import (
+    COMPONENT_BLOCK_KEY,
+    SVELTE_LANGUAGES,
+    VUE_LANGUAGES,
+)
+from code_indexer.indexing.container_chunker import (
+    COMPOSE_LANGUAGES,
+    DOCKER_STAGE_KEY,
+    DOCKERFILE_LANGUAGES,
+)
+from code_indexer.indexing.dart_chunker import DART_LANGUAGES, SYMBOL_KIND_KEY
+from code_indexer.indexing.document_chunker import (
+    DOCUMENT_LANGUAGES,
+    HEADING_PATH_KEY,
+)
+from code_indexer.indexing.fixed_size_chunker import (
+    CHUNKER_FLAGS,
+    CHUNKER_ROUTES,
+    FixedSizeChunker,
+)
+from code_indexer.indexing.hdl_chunker import (
+    HDL_UNIT_KEY,
+    VERILOG_LANGUAGES,
+    VHDL_LANGUAGES,
+)
+from code_indexer.indexing.julia_chunker import JULIA_LANGUAGES
+from code_indexer.indexing.kubernetes_chunker import K8S_KIND_KEY, KUBERNETES_LANGUAGES
+from code_indexer.indexing.objc_chunker import OBJC_LANGUAGES
+from code_indexer.indexing.openapi_chunker import OPENAPI_KIND_KEY, OPENAPI_LANGUAGES
+from code_indexer.indexing.proto_chunker import PROTO_KIND_KEY, PROTO_LANGUAGES
+from code_indexer.indexing.r_chunker import R_LANGUAGES
+from code_indexer.indexing.rust_chunker import RUST_LANGUAGES
+from code_indexer.indexing.scala_chunker import SCALA_LANGUAGES
+from code_indexer.indexing.shell_chunker import SHELL_BLOCK_KEY, SHELL_LANGUAGES
+from code_indexer.indexing.zig_chunker import ZIG_LANGUAGES
+
+COBOL = """       IDENTIFICATION DIVISION.
+       PROGRAM-ID. HELLO.
+       PROCEDURE DIVISION.
+       MAIN-PARA.
+           DISPLAY "HELLO".
+           STOP RUN.
+"""
+
+OPENAPI = """openapi: 3.0.3
+info:
+  title: Users
+  version: "1.0"
+paths:
+  /users:
+    get:
+      responses:
+        "200":
+          description: OK
+"""
+
+# Prints the chunker modules imported after chunking a Dart file
+IMPORTED_CHUNKERS = """
+import json, sys, tempfile
+from pathlib import Path
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+
+def chunkers():
+    return sorted(
+        name.rpartition(".")[2]
+        for name in sys.modules
+        if name.startswith("code_indexer.indexing.") and name.endswith("_chunker")
+    )
+
+imported = [chunkers()]
+chunker = FixedSizeChunker(IndexingConfig(embedded_chunking=False))
+with tempfile.TemporaryDirectory() as tmp:
+    path = Path(tmp) / "counter.dart"
+    path.write_text("class Counter {}\\n")
+    chunker.chunk_file(path)
+imported.append(chunkers())
+print(json.dumps(imported))
+"""
+
+# Prints the chunker modules imported with the chunking pipeline and the
+# services that read chunker payload fields
+MANAGER_CHUNKERS = """
+import json, sys
+import code_indexer.services.file_chunking_manager
+import code_indexer.services.grpc_stubs
+import code_indexer.services.pii_scrubber
+
+print(json.dumps(sorted(
+    name.rpartition(".")[2]
+    for name in sys.modules
+    if name.startswith("code_indexer.indexing.") and name.endswith("_chunker")
+)))
+"""
+
+# (flag, file name, text, chunk field set by the flag's chunker)
+FILES = [
+    ("document_chunking", "guide.md", "# Guide\n\nIntro\n", HEADING_PATH_KEY),
+    (
+        "proto_chunking",
+        "user.proto",
+        'syntax = "proto3";\n\nmessage User {\n  string id = 1;\n}\n',
+        PROTO_KIND_KEY,
+    ),
+    ("openapi_chunking", "openapi.yaml", OPENAPI, OPENAPI_KIND_KEY),
+    (
+        "container_chunking",
+        "Dockerfile",
+        "FROM alpine AS build\nRUN make\n\nFROM alpine\nCOPY --from=build /a /a\n",
+        DOCKER_STAGE_KEY,
+    ),
+    (
+        "kubernetes_chunking",
+        "redis.yaml",
+        "apiVersion: v1\nkind: Service\nmetadata:\n  name: redis\n",
+        K8S_KIND_KEY,
+    ),
+    (
+        "component_chunking",
+        "Page.vue",
+        "<template>\n  <div/>\n</template>\n\n<script>\nexport default {}\n</script>\n",
+        COMPONENT_BLOCK_KEY,
+    ),
+    ("shell_chunking", "deploy.sh", "deploy() {\n  echo hi\n}\n", SHELL_BLOCK_KEY),
+    (
+        "build_file_chunking",
+        "build.gradle",
+        "plugins {\n  id 'java'\n}\n",
+        BUILD_BLOCK_KEY,
+    ),
+    (
+        "dart_chunking",
+        "counter.dart",
+        "class Counter {\n  int count = 0;\n}\n",
+        SYMBOL_KIND_KEY,
+    ),
+    (
+        "objc_chunking",
+        "Store.m",
+        "@implementation Store\n- (void)save {\n}\n@end\n",
+        SYMBOL_KIND_KEY,
+    ),
+    (
+        "julia_chunking",
+        "solve.jl",
+        "function solve(x)\n    x + 1\nend\n",
+        SYMBOL_KIND_KEY,
+    ),
+    (
+        "r_chunking",
+        "shapes.R",
+        "area <- function(r) {\n  pi * r^2\n}\n",
+        SYMBOL_KIND_KEY,
+    ),
+    ("cobol_chunking", "hello.cbl", COBOL, COBOL_DIVISION_KEY),
+    (
+        "hdl_chunking",
+        "counter.v",
+        "module counter(input clk);\nendmodule\n",
+        HDL_UNIT_KEY,
+    ),
+    (
+        "rust_chunking",
+        "point.rs",
+        "struct Point {\n    x: i32,\n}\n",
+        SYMBOL_KIND_KEY,
+    ),
+    (
+        "zig_chunking",
+        "point.zig",
+        "pub fn main() void {\n    run();\n}\n",
+        SYMBOL_KIND_KEY,
+    ),
+    (
+        "beam_chunking",
+        "greeter.ex",
+        "defmodule Greeter do\n  def hello, do: :world\nend\n",
+        SYMBOL_KIND_KEY,
+    ),
+    (
+        "scala_chunking",
+        "Greeter.scala",
+        "object Greeter:\n  def hello: String = \"world\"\n",
+        SYMBOL_KIND_KEY,
+    ),
+]
+
+# (file name, text whose "broken" declaration never ends, name of the
+# declaration after it)
+BROKEN_FILES = [
+    (
+        "lib.rs",
+        "fn ok() {\n}\n\nfn broken() {\n    if x {\n\nfn after() {\n}\n",
+        "after",
+    ),
+    (
+        "lib.zig",
+        "fn ok() void {\n}\n\nfn broken() void {\n    if (x) {\n\n"
+        "fn after() void {\n}\n",
+        "after",
+    ),
+    (
+        "lib.dart",
+        "void ok() {\n}\n\nvoid broken() {\n  if (x) {\n\nvoid after() {\n}\n",
+        "after",
+    ),
+    (
+        "lib.m",
+        "void ok() {\n}\n\nvoid broken() {\n  if (x) {\n\nvoid after() {\n}\n",
+        "after",
+    ),
+    (
+        "lib.jl",
+        "function ok()\nend\n\nfunction broken()\n    if x\n\n"
+        "function after()\nend\n",
+        "after",
+    ),
+    (
+        "lib.R",
+        "ok <- function() {\n}\n\nbroken <- function() {\n  if (x) {\n\n"
+        "after <- function() {\n}\n",
+        "after",
+    ),
+    (
+        "lib.ex",
+        "defmodule A do\n  def ok, do: 1\n\n  def broken do\n    if x do\n\n"
+        "  def later, do: 2\nend\n\ndefmodule B do\n  def after, do: 3\nend\n",
+        "B.after/0",
+    ),
+]
+
+
+class TestChunkerRoutes:
+    """Tests for CHUNKER_ROUTES."""
+
+    def test_routes_cover_the_languages_of_each_chunker(self):
+        routed = {}
+        for language, routes in CHUNKER_ROUTES.items():
+            for route in routes:
+                routed.setdefault(route.flag, set()).add(language)
+
+        assert routed == {
+            "document_chunking": DOCUMENT_LANGUAGES,
+            "proto_chunking": PROTO_LANGUAGES,
+            "openapi_chunking": OPENAPI_LANGUAGES,
+            "container_chunking": DOCKERFILE_LANGUAGES | COMPOSE_LANGUAGES,
+            "kubernetes_chunking": KUBERNETES_LANGUAGES,
+            "component_chunking": VUE_LANGUAGES | SVELTE_LANGUAGES,
+            "shell_chunking": SHELL_LANGUAGES,
+            # build.gradle.kts and pom.xml are told apart by their file names
+            "build_file_chunking": GRADLE_LANGUAGES | CMAKE_LANGUAGES | {"kts", "xml"},
+            "dart_chunking": DART_LANGUAGES,
+            "objc_chunking": OBJC_LANGUAGES,
+            "julia_chunking": JULIA_LANGUAGES,
+            "r_chunking": R_LANGUAGES,
+            "cobol_chunking": COBOL_LANGUAGES,
+            "hdl_chunking": VERILOG_LANGUAGES | VHDL_LANGUAGES,
+            "rust_chunking": RUST_LANGUAGES,
+            "zig_chunking": ZIG_LANGUAGES,
+            "beam_chunking": ELIXIR_LANGUAGES | ERLANG_LANGUAGES,
+            "scala_chunking": SCALA_LANGUAGES,
+        }
+
+    def test_every_chunking_flag_is_routed(self):
+        flags = {
+            name
+            for name, field in IndexingConfig.model_fields.items()
+            if name.endswith("_chunking") and field.annotation is bool
+        }
+
+        assert flags == set(CHUNKER_FLAGS) | {"embedded_chunking"}
+
+    def test_yaml_routes_keep_their_order(self):
+        assert [route.flag for route in CHUNKER_ROUTES["yaml"]] == [
+            "openapi_chunking",
+            "container_chunking",
+            "kubernetes_chunking",
+        ]
+
+    @pytest.mark.parametrize("flag, file_name, text, key", FILES)
+    def test_chunker_applies_while_its_flag_is_on(
+        self, tmp_path, flag, file_name, text, key
+    ):
+        (tmp_path / file_name).write_text(text)
+
+        config = IndexingConfig(embedded_chunking=False)
+        chunks = FixedSizeChunker(config).chunk_file(tmp_path / file_name)
+
+        assert chunks and all(key in chunk for chunk in chunks)
+
+    @pytest.mark.parametrize("flag, file_name, text, key", FILES)
+    def test_chunking_can_be_disabled(self, tmp_path, flag, file_name, text, key):
+        (tmp_path / file_name).write_text(text)
+
+        config = IndexingConfig(**{flag: False, "embedded_chunking": False})
+        chunker = FixedSizeChunker(config)
+        chunks = chunker.chunk_file(tmp_path / file_name)
+
+        assert chunks == chunker.chunk_text(text, tmp_path / file_name)
+        assert all(key not in chunk for chunk in chunks)
+
+    def test_chunker_modules_are_imported_on_first_use(self):
+        result = subprocess.run(
+            [sys.executable, "-c", IMPORTED_CHUNKERS],
+            capture_output=True,
+            text=True,
+            timeout=60,
+            check=True,
+        )
+
+        before, after = json.loads(result.stdout)
+        assert before == ["fixed_size_chunker"]
+        assert after == ["dart_chunker", "fixed_size_chunker"]
+
+    def test_payload_keys_do_not_import_the_chunkers(self):
+        result = subprocess.run(
+            [sys.executable, "-c", MANAGER_CHUNKERS],
+            capture_output=True,
+            text=True,
+            timeout=60,
+            check=True,
+        )
+
+        assert json.loads(result.stdout) == ["fixed_size_chunker"]
+
+
+class TestBrokenCode:
+    """Tests for chunking files with syntax errors."""
+
+    @pytest.mark.parametrize("file_name, text, after", BROKEN_FILES)
+    def test_declarations_around_broken_code_keep_their_chunks(
+        self, tmp_path, file_name, text, after
+    ):
+        (tmp_path / file_name).write_text(text)
+
+        config = IndexingConfig(embedded_chunking=False)
+        chunks = FixedSizeChunker(config).chunk_file(tmp_path / file_name)
+
+        degraded = [chunk for chunk in chunks if chunk.get(DEGRADED_KEY)]
+        assert degraded and all("broken" in chunk["text"] for chunk in degraded)
+        assert degraded[0][SYMBOL_NAME_KEY].rpartition(".")[2].startswith("broken")
+        by_name = {chunk.get(SYMBOL_NAME_KEY): chunk for chunk in chunks}
+        assert after in by_name and not by_name[after].get(DEGRADED_KEY)
+        assert by_name[after]["line_start"] == text.count("\n") - 1
+        assert not chunks[0].get(DEGRADED_KEY)
+
+    def test_failing_chunker_leaves_degraded_windows(self, tmp_path, monkeypatch):
+        def fail(text, chunk_size, overlap_size):
+            raise ValueError("unexpected token")
+
+        monkeypatch.setattr("code_indexer.indexing.rust_chunker.chunk_rust", fail)
+        text = "fn main() {\n    run();\n}\n"
+        (tmp_path / "main.rs").write_text(text)
+
+        config = IndexingConfig(embedded_chunking=False)
+        chunks = FixedSizeChunker(config).chunk_file(tmp_path / "main.rs")
+
+        assert [chunk["text"] for chunk in chunks] == [text]
+        assert chunks[0][DEGRADED_KEY] is True
+        assert (chunks[0]["line_start"], chunks[0]["line_end"]) == (1, 3)
@@ -0,0 +1,155 @@
+"""
+Unit tests for structure-aware chunking of COBOL programs.
+
+Tests splitting fixed and free format programs at divisions, sections and
+paragraphs, the program and copybook fields, comment lines, and how
+FixedSizeChunker applies it to .cbl files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.cobol_chunker import (
+    COBOL_COPYBOOKS_KEY,
+    COBOL_DIVISION_KEY,
+    COBOL_PARAGRAPH_KEY,
+    COBOL_PROGRAM_KEY,
+    COBOL_SECTION_KEY,
+    chunk_cobol,
+    copy_members,
+    is_cobol,
+    is_fixed_format,
+)
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+
+PROGRAM = """000100 IDENTIFICATION DIVISION.
+000200 PROGRAM-ID. CUSTUPD.
+000300 AUTHOR. LEGACY TEAM.
+000400 ENVIRONMENT DIVISION.
+000500 INPUT-OUTPUT SECTION.
+000600 FILE-CONTROL.
+000700     SELECT CUST-FILE ASSIGN TO CUSTIN.
+000800 DATA DIVISION.
+000900 FILE SECTION.
+001000 FD  CUST-FILE.
+001100     COPY CUSTREC.
+001200 WORKING-STORAGE SECTION.
+001300 01  WS-EOF             PIC X VALUE 'N'.
+001400     EXEC SQL INCLUDE SQLCA END-EXEC.
+001500 PROCEDURE DIVISION.
+001600 0000-MAIN SECTION.
+001700*    Drive the update
+001800 0000-START.
+001900     PERFORM 1000-READ UNTIL WS-EOF = 'Y'
+002000     GOBACK.
+002100
+002200*    Read one customer
+002300 1000-READ.
+002400     READ CUST-FILE
+002500         AT END MOVE 'Y' TO WS-EOF
+002600     END-READ
+002700     COPY VALIDATE REPLACING ==:X:== BY ==CUST==.
+002800     EXIT.
+"""
+
+FREE_FORMAT = """>>SOURCE FORMAT FREE
+IDENTIFICATION DIVISION.
+PROGRAM-ID. report-totals.
+PROCEDURE DIVISION.
+*> Print the totals
+print-totals.
+    DISPLAY "TOTAL" *> not a paragraph.
+    EXIT.
+finish.
+    STOP RUN.
+"""
+
+
+def structure_of(chunks):
+    return [
+        (
+            c.get(COBOL_DIVISION_KEY),
+            c.get(COBOL_SECTION_KEY),
+            c.get(COBOL_PARAGRAPH_KEY),
+        )
+        for c in chunks
+    ]
+
+
+class TestChunkCobol:
+    """Tests for splitting COBOL programs into sections and paragraphs."""
+
+    def test_detection(self):
+        assert is_cobol("cbl")
+        assert is_cobol("CPY")
+        assert not is_cobol("c")
+        assert is_fixed_format(PROGRAM)
+        assert not is_fixed_format(FREE_FORMAT)
+
+    def test_divisions_sections_and_paragraphs(self):
+        chunks = chunk_cobol(PROGRAM, 2000, 300)
+
+        assert structure_of(chunks) == [
+            ("IDENTIFICATION", None, None),
+            ("ENVIRONMENT", "INPUT-OUTPUT", None),
+            ("DATA", "FILE", None),
+            ("DATA", "WORKING-STORAGE", None),
+            ("PROCEDURE", "0000-MAIN", "0000-START"),
+            ("PROCEDURE", "0000-MAIN", "1000-READ"),
+        ]
+        assert all(c[COBOL_PROGRAM_KEY] == "CUSTUPD" for c in chunks)
+        assert "".join(c["text"] for c in chunks) == PROGRAM
+
+    def test_comment_lines_belong_to_the_next_paragraph(self):
+        chunks = chunk_cobol(PROGRAM, 2000, 300)
+
+        assert chunks[5]["text"].startswith("002200*    Read one customer\n")
+        assert (chunks[5]["line_start"], chunks[5]["line_end"]) == (22, 28)
+
+    def test_copybooks(self):
+        chunks = chunk_cobol(PROGRAM, 2000, 300)
+
+        assert [c.get(COBOL_COPYBOOKS_KEY) for c in chunks] == [
+            None,
+            None,
+            ["CUSTREC"],
+            ["SQLCA"],
+            None,
+            ["VALIDATE"],
+        ]
+        assert copy_members("       COPY 'custrec.cpy' OF COPYLIB.\n") == ["CUSTREC"]
+
+    def test_free_format(self):
+        chunks = chunk_cobol(FREE_FORMAT, 2000, 300)
+
+        assert [c.get(COBOL_PARAGRAPH_KEY) for c in chunks] == [
+            None,
+            "PRINT-TOTALS",
+            "FINISH",
+        ]
+        assert chunks[0]["text"].startswith(">>SOURCE FORMAT FREE\n")
+        assert chunks[0][COBOL_PROGRAM_KEY] == "REPORT-TOTALS"
+        # The division header and comment are followed by the paragraph
+        assert chunks[1]["text"].startswith("PROCEDURE DIVISION.\n*> Print")
+
+    def test_long_paragraph_is_windowed(self):
+        body = "".join(f"           ADD {i} TO WS-TOTAL\n" for i in range(40))
+        text = f"       PROCEDURE DIVISION.\n       SUM-UP.\n{body}"
+
+        chunks = chunk_cobol(text, 300, 50)
+
+        assert len(chunks) > 1
+        assert all(c[COBOL_PARAGRAPH_KEY] == "SUM-UP" for c in chunks)
+        assert chunks[-1]["line_end"] == text.count("\n")
+
+
+class TestFixedSizeChunkerCobol:
+    """Tests for COBOL programs in FixedSizeChunker."""
+
+    def test_programs_are_chunked_by_paragraph(self, tmp_path):
+        (tmp_path / "CUSTUPD.CBL").write_text(PROGRAM)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(
+            tmp_path / "CUSTUPD.CBL"
+        )
+
+        assert [c["chunk_index"] for c in chunks] == [0, 1, 2, 3, 4, 5]
+        assert chunks[5][COBOL_PARAGRAPH_KEY] == "1000-READ"
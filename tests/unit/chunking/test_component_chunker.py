@@ -0,0 +1,129 @@
+"""
+Unit tests for block-aware chunking of Vue and Svelte components.
+
+Tests component naming, finding top-level blocks, the chunks of Vue and
+Svelte files with their blocks and languages, and how FixedSizeChunker
+applies it to files.
+"""
+
+from pathlib import Path
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.component_chunker import (
+    COMPONENT_BLOCK_KEY,
+    COMPONENT_LANG_KEY,
+    COMPONENT_NAME_KEY,
+    chunk_component,
+    component_name,
+    find_blocks,
+)
+from code_indexer.indexing.document_chunker import HEADING_PATH_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+
+VUE = """<!-- Card showing a user -->
+<template>
+  <div class="card">
+    <template v-if="user">
+      <h2>{{ user.name }}</h2>
+    </template>
+  </div>
+</template>
+
+<script setup lang="ts">
+defineProps<{ user: User }>()
+</script>
+
+<style scoped lang="scss">
+.card { padding: 1rem; }
+</style>
+<i18n lang="json">
+{"en": {"hello": "Hello"}}
+</i18n>
+"""
+
+SVELTE = """<script context="module" lang="ts">
+  export const prerender = true;
+</script>
+
+<script>
+  export let data;
+</script>
+
+<h1>{data.title}</h1>
+<p>Hello</p>
+
+<!-- Styles -->
+<style>
+  h1 { color: red; }
+</style>
+<footer>bye</footer>
+"""
+
+
+def blocks_of(chunks):
+    return [(c[COMPONENT_BLOCK_KEY], c.get(COMPONENT_LANG_KEY)) for c in chunks]
+
+
+class TestChunkComponent:
+    """Tests for splitting components into blocks."""
+
+    def test_component_name(self):
+        assert component_name(Path("src/components/UserCard.vue")) == "UserCard"
+        assert component_name(Path("src/components/Avatar/index.vue")) == "Avatar"
+        assert component_name(Path("src/routes/users/+page.svelte")) == "users"
+
+    def test_nested_templates(self):
+        blocks = find_blocks(VUE, "vue")
+
+        assert [tag for _, _, tag, _ in blocks] == [
+            "template",
+            "script",
+            "style",
+            "i18n",
+        ]
+        start, end, _, _ = blocks[0]
+        assert VUE[start:end].endswith("  </div>\n</template>")
+
+    def test_vue(self):
+        chunks = chunk_component(VUE, Path("UserCard.vue"), "vue", 1000, 150)
+
+        assert blocks_of(chunks) == [
+            ("template", "html"),
+            ("script", "ts"),
+            ("style", "scss"),
+            ("i18n", "json"),
+        ]
+        assert chunks[0]["text"].startswith("<!-- Card showing a user -->\n")
+        assert chunks[1][COMPONENT_NAME_KEY] == "UserCard"
+        assert chunks[1][HEADING_PATH_KEY] == ["UserCard", "script"]
+        assert (chunks[1]["line_start"], chunks[1]["line_end"]) == (9, 12)
+        assert "".join(c["text"] for c in chunks) == VUE
+
+    def test_svelte(self):
+        chunks = chunk_component(SVELTE, Path("Page.svelte"), "svelte", 1000, 150)
+
+        assert blocks_of(chunks) == [
+            ("script", "ts"),
+            ("script", "js"),
+            ("markup", "html"),
+            ("style", "css"),
+            ("markup", "html"),
+        ]
+        assert chunks[2]["text"] == "\n<h1>{data.title}</h1>\n<p>Hello</p>\n"
+        assert chunks[3]["text"].startswith("\n<!-- Styles -->\n<style>")
+        assert "".join(c["text"] for c in chunks) == SVELTE
+
+
+class TestFixedSizeChunkerComponents:
+    """Tests for component files in FixedSizeChunker."""
+
+    def test_components_are_chunked_by_block(self, tmp_path):
+        (tmp_path / "UserCard.vue").write_text(VUE)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(
+            tmp_path / "UserCard.vue"
+        )
+
+        assert [c["chunk_index"] for c in chunks] == [0, 1, 2, 3]
+        assert chunks[1]["file_extension"] == "vue"
+        assert chunks[1][COMPONENT_BLOCK_KEY] == "script"
@@ -0,0 +1,156 @@
+"""
+Unit tests for stage- and service-aware chunking of container files.
+
+Tests Dockerfile instruction parsing, the chunks of build stages with their
+base images, the chunks of Compose services with their images, ports and
+volumes, and how FixedSizeChunker applies it to files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.container_chunker import (
+    COMPOSE_IMAGE_KEY,
+    COMPOSE_PORTS_KEY,
+    COMPOSE_SERVICE_KEY,
+    COMPOSE_VOLUMES_KEY,
+    DOCKER_BASE_IMAGE_KEY,
+    DOCKER_STAGE_KEY,
+    chunk_compose,
+    chunk_dockerfile,
+    dockerfile_instructions,
+    is_compose_file,
+    parse_from,
+)
+from code_indexer.indexing.document_chunker import HEADING_PATH_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+
+DOCKERFILE = """# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.22
+
+# Build the binary
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION} AS build
+WORKDIR /src
+RUN go mod download && \\
+    go build -o /out/app ./cmd/app
+RUN <<EOF
+echo FROM inside a heredoc
+EOF
+
+FROM gcr.io/distroless/static
+COPY --from=build /out/app /app
+ENTRYPOINT ["/app"]
+"""
+
+COMPOSE = """version: "3.8"
+services:
+  # Web frontend
+  web:
+    image: nginx:1.25
+    ports:
+      - "8080:80"
+      - target: 443
+        published: 8443
+    volumes:
+      - ./site:/usr/share/nginx/html:ro
+
+  db:
+    image: postgres:16
+    volumes:
+      - type: volume
+        source: pgdata
+        target: /var/lib/postgresql/data
+volumes:
+  pgdata:
+"""
+
+
+class TestChunkDockerfile:
+    """Tests for splitting Dockerfiles into build stages."""
+
+    def test_instructions(self):
+        instructions = dockerfile_instructions(DOCKERFILE)
+
+        assert instructions[3] == (
+            6,
+            7,
+            "RUN go mod download && go build -o /out/app ./cmd/app",
+        )
+        assert instructions[4][:2] == (8, 10)  # Heredoc body is not parsed
+        assert parse_from("FROM ubuntu") == ("ubuntu", None)
+        assert parse_from("FROM --platform=x node:20 as deps") == ("node:20", "deps")
+
+    def test_stages(self):
+        chunks = chunk_dockerfile(DOCKERFILE, 1000, 150)
+
+        assert [(c[DOCKER_STAGE_KEY], c[DOCKER_BASE_IMAGE_KEY]) for c in chunks] == [
+            ("build", "golang:${GO_VERSION}"),
+            ("1", "gcr.io/distroless/static"),
+        ]
+        assert chunks[0]["text"].startswith("# syntax=docker/dockerfile:1\n")
+        assert (chunks[1]["line_start"], chunks[1]["line_end"]) == (13, 15)
+        assert "".join(c["text"] for c in chunks) == DOCKERFILE
+
+    def test_comments_above_from_belong_to_the_stage(self):
+        text = "FROM a AS one\nRUN x\n\n# The second stage\nFROM b\n"
+
+        chunks = chunk_dockerfile(text, 1000, 150)
+
+        assert chunks[1]["text"] == "# The second stage\nFROM b\n"
+
+
+class TestChunkCompose:
+    """Tests for splitting Compose files into services."""
+
+    def test_compose_file_names(self):
+        assert is_compose_file("yml", "docker-compose.yml")
+        assert is_compose_file("yaml", "compose.override.yaml")
+        assert is_compose_file("yml", "docker-compose.prod.yml")
+        assert not is_compose_file("yml", "compose-notes.yml")
+        assert not is_compose_file("json", "compose.json")
+
+    def test_services(self):
+        chunks = chunk_compose(COMPOSE, 1000, 150)
+
+        assert [c.get(COMPOSE_SERVICE_KEY) for c in chunks] == [
+            None,
+            "web",
+            "db",
+            None,
+        ]
+        web, db = chunks[1], chunks[2]
+        assert web["text"].startswith("services:\n  # Web frontend\n  web:\n")
+        assert web[COMPOSE_IMAGE_KEY] == "nginx:1.25"
+        assert web[COMPOSE_PORTS_KEY] == ["8080:80", "8443:443"]
+        assert web[COMPOSE_VOLUMES_KEY] == ["./site:/usr/share/nginx/html:ro"]
+        assert web[HEADING_PATH_KEY] == ["services", "web"]
+        assert db[COMPOSE_VOLUMES_KEY] == ["pgdata:/var/lib/postgresql/data"]
+        assert (db["line_start"], db["line_end"]) == (12, 18)
+        assert "".join(c["text"] for c in chunks) == COMPOSE
+
+    def test_invalid_yaml(self):
+        assert chunk_compose("services: [unclosed\n", 1000, 150) is None
+
+
+class TestFixedSizeChunkerContainers:
+    """Tests for container files in FixedSizeChunker."""
+
+    def test_dockerfiles_and_compose_files(self, tmp_path):
+        (tmp_path / "Dockerfile").write_text(DOCKERFILE)
+        (tmp_path / "docker-compose.yml").write_text(COMPOSE)
+        chunker = FixedSizeChunker(IndexingConfig())
+
+        stages = chunker.chunk_file(tmp_path / "Dockerfile")
+        services = chunker.chunk_file(tmp_path / "docker-compose.yml")
+
+        assert [c["chunk_index"] for c in stages] == [0, 1]
+        assert stages[0]["file_extension"] == "dockerfile"
+        assert services[1][COMPOSE_SERVICE_KEY] == "web"
+
+    def test_other_yaml_files_are_unchanged(self, tmp_path):
+        (tmp_path / "values.yml").write_text(COMPOSE)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(
+            tmp_path / "values.yml"
+        )
+
+        assert len(chunks) == 1
+        assert COMPOSE_SERVICE_KEY not in chunks[0]
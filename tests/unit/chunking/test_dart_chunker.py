@@ -0,0 +1,162 @@
+"""
+Unit tests for declaration-aware chunking of Dart and Flutter files.
+
+Tests finding top-level declarations past strings and comments, widget
+detection, splitting out build methods, and how FixedSizeChunker applies it
+to files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.dart_chunker import (
+    FLUTTER_WIDGET_KEY,
+    SYMBOL_KIND_KEY,
+    SYMBOL_NAME_KEY,
+    chunk_dart,
+    is_dart,
+)
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+
+SOURCE = """import 'package:flutter/material.dart';
+
+const appTitle = 'Counter {demo}';
+
+void main() => runApp(const CounterApp());
+
+/// The app.
+class CounterApp extends StatelessWidget {
+  const CounterApp({super.key});
+
+  @override
+  Widget build(BuildContext context) {
+    return MaterialApp(title: appTitle, home: const CounterPage());
+  }
+}
+
+class CounterPage
+    extends StatefulWidget {
+  const CounterPage({super.key});
+
+  @override
+  State<CounterPage> createState() => _CounterPageState();
+}
+
+class _CounterPageState extends State<CounterPage> {
+  int _count = 0;
+
+  void _increment() {
+    setState(() => _count++);
+  }
+
+  @override
+  Widget build(BuildContext context) => Scaffold(
+        body: Text(r'Count: \\$_count }'),
+        floatingActionButton: FloatingActionButton(onPressed: _increment),
+      );
+
+  /* A comment with a } brace */
+  @override
+  void dispose() {
+    super.dispose();
+  }
+}
+
+mixin Logging on Object {
+  void log(String message) => print('''
+  [log] $message {
+  ''');
+}
+
+extension on String {
+  String get shout => toUpperCase();
+}
+
+extension Doubling<T> on List<T> {
+  List<T> doubled() => [...this, ...this];
+}
+
+enum Status { idle, busy }
+
+typedef Callback = void Function(int);
+
+Future<int> fetchCount({int retries = 3}) async {
+  return retries;
+}
+"""
+
+
+def symbols_of(chunks):
+    return [(c[SYMBOL_KIND_KEY], c.get(SYMBOL_NAME_KEY)) for c in chunks]
+
+
+class TestChunkDart:
+    """Tests for splitting Dart files into declarations."""
+
+    def test_detection(self):
+        assert is_dart("dart")
+        assert not is_dart("kt")
+
+    def test_declarations(self):
+        chunks = chunk_dart(SOURCE, 1000, 150)
+
+        assert symbols_of(chunks) == [
+            ("library", None),
+            ("function", "main"),
+            ("widget", "CounterApp"),
+            ("build", "CounterApp.build"),
+            ("widget", "CounterPage"),
+            ("class", "_CounterPageState"),
+            ("build", "_CounterPageState.build"),
+            ("class", "_CounterPageState"),
+            ("mixin", "Logging"),
+            ("extension", "on String"),
+            ("extension", "Doubling"),
+            ("enum", "Status"),
+            ("typedef", "Callback"),
+            ("function", "fetchCount"),
+        ]
+        assert "".join(c["text"] for c in chunks) == SOURCE
+
+    def test_widgets(self):
+        chunks = chunk_dart(SOURCE, 1000, 150)
+
+        # The header spans two lines; doc comments belong to the declaration
+        assert chunks[2]["text"].startswith("/// The app.\nclass CounterApp")
+        assert chunks[4][FLUTTER_WIDGET_KEY] == "CounterPage"
+        # State classes belong to their widget
+        assert chunks[5][FLUTTER_WIDGET_KEY] == "CounterPage"
+        assert FLUTTER_WIDGET_KEY not in chunks[8]
+
+    def test_build_methods(self):
+        chunks = chunk_dart(SOURCE, 1000, 150)
+
+        assert chunks[3]["text"].startswith("  @override\n  Widget build(")
+        assert chunks[3]["text"].endswith("  }\n}\n\n")
+        # An arrow-bodied build method ends at its semicolon
+        assert (chunks[6]["line_start"], chunks[6]["line_end"]) == (32, 36)
+        # Braces in comments and strings do not end the class
+        assert "void dispose()" in chunks[7]["text"]
+        assert chunks[7]["text"].endswith("  }\n}\n\n")
+
+    def test_long_declaration_is_windowed(self):
+        body = "".join(f"  int field{i} = {i};\n" for i in range(40))
+        text = f"class Settings {{\n{body}}}\n"
+
+        chunks = chunk_dart(text, 200, 50)
+
+        assert len(chunks) > 1
+        assert all(c[SYMBOL_NAME_KEY] == "Settings" for c in chunks)
+
+
+class TestFixedSizeChunkerDart:
+    """Tests for Dart files in FixedSizeChunker."""
+
+    def test_dart_files_are_chunked_by_declaration(self, tmp_path):
+        (tmp_path / "counter.dart").write_text(SOURCE)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(
+            tmp_path / "counter.dart"
+        )
+
+        assert [c["chunk_index"] for c in chunks] == list(range(14))
+        assert chunks[2]["file_extension"] == "dart"
+        assert chunks[2][SYMBOL_KIND_KEY] == "widget"
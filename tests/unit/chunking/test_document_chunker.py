@@ -0,0 +1,145 @@
+"""
+Unit tests for heading-aware chunking of documentation files.
+
+Tests heading detection for Markdown, reStructuredText and AsciiDoc, the
+breadcrumbs of sections, and how FixedSizeChunker applies it to files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.document_chunker import (
+    HEADING_PATH_KEY,
+    chunk_document,
+    find_headings,
+)
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+
+MARKDOWN = """---
+title: Guide
+---
+# Guide
+
+Intro text.
+
+## Install
+### Linux
+
+apt install x
+
+```bash
+# not a heading
+```
+
+Upgrading
+---------
+
+Run it again.
+"""
+
+
+def sections(chunks):
+    return [(c[HEADING_PATH_KEY], c["line_start"], c["line_end"]) for c in chunks]
+
+
+class TestHeadings:
+    """Tests for heading detection."""
+
+    def test_markdown(self):
+        headings = find_headings(MARKDOWN.split("\n"), "md")
+
+        assert [(h[2], h[3]) for h in headings] == [
+            (1, "Guide"),
+            (2, "Install"),
+            (3, "Linux"),
+            (2, "Upgrading"),
+        ]
+
+    def test_rst_levels_follow_adornment_order(self):
+        lines = [
+            "=====",
+            "Title",
+            "=====",
+            "",
+            "Usage",
+            "-----",
+            "",
+            "Options",
+            "~~~~~~~",
+            "",
+            "API",
+            "---",
+        ]
+
+        headings = find_headings(lines, "rst")
+
+        assert [(h[0], h[1], h[2], h[3]) for h in headings] == [
+            (0, 3, 1, "Title"),
+            (4, 2, 2, "Usage"),
+            (7, 2, 3, "Options"),
+            (10, 2, 2, "API"),
+        ]
+
+    def test_asciidoc_skips_listing_blocks(self):
+        lines = ["= Title", "", "== Part", "----", "== code", "----", "=== Deep"]
+
+        headings = find_headings(lines, "adoc")
+
+        assert [(h[2], h[3]) for h in headings] == [
+            (1, "Title"),
+            (2, "Part"),
+            (3, "Deep"),
+        ]
+
+
+class TestChunkDocument:
+    """Tests for splitting documents into sections."""
+
+    def test_sections_with_breadcrumbs(self):
+        chunks = chunk_document(MARKDOWN, "md", 4096, 600)
+
+        assert sections(chunks) == [
+            ([], 1, 3),
+            (["Guide"], 4, 6),
+            (["Guide", "Install", "Linux"], 8, 15),
+            (["Guide", "Upgrading"], 17, 20),
+        ]
+        # Empty "Install" section is merged into "Linux"
+        assert chunks[2]["text"].startswith("## Install\n### Linux\n")
+        assert "".join(c["text"] for c in chunks) == MARKDOWN
+
+    def test_long_sections_are_windowed(self):
+        text = "# Big\n\n" + "word " * 100 + "\n"
+
+        chunks = chunk_document(text, "md", 200, 30)
+
+        assert len(chunks) == 3
+        assert all(c[HEADING_PATH_KEY] == ["Big"] for c in chunks)
+        assert chunks[1]["text"] == text[170:370]
+
+    def test_document_without_headings(self):
+        chunks = chunk_document("Just a note.\n", "rst", 4096, 600)
+
+        assert sections(chunks) == [([], 1, 1)]
+
+
+class TestFixedSizeChunkerDocuments:
+    """Tests for documentation files in FixedSizeChunker."""
+
+    def test_documents_are_chunked_by_heading(self, tmp_path):
+        doc = tmp_path / "guide.md"
+        doc.write_text(MARKDOWN)
+
+        # The bash block is left in its section rather than chunked as shell
+        config = IndexingConfig(embedded_chunking=False)
+        chunks = FixedSizeChunker(config).chunk_file(doc)
+
+        assert len(chunks) == 4
+        assert [c["chunk_index"] for c in chunks] == [0, 1, 2, 3]
+        assert {c["total_chunks"] for c in chunks} == {4}
+        assert chunks[3]["file_extension"] == "md"
+        assert chunks[3]["size"] == len(chunks[3]["text"])
+
+    def test_chunk_text_is_not_heading_aware(self):
+        # Diffs and commit messages go through chunk_text
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_text("# A\n\n# B\n")
+
+        assert len(chunks) == 1
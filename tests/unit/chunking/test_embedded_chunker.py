@@ -0,0 +1,174 @@
+"""
+Unit tests for routing code embedded in pages, templates and documents.
+
+Tests finding script, style, template code and fenced code regions, the
+language and breadcrumbs of their chunks, and how FixedSizeChunker chunks
+embedded regions with the chunker of their language.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.document_chunker import HEADING_PATH_KEY
+from code_indexer.indexing.embedded_chunker import (
+    EMBEDDED_LANGUAGE_KEY,
+    embedded_regions,
+    is_embedded_host,
+)
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.shell_chunker import SHELL_FUNCTION_KEY
+
+PAGE = """<!doctype html>
+<html>
+<head>
+  <style>
+    body { margin: 0; }
+  </style>
+  <script type="text/x-template" id="row"><tr></tr></script>
+</head>
+<body>
+  <script src="app.js"></script>
+  <script>
+    function greet(name) {
+      return "Hello " + name;
+    }
+  </script>
+  <script type="application/ld+json">{"@type": "Person"}</script>
+</body>
+</html>
+"""
+
+README = """# Setup
+
+Install the dependencies:
+
+```bash
+install_deps() {
+  pip install -r requirements.txt
+}
+```
+
+## Usage
+
+```python
+print(greet("world"))
+```
+
+```
+plain output
+```
+"""
+
+ERB = """<ul>
+<% @users.each do |user| %>
+  <li><%= user.name %></li>
+<% end %>
+</ul>
+<%
+  total = @users.sum(&:score)
+  average = total / @users.size
+%>
+<p><%= average %></p>
+"""
+
+
+def languages_of(text, regions):
+    return [(text[start:end].strip()[:12], lang) for start, end, lang in regions]
+
+
+class TestEmbeddedRegions:
+    """Tests for finding embedded regions."""
+
+    def test_detection(self):
+        assert is_embedded_host("html")
+        assert is_embedded_host("md")
+        assert is_embedded_host("php", "users.blade.php")
+        assert not is_embedded_host("php", "users.php")
+        assert not is_embedded_host("vue")
+
+    def test_html_scripts_and_styles(self):
+        regions = embedded_regions(PAGE, "html")
+
+        assert [language for _, _, language in regions] == [
+            None,
+            "css",
+            None,
+            "js",
+            None,
+            "json",
+            None,
+        ]
+        assert "".join(PAGE[start:end] for start, end, _ in regions) == PAGE
+        # Script bodies are whole lines when their tags are on lines of
+        # their own
+        start, end, _ = regions[3]
+        assert PAGE[start:end].startswith("    function greet")
+        assert PAGE[end:].startswith("  </script>")
+
+    def test_template_code(self):
+        regions = embedded_regions(ERB, "erb")
+
+        # One-line tags stay in the markup
+        assert [language for _, _, language in regions] == [None, "rb", None]
+        assert languages_of(ERB, regions)[1] == ("total = @use", "rb")
+
+        blade = "@php\n  $count = count($users);\n@endphp\n<p>{{ $count }}</p>\n"
+        regions = embedded_regions(blade, "php", "users.blade.php")
+        assert languages_of(blade, regions)[1] == ("$count = cou", "php")
+
+    def test_markdown_fences(self):
+        regions = embedded_regions(README, "md")
+
+        # The fence without an info string stays Markdown
+        assert [language for _, _, language in regions] == [
+            None,
+            "sh",
+            None,
+            "py",
+            None,
+        ]
+
+
+class TestFixedSizeChunkerEmbedded:
+    """Tests for pages and documents with embedded code in FixedSizeChunker."""
+
+    def test_page_scripts_are_chunked_as_javascript(self, tmp_path):
+        (tmp_path / "index.html").write_text(PAGE)
+
+        chunker = FixedSizeChunker(IndexingConfig())
+        chunks = chunker.chunk_file(tmp_path / "index.html")
+
+        assert [c["file_extension"] for c in chunks] == [
+            "html",
+            "css",
+            "html",
+            "js",
+            "html",
+            "json",
+            "html",
+        ]
+        assert chunks[3][EMBEDDED_LANGUAGE_KEY] == "js"
+        assert EMBEDDED_LANGUAGE_KEY not in chunks[0]
+        assert (chunks[3]["line_start"], chunks[3]["line_end"]) == (12, 15)
+        assert "".join(c["text"] for c in chunks) == PAGE
+
+    def test_code_blocks_use_their_language_chunker(self, tmp_path):
+        (tmp_path / "README.md").write_text(README)
+
+        chunker = FixedSizeChunker(IndexingConfig())
+        chunks = chunker.chunk_file(tmp_path / "README.md")
+        shell = [c for c in chunks if c["file_extension"] == "sh"]
+        python = [c for c in chunks if c["file_extension"] == "py"]
+
+        assert shell[0][SHELL_FUNCTION_KEY] == "install_deps"
+        assert shell[0]["line_start"] == 6
+        # Code blocks keep the breadcrumb of their section
+        assert shell[0][HEADING_PATH_KEY] == ["Setup"]
+        assert python[0][HEADING_PATH_KEY] == ["Setup", "Usage"]
+
+    def test_embedded_chunking_can_be_disabled(self, tmp_path):
+        (tmp_path / "index.html").write_text(PAGE)
+
+        config = IndexingConfig(embedded_chunking=False)
+        chunks = FixedSizeChunker(config).chunk_file(tmp_path / "index.html")
+
+        assert {c["file_extension"] for c in chunks} == {"html"}
+        assert all(EMBEDDED_LANGUAGE_KEY not in c for c in chunks)
@@ -0,0 +1,176 @@
+"""
+Unit tests for structure-aware chunking of Verilog and VHDL files.
+
+Tests splitting modules at always blocks, functions and tasks, VHDL design
+units and processes, the port lists of modules and entities, and how
+FixedSizeChunker applies it to .sv and .vhd files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.dart_chunker import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.hdl_chunker import (
+    HDL_PORTS_KEY,
+    HDL_UNIT_KEY,
+    chunk_hdl,
+    is_hdl,
+    verilog_ports,
+    vhdl_ports,
+)
+
+VERILOG = """`timescale 1ns/1ps
+// Counts up on every clock
+module counter #(parameter WIDTH = 8) (
+    input  wire             clk,
+    input  wire             rst_n,
+    output reg [WIDTH-1:0] count, overflow
+);
+  assign overflow = &count;
+
+  // Synchronous counter
+  always @(posedge clk or negedge rst_n) begin : count_up
+    if (!rst_n)
+      count <= 0;
+    else begin
+      count <= count + 1; // "end"
+    end
+  end
+
+  function automatic [7:0] parity(input [7:0] d);
+    parity = ^d;
+  endfunction
+
+  initial $display("counter");
+endmodule
+
+module and_gate(a, b, y);
+  input a, b;
+  output y;
+  always_comb
+    y = a & b;
+endmodule
+"""
+
+VHDL = """library ieee;
+use ieee.std_logic_1164.all;
+
+-- Counts up on every clock
+entity counter is
+  generic (WIDTH : natural := 8);
+  port (
+    clk, rst : in  std_logic;
+    count    : out unsigned(WIDTH - 1 downto 0)
+  );
+end entity counter;
+
+architecture rtl of counter is
+  signal value : unsigned(WIDTH - 1 downto 0);
+begin
+  -- Register the count
+  sync: process (clk)
+  begin
+    if rising_edge(clk) then
+      value <= value + 1;
+    end if;
+  end process sync;
+
+  count <= value;
+end architecture rtl;
+"""
+
+
+def structure_of(chunks):
+    return [
+        (c[SYMBOL_KIND_KEY], c.get(SYMBOL_NAME_KEY), c.get(HDL_UNIT_KEY))
+        for c in chunks
+    ]
+
+
+class TestChunkHdl:
+    """Tests for splitting Verilog and VHDL files into units and blocks."""
+
+    def test_detection(self):
+        assert is_hdl("v")
+        assert is_hdl("SV")
+        assert is_hdl("vhdl")
+        assert not is_hdl("vue")
+
+    def test_verilog_modules_and_blocks(self):
+        chunks = chunk_hdl(VERILOG, "v", 2000, 300)
+
+        assert structure_of(chunks) == [
+            ("module", "counter", "counter"),
+            ("always", "count_up", "counter"),
+            ("function", "parity", "counter"),
+            ("initial", None, "counter"),
+            ("module", "and_gate", "and_gate"),
+            ("always", None, "and_gate"),
+        ]
+        assert "".join(c["text"] for c in chunks) == VERILOG
+        # The directive and comment above a module and the comment above a
+        # block belong to them
+        assert chunks[0]["text"].startswith("`timescale 1ns/1ps\n// Counts")
+        assert (chunks[1]["line_start"], chunks[1]["line_end"]) == (10, 17)
+
+    def test_verilog_ports(self):
+        chunks = chunk_hdl(VERILOG, "v", 2000, 300)
+
+        assert chunks[0][HDL_PORTS_KEY] == [
+            "input wire clk",
+            "input wire rst_n",
+            "output reg [WIDTH-1:0] count",
+            "output reg [WIDTH-1:0] overflow",
+        ]
+        # Non-ANSI ports are declared in the module body
+        assert chunks[4][HDL_PORTS_KEY] == ["input a", "input b", "output y"]
+        assert HDL_PORTS_KEY not in chunks[1]
+        assert verilog_ports("module top;") == []
+
+    def test_vhdl_units_and_processes(self):
+        chunks = chunk_hdl(VHDL, "vhd", 2000, 300)
+
+        assert structure_of(chunks) == [
+            ("entity", "counter", "counter"),
+            ("architecture", "rtl", "counter"),
+            ("process", "sync", "counter"),
+            ("architecture", "rtl", "counter"),
+        ]
+        assert "".join(c["text"] for c in chunks) == VHDL
+        assert chunks[0]["text"].startswith("library ieee;\n")
+        assert chunks[2]["text"].startswith("  -- Register the count\n")
+
+    def test_vhdl_ports(self):
+        chunks = chunk_hdl(VHDL, "vhd", 2000, 300)
+
+        assert chunks[0][HDL_PORTS_KEY] == [
+            "clk : in std_logic",
+            "rst : in std_logic",
+            "count : out unsigned(WIDTH - 1 downto 0)",
+        ]
+        assert vhdl_ports("entity top is\nend entity;") == []
+
+    def test_long_block_is_windowed(self):
+        body = "".join(f"    mem[{i}] <= {i};\n" for i in range(40))
+        text = f"module m;\n  initial begin\n{body}  end\nendmodule\n"
+
+        chunks = chunk_hdl(text, "sv", 300, 50)
+
+        assert len(chunks) > 2
+        assert all(c[SYMBOL_KIND_KEY] == "initial" for c in chunks[1:])
+        assert chunks[-1]["line_end"] == text.count("\n")
+
+
+class TestFixedSizeChunkerHdl:
+    """Tests for Verilog and VHDL files in FixedSizeChunker."""
+
+    def test_hdl_files_are_chunked_by_unit(self, tmp_path):
+        (tmp_path / "counter.sv").write_text(VERILOG)
+        (tmp_path / "counter.vhd").write_text(VHDL)
+        chunker = FixedSizeChunker(IndexingConfig())
+
+        verilog_chunks = chunker.chunk_file(tmp_path / "counter.sv")
+        vhdl_chunks = chunker.chunk_file(tmp_path / "counter.vhd")
+
+        assert [c["chunk_index"] for c in verilog_chunks] == [0, 1, 2, 3, 4, 5]
+        assert verilog_chunks[1][SYMBOL_NAME_KEY] == "count_up"
+        assert vhdl_chunks[2][SYMBOL_KIND_KEY] == "process"
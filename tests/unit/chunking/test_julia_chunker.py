@@ -0,0 +1,120 @@
+"""
+Unit tests for declaration-aware chunking of Julia files.
+
+Tests finding functions, types and macros inside modules past strings,
+comments and `end` in indexing expressions, docstrings, the chunk lines,
+and how FixedSizeChunker applies it to .jl files and Julia scripts.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.dart_chunker import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.julia_chunker import chunk_julia, is_julia
+
+SOURCE = '''# Solver package
+module Solvers
+
+using LinearAlgebra
+export solve!, Point
+
+"""
+    Point(x, y)
+
+A point in the plane.
+"""
+struct Point{T<:Real}
+    x::T
+    y::T
+end
+
+abstract type AbstractSolver end
+
+Base.@kwdef mutable struct Newton <: AbstractSolver
+    tol::Float64 = 1e-8
+end
+
+norm2(p::Point) = p.x^2 + p.y^2
+
+# Solve in place
+function solve!(s::Newton, xs::Vector)
+    for i in eachindex(xs)
+        xs[i] = xs[end] + 1  # end inside brackets
+        ys = [x for x in xs if x > 0]
+        if xs[i]' == 'e'
+            println("end of \\"block\\" $(i)")
+        end
+    end
+    map(xs) do x
+        x + 1
+    end
+    return xs
+end
+
+macro twice(ex)
+    quote
+        $(esc(ex)); $(esc(ex))
+    end
+end
+
+end # module
+
+const VERSION_STRING = "1.0"
+'''
+
+
+def symbols_of(chunks):
+    return [(c[SYMBOL_KIND_KEY], c.get(SYMBOL_NAME_KEY)) for c in chunks]
+
+
+class TestChunkJulia:
+    """Tests for splitting Julia files into declarations."""
+
+    def test_detection(self):
+        assert is_julia("jl")
+        assert not is_julia("r")
+
+    def test_declarations_inside_module(self):
+        chunks = chunk_julia(SOURCE, 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("module", "Solvers"),
+            ("struct", "Point"),
+            ("abstract", "AbstractSolver"),
+            ("struct", "Newton"),
+            ("function", "norm2"),
+            ("function", "solve!"),
+            ("macro", "twice"),
+            ("file", None),
+        ]
+        assert "".join(c["text"] for c in chunks) == SOURCE
+
+    def test_blocks_strings_and_indexing_do_not_end_functions(self):
+        chunks = chunk_julia(SOURCE, 2000, 300)
+
+        assert chunks[1]["text"].startswith('"""\n    Point(x, y)\n')
+        assert chunks[5]["text"].startswith("# Solve in place\nfunction solve!")
+        assert chunks[5]["text"].endswith("    return xs\nend\n\n")
+        assert (chunks[5]["line_start"], chunks[5]["line_end"]) == (25, 38)
+
+    def test_long_function_is_windowed(self):
+        body = "".join(f"    x += {i}\n" for i in range(40))
+        text = f"function accumulate(x)\n{body}    x\nend\n"
+
+        chunks = chunk_julia(text, 200, 50)
+
+        assert len(chunks) > 1
+        assert all(c[SYMBOL_NAME_KEY] == "accumulate" for c in chunks)
+        assert chunks[-1]["line_end"] == text.count("\n")
+
+
+class TestFixedSizeChunkerJulia:
+    """Tests for Julia files in FixedSizeChunker."""
+
+    def test_julia_scripts_are_chunked_by_declaration(self, tmp_path):
+        script = tmp_path / "solve"
+        script.write_text(f"#!/usr/bin/env julia\n{SOURCE}")
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(script)
+
+        assert chunks[0]["file_extension"] == "jl"
+        assert chunks[5][SYMBOL_NAME_KEY] == "solve!"
@@ -0,0 +1,103 @@
+"""
+Unit tests for resource-aware chunking of Kubernetes manifests.
+
+Tests manifest detection, reading kind, name and namespace, splitting
+multi-document files and Helm templates into resources, and how
+FixedSizeChunker applies it to files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.document_chunker import HEADING_PATH_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.kubernetes_chunker import (
+    K8S_KIND_KEY,
+    K8S_NAME_KEY,
+    K8S_NAMESPACE_KEY,
+    chunk_kubernetes,
+    is_kubernetes_manifest,
+    resource_fields,
+)
+
+TEMPLATE = """# Redis for the cache
+{{- if .Values.redis.enabled }}
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "app.fullname" . }}-redis  # cache
+  namespace: "cache"
+  labels:
+    name: redis-labels
+spec:
+  template:
+    spec:
+      volumes:
+        - name: creds
+          secret:
+            secretName: redis-secret
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app: redis
+  name: redis
+{{- end }}
+--- # trailing
+# nothing here
+"""
+
+
+class TestChunkKubernetes:
+    """Tests for splitting manifests into resources."""
+
+    def test_detection(self):
+        assert is_kubernetes_manifest("yaml", TEMPLATE)
+        assert not is_kubernetes_manifest("yml", "name: ci\non: push\n")
+        assert not is_kubernetes_manifest("yml", "apiVersion: v1\n")
+        assert not is_kubernetes_manifest("json", TEMPLATE)
+
+    def test_resource_fields(self):
+        fields = resource_fields(TEMPLATE.split("\n")[:17])
+
+        assert fields[K8S_KIND_KEY] == "Deployment"
+        # Helm expressions are kept; labels are not metadata.name
+        assert fields[K8S_NAME_KEY] == '{{ include "app.fullname" . }}-redis'
+        assert fields[K8S_NAMESPACE_KEY] == "cache"
+        assert resource_fields(["# only a comment"]) == {}
+
+    def test_resources(self):
+        chunks = chunk_kubernetes(TEMPLATE, 1000, 150)
+
+        assert [(c[K8S_KIND_KEY], c[K8S_NAME_KEY]) for c in chunks] == [
+            ("Deployment", '{{ include "app.fullname" . }}-redis'),
+            ("Service", "redis"),
+        ]
+        assert K8S_NAMESPACE_KEY not in chunks[1]
+        assert chunks[1][HEADING_PATH_KEY] == ["Service", "redis"]
+        assert chunks[0]["text"].startswith("# Redis for the cache\n")
+        assert (chunks[1]["line_start"], chunks[1]["line_end"]) == (17, 26)
+        assert "".join(c["text"] for c in chunks) == TEMPLATE
+
+    def test_leading_documents_belong_to_the_next_resource(self):
+        text = "---\n# Namespaces\n---\napiVersion: v1\nkind: Namespace\n"
+
+        chunks = chunk_kubernetes(text, 1000, 150)
+
+        assert len(chunks) == 1
+        assert chunks[0][K8S_KIND_KEY] == "Namespace"
+        assert chunks[0]["line_start"] == 1
+
+
+class TestFixedSizeChunkerKubernetes:
+    """Tests for manifests in FixedSizeChunker."""
+
+    def test_manifests_are_chunked_by_resource(self, tmp_path):
+        manifest = tmp_path / "templates" / "redis.yaml"
+        manifest.parent.mkdir()
+        manifest.write_text(TEMPLATE)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(manifest)
+
+        assert [c["chunk_index"] for c in chunks] == [0, 1]
+        assert chunks[0]["file_extension"] == "yaml"
+        assert chunks[1][K8S_KIND_KEY] == "Service"
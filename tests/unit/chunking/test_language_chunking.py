@@ -0,0 +1,126 @@
+"""
+Unit tests for per-language chunk size, overlap and minimum chunk length.
+
+Tests sizing chunks of a language from its token budget, splitting chunks
+that exceed the budget by the tokenizer's count, merging short chunks,
+configuration validation, and languages embedded in other files.
+"""
+
+import pytest
+
+from code_indexer.config import IndexingConfig, LanguageChunkingConfig
+from code_indexer.indexing.fixed_size_chunker import CHARS_PER_TOKEN, FixedSizeChunker
+from code_indexer.indexing.shell_chunker import SHELL_FUNCTION_KEY
+
+
+def config_for(**languages):
+    return IndexingConfig(
+        language_chunking={
+            language: LanguageChunkingConfig(**settings)
+            for language, settings in languages.items()
+        }
+    )
+
+
+def words(count):
+    return "".join(f"value_{i} = compute({i})\n" for i in range(count))
+
+
+class TestLanguageChunkSizes:
+    """Tests for languages chunked with their own size and overlap."""
+
+    def test_token_budget_sets_the_chunk_size(self, tmp_path):
+        (tmp_path / "main.py").write_text(words(100))
+        (tmp_path / "main.rb").write_text(words(100))
+        chunker = FixedSizeChunker(
+            config_for(py={"chunk_max_tokens": 100, "chunk_overlap": 40})
+        )
+
+        python = chunker.chunk_file(tmp_path / "main.py")
+        ruby = chunker.chunk_file(tmp_path / "main.rb")
+
+        assert max(len(c["text"]) for c in python) == 100 * CHARS_PER_TOKEN
+        assert python[0]["text"][-40:] == python[1]["text"][:40]
+        assert [c["chunk_index"] for c in python] == list(range(len(python)))
+        # Other languages keep the model-aware size
+        assert len(ruby[0]["text"]) == chunker.chunk_size
+
+    def test_chunks_over_the_budget_are_split_by_token_count(self, tmp_path):
+        (tmp_path / "data.go").write_text(words(100))
+        # A tokenizer counting two characters per token
+        chunker = FixedSizeChunker(
+            config_for(go={"chunk_max_tokens": 100}),
+            token_counter=lambda text: len(text) // 2,
+        )
+
+        chunks = chunker.chunk_file(tmp_path / "data.go")
+
+        assert all(len(c["text"]) // 2 <= 100 for c in chunks)
+        assert chunks[-1]["line_end"] == 100
+        assert all(c["file_extension"] == "go" for c in chunks)
+
+    def test_structure_aware_chunks_keep_their_fields(self, tmp_path):
+        body = "".join(f'  echo "step {i}"\n' for i in range(60))
+        (tmp_path / "run.sh").write_text(f"deploy() {{\n{body}}}\n")
+        chunker = FixedSizeChunker(config_for(sh={"chunk_max_tokens": 100}))
+
+        chunks = chunker.chunk_file(tmp_path / "run.sh")
+
+        assert len(chunks) > 1
+        assert all(c[SHELL_FUNCTION_KEY] == "deploy" for c in chunks)
+
+
+class TestMinimumChunkLength:
+    """Tests for merging chunks shorter than min_chunk_chars."""
+
+    def test_short_tail_joins_the_last_chunk(self, tmp_path):
+        (tmp_path / "main.py").write_text("x" * 1020)
+        chunker = FixedSizeChunker(config_for(py={"min_chunk_chars": 50}))
+
+        chunks = chunker.chunk_file(tmp_path / "main.py")
+
+        assert len(chunks) == 1
+        assert len(chunks[0]["text"]) == 1020
+
+    def test_short_structure_chunks_are_merged(self, tmp_path):
+        text = "a() {\n  true\n}\nb() { :; }\nc() {\n  false\n}\n"
+        (tmp_path / "fns.sh").write_text(text)
+        chunker = FixedSizeChunker(config_for(sh={"min_chunk_chars": 12}))
+
+        chunks = chunker.chunk_file(tmp_path / "fns.sh")
+
+        assert [c[SHELL_FUNCTION_KEY] for c in chunks] == ["a", "c"]
+        assert (chunks[0]["line_start"], chunks[0]["line_end"]) == (1, 4)
+        assert "".join(c["text"] for c in chunks) == text
+
+
+class TestLanguageChunkingConfig:
+    """Tests for validating per-language settings."""
+
+    def test_language_keys_are_normalized(self):
+        config = IndexingConfig(language_chunking={".PY": LanguageChunkingConfig()})
+
+        assert list(config.language_chunking) == ["py"]
+
+    def test_overlap_must_be_smaller_than_the_chunk(self):
+        with pytest.raises(ValueError):
+            LanguageChunkingConfig(chunk_max_tokens=10, chunk_overlap=40)
+        with pytest.raises(ValueError):
+            LanguageChunkingConfig(chunk_max_tokens=0)
+
+
+class TestEmbeddedLanguageChunking:
+    """Tests for code embedded in other files."""
+
+    def test_code_blocks_use_the_settings_of_their_language(self, tmp_path):
+        (tmp_path / "README.md").write_text(
+            f"# Setup\n\n```python\n{words(40)}```\n\nDone.\n"
+        )
+        chunker = FixedSizeChunker(config_for(py={"chunk_max_tokens": 50}))
+
+        chunks = chunker.chunk_file(tmp_path / "README.md")
+        python = [c for c in chunks if c["file_extension"] == "py"]
+
+        assert len(python) > 1
+        assert all(len(c["text"]) <= 50 * CHARS_PER_TOKEN for c in python)
+        assert [c["chunk_index"] for c in chunks] == list(range(len(chunks)))
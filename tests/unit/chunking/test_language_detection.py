@@ -0,0 +1,165 @@
+"""
+Unit tests for language detection of files with missing or ambiguous
+extensions.
+
+Tests detection from well-known file names, shebangs, Emacs and Vim
+modelines, and C versus C++ headers, and its use by chunking and file
+discovery.
+"""
+
+from pathlib import Path
+
+import pytest
+
+from code_indexer.config import Config, IndexingConfig
+from code_indexer.indexing.file_finder import FileFinder
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.language_detection import (
+    detect_file_language,
+    detect_language,
+)
+
+
+class TestDetectLanguage:
+    """Tests for detect_language()."""
+
+    @pytest.mark.parametrize(
+        "name,expected",
+        [
+            ("Makefile", "makefile"),
+            ("GNUmakefile", "makefile"),
+            ("Dockerfile", "dockerfile"),
+            ("Dockerfile.dev", "dockerfile"),
+            ("CMakeLists.txt", "cmake"),
+            ("Jenkinsfile", "groovy"),
+            ("Gemfile", "rb"),
+            (".bashrc", "sh"),
+        ],
+    )
+    def test_well_known_file_names(self, name, expected):
+        assert detect_language(Path("repo") / name) == expected
+
+    def test_extension_wins_over_content(self):
+        assert detect_language(Path("tool.rb"), "#!/usr/bin/env python3\n") == "rb"
+
+    @pytest.mark.parametrize(
+        "shebang,expected",
+        [
+            ("#!/usr/bin/env python3", "py"),
+            ("#!/usr/bin/python3.11 -u", "py"),
+            ("#!/bin/bash -e", "sh"),
+            ("#!/usr/bin/env -S node --no-warnings", "js"),
+            ("#!/usr/bin/env LANG=C perl", "pl"),
+            ("#!/usr/bin/make -f", "makefile"),
+            ("#!/usr/bin/env elixir", "exs"),
+            ("#!/usr/bin/env escript", "erl"),
+            ("#!/usr/bin/env julia", "jl"),
+            ("#!/usr/bin/env Rscript", "r"),
+        ],
+    )
+    def test_shebang(self, shebang, expected):
+        assert detect_language(Path("bin/tool"), f"{shebang}\nbody\n") == expected
+
+    @pytest.mark.parametrize(
+        "content",
+        [
+            "# -*- mode: ruby; coding: utf-8 -*-\nputs 1\n",
+            "# -*- ruby -*-\nputs 1\n",
+            "#!/bin/custom\n# -*- mode: ruby -*-\nputs 1\n",
+            "puts 1\n" * 20 + "# vim: set ft=ruby ts=2 :\n",
+        ],
+    )
+    def test_modelines(self, content):
+        assert detect_language(Path("tool"), content) == "rb"
+
+    def test_unknown_script(self):
+        assert detect_language(Path("LICENSE"), "Permission is granted\n") == ""
+
+    def test_name_only_without_content(self):
+        assert detect_language(Path("bin/tool")) == ""
+        assert detect_language(Path("include/api.h")) == "h"
+
+    def test_c_header(self):
+        content = (
+            "#ifndef API_H\n#define API_H\n#include <stdio.h>\n"
+            '#ifdef __cplusplus\nextern "C" {\n#endif\n'
+            "int api_init(void);\n#endif\n"
+        )
+
+        assert detect_language(Path("api.h"), content) == "h"
+
+    @pytest.mark.parametrize(
+        "content",
+        [
+            "#pragma once\nnamespace api {\nint init();\n}\n",
+            "#include <vector>\nint sum(const std::vector<int>& v);\n",
+            "class Widget : public Base {\npublic:\n  Widget();\n};\n",
+            "template <typename T>\nT max(T a, T b);\n",
+            "// -*- C++ -*-\nint init();\n",
+        ],
+    )
+    def test_cpp_header(self, content):
+        assert detect_language(Path("api.h"), content) == "cpp"
+
+    @pytest.mark.parametrize(
+        "content,language",
+        [
+            ("#import <Foundation/Foundation.h>\n@class User;\n", "m"),
+            ("@interface User : NSObject\n@end\n", "m"),
+            ("#include <vector>\n@interface Cache : NSObject\n@end\n", "mm"),
+        ],
+    )
+    def test_objc_header(self, content, language):
+        assert detect_language(Path("User.h"), content) == language
+
+    def test_c_modeline_overrides_heuristics(self):
+        content = "/* -*- mode: c -*- */\n/* see std::thread */\nint init(void);\n"
+
+        assert detect_language(Path("api.h"), content) == "h"
+
+
+class TestDetectFileLanguage:
+    """Tests for detect_file_language()."""
+
+    def test_reads_extensionless_file(self, tmp_path):
+        script = tmp_path / "deploy"
+        script.write_text("#!/usr/bin/env bash\necho deploy\n")
+
+        assert detect_file_language(script) == "sh"
+
+    def test_does_not_read_named_files(self, tmp_path):
+        assert detect_file_language(tmp_path / "missing.py") == "py"
+        assert detect_file_language(tmp_path / "missing") == ""
+
+
+class TestIndexingUsesDetection:
+    """Tests for detection in chunking and file discovery."""
+
+    def test_chunks_record_detected_language(self):
+        chunker = FixedSizeChunker(IndexingConfig())
+
+        chunks = chunker.chunk_text("#!/usr/bin/env python3\nprint(1)\n", Path("run"))
+
+        assert chunks[0]["file_extension"] == "py"
+
+    def test_file_finder_includes_detected_files(self, tmp_path):
+        (tmp_path / "Makefile").write_text("all:\n\tcc main.c\n")
+        (tmp_path / "run").write_text("#!/usr/bin/env python3\nprint(1)\n")
+        (tmp_path / "LICENSE").write_text("Permission is granted\n")
+        (tmp_path / "main.py").write_text("print(1)\n")
+        config = Config(codebase_dir=tmp_path)
+
+        found = {path.name for path in FileFinder(config).find_files()}
+
+        assert found == {"Makefile", "run", "main.py"}
+
+    def test_file_finder_includes_zig_sources(self, tmp_path):
+        (tmp_path / "src").mkdir()
+        (tmp_path / "src" / "main.zig").write_text("pub fn main() void {}\n")
+        (tmp_path / "zig-out" / "bin").mkdir(parents=True)
+        (tmp_path / "zig-out" / "bin" / "gen.zig").write_text("const x = 1;\n")
+        config = Config(codebase_dir=tmp_path)
+
+        found = {path.name for path in FileFinder(config).find_files()}
+
+        assert found == {"main.zig"}
@@ -0,0 +1,102 @@
+"""
+Unit tests for merging tiny method and function chunks.
+
+Tests merging runs of short Objective-C methods of one class and short R
+functions, the symbol list of merged chunks, and the limits of a run.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.dart_chunker import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from code_indexer.indexing.fixed_size_chunker import SYMBOLS_KEY, FixedSizeChunker
+
+OBJC = """#import "User.h"
+
+@implementation User
+
+- (NSString *)name {
+    return _name;
+}
+
+- (void)setName:(NSString *)name {
+    _name = name;
+}
+
+- (void)save {
+    NSDictionary *fields = @{@"name": self.name, @"email": self.email};
+    [self.store writeFields:fields forKey:self.identifier error:nil];
+    [self.store flushWithCompletion:^(NSError *error) { NSLog(@"%@", error); }];
+}
+
+- (NSString *)email {
+    return _email;
+}
+
+@end
+
+@implementation Account
+
+- (NSString *)owner {
+    return _owner;
+}
+
+@end
+"""
+
+R = """add <- function(a, b) a + b
+
+sub <- function(a, b) a - b
+
+mul <- function(a, b) a * b
+"""
+
+
+def chunk(tmp_path, name, text, **config):
+    (tmp_path / name).write_text(text)
+    return FixedSizeChunker(IndexingConfig(**config)).chunk_file(tmp_path / name)
+
+
+class TestMicroChunkMerging:
+    """Tests for merging adjacent short declarations."""
+
+    def test_short_methods_of_a_class_are_merged(self, tmp_path):
+        chunks = chunk(tmp_path, "User.m", OBJC, micro_chunk_chars=100)
+        methods = [c for c in chunks if c[SYMBOL_KIND_KEY] == "method"]
+
+        assert [c.get(SYMBOLS_KEY) for c in methods] == [
+            ["-[User name]", "-[User setName:]"],
+            None,
+            None,
+            None,
+        ]
+        assert methods[0][SYMBOL_NAME_KEY] == "-[User name]"
+        assert "_name = name;" in methods[0]["text"]
+        # The long save method ends the run, and Account is another class
+        assert [c[SYMBOL_NAME_KEY] for c in methods[1:]] == [
+            "-[User save]",
+            "-[User email]",
+            "-[Account owner]",
+        ]
+        assert [c["chunk_index"] for c in chunks] == list(range(len(chunks)))
+        assert "".join(c["text"] for c in chunks) == OBJC
+
+    def test_top_level_functions_are_merged(self, tmp_path):
+        chunks = chunk(tmp_path, "math.R", R, micro_chunk_chars=100)
+
+        assert len(chunks) == 1
+        assert chunks[0][SYMBOLS_KEY] == ["add", "sub", "mul"]
+        assert (chunks[0]["line_start"], chunks[0]["line_end"]) == (1, 5)
+
+    def test_merged_chunks_stay_within_the_chunk_size(self, tmp_path):
+        chunker = FixedSizeChunker(IndexingConfig(micro_chunk_chars=100))
+        chunker.chunk_size = 60
+        (tmp_path / "math.R").write_text(R)
+
+        chunks = chunker.chunk_file(tmp_path / "math.R")
+
+        assert [c.get(SYMBOLS_KEY) for c in chunks] == [["add", "sub"], None]
+
+    def test_off_by_default(self, tmp_path):
+        chunks = chunk(tmp_path, "math.R", R)
+
+        assert len(chunks) == 3
+        assert all(SYMBOLS_KEY not in c for c in chunks)
@@ -0,0 +1,160 @@
+"""
+Unit tests for declaration-aware chunking of Objective-C files.
+
+Tests finding interfaces, categories, implementations, methods and C
+functions past strings and comments, the method names, the chunk lines, and
+how FixedSizeChunker applies it to .m files and Objective-C headers.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.dart_chunker import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.objc_chunker import chunk_objc, is_objc, method_selector
+
+SOURCE = """#import "UserStore.h"
+#import <Foundation/Foundation.h>
+
+static NSString *const kStoreKey = @"users{";
+
+@protocol UserObserver;
+
+@interface UserStore ()
+@property (nonatomic, strong) NSMutableArray *users;
+@end
+
+/// Clamps a count
+static NSInteger ClampCount(NSInteger count)
+{
+    return count < 0 ? 0 : count; // }
+}
+
+@implementation UserStore {
+    NSLock *_lock;
+}
+
++ (instancetype)sharedStore {
+    static UserStore *store;
+    static dispatch_once_t once;
+    dispatch_once(&once, ^{
+        store = [[UserStore alloc] init];
+    });
+    return store;
+}
+
+#pragma mark - Saving
+
+/* Saves a user */
+- (BOOL)saveUser:(User *)user
+           error:(NSError **)error {
+    if (!user) { return NO; }
+    [self.users addObject:user];
+    return YES;
+}
+
+@end
+
+@implementation UserStore (Sync)
+- (void)syncWithCompletion:(void (^)(BOOL ok))completion {
+    completion(YES);
+}
+@end
+
+int main(int argc, char *argv[]) {
+    return 0;
+}
+"""
+
+HEADER = """#import <Foundation/Foundation.h>
+
+@protocol UserObserver <NSObject>
+- (void)storeDidChange:(UserStore *)store;
+@end
+
+@interface UserStore : NSObject
++ (instancetype)sharedStore;
+@end
+
+@interface UserStore (Sync)
+- (void)syncWithCompletion:(void (^)(BOOL ok))completion;
+@end
+"""
+
+
+def symbols_of(chunks):
+    return [(c[SYMBOL_KIND_KEY], c.get(SYMBOL_NAME_KEY)) for c in chunks]
+
+
+class TestChunkObjc:
+    """Tests for splitting Objective-C files into declarations."""
+
+    def test_detection(self):
+        assert is_objc("m")
+        assert is_objc("mm")
+        assert not is_objc("h")
+
+    def test_implementations_methods_and_functions(self):
+        chunks = chunk_objc(SOURCE, 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("file", None),
+            ("extension", "UserStore"),
+            ("function", "ClampCount"),
+            ("implementation", "UserStore"),
+            ("method", "+[UserStore sharedStore]"),
+            ("method", "-[UserStore saveUser:error:]"),
+            ("category", "UserStore (Sync)"),
+            ("method", "-[UserStore(Sync) syncWithCompletion:]"),
+            ("function", "main"),
+        ]
+        assert "".join(c["text"] for c in chunks) == SOURCE
+
+    def test_comments_and_strings_do_not_end_declarations(self):
+        chunks = chunk_objc(SOURCE, 2000, 300)
+
+        assert chunks[0]["text"].endswith("@protocol UserObserver;\n\n")
+        assert chunks[2]["text"].startswith("/// Clamps a count\n")
+        assert chunks[2]["text"].rstrip().endswith("count; // }\n}")
+        assert chunks[5]["text"].startswith("/* Saves a user */\n")
+        assert (chunks[5]["line_start"], chunks[5]["line_end"]) == (33, 41)
+
+    def test_header_interfaces_and_protocols(self):
+        chunks = chunk_objc(HEADER, 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("file", None),
+            ("protocol", "UserObserver"),
+            ("interface", "UserStore"),
+            ("category", "UserStore (Sync)"),
+        ]
+
+    def test_method_selector(self):
+        assert method_selector("- (void)reload {") == "reload"
+        assert (
+            method_selector("- (void)fetch:(NSURL *)url completion:(void (^)(id))cb")
+            == "fetch:completion:"
+        )
+
+    def test_long_method_is_windowed(self):
+        body = "".join(f"    NSLog(@\"line {i}\");\n" for i in range(40))
+        text = f"@implementation Log\n- (void)dump {{\n{body}}}\n@end\n"
+
+        chunks = chunk_objc(text, 200, 50)
+
+        assert len(chunks) > 2
+        assert all(c[SYMBOL_NAME_KEY] == "-[Log dump]" for c in chunks[1:])
+        assert chunks[-1]["line_end"] == text.count("\n")
+
+
+class TestFixedSizeChunkerObjc:
+    """Tests for Objective-C files in FixedSizeChunker."""
+
+    def test_objc_headers_are_chunked_by_declaration(self, tmp_path):
+        (tmp_path / "UserStore.h").write_text(HEADER)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(
+            tmp_path / "UserStore.h"
+        )
+
+        assert [c["chunk_index"] for c in chunks] == [0, 1, 2, 3]
+        assert chunks[0]["file_extension"] == "m"
+        assert chunks[2][SYMBOL_KIND_KEY] == "interface"
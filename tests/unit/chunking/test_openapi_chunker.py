@@ -0,0 +1,143 @@
+"""
+Unit tests for operation-aware chunking of OpenAPI and Swagger specs.
+
+Tests spec detection, the chunks of operations and schemas with their
+operationId, tags and key paths, YAML and JSON specs, and how
+FixedSizeChunker applies it to files.
+"""
+
+import json
+
+import yaml
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.document_chunker import HEADING_PATH_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.openapi_chunker import (
+    OPENAPI_KIND_KEY,
+    OPENAPI_OPERATION_KEY,
+    OPENAPI_SCHEMA_KEY,
+    OPENAPI_TAGS_KEY,
+    OPERATION_ID_KEY,
+    chunk_openapi,
+    is_openapi,
+)
+
+SPEC = """openapi: 3.0.3
+info:
+  title: Users
+  version: "1.0"
+paths:
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+    # Fetch one user
+    get:
+      operationId: getUser
+      tags: [users]
+      responses:
+        "200":
+          description: OK
+
+    delete:
+      operationId: deleteUser
+      tags:
+        - users
+        - admin
+components:
+  schemas:
+    User:
+      type: object
+    Error:
+      type: object
+  securitySchemes:
+    bearer:
+      type: http
+"""
+
+
+class TestChunkOpenapi:
+    """Tests for splitting specs into operations and schemas."""
+
+    def test_detection(self):
+        assert is_openapi("yaml", SPEC)
+        assert is_openapi("json", '{"swagger": "2.0", "paths": {}}')
+        assert not is_openapi("yaml", "name: ci\non: push\n")
+        assert not is_openapi("md", SPEC)
+        assert chunk_openapi("openapi: [unclosed\n", 1000, 150) is None
+        assert chunk_openapi("title: not a spec\n", 1000, 150) is None
+
+    def test_operations_and_schemas(self):
+        chunks = chunk_openapi(SPEC, 1000, 150)
+
+        assert [c[OPENAPI_KIND_KEY] for c in chunks] == [
+            "spec",
+            "operation",
+            "operation",
+            "schema",
+            "schema",
+            "spec",
+        ]
+        get, delete = chunks[1], chunks[2]
+        assert get[OPENAPI_OPERATION_KEY] == "GET /users/{id}"
+        assert get[OPERATION_ID_KEY] == "getUser"
+        assert get[OPENAPI_TAGS_KEY] == ["users"]
+        assert get[HEADING_PATH_KEY] == ["paths", "/users/{id}", "get"]
+        assert get["text"].startswith("    # Fetch one user\n    get:\n")
+        assert (get["line_start"], get["line_end"]) == (10, 16)
+        assert delete[OPENAPI_TAGS_KEY] == ["users", "admin"]
+        assert chunks[4][OPENAPI_SCHEMA_KEY] == "Error"
+        assert chunks[4][HEADING_PATH_KEY] == ["components", "schemas", "Error"]
+        assert OPENAPI_OPERATION_KEY not in chunks[0]
+        # Chunks are exact, contiguous excerpts of the file
+        assert "".join(c["text"] for c in chunks) == SPEC
+
+    def test_json_and_swagger_2(self):
+        spec = yaml.safe_load(SPEC)
+        spec = {
+            "swagger": "2.0",
+            "paths": spec["paths"],
+            "definitions": spec["components"]["schemas"],
+        }
+        text = json.dumps(spec, indent=2)
+
+        chunks = chunk_openapi(text, 1000, 150)
+
+        assert [c.get(OPERATION_ID_KEY) for c in chunks[1:3]] == [
+            "getUser",
+            "deleteUser",
+        ]
+        assert chunks[3][HEADING_PATH_KEY] == ["definitions", "User"]
+        assert "".join(c["text"] for c in chunks) == text
+
+    def test_long_operations_are_windowed(self):
+        chunks = chunk_openapi(SPEC, 60, 10)
+
+        gets = [c for c in chunks if c.get(OPERATION_ID_KEY) == "getUser"]
+        assert len(gets) > 1
+        assert all(len(c["text"]) <= 60 for c in gets)
+        assert all(c[OPENAPI_TAGS_KEY] == ["users"] for c in gets)
+
+
+class TestFixedSizeChunkerOpenapi:
+    """Tests for spec files in FixedSizeChunker."""
+
+    def test_specs_are_chunked_by_operation(self, tmp_path):
+        spec = tmp_path / "openapi.yaml"
+        spec.write_text(SPEC)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(spec)
+
+        assert [c["chunk_index"] for c in chunks] == [0, 1, 2, 3, 4, 5]
+        assert chunks[1]["file_extension"] == "yaml"
+        assert chunks[1][OPERATION_ID_KEY] == "getUser"
+
+    def test_other_yaml_files_are_unchanged(self, tmp_path):
+        workflow = tmp_path / "ci.yml"
+        workflow.write_text("name: ci\non: push\n")
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(workflow)
+
+        assert len(chunks) == 1
+        assert OPENAPI_KIND_KEY not in chunks[0]
@@ -0,0 +1,135 @@
+"""
+Unit tests for definition-aware chunking of Protocol Buffers files.
+
+Tests statement parsing, the chunks of messages, enums and rpcs with their
+names, packages and options, and how FixedSizeChunker applies it to files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.proto_chunker import (
+    PROTO_KIND_KEY,
+    PROTO_NAME_KEY,
+    PROTO_OPTIONS_KEY,
+    PROTO_PACKAGE_KEY,
+    chunk_proto,
+    grpc_method_path,
+    parse_statements,
+)
+
+PROTO = """// User service API
+syntax = "proto3";
+
+package acme.user.v1;
+
+import "google/api/annotations.proto";
+
+option go_package = "github.com/acme/user/v1;userv1";
+
+// A user.
+message User {
+  option deprecated = true;
+  string id = 1 [json_name = "id"];
+  message Address { string city = 1; }
+  oneof contact {
+    string email = 2; // not "a } brace"
+  }
+}
+
+enum Role { ROLE_UNSPECIFIED = 0; ROLE_ADMIN = 1; }
+
+// Users.
+service UserService {
+  // Gets a user.
+  rpc GetUser(GetUserRequest) returns (User) {
+    option (google.api.http) = { get: "/v1/users/{id}" };
+  }
+
+  rpc ListUsers(ListUsersRequest) returns (stream User);
+}
+
+extend google.protobuf.FieldOptions { string label = 50000; }
+// trailing comment
+"""
+
+GO_PACKAGE = "go_package=github.com/acme/user/v1;userv1"
+
+
+def definitions(chunks):
+    return [
+        (c[PROTO_KIND_KEY], c.get(PROTO_NAME_KEY), c["line_start"], c["line_end"])
+        for c in chunks
+    ]
+
+
+class TestChunkProto:
+    """Tests for chunk_proto()."""
+
+    def test_parse_statements(self):
+        statements = parse_statements(PROTO)
+
+        assert [s.keyword for s in statements] == [
+            "syntax",
+            "package",
+            "import",
+            "option",
+            "message",
+            "enum",
+            "service",
+            "extend",
+        ]
+        service = statements[6]
+        assert [c.name for c in service.children] == ["GetUser", "ListUsers"]
+        assert service.children[0].children[0].value == '{ get: "/v1/users/{id}" }'
+
+    def test_definitions(self):
+        chunks = chunk_proto(PROTO, 4096, 600)
+
+        assert definitions(chunks) == [
+            ("file", None, 1, 8),
+            ("message", "acme.user.v1.User", 9, 18),
+            ("enum", "acme.user.v1.Role", 19, 20),
+            ("rpc", "acme.user.v1.UserService.GetUser", 21, 27),
+            ("rpc", "acme.user.v1.UserService.ListUsers", 28, 30),
+            ("extend", "google.protobuf.FieldOptions", 31, 33),
+        ]
+        assert chunks[3]["text"].startswith("\n// Users.\nservice UserService {\n")
+        assert "".join(c["text"] for c in chunks) == PROTO
+
+    def test_package_and_options(self):
+        chunks = chunk_proto(PROTO, 4096, 600)
+
+        assert {c[PROTO_PACKAGE_KEY] for c in chunks} == {"acme.user.v1"}
+        assert chunks[0][PROTO_OPTIONS_KEY] == [GO_PACKAGE]
+        assert chunks[1][PROTO_OPTIONS_KEY] == [GO_PACKAGE, "deprecated=true"]
+        assert chunks[3][PROTO_OPTIONS_KEY] == [
+            GO_PACKAGE,
+            '(google.api.http)={ get: "/v1/users/{id}" }',
+        ]
+
+    def test_long_definitions_are_windowed(self):
+        text = "message Big {\n" + "  string f = 1;\n" * 30 + "}\n"
+
+        chunks = chunk_proto(text, 200, 30)
+
+        assert len(chunks) == 3
+        assert all(c[PROTO_NAME_KEY] == "Big" for c in chunks)
+        assert PROTO_PACKAGE_KEY not in chunks[0]
+
+    def test_grpc_method_path(self):
+        assert grpc_method_path("acme.v1.Users.Get") == "acme.v1.Users/Get"
+        assert grpc_method_path("Get") is None
+
+
+class TestFixedSizeChunkerProto:
+    """Tests for .proto files in FixedSizeChunker."""
+
+    def test_proto_files_are_chunked_by_definition(self, tmp_path):
+        proto = tmp_path / "user.proto"
+        proto.write_text(PROTO)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(proto)
+
+        assert [c["chunk_index"] for c in chunks] == [0, 1, 2, 3, 4, 5]
+        assert chunks[3]["file_extension"] == "proto"
+        assert chunks[3][PROTO_KIND_KEY] == "rpc"
@@ -0,0 +1,119 @@
+"""
+Unit tests for definition-aware chunking of R files.
+
+Tests finding functions and S4, reference and R6 classes past strings and
+comments, statements continued over several lines, roxygen comments, and
+how FixedSizeChunker applies it to .R files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.dart_chunker import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.r_chunker import chunk_r, is_r
+
+SOURCE = """library(R6)
+library(methods)
+
+#' Area of a shape
+#'
+#' @param shape A shape
+#' @export
+area <- function(shape, ...) {
+  # not a } brace
+  msg <- "closing } in a string"
+  UseMethod("area")
+}
+
+scale_by <- function(x,
+                     factor = 2)
+{
+  x * factor
+}
+
+square = function(x) x^2
+
+setClass("Circle", representation(r = "numeric"))
+
+setGeneric("perimeter", function(shape) standardGeneric("perimeter"))
+
+setMethod("perimeter", "Circle", function(shape) {
+  2 * pi * shape@r
+})
+
+Account <- R6::R6Class("Account",
+  public = list(
+    balance = 0,
+    deposit = function(x) {
+      self$balance <- self$balance + x
+      invisible(self)
+    }
+  )
+)
+
+Person <- setRefClass("Person", fields = list(name = "character"))
+
+result <- c(1, 2, 3) |>
+  sum()
+print(r"(raw } string)")
+"""
+
+
+def symbols_of(chunks):
+    return [(c[SYMBOL_KIND_KEY], c.get(SYMBOL_NAME_KEY)) for c in chunks]
+
+
+class TestChunkR:
+    """Tests for splitting R files into definitions."""
+
+    def test_detection(self):
+        assert is_r("R")
+        assert is_r("r")
+        assert not is_r("rb")
+
+    def test_functions_and_classes(self):
+        chunks = chunk_r(SOURCE, 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("file", None),
+            ("function", "area"),
+            ("function", "scale_by"),
+            ("function", "square"),
+            ("s4_class", "Circle"),
+            ("s4_generic", "perimeter"),
+            ("s4_method", "perimeter,Circle"),
+            ("r6_class", "Account"),
+            ("ref_class", "Person"),
+            ("file", None),
+        ]
+        assert "".join(c["text"] for c in chunks) == SOURCE
+
+    def test_roxygen_strings_and_continued_statements(self):
+        chunks = chunk_r(SOURCE, 2000, 300)
+
+        assert chunks[1]["text"].startswith("#' Area of a shape\n")
+        assert chunks[1]["text"].endswith('  UseMethod("area")\n}\n\n')
+        # The body of scale_by starts on the line after its arguments
+        assert (chunks[2]["line_start"], chunks[2]["line_end"]) == (14, 18)
+        assert chunks[-1]["text"].endswith('print(r"(raw } string)")\n')
+
+    def test_long_function_is_windowed(self):
+        body = "".join(f"  x <- x + {i}\n" for i in range(40))
+        text = f"accumulate <- function(x) {{\n{body}  x\n}}\n"
+
+        chunks = chunk_r(text, 200, 50)
+
+        assert len(chunks) > 1
+        assert all(c[SYMBOL_NAME_KEY] == "accumulate" for c in chunks)
+        assert chunks[-1]["line_end"] == text.count("\n")
+
+
+class TestFixedSizeChunkerR:
+    """Tests for R files in FixedSizeChunker."""
+
+    def test_r_files_are_chunked_by_definition(self, tmp_path):
+        (tmp_path / "shapes.R").write_text(SOURCE)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(tmp_path / "shapes.R")
+
+        assert [c["chunk_index"] for c in chunks] == list(range(10))
+        assert chunks[7][SYMBOL_KIND_KEY] == "r6_class"
@@ -0,0 +1,144 @@
+"""
+Unit tests for item-aware chunking of Rust files.
+
+Tests finding items, impl blocks and macros inside inline modules past
+strings, characters, lifetimes and nested comments, derive attributes, the
+chunk lines, and how FixedSizeChunker applies it to .rs files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.boundary_chunking import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.rust_chunker import RUST_DERIVES_KEY, chunk_rust, is_rust
+
+SOURCE = """//! Geometry helpers
+use std::fmt;
+
+pub const ORIGIN: Point<f64> = Point { x: 0.0, y: 0.0 };
+
+/// A point in the plane.
+#[derive(Debug, Clone, serde::Serialize)]
+#[serde(rename_all = "camelCase")]
+pub struct Point<T> {
+    pub x: T,
+    pub y: T,
+}
+
+pub struct Meters(pub f64);
+
+impl<T: fmt::Display> fmt::Display for Point<T>
+where
+    T: Copy,
+{
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "({}, {}) {{", self.x, self.y)?;
+        let brace = '}';
+        let raw = r#"}"#;
+        Ok(())
+    }
+}
+
+#[macro_export]
+macro_rules! point {
+    ($x:expr, $y:expr) => {
+        Point { x: $x, y: $y }
+    };
+}
+
+pub(crate) async unsafe fn parse<'a>(input: &'a str, buf: [u8; 4]) -> Option<&'a str> {
+    /* } nested /* } */ */
+    Some(input)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn displays() {
+        assert_eq!(format!("{}", Point { x: 1, y: 2 }), "(1, 2) {");
+    }
+}
+
+type Pair = (Point<f64>, Point<f64>);
+"""
+
+
+def symbols_of(chunks):
+    return [(c[SYMBOL_KIND_KEY], c.get(SYMBOL_NAME_KEY)) for c in chunks]
+
+
+class TestChunkRust:
+    """Tests for splitting Rust files into items."""
+
+    def test_detection(self):
+        assert is_rust("rs")
+        assert not is_rust("r")
+
+    def test_items(self):
+        chunks = chunk_rust(SOURCE, 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("file", None),
+            ("struct", "Point"),
+            ("struct", "Meters"),
+            ("impl", "fmt::Display for Point<T>"),
+            ("macro", "point"),
+            ("function", "parse"),
+            ("module", "tests"),
+            ("function", "displays"),
+            ("type", "Pair"),
+        ]
+        assert "".join(c["text"] for c in chunks) == SOURCE
+
+    def test_strings_characters_and_comments_do_not_end_items(self):
+        chunks = chunk_rust(SOURCE, 2000, 300)
+
+        impl = chunks[3]
+        assert (impl["line_start"], impl["line_end"]) == (16, 26)
+        assert impl["text"].endswith("        Ok(())\n    }\n}\n\n")
+        assert (chunks[5]["line_start"], chunks[5]["line_end"]) == (35, 38)
+
+    def test_doc_comments_and_attributes_belong_to_the_item(self):
+        chunks = chunk_rust(SOURCE, 2000, 300)
+
+        assert chunks[1]["text"].startswith("/// A point in the plane.\n#[derive(")
+        assert chunks[1][RUST_DERIVES_KEY] == ["Debug", "Clone", "serde::Serialize"]
+        assert chunks[4]["text"].startswith("#[macro_export]\nmacro_rules! point")
+        assert chunks[6]["text"].startswith("#[cfg(test)]\nmod tests {")
+        assert RUST_DERIVES_KEY not in chunks[2]
+
+    def test_one_line_items(self):
+        text = "mod a { fn f() {} }\n#[inline] fn g() -> u8 { 1 }\nstruct Unit;\n"
+
+        chunks = chunk_rust(text, 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("module", "a"),
+            ("function", "g"),
+            ("struct", "Unit"),
+        ]
+
+    def test_long_function_is_windowed(self):
+        body = "".join(f"    x += {i};\n" for i in range(40))
+        text = f"fn accumulate(mut x: u64) -> u64 {{\n{body}    x\n}}\n"
+
+        chunks = chunk_rust(text, 200, 50)
+
+        assert len(chunks) > 1
+        assert all(c[SYMBOL_NAME_KEY] == "accumulate" for c in chunks)
+        assert chunks[-1]["line_end"] == text.count("\n")
+
+
+class TestFixedSizeChunkerRust:
+    """Tests for Rust files in FixedSizeChunker."""
+
+    def test_rust_files_are_chunked_by_item(self, tmp_path):
+        source = tmp_path / "geometry.rs"
+        source.write_text(SOURCE)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(source)
+
+        assert chunks[0]["file_extension"] == "rs"
+        assert chunks[3][SYMBOL_NAME_KEY] == "fmt::Display for Point<T>"
+        assert chunks[3]["chunk_index"] == 3
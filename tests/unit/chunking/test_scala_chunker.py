@@ -0,0 +1,179 @@
+"""
+Unit tests for definition-aware chunking of Scala files.
+
+Tests finding classes, objects, methods, givens, extensions and opaque
+types in brace and indented bodies past strings and comments, names
+qualified by their enclosing objects, the chunk lines, and how
+FixedSizeChunker applies it to .scala files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.boundary_chunking import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.scala_chunker import chunk_scala, is_scala
+
+SOURCE = """package geometry
+
+import scala.annotation.tailrec
+
+/** Shapes of the plane. */
+sealed trait Shape {
+  def area: Double
+}
+
+final case class Circle(radius: Double) extends Shape {
+  val label = "circle {"
+  def area: Double = math.Pi * radius * radius
+
+  /* a } in a comment */
+  override def toString: String = s"Circle($radius)"
+}
+
+object Shapes:
+  val unit = Circle(1.0)
+
+  @tailrec
+  def sum(shapes: List[Shape], total: Double = 0): Double =
+    shapes match
+      case Nil => total
+      case s :: rest => sum(rest, total + s.area)
+
+  extension (c: Circle)
+    def diameter: Double = c.radius * 2
+    def scale(k: Double): Circle = Circle(c.radius * k)
+
+  given Ordering[Circle] with
+    def compare(a: Circle, b: Circle): Int = a.radius.compare(b.radius)
+end Shapes
+
+enum Color(val rgb: Int):
+  case Red extends Color(0xff0000)
+  case Green extends Color(0x00ff00)
+
+  def hex: String = f"#$rgb%06x"
+
+opaque type Meters = Double
+
+given metersOrdering: Ordering[Meters] = Ordering.Double.TotalOrdering
+
+def render(
+    shape: Shape,
+    color: Color
+): String = s"$shape in $color"
+"""
+
+
+def symbols_of(chunks):
+    return [(c[SYMBOL_KIND_KEY], c.get(SYMBOL_NAME_KEY)) for c in chunks]
+
+
+class TestChunkScala:
+    """Tests for splitting Scala files into definitions."""
+
+    def test_detection(self):
+        assert is_scala("scala")
+        assert is_scala("sc")
+        assert not is_scala("s")
+
+    def test_definitions(self):
+        chunks = chunk_scala(SOURCE, 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("file", None),
+            ("trait", "Shape"),
+            ("function", "Shape.area"),
+            ("class", "Circle"),
+            ("function", "Circle.area"),
+            ("function", "Circle.toString"),
+            ("object", "Shapes"),
+            ("function", "Shapes.sum"),
+            ("extension", "Circle"),
+            ("function", "Shapes.diameter"),
+            ("function", "Shapes.scale"),
+            ("given", "Ordering[Circle]"),
+            ("enum", "Color"),
+            ("function", "Color.hex"),
+            ("type", "Meters"),
+            ("given", "metersOrdering"),
+            ("function", "render"),
+        ]
+        assert "".join(c["text"] for c in chunks) == SOURCE
+
+    def test_strings_and_comments_do_not_end_bodies(self):
+        chunks = chunk_scala(SOURCE, 2000, 300)
+
+        circle = chunks[3]
+        assert (circle["line_start"], circle["line_end"]) == (10, 11)
+        assert 'val label = "circle {"' in circle["text"]
+        assert chunks[5]["text"].startswith("  /* a } in a comment */\n")
+        assert chunks[5]["text"].endswith("}\n\n")
+
+    def test_indented_bodies(self):
+        chunks = chunk_scala(SOURCE, 2000, 300)
+
+        assert chunks[6]["text"] == "object Shapes:\n  val unit = Circle(1.0)\n\n"
+        assert (chunks[7]["line_start"], chunks[7]["line_end"]) == (21, 25)
+        assert chunks[7]["text"].startswith("  @tailrec\n  def sum(")
+        given = chunks[11]
+        assert given["text"].endswith("end Shapes\n\n")
+        assert "case Green" in chunks[12]["text"]
+
+    def test_scaladoc_belongs_to_the_definition(self):
+        chunks = chunk_scala(SOURCE, 2000, 300)
+
+        assert chunks[1]["text"].startswith("/** Shapes of the plane. */\n")
+        assert chunks[0]["text"].endswith("import scala.annotation.tailrec\n\n")
+
+    def test_braces_on_their_own_line_and_one_line_bodies(self):
+        text = (
+            "object Outer {\n"
+            "  class Inner\n"
+            "  {\n"
+            "    def `type`: String = \"}\"\n"
+            "  }\n"
+            "  implicit class RichInt(val n: Int) { def twice: Int = n * 2 }\n"
+            "}\n"
+        )
+
+        chunks = chunk_scala(text, 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("object", "Outer"),
+            ("class", "Outer.Inner"),
+            ("function", "Outer.Inner.type"),
+            ("class", "Outer.RichInt"),
+        ]
+
+    def test_anonymous_given_with_type_parameters(self):
+        text = (
+            "given [T](using o: Ordering[T]): Ordering[List[T]] with\n"
+            "  def compare(x: List[T], y: List[T]): Int = 0\n"
+        )
+
+        assert symbols_of(chunk_scala(text, 2000, 300)) == [
+            ("given", "Ordering[List[T]]")
+        ]
+
+    def test_long_method_is_windowed(self):
+        body = "".join(f"    total += {i}\n" for i in range(40))
+        text = f"def accumulate(start: Int): Int =\n    var total = start\n{body}"
+
+        chunks = chunk_scala(text, 200, 50)
+
+        assert len(chunks) > 1
+        assert all(c[SYMBOL_NAME_KEY] == "accumulate" for c in chunks)
+        assert chunks[-1]["line_end"] == text.count("\n")
+
+
+class TestFixedSizeChunkerScala:
+    """Tests for Scala files in FixedSizeChunker."""
+
+    def test_scala_files_are_chunked_by_definition(self, tmp_path):
+        source = tmp_path / "Shapes.scala"
+        source.write_text(SOURCE)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(source)
+
+        assert chunks[0]["file_extension"] == "scala"
+        assert chunks[7][SYMBOL_NAME_KEY] == "Shapes.sum"
+        assert chunks[7]["chunk_index"] == 7
@@ -0,0 +1,117 @@
+"""
+Unit tests for function-aware chunking of shell scripts.
+
+Tests finding functions and case blocks past heredocs, strings and
+comments, the chunk fields and lines, and how FixedSizeChunker applies it
+to scripts, including extensionless scripts detected by their shebang.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.shell_chunker import (
+    SHELL_BLOCK_KEY,
+    SHELL_FUNCTION_KEY,
+    chunk_shell,
+    is_shell,
+)
+
+SCRIPT = """#!/usr/bin/env bash
+set -euo pipefail
+
+# Shared settings
+REGION="${REGION:-us-east-1}"
+
+# Build the image
+build() {
+  local tag="$1"
+  docker build -t "app:${tag}" . # not a } brace
+  cat <<EOF > /tmp/notes
+}
+function fake() {
+EOF
+}
+
+function deploy {
+  if [[ -n "$1" ]]; then
+    echo "deploying { $1"
+  fi
+  kubectl apply -f - <<-'YAML'
+\tkind: Deployment
+\tYAML
+}
+
+usage() { echo "usage: $0 build|deploy"; }
+
+case "${1:-}" in
+  build) build "$2" ;;
+  deploy)
+    case "$2" in prod) deploy prod ;; esac
+    ;;
+  *) usage ;;
+esac
+echo done
+"""
+
+
+def blocks_of(chunks):
+    return [(c[SHELL_BLOCK_KEY], c.get(SHELL_FUNCTION_KEY)) for c in chunks]
+
+
+class TestChunkShell:
+    """Tests for splitting scripts into functions and blocks."""
+
+    def test_detection(self):
+        assert is_shell("bash")
+        assert is_shell("zsh")
+        assert not is_shell("ps1")
+
+    def test_functions_and_case_blocks(self):
+        chunks = chunk_shell(SCRIPT, 1000, 150)
+
+        assert blocks_of(chunks) == [
+            ("script", None),
+            ("function", "build"),
+            ("function", "deploy"),
+            ("function", "usage"),
+            ("case", None),
+            ("script", None),
+        ]
+        assert "".join(c["text"] for c in chunks) == SCRIPT
+
+    def test_heredocs_and_strings_do_not_end_functions(self):
+        chunks = chunk_shell(SCRIPT, 1000, 150)
+
+        # Braces and "function" inside heredocs, strings and comments
+        assert chunks[1]["text"].startswith("# Build the image\nbuild() {\n")
+        assert chunks[1]["text"].rstrip().endswith("EOF\n}")
+        assert (chunks[1]["line_start"], chunks[1]["line_end"]) == (7, 15)
+        assert chunks[2]["text"].rstrip().endswith("\tYAML\n}")
+
+    def test_nested_case_block(self):
+        chunks = chunk_shell(SCRIPT, 1000, 150)
+
+        assert chunks[4]["text"].startswith('case "${1:-}" in\n')
+        assert chunks[4]["text"].endswith("esac\n")
+        assert chunks[5]["text"] == "echo done\n"
+
+    def test_long_function_is_windowed(self):
+        body = "".join(f"  echo line {i}\n" for i in range(40))
+        text = f"main() {{\n{body}}}\n"
+
+        chunks = chunk_shell(text, 200, 50)
+
+        assert len(chunks) > 1
+        assert all(c[SHELL_FUNCTION_KEY] == "main" for c in chunks)
+        assert chunks[-1]["line_end"] == text.count("\n")
+
+
+class TestFixedSizeChunkerShell:
+    """Tests for shell scripts in FixedSizeChunker."""
+
+    def test_extensionless_scripts_are_chunked_by_function(self, tmp_path):
+        (tmp_path / "deploy").write_text(SCRIPT)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(tmp_path / "deploy")
+
+        assert [c["chunk_index"] for c in chunks] == [0, 1, 2, 3, 4, 5]
+        assert chunks[2][SHELL_FUNCTION_KEY] == "deploy"
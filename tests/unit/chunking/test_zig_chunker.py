@@ -0,0 +1,140 @@
+"""
+Unit tests for declaration-aware chunking of Zig files.
+
+Tests finding functions inside containers, error sets, tests and comptime
+blocks past strings, multi-line string literals and characters, doc
+comments, the chunk lines, and how FixedSizeChunker applies it to .zig files.
+"""
+
+from code_indexer.config import IndexingConfig
+from code_indexer.indexing.boundary_chunking import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from code_indexer.indexing.fixed_size_chunker import FixedSizeChunker
+from code_indexer.indexing.zig_chunker import chunk_zig, is_zig
+
+SOURCE = """//! Geometry helpers
+const std = @import("std");
+const Allocator = std.mem.Allocator;
+
+/// A point in the plane.
+pub const Point = struct {
+    x: f32,
+    y: f32,
+
+    /// Creates a point.
+    pub fn init(x: f32, y: f32) Point {
+        return .{ .x = x, .y = y };
+    }
+
+    pub fn format(self: Point, writer: anytype) !void {
+        try writer.print("({d}, {d}) }}", .{ self.x, self.y });
+        const brace = '}';
+        _ = brace;
+    }
+
+    pub const Axis = enum { x, y };
+};
+
+pub const ParseError = error{
+    InvalidCharacter,
+    Overflow,
+};
+
+const usage =
+    \\\\usage: geometry {
+    \\\\  --help
+;
+
+extern "c" fn write(fd: c_int, buf: [*]const u8, len: usize) isize;
+
+pub fn parse(
+    allocator: Allocator,
+    input: []const u8,
+) ParseError![]Point {
+    _ = allocator;
+    _ = input;
+    return error.Overflow;
+}
+
+comptime {
+    std.debug.assert(@sizeOf(Point) == 8);
+}
+
+test "point init" {
+    const p = Point.init(1, 2);
+    try std.testing.expectEqual(@as(f32, 1), p.x);
+}
+"""
+
+
+def symbols_of(chunks):
+    return [(c[SYMBOL_KIND_KEY], c.get(SYMBOL_NAME_KEY)) for c in chunks]
+
+
+class TestChunkZig:
+    """Tests for splitting Zig files into declarations."""
+
+    def test_detection(self):
+        assert is_zig("zig")
+        assert not is_zig("z")
+
+    def test_declarations(self):
+        chunks = chunk_zig(SOURCE, 2000, 300)
+
+        assert symbols_of(chunks) == [
+            ("file", None),
+            ("struct", "Point"),
+            ("function", "Point.init"),
+            ("function", "Point.format"),
+            ("enum", "Point.Axis"),
+            ("error", "ParseError"),
+            ("file", None),
+            ("function", "write"),
+            ("function", "parse"),
+            ("comptime", None),
+            ("test", "point init"),
+        ]
+        assert "".join(c["text"] for c in chunks) == SOURCE
+
+    def test_strings_and_characters_do_not_end_functions(self):
+        chunks = chunk_zig(SOURCE, 2000, 300)
+
+        format_fn = chunks[3]
+        assert (format_fn["line_start"], format_fn["line_end"]) == (15, 19)
+        assert format_fn["text"].endswith("        _ = brace;\n    }\n\n")
+        # A "{" in a multi-line string literal opens nothing
+        assert (chunks[6]["line_start"], chunks[6]["line_end"]) == (28, 32)
+        assert (chunks[8]["line_start"], chunks[8]["line_end"]) == (36, 43)
+
+    def test_doc_comments_belong_to_the_declaration(self):
+        chunks = chunk_zig(SOURCE, 2000, 300)
+
+        assert chunks[1]["text"].startswith("/// A point in the plane.\n")
+        assert chunks[2]["text"].startswith("    /// Creates a point.\n")
+
+    def test_unnamed_tests(self):
+        chunks = chunk_zig("test {\n    _ = @import(\"a.zig\");\n}\n", 2000, 300)
+
+        assert symbols_of(chunks) == [("test", None)]
+
+    def test_long_function_is_windowed(self):
+        body = "".join(f"    x += {i};\n" for i in range(40))
+        text = f"fn accumulate() u64 {{\n    var x = 0;\n{body}    return x;\n}}\n"
+
+        chunks = chunk_zig(text, 200, 50)
+
+        assert len(chunks) > 1
+        assert all(c[SYMBOL_NAME_KEY] == "accumulate" for c in chunks)
+        assert chunks[-1]["line_end"] == text.count("\n")
+
+
+class TestFixedSizeChunkerZig:
+    """Tests for Zig files in FixedSizeChunker."""
+
+    def test_zig_files_are_chunked_by_declaration(self, tmp_path):
+        source = tmp_path / "geometry.zig"
+        source.write_text(SOURCE)
+
+        chunks = FixedSizeChunker(IndexingConfig()).chunk_file(source)
+
+        assert chunks[0]["file_extension"] == "zig"
+        assert chunks[2][SYMBOL_NAME_KEY] == "Point.init"
@@ -229,6 +229,15 @@ class TestMinimalArgumentParsing:
         assert isinstance(result["filters"]["path_filter"], list)
         assert result["filters"]["path_filter"] == ["*/tests/*", "*/src/*"]
 
+    def test_parse_comma_separated_exclude_paths(self):
+        """Test comma-separated --exclude-path patterns accumulate like repeats."""
+        from code_indexer.cli_daemon_fast import parse_query_args
+
+        args = ["term", "--exclude-path", "vendor/**,**/testdata/**"]
+        result = parse_query_args(args)
+
+        assert result["filters"]["exclude_path"] == ["vendor/**", "**/testdata/**"]
+
 
 class TestDisplayFormatting:
     """Test display formatting for multiple filter values."""
@@ -0,0 +1,89 @@
+"""
+Unit tests for grouping query results by file.
+
+Tests that the collapsed matches of a file are shown as "+N more" and that
+grouping flags outside local semantic search are reported one per flag.
+"""
+
+from io import StringIO
+from unittest.mock import patch
+
+from click.testing import CliRunner
+from rich.console import Console
+
+from code_indexer.cli import _display_semantic_results, query
+from code_indexer.services.result_grouping import group_results
+
+
+def result(path, score, line_start):
+    return {
+        "score": score,
+        "payload": {
+            "path": path,
+            "line_start": line_start,
+            "line_end": line_start + 5,
+            "content": "def handler():\n    pass",
+            "language": "python",
+        },
+    }
+
+
+RESULTS = [
+    result("src/auth.py", 0.9, 10),
+    result("src/auth.py", 0.8, 40),
+    result("src/db.py", 0.7, 5),
+    result("src/auth.py", 0.6, 80),
+]
+
+
+def render(results, quiet):
+    output = StringIO()
+    _display_semantic_results(
+        results=results,
+        console=Console(file=output, force_terminal=False, width=120),
+        quiet=quiet,
+    )
+    return output.getvalue()
+
+
+class TestGroupedResultDisplay:
+    """Tests for showing the collapsed matches of a file."""
+
+    def test_quiet_mode_shows_more_in_file(self):
+        output = render(group_results(RESULTS), quiet=True)
+
+        assert "1. 0.900 src/auth.py:10-15 (+2 more in file)" in output
+        assert "2. 0.700 src/db.py:5-10" in output
+        assert "src/db.py:5-10 (+" not in output
+
+    def test_regular_mode_shows_more_in_file(self):
+        output = render(group_results(RESULTS), quiet=False)
+
+        assert "2 more in file" in output
+
+    def test_hits_cut_by_limit_are_counted(self):
+        output = render(group_results(RESULTS, max_per_file=2, limit=2), quiet=True)
+
+        assert "1. 0.900 src/auth.py:10-15 (+1 more in file)" in output
+        assert "src/db.py" not in output
+
+
+class TestGroupingOutsideLocalSemanticSearch:
+    """Tests for the errors of grouping flags in other search modes."""
+
+    def test_each_flag_is_reported(self):
+        runner = CliRunner()
+
+        with patch(
+            "code_indexer.disabled_commands.detect_current_mode", return_value="local"
+        ):
+            outcome = runner.invoke(
+                query,
+                ["handler", "--fts", "--group-by", "file", "--max-per-file", "2"],
+                obj={"mode": "local"},
+            )
+
+        assert outcome.exit_code == 1
+        assert "--group-by applies to local semantic search only" in outcome.output
+        assert "--max-per-file applies to local semantic search only" in outcome.output
+        assert "--grep" not in outcome.output
@@ -0,0 +1,290 @@
+"""
+Unit tests for the CidxClient library API.
+
+Tests mode detection, the local query pipeline, indexing and status results,
+and remote operations mapped to typed results.
+"""
+
+import json
+from types import SimpleNamespace
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from code_indexer.client import (
+    CidxClient,
+    CidxClientError,
+    IndexResult,
+    QueryResult,
+)
+from code_indexer.client.cidx_client import _index_signature, _LocalServices
+from code_indexer.indexing.processor import ProcessingStats
+
+
+def local_project(root):
+    (root / ".code-indexer").mkdir()
+    (root / ".code-indexer" / "config.json").write_text(json.dumps({}))
+    return root
+
+
+def remote_project(root):
+    (root / ".code-indexer").mkdir()
+    (root / ".code-indexer" / ".remote-config").write_text(
+        json.dumps(
+            {
+                "server_url": "https://cidx.example.com",
+                "encrypted_credentials": "x",
+                "username": "dev",
+                "repository_link": {"alias": "payments"},
+            }
+        )
+    )
+    return root
+
+
+def hit(path, score, line_start=1, line_end=2):
+    return {
+        "id": f"{path}:{line_start}",
+        "score": score,
+        "payload": {
+            "path": path,
+            "line_start": line_start,
+            "line_end": line_end,
+            "language": "py",
+            "content": f"# {path}",
+        },
+    }
+
+
+def local_client(root, search_results):
+    client = CidxClient(local_project(root))
+    client._config = SimpleNamespace(
+        codebase_dir=root,
+        hooks=None,
+        voyage_ai=SimpleNamespace(parallel_requests=4),
+    )
+    vector_store = MagicMock()
+    vector_store.search.return_value = search_results
+    client._local_services = MagicMock(
+        return_value=SimpleNamespace(
+            embedding_provider=MagicMock(),
+            vector_store=vector_store,
+            collection="code-indexer-voyage",
+            git_aware=False,
+        )
+    )
+    return client, vector_store
+
+
+class TestModes:
+    """Tests for opening projects."""
+
+    def test_uninitialized_project(self, tmp_path):
+        with pytest.raises(CidxClientError, match="cidx init"):
+            CidxClient(tmp_path)
+
+    def test_finds_project_from_subdirectory(self, tmp_path):
+        local_project(tmp_path)
+        (tmp_path / "src").mkdir()
+
+        client = CidxClient(tmp_path / "src")
+
+        assert client.project_root == tmp_path
+        assert client.mode == "local"
+
+    def test_local_operations_need_local_mode(self, tmp_path):
+        client = CidxClient(remote_project(tmp_path))
+
+        assert client.mode == "remote"
+        with pytest.raises(CidxClientError, match="needs a local project"):
+            client.index()
+
+
+class TestLocal:
+    """Tests for local indexing and querying."""
+
+    def test_query(self, tmp_path):
+        client, vector_store = local_client(
+            tmp_path, [hit("a.py", 0.9), hit("b.py", 0.8), hit("c.py", 0.7)]
+        )
+
+        results = client.query(
+            "retry", limit=2, languages=["python"], paths=["src/*"], min_score=0.5
+        )
+
+        assert [r.path for r in results] == ["a.py", "b.py"]
+        assert results[0] == QueryResult(
+            path="a.py",
+            score=0.9,
+            line_start=1,
+            line_end=2,
+            content="# a.py",
+            language="py",
+            payload=results[0].payload,
+        )
+        search = vector_store.search.call_args.kwargs
+        assert search["limit"] == 4
+        assert search["score_threshold"] == 0.5
+        assert search["ef"] == 100
+        assert {"key": "path", "match": {"text": "src/*"}} in search[
+            "filter_conditions"
+        ]["must"]
+
+    def test_query_reads_chunk_text_from_disk(self, tmp_path):
+        result = hit("a.py", 0.9, line_start=2, line_end=2)
+        del result["payload"]["content"]
+        client, _ = local_client(tmp_path, [result])
+        (tmp_path / "a.py").write_text("import os\ndef retry():\n")
+
+        assert client.query("retry")[0].content == "def retry():"
+
+    def test_unknown_accuracy(self, tmp_path):
+        client, _ = local_client(tmp_path, [])
+
+        with pytest.raises(CidxClientError, match="accuracy"):
+            client.query("retry", accuracy="exact")
+
+    def test_index(self, tmp_path):
+        client, _ = local_client(tmp_path, [])
+        stats = ProcessingStats(files_processed=3, chunks_created=7, failed_files=1)
+
+        with patch(
+            "code_indexer.services.smart_indexer.SmartIndexer"
+        ) as smart_indexer:
+            smart_indexer.return_value.smart_index.return_value = stats
+            result = client.index(clear=True)
+
+        assert result == IndexResult(
+            files_processed=3,
+            chunks_created=7,
+            failed_files=1,
+            duration_seconds=0.0,
+        )
+        kwargs = smart_indexer.return_value.smart_index.call_args.kwargs
+        assert kwargs["force_full"] is True
+        assert kwargs["vector_thread_count"] == 4
+
+
+class TestWarmServices:
+    """Tests for keeping services between calls."""
+
+    def test_services_are_kept_until_the_index_changes(self, tmp_path):
+        client = CidxClient(local_project(tmp_path))
+        client.hnsw_cache = MagicMock()
+        collection_path = tmp_path / ".code-indexer" / "index" / "voyage"
+        collection_path.mkdir(parents=True)
+        (collection_path / "hnsw_index.bin").write_bytes(b"v1")
+        client._create_local_services = MagicMock(
+            side_effect=lambda: _LocalServices(
+                embedding_provider=MagicMock(),
+                vector_store=MagicMock(),
+                collection="voyage",
+                collection_path=collection_path,
+                git_aware=True,
+                signature=_index_signature(collection_path),
+            )
+        )
+
+        first = client._local_services()
+        assert client._local_services() is first
+
+        (collection_path / "hnsw_index.bin").write_bytes(b"v2 rebuilt")
+
+        assert client._local_services() is not first
+        assert client._create_local_services.call_count == 2
+        client.hnsw_cache.invalidate.assert_called_once_with(str(collection_path))
+
+
+class TestSymbols:
+    """Tests for symbol lookup in SCIP indexes."""
+
+    def test_no_scip_index(self, tmp_path):
+        client = CidxClient(local_project(tmp_path))
+
+        with pytest.raises(CidxClientError, match="cidx scip generate"):
+            client.symbol("RetryPayment")
+
+    def test_definitions(self, tmp_path):
+        client = CidxClient(local_project(tmp_path))
+        scip_dir = tmp_path / ".code-indexer" / "scip"
+        scip_dir.mkdir()
+        (scip_dir / "index.scip.db").touch()
+        definition = SimpleNamespace(
+            symbol="scip-go gomod pay v1 `pay`/Service#RetryPayment().",
+            file_path="pay/service.go",
+            line=42,
+            column=17,
+        )
+
+        with patch("code_indexer.scip.query.SCIPQueryEngine") as engine:
+            engine.return_value.find_definition.return_value = [definition] * 3
+            results = client.symbol("RetryPayment", exact=True, limit=2)
+
+        assert len(results) == 2
+        assert results[0].to_dict() == {
+            "symbol": definition.symbol,
+            "path": "pay/service.go",
+            "line": 42,
+            "column": 17,
+        }
+        engine.return_value.find_definition.assert_called_with(
+            "RetryPayment", exact=True
+        )
+
+
+class TestRemote:
+    """Tests for operations against a CIDX server."""
+
+    def test_query(self, tmp_path):
+        client = CidxClient(remote_project(tmp_path))
+        item = SimpleNamespace(
+            file_path="pay.py",
+            line_number=10,
+            code_snippet="def retry():\n    pass",
+            similarity_score=0.8,
+            repository_alias="payments",
+        )
+
+        async def execute_remote_query(*args, **kwargs):
+            return [item]
+
+        with patch(
+            "code_indexer.business_logic.remote_operations.execute_remote_query",
+            side_effect=execute_remote_query,
+        ) as execute:
+            results = client.query("retry", languages=["python"])
+
+        assert results[0].to_dict() == {
+            "path": "pay.py",
+            "score": 0.8,
+            "line_start": 10,
+            "line_end": 11,
+            "content": "def retry():\n    pass",
+            "language": None,
+            "repository": "payments",
+            "payload": {},
+        }
+        assert execute.call_args.kwargs["language_filter"] == "python"
+
+    def test_query_rejects_several_filters(self, tmp_path):
+        client = CidxClient(remote_project(tmp_path))
+
+        with pytest.raises(CidxClientError, match="at most one"):
+            client.query("retry", paths=["a/*", "b/*"])
+
+    def test_remote_failure(self, tmp_path):
+        from code_indexer.business_logic.remote_operations import (
+            RemoteOperationError,
+        )
+
+        client = CidxClient(remote_project(tmp_path))
+
+        async def fail(*args, **kwargs):
+            raise RemoteOperationError("Authentication failed: expired")
+
+        with patch(
+            "code_indexer.business_logic.remote_operations.execute_remote_query",
+            side_effect=fail,
+        ):
+            with pytest.raises(CidxClientError, match="expired"):
+                client.query("retry")
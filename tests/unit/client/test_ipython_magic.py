@@ -0,0 +1,128 @@
+"""
+Unit tests for the %cidx IPython magic.
+
+Tests argument handling, input cell lookup for 'similar', and the plain text
+and HTML rendering of results.
+"""
+
+from types import SimpleNamespace
+from unittest.mock import MagicMock
+
+import pytest
+
+from code_indexer import load_ipython_extension
+from code_indexer.client import CidxClientError, QueryResult
+from code_indexer.client.ipython_magic import CidxMagic, QueryResults, cell_source
+
+HISTORY = ["", "import pandas as pd", "%cidx query x\ndef retry(p):\n    pass", ""]
+
+
+def result(path="pay/retry.py", score=0.83):
+    return QueryResult(
+        path=path,
+        score=score,
+        line_start=3,
+        line_end=4,
+        content="def retry(p):\n    return p < 5",
+    )
+
+
+@pytest.fixture
+def magic():
+    shell = SimpleNamespace(user_ns={"In": HISTORY})
+    magic = CidxMagic(shell)
+    client = MagicMock()
+    client.query.return_value = [result()]
+    magic.client = MagicMock(return_value=client)
+    return magic, client
+
+
+class TestCellSource:
+    """Tests for finding the cell a 'similar' search starts from."""
+
+    def test_previous_cell_without_magic_lines(self):
+        assert cell_source(HISTORY, None) == "def retry(p):\n    pass"
+
+    def test_cell_by_number(self):
+        assert cell_source(HISTORY, "1") == "import pandas as pd"
+        assert cell_source(HISTORY, "In[1]") == "import pandas as pd"
+
+    def test_unknown_cell(self):
+        with pytest.raises(CidxClientError, match="no input cell 7"):
+            cell_source(HISTORY, "7")
+        with pytest.raises(CidxClientError, match="not an input cell"):
+            cell_source(HISTORY, "abc")
+
+
+class TestMagic:
+    """Tests for %cidx and %%cidx."""
+
+    def test_query(self, magic):
+        magic, client = magic
+
+        results = magic('query "retry failed payments" -l 3 --language python')
+
+        assert len(results) == 1
+        client.query.assert_called_once_with(
+            "retry failed payments",
+            limit=3,
+            languages=["python"],
+            paths=[],
+            min_score=None,
+        )
+
+    def test_similar_uses_cell(self, magic):
+        magic, client = magic
+
+        magic("similar 1")
+        magic("similar --path-filter 'pay/*'", cell="def charge():\n    pass\n")
+
+        assert client.query.call_args_list[0].args == ("import pandas as pd",)
+        second = client.query.call_args_list[1]
+        assert second.args == ("def charge():\n    pass",)
+        assert second.kwargs["paths"] == ["pay/*"]
+
+    def test_errors_are_printed(self, magic, capsys):
+        magic, client = magic
+        client.query.side_effect = CidxClientError("Query failed: offline")
+
+        assert magic("query retry") is None
+        assert magic("explain retry") is None
+
+        output = capsys.readouterr().out
+        assert "cidx: Query failed: offline" in output
+        assert "Usage: %cidx" in output
+
+    def test_load_extension(self):
+        shell = MagicMock()
+
+        load_ipython_extension(shell)
+
+        kwargs = shell.register_magic_function.call_args.kwargs
+        assert kwargs == {"magic_kind": "line_cell", "magic_name": "cidx"}
+
+
+class TestRendering:
+    """Tests for displaying results."""
+
+    def test_text(self):
+        results = QueryResults("retry", [result(), result("b.py", 0.5)])
+
+        assert repr(results) == (
+            "1. pay/retry.py:3-4 (score 0.830)\n2. b.py:3-4 (score 0.500)"
+        )
+        assert repr(QueryResults("retry", [])) == "No results"
+
+    def test_html(self):
+        html = QueryResults("retry", [result("a<b>.py")])._repr_html_()
+
+        assert "1. a&lt;b&gt;.py:3-4" in html
+        assert "score 0.830" in html
+
+    def test_long_results_are_cut(self):
+        long_result = result()
+        long_result.content = "\n".join(f"line {i}" for i in range(100))
+
+        excerpt = QueryResults("retry", [long_result])._excerpt(long_result)
+
+        assert excerpt.splitlines()[-2:] == ["line 29", "..."]
@@ -0,0 +1,203 @@
+"""
+Unit tests for the editor-integration daemon ('cidx daemon').
+
+Tests per-project routing and client reuse, request validation, and the
+loopback HTTP front end with its token check.
+"""
+
+import json
+import stat
+import threading
+import time
+import urllib.error
+import urllib.request
+from types import SimpleNamespace
+from unittest.mock import MagicMock
+
+import pytest
+
+from code_indexer.client import CidxClientError, QueryResult
+from code_indexer.daemon.editor_server import EditorApi, EditorApiError, EditorServer
+
+
+def serve(server, state_file):
+    thread = threading.Thread(target=server.serve, args=(state_file,))
+    thread.start()
+    deadline = time.monotonic() + 5
+    while not state_file.exists() and time.monotonic() < deadline:
+        time.sleep(0.01)
+    return thread
+
+
+def make_project(root):
+    (root / ".code-indexer").mkdir(parents=True)
+    (root / "src").mkdir()
+    (root / "src" / "pay.py").write_text("def retry(): pass\n")
+    return root
+
+
+class FakeClient:
+    """CidxClient stand-in recording its project root."""
+
+    def __init__(self, root, hnsw_cache=None):
+        self.project_root = root
+        self.hnsw_cache = hnsw_cache
+        self.query = MagicMock(
+            return_value=[
+                QueryResult(
+                    path="src/pay.py",
+                    score=0.9,
+                    line_start=1,
+                    line_end=1,
+                    content="def retry(): pass",
+                )
+            ]
+        )
+        self.symbol = MagicMock(return_value=[])
+        self.status = MagicMock(
+            return_value=SimpleNamespace(to_dict=lambda: {"chunks": 3})
+        )
+
+
+@pytest.fixture
+def api():
+    return EditorApi(hnsw_cache=MagicMock(), client_factory=FakeClient)
+
+
+class TestEditorApi:
+    """Tests for routing requests to project clients."""
+
+    def test_requests_are_routed_to_the_enclosing_project(self, api, tmp_path):
+        root = make_project(tmp_path / "repo")
+
+        response = api.handle(
+            "/query",
+            {"project": str(root / "src" / "pay.py"), "query": "retry", "limit": 3},
+        )
+
+        assert response["results"][0]["path"] == "src/pay.py"
+        assert "took_ms" in response
+        client = api.clients[root]
+        assert client.hnsw_cache is api.hnsw_cache
+        client.query.assert_called_once_with(
+            "retry", limit=3, languages=[], paths=[], min_score=None
+        )
+
+    def test_clients_are_reused_and_least_recently_used_dropped(self, tmp_path):
+        api = EditorApi(
+            hnsw_cache=MagicMock(), max_projects=2, client_factory=FakeClient
+        )
+        a, b, c = (make_project(tmp_path / name) for name in "abc")
+
+        first = api.client(str(a))
+        assert api.client(str(a / "src")) is first
+        api.client(str(b))
+        api.client(str(a))
+        api.client(str(c))
+
+        assert list(api.clients) == [a, c]
+
+    def test_daemon_status(self, api, tmp_path):
+        root = make_project(tmp_path / "repo")
+        api.client(str(root))
+
+        response = api.handle("/status", {})
+
+        assert response["projects"] == [str(root)]
+        assert api.handle("/status", {"project": str(root)})["status"] == {
+            "chunks": 3
+        }
+
+    def test_invalid_requests(self, api, tmp_path):
+        root = str(make_project(tmp_path / "repo"))
+
+        with pytest.raises(EditorApiError, match="absolute path"):
+            api.handle("/query", {"project": "repo", "query": "retry"})
+        with pytest.raises(EditorApiError, match="'query' is required"):
+            api.handle("/query", {"project": root})
+        with pytest.raises(EditorApiError, match="list of strings"):
+            api.handle("/query", {"project": root, "query": "x", "paths": "src"})
+        with pytest.raises(EditorApiError) as error:
+            api.handle("/index", {})
+        assert error.value.status == 404
+
+    def test_client_errors(self, tmp_path):
+        def uninitialized(root, hnsw_cache=None):
+            raise CidxClientError("No cidx project found")
+
+        api = EditorApi(hnsw_cache=MagicMock(), client_factory=uninitialized)
+
+        with pytest.raises(EditorApiError) as error:
+            api.handle("/status", {"project": str(tmp_path)})
+        assert error.value.status == 404
+
+
+class TestEditorServer:
+    """Tests for the HTTP front end."""
+
+    @pytest.fixture
+    def server(self, api, tmp_path):
+        server = EditorServer(api, token="secret")
+        state_file = tmp_path / "editor-daemon.json"
+        thread = serve(server, state_file)
+        yield server, state_file
+        server.shutdown()
+        thread.join(timeout=5)
+
+    def post(self, server, path, body, headers=None):
+        request = urllib.request.Request(
+            f"http://127.0.0.1:{server.port}{path}",
+            data=json.dumps(body).encode(),
+            headers={"Authorization": "Bearer secret", **(headers or {})},
+            method="POST",
+        )
+        try:
+            with urllib.request.urlopen(request, timeout=5) as response:
+                return response.status, json.loads(response.read())
+        except urllib.error.HTTPError as e:
+            return e.code, json.loads(e.read())
+
+    def test_state_file(self, server):
+        server, state_file = server
+
+        state = json.loads(state_file.read_text())
+
+        assert state["port"] == server.port
+        assert state["token"] == "secret"
+        assert stat.S_IMODE(state_file.stat().st_mode) == 0o600
+
+    def test_query(self, server, tmp_path):
+        server, _ = server
+        root = make_project(tmp_path / "repo")
+
+        status, body = self.post(
+            server, "/query", {"project": str(root), "query": "retry"}
+        )
+
+        assert status == 200
+        assert body["results"][0]["line_start"] == 1
+
+    def test_token_and_origin_are_checked(self, server):
+        server, _ = server
+
+        status, body = self.post(
+            server, "/status", {}, headers={"Authorization": "Bearer wrong"}
+        )
+        assert status == 401
+
+        status, body = self.post(
+            server, "/status", {}, headers={"Origin": "http://example.com"}
+        )
+        assert status == 403
+        assert "Browser" in body["error"]
+
+    def test_state_file_removed_on_shutdown(self, api, tmp_path):
+        server = EditorServer(api)
+        state_file = tmp_path / "editor-daemon.json"
+        thread = serve(server, state_file)
+        assert state_file.exists()
+
+        server.shutdown()
+        thread.join(timeout=5)
+
+        assert not state_file.exists()
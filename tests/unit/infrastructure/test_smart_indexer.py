@@ -1192,3 +1192,94 @@ class TestIndexingExceptionHandling:
             # CRITICAL ASSERTION: end_indexing() MUST be called on success
             mock_filesystem_client.begin_indexing.assert_called_once()
             mock_filesystem_client.end_indexing.assert_called_once()
+
+
+class TestMembershipSnapshot:
+    """Reconcile membership checks answered from the persisted snapshot."""
+
+    def _points(self, codebase_dir):
+        return [
+            {
+                "id": "p1",
+                "payload": {
+                    "path": "src/a.py",
+                    "type": "content",
+                    "git_commit_hash": "abc123",
+                    "file_mtime": 10.0,
+                    "hidden_branches": ["feature"],
+                },
+            },
+            {
+                "id": "p2",
+                "payload": {
+                    "path": str(codebase_dir / "src" / "a.py"),
+                    "type": "content",
+                    "git_commit_hash": "abc123",
+                    "file_mtime": 20.0,
+                },
+            },
+        ]
+
+    def _indexer(self, mock_config, mock_embedding_provider, client, metadata_path):
+        client.get_membership_snapshot_path.return_value = (
+            mock_config.codebase_dir / "membership_snapshot.bin"
+        )
+        return SmartIndexer(
+            mock_config, mock_embedding_provider, client, metadata_path
+        )
+
+    def test_snapshot_built_once_then_reused(
+        self,
+        mock_config,
+        mock_embedding_provider,
+        mock_filesystem_client,
+        temp_metadata_path,
+    ):
+        indexer = self._indexer(
+            mock_config,
+            mock_embedding_provider,
+            mock_filesystem_client,
+            temp_metadata_path,
+        )
+
+        with patch.object(
+            indexer,
+            "_scroll_all_content_points",
+            return_value=self._points(mock_config.codebase_dir),
+        ) as mock_scroll:
+            first = indexer._get_indexed_files_snapshot("test_collection")
+            second = indexer._get_indexed_files_snapshot("test_collection")
+
+        mock_scroll.assert_called_once()
+        assert first == second == {mock_config.codebase_dir / "src" / "a.py": 20.0}
+
+    def test_per_file_checks_use_snapshot_instead_of_queries(
+        self,
+        mock_config,
+        mock_embedding_provider,
+        mock_filesystem_client,
+        temp_metadata_path,
+    ):
+        indexer = self._indexer(
+            mock_config,
+            mock_embedding_provider,
+            mock_filesystem_client,
+            temp_metadata_path,
+        )
+        with patch.object(
+            indexer,
+            "_scroll_all_content_points",
+            return_value=self._points(mock_config.codebase_dir),
+        ):
+            indexer._get_indexed_files_snapshot("test_collection")
+        mock_filesystem_client.scroll_points.reset_mock()
+
+        assert (
+            indexer._get_any_content_id_for_file("src/a.py", "test_collection")
+            == "src/a.py:abc123"
+        )
+        assert indexer._get_any_content_id_for_file("b.py", "test_collection") is None
+        assert indexer._get_hidden_branches_for_file(
+            "src/a.py", "test_collection"
+        ) == ["feature"]
+        mock_filesystem_client.scroll_points.assert_not_called()
@@ -0,0 +1,358 @@
+"""Tests for index delta sync.
+
+Covers the delta wire format, git change detection, applying a delta against
+the right (and wrong) base, and the client fallback to server-side sync.
+"""
+
+import json
+import subprocess
+from pathlib import Path
+from unittest.mock import AsyncMock, Mock, patch
+
+import pytest
+
+from code_indexer.api_clients.base_client import APIClientError, AuthenticationError
+from code_indexer.remote.sync_execution import _try_delta_sync
+from code_indexer.sync.index_delta import (
+    DELTA_FORMAT_VERSION,
+    IndexDelta,
+    IndexDeltaError,
+    apply_index_delta,
+    build_index_delta,
+    get_changed_paths,
+)
+
+
+def _delta(**overrides):
+    fields = {
+        "collection_name": "voyage-code-3",
+        "vector_size": 4,
+        "base_commit": "a" * 40,
+        "target_commit": "b" * 40,
+        "branch": "feature",
+        "upserts": [
+            {
+                "id": "p1",
+                "vector": [0.5, -0.25, 1.0, 0.0],
+                "payload": {"path": "src/app.py", "line_start": 1},
+                "chunk_text": "def main():\n    pass\n",
+                "git_blob_hash": "c" * 40,
+            }
+        ],
+        "deleted_paths": ["src/old.py"],
+        "metadata": {"current_commit": "b" * 40, "current_branch": "feature"},
+    }
+    fields.update(overrides)
+    return IndexDelta(**fields)
+
+
+def _git(repo: Path, *args: str) -> str:
+    result = subprocess.run(
+        ["git", *args], cwd=repo, capture_output=True, text=True, check=True
+    )
+    return result.stdout.strip()
+
+
+class PathReplacingStore:
+    """
+    Vector store stub replacing a path's points with those of each
+    upsert_points() call, like FilesystemVectorStore does.
+    """
+
+    def __init__(self):
+        self.points = {}
+
+    def collection_exists(self, collection_name):
+        return True
+
+    def get_collection_info(self, collection_name):
+        return {"vector_size": 4}
+
+    def begin_indexing(self, collection_name):
+        pass
+
+    def end_indexing(self, collection_name):
+        pass
+
+    def delete_points_for_paths(self, collection_name, paths):
+        return sum(len(self.points.pop(path, set())) for path in paths)
+
+    def upsert_points(self, collection_name, points):
+        call = {}
+        for point in points:
+            call.setdefault(point["payload"]["path"], set()).add(point["id"])
+        self.points.update(call)
+
+
+class TestIndexDeltaFormat:
+    """Tests for IndexDelta serialization."""
+
+    def test_round_trip(self):
+        delta = _delta()
+
+        assert IndexDelta.from_bytes(delta.to_bytes()) == delta
+
+    def test_garbage_is_rejected(self):
+        with pytest.raises(IndexDeltaError, match="Invalid index delta"):
+            IndexDelta.from_bytes(b"not a delta")
+
+    def test_unknown_format_version_is_rejected(self):
+        payload = _delta(format_version=DELTA_FORMAT_VERSION + 1).to_bytes()
+
+        with pytest.raises(IndexDeltaError, match="Unsupported"):
+            IndexDelta.from_bytes(payload)
+
+
+class TestGetChangedPaths:
+    """Tests for get_changed_paths() against a real git repository."""
+
+    def test_reports_changes_and_deletions(self, tmp_path):
+        _git(tmp_path, "init", "-q")
+        _git(tmp_path, "config", "user.email", "test@example.com")
+        _git(tmp_path, "config", "user.name", "Test")
+        (tmp_path / "keep.py").write_text("a = 1\n")
+        (tmp_path / "gone.py").write_text("b = 2\n")
+        (tmp_path / "old_name.py").write_text("c = 3\n" * 20)
+        _git(tmp_path, "add", ".")
+        _git(tmp_path, "commit", "-q", "-m", "base")
+        base = _git(tmp_path, "rev-parse", "HEAD")
+
+        (tmp_path / "keep.py").write_text("a = 10\n")
+        (tmp_path / "gone.py").unlink()
+        _git(tmp_path, "mv", "old_name.py", "new_name.py")
+        (tmp_path / "added.py").write_text("d = 4\n")
+        _git(tmp_path, "add", "-A")
+        _git(tmp_path, "commit", "-q", "-m", "target")
+        target = _git(tmp_path, "rev-parse", "HEAD")
+
+        changed, deleted = get_changed_paths(tmp_path, base, target)
+
+        assert sorted(changed) == ["added.py", "keep.py", "new_name.py"]
+        assert sorted(deleted) == ["gone.py", "old_name.py"]
+
+    def test_unknown_commit_raises(self, tmp_path):
+        _git(tmp_path, "init", "-q")
+
+        with pytest.raises(IndexDeltaError, match="Cannot diff"):
+            get_changed_paths(tmp_path, "0" * 40, "1" * 40)
+
+
+class TestBuildIndexDelta:
+    """Tests for build_index_delta()."""
+
+    def _project(self, project_root: Path, config=None, collection_meta=None):
+        config_dir = project_root / ".code-indexer"
+        (config_dir / "index").mkdir(parents=True)
+        (config_dir / "metadata.json").write_text(
+            json.dumps({"status": "completed", "current_commit": "a" * 40})
+        )
+        if config is not None:
+            (config_dir / "config.json").write_text(json.dumps(config))
+        store = Mock()
+        store.collection_exists.return_value = True
+        store.get_collection_info.return_value = collection_meta or {
+            "vector_size": 4
+        }
+        return patch(
+            "code_indexer.storage.filesystem_vector_store.FilesystemVectorStore",
+            return_value=store,
+        )
+
+    def test_refuses_when_encryption_enabled(self, tmp_path):
+        config = {"codebase_dir": str(tmp_path), "encryption": {"enabled": True}}
+
+        with self._project(tmp_path, config=config):
+            with pytest.raises(IndexDeltaError, match="encryption"):
+                build_index_delta(tmp_path, "b" * 40, "code")
+
+    def test_refuses_encrypted_collection(self, tmp_path):
+        collection_meta = {"vector_size": 4, "encryption": {"key_id": "k"}}
+
+        with self._project(tmp_path, collection_meta=collection_meta):
+            with pytest.raises(IndexDeltaError, match="encryption"):
+                build_index_delta(tmp_path, "b" * 40, "code")
+
+
+class TestApplyIndexDelta:
+    """Tests for apply_index_delta()."""
+
+    def _write_metadata(self, project_root: Path, current_commit: str):
+        metadata_dir = project_root / ".code-indexer"
+        metadata_dir.mkdir(parents=True)
+        (metadata_dir / "metadata.json").write_text(
+            json.dumps({"status": "completed", "current_commit": current_commit})
+        )
+
+    def test_stale_base_is_rejected_before_touching_index(self, tmp_path):
+        self._write_metadata(tmp_path, "f" * 40)
+
+        with patch(
+            "code_indexer.storage.filesystem_vector_store.FilesystemVectorStore"
+        ) as mock_store:
+            with pytest.raises(IndexDeltaError, match="does not match"):
+                apply_index_delta(tmp_path, _delta())
+
+        mock_store.assert_not_called()
+
+    def test_applies_deletions_upserts_and_metadata(self, tmp_path):
+        self._write_metadata(tmp_path, "a" * 40)
+        store = Mock()
+        store.collection_exists.return_value = True
+        store.get_collection_info.return_value = {"vector_size": 4}
+        store.delete_points_for_paths.return_value = 3
+        delta = _delta()
+
+        with patch(
+            "code_indexer.storage.filesystem_vector_store.FilesystemVectorStore",
+            return_value=store,
+        ):
+            result = apply_index_delta(tmp_path, delta)
+
+        store.begin_indexing.assert_called_once_with("voyage-code-3")
+        store.delete_points_for_paths.assert_called_once_with(
+            "voyage-code-3", ["src/old.py"]
+        )
+        store.upsert_points.assert_called_once_with("voyage-code-3", delta.upserts)
+        store.end_indexing.assert_called_once_with("voyage-code-3")
+        assert result.points_upserted == 1
+        assert result.points_deleted == 3
+        assert result.files_changed == 2
+
+        metadata = json.loads(
+            (tmp_path / ".code-indexer" / "metadata.json").read_text()
+        )
+        assert metadata["current_commit"] == "b" * 40
+        assert metadata["branch_commit_watermarks"] == {"feature": "b" * 40}
+
+    def test_large_files_keep_all_chunks(self, tmp_path):
+        self._write_metadata(tmp_path, "a" * 40)
+        store = PathReplacingStore()
+
+        def chunks(path, count):
+            return [
+                {
+                    "id": f"{path}-{i}",
+                    "vector": [0.0, 0.0, 0.0, 1.0],
+                    "payload": {"path": path, "line_start": i + 1},
+                }
+                for i in range(count)
+            ]
+
+        # 1200 chunks of one file, and files crossing a batch boundary
+        upserts = chunks("src/big.py", 1200) + chunks("src/a.py", 300)
+        upserts += chunks("src/b.py", 300) + chunks("src/c.py", 1)
+
+        with patch(
+            "code_indexer.storage.filesystem_vector_store.FilesystemVectorStore",
+            return_value=store,
+        ):
+            result = apply_index_delta(tmp_path, _delta(upserts=upserts))
+
+        assert result.points_upserted == len(upserts)
+        assert {path: len(ids) for path, ids in store.points.items()} == {
+            "src/big.py": 1200,
+            "src/a.py": 300,
+            "src/b.py": 300,
+            "src/c.py": 1,
+        }
+
+    def test_vector_size_mismatch_is_rejected(self, tmp_path):
+        self._write_metadata(tmp_path, "a" * 40)
+        store = Mock()
+        store.collection_exists.return_value = True
+        store.get_collection_info.return_value = {"vector_size": 1024}
+
+        with patch(
+            "code_indexer.storage.filesystem_vector_store.FilesystemVectorStore",
+            return_value=store,
+        ):
+            with pytest.raises(IndexDeltaError, match="Vector size mismatch"):
+                apply_index_delta(tmp_path, _delta())
+
+        store.begin_indexing.assert_not_called()
+
+
+class TestTryDeltaSync:
+    """Tests for the client side of delta sync."""
+
+    def setup_method(self):
+        self.sync_client = Mock()
+        self.sync_client.get_delta_base = AsyncMock(
+            return_value={
+                "collection_name": "voyage-code-3",
+                "vector_size": 4,
+                "indexed_commit": "a" * 40,
+                "branch": "main",
+            }
+        )
+        self.sync_client.upload_index_delta = AsyncMock(
+            return_value={
+                "indexed_commit": "b" * 40,
+                "points_upserted": 1,
+                "points_deleted": 0,
+                "files_changed": 2,
+                "elapsed_seconds": 0.2,
+            }
+        )
+
+    def _run(self, coro):
+        import asyncio
+
+        return asyncio.run(coro)
+
+    def test_uploads_delta_built_against_server_commit(self):
+        with patch(
+            "code_indexer.sync.index_delta.build_index_delta", return_value=_delta()
+        ) as mock_build:
+            result = self._run(
+                _try_delta_sync(self.sync_client, "my-repo", Path("/p"), 300)
+            )
+
+        mock_build.assert_called_once_with(Path("/p"), "a" * 40, "voyage-code-3")
+        uploaded = self.sync_client.upload_index_delta.call_args[0][1]
+        assert IndexDelta.from_bytes(uploaded) == _delta()
+        assert result.status == "completed"
+        assert result.job_id == ""
+
+    def test_falls_back_when_local_index_unusable(self):
+        with patch(
+            "code_indexer.sync.index_delta.build_index_delta",
+            side_effect=IndexDeltaError("No local index found"),
+        ):
+            result = self._run(
+                _try_delta_sync(self.sync_client, "my-repo", Path("/p"), 300)
+            )
+
+        assert result is None
+        self.sync_client.upload_index_delta.assert_not_called()
+
+    def test_falls_back_when_delta_exceeds_upload_limit(self):
+        with patch(
+            "code_indexer.sync.index_delta.build_index_delta", return_value=_delta()
+        ), patch("code_indexer.sync.index_delta.DELTA_MAX_UPLOAD_BYTES", 10):
+            result = self._run(
+                _try_delta_sync(self.sync_client, "my-repo", Path("/p"), 300)
+            )
+
+        assert result is None
+        self.sync_client.upload_index_delta.assert_not_called()
+
+    def test_falls_back_when_server_rejects_delta(self):
+        self.sync_client.upload_index_delta.side_effect = APIClientError(
+            "stale base", 409
+        )
+
+        with patch(
+            "code_indexer.sync.index_delta.build_index_delta", return_value=_delta()
+        ):
+            result = self._run(
+                _try_delta_sync(self.sync_client, "my-repo", Path("/p"), 300)
+            )
+
+        assert result is None
+
+    def test_authentication_errors_propagate(self):
+        self.sync_client.get_delta_base.side_effect = AuthenticationError("expired")
+
+        with pytest.raises(AuthenticationError):
+            self._run(_try_delta_sync(self.sync_client, "my-repo", Path("/p"), 300))
@@ -0,0 +1,99 @@
+"""
+Unit tests for relevance feedback in SemanticQueryManager.
+
+Tests recording judgments for activated repositories and re-ranking of
+semantic results with them.
+"""
+
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from code_indexer.server.query.semantic_query_manager import (
+    QueryResult,
+    SemanticQueryError,
+    SemanticQueryManager,
+)
+
+
+@pytest.fixture
+def manager(tmp_path):
+    activated_repo_manager = MagicMock()
+    activated_repo_manager.activated_repos_dir = str(tmp_path / "activated-repos")
+    activated_repo_manager.list_activated_repositories.return_value = [
+        {"user_alias": "backend", "golden_repo_alias": "backend"}
+    ]
+    activated_repo_manager.get_activated_repo_path.return_value = str(
+        tmp_path / "repos" / "backend"
+    )
+    return SemanticQueryManager(
+        data_dir=str(tmp_path),
+        activated_repo_manager=activated_repo_manager,
+        background_job_manager=MagicMock(),
+    )
+
+
+def query_result(path: str, score: float) -> QueryResult:
+    return QueryResult(
+        file_path=path,
+        line_number=1,
+        code_snippet="",
+        similarity_score=score,
+        repository_alias="backend",
+    )
+
+
+class TestRecordFeedback:
+    """Tests for SemanticQueryManager.record_feedback()."""
+
+    def test_records_for_activated_repository(self, manager):
+        response = manager.record_feedback(
+            username="alice",
+            repository_alias="backend",
+            file_path="src/retry.py",
+            relevant=True,
+            code_snippet="def retry_payment():",
+            line_number=10,
+        )
+
+        assert response["symbols"] == ["retry_payment"]
+        assert response["adjustment"] > 0
+        assert (Path(manager.data_dir) / "feedback" / "alice" / "backend.json").exists()
+
+    def test_unknown_repository(self, manager):
+        with pytest.raises(SemanticQueryError, match="not found"):
+            manager.record_feedback(
+                username="alice",
+                repository_alias="frontend",
+                file_path="a.js",
+                relevant=True,
+            )
+
+
+class TestFeedbackReranking:
+    """Tests for re-ranking semantic results with recorded feedback."""
+
+    def test_semantic_results_are_reranked(self, manager):
+        manager.record_feedback(
+            username="alice",
+            repository_alias="backend",
+            file_path="src/core.py",
+            relevant=True,
+        )
+        results = [
+            query_result("docs/guide.md", 0.80),
+            query_result("src/core.py", 0.79),
+        ]
+
+        with patch.object(manager, "_search_single_repository", return_value=results):
+            ranked = manager._perform_search(
+                "alice",
+                [{"user_alias": "backend"}],
+                "retry",
+                limit=10,
+                min_score=None,
+                file_extensions=None,
+            )
+
+        assert [r.file_path for r in ranked] == ["src/core.py", "docs/guide.md"]
@@ -0,0 +1,74 @@
+"""
+Unit tests for filter expression wiring through semantic_query_manager.
+
+Tests validating filter expressions in query_user_repositories, passing them
+down to single-repository searches, and forwarding them to the CLI for
+composite repositories.
+"""
+
+import tempfile
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from src.code_indexer.server.query.semantic_query_manager import (
+    SemanticQueryError,
+    SemanticQueryManager,
+)
+
+FILTER = "lang:go AND path:internal/** AND NOT generated"
+
+
+class TestQueryFilterWiring:
+    """Tests for the filter_expression parameter of SemanticQueryManager."""
+
+    @pytest.fixture
+    def query_manager(self):
+        with (
+            tempfile.TemporaryDirectory() as temp_dir,
+            patch(
+                "src.code_indexer.server.query.semantic_query_manager.ActivatedRepoManager"
+            ) as mock_activated_manager,
+            patch(
+                "src.code_indexer.server.query.semantic_query_manager.BackgroundJobManager"
+            ),
+        ):
+            activated = MagicMock()
+            activated.list_activated_repositories.return_value = [
+                {"user_alias": "test-repo", "repo_path": f"{temp_dir}/test-repo"}
+            ]
+            activated.activated_repos_dir = f"{temp_dir}/activated-repos"
+            mock_activated_manager.return_value = activated
+            yield SemanticQueryManager(data_dir=temp_dir)
+
+    def test_filter_passed_to_single_repository_search(self, query_manager):
+        with patch.object(
+            query_manager, "_search_single_repository", return_value=[]
+        ) as search:
+            query_manager.query_user_repositories(
+                username="testuser", query_text="retry", filter_expression=FILTER
+            )
+
+        assert search.call_args.kwargs["filter_expression"] == FILTER
+
+    @pytest.mark.parametrize(
+        "kwargs",
+        [
+            {"filter_expression": "color:red"},
+            {"filter_expression": FILTER, "search_mode": "fts"},
+            {"filter_expression": FILTER, "time_range_all": True},
+        ],
+    )
+    def test_invalid_filter_rejected(self, query_manager, kwargs):
+        with patch.object(query_manager, "_perform_search", return_value=[]):
+            with pytest.raises(SemanticQueryError):
+                query_manager.query_user_repositories(
+                    username="testuser", query_text="retry", **kwargs
+                )
+
+    def test_filter_forwarded_to_cli(self, query_manager):
+        args = query_manager._build_cli_args(
+            query="retry", limit=10, filter_expression=FILTER
+        )
+
+        assert args[-2:] == ["--filter", FILTER]
@@ -0,0 +1,48 @@
+"""
+Unit tests for matched regions of semantic query results.
+
+Tests that single-repository semantic results carry the region matching the
+query and that QueryResult.to_dict() includes it only when found.
+"""
+
+from unittest.mock import MagicMock, patch
+
+from code_indexer.server.query.semantic_query_manager import (
+    QueryResult,
+    SemanticQueryManager,
+)
+
+
+class TestQueryResultHighlight:
+    """Tests for the highlight field of QueryResult."""
+
+    def test_to_dict_includes_highlight_when_found(self):
+        result = QueryResult(
+            file_path="a.py",
+            line_number=1,
+            code_snippet="x = 1",
+            similarity_score=0.5,
+            repository_alias="repo",
+        )
+        assert "highlight" not in result.to_dict()
+
+        result.highlight = {"line_start": 1, "line_end": 1}
+        assert result.to_dict()["highlight"] == {"line_start": 1, "line_end": 1}
+
+    @patch("code_indexer.server.services.search_service.SemanticSearchService")
+    def test_semantic_results_carry_matched_region(self, service_class, tmp_path):
+        item = MagicMock()
+        item.file_path = "pay.py"
+        item.line_start = 20
+        item.content = "def pay():\n    retry_with_backoff()\n"
+        item.score = 0.9
+        service_class.return_value.search_repository_path.return_value.results = [
+            item
+        ]
+
+        results = SemanticQueryManager()._search_single_repository(
+            str(tmp_path), "repo", "retry backoff", 10, None, None
+        )
+
+        assert results[0].highlight["line_start"] == 21
+        assert results[0].highlight["terms"] == ["retry", "backoff"]
@@ -0,0 +1,175 @@
+"""
+Unit tests for RetentionService and the retention windows it applies.
+
+Tests retention_config loading and validation, audit log cleanup in
+GroupAccessManager, and RetentionService runs (dry run and real) over
+golden repo indexes, job records and audit entries.
+"""
+
+import json
+import sqlite3
+from datetime import datetime, timedelta, timezone
+from unittest.mock import Mock
+
+import pytest
+
+from code_indexer.server.services.group_access_manager import GroupAccessManager
+from code_indexer.server.services.retention_service import RetentionService
+from code_indexer.server.utils.config_manager import (
+    RetentionConfig,
+    ServerConfig,
+    ServerConfigManager,
+)
+
+
+@pytest.fixture
+def server_config(tmp_path):
+    """Server configuration with every retention window set."""
+    config = ServerConfig(server_dir=str(tmp_path / "server"))
+    config.retention_config = RetentionConfig(
+        enabled=True,
+        index_history_days=365,
+        job_records_days=30,
+        audit_log_days=90,
+    )
+    return config
+
+
+@pytest.fixture
+def group_manager(tmp_path):
+    """GroupAccessManager with one old and one recent audit entry."""
+    manager = GroupAccessManager(tmp_path / "groups.db")
+    manager.log_audit("admin", "user_group_change", "user", "alice")
+    manager.log_audit("admin", "user_group_change", "user", "bob")
+    old = (datetime.now(timezone.utc) - timedelta(days=200)).isoformat()
+    conn = sqlite3.connect(str(tmp_path / "groups.db"))
+    conn.execute(
+        "UPDATE audit_logs SET timestamp = ? WHERE target_id = 'alice'", (old,)
+    )
+    conn.commit()
+    conn.close()
+    return manager
+
+
+class TestRetentionConfig:
+    """Tests for retention_config in the server configuration."""
+
+    def test_disabled_by_default(self, tmp_path):
+        config = ServerConfig(server_dir=str(tmp_path))
+
+        assert config.retention_config.enabled is False
+        assert config.retention_config.audit_log_days is None
+
+    def test_loaded_from_config_file(self, tmp_path):
+        (tmp_path / "config.json").write_text(
+            json.dumps({"retention_config": {"enabled": True, "job_records_days": 30}})
+        )
+
+        config = ServerConfigManager(str(tmp_path)).load_config()
+
+        assert isinstance(config.retention_config, RetentionConfig)
+        assert config.retention_config.job_records_days == 30
+
+    def test_rejects_non_positive_window(self, tmp_path):
+        config = ServerConfig(server_dir=str(tmp_path))
+        config.retention_config.audit_log_days = 0
+
+        with pytest.raises(ValueError, match="audit_log_days"):
+            ServerConfigManager(str(tmp_path)).validate_config(config)
+
+
+class TestAuditLogCleanup:
+    """Tests for GroupAccessManager.cleanup_old_audit_logs()."""
+
+    def test_dry_run_counts_only(self, group_manager):
+        assert group_manager.cleanup_old_audit_logs(90, dry_run=True) == 1
+        assert group_manager.get_audit_logs()[1] == 2
+
+    def test_deletes_entries_past_retention(self, group_manager):
+        assert group_manager.cleanup_old_audit_logs(90) == 1
+
+        logs, total = group_manager.get_audit_logs()
+        assert total == 1
+        assert logs[0]["target_id"] == "bob"
+
+
+class TestRetentionService:
+    """Tests for RetentionService.run()."""
+
+    def _service(self, server_config, tmp_path, group_manager=None):
+        job_manager = Mock()
+        job_manager.cleanup_old_jobs.return_value = 3
+        service = RetentionService(
+            config=server_config,
+            job_manager=job_manager,
+            golden_repos_dir=tmp_path / "golden-repos",
+            group_manager=group_manager,
+        )
+        return service, job_manager
+
+    def test_dry_run_reports_without_deleting(
+        self, server_config, tmp_path, group_manager
+    ):
+        service, job_manager = self._service(server_config, tmp_path, group_manager)
+
+        report = service.run(dry_run=True)
+
+        assert report.dry_run is True
+        assert report.job_records_purged == 3
+        assert report.audit_entries_purged == 1
+        job_manager.cleanup_old_jobs.assert_called_once_with(
+            max_age_hours=30 * 24, dry_run=True
+        )
+        assert group_manager.get_audit_logs()[1] == 2
+        assert service.last_report is None
+
+    def test_run_deletes_past_retention(self, server_config, tmp_path, group_manager):
+        service, _ = self._service(server_config, tmp_path, group_manager)
+
+        report = service.run()
+
+        assert report.audit_entries_purged == 1
+        assert group_manager.get_audit_logs()[1] == 1
+        assert service.last_report is report
+
+    def test_unset_windows_are_skipped(self, tmp_path):
+        config = ServerConfig(server_dir=str(tmp_path / "server"))
+        service, job_manager = self._service(config, tmp_path)
+
+        report = service.run()
+
+        job_manager.cleanup_old_jobs.assert_not_called()
+        assert report.repos == []
+        assert report.errors == []
+
+    def test_missing_group_manager_is_reported(self, server_config, tmp_path):
+        service, _ = self._service(server_config, tmp_path)
+
+        report = service.run(dry_run=True)
+
+        assert report.errors == ["audit log: group manager not available"]
+
+    def test_finds_golden_repo_and_snapshot_indexes(self, server_config, tmp_path):
+        golden = tmp_path / "golden-repos"
+        (golden / "repo-a" / ".code-indexer" / "index").mkdir(parents=True)
+        snapshot = golden / ".versioned" / "repo-a" / "v_1700000000"
+        (snapshot / ".code-indexer" / "index").mkdir(parents=True)
+        (golden / "not-indexed").mkdir()
+        service, _ = self._service(server_config, tmp_path)
+
+        index_dirs = service._index_dirs()
+
+        assert index_dirs == sorted(
+            [
+                golden / "repo-a" / ".code-indexer" / "index",
+                snapshot / ".code-indexer" / "index",
+            ]
+        )
+
+    def test_start_is_noop_when_disabled(self, tmp_path):
+        config = ServerConfig(server_dir=str(tmp_path / "server"))
+        service, _ = self._service(config, tmp_path)
+
+        service.start()
+
+        assert service._timer_thread is None
@@ -311,6 +311,25 @@ class TestBackgroundJobsSqliteBackend:
         assert backend.get_job("recent-completed") is not None
         assert backend.get_job("running") is not None
 
+    def test_count_old_jobs_matches_cleanup(self, backend) -> None:
+        """When count_old_jobs() is called, it counts what cleanup_old_jobs() removes."""
+        old_time = datetime.now(timezone.utc) - timedelta(hours=48)
+
+        backend.save_job(
+            job_id="old-failed",
+            operation_type="add_golden_repo",
+            status="failed",
+            created_at=old_time.isoformat(),
+            completed_at=old_time.isoformat(),
+            username="user1",
+            progress=100,
+        )
+
+        assert backend.count_old_jobs(max_age_hours=24) == 1
+        assert backend.get_job("old-failed") is not None
+        assert backend.cleanup_old_jobs(max_age_hours=24) == 1
+        assert backend.count_old_jobs(max_age_hours=24) == 0
+
     def test_count_jobs_by_status(self, backend) -> None:
         """When count_jobs_by_status() is called, it returns counts for each status."""
         backend.save_job(
@@ -0,0 +1,135 @@
+"""
+Unit tests for deployment artifact generation.
+
+Tests the container configuration derived from the server configuration,
+the Docker Compose project, the Helm chart and overwrite protection.
+"""
+
+import json
+
+import pytest
+import yaml
+
+from code_indexer import __version__
+from code_indexer.server.deploy_generator import (
+    CONTAINER_SERVER_DIR,
+    HEALTH_CHECK_PATH,
+    DeploymentGenerator,
+)
+from code_indexer.server.utils.config_manager import ServerConfig
+
+
+@pytest.fixture
+def config(tmp_path):
+    config = ServerConfig(server_dir=str(tmp_path / "server"), port=8123)
+    config.anthropic_api_key = "sk-ant-secret"
+    config.oidc_provider_config.client_secret = "oidc-secret"
+    return config
+
+
+class TestContainerConfig:
+    """Tests for DeploymentGenerator.container_config()."""
+
+    def test_uses_container_paths_and_strips_secrets(self, config):
+        container_config = DeploymentGenerator(config).container_config()
+
+        assert container_config["server_dir"] == CONTAINER_SERVER_DIR
+        assert container_config["host"] == "0.0.0.0"
+        assert container_config["port"] == 8123
+        assert container_config["anthropic_api_key"] is None
+        assert container_config["oidc_provider_config"]["client_secret"] == ""
+
+    def test_does_not_modify_source_config(self, config):
+        DeploymentGenerator(config).container_config()
+
+        assert config.anthropic_api_key == "sk-ant-secret"
+
+
+class TestDockerCompose:
+    """Tests for DeploymentGenerator.generate_docker_compose()."""
+
+    def test_writes_project(self, config, tmp_path):
+        output_dir = tmp_path / "deploy"
+
+        written = DeploymentGenerator(config).generate_docker_compose(output_dir)
+
+        assert {p.name for p in written} == {
+            "Dockerfile",
+            "docker-compose.yml",
+            "config.json",
+            ".env.example",
+        }
+        compose = yaml.safe_load((output_dir / "docker-compose.yml").read_text())
+        service = compose["services"]["cidx-server"]
+        assert service["ports"] == ["8123:8123"]
+        assert f"cidx-data:{CONTAINER_SERVER_DIR}" in service["volumes"]
+        assert "--port 8123" in service["command"][-1]
+        assert HEALTH_CHECK_PATH in service["healthcheck"]["test"][-1]
+        assert "cidx-data" in compose["volumes"]
+
+        seed = json.loads((output_dir / "config.json").read_text())
+        assert seed["server_dir"] == CONTAINER_SERVER_DIR
+        assert "sk-ant-secret" not in (output_dir / "config.json").read_text()
+
+        dockerfile = (output_dir / "Dockerfile").read_text()
+        assert f"@v{__version__}" in dockerfile
+        assert "EXPOSE 8123" in dockerfile
+
+    def test_issuer_url_in_env_example(self, config, tmp_path):
+        generator = DeploymentGenerator(config, issuer_url="https://cidx.example.com")
+
+        generator.generate_docker_compose(tmp_path)
+
+        env = (tmp_path / ".env.example").read_text()
+        assert "CIDX_ISSUER_URL=https://cidx.example.com" in env
+        assert "VOYAGE_API_KEY=\n" in env
+
+    def test_refuses_to_overwrite(self, config, tmp_path):
+        (tmp_path / "docker-compose.yml").write_text("custom")
+
+        with pytest.raises(FileExistsError, match="docker-compose.yml"):
+            DeploymentGenerator(config).generate_docker_compose(tmp_path)
+
+        assert (tmp_path / "docker-compose.yml").read_text() == "custom"
+
+    def test_overwrite(self, config, tmp_path):
+        (tmp_path / "docker-compose.yml").write_text("custom")
+
+        DeploymentGenerator(config).generate_docker_compose(tmp_path, overwrite=True)
+
+        assert "cidx-server" in (tmp_path / "docker-compose.yml").read_text()
+
+
+class TestHelmChart:
+    """Tests for DeploymentGenerator.generate_helm_chart()."""
+
+    def test_writes_chart(self, config, tmp_path):
+        DeploymentGenerator(config).generate_helm_chart(tmp_path)
+
+        chart_dir = tmp_path / "cidx-server"
+        chart = yaml.safe_load((chart_dir / "Chart.yaml").read_text())
+        assert chart["appVersion"] == __version__
+        for template in ["deployment", "service", "pvc", "configmap", "secret"]:
+            assert (chart_dir / "templates" / f"{template}.yaml").is_file()
+        assert (tmp_path / "Dockerfile").is_file()
+
+    def test_values_parameterized_from_config(self, config, tmp_path):
+        generator = DeploymentGenerator(config, issuer_url="https://cidx.example.com")
+
+        generator.generate_helm_chart(tmp_path)
+
+        values = yaml.safe_load((tmp_path / "cidx-server" / "values.yaml").read_text())
+        assert values["service"]["port"] == 8123
+        assert values["issuerUrl"] == "https://cidx.example.com"
+        assert values["serverConfig"]["server_dir"] == CONTAINER_SERVER_DIR
+        assert values["secrets"]["voyageApiKey"] == ""
+
+    def test_deployment_probes_and_volumes(self, config, tmp_path):
+        DeploymentGenerator(config).generate_helm_chart(tmp_path)
+
+        deployment = (
+            tmp_path / "cidx-server" / "templates" / "deployment.yaml"
+        ).read_text()
+        assert f"path: {HEALTH_CHECK_PATH}" in deployment
+        assert f"mountPath: {CONTAINER_SERVER_DIR}" in deployment
+        assert "--port {{ .Values.service.port }}" in deployment
@@ -0,0 +1,171 @@
+"""
+Unit tests for latency-aware embedding batch sizing.
+
+Tests LatencyAwareBatchScheduler split/merge decisions and its integration
+with VectorCalculationManager and AutoTuneController.
+"""
+
+# mypy: ignore-errors
+
+from unittest.mock import Mock
+
+import pytest
+
+from src.code_indexer.services.batch_scheduler import (
+    CHARS_PER_TOKEN,
+    MAX_BATCH_TOKEN_FRACTION,
+    MIN_BATCH_TOKEN_FRACTION,
+    LatencyAwareBatchScheduler,
+)
+from src.code_indexer.services.concurrency_tuner import (
+    AutoTuneController,
+    ConcurrencyTuner,
+    TuningDecision,
+)
+from src.code_indexer.services.vector_calculation_manager import (
+    VectorCalculationManager,
+    VectorTask,
+)
+
+MODEL_TOKEN_LIMIT = 10000
+
+
+def _full_batch_chars(scheduler):
+    """Characters of a batch that uses the scheduler's whole token budget."""
+    tokens = scheduler.batch_token_fraction * scheduler.model_token_limit
+    return int(tokens * CHARS_PER_TOKEN)
+
+
+def _record_full_batches(scheduler, latency, count=3):
+    for _ in range(count):
+        scheduler.record_batch(_full_batch_chars(scheduler), latency)
+
+
+class TestLatencyAwareBatchScheduler:
+    """Tests for split/merge decisions."""
+
+    def test_rejects_invalid_arguments(self):
+        with pytest.raises(ValueError):
+            LatencyAwareBatchScheduler(0, MODEL_TOKEN_LIMIT)
+        with pytest.raises(ValueError):
+            LatencyAwareBatchScheduler(5.0, 0)
+
+    def test_waits_for_enough_samples(self):
+        scheduler = LatencyAwareBatchScheduler(5.0, MODEL_TOKEN_LIMIT)
+
+        _record_full_batches(scheduler, latency=60.0, count=2)
+
+        assert scheduler.batch_token_fraction == MAX_BATCH_TOKEN_FRACTION
+
+    def test_slow_batches_are_split(self):
+        scheduler = LatencyAwareBatchScheduler(5.0, MODEL_TOKEN_LIMIT)
+
+        _record_full_batches(scheduler, latency=20.0)
+
+        # At most halved per adjustment, however far off the target
+        assert scheduler.batch_token_fraction == MAX_BATCH_TOKEN_FRACTION * 0.5
+        assert scheduler.get_stats()["splits"] == 1
+
+    def test_fast_batches_are_merged_up_to_maximum(self):
+        scheduler = LatencyAwareBatchScheduler(
+            5.0, MODEL_TOKEN_LIMIT, initial_batch_token_fraction=0.2
+        )
+
+        _record_full_batches(scheduler, latency=1.0, count=20)
+
+        assert scheduler.batch_token_fraction == MAX_BATCH_TOKEN_FRACTION
+        assert scheduler.get_stats()["merges"] >= 1
+
+    def test_latency_near_target_keeps_size(self):
+        scheduler = LatencyAwareBatchScheduler(5.0, MODEL_TOKEN_LIMIT)
+
+        _record_full_batches(scheduler, latency=5.5, count=10)
+
+        assert scheduler.batch_token_fraction == MAX_BATCH_TOKEN_FRACTION
+
+    def test_small_batches_do_not_count(self):
+        scheduler = LatencyAwareBatchScheduler(5.0, MODEL_TOKEN_LIMIT)
+
+        for _ in range(10):
+            scheduler.record_batch(100, 2.0)  # Overhead-dominated tiny batch
+
+        assert scheduler.batch_token_fraction == MAX_BATCH_TOKEN_FRACTION
+        assert scheduler.get_stats()["batches_measured"] == 0
+
+    def test_fraction_never_below_minimum(self):
+        scheduler = LatencyAwareBatchScheduler(
+            0.1, MODEL_TOKEN_LIMIT, initial_batch_token_fraction=0.06
+        )
+
+        _record_full_batches(scheduler, latency=100.0, count=10)
+
+        assert scheduler.batch_token_fraction == MIN_BATCH_TOKEN_FRACTION
+
+
+class TestVectorManagerBatchScheduler:
+    """Tests for feeding batch latency into the scheduler."""
+
+    def _task(self, texts):
+        return VectorTask.create_immutable(
+            task_id="task_1", chunk_texts=texts, metadata={}, created_at=0.0
+        )
+
+    def test_successful_batches_update_fraction(self):
+        scheduler = Mock(spec=LatencyAwareBatchScheduler)
+        scheduler.batch_token_fraction = 0.9
+        scheduler.record_batch.return_value = 0.45
+        provider = Mock()
+        provider.get_embeddings_batch.return_value = [[0.1], [0.2]]
+        manager = VectorCalculationManager(
+            provider, thread_count=1, batch_scheduler=scheduler
+        )
+
+        result = manager._calculate_vector(self._task(["abc", "de"]))
+
+        assert result.error is None
+        assert scheduler.record_batch.call_args[0][0] == 5
+        assert manager.batch_token_fraction == 0.45
+
+    def test_failed_batches_are_not_recorded(self):
+        scheduler = Mock(spec=LatencyAwareBatchScheduler)
+        scheduler.batch_token_fraction = 0.9
+        provider = Mock()
+        provider.get_embeddings_batch.side_effect = ValueError("bad request")
+        manager = VectorCalculationManager(
+            provider, thread_count=1, batch_scheduler=scheduler
+        )
+
+        result = manager._calculate_vector(self._task(["abc"]))
+
+        assert result.error
+        scheduler.record_batch.assert_not_called()
+
+
+class TestAutoTuneWithBatchScheduler:
+    """Auto-tune must leave batch sizing to the scheduler when one is set."""
+
+    def _apply(self, vector_manager):
+        controller = AutoTuneController(
+            ConcurrencyTuner(initial_threads=2, max_threads=4), vector_manager
+        )
+        controller._apply(
+            TuningDecision(thread_limit=2, batch_token_fraction=0.25, reason="test")
+        )
+
+    def test_scheduler_keeps_batch_fraction(self):
+        manager = Mock()
+        manager.batch_scheduler = LatencyAwareBatchScheduler(5.0, MODEL_TOKEN_LIMIT)
+        manager.batch_token_fraction = 0.9
+
+        self._apply(manager)
+
+        assert manager.batch_token_fraction == 0.9
+        manager.set_concurrency_limit.assert_called_once_with(2)
+
+    def test_without_scheduler_auto_tune_sets_fraction(self):
+        manager = Mock()
+        manager.batch_scheduler = None
+
+        self._apply(manager)
+
+        assert manager.batch_token_fraction == 0.25
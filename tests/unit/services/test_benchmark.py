@@ -0,0 +1,200 @@
+"""
+Unit tests for the `cidx bench` benchmark service.
+
+Covers percentile math, synthetic corpus determinism, and the staged
+benchmark run against a mocked vector store and embedding provider.
+"""
+
+import tempfile
+from pathlib import Path
+from unittest.mock import Mock, patch
+
+import pytest
+
+from code_indexer.config import Config
+from code_indexer.services.benchmark import (
+    BENCH_COLLECTION_NAME,
+    BenchmarkResult,
+    generate_synthetic_corpus,
+    percentile,
+    run_benchmark,
+)
+
+
+class TestPercentile:
+    """Tests for nearest-rank percentile()."""
+
+    def test_empty_is_zero(self):
+        assert percentile([], 95) == 0.0
+
+    def test_nearest_rank(self):
+        values = [float(v) for v in range(1, 101)]
+
+        assert percentile(values, 50) == 50.0
+        assert percentile(values, 95) == 95.0
+
+    def test_single_value(self):
+        assert percentile([7.0], 50) == 7.0
+        assert percentile([7.0], 95) == 7.0
+
+    def test_unsorted_input(self):
+        assert percentile([30.0, 10.0, 20.0], 50) == 20.0
+
+
+class TestBenchmarkResult:
+    """Tests for derived BenchmarkResult metrics."""
+
+    def test_rates_and_percentiles_in_dict(self):
+        result = BenchmarkResult(
+            corpus="synthetic",
+            provider="voyage-ai",
+            model="voyage-code-3",
+            chunks=100,
+            chunk_seconds=0.5,
+            embeddings=100,
+            embed_seconds=2.0,
+            points_upserted=100,
+            upsert_seconds=0.0,
+            query_latencies_ms=[10.0, 20.0, 30.0, 40.0],
+        )
+
+        data = result.to_dict()
+
+        assert data["chunks_per_second"] == 200.0
+        assert data["embeddings_per_second"] == 50.0
+        assert data["upserts_per_second"] == 0.0
+        assert data["query_p50_ms"] == 20.0
+        assert data["query_p95_ms"] == 40.0
+
+
+class TestSyntheticCorpus:
+    """Tests for generate_synthetic_corpus()."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def _contents(self, corpus_dir, **kwargs):
+        files = generate_synthetic_corpus(corpus_dir, **kwargs)
+        return {str(f.relative_to(corpus_dir)): f.read_text() for f in files}
+
+    def test_same_seed_is_byte_identical(self):
+        first = self._contents(self.root / "a", num_files=20, seed=7)
+        second = self._contents(self.root / "b", num_files=20, seed=7)
+
+        assert len(first) == 20
+        assert first == second
+
+    def test_different_seed_changes_content(self):
+        first = self._contents(self.root / "a", num_files=5, seed=1)
+        second = self._contents(self.root / "b", num_files=5, seed=2)
+
+        assert first.keys() == second.keys()
+        assert first != second
+
+    def test_generated_files_are_valid_python(self):
+        files = generate_synthetic_corpus(self.root, num_files=10)
+
+        for path in files:
+            compile(path.read_text(), str(path), "exec")
+
+
+class TestRunBenchmark:
+    """Tests for run_benchmark() with mocked provider and store."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.corpus_dir = self.root / "corpus"
+        self.work_dir = self.root / "work"
+        self.work_dir.mkdir()
+        self.files = generate_synthetic_corpus(self.corpus_dir, num_files=6)
+
+        self.provider = Mock()
+        self.provider.get_provider_name.return_value = "voyage-ai"
+        self.provider.get_current_model.return_value = "voyage-code-3"
+        self.provider.get_embeddings_batch.side_effect = lambda texts: [
+            [0.1, 0.2, 0.3, 0.4] for _ in texts
+        ]
+        self.store = Mock()
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def _run(self, **kwargs):
+        with patch(
+            "code_indexer.storage.filesystem_vector_store.FilesystemVectorStore",
+            return_value=self.store,
+        ) as mock_store_class:
+            result = run_benchmark(
+                Config(),
+                self.provider,
+                corpus_dir=self.corpus_dir,
+                files=self.files,
+                work_dir=self.work_dir,
+                **kwargs,
+            )
+        self.mock_store_class = mock_store_class
+        return result
+
+    def test_indexes_into_scratch_store_only(self):
+        self._run(queries=["q"], query_repeats=1)
+
+        self.mock_store_class.assert_called_once_with(
+            base_path=self.work_dir / "index", project_root=self.work_dir
+        )
+        self.store.create_collection.assert_called_once_with(
+            BENCH_COLLECTION_NAME, vector_size=4
+        )
+        self.store.begin_indexing.assert_called_once_with(BENCH_COLLECTION_NAME)
+        self.store.end_indexing.assert_called_once_with(BENCH_COLLECTION_NAME)
+
+    def test_counts_every_stage(self):
+        result = self._run(queries=["a", "b"], query_repeats=3, batch_size=2)
+
+        assert result.files == 6
+        assert result.chunks > 0
+        assert result.embeddings == result.chunks
+        assert result.points_upserted == result.chunks
+        assert len(result.query_latencies_ms) == 6
+        assert self.store.search.call_count == 6
+        for call in self.provider.get_embeddings_batch.call_args_list:
+            assert len(call[0][0]) <= 2
+
+    def test_points_use_corpus_relative_paths_and_inline_text(self):
+        self._run(queries=["q"], query_repeats=1)
+
+        points = [
+            point
+            for call in self.store.upsert_points.call_args_list
+            for point in call[0][1]
+        ]
+        first = points[0]
+        assert not Path(first["payload"]["path"]).is_absolute()
+        assert first["payload"]["path"].startswith(self.files[0].parent.name)
+        assert first["chunk_text"]
+        assert len({point["id"] for point in points}) == len(points)
+
+    def test_progress_reports_each_stage(self):
+        messages = []
+
+        self._run(queries=["q"], query_repeats=1, progress_callback=messages.append)
+
+        assert [m.split()[0] for m in messages] == [
+            "Chunking",
+            "Embedding",
+            "Upserting",
+            "Building",
+            "Running",
+        ]
+
+    def test_empty_corpus_raises(self):
+        self.files = []
+
+        with pytest.raises(ValueError, match="No indexable content"):
+            self._run()
+
+        self.provider.get_embeddings_batch.assert_not_called()
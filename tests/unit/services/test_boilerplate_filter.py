@@ -0,0 +1,295 @@
+"""
+Unit tests for removing boilerplate from embedded chunk text.
+
+Tests license header, import block and accessor removal per language,
+configuration, and FileChunkingManager integration (embedded text is
+filtered, stored text is not).
+"""
+
+import tempfile
+import threading
+from concurrent.futures import Future
+from pathlib import Path
+from typing import Dict, List
+from unittest.mock import Mock
+
+import pytest
+
+from code_indexer.config import BoilerplateFilterConfig, Config
+from code_indexer.services.boilerplate_filter import (
+    EMBEDDING_TEXT_KEY,
+    BoilerplateFilter,
+)
+from code_indexer.services.clean_slot_tracker import CleanSlotTracker
+from code_indexer.services.file_chunking_manager import FileChunkingManager
+from code_indexer.services.vector_calculation_manager import VectorResult
+
+APACHE_HEADER = """/*
+ * Copyright 2024 Example Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND.
+ */
+"""
+
+
+class TestLicenseHeaders:
+    """Tests for license header removal."""
+
+    def setup_method(self):
+        self.filter = BoilerplateFilter(filters=["license_headers"])
+
+    def test_block_comment_header(self):
+        text = APACHE_HEADER + "\npackage app;\n\nclass Main {}\n"
+
+        filtered = self.filter.filter_text(text, "java", starts_file=True)
+
+        assert filtered == "package app;\n\nclass Main {}\n"
+
+    def test_line_comment_header_after_shebang(self):
+        text = (
+            "#!/usr/bin/env python3\n"
+            "# SPDX-License-Identifier: MIT\n"
+            "# Copyright (c) 2024 Example\n"
+            "\n"
+            "print('hi')\n"
+        )
+
+        filtered = self.filter.filter_text(text, "py", starts_file=True)
+
+        assert filtered == "#!/usr/bin/env python3\nprint('hi')\n"
+
+    def test_only_at_start_of_file(self):
+        text = APACHE_HEADER + "class Main {}\n"
+
+        assert self.filter.filter_text(text, "java", starts_file=False) == text
+
+    def test_ordinary_comment_is_kept(self):
+        text = "# Validates license keys for the store\ndef check(key):\n    pass\n"
+
+        assert self.filter.filter_text(text, "py", starts_file=True) == text
+
+
+class TestImportBlocks:
+    """Tests for long import block removal."""
+
+    def setup_method(self):
+        self.filter = BoilerplateFilter(filters=["imports"], min_import_lines=3)
+
+    def test_long_python_block_with_parenthesized_import(self):
+        text = (
+            "import os\n"
+            "import sys\n"
+            "# typing helpers\n"
+            "from typing import (\n"
+            "    Any,\n"
+            "    Dict,\n"
+            ")\n"
+            "\n"
+            "# Entry point\n"
+            "def main():\n"
+            "    pass\n"
+        )
+
+        filtered = self.filter.filter_text(text, "py")
+
+        assert filtered == "# Entry point\ndef main():\n    pass\n"
+
+    def test_short_block_is_kept(self):
+        text = "import os\nimport sys\n\ndef main():\n    pass\n"
+
+        assert self.filter.filter_text(text, "py") == text
+
+    def test_go_import_group(self):
+        text = 'package main\n\nimport (\n\t"fmt"\n\t"os"\n)\n\nfunc main() {}\n'
+
+        filtered = self.filter.filter_text(text, "go")
+
+        assert filtered == "package main\n\nfunc main() {}\n"
+
+    def test_c_includes(self):
+        text = "#include <stdio.h>\n#include <stdlib.h>\n#include \"app.h\"\nint x;\n"
+
+        assert self.filter.filter_text(text, "c") == "int x;\n"
+
+    def test_unknown_language_unchanged(self):
+        text = "import a\nimport b\nimport c\n"
+
+        assert self.filter.filter_text(text, "txt") == text
+
+    def test_nothing_left_keeps_original(self):
+        text = "import a\nimport b\nimport c\n"
+
+        assert self.filter.filter_text(text, "py") == text
+
+
+class TestAccessors:
+    """Tests for trivial getter and setter removal."""
+
+    def setup_method(self):
+        self.filter = BoilerplateFilter(filters=["accessors"])
+
+    def test_java_getters_and_setters(self):
+        text = (
+            "class User {\n"
+            "    private String name;\n"
+            "    @Override\n"
+            "    public String getName() {\n"
+            "        return name;\n"
+            "    }\n"
+            "    public void setName(String name) { this.name = name; }\n"
+            "    public boolean isActive() { return this.active; }\n"
+            "    public String greet() {\n"
+            '        return "Hello " + name;\n'
+            "    }\n"
+            "}\n"
+        )
+
+        filtered = self.filter.filter_text(text, "java")
+
+        assert "getName" not in filtered
+        assert "setName" not in filtered
+        assert "isActive" not in filtered
+        assert "greet()" in filtered
+        assert "private String name;" in filtered
+
+    def test_python_properties(self):
+        text = (
+            "class User:\n"
+            "    @property\n"
+            "    def name(self) -> str:\n"
+            "        return self._name\n"
+            "\n"
+            "    @name.setter\n"
+            "    def name(self, value: str) -> None:\n"
+            "        self._name = value\n"
+            "\n"
+            "    def greet(self):\n"
+            "        return f'Hello {self._name}'\n"
+        )
+
+        filtered = self.filter.filter_text(text, "py")
+
+        assert "@property" not in filtered
+        assert "setter" not in filtered
+        assert "def greet(self):" in filtered
+
+    def test_getter_with_logic_is_kept(self):
+        text = "public int getTotal() {\n    return price * quantity;\n}\n"
+
+        assert self.filter.filter_text(text, "java") == text
+
+
+class TestBoilerplateFilterConfig:
+    """Tests for indexing.boilerplate_filter."""
+
+    def test_disabled_by_default(self):
+        assert BoilerplateFilter.from_config(Config()) is None
+
+    def test_from_config(self):
+        config = Config()
+        config.indexing.boilerplate_filter = BoilerplateFilterConfig(
+            enabled=True, filters=["imports"], min_import_lines=4
+        )
+
+        boilerplate_filter = BoilerplateFilter.from_config(config)
+
+        assert boilerplate_filter.filters == ["imports"]
+        assert boilerplate_filter.min_import_lines == 4
+
+    def test_unknown_filter_rejected(self):
+        with pytest.raises(ValueError):
+            BoilerplateFilterConfig(filters=["comments"])
+
+    def test_filter_chunks_sets_embedding_text(self):
+        boilerplate_filter = BoilerplateFilter(min_import_lines=2)
+        chunks = [
+            {"text": "import a\nimport b\nx = 1\n", "file_extension": "py"},
+            {"text": "x = 2\n", "file_extension": "py"},
+        ]
+
+        filtered = boilerplate_filter.filter_chunks(chunks)
+
+        assert filtered[0][EMBEDDING_TEXT_KEY] == "x = 1\n"
+        assert filtered[0]["text"] == chunks[0]["text"]
+        assert EMBEDDING_TEXT_KEY not in filtered[1]
+        assert boilerplate_filter.get_stats()["chunks_filtered"] == 1
+
+
+class RecordingVectorManager:
+    """Vector manager mock recording the texts it is asked to embed."""
+
+    def __init__(self):
+        self.cancellation_event = threading.Event()
+        self.embedding_provider = Mock()
+        self.embedding_provider.get_current_model.return_value = "voyage-code-3"
+        self.embedding_provider._get_model_token_limit.return_value = 120000
+        self.embedded_texts: List[str] = []
+
+    def submit_batch_task(self, chunk_texts: List[str], metadata: Dict):
+        self.embedded_texts.extend(chunk_texts)
+        future = Future()
+        future.set_result(
+            VectorResult(
+                task_id="batch",
+                embeddings=tuple((0.5,) * 8 for _ in chunk_texts),
+                metadata=metadata.copy(),
+                processing_time=0.0,
+                error=None,
+            )
+        )
+        return future
+
+
+class TestFileChunkingManagerFiltering:
+    """Tests for filtering the embedded text of chunks."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.file_path = self.root / "main.py"
+        self.file_path.write_text("import os\nimport sys\n\nprint(os.sep)\n")
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def test_embedded_text_is_filtered_stored_text_is_not(self):
+        vector_manager = RecordingVectorManager()
+        vector_store = Mock()
+        vector_store.upsert_points.return_value = True
+        chunker = Mock()
+        chunker.chunk_file.return_value = [
+            {
+                "text": self.file_path.read_text(),
+                "chunk_index": 0,
+                "total_chunks": 1,
+                "file_extension": "py",
+                "line_start": 1,
+                "line_end": 4,
+            }
+        ]
+        manager = FileChunkingManager(
+            vector_manager=vector_manager,
+            chunker=chunker,
+            vector_store_client=vector_store,
+            thread_count=1,
+            slot_tracker=CleanSlotTracker(max_slots=3),
+            codebase_dir=self.root,
+            boilerplate_filter=BoilerplateFilter(min_import_lines=2),
+        )
+        metadata = {
+            "project_id": "test_project",
+            "file_hash": "sha256:aaa",
+            "git_available": False,
+            "collection_name": "test_collection",
+        }
+
+        with manager:
+            result = manager.submit_file_for_processing(
+                self.file_path, metadata, None
+            ).result(timeout=10.0)
+
+        assert result.success
+        assert vector_manager.embedded_texts == ["print(os.sep)\n"]
+        points = vector_store.upsert_points.call_args.kwargs["points"]
+        assert "import sys" in str(points)
@@ -0,0 +1,116 @@
+"""
+Unit tests for reusing stored content on branch switches.
+
+Tests that changed files whose content is already indexed are made visible in
+the new branch instead of being embedded again, and that files with new
+content are still indexed.
+"""
+
+from unittest.mock import Mock, patch
+
+from code_indexer.config import Config
+from code_indexer.services.high_throughput_processor import HighThroughputProcessor
+from code_indexer.utils.file_hashing import hash_file
+
+
+def point(point_id, path, file_hash, hidden=()):
+    return {
+        "id": point_id,
+        "payload": {
+            "type": "content",
+            "path": path,
+            "file_hash": file_hash,
+            "hidden_branches": list(hidden),
+        },
+    }
+
+
+def make_processor(tmp_path, points, **indexing):
+    config = Config(codebase_dir=tmp_path)
+    for key, value in indexing.items():
+        setattr(config.indexing, key, value)
+    store = Mock()
+    store.scroll_points.return_value = (points, None)
+    store.end_indexing.return_value = {}
+    provider = Mock()
+    provider.get_provider_name.return_value = "voyage-ai"
+    provider.get_current_model.return_value = "voyage-code-3"
+    processor = HighThroughputProcessor(
+        config=config, vector_store_client=store, embedding_provider=provider
+    )
+    return processor, store
+
+
+class TestBranchContentDedup:
+    """Tests for branch switches reusing points of identical content."""
+
+    def test_stored_content_is_made_visible_not_reindexed(self, tmp_path):
+        (tmp_path / "same.py").write_text("print('same')\n")
+        (tmp_path / "edited.py").write_text("print('new')\n")
+        same_hash = hash_file(tmp_path / "same.py", "sha256")
+        processor, store = make_processor(
+            tmp_path,
+            [
+                point("a", "same.py", same_hash, hidden=["feature", "dev"]),
+                point("b", "same.py", same_hash),
+                point("c", "edited.py", "sha256:old"),
+            ],
+        )
+
+        remaining, reused = processor._reuse_stored_content_thread_safe(
+            ["same.py", "edited.py", "new.py"], "feature", "code"
+        )
+
+        assert remaining == ["edited.py", "new.py"]
+        assert reused == 2
+        store._batch_update_points.assert_called_once_with(
+            [{"id": "a", "payload": {"hidden_branches": ["dev"]}}], "code"
+        )
+
+    def test_branch_switch_indexes_only_new_content(self, tmp_path):
+        (tmp_path / "same.py").write_text("print('same')\n")
+        (tmp_path / "edited.py").write_text("print('new')\n")
+        same_hash = hash_file(tmp_path / "same.py", "sha256")
+        processor, _ = make_processor(
+            tmp_path,
+            [point("a", "same.py", same_hash), point("c", "edited.py", "sha256:x")],
+        )
+        stats = Mock(files_processed=1, chunks_created=1, cancelled=False)
+
+        with patch.object(
+            processor, "process_files_high_throughput", return_value=stats
+        ) as process_files:
+            result = processor.process_branch_changes_high_throughput(
+                old_branch="main",
+                new_branch="feature",
+                changed_files=["same.py", "edited.py"],
+                unchanged_files=[],
+                collection_name="code",
+            )
+
+        assert process_files.call_args.kwargs["files"] == [tmp_path / "edited.py"]
+        assert result.content_points_reused == 1
+
+    def test_disabled(self, tmp_path):
+        (tmp_path / "same.py").write_text("print('same')\n")
+        same_hash = hash_file(tmp_path / "same.py", "sha256")
+        processor, _ = make_processor(
+            tmp_path,
+            [point("a", "same.py", same_hash)],
+            deduplicate_branch_content=False,
+        )
+        stats = Mock(files_processed=1, chunks_created=1, cancelled=False)
+
+        with patch.object(
+            processor, "process_files_high_throughput", return_value=stats
+        ) as process_files:
+            result = processor.process_branch_changes_high_throughput(
+                old_branch="main",
+                new_branch="feature",
+                changed_files=["same.py"],
+                unchanged_files=[],
+                collection_name="code",
+            )
+
+        assert process_files.call_args.kwargs["files"] == [tmp_path / "same.py"]
+        assert result.content_points_reused == 0
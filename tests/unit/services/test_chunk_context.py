@@ -0,0 +1,190 @@
+"""
+Unit tests for enclosing-context headers of embedded chunk text.
+
+Tests package, import and enclosing type extraction for Python, Java, Go and
+Rust, header text for chunks that contain the imports, chunk enrichment and
+configuration.
+"""
+
+from code_indexer.config import Config
+from code_indexer.services.boilerplate_filter import EMBEDDING_TEXT_KEY
+from code_indexer.services.chunk_context import (
+    ChunkContextEnricher,
+    enclosing_types,
+    imports_of,
+    package_of,
+)
+
+JAVA = """package com.shop.cart;
+
+import java.util.List;
+import com.shop.model.Item;
+
+public class CartService extends BaseService {
+    private final List<Item> items;
+
+    public int total() {
+        int sum = 0;
+        for (Item item : items) {
+            sum += item.price();
+        }
+        return sum;
+    }
+}
+"""
+
+PYTHON = """import os
+from typing import List
+
+
+class Cart:
+    \"\"\"A shopping cart.\"\"\"
+
+    def total(self):
+        return sum(self.items)
+
+
+def helper():
+    return 1
+"""
+
+GO = """package cart
+
+import (
+\t"fmt"
+\tstore "example.com/shop/store"
+)
+
+type Cart struct {
+\titems []int
+}
+
+func (c *Cart) Total() int {
+\tsum := 0
+\tfor _, i := range c.items {
+\t\tsum += i
+\t}
+\treturn sum
+}
+
+func New() *Cart {
+\treturn &Cart{}
+}
+"""
+
+
+def lines_of(text):
+    return text.split("\n")
+
+
+def chunk(text, start, end, extension):
+    lines = text.split("\n")[start - 1 : end]
+    return {
+        "text": "\n".join(lines),
+        "line_start": start,
+        "line_end": end,
+        "file_extension": extension,
+    }
+
+
+class TestContextExtraction:
+    """Tests for reading packages, imports and enclosing types."""
+
+    def test_java(self):
+        lines = lines_of(JAVA)
+
+        assert package_of(lines, "java") == ("com.shop.cart", 0)
+        assert imports_of(lines, "java") == (
+            ["java.util.List", "com.shop.model.Item"],
+            2,
+        )
+        assert enclosing_types(lines, 10, "java") == [
+            "public class CartService extends BaseService"
+        ]
+        assert enclosing_types(lines, 0, "java") == []
+
+    def test_python_classes_by_indentation(self):
+        lines = lines_of(PYTHON)
+
+        assert imports_of(lines, "py") == (["os", "typing"], 0)
+        assert enclosing_types(lines, 7, "py") == ["class Cart"]
+        assert enclosing_types(lines, 12, "py") == []
+
+    def test_go_receiver_type(self):
+        lines = lines_of(GO)
+
+        assert package_of(lines, "go") == ("cart", 0)
+        assert imports_of(lines, "go") == (["fmt", "example.com/shop/store"], 2)
+        assert enclosing_types(lines, 13, "go") == ["type Cart struct"]
+        assert enclosing_types(lines, 19, "go") == []
+
+    def test_rust_impl_block(self):
+        lines = lines_of(
+            "use std::fmt;\n\nimpl fmt::Display for Cart {\n"
+            "    fn fmt(&self) {\n        todo!()\n    }\n}\n"
+        )
+
+        assert imports_of(lines, "rs") == (["std::fmt"], 0)
+        assert enclosing_types(lines, 3, "rs") == ["impl fmt::Display for Cart"]
+
+
+class TestChunkContextEnricher:
+    """Tests for prefixing the embedded text of chunks."""
+
+    def test_method_chunk_gets_a_header(self, tmp_path):
+        path = tmp_path / "CartService.java"
+        path.write_text(JAVA)
+        method = chunk(JAVA, 9, 15, "java")
+
+        enriched = ChunkContextEnricher(tmp_path).enrich_chunks([method], path)
+
+        assert enriched[0][EMBEDDING_TEXT_KEY] == (
+            "Package: com.shop.cart\n"
+            "Imports: java.util.List, com.shop.model.Item\n"
+            "Enclosing type: public class CartService extends BaseService\n\n"
+            + method["text"]
+        )
+        assert enriched[0]["text"] == method["text"]
+        assert EMBEDDING_TEXT_KEY not in method
+
+    def test_parts_the_chunk_contains_are_left_out(self, tmp_path):
+        path = tmp_path / "src" / "shop" / "cart.py"
+        path.parent.mkdir(parents=True)
+        path.write_text(PYTHON)
+        head = chunk(PYTHON, 1, 6, "py")
+        method = chunk(PYTHON, 8, 9, "py")
+
+        enriched = ChunkContextEnricher(tmp_path).enrich_chunks([head, method], path)
+
+        assert enriched[0][EMBEDDING_TEXT_KEY].split("\n\n")[0] == (
+            "Package: shop.cart"
+        )
+        assert enriched[1][EMBEDDING_TEXT_KEY].split("\n\n")[0] == (
+            "Package: shop.cart\nImports: os, typing\nEnclosing type: class Cart"
+        )
+
+    def test_imports_beyond_the_limit_are_counted(self, tmp_path):
+        path = tmp_path / "main.go"
+        path.write_text(GO)
+
+        enriched = ChunkContextEnricher(tmp_path, max_imports=1).enrich_chunks(
+            [chunk(GO, 20, 22, "go")], path
+        )
+
+        assert "Imports: fmt (+1 more)\n" in enriched[0][EMBEDDING_TEXT_KEY]
+
+    def test_existing_embedding_text_is_kept(self, tmp_path):
+        path = tmp_path / "CartService.java"
+        path.write_text(JAVA)
+        method = {**chunk(JAVA, 9, 15, "java"), EMBEDDING_TEXT_KEY: "filtered"}
+
+        enriched = ChunkContextEnricher(tmp_path).enrich_chunks([method], path)
+
+        assert enriched[0][EMBEDDING_TEXT_KEY].endswith("\n\nfiltered")
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert ChunkContextEnricher.from_config(config) is None
+
+        config.indexing.chunk_context.enabled = True
+        assert ChunkContextEnricher.from_config(config) is not None
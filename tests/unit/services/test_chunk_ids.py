@@ -0,0 +1,97 @@
+"""
+Unit tests for deterministic chunk point IDs.
+
+Tests compute_chunk_point_id() and the IDs FileChunkingManager assigns to
+new points.
+"""
+
+# mypy: ignore-errors
+
+import tempfile
+import uuid
+from pathlib import Path
+from unittest.mock import Mock
+
+from src.code_indexer.services.chunk_ids import compute_chunk_point_id
+from src.code_indexer.services.chunk_integrity import compute_chunk_hash
+from src.code_indexer.services.file_chunking_manager import FileChunkingManager
+
+SOURCE = "def add(a, b):\n    return a + b\n"
+HASH = compute_chunk_hash(SOURCE)
+
+
+class TestComputeChunkPointId:
+    """Tests for compute_chunk_point_id()."""
+
+    def test_identical_content_yields_identical_id(self):
+        first = compute_chunk_point_id("repo", "src/math.py", 0, HASH)
+        second = compute_chunk_point_id("repo", "src/math.py", 0, HASH)
+
+        assert first == second
+
+    def test_id_is_a_uuid(self):
+        point_id, unique_key = compute_chunk_point_id("repo", "src/math.py", 3, HASH)
+
+        assert str(uuid.UUID(point_id)) == point_id
+        assert unique_key == f"repo:src/math.py:3:{HASH}"
+
+    def test_each_component_changes_the_id(self):
+        base = compute_chunk_point_id("repo", "src/math.py", 0, HASH)[0]
+
+        assert compute_chunk_point_id("fork", "src/math.py", 0, HASH)[0] != base
+        assert compute_chunk_point_id("repo", "vendor/math.py", 0, HASH)[0] != base
+        assert compute_chunk_point_id("repo", "src/math.py", 1, HASH)[0] != base
+        other_hash = compute_chunk_hash(SOURCE + "\n")
+        assert compute_chunk_point_id("repo", "src/math.py", 0, other_hash)[0] != base
+
+
+class TestFileChunkingManagerPointIds:
+    """Tests for the IDs of points created while indexing."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.file_path = self.root / "math_utils.py"
+        self.file_path.write_text(SOURCE)
+        vector_manager = Mock()
+        vector_manager.embedding_provider.__class__.__name__ = "Mock"
+        self.manager = FileChunkingManager(
+            vector_manager=vector_manager,
+            chunker=Mock(),
+            vector_store_client=Mock(),
+            thread_count=1,
+            slot_tracker=Mock(),
+            codebase_dir=self.root,
+        )
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def _point(self, text=SOURCE, file_hash="sha256:abc"):
+        chunk = {
+            "text": text,
+            "chunk_index": 0,
+            "total_chunks": 1,
+            "file_extension": "py",
+            "line_start": 1,
+            "line_end": 2,
+        }
+        metadata = {"project_id": "repo", "file_hash": file_hash}
+        return self.manager._create_vector_point(
+            chunk, [0.1], metadata, self.file_path
+        )
+
+    def test_reindexing_identical_content_reuses_id(self):
+        first = self._point()
+        second = self._point()
+
+        assert first["id"] == second["id"]
+        assert first["payload"]["point_id"] == first["id"]
+
+    def test_id_does_not_depend_on_file_hash_algorithm(self):
+        assert self._point(file_hash="sha256:abc")["id"] == (
+            self._point(file_hash="xxh3:def")["id"]
+        )
+
+    def test_changed_content_gets_new_id(self):
+        assert self._point()["id"] != self._point(text=SOURCE + "# done\n")["id"]
@@ -0,0 +1,167 @@
+"""
+Unit tests for chunk provenance and integrity verification.
+
+Tests ChunkIntegrityVerifier against stored text, working files and git
+sources, and the provenance fields written by FileChunkingManager.
+"""
+
+# mypy: ignore-errors
+
+import subprocess
+import tempfile
+from pathlib import Path
+from unittest.mock import Mock
+
+from src.code_indexer import __version__
+from src.code_indexer.services.chunk_integrity import (
+    ISSUE_CORRUPTED,
+    ISSUE_MISSING_BLOB,
+    ISSUE_UNREADABLE,
+    ISSUE_UNTRACKED,
+    ChunkIntegrityVerifier,
+    compute_chunk_hash,
+)
+from src.code_indexer.services.file_chunking_manager import FileChunkingManager
+
+SOURCE = "def add(a, b):\n    return a + b\n"
+
+
+def _record(text, path="math_utils.py", **payload):
+    return {
+        "id": "p1",
+        "chunk_text": text,
+        "payload": {
+            "path": path,
+            "type": "content",
+            "chunk_hash": compute_chunk_hash(text),
+            **payload,
+        },
+    }
+
+
+class TestChunkIntegrityVerifier:
+    """Tests for verifying stored chunks."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        (self.root / "math_utils.py").write_text(SOURCE)
+        self.store = Mock()
+        self.verifier = ChunkIntegrityVerifier(self.store, self.root)
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def _git(self, *args):
+        return subprocess.run(
+            ["git", *args], cwd=self.root, capture_output=True, text=True, check=True
+        ).stdout.strip()
+
+    def test_chunk_of_working_file_verifies(self):
+        assert self.verifier.verify_chunk(_record("return a + b")) is None
+
+    def test_altered_text_is_corrupted(self):
+        record = _record("return a + b")
+        record["chunk_text"] = "return a - b"
+
+        assert self.verifier.verify_chunk(record).kind == ISSUE_CORRUPTED
+
+    def test_text_missing_from_sources_is_untracked(self):
+        issue = self.verifier.verify_chunk(_record("return a * b"))
+
+        assert issue.kind == ISSUE_UNTRACKED
+
+    def test_legacy_chunk_without_hash_checks_sources(self):
+        record = _record("return a * b")
+        del record["payload"]["chunk_hash"]
+
+        assert self.verifier.verify_chunk(record).kind == ISSUE_UNTRACKED
+
+    def test_pii_scrubbed_chunk_checks_hash_only(self):
+        record = _record("email = <EMAIL>", pii_scrubbed=True)
+
+        assert self.verifier.verify_chunk(record) is None
+
+    def test_chunk_from_indexed_commit_verifies(self):
+        self._git("init", "-q")
+        self._git("add", "math_utils.py")
+        self._git(
+            "-c", "user.email=t@t", "-c", "user.name=t", "commit", "-qm", "init"
+        )
+        commit = self._git("rev-parse", "HEAD")
+        (self.root / "math_utils.py").write_text("changed\n")
+
+        record = _record("return a + b", git_commit_hash=commit)
+
+        assert self.verifier.verify_chunk(record) is None
+
+    def test_blob_pointer_requires_blob(self):
+        self._git("init", "-q")
+        blob = self._git("hash-object", "-w", "math_utils.py")
+        record = {"id": "p1", "git_blob_hash": blob, "payload": {"path": "x.py"}}
+
+        assert self.verifier.verify_chunk(record) is None
+        record["git_blob_hash"] = "0" * 40
+        assert self.verifier.verify_chunk(record).kind == ISSUE_MISSING_BLOB
+
+    def test_verify_collection_report(self):
+        self.store.iter_vector_records.return_value = [
+            (Path("vector_p1.json"), _record("return a + b"), None),
+            (Path("vector_p2.json"), None, "Expecting value"),
+            (
+                Path("vector_p3.json"),
+                {"id": "p3", "payload": {"type": "commit_diff"}},
+                None,
+            ),
+        ]
+
+        report = self.verifier.verify_collection("code")
+
+        assert (report.chunks_checked, report.chunks_verified) == (2, 1)
+        assert report.chunks_skipped == 1
+        assert [i.kind for i in report.issues] == [ISSUE_UNREADABLE]
+        assert report.issues[0].point_id == "p2"
+        assert report.to_dict()["ok"] is False
+
+
+class TestProvenanceFields:
+    """Tests for provenance recorded on new points."""
+
+    def test_vector_point_records_provenance(self):
+        with tempfile.TemporaryDirectory() as temp:
+            root = Path(temp)
+            file_path = root / "math_utils.py"
+            file_path.write_text(SOURCE)
+            vector_manager = Mock()
+            vector_manager.embedding_provider.__class__.__name__ = "Mock"
+            manager = FileChunkingManager(
+                vector_manager=vector_manager,
+                chunker=Mock(),
+                vector_store_client=Mock(),
+                thread_count=1,
+                slot_tracker=Mock(),
+                codebase_dir=root,
+            )
+            chunk = {
+                "text": SOURCE,
+                "chunk_index": 0,
+                "total_chunks": 1,
+                "file_extension": "py",
+                "line_start": 1,
+                "line_end": 2,
+                "pii_scrubbed": True,
+            }
+            metadata = {
+                "project_id": "p",
+                "file_hash": "sha256:abc",
+                "git_available": True,
+                "commit_hash": "c" * 40,
+            }
+
+            point = manager._create_vector_point(chunk, [0.1], metadata, file_path)
+
+        payload = point["payload"]
+        assert payload["chunk_hash"] == compute_chunk_hash(SOURCE)
+        assert payload["indexer_version"] == __version__
+        assert payload["git_commit_hash"] == "c" * 40
+        assert payload["pii_scrubbed"] is True
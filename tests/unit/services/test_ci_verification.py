@@ -0,0 +1,158 @@
+"""
+Unit tests for the CI staleness gate ('cidx verify --ci').
+
+Tests each check of CiIndexVerifier against a real git checkout and the
+action recommended for the drift found.
+"""
+
+import json
+import subprocess
+from unittest.mock import Mock
+
+import pytest
+
+from code_indexer.services.ci_verification import (
+    ACTION_INDEX,
+    ACTION_NONE,
+    ACTION_REBUILD,
+    CiIndexVerifier,
+)
+
+MODEL = "voyage-code-3"
+
+
+def git(root, *args):
+    return subprocess.run(
+        ["git", "-c", "user.name=t", "-c", "user.email=t@example.com", *args],
+        cwd=root,
+        check=True,
+        capture_output=True,
+        text=True,
+    ).stdout.strip()
+
+
+def commit(root, files):
+    for path, text in files.items():
+        (root / path).write_text(text)
+    git(root, "add", "-A")
+    git(root, "commit", "-q", "-m", "change")
+    return git(root, "rev-parse", "HEAD")
+
+
+def chunk(path):
+    payload = {"path": path, "type": "content", "chunk_index": 0, "total_chunks": 1}
+    return {"id": path, "payload": payload}
+
+
+@pytest.fixture
+def project(tmp_path):
+    git(tmp_path, "init", "-q", "-b", "main")
+    indexed = commit(tmp_path, {"a.py": "a = 1\n", "b.py": "b = 1\n"})
+    (tmp_path / ".code-indexer").mkdir()
+    (tmp_path / ".code-indexer" / "metadata.json").write_text(
+        json.dumps(
+            {
+                "status": "completed",
+                "current_commit": indexed,
+                "current_branch": "main",
+                "embedding_model": MODEL,
+            }
+        )
+    )
+    return tmp_path
+
+
+@pytest.fixture
+def store(tmp_path):
+    store = Mock()
+    store.base_path = tmp_path / "index"
+    store.list_collections.return_value = [MODEL]
+    store.iter_vector_records.return_value = [
+        (store.base_path / MODEL / f"{p}.json", chunk(p), None)
+        for p in ("a.py", "b.py")
+    ]
+    return store
+
+
+def verify(project, store, tracked=("a.py", "b.py"), model=MODEL, **kwargs):
+    return CiIndexVerifier(
+        project,
+        project / ".code-indexer" / "metadata.json",
+        model,
+        store,
+        tracked,
+        **kwargs,
+    ).verify()
+
+
+def check(report, name):
+    return next(c for c in report.checks if c.name == name)
+
+
+class TestCiIndexVerifier:
+    """Tests for CiIndexVerifier."""
+
+    def test_fresh_index(self, project, store):
+        report = verify(project, store)
+
+        assert report.ok
+        assert report.action == ACTION_NONE
+        assert report.commits_behind == 0
+        assert report.to_dict()["ok"] is True
+
+    def test_changed_and_deleted_files_need_index(self, project, store):
+        (project / "b.py").unlink()
+        commit(project, {"a.py": "a = 2\n", "notes.txt": "x\n"})
+
+        report = verify(project, store, tracked=("a.py",))
+
+        changed = check(report, "changed_files")
+        assert not changed.ok
+        assert changed.paths == ["a.py", "b.py"]
+        assert report.commits_behind == 1
+        assert report.action == ACTION_INDEX
+
+    def test_stale_files_tolerance(self, project, store):
+        commit(project, {"a.py": "a = 2\n"})
+
+        assert verify(project, store, max_stale_files=1).ok
+
+    def test_missing_files(self, project, store):
+        commit(project, {"c.py": "c = 1\n"})
+
+        report = verify(project, store, tracked=("a.py", "b.py", "c.py"))
+
+        missing = check(report, "missing_files")
+        assert missing.paths == ["c.py"]
+        assert missing.path_count == 1
+
+    def test_model_mismatch_needs_rebuild(self, project, store):
+        report = verify(project, store, model="voyage-3-large")
+
+        assert check(report, "embedding_model").detail == (
+            "index built with 'voyage-code-3', configured model is 'voyage-3-large'"
+        )
+        assert report.action == ACTION_REBUILD
+        assert all(c.name != "missing_files" for c in report.checks)
+
+    def test_unknown_indexed_commit_needs_rebuild(self, project, store):
+        metadata_path = project / ".code-indexer" / "metadata.json"
+        metadata = json.loads(metadata_path.read_text())
+        metadata["current_commit"] = "0" * 40
+        metadata_path.write_text(json.dumps(metadata))
+
+        report = verify(project, store)
+
+        assert "not in this checkout" in check(report, "history").detail
+        assert report.action == ACTION_REBUILD
+
+    def test_interrupted_indexing(self, project, store):
+        metadata_path = project / ".code-indexer" / "metadata.json"
+        metadata = json.loads(metadata_path.read_text())
+        metadata["status"] = "in_progress"
+        metadata_path.write_text(json.dumps(metadata))
+
+        report = verify(project, store)
+
+        assert not check(report, "index_complete").ok
+        assert report.action == ACTION_INDEX
@@ -0,0 +1,131 @@
+"""
+Unit tests for near-duplicate code detection (cidx clones).
+
+Tests loading comparable chunks, finding similar vector pairs, clustering
+pairs, counting duplicated lines, and workspace model checks.
+"""
+
+from pathlib import Path
+from unittest.mock import Mock, patch
+
+import pytest
+
+from code_indexer.services.clone_detection import (
+    CloneChunk,
+    CloneReport,
+    cluster_clones,
+    find_similar_pairs,
+    format_cluster,
+    load_chunks,
+    load_workspace_chunks,
+)
+from code_indexer.services.generated_code import GENERATED_KEY
+from code_indexer.storage.vector_kinds import SIGNATURE_VECTOR, VECTOR_KIND_KEY
+
+
+def record(path, start, end, vector, **payload):
+    return (
+        Path("vector.json"),
+        {
+            "vector": vector,
+            "payload": {"path": path, "line_start": start, "line_end": end, **payload},
+        },
+        None,
+    )
+
+
+class TestLoadChunks:
+    """Tests for load_chunks."""
+
+    def test_skips_extra_vectors_hidden_short_and_generated(self):
+        store = Mock()
+        store.iter_vector_records.return_value = [
+            record("a.py", 1, 10, [1.0, 0.0], language="python"),
+            record("a.py", 1, 1, [1.0, 0.0], **{VECTOR_KIND_KEY: SIGNATURE_VECTOR}),
+            record("b.py", 1, 10, [1.0, 0.0], hidden_branches=["main"]),
+            record("c.py", 1, 2, [1.0, 0.0]),
+            record("d_pb.py", 1, 10, [1.0, 0.0], **{GENERATED_KEY: True}),
+            (Path("broken.json"), None, "unreadable"),
+        ]
+
+        with patch(
+            "code_indexer.utils.git_runner.get_current_branch", return_value="main"
+        ):
+            chunks, vectors = load_chunks(store, "code", Path("."), repository="api")
+            with_generated, _ = load_chunks(
+                store, "code", Path("."), include_generated=True
+            )
+
+        assert chunks == [CloneChunk("a.py", 1, 10, "python", "api")]
+        assert vectors == [[1.0, 0.0]]
+        assert [chunk.path for chunk in with_generated] == ["a.py", "d_pb.py"]
+
+
+class TestClustering:
+    """Tests for find_similar_pairs and cluster_clones."""
+
+    def test_find_similar_pairs(self):
+        pytest.importorskip("numpy")
+
+        pairs = find_similar_pairs(
+            [[1.0, 0.0], [2.0, 0.1], [0.0, 1.0], [0.99, 0.01]], threshold=0.99
+        )
+
+        assert [(i, j) for i, j, _ in pairs] == [(0, 1), (0, 3), (1, 3)]
+        assert all(similarity >= 0.99 for _, _, similarity in pairs)
+
+    def test_clusters_pairs_and_counts_duplicated_lines(self):
+        chunks = [
+            CloneChunk("a.py", 1, 20),
+            CloneChunk("b.py", 5, 14),
+            CloneChunk("c.py", 30, 41),
+            CloneChunk("d.py", 1, 8),
+            CloneChunk("e.py", 1, 8),
+            CloneChunk("a.py", 10, 30),
+        ]
+        pairs = [
+            (0, 1, 0.95),
+            (1, 2, 0.97),
+            (3, 4, 0.99),
+            # Overlapping chunks of one file are not clones
+            (0, 5, 0.98),
+        ]
+
+        clusters = cluster_clones(chunks, pairs)
+        report = CloneReport(0.93, len(chunks), clusters)
+
+        assert [[c.path for c in cluster.chunks] for cluster in clusters] == [
+            ["a.py", "b.py", "c.py"],
+            ["d.py", "e.py"],
+        ]
+        assert [cluster.duplicated_lines for cluster in clusters] == [22, 8]
+        assert report.duplicated_lines == 30
+        assert report.to_dict()["clusters"][0]["max_similarity"] == 0.97
+        assert format_cluster(clusters[0], 1) == (
+            "Cluster 1: 3 copies, similarity 0.950-0.970, 22 duplicated lines"
+        )
+
+
+class TestWorkspaceChunks:
+    """Tests for load_workspace_chunks."""
+
+    def test_requires_one_embedding_model(self):
+        loaded = {
+            "api": ([CloneChunk("a.py", 1, 9, repository="api")], [[1.0]], "voyage/m"),
+            "web": ([CloneChunk("a.ts", 1, 9, repository="web")], [[1.0]], "voyage/m"),
+            "ops": ([], [], "ollama/other"),
+        }
+        target = "code_indexer.services.clone_detection.load_repository_chunks"
+
+        with patch(target, side_effect=lambda root, alias, **_: loaded[alias]):
+            chunks, vectors = load_workspace_chunks(
+                {"api": Path("/api"), "web": Path("/web")}
+            )
+            with pytest.raises(ValueError, match="one embedding model"):
+                load_workspace_chunks({"api": Path("/api"), "ops": Path("/ops")})
+
+        assert [chunk.location for chunk in chunks] == [
+            "[api] a.py:1-9",
+            "[web] a.ts:1-9",
+        ]
+        assert vectors == [[1.0], [1.0]]
@@ -0,0 +1,69 @@
+"""
+Unit tests for resolving COBOL copybooks to project files.
+
+Tests copybook file recognition, member lookup, chunk annotation and
+configuration.
+"""
+
+from code_indexer.config import Config
+from code_indexer.indexing.cobol_chunker import COBOL_COPYBOOKS_KEY
+from code_indexer.services.cobol_copybooks import (
+    COPYBOOK_PATHS_KEY,
+    UNRESOLVED_COPYBOOKS_KEY,
+    CopybookIndex,
+    copybook_member,
+)
+
+FILES = {
+    "copybooks/CUSTREC.cpy": "       01  CUST-REC.\n",
+    "legacy/custrec.cbl": "       IDENTIFICATION DIVISION.\n",
+    "shared/validate.copy": "           IF CUST-ID = SPACES\n",
+    "src/CUSTUPD.cbl": "       IDENTIFICATION DIVISION.\n",
+}
+
+
+def write_project(root):
+    for path, text in FILES.items():
+        (root / path).parent.mkdir(parents=True, exist_ok=True)
+        (root / path).write_text(text)
+
+
+class TestCopybookIndex:
+    """Tests for finding copybook files and annotating chunks."""
+
+    def test_copybook_member(self):
+        assert copybook_member("CUSTREC.cpy") == "CUSTREC"
+        assert copybook_member("validate.copy") == "VALIDATE"
+        assert copybook_member("CUSTUPD.CBL") == "CUSTUPD"
+        assert copybook_member("custrec.py") is None
+
+    def test_files_of_members(self, tmp_path):
+        write_project(tmp_path)
+        index = CopybookIndex(tmp_path)
+
+        # A copybook wins over a program of the same name
+        assert index.files_of("custrec") == ["copybooks/CUSTREC.cpy"]
+        assert index.files_of("VALIDATE") == ["shared/validate.copy"]
+        assert index.files_of("SQLCA") == []
+
+    def test_annotate_chunks(self, tmp_path):
+        write_project(tmp_path)
+        index = CopybookIndex(tmp_path)
+        chunks = [
+            {"text": "01  WS-EOF PIC X."},
+            {"text": "COPY CUSTREC.", COBOL_COPYBOOKS_KEY: ["CUSTREC", "SQLCA"]},
+        ]
+
+        annotated = index.annotate_chunks(chunks, tmp_path / "src/CUSTUPD.cbl")
+
+        assert COPYBOOK_PATHS_KEY not in annotated[0]
+        assert annotated[1][COPYBOOK_PATHS_KEY] == ["copybooks/CUSTREC.cpy"]
+        assert annotated[1][UNRESOLVED_COPYBOOKS_KEY] == ["SQLCA"]
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+
+        assert isinstance(CopybookIndex.from_config(config), CopybookIndex)
+
+        config.indexing.cobol_chunking = False
+        assert CopybookIndex.from_config(config) is None
@@ -0,0 +1,116 @@
+"""
+Unit tests for size and complexity metrics of code chunks.
+
+Tests lines of code, cyclomatic complexity, nesting depth and parameter
+counts in Python, Go, Java, TypeScript and Ruby, chunk annotation and
+configuration.
+"""
+
+from code_indexer.config import Config
+from code_indexer.services.code_metrics import (
+    COMPLEXITY_KEY,
+    LOC_KEY,
+    NESTING_DEPTH_KEY,
+    PARAMETER_COUNT_KEY,
+    CodeMetrics,
+    chunk_metrics,
+)
+
+PYTHON = '''def login(self, user, password=None):
+    """Log in if the password matches."""
+    # if locked, refuse
+    if user and password:
+        for attempt in range(3):
+            if attempt or user.locked:
+                return False
+
+    return True
+'''
+
+GO = """func (s *Server) Handle(w http.ResponseWriter, r *http.Request) {
+\tif r == nil || w == nil { // if nothing to do
+\t\treturn
+\t}
+\tlog.Print("if for while")
+\tfor _, x := range r.Items {
+\t\tswitch x {
+\t\tcase 1:
+\t\tcase 2:
+\t\t}
+\t}
+}
+"""
+
+
+class TestChunkMetrics:
+    """Tests for computing metrics of chunk text."""
+
+    def test_python(self):
+        assert chunk_metrics(PYTHON, "py") == {
+            LOC_KEY: 6,
+            COMPLEXITY_KEY: 6,
+            NESTING_DEPTH_KEY: 4,
+            PARAMETER_COUNT_KEY: 2,
+        }
+
+    def test_go_ignores_strings_and_comments(self):
+        assert chunk_metrics(GO, "go") == {
+            LOC_KEY: 12,
+            COMPLEXITY_KEY: 6,
+            NESTING_DEPTH_KEY: 3,
+            PARAMETER_COUNT_KEY: 2,
+        }
+
+    def test_java_generic_signature_and_ternary(self):
+        text = (
+            "public Map<String, List<Item>> group(List<Item> items, "
+            "Map<String, Integer> limits) throws IOException {\n"
+            "    return items.isEmpty() ? Map.of() : index(items);\n"
+            "}\n"
+        )
+
+        metrics = chunk_metrics(text, "java")
+
+        assert metrics[PARAMETER_COUNT_KEY] == 2
+        assert metrics[COMPLEXITY_KEY] == 2
+
+    def test_typescript_arrow_functions_and_rust_receivers(self):
+        arrow = "const f = async (a: number, b): Promise<void> => a && b;\n"
+        rust = "fn parse(&self, input: &str) -> Result<(), E> {}\n"
+
+        assert chunk_metrics(arrow, "ts")[PARAMETER_COUNT_KEY] == 2
+        assert chunk_metrics(rust, "rs")[PARAMETER_COUNT_KEY] == 1
+
+    def test_chunks_without_a_function_have_no_parameter_count(self):
+        metrics = chunk_metrics("validate(a, b);\nint x = 1;\n", "c")
+
+        assert PARAMETER_COUNT_KEY not in metrics
+        assert metrics[COMPLEXITY_KEY] == 1
+
+    def test_ruby_word_operators(self):
+        text = "def greet(name)\n  puts 'hi' unless name.nil? or muted\nend\n"
+
+        assert chunk_metrics(text, "rb")[COMPLEXITY_KEY] == 3
+
+
+class TestCodeMetrics:
+    """Tests for recording metrics with chunks."""
+
+    def test_annotate_code_chunks_only(self, tmp_path):
+        chunks = [
+            {"text": PYTHON, "file_extension": "py"},
+            {"text": "# Title\n\nif and for\n", "file_extension": "md"},
+        ]
+
+        annotated = CodeMetrics().annotate_chunks(chunks, tmp_path / "auth.py")
+
+        assert annotated[0][COMPLEXITY_KEY] == 6
+        assert COMPLEXITY_KEY not in annotated[1]
+        assert COMPLEXITY_KEY not in chunks[0]
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert CodeMetrics.from_config(config) is not None
+
+        config.indexing.code_metrics.enabled = False
+        assert CodeMetrics.from_config(config) is None
@@ -0,0 +1,126 @@
+"""
+Unit tests for CODEOWNERS-based ownership metadata.
+
+Tests CODEOWNERS pattern semantics, file discovery, configuration, chunk
+annotation, filtering on the owners list, and per-owner result facets.
+"""
+
+from pathlib import Path
+
+from code_indexer.config import Config
+from code_indexer.services.code_owners import (
+    OWNERS_KEY,
+    CodeOwners,
+    normalize_owner,
+    owner_facets,
+    parse_codeowners,
+)
+from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+CODEOWNERS = """# Default owners
+*                       @acme/core
+
+*.js                    @acme/frontend   # inline comment
+/docs/                  @acme/docs
+apps/                   @octocat
+/build/logs/            @doctocat
+docs/*                  docs@example.com
+**/logs                 @acme/ops
+/scripts/**/deploy.sh   @acme/release @alice
+
+[Optional Section]
+/vendor/
+"""
+
+
+def owners_of(path: str) -> list:
+    return CodeOwners(Path("."), parse_codeowners(CODEOWNERS)).owners_for(path)
+
+
+class TestRules:
+    """Tests for CODEOWNERS pattern semantics."""
+
+    def test_last_matching_rule_wins(self):
+        assert owners_of("main.go") == ["@acme/core"]
+        assert owners_of("web/app.js") == ["@acme/frontend"]
+
+    def test_directory_patterns(self):
+        assert owners_of("apps/api/server.py") == ["@octocat"]
+        assert owners_of("src/apps/cli.py") == ["@octocat"]
+        assert owners_of("build/logs/out.txt") == ["@acme/ops"]
+        assert owners_of("deep/logs/today.txt") == ["@acme/ops"]
+
+    def test_direct_children_only(self):
+        assert owners_of("docs/index.md") == ["docs@example.com"]
+        assert owners_of("docs/guides/setup.md") == ["@acme/docs"]
+
+    def test_double_star(self):
+        assert owners_of("scripts/deploy.sh") == ["@acme/release", "@alice"]
+        assert owners_of("scripts/prod/eu/deploy.sh") == ["@acme/release", "@alice"]
+
+    def test_rule_without_owners_removes_ownership(self):
+        assert owners_of("vendor/lib/x.go") == []
+
+    def test_normalize_owner(self):
+        assert normalize_owner("platform-team") == "@platform-team"
+        assert normalize_owner("@acme/ops") == "@acme/ops"
+        assert normalize_owner("docs@example.com") == "docs@example.com"
+
+
+class TestCodeOwners:
+    """Tests for loading CODEOWNERS and annotating chunks."""
+
+    def test_github_location_is_preferred(self, tmp_path):
+        (tmp_path / ".github").mkdir()
+        (tmp_path / ".github" / "CODEOWNERS").write_text("* @github\n")
+        (tmp_path / "CODEOWNERS").write_text("* @root\n")
+
+        assert CodeOwners.load(tmp_path).owners_for("a.py") == ["@github"]
+
+    def test_classify_chunks(self, tmp_path):
+        (tmp_path / "CODEOWNERS").write_text(CODEOWNERS)
+        code_owners = CodeOwners.load(tmp_path)
+        chunks = [{"text": "a"}, {"text": "b"}]
+
+        classified = code_owners.classify_chunks(chunks, tmp_path / "web" / "app.js")
+        vendored = code_owners.classify_chunks(chunks, tmp_path / "vendor" / "x.js")
+
+        assert [c[OWNERS_KEY] for c in classified] == [["@acme/frontend"]] * 2
+        assert vendored is chunks
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert CodeOwners.from_config(config) is None  # No CODEOWNERS
+
+        (tmp_path / "CODEOWNERS").write_text("* @acme/core\n")
+        assert CodeOwners.from_config(config) is not None
+
+        config.indexing.code_owners.enabled = False
+        assert CodeOwners.from_config(config) is None
+
+
+class TestOwnerFiltersAndFacets:
+    """Tests for filtering and summarizing results by owner."""
+
+    def test_match_value_on_owner_list(self, tmp_path):
+        store = FilesystemVectorStore(base_path=tmp_path, project_root=tmp_path)
+        matches = store._parse_filter(
+            {"must": [{"key": OWNERS_KEY, "match": {"value": "@acme/ops"}}]}
+        )
+
+        assert matches({OWNERS_KEY: ["@acme/core", "@acme/ops"]})
+        assert not matches({OWNERS_KEY: ["@acme/core"]})
+        assert not matches({"path": "unowned.py"})
+
+    def test_owner_facets(self):
+        results = [
+            {"payload": {OWNERS_KEY: ["@acme/core", "@alice"]}},
+            {"payload": {OWNERS_KEY: ["@acme/core"]}},
+            {"payload": {}},
+        ]
+
+        assert owner_facets(results) == [
+            ("@acme/core", 2),
+            ("@alice", 1),
+            (None, 1),
+        ]
@@ -0,0 +1,256 @@
+"""
+Unit tests for automatic concurrency tuning.
+
+Tests ConcurrencyTuner AIMD decisions, AutoTuneController sampling, and the
+adjustable concurrency limit on VectorCalculationManager.
+"""
+
+# mypy: ignore-errors
+
+import threading
+import time
+from unittest.mock import Mock
+
+import pytest
+
+from src.code_indexer.services.concurrency_tuner import (
+    AutoTuneController,
+    ConcurrencyTuner,
+    MAX_BATCH_TOKEN_FRACTION,
+    MIN_BATCH_TOKEN_FRACTION,
+    TuningSample,
+)
+from src.code_indexer.services.vector_calculation_manager import (
+    ConcurrencyGate,
+    VectorCalculationManager,
+    VectorCalculationStats,
+)
+
+
+def _sample(eps, throttles=0, latency=2.0, cpu=None, backlog=None):
+    return TuningSample(
+        embeddings_per_second=eps,
+        average_batch_latency=latency,
+        server_throttle_count=throttles,
+        cpu_load_ratio=cpu,
+        ingest_backlog_ratio=backlog,
+    )
+
+
+class TestConcurrencyTuner:
+    """Tests for AIMD thread limit and batch size decisions."""
+
+    def test_invalid_bounds_rejected(self):
+        with pytest.raises(ValueError):
+            ConcurrencyTuner(initial_threads=4, max_threads=2, min_threads=3)
+        with pytest.raises(ValueError):
+            ConcurrencyTuner(initial_threads=4, max_threads=8, min_threads=0)
+
+    def test_initial_threads_clamped_to_max(self):
+        tuner = ConcurrencyTuner(initial_threads=64, max_threads=16)
+        assert tuner.thread_limit == 16
+
+    def test_increases_while_throughput_improves(self):
+        tuner = ConcurrencyTuner(initial_threads=4, max_threads=12)
+
+        assert tuner.evaluate(_sample(100.0)).thread_limit == 6
+        assert tuner.evaluate(_sample(150.0)).thread_limit == 8
+        assert tuner.evaluate(_sample(200.0)).thread_limit == 10
+
+    def test_reverts_and_holds_when_increase_does_not_help(self):
+        tuner = ConcurrencyTuner(initial_threads=4, max_threads=16)
+
+        tuner.evaluate(_sample(100.0))  # 4 -> 6 (probe)
+        tuner.evaluate(_sample(150.0))  # best at 6, 6 -> 8 (probe)
+        decision = tuner.evaluate(_sample(151.0))  # no gain at 8
+
+        assert decision.thread_limit == 6
+        assert "no throughput gain" in decision.reason
+
+        # Holds instead of oscillating
+        assert tuner.evaluate(_sample(150.0)).thread_limit == 6
+
+    def test_server_throttling_halves_limit(self):
+        tuner = ConcurrencyTuner(initial_threads=12, max_threads=16)
+
+        decision = tuner.evaluate(_sample(100.0, throttles=2))
+
+        assert decision.thread_limit == 6
+        assert "throttling" in decision.reason
+
+    def test_throttle_count_is_cumulative(self):
+        tuner = ConcurrencyTuner(initial_threads=12, max_threads=16)
+        tuner.evaluate(_sample(100.0, throttles=2))  # 12 -> 6
+
+        # Same cumulative count means no new throttles
+        decision = tuner.evaluate(_sample(100.0, throttles=2))
+        assert "throttling" not in decision.reason
+
+    def test_cpu_saturation_backs_off(self):
+        tuner = ConcurrencyTuner(initial_threads=8, max_threads=16)
+
+        decision = tuner.evaluate(_sample(100.0, cpu=1.5))
+
+        assert decision.thread_limit == 7
+        assert "CPU" in decision.reason
+
+    def test_vector_store_backlog_backs_off(self):
+        tuner = ConcurrencyTuner(initial_threads=8, max_threads=16)
+
+        decision = tuner.evaluate(_sample(100.0, backlog=1.0))
+
+        assert decision.thread_limit == 7
+        assert "vector store backlog" in decision.reason
+
+    def test_never_below_min_threads(self):
+        tuner = ConcurrencyTuner(initial_threads=2, max_threads=8, min_threads=2)
+
+        assert tuner.evaluate(_sample(10.0, throttles=5)).thread_limit == 2
+
+    def test_slow_batches_shrink_token_budget(self):
+        tuner = ConcurrencyTuner(initial_threads=4, max_threads=8)
+
+        for _ in range(20):
+            tuner.evaluate(_sample(10.0, latency=60.0))
+
+        assert tuner.batch_token_fraction == MIN_BATCH_TOKEN_FRACTION
+
+    def test_fast_batches_restore_token_budget(self):
+        tuner = ConcurrencyTuner(
+            initial_threads=4, max_threads=8, initial_batch_token_fraction=0.3
+        )
+
+        for _ in range(20):
+            tuner.evaluate(_sample(10.0, latency=0.5))
+
+        assert tuner.batch_token_fraction == MAX_BATCH_TOKEN_FRACTION
+
+    def test_finalize_settles_on_best_limit(self):
+        tuner = ConcurrencyTuner(initial_threads=4, max_threads=16)
+        tuner.evaluate(_sample(100.0))  # best at 4, probe to 6
+        tuner.evaluate(_sample(300.0))  # best at 6, probe to 8
+        tuner.evaluate(_sample(50.0, cpu=2.0))  # CPU back-off to 7
+
+        assert tuner.finalize().thread_limit == 6
+
+
+class TestAutoTuneController:
+    """Tests for applying tuner decisions to a running vector manager."""
+
+    def _vector_manager(self, eps=100.0):
+        manager = Mock()
+        manager.get_stats.return_value = VectorCalculationStats(
+            embeddings_per_second=eps,
+            average_processing_time=1.0,
+            server_throttle_count=0,
+        )
+        return manager
+
+    def test_sample_collects_all_signals(self):
+        manager = self._vector_manager(eps=42.0)
+        controller = AutoTuneController(
+            ConcurrencyTuner(initial_threads=4, max_threads=8),
+            manager,
+            ingest_backlog_provider=lambda: 0.25,
+            cpu_load_provider=lambda: 0.5,
+        )
+
+        sample = controller.sample()
+
+        assert sample.embeddings_per_second == 42.0
+        assert sample.ingest_backlog_ratio == 0.25
+        assert sample.cpu_load_ratio == 0.5
+
+    def test_applies_decisions_and_finalizes_after_window(self):
+        manager = self._vector_manager()
+        controller = AutoTuneController(
+            ConcurrencyTuner(initial_threads=4, max_threads=8),
+            manager,
+            tuning_window_seconds=0.2,
+            sample_interval_seconds=0.05,
+            cpu_load_provider=lambda: None,
+        )
+
+        with controller:
+            time.sleep(0.5)
+
+        applied = [c.args[0] for c in manager.set_concurrency_limit.call_args_list]
+        assert applied[0] == 4  # Initial limit applied on start
+        assert len(applied) >= 2
+        assert controller.tuner.history[-1].reason == "tuning window closed"
+
+    def test_sample_failure_does_not_stop_controller(self):
+        manager = self._vector_manager()
+        manager.get_stats.side_effect = RuntimeError("stats unavailable")
+        controller = AutoTuneController(
+            ConcurrencyTuner(initial_threads=4, max_threads=8),
+            manager,
+            tuning_window_seconds=10.0,
+            sample_interval_seconds=0.05,
+        )
+
+        with controller:
+            time.sleep(0.2)
+
+        assert manager.get_stats.call_count >= 2
+
+
+class TestVectorManagerConcurrencyLimit:
+    """Tests for the adjustable concurrency limit."""
+
+    def test_fixed_limit_without_max_thread_count(self):
+        manager = VectorCalculationManager(Mock(), thread_count=4)
+
+        assert manager.get_concurrency_limit() == 4
+        with pytest.raises(RuntimeError):
+            manager.set_concurrency_limit(2)
+
+    def test_limit_clamped_to_pool_size(self):
+        manager = VectorCalculationManager(
+            Mock(), thread_count=4, max_thread_count=8
+        )
+
+        manager.set_concurrency_limit(100)
+        assert manager.get_concurrency_limit() == 8
+        manager.set_concurrency_limit(0)
+        assert manager.get_concurrency_limit() == 1
+
+    def test_gate_caps_concurrent_api_calls(self):
+        active = 0
+        peak = 0
+        lock = threading.Lock()
+
+        def get_embeddings_batch(texts):
+            nonlocal active, peak
+            with lock:
+                active += 1
+                peak = max(peak, active)
+            time.sleep(0.05)
+            with lock:
+                active -= 1
+            return [[0.1] for _ in texts]
+
+        provider = Mock()
+        provider.get_embeddings_batch.side_effect = get_embeddings_batch
+
+        with VectorCalculationManager(
+            provider, thread_count=2, max_thread_count=8
+        ) as manager:
+            futures = [manager.submit_batch_task(["x"], {}) for _ in range(12)]
+            results = [f.result(timeout=10) for f in futures]
+
+        assert all(r.error is None for r in results)
+        assert peak <= 2
+
+    def test_gate_wakes_waiters_when_limit_grows(self):
+        gate = ConcurrencyGate(1)
+        entered = threading.Event()
+
+        with gate:
+            thread = threading.Thread(target=lambda: gate.__enter__() and entered.set())
+            thread.start()
+            assert not entered.wait(0.1)
+
+            gate.set_limit(2)
+            assert entered.wait(2.0)
+        thread.join()
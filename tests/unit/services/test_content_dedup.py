@@ -0,0 +1,187 @@
+"""
+Unit tests for deduplication of identical files within an indexing run.
+
+Tests grouping of files by content hash and FileChunkingManager writing one
+file's chunks and embeddings for every duplicate path.
+"""
+
+# mypy: ignore-errors
+
+import tempfile
+import threading
+from concurrent.futures import Future
+from pathlib import Path
+from typing import Dict, List
+from unittest.mock import Mock
+
+from src.code_indexer.config import Config
+from src.code_indexer.services.clean_slot_tracker import CleanSlotTracker
+from src.code_indexer.services.content_dedup import (
+    DUPLICATE_PATHS_KEY,
+    group_identical_files,
+)
+from src.code_indexer.services.file_chunking_manager import FileChunkingManager
+from src.code_indexer.services.vector_calculation_manager import VectorResult
+
+
+def _hash_results(hashes: Dict[str, str]) -> Dict[Path, tuple]:
+    return {Path(path): ({"file_hash": h}, 10) for path, h in hashes.items()}
+
+
+class CountingVectorManager:
+    """Vector manager mock counting embedded chunks."""
+
+    def __init__(self):
+        self.cancellation_event = threading.Event()
+        self.embedding_provider = Mock()
+        self.embedding_provider.get_current_model.return_value = "voyage-code-3"
+        self.embedding_provider._get_model_token_limit.return_value = 120000
+        self.embedded_chunks = 0
+
+    def submit_batch_task(self, chunk_texts: List[str], metadata: Dict):
+        self.embedded_chunks += len(chunk_texts)
+        future = Future()
+        future.set_result(
+            VectorResult(
+                task_id="batch",
+                embeddings=tuple((0.5,) * 8 for _ in chunk_texts),
+                metadata=metadata.copy(),
+                processing_time=0.0,
+                error=None,
+            )
+        )
+        return future
+
+
+class SingleChunkChunker:
+    """Chunker mock producing one chunk per file."""
+
+    def chunk_file(self, file_path: Path) -> List[Dict]:
+        return [
+            {
+                "text": file_path.read_text(),
+                "chunk_index": 0,
+                "total_chunks": 1,
+                "file_extension": file_path.suffix.lstrip("."),
+                "line_start": 1,
+                "line_end": 1,
+            }
+        ]
+
+
+class TestGroupIdenticalFiles:
+    """Tests for group_identical_files()."""
+
+    def test_groups_by_hash_with_sorted_canonical(self):
+        groups, duplicate_count = group_identical_files(
+            _hash_results(
+                {
+                    "vendor/b/lodash.js": "sha256:aaa",
+                    "node_modules/lodash.js": "sha256:aaa",
+                    "vendor/a/lodash.js": "sha256:aaa",
+                    "src/app.js": "sha256:bbb",
+                }
+            )
+        )
+
+        assert groups == {
+            Path("node_modules/lodash.js"): [
+                Path("vendor/a/lodash.js"),
+                Path("vendor/b/lodash.js"),
+            ]
+        }
+        assert duplicate_count == 2
+
+    def test_unique_files_are_not_grouped(self):
+        groups, duplicate_count = group_identical_files(
+            _hash_results({"a.py": "sha256:1", "b.py": "sha256:2"})
+        )
+
+        assert groups == {}
+        assert duplicate_count == 0
+
+    def test_hash_errors_are_never_grouped(self):
+        groups, _ = group_identical_files(
+            _hash_results({"a.py": "sha256:error-1", "b.py": "sha256:error-1"})
+        )
+
+        assert groups == {}
+
+    def test_enabled_by_default(self):
+        assert Config().indexing.deduplicate_identical_files is True
+
+
+class TestFileChunkingManagerDuplicates:
+    """Tests for writing one file's embeddings for its duplicate paths."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.files = []
+        for directory in ("node_modules", "vendor/a", "vendor/b"):
+            file_path = self.root / directory / "lodash.js"
+            file_path.parent.mkdir(parents=True)
+            file_path.write_text("function chunk(array, size) {}\n")
+            self.files.append(file_path)
+
+        self.vector_manager = CountingVectorManager()
+        self.vector_store = Mock()
+        self.vector_store.upsert_points.return_value = True
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def _process(self, metadata):
+        manager = FileChunkingManager(
+            vector_manager=self.vector_manager,
+            chunker=SingleChunkChunker(),
+            vector_store_client=self.vector_store,
+            thread_count=1,
+            slot_tracker=CleanSlotTracker(max_slots=3),
+            codebase_dir=self.root,
+        )
+        with manager:
+            return manager.submit_file_for_processing(
+                self.files[0], metadata, None
+            ).result(timeout=10.0)
+
+    def _metadata(self, **extra):
+        return {
+            "project_id": "test_project",
+            "file_hash": "sha256:aaa",
+            "git_available": False,
+            "collection_name": "test_collection",
+            DUPLICATE_PATHS_KEY: [str(f) for f in self.files[1:]],
+            **extra,
+        }
+
+    def _written_points(self):
+        return [
+            point
+            for call in self.vector_store.upsert_points.call_args_list
+            for point in call.kwargs["points"]
+        ]
+
+    def test_content_embedded_once_and_written_for_every_path(self):
+        result = self._process(self._metadata())
+
+        assert result.success
+        assert result.chunks_processed == 3
+        assert self.vector_manager.embedded_chunks == 1
+        points = self._written_points()
+        assert [p["payload"]["path"] for p in points] == [
+            "node_modules/lodash.js",
+            "vendor/a/lodash.js",
+            "vendor/b/lodash.js",
+        ]
+        assert len({p["id"] for p in points}) == 3
+        assert all(p["vector"] == points[0]["vector"] for p in points)
+
+    def test_duplicates_use_their_own_mtime(self):
+        self._process(self._metadata(file_mtime=1.0))
+
+        points = self._written_points()
+        assert points[0]["payload"]["filesystem_mtime"] == 1.0
+        assert points[1]["payload"]["filesystem_mtime"] == (
+            self.files[1].stat().st_mtime
+        )
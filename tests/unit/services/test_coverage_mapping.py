@@ -0,0 +1,206 @@
+"""
+Unit tests for test-to-code coverage mapping.
+
+Tests Go coverprofile, Cobertura and lcov parsing, path normalization,
+persistence, range lookups, and annotation and filtering of query results.
+"""
+
+from pathlib import Path
+
+import pytest
+
+from code_indexer.services.coverage_mapping import (
+    CoverageMap,
+    detect_report_format,
+)
+
+GO_PROFILE = """mode: set
+example.com/shop/payments/charge.go:10.2,12.16 2 1
+example.com/shop/payments/charge.go:14.2,16.3 1 0
+example.com/other/lib.go:1.1,2.2 1 1
+"""
+
+COBERTURA_XML = """<?xml version="1.0" ?>
+<coverage version="7.4" line-rate="0.5">
+  <sources>
+    <source>{source}</source>
+  </sources>
+  <packages>
+    <package name="app">
+      <classes>
+        <class name="auth.py" filename="auth.py">
+          <lines>
+            <line number="1" hits="1"/>
+            <line number="2" hits="0"/>
+            <line number="5" hits="3"/>
+          </lines>
+        </class>
+      </classes>
+    </package>
+  </packages>
+</coverage>
+"""
+
+LCOV = """TN:test_login
+SF:{root}/src/auth.js
+DA:1,1
+DA:2,0
+DA:3,2
+end_of_record
+TN:test_logout
+SF:src/auth.js
+DA:3,1
+DA:8,1
+end_of_record
+"""
+
+
+@pytest.fixture
+def project(tmp_path: Path) -> Path:
+    root = tmp_path / "shop"
+    (root / ".code-indexer").mkdir(parents=True)
+    (root / "go.mod").write_text("module example.com/shop\n\ngo 1.22\n")
+    (root / "payments").mkdir()
+    (root / "payments" / "charge.go").write_text("package payments\n")
+    (root / "src" / "app").mkdir(parents=True)
+    (root / "src" / "app" / "auth.py").write_text("x = 1\n")
+    (root / "src" / "auth.js").write_text("x = 1\n")
+    return root
+
+
+def make_map(root: Path) -> CoverageMap:
+    return CoverageMap(root, root / ".code-indexer")
+
+
+class TestParsing:
+    """Tests for report format detection and parsing."""
+
+    def test_detect_formats(self, tmp_path):
+        reports = {
+            "go": GO_PROFILE,
+            "cobertura": COBERTURA_XML.format(source="src"),
+            "lcov": LCOV.format(root=tmp_path),
+        }
+        for expected, content in reports.items():
+            report = tmp_path / f"{expected}.out"
+            report.write_text(content)
+            assert detect_report_format(report) == expected
+
+    def test_unknown_format(self, tmp_path):
+        report = tmp_path / "report.txt"
+        report.write_text("nothing to see\n")
+
+        with pytest.raises(ValueError):
+            detect_report_format(report)
+
+    def test_go_coverprofile_strips_module(self, project):
+        report = project / "cover.out"
+        report.write_text(GO_PROFILE)
+        coverage_map = make_map(project)
+
+        summary = coverage_map.import_report(report, test_name="TestCharge")
+
+        assert summary["format"] == "go"
+        charge = coverage_map.files["payments/charge.go"]
+        assert charge.coverable == {10, 11, 12, 14, 15, 16}
+        assert charge.covered == {10, 11, 12}
+        assert charge.tests == {"TestCharge": {10, 11, 12}}
+        # Other modules are kept under their import path
+        assert "example.com/other/lib.go" in coverage_map.files
+
+    def test_cobertura_resolves_sources(self, project):
+        report = project / "coverage.xml"
+        report.write_text(COBERTURA_XML.format(source=project / "src" / "app"))
+        coverage_map = make_map(project)
+
+        coverage_map.import_report(report)
+
+        auth = coverage_map.files["src/app/auth.py"]
+        assert auth.coverable == {1, 2, 5}
+        assert auth.covered == {1, 5}
+        assert auth.tests == {}
+
+    def test_lcov_test_names(self, project):
+        report = project / "lcov.info"
+        report.write_text(LCOV.format(root=project))
+        coverage_map = make_map(project)
+
+        summary = coverage_map.import_report(report)
+
+        assert summary["tests"] == ["test_login", "test_logout"]
+        auth = coverage_map.files["src/auth.js"]
+        assert auth.tests == {"test_login": {1, 3}, "test_logout": {3, 8}}
+
+    def test_files_outside_project_are_skipped(self, project):
+        outside = project.parent / "elsewhere"
+        report = project / "lcov.info"
+        report.write_text(f"SF:{outside}/lib.js\nDA:1,1\nend_of_record\n")
+        coverage_map = make_map(project)
+
+        summary = coverage_map.import_report(report)
+
+        assert summary["skipped_files"] == 1
+        assert not coverage_map.has_data
+
+    def test_malformed_xml(self, project):
+        report = project / "coverage.xml"
+        report.write_text("<coverage><class")
+
+        with pytest.raises(ValueError):
+            make_map(project).import_report(report, report_format="cobertura")
+
+
+class TestCoverageMap:
+    """Tests for persistence, lookups and query result annotation."""
+
+    def setup_coverage(self, project: Path) -> CoverageMap:
+        report = project / "lcov.info"
+        report.write_text(LCOV.format(root=project))
+        coverage_map = make_map(project)
+        coverage_map.import_report(report)
+        coverage_map.save()
+        return make_map(project)
+
+    def test_save_and_load(self, project):
+        coverage_map = self.setup_coverage(project)
+
+        assert coverage_map.files["src/auth.js"].covered == {1, 3, 8}
+        assert len(coverage_map.reports) == 1
+
+    def test_range_coverage(self, project):
+        coverage_map = self.setup_coverage(project)
+
+        first = coverage_map.range_coverage("src/auth.js", 1, 2)
+        assert (first.coverable, first.covered, first.tests) == (2, 1, ["test_login"])
+        assert coverage_map.range_coverage("src/auth.js", 8, 8).tests == [
+            "test_logout"
+        ]
+        assert coverage_map.range_coverage("src/auth.js", 2, 2).is_uncovered
+        assert not coverage_map.range_coverage("src/auth.js", 20, 30).is_uncovered
+        assert coverage_map.range_coverage("src/other.js") is None
+
+    def test_annotate_and_filter_results(self, project):
+        coverage_map = self.setup_coverage(project)
+        results = [
+            {"payload": {"path": "src/auth.js", "line_start": 1, "line_end": 3}},
+            {"payload": {"path": "src/auth.js", "line_start": 2, "line_end": 2}},
+            {"payload": {"path": "src/other.js", "line_start": 1, "line_end": 9}},
+        ]
+
+        coverage_map.annotate_results(results)
+
+        assert results[0]["coverage"]["tests"] == ["test_login", "test_logout"]
+        assert results[1]["coverage"]["uncovered"] is True
+        assert "coverage" not in results[2]
+        assert CoverageMap.filter_results(results, uncovered=True) == [results[1]]
+        assert CoverageMap.filter_results(results, covered_by=["test_logout"]) == [
+            results[0]
+        ]
+
+    def test_clear(self, project):
+        coverage_map = self.setup_coverage(project)
+
+        coverage_map.clear()
+        coverage_map.save()
+
+        assert not make_map(project).has_data
@@ -0,0 +1,70 @@
+"""
+Unit tests for diff-scoped search (cidx query --changed-since).
+
+Tests resolving the files changed since a ref's merge base with git, and
+the payload condition restricting the search to them.
+"""
+
+import subprocess
+
+import pytest
+
+from code_indexer.services.diff_scope import resolve_changed_files
+
+
+def git(repo, *args):
+    return subprocess.run(
+        ["git", *args], cwd=repo, capture_output=True, text=True, check=True
+    ).stdout.strip()
+
+
+@pytest.fixture
+def repo(tmp_path):
+    git(tmp_path, "init", "-q", "-b", "main")
+    git(tmp_path, "config", "user.email", "dev@example.com")
+    git(tmp_path, "config", "user.name", "Dev")
+    (tmp_path / "app").mkdir()
+    for name in ("auth.py", "billing.py", "old.py", "notes.py"):
+        (tmp_path / "app" / name).write_text(f"# {name}\n")
+    (tmp_path / ".gitignore").write_text("*.log\n")
+    git(tmp_path, "add", ".")
+    git(tmp_path, "commit", "-q", "-m", "base")
+
+    git(tmp_path, "checkout", "-q", "-b", "feature")
+    (tmp_path / "app" / "auth.py").write_text("# auth.py\ndef refresh(): pass\n")
+    (tmp_path / "app" / "old.py").unlink()
+    git(tmp_path, "commit", "-q", "-am", "feature")
+
+    # Work on main after the fork is not part of the branch's change
+    git(tmp_path, "checkout", "-q", "main")
+    (tmp_path / "app" / "billing.py").write_text("# billing.py\n# main\n")
+    git(tmp_path, "commit", "-q", "-am", "main moves on")
+    git(tmp_path, "checkout", "-q", "feature")
+
+    (tmp_path / "app" / "notes.py").write_text("# notes.py\n# edited\n")
+    (tmp_path / "app" / "new.py").write_text("# new\n")
+    (tmp_path / "debug.log").write_text("ignored\n")
+    return tmp_path
+
+
+class TestResolveChangedFiles:
+    """Tests for resolve_changed_files."""
+
+    def test_branch_and_working_tree_changes(self, repo):
+        changed = resolve_changed_files(repo, "main")
+
+        assert changed.base == git(repo, "merge-base", "main", "HEAD")
+        assert changed.paths == ["app/auth.py", "app/new.py", "app/notes.py"]
+        assert changed.payload_condition() == {
+            "key": "path",
+            "match": {"any": {"app/auth.py", "app/new.py", "app/notes.py"}},
+        }
+
+    def test_paths_relative_to_a_subdirectory_project(self, repo):
+        changed = resolve_changed_files(repo / "app", "main")
+
+        assert changed.paths == ["auth.py", "new.py", "notes.py"]
+
+    def test_unknown_ref(self, repo):
+        with pytest.raises(ValueError, match="origin/nope"):
+            resolve_changed_files(repo, "origin/nope")
@@ -0,0 +1,154 @@
+"""
+Unit tests for pairing doc comments with their declarations.
+
+Tests Go, Java, Python, Rust and Ruby docs, chunk annotation when the doc is
+inside the chunk and when a chunk boundary split it off, and configuration.
+"""
+
+from code_indexer.config import Config
+from code_indexer.services.boilerplate_filter import EMBEDDING_TEXT_KEY
+from code_indexer.services.doc_comments import (
+    DOC_KEY,
+    DocCommentPairer,
+    doc_comments,
+)
+
+GO = """package cart
+
+// Total returns the sum of the item prices,
+// after discounts.
+//go:noinline
+func (c *Cart) Total() int {
+\treturn 0
+}
+
+// helper is not followed by a declaration
+
+x := 1
+"""
+
+JAVA = """/*
+ * Copyright 2024 Shop Inc.
+ */
+package com.shop.cart;
+
+/**
+ * Applies discount codes to carts.
+ */
+@Service
+public class DiscountService {
+    /** Percentage taken off. */
+    private int percent;
+}
+"""
+
+PYTHON = '''class Cart:
+    """A shopping cart."""
+
+    def total(
+        self,
+        currency: str,
+    ) -> int:
+        """
+        Sum of the item prices.
+
+        Discounts are applied first.
+        """
+        return 0
+'''
+
+
+def chunk(text, start, end, extension):
+    return {
+        "text": "\n".join(text.split("\n")[start - 1 : end]),
+        "line_start": start,
+        "line_end": end,
+        "file_extension": extension,
+    }
+
+
+class TestDocComments:
+    """Tests for finding the doc comments of declarations."""
+
+    def test_go(self):
+        docs = doc_comments(GO, "go")
+
+        assert [(d.first_line, d.last_line, d.declaration_line) for d in docs] == [
+            (2, 4, 5)
+        ]
+        assert docs[0].text == (
+            "Total returns the sum of the item prices,\nafter discounts."
+        )
+
+    def test_java_blocks_and_annotations(self):
+        docs = doc_comments(JAVA, "java")
+
+        assert [(d.declaration_line, d.text) for d in docs] == [
+            (9, "Applies discount codes to carts."),
+            (11, "Percentage taken off."),
+        ]
+
+    def test_python_docstrings(self):
+        docs = doc_comments(PYTHON, "py")
+
+        assert [(d.declaration_line, d.text) for d in docs] == [
+            (0, "A shopping cart."),
+            (3, "Sum of the item prices.\n\nDiscounts are applied first."),
+        ]
+        assert (docs[1].first_line, docs[1].last_line) == (7, 11)
+
+    def test_rust_and_ruby(self):
+        rust = "/// Adds two numbers.\n#[inline]\npub fn add() {}\n"
+        ruby = "# Greets the user.\ndef greet\nend\n"
+
+        assert [d.text for d in doc_comments(rust, "rs")] == ["Adds two numbers."]
+        assert [d.text for d in doc_comments(ruby, "rb")] == ["Greets the user."]
+
+    def test_other_languages_have_none(self):
+        assert doc_comments("-- a comment\nSELECT 1;\n", "sql") == []
+
+
+class TestDocCommentPairer:
+    """Tests for recording docs with the chunks of their declarations."""
+
+    def test_split_off_doc_is_embedded_with_the_declaration(self, tmp_path):
+        path = tmp_path / "cart.go"
+        path.write_text(GO)
+        comment = chunk(GO, 1, 4, "go")
+        function = chunk(GO, 5, 12, "go")
+
+        annotated = DocCommentPairer().annotate_chunks([comment, function], path)
+
+        assert DOC_KEY not in annotated[0]
+        doc = "Total returns the sum of the item prices,\nafter discounts."
+        assert annotated[1][DOC_KEY] == doc
+        assert annotated[1][EMBEDDING_TEXT_KEY] == f"{doc}\n\n{function['text']}"
+        assert annotated[1]["text"] == function["text"]
+
+    def test_doc_inside_the_chunk_is_not_repeated(self, tmp_path):
+        path = tmp_path / "DiscountService.java"
+        path.write_text(JAVA)
+        whole = chunk(JAVA, 1, 14, "java")
+
+        annotated = DocCommentPairer().annotate_chunks([whole], path)
+
+        assert annotated[0][DOC_KEY] == "Applies discount codes to carts."
+        assert EMBEDDING_TEXT_KEY not in annotated[0]
+
+    def test_docstring_split_after_the_signature(self, tmp_path):
+        path = tmp_path / "cart.py"
+        path.write_text(PYTHON)
+        signature = chunk(PYTHON, 3, 9, "py")
+
+        annotated = DocCommentPairer().annotate_chunks([signature], path)
+
+        assert annotated[0][EMBEDDING_TEXT_KEY].startswith(
+            "Sum of the item prices.\n\nDiscounts are applied first.\n\n"
+        )
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert DocCommentPairer.from_config(config) is not None
+
+        config.indexing.doc_comments.enabled = False
+        assert DocCommentPairer.from_config(config) is None
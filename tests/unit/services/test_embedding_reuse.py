@@ -0,0 +1,147 @@
+"""
+Unit tests for reusing stored vectors of unchanged chunks.
+
+Tests that FileChunkingManager records the hash of each chunk's embedded
+text, embeds only the chunks of a re-indexed file whose embedded text
+changed, and keeps embedding every chunk when reuse is off.
+"""
+
+import tempfile
+import threading
+from concurrent.futures import Future
+from pathlib import Path
+from typing import Any, Dict, List
+from unittest.mock import Mock
+
+from code_indexer.services.clean_slot_tracker import CleanSlotTracker
+from code_indexer.services.file_chunking_manager import (
+    EMBEDDING_HASH_KEY,
+    FileChunkingManager,
+)
+from code_indexer.services.vector_calculation_manager import VectorResult
+
+FIRST = "def add(a, b):\n    return a + b\n"
+SECOND = "def sub(a, b):\n    return a - b\n"
+
+
+class RecordingVectorManager:
+    """Vector manager mock giving every embedded text a vector of its own."""
+
+    def __init__(self):
+        self.cancellation_event = threading.Event()
+        self.embedding_provider = Mock()
+        self.embedding_provider.get_current_model.return_value = "voyage-code-3"
+        self.embedding_provider._get_model_token_limit.return_value = 120000
+        self.embedded_texts: List[str] = []
+
+    def submit_batch_task(self, chunk_texts: List[str], metadata: Dict):
+        embeddings = []
+        for text in chunk_texts:
+            self.embedded_texts.append(text)
+            embeddings.append((float(len(self.embedded_texts)),) * 4)
+        future = Future()
+        future.set_result(
+            VectorResult(
+                task_id="batch",
+                embeddings=tuple(embeddings),
+                metadata=metadata.copy(),
+                processing_time=0.0,
+                error=None,
+            )
+        )
+        return future
+
+
+class FakeVectorStore:
+    """Vector store keeping the last points written for each path."""
+
+    def __init__(self):
+        self.points: List[Dict[str, Any]] = []
+
+    def upsert_points(self, points, collection_name):
+        self.points = points
+        return True
+
+    def get_vectors_for_paths(self, collection_name, file_paths):
+        return [p for p in self.points if p["payload"]["path"] in file_paths]
+
+
+class TestEmbeddingReuse:
+    """Tests for embedding only the changed chunks of a re-indexed file."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.file_path = self.root / "math_utils.py"
+        self.vector_manager = RecordingVectorManager()
+        self.vector_store = FakeVectorStore()
+        self.metadata = {
+            "project_id": "test_project",
+            "file_hash": "sha256:aaa",
+            "git_available": False,
+            "collection_name": "test_collection",
+        }
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def _index(self, texts, reuse_embeddings=True):
+        self.file_path.write_text("".join(texts))
+        chunker = Mock()
+        chunker.chunk_file.return_value = [
+            {
+                "text": text,
+                "chunk_index": i,
+                "total_chunks": len(texts),
+                "file_extension": "py",
+                "line_start": 2 * i + 1,
+                "line_end": 2 * i + 2,
+            }
+            for i, text in enumerate(texts)
+        ]
+        manager = FileChunkingManager(
+            vector_manager=self.vector_manager,
+            chunker=chunker,
+            vector_store_client=self.vector_store,
+            thread_count=1,
+            slot_tracker=CleanSlotTracker(max_slots=3),
+            codebase_dir=self.root,
+            reuse_embeddings=reuse_embeddings,
+        )
+        with manager:
+            result = manager.submit_file_for_processing(
+                self.file_path, self.metadata, None
+            ).result(timeout=10.0)
+        assert result.success
+        return self.vector_store.points
+
+    def test_unchanged_chunks_keep_their_vectors(self):
+        first = self._index([FIRST, SECOND])
+        self.vector_manager.embedded_texts.clear()
+
+        edited = SECOND.replace("a - b", "b - a")
+        second = self._index([FIRST, edited])
+
+        assert self.vector_manager.embedded_texts == [edited]
+        assert second[0]["vector"] == first[0]["vector"]
+        assert second[1]["vector"] != first[1]["vector"]
+        assert second[0]["payload"][EMBEDDING_HASH_KEY] == (
+            first[0]["payload"][EMBEDDING_HASH_KEY]
+        )
+
+    def test_unchanged_file_embeds_nothing(self):
+        self._index([FIRST, SECOND])
+        self.vector_manager.embedded_texts.clear()
+
+        points = self._index([FIRST, SECOND])
+
+        assert self.vector_manager.embedded_texts == []
+        assert len(points) == 2
+
+    def test_every_chunk_is_embedded_without_reuse(self):
+        self._index([FIRST, SECOND], reuse_embeddings=False)
+        self.vector_manager.embedded_texts.clear()
+
+        self._index([FIRST, SECOND], reuse_embeddings=False)
+
+        assert self.vector_manager.embedded_texts == [FIRST, SECOND]
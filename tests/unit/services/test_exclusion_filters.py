@@ -0,0 +1,36 @@
+"""
+Unit tests for the exclusion filters of cidx query.
+
+Tests splitting comma-separated option values and the must_not payload
+conditions of --exclude-kind.
+"""
+
+from code_indexer.services.exclusion_filters import (
+    MOCK_PATH_PATTERNS,
+    kind_exclusion_conditions,
+    split_values,
+)
+
+
+def test_split_values_accepts_commas_and_repeats():
+    assert split_values(("vendor/**, **/testdata/**", "*.min.js", ",")) == [
+        "vendor/**",
+        "**/testdata/**",
+        "*.min.js",
+    ]
+
+
+def test_kind_conditions():
+    conditions = kind_exclusion_conditions(("test,Mock", "build", "test"))
+
+    assert conditions[0] == {"key": "is_test", "match": {"value": True}}
+    assert [c["match"]["text"] for c in conditions[1:-1]] == list(
+        MOCK_PATH_PATTERNS
+    )
+    assert conditions[-1] == {"key": "symbol_kind", "match": {"value": "build"}}
+
+
+def test_generated_kind():
+    assert kind_exclusion_conditions(["generated"]) == [
+        {"key": "generated", "match": {"value": True}}
+    ]
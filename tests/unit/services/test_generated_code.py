@@ -0,0 +1,98 @@
+"""
+Unit tests for generated file detection.
+
+Tests generator header markers, generated file name conventions, minified
+JavaScript and CSS, chunk annotation and configuration.
+"""
+
+from pathlib import Path
+
+from code_indexer.config import Config
+from code_indexer.services.generated_code import (
+    GENERATED_KEY,
+    GeneratedCodeDetector,
+    has_generated_marker,
+    has_generated_name,
+    is_generated,
+    is_minified,
+)
+
+GO_STUB = """// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// \tprotoc-gen-go v1.31.0
+// source: user.proto
+
+package userpb
+"""
+
+MINIFIED = "!function(e){" + "var t=e.a||{};e.b=function(n){return t[n]};" * 50 + "}"
+
+
+class TestGeneratedDetection:
+    """Tests for recognizing generated files."""
+
+    def test_header_markers(self):
+        assert has_generated_marker(GO_STUB)
+        assert has_generated_marker(
+            "# Generated by the protocol buffer compiler.  DO NOT EDIT!\n"
+        )
+        assert has_generated_marker("/**\n * @generated\n */\n")
+        assert has_generated_marker("// <auto-generated>\n// Generated by a tool\n")
+        assert not has_generated_marker("// Package user handles accounts.\n")
+
+    def test_marker_below_the_header_is_ignored(self):
+        text = "x = 1\n" * 50 + "# @generated\n"
+
+        assert not has_generated_marker(text)
+
+    def test_file_names(self):
+        assert has_generated_name("user.pb.go")
+        assert has_generated_name("user_pb2.py")
+        assert has_generated_name("user_pb2_grpc.py")
+        assert has_generated_name("model.g.dart")
+        assert has_generated_name("Form1.Designer.cs")
+        assert has_generated_name("schema_generated.go")
+        assert has_generated_name("schema.generated.ts")
+        assert has_generated_name("zz_generated.deepcopy.go")
+        assert has_generated_name("vendor.min.js")
+        assert not has_generated_name("user.go")
+        assert not has_generated_name("generated.go")
+        assert not has_generated_name("generator.py")
+
+    def test_minified_code(self):
+        assert is_minified(MINIFIED)
+        assert not is_minified("function f() {\n  return 1;\n}\n" * 100)
+        # Short one-liners are not minified bundles
+        assert not is_minified("module.exports = require('./lib');")
+
+    def test_minified_only_counts_for_javascript_and_css(self):
+        assert is_generated(Path("bundle.js"), MINIFIED)
+        assert not is_generated(Path("data.json"), MINIFIED)
+
+
+class TestGeneratedCodeDetector:
+    """Tests for marking the chunks of generated files."""
+
+    def test_annotate_chunks(self):
+        detector = GeneratedCodeDetector()
+        chunks = [{"text": GO_STUB}, {"text": "type User struct{}\n"}]
+
+        annotated = detector.annotate_chunks(chunks, Path("api/user.go"))
+
+        assert [c[GENERATED_KEY] for c in annotated] == [True, True]
+        assert GENERATED_KEY not in chunks[0]
+
+    def test_hand_written_files_are_not_marked(self):
+        detector = GeneratedCodeDetector()
+        chunks = [{"text": "package user\n\nfunc New() {}\n"}]
+
+        annotated = detector.annotate_chunks(chunks, Path("api/user.go"))
+
+        assert GENERATED_KEY not in annotated[0]
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert GeneratedCodeDetector.from_config(config) is not None
+
+        config.indexing.generated_code.enabled = False
+        assert GeneratedCodeDetector.from_config(config) is None
@@ -0,0 +1,132 @@
+"""
+Unit tests for the auto-index git hooks ('cidx hooks').
+
+Tests hook installation next to existing hook code, removal, and the
+throttled coalescing of hook triggers.
+"""
+
+import subprocess
+import threading
+import time
+
+import pytest
+
+from code_indexer.services.git_index_hooks import GitIndexHooks
+from code_indexer.utils.file_lock import lock_file, unlock_file
+
+
+@pytest.fixture
+def project(tmp_path):
+    subprocess.run(["git", "init", "-q", str(tmp_path)], check=True)
+    (tmp_path / ".code-indexer").mkdir()
+    return tmp_path
+
+
+def hook(project, name):
+    return project / ".git" / "hooks" / name
+
+
+class TestInstall:
+    """Tests for installing and removing the hooks."""
+
+    def test_install_creates_executable_hooks(self, project):
+        written = GitIndexHooks(project).install(min_interval=5)
+
+        assert [f.name for f in written] == [
+            "post-commit",
+            "post-checkout",
+            "post-merge",
+        ]
+        content = hook(project, "post-commit").read_text()
+        assert content.startswith("#!/bin/sh\n")
+        assert "hooks run --min-interval 5" in content
+        assert content.rstrip().splitlines()[-2].endswith(" &")
+        assert hook(project, "post-commit").stat().st_mode & 0o111
+
+    def test_existing_hook_code_is_kept(self, project):
+        hooks = GitIndexHooks(project)
+        hook(project, "post-merge").write_text("#!/bin/bash\nmake deps\n")
+
+        hooks.install()
+        hooks.install(min_interval=60)
+
+        content = hook(project, "post-merge").read_text()
+        assert content.startswith("#!/bin/bash\nmake deps\n")
+        assert content.count("cidx auto-index") == 2
+        assert "--min-interval 60" in content
+        assert hooks.installed_hooks() == ["post-commit", "post-checkout", "post-merge"]
+
+        hooks.uninstall()
+
+        assert hook(project, "post-merge").read_text() == "#!/bin/bash\nmake deps\n"
+        assert not hook(project, "post-commit").exists()
+        assert hooks.installed_hooks() == []
+
+    def test_non_shell_hook_is_refused(self, project):
+        hook(project, "post-commit").write_text("#!/usr/bin/env python3\nprint()\n")
+
+        with pytest.raises(ValueError, match="not a shell script"):
+            GitIndexHooks(project).install()
+
+    def test_not_a_git_repository(self, tmp_path):
+        with pytest.raises(ValueError, match="Not a git repository"):
+            GitIndexHooks(tmp_path).install()
+
+
+class TestRun:
+    """Tests for serving hook triggers."""
+
+    def test_trigger_runs_update(self, project):
+        hooks = GitIndexHooks(project)
+        updates = []
+
+        assert hooks.run(min_interval=0, update=lambda: updates.append(1) or 0)
+
+        assert updates == [1]
+        assert not hooks.pending_path.exists()
+        assert hooks.last_run_path.exists()
+
+    def test_trigger_during_update_is_queued(self, project):
+        hooks = GitIndexHooks(project)
+        with open(hooks.lock_path, "a+") as f:
+            lock_file(f)
+            try:
+                assert not hooks.run(update=pytest.fail)
+            finally:
+                unlock_file(f)
+
+        assert hooks.pending_path.exists()
+
+    def test_triggers_are_coalesced(self, project):
+        hooks = GitIndexHooks(project)
+        started = threading.Event()
+        release = threading.Event()
+        updates = []
+
+        def update():
+            updates.append(1)
+            started.set()
+            release.wait(5)
+            return 0
+
+        runner = threading.Thread(
+            target=hooks.run, kwargs={"min_interval": 0, "update": update}
+        )
+        runner.start()
+        started.wait(5)
+        for _ in range(3):
+            GitIndexHooks(project).run(min_interval=0, update=pytest.fail)
+        release.set()
+        runner.join(5)
+
+        assert updates == [1, 1]
+
+    def test_min_interval_is_respected(self, project, monkeypatch):
+        hooks = GitIndexHooks(project)
+        hooks.last_run_path.write_text(str(time.time()))
+        sleeps = []
+        monkeypatch.setattr(time, "sleep", sleeps.append)
+
+        hooks.run(min_interval=30, update=lambda: 0)
+
+        assert 29 < sleeps[0] <= 30
@@ -0,0 +1,142 @@
+"""
+Unit tests for Go build constraint metadata.
+
+Tests //go:build and legacy +build parsing, file name suffixes, the platforms
+a constraint builds for, chunk annotation, configuration and the --platform
+filter conditions.
+"""
+
+import pytest
+
+from code_indexer.config import Config, GoBuildConstraintsConfig
+from code_indexer.services.go_build_constraints import (
+    BUILD_ARCH_KEY,
+    BUILD_CONSTRAINT_KEY,
+    BUILD_OS_KEY,
+    BUILD_TAGS_KEY,
+    FileConstraint,
+    GoBuildConstraints,
+    file_name_expression,
+    header_expression,
+    parse_expression,
+    parse_platform,
+    platform_conditions,
+)
+from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+
+class TestParsing:
+    """Tests for reading constraints from headers and file names."""
+
+    def test_parse_expression(self):
+        assert parse_expression("linux && !(386 || arm)") == (
+            "and",
+            ("tag", "linux"),
+            ("not", ("or", ("tag", "386"), ("tag", "arm"))),
+        )
+        with pytest.raises(ValueError, match="Invalid build constraint"):
+            parse_expression("linux &&")
+
+    def test_go_build_line_wins_over_plus_build(self):
+        header = "// Copyright\n\n//go:build linux\n// +build darwin\n\npackage x\n"
+
+        assert header_expression(header) == "linux"
+
+    def test_plus_build_lines(self):
+        header = "// +build linux,386 darwin,!cgo\n// +build go1.20\n\npackage x\n"
+
+        assert header_expression(header) == (
+            "(linux && 386 || darwin && !cgo) && go1.20"
+        )
+
+    def test_constraints_after_package_clause_are_ignored(self):
+        assert header_expression("package x\n\n//go:build linux\n") == ""
+
+    def test_file_name_suffixes(self):
+        assert file_name_expression("poll_windows.go") == "windows"
+        assert file_name_expression("asm_linux_arm64_test.go") == "linux && arm64"
+        assert file_name_expression("zsys_amd64.go") == "amd64"
+        assert file_name_expression("linux.go") == ""
+        assert file_name_expression("user_test.go") == ""
+
+
+class TestFileConstraint:
+    """Tests for the platforms and tags of a file."""
+
+    def test_os_and_arch(self):
+        constraint = FileConstraint.of_file(
+            "net_arm64.go", "//go:build darwin || linux\n\npackage net\n"
+        )
+
+        assert constraint.payload() == {
+            BUILD_CONSTRAINT_KEY: "(darwin || linux) && arm64",
+            BUILD_OS_KEY: ["android", "darwin", "ios", "linux"],
+            BUILD_ARCH_KEY: ["arm64"],
+        }
+
+    def test_negation_and_unix(self):
+        assert "windows" not in FileConstraint("!windows").os
+        assert "plan9" in FileConstraint("!windows").os
+        assert "plan9" not in FileConstraint("unix").os
+
+    def test_tags_leave_platforms_open(self):
+        constraint = FileConstraint("integration || linux")
+
+        assert constraint.os == []
+        assert constraint.arch == []
+        assert constraint.tags == ["integration"]
+
+    def test_unconstrained_file(self):
+        assert FileConstraint.of_file("main.go", "package main\n").payload() == {}
+
+
+class TestGoBuildConstraints:
+    """Tests for chunk annotation, configuration and filters."""
+
+    def test_classify_chunks(self, tmp_path):
+        source = tmp_path / "sys_windows.go"
+        source.write_text("//go:build integration\n\npackage sys\n")
+        chunks = [{"text": "a"}, {"text": "b"}]
+
+        classified = GoBuildConstraints().classify_chunks(chunks, source)
+
+        assert [c[BUILD_OS_KEY] for c in classified] == [["windows"]] * 2
+        assert classified[0][BUILD_TAGS_KEY] == ["integration"]
+        assert classified[0][BUILD_CONSTRAINT_KEY] == "integration && windows"
+
+    def test_malformed_constraint_is_skipped(self, tmp_path):
+        source = tmp_path / "x.go"
+        source.write_text("//go:build (linux\n\npackage x\n")
+        chunks = [{"text": "a"}]
+
+        assert GoBuildConstraints().classify_chunks(chunks, source) is chunks
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert GoBuildConstraints.from_config(config) is not None
+
+        config.indexing.go_build_constraints.enabled = False
+        assert GoBuildConstraints.from_config(config) is None
+
+    def test_default_platform_is_validated(self):
+        assert GoBuildConstraintsConfig(default_platform="linux/amd64")
+        with pytest.raises(ValueError, match="Unknown GOARCH"):
+            GoBuildConstraintsConfig(default_platform="linux/x86")
+
+    def test_parse_platform(self):
+        assert parse_platform("Linux/AMD64") == ("linux", "amd64")
+        assert parse_platform("windows") == ("windows", None)
+        with pytest.raises(ValueError, match="Unknown GOOS"):
+            parse_platform("win")
+
+    def test_platform_conditions(self, tmp_path):
+        store = FilesystemVectorStore(base_path=tmp_path, project_root=tmp_path)
+        matches = store._parse_filter(
+            {"must": platform_conditions("linux", "arm64")}
+        )
+
+        assert matches({"path": "main.go"})
+        assert matches({BUILD_OS_KEY: ["linux"], BUILD_ARCH_KEY: ["arm64"]})
+        assert matches({BUILD_OS_KEY: ["android", "linux"]})
+        assert not matches({BUILD_OS_KEY: ["windows"]})
+        assert not matches({BUILD_OS_KEY: ["linux"], BUILD_ARCH_KEY: ["amd64"]})
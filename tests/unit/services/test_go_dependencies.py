@@ -0,0 +1,219 @@
+"""
+Unit tests for the Go module dependency graph.
+
+Tests go.mod, go.work and go.sum parsing, import extraction, resolving
+imports to modules, chunk annotation, configuration and listing the
+packages importing a module.
+"""
+
+from unittest.mock import Mock, patch
+
+from code_indexer.config import Config
+from code_indexer.services.go_dependencies import (
+    GO_IMPORTS_KEY,
+    GO_MODULES_KEY,
+    GO_PACKAGE_KEY,
+    GoDependencyGraph,
+    find_importing_packages,
+    go_imports,
+    is_standard_library,
+    parse_go_mod,
+    parse_go_sum,
+    parse_go_work,
+)
+
+GO_MOD = """module example.com/shop // storefront
+
+go 1.21
+
+require github.com/gin-gonic/gin v1.9.1
+
+require (
+\tgithub.com/go-playground/validator/v10 v10.14.0 // indirect
+\tgolang.org/x/net v0.17.0
+)
+
+replace golang.org/x/net => ../net
+"""
+
+GO_SUM = """github.com/gin-contrib/sse v0.1.0 h1:abc=
+github.com/gin-contrib/sse v0.1.0/go.mod h1:def=
+github.com/gin-gonic/gin v1.9.1 h1:ghi=
+"""
+
+HANDLER = """package api
+
+import "fmt"
+
+import (
+\t"net/http"
+
+\tsse "github.com/gin-contrib/sse"
+\t"github.com/gin-gonic/gin/binding" // request binding
+\t"example.com/shop/internal/store"
+\t_ "github.com/lib/pq"
+)
+"""
+
+
+def write_project(root):
+    (root / "go.mod").write_text(GO_MOD)
+    (root / "go.sum").write_text(GO_SUM)
+    (root / "internal" / "api").mkdir(parents=True)
+    (root / "internal" / "api" / "handler.go").write_text(HANDLER)
+    (root / "tools").mkdir()
+    (root / "tools" / "go.mod").write_text("module example.com/shop/tools\n")
+    (root / "tools" / "gen.go").write_text(
+        'package main\n\nimport "example.com/shop/internal/store"\n'
+    )
+    (root / "go.work").write_text("go 1.21\n\nuse (\n\t.\n\t./tools\n)\n")
+
+
+class TestParsing:
+    """Tests for reading module files and imports."""
+
+    def test_parse_go_mod(self):
+        module = parse_go_mod(GO_MOD, "svc")
+
+        assert module.path == "example.com/shop"
+        assert module.dir == "svc"
+        assert module.go_version == "1.21"
+        assert [(r.module, r.indirect, r.replaced_by) for r in module.requires] == [
+            ("github.com/gin-gonic/gin", False, None),
+            ("github.com/go-playground/validator/v10", True, None),
+            ("golang.org/x/net", False, "../net"),
+        ]
+
+    def test_go_mod_without_module_directive(self):
+        assert parse_go_mod("go 1.21\n") is None
+
+    def test_parse_go_work_and_go_sum(self):
+        assert parse_go_work("go 1.21\nuse ./a\nuse (\n\t./b // tools\n)\n") == [
+            "./a",
+            "./b",
+        ]
+        assert parse_go_sum(GO_SUM) == [
+            "github.com/gin-contrib/sse",
+            "github.com/gin-gonic/gin",
+        ]
+
+    def test_go_imports(self):
+        assert go_imports(HANDLER) == [
+            "fmt",
+            "net/http",
+            "github.com/gin-contrib/sse",
+            "github.com/gin-gonic/gin/binding",
+            "example.com/shop/internal/store",
+            "github.com/lib/pq",
+        ]
+        assert is_standard_library("net/http")
+        assert not is_standard_library("golang.org/x/net/http2")
+
+
+class TestGoDependencyGraph:
+    """Tests for resolving imports and annotating chunks."""
+
+    def test_modules_and_workspaces(self, tmp_path):
+        write_project(tmp_path)
+        graph = GoDependencyGraph(tmp_path)
+
+        assert [(m.path, m.dir) for m in graph.modules()] == [
+            ("example.com/shop", ""),
+            ("example.com/shop/tools", "tools"),
+        ]
+        assert graph.modules()[0].sums == [
+            "github.com/gin-contrib/sse",
+            "github.com/gin-gonic/gin",
+        ]
+        assert graph.workspaces() == {"": [".", "./tools"]}
+        assert graph.module_of("tools/gen.go").path == "example.com/shop/tools"
+
+    def test_annotate_chunks(self, tmp_path):
+        write_project(tmp_path)
+        chunks = [{"text": "a"}, {"text": "b"}]
+
+        annotated = GoDependencyGraph(tmp_path).annotate_chunks(
+            chunks, tmp_path / "internal" / "api" / "handler.go"
+        )
+
+        assert annotated[1][GO_PACKAGE_KEY] == "example.com/shop/internal/api"
+        assert "net/http" not in annotated[0][GO_IMPORTS_KEY]
+        assert annotated[0][GO_MODULES_KEY] == [
+            "github.com/gin-contrib/sse",
+            "github.com/gin-gonic/gin",
+            "example.com/shop",
+            # Not in go.mod or go.sum: named by the import
+            "github.com/lib/pq",
+        ]
+
+    def test_nested_module(self, tmp_path):
+        write_project(tmp_path)
+        chunks = [{"text": "a"}]
+
+        annotated = GoDependencyGraph(tmp_path).annotate_chunks(
+            chunks, tmp_path / "tools" / "gen.go"
+        )
+
+        assert annotated[0][GO_PACKAGE_KEY] == "example.com/shop/tools"
+        assert annotated[0][GO_MODULES_KEY] == ["example.com/shop"]
+
+    def test_other_files_are_skipped(self, tmp_path):
+        write_project(tmp_path)
+        chunks = [{"text": "a"}]
+
+        graph = GoDependencyGraph(tmp_path)
+        assert graph.annotate_chunks(chunks, tmp_path / "README.md") is chunks
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert GoDependencyGraph.from_config(config) is not None
+
+        config.indexing.go_dependencies.enabled = False
+        assert GoDependencyGraph.from_config(config) is None
+
+
+class TestFindImportingPackages:
+    """Tests for listing the packages importing a module."""
+
+    def test_groups_files_by_package(self, tmp_path):
+        def record(path, package, imports, **extra):
+            payload = {
+                "path": path,
+                GO_PACKAGE_KEY: package,
+                GO_IMPORTS_KEY: imports,
+                **extra,
+            }
+            return "id", {"payload": payload}, None
+
+        store = Mock()
+        store.list_collections.return_value = ["code"]
+        store.iter_vector_records.return_value = [
+            record("api/a.go", "shop/api", ["github.com/gin-gonic/gin"]),
+            record("api/a.go", "shop/api", ["github.com/gin-gonic/gin"]),
+            record("api/b.go", "shop/api", ["github.com/gin-gonic/gin/binding"]),
+            record("web/c.go", "shop/web", ["github.com/gin-gonic/ginx"]),
+            record(
+                "old/d.go",
+                "shop/old",
+                ["github.com/gin-gonic/gin"],
+                hidden_branches=["main"],
+            ),
+        ]
+
+        with patch(
+            "code_indexer.utils.git_runner.get_current_branch", return_value="main"
+        ):
+            packages = find_importing_packages(
+                store, tmp_path, "github.com/gin-gonic/gin"
+            )
+
+        assert [p.to_dict() for p in packages] == [
+            {
+                "package": "shop/api",
+                "files": ["api/a.go", "api/b.go"],
+                "imports": [
+                    "github.com/gin-gonic/gin",
+                    "github.com/gin-gonic/gin/binding",
+                ],
+            }
+        ]
@@ -0,0 +1,185 @@
+"""
+Unit tests for Go interface-to-implementation linkage.
+
+Tests signature normalization, method-set matching across the files of a
+package and across packages, embedded interfaces, chunk annotation and
+configuration.
+"""
+
+import shutil
+from pathlib import Path
+
+from code_indexer.config import Config
+from code_indexer.services.go_interfaces import (
+    IMPLEMENTS_KEY,
+    GoFileDeclarations,
+    GoInterfaceIndex,
+    parameter_types,
+)
+
+GO_FIXTURE = Path(__file__).parents[1] / "parsers/test_files/go/MicroserviceAPI.go"
+
+FILES = {
+    "repository/repository.go": """package repository
+
+import "context"
+
+type Reader interface {
+\tFindByID(ctx context.Context, id uint) (*domain.User, error)
+}
+
+type UserRepository interface {
+\tReader
+\tSave(ctx context.Context, user *domain.User) error // upsert
+}
+
+type Closer interface { Close() error }
+
+type Number interface {
+\t~int | ~float64
+}
+
+type Any interface{}
+""",
+    "postgres/user.go": """package postgres
+
+type (
+\tuserRepository struct {
+\t\tdb *sql.DB
+\t}
+\tcache map[uint]*domain.User
+)
+
+func (r *userRepository) FindByID(c context.Context, uid uint) (*domain.User, error) {
+\treturn nil, nil
+}
+""",
+    "postgres/user_save.go": """package postgres
+
+func (r *userRepository) Save(c context.Context, u *domain.User) error {
+\treturn nil
+}
+
+func (c cache) FindByID(ctx context.Context, id int) (*domain.User, error) {
+\treturn nil, nil
+}
+
+type file struct{}
+
+func (file) Close() error { return nil }
+""",
+}
+
+
+def write_project(root):
+    for path, text in FILES.items():
+        (root / path).parent.mkdir(parents=True, exist_ok=True)
+        (root / path).write_text(text)
+
+
+class TestDeclarations:
+    """Tests for parsing Go declarations."""
+
+    def test_parameter_types(self):
+        assert parameter_types("ctx context.Context, a, b int, opts ...Option") == [
+            "Context",
+            "int",
+            "int",
+            "...Option",
+        ]
+        assert parameter_types("int, func(a int) error") == [
+            "int",
+            "func(aint)error",
+        ]
+        assert parameter_types("fn func(int) error, ch chan string") == [
+            "func(int)error",
+            "chanstring",
+        ]
+
+    def test_interfaces_and_types(self):
+        declarations = GoFileDeclarations(FILES["repository/repository.go"])
+
+        methods, embedded = declarations.interfaces["UserRepository"]
+        assert methods == {"Save": "(Context,*User)(error)"}
+        assert embedded == ["Reader"]
+        assert declarations.interfaces["Closer"] == (
+            {"Close": "()(error)"},
+            [],
+        )
+        assert declarations.interfaces["Number"] is None
+        assert declarations.types == {}
+
+    def test_grouped_types_and_methods(self):
+        declarations = GoFileDeclarations(FILES["postgres/user.go"])
+
+        assert declarations.types == {"userRepository": 4, "cache": 7}
+        assert declarations.methods["userRepository"] == {
+            "FindByID": "(Context,uint)(*User,error)"
+        }
+
+
+class TestGoInterfaceIndex:
+    """Tests for linking types to interfaces and annotating chunks."""
+
+    def test_links(self, tmp_path):
+        write_project(tmp_path)
+
+        links = GoInterfaceIndex(tmp_path).links()
+
+        assert links["postgres/user.go"] == {
+            "userRepository": (
+                4,
+                [
+                    "Reader",
+                    "repository.Reader",
+                    "UserRepository",
+                    "repository.UserRepository",
+                ],
+            )
+        }
+        # Parameter type differs (int, not uint)
+        assert "cache" not in links["postgres/user.go"]
+        assert links["postgres/user_save.go"] == {
+            "file": (11, ["Closer", "repository.Closer"])
+        }
+
+    def test_annotate_chunks(self, tmp_path):
+        write_project(tmp_path)
+        chunks = [
+            {"text": "...", "line_start": 1, "line_end": 6},
+            {"text": "...", "line_start": 7, "line_end": 13},
+        ]
+
+        annotated = GoInterfaceIndex(tmp_path).annotate_chunks(
+            chunks, tmp_path / "postgres" / "user.go"
+        )
+
+        assert "UserRepository" in annotated[0][IMPLEMENTS_KEY]
+        assert IMPLEMENTS_KEY not in annotated[1]
+
+    def test_fixture_repository(self, tmp_path):
+        shutil.copy(GO_FIXTURE, tmp_path / "main.go")
+        chunks = [{"text": "...", "line_start": 275, "line_end": 290}]
+
+        annotated = GoInterfaceIndex(tmp_path).annotate_chunks(
+            chunks, tmp_path / "main.go"
+        )
+
+        assert annotated[0][IMPLEMENTS_KEY] == ["UserRepository", "main.UserRepository"]
+
+    def test_hidden_directories_and_other_files_are_skipped(self, tmp_path):
+        write_project(tmp_path)
+        (tmp_path / ".cache").mkdir()
+        (tmp_path / ".cache" / "x.go").write_text(FILES["postgres/user_save.go"])
+        chunks = [{"text": "...", "line_start": 1, "line_end": 5}]
+        index = GoInterfaceIndex(tmp_path)
+
+        assert ".cache/x.go" not in index.links()
+        assert index.annotate_chunks(chunks, tmp_path / "README.md") is chunks
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert GoInterfaceIndex.from_config(config) is not None
+
+        config.indexing.go_interfaces.enabled = False
+        assert GoInterfaceIndex.from_config(config) is None
@@ -0,0 +1,107 @@
+"""
+Unit tests for linking protobuf rpcs to generated gRPC stubs.
+
+Tests stub file recognition, per-method and per-service matching, chunk
+annotation and configuration.
+"""
+
+from code_indexer.config import Config
+from code_indexer.indexing.proto_chunker import PROTO_KIND_KEY, PROTO_NAME_KEY
+from code_indexer.services.grpc_stubs import (
+    GRPC_STUBS_KEY,
+    GrpcStubIndex,
+    is_stub_file,
+)
+
+FILES = {
+    "gen/go/user_grpc.pb.go": """package userv1
+
+const (
+\tUserService_GetUser_FullMethodName = "/acme.user.v1.UserService/GetUser"
+)
+""",
+    "gen/python/user_pb2_grpc.py": """class UserServiceStub(object):
+    def __init__(self, channel):
+        self.GetUser = channel.unary_unary('/acme.user.v1.UserService/GetUser')
+        self.ListUsers = channel.unary_stream(
+            '/acme.user.v1.UserService/ListUsers'
+        )
+""",
+    "gen/java/UserServiceGrpc.java": """public final class UserServiceGrpc {
+  public static final java.lang.String SERVICE_NAME = "acme.user.v1.UserService";
+}
+""",
+    "gen/go/admin_grpc.pb.go": '"/acme.admin.v1.AdminService/Ban"\n',
+    "internal/client.go": '"/acme.user.v1.UserService/GetUser"\n',
+}
+
+
+def write_project(root):
+    for path, text in FILES.items():
+        (root / path).parent.mkdir(parents=True, exist_ok=True)
+        (root / path).write_text(text)
+
+
+class TestGrpcStubIndex:
+    """Tests for finding stubs and annotating chunks."""
+
+    def test_is_stub_file(self):
+        assert is_stub_file("user_grpc.pb.go")
+        assert is_stub_file("user_pb2_grpc.py")
+        assert is_stub_file("UserServiceGrpc.java")
+        assert not is_stub_file("user.pb.go")
+        assert not is_stub_file("user_pb2.py")
+
+    def test_stubs_of_rpcs(self, tmp_path):
+        write_project(tmp_path)
+        index = GrpcStubIndex(tmp_path)
+
+        assert index.stubs_of("rpc", "acme.user.v1.UserService.GetUser") == [
+            "gen/go/user_grpc.pb.go",
+            "gen/python/user_pb2_grpc.py",
+        ]
+        assert index.stubs_of("rpc", "acme.user.v1.UserService.ListUsers") == [
+            "gen/python/user_pb2_grpc.py"
+        ]
+        # Without stubs naming the method, those of its service are linked
+        assert index.stubs_of("rpc", "acme.user.v1.UserService.Delete") == [
+            "gen/go/user_grpc.pb.go",
+            "gen/java/UserServiceGrpc.java",
+            "gen/python/user_pb2_grpc.py",
+        ]
+
+    def test_stubs_of_services(self, tmp_path):
+        write_project(tmp_path)
+        index = GrpcStubIndex(tmp_path)
+
+        assert index.stubs_of("service", "acme.user.v1.UserService") == [
+            "gen/go/user_grpc.pb.go",
+            "gen/java/UserServiceGrpc.java",
+            "gen/python/user_pb2_grpc.py",
+        ]
+        assert index.stubs_of("message", "acme.user.v1.User") == []
+
+    def test_annotate_chunks(self, tmp_path):
+        write_project(tmp_path)
+        chunks = [
+            {"text": "...", PROTO_KIND_KEY: "file"},
+            {
+                "text": "...",
+                PROTO_KIND_KEY: "rpc",
+                PROTO_NAME_KEY: "acme.admin.v1.AdminService.Ban",
+            },
+        ]
+        index = GrpcStubIndex(tmp_path)
+
+        annotated = index.annotate_chunks(chunks, tmp_path / "api" / "admin.proto")
+
+        assert GRPC_STUBS_KEY not in annotated[0]
+        assert annotated[1][GRPC_STUBS_KEY] == ["gen/go/admin_grpc.pb.go"]
+        assert index.annotate_chunks(chunks, tmp_path / "admin.go") is chunks
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert GrpcStubIndex.from_config(config) is not None
+
+        config.indexing.grpc_stubs.enabled = False
+        assert GrpcStubIndex.from_config(config) is None
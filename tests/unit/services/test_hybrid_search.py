@@ -0,0 +1,63 @@
+"""
+Unit tests for reciprocal rank fusion of hybrid search results.
+
+Tests fusing full-text matches into the semantic chunk that contains them,
+ranking by summed reciprocal ranks, and the result limit.
+"""
+
+import pytest
+
+from code_indexer.services.hybrid_search import RRF_K, fuse_results
+
+
+def semantic(path, line_start, line_end):
+    return {
+        "score": 0.8,
+        "payload": {"path": path, "line_start": line_start, "line_end": line_end},
+    }
+
+
+def fts(path, line):
+    return {"path": path, "line": line, "column": 1, "snippet": "", "match_text": ""}
+
+
+class TestFuseResults:
+    """Tests for fuse_results."""
+
+    def test_result_found_by_both_searches_ranks_first(self):
+        fused = fuse_results(
+            fts_results=[fts("b.py", 40), fts("a.py", 12)],
+            semantic_results=[semantic("c.py", 1, 20), semantic("a.py", 10, 30)],
+            limit=10,
+        )
+
+        assert [(r.path, r.line_start) for r in fused] == [
+            ("a.py", 10),
+            ("c.py", 1),
+            ("b.py", 40),
+        ]
+        assert (fused[0].semantic_rank, fused[0].fts_rank) == (2, 2)
+        assert fused[0].score == pytest.approx(2 / (RRF_K + 2))
+
+    def test_matches_outside_chunks_stand_on_their_own_line(self):
+        fused = fuse_results(
+            fts_results=[fts("a.py", 50), fts("a.py", 50)],
+            semantic_results=[semantic("a.py", 10, 30)],
+            limit=10,
+        )
+
+        lone = [r for r in fused if r.semantic is None]
+        assert [(r.line_start, r.line_end) for r in lone] == [(50, 50)]
+        # Only the best-ranked match of a result counts
+        assert lone[0].fts_rank == 1
+        assert len(lone[0].fts_matches) == 2
+        assert lone[0].score == pytest.approx(1 / (RRF_K + 1))
+
+    def test_limit(self):
+        fused = fuse_results(
+            fts_results=[fts("a.py", 1), fts("b.py", 1)],
+            semantic_results=[semantic("c.py", 1, 5)],
+            limit=2,
+        )
+
+        assert len(fused) == 2
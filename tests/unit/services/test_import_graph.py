@@ -0,0 +1,206 @@
+"""
+Unit tests for the file import graph.
+
+Tests resolving Python, JavaScript, Go, Java and C imports to project paths,
+chunk annotation, reading imports from the index and tracing dependencies
+and dependents.
+"""
+
+from unittest.mock import Mock, patch
+
+from code_indexer.config import Config
+from code_indexer.services.import_graph import (
+    IMPORTED_PATHS_KEY,
+    IMPORTS_KEY,
+    ImportGraph,
+    load_imports,
+    python_imports,
+    trace_imports,
+)
+
+
+def write(root, files):
+    for path, text in files.items():
+        (root / path).parent.mkdir(parents=True, exist_ok=True)
+        (root / path).write_text(text)
+
+
+def graph_of(**imported):
+    return {
+        path: {IMPORTS_KEY: list(targets), IMPORTED_PATHS_KEY: list(targets)}
+        for path, targets in imported.items()
+    }
+
+
+class TestResolveImports:
+    """Tests for resolving imports to project paths."""
+
+    def test_python(self, tmp_path):
+        write(
+            tmp_path,
+            {
+                "src/shop/__init__.py": "",
+                "src/shop/cart.py": "",
+                "src/shop/models/item.py": "",
+                "src/shop/api.py": "",
+            },
+        )
+        text = (
+            "import os, shop.cart\n"
+            "from shop.models import item\n"
+            "from .cart import Cart\n"
+            "from . import api\n"
+        )
+
+        imports, paths = ImportGraph(tmp_path).file_imports("src/shop/app.py", text)
+
+        assert imports == ["shop.models", ".cart", ".", "os", "shop.cart"]
+        assert paths == [
+            "src/shop/models/item.py",
+            "src/shop/cart.py",
+            "src/shop/api.py",
+        ]
+
+    def test_python_import_names(self):
+        text = "from a.b import (\n    c,\n    d as e,\n)\nimport f.g as h\n"
+
+        assert python_imports(text) == [("a.b", ["c", "d"]), ("f.g", [])]
+
+    def test_javascript_relative_specifiers(self, tmp_path):
+        write(tmp_path, {"src/cart.ts": "", "src/lib/index.js": ""})
+        text = "import { Cart } from './cart';\nconst lib = require('./lib');\n"
+        text += "import React from 'react';\n"
+
+        imports, paths = ImportGraph(tmp_path).file_imports("src/app.tsx", text)
+
+        assert imports == ["./cart", "./lib", "react"]
+        assert paths == ["src/cart.ts", "src/lib/index.js"]
+
+    def test_go_packages_of_the_module(self, tmp_path):
+        write(tmp_path, {"go.mod": "module example.com/shop\n", "store/db.go": ""})
+        text = 'package api\n\nimport (\n\t"fmt"\n\t"example.com/shop/store"\n'
+        text += '\t"github.com/gin-gonic/gin"\n)\n'
+
+        imports, paths = ImportGraph(tmp_path).file_imports("api/api.go", text)
+
+        assert imports == ["example.com/shop/store", "github.com/gin-gonic/gin"]
+        assert paths == ["store"]
+
+    def test_java_classes_and_wildcards(self, tmp_path):
+        write(
+            tmp_path,
+            {
+                "src/main/java/com/shop/cart/Cart.java": "",
+                "src/main/java/com/shop/model/Item.java": "",
+            },
+        )
+        text = (
+            "package com.shop.api;\n\n"
+            "import com.shop.cart.Cart;\n"
+            "import static com.shop.cart.Cart.EMPTY;\n"
+            "import com.shop.model.*;\n"
+            "import java.util.List;\n"
+        )
+
+        _, paths = ImportGraph(tmp_path).file_imports(
+            "src/main/java/com/shop/api/Api.java", text
+        )
+
+        assert paths == [
+            "src/main/java/com/shop/cart/Cart.java",
+            "src/main/java/com/shop/model",
+        ]
+
+    def test_c_includes(self, tmp_path):
+        write(tmp_path, {"src/cart.h": "", "include/util/log.h": ""})
+        text = '#include <stdio.h>\n#include "cart.h"\n#include "util/log.h"\n'
+
+        _, paths = ImportGraph(tmp_path).file_imports("src/cart.c", text)
+
+        assert paths == ["src/cart.h", "include/util/log.h"]
+
+    def test_annotate_chunks(self, tmp_path):
+        write(tmp_path, {"lib.py": "", "main.py": "import lib\nimport json\n"})
+        chunks = [{"text": "import lib"}, {"text": "main()"}]
+
+        annotated = ImportGraph(tmp_path).annotate_chunks(
+            chunks, tmp_path / "main.py"
+        )
+
+        assert [c[IMPORTED_PATHS_KEY] for c in annotated] == [["lib.py"]] * 2
+        assert annotated[0][IMPORTS_KEY] == ["lib", "json"]
+        assert IMPORTS_KEY not in chunks[0]
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert ImportGraph.from_config(config) is not None
+
+        config.indexing.import_graph.enabled = False
+        assert ImportGraph.from_config(config) is None
+
+
+class TestTraceImports:
+    """Tests for following import edges."""
+
+    FILES = graph_of(
+        **{
+            "api/handlers.go": ["service"],
+            "cmd/main.go": ["api"],
+            "service/cart.go": ["store"],
+            "service/order.go": ["service/cart.go"],
+            "store/db.go": [],
+            "tools/gen.py": [],
+        }
+    )
+
+    def test_dependents_of_a_package(self):
+        trace = trace_imports(self.FILES, "service/", reverse=True)
+
+        assert trace.files == ["service/cart.go", "service/order.go"]
+        assert [(e.path, e.depth, e.via) for e in trace.edges] == [
+            ("api/handlers.go", 1, ["service/cart.go", "service/order.go"]),
+            ("cmd/main.go", 2, ["api/handlers.go"]),
+        ]
+
+    def test_dependencies_of_a_package(self):
+        trace = trace_imports(self.FILES, "cmd")
+
+        assert [(e.path, e.depth) for e in trace.edges] == [
+            ("api", 1),
+            ("service", 2),
+            ("store", 3),
+        ]
+
+    def test_depth_limit(self):
+        trace = trace_imports(self.FILES, "store", reverse=True, max_depth=1)
+
+        assert [e.path for e in trace.edges] == ["service/cart.go"]
+
+    def test_load_imports_from_the_index(self, tmp_path):
+        def record(path, imported, **extra):
+            payload = {
+                "path": path,
+                IMPORTS_KEY: imported,
+                IMPORTED_PATHS_KEY: imported,
+                **extra,
+            }
+            return "id", {"payload": payload}, None
+
+        store = Mock()
+        store.list_collections.return_value = ["code"]
+        store.iter_vector_records.return_value = [
+            record("a.py", ["b.py"]),
+            record("a.py", ["b.py"]),
+            record("old.py", ["b.py"], hidden_branches=["main"]),
+            record("b.py", []),
+        ]
+
+        with patch(
+            "code_indexer.utils.git_runner.get_current_branch", return_value="main"
+        ):
+            files = load_imports(store, tmp_path)
+
+        assert files == {
+            "a.py": {IMPORTS_KEY: ["b.py"], IMPORTED_PATHS_KEY: ["b.py"]},
+            "b.py": {IMPORTS_KEY: [], IMPORTED_PATHS_KEY: []},
+        }
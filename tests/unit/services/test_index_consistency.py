@@ -0,0 +1,180 @@
+"""
+Unit tests for end-to-end index consistency checks.
+
+Tests IndexConsistencyChecker's cross-checks of tracked files, visible chunks,
+branch visibility and derived indexes, and the order of its repairs.
+"""
+
+# mypy: ignore-errors
+
+import json
+import tempfile
+from pathlib import Path
+from unittest.mock import Mock
+
+from src.code_indexer.services.index_consistency import (
+    ISSUE_DUPLICATE_VERSIONS,
+    ISSUE_INCOMPLETE_FILE,
+    ISSUE_INDEX_DRIFT,
+    ISSUE_INVALID_VISIBILITY,
+    ISSUE_MISSING_FILE,
+    ISSUE_ORPHANED_POINTS,
+    IndexConsistencyChecker,
+)
+from src.code_indexer.storage.filesystem_vector_store import PathIndex
+from src.code_indexer.storage.id_index_manager import IDIndexManager
+
+COLLECTION = "voyage-code-3"
+
+
+def _chunk(point_id, path, chunk_index=0, total_chunks=1, **payload):
+    payload = {
+        "path": path,
+        "type": "content",
+        "chunk_index": chunk_index,
+        "total_chunks": total_chunks,
+        "file_hash": "sha256:a",
+        "hidden_branches": [],
+        **payload,
+    }
+    return {"id": point_id, "payload": payload}
+
+
+class TestIndexConsistencyChecker:
+    """Tests for IndexConsistencyChecker."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.collection_path = self.root / "index" / COLLECTION
+        self.collection_path.mkdir(parents=True)
+        self.records = []
+        self.store = Mock()
+        self.store.base_path = self.root / "index"
+        self.store.iter_vector_records.side_effect = lambda name: [
+            (self.collection_path / f"vector_{r['id']}.json", r, None)
+            for r in self.records
+        ]
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def _check(self, tracked, branch="main"):
+        checker = IndexConsistencyChecker(self.store, tracked, branch, self.root)
+        return checker, checker.check_collection(COLLECTION, check_project=True)
+
+    def _kinds(self, result):
+        return sorted((i.kind, i.path) for i in result.issues)
+
+    def test_consistent_index(self):
+        self.records = [_chunk("a0", "a.py", 0, 2), _chunk("a1", "a.py", 1, 2)]
+
+        _, result = self._check(["a.py"])
+
+        assert result.ok
+        assert result.files_indexed == 1
+        assert result.points_on_disk == 2
+
+    def test_missing_and_orphaned_files(self):
+        (self.root / "new.py").write_text("x = 1\n")
+        (self.root / "blank.py").write_text("\n\n")
+        self.records = [
+            _chunk("gone", "gone.py"),
+            _chunk("hidden", "old.py", hidden_branches=["main"]),
+        ]
+
+        _, result = self._check(["new.py", "blank.py"])
+
+        assert self._kinds(result) == [
+            (ISSUE_MISSING_FILE, "new.py"),
+            (ISSUE_ORPHANED_POINTS, "gone.py"),
+        ]
+
+    def test_incomplete_and_duplicate_versions(self):
+        self.records = [
+            _chunk("a0", "a.py", 0, 3),
+            _chunk("a2", "a.py", 2, 3),
+            _chunk("b_old", "b.py", file_hash="sha256:old"),
+            _chunk("b_new", "b.py", file_hash="sha256:new"),
+        ]
+
+        _, result = self._check(["a.py", "b.py"])
+
+        assert self._kinds(result) == [
+            (ISSUE_DUPLICATE_VERSIONS, "b.py"),
+            (ISSUE_INCOMPLETE_FILE, "a.py"),
+        ]
+        assert "1 missing" in result.issues[0].detail
+
+    def test_invalid_visibility(self):
+        self.records = [_chunk("a0", "a.py", hidden_branches="main")]
+
+        _, result = self._check(["a.py"])
+
+        assert ISSUE_INVALID_VISIBILITY in [i.kind for i in result.issues]
+
+    def test_derived_index_drift(self):
+        self.records = [_chunk("a0", "a.py"), _chunk("b0", "b.py")]
+        IDIndexManager().save_index(
+            self.collection_path,
+            {
+                "a0": self.collection_path / "vector_a0.json",
+                "stale": self.collection_path / "vector_stale.json",
+            },
+        )
+        path_index = PathIndex()
+        path_index.add_point("a.py", "a0")
+        path_index.add_point("b.py", "b0")
+        path_index.save(self.collection_path / "path_index.bin")
+        (self.collection_path / "collection_meta.json").write_text(
+            json.dumps(
+                {
+                    "unique_file_count": 3,
+                    "hnsw_index": {"is_stale": False, "id_mapping": {"0": "a0"}},
+                }
+            )
+        )
+
+        _, result = self._check(["a.py", "b.py"])
+
+        drift = {i.path: i.detail for i in result.issues if i.kind == ISSUE_INDEX_DRIFT}
+        assert drift == {
+            "id_index.bin": "1 points missing, 1 stale entries",
+            "HNSW index": "1 points missing, 0 stale entries",
+            "unique_file_count": "metadata records 3 files, vectors cover 2",
+        }
+
+    def test_project_checks_only_when_requested(self):
+        self.records = [_chunk("gone", "gone.py")]
+        checker = IndexConsistencyChecker(self.store, [], "main", self.root)
+
+        result = checker.check_collection(COLLECTION)
+
+        assert result.ok
+        assert result.project_checked is False
+
+    def test_repair_rebuilds_indexes_before_file_repairs(self):
+        (self.root / "new.py").write_text("x = 1\n")
+        self.records = [_chunk("gone", "gone.py")]
+        IDIndexManager().save_index(self.collection_path, {})
+        checker, result = self._check(["new.py"])
+        calls = []
+        self.store.rebuild_indexes.side_effect = lambda name: calls.append(
+            ("rebuild", name)
+        )
+
+        repair = checker.repair(
+            [result],
+            reindex_files=lambda paths: calls.append(("reindex", paths)),
+            remove_file=lambda path: calls.append(("remove", path)),
+        )
+
+        assert calls == [
+            ("rebuild", COLLECTION),
+            ("remove", "gone.py"),
+            ("reindex", ["new.py"]),
+        ]
+        assert repair.indexes_rebuilt == [COLLECTION]
+        assert repair.files_removed == 1
+        assert repair.files_reindexed == 1
+        assert repair.errors == []
@@ -0,0 +1,210 @@
+"""
+Unit tests for retention purge of indexed points.
+
+Tests parse_duration(), PurgeCriteria validation and IndexPurger's point
+selection for git history and branch-aware content collections.
+"""
+
+# mypy: ignore-errors
+
+import subprocess
+import tempfile
+from pathlib import Path
+from unittest.mock import Mock
+
+import pytest
+
+from src.code_indexer.services.index_purge import (
+    IndexPurger,
+    PurgeCriteria,
+    parse_duration,
+)
+
+DAY = 86400
+NOW = 1_000 * DAY
+
+
+def _content(point_id, hidden_branches, git_branch, age_days):
+    return {
+        "id": point_id,
+        "payload": {
+            "path": f"{point_id}.py",
+            "type": "content",
+            "hidden_branches": hidden_branches,
+            "git_branch": git_branch,
+            "indexed_timestamp": NOW - age_days * DAY,
+        },
+    }
+
+
+def _commit(point_id, age_days):
+    return {
+        "id": point_id,
+        "payload": {"path": "a.py", "commit_timestamp": NOW - age_days * DAY},
+    }
+
+
+class TestParseDuration:
+    """Tests for parse_duration()."""
+
+    def test_units(self):
+        assert parse_duration("36h") == 36 * 3600
+        assert parse_duration("90d") == 90 * DAY
+        assert parse_duration("2W") == 14 * DAY
+        assert parse_duration("1y") == 365 * DAY
+
+    @pytest.mark.parametrize("text", ["", "90", "0d", "-1d", "1m", "d90"])
+    def test_invalid(self, text):
+        with pytest.raises(ValueError):
+            parse_duration(text)
+
+
+class TestPurgeCriteria:
+    """Tests for PurgeCriteria validation."""
+
+    def test_requires_a_criterion(self):
+        with pytest.raises(ValueError):
+            PurgeCriteria()
+
+    def test_rejects_unknown_branch_filter(self):
+        with pytest.raises(ValueError):
+            PurgeCriteria(branches="stale")
+
+    def test_cutoff(self):
+        assert PurgeCriteria(older_than_seconds=DAY, now=NOW).cutoff == NOW - DAY
+        assert PurgeCriteria(branches="merged").cutoff is None
+
+
+class TestIndexPurger:
+    """Tests for IndexPurger point selection and deletion."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.store = Mock()
+        self.records = {}
+        self.store.list_collections.side_effect = lambda: list(self.records)
+        self.store.iter_vector_records.side_effect = lambda name: [
+            (Path(f"vector_{r['id']}.json"), r, None) for r in self.records[name]
+        ]
+        self.purger = IndexPurger(self.store, self.root)
+        self.purger._branch_state = lambda: {
+            "current": "main",
+            "merged": {"main", "feature"},
+            "existing": {"main", "feature", "wip"},
+        }
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def _deleted_ids(self, collection):
+        return [
+            point_id
+            for call in self.store.delete_points.call_args_list
+            if call.args[0] == collection
+            for point_id in call.args[1]
+        ]
+
+    def test_history_purged_by_commit_age(self):
+        self.records["code-indexer-temporal"] = [
+            _commit("old", 100),
+            _commit("new", 10),
+        ]
+
+        report = self.purger.purge(PurgeCriteria(older_than_seconds=90 * DAY, now=NOW))
+
+        assert report.points_purged == 1
+        assert self._deleted_ids("code-indexer-temporal") == ["old"]
+        self.store.begin_indexing.assert_called_once_with("code-indexer-temporal")
+        self.store.end_indexing.assert_called_once_with("code-indexer-temporal")
+
+    def test_branches_criterion_leaves_history_alone(self):
+        self.records["code-indexer-temporal"] = [_commit("old", 100)]
+
+        report = self.purger.purge(
+            PurgeCriteria(older_than_seconds=90 * DAY, branches="merged", now=NOW)
+        )
+
+        assert report.points_purged == 0
+        self.store.delete_points.assert_not_called()
+
+    def test_visible_content_is_never_purged(self):
+        self.records["code"] = [_content("visible", [], "feature", 500)]
+
+        report = self.purger.purge(PurgeCriteria(older_than_seconds=DAY, now=NOW))
+
+        assert report.points_purged == 0
+
+    def test_hidden_content_purged_by_age_and_merged_branch(self):
+        self.records["code"] = [
+            _content("merged_old", ["main"], "feature", 100),
+            _content("merged_new", ["main"], "feature", 10),
+            _content("unmerged_old", ["main"], "wip", 100),
+            _content("current_old", ["main"], "main", 100),
+        ]
+
+        report = self.purger.purge(
+            PurgeCriteria(older_than_seconds=90 * DAY, branches="merged", now=NOW)
+        )
+
+        assert self._deleted_ids("code") == ["merged_old"]
+        assert report.collections[0].by_branch == {"feature": 1}
+        assert report.collections[0].points_scanned == 4
+
+    def test_deleted_branch_filter(self):
+        self.records["code"] = [
+            _content("gone", ["main"], "removed-branch", 1),
+            _content("kept", ["main"], "wip", 1),
+        ]
+
+        self.purger.purge(PurgeCriteria(branches="deleted", now=NOW))
+
+        assert self._deleted_ids("code") == ["gone"]
+
+    def test_indexed_at_fallback(self):
+        record = _content("legacy", ["main"], "feature", 0)
+        del record["payload"]["indexed_timestamp"]
+        record["payload"]["indexed_at"] = "2000-01-01T00:00:00+00:00Z"
+        self.records["code"] = [record]
+
+        report = self.purger.purge(PurgeCriteria(older_than_seconds=DAY))
+
+        assert report.points_purged == 1
+
+    def test_dry_run_reports_without_deleting(self):
+        self.records["code"] = [_content("old", ["main"], "feature", 100)]
+
+        report = self.purger.purge(
+            PurgeCriteria(older_than_seconds=DAY, now=NOW), dry_run=True
+        )
+
+        assert report.dry_run is True
+        assert report.to_dict()["points_purged"] == 1
+        assert report.collections[0].files_affected == 1
+        self.store.delete_points.assert_not_called()
+        self.store.begin_indexing.assert_not_called()
+
+    def test_branch_state_from_git(self):
+        def git(*args):
+            subprocess.run(
+                ["git", *args], cwd=self.root, check=True, capture_output=True
+            )
+
+        git("init", "-b", "main")
+        git("config", "user.email", "test@example.com")
+        git("config", "user.name", "Test")
+        (self.root / "a.py").write_text("x = 1\n")
+        git("add", ".")
+        git("commit", "-m", "init")
+        git("branch", "feature")
+        git("checkout", "-b", "wip")
+        (self.root / "b.py").write_text("y = 2\n")
+        git("add", ".")
+        git("commit", "-m", "wip")
+        git("checkout", "main")
+
+        state = IndexPurger(self.store, self.root)._branch_state()
+
+        assert state["current"] == "main"
+        assert state["merged"] == {"main", "feature"}
+        assert state["existing"] == {"main", "feature", "wip"}
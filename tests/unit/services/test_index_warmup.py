@@ -0,0 +1,200 @@
+"""
+Unit tests for warm-cache preloading.
+
+Tests page cache warming order and budget, HNSW cache population, embedding
+provider priming, and the daemon preload entry point used by
+`cidx start --preload`.
+"""
+
+# mypy: ignore-errors
+
+import json
+import tempfile
+import time
+from pathlib import Path
+from unittest.mock import Mock, patch
+
+from src.code_indexer.services.index_warmup import (
+    get_collection_paths,
+    iter_warmup_files,
+    warm_project_index,
+)
+
+
+def _make_collection(project_root: Path, name: str, vector_files: int = 3) -> Path:
+    collection_path = project_root / ".code-indexer" / "index" / name
+    (collection_path / "ab").mkdir(parents=True)
+    (collection_path / "collection_meta.json").write_text(
+        json.dumps({"vector_size": 8})
+    )
+    (collection_path / "hnsw_index.bin").write_bytes(b"h" * 1000)
+    (collection_path / "id_index.bin").write_bytes(b"i" * 100)
+    for i in range(vector_files):
+        (collection_path / "ab" / f"vector_{i}.json").write_text("x" * 500)
+    return collection_path
+
+
+class TestWarmupFiles:
+    """Tests for warm-up file discovery and ordering."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.project_root = Path(self.temp_dir.name)
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def test_no_index_means_no_collections(self):
+        assert get_collection_paths(self.project_root) == []
+
+    def test_directories_without_metadata_are_ignored(self):
+        _make_collection(self.project_root, "code")
+        (self.project_root / ".code-indexer" / "index" / "stray").mkdir()
+
+        paths = get_collection_paths(self.project_root)
+
+        assert [p.name for p in paths] == ["code"]
+
+    def test_index_files_of_all_collections_come_first(self):
+        code = _make_collection(self.project_root, "code", vector_files=1)
+        temporal = _make_collection(self.project_root, "temporal", vector_files=1)
+
+        names = [
+            (p.parent.name if p.parent.name != "ab" else p.parent.parent.name, p.name)
+            for p in iter_warmup_files([code, temporal])
+        ]
+
+        assert names[:6] == [
+            ("code", "collection_meta.json"),
+            ("code", "hnsw_index.bin"),
+            ("code", "id_index.bin"),
+            ("temporal", "collection_meta.json"),
+            ("temporal", "hnsw_index.bin"),
+            ("temporal", "id_index.bin"),
+        ]
+        assert [name for _, name in names[6:]] == ["vector_0.json", "vector_0.json"]
+
+
+class TestWarmProjectIndex:
+    """Tests for warm_project_index()."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.project_root = Path(self.temp_dir.name)
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def test_reads_all_files_within_budget(self):
+        _make_collection(self.project_root, "code", vector_files=3)
+
+        result = warm_project_index(self.project_root, prime_embedding=False)
+
+        assert result.collections == ["code"]
+        assert result.files_warmed == 6
+        assert result.bytes_warmed == len(json.dumps({"vector_size": 8})) + 2600
+        assert not result.budget_exhausted
+        assert result.errors == []
+
+    def test_budget_stops_after_index_files(self):
+        _make_collection(self.project_root, "code", vector_files=3)
+
+        result = warm_project_index(
+            self.project_root, max_bytes=1000, prime_embedding=False
+        )
+
+        assert result.budget_exhausted
+        assert result.files_warmed == 2  # metadata + hnsw index
+
+    def test_populates_hnsw_cache(self):
+        collection_path = _make_collection(self.project_root, "code")
+        hnsw_cache = Mock()
+
+        with patch(
+            "src.code_indexer.services.index_warmup.load_hnsw_into_cache"
+        ) as mock_load:
+            result = warm_project_index(
+                self.project_root, hnsw_cache=hnsw_cache, prime_embedding=False
+            )
+
+        mock_load.assert_called_once_with(collection_path, hnsw_cache)
+        assert result.hnsw_indexes_cached == 1
+
+    def test_hnsw_failure_is_recorded_not_raised(self):
+        _make_collection(self.project_root, "code")
+
+        with patch(
+            "src.code_indexer.services.index_warmup.load_hnsw_into_cache",
+            side_effect=RuntimeError("corrupt index"),
+        ):
+            result = warm_project_index(
+                self.project_root, hnsw_cache=Mock(), prime_embedding=False
+            )
+
+        assert result.hnsw_indexes_cached == 0
+        assert "corrupt index" in result.errors[0]
+
+    def test_primes_embedding_provider(self):
+        config = Mock()
+
+        with patch(
+            "src.code_indexer.services.index_warmup.prime_embedding_provider"
+        ) as mock_prime:
+            result = warm_project_index(self.project_root, config=config)
+
+        mock_prime.assert_called_once_with(config)
+        assert result.embedding_primed
+
+    def test_priming_failure_is_recorded_not_raised(self):
+        with patch(
+            "src.code_indexer.services.index_warmup.prime_embedding_provider",
+            side_effect=ValueError("VOYAGE_API_KEY environment variable is required"),
+        ):
+            result = warm_project_index(self.project_root, config=Mock())
+
+        assert not result.embedding_primed
+        assert "VOYAGE_API_KEY" in result.errors[0]
+
+
+class TestDaemonPreload:
+    """Tests for CIDXDaemonService.exposed_preload()."""
+
+    def setup_method(self):
+        from code_indexer.daemon.service import CIDXDaemonService
+
+        self.service = CIDXDaemonService()
+
+    def teardown_method(self):
+        self.service.eviction_thread.stop()
+        self.service.eviction_thread.join(timeout=1)
+
+    def _wait_for_preload(self):
+        deadline = time.time() + 5
+        while self.service.exposed_get_status()["preload_running"]:
+            assert time.time() < deadline
+            time.sleep(0.01)
+
+    def test_preload_runs_in_background_and_reports_result(self):
+        with patch.object(self.service, "_ensure_cache_loaded") as mock_load, patch(
+            "code_indexer.services.index_warmup.prime_embedding_provider"
+        ), patch("code_indexer.config.ConfigManager.create_with_backtrack"):
+            response = self.service.exposed_preload("/tmp/project")
+            self._wait_for_preload()
+
+        assert response == {"status": "started"}
+        mock_load.assert_called_once_with("/tmp/project")
+        result = self.service.exposed_get_status()["preload_result"]
+        assert result["project_root"] == "/tmp/project"
+        assert result["embedding_primed"] is True
+
+    def test_cache_load_failure_is_reported(self):
+        with patch.object(
+            self.service,
+            "_ensure_cache_loaded",
+            side_effect=RuntimeError("index unreadable"),
+        ):
+            self.service.exposed_preload("/tmp/project")
+            self._wait_for_preload()
+
+        result = self.service.exposed_get_status()["preload_result"]
+        assert result["errors"] == ["index unreadable"]
@@ -0,0 +1,141 @@
+"""
+Unit tests for indexing throttle profiles.
+
+Tests throttle profile lookup, in-memory config application, and best-effort
+process priority lowering used by `cidx index --throttle low` and watch mode.
+"""
+
+# mypy: ignore-errors
+
+import sys
+from unittest.mock import Mock, patch
+
+import pytest
+
+from src.code_indexer.config import Config
+from src.code_indexer.services.indexing_throttle import (
+    THROTTLE_LOW,
+    THROTTLE_NORMAL,
+    THROTTLE_PROFILES,
+    apply_throttle_to_config,
+    get_configured_throttle_profile,
+    get_throttle_profile,
+    lower_process_priority,
+)
+
+
+class TestThrottleProfiles:
+    """Tests for profile lookup."""
+
+    def test_known_levels(self):
+        assert get_throttle_profile("normal").name == THROTTLE_NORMAL
+        assert get_throttle_profile("low").name == THROTTLE_LOW
+
+    def test_unknown_level_raises(self):
+        with pytest.raises(ValueError, match="Unknown throttle level"):
+            get_throttle_profile("turbo")
+
+    def test_normal_profile_changes_nothing(self):
+        profile = THROTTLE_PROFILES[THROTTLE_NORMAL]
+
+        assert profile.max_parallel_requests is None
+        assert profile.file_delay_seconds == 0.0
+        assert profile.nice_increment == 0
+        assert not profile.idle_io_priority
+
+    def test_low_profile_limits_resources(self):
+        profile = THROTTLE_PROFILES[THROTTLE_LOW]
+
+        assert profile.max_parallel_requests is not None
+        assert profile.file_delay_seconds > 0
+        assert profile.nice_increment > 0
+        assert profile.idle_io_priority
+
+
+class TestConfiguredThrottle:
+    """Tests for reading throttle levels from configuration."""
+
+    def test_defaults_index_normal_watch_low(self):
+        config = Config()
+
+        assert get_configured_throttle_profile(config).name == THROTTLE_NORMAL
+        assert get_configured_throttle_profile(config, watch=True).name == THROTTLE_LOW
+
+    def test_configured_index_throttle(self):
+        config = Config()
+        config.indexing.throttle = "low"
+
+        assert get_configured_throttle_profile(config).name == THROTTLE_LOW
+
+    def test_partial_config_falls_back_to_normal(self):
+        config = Mock()
+
+        assert get_configured_throttle_profile(config).name == THROTTLE_NORMAL
+        assert (
+            get_configured_throttle_profile(config, watch=True).name == THROTTLE_NORMAL
+        )
+
+
+class TestApplyThrottleToConfig:
+    """Tests for applying a throttle level to an in-memory config."""
+
+    def test_low_caps_parallel_requests_and_disables_auto_tune(self):
+        config = Config()
+        config.voyage_ai.parallel_requests = 16
+        config.voyage_ai.auto_tune = True
+
+        profile = apply_throttle_to_config(config, "low")
+
+        assert config.voyage_ai.parallel_requests == profile.max_parallel_requests
+        assert config.voyage_ai.auto_tune is False
+        assert config.indexing.throttle == "low"
+
+    def test_low_never_raises_thread_count(self):
+        config = Config()
+        config.voyage_ai.parallel_requests = 1
+
+        apply_throttle_to_config(config, "low")
+
+        assert config.voyage_ai.parallel_requests == 1
+
+    def test_normal_keeps_configuration(self):
+        config = Config()
+        config.voyage_ai.parallel_requests = 16
+        config.voyage_ai.auto_tune = True
+
+        apply_throttle_to_config(config, "normal")
+
+        assert config.voyage_ai.parallel_requests == 16
+        assert config.voyage_ai.auto_tune is True
+
+
+class TestLowerProcessPriority:
+    """Tests for best-effort CPU/IO priority lowering."""
+
+    def test_normal_profile_applies_nothing(self):
+        with patch("os.nice") as mock_nice:
+            applied = lower_process_priority(THROTTLE_PROFILES[THROTTLE_NORMAL])
+
+        mock_nice.assert_not_called()
+        assert applied == []
+
+    def test_low_profile_lowers_cpu_priority(self):
+        profile = THROTTLE_PROFILES[THROTTLE_LOW]
+        with patch("os.nice", return_value=10, create=True) as mock_nice, patch.dict(
+            sys.modules, {"psutil": None}
+        ):
+            applied = lower_process_priority(profile)
+
+        mock_nice.assert_called_once_with(profile.nice_increment)
+        assert "CPU niceness 10" in applied
+
+    def test_permission_errors_are_swallowed(self):
+        mock_psutil = Mock()
+        mock_psutil.Process.return_value.ionice.side_effect = OSError("denied")
+
+        with patch("os.nice", side_effect=OSError("denied"), create=True), patch.dict(
+            sys.modules, {"psutil": mock_psutil}
+        ):
+            applied = lower_process_priority(THROTTLE_PROFILES[THROTTLE_LOW])
+
+        assert applied == []
@@ -0,0 +1,243 @@
+"""
+Unit tests for per-file license classification.
+
+Tests SPDX identifiers, license header recognition, inheritance from LICENSE
+files, configuration, and payload recording by FileChunkingManager.
+"""
+
+import tempfile
+import threading
+from concurrent.futures import Future
+from pathlib import Path
+from typing import Dict, List
+from unittest.mock import Mock
+
+from code_indexer.config import Config
+from code_indexer.services.clean_slot_tracker import CleanSlotTracker
+from code_indexer.services.file_chunking_manager import FileChunkingManager
+from code_indexer.services.license_detection import (
+    LICENSE_KEY,
+    LICENSE_SOURCE_KEY,
+    LicenseDetector,
+    canonical_license_id,
+    detect_license_text,
+)
+from code_indexer.services.vector_calculation_manager import VectorResult
+
+APACHE_HEADER = """# Copyright 2024 Example Corp
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+
+import os
+"""
+
+MIT_LICENSE = """MIT License
+
+Copyright (c) 2024 Example
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+"""
+
+GPL_HEADER = """/*
+ * This program is free software; you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation; either version 2 of the License, or
+ * (at your option) any later version.
+ */
+#include <stdio.h>
+"""
+
+BSD3_HEADER = """// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products.
+package main
+"""
+
+
+class TestDetectLicenseText:
+    """Tests for recognizing license declarations."""
+
+    def test_spdx_identifiers(self):
+        assert (
+            detect_license_text("// SPDX-License-Identifier: MIT\npackage x\n")
+            == "MIT"
+        )
+        assert (
+            detect_license_text("/* SPDX-License-Identifier: GPL-2.0+ */\nint x;\n")
+            == "GPL-2.0-or-later"
+        )
+        assert (
+            detect_license_text(
+                "# SPDX-License-Identifier: mit or apache-2.0\nimport os\n"
+            )
+            == "MIT OR Apache-2.0"
+        )
+
+    def test_canonical_ids(self):
+        assert canonical_license_id("apache-2.0") == "Apache-2.0"
+        assert canonical_license_id("GPL-3.0") == "GPL-3.0-only"
+        assert canonical_license_id("LicenseRef-Acme") == "LicenseRef-Acme"
+
+    def test_header_texts(self):
+        assert detect_license_text(APACHE_HEADER) == "Apache-2.0"
+        assert detect_license_text(GPL_HEADER) == "GPL-2.0-or-later"
+        assert detect_license_text(BSD3_HEADER) == "BSD-3-Clause"
+
+    def test_license_phrases_in_code_are_ignored(self):
+        text = (
+            "import os\n"
+            "\n"
+            'NOTICE = "Licensed under the Apache License, Version 2.0"\n'
+            "# SPDX-License-Identifier: MIT\n"
+        )
+
+        assert detect_license_text(text) is None
+
+    def test_license_file_text(self):
+        assert detect_license_text(MIT_LICENSE) is None
+        assert detect_license_text(MIT_LICENSE, header_only=False) == "MIT"
+
+
+class TestLicenseDetector:
+    """Tests for classifying files of a project."""
+
+    def setup_project(self, root: Path) -> None:
+        (root / "LICENSE").write_text("Apache License, Version 2.0, January 2004\n")
+        (root / "src").mkdir()
+        (root / "vendor" / "lib").mkdir(parents=True)
+        (root / "vendor" / "lib" / "COPYING").write_text(MIT_LICENSE)
+
+    def test_header_wins_over_license_file(self, tmp_path):
+        self.setup_project(tmp_path)
+
+        fields = LicenseDetector(tmp_path).classify(
+            tmp_path / "src" / "gpl.c", GPL_HEADER
+        )
+
+        assert fields == {
+            LICENSE_KEY: "GPL-2.0-or-later",
+            LICENSE_SOURCE_KEY: "header",
+        }
+
+    def test_nearest_license_file(self, tmp_path):
+        self.setup_project(tmp_path)
+        detector = LicenseDetector(tmp_path)
+
+        own = detector.classify(tmp_path / "src" / "app.py", "import os\n")
+        vendored = detector.classify(
+            tmp_path / "vendor" / "lib" / "util.go", "package lib\n"
+        )
+
+        assert own == {LICENSE_KEY: "Apache-2.0", LICENSE_SOURCE_KEY: "license_file"}
+        assert vendored[LICENSE_KEY] == "MIT"
+
+    def test_license_files_disabled(self, tmp_path):
+        self.setup_project(tmp_path)
+        detector = LicenseDetector(tmp_path, use_license_files=False)
+
+        assert detector.classify(tmp_path / "src" / "app.py", "import os\n") == {}
+
+    def test_classify_chunks(self, tmp_path):
+        chunks = [{"text": APACHE_HEADER}, {"text": "def main(): pass\n"}]
+
+        classified = LicenseDetector(tmp_path).classify_chunks(
+            chunks, tmp_path / "a.py"
+        )
+
+        assert [c[LICENSE_KEY] for c in classified] == ["Apache-2.0", "Apache-2.0"]
+        assert LICENSE_KEY not in chunks[0]
+
+
+class TestLicenseDetectionConfig:
+    """Tests for indexing.license_detection."""
+
+    def test_enabled_by_default(self, tmp_path):
+        detector = LicenseDetector.from_config(Config(codebase_dir=tmp_path))
+
+        assert detector.use_license_files is True
+
+    def test_disabled(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        config.indexing.license_detection.enabled = False
+
+        assert LicenseDetector.from_config(config) is None
+
+
+class RecordingVectorManager:
+    """Vector manager mock returning fixed embeddings."""
+
+    def __init__(self):
+        self.cancellation_event = threading.Event()
+        self.embedding_provider = Mock()
+        self.embedding_provider.get_current_model.return_value = "voyage-code-3"
+        self.embedding_provider._get_model_token_limit.return_value = 120000
+
+    def submit_batch_task(self, chunk_texts: List[str], metadata: Dict):
+        future = Future()
+        future.set_result(
+            VectorResult(
+                task_id="batch",
+                embeddings=tuple((0.5,) * 8 for _ in chunk_texts),
+                metadata=metadata.copy(),
+                processing_time=0.0,
+                error=None,
+            )
+        )
+        return future
+
+
+class TestFileChunkingManagerRecording:
+    """Tests for recording licenses in point payloads."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.file_path = self.root / "main.py"
+        self.file_path.write_text(APACHE_HEADER)
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def test_license_is_stored_in_payload(self):
+        vector_store = Mock()
+        vector_store.upsert_points.return_value = True
+        chunker = Mock()
+        chunker.chunk_file.return_value = [
+            {
+                "text": APACHE_HEADER,
+                "chunk_index": 0,
+                "total_chunks": 1,
+                "file_extension": "py",
+                "line_start": 1,
+                "line_end": 6,
+            }
+        ]
+        manager = FileChunkingManager(
+            vector_manager=RecordingVectorManager(),
+            chunker=chunker,
+            vector_store_client=vector_store,
+            thread_count=1,
+            slot_tracker=CleanSlotTracker(max_slots=3),
+            codebase_dir=self.root,
+            license_detector=LicenseDetector(self.root),
+        )
+        metadata = {
+            "project_id": "test_project",
+            "file_hash": "sha256:aaa",
+            "git_available": False,
+            "collection_name": "test_collection",
+        }
+
+        with manager:
+            result = manager.submit_file_for_processing(
+                self.file_path, metadata, None
+            ).result(timeout=10.0)
+
+        assert result.success
+        payload = vector_store.upsert_points.call_args.kwargs["points"][0]["payload"]
+        assert payload[LICENSE_KEY] == "Apache-2.0"
+        assert payload[LICENSE_SOURCE_KEY] == "header"
@@ -0,0 +1,252 @@
+"""
+Unit tests for lifecycle hooks.
+
+Tests plugin and command loading, the event contract of each hook point,
+error handling, and hooks running inside FileChunkingManager.
+"""
+
+import sys
+import tempfile
+import threading
+from concurrent.futures import Future
+from pathlib import Path
+from types import SimpleNamespace
+from typing import Dict, List
+from unittest.mock import Mock
+
+import pytest
+
+from code_indexer.services.clean_slot_tracker import CleanSlotTracker
+from code_indexer.services.file_chunking_manager import FileChunkingManager
+from code_indexer.services.lifecycle_hooks import (
+    CUSTOM_METADATA_KEY,
+    HookError,
+    LifecycleHooks,
+    load_plugin,
+)
+from code_indexer.services.vector_calculation_manager import VectorResult
+
+PLUGIN = '''
+def veto_generated(event):
+    if event["path"].endswith("_pb2.py"):
+        return {"index": False}
+
+
+def redact(event):
+    chunks = []
+    for chunk in event["chunks"]:
+        text = chunk["text"].replace("ACME-SECRET", "[REDACTED]")
+        chunks.append({**chunk, "text": text, "custom_metadata": {"team": "pay"}})
+    return {"chunks": chunks}
+
+
+def prefix_path(event):
+    return {"texts": [event["path"] + ": " + t for t in event["texts"]]}
+
+
+def top_first(event):
+    return {"results": sorted(event["results"], key=lambda r: -r["score"])[:1]}
+
+
+def broken(event):
+    raise RuntimeError("boom")
+'''
+
+
+def hook(**fields):
+    return SimpleNamespace(
+        command=fields.get("command"),
+        plugin=fields.get("plugin"),
+        timeout_seconds=fields.get("timeout_seconds", 30),
+        on_error=fields.get("on_error", "fail"),
+    )
+
+
+def make_config(root: Path, **points) -> SimpleNamespace:
+    hooks = SimpleNamespace(pre_index=[], post_chunk=[], pre_embed=[], post_query=[])
+    for point, entries in points.items():
+        setattr(hooks, point, entries)
+    return SimpleNamespace(codebase_dir=root, hooks=hooks)
+
+
+@pytest.fixture
+def root(tmp_path):
+    (tmp_path / "hooks.py").write_text(PLUGIN)
+    return tmp_path
+
+
+class TestLoading:
+    """Tests for loading hooks from configuration."""
+
+    def test_no_hooks(self, root):
+        assert LifecycleHooks.from_config(make_config(root)) is None
+
+    def test_only_requested_points_are_loaded(self, root):
+        config = make_config(
+            root, post_query=[hook(plugin="hooks.py:top_first")]
+        )
+
+        assert LifecycleHooks.from_config(config, points=("pre_index",)) is None
+        assert LifecycleHooks.from_config(config).has("post_query")
+
+    def test_command_or_plugin_required(self, root):
+        config = make_config(root, pre_index=[hook()])
+
+        with pytest.raises(ValueError, match="exactly one"):
+            LifecycleHooks.from_config(config)
+
+    def test_bad_plugin_spec(self, root):
+        with pytest.raises(ValueError, match="module:function"):
+            load_plugin("hooks.py", root)
+        with pytest.raises(ValueError, match="not callable"):
+            load_plugin("hooks.py:missing", root)
+
+
+class TestHookPoints:
+    """Tests for the event contract of each hook point."""
+
+    def test_pre_index_veto(self, root):
+        hooks = LifecycleHooks.from_config(
+            make_config(root, pre_index=[hook(plugin="hooks.py:veto_generated")])
+        )
+
+        assert hooks.allows_file("src/api.py")
+        assert not hooks.allows_file("src/api_pb2.py")
+
+    def test_post_query(self, root):
+        hooks = LifecycleHooks.from_config(
+            make_config(root, post_query=[hook(plugin="hooks.py:top_first")])
+        )
+        results = [
+            {"score": 0.5, "payload": {"path": "a.py"}},
+            {"score": 0.9, "payload": {"path": "b.py"}},
+        ]
+
+        assert hooks.process_results("retry", results) == [results[1]]
+
+    def test_command_hook(self, root):
+        script = (
+            "import json, sys; event = json.load(sys.stdin); "
+            "print(json.dumps({'texts': [t.upper() for t in event['texts']]}))"
+        )
+        (root / "upper.py").write_text(script)
+        hooks = LifecycleHooks.from_config(
+            make_config(root, pre_embed=[hook(command=f"{sys.executable} upper.py")])
+        )
+
+        assert hooks.embedding_texts(["def f():"], "a.py") == ["DEF F():"]
+
+    def test_pre_embed_must_keep_length(self, root):
+        (root / "drop.py").write_text("print('{\"texts\": []}')")
+        hooks = LifecycleHooks.from_config(
+            make_config(root, pre_embed=[hook(command=f"{sys.executable} drop.py")])
+        )
+
+        with pytest.raises(HookError, match="must return 1 texts"):
+            hooks.embedding_texts(["x"], "a.py")
+
+    def test_failing_hook(self, root):
+        config = make_config(root, pre_index=[hook(plugin="hooks.py:broken")])
+
+        with pytest.raises(HookError, match="boom"):
+            LifecycleHooks.from_config(config).allows_file("a.py")
+
+    def test_ignored_failure(self, root):
+        config = make_config(
+            root,
+            pre_index=[
+                hook(plugin="hooks.py:broken", on_error="ignore"),
+                hook(plugin="hooks.py:veto_generated"),
+            ],
+        )
+
+        hooks = LifecycleHooks.from_config(config)
+
+        assert hooks.allows_file("a.py")
+        assert not hooks.allows_file("a_pb2.py")
+
+
+class RecordingVectorManager:
+    """Vector manager mock recording the texts it embeds."""
+
+    def __init__(self):
+        self.cancellation_event = threading.Event()
+        self.embedding_provider = Mock()
+        self.embedding_provider.get_current_model.return_value = "voyage-code-3"
+        self.embedding_provider._get_model_token_limit.return_value = 120000
+        self.texts: List[str] = []
+
+    def submit_batch_task(self, chunk_texts: List[str], metadata: Dict):
+        self.texts.extend(chunk_texts)
+        future = Future()
+        future.set_result(
+            VectorResult(
+                task_id="batch",
+                embeddings=tuple((0.5,) * 8 for _ in chunk_texts),
+                metadata=metadata.copy(),
+                processing_time=0.0,
+                error=None,
+            )
+        )
+        return future
+
+
+class TestFileChunkingManagerHooks:
+    """Tests for post_chunk and pre_embed hooks while indexing."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        (self.root / "hooks.py").write_text(PLUGIN)
+        self.file_path = self.root / "pay.py"
+        self.file_path.write_text("KEY = 'ACME-SECRET'\n")
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def test_chunks_are_redacted_annotated_and_embedded_with_prefix(self):
+        config = make_config(
+            self.root,
+            post_chunk=[hook(plugin="hooks.py:redact")],
+            pre_embed=[hook(plugin="hooks.py:prefix_path")],
+        )
+        vector_manager = RecordingVectorManager()
+        vector_store = Mock()
+        vector_store.upsert_points.return_value = True
+        chunker = Mock()
+        chunker.chunk_file.return_value = [
+            {
+                "text": "KEY = 'ACME-SECRET'",
+                "chunk_index": 0,
+                "total_chunks": 1,
+                "file_extension": "py",
+                "line_start": 1,
+                "line_end": 1,
+            }
+        ]
+        manager = FileChunkingManager(
+            vector_manager=vector_manager,
+            chunker=chunker,
+            vector_store_client=vector_store,
+            thread_count=1,
+            slot_tracker=CleanSlotTracker(max_slots=3),
+            codebase_dir=self.root,
+            lifecycle_hooks=LifecycleHooks.from_config(config),
+        )
+        metadata = {
+            "project_id": "test_project",
+            "file_hash": "sha256:aaa",
+            "git_available": False,
+            "collection_name": "test_collection",
+        }
+
+        with manager:
+            result = manager.submit_file_for_processing(
+                self.file_path, metadata, None
+            ).result(timeout=10.0)
+
+        assert result.success
+        assert vector_manager.texts == ["pay.py: KEY = '[REDACTED]'"]
+        payload = vector_store.upsert_points.call_args.kwargs["points"][0]["payload"]
+        assert payload["content"] == "KEY = '[REDACTED]'"
+        assert payload[CUSTOM_METADATA_KEY] == {"team": "pay"}
@@ -0,0 +1,76 @@
+"""
+Unit tests for highlighting the region of a chunk matching a query.
+
+Tests splitting queries into words, finding the best-matching window of
+lines and its character ranges, and annotating search results.
+"""
+
+from code_indexer.services.match_highlighting import (
+    HIGHLIGHT_KEY,
+    annotate_highlights,
+    matched_region,
+    query_terms,
+)
+
+CHUNK = """def charge(card):
+    amount = compute(card)
+    for attempt in range(3):
+        try:
+            return gateway.charge(card, amount)
+        except Timeout:
+            time.sleep(backoff(attempt))
+    raise PaymentRetriesExhausted()"""
+
+
+class TestQueryTerms:
+    """Tests for query_terms."""
+
+    def test_splits_identifiers_and_drops_stop_words(self):
+        assert query_terms("how does retryPayment work with the HTTP_client") == [
+            "retry",
+            "payment",
+            "http",
+            "client",
+        ]
+
+
+class TestMatchedRegion:
+    """Tests for matched_region."""
+
+    def test_window_covering_most_terms(self):
+        region = matched_region(CHUNK, "retry payment with backoff", first_line=10)
+
+        assert (region["line_start"], region["line_end"]) == (16, 17)
+        assert region["terms"] == ["retry", "payment", "backoff"]
+        assert CHUNK[region["char_start"] : region["char_end"]] == (
+            "            time.sleep(backoff(attempt))\n"
+            "    raise PaymentRetriesExhausted()"
+        )
+        assert [CHUNK[start:end] for start, end in region["matches"]] == [
+            "backoff",
+            "PaymentRetriesExhausted",
+        ]
+
+    def test_shortest_then_earliest_window_wins_ties(self):
+        region = matched_region(CHUNK, "charging cards")
+
+        assert (region["line_start"], region["line_end"]) == (1, 1)
+
+    def test_no_shared_words(self):
+        assert matched_region(CHUNK, "parse configuration files") is None
+        assert matched_region(CHUNK, "how does the") is None
+
+
+class TestAnnotateHighlights:
+    """Tests for annotate_highlights."""
+
+    def test_annotates_results_with_a_region(self):
+        results = [
+            {"payload": {"content": CHUNK, "line_start": 5}},
+            {"payload": {"content": "x = 1", "line_start": 1}},
+        ]
+
+        annotate_highlights(results, "backoff")
+
+        assert results[0][HIGHLIGHT_KEY]["line_start"] == 11
+        assert HIGHLIGHT_KEY not in results[1]
@@ -0,0 +1,224 @@
+"""
+Unit tests for bounded-memory indexing (indexing.max_memory_mb).
+
+Tests MemoryBudget accounting and spill files, and FileChunkingManager's
+pipelined mode spilling points that do not fit in the budget to disk until
+the upsert stage writes them.
+"""
+
+# mypy: ignore-errors
+
+import tempfile
+import threading
+from concurrent.futures import Future
+from pathlib import Path
+from typing import Dict, List
+from unittest.mock import Mock
+
+import pytest
+
+from src.code_indexer.config import Config
+from src.code_indexer.services.clean_slot_tracker import CleanSlotTracker
+from src.code_indexer.services.file_chunking_manager import FileChunkingManager
+from src.code_indexer.services.memory_budget import (
+    BYTES_PER_MB,
+    BYTES_PER_VECTOR_DIMENSION,
+    POINT_OVERHEAD_BYTES,
+    TYPICAL_FILE_POINTS_BYTES,
+    MemoryBudget,
+    estimate_points_bytes,
+)
+from src.code_indexer.services.vector_calculation_manager import VectorResult
+
+
+class ImmediateVectorManager:
+    """Vector manager mock returning already-completed batch futures."""
+
+    def __init__(self):
+        self.cancellation_event = threading.Event()
+        self.embedding_provider = Mock()
+        self.embedding_provider.get_current_model.return_value = "voyage-code-3"
+        self.embedding_provider._get_model_token_limit.return_value = 120000
+
+    def submit_batch_task(self, chunk_texts: List[str], metadata: Dict):
+        future = Future()
+        future.set_result(
+            VectorResult(
+                task_id="batch",
+                embeddings=tuple((0.5,) * 8 for _ in chunk_texts),
+                metadata=metadata.copy(),
+                processing_time=0.0,
+                error=None,
+            )
+        )
+        return future
+
+
+class SingleChunkChunker:
+    """Chunker mock producing one chunk per file."""
+
+    def chunk_file(self, file_path: Path) -> List[Dict]:
+        return [
+            {
+                "text": file_path.read_text(),
+                "chunk_index": 0,
+                "total_chunks": 1,
+                "file_extension": file_path.suffix.lstrip("."),
+                "line_start": 1,
+                "line_end": 1,
+            }
+        ]
+
+
+class RecordingVectorStore:
+    """Vector store mock recording upserted points."""
+
+    def __init__(self):
+        self.points: List[Dict] = []
+        self._lock = threading.Lock()
+
+    def upsert_points(self, points, collection_name=None) -> bool:
+        with self._lock:
+            self.points.extend(points)
+        return True
+
+
+class TestMemoryBudget:
+    """Tests for MemoryBudget accounting and spill files."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.spill_dir = Path(self.temp_dir.name) / "index_spill"
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def test_rejects_non_positive_limit(self):
+        with pytest.raises(ValueError):
+            MemoryBudget(0, self.spill_dir)
+
+    def test_estimate_counts_text_and_vector(self):
+        points = [{"text": "abcd", "vector": [0.0] * 10, "metadata": {}}]
+
+        assert estimate_points_bytes(points) == (
+            POINT_OVERHEAD_BYTES + 4 + 10 * BYTES_PER_VECTOR_DIMENSION
+        )
+
+    def test_queue_shrinks_to_fit_budget(self):
+        budget = MemoryBudget(64, self.spill_dir)  # 16 MB for points
+
+        assert budget.queue_size_for(16) == 16 * BYTES_PER_MB // (
+            TYPICAL_FILE_POINTS_BYTES
+        )
+        assert budget.queue_size_for(2) == 2
+        assert budget.queue_size_for(0) == 0
+        assert MemoryBudget(1, self.spill_dir).queue_size_for(16) == 1
+
+    def test_reservations_stop_at_budget(self):
+        budget = MemoryBudget(1, self.spill_dir)
+        half = budget.points_budget_bytes // 2
+
+        assert budget.try_reserve(half)
+        assert budget.try_reserve(half)
+        assert not budget.try_reserve(half)
+
+        budget.release(half)
+        assert budget.try_reserve(half)
+        assert budget.get_stats()["max_held_bytes"] == 2 * half
+
+    def test_spill_round_trip_removes_file(self):
+        budget = MemoryBudget(1, self.spill_dir)
+        points = [{"text": "x", "vector": [0.25, 0.5], "metadata": {"a": None}}]
+
+        spill_path = budget.spill(points)
+
+        assert spill_path.parent == self.spill_dir
+        assert budget.load_spilled(spill_path) == points
+        assert not spill_path.exists()
+        assert budget.get_stats()["batches_spilled"] == 1
+
+    def test_cleanup_removes_leftover_spill_files(self):
+        budget = MemoryBudget(1, self.spill_dir)
+        budget.spill([{"text": "x", "vector": [], "metadata": {}}])
+
+        budget.cleanup()
+
+        assert not self.spill_dir.exists()
+
+
+class TestFileChunkingManagerSpilling:
+    """Tests for spilling queued points in the pipelined upsert mode."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.files = []
+        for i in range(4):
+            file_path = self.root / f"module_{i}.py"
+            file_path.write_text(f"def function_{i}():\n    return {i}\n")
+            self.files.append(file_path)
+        self.vector_store = RecordingVectorStore()
+        self.budget = MemoryBudget(1, self.root / ".code-indexer" / "index_spill")
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def _run(self):
+        manager = FileChunkingManager(
+            vector_manager=ImmediateVectorManager(),
+            chunker=SingleChunkChunker(),
+            vector_store_client=self.vector_store,
+            thread_count=2,
+            slot_tracker=CleanSlotTracker(max_slots=4),
+            codebase_dir=self.root,
+            upsert_queue_size=2,
+            memory_budget=self.budget,
+        )
+        with manager:
+            futures = [
+                manager.submit_file_for_processing(
+                    f,
+                    {
+                        "project_id": "test_project",
+                        "file_hash": f.name,
+                        "git_available": False,
+                        "collection_name": "test_collection",
+                    },
+                    None,
+                )
+                for f in self.files
+            ]
+            return [f.result(timeout=10.0) for f in futures]
+
+    def test_points_within_budget_stay_in_memory(self):
+        results = self._run()
+
+        assert all(r.success for r in results)
+        stats = self.budget.get_stats()
+        assert stats["batches_spilled"] == 0
+        assert stats["held_bytes"] == 0  # Every reservation released
+        assert len(self.vector_store.points) == len(self.files)
+
+    def test_points_over_budget_are_spilled_and_written(self):
+        self.budget.points_budget_bytes = 0
+
+        results = self._run()
+
+        assert all(r.success and r.chunks_processed == 1 for r in results)
+        assert self.budget.get_stats()["batches_spilled"] == len(self.files)
+        assert sorted(p["payload"]["path"] for p in self.vector_store.points) == [
+            f.name for f in self.files
+        ]
+        assert self.vector_store.points[0]["vector"] == [0.5] * 8
+        assert not self.budget.spill_dir.exists()
+
+
+class TestMaxMemoryConfig:
+    """Tests for the indexing.max_memory_mb setting."""
+
+    def test_unlimited_by_default(self):
+        assert Config().indexing.max_memory_mb is None
+
+    def test_rejects_tiny_limit(self):
+        with pytest.raises(ValueError):
+            Config(indexing={"max_memory_mb": 16})
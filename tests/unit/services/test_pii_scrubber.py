@@ -0,0 +1,258 @@
+"""
+Unit tests for masking PII in chunk text before embedding.
+
+Tests built-in and custom patterns, language-aware string literal scrubbing,
+configuration and FileChunkingManager integration.
+"""
+
+# mypy: ignore-errors
+
+import tempfile
+import threading
+from concurrent.futures import Future
+from pathlib import Path
+from typing import Dict, List
+from unittest.mock import Mock
+
+import pytest
+
+from src.code_indexer.config import Config, PiiScrubbingConfig
+from src.code_indexer.services.clean_slot_tracker import CleanSlotTracker
+from src.code_indexer.services.file_chunking_manager import FileChunkingManager
+from src.code_indexer.services.pii_scrubber import PiiScrubber
+from src.code_indexer.services.vector_calculation_manager import VectorResult
+
+
+class TestBuiltinPatterns:
+    """Tests for the built-in PII patterns."""
+
+    def setup_method(self):
+        self.scrubber = PiiScrubber()
+
+    def test_masks_email(self):
+        assert (
+            self.scrubber.scrub("contact: jane.doe+test@example.co.uk")
+            == "contact: <EMAIL>"
+        )
+
+    def test_masks_phone_numbers(self):
+        text = "call (555) 123-4567 or +1 555.123.4567"
+
+        assert self.scrubber.scrub(text) == "call <PHONE> or <PHONE>"
+
+    def test_plain_numbers_are_kept(self):
+        text = "timeout = 5551234567\nversion = 1.2.3"
+
+        assert self.scrubber.scrub(text) == text
+
+    def test_masks_ssn(self):
+        assert self.scrubber.scrub("ssn=123-45-6789") == "ssn=<SSN>"
+
+    def test_credit_card_requires_valid_checksum(self):
+        text = "valid 4111 1111 1111 1111 invalid 4111 1111 1111 1112"
+
+        assert self.scrubber.scrub(text) == (
+            "valid <CREDIT_CARD> invalid 4111 1111 1111 1112"
+        )
+
+    def test_selected_patterns_only(self):
+        scrubber = PiiScrubber(builtin_patterns=["ssn"])
+
+        assert scrubber.scrub("a@b.io 123-45-6789") == "a@b.io <SSN>"
+
+    def test_custom_patterns(self):
+        scrubber = PiiScrubber(
+            builtin_patterns=[], custom_patterns={"customer_id": r"CUST-\d{8}"}
+        )
+
+        assert scrubber.scrub("id = CUST-00012345") == "id = <CUSTOMER_ID>"
+
+    def test_invalid_patterns_are_rejected(self):
+        with pytest.raises(ValueError):
+            PiiScrubber(builtin_patterns=["passport"])
+        with pytest.raises(ValueError):
+            PiiScrubber(custom_patterns={"broken": "("})
+
+
+class TestStringLiteralsOnly:
+    """Tests for language-aware scrubbing of string literals."""
+
+    def setup_method(self):
+        self.scrubber = PiiScrubber(string_literals_only=True)
+
+    def test_only_literals_are_masked_in_source(self):
+        text = (
+            "# maintainer: ops@example.com\n"
+            'CUSTOMER = {"email": "jane@example.com"}\n'
+            "x = 1\n"
+        )
+
+        assert self.scrubber.scrub(text, Path("fixtures.py")) == (
+            "# maintainer: ops@example.com\n"
+            'CUSTOMER = {"email": "<EMAIL>"}\n'
+            "x = 1\n"
+        )
+
+    def test_multiline_literals_by_language(self):
+        python = 'x = 1\nDATA = """\nname,email\nJane,jane@example.com\n"""\ny = 2'
+        javascript = "x = 1\nconst csv = `\njane@example.com\n`;\ny = 2"
+
+        assert "<EMAIL>" in self.scrubber.scrub(python, Path("seed.py"))
+        assert "<EMAIL>" in self.scrubber.scrub(javascript, Path("seed.ts"))
+
+    def test_literal_cut_by_chunk_boundary_scrubs_whole_chunk(self):
+        text = 'jane@example.com",\n    "role": "admin"\n}\nx = 1'
+
+        assert self.scrubber.scrub(text, Path("fixtures.py")).startswith("<EMAIL>")
+
+    def test_data_files_are_scrubbed_entirely(self):
+        text = "name,email\nJane,jane@example.com\n"
+
+        assert self.scrubber.scrub(text, Path("customers.csv")) == (
+            "name,email\nJane,<EMAIL>\n"
+        )
+
+
+class TestPiiScrubbingConfig:
+    """Tests for indexing.pii_scrubbing."""
+
+    def test_disabled_by_default(self):
+        assert PiiScrubber.from_config(Config()) is None
+
+    def test_from_config(self):
+        config = Config()
+        config.indexing.pii_scrubbing = PiiScrubbingConfig(
+            enabled=True, builtin_patterns=["email"], string_literals_only=True
+        )
+
+        scrubber = PiiScrubber.from_config(config)
+
+        assert scrubber.string_literals_only
+        assert scrubber.scrub("a@b.io 123-45-6789") == "<EMAIL> 123-45-6789"
+
+    def test_invalid_custom_pattern_rejected(self):
+        with pytest.raises(ValueError):
+            PiiScrubbingConfig(custom_patterns={"broken": "("})
+
+
+class RecordingVectorManager:
+    """Vector manager mock recording the texts it is asked to embed."""
+
+    def __init__(self):
+        self.cancellation_event = threading.Event()
+        self.embedding_provider = Mock()
+        self.embedding_provider.get_current_model.return_value = "voyage-code-3"
+        self.embedding_provider._get_model_token_limit.return_value = 120000
+        self.embedded_texts: List[str] = []
+
+    def submit_batch_task(self, chunk_texts: List[str], metadata: Dict):
+        self.embedded_texts.extend(chunk_texts)
+        future = Future()
+        future.set_result(
+            VectorResult(
+                task_id="batch",
+                embeddings=tuple((0.5,) * 8 for _ in chunk_texts),
+                metadata=metadata.copy(),
+                processing_time=0.0,
+                error=None,
+            )
+        )
+        return future
+
+
+class TestFileChunkingManagerScrubbing:
+    """Tests for scrubbing chunks before they are embedded and stored."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.file_path = self.root / "customers.json"
+        self.file_path.write_text('{"email": "jane@example.com"}\n')
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def test_embedded_and_stored_text_is_scrubbed(self):
+        vector_manager = RecordingVectorManager()
+        vector_store = Mock()
+        vector_store.upsert_points.return_value = True
+        chunker = Mock()
+        chunker.chunk_file.return_value = [
+            {
+                "text": self.file_path.read_text(),
+                "chunk_index": 0,
+                "total_chunks": 1,
+                "file_extension": "json",
+                "line_start": 1,
+                "line_end": 1,
+            }
+        ]
+        manager = FileChunkingManager(
+            vector_manager=vector_manager,
+            chunker=chunker,
+            vector_store_client=vector_store,
+            thread_count=1,
+            slot_tracker=CleanSlotTracker(max_slots=3),
+            codebase_dir=self.root,
+            pii_scrubber=PiiScrubber(),
+        )
+        metadata = {
+            "project_id": "test_project",
+            "file_hash": "sha256:aaa",
+            "git_available": False,
+            "collection_name": "test_collection",
+        }
+
+        with manager:
+            result = manager.submit_file_for_processing(
+                self.file_path, metadata, None
+            ).result(timeout=10.0)
+
+        assert result.success
+        assert vector_manager.embedded_texts == ['{"email": "<EMAIL>"}\n']
+        points = vector_store.upsert_points.call_args.kwargs["points"]
+        assert "jane@example.com" not in str(points)
+
+    def test_heading_paths_are_scrubbed_and_embedded(self):
+        vector_manager = RecordingVectorManager()
+        vector_store = Mock()
+        vector_store.upsert_points.return_value = True
+        chunker = Mock()
+        chunker.chunk_file.return_value = [
+            {
+                "text": "## Contact jane@example.com\n\nAsk Jane.\n",
+                "chunk_index": 0,
+                "total_chunks": 1,
+                "file_extension": "md",
+                "line_start": 1,
+                "line_end": 3,
+                "heading_path": ["Support", "Contact jane@example.com"],
+            }
+        ]
+        manager = FileChunkingManager(
+            vector_manager=vector_manager,
+            chunker=chunker,
+            vector_store_client=vector_store,
+            thread_count=1,
+            slot_tracker=CleanSlotTracker(max_slots=3),
+            codebase_dir=self.root,
+            pii_scrubber=PiiScrubber(),
+        )
+        metadata = {
+            "project_id": "test_project",
+            "file_hash": "sha256:aaa",
+            "git_available": False,
+            "collection_name": "test_collection",
+        }
+
+        with manager:
+            result = manager.submit_file_for_processing(
+                self.file_path, metadata, None
+            ).result(timeout=10.0)
+
+        assert result.success
+        assert vector_manager.embedded_texts == [
+            "Support > Contact <EMAIL>\n\n## Contact <EMAIL>\n\nAsk Jane.\n"
+        ]
+        points = vector_store.upsert_points.call_args.kwargs["points"]
+        assert points[0]["payload"]["heading_path"] == ["Support", "Contact <EMAIL>"]
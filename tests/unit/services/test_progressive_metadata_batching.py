@@ -0,0 +1,125 @@
+"""
+Unit tests for batched, journaled ProgressiveMetadata writes.
+
+Per-file updates inside batched_writes() must not rewrite metadata.json per
+file, must survive a crash via the journal, and must never be applied twice.
+"""
+
+import json
+import tempfile
+from pathlib import Path
+from unittest.mock import patch
+
+import pytest
+
+from code_indexer.services.progressive_metadata import ProgressiveMetadata
+
+
+class TestBatchedWrites:
+    """Tests for ProgressiveMetadata.batched_writes()."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.metadata_path = Path(self.temp_dir.name) / "metadata.json"
+        self.metadata = ProgressiveMetadata(self.metadata_path)
+        self.metadata.set_files_to_index([f"f{i}.py" for i in range(10)])
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def _on_disk(self):
+        return json.loads(self.metadata_path.read_text())
+
+    def test_unbatched_update_writes_immediately(self):
+        self.metadata.mark_file_completed("f0.py", 3)
+
+        assert self._on_disk()["completed_files"] == ["f0.py"]
+
+    def test_batch_writes_metadata_once_on_exit(self):
+        with patch.object(
+            self.metadata, "_write_metadata", wraps=self.metadata._write_metadata
+        ) as mock_write:
+            with self.metadata.batched_writes(flush_interval_seconds=3600):
+                for i in range(10):
+                    self.metadata.mark_file_completed(f"f{i}.py", 2)
+                assert mock_write.call_count == 0
+
+        assert mock_write.call_count == 1
+        on_disk = self._on_disk()
+        assert len(on_disk["completed_files"]) == 10
+        assert on_disk["chunks_indexed"] == 20
+        assert not self.metadata.journal_path.exists()
+
+    def test_periodic_flush_appends_to_journal(self):
+        with self.metadata.batched_writes(
+            flush_interval_seconds=3600, flush_every_updates=4
+        ):
+            for i in range(5):
+                self.metadata.mark_file_completed(f"f{i}.py", 1)
+
+            journal = self.metadata.journal_path.read_text().splitlines()
+            assert [json.loads(line)["file"] for line in journal] == [
+                "f0.py",
+                "f1.py",
+                "f2.py",
+                "f3.py",
+            ]
+            assert self._on_disk()["completed_files"] == []
+
+    def test_crash_recovers_flushed_updates_from_journal(self):
+        batch = self.metadata.batched_writes(
+            flush_interval_seconds=3600, flush_every_updates=3
+        )
+        batch.__enter__()
+        for i in range(2):
+            self.metadata.mark_file_completed(f"f{i}.py", 1)
+        self.metadata.mark_file_failed("f2.py")
+        self.metadata.mark_file_completed("f3.py", 1)
+        # Process dies here: no batch exit, f3 never flushed
+
+        recovered = ProgressiveMetadata(self.metadata_path)
+
+        assert recovered.metadata["completed_files"] == ["f0.py", "f1.py"]
+        assert recovered.metadata["failed_file_paths"] == ["f2.py"]
+        assert recovered.metadata["current_file_index"] == 3
+        assert recovered.get_remaining_files()[0] == "f3.py"
+
+    def test_torn_journal_line_is_ignored(self):
+        with self.metadata.batched_writes(flush_every_updates=1):
+            self.metadata.mark_file_completed("f0.py", 1)
+            with open(self.metadata.journal_path, "a") as f:
+                f.write('{"seq": 99, "op": "compl')
+
+            recovered = ProgressiveMetadata(self.metadata_path)
+
+        assert recovered.metadata["completed_files"] == ["f0.py"]
+
+    def test_journal_left_after_full_write_is_not_reapplied(self):
+        with self.metadata.batched_writes(flush_every_updates=1):
+            self.metadata.mark_file_completed("f0.py", 5)
+            stale_journal = self.metadata.journal_path.read_text()
+
+        # Crash between metadata.json replace and journal unlink
+        self.metadata.journal_path.write_text(stale_journal)
+        recovered = ProgressiveMetadata(self.metadata_path)
+
+        assert recovered.metadata["chunks_indexed"] == 5
+        assert recovered.metadata["current_file_index"] == 1
+
+    def test_full_write_inside_batch_is_deferred_and_compacts(self):
+        with self.metadata.batched_writes(flush_interval_seconds=3600):
+            self.metadata.mark_file_completed("f0.py", 1)
+            self.metadata.update_commit_watermark("main", "abc123")
+            assert "main" not in self._on_disk()["branch_commit_watermarks"]
+
+        on_disk = self._on_disk()
+        assert on_disk["branch_commit_watermarks"] == {"main": "abc123"}
+        assert on_disk["completed_files"] == ["f0.py"]
+
+    def test_batch_exit_flushes_on_exception(self):
+        with pytest.raises(RuntimeError):
+            with self.metadata.batched_writes(flush_interval_seconds=3600):
+                self.metadata.update_progress(files_processed=1, chunks_added=4)
+                raise RuntimeError("indexing failed")
+
+        assert self._on_disk()["chunks_indexed"] == 4
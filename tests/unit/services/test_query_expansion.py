@@ -0,0 +1,109 @@
+"""
+Unit tests for HyDE query expansion.
+
+Tests drafting hypothetical code with an LLM command, configuration, and
+embedding the code instead of or fused with the query.
+"""
+
+import sys
+from types import SimpleNamespace
+from unittest.mock import Mock
+
+import pytest
+
+from code_indexer.services.query_expansion import (
+    HydeEmbeddingProvider,
+    HydeExpander,
+    fuse_embeddings,
+)
+
+
+def command(script: str) -> str:
+    return f"{sys.executable} -c '{script}'"
+
+
+def config(expansion_command=None, generation_command=None):
+    return SimpleNamespace(
+        query_expansion=SimpleNamespace(
+            llm_command=expansion_command, timeout_seconds=5
+        ),
+        query_generation=SimpleNamespace(llm_command=generation_command),
+    )
+
+
+class TestHydeExpander:
+    """Tests for drafting hypothetical code."""
+
+    def test_expand_strips_markdown_fence(self):
+        expander = HydeExpander(
+            command(
+                'import sys; sys.stdin.read(); print("```go\\nfunc Throttle() {}\\n```")'
+            )
+        )
+
+        assert expander.expand("rate limiting") == "func Throttle() {}"
+
+    def test_prompt_contains_query(self):
+        expander = HydeExpander(command("import sys; print(sys.stdin.read())"))
+
+        assert "where do we throttle requests" in expander.expand(
+            "where do we throttle requests"
+        )
+
+    def test_failing_or_silent_command(self):
+        with pytest.raises(RuntimeError, match="exited with 3"):
+            HydeExpander(command("import sys; sys.exit(3)")).expand("retry")
+        with pytest.raises(RuntimeError, match="printed nothing"):
+            HydeExpander(command("import sys; sys.stdin.read()")).expand("retry")
+
+    def test_from_config(self):
+        assert HydeExpander.from_config(config("llm-a", "llm-b")).command == ["llm-a"]
+        assert HydeExpander.from_config(config(None, "llm-b")).command == ["llm-b"]
+        with pytest.raises(ValueError, match="llm_command"):
+            HydeExpander.from_config(config())
+
+
+class TestHydeEmbeddingProvider:
+    """Tests for embedding hypothetical code."""
+
+    @staticmethod
+    def provider():
+        provider = Mock()
+        provider.get_embedding.side_effect = lambda text, model=None: (
+            [1.0, 0.0] if text == "retry" else [0.0, 2.0]
+        )
+        provider.get_embeddings_batch.side_effect = lambda texts, model=None: [
+            provider.get_embedding(text) for text in texts
+        ]
+        provider.get_provider_name.return_value = "voyage-ai"
+        return provider
+
+    @staticmethod
+    def expander(snippet="func Retry() {}", error=None):
+        expander = Mock()
+        expander.expand.side_effect = error or (lambda query: snippet)
+        return expander
+
+    def test_replace_embeds_hypothetical_code(self):
+        hyde = HydeEmbeddingProvider(self.provider(), self.expander(), "replace")
+
+        assert hyde.get_embedding("retry") == [0.0, 2.0]
+        assert hyde.hypothetical == "func Retry() {}"
+        assert hyde.get_provider_name() == "voyage-ai"
+
+    def test_fuse_averages_unit_embeddings(self):
+        hyde = HydeEmbeddingProvider(self.provider(), self.expander(), "fuse")
+
+        assert hyde.get_embedding("retry") == pytest.approx([0.7071, 0.7071], 1e-3)
+
+    def test_failed_expansion_embeds_query(self):
+        hyde = HydeEmbeddingProvider(
+            self.provider(), self.expander(error=RuntimeError("timed out"))
+        )
+
+        assert hyde.get_embedding("retry") == [1.0, 0.0]
+        assert hyde.error == "timed out"
+        assert hyde.hypothetical is None
+
+    def test_fuse_embeddings(self):
+        assert fuse_embeddings([[3.0, 4.0]]) == pytest.approx([0.6, 0.8])
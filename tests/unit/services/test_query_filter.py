@@ -0,0 +1,76 @@
+"""
+Unit tests for filter expressions of queries.
+
+Tests parsing terms, operator precedence and grouping into payload filters,
+evaluating the filters in the filesystem vector store, and syntax errors.
+"""
+
+import pytest
+
+from code_indexer.services.code_metrics import COMPLEXITY_KEY
+from code_indexer.services.generated_code import GENERATED_KEY
+from code_indexer.services.query_filter import (
+    FilterSyntaxError,
+    filter_keys,
+    parse_filter,
+)
+from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+GO = {"key": "language", "match": {"value": "go"}}
+INTERNAL = {"key": "path", "match": {"text": "internal/**"}}
+GENERATED = {"key": GENERATED_KEY, "match": {"value": True}}
+
+
+def matches(expression, payload, tmp_path):
+    store = FilesystemVectorStore(base_path=tmp_path, project_root=tmp_path)
+    return store._parse_filter({"must": [parse_filter(expression)]})(payload)
+
+
+class TestParseFilter:
+    """Tests for parse_filter."""
+
+    def test_terms_combined_with_and_and_not(self):
+        condition = parse_filter("lang:go AND path:internal/** AND NOT generated")
+
+        assert condition == {"must": [GO, INTERNAL, {"must_not": [GENERATED]}]}
+
+    def test_and_binds_tighter_than_or(self):
+        condition = parse_filter("lang:go path:internal/** or generated")
+
+        assert condition == {"should": [{"must": [GO, INTERNAL]}, GENERATED]}
+        assert parse_filter("lang:go AND (path:internal/** OR generated)") == {
+            "must": [GO, {"should": [INTERNAL, GENERATED]}]
+        }
+
+    def test_metric_comparisons_and_quoted_values(self):
+        assert parse_filter("complexity>=10") == {
+            "key": COMPLEXITY_KEY,
+            "range": {"gte": 10},
+        }
+        assert parse_filter("complexity=3")["range"] == {"gte": 3, "lte": 3}
+        assert parse_filter('path:"my docs/**"') == {
+            "key": "path",
+            "match": {"text": "my docs/**"},
+        }
+
+    def test_filter_keys(self):
+        condition = parse_filter("(lang:go OR complexity>5) AND NOT generated")
+
+        assert filter_keys(condition) == {"language", COMPLEXITY_KEY, GENERATED_KEY}
+
+    @pytest.mark.parametrize(
+        "expression",
+        ["", "lang:", "color:red", "(lang:go", "lang:go AND", "loc>many", "lang:go)"],
+    )
+    def test_syntax_errors(self, expression):
+        with pytest.raises(FilterSyntaxError):
+            parse_filter(expression)
+
+    def test_filters_payloads(self, tmp_path):
+        expression = "lang:go AND path:internal/** AND NOT generated"
+
+        go_file = {"language": "go", "path": "internal/a.go"}
+
+        assert matches(expression, go_file, tmp_path)
+        assert not matches(expression, {**go_file, GENERATED_KEY: True}, tmp_path)
+        assert not matches(expression, {**go_file, "language": "py"}, tmp_path)
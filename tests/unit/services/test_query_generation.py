@@ -0,0 +1,170 @@
+"""
+Unit tests for synthetic evaluation query generation.
+
+Tests template queries from doc comments and symbols, LLM command output
+parsing, chunk selection from the index, and merging of queries generated
+for several chunks.
+"""
+
+import sys
+from types import SimpleNamespace
+
+import pytest
+
+from code_indexer.services.query_generation import (
+    LlmQueryGenerator,
+    TemplateQueryGenerator,
+    doc_comments,
+    generate_queries,
+    generator_from_config,
+    indexed_chunks,
+    split_identifier,
+)
+
+GO_CHUNK = """\
+// RetryPayment retries a failed payment with exponential backoff.
+// It gives up after five attempts.
+func (s *Service) RetryPayment(ctx context.Context) error {
+    // SPDX-License-Identifier: MIT
+    return nil
+}
+"""
+
+
+def point(path: str, line_start: int, line_end: int, content: str = "", **extra):
+    payload = {"path": path, "line_start": line_start, "line_end": line_end}
+    payload.update(extra)
+    if content:
+        payload["content"] = content
+    return {"id": f"{path}:{line_start}", "payload": payload}
+
+
+class TestTemplateQueries:
+    """Tests for queries from doc comments and symbol names."""
+
+    def test_split_identifier(self):
+        assert split_identifier("retryPaymentHTTP_v2") == "retry payment http v2"
+        assert split_identifier("parse_config_file") == "parse config file"
+
+    def test_doc_comments(self):
+        text = GO_CHUNK + 'def load(path):\n    """Load the YAML config from disk."""\n'
+
+        assert doc_comments(text) == [
+            "Load the YAML config from disk",
+            "RetryPayment retries a failed payment with exponential backoff",
+        ]
+
+    def test_generate(self):
+        queries = TemplateQueryGenerator().generate(GO_CHUNK, "pay.go", count=5)
+
+        assert queries == [
+            "RetryPayment retries a failed payment with exponential backoff",
+            "how does retry payment work",
+        ]
+
+    def test_count_limits_queries(self):
+        assert len(TemplateQueryGenerator().generate(GO_CHUNK, "pay.go", 1)) == 1
+
+
+class TestLlmQueries:
+    """Tests for queries from an LLM command."""
+
+    def command(self, script: str) -> str:
+        return f"{sys.executable} -c '{script}'"
+
+    def test_parses_one_query_per_line(self):
+        generator = LlmQueryGenerator(
+            self.command(
+                "import sys; sys.stdin.read(); "
+                'print("1. how are payments retried\\n- \\"backoff for failed '
+                'charges\\"\\n\\nretry")'
+            )
+        )
+
+        assert generator.generate(GO_CHUNK, "pay.go", count=3) == [
+            "how are payments retried",
+            "backoff for failed charges",
+        ]
+
+    def test_failing_command(self):
+        generator = LlmQueryGenerator(self.command("import sys; sys.exit(3)"))
+
+        with pytest.raises(RuntimeError, match="exited with 3"):
+            generator.generate(GO_CHUNK, "pay.go", count=3)
+
+    def test_llm_requires_command(self):
+        config = SimpleNamespace(
+            query_generation=SimpleNamespace(llm_command=None, timeout_seconds=5)
+        )
+
+        assert isinstance(generator_from_config(config, False), TemplateQueryGenerator)
+        with pytest.raises(ValueError, match="llm_command"):
+            generator_from_config(config, True)
+        assert generator_from_config(config, True, "claude --print").command == [
+            "claude",
+            "--print",
+        ]
+
+
+class FakeVectorStore:
+    """Vector store with iter_vector_records() over fixed points."""
+
+    def __init__(self, points):
+        self.points = points
+
+    def list_collections(self):
+        return ["code-indexer-voyage", "code-indexer-temporal"]
+
+    def iter_vector_records(self, collection_name):
+        assert collection_name == "code-indexer-voyage"
+        for data in self.points:
+            yield None, data, None
+
+
+class TestGenerateQueries:
+    """Tests for chunk selection and query merging."""
+
+    def test_indexed_chunks(self, tmp_path):
+        store = FakeVectorStore(
+            [
+                point("src/b.go", 1, 10),
+                point("src/a.go", 5, 9),
+                point("src/a.go", 5, 9),
+                point("docs/x.md", 1, 3),
+            ]
+        )
+
+        chunks = indexed_chunks(store, tmp_path, path_filter="src/")
+
+        assert [c["payload"]["path"] for c in chunks] == ["src/a.go", "src/b.go"]
+
+    def test_queries_are_labeled_and_merged(self, tmp_path):
+        (tmp_path / "b.py").write_text("x = 1\ndef parse_config_file():\n    pass\n")
+        chunks = [
+            point("a.py", 1, 2, "def parse_config_file():\n    pass"),
+            point("b.py", 2, 3),
+        ]
+
+        queries = generate_queries(chunks, tmp_path, TemplateQueryGenerator())
+
+        assert len(queries) == 1
+        assert queries[0].to_dict() == {
+            "query": "how does parse config file work",
+            "expected": [
+                {"path": "a.py", "line_start": 1, "line_end": 2},
+                {"path": "b.py", "line_start": 2, "line_end": 3},
+            ],
+            "source": "template",
+        }
+
+    def test_sample_is_repeatable(self, tmp_path):
+        chunks = [
+            point(f"f{i}.py", 1, 1, f"def handler_number_{i}(): pass")
+            for i in range(20)
+        ]
+
+        first = generate_queries(chunks, tmp_path, TemplateQueryGenerator(), sample=5)
+        second = generate_queries(chunks, tmp_path, TemplateQueryGenerator(), sample=5)
+
+        assert len(first) == 5
+        assert [q.query for q in first] == [q.query for q in second]
@@ -0,0 +1,106 @@
+"""
+Unit tests for the local query latency store behind ``cidx stats --latency``.
+
+Tests mapping query timing telemetry onto stages, recording, trimming and
+percentile summaries.
+"""
+
+# mypy: ignore-errors
+
+import tempfile
+from pathlib import Path
+
+from src.code_indexer.services.query_latency_stats import (
+    QUERY_LATENCY_FILENAME,
+    QueryLatencyStore,
+    stage_timings_from_query,
+)
+
+
+class TestStageTimingsFromQuery:
+    """Tests for stage_timings_from_query()."""
+
+    def test_parallel_search_timings(self):
+        stages = stage_timings_from_query(
+            {
+                "filter_build_ms": 1.0,
+                "parallel_load_ms": 40.0,
+                "embedding_ms": 35.0,
+                "index_load_ms": 20.0,
+                "hnsw_search_ms": 5.0,
+                "candidate_load_ms": 8.0,
+                "staleness_detection_ms": 2.0,
+                "git_filter_ms": 3.0,
+            },
+            render_ms=4.0,
+        )
+
+        assert stages == {
+            "filter_build_ms": 1.0,
+            "vector_search_ms": 45.0,
+            "payload_fetch_ms": 10.0,
+            "render_ms": 4.0,
+        }
+
+    def test_sequential_search_and_rerank(self):
+        stages = stage_timings_from_query({"vector_search_ms": 12.0, "rerank_ms": 6.0})
+
+        assert stages == {"vector_search_ms": 12.0, "rerank_ms": 6.0}
+
+
+class TestQueryLatencyStore:
+    """Tests for QueryLatencyStore."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.config_dir = Path(self.temp_dir.name)
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def test_record_and_summarize(self):
+        store = QueryLatencyStore(self.config_dir)
+        for ms in range(1, 101):
+            assert store.record({"vector_search_ms": float(ms), "render_ms": 1.0})
+
+        summary = store.summarize()
+
+        assert summary["queries"] == 100
+        search = summary["stages"]["vector_search_ms"]
+        assert (search["p50"], search["p95"], search["p99"]) == (50.0, 95.0, 99.0)
+        assert summary["stages"]["total_ms"]["p50"] == 51.0
+        assert summary["stages"]["rerank_ms"]["count"] == 0
+
+    def test_keeps_most_recent_records(self):
+        store = QueryLatencyStore(self.config_dir, max_records=10)
+        for ms in range(25):
+            store.record({"vector_search_ms": float(ms)})
+
+        records = store.load()
+
+        assert len(records) == 10
+        assert records[-1]["vector_search_ms"] == 24.0
+        lines = (self.config_dir / QUERY_LATENCY_FILENAME).read_text().splitlines()
+        assert len(lines) <= 20
+
+    def test_corrupt_lines_are_skipped(self):
+        store = QueryLatencyStore(self.config_dir)
+        store.record({"render_ms": 2.0})
+        with open(self.config_dir / QUERY_LATENCY_FILENAME, "a") as f:
+            f.write("{not json\n")
+
+        assert len(store.load()) == 1
+
+    def test_missing_config_dir_is_not_created(self):
+        store = QueryLatencyStore(self.config_dir / "missing")
+
+        assert store.record({"render_ms": 2.0}) is False
+        assert not (self.config_dir / "missing").exists()
+
+    def test_clear(self):
+        store = QueryLatencyStore(self.config_dir)
+        store.record({"render_ms": 2.0})
+
+        store.clear()
+
+        assert store.summarize()["queries"] == 0
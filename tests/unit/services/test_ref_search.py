@@ -0,0 +1,87 @@
+"""
+Unit tests for searching the file versions at a commit or tag.
+
+Tests resolving a ref's files with git, the payload condition restricting
+the search to their blobs, and keeping results whose path and blob match.
+"""
+
+import subprocess
+
+import pytest
+
+from code_indexer.services.ref_search import (
+    RefSnapshot,
+    filter_results_at_ref,
+    resolve_ref,
+)
+
+
+def git(repo, *args):
+    return subprocess.run(
+        ["git", *args], cwd=repo, capture_output=True, text=True, check=True
+    ).stdout.strip()
+
+
+@pytest.fixture
+def repo(tmp_path):
+    git(tmp_path, "init", "-q")
+    git(tmp_path, "config", "user.email", "dev@example.com")
+    git(tmp_path, "config", "user.name", "Dev")
+    (tmp_path / "pay").mkdir()
+    (tmp_path / "pay" / "retry.py").write_text("def retry(): pass\n")
+    (tmp_path / "main.py").write_text("print('v1')\n")
+    git(tmp_path, "add", ".")
+    git(tmp_path, "commit", "-q", "-m", "v1")
+    git(tmp_path, "tag", "v1.0")
+    (tmp_path / "main.py").write_text("print('v2')\n")
+    git(tmp_path, "commit", "-q", "-am", "v2")
+    return tmp_path
+
+
+def result(path, blob):
+    return {"score": 0.5, "payload": {"path": path, "git_blob_hash": blob}}
+
+
+class TestResolveRef:
+    """Tests for resolve_ref."""
+
+    def test_files_of_a_tag(self, repo):
+        snapshot = resolve_ref(repo, "v1.0")
+
+        assert snapshot.commit == git(repo, "rev-parse", "HEAD~1")
+        assert snapshot.files == {
+            "main.py": git(repo, "rev-parse", "v1.0:main.py"),
+            "pay/retry.py": git(repo, "rev-parse", "v1.0:pay/retry.py"),
+        }
+        assert snapshot.files["main.py"] != git(repo, "rev-parse", "HEAD:main.py")
+
+    def test_unknown_ref(self, repo):
+        with pytest.raises(ValueError, match="v9.9"):
+            resolve_ref(repo, "v9.9")
+
+
+class TestRefSnapshot:
+    """Tests for filtering results by the versions at a ref."""
+
+    SNAPSHOT = RefSnapshot(
+        ref="v1.0", commit="c" * 40, files={"main.py": "a" * 40, "lib.py": "b" * 40}
+    )
+
+    def test_payload_condition_matches_the_ref_blobs(self):
+        condition = self.SNAPSHOT.payload_condition()
+
+        assert condition["key"] == "git_blob_hash"
+        assert condition["match"]["any"] == {"a" * 40, "b" * 40}
+
+    def test_keeps_results_matching_path_and_blob(self):
+        results = [
+            result("main.py", "a" * 40),
+            result("lib.py", "a" * 40),  # Same blob, other path
+            result("main.py", "d" * 40),  # Other version
+            {"score": 0.4, "payload": {"path": "main.py"}},
+            result("lib.py", "b" * 40),
+        ]
+
+        kept = filter_results_at_ref(results, self.SNAPSHOT)
+
+        assert kept == [results[0], results[4]]
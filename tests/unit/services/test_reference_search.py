@@ -0,0 +1,110 @@
+"""
+Unit tests for find-references with semantic fallback (cidx refs).
+
+Tests discovering SCIP databases, converting SCIP occurrences, falling back
+when no database can be queried, and matching identifiers in semantic
+results.
+"""
+
+from unittest.mock import patch
+
+from code_indexer.scip.query import QueryResult, SCIPQueryEngine
+from code_indexer.services.reference_search import (
+    SCIP_BACKEND,
+    SEMANTIC_BACKEND,
+    ReferenceHit,
+    references_in_results,
+    scip_references,
+)
+from code_indexer.storage.vector_kinds import SIGNATURE_VECTOR, VECTOR_KIND_KEY
+
+
+def result(path, line_start, content, score=0.8, **payload):
+    return {
+        "score": score,
+        "payload": {
+            "path": path,
+            "line_start": line_start,
+            "content": content,
+            **payload,
+        },
+    }
+
+
+class TestScipReferences:
+    """Tests for SCIPQueryEngine.index_files and scip_references."""
+
+    def test_index_files(self, tmp_path):
+        scip_dir = tmp_path / ".code-indexer" / "scip"
+        (scip_dir / "backend").mkdir(parents=True)
+        (scip_dir / "backend" / "index.scip.db").write_text("")
+        (scip_dir / "index.scip.db").write_text("")
+        (scip_dir / "orphan.scip").write_text("")
+
+        assert SCIPQueryEngine.index_files(tmp_path) == [
+            scip_dir / "backend" / "index.scip.db",
+            scip_dir / "index.scip.db",
+        ]
+        assert SCIPQueryEngine.index_files(tmp_path / "missing") == []
+
+    def test_no_index_falls_back(self, tmp_path):
+        assert scip_references(tmp_path, "UserService", limit=10) is None
+
+    def test_converts_occurrences(self, tmp_path):
+        (tmp_path / "app.py").write_text("import x\nsvc = UserService()\n")
+        occurrences = [
+            QueryResult("UserService#", "", "app.py", 1, 6, "reference"),
+            QueryResult("UserService#", "", "app.py", 1, 6, "reference"),
+            QueryResult("UserService#", "", "api.py", 9, 0, "reference"),
+        ]
+
+        with patch.object(
+            SCIPQueryEngine, "index_files", return_value=[tmp_path / "x.scip.db"]
+        ), patch.object(SCIPQueryEngine, "__init__", return_value=None), patch.object(
+            SCIPQueryEngine, "find_references", return_value=occurrences
+        ):
+            hits = scip_references(tmp_path, "UserService", limit=10)
+
+        assert hits == [
+            ReferenceHit("api.py", 10, 1, "", SCIP_BACKEND),
+            ReferenceHit("app.py", 2, 7, "svc = UserService()", SCIP_BACKEND),
+        ]
+
+    def test_unreadable_index_falls_back(self, tmp_path):
+        with patch.object(
+            SCIPQueryEngine, "index_files", return_value=[tmp_path / "x.scip.db"]
+        ):
+            # The database file does not exist
+            assert scip_references(tmp_path, "UserService", limit=10) is None
+
+
+class TestReferencesInResults:
+    """Tests for references_in_results."""
+
+    def test_whole_identifiers_without_declarations(self):
+        results = [
+            result(
+                "svc.py",
+                10,
+                "class UserService:\n    pass\n\nsvc = UserService()",
+                score=0.9,
+            ),
+            result("api.py", 1, "from svc import UserService, UserServiceFactory"),
+            result("docs.py", 1, "UserServices = []\nmy_UserService = 1"),
+            result(
+                "svc.py",
+                10,
+                "class UserService:",
+                **{VECTOR_KIND_KEY: SIGNATURE_VECTOR},
+            ),
+            result("svc.py", 13, "svc = UserService()"),
+        ]
+
+        hits = references_in_results(results, "UserService", [("svc.py", 10)])
+
+        assert [(h.path, h.line, h.column) for h in hits] == [
+            ("svc.py", 13, 7),
+            ("api.py", 1, 17),
+        ]
+        assert hits[0].context == "svc = UserService()"
+        assert (hits[0].backend, hits[0].score) == (SEMANTIC_BACKEND, 0.9)
@@ -0,0 +1,174 @@
+"""
+Unit tests for relevance feedback and feedback-based re-ranking.
+
+Tests judgment persistence, score adjustments per path, directory and
+symbol, re-ranking of search results, and result ID resolution.
+"""
+
+from pathlib import Path
+
+import pytest
+
+from code_indexer.services.relevance_feedback import (
+    BOOST_PER_JUDGMENT,
+    MAX_ADJUSTMENT,
+    FeedbackStore,
+    chunk_text,
+    defined_symbols,
+    resolve_result_id,
+    short_result_id,
+)
+
+
+def make_store(tmp_path: Path) -> FeedbackStore:
+    return FeedbackStore.for_project(tmp_path / ".code-indexer")
+
+
+def result(path: str, score: float, content: str = "") -> dict:
+    return {"id": path, "score": score, "payload": {"path": path, "content": content}}
+
+
+class TestDefinedSymbols:
+    """Tests for symbol extraction from chunk text."""
+
+    def test_definitions(self):
+        text = (
+            "class PaymentRetry:\n"
+            "    def schedule(self):\n"
+            "func (r *Repo) Save(ctx context.Context) error {\n"
+            "export function parseConfig() {}\n"
+            "type Options struct {\n"
+        )
+
+        assert defined_symbols(text) == [
+            "PaymentRetry",
+            "schedule",
+            "Save",
+            "parseConfig",
+            "Options",
+        ]
+
+    def test_short_result_id(self):
+        assert short_result_id("3f9a2c1e-1111-5222-8333-444455556666") == "3f9a2c1e"
+
+
+class TestFeedbackStore:
+    """Tests for recording judgments and deriving adjustments."""
+
+    def test_judgments_persist(self, tmp_path):
+        make_store(tmp_path).record(
+            "src/pay/retry.py", True, text="def retry():", query="retry"
+        )
+
+        judgments = make_store(tmp_path).judgments
+
+        assert len(judgments) == 1
+        assert judgments[0].path == "src/pay/retry.py"
+        assert judgments[0].symbols == ["retry"]
+        assert judgments[0].query == "retry"
+
+    def test_path_directory_and_symbol_adjustments(self, tmp_path):
+        store = make_store(tmp_path)
+        store.record("src/pay/retry.py", True, text="def retry_payment():")
+
+        # Path vote plus half a directory vote
+        assert store.adjustment("src/pay/retry.py") == pytest.approx(
+            1.5 * BOOST_PER_JUDGMENT
+        )
+        assert store.adjustment("src/pay/refund.py") == pytest.approx(
+            0.5 * BOOST_PER_JUDGMENT
+        )
+        assert store.adjustment(
+            "lib/other.py", "def retry_payment(): pass"
+        ) == pytest.approx(0.5 * BOOST_PER_JUDGMENT)
+        assert store.adjustment("lib/other.py") == 0.0
+
+    def test_adjustment_is_capped(self, tmp_path):
+        store = make_store(tmp_path)
+        for i in range(20):
+            store.record("vendor/junk.js", False, user=f"user{i}")
+
+        assert store.adjustment("vendor/junk.js") == -MAX_ADJUSTMENT
+
+    def test_rejudging_replaces_earlier_judgment(self, tmp_path):
+        store = make_store(tmp_path)
+        store.record("a.py", True, user="alice", result_key="p1")
+        store.record("a.py", False, user="alice", result_key="p1")
+        store.record("a.py", True, user="bob", result_key="p1")
+
+        assert [(j.user, j.relevant) for j in store.judgments] == [
+            ("alice", False),
+            ("bob", True),
+        ]
+
+    def test_clear(self, tmp_path):
+        store = make_store(tmp_path)
+        store.record("a.py", True)
+
+        assert store.clear() == 1
+        assert not make_store(tmp_path).has_feedback
+
+    def test_rerank(self, tmp_path):
+        store = make_store(tmp_path)
+        store.record("docs/guide.md", False)
+        store.record("src/core.py", True)
+        results = [
+            result("docs/guide.md", 0.80),
+            result("src/core.py", 0.78),
+            result("tests/test_core.py", 0.70),
+        ]
+
+        reranked = store.rerank(results)
+
+        assert [r["payload"]["path"] for r in reranked] == [
+            "src/core.py",
+            "docs/guide.md",
+            "tests/test_core.py",
+        ]
+        assert reranked[0]["feedback_adjustment"] > 0
+        assert "feedback_adjustment" not in reranked[2]
+
+    def test_rerank_without_feedback_keeps_results(self, tmp_path):
+        results = [result("a.py", 0.5)]
+
+        assert make_store(tmp_path).rerank(results) is results
+
+
+class FakeVectorStore:
+    """Vector store with an ID index for resolve_result_id()."""
+
+    def __init__(self):
+        self.points = {
+            "3f9a2c1e-aaaa": {"id": "3f9a2c1e-aaaa", "payload": {"path": "a.py"}},
+            "3f9b0000-bbbb": {"id": "3f9b0000-bbbb", "payload": {"path": "b.py"}},
+        }
+
+    def list_collections(self):
+        return ["code-indexer-voyage", "code-indexer-temporal"]
+
+    def load_id_index(self, collection_name):
+        assert collection_name == "code-indexer-voyage"
+        return set(self.points)
+
+    def get_point(self, point_id, collection_name):
+        return self.points[point_id]
+
+
+class TestResolveResultId:
+    """Tests for finding the chunk behind a result ID."""
+
+    def test_prefix_lookup(self):
+        store = FakeVectorStore()
+
+        assert resolve_result_id(store, "3F9A2C1E")["payload"]["path"] == "a.py"
+        assert resolve_result_id(store, "ffff") is None
+
+    def test_ambiguous_prefix(self):
+        with pytest.raises(ValueError, match="ambiguous"):
+            resolve_result_id(FakeVectorStore(), "3f9")
+
+    def test_chunk_text_from_working_tree(self, tmp_path):
+        (tmp_path / "a.py").write_text("x = 1\ndef f():\n    pass\ny = 2\n")
+        point = {"payload": {"path": "a.py", "line_start": 2, "line_end": 3}}
+
+        assert chunk_text(point, tmp_path) == "def f():\n    pass"
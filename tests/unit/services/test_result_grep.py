@@ -0,0 +1,45 @@
+"""
+Unit tests for the regex post-filter of semantic search results.
+
+Tests matching chunk content, keeping the result order, and rejecting
+invalid patterns.
+"""
+
+import pytest
+
+from code_indexer.services.result_grep import compile_grep, grep_results
+
+
+def result(path, content):
+    return {"score": 0.8, "payload": {"path": path, "content": content}}
+
+
+class TestGrepResults:
+    """Tests for compile_grep and grep_results."""
+
+    def test_keeps_matching_results_in_order(self):
+        results = [
+            result("a.go", "delay := backoff.Next()"),
+            result("b.go", "for attempt := 0; attempt < 3; attempt++ {"),
+            result("c.go", "delay += rand.Int63n(jitter)"),
+        ]
+
+        matched = grep_results(results, compile_grep("backoff|jitter"))
+
+        assert [r["payload"]["path"] for r in matched] == ["a.go", "c.go"]
+
+    def test_case_sensitive_unless_flagged(self):
+        results = [result("a.py", "class TokenBucket:")]
+
+        assert grep_results(results, compile_grep("token")) == []
+        assert grep_results(results, compile_grep("(?i)token")) == results
+
+    def test_anchors_match_lines(self):
+        results = [result("a.py", "import time\ndef retry():\n    pass")]
+
+        assert grep_results(results, compile_grep(r"^def retry")) == results
+
+    @pytest.mark.parametrize("pattern", ["", "retry(", "[a-"])
+    def test_invalid_patterns(self, pattern):
+        with pytest.raises(ValueError):
+            compile_grep(pattern)
@@ -0,0 +1,56 @@
+"""
+Unit tests for grouping search results by file.
+
+Tests keeping the best hits of each file in order and counting the
+collapsed matches, including those cut off by the result limit.
+"""
+
+import pytest
+
+from code_indexer.services.result_grouping import MORE_IN_FILE_KEY, group_results
+
+
+def result(path, score):
+    return {"score": score, "payload": {"path": path}}
+
+
+RESULTS = [
+    result("a.py", 0.9),
+    result("a.py", 0.8),
+    result("b.py", 0.7),
+    result("a.py", 0.6),
+    result("c.py", 0.5),
+    result("b.py", 0.4),
+]
+
+
+class TestGroupResults:
+    """Tests for group_results."""
+
+    def test_best_hit_per_file(self):
+        grouped = group_results(RESULTS)
+
+        assert [(r["payload"]["path"], r["score"]) for r in grouped] == [
+            ("a.py", 0.9),
+            ("b.py", 0.7),
+            ("c.py", 0.5),
+        ]
+        assert [r.get(MORE_IN_FILE_KEY) for r in grouped] == [2, 1, None]
+        assert MORE_IN_FILE_KEY not in RESULTS[0]
+
+    def test_max_per_file(self):
+        grouped = group_results(RESULTS, max_per_file=2)
+
+        assert [r["score"] for r in grouped] == [0.9, 0.8, 0.7, 0.5, 0.4]
+        assert [r.get(MORE_IN_FILE_KEY) for r in grouped] == [1, None, None, None, None]
+
+    def test_limit_counts_cut_hits_of_shown_files(self):
+        grouped = group_results(RESULTS, max_per_file=2, limit=3)
+
+        assert [r["score"] for r in grouped] == [0.9, 0.8, 0.7]
+        # a.py's third hit was collapsed, b.py's second hit cut by the limit
+        assert [r.get(MORE_IN_FILE_KEY) for r in grouped] == [1, None, 1]
+
+    def test_invalid_max_per_file(self):
+        with pytest.raises(ValueError):
+            group_results(RESULTS, max_per_file=0)
@@ -0,0 +1,95 @@
+"""
+Unit tests for score explanations of cidx query --explain.
+
+Tests recording vector scores, breaking final scores down into feedback
+and hook adjustments, formatting, and describing payload filters.
+"""
+
+from code_indexer.services.score_explanation import (
+    EXPLANATION_KEY,
+    describe_filter,
+    explain_results,
+    format_explanation,
+    record_vector_scores,
+)
+
+
+def result(path, score, content="", **extra):
+    return {"score": score, "payload": {"path": path, "content": content}, **extra}
+
+
+class TestExplainResults:
+    """Tests for record_vector_scores and explain_results."""
+
+    def test_breaks_score_into_vector_feedback_and_hooks(self):
+        results = [
+            result("a.py", 0.80, "def retry(): backoff()"),
+            result("b.py", 0.70, matched_vector="signature"),
+        ]
+        record_vector_scores(results)
+        # Feedback raises b.py, a hook lowers a.py
+        results[1]["score"] += 0.05
+        results[1]["feedback_adjustment"] = 0.05
+        results[0]["score"] -= 0.1
+        final = [results[1], results[0]]
+
+        explain_results(final, "retry with backoff and jitter")
+
+        b, a = (r[EXPLANATION_KEY] for r in final)
+        assert (b["rank"], b["vector_rank"], b["matched_vector"]) == (
+            1,
+            2,
+            "signature",
+        )
+        assert b["hook_adjustment"] == 0.0
+        assert b["matched_terms"] == []
+        assert abs(a["hook_adjustment"] + 0.1) < 1e-9
+        assert a["matched_terms"] == ["retry", "backoff"]
+        assert a["query_terms"] == ["retry", "backoff", "jitter"]
+
+    def test_results_replaced_by_hooks(self):
+        results = [result("a.py", 0.9)]
+        explain_results(results, "retry")
+
+        explanation = results[0][EXPLANATION_KEY]
+        assert "vector_similarity" not in explanation
+        assert format_explanation(explanation) == (
+            "🧮 Score 0.900 (set by a post_query hook) | words 0/1"
+        )
+
+
+def test_format_explanation():
+    results = [result("a.py", 0.812, "retry()")]
+    record_vector_scores(results)
+    explain_results(results, "retry backoff")
+
+    assert format_explanation(results[0][EXPLANATION_KEY]) == (
+        "🧮 Score 0.812 = vector 0.812 (body, vector rank #1) "
+        "+ feedback +0.000 + hooks +0.000 | words 1/2 (retry)"
+    )
+
+
+def test_describe_filter():
+    conditions = {
+        "must": [
+            {"key": "git_available", "match": {"value": True}},
+            {
+                "should": [
+                    {"key": "language", "match": {"value": "py"}},
+                    {"key": "language", "match": {"value": "go"}},
+                ]
+            },
+            {"key": "complexity", "range": {"gte": 10}},
+            {"key": "git_blob_hash", "match": {"any": {"a", "b", "c", "d"}}},
+        ],
+        "must_not": [{"key": "path", "match": {"text": "vendor/**"}}],
+    }
+
+    assert describe_filter(conditions) == [
+        "git_available=True",
+        "(language=py OR language=go)",
+        "complexity>=10",
+        "git_blob_hash in 4 values",
+        "NOT path~vendor/**",
+    ]
+    assert describe_filter({}) == []
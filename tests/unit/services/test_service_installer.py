@@ -0,0 +1,209 @@
+"""
+Unit tests for user-level service installation.
+
+Tests service specifications for watch and the server, systemd unit and
+launchd plist rendering, and the service manager calls of install and
+uninstall.
+"""
+
+import os
+import plistlib
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from code_indexer.services.service_installer import (
+    ServiceInstaller,
+    ServiceSpec,
+    server_service_spec,
+    watch_service_spec,
+)
+
+
+@pytest.fixture
+def spec(tmp_path):
+    return ServiceSpec(
+        name="cidx-watch-demo-12345678",
+        description="CIDX watch for demo",
+        command=["/usr/bin/cidx", "watch", "--foreground"],
+        working_dir=tmp_path / "my project",
+        environment={"PATH": "/usr/bin", "VOYAGE_API_KEY": "key%1"},
+    )
+
+
+def _completed(returncode=0, stdout="", stderr=""):
+    return MagicMock(returncode=returncode, stdout=stdout, stderr=stderr)
+
+
+class TestServiceSpecs:
+    """Tests for watch_service_spec() and server_service_spec()."""
+
+    def test_watch_service_named_per_project(self, tmp_path):
+        first = watch_service_spec(tmp_path / "My Repo")
+        second = watch_service_spec(tmp_path / "other" / "My Repo")
+
+        assert first.name.startswith("cidx-watch-my-repo-")
+        assert first.name != second.name
+        assert first.working_dir == (tmp_path / "My Repo").resolve()
+
+    def test_watch_command_and_arguments(self, tmp_path):
+        spec = watch_service_spec(tmp_path, watch_args=["--foreground"])
+
+        assert spec.command[-2:] == ["watch", "--foreground"]
+
+    def test_copies_only_requested_variables(self, tmp_path):
+        env = {"PATH": "/bin", "VOYAGE_API_KEY": "key", "OTHER_SECRET": "x"}
+        with patch.dict(os.environ, env, clear=True):
+            spec = watch_service_spec(tmp_path, pass_env=["VOYAGE_API_KEY", "UNSET"])
+
+        assert spec.environment == {"PATH": "/bin", "VOYAGE_API_KEY": "key"}
+
+    def test_server_service(self):
+        spec = server_service_spec("0.0.0.0", 8090)
+
+        assert spec.name == "cidx-server"
+        assert spec.command[-5:] == [
+            "code_indexer.server.main",
+            "--host",
+            "0.0.0.0",
+            "--port",
+            "8090",
+        ]
+
+
+class TestRender:
+    """Tests for ServiceInstaller.render()."""
+
+    def test_systemd_unit(self, spec, tmp_path):
+        installer = ServiceInstaller(platform="linux", home=tmp_path)
+
+        unit = installer.render(spec)
+
+        assert "ExecStart=/usr/bin/cidx watch --foreground\n" in unit
+        assert f"WorkingDirectory={spec.working_dir}\n" in unit
+        assert "Environment=VOYAGE_API_KEY=key%%1\n" in unit
+        assert "Restart=on-failure" in unit
+        assert "SyslogIdentifier=cidx-watch-demo-12345678" in unit
+        assert "WantedBy=default.target" in unit
+        assert installer.service_file(spec.name) == (
+            tmp_path / ".config/systemd/user/cidx-watch-demo-12345678.service"
+        )
+
+    def test_systemd_quotes_arguments_with_spaces(self, spec, tmp_path):
+        spec.command = ["/opt/my tools/cidx", "watch"]
+
+        unit = ServiceInstaller(platform="linux", home=tmp_path).render(spec)
+
+        assert 'ExecStart="/opt/my tools/cidx" watch\n' in unit
+
+    def test_launchd_plist(self, spec, tmp_path):
+        installer = ServiceInstaller(platform="darwin", home=tmp_path)
+
+        plist = plistlib.loads(installer.render(spec).encode())
+
+        assert plist["Label"] == "io.github.jsbattig.cidx-watch-demo-12345678"
+        assert plist["ProgramArguments"] == spec.command
+        assert plist["EnvironmentVariables"]["VOYAGE_API_KEY"] == "key%1"
+        assert plist["RunAtLoad"] is True
+        assert plist["KeepAlive"] == {"SuccessfulExit": False}
+        log_file = str(tmp_path / "Library/Logs/cidx/cidx-watch-demo-12345678.log")
+        assert plist["StandardOutPath"] == log_file
+        assert plist["StandardErrorPath"] == log_file
+
+    def test_service_file_location_on_macos(self, tmp_path):
+        installer = ServiceInstaller(platform="darwin", home=tmp_path)
+
+        assert installer.service_file("cidx-server") == Path(
+            tmp_path, "Library", "LaunchAgents", "io.github.jsbattig.cidx-server.plist"
+        )
+
+    def test_unsupported_platform(self):
+        with pytest.raises(RuntimeError, match="systemd"):
+            ServiceInstaller(platform="win32")
+
+
+class TestInstall:
+    """Tests for ServiceInstaller.install() and uninstall()."""
+
+    def test_systemd_install_enables_service(self, spec, tmp_path):
+        installer = ServiceInstaller(platform="linux", home=tmp_path)
+
+        with patch("subprocess.run", return_value=_completed()) as run:
+            service_file = installer.install(spec)
+
+        assert service_file.exists()
+        assert oct(service_file.stat().st_mode & 0o777) == "0o600"
+        commands = [call.args[0] for call in run.call_args_list]
+        assert commands == [
+            ["systemctl", "--user", "daemon-reload"],
+            ["systemctl", "--user", "enable", "--now", f"{spec.name}.service"],
+        ]
+
+    def test_launchd_install_loads_agent(self, spec, tmp_path):
+        installer = ServiceInstaller(platform="darwin", home=tmp_path)
+
+        with patch("subprocess.run", return_value=_completed()) as run:
+            service_file = installer.install(spec)
+
+        assert run.call_args_list[-1].args[0] == [
+            "launchctl",
+            "load",
+            "-w",
+            str(service_file),
+        ]
+        assert (tmp_path / "Library" / "Logs" / "cidx").is_dir()
+
+    def test_install_reports_service_manager_failure(self, spec, tmp_path):
+        installer = ServiceInstaller(platform="linux", home=tmp_path)
+        failed = _completed(returncode=1, stderr="Failed to connect to bus")
+
+        with patch("subprocess.run", return_value=failed):
+            with pytest.raises(RuntimeError, match="Failed to connect to bus"):
+                installer.install(spec)
+
+    def test_missing_service_manager(self, spec, tmp_path):
+        installer = ServiceInstaller(platform="linux", home=tmp_path)
+
+        with patch("subprocess.run", side_effect=FileNotFoundError("systemctl")):
+            with pytest.raises(RuntimeError, match="systemd is not available"):
+                installer.install(spec)
+
+    def test_uninstall(self, spec, tmp_path):
+        installer = ServiceInstaller(platform="linux", home=tmp_path)
+        with patch("subprocess.run", return_value=_completed()):
+            installer.install(spec)
+
+        with patch("subprocess.run", return_value=_completed()) as run:
+            assert installer.uninstall(spec.name) is True
+
+        assert not installer.service_file(spec.name).exists()
+        assert run.call_args_list[0].args[0][:4] == [
+            "systemctl",
+            "--user",
+            "disable",
+            "--now",
+        ]
+
+    def test_uninstall_not_installed(self, tmp_path):
+        installer = ServiceInstaller(platform="linux", home=tmp_path)
+
+        with patch("subprocess.run") as run:
+            assert installer.uninstall("cidx-server") is False
+
+        run.assert_not_called()
+
+    @pytest.mark.parametrize(
+        "stdout,expected", [("Linger=yes\n", True), ("Linger=no\n", False)]
+    )
+    def test_linger_enabled(self, tmp_path, stdout, expected):
+        installer = ServiceInstaller(platform="linux", home=tmp_path)
+
+        with patch.dict(os.environ, {"USER": "dev"}):
+            with patch("subprocess.run", return_value=_completed(stdout=stdout)):
+                assert installer.linger_enabled() is expected
+
+    def test_linger_not_applicable_on_macos(self, tmp_path):
+        installer = ServiceInstaller(platform="darwin", home=tmp_path)
+
+        assert installer.linger_enabled() is None
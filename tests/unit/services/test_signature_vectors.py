@@ -0,0 +1,164 @@
+"""
+Unit tests for signature vectors of chunks.
+
+Tests finding the signature of a chunk's first declaration, the summary
+embedded with the doc comment, the signature points FileChunkingManager
+writes next to body points, and configuration.
+"""
+
+import tempfile
+import threading
+from concurrent.futures import Future
+from pathlib import Path
+from typing import Dict, List
+from unittest.mock import Mock
+
+from code_indexer.config import Config
+from code_indexer.services.boilerplate_filter import EMBEDDING_TEXT_KEY
+from code_indexer.services.clean_slot_tracker import CleanSlotTracker
+from code_indexer.services.doc_comments import DOC_KEY
+from code_indexer.services.file_chunking_manager import FileChunkingManager
+from code_indexer.services.signature_vectors import (
+    SignatureVectors,
+    signature_of,
+    signature_summary,
+)
+from code_indexer.services.vector_calculation_manager import VectorResult
+from code_indexer.storage.vector_kinds import (
+    BODY_POINT_KEY,
+    SIGNATURE_VECTOR,
+    VECTOR_KIND_KEY,
+)
+
+FIND = (
+    "    @Override\n"
+    "    public Optional<User> findByEmail(\n"
+    "            String email, boolean active) {\n"
+    "        return repository.find(email);\n"
+    "    }\n"
+)
+HELPER = "x = compute()\nprint(x)\n"
+
+
+class RecordingVectorManager:
+    """Vector manager mock recording the embedded texts."""
+
+    def __init__(self):
+        self.cancellation_event = threading.Event()
+        self.embedding_provider = Mock()
+        self.embedding_provider._get_model_token_limit.return_value = 120000
+        self.embedded_texts: List[str] = []
+
+    def submit_batch_task(self, chunk_texts: List[str], metadata: Dict):
+        self.embedded_texts.extend(chunk_texts)
+        future = Future()
+        future.set_result(
+            VectorResult(
+                task_id="batch",
+                embeddings=tuple((1.0, 0.0) for _ in chunk_texts),
+                metadata=metadata.copy(),
+                processing_time=0.0,
+                error=None,
+            )
+        )
+        return future
+
+
+class TestSignatureOf:
+    """Tests for finding the signature of a chunk's first declaration."""
+
+    def test_multi_line_java_signature(self):
+        assert signature_of(FIND, "java") == (
+            "public Optional<User> findByEmail(String email, boolean active)"
+        )
+
+    def test_other_languages(self):
+        go = "// Total sums.\nfunc (c *Cart) Total() int {\n\treturn 0\n}\n"
+        python = 'x = 1\n\nasync def fetch(url):\n    """Fetch."""\n'
+        rust = "pub(crate) fn parse(input: &str) -> Result<Ast> {\n"
+
+        assert signature_of(go, "go") == "func (c *Cart) Total() int"
+        assert signature_of(python, "py") == "async def fetch(url):"
+        assert signature_of(rust, "rs") == (
+            "pub(crate) fn parse(input: &str) -> Result<Ast>"
+        )
+
+    def test_calls_and_statements_are_not_declarations(self):
+        assert signature_of("if (ready(x)) {\n  run(x);\n}\n", "js") is None
+        assert signature_of(HELPER, "py") is None
+
+    def test_summary_adds_the_doc(self):
+        chunk = {"text": FIND, "file_extension": "java", DOC_KEY: "Finds a user."}
+
+        assert signature_summary(chunk).endswith(")\n\nFinds a user.")
+        assert signature_summary({**chunk, "file_extension": "md"}) is None
+
+
+class TestSignatureChunks:
+    """Tests for the signature points written next to body points."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def test_signature_points_follow_their_body_points(self):
+        file_path = self.root / "UserService.java"
+        file_path.write_text(FIND + HELPER)
+        chunker = Mock()
+        chunker.chunk_file.return_value = [
+            {"text": text, "file_extension": "java", "line_start": s, "line_end": e}
+            for text, s, e in [(FIND, 1, 5), (HELPER, 6, 7)]
+        ]
+        vector_manager = RecordingVectorManager()
+        vector_store = Mock()
+        vector_store.upsert_points.return_value = True
+        manager = FileChunkingManager(
+            vector_manager=vector_manager,
+            chunker=chunker,
+            vector_store_client=vector_store,
+            thread_count=1,
+            slot_tracker=CleanSlotTracker(max_slots=3),
+            codebase_dir=self.root,
+            signature_vectors=SignatureVectors(),
+        )
+        metadata = {
+            "project_id": "shop",
+            "file_hash": "sha256:aaa",
+            "git_available": False,
+            "collection_name": "code",
+        }
+
+        with manager:
+            result = manager.submit_file_for_processing(
+                file_path, metadata, None
+            ).result(timeout=10.0)
+
+        assert result.success
+        points = vector_store.upsert_points.call_args.kwargs["points"]
+        body, signature, helper = points
+        assert vector_manager.embedded_texts[1].startswith("public Optional<User>")
+        assert signature["payload"][VECTOR_KIND_KEY] == SIGNATURE_VECTOR
+        assert signature["payload"][BODY_POINT_KEY] == body["id"]
+        assert signature["id"] != body["id"]
+        assert [p["payload"]["chunk_index"] for p in points] == [0, 0, 1]
+        assert {p["payload"]["total_chunks"] for p in points} == {2}
+        assert VECTOR_KIND_KEY not in helper["payload"]
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert SignatureVectors.from_config(config) is None
+
+        config.indexing.signature_vectors.enabled = True
+        assert SignatureVectors.from_config(config) is not None
+
+    def test_signature_chunks_embed_the_summary(self):
+        chunks = SignatureVectors().add_signature_chunks(
+            [{"text": FIND, "file_extension": "java"}]
+        )
+
+        assert len(chunks) == 2
+        assert chunks[1]["text"] == FIND
+        assert chunks[1][EMBEDDING_TEXT_KEY].startswith("public Optional<User>")
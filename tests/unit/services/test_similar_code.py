@@ -0,0 +1,172 @@
+"""
+Unit tests for "more like this" search (cidx similar).
+
+Tests parsing locations, reading referenced lines, finding the chunk of a
+qualified symbol, and leaving the reference out of the results.
+"""
+
+from unittest.mock import Mock, patch
+
+import pytest
+
+from code_indexer.services.similar_code import (
+    CodeReference,
+    StoredVectorProvider,
+    parse_location,
+    reference_from_location,
+    reference_from_symbol,
+    search_similar,
+)
+from code_indexer.services.symbol_declarations import SymbolDefinition
+from code_indexer.storage.vector_kinds import SIGNATURE_VECTOR, VECTOR_KIND_KEY
+
+PYTHON_SOURCE = """class Client:
+    def do(self):
+        pass
+
+
+class Server:
+    def do(self):
+        pass
+"""
+
+GO_SOURCE = """package client
+
+type Client struct{}
+
+func Do() {}
+
+func (c *Client) Do() error {
+	return nil
+}
+"""
+
+
+class TestReferences:
+    """Tests for parse_location and the references of locations and symbols."""
+
+    def test_parse_location(self):
+        assert parse_location("a/b.go:42-90") == ("a/b.go", 42, 90)
+        assert parse_location("a/b.go:42") == ("a/b.go", 42, 42)
+        assert parse_location("a/b.go") == ("a/b.go", None, None)
+        with pytest.raises(ValueError):
+            parse_location("a/b.go:9-3")
+
+    def test_reference_from_location(self, tmp_path):
+        (tmp_path / "pkg").mkdir()
+        (tmp_path / "pkg" / "c.go").write_text(GO_SOURCE)
+
+        reference = reference_from_location(tmp_path, "pkg/c.go:7-20")
+
+        assert (reference.path, reference.line_start, reference.line_end) == (
+            "pkg/c.go",
+            7,
+            9,
+        )
+        assert reference.text.startswith("func (c *Client) Do()")
+        with pytest.raises(ValueError):
+            reference_from_location(tmp_path, "pkg/missing.go")
+        with pytest.raises(ValueError):
+            reference_from_location(tmp_path, "pkg/c.go:40")
+
+    def test_reference_from_qualified_symbol(self, tmp_path):
+        (tmp_path / "c.go").write_text(GO_SOURCE)
+        store = Mock()
+        store.get_points_for_paths.return_value = [
+            {
+                "id": "file",
+                "vector": [0.0, 1.0],
+                "payload": {"path": "c.go", "line_start": 1, "line_end": 9},
+            },
+            {
+                "id": "signature",
+                "vector": [1.0, 1.0],
+                "payload": {
+                    "path": "c.go",
+                    "line_start": 7,
+                    "line_end": 7,
+                    VECTOR_KIND_KEY: SIGNATURE_VECTOR,
+                },
+            },
+            {
+                "id": "method",
+                "vector": [1.0, 0.0],
+                "payload": {"path": "c.go", "line_start": 7, "line_end": 9},
+                "chunk_text": "func (c *Client) Do() error {",
+            },
+        ]
+        definitions = [
+            SymbolDefinition("Do", "function", "c.go", 5),
+            SymbolDefinition("Do", "method", "c.go", 7),
+        ]
+
+        with patch(
+            "code_indexer.services.similar_code.find_symbols",
+            return_value=definitions,
+        ):
+            reference, matches = reference_from_symbol(
+                store, "code", tmp_path, "Client.Do"
+            )
+            with pytest.raises(ValueError):
+                reference_from_symbol(store, "code", tmp_path, "Server.Do")
+
+        assert matches == 1
+        assert (reference.location, reference.vector) == ("c.go:7-9", [1.0, 0.0])
+        store.get_points_for_paths.assert_called_once_with("code", ["c.go"])
+
+    def test_reference_from_class_member(self, tmp_path):
+        (tmp_path / "c.py").write_text(PYTHON_SOURCE)
+        store = Mock()
+        store.get_points_for_paths.return_value = [
+            {
+                "id": "server",
+                "vector": [0.0, 1.0],
+                "payload": {"path": "c.py", "line_start": 6, "line_end": 8},
+            }
+        ]
+        definitions = [
+            SymbolDefinition("do", "method", "c.py", 2),
+            SymbolDefinition("do", "method", "c.py", 7),
+        ]
+
+        with patch(
+            "code_indexer.services.similar_code.find_symbols",
+            return_value=definitions,
+        ):
+            reference, matches = reference_from_symbol(
+                store, "code", tmp_path, "Server::do"
+            )
+
+        assert (matches, reference.location) == (1, "c.py:6-8")
+
+
+class TestSearchSimilar:
+    """Tests for search_similar."""
+
+    def test_reuses_stored_vector_and_drops_reference(self):
+        reference = CodeReference("c.go", 7, 9, "func Do()", vector=[1.0, 0.0])
+        results = [
+            {"score": 1.0, "payload": {"path": "c.go", "line_start": 5, "line_end": 8}},
+            {"score": 0.9, "payload": {"path": "d.go", "line_start": 7, "line_end": 9}},
+            {"score": 0.8, "payload": {"path": "c.go", "line_start": 12}},
+        ]
+        store = Mock()
+        store.search.return_value = results
+        provider = Mock()
+
+        found = search_similar(store, provider, "code", reference, limit=2)
+
+        assert [r["payload"]["path"] for r in found] == ["d.go", "c.go"]
+        assert store.search.call_args.kwargs["query_vector"] == [1.0, 0.0]
+        assert store.search.call_args.kwargs["limit"] == 9
+        provider.get_embedding.assert_not_called()
+
+    def test_stored_vector_provider_delegates(self):
+        provider = Mock()
+        provider.get_current_model.return_value = "voyage-code-3"
+
+        wrapped = StoredVectorProvider(provider, [0.5, 0.5])
+
+        assert wrapped.get_embedding("anything") == [0.5, 0.5]
+        assert wrapped.get_current_model() == "voyage-code-3"
+        provider.get_embedding.assert_not_called()
@@ -0,0 +1,134 @@
+"""
+Unit tests for Go struct tag metadata.
+
+Tests tag parsing, struct resolution across chunk boundaries, chunk
+annotation of a real Go file, configuration and the --struct-tag filter terms.
+"""
+
+import shutil
+from pathlib import Path
+
+from code_indexer.config import Config
+from code_indexer.services.struct_tags import (
+    STRUCT_FIELDS_KEY,
+    STRUCT_TAGS_KEY,
+    StructTagExtractor,
+    find_struct_fields,
+    normalize_struct_tag,
+    parse_tag,
+)
+from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+GO_FIXTURE = Path(__file__).parents[1] / "parsers/test_files/go/MicroserviceAPI.go"
+
+SOURCE = """package store
+
+type (
+\tOrder struct {
+\t\tID     uint   `json:"id" gorm:"primaryKey"`
+\t\tNumber string `json:"number,omitempty" db:"order_no" gorm:"uniqueIndex;not null"`
+\t\tItems  []struct {
+\t\t\tSKU string `json:"sku" validate:"required,min=3"`
+\t\t} `json:"items"`
+\t\tNote string // `not a tag`
+\t}
+)
+
+type Empty struct{}
+
+func (Order) TableName() string { return "orders" }
+
+type Base struct {
+\t*Model `json:"-"`
+}
+"""
+
+
+class TestParsing:
+    """Tests for parsing struct tags and fields."""
+
+    def test_parse_tag(self):
+        assert parse_tag('json:"name,omitempty" gorm:"column:name" x:"a\\"b"') == {
+            "json": "name,omitempty",
+            "gorm": "column:name",
+            "x": 'a"b',
+        }
+
+    def test_find_struct_fields(self):
+        fields = find_struct_fields(SOURCE)
+
+        assert [(f.struct, f.field, f.line) for f in fields] == [
+            ("Order", "ID", 5),
+            ("Order", "Number", 6),
+            ("Order", "SKU", 8),
+            ("Order", "Items", 9),
+            ("Base", "Model", 19),
+        ]
+        assert fields[1].terms() == [
+            "json",
+            "json:number",
+            "json:omitempty",
+            "db",
+            "db:order_no",
+            "gorm",
+            "gorm:uniqueIndex",
+            "gorm:not null",
+        ]
+        assert fields[2].terms()[-2:] == ["validate:required", "validate:min=3"]
+
+    def test_normalize_struct_tag(self):
+        assert normalize_struct_tag('gorm:"uniqueIndex"') == "gorm:uniqueIndex"
+        assert normalize_struct_tag(" `db` ") == "db"
+
+
+class TestStructTagExtractor:
+    """Tests for annotating chunks and configuration."""
+
+    def test_annotate_chunks_of_fixture(self, tmp_path):
+        go_file = tmp_path / "api.go"
+        shutil.copy(GO_FIXTURE, go_file)
+        chunks = [
+            {"text": "...", "line_start": 1, "line_end": 70},
+            # Starts inside "type User struct"
+            {"text": "...", "line_start": 70, "line_end": 75},
+            {"text": "...", "line_start": 250, "line_end": 260},
+        ]
+
+        annotated = StructTagExtractor().annotate_chunks(chunks, go_file)
+
+        user = annotated[1]
+        assert {f["struct"] for f in user[STRUCT_FIELDS_KEY]} == {"User"}
+        assert user[STRUCT_FIELDS_KEY][0] == {
+            "struct": "User",
+            "field": "Email",
+            "line": 70,
+            "tags": {"json": "email", "gorm": "uniqueIndex;not null"},
+        }
+        assert "gorm:uniqueIndex" in user[STRUCT_TAGS_KEY]
+        assert len(user[STRUCT_TAGS_KEY]) == len(set(user[STRUCT_TAGS_KEY]))
+        assert "json:server" in annotated[0][STRUCT_TAGS_KEY]
+        assert STRUCT_TAGS_KEY not in annotated[2]
+
+    def test_non_go_files_are_skipped(self, tmp_path):
+        source = tmp_path / "model.py"
+        source.write_text(SOURCE)
+        chunks = [{"text": SOURCE, "line_start": 1, "line_end": 19}]
+
+        assert StructTagExtractor().annotate_chunks(chunks, source) is chunks
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert StructTagExtractor.from_config(config) is not None
+
+        config.indexing.struct_tags.enabled = False
+        assert StructTagExtractor.from_config(config) is None
+
+    def test_match_value_on_struct_tags(self, tmp_path):
+        store = FilesystemVectorStore(base_path=tmp_path, project_root=tmp_path)
+        matches = store._parse_filter(
+            {"must": [{"key": STRUCT_TAGS_KEY, "match": {"value": "gorm:uniqueIndex"}}]}
+        )
+
+        assert matches({STRUCT_TAGS_KEY: ["json", "gorm", "gorm:uniqueIndex"]})
+        assert not matches({STRUCT_TAGS_KEY: ["gorm", "gorm:primaryKey"]})
+        assert not matches({"path": "main.py"})
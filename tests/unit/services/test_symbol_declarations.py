@@ -0,0 +1,184 @@
+"""
+Unit tests for symbol declarations and lookup.
+
+Tests finding declarations and their kinds in chunk text, chunk annotation,
+configuration and looking symbols up in the indexed payloads.
+"""
+
+from pathlib import Path
+from unittest.mock import Mock, patch
+
+from code_indexer.config import Config
+from code_indexer.indexing.dart_chunker import SYMBOL_KIND_KEY, SYMBOL_NAME_KEY
+from code_indexer.services.symbol_declarations import (
+    DECLARATIONS_KEY,
+    SymbolDeclarations,
+    declarations_of,
+    find_symbols,
+)
+from code_indexer.storage.vector_kinds import SIGNATURE_VECTOR, VECTOR_KIND_KEY
+
+PYTHON = '''class UserService:
+    """Users."""
+
+    def find(self, email):
+        return None
+
+
+def load_users(path):
+    # def commented(): not a declaration
+    return "def quoted(): neither"
+'''
+
+GO = """type Store struct {
+\tdb *DB
+}
+
+type ID string
+
+func (s *Store) Get(id ID) error {
+\treturn nil
+}
+
+func NewStore() *Store {
+\treturn &Store{}
+}
+"""
+
+JAVA = """public class Orders {
+    public Order find(long id) {
+        if (id < 0) {
+            return null;
+        }
+        return repo.get(id);
+    }
+}
+"""
+
+
+class TestDeclarationsOf:
+    """Tests for finding the declarations in a chunk."""
+
+    def test_python_declarations(self):
+        assert declarations_of(PYTHON, "py", first_line=10) == [
+            {"name": "UserService", "kind": "class", "line": 10},
+            {"name": "find", "kind": "method", "line": 13},
+            {"name": "load_users", "kind": "function", "line": 17},
+        ]
+
+    def test_go_declarations(self):
+        assert declarations_of(GO, "go") == [
+            {"name": "Store", "kind": "struct", "line": 1},
+            {"name": "ID", "kind": "type", "line": 5},
+            {"name": "Get", "kind": "method", "line": 7},
+            {"name": "NewStore", "kind": "function", "line": 11},
+        ]
+
+    def test_c_style_declarations(self):
+        assert declarations_of(JAVA, "java") == [
+            {"name": "Orders", "kind": "class", "line": 1},
+            {"name": "find", "kind": "method", "line": 2},
+        ]
+
+
+class TestSymbolDeclarations:
+    """Tests for chunk annotation and configuration."""
+
+    def test_annotates_code_chunks(self):
+        chunks = [
+            {"text": GO, "file_extension": "go", "line_start": 3},
+            {"text": "def not_code(): pass", "file_extension": "md"},
+        ]
+
+        annotated = SymbolDeclarations().annotate_chunks(chunks, Path("store.go"))
+
+        assert annotated[0][DECLARATIONS_KEY][0] == {
+            "name": "Store",
+            "kind": "struct",
+            "line": 3,
+        }
+        assert DECLARATIONS_KEY not in annotated[1]
+        assert DECLARATIONS_KEY not in chunks[0]
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert isinstance(SymbolDeclarations.from_config(config), SymbolDeclarations)
+
+        config.indexing.symbol_declarations.enabled = False
+        assert SymbolDeclarations.from_config(config) is None
+
+
+class TestFindSymbols:
+    """Tests for looking symbols up in the indexed payloads."""
+
+    @staticmethod
+    def lookup(records, name, **kwargs):
+        store = Mock()
+        store.list_collections.return_value = ["code"]
+        store.iter_vector_records.return_value = [
+            ("id", {"payload": payload}, None) for payload in records
+        ]
+        with patch(
+            "code_indexer.utils.git_runner.get_current_branch", return_value="main"
+        ):
+            return find_symbols(store, Path("."), name, **kwargs)
+
+    @staticmethod
+    def payload(path, *declarations, **extra):
+        return {
+            "path": path,
+            DECLARATIONS_KEY: [
+                {"name": name, "kind": kind, "line": line}
+                for name, kind, line in declarations
+            ],
+            **extra,
+        }
+
+    def test_ranks_exact_matches_and_types_first(self):
+        records = [
+            self.payload("a.py", ("parse", "method", 4), ("parser", "class", 1)),
+            self.payload("b.py", ("parse", "function", 2)),
+            self.payload("c.py", ("Parse", "class", 8)),
+            self.payload("b.py", ("parse", "function", 2)),
+            self.payload("d.py", ("parse", "class", 1), hidden_branches=["main"]),
+            self.payload(
+                "e.py", ("parse", "class", 1), **{VECTOR_KIND_KEY: SIGNATURE_VECTOR}
+            ),
+        ]
+
+        found = self.lookup(records, "parse", prefix=True)
+
+        assert [(d.name, d.kind, d.path) for d in found] == [
+            ("parse", "function", "b.py"),
+            ("parse", "method", "a.py"),
+            ("parser", "class", "a.py"),
+        ]
+
+    def test_ignore_case_and_kinds(self):
+        records = [self.payload("c.py", ("Parse", "class", 8), ("parse", "method", 9))]
+
+        found = self.lookup(records, "PARSE", ignore_case=True, kinds=["class"])
+
+        assert [d.to_dict() for d in found] == [
+            {
+                "name": "Parse",
+                "kind": "class",
+                "path": "c.py",
+                "line": 8,
+                "language": "",
+            }
+        ]
+
+    def test_chunker_declarations(self):
+        records = [
+            {
+                "path": "lib/login.dart",
+                "line_start": 12,
+                SYMBOL_NAME_KEY: "LoginForm",
+                SYMBOL_KIND_KEY: "widget",
+            }
+        ]
+
+        found = self.lookup(records, "LoginForm")
+
+        assert [(d.kind, d.line) for d in found] == [("widget", 12)]
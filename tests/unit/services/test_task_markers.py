@@ -0,0 +1,277 @@
+"""
+Unit tests for TODO/FIXME/HACK task comment extraction.
+
+Tests comment detection, git blame attribution, configuration, payload
+recording by FileChunkingManager, and listing task comments from the index.
+"""
+
+import subprocess
+import tempfile
+import threading
+from concurrent.futures import Future
+from pathlib import Path
+from typing import Dict, List
+from unittest.mock import Mock
+
+from code_indexer.config import Config
+from code_indexer.services.clean_slot_tracker import CleanSlotTracker
+from code_indexer.services.file_chunking_manager import FileChunkingManager
+from code_indexer.services.task_markers import (
+    TASK_MARKERS_KEY,
+    TaskMarkerExtractor,
+    find_task_markers,
+)
+from code_indexer.services.vector_calculation_manager import VectorResult
+
+DAY = 86400
+
+
+def git(repo: Path, *args: str) -> None:
+    subprocess.run(["git", *args], cwd=repo, check=True, capture_output=True)
+
+
+class TestExtract:
+    """Tests for finding task comments in text."""
+
+    def setup_method(self):
+        self.extractor = TaskMarkerExtractor(Path("."), blame=False)
+
+    def test_comment_styles(self):
+        text = (
+            "# TODO: drop python 3.8 support\n"
+            "x = 1  # FIXME handle None\n"
+            "// HACK(bob): retry twice\n"
+            "/* XXX remove before release */\n"
+            " * TODO document the flags\n"
+        )
+
+        found = self.extractor.extract(text, line_start=10)
+
+        assert [(e["line"], e["marker"], e["text"]) for e in found] == [
+            (10, "TODO", "drop python 3.8 support"),
+            (11, "FIXME", "handle None"),
+            (12, "HACK", "retry twice"),
+            (13, "XXX", "remove before release"),
+            (14, "TODO", "document the flags"),
+        ]
+        assert found[2]["owner"] == "bob"
+
+    def test_non_comments_are_ignored(self):
+        text = (
+            "TODO_LIST = []\n"
+            "def add_todo(item):\n"
+            "    todo = 'TODO: not a comment'\n"
+            "# todo: lowercase is not a marker\n"
+            "# TODOS are plural\n"
+        )
+
+        assert self.extractor.extract(text) == []
+
+    def test_custom_markers(self):
+        extractor = TaskMarkerExtractor(Path("."), markers=["NOTE"], blame=False)
+
+        found = extractor.extract("# NOTE: keep in sync\n# TODO: ignored\n")
+
+        assert [e["marker"] for e in found] == ["NOTE"]
+
+
+class TestAttribution:
+    """Tests for git blame attribution."""
+
+    def test_blame_committed_and_uncommitted_lines(self, tmp_path):
+        git(tmp_path, "init", "-q")
+        git(tmp_path, "config", "user.name", "Alice Example")
+        git(tmp_path, "config", "user.email", "alice@example.com")
+        source = tmp_path / "app.py"
+        source.write_text("# TODO: committed\nx = 1\n")
+        git(tmp_path, "add", "app.py")
+        git(tmp_path, "commit", "-q", "-m", "init")
+        source.write_text("# TODO: committed\nx = 1\n# FIXME: local edit\n")
+
+        extractor = TaskMarkerExtractor(tmp_path)
+        chunks = extractor.annotate_chunks(
+            [{"text": source.read_text(), "line_start": 1}], source
+        )
+
+        committed, uncommitted = chunks[0][TASK_MARKERS_KEY]
+        assert committed["author"] == "Alice Example"
+        assert committed["author_email"] == "alice@example.com"
+        assert committed["authored_at"] > 0
+        assert len(committed["commit"]) == 40
+        assert "author" not in uncommitted
+        assert uncommitted["authored_at"] == source.stat().st_mtime
+
+    def test_without_git(self, tmp_path):
+        source = tmp_path / "app.py"
+        source.write_text("# TODO: no git here\n")
+
+        chunks = TaskMarkerExtractor(tmp_path).annotate_chunks(
+            [{"text": source.read_text(), "line_start": 1}], source
+        )
+
+        assert chunks[0][TASK_MARKERS_KEY][0]["authored_at"] == source.stat().st_mtime
+
+    def test_chunks_without_markers_are_unchanged(self, tmp_path):
+        chunks = [{"text": "x = 1\n", "line_start": 1}]
+
+        assert TaskMarkerExtractor(tmp_path).annotate_chunks(chunks, tmp_path) is chunks
+
+
+class TestTaskMarkersConfig:
+    """Tests for indexing.task_markers."""
+
+    def test_enabled_by_default(self, tmp_path):
+        extractor = TaskMarkerExtractor.from_config(Config(codebase_dir=tmp_path))
+
+        assert extractor.markers == ["TODO", "FIXME", "HACK", "XXX"]
+        assert extractor.blame is True
+
+    def test_disabled(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        config.indexing.task_markers.enabled = False
+
+        assert TaskMarkerExtractor.from_config(config) is None
+
+
+class FakeVectorStore:
+    """Vector store exposing vector records for find_task_markers()."""
+
+    def __init__(self, payloads: List[Dict]):
+        self.payloads = payloads
+
+    def list_collections(self):
+        return ["code-indexer-voyage", "code-indexer-temporal"]
+
+    def iter_vector_records(self, collection_name):
+        assert collection_name == "code-indexer-voyage"
+        for payload in self.payloads:
+            yield Path("v.json"), {"id": "x", "payload": payload}, None
+
+
+class TestFindTaskMarkers:
+    """Tests for listing task comments from the index."""
+
+    NOW = 1_700_000_000.0
+
+    def setup_method(self):
+        old = {"marker": "TODO", "line": 3, "text": "old", "author": "Alice"}
+        old["authored_at"] = self.NOW - 400 * DAY
+        recent = {"marker": "FIXME", "line": 9, "text": "new", "author": "Bob"}
+        recent["authored_at"] = self.NOW - 2 * DAY
+        self.store = FakeVectorStore(
+            [
+                {"path": "pkg/a.go", TASK_MARKERS_KEY: [old, recent]},
+                # Overlapping chunk repeating the same comment
+                {"path": "pkg/a.go", TASK_MARKERS_KEY: [recent]},
+                {
+                    "path": "cmd/main.go",
+                    TASK_MARKERS_KEY: [{**old, "line": 1}],
+                },
+                {"path": "pkg/b.go"},
+            ]
+        )
+
+    def find(self, tmp_path, **kwargs):
+        return find_task_markers(self.store, tmp_path, now=self.NOW, **kwargs)
+
+    def test_lists_oldest_first_without_duplicates(self, tmp_path):
+        found = self.find(tmp_path)
+
+        assert [(t.path, t.line) for t in found] == [
+            ("cmd/main.go", 1),
+            ("pkg/a.go", 3),
+            ("pkg/a.go", 9),
+        ]
+
+    def test_filters(self, tmp_path):
+        assert len(self.find(tmp_path, older_than_seconds=365 * DAY)) == 2
+        assert [t.path for t in self.find(tmp_path, path_filter="pkg/")] == [
+            "pkg/a.go",
+            "pkg/a.go",
+        ]
+        assert len(self.find(tmp_path, path_filter="*/main.go")) == 1
+        assert [t.line for t in self.find(tmp_path, markers=["FIXME"])] == [9]
+        assert [t.author for t in self.find(tmp_path, author="bob")] == ["Bob"]
+
+    def test_hidden_on_current_branch(self, tmp_path):
+        git(tmp_path, "init", "-q", "-b", "main")
+        for payload in self.store.payloads:
+            payload["hidden_branches"] = ["main"]
+
+        assert self.find(tmp_path) == []
+
+
+class RecordingVectorManager:
+    """Vector manager mock returning fixed embeddings."""
+
+    def __init__(self):
+        self.cancellation_event = threading.Event()
+        self.embedding_provider = Mock()
+        self.embedding_provider.get_current_model.return_value = "voyage-code-3"
+        self.embedding_provider._get_model_token_limit.return_value = 120000
+
+    def submit_batch_task(self, chunk_texts: List[str], metadata: Dict):
+        future = Future()
+        future.set_result(
+            VectorResult(
+                task_id="batch",
+                embeddings=tuple((0.5,) * 8 for _ in chunk_texts),
+                metadata=metadata.copy(),
+                processing_time=0.0,
+                error=None,
+            )
+        )
+        return future
+
+
+class TestFileChunkingManagerRecording:
+    """Tests for recording task comments in point payloads."""
+
+    def setup_method(self):
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.root = Path(self.temp_dir.name)
+        self.file_path = self.root / "main.py"
+        self.file_path.write_text("x = 1\n# TODO: split this module\n")
+
+    def teardown_method(self):
+        self.temp_dir.cleanup()
+
+    def test_markers_are_stored_in_payload(self):
+        vector_store = Mock()
+        vector_store.upsert_points.return_value = True
+        chunker = Mock()
+        chunker.chunk_file.return_value = [
+            {
+                "text": self.file_path.read_text(),
+                "chunk_index": 0,
+                "total_chunks": 1,
+                "file_extension": "py",
+                "line_start": 1,
+                "line_end": 2,
+            }
+        ]
+        manager = FileChunkingManager(
+            vector_manager=RecordingVectorManager(),
+            chunker=chunker,
+            vector_store_client=vector_store,
+            thread_count=1,
+            slot_tracker=CleanSlotTracker(max_slots=3),
+            codebase_dir=self.root,
+            task_marker_extractor=TaskMarkerExtractor(self.root, blame=False),
+        )
+        metadata = {
+            "project_id": "test_project",
+            "file_hash": "sha256:aaa",
+            "git_available": False,
+            "collection_name": "test_collection",
+        }
+
+        with manager:
+            result = manager.submit_file_for_processing(
+                self.file_path, metadata, None
+            ).result(timeout=10.0)
+
+        assert result.success
+        points = vector_store.upsert_points.call_args.kwargs["points"]
+        markers = points[0]["payload"][TASK_MARKERS_KEY]
+        assert [(m["marker"], m["line"]) for m in markers] == [("TODO", 2)]
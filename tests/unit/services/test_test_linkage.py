@@ -0,0 +1,114 @@
+"""
+Unit tests for test-to-subject file linkage.
+
+Tests the test file naming conventions per language, finding subjects next
+to a test and across mirrored test directories, chunk annotation and
+configuration.
+"""
+
+from code_indexer.config import Config
+from code_indexer.services.test_linkage import (
+    IS_TEST_KEY,
+    TEST_OF_KEY,
+    SubjectLinker,
+    is_test_file,
+    subject_names,
+)
+
+FILES = [
+    "pkg/api/user.go",
+    "pkg/api/user_test.go",
+    "pkg/api/router_test.go",
+    "src/app/api/user.py",
+    "src/app/models/user.py",
+    "src/app/util.py",
+    "tools/util.py",
+    "tests/unit/api/test_user.py",
+    "tests/test_util.py",
+    "web/src/Button.tsx",
+    "web/src/__tests__/Button.test.tsx",
+    "web/src/format.ts",
+    "web/src/format.spec.ts",
+    "src/main/java/com/shop/Cart.java",
+    "src/test/java/com/shop/CartTest.java",
+    "node_modules/lib/util.py",
+]
+
+
+def write_project(root):
+    for path in FILES:
+        (root / path).parent.mkdir(parents=True, exist_ok=True)
+        (root / path).write_text("")
+
+
+class TestNamingConventions:
+    """Tests for recognizing test files by name."""
+
+    def test_subject_names(self):
+        assert subject_names("user_test.go") == ("user.go",)
+        assert subject_names("test_user.py") == ("user.py",)
+        assert subject_names("user_test.py") == ("user.py",)
+        assert subject_names("format.spec.ts")[:2] == ("format.js", "format.jsx")
+        assert subject_names("CartTest.java") == ("Cart.java", "Cart.kt")
+        assert subject_names("CartTests.kt") == ("Cart.java", "Cart.kt")
+        assert subject_names("TestCart.java") == ("Cart.java", "Cart.kt")
+        assert subject_names("CartIT.java") == ("Cart.java", "Cart.kt")
+
+    def test_non_test_files(self):
+        assert not is_test_file("user.go")
+        assert not is_test_file("_test.go")
+        assert not is_test_file("conftest.py")
+        assert not is_test_file("latest.ts")
+        assert not is_test_file("Testing.java")
+        assert not is_test_file("README.md")
+
+
+class TestSubjectLinker:
+    """Tests for finding subjects and annotating chunks."""
+
+    def test_same_directory(self, tmp_path):
+        write_project(tmp_path)
+        linker = SubjectLinker(tmp_path)
+
+        assert linker.subjects_of("pkg/api/user_test.go") == ["pkg/api/user.go"]
+        assert linker.subjects_of("web/src/format.spec.ts") == ["web/src/format.ts"]
+        assert linker.subjects_of("pkg/api/router_test.go") == []
+
+    def test_tests_directory(self, tmp_path):
+        write_project(tmp_path)
+        linker = SubjectLinker(tmp_path)
+
+        assert linker.subjects_of("web/src/__tests__/Button.test.tsx") == [
+            "web/src/Button.tsx"
+        ]
+        assert linker.subjects_of("tests/unit/api/test_user.py") == [
+            "src/app/api/user.py"
+        ]
+        assert linker.subjects_of("src/test/java/com/shop/CartTest.java") == [
+            "src/main/java/com/shop/Cart.java"
+        ]
+
+    def test_ambiguous_name_is_not_linked(self, tmp_path):
+        write_project(tmp_path)
+
+        assert SubjectLinker(tmp_path).subjects_of("tests/test_util.py") == []
+
+    def test_annotate_chunks(self, tmp_path):
+        write_project(tmp_path)
+        linker = SubjectLinker(tmp_path)
+        chunks = [{"text": "a"}, {"text": "b"}]
+
+        annotated = linker.annotate_chunks(chunks, tmp_path / "pkg/api/user_test.go")
+        unlinked = linker.annotate_chunks(chunks, tmp_path / "pkg/api/router_test.go")
+
+        assert [c[TEST_OF_KEY] for c in annotated] == [["pkg/api/user.go"]] * 2
+        assert annotated[0][IS_TEST_KEY] is True
+        assert unlinked[0] == {"text": "a", IS_TEST_KEY: True}
+        assert linker.annotate_chunks(chunks, tmp_path / "pkg/api/user.go") is chunks
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert SubjectLinker.from_config(config) is not None
+
+        config.indexing.test_linkage.enabled = False
+        assert SubjectLinker.from_config(config) is None
@@ -0,0 +1,174 @@
+"""
+Unit tests for Go type parameter metadata.
+
+Tests type parameter parsing, declaration spans, chunk annotation with the
+embedded constraint context, configuration and the --symbol-kind filter.
+"""
+
+from code_indexer.config import Config
+from code_indexer.indexing.boundary_chunking import SYMBOL_KIND_KEY
+from code_indexer.services.boilerplate_filter import EMBEDDING_TEXT_KEY
+from code_indexer.services.type_parameters import (
+    GENERIC_KIND,
+    TYPE_CONSTRAINTS_KEY,
+    TYPE_PARAMETERS_KEY,
+    TypeParameterExtractor,
+    find_generic_declarations,
+    parse_type_parameters,
+)
+from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+SOURCE = """package slices
+
+type Buffer [64]byte
+
+type Number interface {
+\t~int | ~float64
+}
+
+func Map[T any, U comparable](items []T, f func(T) U) []U {
+\tout := make([]U, 0, len(items))
+\tfor _, item := range items {
+\t\tout = append(out, f(item)) // "}" is not the end
+\t}
+\treturn out
+}
+
+type (
+\tPair[K, V comparable] struct {
+\t\tKey   K
+\t\tValue V
+\t}
+\tSet[T comparable] map[T]struct{}
+)
+
+func Sum[
+\tS ~[]E,
+\tE Number,
+](s S) E {
+\tvar total E
+\treturn total
+}
+
+func (p Pair[K, V]) Swap() Pair[V, K] { return Pair[V, K]{p.Value, p.Key} }
+"""
+
+
+class TestParsing:
+    """Tests for parsing type parameter lists and declarations."""
+
+    def test_parse_type_parameters(self):
+        parameters = parse_type_parameters("T any, U comparable")
+
+        assert [(p.name, p.constraint) for p in parameters] == [
+            ("T", "any"),
+            ("U", "comparable"),
+        ]
+
+    def test_names_listed_together_share_the_constraint(self):
+        parameters = parse_type_parameters("K, V comparable, N interface{ ~int }")
+
+        assert [(p.name, p.constraint) for p in parameters] == [
+            ("K", "comparable"),
+            ("V", "comparable"),
+            ("N", "interface{ ~int }"),
+        ]
+
+    def test_array_lengths_are_not_type_parameters(self):
+        assert parse_type_parameters("64") is None
+        assert parse_type_parameters("N") is None
+        assert parse_type_parameters("") is None
+
+    def test_find_generic_declarations(self):
+        declarations = find_generic_declarations(SOURCE)
+
+        assert [(d.declaration, d.kind, d.line, d.end_line) for d in declarations] == [
+            ("Map", "func", 9, 15),
+            ("Pair", "type", 18, 21),
+            ("Set", "type", 22, 22),
+            ("Sum", "func", 25, 31),
+        ]
+        assert declarations[0].signature() == "Map[T any, U comparable]"
+        assert declarations[3].signature() == "Sum[S ~[]E, E Number]"
+
+
+class TestTypeParameterExtractor:
+    """Tests for annotating chunks and configuration."""
+
+    def test_annotate_chunks(self, tmp_path):
+        go_file = tmp_path / "slices.go"
+        go_file.write_text(SOURCE)
+        chunks = [
+            {"text": "...", "line_start": 1, "line_end": 8},
+            {"text": "func Map...", "line_start": 9, "line_end": 11},
+            # Starts inside the body of Map
+            {"text": "\t\tout = append(...)", "line_start": 12, "line_end": 16},
+            {"text": "...", "line_start": 33, "line_end": 33},
+        ]
+
+        annotated = TypeParameterExtractor().annotate_chunks(chunks, go_file)
+
+        assert TYPE_PARAMETERS_KEY not in annotated[0]
+        assert annotated[1][TYPE_PARAMETERS_KEY] == [
+            {
+                "declaration": "Map",
+                "kind": "func",
+                "line": 9,
+                "parameters": [
+                    {"name": "T", "constraint": "any"},
+                    {"name": "U", "constraint": "comparable"},
+                ],
+            }
+        ]
+        assert annotated[1][TYPE_CONSTRAINTS_KEY] == ["any", "comparable"]
+        assert annotated[1][SYMBOL_KIND_KEY] == GENERIC_KIND
+        assert EMBEDDING_TEXT_KEY not in annotated[1]
+        assert annotated[2][EMBEDDING_TEXT_KEY] == (
+            "Type parameters: Map[T any, U comparable]\n\n\t\tout = append(...)"
+        )
+        # Methods of generic types only name the receiver's parameters
+        assert TYPE_PARAMETERS_KEY not in annotated[3]
+
+    def test_existing_kind_and_embedded_text_are_kept(self, tmp_path):
+        go_file = tmp_path / "slices.go"
+        go_file.write_text(SOURCE)
+        chunks = [
+            {
+                "text": "\tvar total E",
+                "line_start": 29,
+                "line_end": 31,
+                SYMBOL_KIND_KEY: "function",
+                EMBEDDING_TEXT_KEY: "var total E",
+            }
+        ]
+
+        (chunk,) = TypeParameterExtractor().annotate_chunks(chunks, go_file)
+
+        assert chunk[SYMBOL_KIND_KEY] == "function"
+        assert chunk[TYPE_CONSTRAINTS_KEY] == ["~[]E", "Number"]
+        assert chunk[EMBEDDING_TEXT_KEY] == (
+            "Type parameters: Sum[S ~[]E, E Number]\n\nvar total E"
+        )
+
+    def test_non_go_files_are_skipped(self, tmp_path):
+        source = tmp_path / "slices.py"
+        source.write_text(SOURCE)
+        chunks = [{"text": SOURCE, "line_start": 1, "line_end": 33}]
+
+        assert TypeParameterExtractor().annotate_chunks(chunks, source) is chunks
+
+    def test_from_config(self, tmp_path):
+        config = Config(codebase_dir=tmp_path)
+        assert TypeParameterExtractor.from_config(config) is not None
+
+        config.indexing.type_parameters.enabled = False
+        assert TypeParameterExtractor.from_config(config) is None
+
+    def test_match_value_on_symbol_kind(self, tmp_path):
+        store = FilesystemVectorStore(base_path=tmp_path, project_root=tmp_path)
+        matches = store._parse_filter(
+            {"must": [{"key": SYMBOL_KIND_KEY, "match": {"value": GENERIC_KIND}}]}
+        )
+
+        assert matches({SYMBOL_KIND_KEY: GENERIC_KIND})
+        assert not matches({SYMBOL_KIND_KEY: "function"})
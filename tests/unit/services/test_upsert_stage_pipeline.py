@@ -0,0 +1,348 @@
+"""
+Unit tests for the pipelined upsert stage.
+
+Tests UpsertStage bounded-queue backpressure and FileChunkingManager's
+pipelined mode where chunk → vector runs on worker threads and vector storage
+writes are handed off to a dedicated writer through a bounded queue.
+"""
+
+# mypy: ignore-errors
+
+import tempfile
+import threading
+import time
+from concurrent.futures import as_completed
+from pathlib import Path
+from typing import Dict, List
+from unittest.mock import Mock
+
+import pytest
+
+from src.code_indexer.services.clean_slot_tracker import CleanSlotTracker, FileStatus
+from src.code_indexer.services.file_chunking_manager import FileChunkingManager
+from src.code_indexer.services.upsert_stage import UpsertStage
+from src.code_indexer.services.vector_calculation_manager import VectorResult
+
+
+class ImmediateVectorManager:
+    """Vector manager mock returning already-completed batch futures."""
+
+    def __init__(self):
+        self.cancellation_event = threading.Event()
+        self.embedding_provider = Mock()
+        self.embedding_provider.get_current_model.return_value = "voyage-code-3"
+        self.embedding_provider._get_model_token_limit.return_value = 120000
+
+    def submit_batch_task(self, chunk_texts: List[str], metadata: Dict):
+        from concurrent.futures import Future
+
+        future = Future()
+        future.set_result(
+            VectorResult(
+                task_id="batch",
+                embeddings=tuple((0.1,) * 8 for _ in chunk_texts),
+                metadata=metadata.copy(),
+                processing_time=0.0,
+                error=None,
+            )
+        )
+        return future
+
+
+class SingleChunkChunker:
+    """Chunker mock producing one chunk per file."""
+
+    def chunk_file(self, file_path: Path) -> List[Dict]:
+        return [
+            {
+                "text": file_path.read_text(),
+                "chunk_index": 0,
+                "total_chunks": 1,
+                "file_extension": file_path.suffix.lstrip("."),
+                "line_start": 1,
+                "line_end": 1,
+            }
+        ]
+
+
+class SlowVectorStore:
+    """Vector store mock recording upserts with configurable latency."""
+
+    def __init__(
+        self,
+        delay: float = 0.0,
+        fail: bool = False,
+        release: threading.Event = None,
+    ):
+        self.delay = delay
+        self.fail = fail
+        # Writes wait for this event when given
+        self.release = release
+        self.writing = threading.Event()
+        self.upsert_threads: List[str] = []
+        self.upserted_paths: List[str] = []
+        self._lock = threading.Lock()
+
+    def upsert_points(self, points, collection_name=None) -> bool:
+        self.writing.set()
+        if self.release is not None:
+            self.release.wait(timeout=10.0)
+        time.sleep(self.delay)
+        with self._lock:
+            self.upsert_threads.append(threading.current_thread().name)
+            self.upserted_paths.extend(p["payload"]["path"] for p in points)
+        return not self.fail
+
+
+class TestUpsertStage:
+    """Tests for the bounded single-writer stage."""
+
+    def test_rejects_non_positive_queue_size(self):
+        with pytest.raises(ValueError):
+            UpsertStage(0)
+
+    def test_submit_requires_start(self):
+        stage = UpsertStage(2)
+        with pytest.raises(RuntimeError):
+            stage.submit(lambda: None)
+
+    def test_jobs_run_in_submission_order_on_writer_thread(self):
+        stage = UpsertStage(4).start()
+        executed: List[tuple] = []
+
+        for i in range(10):
+            stage.submit(
+                lambda i=i: executed.append((i, threading.current_thread().name))
+            )
+        stage.close()
+
+        assert [i for i, _ in executed] == list(range(10))
+        assert {name for _, name in executed} == {"UpsertWriter"}
+        stats = stage.get_stats()
+        assert stats["jobs_submitted"] == 10
+        assert stats["jobs_completed"] == 10
+        assert stats["jobs_failed"] == 0
+
+    def test_full_queue_blocks_producer(self):
+        stage = UpsertStage(1).start()
+        release = threading.Event()
+        stage.submit(release.wait)  # Occupies the writer
+        stage.submit(lambda: None)  # Fills the queue
+
+        blocked_submit_done = threading.Event()
+
+        def producer():
+            stage.submit(lambda: None)
+            blocked_submit_done.set()
+
+        threading.Thread(target=producer, daemon=True).start()
+
+        # Backpressure: producer cannot enqueue while queue is full
+        assert not blocked_submit_done.wait(timeout=0.3)
+
+        release.set()
+        assert blocked_submit_done.wait(timeout=5.0)
+        stage.close()
+        assert stage.get_stats()["jobs_completed"] == 3
+
+    def test_failing_job_does_not_stop_writer(self):
+        stage = UpsertStage(2).start()
+        executed = []
+
+        def failing_job():
+            raise RuntimeError("disk full")
+
+        stage.submit(failing_job)
+        stage.submit(lambda: executed.append("after"))
+        stage.close()
+
+        assert executed == ["after"]
+        assert stage.get_stats()["jobs_failed"] == 1
+
+    def test_submit_after_close_raises(self):
+        stage = UpsertStage(2).start()
+        stage.close()
+        with pytest.raises(RuntimeError):
+            stage.submit(lambda: None)
+
+    def test_submit_racing_close_runs_every_accepted_job(self):
+        for _ in range(20):
+            stage = UpsertStage(1).start()
+            accepted = []
+            executed = []
+            lock = threading.Lock()
+
+            def producer(n):
+                for i in range(50):
+                    job_id = (n, i)
+                    try:
+                        stage.submit(lambda job_id=job_id: executed.append(job_id))
+                    except RuntimeError:
+                        return
+                    with lock:
+                        accepted.append(job_id)
+
+            producers = [
+                threading.Thread(target=producer, args=(n,), daemon=True)
+                for n in range(4)
+            ]
+            for thread in producers:
+                thread.start()
+            stage.close()
+            for thread in producers:
+                thread.join(timeout=5.0)
+
+            # No job is queued behind the sentinel and left to never run
+            assert sorted(executed) == sorted(accepted)
+            assert stage.get_stats()["jobs_completed"] == len(accepted)
+
+
+class TestFileChunkingManagerPipelinedUpserts:
+    """Tests for FileChunkingManager with the upsert stage enabled."""
+
+    def setup_method(self):
+        self.temp_dir = Path(tempfile.mkdtemp())
+        self.files = []
+        for i in range(6):
+            file_path = self.temp_dir / f"module_{i}.py"
+            file_path.write_text(f"def function_{i}():\n    return {i}\n")
+            self.files.append(file_path)
+
+    def teardown_method(self):
+        import shutil
+
+        shutil.rmtree(self.temp_dir, ignore_errors=True)
+
+    def _create_manager(self, vector_store, upsert_queue_size: int, slot_tracker=None):
+        return FileChunkingManager(
+            vector_manager=ImmediateVectorManager(),
+            chunker=SingleChunkChunker(),
+            vector_store_client=vector_store,
+            thread_count=2,
+            slot_tracker=slot_tracker or CleanSlotTracker(max_slots=4),
+            codebase_dir=self.temp_dir,
+            upsert_queue_size=upsert_queue_size,
+        )
+
+    def _metadata(self, file_path: Path) -> Dict:
+        return {
+            "project_id": "test_project",
+            "file_hash": file_path.name,
+            "git_available": False,
+            "collection_name": "test_collection",
+        }
+
+    def test_negative_queue_size_rejected(self):
+        with pytest.raises(ValueError):
+            self._create_manager(SlowVectorStore(), upsert_queue_size=-1)
+
+    def test_writes_happen_on_upsert_stage_thread(self):
+        vector_store = SlowVectorStore()
+
+        with self._create_manager(vector_store, upsert_queue_size=2) as manager:
+            futures = [
+                manager.submit_file_for_processing(f, self._metadata(f), None)
+                for f in self.files
+            ]
+            results = [f.result(timeout=10.0) for f in as_completed(futures)]
+
+        assert all(r.success for r in results)
+        assert sum(r.chunks_processed for r in results) == len(self.files)
+        assert set(vector_store.upsert_threads) == {"UpsertWriter"}
+        assert sorted(vector_store.upserted_paths) == sorted(
+            f.name for f in self.files
+        )
+
+    def test_result_future_resolves_only_after_write(self):
+        vector_store = SlowVectorStore(delay=0.2)
+
+        with self._create_manager(vector_store, upsert_queue_size=1) as manager:
+            future = manager.submit_file_for_processing(
+                self.files[0], self._metadata(self.files[0]), None
+            )
+            result = future.result(timeout=10.0)
+
+            assert result.success
+            assert vector_store.upserted_paths == [self.files[0].name]
+
+    def test_write_failure_reported_through_result_future(self):
+        vector_store = SlowVectorStore(fail=True)
+        progress_callback = Mock()
+
+        with self._create_manager(vector_store, upsert_queue_size=2) as manager:
+            future = manager.submit_file_for_processing(
+                self.files[0], self._metadata(self.files[0]), progress_callback
+            )
+            result = future.result(timeout=10.0)
+
+        assert not result.success
+        assert "Vector storage write failed" in result.error
+        # Completion callback still fires so the display is updated
+        progress_callback.assert_called()
+
+    def test_slot_completes_only_after_write(self):
+        release = threading.Event()
+        vector_store = SlowVectorStore(release=release)
+        slot_tracker = CleanSlotTracker(max_slots=4)
+
+        with self._create_manager(vector_store, 2, slot_tracker) as manager:
+            future = manager.submit_file_for_processing(
+                self.files[0], self._metadata(self.files[0]), None
+            )
+            assert vector_store.writing.wait(timeout=10.0)
+            statuses = [f.status for f in slot_tracker.get_display_files()]
+            assert statuses == [FileStatus.FINALIZING]
+            # The writer still holds the slot
+            assert slot_tracker.get_available_slot_count() == 3
+
+            release.set()
+            assert future.result(timeout=10.0).success
+
+        assert [f.status for f in slot_tracker.get_display_files()] == [
+            FileStatus.COMPLETE
+        ]
+        assert slot_tracker.get_available_slot_count() == 4
+
+    def test_failed_write_is_not_marked_complete(self):
+        vector_store = SlowVectorStore(fail=True)
+        slot_tracker = CleanSlotTracker(max_slots=4)
+
+        with self._create_manager(vector_store, 2, slot_tracker) as manager:
+            future = manager.submit_file_for_processing(
+                self.files[0], self._metadata(self.files[0]), None
+            )
+            assert not future.result(timeout=10.0).success
+
+        assert [f.status for f in slot_tracker.get_display_files()] == [
+            FileStatus.FINALIZING
+        ]
+        assert slot_tracker.get_available_slot_count() == 4
+
+    def test_file_finished_after_stage_closed_is_written_by_worker(self):
+        vector_store = SlowVectorStore()
+        slot_tracker = CleanSlotTracker(max_slots=4)
+
+        with self._create_manager(vector_store, 2, slot_tracker) as manager:
+            manager._upsert_stage.close()
+            future = manager.submit_file_for_processing(
+                self.files[0], self._metadata(self.files[0]), None
+            )
+            result = future.result(timeout=10.0)
+
+        assert result.success
+        assert vector_store.upserted_paths == [self.files[0].name]
+        assert vector_store.upsert_threads != ["UpsertWriter"]
+        assert slot_tracker.get_available_slot_count() == 4
+
+    def test_inline_mode_writes_on_worker_threads(self):
+        vector_store = SlowVectorStore()
+
+        with self._create_manager(vector_store, upsert_queue_size=0) as manager:
+            futures = [
+                manager.submit_file_for_processing(f, self._metadata(f), None)
+                for f in self.files
+            ]
+            results = [f.result(timeout=10.0) for f in futures]
+
+        assert all(r.success for r in results)
+        assert all(name.startswith("FileChunk") for name in vector_store.upsert_threads)
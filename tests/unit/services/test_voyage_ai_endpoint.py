@@ -0,0 +1,136 @@
+"""
+Unit tests for self-hosted VoyageAI-compatible endpoints.
+
+Tests the token environment variable, TLS settings passed to HTTP clients,
+and check_endpoint() diagnostics for TLS, connectivity, credential and URL
+errors.
+"""
+
+import os
+import ssl
+from unittest.mock import MagicMock, patch
+
+import httpx
+import pytest
+
+from src.code_indexer.config import VoyageAIConfig
+from src.code_indexer.services.voyage_ai import VoyageAIClient
+
+GATEWAY = "https://embeddings.internal.example.com/v1/embeddings"
+
+
+def _mock_client(response=None, error=None):
+    """Patchable httpx.Client whose post() returns response or raises error."""
+    client = MagicMock()
+    client.__enter__.return_value = client
+    if error is not None:
+        client.post.side_effect = error
+    else:
+        client.post.return_value = response
+    return MagicMock(return_value=client)
+
+
+def _response(status_code, text=""):
+    response = MagicMock()
+    response.status_code = status_code
+    response.text = text
+    return response
+
+
+class TestVoyageAIEndpointConfig:
+    """Tests for api_key_env and TLS settings."""
+
+    def test_token_read_from_configured_variable(self):
+        config = VoyageAIConfig(api_endpoint=GATEWAY, api_key_env="TEAM_TOKEN")
+
+        with patch.dict(os.environ, {"TEAM_TOKEN": "team-secret"}, clear=True):
+            client = VoyageAIClient(config)
+
+        assert client.api_key == "team-secret"
+
+    def test_missing_token_names_configured_variable(self):
+        config = VoyageAIConfig(api_key_env="TEAM_TOKEN")
+
+        with patch.dict(os.environ, {}, clear=True):
+            with pytest.raises(ValueError, match="TEAM_TOKEN"):
+                VoyageAIClient(config)
+
+    @pytest.mark.parametrize(
+        "settings,expected",
+        [
+            ({}, True),
+            ({"ca_bundle": "/etc/ssl/internal-ca.pem"}, "/etc/ssl/internal-ca.pem"),
+            ({"ca_bundle": "/etc/ssl/internal-ca.pem", "verify_tls": False}, False),
+        ],
+    )
+    def test_tls_settings_passed_to_http_client(self, settings, expected):
+        config = VoyageAIConfig(api_endpoint=GATEWAY, **settings)
+        with patch.dict(os.environ, {"VOYAGE_API_KEY": "key"}):
+            client = VoyageAIClient(config)
+        mock_client = _mock_client(_response(200))
+
+        with patch("src.code_indexer.services.voyage_ai.httpx.Client", mock_client):
+            client.check_endpoint()
+
+        assert mock_client.call_args.kwargs["verify"] == expected
+
+
+class TestCheckEndpoint:
+    """Tests for VoyageAIClient.check_endpoint()."""
+
+    @pytest.fixture
+    def client(self):
+        with patch.dict(os.environ, {"VOYAGE_API_KEY": "key"}):
+            return VoyageAIClient(VoyageAIConfig(api_endpoint=GATEWAY))
+
+    def _check(self, client, response=None, error=None):
+        with patch(
+            "src.code_indexer.services.voyage_ai.httpx.Client",
+            _mock_client(response, error),
+        ):
+            return client.check_endpoint()
+
+    def test_reachable(self, client):
+        ok, detail = self._check(client, _response(200))
+
+        assert ok is True
+        assert detail.startswith("Reachable (HTTP 200")
+
+    def test_rate_limited_endpoint_is_reachable(self, client):
+        ok, _ = self._check(client, _response(429))
+
+        assert ok is True
+
+    def test_rejected_token(self, client):
+        ok, detail = self._check(client, _response(401))
+
+        assert ok is False
+        assert "VOYAGE_API_KEY" in detail
+
+    def test_wrong_url(self, client):
+        ok, detail = self._check(client, _response(404))
+
+        assert ok is False
+        assert "voyage_ai.api_endpoint" in detail
+
+    def test_tls_failure_suggests_ca_bundle(self, client):
+        error = httpx.ConnectError("[SSL: CERTIFICATE_VERIFY_FAILED] self-signed")
+        error.__cause__ = ssl.SSLError("certificate verify failed")
+
+        ok, detail = self._check(client, error=error)
+
+        assert ok is False
+        assert "TLS verification failed for embeddings.internal.example.com" in detail
+        assert "voyage_ai.ca_bundle" in detail
+
+    def test_unreachable_host(self, client):
+        ok, detail = self._check(client, error=httpx.ConnectError("Name not known"))
+
+        assert ok is False
+        assert detail.startswith("Cannot connect to embeddings.internal.example.com")
+
+    def test_timeout(self, client):
+        ok, detail = self._check(client, error=httpx.ReadTimeout("timed out"))
+
+        assert ok is False
+        assert "voyage_ai.timeout" in detail
@@ -0,0 +1,127 @@
+"""
+Unit tests for workspaces of local repositories searched together.
+
+Tests registering repositories, resolving aliases, merging the rankings of
+several repositories, and fanning a query out while tolerating failures.
+"""
+
+from unittest.mock import patch
+
+import pytest
+
+from code_indexer.services.workspace import (
+    MERGED_SCORE_KEY,
+    REPOSITORY_KEY,
+    WorkspaceRegistry,
+    federated_search,
+    merge_results,
+)
+
+
+def indexed_repo(path):
+    (path / ".code-indexer").mkdir(parents=True)
+    (path / ".code-indexer" / "config.json").write_text("{}")
+    return path
+
+
+def result(path, score):
+    return {"score": score, "payload": {"path": path}}
+
+
+@pytest.fixture
+def registry(tmp_path):
+    return WorkspaceRegistry(tmp_path / "home" / "workspace.json")
+
+
+class TestWorkspaceRegistry:
+    """Tests for WorkspaceRegistry."""
+
+    def test_add_list_remove(self, tmp_path, registry):
+        backend = indexed_repo(tmp_path / "backend")
+        web = indexed_repo(tmp_path / "web")
+
+        assert registry.add(backend) == "backend"
+        assert registry.add(web, alias="frontend") == "frontend"
+        assert WorkspaceRegistry(registry.workspace_file).repositories == {
+            "backend": backend.resolve(),
+            "frontend": web.resolve(),
+        }
+
+        assert registry.remove("backend") is True
+        assert registry.remove("backend") is False
+        assert list(registry.repositories) == ["frontend"]
+
+    def test_rejects_unindexed_repositories_and_taken_aliases(
+        self, tmp_path, registry
+    ):
+        (tmp_path / "plain").mkdir()
+        with pytest.raises(ValueError, match="not an indexed repository"):
+            registry.add(tmp_path / "plain")
+
+        registry.add(indexed_repo(tmp_path / "a" / "api"))
+        with pytest.raises(ValueError, match="taken"):
+            registry.add(indexed_repo(tmp_path / "b" / "api"))
+        with pytest.raises(ValueError, match="Invalid alias"):
+            registry.add(tmp_path / "a" / "api", alias="my api")
+
+    def test_resolve_in_given_order(self, tmp_path, registry):
+        registry.add(indexed_repo(tmp_path / "backend"))
+        registry.add(indexed_repo(tmp_path / "infra"))
+
+        assert list(registry.resolve(["infra", "backend"])) == ["infra", "backend"]
+        with pytest.raises(ValueError, match="frontend"):
+            registry.resolve(["backend", "frontend"])
+
+
+class TestMergeResults:
+    """Tests for merge_results."""
+
+    REPO_RESULTS = {
+        "backend": [result("a.py", 0.9), result("b.py", 0.5)],
+        "frontend": [result("x.ts", 0.7)],
+    }
+
+    def test_same_model_merges_by_score(self):
+        merged = merge_results(
+            self.REPO_RESULTS, {"backend": "voyage/m", "frontend": "voyage/m"}, 10
+        )
+
+        assert [(r[REPOSITORY_KEY], r["score"]) for r in merged] == [
+            ("backend", 0.9),
+            ("frontend", 0.7),
+            ("backend", 0.5),
+        ]
+        assert REPOSITORY_KEY not in self.REPO_RESULTS["backend"][0]
+
+    def test_different_models_merge_by_rank(self):
+        merged = merge_results(
+            self.REPO_RESULTS, {"backend": "voyage/m", "frontend": "ollama/n"}, 2
+        )
+
+        assert [r["payload"]["path"] for r in merged] == ["a.py", "x.ts"]
+        assert merged[0][MERGED_SCORE_KEY] == merged[1][MERGED_SCORE_KEY]
+
+
+class TestFederatedSearch:
+    """Tests for federated_search."""
+
+    @patch("code_indexer.services.workspace.search_repository")
+    def test_failed_repositories_are_reported(self, search_repository, tmp_path):
+        def search(root, query, limit, filter_conditions):
+            if root.name == "infra":
+                raise RuntimeError("index missing")
+            return [result(f"{root.name}.py", 0.8), result("low.py", 0.2)], "m"
+
+        search_repository.side_effect = search
+
+        results, errors = federated_search(
+            {"backend": tmp_path / "backend", "infra": tmp_path / "infra"},
+            "query",
+            5,
+            min_score=0.5,
+        )
+
+        assert [(r[REPOSITORY_KEY], r["payload"]["path"]) for r in results] == [
+            ("backend", "backend.py")
+        ]
+        assert errors == {"infra": "index missing"}
@@ -0,0 +1,221 @@
+"""Unit tests for zstd compression of stored chunk text and payload fields.
+
+Covers the vector data encoding, per-collection settings, the migration of
+existing collections, and FilesystemVectorStore reads/writes of both formats.
+"""
+
+import json
+
+import numpy as np
+import pytest
+
+from code_indexer.storage.chunk_compression import (
+    COMPRESSED_CHUNK_TEXT_KEY,
+    COMPRESSED_PAYLOAD_KEY,
+    CompressionSettings,
+    compress_text,
+    compress_vector_data,
+    decompress_text,
+    decompress_vector_data,
+    migrate_collection,
+)
+
+CHUNK_TEXT = "def foo():\n    return 42\n" * 20
+
+
+def _vector_data(chunk_text=CHUNK_TEXT, **payload):
+    return {
+        "id": "point_1",
+        "vector": [0.1, 0.2, 0.3],
+        "payload": {"path": "src/foo.py", "language": "py", **payload},
+        "chunk_text": chunk_text,
+    }
+
+
+def _write_collection(collection_path, vectors, metadata=None):
+    (collection_path / "ab").mkdir(parents=True)
+    with open(collection_path / "collection_meta.json", "w") as f:
+        json.dump(metadata or {"name": collection_path.name, "vector_size": 3}, f)
+    for i, data in enumerate(vectors):
+        with open(collection_path / "ab" / f"vector_{i}.json", "w") as f:
+            json.dump(data, f, indent=2)
+
+
+class TestVectorDataEncoding:
+    """Tests for compress_vector_data()/decompress_vector_data()."""
+
+    def test_text_round_trip(self):
+        text = "unicode ✓ and\nnewlines\t" * 10
+
+        assert decompress_text(compress_text(text)) == text
+
+    def test_chunk_text_moves_to_compressed_key(self):
+        data = compress_vector_data(_vector_data(), CompressionSettings())
+
+        assert "chunk_text" not in data
+        assert COMPRESSED_CHUNK_TEXT_KEY in data
+        assert len(data[COMPRESSED_CHUNK_TEXT_KEY]) < len(CHUNK_TEXT)
+
+    def test_round_trip_restores_original(self):
+        original = _vector_data(commit_message="m" * 5000)
+        settings = CompressionSettings(payload_min_bytes=1024)
+
+        data = compress_vector_data(json.loads(json.dumps(original)), settings)
+
+        assert decompress_vector_data(data) == original
+
+    def test_payload_fields_compressed_only_when_enabled(self):
+        data = compress_vector_data(
+            _vector_data(commit_message="m" * 5000), CompressionSettings()
+        )
+
+        assert COMPRESSED_PAYLOAD_KEY not in data
+        assert data["payload"]["commit_message"] == "m" * 5000
+
+    def test_payload_threshold_and_path_exclusion(self):
+        long_path = "dir/" * 500 + "file.py"
+        data = _vector_data(commit_message="m" * 5000, author="short")
+        data["payload"]["path"] = long_path
+
+        compress_vector_data(data, CompressionSettings(payload_min_bytes=1024))
+
+        assert list(data[COMPRESSED_PAYLOAD_KEY]) == ["commit_message"]
+        assert data["payload"]["author"] == "short"
+        assert data["payload"]["path"] == long_path
+
+    def test_disabled_settings_leave_data_untouched(self):
+        data = compress_vector_data(
+            _vector_data(), CompressionSettings(enabled=False)
+        )
+
+        assert data == _vector_data()
+
+    def test_plain_data_decodes_unchanged(self):
+        assert decompress_vector_data(_vector_data()) == _vector_data()
+
+
+class TestCompressionSettings:
+    """Tests for per-collection compression settings."""
+
+    def test_legacy_metadata_is_uncompressed(self):
+        settings = CompressionSettings.from_metadata({"vector_size": 1024})
+
+        assert not settings.enabled
+
+    def test_metadata_round_trip(self):
+        settings = CompressionSettings(level=9, payload_min_bytes=2048)
+
+        restored = CompressionSettings.from_metadata(
+            {"compression": settings.to_metadata()}
+        )
+
+        assert restored == settings
+
+
+class TestMigrateCollection:
+    """Tests for migrate_collection()."""
+
+    def test_compresses_existing_vectors_and_records_settings(self, tmp_path):
+        collection_path = tmp_path / "code"
+        _write_collection(collection_path, [_vector_data(), _vector_data()])
+
+        result = migrate_collection(collection_path, CompressionSettings())
+
+        assert result.files_scanned == 2
+        assert result.files_rewritten == 2
+        assert result.bytes_after < result.bytes_before
+        with open(collection_path / "ab" / "vector_0.json") as f:
+            stored = json.load(f)
+        assert COMPRESSED_CHUNK_TEXT_KEY in stored
+        assert decompress_vector_data(stored) == _vector_data()
+        with open(collection_path / "collection_meta.json") as f:
+            metadata = json.load(f)
+        assert CompressionSettings.from_metadata(metadata).enabled
+
+    def test_rerun_rewrites_nothing(self, tmp_path):
+        collection_path = tmp_path / "code"
+        _write_collection(collection_path, [_vector_data()])
+        migrate_collection(collection_path, CompressionSettings())
+
+        result = migrate_collection(collection_path, CompressionSettings())
+
+        assert result.files_rewritten == 0
+
+    def test_decompress_reverts_to_plain_format(self, tmp_path):
+        collection_path = tmp_path / "code"
+        _write_collection(collection_path, [_vector_data()])
+        migrate_collection(collection_path, CompressionSettings())
+
+        migrate_collection(collection_path, CompressionSettings(enabled=False))
+
+        with open(collection_path / "ab" / "vector_0.json") as f:
+            assert json.load(f) == _vector_data()
+        with open(collection_path / "collection_meta.json") as f:
+            assert "compression" not in json.load(f)
+
+    def test_corrupted_file_is_counted_and_skipped(self, tmp_path):
+        collection_path = tmp_path / "code"
+        _write_collection(collection_path, [_vector_data()])
+        (collection_path / "ab" / "vector_1.json").write_text("{not json")
+
+        result = migrate_collection(collection_path, CompressionSettings())
+
+        assert result.files_failed == 1
+        assert result.files_rewritten == 1
+
+    def test_missing_collection_raises(self, tmp_path):
+        with pytest.raises(FileNotFoundError):
+            migrate_collection(tmp_path / "missing", CompressionSettings())
+
+
+class TestFilesystemVectorStoreCompression:
+    """Tests for compressed storage in FilesystemVectorStore."""
+
+    def _points(self):
+        return [
+            {
+                "id": "test_001",
+                "vector": np.random.randn(64).tolist(),
+                "payload": {"path": "test.py", "line_start": 0, "line_end": 2},
+                "chunk_text": CHUNK_TEXT,
+            }
+        ]
+
+    def _stored_vector(self, collection_path):
+        vector_file = next(collection_path.rglob("vector_*.json"))
+        with open(vector_file) as f:
+            return json.load(f)
+
+    def test_new_collection_stores_compressed_chunk_text(self, tmp_path):
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.create_collection("test_coll", vector_size=64)
+        store.upsert_points("test_coll", self._points())
+
+        stored = self._stored_vector(tmp_path / "test_coll")
+        assert "chunk_text" not in stored
+        assert COMPRESSED_CHUNK_TEXT_KEY in stored
+        assert store.get_point("test_001", "test_coll")["chunk_text"] == CHUNK_TEXT
+
+    def test_legacy_collection_stays_plain_until_migrated(self, tmp_path):
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.create_collection("test_coll", vector_size=64)
+        meta_file = tmp_path / "test_coll" / "collection_meta.json"
+        metadata = json.loads(meta_file.read_text())
+        del metadata["compression"]
+        meta_file.write_text(json.dumps(metadata))
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.upsert_points("test_coll", self._points())
+        assert self._stored_vector(tmp_path / "test_coll")["chunk_text"] == CHUNK_TEXT
+
+        result = store.migrate_compression("test_coll", CompressionSettings())
+
+        assert result.files_rewritten == 1
+        assert COMPRESSED_CHUNK_TEXT_KEY in self._stored_vector(tmp_path / "test_coll")
+        points, _ = store.scroll_points("test_coll")
+        assert points[0]["payload"]["path"] == "test.py"
+        assert store.get_point("test_001", "test_coll")["chunk_text"] == CHUNK_TEXT
@@ -100,9 +100,9 @@ class TestFilesystemVectorStoreCore:
 
         assert len(vector_files) >= 1, "At least one vector file should exist"
 
-        # Verify JSON structure (payload nested as of batch implementation)
-        with open(vector_files[0]) as f:
-            data = json.load(f)
+        # Verify JSON structure (payload nested as of batch implementation,
+        # repeated strings dictionary-encoded)
+        data = store._read_vector_file(vector_files[0], "test_coll")
 
         assert data["id"] == "test_001"
         assert data["payload"]["path"] == "src/test.py"
@@ -356,6 +356,7 @@ class TestChunkContentStorageAndRetrieval:
         AC4: Non-git repos store chunk_text
         AC8: Automatic storage mode detection (non-git)
         """
+        from code_indexer.storage.chunk_compression import decompress_vector_data
         from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
 
         # No git init - plain directory
@@ -383,7 +384,7 @@ class TestChunkContentStorageAndRetrieval:
             f for f in coll_path.rglob("*.json") if "collection_meta" not in f.name
         ]
         with open(json_files[0]) as f:
-            data = json.load(f)
+            data = decompress_vector_data(json.load(f))
 
         assert "chunk_text" in data, "Chunk text should be stored for non-git"
         assert data["chunk_text"] == "def foo():\n    return 42\n"
@@ -481,6 +482,7 @@ class TestChunkContentStorageAndRetrieval:
         dirty_content = "def foo(): return 99\n"
         test_file.write_text(dirty_content)
 
+        from code_indexer.storage.chunk_compression import decompress_vector_data
         from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
 
         store = FilesystemVectorStore(base_path=tmp_path, project_root=tmp_path)
@@ -507,7 +509,7 @@ class TestChunkContentStorageAndRetrieval:
             f for f in coll_path.rglob("*.json") if "collection_meta" not in f.name
         ]
         with open(json_files[0]) as f:
-            data = json.load(f)
+            data = decompress_vector_data(json.load(f))
 
         assert "chunk_text" in data, "Chunk text should be stored for dirty git"
         assert data["chunk_text"] == dirty_content
@@ -574,6 +576,40 @@ class TestSearchMethods:
         result = store.get_point("nonexistent", "test_coll")
         assert result is None
 
+    def test_get_vectors_for_paths_skips_chunk_content(self, tmp_path, test_vectors):
+        """GIVEN chunks of two files
+        WHEN get_vectors_for_paths() is called for one of them
+        THEN returns its vectors and payloads without resolving chunk content
+        """
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.create_collection("test_coll", vector_size=1536)
+        points = [
+            {
+                "id": f"chunk_{n}",
+                "vector": test_vectors[n].tolist(),
+                "payload": {
+                    "path": path,
+                    "embedding_hash": f"hash_{n}",
+                    "content": "x = 1\n",
+                },
+            }
+            for n, path in enumerate(["src/a.py", "src/a.py", "src/b.py"])
+        ]
+        store.upsert_points("test_coll", points)
+        store._get_chunk_content_with_staleness = Mock(side_effect=AssertionError)
+
+        result = store.get_vectors_for_paths("test_coll", ["src/a.py"])
+
+        assert [point["id"] for point in result] == ["chunk_0", "chunk_1"]
+        assert [point["payload"]["embedding_hash"] for point in result] == [
+            "hash_0",
+            "hash_1",
+        ]
+        assert all(len(point["vector"]) == 1536 for point in result)
+        assert all("chunk_text" not in point for point in result)
+
     def test_scroll_points_returns_all_points(self, tmp_path, test_vectors):
         """GIVEN 10 vectors stored
         WHEN scroll_points() is called with limit=100
@@ -0,0 +1,277 @@
+"""Unit tests for encryption at rest of stored chunk text and payloads.
+
+Covers the vector data encryption, key sources (OS keyring and passphrase),
+the migration of existing collections, and FilesystemVectorStore reads/writes
+of encrypted collections.
+"""
+
+import json
+import sys
+from types import SimpleNamespace
+
+import numpy as np
+import pytest
+
+from code_indexer.storage import index_encryption
+from code_indexer.storage.index_encryption import (
+    ENCRYPTED_DATA_KEY,
+    ENCRYPTION_METADATA_KEY,
+    KEYRING_SERVICE,
+    PASSPHRASE_ENV_VAR,
+    EncryptionSettings,
+    IndexCipher,
+    IndexKeyError,
+    create_encryption_settings,
+    key_source_from_config,
+    load_cipher,
+    migrate_collection,
+)
+
+CHUNK_TEXT = "def secret_algorithm():\n    return 42\n"
+
+
+class FakeKeyring:
+    """In-memory stand-in for the keyring package."""
+
+    def __init__(self):
+        self.passwords = {}
+
+    def set_password(self, service, username, password):
+        self.passwords[(service, username)] = password
+
+    def get_password(self, service, username):
+        return self.passwords.get((service, username))
+
+    def delete_password(self, service, username):
+        del self.passwords[(service, username)]
+
+
+@pytest.fixture(autouse=True)
+def isolated_keys(monkeypatch):
+    """Fresh key cache, passphrase and keyring for every test."""
+    monkeypatch.setattr(index_encryption, "_key_cache", {})
+    monkeypatch.setattr(index_encryption, "_passphrase", None)
+    monkeypatch.delenv(PASSPHRASE_ENV_VAR, raising=False)
+    keyring = FakeKeyring()
+    monkeypatch.setitem(sys.modules, "keyring", keyring)
+    return keyring
+
+
+def _vector_data():
+    return {
+        "id": "point_1",
+        "vector": [0.1, 0.2, 0.3],
+        "payload": {"path": "src/foo.py", "language": "py", "line_start": 1},
+        "chunk_text": CHUNK_TEXT,
+    }
+
+
+def _cipher():
+    return IndexCipher(b"k" * 32)
+
+
+class TestIndexCipher:
+    """Tests for encrypt_vector_data()/decrypt_vector_data()."""
+
+    def test_round_trip_restores_original(self):
+        data = _cipher().encrypt_vector_data(_vector_data())
+
+        assert _cipher().decrypt_vector_data(data) == _vector_data()
+
+    def test_source_text_is_not_stored_in_plaintext(self):
+        data = _cipher().encrypt_vector_data(_vector_data())
+
+        assert "chunk_text" not in data
+        assert "secret_algorithm" not in json.dumps(data)
+        assert data["payload"] == {"path": "src/foo.py"}
+        assert data["vector"] == [0.1, 0.2, 0.3]
+
+    def test_plain_data_decodes_unchanged(self):
+        assert _cipher().decrypt_vector_data(_vector_data()) == _vector_data()
+
+    def test_ciphertext_is_bound_to_point_id(self):
+        data = _cipher().encrypt_vector_data(_vector_data())
+        data["id"] = "point_2"
+
+        with pytest.raises(ValueError):
+            _cipher().decrypt_vector_data(data)
+
+    def test_wrong_key_fails_authentication(self):
+        data = _cipher().encrypt_vector_data(_vector_data())
+
+        with pytest.raises(ValueError):
+            IndexCipher(b"x" * 32).decrypt_vector_data(data)
+
+
+class TestKeySources:
+    """Tests for creating and loading collection keys."""
+
+    def test_keyring_key_round_trip(self, isolated_keys):
+        settings = create_encryption_settings("keyring")
+        data = load_cipher(settings).encrypt_vector_data(_vector_data())
+
+        assert (KEYRING_SERVICE, settings.key_id) in isolated_keys.passwords
+        index_encryption._key_cache.clear()
+        assert load_cipher(settings).decrypt_vector_data(data) == _vector_data()
+
+    def test_missing_keyring_entry_raises(self, isolated_keys):
+        settings = create_encryption_settings("keyring")
+        isolated_keys.passwords.clear()
+        index_encryption._key_cache.clear()
+
+        with pytest.raises(IndexKeyError):
+            load_cipher(settings)
+
+    def test_passphrase_key_from_environment(self, monkeypatch):
+        monkeypatch.setenv(PASSPHRASE_ENV_VAR, "correct horse")
+        settings = create_encryption_settings("passphrase")
+        data = load_cipher(settings).encrypt_vector_data(_vector_data())
+
+        assert settings.salt
+        index_encryption._key_cache.clear()
+        index_encryption._passphrase = None
+        assert load_cipher(settings).decrypt_vector_data(data) == _vector_data()
+
+    def test_wrong_passphrase_fails_key_check(self, monkeypatch):
+        monkeypatch.setenv(PASSPHRASE_ENV_VAR, "correct horse")
+        settings = create_encryption_settings("passphrase")
+        index_encryption._key_cache.clear()
+        index_encryption._passphrase = None
+        monkeypatch.setenv(PASSPHRASE_ENV_VAR, "battery staple")
+
+        with pytest.raises(IndexKeyError, match="Wrong passphrase key"):
+            load_cipher(settings)
+
+    def test_metadata_round_trip(self):
+        settings = create_encryption_settings("keyring")
+        metadata = {ENCRYPTION_METADATA_KEY: settings.to_metadata()}
+
+        assert EncryptionSettings.from_metadata(metadata) == settings
+        assert EncryptionSettings.from_metadata({}) is None
+
+    def test_key_source_from_config(self):
+        enabled = SimpleNamespace(
+            encryption=SimpleNamespace(enabled=True, key_source="passphrase")
+        )
+        disabled = SimpleNamespace(
+            encryption=SimpleNamespace(enabled=False, key_source="keyring")
+        )
+
+        assert key_source_from_config(enabled) == "passphrase"
+        assert key_source_from_config(disabled) is None
+        assert key_source_from_config(object()) is None
+
+
+class TestMigrateCollection:
+    """Tests for migrate_collection()."""
+
+    def _write_collection(self, collection_path):
+        (collection_path / "ab").mkdir(parents=True)
+        (collection_path / "collection_meta.json").write_text(
+            json.dumps({"name": collection_path.name, "vector_size": 3})
+        )
+        vector_file = collection_path / "ab" / "vector_point_1.json"
+        vector_file.write_text(json.dumps(_vector_data(), indent=2))
+        return vector_file
+
+    def test_encrypt_then_decrypt(self, tmp_path):
+        vector_file = self._write_collection(tmp_path / "code")
+        settings = create_encryption_settings("keyring")
+        cipher = load_cipher(settings)
+
+        result = migrate_collection(tmp_path / "code", None, settings, cipher)
+
+        assert result.files_rewritten == 1
+        assert ENCRYPTED_DATA_KEY in json.loads(vector_file.read_text())
+        metadata = json.loads((tmp_path / "code" / "collection_meta.json").read_text())
+        assert metadata[ENCRYPTION_METADATA_KEY]["key_id"] == settings.key_id
+
+        rerun = migrate_collection(tmp_path / "code", cipher, settings, cipher)
+        assert rerun.files_rewritten == 0
+
+        migrate_collection(tmp_path / "code", cipher, None, None)
+
+        assert json.loads(vector_file.read_text()) == _vector_data()
+        metadata = json.loads((tmp_path / "code" / "collection_meta.json").read_text())
+        assert ENCRYPTION_METADATA_KEY not in metadata
+
+    def test_failed_decryption_keeps_key_metadata(self, tmp_path):
+        self._write_collection(tmp_path / "code")
+        settings = create_encryption_settings("keyring")
+        migrate_collection(tmp_path / "code", None, settings, load_cipher(settings))
+
+        result = migrate_collection(
+            tmp_path / "code", IndexCipher(b"x" * 32), None, None
+        )
+
+        assert result.files_failed == 1
+        metadata = json.loads((tmp_path / "code" / "collection_meta.json").read_text())
+        assert ENCRYPTION_METADATA_KEY in metadata
+
+
+class TestFilesystemVectorStoreEncryption:
+    """Tests for encrypted storage in FilesystemVectorStore."""
+
+    def _points(self):
+        return [
+            {
+                "id": "test_001",
+                "vector": np.random.randn(64).tolist(),
+                "payload": {"path": "test.py", "line_start": 0, "line_end": 2},
+                "chunk_text": CHUNK_TEXT,
+            }
+        ]
+
+    def _stored_vector(self, collection_path):
+        vector_file = next(collection_path.rglob("vector_*.json"))
+        with open(vector_file) as f:
+            return json.load(f)
+
+    def test_migrated_collection_encrypts_reads_and_writes(self, tmp_path):
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.create_collection("test_coll", vector_size=64)
+        store.upsert_points("test_coll", self._points())
+
+        result = store.migrate_encryption("test_coll", "keyring")
+
+        assert result.files_rewritten == 1
+        stored = self._stored_vector(tmp_path / "test_coll")
+        assert ENCRYPTED_DATA_KEY in stored
+        assert "secret_algorithm" not in json.dumps(stored)
+
+        # A fresh store resolves the key from the collection's metadata
+        store = FilesystemVectorStore(base_path=tmp_path)
+        point = store.get_point("test_001", "test_coll")
+        assert point["chunk_text"] == CHUNK_TEXT
+        assert point["payload"]["line_end"] == 2
+        assert store.get_all_indexed_files("test_coll") == ["test.py"]
+
+    def test_decrypt_removes_keyring_key(self, tmp_path, isolated_keys):
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.create_collection("test_coll", vector_size=64)
+        store.migrate_encryption("test_coll", "keyring")
+        store.upsert_points("test_coll", self._points())
+
+        store.migrate_encryption("test_coll", None)
+
+        assert ENCRYPTED_DATA_KEY not in self._stored_vector(tmp_path / "test_coll")
+        assert isolated_keys.passwords == {}
+        assert store.get_point("test_001", "test_coll")["chunk_text"] == CHUNK_TEXT
+
+    def test_missing_key_fails_instead_of_skipping(self, tmp_path, isolated_keys):
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.create_collection("test_coll", vector_size=64)
+        store.migrate_encryption("test_coll", "keyring")
+        store.upsert_points("test_coll", self._points())
+        isolated_keys.passwords.clear()
+        index_encryption._key_cache.clear()
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        with pytest.raises(IndexKeyError):
+            store.scroll_points("test_coll")
@@ -0,0 +1,171 @@
+"""
+Unit tests for index format versioning.
+
+Tests format version stamping of new collections, migration of older
+collections, rejection of collections written by newer versions, and the
+re-index instructions for collections that cannot be migrated.
+"""
+
+import json
+
+import pytest
+
+from code_indexer.storage import index_format
+from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+from code_indexer.storage.index_format import (
+    FORMAT_VERSION_KEY,
+    INDEX_FORMAT_VERSION,
+    IndexFormatError,
+    check_collection_format,
+    ensure_collection_format,
+)
+
+
+def _write_meta(collection_path, **metadata):
+    collection_path.mkdir(parents=True, exist_ok=True)
+    (collection_path / "collection_meta.json").write_text(json.dumps(metadata))
+
+
+def _read_meta(collection_path):
+    return json.loads((collection_path / "collection_meta.json").read_text())
+
+
+class TestEnsureCollectionFormat:
+    """Tests for ensure_collection_format()."""
+
+    def test_current_collection_unchanged(self, tmp_path):
+        collection = tmp_path / "code-indexer"
+        _write_meta(collection, vector_size=8, format_version=INDEX_FORMAT_VERSION)
+
+        assert ensure_collection_format(collection) is False
+
+    def test_unversioned_collection_is_stamped(self, tmp_path):
+        collection = tmp_path / "code-indexer"
+        _write_meta(collection, vector_size=8, compression={"codec": "zstd"})
+
+        assert ensure_collection_format(collection) is True
+
+        metadata = _read_meta(collection)
+        assert metadata[FORMAT_VERSION_KEY] == INDEX_FORMAT_VERSION
+        assert metadata["compression"] == {"codec": "zstd"}
+        assert not (collection / "collection_meta.json.tmp").exists()
+
+    def test_unversioned_collection_without_vector_size_needs_reindex(self, tmp_path):
+        collection = tmp_path / "code-indexer"
+        _write_meta(collection, name="code-indexer")
+
+        with pytest.raises(IndexFormatError) as exc_info:
+            ensure_collection_format(collection)
+
+        assert "vector size" in exc_info.value.reason
+        assert "cidx index --clear" in str(exc_info.value)
+
+    def test_legacy_temporal_collection_needs_reindex(self, tmp_path):
+        collection = tmp_path / "code-indexer-temporal"
+        _write_meta(collection, vector_size=8)
+        (collection / "ab").mkdir()
+        (collection / "ab" / "vector_project_diff_abc_src_main.py_0.json").write_text(
+            "{}"
+        )
+
+        with pytest.raises(IndexFormatError, match="--index-commits"):
+            ensure_collection_format(collection)
+
+    def test_newer_collection_is_rejected(self, tmp_path):
+        collection = tmp_path / "code-indexer"
+        _write_meta(collection, vector_size=8, format_version=INDEX_FORMAT_VERSION + 1)
+
+        with pytest.raises(IndexFormatError, match="newer version of cidx"):
+            ensure_collection_format(collection)
+
+        assert _read_meta(collection)[FORMAT_VERSION_KEY] == INDEX_FORMAT_VERSION + 1
+
+    def test_runs_registered_migrations_in_order(self, tmp_path, monkeypatch):
+        calls = []
+
+        def step(version):
+            def migrate(collection_path, metadata):
+                calls.append(version)
+                metadata[f"migrated_from_{version}"] = True
+                return metadata
+
+            return migrate
+
+        monkeypatch.setattr(index_format, "INDEX_FORMAT_VERSION", 4)
+        monkeypatch.setattr(
+            index_format, "_MIGRATIONS", {version: step(version) for version in (2, 3)}
+        )
+        collection = tmp_path / "code-indexer"
+        _write_meta(collection, vector_size=8, format_version=2)
+
+        assert ensure_collection_format(collection) is True
+
+        metadata = _read_meta(collection)
+        assert calls == [2, 3]
+        assert metadata[FORMAT_VERSION_KEY] == 4
+        assert metadata["migrated_from_2"] and metadata["migrated_from_3"]
+
+    def test_missing_migration_needs_reindex(self, tmp_path, monkeypatch):
+        monkeypatch.setattr(index_format, "INDEX_FORMAT_VERSION", 3)
+        collection = tmp_path / "code-indexer"
+        _write_meta(collection, vector_size=8, format_version=2)
+
+        with pytest.raises(IndexFormatError, match="Re-index required"):
+            ensure_collection_format(collection)
+
+    def test_duplicate_migration_registration_rejected(self):
+        with pytest.raises(ValueError, match="already registered"):
+            index_format.register_migration(index_format.UNVERSIONED_FORMAT)(
+                lambda collection_path, metadata: metadata
+            )
+
+
+class TestCheckCollectionFormat:
+    """Tests for check_collection_format()."""
+
+    def test_reports_status(self, tmp_path):
+        collection = tmp_path / "code-indexer"
+        _write_meta(collection, vector_size=8)
+
+        status = check_collection_format(collection)
+
+        assert status.version == index_format.UNVERSIONED_FORMAT
+        assert status.needs_migration is True
+        assert status.is_newer is False
+
+    def test_missing_collection(self, tmp_path):
+        assert check_collection_format(tmp_path / "missing") is None
+
+
+class TestFilesystemVectorStoreFormat:
+    """Tests for format handling in FilesystemVectorStore."""
+
+    def test_new_collection_is_stamped(self, tmp_path):
+        store = FilesystemVectorStore(base_path=tmp_path)
+
+        store.create_collection("code-indexer", vector_size=64)
+
+        metadata = _read_meta(tmp_path / "code-indexer")
+        assert metadata[FORMAT_VERSION_KEY] == INDEX_FORMAT_VERSION
+
+    def test_begin_indexing_rejects_newer_collection(self, tmp_path):
+        _write_meta(
+            tmp_path / "code-indexer",
+            vector_size=64,
+            format_version=INDEX_FORMAT_VERSION + 1,
+        )
+        store = FilesystemVectorStore(base_path=tmp_path)
+
+        with pytest.raises(IndexFormatError):
+            store.begin_indexing("code-indexer")
+
+    def test_clear_collection_restamps_metadata(self, tmp_path):
+        collection = tmp_path / "code-indexer"
+        _write_meta(
+            collection, vector_size=64, format_version=INDEX_FORMAT_VERSION + 1
+        )
+        store = FilesystemVectorStore(base_path=tmp_path)
+
+        assert store.clear_collection("code-indexer") is True
+
+        assert _read_meta(collection)[FORMAT_VERSION_KEY] == INDEX_FORMAT_VERSION
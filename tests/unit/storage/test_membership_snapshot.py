@@ -0,0 +1,152 @@
+"""Unit tests for the persisted index membership snapshot."""
+
+from code_indexer.storage.membership_snapshot import (
+    MEMBERSHIP_SNAPSHOT_FILENAME,
+    IndexMembershipSnapshot,
+    payload_timestamp,
+)
+
+
+def _timestamp(payload):
+    return float(payload.get("file_mtime", 0.0))
+
+
+POINTS = [
+    {
+        "id": "p1",
+        "payload": {
+            "path": "/repo/src/a.py",
+            "file_mtime": 5.0,
+            "git_commit_hash": "abc",
+            "hidden_branches": ["old"],
+            "language": "py",
+        },
+    },
+    {"id": "p2", "payload": {"path": "/repo/src/a.py", "file_mtime": 9.0}},
+    {"id": "p3", "payload": {"path": "b.py", "file_mtime": 1.0}},
+    {"id": "p4", "payload": {"type": "metadata"}},
+]
+
+
+def _normalize(path):
+    return path[len("/repo/") :] if path.startswith("/repo/") else path
+
+
+class TestIndexMembershipSnapshot:
+    """Tests for IndexMembershipSnapshot."""
+
+    def test_from_points_keeps_first_chunk_and_latest_timestamp(self):
+        snapshot = IndexMembershipSnapshot.from_points(POINTS, _timestamp, _normalize)
+
+        assert sorted(snapshot) == ["b.py", "src/a.py"]
+        entry = snapshot.get("src/a.py")
+        assert entry.point_id == "p1"
+        assert entry.timestamp == 9.0
+        assert entry.payload == {"git_commit_hash": "abc", "hidden_branches": ["old"]}
+        assert "missing.py" not in snapshot
+
+    def test_save_and_load_round_trip(self, tmp_path):
+        path = tmp_path / MEMBERSHIP_SNAPSHOT_FILENAME
+        snapshot = IndexMembershipSnapshot.from_points(POINTS, _timestamp, _normalize)
+
+        snapshot.save(path)
+        loaded = IndexMembershipSnapshot.load(path)
+
+        assert sorted(loaded) == sorted(snapshot)
+        assert loaded.get("src/a.py") == snapshot.get("src/a.py")
+
+    def test_missing_or_corrupt_snapshot_loads_as_none(self, tmp_path):
+        path = tmp_path / MEMBERSHIP_SNAPSHOT_FILENAME
+        assert IndexMembershipSnapshot.load(path) is None
+
+        path.write_bytes(b"\x00garbage")
+
+        assert IndexMembershipSnapshot.load(path) is None
+
+    def test_update_from_points_replaces_file_entries(self):
+        snapshot = IndexMembershipSnapshot.from_points(POINTS, _timestamp, _normalize)
+
+        snapshot.update_from_points(
+            [
+                {"id": "p5", "payload": {"path": "b.py", "file_mtime": 7.0}},
+                {"id": "p6", "payload": {"path": "/repo/c.py", "file_mtime": 2.0}},
+            ],
+            _timestamp,
+            _normalize,
+        )
+        snapshot.remove("src/a.py")
+
+        assert sorted(snapshot) == ["b.py", "c.py"]
+        assert snapshot.get("b.py").point_id == "p5"
+        assert snapshot.get("b.py").timestamp == 7.0
+
+    def test_payload_timestamp_priority(self):
+        assert payload_timestamp({"file_mtime": 3, "created_at": 9}) == 3.0
+        assert payload_timestamp({"filesystem_mtime": 4, "created_at": 9}) == 4.0
+        assert payload_timestamp({"indexed_at": "not a date"}) == 0.0
+        assert payload_timestamp({}) == 0.0
+
+
+class TestStoreMembershipSnapshot:
+    """Tests for FilesystemVectorStore keeping the snapshot current."""
+
+    def _point(self, point_id, path, mtime):
+        return {
+            "id": point_id,
+            "vector": [0.1] * 64,
+            "payload": {
+                "path": path,
+                "type": "content",
+                "file_mtime": mtime,
+                "line_start": 1,
+                "line_end": 2,
+            },
+            "chunk_text": f"chunk {point_id}",
+        }
+
+    def _store_with_snapshot(self, tmp_path):
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path, project_root=tmp_path)
+        store.create_collection("code", vector_size=64)
+        store.upsert_points(
+            "code",
+            [self._point("a1", "a.py", 1.0), self._point("b1", "b.py", 1.0)],
+        )
+        path = store.get_membership_snapshot_path("code")
+        IndexMembershipSnapshot.from_points(
+            store.scroll_points("code")[0], payload_timestamp
+        ).save(path)
+        return store, path
+
+    def test_upsert_updates_snapshot_and_save_persists_it(self, tmp_path):
+        store, path = self._store_with_snapshot(tmp_path)
+
+        store.upsert_points("code", [self._point("a2", "a.py", 5.0)])
+        store.upsert_points("code", [self._point("c1", "c.py", 2.0)])
+
+        # Removed until the run saves it, so an interrupted run leaves no
+        # stale snapshot behind
+        assert not path.exists()
+        store.save_membership_snapshot("code")
+
+        saved = IndexMembershipSnapshot.load(path)
+        assert sorted(saved) == ["a.py", "b.py", "c.py"]
+        assert saved.get("a.py").point_id == "a2"
+        assert saved.get("a.py").timestamp == 5.0
+
+    def test_delete_drops_files_without_chunks(self, tmp_path):
+        store, path = self._store_with_snapshot(tmp_path)
+
+        store.delete_points("code", ["b1"])
+        store.save_membership_snapshot("code")
+
+        assert sorted(IndexMembershipSnapshot.load(path)) == ["a.py"]
+
+    def test_save_without_writes_keeps_snapshot(self, tmp_path):
+        store, path = self._store_with_snapshot(tmp_path)
+        before = path.read_bytes()
+
+        store.save_membership_snapshot("code")
+
+        assert path.read_bytes() == before
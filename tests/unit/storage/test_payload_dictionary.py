@@ -0,0 +1,230 @@
+"""Unit tests for dictionary encoding of repeated payload strings.
+
+Covers encoding/decoding of scalar and list fields, persistence and crash
+recovery of the append-only lookup table, readers in other processes, and
+FilesystemVectorStore reads/writes of both payload formats.
+"""
+
+import json
+
+import numpy as np
+import pytest
+
+from code_indexer.storage.payload_dictionary import (
+    DEFAULT_DICTIONARY_FIELDS,
+    ENCODED_PAYLOAD_KEY,
+    PAYLOAD_DICTIONARY_FILENAME,
+    PAYLOAD_DICTIONARY_METADATA_KEY,
+    PayloadDictionary,
+    PayloadDictionaryError,
+    dictionary_fields_from_metadata,
+    dictionary_metadata,
+)
+
+
+def _vector_data(path="src/app.py", **payload):
+    return {
+        "id": "point_1",
+        "vector": [0.1, 0.2],
+        "payload": {"path": path, "language": "py", "line_start": 1, **payload},
+    }
+
+
+class TestPayloadDictionaryEncoding:
+    """Tests for PayloadDictionary.intern()/encode()/decode()."""
+
+    def test_round_trip(self, tmp_path):
+        dictionary = PayloadDictionary(tmp_path, DEFAULT_DICTIONARY_FIELDS)
+        original = _vector_data(hidden_branches=["main", "feature"])
+        dictionary.intern([original["payload"]])
+
+        data = dictionary.encode(json.loads(json.dumps(original)))
+
+        assert set(data[ENCODED_PAYLOAD_KEY]) == {"path", "language", "hidden_branches"}
+        assert data["payload"] == {"line_start": 1}
+        assert dictionary.decode(data) == original
+
+    def test_repeated_values_share_ids(self, tmp_path):
+        dictionary = PayloadDictionary(tmp_path, DEFAULT_DICTIONARY_FIELDS)
+        payloads = [_vector_data()["payload"] for _ in range(3)]
+
+        assert dictionary.intern(payloads) == 2  # one path, one language
+        assert dictionary.intern(payloads) == 0
+        encoded = [dictionary.encode(_vector_data()) for _ in range(3)]
+        assert len({json.dumps(d[ENCODED_PAYLOAD_KEY]) for d in encoded}) == 1
+
+    def test_encode_does_not_mutate_caller_payload(self, tmp_path):
+        dictionary = PayloadDictionary(tmp_path, ("path",))
+        payload = {"path": "a.py", "line_start": 1}
+        dictionary.intern([payload])
+
+        dictionary.encode({"id": "p", "payload": payload})
+
+        assert payload == {"path": "a.py", "line_start": 1}
+
+    def test_non_string_values_stay_in_payload(self, tmp_path):
+        dictionary = PayloadDictionary(tmp_path, ("language", "hidden_branches"))
+        data = {"payload": {"language": None, "hidden_branches": [1, 2]}}
+        dictionary.intern([data["payload"]])
+
+        dictionary.encode(data)
+
+        assert ENCODED_PAYLOAD_KEY not in data
+        assert data["payload"] == {"language": None, "hidden_branches": [1, 2]}
+
+    def test_plain_data_decodes_unchanged(self, tmp_path):
+        dictionary = PayloadDictionary(tmp_path, DEFAULT_DICTIONARY_FIELDS)
+
+        assert dictionary.decode(_vector_data()) == _vector_data()
+
+
+class TestPayloadDictionaryPersistence:
+    """Tests for the append-only lookup table on disk."""
+
+    def test_values_survive_reload(self, tmp_path):
+        writer = PayloadDictionary(tmp_path, DEFAULT_DICTIONARY_FIELDS)
+        writer.intern([_vector_data()["payload"]])
+        data = writer.encode(_vector_data())
+
+        reader = PayloadDictionary(tmp_path, DEFAULT_DICTIONARY_FIELDS)
+
+        assert reader.decode(data) == _vector_data()
+
+    def test_reader_picks_up_values_appended_by_another_writer(self, tmp_path):
+        reader = PayloadDictionary(tmp_path, DEFAULT_DICTIONARY_FIELDS)
+        writer = PayloadDictionary(tmp_path, DEFAULT_DICTIONARY_FIELDS)
+        writer.intern([_vector_data(path="new.py")["payload"]])
+
+        data = writer.encode(_vector_data(path="new.py"))
+
+        assert reader.decode(data)["payload"]["path"] == "new.py"
+
+    def test_torn_last_line_is_ignored(self, tmp_path):
+        writer = PayloadDictionary(tmp_path, ("path",))
+        writer.intern([{"path": "a.py"}])
+        with open(tmp_path / PAYLOAD_DICTIONARY_FILENAME, "a") as f:
+            f.write('["path", 1, "b.p')
+
+        reader = PayloadDictionary(tmp_path, ("path",))
+
+        assert len(reader) == 1
+        assert reader.intern([{"path": "b.py"}]) == 1
+
+    def test_unknown_id_raises(self, tmp_path):
+        dictionary = PayloadDictionary(tmp_path, ("path",))
+
+        with pytest.raises(PayloadDictionaryError, match="no path value 7"):
+            dictionary.decode({"payload": {}, ENCODED_PAYLOAD_KEY: {"path": 7}})
+
+    def test_cleared_table_restarts_ids(self, tmp_path):
+        dictionary = PayloadDictionary(tmp_path, ("path",))
+        dictionary.intern([{"path": "a.py"}])
+        (tmp_path / PAYLOAD_DICTIONARY_FILENAME).unlink()
+
+        assert dictionary.intern([{"path": "a.py"}]) == 1
+        assert PayloadDictionary(tmp_path, ("path",)).lookup("path", 0) == "a.py"
+
+    def test_get_path_reads_both_formats(self, tmp_path):
+        dictionary = PayloadDictionary(tmp_path, ("path",))
+        dictionary.intern([{"path": "a.py"}])
+
+        assert dictionary.get_path(dictionary.encode(_vector_data("a.py"))) == "a.py"
+        assert dictionary.get_path(_vector_data("b.py")) == "b.py"
+        assert dictionary.get_path({"payload": {}}) is None
+
+
+class TestDictionaryMetadata:
+    """Tests for the collection_meta.json entry."""
+
+    def test_legacy_metadata_has_no_fields(self):
+        assert dictionary_fields_from_metadata({"vector_size": 1024}) == ()
+
+    def test_metadata_round_trip(self):
+        metadata = {PAYLOAD_DICTIONARY_METADATA_KEY: dictionary_metadata()}
+
+        assert dictionary_fields_from_metadata(metadata) == DEFAULT_DICTIONARY_FIELDS
+
+
+class TestFilesystemVectorStorePayloadDictionary:
+    """Tests for dictionary-encoded payloads in FilesystemVectorStore."""
+
+    def _points(self, count=3):
+        return [
+            {
+                "id": f"test_{i:03d}",
+                "vector": np.random.randn(64).tolist(),
+                "payload": {
+                    "path": "src/module.py",
+                    "language": "py",
+                    "line_start": i * 10,
+                    "line_end": i * 10 + 9,
+                    "type": "content",
+                },
+                "chunk_text": f"chunk {i}",
+            }
+            for i in range(count)
+        ]
+
+    def _stored_vectors(self, collection_path):
+        return [
+            json.loads(f.read_text()) for f in collection_path.rglob("vector_*.json")
+        ]
+
+    def test_new_collection_stores_ids(self, tmp_path):
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.create_collection("test_coll", vector_size=64)
+        store.upsert_points("test_coll", self._points())
+
+        stored = self._stored_vectors(tmp_path / "test_coll")
+        assert all("path" not in data["payload"] for data in stored)
+        assert {data[ENCODED_PAYLOAD_KEY]["path"] for data in stored} == {0}
+
+        point = store.get_point("test_001", "test_coll")
+        assert point["payload"]["path"] == "src/module.py"
+        assert point["payload"]["language"] == "py"
+        points, _ = store.scroll_points("test_coll")
+        assert {p["payload"]["path"] for p in points} == {"src/module.py"}
+
+    def test_legacy_collection_keeps_plain_payloads(self, tmp_path):
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.create_collection("test_coll", vector_size=64)
+        meta_file = tmp_path / "test_coll" / "collection_meta.json"
+        metadata = json.loads(meta_file.read_text())
+        del metadata[PAYLOAD_DICTIONARY_METADATA_KEY]
+        meta_file.write_text(json.dumps(metadata))
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.upsert_points("test_coll", self._points())
+
+        stored = self._stored_vectors(tmp_path / "test_coll")
+        assert all(data["payload"]["path"] == "src/module.py" for data in stored)
+        assert not (tmp_path / "test_coll" / PAYLOAD_DICTIONARY_FILENAME).exists()
+
+    def test_caller_points_keep_their_payload(self, tmp_path):
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.create_collection("test_coll", vector_size=64)
+        points = self._points()
+
+        store.upsert_points("test_coll", points)
+
+        assert all(p["payload"]["path"] == "src/module.py" for p in points)
+
+    def test_recreated_collection_gets_fresh_dictionary(self, tmp_path):
+        from code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
+
+        store = FilesystemVectorStore(base_path=tmp_path)
+        store.create_collection("test_coll", vector_size=64)
+        store.upsert_points("test_coll", self._points())
+        store.delete_collection("test_coll")
+
+        store.create_collection("test_coll", vector_size=64)
+        store.upsert_points("test_coll", self._points())
+
+        point = store.get_point("test_000", "test_coll")
+        assert point["payload"]["path"] == "src/module.py"
@@ -11,6 +11,7 @@ import json
 import numpy as np
 
 
+from src.code_indexer.storage.chunk_compression import decompress_vector_data
 from src.code_indexer.storage.filesystem_vector_store import FilesystemVectorStore
 
 
@@ -105,7 +106,7 @@ class TestTemporalDiffContentBug:
 
             # Load the stored data
             with open(vector_files[0]) as f:
-                stored_data = json.load(f)
+                stored_data = decompress_vector_data(json.load(f))
 
             # CRITICAL ASSERTION: The temporal diff content should be stored
             # Either in chunk_text or in payload["content"]
@@ -0,0 +1,42 @@
+"""
+Unit tests for selecting chunk vectors in searches.
+
+Tests which points each vector mode searches and how signature hits are
+reported as their chunks.
+"""
+
+from code_indexer.storage.vector_kinds import (
+    BODY_POINT_KEY,
+    SIGNATURE_VECTOR,
+    VECTOR_KIND_KEY,
+    matches_vector_mode,
+    merge_by_chunk,
+)
+
+BODY = {"path": "user.py"}
+SIGNATURE = {"path": "user.py", VECTOR_KIND_KEY: SIGNATURE_VECTOR, BODY_POINT_KEY: "b1"}
+
+
+class TestVectorKinds:
+    """Tests for vector modes."""
+
+    def test_modes(self):
+        assert matches_vector_mode(BODY, "body")
+        assert not matches_vector_mode(SIGNATURE, "body")
+        assert matches_vector_mode(SIGNATURE, "signature")
+        assert not matches_vector_mode(BODY, "signature")
+        assert matches_vector_mode(BODY, "both")
+        assert matches_vector_mode(SIGNATURE, "both")
+
+    def test_merge_keeps_the_best_hit_of_each_chunk(self):
+        results = [
+            {"id": "s1", "score": 0.9, "payload": SIGNATURE},
+            {"id": "b2", "score": 0.8, "payload": {"path": "cart.py"}},
+            {"id": "b1", "score": 0.7, "payload": BODY},
+        ]
+
+        merged = merge_by_chunk(results)
+
+        assert [(r["id"], r["score"]) for r in merged] == [("b1", 0.9), ("b2", 0.8)]
+        assert merged[0]["matched_vector"] == SIGNATURE_VECTOR
+        assert "matched_vector" not in merged[1]
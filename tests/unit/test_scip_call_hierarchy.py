@@ -0,0 +1,176 @@
+"""Unit tests for the SCIP call hierarchy query primitive (cidx hierarchy)."""
+
+from unittest.mock import patch
+
+import pytest
+
+try:
+    from pysqlite3 import dbapi2 as sqlite3
+except ImportError:
+    import sqlite3
+
+from code_indexer.scip.database.queries import get_call_edges, get_symbol_locations
+from code_indexer.scip.query.backends import DatabaseBackend
+from code_indexer.scip.query.primitives import QueryResult
+
+SYMBOLS = [
+    (1, "pkg/handler().", "Function"),
+    (2, "pkg/service().", "Function"),
+    (3, "pkg/db_query().", "Function"),
+    (4, "pkg/recurse().", "Function"),
+    (5, "local 0", "Local"),
+    (6, "pkg/CONFIG.", "Variable"),
+    (7, "pkg/main().", "Function"),
+]
+DOCUMENTS = [(1, "app/handler.py"), (2, "app/service.py"), (3, "app/db.py")]
+# id, symbol, document, line (0-indexed), role (1 = definition, 8 = read)
+OCCURRENCES = [
+    (1, 1, 1, 0, 1),
+    (2, 2, 2, 2, 1),
+    (3, 3, 3, 4, 1),
+    (4, 4, 3, 30, 1),
+    (7, 7, 1, 40, 1),
+    (100, 3, 2, 10, 8),
+    (101, 3, 3, 35, 8),
+    (102, 2, 1, 7, 8),
+    (103, 2, 1, 5, 8),
+    (104, 4, 3, 36, 8),
+]
+# caller, callee, occurrence, relationship
+CALLS = [
+    (2, 3, 100, "calls"),
+    (4, 3, 101, "calls"),
+    (1, 2, 102, "calls"),
+    (1, 2, 103, "calls"),
+    (4, 4, 104, "calls"),
+    (7, 2, None, "import"),
+    (5, 3, None, "calls"),
+    (6, 3, None, "calls"),
+]
+
+
+@pytest.fixture
+def conn():
+    conn = sqlite3.connect(":memory:")
+    conn.execute("CREATE TABLE symbols (id INTEGER PRIMARY KEY, name TEXT, kind TEXT)")
+    conn.execute("CREATE TABLE documents (id INTEGER PRIMARY KEY, relative_path TEXT)")
+    conn.execute(
+        "CREATE TABLE occurrences (id INTEGER PRIMARY KEY, symbol_id INTEGER, "
+        "document_id INTEGER, start_line INTEGER, start_char INTEGER, role INTEGER)"
+    )
+    conn.execute(
+        "CREATE TABLE call_graph (id INTEGER PRIMARY KEY, caller_symbol_id INTEGER, "
+        "callee_symbol_id INTEGER, occurrence_id INTEGER, relationship TEXT)"
+    )
+    conn.executemany("INSERT INTO symbols VALUES (?, ?, ?)", SYMBOLS)
+    conn.executemany("INSERT INTO documents VALUES (?, ?)", DOCUMENTS)
+    conn.executemany(
+        "INSERT INTO occurrences VALUES (?, ?, ?, ?, 0, ?)", OCCURRENCES
+    )
+    conn.executemany(
+        "INSERT INTO call_graph (caller_symbol_id, callee_symbol_id, occurrence_id, "
+        "relationship) VALUES (?, ?, ?, ?)",
+        CALLS,
+    )
+    yield conn
+    conn.close()
+
+
+def backend(conn, symbol):
+    """DatabaseBackend over conn whose definition lookup finds symbol."""
+    db_backend = DatabaseBackend.__new__(DatabaseBackend)
+    db_backend.conn = conn
+    db_backend.project_root = ""
+    db_backend.scip_file = None
+    definition = QueryResult(symbol, "", "", 0, 0, "definition")
+    return db_backend, patch.object(
+        DatabaseBackend, "find_definition", return_value=[definition]
+    )
+
+
+def shape(node):
+    return (
+        node.symbol,
+        f"{node.file_path}:{node.line}",
+        node.call_lines,
+        node.recursive,
+        [shape(child) for child in node.children],
+    )
+
+
+def test_call_edges_skip_imports_and_writes(conn):
+    callers = get_call_edges(conn, [2, 3], "callers")
+    callees = get_call_edges(conn, [1], "callees")
+
+    assert [(e["from_id"], e["to_id"], e["call_line"]) for e in callers] == [
+        (2, 1, 5),
+        (2, 1, 7),
+        (3, 5, None),
+        (3, 6, None),
+        (3, 2, 10),
+        (3, 4, 35),
+    ]
+    assert {e["to_id"] for e in callees} == {2}
+    with pytest.raises(ValueError):
+        get_call_edges(conn, [1], "sideways")
+
+
+def test_symbol_locations(conn):
+    locations = get_symbol_locations(conn, [3, 5])
+
+    assert locations[3] == {
+        "symbol_name": "pkg/db_query().",
+        "kind": "Function",
+        "file_path": "app/db.py",
+        "line": 4,
+        "column": 0,
+    }
+    assert locations[5]["file_path"] is None
+
+
+def test_callers_tree(conn):
+    db_backend, lookup = backend(conn, "pkg/db_query().")
+    with lookup:
+        (root,) = db_backend.call_hierarchy("db_query", depth=3)
+
+    assert shape(root) == (
+        "pkg/db_query().",
+        "app/db.py:5",
+        [],
+        False,
+        [
+            (
+                "pkg/service().",
+                "app/service.py:3",
+                [11],
+                False,
+                [("pkg/handler().", "app/handler.py:1", [6, 8], False, [])],
+            ),
+            (
+                "pkg/recurse().",
+                "app/db.py:31",
+                [36],
+                False,
+                [("pkg/recurse().", "app/db.py:31", [37], True, [])],
+            ),
+        ],
+    )
+
+
+def test_callees_depth_and_truncation(conn):
+    db_backend, lookup = backend(conn, "pkg/handler().")
+    with lookup:
+        (callees,) = db_backend.call_hierarchy("handler", "callees", depth=1)
+    db_backend, lookup = backend(conn, "pkg/db_query().")
+    with lookup:
+        (truncated,) = db_backend.call_hierarchy("db_query", max_nodes=2)
+        with pytest.raises(ValueError):
+            db_backend.call_hierarchy("db_query", depth=11)
+
+    assert [(c.symbol, c.call_lines) for c in callees.children] == [
+        ("pkg/service().", [6, 8])
+    ]
+    assert callees.children[0].children == []
+    assert [c.symbol for c in truncated.children] == ["pkg/service()."]
+    assert truncated.truncated
+    assert truncated.to_dict()["children"][0]["relationship"] == "calls"
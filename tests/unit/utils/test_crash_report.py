@@ -0,0 +1,208 @@
+"""
+Unit tests for diagnostic bundles.
+
+Tests config and command line sanitization, bundle contents (config, state,
+logs, report.json), output locations and pruning of old crash bundles.
+"""
+
+import json
+import os
+import zipfile
+from pathlib import Path
+
+import pytest
+
+from code_indexer.utils import crash_report
+from code_indexer.utils.crash_report import (
+    REDACTED,
+    EncryptedProjectError,
+    sanitize,
+    sanitize_command,
+    write_bundle,
+    write_crash_bundle,
+)
+
+
+@pytest.fixture
+def project(tmp_path, monkeypatch):
+    """Initialized project with config, state files and logs."""
+    monkeypatch.chdir(tmp_path)
+    config_dir = tmp_path / ".code-indexer"
+    (config_dir / "logs").mkdir(parents=True)
+    (config_dir / "config.json").write_text(
+        json.dumps(
+            {
+                "codebase_dir": str(tmp_path),
+                "voyage_ai": {"model": "voyage-code-3", "tokens_per_minute": 1000},
+                "api_key": "sk-secret",
+                "git_service": {"password": "hunter2", "token": ""},
+            }
+        )
+    )
+    (config_dir / ".creds").write_text("do-not-ship")
+    (config_dir / "metadata.json").write_text(json.dumps({"status": "in_progress"}))
+    (config_dir / "error_20260101_000000_1.log").write_text("Traceback ...\n")
+    (config_dir / "error_20260101_000001_2.log").write_text("")
+    (config_dir / "daemon.log").write_text("daemon started\n")
+    (config_dir / "logs" / "watch.jsonl").write_text('{"message": "hi"}\n')
+    return tmp_path
+
+
+def _open(bundle_path: Path):
+    bundle = zipfile.ZipFile(bundle_path)
+    return bundle, json.loads(bundle.read("report.json"))
+
+
+class TestSanitize:
+    """Tests for sanitize() and sanitize_command()."""
+
+    def test_redacts_secret_values_recursively(self):
+        config = {
+            "remote": {"encrypted_credentials": "abc", "server_url": "https://x"},
+            "items": [{"access_token": "t"}],
+            "tokens_per_minute": 1000,
+            "password": None,
+        }
+
+        assert sanitize(config) == {
+            "remote": {"encrypted_credentials": REDACTED, "server_url": "https://x"},
+            "items": [{"access_token": REDACTED}],
+            "tokens_per_minute": 1000,
+            "password": None,
+        }
+
+    def test_redacts_credential_options_of_command(self):
+        command = ["cidx", "auth", "login", "--password", "pw", "--api-key=k", "q"]
+
+        assert sanitize_command(command) == [
+            "cidx",
+            "auth",
+            "login",
+            "--password",
+            REDACTED,
+            f"--api-key={REDACTED}",
+            "q",
+        ]
+
+
+class TestWriteBundle:
+    """Tests for write_bundle()."""
+
+    def test_bundle_contents(self, project):
+        bundle_path = write_bundle(project, command=["cidx", "index"])
+
+        assert bundle_path.parent == project / ".code-indexer" / "crash-reports"
+        bundle, report = _open(bundle_path)
+        names = set(bundle.namelist())
+        assert {
+            "report.json",
+            "config.json",
+            "state/metadata.json",
+            "logs/error_20260101_000000_1.log",
+            "logs/daemon.log",
+            "logs/logs/watch.jsonl",
+        } <= names
+        assert "logs/error_20260101_000001_2.log" not in names
+        assert not any(".creds" in name for name in names)
+
+        config = json.loads(bundle.read("config.json"))
+        assert config["api_key"] == REDACTED
+        assert config["git_service"] == {"password": REDACTED, "token": ""}
+        assert config["voyage_ai"]["tokens_per_minute"] == 1000
+
+        assert report["trigger"] == "report-bug"
+        assert report["command"] == ["cidx", "index"]
+        assert report["exception"] is None
+        assert report["versions"]["cidx"]
+        assert "platform" in report["environment"]
+
+    def test_environment_lists_variable_names_only(self, project, monkeypatch):
+        monkeypatch.setenv("VOYAGE_API_KEY", "sk-very-secret")
+
+        bundle, report = _open(write_bundle(project))
+
+        assert "VOYAGE_API_KEY" in report["environment"]["env_vars_set"]
+        assert b"sk-very-secret" not in Path(bundle.filename).read_bytes()
+
+    def test_log_tail_is_truncated(self, project, monkeypatch):
+        monkeypatch.setattr(crash_report, "MAX_LOG_BYTES", 64)
+        (project / ".code-indexer" / "daemon.log").write_text(
+            "".join(f"line {i}\n" for i in range(100))
+        )
+
+        bundle, _ = _open(write_bundle(project))
+
+        tail = bundle.read("logs/daemon.log").decode()
+        assert tail.startswith("[... truncated ...]")
+        assert tail.endswith("line 99\n")
+
+    def test_output_directory_and_file(self, project, tmp_path):
+        out_dir = tmp_path / "out"
+        out_dir.mkdir()
+
+        in_dir = write_bundle(project, output=out_dir)
+        as_file = write_bundle(project, output=tmp_path / "report.zip")
+
+        assert in_dir.parent == out_dir
+        assert as_file == tmp_path / "report.zip"
+        assert zipfile.is_zipfile(as_file)
+
+    def test_outside_project_uses_home(self, tmp_path, monkeypatch):
+        monkeypatch.setenv("HOME", str(tmp_path / "home"))
+        monkeypatch.chdir(tmp_path)
+
+        bundle_path = write_bundle(None)
+
+        expected = tmp_path / "home" / ".code-indexer" / "crash-reports"
+        assert bundle_path.parent == expected
+
+    def test_refuses_project_with_encryption_enabled(self, project, tmp_path):
+        config_file = project / ".code-indexer" / "config.json"
+        config = json.loads(config_file.read_text())
+        config["encryption"] = {"enabled": True}
+        config_file.write_text(json.dumps(config))
+
+        with pytest.raises(EncryptedProjectError):
+            write_bundle(project, output=tmp_path / "report.zip")
+
+        assert not (tmp_path / "report.zip").exists()
+        assert write_crash_bundle(project, RuntimeError("x")) is None
+
+
+class TestWriteCrashBundle:
+    """Tests for write_crash_bundle()."""
+
+    def test_records_exception(self, project):
+        try:
+            raise RuntimeError("index exploded")
+        except RuntimeError as e:
+            bundle_path = write_crash_bundle(project, e)
+
+        _, report = _open(bundle_path)
+        assert report["trigger"] == "crash"
+        assert report["exception"]["type"] == "RuntimeError"
+        assert report["exception"]["message"] == "index exploded"
+        assert "raise RuntimeError" in report["exception"]["traceback"]
+
+    def test_keeps_newest_bundles(self, project, monkeypatch):
+        monkeypatch.setattr(crash_report, "KEEP_REPORTS", 2)
+        reports = project / ".code-indexer" / "crash-reports"
+        reports.mkdir()
+        for i in range(3):
+            stale = reports / f"cidx-report-2020010{i}_000000-1.zip"
+            stale.write_bytes(b"")
+            os.utime(stale, (1000 + i, 1000 + i))
+
+        write_crash_bundle(project, RuntimeError("x"))
+
+        remaining = sorted(p.name for p in reports.glob("*.zip"))
+        assert len(remaining) == 2
+        assert "cidx-report-20200102_000000-1.zip" in remaining
+
+    def test_never_raises(self, project, monkeypatch):
+        def broken(*args, **kwargs):
+            raise OSError("disk full")
+
+        monkeypatch.setattr(crash_report, "write_bundle", broken)
+
+        assert write_crash_bundle(project, RuntimeError("x")) is None
@@ -0,0 +1,80 @@
+"""Unit tests for change-detection content hashing."""
+
+import hashlib
+import sys
+from unittest.mock import patch
+
+import pytest
+
+from code_indexer.config import Config
+from code_indexer.services.file_identifier import FileIdentifier
+from code_indexer.utils.file_hashing import hash_file, resolve_hash_algorithm
+
+
+class TestResolveHashAlgorithm:
+    """Tests for resolve_hash_algorithm()."""
+
+    def test_sha256_is_default_for_unknown_values(self):
+        assert resolve_hash_algorithm(None) == "sha256"
+        assert resolve_hash_algorithm("md5") == "sha256"
+
+    def test_xxh3_falls_back_without_xxhash(self):
+        with patch.dict(sys.modules, {"xxhash": None}):
+            assert resolve_hash_algorithm("xxh3") == "sha256"
+
+    def test_xxh3_used_when_installed(self):
+        pytest.importorskip("xxhash")
+
+        assert resolve_hash_algorithm("xxh3") == "xxh3"
+
+
+class TestHashFile:
+    """Tests for hash_file()."""
+
+    def test_sha256_matches_hashlib(self, tmp_path):
+        test_file = tmp_path / "a.py"
+        test_file.write_bytes(b"print('hello')\n" * 100000)
+
+        expected = hashlib.sha256(test_file.read_bytes()).hexdigest()
+
+        assert hash_file(test_file) == f"sha256:{expected}"
+
+    def test_xxh3_is_prefixed_and_content_sensitive(self, tmp_path):
+        pytest.importorskip("xxhash")
+        first = tmp_path / "a.py"
+        second = tmp_path / "b.py"
+        first.write_text("x = 1\n")
+        second.write_text("x = 2\n")
+
+        first_hash = hash_file(first, "xxh3")
+
+        assert first_hash.startswith("xxh3:")
+        assert first_hash == hash_file(first, "xxh3")
+        assert first_hash != hash_file(second, "xxh3")
+
+    def test_missing_file_raises(self, tmp_path):
+        with pytest.raises(OSError):
+            hash_file(tmp_path / "missing.py")
+
+
+class TestFileIdentifierHashAlgorithm:
+    """Tests for indexing.hash_algorithm in FileIdentifier."""
+
+    def test_configured_algorithm_is_used(self, tmp_path):
+        pytest.importorskip("xxhash")
+        config = Config(codebase_dir=tmp_path)
+        config.indexing.hash_algorithm = "xxh3"
+        test_file = tmp_path / "a.py"
+        test_file.write_text("x = 1\n")
+
+        identifier = FileIdentifier(tmp_path, config)
+
+        assert identifier._get_file_content_hash(test_file).startswith("xxh3:")
+
+    def test_without_config_uses_sha256(self, tmp_path):
+        test_file = tmp_path / "a.py"
+        test_file.write_text("x = 1\n")
+
+        identifier = FileIdentifier(tmp_path)
+
+        assert identifier._get_file_content_hash(test_file).startswith("sha256:")
@@ -0,0 +1,58 @@
+"""
+Unit tests for cross-platform file locks.
+
+Tests exclusive and shared locks, non-blocking requests and release of
+locks acquired with lock_file().
+"""
+
+import sys
+
+import pytest
+
+from code_indexer.utils.file_lock import lock_file, unlock_file
+
+
+@pytest.fixture
+def lock_path(tmp_path):
+    return tmp_path / "test.lock"
+
+
+class TestFileLock:
+    """Tests for lock_file() and unlock_file()."""
+
+    def test_exclusive_lock_blocks_other_handle(self, lock_path):
+        with open(lock_path, "a+") as holder, open(lock_path, "a+") as other:
+            lock_file(holder)
+
+            with pytest.raises(BlockingIOError):
+                lock_file(other, blocking=False)
+
+            unlock_file(holder)
+
+    def test_lock_available_after_unlock(self, lock_path):
+        with open(lock_path, "a+") as holder, open(lock_path, "a+") as other:
+            lock_file(holder)
+            unlock_file(holder)
+
+            lock_file(other, blocking=False)
+            unlock_file(other)
+
+    @pytest.mark.skipif(sys.platform == "win32", reason="No shared locks on Windows")
+    def test_shared_locks_coexist(self, lock_path):
+        with open(lock_path, "a+") as first, open(lock_path, "a+") as second:
+            lock_file(first, exclusive=False)
+
+            lock_file(second, exclusive=False, blocking=False)
+
+            unlock_file(second)
+            unlock_file(first)
+
+    def test_preserves_file_position(self, lock_path):
+        with open(lock_path, "a+") as f:
+            f.write("content")
+            position = f.tell()
+
+            lock_file(f)
+            unlock_file(f)
+
+            assert f.tell() == position
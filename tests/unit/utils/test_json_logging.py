@@ -0,0 +1,236 @@
+"""
+Unit tests for structured JSON logging.
+
+Tests the JSON log formatter, the rotating handler installation, project
+logging configuration for watch/daemon, and json_logging_config of the
+server configuration.
+"""
+
+import json
+import logging
+from types import SimpleNamespace
+
+import pytest
+
+from code_indexer.server.utils.config_manager import (
+    JsonLoggingConfig,
+    ServerConfig,
+    ServerConfigManager,
+)
+from code_indexer.utils.json_logging import (
+    JSONLogFormatter,
+    configure_project_json_logging,
+    install_json_log_handler,
+)
+
+
+def _record(message="hello", level=logging.INFO, **extra):
+    record = logging.LogRecord("cidx.test", level, __file__, 1, message, None, None)
+    for key, value in extra.items():
+        setattr(record, key, value)
+    return record
+
+
+@pytest.fixture
+def test_logger():
+    """Isolated logger with no handlers, cleaned up after the test."""
+    logger = logging.getLogger("cidx.test.json_logging")
+    logger.setLevel(logging.WARNING)
+    logger.propagate = False
+    yield logger
+    for handler in list(logger.handlers):
+        logger.removeHandler(handler)
+        handler.close()
+
+
+def _read_lines(path):
+    return [json.loads(line) for line in path.read_text().splitlines()]
+
+
+class TestJSONLogFormatter:
+    """Tests for JSONLogFormatter."""
+
+    def test_formats_record_with_static_and_extra_fields(self):
+        formatter = JSONLogFormatter(static_fields={"component": "watch"})
+
+        entry = json.loads(
+            formatter.format(
+                _record("Processed 3 files", operation="watch_batch", duration_ms=12)
+            )
+        )
+
+        assert entry["message"] == "Processed 3 files"
+        assert entry["level"] == "INFO"
+        assert entry["logger"] == "cidx.test"
+        assert entry["timestamp"].endswith("Z")
+        assert entry["component"] == "watch"
+        assert entry["operation"] == "watch_batch"
+        assert entry["duration_ms"] == 12
+
+    def test_context_provider_adds_fields_unless_explicit(self):
+        formatter = JSONLogFormatter(
+            context_provider=lambda: {"correlation_id": "ctx-id"}
+        )
+
+        from_context = json.loads(formatter.format(_record(correlation_id=None)))
+        explicit = json.loads(formatter.format(_record(correlation_id="explicit")))
+
+        assert from_context["correlation_id"] == "ctx-id"
+        assert explicit["correlation_id"] == "explicit"
+
+    def test_failing_context_provider_keeps_record(self):
+        def broken():
+            raise RuntimeError("no context")
+
+        entry = json.loads(JSONLogFormatter(context_provider=broken).format(_record()))
+
+        assert entry["message"] == "hello"
+
+    def test_includes_exception_text(self):
+        try:
+            raise ValueError("boom")
+        except ValueError:
+            import sys
+
+            record = logging.LogRecord(
+                "cidx.test", logging.ERROR, __file__, 1, "failed", None, sys.exc_info()
+            )
+
+        entry = json.loads(JSONLogFormatter().format(record))
+
+        assert "ValueError: boom" in entry["exception"]
+
+
+class TestInstallJsonLogHandler:
+    """Tests for install_json_log_handler()."""
+
+    def test_writes_json_lines(self, tmp_path, test_logger):
+        log_file = tmp_path / "logs" / "app.jsonl"
+        install_json_log_handler(
+            log_file, static_fields={"component": "server"}, logger=test_logger
+        )
+
+        test_logger.info("started", extra={"operation": "startup"})
+
+        entries = _read_lines(log_file)
+        assert len(entries) == 1
+        assert entries[0]["component"] == "server"
+        assert entries[0]["operation"] == "startup"
+
+    def test_rotates_by_size(self, tmp_path, test_logger):
+        log_file = tmp_path / "app.jsonl"
+        install_json_log_handler(
+            log_file, max_bytes=1024, backup_count=2, logger=test_logger
+        )
+
+        for i in range(100):
+            test_logger.info(f"record {i} " + "x" * 50)
+
+        assert (tmp_path / "app.jsonl.1").exists()
+        assert (tmp_path / "app.jsonl.2").exists()
+        assert not (tmp_path / "app.jsonl.3").exists()
+        assert log_file.stat().st_size <= 1024
+
+    def test_installing_twice_replaces_handler(self, tmp_path, test_logger):
+        log_file = tmp_path / "app.jsonl"
+        install_json_log_handler(log_file, logger=test_logger)
+        install_json_log_handler(log_file, logger=test_logger)
+
+        test_logger.info("once")
+
+        assert len(test_logger.handlers) == 1
+        assert len(_read_lines(log_file)) == 1
+
+    def test_keeps_level_of_existing_handlers(self, tmp_path, test_logger):
+        console = logging.StreamHandler()
+        test_logger.addHandler(console)
+
+        install_json_log_handler(
+            tmp_path / "app.jsonl", level=logging.INFO, logger=test_logger
+        )
+
+        assert test_logger.level == logging.INFO
+        assert console.level == logging.WARNING
+
+
+class TestConfigureProjectJsonLogging:
+    """Tests for configure_project_json_logging()."""
+
+    @staticmethod
+    def _config(tmp_path, **logging_fields):
+        fields = {
+            "json_logs": True,
+            "log_file": None,
+            "level": "INFO",
+            "max_bytes": 1024 * 1024,
+            "backup_count": 3,
+        }
+        fields.update(logging_fields)
+        return SimpleNamespace(
+            codebase_dir=tmp_path, logging=SimpleNamespace(**fields)
+        )
+
+    def test_disabled_installs_nothing(self, tmp_path):
+        config = self._config(tmp_path, json_logs=False)
+
+        assert configure_project_json_logging(config, "watch") is None
+        assert not (tmp_path / ".code-indexer" / "logs").exists()
+
+    def test_default_file_per_component(self, tmp_path):
+        handler = configure_project_json_logging(self._config(tmp_path), "watch")
+        try:
+            logging.getLogger("cidx.test.project").info(
+                "Processed 1 files", extra={"files_processed": 1}
+            )
+            handler.flush()
+
+            log_file = tmp_path / ".code-indexer" / "logs" / "watch.jsonl"
+            entry = _read_lines(log_file)[-1]
+            assert entry["component"] == "watch"
+            assert entry["project"] == str(tmp_path.resolve())
+            assert entry["files_processed"] == 1
+        finally:
+            logging.getLogger().removeHandler(handler)
+            handler.close()
+
+    def test_relative_log_file_resolves_against_project(self, tmp_path):
+        config = self._config(tmp_path, log_file="out/cidx.jsonl")
+
+        handler = configure_project_json_logging(config, "daemon")
+        try:
+            assert handler.baseFilename == str(tmp_path / "out" / "cidx.jsonl")
+        finally:
+            logging.getLogger().removeHandler(handler)
+            handler.close()
+
+
+class TestServerJsonLoggingConfig:
+    """Tests for json_logging_config in the server configuration."""
+
+    def test_disabled_by_default(self, tmp_path):
+        config = ServerConfig(server_dir=str(tmp_path))
+
+        assert config.json_logging_config.enabled is False
+        assert config.json_logging_config.log_file is None
+
+    def test_loaded_from_config_file(self, tmp_path):
+        (tmp_path / "config.json").write_text(
+            json.dumps({"json_logging_config": {"enabled": True, "level": "DEBUG"}})
+        )
+
+        config = ServerConfigManager(str(tmp_path)).load_config()
+
+        assert isinstance(config.json_logging_config, JsonLoggingConfig)
+        assert config.json_logging_config.enabled is True
+        assert config.json_logging_config.level == "DEBUG"
+
+    @pytest.mark.parametrize(
+        "field,value",
+        [("level", "TRACE"), ("max_bytes", 10), ("backup_count", 0)],
+    )
+    def test_rejects_invalid_values(self, tmp_path, field, value):
+        config = ServerConfig(server_dir=str(tmp_path))
+        setattr(config.json_logging_config, field, value)
+
+        with pytest.raises(ValueError, match="json_logging_config"):
+            ServerConfigManager(str(tmp_path)).validate_config(config)
@@ -0,0 +1,191 @@
+"""
+Unit tests for lazy attribute imports and cached mode detection.
+
+Covers the startup optimizations for read-only CLI commands: deferred provider
+and backend imports that stay compatible with mock.patch, and mode detection
+results reused while configuration files are unchanged.
+"""
+
+# mypy: ignore-errors
+
+import json
+import sys
+import tempfile
+import types
+from pathlib import Path
+from unittest.mock import patch
+
+import pytest
+
+from code_indexer.mode_detection import command_mode_detector
+from code_indexer.mode_detection.command_mode_detector import (
+    CommandModeDetector,
+    clear_mode_detection_cache,
+)
+from code_indexer.utils.lazy_import import LazyAttribute
+
+FAKE_MODULE = "code_indexer_lazy_import_test_module"
+
+
+class _Factory:
+    """Stand-in for a factory class with static and class methods."""
+
+    @staticmethod
+    def create(value):
+        return ("real", value)
+
+    @classmethod
+    def name(cls):
+        return cls.__name__
+
+
+class _Client:
+    def __init__(self, url):
+        self.url = url
+
+
+class TestLazyAttribute:
+    """Tests for the LazyAttribute proxy."""
+
+    def setup_method(self):
+        module = types.ModuleType(FAKE_MODULE)
+        module.Factory = _Factory
+        module.Client = _Client
+        sys.modules[FAKE_MODULE] = module
+
+    def teardown_method(self):
+        sys.modules.pop(FAKE_MODULE, None)
+
+    def test_does_not_import_until_used(self):
+        sys.modules.pop(FAKE_MODULE)
+        proxy = LazyAttribute(FAKE_MODULE, "Factory")
+
+        assert not proxy.is_resolved
+        with pytest.raises(ModuleNotFoundError):
+            proxy.create(1)
+
+    def test_forwards_attribute_access_and_calls(self):
+        factory = LazyAttribute(FAKE_MODULE, "Factory")
+        client = LazyAttribute(FAKE_MODULE, "Client")
+
+        assert factory.create(1) == ("real", 1)
+        assert factory.name() == "_Factory"
+        assert client("http://server").url == "http://server"
+        assert factory.resolve() is _Factory
+
+    def test_relative_module_with_package(self):
+        proxy = LazyAttribute(".lazy_import", "LazyAttribute", "code_indexer.utils")
+
+        assert proxy.resolve() is LazyAttribute
+
+    def test_patching_method_through_proxy_restores_original(self):
+        holder = types.SimpleNamespace(
+            Factory=LazyAttribute(FAKE_MODULE, "Factory")
+        )
+
+        with patch.object(holder.Factory, "create", return_value="mocked"):
+            assert holder.Factory.create(1) == "mocked"
+
+        assert holder.Factory.create(1) == ("real", 1)
+        assert isinstance(_Factory.__dict__["create"], staticmethod)
+
+    def test_repr_reports_load_state(self):
+        proxy = LazyAttribute(FAKE_MODULE, "Client")
+
+        assert "not loaded" in repr(proxy)
+        proxy.resolve()
+        assert "resolved" in repr(proxy)
+
+
+class TestCliLazyImports:
+    """Tests for deferred heavy imports in the CLI module."""
+
+    def test_cli_test_hooks_are_lazy_proxies(self):
+        from code_indexer import cli
+
+        for name in (
+            "AdminAPIClient",
+            "ReposAPIClient",
+            "BackendFactory",
+            "EmbeddingProviderFactory",
+            "ProjectCredentialManager",
+        ):
+            assert isinstance(getattr(cli, name), LazyAttribute), name
+
+    def test_services_package_exports_resolve_lazily(self):
+        import code_indexer.services as services
+
+        assert "EmbeddingProviderFactory" in services.__all__
+        with pytest.raises(AttributeError):
+            services.NotAService
+
+
+class TestModeDetectionCache:
+    """Tests for cached mode detection."""
+
+    def setup_method(self):
+        clear_mode_detection_cache()
+
+    def _write_config(self, project_root: Path, data: dict) -> Path:
+        config_dir = project_root / ".code-indexer"
+        config_dir.mkdir(exist_ok=True)
+        config_path = config_dir / "config.json"
+        config_path.write_text(json.dumps(data))
+        return config_path
+
+    def test_repeated_detection_reads_config_once(self):
+        with tempfile.TemporaryDirectory() as temp_dir:
+            project_root = Path(temp_dir)
+            self._write_config(project_root, {"codebase_dir": temp_dir})
+
+            with patch.object(
+                CommandModeDetector,
+                "_validate_local_config",
+                autospec=True,
+                return_value=True,
+            ) as mock_validate:
+                modes = [
+                    CommandModeDetector(project_root).detect_mode() for _ in range(3)
+                ]
+
+            assert modes == ["local", "local", "local"]
+            assert mock_validate.call_count == 1
+
+    def test_cached_proxy_flag_is_restored(self):
+        with tempfile.TemporaryDirectory() as temp_dir:
+            project_root = Path(temp_dir)
+            self._write_config(project_root, {"proxy_mode": True})
+
+            CommandModeDetector(project_root).detect_mode()
+            detector = CommandModeDetector(project_root)
+
+            assert detector.detect_mode() == "proxy"
+            assert detector._is_proxy_mode is True
+
+    def test_config_change_invalidates_cache(self):
+        with tempfile.TemporaryDirectory() as temp_dir:
+            project_root = Path(temp_dir)
+            self._write_config(project_root, {"codebase_dir": temp_dir})
+            assert CommandModeDetector(project_root).detect_mode() == "local"
+
+            remote_config = project_root / ".code-indexer" / ".remote-config"
+            remote_config.write_text(
+                json.dumps(
+                    {
+                        "server_url": "https://cidx.example.com",
+                        "encrypted_credentials": "secret",
+                    }
+                )
+            )
+
+            assert CommandModeDetector(project_root).detect_mode() == "remote"
+
+    def test_clear_cache(self):
+        with tempfile.TemporaryDirectory() as temp_dir:
+            project_root = Path(temp_dir)
+            self._write_config(project_root, {})
+            CommandModeDetector(project_root).detect_mode()
+
+            clear_mode_detection_cache()
+
+            assert command_mode_detector._detection_cache == {}
@@ -0,0 +1,180 @@
+"""
+Unit tests for opt-in usage telemetry.
+
+Tests that nothing is recorded until enabled, what an event contains,
+per-command summaries, upload scheduling and command name resolution.
+"""
+
+import json
+from types import SimpleNamespace
+from unittest.mock import MagicMock, patch
+
+from code_indexer.utils.telemetry import (
+    UPLOAD_INTERVAL_SECONDS,
+    Telemetry,
+    UsageRecorder,
+    error_category,
+    resolve_command_name,
+)
+
+
+def group(**commands):
+    return SimpleNamespace(commands=commands)
+
+
+CLI = group(
+    query=SimpleNamespace(),
+    coverage=group(**{"import": SimpleNamespace()}),
+)
+
+
+class TestRecording:
+    """Tests for recording usage events."""
+
+    def test_nothing_recorded_until_enabled(self, tmp_path):
+        telemetry = Telemetry(tmp_path)
+
+        telemetry.record("query", 0.5)
+
+        assert not telemetry.enabled
+        assert telemetry.events() == []
+        assert not tmp_path.joinpath("events.jsonl").exists()
+
+    def test_event_contents(self, tmp_path):
+        telemetry = Telemetry(tmp_path)
+        telemetry.enable()
+
+        telemetry.record("query", 0.25, error="ValueError", now=0)
+
+        (event,) = telemetry.events()
+        assert set(event) == {
+            "command",
+            "duration_ms",
+            "error",
+            "version",
+            "python",
+            "os",
+            "day",
+        }
+        assert event["command"] == "query"
+        assert event["duration_ms"] == 250
+        assert event["error"] == "ValueError"
+        assert event["day"] == "1970-01-01"
+
+    def test_disable_deletes_unsent_events(self, tmp_path):
+        telemetry = Telemetry(tmp_path)
+        telemetry.enable()
+        telemetry.record("query", 0.1)
+
+        assert telemetry.disable() == 1
+        assert not telemetry.enabled
+        assert telemetry.events() == []
+
+    def test_install_id_is_kept(self, tmp_path):
+        telemetry = Telemetry(tmp_path)
+        telemetry.enable()
+        install_id = telemetry.settings["install_id"]
+
+        telemetry.disable()
+        telemetry.enable(endpoint="https://example.com/cidx")
+
+        assert telemetry.settings["install_id"] == install_id
+        assert telemetry.endpoint == "https://example.com/cidx"
+
+    def test_summary(self, tmp_path):
+        telemetry = Telemetry(tmp_path)
+        telemetry.enable()
+        telemetry.record("query", 0.1)
+        telemetry.record("query", 0.3, error="exit_1")
+        telemetry.record("index", 2.0)
+
+        assert telemetry.summary() == [
+            {
+                "command": "query",
+                "runs": 2,
+                "errors": 1,
+                "p50_ms": 300,
+                "max_ms": 300,
+                "error_categories": {"exit_1": 1},
+            },
+            {
+                "command": "index",
+                "runs": 1,
+                "errors": 0,
+                "p50_ms": 2000,
+                "max_ms": 2000,
+                "error_categories": {},
+            },
+        ]
+
+
+class TestUpload:
+    """Tests for uploading recorded events."""
+
+    def make_telemetry(self, tmp_path, endpoint="https://example.com/cidx"):
+        telemetry = Telemetry(tmp_path)
+        telemetry.enable(endpoint=endpoint)
+        telemetry.record("query", 0.1)
+        return telemetry
+
+    def test_no_upload_without_endpoint(self, tmp_path):
+        telemetry = self.make_telemetry(tmp_path, endpoint=None)
+
+        with patch("urllib.request.urlopen") as urlopen:
+            assert not telemetry.maybe_upload(now=1e9)
+
+        urlopen.assert_not_called()
+
+    def test_upload_sends_payload_once_a_day(self, tmp_path):
+        telemetry = self.make_telemetry(tmp_path)
+        response = MagicMock(status=204)
+        response.__enter__.return_value = response
+
+        with patch("urllib.request.urlopen", return_value=response) as urlopen:
+            assert telemetry.maybe_upload(now=1e9)
+            telemetry.record("query", 0.1)
+            assert not telemetry.maybe_upload(now=1e9 + 60)
+            assert telemetry.maybe_upload(now=1e9 + UPLOAD_INTERVAL_SECONDS)
+
+        request = urlopen.call_args_list[0][0][0]
+        payload = json.loads(request.data)
+        assert payload["install_id"] == telemetry.settings["install_id"]
+        assert [e["command"] for e in payload["events"]] == ["query"]
+        assert telemetry.events() == []
+
+    def test_failed_upload_keeps_events(self, tmp_path):
+        telemetry = self.make_telemetry(tmp_path)
+
+        with patch("urllib.request.urlopen", side_effect=OSError("offline")):
+            assert not telemetry.maybe_upload(now=1e9)
+
+        assert len(telemetry.events()) == 1
+
+
+class TestCommandNames:
+    """Tests for command names and error categories."""
+
+    def test_resolve_command_name(self):
+        assert resolve_command_name(CLI, ["query", "auth token", "--limit", "5"]) == (
+            "query"
+        )
+        assert resolve_command_name(CLI, ["coverage", "import", "c.out"]) == (
+            "coverage import"
+        )
+        assert resolve_command_name(CLI, ["-p", "/src/secret", "query"]) == "query"
+        assert resolve_command_name(CLI, ["--help"]) is None
+
+    def test_error_category(self):
+        assert error_category(0, None) is None
+        assert error_category(2, None) == "exit_2"
+        assert error_category(1, KeyError("/home/alice/x")) == "KeyError"
+
+    def test_recorder_records_when_enabled(self, tmp_path):
+        telemetry = Telemetry(tmp_path)
+        telemetry.enable()
+        recorder = UsageRecorder(telemetry)
+        recorder.exception = RuntimeError("boom")
+
+        recorder.finish("query", 1)
+
+        assert telemetry.events()[0]["error"] == "RuntimeError"